@@ -17,9 +17,11 @@ limitations under the License.
 package cauthdsl
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/msp"
 	cb "github.com/hyperledger/fabric/protos/common"
 )
 
@@ -91,6 +93,25 @@ func TestComplexNestedSignature(t *testing.T) {
 	}
 }
 
+type failingDeserializer struct{}
+
+func (*failingDeserializer) DeserializeIdentity(serializedIdentity []byte) (msp.Identity, error) {
+	return nil, fmt.Errorf("Invalid identity")
+}
+
+func TestUndeserializableIdentity(t *testing.T) {
+	policy := Envelope(SignedBy(0), signers)
+
+	spe, err := compile(policy.Policy, policy.Identities, &failingDeserializer{})
+	if err != nil {
+		t.Fatalf("Could not create a new SignaturePolicyEvaluator using the given policy, crypto-helper: %s", err)
+	}
+
+	if spe(toSignedData([][]byte{nil}, [][]byte{signers[0]}, [][]byte{validSignature})) {
+		t.Errorf("Expected authentication to fail as the identity cannot be deserialized")
+	}
+}
+
 func TestNegatively(t *testing.T) {
 	rpolicy := Envelope(And(SignedBy(0), SignedBy(1)), signers)
 	rpolicy.Policy.Type = nil
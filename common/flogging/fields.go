@@ -0,0 +1,188 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flogging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/op/go-logging"
+)
+
+// JSONFormat is the logging format that emits each log record as a JSON object
+const JSONFormat = "json"
+
+// Fields are the structured fields attached to a log message, such as the channel or the block number
+type Fields map[string]interface{}
+
+// FieldLogger logs the messages of a module along with structured fields. The level of the module
+// is controlled like that of any other module, including the changes at runtime via the admin service
+type FieldLogger struct {
+	logger *logging.Logger
+	fields Fields
+}
+
+// MustGetFieldLogger returns a FieldLogger for the given module
+func MustGetFieldLogger(module string) *FieldLogger {
+	logger := logging.MustGetLogger(module)
+	// skip the frame of the FieldLogger while reporting the caller of a log method
+	logger.ExtraCalldepth = 1
+	return &FieldLogger{logger: logger}
+}
+
+// With returns a FieldLogger that attaches the given fields, in addition to the fields of this logger, to the messages
+func (l *FieldLogger) With(fields Fields) *FieldLogger {
+	merged := make(Fields, len(l.fields)+len(fields))
+	for k, v := range l.fields {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return &FieldLogger{logger: l.logger, fields: merged}
+}
+
+// IsEnabledFor returns true if the logger is enabled for the given level
+func (l *FieldLogger) IsEnabledFor(level logging.Level) bool {
+	return l.logger.IsEnabledFor(level)
+}
+
+// Debug logs a message at the debug level
+func (l *FieldLogger) Debug(args ...interface{}) {
+	if l.logger.IsEnabledFor(logging.DEBUG) {
+		l.logger.Debug(l.message(fmt.Sprint(args...)))
+	}
+}
+
+// Debugf logs a formatted message at the debug level
+func (l *FieldLogger) Debugf(format string, args ...interface{}) {
+	if l.logger.IsEnabledFor(logging.DEBUG) {
+		l.logger.Debug(l.message(fmt.Sprintf(format, args...)))
+	}
+}
+
+// Info logs a message at the info level
+func (l *FieldLogger) Info(args ...interface{}) {
+	if l.logger.IsEnabledFor(logging.INFO) {
+		l.logger.Info(l.message(fmt.Sprint(args...)))
+	}
+}
+
+// Infof logs a formatted message at the info level
+func (l *FieldLogger) Infof(format string, args ...interface{}) {
+	if l.logger.IsEnabledFor(logging.INFO) {
+		l.logger.Info(l.message(fmt.Sprintf(format, args...)))
+	}
+}
+
+// Warning logs a message at the warning level
+func (l *FieldLogger) Warning(args ...interface{}) {
+	if l.logger.IsEnabledFor(logging.WARNING) {
+		l.logger.Warning(l.message(fmt.Sprint(args...)))
+	}
+}
+
+// Warningf logs a formatted message at the warning level
+func (l *FieldLogger) Warningf(format string, args ...interface{}) {
+	if l.logger.IsEnabledFor(logging.WARNING) {
+		l.logger.Warning(l.message(fmt.Sprintf(format, args...)))
+	}
+}
+
+// Error logs a message at the error level
+func (l *FieldLogger) Error(args ...interface{}) {
+	if l.logger.IsEnabledFor(logging.ERROR) {
+		l.logger.Error(l.message(fmt.Sprint(args...)))
+	}
+}
+
+// Errorf logs a formatted message at the error level
+func (l *FieldLogger) Errorf(format string, args ...interface{}) {
+	if l.logger.IsEnabledFor(logging.ERROR) {
+		l.logger.Error(l.message(fmt.Sprintf(format, args...)))
+	}
+}
+
+func (l *FieldLogger) message(msg string) *fieldsMessage {
+	return &fieldsMessage{msg, l.fields}
+}
+
+// fieldsMessage is passed as the only argument of a log record so that the JSON formatter can
+// emit the fields individually. The other formatters print the fields after the message as key=value pairs
+type fieldsMessage struct {
+	msg    string
+	fields Fields
+}
+
+func (m *fieldsMessage) String() string {
+	if len(m.fields) == 0 {
+		return m.msg
+	}
+	buf := bytes.NewBufferString(m.msg)
+	for _, k := range sortedFieldNames(m.fields) {
+		fmt.Fprintf(buf, " %s=%v", k, m.fields[k])
+	}
+	return buf.String()
+}
+
+func sortedFieldNames(fields Fields) []string {
+	var names []string
+	for k := range fields {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// jsonFormatter formats a log record as a JSON object with the time, level, module, and message
+// of the record along with the structured fields of the message
+type jsonFormatter struct{}
+
+func (f *jsonFormatter) Format(calldepth int, r *logging.Record, w io.Writer) error {
+	entry := make(map[string]interface{})
+	msg := ""
+	if m, ok := fieldsMessageOf(r); ok {
+		for k, v := range m.fields {
+			entry[k] = v
+		}
+		msg = m.msg
+	} else {
+		msg = r.Message()
+	}
+	entry["ts"] = r.Time.Format(time.RFC3339Nano)
+	entry["level"] = r.Level.String()
+	entry["module"] = r.Module
+	entry["msg"] = msg
+	entryBytes, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(entryBytes)
+	return err
+}
+
+func fieldsMessageOf(r *logging.Record) (*fieldsMessage, bool) {
+	if len(r.Args) != 1 {
+		return nil, false
+	}
+	m, ok := r.Args[0].(*fieldsMessage)
+	return m, ok
+}
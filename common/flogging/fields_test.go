@@ -0,0 +1,66 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flogging_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/op/go-logging"
+)
+
+func ExampleFieldLogger() {
+	// initializes logging backend for testing and sets
+	// time to 1970-01-01 00:00:00.000 UTC
+	logging.InitForTesting(flogging.DefaultLevel())
+
+	logFormat := "[%{module}] %{shortfunc} -> %{level:.4s} %{message}"
+	flogging.SetLoggingFormat(logFormat, os.Stdout)
+
+	logger := flogging.MustGetFieldLogger("floggingFieldsTest")
+	logger.With(flogging.Fields{"channel": "mychannel", "block": 5}).Infof("Committed block with %d transaction(s)", 2)
+	logger.Info("test")
+
+	// Output:
+	// [floggingFieldsTest] ExampleFieldLogger -> INFO Committed block with 2 transaction(s) block=5 channel=mychannel
+	// [floggingFieldsTest] ExampleFieldLogger -> INFO test
+}
+
+func ExampleFieldLogger_json() {
+	// initializes logging backend for testing and sets
+	// time to 1970-01-01 00:00:00.000 UTC
+	logging.InitForTesting(flogging.DefaultLevel())
+
+	flogging.SetLoggingFormat(flogging.JSONFormat, os.Stdout)
+
+	logger := flogging.MustGetFieldLogger("floggingFieldsTest")
+	logger.With(flogging.Fields{"channel": "mychannel"}).With(flogging.Fields{"block": 5}).Warning("Ignoring block")
+	logging.MustGetLogger("floggingTest").Warningf("test %d", 1)
+
+	// Output:
+	// {"block":5,"channel":"mychannel","level":"WARNING","module":"floggingFieldsTest","msg":"Ignoring block","ts":"1970-01-01T00:00:00Z"}
+	// {"level":"WARNING","module":"floggingTest","msg":"test 1","ts":"1970-01-01T00:00:00Z"}
+}
+
+func TestFieldLoggerModuleLevel(t *testing.T) {
+	logger := flogging.MustGetFieldLogger("floggingFieldsLevelTest")
+	flogging.SetModuleLevel("floggingFieldsLevelTest", "debug")
+	assertEquals(t, true, logger.IsEnabledFor(logging.DEBUG))
+	flogging.SetModuleLevel("floggingFieldsLevelTest", "error")
+	assertEquals(t, false, logger.With(flogging.Fields{"channel": "mychannel"}).IsEnabledFor(logging.WARNING))
+}
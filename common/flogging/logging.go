@@ -106,8 +106,13 @@ func init() {
 	SetLoggingFormat(defaultFormat, defaultOutput)
 }
 
-// SetLoggingFormat sets the logging format and the location of the log output
+// SetLoggingFormat sets the logging format and the location of the log output.
+// The format JSONFormat emits each log record as a JSON object
 func SetLoggingFormat(formatString string, output io.Writer) {
+	if formatString == JSONFormat {
+		initLoggingBackend(&jsonFormatter{}, output)
+		return
+	}
 	if formatString == "" {
 		formatString = defaultFormat
 	}
@@ -18,7 +18,10 @@ package blkstorage
 
 import (
 	"errors"
+	"time"
 
+	"github.com/hyperledger/fabric/bccsp"
+	"github.com/hyperledger/fabric/bccsp/factory"
 	"github.com/hyperledger/fabric/common/ledger"
 	"github.com/hyperledger/fabric/protos/common"
 	"github.com/hyperledger/fabric/protos/peer"
@@ -29,12 +32,14 @@ type IndexableAttr string
 
 // constants for indexable attributes
 const (
-	IndexableAttrBlockNum         = IndexableAttr("BlockNum")
-	IndexableAttrBlockHash        = IndexableAttr("BlockHash")
-	IndexableAttrTxID             = IndexableAttr("TxID")
-	IndexableAttrBlockNumTranNum  = IndexableAttr("BlockNumTranNum")
-	IndexableAttrBlockTxID        = IndexableAttr("BlockTxID")
-	IndexableAttrTxValidationCode = IndexableAttr("TxValidationCode")
+	IndexableAttrBlockNum          = IndexableAttr("BlockNum")
+	IndexableAttrBlockHash         = IndexableAttr("BlockHash")
+	IndexableAttrTxID              = IndexableAttr("TxID")
+	IndexableAttrBlockNumTranNum   = IndexableAttr("BlockNumTranNum")
+	IndexableAttrBlockTxID         = IndexableAttr("BlockTxID")
+	IndexableAttrTxValidationCode  = IndexableAttr("TxValidationCode")
+	IndexableAttrTxBlockNumTranNum = IndexableAttr("TxBlockNumTranNum")
+	IndexableAttrBlockTimestamp    = IndexableAttr("BlockTimestamp")
 )
 
 // IndexConfig - a configuration that includes a list of attributes that should be indexed
@@ -47,13 +52,41 @@ var (
 	ErrNotFoundInIndex = errors.New("Entry not found in index")
 	// ErrAttrNotIndexed is used to indicate that an attribute is not indexed
 	ErrAttrNotIndexed = errors.New("Attribute not indexed")
+	// ErrReadOnly is used to indicate that a write is attempted on a block store opened in read-only mode
+	ErrReadOnly = errors.New("Block store is opened in read-only mode")
 )
 
+// ComputeBlockHash computes the hash of the block header through the BCCSP. The block hash is always SHA256,
+// whatever the hash function configured for the ledger, as the orderer chains the blocks through
+// the SHA256 hash of the previous block header that it sets in the PreviousHash of each block
+func ComputeBlockHash(blockHeader *common.BlockHeader) ([]byte, error) {
+	return factory.GetDefault().Hash(blockHeader.Bytes(), &bccsp.SHA256Opts{})
+}
+
+// SnapshotInfo captures the details of the last block included in a ledger snapshot.
+// A block store bootstrapped from a snapshot starts with a height of LastBlockNum+1 and
+// does not contain the blocks up to (and including) the LastBlockNum
+type SnapshotInfo struct {
+	LastBlockNum      uint64
+	LastBlockHash     []byte
+	PreviousBlockHash []byte
+	// LastConfigBlock is the serialized last config block as of the snapshot. The block store
+	// carries this block as the blocks included in the snapshot are not available
+	LastConfigBlock []byte
+	// CommitHash is the commit hash of the last block included in the snapshot
+	CommitHash []byte
+}
+
 // BlockStoreProvider provides an handle to a BlockStore
 type BlockStoreProvider interface {
 	CreateBlockStore(ledgerid string) (BlockStore, error)
+	CreateBlockStoreFromSnapshot(ledgerid string, snapshotInfo *SnapshotInfo) (BlockStore, error)
 	OpenBlockStore(ledgerid string) (BlockStore, error)
+	RollbackBlockStore(ledgerid string, height uint64) error
+	RebuildBlockIndex(ledgerid string) error
+	Drop(ledgerid string) error
 	Exists(ledgerid string) (bool, error)
+	GetHeight(ledgerid string) (uint64, error)
 	List() ([]string, error)
 	Close()
 }
@@ -71,5 +104,13 @@ type BlockStore interface {
 	RetrieveTxByBlockNumTranNum(blockNum uint64, tranNum uint64) (*common.Envelope, error)
 	RetrieveBlockByTxID(txID string) (*common.Block, error)
 	RetrieveTxValidationCodeByTxID(txID string) (peer.TxValidationCode, error)
+	RetrieveTxLocByTxID(txID string) (blockNum uint64, tranNum uint64, err error) // tranNum is the position of the tx in the block starting from 0
+	TxIDExists(txID string) (bool, error)                                         // looks up the index only, without reading the transaction
+	ForEachTxID(fn func(txID string) error) error                                 // visits the ids of the indexed transactions in the byte order of the ids
+	RetrieveBlockNumByTimestamp(timestamp time.Time) (uint64, error)              // returns the first block with a timestamp not earlier than the given timestamp
+	GetSnapshotInfo() (*SnapshotInfo, error)                                      // returns nil if the block store is not bootstrapped from a snapshot
+	GetDiskUsage() (int64, error)                                                 // returns the size of the block files plus the approximate size of the index
+	Sync() error                                                                  // flushes the block files and the index to the disk
+	CompactIndex() error                                                          // compacts the index of the blocks of the store
 	Shutdown()
 }
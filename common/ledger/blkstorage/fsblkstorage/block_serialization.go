@@ -17,7 +17,9 @@ limitations under the License.
 package fsblkstorage
 
 import (
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/golang/protobuf/proto"
 	ledgerutil "github.com/hyperledger/fabric/common/ledger/util"
@@ -35,24 +37,75 @@ type serializedBlockInfo struct {
 type txindexInfo struct {
 	txID string
 	loc  *locPointer
+	// timestamp is the timestamp in the channel header of the transaction in nanoseconds since the epoch
+	timestamp int64
 }
 
 func serializeBlock(block *common.Block) ([]byte, *serializedBlockInfo, error) {
-	buf := proto.NewBuffer(nil)
+	buf := proto.NewBuffer(make([]byte, 0, serializedBlockSize(block)))
+	info, err := addBlockBytes(block, buf)
+	if err != nil {
+		return nil, nil, err
+	}
+	return buf.Bytes(), info, nil
+}
+
+// serializeBlockWithLength serializes the block preceded by the length of the serialized block, as the block
+// is appended to a block file, into a buffer that is allocated once. The tx offsets are relative to the start of
+// the serialized block and the number of the bytes of the length is returned along with the bytes
+func serializeBlockWithLength(block *common.Block) ([]byte, int, *serializedBlockInfo, error) {
+	blockSize := serializedBlockSize(block)
+	lengthSize := proto.SizeVarint(uint64(blockSize))
+	buf := proto.NewBuffer(make([]byte, 0, lengthSize+blockSize))
+	if err := buf.EncodeVarint(uint64(blockSize)); err != nil {
+		return nil, 0, nil, err
+	}
+	info, err := addBlockBytes(block, buf)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	return buf.Bytes(), lengthSize, info, nil
+}
+
+// addBlockBytes appends the serialized block to the buffer. The txOffsets of the returned info are relative to the
+// start of the serialized block. Each transaction is parsed once, for its id, while its bytes are appended to the buffer
+func addBlockBytes(block *common.Block, buf *proto.Buffer) (*serializedBlockInfo, error) {
 	var err error
 	info := &serializedBlockInfo{}
 	info.blockHeader = block.Header
 	info.metadata = block.Metadata
+	start := len(buf.Bytes())
 	if err = addHeaderBytes(block.Header, buf); err != nil {
-		return nil, nil, err
+		return nil, err
 	}
-	if info.txOffsets, err = addDataBytes(block.Data, buf); err != nil {
-		return nil, nil, err
+	if info.txOffsets, err = addDataBytes(block.Data, buf, start); err != nil {
+		return nil, err
 	}
 	if err = addMetadataBytes(block.Metadata, buf); err != nil {
-		return nil, nil, err
+		return nil, err
 	}
-	return buf.Bytes(), info, nil
+	return info, nil
+}
+
+// serializedBlockSize returns the number of the bytes of the serialized block, so that the buffer is sized upfront
+func serializedBlockSize(block *common.Block) int {
+	size := proto.SizeVarint(block.Header.Number) + sizeRawBytes(block.Header.DataHash) + sizeRawBytes(block.Header.PreviousHash)
+	size += proto.SizeVarint(uint64(len(block.Data.Data)))
+	for _, txEnvelopeBytes := range block.Data.Data {
+		size += sizeRawBytes(txEnvelopeBytes)
+	}
+	if block.Metadata == nil {
+		return size + proto.SizeVarint(0)
+	}
+	size += proto.SizeVarint(uint64(len(block.Metadata.Metadata)))
+	for _, b := range block.Metadata.Metadata {
+		size += sizeRawBytes(b)
+	}
+	return size
+}
+
+func sizeRawBytes(b []byte) int {
+	return proto.SizeVarint(uint64(len(b))) + len(b)
 }
 
 func deserializeBlock(serializedBlockBytes []byte) (*common.Block, error) {
@@ -108,22 +161,22 @@ func addHeaderBytes(blockHeader *common.BlockHeader, buf *proto.Buffer) error {
 	return nil
 }
 
-func addDataBytes(blockData *common.BlockData, buf *proto.Buffer) ([]*txindexInfo, error) {
-	var txOffsets []*txindexInfo
+func addDataBytes(blockData *common.BlockData, buf *proto.Buffer, start int) ([]*txindexInfo, error) {
+	txOffsets := make([]*txindexInfo, 0, len(blockData.Data))
 
 	if err := buf.EncodeVarint(uint64(len(blockData.Data))); err != nil {
 		return nil, err
 	}
 	for _, txEnvelopeBytes := range blockData.Data {
-		offset := len(buf.Bytes())
-		txid, err := extractTxID(txEnvelopeBytes)
+		offset := len(buf.Bytes()) - start
+		txid, timestamp, err := extractTxIDAndTimestamp(txEnvelopeBytes)
 		if err != nil {
 			return nil, err
 		}
 		if err := buf.EncodeRawBytes(txEnvelopeBytes); err != nil {
 			return nil, err
 		}
-		idxInfo := &txindexInfo{txid, &locPointer{offset, len(buf.Bytes()) - offset}}
+		idxInfo := &txindexInfo{txid, &locPointer{offset, len(buf.Bytes()) - start - offset}, timestamp}
 		txOffsets = append(txOffsets, idxInfo)
 	}
 	return txOffsets, nil
@@ -175,15 +228,16 @@ func extractData(buf *ledgerutil.Buffer) (*common.BlockData, []*txindexInfo, err
 	for i := uint64(0); i < numItems; i++ {
 		var txEnvBytes []byte
 		var txid string
+		var timestamp int64
 		txOffset := buf.GetBytesConsumed()
 		if txEnvBytes, err = buf.DecodeRawBytes(false); err != nil {
 			return nil, nil, err
 		}
-		if txid, err = extractTxID(txEnvBytes); err != nil {
+		if txid, timestamp, err = extractTxIDAndTimestamp(txEnvBytes); err != nil {
 			return nil, nil, err
 		}
 		data.Data = append(data.Data, txEnvBytes)
-		idxInfo := &txindexInfo{txid, &locPointer{txOffset, buf.GetBytesConsumed() - txOffset}}
+		idxInfo := &txindexInfo{txid, &locPointer{txOffset, buf.GetBytesConsumed() - txOffset}, timestamp}
 		txOffsets = append(txOffsets, idxInfo)
 	}
 	return data, txOffsets, nil
@@ -207,17 +261,84 @@ func extractMetadata(buf *ledgerutil.Buffer) (*common.BlockMetadata, error) {
 }
 
 func extractTxID(txEnvelopBytes []byte) (string, error) {
-	txEnvelope, err := utils.GetEnvelopeFromBlock(txEnvelopBytes)
+	txid, _, err := extractTxIDAndTimestamp(txEnvelopBytes)
+	return txid, err
+}
+
+// extractTxIDAndTimestamp returns the id and the timestamp, in nanoseconds since the epoch, from the channel header
+// of the transaction. The timestamp is 0 if the channel header does not carry a timestamp.
+// Only the channel header is unmarshaled; the envelope, the payload, and the header are walked in place
+// so that the transaction data is neither copied nor decoded
+func extractTxIDAndTimestamp(txEnvelopBytes []byte) (string, int64, error) {
+	payloadBytes, err := extractBytesField(txEnvelopBytes, envelopePayloadField)
+	if err != nil {
+		return "", 0, fmt.Errorf("Error unmarshaling Envelope: %s", err)
+	}
+	headerBytes, err := extractBytesField(payloadBytes, payloadHeaderField)
 	if err != nil {
-		return "", err
+		return "", 0, nil
 	}
-	txPayload, err := utils.GetPayload(txEnvelope)
+	chdrBytes, err := extractBytesField(headerBytes, headerChannelHeaderField)
 	if err != nil {
-		return "", nil
+		return "", 0, nil
 	}
-	chdr, err := utils.UnmarshalChannelHeader(txPayload.Header.ChannelHeader)
+	chdr, err := utils.UnmarshalChannelHeader(chdrBytes)
 	if err != nil {
-		return "", err
+		return "", 0, err
+	}
+	if chdr.Timestamp == nil {
+		return chdr.TxId, 0, nil
+	}
+	return chdr.TxId, chdr.Timestamp.Seconds*int64(time.Second) + int64(chdr.Timestamp.Nanos), nil
+}
+
+var errTruncatedProto = errors.New("truncated proto message")
+
+// the field numbers of the protos that are walked to reach the channel header of a transaction
+const (
+	envelopePayloadField     = 1 // common.Envelope.payload
+	payloadHeaderField       = 1 // common.Payload.header
+	headerChannelHeaderField = 1 // common.Header.channel_header
+)
+
+// extractBytesField returns the value of the given length-delimited field from the wire encoding of a proto message,
+// without copying it. As for the unmarshaling, the last occurrence of the field wins and a missing field is nil
+func extractBytesField(msgBytes []byte, fieldNum uint64) ([]byte, error) {
+	var value []byte
+	b := msgBytes
+	for len(b) > 0 {
+		key, n := proto.DecodeVarint(b)
+		if n == 0 {
+			return nil, errTruncatedProto
+		}
+		b = b[n:]
+		switch wireType := key & 0x7; wireType {
+		case proto.WireVarint:
+			if _, n = proto.DecodeVarint(b); n == 0 {
+				return nil, errTruncatedProto
+			}
+			b = b[n:]
+		case proto.WireFixed64, proto.WireFixed32:
+			size := 8
+			if wireType == proto.WireFixed32 {
+				size = 4
+			}
+			if len(b) < size {
+				return nil, errTruncatedProto
+			}
+			b = b[size:]
+		case proto.WireBytes:
+			length, n := proto.DecodeVarint(b)
+			if n == 0 || length > uint64(len(b)-n) {
+				return nil, errTruncatedProto
+			}
+			if key>>3 == fieldNum {
+				value = b[n : n+int(length)]
+			}
+			b = b[n+int(length):]
+		default:
+			return nil, fmt.Errorf("unexpected wire type [%d]", wireType)
+		}
 	}
-	return chdr.TxId, nil
+	return value, nil
 }
@@ -21,6 +21,7 @@ import (
 
 	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/protos/common"
 	putils "github.com/hyperledger/fabric/protos/utils"
 )
 
@@ -64,3 +65,36 @@ func TestSerializedBlockInfo(t *testing.T) {
 		testutil.AssertEquals(t, txEnvBytesFromBB, txEnvBytes)
 	}
 }
+
+func TestSerializeBlockWithLength(t *testing.T) {
+	block := testutil.ConstructTestBlock(t, 10, 100)
+	bb, expectedInfo, _ := serializeBlock(block)
+	bbWithLength, lengthSize, info, err := serializeBlockWithLength(block)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, info, expectedInfo)
+	length, n := proto.DecodeVarint(bbWithLength)
+	testutil.AssertEquals(t, n, lengthSize)
+	testutil.AssertEquals(t, int(length), len(bb))
+	testutil.AssertEquals(t, bbWithLength[lengthSize:], bb)
+	// the buffer is sized exactly
+	testutil.AssertEquals(t, cap(bbWithLength), len(bbWithLength))
+}
+
+func TestExtractTxIDAndTimestampFromMalformedEnvelope(t *testing.T) {
+	// a payload that cannot be parsed yields an empty transaction id as before
+	envBytes, _ := proto.Marshal(&common.Envelope{Payload: []byte{0x0a, 0x05}})
+	txid, timestamp, err := extractTxIDAndTimestamp(envBytes)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, txid, "")
+	testutil.AssertEquals(t, timestamp, int64(0))
+
+	// a payload without a header yields an empty transaction id
+	payloadBytes, _ := proto.Marshal(&common.Payload{Data: []byte("data")})
+	envBytes, _ = proto.Marshal(&common.Envelope{Payload: payloadBytes})
+	txid, _, err = extractTxIDAndTimestamp(envBytes)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, txid, "")
+
+	_, _, err = extractTxIDAndTimestamp([]byte{0x0a, 0x05, 0x01})
+	testutil.AssertError(t, err, "Expected an error for a truncated envelope")
+}
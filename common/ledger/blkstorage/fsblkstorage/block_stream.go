@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sync"
 
 	"github.com/golang/protobuf/proto"
 )
@@ -61,6 +62,10 @@ type blockPlacementInfo struct {
 ///////////////////////////////////
 // blockfileStream functions
 ////////////////////////////////////
+// bufReaderPool holds the buffered readers of the closed block file streams, as a stream is opened for each
+// block retrieved by number, hash or transaction id
+var bufReaderPool = sync.Pool{New: func() interface{} { return bufio.NewReader(nil) }}
+
 func newBlockfileStream(rootDir string, fileNum int, startOffset int64) (*blockfileStream, error) {
 	filePath := deriveBlockfilePath(rootDir, fileNum)
 	logger.Debugf("newBlockfileStream(): filePath=[%s], startOffset=[%d]", filePath, startOffset)
@@ -78,7 +83,9 @@ func newBlockfileStream(rootDir string, fileNum int, startOffset int64) (*blockf
 		panic(fmt.Sprintf("Could not seek file [%s] to given startOffset [%d]. New position = [%d]",
 			filePath, startOffset, newPosition))
 	}
-	s := &blockfileStream{fileNum, file, bufio.NewReader(file), startOffset}
+	reader := bufReaderPool.Get().(*bufio.Reader)
+	reader.Reset(file)
+	s := &blockfileStream{fileNum, file, reader, startOffset}
 	return s, nil
 }
 
@@ -150,6 +157,11 @@ func (s *blockfileStream) nextBlockBytesAndPlacementInfo() ([]byte, *blockPlacem
 }
 
 func (s *blockfileStream) close() error {
+	if s.reader != nil {
+		s.reader.Reset(nil)
+		bufReaderPool.Put(s.reader)
+		s.reader = nil
+	}
 	return s.file.Close()
 }
 
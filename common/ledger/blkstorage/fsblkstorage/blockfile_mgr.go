@@ -18,9 +18,11 @@ package fsblkstorage
 
 import (
 	"fmt"
+	"io"
 	"math"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric/common/ledger/blkstorage"
@@ -36,10 +38,13 @@ var logger = logging.MustGetLogger("kvledger")
 
 const (
 	blockfilePrefix = "blockfile_"
+	// syncIndexProgressInterval is the number of the blocks after which the progress of the indexing is logged
+	syncIndexProgressInterval = 10000
 )
 
 var (
-	blkMgrInfoKey = []byte("blkMgrInfo")
+	blkMgrInfoKey   = []byte("blkMgrInfo")
+	snapshotInfoKey = []byte("snapshotInfo")
 )
 
 type conf struct {
@@ -55,7 +60,12 @@ type blockfileMgr struct {
 	cpInfo            *checkpointInfo
 	cpInfoCond        *sync.Cond
 	currentFileWriter *blockfileWriter
-	bcInfo            atomic.Value
+	// writerLock guards the switch of the current file against a flush scheduled by the syncer
+	writerLock sync.Mutex
+	// syncer flushes the block files according to the sync policy and is nil in read-only mode
+	syncer       *util.Syncer
+	bcInfo       atomic.Value
+	snapshotInfo *blkstorage.SnapshotInfo
 }
 
 /*
@@ -103,6 +113,9 @@ func newBlockfileMgr(id string, conf *Conf, indexConfig *blkstorage.IndexConfig,
 	logger.Debugf("newBlockfileMgr() initializing file-based block storage for ledger: %s ", id)
 	//Determine the root directory for the blockfile storage, if it does not exist create it
 	rootDir := conf.getLedgerBlockDir(id)
+	if conf.readOnly {
+		return newReadOnlyBlockfileMgr(rootDir, conf, indexConfig, indexStore)
+	}
 	_, err := util.CreateDirIfMissing(rootDir)
 	if err != nil {
 		panic(fmt.Sprintf("Error: %s", err))
@@ -117,8 +130,15 @@ func newBlockfileMgr(id string, conf *Conf, indexConfig *blkstorage.IndexConfig,
 	if err != nil {
 		panic(fmt.Sprintf("Could not get block file info for current block file from db: %s", err))
 	}
+	// A block store bootstrapped from a snapshot starts after the last block of the snapshot
+	if mgr.snapshotInfo, err = loadSnapshotInfo(indexStore); err != nil {
+		panic(fmt.Sprintf("Could not get snapshot info from db: %s", err))
+	}
 	if cpInfo == nil { //if no cpInfo stored in db initiate to zero
 		cpInfo = &checkpointInfo{0, 0, true, 0}
+		if mgr.snapshotInfo != nil {
+			cpInfo.lastBlockNumber = mgr.snapshotInfo.LastBlockNum
+		}
 		err = mgr.saveCurrentInfo(cpInfo, true)
 		if err != nil {
 			panic(fmt.Sprintf("Could not save next block file info to db: %s", err))
@@ -126,7 +146,7 @@ func newBlockfileMgr(id string, conf *Conf, indexConfig *blkstorage.IndexConfig,
 	}
 	//Verify that the checkpoint stored in db is accurate with what is actually stored in block file system
 	// If not the same, sync the cpInfo and the file system
-	syncCPInfoFromFS(rootDir, cpInfo)
+	syncCPInfoFromFS(rootDir, cpInfo, mgr.snapshotInfo != nil)
 	//Open a writer to the file identified by the number and truncate it to only contain the latest block
 	// that was completely saved (file system, index, cpinfo, etc)
 	currentFileWriter, err := newBlockfileWriter(deriveBlockfilePath(rootDir, cpInfo.latestFileChunkSuffixNum))
@@ -145,6 +165,7 @@ func newBlockfileMgr(id string, conf *Conf, indexConfig *blkstorage.IndexConfig,
 	// Update the manager with the checkpoint info and the file writer
 	mgr.cpInfo = cpInfo
 	mgr.currentFileWriter = currentFileWriter
+	mgr.syncer = util.NewSyncer(conf.syncPolicy, mgr.syncCurrentFile)
 	// Create a checkpoint condition (event) variable, for the  goroutine waiting for
 	// or announcing the occurrence of an event.
 	mgr.cpInfoCond = sync.NewCond(&sync.Mutex{})
@@ -153,19 +174,36 @@ func newBlockfileMgr(id string, conf *Conf, indexConfig *blkstorage.IndexConfig,
 	// If not the same, sync the index and the file system
 	mgr.syncIndex()
 
-	// init BlockchainInfo for external API's
+	mgr.initBlockchainInfo()
+	//return the new manager (blockfileMgr)
+	return mgr
+}
+
+// initBlockchainInfo initializes the BlockchainInfo for external API's from the checkpoint info
+func (mgr *blockfileMgr) initBlockchainInfo() {
+	cpInfo := mgr.cpInfo
 	bcInfo := &common.BlockchainInfo{
 		Height:            0,
 		CurrentBlockHash:  nil,
 		PreviousBlockHash: nil}
 
+	if cpInfo.isChainEmpty && mgr.snapshotInfo != nil {
+		bcInfo = &common.BlockchainInfo{
+			Height:            mgr.snapshotInfo.LastBlockNum + 1,
+			CurrentBlockHash:  mgr.snapshotInfo.LastBlockHash,
+			PreviousBlockHash: mgr.snapshotInfo.PreviousBlockHash}
+	}
+
 	//If start up is a restart of an existing storage, update BlockchainInfo for external API's
 	if !cpInfo.isChainEmpty {
 		lastBlockHeader, err := mgr.retrieveBlockHeaderByNumber(cpInfo.lastBlockNumber)
 		if err != nil {
 			panic(fmt.Sprintf("Could not retrieve header of the last block form file: %s", err))
 		}
-		lastBlockHash := lastBlockHeader.Hash()
+		lastBlockHash, err := blkstorage.ComputeBlockHash(lastBlockHeader)
+		if err != nil {
+			panic(fmt.Sprintf("Could not compute hash of the last block: %s", err))
+		}
 		previousBlockHash := lastBlockHeader.PreviousHash
 		bcInfo = &common.BlockchainInfo{
 			Height:            cpInfo.lastBlockNumber + 1,
@@ -173,15 +211,14 @@ func newBlockfileMgr(id string, conf *Conf, indexConfig *blkstorage.IndexConfig,
 			PreviousBlockHash: previousBlockHash}
 	}
 	mgr.bcInfo.Store(bcInfo)
-	//return the new manager (blockfileMgr)
-	return mgr
 }
 
 //cp = checkpointInfo, from the database gets the file suffix and the size of
 // the file of where the last block was written.  Also retrieves contains the
 // last block number that was written.  At init
 //checkpointInfo:latestFileChunkSuffixNum=[0], latestFileChunksize=[0], lastBlockNumber=[0]
-func syncCPInfoFromFS(rootDir string, cpInfo *checkpointInfo) {
+//For a block store bootstrapped from a snapshot, the lastBlockNumber of an empty chain is the last block of the snapshot
+func syncCPInfoFromFS(rootDir string, cpInfo *checkpointInfo, bootstrappedFromSnapshot bool) {
 	logger.Debugf("Starting checkpoint=%s", cpInfo)
 	//Checks if the file suffix of where the last block was written exists
 	filePath := deriveBlockfilePath(rootDir, cpInfo.latestFileChunkSuffixNum)
@@ -208,7 +245,7 @@ func syncCPInfoFromFS(rootDir string, cpInfo *checkpointInfo) {
 		return
 	}
 	//Updates the checkpoint info for the actual last block number stored and it's end location
-	if cpInfo.isChainEmpty {
+	if cpInfo.isChainEmpty && !bootstrappedFromSnapshot {
 		cpInfo.lastBlockNumber = uint64(numBlocks - 1)
 	} else {
 		cpInfo.lastBlockNumber += uint64(numBlocks)
@@ -226,7 +263,28 @@ func (mgr *blockfileMgr) open() error {
 }
 
 func (mgr *blockfileMgr) close() {
-	mgr.currentFileWriter.close()
+	if mgr.syncer != nil {
+		if err := mgr.syncer.Close(); err != nil {
+			logger.Errorf("Error while flushing the block file: %s", err)
+		}
+	}
+	if mgr.currentFileWriter != nil {
+		mgr.currentFileWriter.close()
+	}
+}
+
+// sync flushes the blocks appended to the current block file
+func (mgr *blockfileMgr) sync() error {
+	if mgr.syncer == nil {
+		return nil
+	}
+	return mgr.syncer.Sync()
+}
+
+func (mgr *blockfileMgr) syncCurrentFile() error {
+	mgr.writerLock.Lock()
+	defer mgr.writerLock.Unlock()
+	return mgr.currentFileWriter.sync()
 }
 
 func (mgr *blockfileMgr) moveToNextFile() {
@@ -241,6 +299,12 @@ func (mgr *blockfileMgr) moveToNextFile() {
 	if err != nil {
 		panic(fmt.Sprintf("Could not open writer to next file: %s", err))
 	}
+	// the blocks of the current file that are not flushed yet are flushed before the file is closed
+	if err = mgr.syncer.Sync(); err != nil {
+		panic(fmt.Sprintf("Could not flush current file: %s", err))
+	}
+	mgr.writerLock.Lock()
+	defer mgr.writerLock.Unlock()
 	mgr.currentFileWriter.close()
 	err = mgr.saveCurrentInfo(cpInfo, true)
 	if err != nil {
@@ -251,23 +315,27 @@ func (mgr *blockfileMgr) moveToNextFile() {
 }
 
 func (mgr *blockfileMgr) addBlock(block *common.Block) error {
+	if mgr.conf.readOnly {
+		return blkstorage.ErrReadOnly
+	}
 	if block.Header.Number != mgr.getBlockchainInfo().Height {
 		return fmt.Errorf("Block number should have been %d but was %d", mgr.getBlockchainInfo().Height, block.Header.Number)
 	}
-	blockBytes, info, err := serializeBlock(block)
+	blockBytes, lengthSize, info, err := serializeBlockWithLength(block)
 	if err != nil {
 		return fmt.Errorf("Error while serializing block: %s", err)
 	}
-	blockHash := block.Header.Hash()
+	blockHash, err := blkstorage.ComputeBlockHash(block.Header)
+	if err != nil {
+		return fmt.Errorf("Error while computing block hash: %s", err)
+	}
 	//Get the location / offset where each transaction starts in the block and where the block ends
 	txOffsets := info.txOffsets
 	currentOffset := mgr.cpInfo.latestFileChunksize
 	if err != nil {
 		return fmt.Errorf("Error while serializing block: %s", err)
 	}
-	blockBytesLen := len(blockBytes)
-	blockBytesEncodedLen := proto.EncodeVarint(uint64(blockBytesLen))
-	totalBytesToAppend := blockBytesLen + len(blockBytesEncodedLen)
+	totalBytesToAppend := len(blockBytes)
 
 	//Determine if we need to start a new file since the size of this block
 	//exceeds the amount of space left in the current file
@@ -275,11 +343,11 @@ func (mgr *blockfileMgr) addBlock(block *common.Block) error {
 		mgr.moveToNextFile()
 		currentOffset = 0
 	}
-	//append blockBytesEncodedLen to the file
-	err = mgr.currentFileWriter.append(blockBytesEncodedLen, false)
+	//append the length of the block followed by the block bytes to the file
+	err = mgr.currentFileWriter.append(blockBytes, false)
 	if err == nil {
-		//append the actual block bytes to the file
-		err = mgr.currentFileWriter.append(blockBytes, true)
+		//flush the block file if due by the sync policy
+		err = mgr.syncer.BlockWritten()
 	}
 	if err != nil {
 		truncateErr := mgr.currentFileWriter.truncateFile(mgr.cpInfo.latestFileChunksize)
@@ -310,7 +378,7 @@ func (mgr *blockfileMgr) addBlock(block *common.Block) error {
 	blockFLP.offset = currentOffset
 	// shift the txoffset because we prepend length of bytes before block bytes
 	for _, txOffset := range txOffsets {
-		txOffset.loc.offset += len(blockBytesEncodedLen)
+		txOffset.loc.offset += lengthSize
 	}
 	//save the index in the database
 	mgr.index.indexBlock(&blockIdxInfo{
@@ -395,7 +463,9 @@ func (mgr *blockfileMgr) syncIndex() error {
 
 		//Update the blockIndexInfo with what was actually stored in file system
 		blockIdxInfo := &blockIdxInfo{}
-		blockIdxInfo.blockHash = info.blockHeader.Hash()
+		if blockIdxInfo.blockHash, err = blkstorage.ComputeBlockHash(info.blockHeader); err != nil {
+			return err
+		}
 		blockIdxInfo.blockNum = info.blockHeader.Number
 		blockIdxInfo.flp = &fileLocPointer{fileSuffixNum: blockPlacementInfo.fileNum,
 			locPointer: locPointer{offset: int(blockPlacementInfo.blockStartOffset)}}
@@ -406,6 +476,9 @@ func (mgr *blockfileMgr) syncIndex() error {
 		if err = mgr.index.indexBlock(blockIdxInfo); err != nil {
 			return err
 		}
+		if (blockIdxInfo.blockNum+1)%syncIndexProgressInterval == 0 {
+			logger.Infof("syncIndex() indexed blocks up to [%d] of [%d]", blockIdxInfo.blockNum, mgr.cpInfo.lastBlockNumber)
+		}
 		blockNum++
 	}
 	return nil
@@ -473,6 +546,52 @@ func (mgr *blockfileMgr) retrieveTxValidationCodeByTxID(txID string) (peer.TxVal
 	return mgr.index.getTxValidationCodeByTxID(txID)
 }
 
+func (mgr *blockfileMgr) retrieveTxLocByTxID(txID string) (uint64, uint64, error) {
+	logger.Debugf("retrieveTxLocByTxID() - txID = [%s]", txID)
+	blockNum, tranNum, err := mgr.index.getTxBlockNumTranNumByTxID(txID)
+	if err != blkstorage.ErrNotFoundInIndex {
+		return blockNum, tranNum, err
+	}
+	// the transactions indexed before the block number and the transaction number were indexed by txID
+	// are located from the block that contains the transaction
+	loc, err := mgr.index.getBlockLocByTxID(txID)
+	if err != nil {
+		if err == blkstorage.ErrAttrNotIndexed {
+			err = blkstorage.ErrNotFoundInIndex
+		}
+		return 0, 0, err
+	}
+	block, err := mgr.fetchBlock(loc)
+	if err != nil {
+		return 0, 0, err
+	}
+	// the last transaction with the txID is the one indexed by txID
+	for tranNum := len(block.Data.Data) - 1; tranNum >= 0; tranNum-- {
+		blockTxID, err := extractTxID(block.Data.Data[tranNum])
+		if err != nil {
+			return 0, 0, err
+		}
+		if blockTxID == txID {
+			return block.Header.Number, uint64(tranNum), nil
+		}
+	}
+	return 0, 0, blkstorage.ErrNotFoundInIndex
+}
+
+func (mgr *blockfileMgr) txIDExists(txID string) (bool, error) {
+	logger.Debugf("txIDExists() - txID = [%s]", txID)
+	return mgr.index.txIDExists(txID)
+}
+
+func (mgr *blockfileMgr) forEachTxID(fn func(txID string) error) error {
+	return mgr.index.forEachTxID(fn)
+}
+
+func (mgr *blockfileMgr) retrieveBlockNumByTimestamp(timestamp time.Time) (uint64, error) {
+	logger.Debugf("retrieveBlockNumByTimestamp() - timestamp = [%s]", timestamp)
+	return mgr.index.getBlockNumByTimestamp(timestamp.UnixNano())
+}
+
 func (mgr *blockfileMgr) retrieveBlockHeaderByNumber(blockNum uint64) (*common.BlockHeader, error) {
 	logger.Debugf("retrieveBlockHeaderByNumber() - blockNum = [%d]", blockNum)
 	loc, err := mgr.index.getBlockLocByBlockNum(blockNum)
@@ -526,9 +645,16 @@ func (mgr *blockfileMgr) fetchBlock(lp *fileLocPointer) (*common.Block, error) {
 
 func (mgr *blockfileMgr) fetchTransactionEnvelope(lp *fileLocPointer) (*common.Envelope, error) {
 	logger.Debugf("Entering fetchTransactionEnvelope() %v\n", lp)
-	var err error
-	var txEnvelopeBytes []byte
-	if txEnvelopeBytes, err = mgr.fetchRawBytes(lp); err != nil {
+	filePath := deriveBlockfilePath(mgr.rootDir, lp.fileSuffixNum)
+	reader, err := newBlockfileReader(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.close()
+	buf := acquireReadBuffer(lp.bytesLength)
+	defer releaseReadBuffer(buf)
+	txEnvelopeBytes := *buf
+	if err = reader.readInto(txEnvelopeBytes, lp.offset); err != nil {
 		return nil, err
 	}
 	_, n := proto.DecodeVarint(txEnvelopeBytes)
@@ -548,20 +674,6 @@ func (mgr *blockfileMgr) fetchBlockBytes(lp *fileLocPointer) ([]byte, error) {
 	return b, nil
 }
 
-func (mgr *blockfileMgr) fetchRawBytes(lp *fileLocPointer) ([]byte, error) {
-	filePath := deriveBlockfilePath(mgr.rootDir, lp.fileSuffixNum)
-	reader, err := newBlockfileReader(filePath)
-	if err != nil {
-		return nil, err
-	}
-	defer reader.close()
-	b, err := reader.read(lp.offset, lp.bytesLength)
-	if err != nil {
-		return nil, err
-	}
-	return b, nil
-}
-
 //Get the current checkpoint information that is stored in the database
 func (mgr *blockfileMgr) loadCurrentInfo() (*checkpointInfo, error) {
 	var b []byte
@@ -588,6 +700,77 @@ func (mgr *blockfileMgr) saveCurrentInfo(i *checkpointInfo, sync bool) error {
 	return nil
 }
 
+func loadSnapshotInfo(db *leveldbhelper.DBHandle) (*blkstorage.SnapshotInfo, error) {
+	b, err := db.Get(snapshotInfoKey)
+	if b == nil || err != nil {
+		return nil, err
+	}
+	buffer := proto.NewBuffer(b)
+	info := &blkstorage.SnapshotInfo{}
+	if info.LastBlockNum, err = buffer.DecodeVarint(); err != nil {
+		return nil, err
+	}
+	if info.LastBlockHash, err = buffer.DecodeRawBytes(false); err != nil {
+		return nil, err
+	}
+	if info.PreviousBlockHash, err = buffer.DecodeRawBytes(false); err != nil {
+		return nil, err
+	}
+	// the last config block and the commit hash are absent in the snapshot info saved by the earlier versions
+	if info.LastConfigBlock, err = buffer.DecodeRawBytes(false); err == io.ErrUnexpectedEOF {
+		return info, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if info.CommitHash, err = buffer.DecodeRawBytes(false); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+func saveSnapshotInfo(db *leveldbhelper.DBHandle, info *blkstorage.SnapshotInfo) error {
+	buffer := proto.NewBuffer([]byte{})
+	if err := buffer.EncodeVarint(info.LastBlockNum); err != nil {
+		return err
+	}
+	if err := buffer.EncodeRawBytes(info.LastBlockHash); err != nil {
+		return err
+	}
+	if err := buffer.EncodeRawBytes(info.PreviousBlockHash); err != nil {
+		return err
+	}
+	if err := buffer.EncodeRawBytes(info.LastConfigBlock); err != nil {
+		return err
+	}
+	if err := buffer.EncodeRawBytes(info.CommitHash); err != nil {
+		return err
+	}
+	return db.Put(snapshotInfoKey, buffer.Bytes(), true)
+}
+
+// loadHeight derives the height of a block store from the checkpoint info and the snapshot info saved in the db
+func loadHeight(db *leveldbhelper.DBHandle) (uint64, error) {
+	b, err := db.Get(blkMgrInfoKey)
+	if err != nil {
+		return 0, err
+	}
+	if b != nil {
+		cpInfo := &checkpointInfo{}
+		if err := cpInfo.unmarshal(b); err != nil {
+			return 0, err
+		}
+		if !cpInfo.isChainEmpty {
+			return cpInfo.lastBlockNumber + 1, nil
+		}
+	}
+	snapshotInfo, err := loadSnapshotInfo(db)
+	if err != nil || snapshotInfo == nil {
+		return 0, err
+	}
+	return snapshotInfo.LastBlockNum + 1, nil
+}
+
 // scanForLastCompleteBlock scan a given block file and detects the last offset in the file
 // after which there may lie a block partially written (towards the end of the file in a crash scenario).
 func scanForLastCompleteBlock(rootDir string, fileNum int, startingOffset int64) (int64, int, error) {
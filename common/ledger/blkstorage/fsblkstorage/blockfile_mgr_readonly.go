@@ -0,0 +1,56 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fsblkstorage
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/hyperledger/fabric/common/ledger/blkstorage"
+	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
+)
+
+// newReadOnlyBlockfileMgr creates a manager that serves the blocks of an existing block store
+// without modifying the block files or the index. Unlike newBlockfileMgr, neither the checkpoint info
+// is synced with the block files nor the index is synced with the block files. As a block is indexed only
+// after it is appended to the block files, the blocks up to the last indexed block are served
+func newReadOnlyBlockfileMgr(rootDir string, conf *Conf, indexConfig *blkstorage.IndexConfig, indexStore *leveldbhelper.DBHandle) *blockfileMgr {
+	logger.Debugf("newReadOnlyBlockfileMgr() opening block storage at [%s] in read-only mode", rootDir)
+	mgr := &blockfileMgr{rootDir: rootDir, conf: conf, db: indexStore}
+	var err error
+	if mgr.snapshotInfo, err = loadSnapshotInfo(indexStore); err != nil {
+		panic(fmt.Sprintf("Could not get snapshot info from db: %s", err))
+	}
+	mgr.index = newBlockIndex(indexConfig, indexStore)
+
+	cpInfo := &checkpointInfo{isChainEmpty: true}
+	if mgr.snapshotInfo != nil {
+		cpInfo.lastBlockNumber = mgr.snapshotInfo.LastBlockNum
+	}
+	lastBlockIndexed, err := mgr.index.getLastBlockIndexed()
+	if err != nil && err != errIndexEmpty {
+		panic(fmt.Sprintf("Could not get last block indexed from db: %s", err))
+	}
+	if err == nil && (mgr.snapshotInfo == nil || lastBlockIndexed > mgr.snapshotInfo.LastBlockNum) {
+		cpInfo.isChainEmpty = false
+		cpInfo.lastBlockNumber = lastBlockIndexed
+	}
+	mgr.cpInfo = cpInfo
+	mgr.cpInfoCond = sync.NewCond(&sync.Mutex{})
+	mgr.initBlockchainInfo()
+	return mgr
+}
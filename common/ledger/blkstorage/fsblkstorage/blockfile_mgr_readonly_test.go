@@ -0,0 +1,74 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fsblkstorage
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/blkstorage"
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+)
+
+func TestReadOnlyBlockStore(t *testing.T) {
+	env := newTestEnv(t, NewConf(testPath(), 0))
+	defer env.Cleanup()
+	blockStorageDir := env.provider.conf.blockStorageDir
+	ledgerid := "testLedger"
+	blkfileMgrWrapper := newTestBlockfileWrapper(env, ledgerid)
+	blocks := testutil.ConstructTestBlocks(t, 10)
+	blkfileMgrWrapper.addBlocks(blocks[:8])
+	// the last two blocks are appended to the block files but not indexed
+	blkfileMgrWrapper.blockfileMgr.index = &noopIndex{}
+	blkfileMgrWrapper.addBlocks(blocks[8:])
+	blkfileMgrWrapper.close()
+	env.provider.Close()
+
+	// multiple read-only providers can be opened on the same block storage
+	readOnlyProvider1 := NewProvider(NewReadOnlyConf(blockStorageDir), env.provider.indexConfig)
+	defer readOnlyProvider1.Close()
+	readOnlyProvider2 := NewProvider(NewReadOnlyConf(blockStorageDir), env.provider.indexConfig)
+	defer readOnlyProvider2.Close()
+	for _, p := range []blkstorage.BlockStoreProvider{readOnlyProvider1, readOnlyProvider2} {
+		store, err := p.OpenBlockStore(ledgerid)
+		testutil.AssertNoError(t, err, "")
+		bcInfo, _ := store.GetBlockchainInfo()
+		testutil.AssertEquals(t, bcInfo.Height, uint64(8))
+		testutil.AssertEquals(t, bcInfo.CurrentBlockHash, blocks[7].Header.Hash())
+		w := &testBlockfileMgrWrapper{t, store.(*fsBlockStore).fileMgr}
+		w.testGetBlockByHash(blocks[:8])
+		w.testGetBlockByNumber(blocks[:8], 0)
+		testutil.AssertEquals(t, store.AddBlock(blocks[8]), blkstorage.ErrReadOnly)
+		store.Shutdown()
+	}
+
+	_, err := readOnlyProvider1.OpenBlockStore("non-existing-ledger")
+	testutil.AssertError(t, err, "Expected an error for opening a non-existing block store in read-only mode")
+	_, err = readOnlyProvider1.CreateBlockStore("newLedger")
+	testutil.AssertEquals(t, err, blkstorage.ErrReadOnly)
+	testutil.AssertEquals(t, readOnlyProvider1.RollbackBlockStore(ledgerid, 5), blkstorage.ErrReadOnly)
+	testutil.AssertEquals(t, readOnlyProvider1.Drop(ledgerid), blkstorage.ErrReadOnly)
+
+	// the read-only block store does not modify the block files and hence the unindexed blocks
+	// are recovered when the block store is opened next time in the regular mode
+	readOnlyProvider1.Close()
+	readOnlyProvider2.Close()
+	env = newTestEnv(t, NewConf(blockStorageDir, 0))
+	defer env.Cleanup()
+	blkfileMgrWrapper = newTestBlockfileWrapper(env, ledgerid)
+	defer blkfileMgrWrapper.close()
+	blkfileMgrWrapper.testGetBlockByNumber(blocks, 0)
+}
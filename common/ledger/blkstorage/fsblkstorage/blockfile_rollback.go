@@ -0,0 +1,102 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fsblkstorage
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
+)
+
+// rollback truncates the block files such that only the blocks below the given height remain in the block store.
+// The block index is cleared and is rebuilt from the remaining block files by the syncIndex during the next start.
+// This is expected to be invoked only when the block store is not in use
+func (mgr *blockfileMgr) rollback(height uint64) error {
+	if mgr.snapshotInfo != nil {
+		return fmt.Errorf("Rollback is not supported for a block store bootstrapped from a snapshot")
+	}
+	currentHeight := mgr.getBlockchainInfo().Height
+	if height == 0 || height > currentHeight {
+		return fmt.Errorf("Target height [%d] should be between 1 and the current height [%d]", height, currentHeight)
+	}
+	if height == currentHeight {
+		logger.Infof("Block store is already at height [%d], nothing to rollback", height)
+		return nil
+	}
+	// the first block to be removed marks the end of the retained blocks
+	flp, err := mgr.index.getBlockLocByBlockNum(height)
+	if err != nil {
+		return err
+	}
+	lastFileSuffixNum := mgr.cpInfo.latestFileChunkSuffixNum
+	cpInfo := &checkpointInfo{
+		latestFileChunkSuffixNum: flp.fileSuffixNum,
+		latestFileChunksize:      flp.offset,
+		isChainEmpty:             false,
+		lastBlockNumber:          height - 1}
+	logger.Infof("Rolling back block store from height [%d] to height [%d]. New checkpoint: %s", currentHeight, height, cpInfo)
+
+	if err := mgr.clearIndex(); err != nil {
+		return err
+	}
+	if err := mgr.saveCurrentInfo(cpInfo, true); err != nil {
+		return err
+	}
+	if err := os.Truncate(deriveBlockfilePath(mgr.rootDir, cpInfo.latestFileChunkSuffixNum), int64(cpInfo.latestFileChunksize)); err != nil {
+		return err
+	}
+	for fileNum := cpInfo.latestFileChunkSuffixNum + 1; fileNum <= lastFileSuffixNum; fileNum++ {
+		if err := os.Remove(deriveBlockfilePath(mgr.rootDir, fileNum)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// rebuildIndex clears the block index and rebuilds it from the block files.
+// This is expected to be invoked only when the block store is not in use
+func (mgr *blockfileMgr) rebuildIndex() error {
+	logger.Infof("Rebuilding block index from the block files, last block number [%d]", mgr.cpInfo.lastBlockNumber)
+	if err := mgr.clearIndex(); err != nil {
+		return err
+	}
+	if err := mgr.syncIndex(); err != nil {
+		return err
+	}
+	logger.Infof("Rebuilt block index up to block number [%d]", mgr.cpInfo.lastBlockNumber)
+	return nil
+}
+
+// clearIndex removes all the index entries, retaining only the checkpoint info of the block files
+func (mgr *blockfileMgr) clearIndex() error {
+	batch := leveldbhelper.NewUpdateBatch()
+	itr := mgr.db.GetIterator(nil, nil)
+	for itr.Next() {
+		key := itr.Key()
+		if bytes.Equal(key, blkMgrInfoKey) || bytes.Equal(key, snapshotInfoKey) {
+			continue
+		}
+		batch.Delete(append([]byte{}, key...))
+	}
+	itr.Release()
+	if err := itr.Error(); err != nil {
+		return err
+	}
+	return mgr.db.WriteBatch(batch, true)
+}
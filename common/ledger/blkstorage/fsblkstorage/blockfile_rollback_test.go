@@ -0,0 +1,96 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fsblkstorage
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/blkstorage"
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+)
+
+func TestBlockfileMgrRollback(t *testing.T) {
+	// a max file size of 0 places each block in a separate file
+	testBlockfileMgrRollback(t, 0)
+	testBlockfileMgrRollback(t, 1024*1024)
+}
+
+func testBlockfileMgrRollback(t *testing.T, maxFileSize int) {
+	env := newTestEnv(t, NewConf(testPath(), maxFileSize))
+	defer env.Cleanup()
+	ledgerid := "testLedger"
+	blkfileMgrWrapper := newTestBlockfileWrapper(env, ledgerid)
+	blocks := testutil.ConstructTestBlocks(t, 10)
+	blkfileMgrWrapper.addBlocks(blocks)
+	blkfileMgrWrapper.close()
+
+	testutil.AssertError(t, env.provider.RollbackBlockStore(ledgerid, 0), "Expected an error for rollback to height 0")
+	testutil.AssertError(t, env.provider.RollbackBlockStore(ledgerid, 11), "Expected an error for rollback beyond the current height")
+	testutil.AssertError(t, env.provider.RollbackBlockStore("non-existing-ledger", 5), "Expected an error for a non-existing block store")
+	testutil.AssertNoError(t, env.provider.RollbackBlockStore(ledgerid, 6), "")
+
+	blkfileMgrWrapper = newTestBlockfileWrapper(env, ledgerid)
+	bcInfo := blkfileMgrWrapper.blockfileMgr.getBlockchainInfo()
+	testutil.AssertEquals(t, bcInfo.Height, uint64(6))
+	testutil.AssertEquals(t, bcInfo.CurrentBlockHash, blocks[5].Header.Hash())
+	blkfileMgrWrapper.testGetBlockByHash(blocks[:6])
+	blkfileMgrWrapper.testGetBlockByNumber(blocks[:6], 0)
+	_, err := blkfileMgrWrapper.blockfileMgr.retrieveBlockByHash(blocks[6].Header.Hash())
+	testutil.AssertEquals(t, err, blkstorage.ErrNotFoundInIndex)
+	_, err = blkfileMgrWrapper.blockfileMgr.retrieveBlockByNumber(6)
+	testutil.AssertEquals(t, err, blkstorage.ErrNotFoundInIndex)
+
+	// the removed blocks can be added again
+	blkfileMgrWrapper.addBlocks(blocks[6:])
+	blkfileMgrWrapper.testGetBlockByHash(blocks)
+	blkfileMgrWrapper.testGetBlockByNumber(blocks, 0)
+	blkfileMgrWrapper.close()
+}
+
+func TestBlockfileMgrRebuildIndex(t *testing.T) {
+	env := newTestEnv(t, NewConf(testPath(), 0))
+	defer env.Cleanup()
+	ledgerid := "testLedger"
+	blkfileMgrWrapper := newTestBlockfileWrapper(env, ledgerid)
+	blocks := testutil.ConstructTestBlocks(t, 10)
+	blkfileMgrWrapper.addBlocks(blocks)
+	blkfileMgrWrapper.close()
+
+	testutil.AssertError(t, env.provider.RebuildBlockIndex("non-existing-ledger"), "Expected an error for a non-existing block store")
+	testutil.AssertNoError(t, env.provider.RebuildBlockIndex(ledgerid), "")
+
+	blkfileMgrWrapper = newTestBlockfileWrapper(env, ledgerid)
+	defer blkfileMgrWrapper.close()
+	bcInfo := blkfileMgrWrapper.blockfileMgr.getBlockchainInfo()
+	testutil.AssertEquals(t, bcInfo.Height, uint64(10))
+	blkfileMgrWrapper.testGetBlockByHash(blocks)
+	blkfileMgrWrapper.testGetBlockByNumber(blocks, 0)
+}
+
+func TestBlockfileMgrRollbackSnapshotBootstrapped(t *testing.T) {
+	env := newTestEnv(t, NewConf(testPath(), 0))
+	defer env.Cleanup()
+	blocks := testutil.ConstructTestBlocks(t, 10)
+	store, err := env.provider.CreateBlockStoreFromSnapshot("testLedger",
+		&blkstorage.SnapshotInfo{LastBlockNum: 5, LastBlockHash: blocks[5].Header.Hash(), PreviousBlockHash: blocks[5].Header.PreviousHash})
+	testutil.AssertNoError(t, err, "")
+	for _, b := range blocks[6:] {
+		testutil.AssertNoError(t, store.AddBlock(b), "")
+	}
+	store.Shutdown()
+	testutil.AssertError(t, env.provider.RollbackBlockStore("testLedger", 8), "Expected an error for rollback of a snapshot bootstrapped block store")
+}
@@ -18,6 +18,7 @@ package fsblkstorage
 
 import (
 	"os"
+	"sync"
 )
 
 ////  WRITER ////
@@ -53,6 +54,10 @@ func (w *blockfileWriter) append(b []byte, sync bool) error {
 	return nil
 }
 
+func (w *blockfileWriter) sync() error {
+	return w.file.Sync()
+}
+
 func (w *blockfileWriter) open() error {
 	file, err := os.OpenFile(w.filePath, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0660)
 	if err != nil {
@@ -82,13 +87,41 @@ func newBlockfileReader(filePath string) (*blockfileReader, error) {
 
 func (r *blockfileReader) read(offset int, length int) ([]byte, error) {
 	b := make([]byte, length)
-	_, err := r.file.ReadAt(b, int64(offset))
-	if err != nil {
+	if err := r.readInto(b, offset); err != nil {
 		return nil, err
 	}
 	return b, nil
 }
 
+// readInto reads len(b) bytes at the given offset into b
+func (r *blockfileReader) readInto(b []byte, offset int) error {
+	_, err := r.file.ReadAt(b, int64(offset))
+	return err
+}
+
+// readBufferPool holds the buffers that the transactions are read into from the block files. A buffer is returned
+// to the pool once the transaction is unmarshalled from it, as the unmarshalling copies the bytes it keeps
+var readBufferPool = sync.Pool{New: func() interface{} { return new([]byte) }}
+
+// maxPooledReadBufferSize bounds the buffers kept in the pool, so that a single large transaction does not pin its
+// memory in the pool
+const maxPooledReadBufferSize = 1024 * 1024
+
+func acquireReadBuffer(length int) *[]byte {
+	buf := readBufferPool.Get().(*[]byte)
+	if cap(*buf) < length {
+		*buf = make([]byte, length)
+	}
+	*buf = (*buf)[:length]
+	return buf
+}
+
+func releaseReadBuffer(buf *[]byte) {
+	if cap(*buf) <= maxPooledReadBufferSize {
+		readBufferPool.Put(buf)
+	}
+}
+
 func (r *blockfileReader) close() error {
 	return r.file.Close()
 }
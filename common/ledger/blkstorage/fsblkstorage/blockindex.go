@@ -37,10 +37,14 @@ const (
 	blockNumTranNumIdxKeyPrefix    = 'a'
 	blockTxIDIdxKeyPrefix          = 'b'
 	txValidationResultIdxKeyPrefix = 'v'
+	txBlockNumTranNumIdxKeyPrefix  = 'c'
+	blockTimestampIdxKeyPrefix     = 'm'
 	indexCheckpointKeyStr          = "indexCheckpointKey"
+	lastBlockTimestampKeyStr       = "lastBlockTimestampKey"
 )
 
 var indexCheckpointKey = []byte(indexCheckpointKeyStr)
+var lastBlockTimestampKey = []byte(lastBlockTimestampKeyStr)
 var errIndexEmpty = errors.New("NoBlockIndexed")
 
 type index interface {
@@ -52,6 +56,10 @@ type index interface {
 	getTXLocByBlockNumTranNum(blockNum uint64, tranNum uint64) (*fileLocPointer, error)
 	getBlockLocByTxID(txID string) (*fileLocPointer, error)
 	getTxValidationCodeByTxID(txID string) (peer.TxValidationCode, error)
+	getTxBlockNumTranNumByTxID(txID string) (uint64, uint64, error)
+	txIDExists(txID string) (bool, error)
+	forEachTxID(fn func(txID string) error) error
+	getBlockNumByTimestamp(timestamp int64) (uint64, error)
 }
 
 type blockIdxInfo struct {
@@ -155,6 +163,29 @@ func (index *blockIndex) indexBlock(blockIdxInfo *blockIdxInfo) error {
 		}
 	}
 
+	// Index7 - Store block number and the position of the transaction in the block by transaction id
+	if _, ok := index.indexItemsMap[blkstorage.IndexableAttrTxBlockNumTranNum]; ok {
+		for idx, txoffset := range txOffsets {
+			batch.Put(constructTxBlockNumTranNumKey(txoffset.txID), encodeBlockNumTranNum(blockIdxInfo.blockNum, uint64(idx)))
+		}
+	}
+
+	// Index8 - Store block number by the timestamp of the block. The timestamp of a block is the latest of the timestamps
+	// of its transactions and of the preceding blocks so that the timestamps do not decrease along the chain
+	if _, ok := index.indexItemsMap[blkstorage.IndexableAttrBlockTimestamp]; ok {
+		blockTimestamp, err := index.getLastBlockTimestamp()
+		if err != nil {
+			return err
+		}
+		for _, txoffset := range txOffsets {
+			if txoffset.timestamp > blockTimestamp {
+				blockTimestamp = txoffset.timestamp
+			}
+		}
+		batch.Put(constructBlockTimestampKey(blockTimestamp, blockIdxInfo.blockNum), encodeBlockNum(blockIdxInfo.blockNum))
+		batch.Put(lastBlockTimestampKey, util.EncodeOrderPreservingVarUint64(uint64(blockTimestamp)))
+	}
+
 	batch.Put(indexCheckpointKey, encodeBlockNum(blockIdxInfo.blockNum))
 	if err := index.db.WriteBatch(batch, false); err != nil {
 		return err
@@ -210,6 +241,31 @@ func (index *blockIndex) getTxLoc(txID string) (*fileLocPointer, error) {
 	return txFLP, nil
 }
 
+func (index *blockIndex) txIDExists(txID string) (bool, error) {
+	if _, ok := index.indexItemsMap[blkstorage.IndexableAttrTxID]; !ok {
+		return false, blkstorage.ErrAttrNotIndexed
+	}
+	b, err := index.db.Get(constructTxIDKey(txID))
+	if err != nil {
+		return false, err
+	}
+	return b != nil, nil
+}
+
+func (index *blockIndex) forEachTxID(fn func(txID string) error) error {
+	if _, ok := index.indexItemsMap[blkstorage.IndexableAttrTxID]; !ok {
+		return blkstorage.ErrAttrNotIndexed
+	}
+	itr := index.db.GetIterator([]byte{txIDIdxKeyPrefix}, []byte{txIDIdxKeyPrefix + 1})
+	defer itr.Release()
+	for itr.Next() {
+		if err := fn(string(itr.Key()[1:])); err != nil {
+			return err
+		}
+	}
+	return itr.Error()
+}
+
 func (index *blockIndex) getBlockLocByTxID(txID string) (*fileLocPointer, error) {
 	if _, ok := index.indexItemsMap[blkstorage.IndexableAttrBlockTxID]; !ok {
 		return nil, blkstorage.ErrAttrNotIndexed
@@ -252,7 +308,7 @@ func (index *blockIndex) getTxValidationCodeByTxID(txID string) (peer.TxValidati
 	if err != nil {
 		return peer.TxValidationCode(-1), err
 	} else if raw == nil {
-		return peer.TxValidationCode(-1), blkstorage.ErrAttrNotIndexed
+		return peer.TxValidationCode(-1), blkstorage.ErrNotFoundInIndex
 	} else if len(raw) != 1 {
 		return peer.TxValidationCode(-1), errors.New("Invalid value in indexItems")
 	}
@@ -262,6 +318,49 @@ func (index *blockIndex) getTxValidationCodeByTxID(txID string) (peer.TxValidati
 	return result, nil
 }
 
+func (index *blockIndex) getTxBlockNumTranNumByTxID(txID string) (uint64, uint64, error) {
+	if _, ok := index.indexItemsMap[blkstorage.IndexableAttrTxBlockNumTranNum]; !ok {
+		return 0, 0, blkstorage.ErrAttrNotIndexed
+	}
+	b, err := index.db.Get(constructTxBlockNumTranNumKey(txID))
+	if err != nil {
+		return 0, 0, err
+	}
+	if b == nil {
+		return 0, 0, blkstorage.ErrNotFoundInIndex
+	}
+	return decodeBlockNumTranNum(b)
+}
+
+// getLastBlockTimestamp returns the timestamp of the last indexed block, or 0 if no block is indexed
+func (index *blockIndex) getLastBlockTimestamp() (int64, error) {
+	b, err := index.db.Get(lastBlockTimestampKey)
+	if err != nil || b == nil {
+		return 0, err
+	}
+	timestamp, _ := util.DecodeOrderPreservingVarUint64(b)
+	return int64(timestamp), nil
+}
+
+// getBlockNumByTimestamp returns the number of the first block with a timestamp not earlier than the given timestamp
+func (index *blockIndex) getBlockNumByTimestamp(timestamp int64) (uint64, error) {
+	if _, ok := index.indexItemsMap[blkstorage.IndexableAttrBlockTimestamp]; !ok {
+		return 0, blkstorage.ErrAttrNotIndexed
+	}
+	if timestamp < 0 {
+		timestamp = 0
+	}
+	itr := index.db.GetIterator(constructBlockTimestampKey(timestamp, 0), []byte{blockTimestampIdxKeyPrefix + 1})
+	defer itr.Release()
+	if !itr.Next() {
+		if err := itr.Error(); err != nil {
+			return 0, err
+		}
+		return 0, blkstorage.ErrNotFoundInIndex
+	}
+	return decodeBlockNum(itr.Value()), nil
+}
+
 func constructBlockNumKey(blockNum uint64) []byte {
 	blkNumBytes := util.EncodeOrderPreservingVarUint64(blockNum)
 	return append([]byte{blockNumIdxKeyPrefix}, blkNumBytes...)
@@ -290,6 +389,32 @@ func constructBlockNumTranNumKey(blockNum uint64, txNum uint64) []byte {
 	return append([]byte{blockNumTranNumIdxKeyPrefix}, key...)
 }
 
+func constructBlockTimestampKey(timestamp int64, blockNum uint64) []byte {
+	key := append([]byte{blockTimestampIdxKeyPrefix}, util.EncodeOrderPreservingVarUint64(uint64(timestamp))...)
+	return append(key, util.EncodeOrderPreservingVarUint64(blockNum)...)
+}
+
+func constructTxBlockNumTranNumKey(txID string) []byte {
+	return append([]byte{txBlockNumTranNumIdxKeyPrefix}, []byte(txID)...)
+}
+
+func encodeBlockNumTranNum(blockNum uint64, tranNum uint64) []byte {
+	return append(proto.EncodeVarint(blockNum), proto.EncodeVarint(tranNum)...)
+}
+
+func decodeBlockNumTranNum(b []byte) (uint64, uint64, error) {
+	buffer := proto.NewBuffer(b)
+	blockNum, err := buffer.DecodeVarint()
+	if err != nil {
+		return 0, 0, err
+	}
+	tranNum, err := buffer.DecodeVarint()
+	if err != nil {
+		return 0, 0, err
+	}
+	return blockNum, tranNum, nil
+}
+
 func encodeBlockNum(blockNum uint64) []byte {
 	return proto.EncodeVarint(blockNum)
 }
@@ -19,7 +19,9 @@ package fsblkstorage
 import (
 	"fmt"
 	"testing"
+	"time"
 
+	"github.com/golang/protobuf/ptypes/timestamp"
 	"github.com/hyperledger/fabric/common/ledger/blkstorage"
 	"github.com/hyperledger/fabric/common/ledger/testutil"
 	"github.com/hyperledger/fabric/core/ledger/util"
@@ -58,6 +60,22 @@ func (i *noopIndex) getTxValidationCodeByTxID(txID string) (peer.TxValidationCod
 	return peer.TxValidationCode(-1), nil
 }
 
+func (i *noopIndex) getTxBlockNumTranNumByTxID(txID string) (uint64, uint64, error) {
+	return 0, 0, nil
+}
+
+func (i *noopIndex) getBlockNumByTimestamp(timestamp int64) (uint64, error) {
+	return 0, nil
+}
+
+func (i *noopIndex) txIDExists(txID string) (bool, error) {
+	return false, nil
+}
+
+func (i *noopIndex) forEachTxID(fn func(txID string) error) error {
+	return nil
+}
+
 func TestBlockIndexSync(t *testing.T) {
 	testBlockIndexSync(t, 10, 5, false)
 	testBlockIndexSync(t, 10, 5, true)
@@ -127,6 +145,7 @@ func TestBlockIndexSelectiveIndexing(t *testing.T) {
 	testBlockIndexSelectiveIndexing(t, []blkstorage.IndexableAttr{blkstorage.IndexableAttrTxID, blkstorage.IndexableAttrBlockNumTranNum})
 	testBlockIndexSelectiveIndexing(t, []blkstorage.IndexableAttr{blkstorage.IndexableAttrBlockTxID})
 	testBlockIndexSelectiveIndexing(t, []blkstorage.IndexableAttr{blkstorage.IndexableAttrTxValidationCode})
+	testBlockIndexSelectiveIndexing(t, []blkstorage.IndexableAttr{blkstorage.IndexableAttrTxBlockNumTranNum})
 }
 
 func testBlockIndexSelectiveIndexing(t *testing.T, indexItems []blkstorage.IndexableAttr) {
@@ -208,6 +227,15 @@ func testBlockIndexSelectiveIndexing(t *testing.T, indexItems []blkstorage.Index
 				txid, err = extractTxID(d)
 				testutil.AssertNoError(t, err, "")
 
+				blockNum, tranNum, err := blockfileMgr.retrieveTxLocByTxID(txid)
+				if testutil.Contains(indexItems, blkstorage.IndexableAttrTxBlockNumTranNum) {
+					testutil.AssertNoError(t, err, "Error while retrieving tx location by txID")
+					testutil.AssertEquals(t, blockNum, block.Header.Number)
+					testutil.AssertEquals(t, tranNum, uint64(idx))
+				} else {
+					testutil.AssertSame(t, err, blkstorage.ErrAttrNotIndexed)
+				}
+
 				reason, err := blockfileMgr.retrieveTxValidationCodeByTxID(txid)
 
 				if testutil.Contains(indexItems, blkstorage.IndexableAttrTxValidationCode) {
@@ -221,5 +249,123 @@ func testBlockIndexSelectiveIndexing(t *testing.T, indexItems []blkstorage.Index
 				}
 			}
 		}
+
+		_, err = blockfileMgr.retrieveTxValidationCodeByTxID("nonExistingTxID")
+		if testutil.Contains(indexItems, blkstorage.IndexableAttrTxValidationCode) {
+			testutil.AssertSame(t, err, blkstorage.ErrNotFoundInIndex)
+		} else {
+			testutil.AssertSame(t, err, blkstorage.ErrAttrNotIndexed)
+		}
+
+		// test 'txIDExists' and 'forEachTxID'
+		txid, err = extractTxID(blocks[1].Data.Data[0])
+		testutil.AssertNoError(t, err, "")
+		exists, err := blockfileMgr.txIDExists(txid)
+		nonExisting, nonExistingErr := blockfileMgr.txIDExists("nonExistingTxID")
+		expectedTxIDs := make(map[string]bool)
+		for _, block := range blocks {
+			for _, d := range block.Data.Data {
+				id, err := extractTxID(d)
+				testutil.AssertNoError(t, err, "")
+				expectedTxIDs[id] = true
+			}
+		}
+		visitedTxIDs := make(map[string]bool)
+		forEachErr := blockfileMgr.forEachTxID(func(txID string) error {
+			visitedTxIDs[txID] = true
+			return nil
+		})
+		if testutil.Contains(indexItems, blkstorage.IndexableAttrTxID) {
+			testutil.AssertNoError(t, err, "Error while looking up txID")
+			testutil.AssertEquals(t, exists, true)
+			testutil.AssertNoError(t, nonExistingErr, "Error while looking up txID")
+			testutil.AssertEquals(t, nonExisting, false)
+			testutil.AssertNoError(t, forEachErr, "Error while visiting txIDs")
+			testutil.AssertEquals(t, visitedTxIDs, expectedTxIDs)
+		} else {
+			testutil.AssertSame(t, err, blkstorage.ErrAttrNotIndexed)
+			testutil.AssertSame(t, nonExistingErr, blkstorage.ErrAttrNotIndexed)
+			testutil.AssertSame(t, forEachErr, blkstorage.ErrAttrNotIndexed)
+		}
 	})
 }
+
+func TestTxLocOfTxsIndexedBeforeTxBlockNumTranNum(t *testing.T) {
+	// the blocks are indexed as before the block number and the transaction number were indexed by txID
+	conf := NewConf(testPath(), 0)
+	env := newTestEnvSelectiveIndexing(t, conf, []blkstorage.IndexableAttr{blkstorage.IndexableAttrBlockNum,
+		blkstorage.IndexableAttrTxID, blkstorage.IndexableAttrBlockTxID, blkstorage.IndexableAttrTxValidationCode})
+	blocks := testutil.ConstructTestBlocks(t, 4)
+	blkfileMgrWrapper := newTestBlockfileWrapper(env, "testledger")
+	blkfileMgrWrapper.addBlocks(blocks[:3])
+	blkfileMgrWrapper.close()
+	env.provider.Close()
+
+	env = newTestEnv(t, conf)
+	defer env.Cleanup()
+	blkfileMgrWrapper = newTestBlockfileWrapper(env, "testledger")
+	defer blkfileMgrWrapper.close()
+	blkfileMgrWrapper.addBlocks(blocks[3:])
+	blockfileMgr := blkfileMgrWrapper.blockfileMgr
+	for _, block := range blocks {
+		for idx, d := range block.Data.Data {
+			txid, err := extractTxID(d)
+			testutil.AssertNoError(t, err, "")
+			blockNum, tranNum, err := blockfileMgr.retrieveTxLocByTxID(txid)
+			testutil.AssertNoError(t, err, fmt.Sprintf("Error while retrieving tx location of tx [%s]", txid))
+			testutil.AssertEquals(t, blockNum, block.Header.Number)
+			testutil.AssertEquals(t, tranNum, uint64(idx))
+		}
+	}
+	_, _, err := blockfileMgr.retrieveTxLocByTxID("nonExistentTxID")
+	testutil.AssertSame(t, err, blkstorage.ErrNotFoundInIndex)
+}
+
+func TestBlockIndexTimestamp(t *testing.T) {
+	env := newTestEnv(t, NewConf(testPath(), 0))
+	defer env.Cleanup()
+	blkfileMgrWrapper := newTestBlockfileWrapper(env, "testledger")
+	defer blkfileMgrWrapper.close()
+	blockfileMgr := blkfileMgrWrapper.blockfileMgr
+
+	// the timestamp of the block 2 is lower than that of the block 1 and is taken as the timestamp of the block 1
+	blocks := []*common.Block{
+		constructBlockWithTimestamps(0, []int64{100}),
+		constructBlockWithTimestamps(1, []int64{250, 300}),
+		constructBlockWithTimestamps(2, []int64{200}),
+		constructBlockWithTimestamps(3, []int64{500}),
+	}
+	blkfileMgrWrapper.addBlocks(blocks)
+
+	verify := func() {
+		for _, c := range []struct {
+			timestamp int64
+			blockNum  uint64
+		}{{0, 0}, {100, 0}, {101, 1}, {300, 1}, {301, 3}, {500, 3}} {
+			blockNum, err := blockfileMgr.retrieveBlockNumByTimestamp(time.Unix(c.timestamp, 0))
+			testutil.AssertNoError(t, err, fmt.Sprintf("Error while retrieving block by timestamp %d", c.timestamp))
+			testutil.AssertEquals(t, blockNum, c.blockNum)
+		}
+		_, err := blockfileMgr.retrieveBlockNumByTimestamp(time.Unix(501, 0))
+		testutil.AssertSame(t, err, blkstorage.ErrNotFoundInIndex)
+	}
+	verify()
+	// the timestamps are recomputed when the index is rebuilt from the block files
+	testutil.AssertNoError(t, blockfileMgr.rebuildIndex(), "")
+	verify()
+}
+
+func constructBlockWithTimestamps(blockNum uint64, timestamps []int64) *common.Block {
+	block := common.NewBlock(blockNum, []byte{})
+	for i, ts := range timestamps {
+		chdr := putil.MakeChannelHeader(common.HeaderType_ENDORSER_TRANSACTION, 0, "testchain", 0)
+		chdr.Timestamp = &timestamp.Timestamp{Seconds: ts}
+		chdr.TxId = fmt.Sprintf("tx-%d-%d", blockNum, i)
+		payload := &common.Payload{Header: putil.MakePayloadHeader(chdr, &common.SignatureHeader{})}
+		block.Data.Data = append(block.Data.Data, putil.MarshalOrPanic(&common.Envelope{Payload: putil.MarshalOrPanic(payload)}))
+	}
+	block.Header.DataHash = block.Data.Hash()
+	putil.InitBlockMetadata(block)
+	block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER] = util.NewTxValidationFlags(len(timestamps))
+	return block
+}
@@ -16,7 +16,12 @@ limitations under the License.
 
 package fsblkstorage
 
-import "path/filepath"
+import (
+	"path/filepath"
+
+	"github.com/hyperledger/fabric/common/ledger/util"
+	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
+)
 
 const (
 	defaultMaxBlockfileSize = 64 * 1024 * 1024
@@ -26,6 +31,12 @@ const (
 type Conf struct {
 	blockStorageDir  string
 	maxBlockfileSize int
+	readOnly         bool
+	indexTuning      leveldbhelper.Tuning
+	// sharedIndexDBPath and sharedIndexDBPrefix are set if the index is kept in a leveldb shared with other stores
+	sharedIndexDBPath   string
+	sharedIndexDBPrefix string
+	syncPolicy          util.SyncPolicy
 }
 
 // NewConf constructs new `Conf`.
@@ -34,7 +45,34 @@ func NewConf(blockStorageDir string, maxBlockfileSize int) *Conf {
 	if maxBlockfileSize <= 0 {
 		maxBlockfileSize = defaultMaxBlockfileSize
 	}
-	return &Conf{blockStorageDir, maxBlockfileSize}
+	return &Conf{blockStorageDir: blockStorageDir, maxBlockfileSize: maxBlockfileSize, syncPolicy: util.SyncPolicy{Blocks: 1}}
+}
+
+// NewReadOnlyConf constructs new `Conf` for opening the existing block stores in read-only mode.
+// A block store opened in read-only mode does not write to the block files or the index
+// and exposes the blocks up to the last block recorded in the index
+func NewReadOnlyConf(blockStorageDir string) *Conf {
+	return &Conf{blockStorageDir: blockStorageDir, maxBlockfileSize: defaultMaxBlockfileSize, readOnly: true}
+}
+
+// SetIndexTuning sets the tuning of the goleveldb database that indexes the blocks
+func (conf *Conf) SetIndexTuning(tuning leveldbhelper.Tuning) {
+	conf.indexTuning = tuning
+}
+
+// SetSharedIndexDB keeps the index in the leveldb at the given path that is shared with other stores.
+// The names of the index dbs are prefixed by the given prefix. The tuning applies if the index
+// is the first store that opens the shared leveldb
+func (conf *Conf) SetSharedIndexDB(dbPath string, namePrefix string) {
+	conf.sharedIndexDBPath = dbPath
+	conf.sharedIndexDBPrefix = namePrefix
+}
+
+// SetSyncPolicy sets the policy by which the appended blocks are flushed to the block files. By default, every block
+// is flushed before it is added to the index. A block that is not flushed yet may be lost by a crash of the operating
+// system and the block store is then recovered up to the last flushed block
+func (conf *Conf) SetSyncPolicy(policy util.SyncPolicy) {
+	conf.syncPolicy = policy
 }
 
 func (conf *Conf) getIndexDir() string {
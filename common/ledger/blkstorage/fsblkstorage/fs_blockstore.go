@@ -17,8 +17,11 @@ limitations under the License.
 package fsblkstorage
 
 import (
+	"time"
+
 	"github.com/hyperledger/fabric/common/ledger"
 	"github.com/hyperledger/fabric/common/ledger/blkstorage"
+	"github.com/hyperledger/fabric/common/ledger/util"
 	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
 
 	"github.com/hyperledger/fabric/protos/common"
@@ -86,6 +89,57 @@ func (store *fsBlockStore) RetrieveTxValidationCodeByTxID(txID string) (peer.TxV
 	return store.fileMgr.retrieveTxValidationCodeByTxID(txID)
 }
 
+// RetrieveTxLocByTxID returns the block number and the position in the block of the transaction with the given id
+func (store *fsBlockStore) RetrieveTxLocByTxID(txID string) (uint64, uint64, error) {
+	return store.fileMgr.retrieveTxLocByTxID(txID)
+}
+
+// TxIDExists returns whether a transaction with the given id is indexed. Only the index is looked up
+func (store *fsBlockStore) TxIDExists(txID string) (bool, error) {
+	return store.fileMgr.txIDExists(txID)
+}
+
+// ForEachTxID invokes the given function for the id of each indexed transaction and stops at the first error
+func (store *fsBlockStore) ForEachTxID(fn func(txID string) error) error {
+	return store.fileMgr.forEachTxID(fn)
+}
+
+// RetrieveBlockNumByTimestamp returns the number of the first block with a timestamp not earlier than the given timestamp
+func (store *fsBlockStore) RetrieveBlockNumByTimestamp(timestamp time.Time) (uint64, error) {
+	return store.fileMgr.retrieveBlockNumByTimestamp(timestamp)
+}
+
+// GetSnapshotInfo returns the info of the snapshot from which the block store is bootstrapped
+func (store *fsBlockStore) GetSnapshotInfo() (*blkstorage.SnapshotInfo, error) {
+	return store.fileMgr.snapshotInfo, nil
+}
+
+// GetDiskUsage returns the size of the block files and the approximate size of the block index of the ledger
+func (store *fsBlockStore) GetDiskUsage() (int64, error) {
+	filesSize, err := util.DirSize(store.conf.getLedgerBlockDir(store.id))
+	if err != nil {
+		return 0, err
+	}
+	indexSize, err := store.fileMgr.db.ApproximateSize()
+	if err != nil {
+		return 0, err
+	}
+	return filesSize + indexSize, nil
+}
+
+// Sync flushes the block files and the block index to the disk
+func (store *fsBlockStore) Sync() error {
+	if err := store.fileMgr.sync(); err != nil {
+		return err
+	}
+	return store.fileMgr.db.Sync()
+}
+
+// CompactIndex compacts the block index of the ledger
+func (store *fsBlockStore) CompactIndex() error {
+	return store.fileMgr.db.Compact()
+}
+
 // Shutdown shuts down the block store
 func (store *fsBlockStore) Shutdown() {
 	logger.Debugf("closing fs blockStore:%s", store.id)
@@ -17,6 +17,9 @@ limitations under the License.
 package fsblkstorage
 
 import (
+	"fmt"
+	"os"
+
 	"github.com/hyperledger/fabric/common/ledger/blkstorage"
 	"github.com/hyperledger/fabric/common/ledger/util"
 	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
@@ -31,34 +34,149 @@ type FsBlockstoreProvider struct {
 
 // NewProvider constructs a filesystem based block store provider
 func NewProvider(conf *Conf, indexConfig *blkstorage.IndexConfig) blkstorage.BlockStoreProvider {
-	p := leveldbhelper.NewProvider(&leveldbhelper.Conf{DBPath: conf.getIndexDir()})
+	var p *leveldbhelper.Provider
+	if conf.sharedIndexDBPath != "" {
+		p = leveldbhelper.NewSharedProvider(&leveldbhelper.Conf{DBPath: conf.sharedIndexDBPath, ReadOnly: conf.readOnly, Tuning: conf.indexTuning},
+			conf.sharedIndexDBPrefix)
+	} else {
+		p = leveldbhelper.NewProvider(&leveldbhelper.Conf{DBPath: conf.getIndexDir(), ReadOnly: conf.readOnly, Tuning: conf.indexTuning})
+	}
 	return &FsBlockstoreProvider{conf, indexConfig, p}
 }
 
 // CreateBlockStore simply calls OpenBlockStore
 func (p *FsBlockstoreProvider) CreateBlockStore(ledgerid string) (blkstorage.BlockStore, error) {
+	if p.conf.readOnly {
+		return nil, blkstorage.ErrReadOnly
+	}
 	return p.OpenBlockStore(ledgerid)
 }
 
+// CreateBlockStoreFromSnapshot creates a block store for given ledgerid that starts
+// from the block next to the last block included in the snapshot
+func (p *FsBlockstoreProvider) CreateBlockStoreFromSnapshot(ledgerid string, snapshotInfo *blkstorage.SnapshotInfo) (blkstorage.BlockStore, error) {
+	if p.conf.readOnly {
+		return nil, blkstorage.ErrReadOnly
+	}
+	exists, err := p.Exists(ledgerid)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, fmt.Errorf("Block store for ledger [%s] already exists", ledgerid)
+	}
+	indexStoreHandle := p.leveldbProvider.GetDBHandle(ledgerid)
+	if err := saveSnapshotInfo(indexStoreHandle, snapshotInfo); err != nil {
+		return nil, err
+	}
+	return newFsBlockStore(ledgerid, p.conf, p.indexConfig, indexStoreHandle), nil
+}
+
 // OpenBlockStore opens a block store for given ledgerid.
 // If a blockstore is not existing, this method creates one
-// This method should be invoked only once for a particular ledgerid
+// This method should be invoked only once for a particular ledgerid.
+// In read-only mode, the block store is expected to exist
 func (p *FsBlockstoreProvider) OpenBlockStore(ledgerid string) (blkstorage.BlockStore, error) {
+	if p.conf.readOnly {
+		exists, err := p.Exists(ledgerid)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, fmt.Errorf("Block store for ledger [%s] does not exist", ledgerid)
+		}
+	}
 	indexStoreHandle := p.leveldbProvider.GetDBHandle(ledgerid)
 	return newFsBlockStore(ledgerid, p.conf, p.indexConfig, indexStoreHandle), nil
 }
 
+// RollbackBlockStore truncates the block store for given ledgerid such that it retains only the blocks
+// below the given height. The block store should not be open while this method is invoked
+func (p *FsBlockstoreProvider) RollbackBlockStore(ledgerid string, height uint64) error {
+	if p.conf.readOnly {
+		return blkstorage.ErrReadOnly
+	}
+	exists, err := p.Exists(ledgerid)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("Block store for ledger [%s] does not exist", ledgerid)
+	}
+	indexStoreHandle := p.leveldbProvider.GetDBHandle(ledgerid)
+	mgr := newBlockfileMgr(ledgerid, p.conf, p.indexConfig, indexStoreHandle)
+	defer mgr.close()
+	return mgr.rollback(height)
+}
+
+// RebuildBlockIndex drops the index of the block store for given ledgerid and rebuilds it from the block files.
+// The block store should not be open while this method is invoked
+func (p *FsBlockstoreProvider) RebuildBlockIndex(ledgerid string) error {
+	if p.conf.readOnly {
+		return blkstorage.ErrReadOnly
+	}
+	exists, err := p.Exists(ledgerid)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("Block store for ledger [%s] does not exist", ledgerid)
+	}
+	indexStoreHandle := p.leveldbProvider.GetDBHandle(ledgerid)
+	mgr := newBlockfileMgr(ledgerid, p.conf, p.indexConfig, indexStoreHandle)
+	defer mgr.close()
+	return mgr.rebuildIndex()
+}
+
+// Drop removes the block files and the index of the block store for given ledgerid.
+// The block store should not be open while this method is invoked
+func (p *FsBlockstoreProvider) Drop(ledgerid string) error {
+	if p.conf.readOnly {
+		return blkstorage.ErrReadOnly
+	}
+	if err := p.leveldbProvider.GetDBHandle(ledgerid).DeleteAll(); err != nil {
+		return err
+	}
+	return os.RemoveAll(p.conf.getLedgerBlockDir(ledgerid))
+}
+
 // Exists tells whether the BlockStore with given id exists
 func (p *FsBlockstoreProvider) Exists(ledgerid string) (bool, error) {
 	exists, _, err := util.FileExists(p.conf.getLedgerBlockDir(ledgerid))
 	return exists, err
 }
 
+// GetHeight returns the height of the block store for given ledgerid as recorded in its checkpoint.
+// This does not require the block store to be open
+func (p *FsBlockstoreProvider) GetHeight(ledgerid string) (uint64, error) {
+	return loadHeight(p.leveldbProvider.GetDBHandle(ledgerid))
+}
+
 // List lists the ids of the existing ledgers
 func (p *FsBlockstoreProvider) List() ([]string, error) {
 	return util.ListSubdirs(p.conf.getBlocksDir())
 }
 
+// IsEmpty returns true if no block store exists
+func (p *FsBlockstoreProvider) IsEmpty() (bool, error) {
+	ledgerIDs, err := p.List()
+	if os.IsNotExist(err) {
+		return true, nil
+	}
+	return len(ledgerIDs) == 0, err
+}
+
+// GetDataFormat returns the format of the data of the block stores, the empty string if no format is recorded.
+// The format is recorded in the index
+func (p *FsBlockstoreProvider) GetDataFormat() (string, error) {
+	return p.leveldbProvider.GetDataFormat()
+}
+
+// SetDataFormat records the format of the data of the block stores
+func (p *FsBlockstoreProvider) SetDataFormat(format string) error {
+	return p.leveldbProvider.SetDataFormat(format)
+}
+
 // Close closes the FsBlockstoreProvider
 func (p *FsBlockstoreProvider) Close() {
 	p.leveldbProvider.Close()
@@ -90,8 +90,67 @@ func TestBlockStoreProvider(t *testing.T) {
 	testutil.AssertNoError(t, err, "")
 	testutil.AssertEquals(t, exists, false)
 
+	height, err := provider.GetHeight(constructLedgerid(numStores + 1))
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, height, uint64(0))
+	stores[0].AddBlock(testutil.ConstructTestBlocks(t, 1)[0])
+	height, err = provider.GetHeight(constructLedgerid(0))
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, height, uint64(1))
+
 }
 
 func constructLedgerid(id int) string {
 	return fmt.Sprintf("ledger_%d", id)
 }
+
+func TestBlockStoreFromSnapshot(t *testing.T) {
+	env := newTestEnv(t, NewConf(testPath(), 0))
+	defer env.Cleanup()
+
+	provider := env.provider
+	blocks := testutil.ConstructTestBlocks(t, 10)
+	snapshotInfo := &blkstorage.SnapshotInfo{
+		LastBlockNum:      5,
+		LastBlockHash:     blocks[5].Header.Hash(),
+		PreviousBlockHash: blocks[5].Header.PreviousHash,
+		LastConfigBlock:   []byte("lastConfigBlock"),
+		CommitHash:        []byte("commitHash"),
+	}
+	store, err := provider.CreateBlockStoreFromSnapshot("ledger1", snapshotInfo)
+	testutil.AssertNoError(t, err, "")
+	height, _ := provider.GetHeight("ledger1")
+	testutil.AssertEquals(t, height, uint64(6))
+	bcInfo, _ := store.GetBlockchainInfo()
+	testutil.AssertEquals(t, bcInfo, &common.BlockchainInfo{
+		Height: 6, CurrentBlockHash: blocks[5].Header.Hash(), PreviousBlockHash: blocks[5].Header.PreviousHash})
+
+	// a block within the snapshot cannot be added
+	testutil.AssertError(t, store.AddBlock(blocks[5]), "Expected an error while adding a block included in the snapshot")
+	for _, b := range blocks[6:8] {
+		testutil.AssertNoError(t, store.AddBlock(b), "")
+	}
+	store.Shutdown()
+
+	_, err = provider.CreateBlockStoreFromSnapshot("ledger1", snapshotInfo)
+	testutil.AssertError(t, err, "Expected an error while creating an existing block store from snapshot")
+
+	store, _ = provider.OpenBlockStore("ledger1")
+	defer store.Shutdown()
+	bcInfo, _ = store.GetBlockchainInfo()
+	testutil.AssertEquals(t, bcInfo.Height, uint64(8))
+	retrievedSnapshotInfo, err := store.GetSnapshotInfo()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, retrievedSnapshotInfo, snapshotInfo)
+	for _, b := range blocks[8:] {
+		testutil.AssertNoError(t, store.AddBlock(b), "")
+	}
+	itr, _ := store.RetrieveBlocks(6)
+	defer itr.Close()
+	for i := 6; i < 10; i++ {
+		blockHolder, _ := itr.Next()
+		testutil.AssertEquals(t, blockHolder.(ledger.BlockHolder).GetBlock(), blocks[i])
+	}
+	_, err = store.RetrieveBlockByNumber(5)
+	testutil.AssertError(t, err, "Expected an error while retrieving a block included in the snapshot")
+}
@@ -50,6 +50,8 @@ func newTestEnv(t testing.TB, conf *Conf) *testEnv {
 		blkstorage.IndexableAttrBlockNumTranNum,
 		blkstorage.IndexableAttrBlockTxID,
 		blkstorage.IndexableAttrTxValidationCode,
+		blkstorage.IndexableAttrTxBlockNumTranNum,
+		blkstorage.IndexableAttrBlockTimestamp,
 	}
 	return newTestEnvSelectiveIndexing(t, conf, attrsToIndex)
 }
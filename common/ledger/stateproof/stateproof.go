@@ -0,0 +1,232 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package stateproof verifies the proofs of the values of the keys of the state of a ledger, as returned by
+// qscc GetStateProof, against the root of the state trie of a block. It depends only on the BCCSP and the
+// protos, so that the clients and the light clients can verify the responses of a peer without trusting it.
+//
+// The state trie is a Merkle Patricia trie over the hashes of the composite keys, split into nibbles, so that
+// all the paths have the same length and the values are held only by the leaves. A leaf holds the rest of its
+// path and the hash of the value, an extension holds a shared part of the paths and the hash of a branch, and a
+// branch holds the hashes of its 16 children, so that the nodes on the path of a key, each matching the hash held
+// by the previous one, prove the value of the key, or its absence, as of the root
+package stateproof
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/bccsp"
+	"github.com/hyperledger/fabric/bccsp/factory"
+	"github.com/hyperledger/fabric/protos/common"
+)
+
+// The types of the nodes of the state trie
+const (
+	LeafNode      byte = 0x00
+	ExtensionNode byte = 0x01
+	BranchNode    byte = 0x02
+)
+
+var compositeKeySep = []byte{0x00}
+
+// Node is a node of the state trie. Path and Value are set for a leaf, Path and Child for an extension, and
+// Children, with nil for the missing children, for a branch. Child and Children hold the hashes of the nodes
+type Node struct {
+	Kind     byte
+	Path     []byte
+	Value    []byte
+	Child    []byte
+	Children [16][]byte
+}
+
+// KeyPath returns the path of the given key in the state trie, one nibble per byte
+func KeyPath(hashOpts bccsp.HashOpts, namespace string, key string) ([]byte, error) {
+	compositeKey := append(append([]byte(namespace), compositeKeySep...), []byte(key)...)
+	keyHash, err := factory.GetDefault().Hash(compositeKey, hashOpts)
+	if err != nil {
+		return nil, err
+	}
+	path := make([]byte, 0, 2*len(keyHash))
+	for _, b := range keyHash {
+		path = append(path, b>>4, b&0x0f)
+	}
+	return path, nil
+}
+
+// Step follows the given path through the given node. It returns the hash of the child to follow with the rest
+// of the path or, when the path ends at the node, done along with the hash of the value, nil if the path is absent
+func Step(n *Node, path []byte) (childHash []byte, rest []byte, valueHash []byte, done bool) {
+	switch n.Kind {
+	case LeafNode:
+		if bytes.Equal(n.Path, path) {
+			return nil, nil, n.Value, true
+		}
+		return nil, nil, nil, true
+	case ExtensionNode:
+		if !bytes.HasPrefix(path, n.Path) {
+			return nil, nil, nil, true
+		}
+		return n.Child, path[len(n.Path):], nil, false
+	default:
+		if len(path) == 0 || len(n.Children[path[0]]) == 0 {
+			return nil, nil, nil, true
+		}
+		return n.Children[path[0]], path[1:], nil, false
+	}
+}
+
+// EncodeNode encodes the node as it is hashed
+func EncodeNode(n *Node) []byte {
+	buf := proto.NewBuffer([]byte{n.Kind})
+	switch n.Kind {
+	case LeafNode:
+		buf.EncodeRawBytes(n.Path)
+		buf.EncodeRawBytes(n.Value)
+	case ExtensionNode:
+		buf.EncodeRawBytes(n.Path)
+		buf.EncodeRawBytes(n.Child)
+	default:
+		for _, child := range n.Children {
+			buf.EncodeRawBytes(child)
+		}
+	}
+	return buf.Bytes()
+}
+
+// DecodeNode decodes a node encoded by EncodeNode
+func DecodeNode(nodeBytes []byte) (*Node, error) {
+	if len(nodeBytes) == 0 {
+		return nil, fmt.Errorf("Empty node of the state trie")
+	}
+	n := &Node{Kind: nodeBytes[0]}
+	buf := proto.NewBuffer(nodeBytes[1:])
+	var err error
+	switch n.Kind {
+	case LeafNode:
+		if n.Path, err = buf.DecodeRawBytes(true); err != nil {
+			return nil, err
+		}
+		if n.Value, err = buf.DecodeRawBytes(true); err != nil {
+			return nil, err
+		}
+	case ExtensionNode:
+		if n.Path, err = buf.DecodeRawBytes(true); err != nil {
+			return nil, err
+		}
+		if n.Child, err = buf.DecodeRawBytes(true); err != nil {
+			return nil, err
+		}
+	case BranchNode:
+		for i := range n.Children {
+			child, err := buf.DecodeRawBytes(true)
+			if err != nil {
+				return nil, err
+			}
+			if len(child) != 0 {
+				n.Children[i] = child
+			}
+		}
+	default:
+		return nil, fmt.Errorf("Unknown type [%d] of node of the state trie", n.Kind)
+	}
+	return n, nil
+}
+
+// Verify verifies that the given value is the value of the key of the proof as of the state root of the proof,
+// or that the key is absent from the state if the value is nil. The state root itself is not checked, see
+// VerifyWithRoot
+func Verify(proof *common.StateProof, value []byte) error {
+	hashOpts, err := bccsp.GetHashOpt(proof.HashAlgorithm)
+	if err != nil {
+		return err
+	}
+	var expectedValueHash []byte
+	if value != nil {
+		if expectedValueHash, err = factory.GetDefault().Hash(value, hashOpts); err != nil {
+			return err
+		}
+	}
+	if !bytes.Equal(proof.ValueHash, expectedValueHash) {
+		return fmt.Errorf("Value does not match the value hash of the proof of key [%s] of namespace [%s]", proof.Key, proof.Namespace)
+	}
+	path, err := KeyPath(hashOpts, proof.Namespace, proof.Key)
+	if err != nil {
+		return err
+	}
+	if len(proof.StateRoot) == 0 {
+		if len(proof.Nodes) != 0 || value != nil {
+			return fmt.Errorf("Proof of key [%s] of namespace [%s] does not match the empty state", proof.Key, proof.Namespace)
+		}
+		return nil
+	}
+	expectedHash := proof.StateRoot
+	for i, nodeBytes := range proof.Nodes {
+		hash, err := factory.GetDefault().Hash(nodeBytes, hashOpts)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(hash, expectedHash) {
+			return fmt.Errorf("Node [%d] of the proof of key [%s] of namespace [%s] does not match the hash held by its parent", i, proof.Key, proof.Namespace)
+		}
+		n, err := DecodeNode(nodeBytes)
+		if err != nil {
+			return err
+		}
+		childHash, rest, valueHash, done := Step(n, path)
+		if done {
+			if i != len(proof.Nodes)-1 {
+				return fmt.Errorf("Proof of key [%s] of namespace [%s] has nodes past the end of the path", proof.Key, proof.Namespace)
+			}
+			if !bytes.Equal(valueHash, proof.ValueHash) {
+				return fmt.Errorf("Value hash of the proof of key [%s] of namespace [%s] does not match the state trie", proof.Key, proof.Namespace)
+			}
+			return nil
+		}
+		expectedHash, path = childHash, rest
+	}
+	return fmt.Errorf("Proof of key [%s] of namespace [%s] ends before the end of the path", proof.Key, proof.Namespace)
+}
+
+// VerifyWithRoot verifies the proof as Verify does, and that the state root of the proof is the given root,
+// which the verifier trusts, e.g., the root agreed by several peers as per AgreedRoot
+func VerifyWithRoot(proof *common.StateProof, value []byte, trustedRoot []byte) error {
+	if !bytes.Equal(proof.StateRoot, trustedRoot) {
+		return fmt.Errorf("State root of the proof of key [%s] of namespace [%s] does not match the trusted root of block [%d]",
+			proof.Key, proof.Namespace, proof.BlockNumber)
+	}
+	return Verify(proof, value)
+}
+
+// AgreedRoot returns the state root of a block reported by at least quorum of the given proofs, which the
+// verifier obtains from as many peers. The proofs may be of different keys but must be of the same block
+func AgreedRoot(proofs []*common.StateProof, quorum int) ([]byte, error) {
+	if quorum <= 0 {
+		return nil, fmt.Errorf("Quorum [%d] is not positive", quorum)
+	}
+	counts := make(map[string]int)
+	for _, proof := range proofs {
+		if proof.BlockNumber != proofs[0].BlockNumber {
+			return nil, fmt.Errorf("Proofs are of blocks [%d] and [%d]", proofs[0].BlockNumber, proof.BlockNumber)
+		}
+		counts[string(proof.StateRoot)]++
+		if counts[string(proof.StateRoot)] >= quorum {
+			return proof.StateRoot, nil
+		}
+	}
+	return nil, fmt.Errorf("No state root is reported by [%d] of the [%d] proofs", quorum, len(proofs))
+}
@@ -0,0 +1,156 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stateproof
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/bccsp"
+	"github.com/hyperledger/fabric/bccsp/factory"
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/protos/common"
+)
+
+const testHashAlgorithm = bccsp.SHA256
+
+func hash(t *testing.T, b []byte) []byte {
+	hashOpts, err := bccsp.GetHashOpt(testHashAlgorithm)
+	testutil.AssertNoError(t, err, "")
+	h, err := factory.GetDefault().Hash(b, hashOpts)
+	testutil.AssertNoError(t, err, "")
+	return h
+}
+
+func path(t *testing.T, key string) []byte {
+	hashOpts, err := bccsp.GetHashOpt(testHashAlgorithm)
+	testutil.AssertNoError(t, err, "")
+	p, err := KeyPath(hashOpts, "ns", key)
+	testutil.AssertNoError(t, err, "")
+	return p
+}
+
+// testProofs builds the trie of keys key1 and key2 by hand and returns the proofs of both keys and of
+// the absent key3
+func testProofs(t *testing.T) (*common.StateProof, *common.StateProof, *common.StateProof) {
+	path1, path2 := path(t, "key1"), path(t, "key2")
+	c := 0
+	for path1[c] == path2[c] {
+		c++
+	}
+	nodes := make(map[string][]byte)
+	add := func(n *Node) []byte {
+		nodeBytes := EncodeNode(n)
+		h := hash(t, nodeBytes)
+		nodes[string(h)] = nodeBytes
+		return h
+	}
+	branch := &Node{Kind: BranchNode}
+	branch.Children[path1[c]] = add(&Node{Kind: LeafNode, Path: path1[c+1:], Value: hash(t, []byte("value1"))})
+	branch.Children[path2[c]] = add(&Node{Kind: LeafNode, Path: path2[c+1:], Value: hash(t, []byte("value2"))})
+	root := add(branch)
+	if c > 0 {
+		root = add(&Node{Kind: ExtensionNode, Path: path1[:c], Child: root})
+	}
+	prove := func(key string) *common.StateProof {
+		proof := &common.StateProof{Namespace: "ns", Key: key, BlockNumber: 5, StateRoot: root, HashAlgorithm: testHashAlgorithm}
+		h, p := root, path(t, key)
+		for {
+			nodeBytes := append([]byte{}, nodes[string(h)]...)
+			proof.Nodes = append(proof.Nodes, nodeBytes)
+			n, err := DecodeNode(nodeBytes)
+			testutil.AssertNoError(t, err, "")
+			childHash, rest, valueHash, done := Step(n, p)
+			if done {
+				proof.ValueHash = valueHash
+				return proof
+			}
+			h, p = childHash, rest
+		}
+	}
+	return prove("key1"), prove("key2"), prove("key3")
+}
+
+func TestVerify(t *testing.T) {
+	proof1, proof2, proof3 := testProofs(t)
+	testutil.AssertNoError(t, Verify(proof1, []byte("value1")), "")
+	testutil.AssertNoError(t, Verify(proof2, []byte("value2")), "")
+	testutil.AssertNoError(t, Verify(proof3, nil), "")
+	testutil.AssertError(t, Verify(proof1, []byte("value2")), "the value is not the value of key1")
+	testutil.AssertError(t, Verify(proof3, []byte("value3")), "key3 is absent")
+
+	// a proof of a key claimed by the nodes of another key
+	proof2.Key = "key1"
+	testutil.AssertError(t, Verify(proof2, []byte("value2")), "the nodes are of key2")
+
+	tampered, _, _ := testProofs(t)
+	last := tampered.Nodes[len(tampered.Nodes)-1]
+	last[len(last)-1] ^= 0xff
+	testutil.AssertError(t, Verify(tampered, []byte("value1")), "the leaf is tampered with")
+
+	truncated, _, _ := testProofs(t)
+	truncated.Nodes = truncated.Nodes[:len(truncated.Nodes)-1]
+	testutil.AssertError(t, Verify(truncated, []byte("value1")), "the proof is truncated")
+
+	empty := &common.StateProof{Namespace: "ns", Key: "key1", HashAlgorithm: testHashAlgorithm}
+	testutil.AssertNoError(t, Verify(empty, nil), "")
+	testutil.AssertError(t, Verify(empty, []byte("value1")), "the state is empty")
+
+	unknown, _, _ := testProofs(t)
+	unknown.HashAlgorithm = "MD5"
+	testutil.AssertError(t, Verify(unknown, []byte("value1")), "the hash algorithm is unknown")
+}
+
+func TestVerifyWithRoot(t *testing.T) {
+	proof1, _, _ := testProofs(t)
+	testutil.AssertNoError(t, VerifyWithRoot(proof1, []byte("value1"), proof1.StateRoot), "")
+	testutil.AssertError(t, VerifyWithRoot(proof1, []byte("value1"), hash(t, []byte("root"))), "the root is not trusted")
+}
+
+func TestAgreedRoot(t *testing.T) {
+	proof1, proof2, proof3 := testProofs(t)
+	forged := &common.StateProof{BlockNumber: 5, StateRoot: hash(t, []byte("root"))}
+	root, err := AgreedRoot([]*common.StateProof{forged, proof1, proof2, proof3}, 3)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, root, proof1.StateRoot)
+	_, err = AgreedRoot([]*common.StateProof{forged, proof1, proof2}, 3)
+	testutil.AssertError(t, err, "only two proofs agree")
+	_, err = AgreedRoot([]*common.StateProof{proof1, {BlockNumber: 6, StateRoot: proof1.StateRoot}}, 2)
+	testutil.AssertError(t, err, "the proofs are of different blocks")
+	_, err = AgreedRoot([]*common.StateProof{proof1}, 0)
+	testutil.AssertError(t, err, "the quorum is not positive")
+}
+
+func TestNodeEncoding(t *testing.T) {
+	branch := &Node{Kind: BranchNode}
+	branch.Children[3] = []byte("child3")
+	branch.Children[15] = []byte("child15")
+	for _, n := range []*Node{
+		{Kind: LeafNode, Path: []byte{1, 2, 3}, Value: []byte("valueHash")},
+		{Kind: ExtensionNode, Path: []byte{4}, Child: []byte("childHash")},
+		branch,
+	} {
+		decoded, err := DecodeNode(EncodeNode(n))
+		testutil.AssertNoError(t, err, "")
+		testutil.AssertEquals(t, decoded, n)
+	}
+	_, err := DecodeNode(nil)
+	testutil.AssertError(t, err, "the node is empty")
+	_, err = DecodeNode([]byte{0x07})
+	testutil.AssertError(t, err, "the type of the node is unknown")
+	_, err = DecodeNode([]byte{LeafNode, 0x05})
+	testutil.AssertError(t, err, "the node is truncated")
+}
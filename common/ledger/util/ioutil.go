@@ -21,6 +21,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"path/filepath"
 	"strings"
 
 	"github.com/op/go-logging"
@@ -86,6 +87,24 @@ func ListSubdirs(dirPath string) ([]string, error) {
 	return subdirs, nil
 }
 
+// DirSize returns the total size of the regular files under the given dir. A missing dir has a size of zero
+func DirSize(dirPath string) (int64, error) {
+	var size int64
+	err := filepath.Walk(dirPath, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
 func logDirStatus(msg string, dirPath string) {
 	exists, _, err := FileExists(dirPath)
 	if err != nil {
@@ -90,6 +90,30 @@ func TestDirNotEmptyAndFileExists(t *testing.T) {
 	testutil.AssertEquals(t, dirEmpty5, false) //test directory is empty is returning false
 }
 
+func TestDirSize(t *testing.T) {
+	cleanup(DbPathTest)
+	defer cleanup(DbPathTest)
+
+	//a missing directory has a size of zero
+	size, err := DirSize(DbPathTest)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, size, int64(0))
+
+	_, err = CreateDirIfMissing(DbPathTest + "/subdir")
+	testutil.AssertNoError(t, err, "")
+	sizeOfFileCreated, err := createAndWriteAFile("This is some test data in a file")
+	testutil.AssertNoError(t, err, "")
+	f, err := os.Create(DbPathTest + "/subdir/testUtilFileDat2")
+	testutil.AssertNoError(t, err, "")
+	_, err = f.WriteString("more data")
+	f.Close()
+	testutil.AssertNoError(t, err, "")
+
+	size, err = DirSize(DbPathTest)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, size, int64(sizeOfFileCreated+len("more data")))
+}
+
 func createAndWriteAFile(sentence string) (int, error) {
 	//create a file in the direcotry
 	f, err2 := os.Create(DbFileTest)
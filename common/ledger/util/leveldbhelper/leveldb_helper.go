@@ -23,6 +23,7 @@ import (
 	"github.com/hyperledger/fabric/common/ledger/util"
 	"github.com/op/go-logging"
 	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/filter"
 	"github.com/syndtr/goleveldb/leveldb/iterator"
 	"github.com/syndtr/goleveldb/leveldb/opt"
 	goleveldbutil "github.com/syndtr/goleveldb/leveldb/util"
@@ -30,6 +31,10 @@ import (
 
 var logger = logging.MustGetLogger("leveldbhelper")
 
+// syncKey is the key deleted by Sync. The key starts with the separator used by the
+// DBHandle and hence does not clash with the keys of the named dbs
+var syncKey = []byte("\x00__sync__")
+
 type dbState int32
 
 const (
@@ -38,8 +43,39 @@ const (
 )
 
 // Conf configuration for `DB`
+// A `DB` opened with ReadOnly neither creates the db nor takes an exclusive lock on it
+// and hence can be opened by multiple processes at the same time
 type Conf struct {
-	DBPath string
+	DBPath   string
+	ReadOnly bool
+	Tuning   Tuning
+}
+
+// Tuning contains the goleveldb options that trade the memory and the disk usage of a `DB` for its performance.
+// The sizes are in bytes. A zero value of an option retains the default of goleveldb
+type Tuning struct {
+	// BlockCacheSize is the capacity of the cache of the uncompressed blocks of the sorted tables
+	BlockCacheSize int
+	// WriteBufferSize is the size of the memtable that is filled before being flushed to a sorted table
+	WriteBufferSize int
+	// CompactionTableSize is the size of the sorted tables generated by a compaction
+	CompactionTableSize int
+	// CompactionL0Trigger is the number of the sorted tables at level-0 that triggers a compaction
+	CompactionL0Trigger int
+	// BloomFilterBits is the number of bits per key of the bloom filter of the sorted tables.
+	// The bloom filter is not used if zero. A change applies to the sorted tables generated afterwards
+	BloomFilterBits int
+}
+
+// apply sets the non-zero options of the tuning on the given goleveldb options
+func (t *Tuning) apply(dbOpts *opt.Options) {
+	dbOpts.BlockCacheCapacity = t.BlockCacheSize
+	dbOpts.WriteBuffer = t.WriteBufferSize
+	dbOpts.CompactionTableSize = t.CompactionTableSize
+	dbOpts.CompactionL0Trigger = t.CompactionL0Trigger
+	if t.BloomFilterBits > 0 {
+		dbOpts.Filter = filter.NewBloomFilter(t.BloomFilterBits)
+	}
 }
 
 // DB - a wrapper on an actual store
@@ -77,10 +113,13 @@ func (dbInst *DB) Open() {
 		return
 	}
 	dbOpts := &opt.Options{}
+	dbInst.conf.Tuning.apply(dbOpts)
 	dbPath := dbInst.conf.DBPath
 	var err error
 	var dirEmpty bool
-	if dirEmpty, err = util.CreateDirIfMissing(dbPath); err != nil {
+	if dbInst.conf.ReadOnly {
+		dbOpts.ReadOnly = true
+	} else if dirEmpty, err = util.CreateDirIfMissing(dbPath); err != nil {
 		panic(fmt.Sprintf("Error while trying to create dir if missing: %s", err))
 	}
 	dbOpts.ErrorIfMissing = !dirEmpty
@@ -158,6 +197,29 @@ func (dbInst *DB) GetIterator(startKey []byte, endKey []byte) iterator.Iterator
 	return dbInst.db.NewIterator(&goleveldbutil.Range{Start: startKey, Limit: endKey}, dbInst.readOpts)
 }
 
+// SizeOf returns the approximate size, on the disk, of the keys between the startKey (inclusive) and the endKey (exclusive).
+// The size does not account for the data that is not yet flushed from the memory to the disk
+func (dbInst *DB) SizeOf(startKey []byte, endKey []byte) (int64, error) {
+	sizes, err := dbInst.db.SizeOf([]goleveldbutil.Range{{Start: startKey, Limit: endKey}})
+	if err != nil {
+		return 0, err
+	}
+	return sizes.Sum(), nil
+}
+
+// Sync flushes the writes that are made without the sync option to the disk. The flush is done by
+// deleting, with the sync option, a key that is not written by any of the users of the db
+func (dbInst *DB) Sync() error {
+	return dbInst.Delete(syncKey, true)
+}
+
+// CompactRange compacts the keys between the startKey (inclusive) and the endKey (exclusive), which drops
+// the deleted and the overwritten values and merges the files of the range into the bottom level.
+// A nil startKey and a nil endKey compact the whole db
+func (dbInst *DB) CompactRange(startKey []byte, endKey []byte) error {
+	return dbInst.db.CompactRange(goleveldbutil.Range{Start: startKey, Limit: endKey})
+}
+
 // WriteBatch writes a batch
 func (dbInst *DB) WriteBatch(batch *leveldb.Batch, sync bool) error {
 	wo := dbInst.writeOptsNoSync
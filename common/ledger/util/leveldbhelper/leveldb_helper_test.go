@@ -22,6 +22,7 @@ import (
 	"testing"
 
 	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/syndtr/goleveldb/leveldb/opt"
 )
 
 const testDBPath = "/tmp/fabric/ledgertests/util/leveldbhelper"
@@ -81,6 +82,178 @@ func TestIterator(t *testing.T) {
 	checkItrResults(t, itr3, createTestKeys(0, 19), createTestValues("db2", 0, 19))
 }
 
+func TestDeleteAll(t *testing.T) {
+	p := createTestDBProvider(t)
+	defer p.Close()
+	db1 := p.GetDBHandle("db1")
+	db2 := p.GetDBHandle("db2")
+	for i := 0; i < 2500; i++ {
+		db1.Put([]byte(createTestKey(i)), []byte(createTestValue("db1", i)), false)
+		db2.Put([]byte(createTestKey(i)), []byte(createTestValue("db2", i)), false)
+	}
+	testutil.AssertNoError(t, db1.DeleteAll(), "")
+	checkItrResults(t, db1.GetIterator(nil, nil), nil, nil)
+	checkItrResults(t, db2.GetIterator(nil, nil), createTestKeys(0, 2499), createTestValues("db2", 0, 2499))
+}
+
+func TestSync(t *testing.T) {
+	p := createTestDBProvider(t)
+	defer p.Close()
+	db1 := p.GetDBHandle("db1")
+	db1.Put([]byte("key1"), []byte("value1"), false)
+	testutil.AssertNoError(t, db1.Sync(), "")
+	// the sync leaves the contents of the dbs unchanged
+	checkItrResults(t, db1.GetIterator(nil, nil), []string{"key1"}, []string{"value1"})
+	checkItrResults(t, p.GetDBHandle("").GetIterator(nil, nil), nil, nil)
+}
+
+func TestCompact(t *testing.T) {
+	p := createTestDBProvider(t)
+	defer p.Close()
+	db1 := p.GetDBHandle("db1")
+	db2 := p.GetDBHandle("db2")
+	for i := 0; i < 100; i++ {
+		db1.Put([]byte(createTestKey(i)), []byte(createTestValue("db1", i)), false)
+		db2.Put([]byte(createTestKey(i)), []byte(createTestValue("db2", i)), false)
+	}
+	for i := 50; i < 100; i++ {
+		db1.Delete([]byte(createTestKey(i)), false)
+	}
+	testutil.AssertNoError(t, db1.Compact(), "")
+	// the compaction leaves the contents of the dbs unchanged
+	checkItrResults(t, db1.GetIterator(nil, nil), createTestKeys(0, 49), createTestValues("db1", 0, 49))
+	checkItrResults(t, db2.GetIterator(nil, nil), createTestKeys(0, 99), createTestValues("db2", 0, 99))
+}
+
+func TestReadOnlyProvider(t *testing.T) {
+	p := createTestDBProvider(t)
+	p.GetDBHandle("db1").Put([]byte("key1"), []byte("value1"), true)
+	p.Close()
+
+	// multiple read-only providers can be opened on the same db
+	readOnlyConf := &Conf{DBPath: testDBPath, ReadOnly: true}
+	p1 := NewProvider(readOnlyConf)
+	defer p1.Close()
+	p2 := NewProvider(readOnlyConf)
+	defer p2.Close()
+	for _, p := range []*Provider{p1, p2} {
+		db := p.GetDBHandle("db1")
+		val, err := db.Get([]byte("key1"))
+		testutil.AssertNoError(t, err, "")
+		testutil.AssertEquals(t, val, []byte("value1"))
+		testutil.AssertError(t, db.Put([]byte("key2"), []byte("value2"), true), "Expected an error for a write to a read-only db")
+	}
+}
+
+func TestTuning(t *testing.T) {
+	os.RemoveAll(testDBPath)
+	defer os.RemoveAll(testDBPath)
+	tuning := Tuning{BlockCacheSize: 16 * 1024 * 1024, WriteBufferSize: 1024 * 1024, BloomFilterBits: 10}
+	p := NewProvider(&Conf{DBPath: testDBPath, Tuning: tuning})
+	db := p.GetDBHandle("db1")
+	db.Put([]byte("key1"), []byte("value1"), true)
+	p.Close()
+
+	// the db is reopened with a different tuning
+	p = NewProvider(&Conf{DBPath: testDBPath})
+	defer p.Close()
+	val, err := p.GetDBHandle("db1").Get([]byte("key1"))
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, val, []byte("value1"))
+
+	dbOpts := &opt.Options{}
+	tuning.apply(dbOpts)
+	testutil.AssertEquals(t, dbOpts.GetBlockCacheCapacity(), 16*1024*1024)
+	testutil.AssertEquals(t, dbOpts.GetWriteBuffer(), 1024*1024)
+	testutil.AssertEquals(t, dbOpts.GetCompactionL0Trigger(), opt.DefaultCompactionL0Trigger)
+	testutil.AssertNotEquals(t, dbOpts.GetFilter(), nil)
+}
+
+func TestSharedProvider(t *testing.T) {
+	os.RemoveAll(testDBPath)
+	defer os.RemoveAll(testDBPath)
+	conf := &Conf{DBPath: testDBPath}
+	p1 := NewSharedProvider(conf, "p1/")
+	p2 := NewSharedProvider(conf, "p2/")
+	p1.GetDBHandle("db1").Put([]byte("key1"), []byte("value1_p1"), true)
+	p2.GetDBHandle("db1").Put([]byte("key1"), []byte("value1_p2"), true)
+	p2.GetDBHandle("").Put([]byte("key2"), []byte("value2_p2"), true)
+
+	// the dbs of the same name are kept apart by the prefixes of the providers
+	checkItrResults(t, p1.GetDBHandle("db1").GetIterator(nil, nil), []string{"key1"}, []string{"value1_p1"})
+	checkItrResults(t, p2.GetDBHandle("db1").GetIterator(nil, nil), []string{"key1"}, []string{"value1_p2"})
+	checkItrResults(t, p2.GetDBHandle("").GetIterator(nil, nil), []string{"key2"}, []string{"value2_p2"})
+	testutil.AssertNoError(t, p1.GetDBHandle("db1").DeleteAll(), "")
+	checkItrResults(t, p2.GetDBHandle("db1").GetIterator(nil, nil), []string{"key1"}, []string{"value1_p2"})
+
+	// the leveldb remains open until the last provider is closed, even if a provider is closed twice
+	p1.Close()
+	p1.Close()
+	val, err := p2.GetDBHandle("db1").Get([]byte("key1"))
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, val, []byte("value1_p2"))
+	p2.Close()
+
+	p := NewSharedProvider(conf, "p2/")
+	defer p.Close()
+	val, err = p.GetDBHandle("").Get([]byte("key2"))
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, val, []byte("value2_p2"))
+}
+
+func TestDataFormat(t *testing.T) {
+	os.RemoveAll(testDBPath)
+	defer os.RemoveAll(testDBPath)
+	conf := &Conf{DBPath: testDBPath}
+	p1 := NewSharedProvider(conf, "p1/")
+	defer p1.Close()
+	p2 := NewSharedProvider(conf, "p2/")
+	defer p2.Close()
+	p1.GetDBHandle("").Put([]byte("key1"), []byte("value1"), true)
+
+	format, err := p1.GetDataFormat()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, format, "")
+	testutil.AssertNoError(t, p1.SetDataFormat("1.0"), "")
+	format, _ = p1.GetDataFormat()
+	testutil.AssertEquals(t, format, "1.0")
+
+	// the format is recorded per provider and is not visible in the dbs of the provider
+	format, _ = p2.GetDataFormat()
+	testutil.AssertEquals(t, format, "")
+	checkItrResults(t, p1.GetDBHandle("").GetIterator(nil, nil), []string{"key1"}, []string{"value1"})
+}
+
+func TestListDBNames(t *testing.T) {
+	os.RemoveAll(testDBPath)
+	defer os.RemoveAll(testDBPath)
+	conf := &Conf{DBPath: testDBPath}
+	p1 := NewSharedProvider(conf, "p1/")
+	defer p1.Close()
+	p2 := NewSharedProvider(conf, "p2/")
+	defer p2.Close()
+	dbNames, err := p1.ListDBNames()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, len(dbNames), 0)
+
+	for _, dbName := range []string{"db2", "db1"} {
+		for i := 0; i < 3; i++ {
+			p1.GetDBHandle(dbName).Put([]byte(createTestKey(i)), []byte(createTestValue(dbName, i)), false)
+		}
+	}
+	p2.GetDBHandle("db3").Put([]byte("key1"), []byte("value1"), true)
+	testutil.AssertNoError(t, p1.SetDataFormat("1.0"), "")
+	testutil.AssertNoError(t, p1.GetDBHandle("").Sync(), "")
+
+	// the dbs of the other providers and the internal db are not listed
+	dbNames, err = p1.ListDBNames()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, dbNames, []string{"db1", "db2"})
+	testutil.AssertNoError(t, p1.GetDBHandle("db1").DeleteAll(), "")
+	dbNames, _ = p1.ListDBNames()
+	testutil.AssertEquals(t, dbNames, []string{"db2"})
+}
+
 func checkItrResults(t *testing.T, itr *Iterator, expectedKeys []string, expectedValues []string) {
 	defer itr.Release()
 	var actualKeys []string
@@ -122,6 +295,6 @@ func createTestDBProvider(t *testing.T) *Provider {
 	if err := os.RemoveAll(testDBPath); err != nil {
 		t.Fatalf("Error:%s", err)
 	}
-	dbConf := &Conf{testDBPath}
+	dbConf := &Conf{DBPath: testDBPath}
 	return NewProvider(dbConf)
 }
@@ -18,27 +18,89 @@ package leveldbhelper
 
 import (
 	"bytes"
+	"fmt"
+	"strings"
 	"sync"
 
 	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/syndtr/goleveldb/leveldb/iterator"
+	goleveldbutil "github.com/syndtr/goleveldb/leveldb/util"
 )
 
 var dbNameKeySep = []byte{0x00}
 var lastKeyIndicator = byte(0x01)
 
+// maxDeleteAllBatchSize is the number of keys deleted in a single batch by DeleteAll
+const maxDeleteAllBatchSize = 1000
+
+// internalDBName is the name of the db that holds the data of the provider itself. The name is not a valid
+// ledger id and hence does not collide with the dbs that the stores name after the ledgers
+const internalDBName = "_"
+
+// dataFormatKey is the key, in the internal db, of the format of the data of the provider
+var dataFormatKey = []byte("dataFormat")
+
 // Provider enables to use a single leveldb as multiple logical leveldbs
 type Provider struct {
 	db        *DB
 	dbHandles map[string]*DBHandle
 	mux       sync.Mutex
+	// namePrefix is prepended to the names of the dbs of a shared provider
+	namePrefix string
+	// release is set for a shared provider and releases the shared leveldb instead of closing it
+	release func()
 }
 
 // NewProvider constructs a Provider
 func NewProvider(conf *Conf) *Provider {
 	db := CreateDB(conf)
 	db.Open()
-	return &Provider{db, make(map[string]*DBHandle), sync.Mutex{}}
+	return &Provider{db: db, dbHandles: make(map[string]*DBHandle)}
+}
+
+// sharedDB is a leveldb that is shared by multiple providers
+type sharedDB struct {
+	db   *DB
+	refs int
+}
+
+var sharedDBs = make(map[string]*sharedDB)
+var sharedDBsLock sync.Mutex
+
+// NewSharedProvider constructs a Provider over the leveldb at the path of the given conf. The leveldb is
+// shared by all the providers constructed with the same path and is opened with the conf of the first of them.
+// The names of the dbs are prefixed by the given namePrefix, which keeps the dbs of the providers apart as long
+// as no prefix of a provider is a prefix of the other. The leveldb is closed when the last provider is closed
+func NewSharedProvider(conf *Conf, namePrefix string) *Provider {
+	if namePrefix == "" || strings.Contains(namePrefix, string(dbNameKeySep)) {
+		panic(fmt.Sprintf("Invalid name prefix [%q] for a shared leveldb", namePrefix))
+	}
+	sharedDBsLock.Lock()
+	defer sharedDBsLock.Unlock()
+	shared := sharedDBs[conf.DBPath]
+	if shared == nil {
+		db := CreateDB(conf)
+		db.Open()
+		shared = &sharedDB{db: db}
+		sharedDBs[conf.DBPath] = shared
+	}
+	shared.refs++
+	var once sync.Once
+	release := func() {
+		once.Do(func() { releaseSharedDB(conf.DBPath) })
+	}
+	return &Provider{db: shared.db, dbHandles: make(map[string]*DBHandle), namePrefix: namePrefix, release: release}
+}
+
+func releaseSharedDB(dbPath string) {
+	sharedDBsLock.Lock()
+	defer sharedDBsLock.Unlock()
+	shared := sharedDBs[dbPath]
+	shared.refs--
+	if shared.refs == 0 {
+		shared.db.Close()
+		delete(sharedDBs, dbPath)
+	}
 }
 
 // GetDBHandle returns a handle to a named db
@@ -47,14 +109,59 @@ func (p *Provider) GetDBHandle(dbName string) *DBHandle {
 	defer p.mux.Unlock()
 	dbHandle := p.dbHandles[dbName]
 	if dbHandle == nil {
-		dbHandle = &DBHandle{dbName, p.db}
+		dbHandle = &DBHandle{p.namePrefix + dbName, p.db}
 		p.dbHandles[dbName] = dbHandle
 	}
 	return dbHandle
 }
 
-// Close closes the underlying leveldb
+// GetDataFormat returns the format of the data of the provider as recorded by SetDataFormat,
+// the empty string if no format is recorded
+func (p *Provider) GetDataFormat() (string, error) {
+	format, err := p.GetDBHandle(internalDBName).Get(dataFormatKey)
+	if err != nil {
+		return "", err
+	}
+	return string(format), nil
+}
+
+// SetDataFormat records the format of the data of the provider
+func (p *Provider) SetDataFormat(format string) error {
+	return p.GetDBHandle(internalDBName).Put(dataFormatKey, []byte(format), true)
+}
+
+// ListDBNames returns the names of the dbs of the provider that hold at least a key.
+// The db that holds the data of the provider itself is not listed
+func (p *Provider) ListDBNames() ([]string, error) {
+	prefixRange := goleveldbutil.BytesPrefix([]byte(p.namePrefix))
+	itr := p.db.GetIterator(prefixRange.Start, prefixRange.Limit)
+	defer itr.Release()
+	var dbNames []string
+	for ok := itr.First(); ok; {
+		levelKey := itr.Key()
+		sepIndex := bytes.Index(levelKey[len(p.namePrefix):], dbNameKeySep)
+		if sepIndex < 0 {
+			ok = itr.Next()
+			continue
+		}
+		dbName := string(levelKey[len(p.namePrefix) : len(p.namePrefix)+sepIndex])
+		if dbName != internalDBName {
+			dbNames = append(dbNames, dbName)
+		}
+		// skip the remaining keys of the db
+		nextDBKey := constructLevelKey(p.namePrefix+dbName, nil)
+		nextDBKey[len(nextDBKey)-1] = lastKeyIndicator
+		ok = itr.Seek(nextDBKey)
+	}
+	return dbNames, itr.Error()
+}
+
+// Close closes the underlying leveldb. A shared leveldb is closed once all its providers are closed
 func (p *Provider) Close() {
+	if p.release != nil {
+		p.release()
+		return
+	}
 	p.db.Close()
 }
 
@@ -79,6 +186,12 @@ func (h *DBHandle) Delete(key []byte, sync bool) error {
 	return h.db.Delete(constructLevelKey(h.dbName, key), sync)
 }
 
+// Sync flushes the writes that are made without the sync option to the disk.
+// As the handles share the underlying db, the writes made through the other handles are flushed as well
+func (h *DBHandle) Sync() error {
+	return h.db.Sync()
+}
+
 // WriteBatch writes a batch in an atomic way
 func (h *DBHandle) WriteBatch(batch *UpdateBatch, sync bool) error {
 	levelBatch := &leveldb.Batch{}
@@ -96,6 +209,26 @@ func (h *DBHandle) WriteBatch(batch *UpdateBatch, sync bool) error {
 	return nil
 }
 
+// DeleteAll deletes all the keys of the named db
+func (h *DBHandle) DeleteAll() error {
+	itr := h.GetIterator(nil, nil)
+	defer itr.Release()
+	levelBatch := &leveldb.Batch{}
+	for itr.Next() {
+		levelBatch.Delete(append([]byte{}, itr.Iterator.Key()...))
+		if levelBatch.Len() == maxDeleteAllBatchSize {
+			if err := h.db.WriteBatch(levelBatch, true); err != nil {
+				return err
+			}
+			levelBatch.Reset()
+		}
+	}
+	if err := itr.Error(); err != nil {
+		return err
+	}
+	return h.db.WriteBatch(levelBatch, true)
+}
+
 // GetIterator gets an handle to iterator. The iterator should be released after the use.
 // The resultset contains all the keys that are present in the db between the startKey (inclusive) and the endKey (exclusive).
 // A nil startKey represents the first available key and a nil endKey represent a logical key after the last available key
@@ -110,6 +243,22 @@ func (h *DBHandle) GetIterator(startKey []byte, endKey []byte) *Iterator {
 	return &Iterator{h.db.GetIterator(sKey, eKey)}
 }
 
+// ApproximateSize returns the approximate size, on the disk, of the keys of the named db
+func (h *DBHandle) ApproximateSize() (int64, error) {
+	sKey := constructLevelKey(h.dbName, nil)
+	eKey := constructLevelKey(h.dbName, nil)
+	eKey[len(eKey)-1] = lastKeyIndicator
+	return h.db.SizeOf(sKey, eKey)
+}
+
+// Compact compacts the keys of the named db
+func (h *DBHandle) Compact() error {
+	sKey := constructLevelKey(h.dbName, nil)
+	eKey := constructLevelKey(h.dbName, nil)
+	eKey[len(eKey)-1] = lastKeyIndicator
+	return h.db.CompactRange(sKey, eKey)
+}
+
 // UpdateBatch encloses the details of multiple `updates`
 type UpdateBatch struct {
 	KVs map[string][]byte
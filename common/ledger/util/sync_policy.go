@@ -0,0 +1,115 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"sync"
+	"time"
+)
+
+// SyncPolicy determines when the writes of a store are flushed to the disk. A write that is not yet flushed
+// survives a crash of the process but may be lost by a crash of the operating system or by a power loss
+type SyncPolicy struct {
+	// Blocks is the number of the written blocks after which the writes are flushed. 1 flushes every block
+	Blocks int
+	// Interval is the duration after the first unflushed block at which the writes are flushed. The blocks
+	// written within the interval are flushed together by a single flush (a group commit)
+	Interval time.Duration
+}
+
+// IsDisabled returns true if the policy flushes the writes neither by the number of blocks nor by the time.
+// The writes are then flushed only by an explicit sync
+func (p SyncPolicy) IsDisabled() bool {
+	return p.Blocks <= 0 && p.Interval <= 0
+}
+
+// Syncer flushes the writes of a store according to a SyncPolicy
+type Syncer struct {
+	policy SyncPolicy
+	sync   func() error
+	lock   sync.Mutex
+	// pending is the number of the written blocks that are not flushed yet
+	pending int
+	// generation is incremented by every flush so that a timer scheduled before the flush is ignored
+	generation uint64
+	timer      *time.Timer
+	closed     bool
+}
+
+// NewSyncer constructs a Syncer that flushes the writes with the given function
+func NewSyncer(policy SyncPolicy, syncFunc func() error) *Syncer {
+	return &Syncer{policy: policy, sync: syncFunc}
+}
+
+// BlockWritten records a written block. The writes are flushed before returning once the number of
+// the unflushed blocks reaches the limit of the policy, otherwise a flush is scheduled at the end of the interval
+func (s *Syncer) BlockWritten() error {
+	if s.policy.IsDisabled() {
+		return nil
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.pending++
+	if s.policy.Blocks > 0 && s.pending >= s.policy.Blocks {
+		return s.syncPending()
+	}
+	if s.policy.Interval > 0 && s.timer == nil && !s.closed {
+		generation := s.generation
+		s.timer = time.AfterFunc(s.policy.Interval, func() { s.syncOnTimer(generation) })
+	}
+	return nil
+}
+
+// Sync flushes the writes irrespective of the policy
+func (s *Syncer) Sync() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.syncPending()
+}
+
+// Close flushes the writes and stops the scheduled flush
+func (s *Syncer) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.closed = true
+	return s.syncPending()
+}
+
+func (s *Syncer) syncOnTimer(generation uint64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if generation != s.generation {
+		return
+	}
+	s.timer = nil
+	if err := s.syncPending(); err != nil {
+		logger.Errorf("Error while flushing the writes: %s", err)
+	}
+}
+
+func (s *Syncer) syncPending() error {
+	if err := s.sync(); err != nil {
+		return err
+	}
+	s.pending = 0
+	s.generation++
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	return nil
+}
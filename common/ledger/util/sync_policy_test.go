@@ -0,0 +1,62 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+)
+
+func TestSyncerEveryNBlocks(t *testing.T) {
+	var syncs int32
+	syncer := NewSyncer(SyncPolicy{Blocks: 3}, func() error { atomic.AddInt32(&syncs, 1); return nil })
+	for i := 0; i < 7; i++ {
+		testutil.AssertNoError(t, syncer.BlockWritten(), "")
+	}
+	testutil.AssertEquals(t, atomic.LoadInt32(&syncs), int32(2))
+	testutil.AssertNoError(t, syncer.Close(), "")
+	testutil.AssertEquals(t, atomic.LoadInt32(&syncs), int32(3))
+}
+
+func TestSyncerInterval(t *testing.T) {
+	var syncs int32
+	syncer := NewSyncer(SyncPolicy{Interval: 50 * time.Millisecond}, func() error { atomic.AddInt32(&syncs, 1); return nil })
+	defer syncer.Close()
+	// the blocks written within the interval are flushed together
+	for i := 0; i < 5; i++ {
+		testutil.AssertNoError(t, syncer.BlockWritten(), "")
+	}
+	testutil.AssertEquals(t, atomic.LoadInt32(&syncs), int32(0))
+	time.Sleep(200 * time.Millisecond)
+	testutil.AssertEquals(t, atomic.LoadInt32(&syncs), int32(1))
+	// no flush is scheduled without a written block
+	time.Sleep(100 * time.Millisecond)
+	testutil.AssertEquals(t, atomic.LoadInt32(&syncs), int32(1))
+}
+
+func TestSyncerDisabled(t *testing.T) {
+	syncErr := fmt.Errorf("sync error")
+	var syncs int32
+	syncer := NewSyncer(SyncPolicy{}, func() error { atomic.AddInt32(&syncs, 1); return syncErr })
+	testutil.AssertNoError(t, syncer.BlockWritten(), "")
+	testutil.AssertEquals(t, atomic.LoadInt32(&syncs), int32(0))
+	testutil.AssertEquals(t, syncer.Sync(), syncErr)
+}
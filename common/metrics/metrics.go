@@ -0,0 +1,289 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics maintains the metrics of the peer and exposes them in the Prometheus text exposition format
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	logging "github.com/op/go-logging"
+)
+
+var logger = logging.MustGetLogger("metrics")
+
+const labelValuesSep = "\x00"
+
+var labelValueEscaper = strings.NewReplacer("\\", `\\`, "\"", `\"`, "\n", `\n`)
+
+// DefaultBuckets are the upper bounds, in seconds, of the buckets of the histograms that measure durations
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+var defaultRegistry = NewRegistry()
+
+// collector is a family of metrics that share a name and differ by the values of the labels
+type collector interface {
+	write(w *bufio.Writer)
+}
+
+// Registry holds the metrics exposed by a Handler
+type Registry struct {
+	lock       sync.Mutex
+	collectors map[string]collector
+}
+
+// NewRegistry constructs an empty Registry
+func NewRegistry() *Registry {
+	return &Registry{collectors: make(map[string]collector)}
+}
+
+func (r *Registry) register(name string, c collector) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if _, ok := r.collectors[name]; ok {
+		panic(fmt.Errorf("Metric [%s] is already registered", name))
+	}
+	r.collectors[name] = c
+}
+
+// Write writes all the metrics of the registry in the Prometheus text exposition format
+func (r *Registry) Write(w io.Writer) error {
+	r.lock.Lock()
+	var names []string
+	for name := range r.collectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var collectors []collector
+	for _, name := range names {
+		collectors = append(collectors, r.collectors[name])
+	}
+	r.lock.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, c := range collectors {
+		c.write(bw)
+	}
+	return bw.Flush()
+}
+
+// Handler returns an http handler that serves the metrics of the registry
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		if err := r.Write(w); err != nil {
+			logger.Warningf("Error while writing metrics: %s", err)
+		}
+	})
+}
+
+// Handler returns an http handler that serves the metrics registered with the default registry
+func Handler() http.Handler {
+	return defaultRegistry.Handler()
+}
+
+// desc describes a family of metrics
+type desc struct {
+	name       string
+	help       string
+	metricType string
+	labelNames []string
+}
+
+func (d *desc) key(labelValues []string) string {
+	if len(labelValues) != len(d.labelNames) {
+		panic(fmt.Errorf("Metric [%s] expects %d label values, found %d", d.name, len(d.labelNames), len(labelValues)))
+	}
+	return strings.Join(labelValues, labelValuesSep)
+}
+
+func (d *desc) writeHeader(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", d.name, d.help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", d.name, d.metricType)
+}
+
+// writeSample writes a sample of the metric. The extra label, if not empty, is appended to the labels of the metric
+func (d *desc) writeSample(w *bufio.Writer, suffix string, key string, extraLabel string, value float64) {
+	w.WriteString(d.name + suffix)
+	var labels []string
+	if len(d.labelNames) > 0 {
+		for i, labelValue := range strings.Split(key, labelValuesSep) {
+			labels = append(labels, d.labelNames[i]+"=\""+labelValueEscaper.Replace(labelValue)+"\"")
+		}
+	}
+	if extraLabel != "" {
+		labels = append(labels, extraLabel)
+	}
+	if len(labels) > 0 {
+		w.WriteString("{" + strings.Join(labels, ",") + "}")
+	}
+	w.WriteString(" " + formatFloat(value) + "\n")
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+}
+
+// CounterVec is a family of counters partitioned by the values of the labels
+type CounterVec struct {
+	desc
+	lock   sync.Mutex
+	values map[string]float64
+}
+
+// NewCounterVec constructs a CounterVec and registers it with the default registry
+func NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	c := &CounterVec{desc: desc{name, help, "counter", labelNames}, values: make(map[string]float64)}
+	defaultRegistry.register(name, c)
+	return c
+}
+
+// Add adds the given value, which must not be negative, to the counter with the given label values
+func (c *CounterVec) Add(value float64, labelValues ...string) {
+	if value < 0 {
+		panic(fmt.Errorf("Counter [%s] cannot be decreased", c.name))
+	}
+	key := c.key(labelValues)
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.values[key] += value
+}
+
+func (c *CounterVec) write(w *bufio.Writer) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.writeHeader(w)
+	var keys []string
+	for key := range c.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		c.writeSample(w, "", key, "", c.values[key])
+	}
+}
+
+// GaugeVec is a family of gauges partitioned by the values of the labels
+type GaugeVec struct {
+	desc
+	lock   sync.Mutex
+	values map[string]float64
+}
+
+// NewGaugeVec constructs a GaugeVec and registers it with the default registry
+func NewGaugeVec(name, help string, labelNames ...string) *GaugeVec {
+	g := &GaugeVec{desc: desc{name, help, "gauge", labelNames}, values: make(map[string]float64)}
+	defaultRegistry.register(name, g)
+	return g
+}
+
+// Set sets the gauge with the given label values to the given value
+func (g *GaugeVec) Set(value float64, labelValues ...string) {
+	key := g.key(labelValues)
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.values[key] = value
+}
+
+func (g *GaugeVec) write(w *bufio.Writer) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.writeHeader(w)
+	var keys []string
+	for key := range g.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		g.writeSample(w, "", key, "", g.values[key])
+	}
+}
+
+// HistogramVec is a family of histograms partitioned by the values of the labels
+type HistogramVec struct {
+	desc
+	buckets []float64
+	lock    sync.Mutex
+	values  map[string]*histogram
+}
+
+type histogram struct {
+	bucketCounts []uint64
+	count        uint64
+	sum          float64
+}
+
+// NewHistogramVec constructs a HistogramVec with the given bucket upper bounds, in increasing order,
+// and registers it with the default registry
+func NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	h := &HistogramVec{desc: desc{name, help, "histogram", labelNames}, buckets: buckets, values: make(map[string]*histogram)}
+	defaultRegistry.register(name, h)
+	return h
+}
+
+// Observe adds an observation to the histogram with the given label values
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	key := h.key(labelValues)
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	hist, ok := h.values[key]
+	if !ok {
+		hist = &histogram{bucketCounts: make([]uint64, len(h.buckets))}
+		h.values[key] = hist
+	}
+	for i, upperBound := range h.buckets {
+		if value <= upperBound {
+			hist.bucketCounts[i]++
+		}
+	}
+	hist.count++
+	hist.sum += value
+}
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.writeHeader(w)
+	var keys []string
+	for key := range h.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		hist := h.values[key]
+		for i, upperBound := range h.buckets {
+			h.writeSample(w, "_bucket", key, "le=\""+formatFloat(upperBound)+"\"", float64(hist.bucketCounts[i]))
+		}
+		h.writeSample(w, "_bucket", key, "le=\"+Inf\"", float64(hist.count))
+		h.writeSample(w, "_sum", key, "", hist.sum)
+		h.writeSample(w, "_count", key, "", float64(hist.count))
+	}
+}
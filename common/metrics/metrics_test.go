@@ -0,0 +1,70 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricsExposition(t *testing.T) {
+	counter := NewCounterVec("test_counter", "A test counter.", "channel", "code")
+	gauge := NewGaugeVec("test_gauge", "A test gauge.", "channel")
+	histogram := NewHistogramVec("test_histogram", "A test histogram.", []float64{0.1, 1}, "channel")
+
+	counter.Add(1, "ch1", "VALID")
+	counter.Add(2, "ch1", "VALID")
+	counter.Add(1, "ch\"2\"", "MVCC_READ_CONFLICT")
+	gauge.Set(10, "ch1")
+	gauge.Set(12, "ch1")
+	histogram.Observe(0.05, "ch1")
+	histogram.Observe(0.5, "ch1")
+	histogram.Observe(5, "ch1")
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, defaultRegistry.Write(buf))
+	assert.Equal(t, `# HELP test_counter A test counter.
+# TYPE test_counter counter
+test_counter{channel="ch\"2\"",code="MVCC_READ_CONFLICT"} 1
+test_counter{channel="ch1",code="VALID"} 3
+# HELP test_gauge A test gauge.
+# TYPE test_gauge gauge
+test_gauge{channel="ch1"} 12
+# HELP test_histogram A test histogram.
+# TYPE test_histogram histogram
+test_histogram_bucket{channel="ch1",le="0.1"} 1
+test_histogram_bucket{channel="ch1",le="1"} 2
+test_histogram_bucket{channel="ch1",le="+Inf"} 3
+test_histogram_sum{channel="ch1"} 5.55
+test_histogram_count{channel="ch1"} 3
+`, buf.String())
+
+	recorder := httptest.NewRecorder()
+	Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, 200, recorder.Code)
+	assert.Equal(t, buf.String(), recorder.Body.String())
+}
+
+func TestMetricsMisuse(t *testing.T) {
+	counter := NewCounterVec("test_misuse_counter", "A test counter.", "channel")
+	assert.Panics(t, func() { counter.Add(1) }, "Expected a panic for missing label values")
+	assert.Panics(t, func() { counter.Add(-1, "ch1") }, "Expected a panic for decreasing a counter")
+	assert.Panics(t, func() { NewGaugeVec("test_misuse_counter", "A duplicate metric.") }, "Expected a panic for a duplicate metric")
+}
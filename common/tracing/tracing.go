@@ -0,0 +1,204 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tracing records the spans of the endorsement and the commit paths of the peer. The trace context
+// is propagated across processes in the W3C traceparent format, as used by OpenTelemetry, so that the spans
+// of the peer can be joined with the spans of the clients. The finished spans are handed to a Reporter
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric/common/flogging"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/metadata"
+)
+
+var logger = flogging.MustGetFieldLogger("tracing")
+
+// TraceParentKey is the key of the gRPC metadata that carries the trace context
+const TraceParentKey = "traceparent"
+
+type spanContextKey struct{}
+
+// Reporter receives the finished spans
+type Reporter interface {
+	Report(span *Span)
+}
+
+var (
+	reporter     Reporter
+	reporterLock sync.RWMutex
+)
+
+// SetReporter sets the reporter of the finished spans. Tracing is disabled while the reporter is nil
+func SetReporter(r Reporter) {
+	reporterLock.Lock()
+	defer reporterLock.Unlock()
+	reporter = r
+}
+
+func getReporter() Reporter {
+	reporterLock.RLock()
+	defer reporterLock.RUnlock()
+	return reporter
+}
+
+// SpanContext identifies a span within a trace
+type SpanContext struct {
+	TraceID string
+	SpanID  string
+}
+
+// Span records the duration of an operation
+type Span struct {
+	SpanContext
+	ParentID string
+	Name     string
+	Start    time.Time
+	End      time.Time
+	Tags     map[string]string
+	reporter Reporter
+}
+
+// StartSpan starts a span as a child of the span carried by the given context, or as the root of a new trace
+// if the context carries none. The returned context carries the new span. A nil span is returned if tracing is
+// disabled, and the methods of a nil span do nothing so that the callers need not check for it
+func StartSpan(ctx context.Context, name string) (*Span, context.Context) {
+	r := getReporter()
+	if r == nil {
+		return nil, ctx
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	span := &Span{Name: name, Start: time.Now(), Tags: make(map[string]string), reporter: r}
+	span.SpanID = newID(8)
+	if parent, ok := SpanContextFromContext(ctx); ok {
+		span.TraceID = parent.TraceID
+		span.ParentID = parent.SpanID
+	} else {
+		span.TraceID = newID(16)
+	}
+	return span, ContextWithSpanContext(ctx, span.SpanContext)
+}
+
+// SetTag attaches a tag to the span
+func (s *Span) SetTag(key, value string) {
+	if s == nil {
+		return
+	}
+	s.Tags[key] = value
+}
+
+// Finish ends the span and reports it
+func (s *Span) Finish() {
+	if s == nil {
+		return
+	}
+	s.End = time.Now()
+	s.reporter.Report(s)
+}
+
+// SpanContextFromContext returns the span context carried by the given context
+func SpanContextFromContext(ctx context.Context) (SpanContext, bool) {
+	if ctx == nil {
+		return SpanContext{}, false
+	}
+	sc, ok := ctx.Value(spanContextKey{}).(SpanContext)
+	return sc, ok
+}
+
+// ContextWithSpanContext returns a context that carries the given span context
+func ContextWithSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, sc)
+}
+
+// ExtractFromGRPC returns a context that carries the span context received in the metadata of a gRPC request,
+// so that the spans started for the request join the trace of the caller
+func ExtractFromGRPC(ctx context.Context) context.Context {
+	md, ok := metadata.FromContext(ctx)
+	if !ok || len(md[TraceParentKey]) == 0 {
+		return ctx
+	}
+	sc, err := ParseTraceParent(md[TraceParentKey][0])
+	if err != nil {
+		logger.Debugf("Ignoring the trace context of the request: %s", err)
+		return ctx
+	}
+	return ContextWithSpanContext(ctx, sc)
+}
+
+// InjectIntoGRPC returns a context whose outgoing gRPC metadata carries the span context of the given context
+func InjectIntoGRPC(ctx context.Context) context.Context {
+	sc, ok := SpanContextFromContext(ctx)
+	if !ok {
+		return ctx
+	}
+	return metadata.NewContext(ctx, metadata.Pairs(TraceParentKey, FormatTraceParent(sc)))
+}
+
+// FormatTraceParent encodes the span context in the W3C traceparent format
+func FormatTraceParent(sc SpanContext) string {
+	return fmt.Sprintf("00-%s-%s-01", sc.TraceID, sc.SpanID)
+}
+
+// ParseTraceParent decodes a span context encoded in the W3C traceparent format
+func ParseTraceParent(traceParent string) (SpanContext, error) {
+	parts := strings.Split(traceParent, "-")
+	if len(parts) != 4 || len(parts[1]) != 32 || len(parts[2]) != 16 || !isHex(parts[1]) || !isHex(parts[2]) {
+		return SpanContext{}, fmt.Errorf("Malformed traceparent [%s]", traceParent)
+	}
+	return SpanContext{TraceID: parts[1], SpanID: parts[2]}, nil
+}
+
+func isHex(s string) bool {
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+func newID(numBytes int) string {
+	id := make([]byte, numBytes)
+	if _, err := rand.Read(id); err != nil {
+		panic(fmt.Errorf("Error while generating a span id: %s", err))
+	}
+	return hex.EncodeToString(id)
+}
+
+// LogReporter reports the finished spans to the log of the module "tracing" at the info level,
+// with the ids, the duration, and the tags of a span as structured fields
+type LogReporter struct{}
+
+// Report implements method in interface Reporter
+func (r *LogReporter) Report(span *Span) {
+	fields := flogging.Fields{
+		"traceID":    span.TraceID,
+		"spanID":     span.SpanID,
+		"durationMs": float64(span.End.Sub(span.Start).Nanoseconds()) / 1e6,
+	}
+	if span.ParentID != "" {
+		fields["parentID"] = span.ParentID
+	}
+	for k, v := range span.Tags {
+		fields[k] = v
+	}
+	logger.With(fields).Info(span.Name)
+}
@@ -0,0 +1,105 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/metadata"
+)
+
+type recordingReporter struct {
+	lock  sync.Mutex
+	spans []*Span
+}
+
+func (r *recordingReporter) Report(span *Span) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.spans = append(r.spans, span)
+}
+
+func TestStartSpanDisabled(t *testing.T) {
+	SetReporter(nil)
+	span, ctx := StartSpan(context.Background(), "op")
+	assert.Nil(t, span)
+	_, ok := SpanContextFromContext(ctx)
+	assert.False(t, ok)
+	// the methods of a nil span are no-ops
+	span.SetTag("key", "value")
+	span.Finish()
+}
+
+func TestParentAndChildSpans(t *testing.T) {
+	r := &recordingReporter{}
+	SetReporter(r)
+	defer SetReporter(nil)
+
+	parent, ctx := StartSpan(nil, "parent")
+	parent.SetTag("channel", "ch1")
+	child, _ := StartSpan(ctx, "child")
+	child.Finish()
+	parent.Finish()
+
+	assert.Len(t, r.spans, 2)
+	assert.Equal(t, "child", r.spans[0].Name)
+	assert.Equal(t, "parent", r.spans[1].Name)
+	assert.Equal(t, parent.TraceID, child.TraceID)
+	assert.Equal(t, parent.SpanID, child.ParentID)
+	assert.Empty(t, parent.ParentID)
+	assert.Len(t, parent.TraceID, 32)
+	assert.Len(t, parent.SpanID, 16)
+	assert.Equal(t, "ch1", parent.Tags["channel"])
+	assert.False(t, parent.End.Before(parent.Start))
+}
+
+func TestTraceParent(t *testing.T) {
+	sc := SpanContext{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", SpanID: "00f067aa0ba902b7"}
+	traceParent := FormatTraceParent(sc)
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", traceParent)
+	parsed, err := ParseTraceParent(traceParent)
+	assert.NoError(t, err)
+	assert.Equal(t, sc, parsed)
+
+	for _, malformed := range []string{"", "00-abc-def-01", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902bz-01"} {
+		_, err := ParseTraceParent(malformed)
+		assert.Error(t, err, "traceparent [%s] should be rejected", malformed)
+	}
+}
+
+func TestGRPCPropagation(t *testing.T) {
+	r := &recordingReporter{}
+	SetReporter(r)
+	defer SetReporter(nil)
+
+	clientSpan, clientCtx := StartSpan(context.Background(), "client")
+	md, ok := metadata.FromContext(InjectIntoGRPC(clientCtx))
+	assert.True(t, ok)
+
+	serverCtx := ExtractFromGRPC(metadata.NewContext(context.Background(), md))
+	serverSpan, _ := StartSpan(serverCtx, "server")
+	assert.Equal(t, clientSpan.TraceID, serverSpan.TraceID)
+	assert.Equal(t, clientSpan.SpanID, serverSpan.ParentID)
+
+	// a malformed trace context starts a new trace
+	badCtx := ExtractFromGRPC(metadata.NewContext(context.Background(), metadata.Pairs(TraceParentKey, "bad")))
+	_, ok = SpanContextFromContext(badCtx)
+	assert.False(t, ok)
+}
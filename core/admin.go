@@ -19,13 +19,19 @@ package core
 import (
 	"os"
 	"runtime"
+	"sort"
+	"time"
 
 	"github.com/op/go-logging"
 	"github.com/spf13/viper"
 	"golang.org/x/net/context"
+	"google.golang.org/grpc"
 
 	"github.com/golang/protobuf/ptypes/empty"
 	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+	"github.com/hyperledger/fabric/core/ledger/ledgermgmt"
 	pb "github.com/hyperledger/fabric/protos/peer"
 )
 
@@ -54,9 +60,12 @@ func worker(id int, die chan struct{}) {
 	}
 }
 
-// GetStatus reports the status of the server
+// GetStatus reports the status of the server. The server in maintenance mode is reported as paused
 func (*ServerAdmin) GetStatus(context.Context, *empty.Empty) (*pb.ServerStatus, error) {
 	status := &pb.ServerStatus{Status: pb.ServerStatus_STARTED}
+	if ledgermgmt.IsInMaintenanceMode() {
+		status.Status = pb.ServerStatus_PAUSED
+	}
 	log.Debugf("returning status: %s", status)
 	return status, nil
 }
@@ -95,3 +104,250 @@ func (*ServerAdmin) SetModuleLogLevel(ctx context.Context, request *pb.LogLevelR
 
 	return logResponse, err
 }
+
+// EnterMaintenanceMode disables commits and endorsements while keeping the channels loaded
+func (*ServerAdmin) EnterMaintenanceMode(context.Context, *empty.Empty) (*pb.ServerStatus, error) {
+	ledgermgmt.EnterMaintenanceMode()
+	status := &pb.ServerStatus{Status: pb.ServerStatus_PAUSED}
+	log.Debugf("returning status: %s", status)
+	return status, nil
+}
+
+// ExitMaintenanceMode enables commits and endorsements again
+func (*ServerAdmin) ExitMaintenanceMode(context.Context, *empty.Empty) (*pb.ServerStatus, error) {
+	ledgermgmt.ExitMaintenanceMode()
+	status := &pb.ServerStatus{Status: pb.ServerStatus_STARTED}
+	log.Debugf("returning status: %s", status)
+	return status, nil
+}
+
+// QuiesceCommits blocks the commits and flushes the ledgers of all the channels to the disk. The heights
+// of the stores of each ledger are returned so that a backup tool can record the heights of the backup
+func (*ServerAdmin) QuiesceCommits(ctx context.Context, request *pb.QuiesceCommitsRequest) (*pb.QuiesceCommitsResponse, error) {
+	timeout := ledgerconfig.GetQuiesceTimeout()
+	if request.TimeoutSeconds > 0 {
+		timeout = time.Duration(request.TimeoutSeconds) * time.Second
+	}
+	heights, err := ledgermgmt.QuiesceCommits(timeout)
+	if err != nil {
+		return nil, grpc.Errorf(ledger.GRPCCode(err), "Failed to quiesce the commits: %s", err)
+	}
+	channelIDs := make([]string, 0, len(heights))
+	for channelID := range heights {
+		channelIDs = append(channelIDs, channelID)
+	}
+	sort.Strings(channelIDs)
+	response := &pb.QuiesceCommitsResponse{}
+	for _, channelID := range channelIDs {
+		response.Ledgers = append(response.Ledgers, &pb.LedgerHeights{
+			ChannelId:        channelID,
+			BlockStoreHeight: heights[channelID].BlockStore,
+			StateDbHeight:    heights[channelID].StateDB,
+			HistoryDbHeight:  heights[channelID].HistoryDB,
+		})
+	}
+	return response, nil
+}
+
+// ReleaseCommits resumes the commits that are blocked by QuiesceCommits
+func (*ServerAdmin) ReleaseCommits(context.Context, *empty.Empty) (*pb.ServerStatus, error) {
+	if err := ledgermgmt.ReleaseCommits(); err != nil {
+		return nil, grpc.Errorf(ledger.GRPCCode(err), "Failed to release the commits: %s", err)
+	}
+	status := &pb.ServerStatus{Status: pb.ServerStatus_STARTED}
+	log.Debugf("returning status: %s", status)
+	return status, nil
+}
+
+// GetLedgerDiskUsage returns the number of bytes that the ledger of the given channel occupies on the disk,
+// or those of the ledgers of all the channels if no channel is given
+func (*ServerAdmin) GetLedgerDiskUsage(ctx context.Context, request *pb.LedgerDiskUsageRequest) (*pb.LedgerDiskUsageResponse, error) {
+	channelIDs := []string{request.ChannelId}
+	if request.ChannelId == "" {
+		var err error
+		if channelIDs, err = ledgermgmt.GetLedgerIDs(); err != nil {
+			return nil, grpc.Errorf(ledger.GRPCCode(err), "Failed to list the ledgers: %s", err)
+		}
+	}
+	response := &pb.LedgerDiskUsageResponse{}
+	for _, channelID := range channelIDs {
+		diskUsage, err := ledgermgmt.GetDiskUsage(channelID)
+		if err != nil {
+			return nil, grpc.Errorf(ledger.GRPCCode(err), "Failed to compute the disk usage of the ledger [%s]: %s", channelID, err)
+		}
+		response.Ledgers = append(response.Ledgers, &pb.LedgerDiskUsage{
+			ChannelId:       channelID,
+			BlockStoreBytes: diskUsage.BlockStoreBytes,
+			StateDbBytes:    diskUsage.StateDBBytes,
+			HistoryDbBytes:  diskUsage.HistoryDBBytes,
+		})
+	}
+	log.Debugf("returning disk usage: %s", response)
+	return response, nil
+}
+
+// GetBlockFingerprint returns the hash and the commit hash of a block of the ledger of the given channel
+func (*ServerAdmin) GetBlockFingerprint(ctx context.Context, request *pb.BlockFingerprintRequest) (*pb.BlockFingerprint, error) {
+	fingerprint, err := ledgermgmt.GetBlockFingerprint(request.ChannelId, request.BlockNumber)
+	if err != nil {
+		return nil, grpc.Errorf(ledger.GRPCCode(err), "Failed to get the fingerprint of block [%d] of the ledger [%s]: %s", request.BlockNumber, request.ChannelId, err)
+	}
+	return &pb.BlockFingerprint{BlockNumber: fingerprint.BlockNumber, BlockHash: fingerprint.BlockHash, CommitHash: fingerprint.CommitHash}, nil
+}
+
+// GetStateFingerprint returns the hashes of the contents of the state database of the given channel per namespace
+func (*ServerAdmin) GetStateFingerprint(ctx context.Context, request *pb.StateFingerprintRequest) (*pb.StateFingerprint, error) {
+	fingerprint, err := ledgermgmt.GetStateFingerprint(request.ChannelId)
+	if err != nil {
+		return nil, grpc.Errorf(ledger.GRPCCode(err), "Failed to get the state fingerprint of the ledger [%s]: %s", request.ChannelId, err)
+	}
+	response := &pb.StateFingerprint{Height: fingerprint.Height}
+	namespaces := make([]string, 0, len(fingerprint.NamespaceHashes))
+	for namespace := range fingerprint.NamespaceHashes {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+	for _, namespace := range namespaces {
+		response.NamespaceHashes = append(response.NamespaceHashes, &pb.NamespaceHash{Namespace: namespace, Hash: fingerprint.NamespaceHashes[namespace]})
+	}
+	return response, nil
+}
+
+// WarmUpState reads the keys most recently written to the state database of the given channel, or of all the channels
+// if no channel is given, into the caches of the state database
+func (*ServerAdmin) WarmUpState(ctx context.Context, request *pb.StateWarmUpRequest) (*pb.StateWarmUpResponse, error) {
+	channelIDs := []string{request.ChannelId}
+	if request.ChannelId == "" {
+		var err error
+		if channelIDs, err = ledgermgmt.GetLedgerIDs(); err != nil {
+			return nil, grpc.Errorf(ledger.GRPCCode(err), "Failed to list the ledgers: %s", err)
+		}
+	}
+	response := &pb.StateWarmUpResponse{}
+	for _, channelID := range channelIDs {
+		keysLoaded, err := ledgermgmt.WarmUpState(channelID, int(request.KeysPerNamespace))
+		if err != nil {
+			return nil, grpc.Errorf(ledger.GRPCCode(err), "Failed to warm up the state database of the ledger [%s]: %s", channelID, err)
+		}
+		response.Ledgers = append(response.Ledgers, &pb.LedgerWarmUp{ChannelId: channelID, KeysLoaded: keysLoaded})
+	}
+	log.Debugf("returning state warm-up: %s", response)
+	return response, nil
+}
+
+// GetNamespaceStats returns the number of the operations on the state per namespace of the given channel, or of all
+// the channels if no channel is given
+func (*ServerAdmin) GetNamespaceStats(ctx context.Context, request *pb.NamespaceStatsRequest) (*pb.NamespaceStatsResponse, error) {
+	channelIDs := []string{request.ChannelId}
+	if request.ChannelId == "" {
+		var err error
+		if channelIDs, err = ledgermgmt.GetLedgerIDs(); err != nil {
+			return nil, grpc.Errorf(ledger.GRPCCode(err), "Failed to list the ledgers: %s", err)
+		}
+	}
+	response := &pb.NamespaceStatsResponse{}
+	for _, channelID := range channelIDs {
+		stats, err := ledgermgmt.GetNamespaceStats(channelID)
+		if err != nil {
+			return nil, grpc.Errorf(ledger.GRPCCode(err), "Failed to get the namespace statistics of the ledger [%s]: %s", channelID, err)
+		}
+		ledgerStats := &pb.LedgerNamespaceStats{ChannelId: channelID}
+		for _, nsStats := range stats {
+			ledgerStats.Namespaces = append(ledgerStats.Namespaces, &pb.NamespaceStats{
+				Namespace:    nsStats.Namespace,
+				Blocks:       nsStats.Blocks,
+				Reads:        nsStats.Reads,
+				RangeQueries: nsStats.RangeQueries,
+				RichQueries:  nsStats.RichQueries,
+				Writes:       nsStats.Writes,
+				Deletes:      nsStats.Deletes,
+				WrittenBytes: nsStats.WrittenBytes,
+			})
+		}
+		response.Ledgers = append(response.Ledgers, ledgerStats)
+	}
+	log.Debugf("returning namespace statistics: %s", response)
+	return response, nil
+}
+
+// CompactLedger compacts the block index and the history database of the ledger of the given channel, or of all the
+// channels if no channel is given, one ledger after the other
+func (*ServerAdmin) CompactLedger(ctx context.Context, request *pb.LedgerCompactionRequest) (*pb.LedgerCompactionResponse, error) {
+	channelIDs := []string{request.ChannelId}
+	if request.ChannelId == "" {
+		var err error
+		if channelIDs, err = ledgermgmt.GetLedgerIDs(); err != nil {
+			return nil, grpc.Errorf(ledger.GRPCCode(err), "Failed to list the ledgers: %s", err)
+		}
+	}
+	response := &pb.LedgerCompactionResponse{}
+	for _, channelID := range channelIDs {
+		startTime := time.Now()
+		if err := ledgermgmt.CompactLedger(channelID); err != nil {
+			return nil, grpc.Errorf(ledger.GRPCCode(err), "Failed to compact the ledger [%s]: %s", channelID, err)
+		}
+		durationMillis := uint64(time.Since(startTime) / time.Millisecond)
+		response.Ledgers = append(response.Ledgers, &pb.LedgerCompaction{ChannelId: channelID, DurationMillis: durationMillis})
+	}
+	log.Debugf("returning ledger compaction: %s", response)
+	return response, nil
+}
+
+// SnapshotSystemState generates a snapshot of the system namespaces of the state of the ledger of the given channel.
+// If no channel is given, the snapshots are generated for all the channels that keep the system namespaces in the
+// system state database
+func (*ServerAdmin) SnapshotSystemState(ctx context.Context, request *pb.SystemStateSnapshotRequest) (*pb.SystemStateSnapshotResponse, error) {
+	channelIDs := []string{request.ChannelId}
+	if request.ChannelId == "" {
+		var err error
+		if channelIDs, err = ledgermgmt.GetLedgerIDs(); err != nil {
+			return nil, grpc.Errorf(ledger.GRPCCode(err), "Failed to list the ledgers: %s", err)
+		}
+	}
+	response := &pb.SystemStateSnapshotResponse{}
+	for _, channelID := range channelIDs {
+		dir, blockNum, err := ledgermgmt.SnapshotSystemState(channelID)
+		if _, ok := err.(*ledger.NotEnabledError); ok && request.ChannelId == "" {
+			continue
+		}
+		if err != nil {
+			return nil, grpc.Errorf(ledger.GRPCCode(err), "Failed to snapshot the system state of the ledger [%s]: %s", channelID, err)
+		}
+		response.Snapshots = append(response.Snapshots, &pb.SystemStateSnapshot{ChannelId: channelID, BlockNumber: blockNum, Directory: dir})
+	}
+	log.Debugf("returning system state snapshots: %s", response)
+	return response, nil
+}
+
+// GetInvalidTransactions returns the most recent transactions invalidated at commit of the ledger of the given channel,
+// or of all the channels if no channel is given
+func (*ServerAdmin) GetInvalidTransactions(ctx context.Context, request *pb.InvalidTransactionsRequest) (*pb.InvalidTransactionsResponse, error) {
+	channelIDs := []string{request.ChannelId}
+	if request.ChannelId == "" {
+		var err error
+		if channelIDs, err = ledgermgmt.GetLedgerIDs(); err != nil {
+			return nil, grpc.Errorf(ledger.GRPCCode(err), "Failed to list the ledgers: %s", err)
+		}
+	}
+	response := &pb.InvalidTransactionsResponse{}
+	for _, channelID := range channelIDs {
+		invalidTxs, err := ledgermgmt.GetInvalidTransactions(channelID, int(request.MaxEntries))
+		if err != nil {
+			return nil, grpc.Errorf(ledger.GRPCCode(err), "Failed to get the invalid transactions of the ledger [%s]: %s", channelID, err)
+		}
+		ledgerInvalidTxs := &pb.LedgerInvalidTransactions{ChannelId: channelID}
+		for _, invalidTx := range invalidTxs {
+			ledgerInvalidTxs.Transactions = append(ledgerInvalidTxs.Transactions, &pb.InvalidTransaction{
+				TxId:           invalidTx.TxID,
+				BlockNum:       invalidTx.BlockNum,
+				TxNum:          invalidTx.TxNum,
+				ValidationCode: invalidTx.ValidationCode.String(),
+				CreatorMspId:   invalidTx.CreatorMSPID,
+				Chaincode:      invalidTx.Chaincode,
+			})
+		}
+		response.Ledgers = append(response.Ledgers, ledgerInvalidTxs)
+	}
+	log.Debugf("returning invalid transactions: %s", response)
+	return response, nil
+}
@@ -0,0 +1,229 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cdc
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"time"
+
+	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+	logging "github.com/op/go-logging"
+)
+
+var logger = logging.MustGetLogger("cdc")
+
+// defaultRetryInterval is used if the configured retry interval is not positive
+const defaultRetryInterval = 5 * time.Second
+
+var cursorKey = []byte{0x00}
+var queueKeyPrefix = []byte{0x01}
+var queueKeyEnd = []byte{0x02}
+
+// ChangeBatch carries the writes of the valid transactions of a block. The batches of a channel are
+// published in the order of the blocks. As the delivery is at-least-once, a batch may be published
+// more than once and the consumers are expected to deduplicate the batches by the channel and the block number
+type ChangeBatch struct {
+	ChannelID   string    `json:"channelID"`
+	BlockNumber uint64    `json:"blockNumber"`
+	Changes     []*Change `json:"changes"`
+}
+
+// Change is a write of a key. TxNum is the position of the writing transaction in the block
+type Change struct {
+	Namespace string `json:"namespace"`
+	Key       string `json:"key"`
+	Value     []byte `json:"value,omitempty"`
+	IsDelete  bool   `json:"isDelete"`
+	TxID      string `json:"txID"`
+	TxNum     uint64 `json:"txNum"`
+}
+
+// Provider captures the writes committed to the ledgers and publishes them to a sink. It is
+// registered with the ledger as a commit decorator, which delivers the valid writes of each block.
+// The writes are first appended to a persistent queue so that the commit does not wait for the sink,
+// and a publisher per ledger drains the queue to the sink, retrying until the sink accepts a batch.
+// The cursor of the publisher, i.e., the last published block, is persisted along with the removal
+// of the batch from the queue, hence a batch is published at least once
+type Provider struct {
+	dbProvider    *leveldbhelper.Provider
+	sink          Sink
+	retryInterval time.Duration
+}
+
+// NewProvider constructs a Provider that publishes to the given sink and retries a failed publication after the given interval
+func NewProvider(sink Sink, retryInterval time.Duration) *Provider {
+	dbPath := ledgerconfig.GetChangeDataCapturePath()
+	logger.Debugf("constructing change data capture Provider dbPath=%s", dbPath)
+	if retryInterval <= 0 {
+		retryInterval = defaultRetryInterval
+	}
+	return &Provider{leveldbhelper.NewProvider(&leveldbhelper.Conf{DBPath: dbPath}), sink, retryInterval}
+}
+
+// Name implements method in interface ledger.CommitDecoratorProvider
+func (p *Provider) Name() string {
+	return "changeDataCapture"
+}
+
+// NewDecorator implements method in interface ledger.CommitDecoratorProvider. The publisher of the ledger is started
+func (p *Provider) NewDecorator(ledgerID string) (ledger.CommitDecorator, error) {
+	pub := &publisher{
+		ledgerID:      ledgerID,
+		db:            p.dbProvider.GetDBHandle(ledgerID),
+		sink:          p.sink,
+		retryInterval: p.retryInterval,
+		notify:        make(chan struct{}, 1),
+		done:          make(chan struct{}),
+		stopped:       make(chan struct{}),
+	}
+	go pub.run()
+	return pub, nil
+}
+
+// Drop implements method in interface ledger.CommitDecoratorProvider. The queue and the cursor of the ledger
+// are removed, hence all the blocks delivered again by the ledger are published again
+func (p *Provider) Drop(ledgerID string) error {
+	return p.dbProvider.GetDBHandle(ledgerID).DeleteAll()
+}
+
+// Close closes the sink and the underlying db. The decorators of the ledgers should be closed before
+func (p *Provider) Close() {
+	p.sink.Close()
+	p.dbProvider.Close()
+}
+
+// publisher queues the writes of the blocks of a ledger and publishes them to the sink in the background
+type publisher struct {
+	ledgerID      string
+	db            *leveldbhelper.DBHandle
+	sink          Sink
+	retryInterval time.Duration
+	notify        chan struct{}
+	done          chan struct{}
+	stopped       chan struct{}
+}
+
+// HandleCommit implements method in interface ledger.CommitDecorator. A block that is already published,
+// as the ledger redelivers the blocks after a crash, is ignored. A block without writes is not published
+func (pub *publisher) HandleCommit(event *ledger.CommitEvent) error {
+	cursor, err := pub.getCursor()
+	if err != nil {
+		return err
+	}
+	if cursor != nil && event.BlockNumber <= *cursor {
+		return nil
+	}
+	batch := &ChangeBatch{ChannelID: event.LedgerID, BlockNumber: event.BlockNumber}
+	for _, txWriteSet := range event.TxWriteSets {
+		for _, nsWrites := range txWriteSet.NsWrites {
+			for _, kv := range nsWrites.Writes {
+				batch.Changes = append(batch.Changes, &Change{Namespace: nsWrites.Namespace, Key: kv.Key, Value: kv.Value,
+					IsDelete: kv.Value == nil, TxID: txWriteSet.TxID, TxNum: txWriteSet.TxNum})
+			}
+		}
+	}
+	if len(batch.Changes) == 0 {
+		return nil
+	}
+	batchBytes, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+	if err := pub.db.Put(encodeQueueKey(event.BlockNumber), batchBytes, true); err != nil {
+		return err
+	}
+	select {
+	case pub.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Close implements method in interface ledger.CommitDecorator. The publisher is stopped and the
+// batches that are not published yet remain in the queue until the ledger is opened again
+func (pub *publisher) Close() {
+	close(pub.done)
+	<-pub.stopped
+}
+
+func (pub *publisher) run() {
+	defer close(pub.stopped)
+	for {
+		wait := pub.notify
+		if err := pub.publishQueued(); err != nil {
+			logger.Warningf("Channel [%s]: Error publishing the changes, retrying in %s: %s", pub.ledgerID, pub.retryInterval, err)
+			wait = nil
+		}
+		select {
+		case <-pub.done:
+			return
+		case <-wait:
+		case <-time.After(pub.retryInterval):
+		}
+	}
+}
+
+// publishQueued publishes the queued batches in the order of the blocks and advances the cursor after each batch
+func (pub *publisher) publishQueued() error {
+	itr := pub.db.GetIterator(queueKeyPrefix, queueKeyEnd)
+	defer itr.Release()
+	for itr.Next() {
+		select {
+		case <-pub.done:
+			return nil
+		default:
+		}
+		batch := &ChangeBatch{}
+		if err := json.Unmarshal(itr.Value(), batch); err != nil {
+			return err
+		}
+		if err := pub.sink.Publish(batch); err != nil {
+			return err
+		}
+		logger.Debugf("Channel [%s]: Published [%d] changes of block [%d]", pub.ledgerID, len(batch.Changes), batch.BlockNumber)
+		updates := leveldbhelper.NewUpdateBatch()
+		updates.Delete(encodeQueueKey(batch.BlockNumber))
+		updates.Put(cursorKey, encodeBlockNum(batch.BlockNumber))
+		if err := pub.db.WriteBatch(updates, true); err != nil {
+			return err
+		}
+	}
+	return itr.Error()
+}
+
+// getCursor returns the number of the last published block, or nil if no block is published yet
+func (pub *publisher) getCursor() (*uint64, error) {
+	cursorBytes, err := pub.db.Get(cursorKey)
+	if err != nil || cursorBytes == nil {
+		return nil, err
+	}
+	cursor := binary.BigEndian.Uint64(cursorBytes)
+	return &cursor, nil
+}
+
+func encodeQueueKey(blockNum uint64) []byte {
+	return append(append([]byte{}, queueKeyPrefix...), encodeBlockNum(blockNum)...)
+}
+
+func encodeBlockNum(blockNum uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, blockNum)
+	return b
+}
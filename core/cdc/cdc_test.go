@@ -0,0 +1,124 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cdc
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+	"github.com/spf13/viper"
+)
+
+func TestMain(m *testing.M) {
+	viper.Set("peer.fileSystemPath", "/tmp/fabric/cdctests")
+	os.Exit(m.Run())
+}
+
+func TestPublishAtLeastOnce(t *testing.T) {
+	os.RemoveAll(ledgerconfig.GetRootPath())
+	defer os.RemoveAll(ledgerconfig.GetRootPath())
+	sink := &mockSink{err: fmt.Errorf("sink unavailable")}
+	provider := NewProvider(sink, 10*time.Millisecond)
+	defer provider.Close()
+	decorator, _ := provider.NewDecorator("testLedger")
+
+	// the commit is not blocked by an unavailable sink and the batches are retried in order
+	testutil.AssertNoError(t, decorator.HandleCommit(newTestEvent(0, "key1", []byte("value1"))), "")
+	testutil.AssertNoError(t, decorator.HandleCommit(&ledger.CommitEvent{LedgerID: "testLedger", BlockNumber: 1}), "")
+	testutil.AssertNoError(t, decorator.HandleCommit(newTestEvent(2, "key1", nil)), "")
+	sink.setError(nil)
+	batches := sink.waitForBatches(t, 2)
+	testutil.AssertEquals(t, batches[0], &ChangeBatch{ChannelID: "testLedger", BlockNumber: 0,
+		Changes: []*Change{{Namespace: "ns", Key: "key1", Value: []byte("value1"), TxID: "tx0", TxNum: 1}}})
+	testutil.AssertEquals(t, batches[1].BlockNumber, uint64(2))
+	testutil.AssertEquals(t, batches[1].Changes[0].IsDelete, true)
+
+	// the blocks redelivered by the ledger up to the cursor are not published again
+	testutil.AssertNoError(t, decorator.HandleCommit(newTestEvent(2, "key1", nil)), "")
+	testutil.AssertNoError(t, decorator.HandleCommit(newTestEvent(3, "key2", []byte("value2"))), "")
+	batches = sink.waitForBatches(t, 3)
+	testutil.AssertEquals(t, batches[2].BlockNumber, uint64(3))
+
+	// the queued batches are published once the ledger is opened again
+	sink.setError(fmt.Errorf("sink unavailable"))
+	testutil.AssertNoError(t, decorator.HandleCommit(newTestEvent(4, "key3", []byte("value3"))), "")
+	decorator.Close()
+	sink.setError(nil)
+	decorator, _ = provider.NewDecorator("testLedger")
+	defer decorator.Close()
+	batches = sink.waitForBatches(t, 4)
+	testutil.AssertEquals(t, batches[3].BlockNumber, uint64(4))
+
+	cursor, err := decorator.(*publisher).getCursor()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, *cursor, uint64(4))
+	testutil.AssertNoError(t, provider.Drop("testLedger"), "")
+	cursor, _ = decorator.(*publisher).getCursor()
+	testutil.AssertNil(t, cursor)
+}
+
+func newTestEvent(blockNum uint64, key string, value []byte) *ledger.CommitEvent {
+	return &ledger.CommitEvent{LedgerID: "testLedger", BlockNumber: blockNum, TxWriteSets: []*ledger.TxWriteSet{
+		{TxID: fmt.Sprintf("tx%d", blockNum), TxNum: 1, NsWrites: []*ledger.NsWrites{
+			{Namespace: "ns", Writes: []*ledger.KV{{Key: key, Value: value}}}}}}}
+}
+
+type mockSink struct {
+	lock    sync.Mutex
+	err     error
+	batches []*ChangeBatch
+}
+
+func (s *mockSink) Publish(batch *ChangeBatch) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.batches = append(s.batches, batch)
+	return nil
+}
+
+func (s *mockSink) Close() {
+}
+
+func (s *mockSink) setError(err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.err = err
+}
+
+func (s *mockSink) waitForBatches(t *testing.T, n int) []*ChangeBatch {
+	for i := 0; i < 200; i++ {
+		s.lock.Lock()
+		batches := append([]*ChangeBatch{}, s.batches...)
+		s.lock.Unlock()
+		if len(batches) >= n {
+			testutil.AssertEquals(t, len(batches), n)
+			return batches
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("Timed out waiting for [%d] batches", n)
+	return nil
+}
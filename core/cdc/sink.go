@@ -0,0 +1,221 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cdc
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/spf13/viper"
+)
+
+// Sink receives the batches of changes. Publish returns once the sink has accepted the batch,
+// and an error otherwise, upon which the batch is published again
+type Sink interface {
+	Publish(batch *ChangeBatch) error
+	Close()
+}
+
+// NewSinkFromConfig constructs the sink configured by ledger.cdc.sink, one of webhook, kafka, or nats
+func NewSinkFromConfig() (Sink, error) {
+	sinkType := viper.GetString("ledger.cdc.sink")
+	switch sinkType {
+	case "webhook":
+		return NewWebhookSink(viper.GetString("ledger.cdc.webhook.url"), viper.GetDuration("ledger.cdc.webhook.timeout")), nil
+	case "kafka":
+		return NewKafkaSink(viper.GetStringSlice("ledger.cdc.kafka.brokers"), viper.GetString("ledger.cdc.kafka.topic"))
+	case "nats":
+		return NewNATSSink(viper.GetString("ledger.cdc.nats.address"), viper.GetString("ledger.cdc.nats.subject"), viper.GetDuration("ledger.cdc.nats.timeout")), nil
+	default:
+		return nil, fmt.Errorf("Unsupported change data capture sink [%s]", sinkType)
+	}
+}
+
+// webhookSink posts each batch as JSON to a URL. A batch is accepted if the response status is 2xx
+type webhookSink struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookSink constructs a Sink that posts the batches to the given URL
+func NewWebhookSink(url string, timeout time.Duration) Sink {
+	return &webhookSink{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+func (s *webhookSink) Publish(batch *ChangeBatch) error {
+	batchBytes, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Post(s.url, "application/json", bytes.NewReader(batchBytes))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("Webhook [%s] responded with status [%s]", s.url, resp.Status)
+	}
+	return nil
+}
+
+func (s *webhookSink) Close() {
+}
+
+// kafkaSink produces each batch as a JSON message to a topic. The messages are keyed by the channel
+// so that the batches of a channel are produced to the same partition and are consumed in order
+type kafkaSink struct {
+	topic    string
+	producer sarama.SyncProducer
+}
+
+// NewKafkaSink constructs a Sink that produces the batches to the given topic. A batch is accepted
+// once it is committed by all the in-sync replicas of the partition
+func NewKafkaSink(brokers []string, topic string) (Sink, error) {
+	config := sarama.NewConfig()
+	config.Producer.RequiredAcks = sarama.WaitForAll
+	config.Producer.Return.Successes = true
+	producer, err := sarama.NewSyncProducer(brokers, config)
+	if err != nil {
+		return nil, err
+	}
+	return &kafkaSink{topic: topic, producer: producer}, nil
+}
+
+func (s *kafkaSink) Publish(batch *ChangeBatch) error {
+	batchBytes, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+	_, _, err = s.producer.SendMessage(&sarama.ProducerMessage{Topic: s.topic, Key: sarama.StringEncoder(batch.ChannelID),
+		Value: sarama.ByteEncoder(batchBytes)})
+	return err
+}
+
+func (s *kafkaSink) Close() {
+	s.producer.Close()
+}
+
+// natsSink publishes each batch as JSON to the subject <subject>.<channel> of a NATS server. The
+// publication is followed by a PING so that the batch is accepted once the server has processed it
+type natsSink struct {
+	address string
+	subject string
+	timeout time.Duration
+	lock    sync.Mutex
+	conn    net.Conn
+	reader  *bufio.Reader
+}
+
+// NewNATSSink constructs a Sink that publishes the batches to the NATS server at the given address
+func NewNATSSink(address string, subject string, timeout time.Duration) Sink {
+	return &natsSink{address: address, subject: subject, timeout: timeout}
+}
+
+func (s *natsSink) Publish(batch *ChangeBatch) error {
+	batchBytes, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if err := s.publish(s.subject+"."+batch.ChannelID, batchBytes); err != nil {
+		// the connection is reestablished for the next publication
+		s.closeConn()
+		return err
+	}
+	return nil
+}
+
+func (s *natsSink) publish(subject string, data []byte) error {
+	if s.conn == nil {
+		if err := s.connect(); err != nil {
+			return err
+		}
+	}
+	s.conn.SetDeadline(time.Now().Add(s.timeout))
+	msg := &bytes.Buffer{}
+	fmt.Fprintf(msg, "PUB %s %d\r\n", subject, len(data))
+	msg.Write(data)
+	msg.WriteString("\r\nPING\r\n")
+	if _, err := s.conn.Write(msg.Bytes()); err != nil {
+		return err
+	}
+	for {
+		line, err := s.readLine()
+		if err != nil {
+			return err
+		}
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := s.conn.Write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("NATS server [%s] responded with [%s]", s.address, line)
+		}
+	}
+}
+
+func (s *natsSink) connect() error {
+	conn, err := net.DialTimeout("tcp", s.address, s.timeout)
+	if err != nil {
+		return err
+	}
+	s.conn = conn
+	s.reader = bufio.NewReader(conn)
+	s.conn.SetDeadline(time.Now().Add(s.timeout))
+	line, err := s.readLine()
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, "INFO") {
+		return fmt.Errorf("Unexpected greeting [%s] from NATS server [%s]", line, s.address)
+	}
+	_, err = s.conn.Write([]byte(`CONNECT {"verbose":false,"pedantic":false,"name":"fabric-peer-cdc"}` + "\r\n"))
+	return err
+}
+
+func (s *natsSink) readLine() (string, error) {
+	line, err := s.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (s *natsSink) closeConn() {
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+}
+
+func (s *natsSink) Close() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.closeConn()
+}
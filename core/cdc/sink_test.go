@@ -0,0 +1,125 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cdc
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/Shopify/sarama/mocks"
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+)
+
+var testBatch = &ChangeBatch{ChannelID: "testchannel", BlockNumber: 5,
+	Changes: []*Change{{Namespace: "ns", Key: "key1", Value: []byte("value1"), TxID: "tx1", TxNum: 0}}}
+
+func TestWebhookSink(t *testing.T) {
+	var received []*ChangeBatch
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		batch := &ChangeBatch{}
+		testutil.AssertNoError(t, json.Unmarshal(body, batch), "")
+		received = append(received, batch)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	sink := NewWebhookSink(server.URL, time.Second)
+	defer sink.Close()
+	testutil.AssertNoError(t, sink.Publish(testBatch), "")
+	testutil.AssertEquals(t, received, []*ChangeBatch{testBatch})
+	status = http.StatusServiceUnavailable
+	testutil.AssertError(t, sink.Publish(testBatch), "Expected an error for a rejected batch")
+}
+
+func TestKafkaSink(t *testing.T) {
+	producer := mocks.NewSyncProducer(t, nil)
+	producer.ExpectSendMessageAndSucceed()
+	producer.ExpectSendMessageAndFail(sarama.ErrNotEnoughReplicas)
+	sink := &kafkaSink{topic: "fabric-cdc", producer: producer}
+	defer sink.Close()
+	testutil.AssertNoError(t, sink.Publish(testBatch), "")
+	testutil.AssertError(t, sink.Publish(testBatch), "Expected an error for a rejected message")
+}
+
+func TestNATSSink(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	testutil.AssertNoError(t, err, "")
+	defer lis.Close()
+	published := make(chan string, 10)
+	go serveNATS(lis, published)
+
+	sink := NewNATSSink(lis.Addr().String(), "fabric.cdc", time.Second)
+	defer sink.Close()
+	testutil.AssertNoError(t, sink.Publish(testBatch), "")
+	testutil.AssertEquals(t, <-published, "fabric.cdc.testchannel")
+	// the server rejects the subjects of the other channels
+	otherBatch := &ChangeBatch{ChannelID: "forbidden"}
+	testutil.AssertError(t, sink.Publish(otherBatch), "Expected an error for a rejected publication")
+	// the sink reconnects after an error
+	testutil.AssertNoError(t, sink.Publish(testBatch), "")
+	testutil.AssertEquals(t, <-published, "fabric.cdc.testchannel")
+}
+
+// serveNATS implements the part of the NATS protocol used by the sink. The publications
+// to the subjects ending with "forbidden" are rejected
+func serveNATS(lis net.Listener, published chan<- string) {
+	for {
+		conn, err := lis.Accept()
+		if err != nil {
+			return
+		}
+		go func(conn net.Conn) {
+			defer conn.Close()
+			reader := bufio.NewReader(conn)
+			conn.Write([]byte("INFO {\"server_id\":\"test\"}\r\n"))
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				fields := strings.Fields(line)
+				switch fields[0] {
+				case "PUB":
+					size, _ := strconv.Atoi(fields[2])
+					payload := make([]byte, size+2)
+					if _, err := io.ReadFull(reader, payload); err != nil {
+						return
+					}
+					if strings.HasSuffix(fields[1], "forbidden") {
+						conn.Write([]byte("-ERR 'Permissions Violation'\r\n"))
+						continue
+					}
+					published <- fields[1]
+				case "PING":
+					conn.Write([]byte("PONG\r\n"))
+				}
+			}
+		}(conn)
+	}
+}
@@ -31,6 +31,7 @@ import (
 	"github.com/hyperledger/fabric/core/ledger"
 	"github.com/hyperledger/fabric/core/peer"
 	pb "github.com/hyperledger/fabric/protos/peer"
+	putils "github.com/hyperledger/fabric/protos/utils"
 	"github.com/looplab/fsm"
 	logging "github.com/op/go-logging"
 	"golang.org/x/net/context"
@@ -414,12 +415,16 @@ func newChaincodeSupportHandler(chaincodeSupport *ChaincodeSupport, peerChatStre
 			{Name: pb.ChaincodeMessage_READY.String(), Src: []string{establishedstate}, Dst: readystate},
 			{Name: pb.ChaincodeMessage_PUT_STATE.String(), Src: []string{readystate}, Dst: readystate},
 			{Name: pb.ChaincodeMessage_DEL_STATE.String(), Src: []string{readystate}, Dst: readystate},
+			{Name: pb.ChaincodeMessage_DEL_STATE_BY_RANGE.String(), Src: []string{readystate}, Dst: readystate},
 			{Name: pb.ChaincodeMessage_INVOKE_CHAINCODE.String(), Src: []string{readystate}, Dst: readystate},
 			{Name: pb.ChaincodeMessage_COMPLETED.String(), Src: []string{readystate}, Dst: readystate},
 			{Name: pb.ChaincodeMessage_GET_STATE.String(), Src: []string{readystate}, Dst: readystate},
+			{Name: pb.ChaincodeMessage_GET_STATE_MULTIPLE.String(), Src: []string{readystate}, Dst: readystate},
+			{Name: pb.ChaincodeMessage_GET_STATE_HASH.String(), Src: []string{readystate}, Dst: readystate},
 			{Name: pb.ChaincodeMessage_GET_STATE_BY_RANGE.String(), Src: []string{readystate}, Dst: readystate},
 			{Name: pb.ChaincodeMessage_GET_QUERY_RESULT.String(), Src: []string{readystate}, Dst: readystate},
 			{Name: pb.ChaincodeMessage_GET_HISTORY_FOR_KEY.String(), Src: []string{readystate}, Dst: readystate},
+			{Name: pb.ChaincodeMessage_GET_TOTAL_FOR_KEY_PREFIX.String(), Src: []string{readystate}, Dst: readystate},
 			{Name: pb.ChaincodeMessage_QUERY_STATE_NEXT.String(), Src: []string{readystate}, Dst: readystate},
 			{Name: pb.ChaincodeMessage_QUERY_STATE_CLOSE.String(), Src: []string{readystate}, Dst: readystate},
 			{Name: pb.ChaincodeMessage_ERROR.String(), Src: []string{readystate}, Dst: readystate},
@@ -428,20 +433,24 @@ func newChaincodeSupportHandler(chaincodeSupport *ChaincodeSupport, peerChatStre
 			{Name: pb.ChaincodeMessage_TRANSACTION.String(), Src: []string{readystate}, Dst: readystate},
 		},
 		fsm.Callbacks{
-			"before_" + pb.ChaincodeMessage_REGISTER.String():           func(e *fsm.Event) { v.beforeRegisterEvent(e, v.FSM.Current()) },
-			"before_" + pb.ChaincodeMessage_COMPLETED.String():          func(e *fsm.Event) { v.beforeCompletedEvent(e, v.FSM.Current()) },
-			"after_" + pb.ChaincodeMessage_GET_STATE.String():           func(e *fsm.Event) { v.afterGetState(e, v.FSM.Current()) },
-			"after_" + pb.ChaincodeMessage_GET_STATE_BY_RANGE.String():  func(e *fsm.Event) { v.afterGetStateByRange(e, v.FSM.Current()) },
-			"after_" + pb.ChaincodeMessage_GET_QUERY_RESULT.String():    func(e *fsm.Event) { v.afterGetQueryResult(e, v.FSM.Current()) },
-			"after_" + pb.ChaincodeMessage_GET_HISTORY_FOR_KEY.String(): func(e *fsm.Event) { v.afterGetHistoryForKey(e, v.FSM.Current()) },
-			"after_" + pb.ChaincodeMessage_QUERY_STATE_NEXT.String():    func(e *fsm.Event) { v.afterQueryStateNext(e, v.FSM.Current()) },
-			"after_" + pb.ChaincodeMessage_QUERY_STATE_CLOSE.String():   func(e *fsm.Event) { v.afterQueryStateClose(e, v.FSM.Current()) },
-			"after_" + pb.ChaincodeMessage_PUT_STATE.String():           func(e *fsm.Event) { v.enterBusyState(e, v.FSM.Current()) },
-			"after_" + pb.ChaincodeMessage_DEL_STATE.String():           func(e *fsm.Event) { v.enterBusyState(e, v.FSM.Current()) },
-			"after_" + pb.ChaincodeMessage_INVOKE_CHAINCODE.String():    func(e *fsm.Event) { v.enterBusyState(e, v.FSM.Current()) },
-			"enter_" + establishedstate:                                 func(e *fsm.Event) { v.enterEstablishedState(e, v.FSM.Current()) },
-			"enter_" + readystate:                                       func(e *fsm.Event) { v.enterReadyState(e, v.FSM.Current()) },
-			"enter_" + endstate:                                         func(e *fsm.Event) { v.enterEndState(e, v.FSM.Current()) },
+			"before_" + pb.ChaincodeMessage_REGISTER.String():                func(e *fsm.Event) { v.beforeRegisterEvent(e, v.FSM.Current()) },
+			"before_" + pb.ChaincodeMessage_COMPLETED.String():               func(e *fsm.Event) { v.beforeCompletedEvent(e, v.FSM.Current()) },
+			"after_" + pb.ChaincodeMessage_GET_STATE.String():                func(e *fsm.Event) { v.afterGetState(e, v.FSM.Current()) },
+			"after_" + pb.ChaincodeMessage_GET_STATE_MULTIPLE.String():       func(e *fsm.Event) { v.afterGetStateMultiple(e, v.FSM.Current()) },
+			"after_" + pb.ChaincodeMessage_GET_STATE_HASH.String():           func(e *fsm.Event) { v.afterGetStateHash(e, v.FSM.Current()) },
+			"after_" + pb.ChaincodeMessage_GET_STATE_BY_RANGE.String():       func(e *fsm.Event) { v.afterGetStateByRange(e, v.FSM.Current()) },
+			"after_" + pb.ChaincodeMessage_GET_QUERY_RESULT.String():         func(e *fsm.Event) { v.afterGetQueryResult(e, v.FSM.Current()) },
+			"after_" + pb.ChaincodeMessage_GET_HISTORY_FOR_KEY.String():      func(e *fsm.Event) { v.afterGetHistoryForKey(e, v.FSM.Current()) },
+			"after_" + pb.ChaincodeMessage_GET_TOTAL_FOR_KEY_PREFIX.String(): func(e *fsm.Event) { v.afterGetTotalForKeyPrefix(e, v.FSM.Current()) },
+			"after_" + pb.ChaincodeMessage_QUERY_STATE_NEXT.String():         func(e *fsm.Event) { v.afterQueryStateNext(e, v.FSM.Current()) },
+			"after_" + pb.ChaincodeMessage_QUERY_STATE_CLOSE.String():        func(e *fsm.Event) { v.afterQueryStateClose(e, v.FSM.Current()) },
+			"after_" + pb.ChaincodeMessage_PUT_STATE.String():                func(e *fsm.Event) { v.enterBusyState(e, v.FSM.Current()) },
+			"after_" + pb.ChaincodeMessage_DEL_STATE.String():                func(e *fsm.Event) { v.enterBusyState(e, v.FSM.Current()) },
+			"after_" + pb.ChaincodeMessage_DEL_STATE_BY_RANGE.String():       func(e *fsm.Event) { v.enterBusyState(e, v.FSM.Current()) },
+			"after_" + pb.ChaincodeMessage_INVOKE_CHAINCODE.String():         func(e *fsm.Event) { v.enterBusyState(e, v.FSM.Current()) },
+			"enter_" + establishedstate:                                      func(e *fsm.Event) { v.enterEstablishedState(e, v.FSM.Current()) },
+			"enter_" + readystate:                                            func(e *fsm.Event) { v.enterReadyState(e, v.FSM.Current()) },
+			"enter_" + endstate:                                              func(e *fsm.Event) { v.enterEndState(e, v.FSM.Current()) },
 		},
 	)
 
@@ -636,6 +645,145 @@ func (handler *Handler) handleGetState(msg *pb.ChaincodeMessage) {
 	}()
 }
 
+// afterGetStateMultiple handles a GET_STATE_MULTIPLE request from the chaincode.
+func (handler *Handler) afterGetStateMultiple(e *fsm.Event, state string) {
+	msg, ok := e.Args[0].(*pb.ChaincodeMessage)
+	if !ok {
+		e.Cancel(fmt.Errorf("Received unexpected message type"))
+		return
+	}
+	chaincodeLogger.Debugf("[%s]Received %s, invoking get state from ledger", shorttxid(msg.Txid), pb.ChaincodeMessage_GET_STATE_MULTIPLE)
+
+	// Query ledger for the states
+	handler.handleGetStateMultiple(msg)
+}
+
+// Handles query to ledger to get the states of multiple keys. The keys are read from the state database
+// in a single call and the values are sent back to the chaincode in a single response
+func (handler *Handler) handleGetStateMultiple(msg *pb.ChaincodeMessage) {
+	// The defer followed by triggering a go routine dance is needed to ensure that the previous state transition
+	// is completed before the next one is triggered. The previous state transition is deemed complete only when
+	// the afterGetStateMultiple function is exited.
+	go func() {
+		// Check if this is the unique state request from this chaincode txid
+		uniqueReq := handler.createTXIDEntry(msg.Txid)
+		if !uniqueReq {
+			// Drop this request
+			chaincodeLogger.Error("Another state request pending for this Txid. Cannot process.")
+			return
+		}
+
+		var serialSendMsg *pb.ChaincodeMessage
+
+		defer func() {
+			handler.deleteTXIDEntry(msg.Txid)
+			chaincodeLogger.Debugf("[%s]handleGetStateMultiple serial send %s", shorttxid(serialSendMsg.Txid), serialSendMsg.Type)
+			handler.serialSendAsync(serialSendMsg, nil)
+		}()
+
+		getStateMultiple := &pb.GetStateMultiple{}
+		unmarshalErr := proto.Unmarshal(msg.Payload, getStateMultiple)
+		if unmarshalErr != nil {
+			payload := []byte(unmarshalErr.Error())
+			chaincodeLogger.Errorf("Failed to unmarshall state request. Sending %s", pb.ChaincodeMessage_ERROR)
+			serialSendMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: payload, Txid: msg.Txid}
+			return
+		}
+
+		var txContext *transactionContext
+
+		txContext, serialSendMsg = handler.isValidTxSim(msg.Txid, "[%s]No ledger context for GetStateMultiple. Sending %s", shorttxid(msg.Txid), pb.ChaincodeMessage_ERROR)
+		if txContext == nil {
+			return
+		}
+		chaincodeID := handler.getCCRootName()
+		chaincodeLogger.Debugf("[%s] getting state for chaincode %s, %d keys, channel %s",
+			shorttxid(msg.Txid), chaincodeID, len(getStateMultiple.Keys), txContext.chainID)
+
+		values, err := txContext.txsimulator.GetStateMultipleKeys(chaincodeID, getStateMultiple.Keys)
+		if err != nil {
+			payload := []byte(err.Error())
+			chaincodeLogger.Errorf("[%s]Failed to get chaincode state(%s). Sending %s",
+				shorttxid(msg.Txid), err, pb.ChaincodeMessage_ERROR)
+			serialSendMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: payload, Txid: msg.Txid}
+			return
+		}
+
+		payloadBytes, err := proto.Marshal(&pb.GetStateMultipleResponse{Values: values})
+		if err != nil {
+			payload := []byte(err.Error())
+			chaincodeLogger.Errorf("Failed marshall response. Sending %s", pb.ChaincodeMessage_ERROR)
+			serialSendMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: payload, Txid: msg.Txid}
+			return
+		}
+
+		chaincodeLogger.Debugf("[%s]Got states. Sending %s", shorttxid(msg.Txid), pb.ChaincodeMessage_RESPONSE)
+		serialSendMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_RESPONSE, Payload: payloadBytes, Txid: msg.Txid}
+	}()
+}
+
+// afterGetStateHash handles a GET_STATE_HASH request from the chaincode.
+func (handler *Handler) afterGetStateHash(e *fsm.Event, state string) {
+	msg, ok := e.Args[0].(*pb.ChaincodeMessage)
+	if !ok {
+		e.Cancel(fmt.Errorf("Received unexpected message type"))
+		return
+	}
+	chaincodeLogger.Debugf("[%s]Received %s, invoking get state hash from ledger", shorttxid(msg.Txid), pb.ChaincodeMessage_GET_STATE_HASH)
+
+	// Query ledger for the hash of the state
+	handler.handleGetStateHash(msg)
+}
+
+// Handles query to ledger to get the hash of the value of a key, so that a large value does not need to be
+// sent to the chaincode when the chaincode only compares or verifies it
+func (handler *Handler) handleGetStateHash(msg *pb.ChaincodeMessage) {
+	// The defer followed by triggering a go routine dance is needed to ensure that the previous state transition
+	// is completed before the next one is triggered. The previous state transition is deemed complete only when
+	// the afterGetStateHash function is exited.
+	go func() {
+		// Check if this is the unique state request from this chaincode txid
+		uniqueReq := handler.createTXIDEntry(msg.Txid)
+		if !uniqueReq {
+			// Drop this request
+			chaincodeLogger.Error("Another state request pending for this Txid. Cannot process.")
+			return
+		}
+
+		var serialSendMsg *pb.ChaincodeMessage
+		var txContext *transactionContext
+		txContext, serialSendMsg = handler.isValidTxSim(msg.Txid,
+			"[%s]No ledger context for GetStateHash. Sending %s", shorttxid(msg.Txid), pb.ChaincodeMessage_ERROR)
+
+		defer func() {
+			handler.deleteTXIDEntry(msg.Txid)
+			chaincodeLogger.Debugf("[%s]handleGetStateHash serial send %s", shorttxid(serialSendMsg.Txid), serialSendMsg.Type)
+			handler.serialSendAsync(serialSendMsg, nil)
+		}()
+
+		if txContext == nil {
+			return
+		}
+
+		key := string(msg.Payload)
+		chaincodeID := handler.getCCRootName()
+		chaincodeLogger.Debugf("[%s] getting state hash for chaincode %s, channel %s", shorttxid(msg.Txid), chaincodeID, txContext.chainID)
+
+		hash, err := txContext.txsimulator.GetStateHash(chaincodeID, key)
+		if err != nil {
+			payload := []byte(err.Error())
+			chaincodeLogger.Errorf("[%s]Failed to get chaincode state hash(%s). Sending %s",
+				shorttxid(msg.Txid), err, pb.ChaincodeMessage_ERROR)
+			serialSendMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: payload, Txid: msg.Txid}
+			return
+		}
+
+		// the payload is empty if the key does not exist
+		chaincodeLogger.Debugf("[%s]Got state hash. Sending %s", shorttxid(msg.Txid), pb.ChaincodeMessage_RESPONSE)
+		serialSendMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_RESPONSE, Payload: hash, Txid: msg.Txid}
+	}()
+}
+
 const maxGetStateByRangeLimit = 100
 
 // afterGetStateByRange handles a GET_STATE_BY_RANGE request from the chaincode.
@@ -693,6 +841,11 @@ func (handler *Handler) handleGetStateByRange(msg *pb.ChaincodeMessage) {
 		}
 		chaincodeID := handler.getCCRootName()
 
+		if getStateByRange.PageSize > 0 {
+			serialSendMsg = handler.getStateByRangePage(txContext, chaincodeID, getStateByRange, msg.Txid)
+			return
+		}
+
 		rangeIter, err := txContext.txsimulator.GetStateRangeScanIterator(chaincodeID, getStateByRange.StartKey, getStateByRange.EndKey)
 		if err != nil {
 			// Send error msg back to chaincode. GetState will not trigger event
@@ -745,6 +898,41 @@ func (handler *Handler) handleGetStateByRange(msg *pb.ChaincodeMessage) {
 	}()
 }
 
+// getStateByRangePage returns the response to a paginated GET_STATE_BY_RANGE request. The whole page is
+// returned in the response, so no iterator is kept open for the chaincode
+func (handler *Handler) getStateByRangePage(txContext *transactionContext, chaincodeID string, getStateByRange *pb.GetStateByRange, txid string) *pb.ChaincodeMessage {
+	rangeIter, err := txContext.txsimulator.GetStateRangeScanIteratorWithPagination(chaincodeID,
+		getStateByRange.StartKey, getStateByRange.EndKey, getStateByRange.PageSize, getStateByRange.Bookmark)
+	if err != nil {
+		chaincodeLogger.Errorf("Failed to get ledger scan iterator. Sending %s", pb.ChaincodeMessage_ERROR)
+		return &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: []byte(err.Error()), Txid: txid}
+	}
+	defer rangeIter.Close()
+
+	var keysAndValues []*pb.QueryStateKeyValue
+	for {
+		qresult, err := rangeIter.Next()
+		if err != nil {
+			chaincodeLogger.Errorf("Failed to get query result from iterator. Sending %s", pb.ChaincodeMessage_ERROR)
+			return &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: []byte(err.Error()), Txid: txid}
+		}
+		if qresult == nil {
+			break
+		}
+		kv := qresult.(*ledger.KV)
+		keysAndValues = append(keysAndValues, &pb.QueryStateKeyValue{Key: kv.Key, Value: kv.Value})
+	}
+
+	metadata := &pb.QueryResponseMetadata{FetchedRecordsCount: int32(len(keysAndValues)), Bookmark: rangeIter.Bookmark()}
+	payloadBytes, err := proto.Marshal(&pb.QueryStateResponse{KeysAndValues: keysAndValues, Metadata: metadata})
+	if err != nil {
+		chaincodeLogger.Errorf("Failed marshall resopnse. Sending %s", pb.ChaincodeMessage_ERROR)
+		return &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: []byte(err.Error()), Txid: txid}
+	}
+	chaincodeLogger.Debugf("Got page of keys and values. Sending %s", pb.ChaincodeMessage_RESPONSE)
+	return &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_RESPONSE, Payload: payloadBytes, Txid: txid}
+}
+
 // afterQueryStateNext handles a QUERY_STATE_NEXT request from the chaincode.
 func (handler *Handler) afterQueryStateNext(e *fsm.Event, state string) {
 	msg, ok := e.Args[0].(*pb.ChaincodeMessage)
@@ -970,7 +1158,13 @@ func (handler *Handler) handleGetQueryResult(msg *pb.ChaincodeMessage) {
 
 		chaincodeID := handler.getCCRootName()
 
-		executeIter, err := txContext.txsimulator.ExecuteQuery(chaincodeID, getQueryResult.Query)
+		var executeIter commonledger.ResultsIterator
+		var err error
+		if len(getQueryResult.Fields) > 0 {
+			executeIter, err = txContext.txsimulator.ExecuteQueryWithFields(chaincodeID, getQueryResult.Query, getQueryResult.Fields)
+		} else {
+			executeIter, err = txContext.txsimulator.ExecuteQuery(chaincodeID, getQueryResult.Query)
+		}
 		if err != nil {
 			// Send error msg back to chaincode. GetState will not trigger event
 			payload := []byte(err.Error())
@@ -1080,7 +1274,23 @@ func (handler *Handler) handleGetHistoryForKey(msg *pb.ChaincodeMessage) {
 		}
 		chaincodeID := handler.getCCRootName()
 
-		historyIter, err := txContext.historyQueryExecutor.GetHistoryForKey(chaincodeID, getHistoryForKey.Key)
+		if err := handler.checkHistoryAccess(txContext, chaincodeID, getHistoryForKey.Key); err != nil {
+			payload := []byte(err.Error())
+			chaincodeLogger.Warningf("[%s]Access to the history of key [%s] of chaincode [%s] denied: %s. Sending %s",
+				shorttxid(msg.Txid), getHistoryForKey.Key, chaincodeID, err, pb.ChaincodeMessage_ERROR)
+			serialSendMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: payload, Txid: msg.Txid}
+			return
+		}
+
+		var historyIter commonledger.ResultsIterator
+		var err error
+		if options := getHistoryForKey.Options; options != nil {
+			historyIter, err = txContext.historyQueryExecutor.GetHistoryForKeyWithOptions(chaincodeID, getHistoryForKey.Key,
+				&ledger.HistoryQueryOptions{Limit: int(options.Limit), Reverse: options.Reverse, StartBlock: options.StartBlock,
+					EndBlock: options.EndBlock, IncludeDeletes: options.IncludeDeletes})
+		} else {
+			historyIter, err = txContext.historyQueryExecutor.GetHistoryForKey(chaincodeID, getHistoryForKey.Key)
+		}
 		if err != nil {
 			// Send error msg back to chaincode. GetState will not trigger event
 			payload := []byte(err.Error())
@@ -1135,6 +1345,98 @@ func (handler *Handler) handleGetHistoryForKey(msg *pb.ChaincodeMessage) {
 	}()
 }
 
+// checkHistoryAccess checks that the creator of the proposal of the transaction may read the history of the given key
+func (handler *Handler) checkHistoryAccess(txContext *transactionContext, chaincodeID string, key string) error {
+	var creator []byte
+	if txContext.proposal != nil {
+		hdr, err := putils.GetHeader(txContext.proposal.Header)
+		if err != nil {
+			return err
+		}
+		shdr, err := putils.GetSignatureHeader(hdr.SignatureHeader)
+		if err != nil {
+			return err
+		}
+		creator = shdr.Creator
+	}
+	return peer.CheckHistoryAccess(creator, txContext.chainID, chaincodeID, key)
+}
+
+// afterGetTotalForKeyPrefix handles a GET_TOTAL_FOR_KEY_PREFIX request from the chaincode.
+func (handler *Handler) afterGetTotalForKeyPrefix(e *fsm.Event, state string) {
+	msg, ok := e.Args[0].(*pb.ChaincodeMessage)
+	if !ok {
+		e.Cancel(fmt.Errorf("Received unexpected message type"))
+		return
+	}
+	chaincodeLogger.Debugf("Received %s, invoking get total from ledger", pb.ChaincodeMessage_GET_TOTAL_FOR_KEY_PREFIX)
+
+	// Aggregate the values in the ledger
+	handler.handleGetTotalForKeyPrefix(msg)
+	chaincodeLogger.Debug("Exiting GET_TOTAL_FOR_KEY_PREFIX")
+}
+
+// Handles the aggregation of the values of the keys with a prefix. The aggregation is performed by the ledger so
+// that only the total, rather than each of the values, is sent to the chaincode
+func (handler *Handler) handleGetTotalForKeyPrefix(msg *pb.ChaincodeMessage) {
+	// The defer followed by triggering a go routine dance is needed to ensure that the previous state transition
+	// is completed before the next one is triggered. The previous state transition is deemed complete only when
+	// the afterGetTotalForKeyPrefix function is exited.
+	go func() {
+		// Check if this is the unique state request from this chaincode txid
+		uniqueReq := handler.createTXIDEntry(msg.Txid)
+		if !uniqueReq {
+			// Drop this request
+			chaincodeLogger.Error("Another state request pending for this Txid. Cannot process.")
+			return
+		}
+
+		var serialSendMsg *pb.ChaincodeMessage
+
+		defer func() {
+			handler.deleteTXIDEntry(msg.Txid)
+			chaincodeLogger.Debugf("[%s]handleGetTotalForKeyPrefix serial send %s", shorttxid(serialSendMsg.Txid), serialSendMsg.Type)
+			handler.serialSendAsync(serialSendMsg, nil)
+		}()
+
+		getTotalForKeyPrefix := &pb.GetTotalForKeyPrefix{}
+		unmarshalErr := proto.Unmarshal(msg.Payload, getTotalForKeyPrefix)
+		if unmarshalErr != nil {
+			payload := []byte(unmarshalErr.Error())
+			chaincodeLogger.Errorf("Failed to unmarshall total request. Sending %s", pb.ChaincodeMessage_ERROR)
+			serialSendMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: payload, Txid: msg.Txid}
+			return
+		}
+
+		var txContext *transactionContext
+
+		txContext, serialSendMsg = handler.isValidTxSim(msg.Txid, "[%s]No ledger context for GetTotalForKeyPrefix. Sending %s", shorttxid(msg.Txid), pb.ChaincodeMessage_ERROR)
+		if txContext == nil {
+			return
+		}
+		chaincodeID := handler.getCCRootName()
+
+		aggregate, err := txContext.txsimulator.GetTotalForKeyPrefix(chaincodeID, getTotalForKeyPrefix.KeyPrefix, getTotalForKeyPrefix.FieldName)
+		if err != nil {
+			payload := []byte(err.Error())
+			chaincodeLogger.Errorf("Failed to get total for key prefix. Sending %s", pb.ChaincodeMessage_ERROR)
+			serialSendMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: payload, Txid: msg.Txid}
+			return
+		}
+
+		payloadBytes, err := proto.Marshal(&pb.TotalForKeyPrefixResponse{Sum: aggregate.Sum, Count: aggregate.Count})
+		if err != nil {
+			payload := []byte(err.Error())
+			chaincodeLogger.Errorf("Failed marshall response. Sending %s", pb.ChaincodeMessage_ERROR)
+			serialSendMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: payload, Txid: msg.Txid}
+			return
+		}
+
+		chaincodeLogger.Debugf("Got total for key prefix. Sending %s", pb.ChaincodeMessage_RESPONSE)
+		serialSendMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_RESPONSE, Payload: payloadBytes, Txid: msg.Txid}
+	}()
+}
+
 // afterPutState handles a PUT_STATE request from the chaincode.
 func (handler *Handler) afterPutState(e *fsm.Event, state string) {
 	_, ok := e.Args[0].(*pb.ChaincodeMessage)
@@ -1218,11 +1520,27 @@ func (handler *Handler) enterBusyState(e *fsm.Event, state string) {
 				return
 			}
 
-			err = txContext.txsimulator.SetState(chaincodeID, putStateInfo.Key, putStateInfo.Value)
+			if putStateInfo.Ttl > 0 {
+				err = txContext.txsimulator.SetStateWithTTL(chaincodeID, putStateInfo.Key, putStateInfo.Value, putStateInfo.Ttl)
+			} else {
+				err = txContext.txsimulator.SetState(chaincodeID, putStateInfo.Key, putStateInfo.Value)
+			}
 		} else if msg.Type.String() == pb.ChaincodeMessage_DEL_STATE.String() {
 			// Invoke ledger to delete state
 			key := string(msg.Payload)
 			err = txContext.txsimulator.DeleteState(chaincodeID, key)
+		} else if msg.Type.String() == pb.ChaincodeMessage_DEL_STATE_BY_RANGE.String() {
+			delStateByRange := &pb.DelStateByRange{}
+			unmarshalErr := proto.Unmarshal(msg.Payload, delStateByRange)
+			if unmarshalErr != nil {
+				payload := []byte(unmarshalErr.Error())
+				chaincodeLogger.Debugf("[%s]Unable to decipher payload. Sending %s", shorttxid(msg.Txid), pb.ChaincodeMessage_ERROR)
+				triggerNextStateMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: payload, Txid: msg.Txid}
+				return
+			}
+
+			// Invoke ledger to record the deletion of the range
+			err = txContext.txsimulator.DeleteStateByRange(chaincodeID, delStateByRange.StartKey, delStateByRange.EndKey)
 		} else if msg.Type.String() == pb.ChaincodeMessage_INVOKE_CHAINCODE.String() {
 			if chaincodeLogger.IsEnabledFor(logging.DEBUG) {
 				chaincodeLogger.Debugf("[%s] C-call-C", shorttxid(msg.Txid))
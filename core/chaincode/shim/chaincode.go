@@ -337,9 +337,33 @@ func (stub *ChaincodeStub) GetState(key string) ([]byte, error) {
 	return stub.handler.handleGetState(key, stub.TxID)
 }
 
+// GetStateMultiple returns the values of the given keys, in the order of the
+// keys, fetched from the ledger in a single round trip. The value of a key
+// that does not exist is nil.
+func (stub *ChaincodeStub) GetStateMultiple(keys []string) ([][]byte, error) {
+	return stub.handler.handleGetStateMultiple(keys, stub.TxID)
+}
+
+// GetStateHash returns the SHA256 hash of the value of the given key, or nil
+// if the key does not exist. The value itself is not sent to the chaincode.
+func (stub *ChaincodeStub) GetStateHash(key string) ([]byte, error) {
+	return stub.handler.handleGetStateHash(key, stub.TxID)
+}
+
 // PutState writes the specified `value` and `key` into the ledger.
 func (stub *ChaincodeStub) PutState(key string, value []byte) error {
-	return stub.handler.handlePutState(key, value, stub.TxID)
+	return stub.handler.handlePutState(key, value, 0, stub.TxID)
+}
+
+// PutStateWithTTL writes the specified `value` and `key` into the ledger. The
+// key expires, i.e., it is deleted from the ledger, on the commit of the ttl-th
+// block after the block that commits the transaction, unless it is written again
+// in the meantime.
+func (stub *ChaincodeStub) PutStateWithTTL(key string, value []byte, ttl uint64) error {
+	if ttl == 0 {
+		return errors.New("ttl must be greater than zero")
+	}
+	return stub.handler.handlePutState(key, value, ttl, stub.TxID)
 }
 
 // DelState removes the specified `key` and its value from the ledger.
@@ -347,6 +371,13 @@ func (stub *ChaincodeStub) DelState(key string) error {
 	return stub.handler.handleDelState(key, stub.TxID)
 }
 
+// DelStateByRange removes the keys in the range [startKey, endKey) and their
+// values from the ledger. The range is expanded to the keys present when the
+// transaction is committed.
+func (stub *ChaincodeStub) DelStateByRange(startKey, endKey string) error {
+	return stub.handler.handleDelStateByRange(startKey, endKey, stub.TxID)
+}
+
 // StateQueryIterator allows a chaincode to iterate over a set of
 // key/value pairs in the state.
 type StateQueryIterator struct {
@@ -362,20 +393,48 @@ type StateQueryIterator struct {
 // between the startKey and endKey, inclusive. The order in which keys are
 // returned by the iterator is random.
 func (stub *ChaincodeStub) GetStateByRange(startKey, endKey string) (StateQueryIteratorInterface, error) {
-	response, err := stub.handler.handleGetStateByRange(startKey, endKey, stub.TxID)
+	response, err := stub.handler.handleGetStateByRange(startKey, endKey, 0, "", stub.TxID)
 	if err != nil {
 		return nil, err
 	}
 	return &StateQueryIterator{stub.handler, stub.TxID, response, 0}, nil
 }
 
+// GetStateByRangeWithPagination function can be invoked by a chaincode to query
+// a page of at most pageSize keys of the range, starting from the given bookmark.
+// An empty bookmark starts from the startKey. The returned metadata contains the
+// number of the keys in the page and the bookmark of the next page, which is
+// empty when there are no more keys in the range.
+func (stub *ChaincodeStub) GetStateByRangeWithPagination(startKey, endKey string, pageSize int32, bookmark string) (StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	if pageSize <= 0 {
+		return nil, nil, errors.New("pageSize must be greater than zero")
+	}
+	response, err := stub.handler.handleGetStateByRange(startKey, endKey, pageSize, bookmark, stub.TxID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &StateQueryIterator{stub.handler, stub.TxID, response, 0}, response.Metadata, nil
+}
+
 // GetQueryResult function can be invoked by a chaincode to perform a
 // rich query against state database.  Only supported by state database implementations
 // that support rich query.  The query string is in the syntax of the underlying
 // state database. An iterator is returned which can be used to iterate (next) over
 // the query result set
 func (stub *ChaincodeStub) GetQueryResult(query string) (StateQueryIteratorInterface, error) {
-	response, err := stub.handler.handleGetQueryResult(query, stub.TxID)
+	response, err := stub.handler.handleGetQueryResult(query, nil, stub.TxID)
+	if err != nil {
+		return nil, err
+	}
+	return &StateQueryIterator{stub.handler, stub.TxID, response, 0}, nil
+}
+
+// GetQueryResultWithFields function can be invoked by a chaincode to perform a
+// rich query against state database, returning only the given fields of the
+// matching JSON documents. Nested fields are specified in dotted notation
+// (e.g. "owner.name")
+func (stub *ChaincodeStub) GetQueryResultWithFields(query string, fields []string) (StateQueryIteratorInterface, error) {
+	response, err := stub.handler.handleGetQueryResult(query, fields, stub.TxID)
 	if err != nil {
 		return nil, err
 	}
@@ -385,13 +444,41 @@ func (stub *ChaincodeStub) GetQueryResult(query string) (StateQueryIteratorInter
 // GetHistoryForKey function can be invoked by a chaincode to return a history of
 // key values across time. GetHistoryForKey is intended to be used for read-only queries.
 func (stub *ChaincodeStub) GetHistoryForKey(key string) (StateQueryIteratorInterface, error) {
-	response, err := stub.handler.handleGetHistoryForKey(key, stub.TxID)
+	response, err := stub.handler.handleGetHistoryForKey(key, nil, stub.TxID)
 	if err != nil {
 		return nil, err
 	}
 	return &StateQueryIterator{stub.handler, stub.TxID, response, 0}, nil
 }
 
+// GetHistoryForKeyWithOptions function can be invoked by a chaincode to return
+// the history of the key narrowed down and ordered as per the options. The
+// history can be returned from the most recent modification, restricted to a
+// range of blocks and to a number of modifications, and can include or skip the
+// deletes of the key.
+func (stub *ChaincodeStub) GetHistoryForKeyWithOptions(key string, options *pb.HistoryQueryOptions) (StateQueryIteratorInterface, error) {
+	if options == nil {
+		return nil, errors.New("options must be set, use GetHistoryForKey for the whole history of the key")
+	}
+	response, err := stub.handler.handleGetHistoryForKey(key, options, stub.TxID)
+	if err != nil {
+		return nil, err
+	}
+	return &StateQueryIterator{stub.handler, stub.TxID, response, 0}, nil
+}
+
+// GetTotalForKeyPrefix function can be invoked by a chaincode to add up the
+// numeric field with the given name in the JSON values of the keys that begin
+// with the given prefix. The sum and the count of the values that contributed
+// to it are returned.
+func (stub *ChaincodeStub) GetTotalForKeyPrefix(keyPrefix string, fieldName string) (float64, uint64, error) {
+	response, err := stub.handler.handleGetTotalForKeyPrefix(keyPrefix, fieldName, stub.TxID)
+	if err != nil {
+		return 0, 0, err
+	}
+	return response.Sum, response.Count, nil
+}
+
 //CreateCompositeKey combines the given attributes to form a composite key.
 func (stub *ChaincodeStub) CreateCompositeKey(objectType string, attributes []string) (string, error) {
 	return createCompositeKey(objectType, attributes)
@@ -460,6 +547,22 @@ func getStateByPartialCompositeKey(stub ChaincodeStubInterface, objectType strin
 	return keysIter, nil
 }
 
+//GetStateByPartialCompositeKeyWithPagination function can be invoked by a
+//chaincode to query a page of the composite keys whose prefix matches the given
+//partial composite key, as GetStateByRangeWithPagination does for a range.
+func (stub *ChaincodeStub) GetStateByPartialCompositeKeyWithPagination(objectType string, keys []string, pageSize int32, bookmark string) (StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	return getStateByPartialCompositeKeyWithPagination(stub, objectType, keys, pageSize, bookmark)
+}
+
+func getStateByPartialCompositeKeyWithPagination(stub ChaincodeStubInterface, objectType string, attributes []string, pageSize int32, bookmark string) (StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	partialCompositeKey, _ := stub.CreateCompositeKey(objectType, attributes)
+	keysIter, metadata, err := stub.GetStateByRangeWithPagination(partialCompositeKey, partialCompositeKey+string(maxUnicodeRuneValue), pageSize, bookmark)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error fetching rows: %s", err)
+	}
+	return keysIter, metadata, nil
+}
+
 // HasNext returns true if the range query iterator contains additional keys
 // and values.
 func (iter *StateQueryIterator) HasNext() bool {
@@ -500,6 +603,33 @@ func (iter *StateQueryIterator) Close() error {
 	return err
 }
 
+// queryResultsIterator is a StateQueryIteratorInterface over the key/value
+// pairs already fetched by the chaincode, such as a page of the history of a key.
+type queryResultsIterator struct {
+	keysAndValues []*pb.QueryStateKeyValue
+	currentLoc    int
+}
+
+// HasNext returns true if the iterator contains additional keys and values.
+func (iter *queryResultsIterator) HasNext() bool {
+	return iter.currentLoc < len(iter.keysAndValues)
+}
+
+// Next returns the next key and value in the iterator.
+func (iter *queryResultsIterator) Next() (string, []byte, error) {
+	if !iter.HasNext() {
+		return "", nil, errors.New("No such key")
+	}
+	keyValue := iter.keysAndValues[iter.currentLoc]
+	iter.currentLoc++
+	return keyValue.Key, keyValue.Value, nil
+}
+
+// Close closes the iterator.
+func (iter *queryResultsIterator) Close() error {
+	return nil
+}
+
 // GetArgs returns the argument list
 func (stub *ChaincodeStub) GetArgs() [][]byte {
 	return stub.args
@@ -424,11 +424,111 @@ func (handler *Handler) handleGetState(key string, txid string) ([]byte, error)
 	return nil, errors.New("Incorrect chaincode message received")
 }
 
-// handlePutState communicates with the validator to put state information into the ledger.
-func (handler *Handler) handlePutState(key string, value []byte, txid string) error {
+// handleGetStateHash communicates with the validator to fetch the hash of the value of a key from the ledger.
+func (handler *Handler) handleGetStateHash(key string, txid string) ([]byte, error) {
+	// Create the channel on which to communicate the response from validating peer
+	respChan, uniqueReqErr := handler.createChannel(txid)
+	if uniqueReqErr != nil {
+		chaincodeLogger.Debug("Another state request pending for this Txid. Cannot process.")
+		return nil, uniqueReqErr
+	}
+
+	defer handler.deleteChannel(txid)
+
+	// Send GET_STATE_HASH message to validator chaincode support
+	msg := &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_GET_STATE_HASH, Payload: []byte(key), Txid: txid}
+	chaincodeLogger.Debugf("[%s]Sending %s", shorttxid(msg.Txid), pb.ChaincodeMessage_GET_STATE_HASH)
+	responseMsg, err := handler.sendReceive(msg, respChan)
+	if err != nil {
+		chaincodeLogger.Errorf("[%s]error sending GET_STATE_HASH %s", shorttxid(txid), err)
+		return nil, errors.New("could not send msg")
+	}
+
+	if responseMsg.Type.String() == pb.ChaincodeMessage_RESPONSE.String() {
+		// Success response
+		chaincodeLogger.Debugf("[%s]GetStateHash received payload %s", shorttxid(responseMsg.Txid), pb.ChaincodeMessage_RESPONSE)
+		// As for GetState, the hash of a key that does not exist is nil
+		if len(responseMsg.Payload) == 0 {
+			return nil, nil
+		}
+		return responseMsg.Payload, nil
+	}
+	if responseMsg.Type.String() == pb.ChaincodeMessage_ERROR.String() {
+		// Error response
+		chaincodeLogger.Errorf("[%s]GetStateHash received error %s", shorttxid(responseMsg.Txid), pb.ChaincodeMessage_ERROR)
+		return nil, errors.New(string(responseMsg.Payload[:]))
+	}
+
+	// Incorrect chaincode message received
+	chaincodeLogger.Errorf("[%s]Incorrect chaincode message %s received. Expecting %s or %s", shorttxid(responseMsg.Txid), responseMsg.Type, pb.ChaincodeMessage_RESPONSE, pb.ChaincodeMessage_ERROR)
+	return nil, errors.New("Incorrect chaincode message received")
+}
+
+// handleGetStateMultiple communicates with the validator to fetch the states of multiple keys from the ledger in a
+// single round trip.
+func (handler *Handler) handleGetStateMultiple(keys []string, txid string) ([][]byte, error) {
+	// Create the channel on which to communicate the response from validating peer
+	respChan, uniqueReqErr := handler.createChannel(txid)
+	if uniqueReqErr != nil {
+		chaincodeLogger.Debug("Another state request pending for this Txid. Cannot process.")
+		return nil, uniqueReqErr
+	}
+
+	defer handler.deleteChannel(txid)
+
+	// Send GET_STATE_MULTIPLE message to validator chaincode support
+	payload := &pb.GetStateMultiple{Keys: keys}
+	payloadBytes, err := proto.Marshal(payload)
+	if err != nil {
+		return nil, errors.New("Failed to process get state request")
+	}
+	msg := &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_GET_STATE_MULTIPLE, Payload: payloadBytes, Txid: txid}
+	chaincodeLogger.Debugf("[%s]Sending %s", shorttxid(msg.Txid), pb.ChaincodeMessage_GET_STATE_MULTIPLE)
+	responseMsg, err := handler.sendReceive(msg, respChan)
+	if err != nil {
+		chaincodeLogger.Errorf("[%s]error sending GET_STATE_MULTIPLE %s", shorttxid(txid), err)
+		return nil, errors.New("could not send msg")
+	}
+
+	if responseMsg.Type.String() == pb.ChaincodeMessage_RESPONSE.String() {
+		// Success response
+		chaincodeLogger.Debugf("[%s]GetStateMultiple received payload %s", shorttxid(responseMsg.Txid), pb.ChaincodeMessage_RESPONSE)
+
+		stateResponse := &pb.GetStateMultipleResponse{}
+		unmarshalErr := proto.Unmarshal(responseMsg.Payload, stateResponse)
+		if unmarshalErr != nil {
+			chaincodeLogger.Errorf("[%s]unmarshall error", shorttxid(responseMsg.Txid))
+			return nil, errors.New("Error unmarshalling GetStateMultipleResponse.")
+		}
+		if len(stateResponse.Values) != len(keys) {
+			return nil, fmt.Errorf("Expected %d values, received %d", len(keys), len(stateResponse.Values))
+		}
+
+		// As for GetState, the value of a key that does not exist is nil
+		for i, value := range stateResponse.Values {
+			if len(value) == 0 {
+				stateResponse.Values[i] = nil
+			}
+		}
+		return stateResponse.Values, nil
+	}
+	if responseMsg.Type.String() == pb.ChaincodeMessage_ERROR.String() {
+		// Error response
+		chaincodeLogger.Errorf("[%s]GetStateMultiple received error %s", shorttxid(responseMsg.Txid), pb.ChaincodeMessage_ERROR)
+		return nil, errors.New(string(responseMsg.Payload[:]))
+	}
+
+	// Incorrect chaincode message received
+	chaincodeLogger.Errorf("[%s]Incorrect chaincode message %s received. Expecting %s or %s", shorttxid(responseMsg.Txid), responseMsg.Type, pb.ChaincodeMessage_RESPONSE, pb.ChaincodeMessage_ERROR)
+	return nil, errors.New("Incorrect chaincode message received")
+}
+
+// handlePutState communicates with the validator to put state information into the ledger. A ttl greater than
+// zero sets the number of blocks after which the key expires.
+func (handler *Handler) handlePutState(key string, value []byte, ttl uint64, txid string) error {
 	// Check if this is a transaction
 	chaincodeLogger.Debugf("[%s]Inside putstate", shorttxid(txid))
-	payload := &pb.PutStateInfo{Key: key, Value: value}
+	payload := &pb.PutStateInfo{Key: key, Value: value, Ttl: ttl}
 	payloadBytes, err := proto.Marshal(payload)
 	if err != nil {
 		return errors.New("Failed to process put state request")
@@ -506,7 +606,48 @@ func (handler *Handler) handleDelState(key string, txid string) error {
 	return errors.New("Incorrect chaincode message received")
 }
 
-func (handler *Handler) handleGetStateByRange(startKey, endKey string, txid string) (*pb.QueryStateResponse, error) {
+// handleDelStateByRange communicates with the validator to delete a range of keys from the state in the ledger.
+func (handler *Handler) handleDelStateByRange(startKey, endKey string, txid string) error {
+	// Create the channel on which to communicate the response from validating peer
+	respChan, uniqueReqErr := handler.createChannel(txid)
+	if uniqueReqErr != nil {
+		chaincodeLogger.Errorf("[%s]Another state request pending for this Txid. Cannot process create createChannel.", shorttxid(txid))
+		return uniqueReqErr
+	}
+
+	defer handler.deleteChannel(txid)
+
+	// Send DEL_STATE_BY_RANGE message to validator chaincode support
+	payload := &pb.DelStateByRange{StartKey: startKey, EndKey: endKey}
+	payloadBytes, err := proto.Marshal(payload)
+	if err != nil {
+		return errors.New("Failed to process delete state by range request")
+	}
+	msg := &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_DEL_STATE_BY_RANGE, Payload: payloadBytes, Txid: txid}
+	chaincodeLogger.Debugf("[%s]Sending %s", shorttxid(msg.Txid), pb.ChaincodeMessage_DEL_STATE_BY_RANGE)
+	responseMsg, err := handler.sendReceive(msg, respChan)
+	if err != nil {
+		chaincodeLogger.Errorf("[%s]error sending DEL_STATE_BY_RANGE %s", shorttxid(msg.Txid), pb.ChaincodeMessage_DEL_STATE_BY_RANGE)
+		return errors.New("could not send msg")
+	}
+
+	if responseMsg.Type.String() == pb.ChaincodeMessage_RESPONSE.String() {
+		// Success response
+		chaincodeLogger.Debugf("[%s]Received %s. Successfully deleted state by range", msg.Txid, pb.ChaincodeMessage_RESPONSE)
+		return nil
+	}
+	if responseMsg.Type.String() == pb.ChaincodeMessage_ERROR.String() {
+		// Error response
+		chaincodeLogger.Errorf("[%s]Received %s. Payload: %s", msg.Txid, pb.ChaincodeMessage_ERROR, responseMsg.Payload)
+		return errors.New(string(responseMsg.Payload[:]))
+	}
+
+	// Incorrect chaincode message received
+	chaincodeLogger.Errorf("[%s]Incorrect chaincode message %s received. Expecting %s or %s", shorttxid(responseMsg.Txid), responseMsg.Type, pb.ChaincodeMessage_RESPONSE, pb.ChaincodeMessage_ERROR)
+	return errors.New("Incorrect chaincode message received")
+}
+
+func (handler *Handler) handleGetStateByRange(startKey, endKey string, pageSize int32, bookmark string, txid string) (*pb.QueryStateResponse, error) {
 	// Create the channel on which to communicate the response from validating peer
 	respChan, uniqueReqErr := handler.createChannel(txid)
 	if uniqueReqErr != nil {
@@ -517,7 +658,7 @@ func (handler *Handler) handleGetStateByRange(startKey, endKey string, txid stri
 	defer handler.deleteChannel(txid)
 
 	// Send GET_STATE_BY_RANGE message to validator chaincode support
-	payload := &pb.GetStateByRange{StartKey: startKey, EndKey: endKey}
+	payload := &pb.GetStateByRange{StartKey: startKey, EndKey: endKey, PageSize: pageSize, Bookmark: bookmark}
 	payloadBytes, err := proto.Marshal(payload)
 	if err != nil {
 		return nil, errors.New("Failed to process range query state request")
@@ -650,7 +791,7 @@ func (handler *Handler) handleQueryStateClose(id, txid string) (*pb.QueryStateRe
 	return nil, errors.New("Incorrect chaincode message received")
 }
 
-func (handler *Handler) handleGetQueryResult(query string, txid string) (*pb.QueryStateResponse, error) {
+func (handler *Handler) handleGetQueryResult(query string, fields []string, txid string) (*pb.QueryStateResponse, error) {
 	// Create the channel on which to communicate the response from validating peer
 	respChan, uniqueReqErr := handler.createChannel(txid)
 	if uniqueReqErr != nil {
@@ -661,7 +802,7 @@ func (handler *Handler) handleGetQueryResult(query string, txid string) (*pb.Que
 	defer handler.deleteChannel(txid)
 
 	// Send GET_QUERY_RESULT message to validator chaincode support
-	payload := &pb.GetQueryResult{Query: query}
+	payload := &pb.GetQueryResult{Query: query, Fields: fields}
 	payloadBytes, err := proto.Marshal(payload)
 	if err != nil {
 		return nil, errors.New("Failed to process query state request")
@@ -698,7 +839,9 @@ func (handler *Handler) handleGetQueryResult(query string, txid string) (*pb.Que
 	return nil, errors.New("Incorrect chaincode message received")
 }
 
-func (handler *Handler) handleGetHistoryForKey(key string, txid string) (*pb.QueryStateResponse, error) {
+// handleGetHistoryForKey communicates with the validator to fetch the history of the key. The options are nil
+// for the whole history of the key
+func (handler *Handler) handleGetHistoryForKey(key string, options *pb.HistoryQueryOptions, txid string) (*pb.QueryStateResponse, error) {
 	// Create the channel on which to communicate the response from validating peer
 	respChan, uniqueReqErr := handler.createChannel(txid)
 	if uniqueReqErr != nil {
@@ -709,7 +852,7 @@ func (handler *Handler) handleGetHistoryForKey(key string, txid string) (*pb.Que
 	defer handler.deleteChannel(txid)
 
 	// Send GET_HISTORY_FOR_KEY message to validator chaincode support
-	payload := &pb.GetHistoryForKey{Key: key}
+	payload := &pb.GetHistoryForKey{Key: key, Options: options}
 	payloadBytes, err := proto.Marshal(payload)
 	if err != nil {
 		return nil, errors.New("Failed to process query state request")
@@ -746,6 +889,55 @@ func (handler *Handler) handleGetHistoryForKey(key string, txid string) (*pb.Que
 	return nil, errors.New("Incorrect chaincode message received")
 }
 
+// handleGetTotalForKeyPrefix communicates with the validator to add up the values of the keys with the given prefix.
+func (handler *Handler) handleGetTotalForKeyPrefix(keyPrefix string, fieldName string, txid string) (*pb.TotalForKeyPrefixResponse, error) {
+	// Create the channel on which to communicate the response from validating peer
+	respChan, uniqueReqErr := handler.createChannel(txid)
+	if uniqueReqErr != nil {
+		chaincodeLogger.Debugf("[%s]Another state request pending for this Txid. Cannot process.", shorttxid(txid))
+		return nil, uniqueReqErr
+	}
+
+	defer handler.deleteChannel(txid)
+
+	// Send GET_TOTAL_FOR_KEY_PREFIX message to validator chaincode support
+	payload := &pb.GetTotalForKeyPrefix{KeyPrefix: keyPrefix, FieldName: fieldName}
+	payloadBytes, err := proto.Marshal(payload)
+	if err != nil {
+		return nil, errors.New("Failed to process total request")
+	}
+	msg := &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_GET_TOTAL_FOR_KEY_PREFIX, Payload: payloadBytes, Txid: txid}
+	chaincodeLogger.Debugf("[%s]Sending %s", shorttxid(msg.Txid), pb.ChaincodeMessage_GET_TOTAL_FOR_KEY_PREFIX)
+	responseMsg, err := handler.sendReceive(msg, respChan)
+	if err != nil {
+		chaincodeLogger.Errorf("[%s]error sending %s", shorttxid(msg.Txid), pb.ChaincodeMessage_GET_TOTAL_FOR_KEY_PREFIX)
+		return nil, errors.New("could not send msg")
+	}
+
+	if responseMsg.Type.String() == pb.ChaincodeMessage_RESPONSE.String() {
+		// Success response
+		chaincodeLogger.Debugf("[%s]Received %s. Successfully got total", shorttxid(responseMsg.Txid), pb.ChaincodeMessage_RESPONSE)
+
+		totalResponse := &pb.TotalForKeyPrefixResponse{}
+		unmarshalErr := proto.Unmarshal(responseMsg.Payload, totalResponse)
+		if unmarshalErr != nil {
+			chaincodeLogger.Errorf("[%s]unmarshall error", shorttxid(responseMsg.Txid))
+			return nil, errors.New("Error unmarshalling TotalForKeyPrefixResponse.")
+		}
+
+		return totalResponse, nil
+	}
+	if responseMsg.Type.String() == pb.ChaincodeMessage_ERROR.String() {
+		// Error response
+		chaincodeLogger.Errorf("[%s]Received %s", shorttxid(responseMsg.Txid), pb.ChaincodeMessage_ERROR)
+		return nil, errors.New(string(responseMsg.Payload[:]))
+	}
+
+	// Incorrect chaincode message received
+	chaincodeLogger.Errorf("Incorrect chaincode message %s recieved. Expecting %s or %s", responseMsg.Type, pb.ChaincodeMessage_RESPONSE, pb.ChaincodeMessage_ERROR)
+	return nil, errors.New("Incorrect chaincode message received")
+}
+
 // handleInvokeChaincode communicates with the validator to invoke another chaincode.
 func (handler *Handler) handleInvokeChaincode(chaincodeName string, args [][]byte, txid string) pb.Response {
 	chaincodeID := &pb.ChaincodeID{Name: chaincodeName}
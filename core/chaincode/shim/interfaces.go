@@ -59,12 +59,39 @@ type ChaincodeStubInterface interface {
 	// GetState returns the byte array value specified by the `key`.
 	GetState(key string) ([]byte, error)
 
+	// GetStateMultiple returns the values of the given keys, in the order of the
+	// keys. The values are fetched from the ledger in a single round trip, which
+	// is cheaper than calling GetState for each of the keys. The value of a key
+	// that does not exist is nil.
+	GetStateMultiple(keys []string) ([][]byte, error)
+
+	// GetStateHash returns the SHA256 hash of the value of the given key, or nil
+	// if the key does not exist. Only the hash is sent to the chaincode, which
+	// makes it cheaper than GetState for a chaincode that only compares or
+	// verifies large values. As for GetState, the key is recorded as read.
+	GetStateHash(key string) ([]byte, error)
+
 	// PutState writes the specified `value` and `key` into the ledger.
 	PutState(key string, value []byte) error
 
+	// PutStateWithTTL writes the specified `value` and `key` into the ledger,
+	// along with the number of blocks after which the key expires. The key is
+	// deleted from the ledger on the commit of the ttl-th block after the block
+	// that commits the transaction, unless it is written again in the meantime;
+	// a write with PutState clears the expiry. This saves chaincode that caches
+	// data from cleaning it up with separate transactions.
+	PutStateWithTTL(key string, value []byte, ttl uint64) error
+
 	// DelState removes the specified `key` and its value from the ledger.
 	DelState(key string) error
 
+	// DelStateByRange removes the keys in the range [startKey, endKey) and their
+	// values from the ledger. An empty endKey denotes the end of the key space.
+	// The keys are not read by the chaincode; the range is expanded to the keys
+	// present when the transaction is committed. The keys in the range that are
+	// written by the transaction after the call are kept.
+	DelStateByRange(startKey, endKey string) error
+
 	// GetStateByRange function can be invoked by a chaincode to query of a range
 	// of keys in the state. Assuming the startKey and endKey are in lexical
 	// an iterator will be returned that can be used to iterate over all keys
@@ -72,6 +99,13 @@ type ChaincodeStubInterface interface {
 	// returned by the iterator is random.
 	GetStateByRange(startKey, endKey string) (StateQueryIteratorInterface, error)
 
+	// GetStateByRangeWithPagination returns an iterator over a page of at most
+	// pageSize keys of the range, starting from the given bookmark, along with
+	// the metadata of the page. An empty bookmark starts from the startKey. The
+	// bookmark of the metadata is passed to fetch the next page and is empty
+	// when there are no more keys in the range.
+	GetStateByRangeWithPagination(startKey, endKey string, pageSize int32, bookmark string) (StateQueryIteratorInterface, *pb.QueryResponseMetadata, error)
+
 	// GetStateByPartialCompositeKey function can be invoked by a chaincode to query the
 	// state based on a given partial composite key. This function returns an
 	// iterator which can be used to iterate over all composite keys whose prefix
@@ -81,6 +115,11 @@ type ChaincodeStubInterface interface {
 	// valid utf8 strings and should not contain U+0000 (nil byte) and U+10FFFF (biggest and unallocated code point)
 	GetStateByPartialCompositeKey(objectType string, keys []string) (StateQueryIteratorInterface, error)
 
+	// GetStateByPartialCompositeKeyWithPagination returns a page of the composite
+	// keys whose prefix matches the given partial composite key, as
+	// GetStateByRangeWithPagination does for a range.
+	GetStateByPartialCompositeKeyWithPagination(objectType string, keys []string, pageSize int32, bookmark string) (StateQueryIteratorInterface, *pb.QueryResponseMetadata, error)
+
 	// Given a list of attributes, CreateCompositeKey function combines these attributes
 	// to form a composite key. The objectType and attributes are expected to have only
 	// valid utf8 strings and should not contain U+0000 (nil byte) and U+10FFFF (biggest and unallocated code point)
@@ -97,10 +136,35 @@ type ChaincodeStubInterface interface {
 	// the query result set
 	GetQueryResult(query string) (StateQueryIteratorInterface, error)
 
+	// GetQueryResultWithFields function can be invoked by a chaincode to perform a
+	// rich query against state database, returning only the given fields of the
+	// matching JSON documents. Nested fields are specified in dotted notation
+	// (e.g. "owner.name")
+	GetQueryResultWithFields(query string, fields []string) (StateQueryIteratorInterface, error)
+
 	// GetHistoryForKey function can be invoked by a chaincode to return a history of
 	// key values across time. GetHistoryForKey is intended to be used for read-only queries.
 	GetHistoryForKey(key string) (StateQueryIteratorInterface, error)
 
+	// GetHistoryForKeyWithOptions returns the history of the key narrowed down
+	// and ordered as per the options. The history is returned from the oldest
+	// modification unless options.Reverse is set, and is restricted to the
+	// blocks from options.StartBlock to options.EndBlock, inclusive, where an
+	// EndBlock of zero does not bound the history. At most options.Limit
+	// modifications are returned if the limit is positive, and the deletes of
+	// the key are skipped unless options.IncludeDeletes is set.
+	GetHistoryForKeyWithOptions(key string, options *pb.HistoryQueryOptions) (StateQueryIteratorInterface, error)
+
+	// GetTotalForKeyPrefix function can be invoked by a chaincode to add up the
+	// numeric field with the given name in the JSON values of the keys that begin
+	// with the given prefix. The sum is returned along with the count of the values
+	// that contributed to it; the values that are not JSON or that do not contain
+	// the numeric field are skipped. The aggregation is performed by the peer so
+	// that the values are not transferred to the chaincode, whereas the keys are
+	// recorded in the read set so that the transaction is invalidated if any of
+	// them changes before the commit.
+	GetTotalForKeyPrefix(keyPrefix string, fieldName string) (float64, uint64, error)
+
 	// GetCreator returns SignatureHeader.Creator of the proposal
 	// this Stub refers to.
 	GetCreator() ([]byte, error)
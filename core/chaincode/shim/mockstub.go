@@ -20,8 +20,11 @@ package shim
 
 import (
 	"container/list"
+	"crypto/sha256"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/golang/protobuf/ptypes/timestamp"
@@ -57,6 +60,21 @@ type MockStub struct {
 	// stores a transaction uuid while being Invoked / Deployed
 	// TODO if a chaincode uses recursion this may need to be a stack of TxIDs or possibly a reference counting map
 	TxID string
+
+	// History keeps the writes of the ended transactions to each key, the oldest first
+	History map[string][]*MockKeyModification
+
+	// the keys written by the current transaction and their last values, which enter
+	// the History when the transaction ends. A delete is recorded as a nil value
+	pendingKeys   []string
+	pendingWrites map[string][]byte
+}
+
+// MockKeyModification is a write of a key recorded in the History of the MockStub.
+// The Value of a delete is nil
+type MockKeyModification struct {
+	TxID  string
+	Value []byte
 }
 
 func (stub *MockStub) GetTxID() string {
@@ -94,11 +112,31 @@ func (stub *MockStub) MockTransactionStart(txid string) {
 	stub.TxID = txid
 }
 
-// End a mocked transaction, clearing the UUID.
+// End a mocked transaction, clearing the UUID. The writes of the transaction
+// are added to the History.
 func (stub *MockStub) MockTransactionEnd(uuid string) {
+	for _, key := range stub.pendingKeys {
+		stub.History[key] = append(stub.History[key], &MockKeyModification{TxID: stub.TxID, Value: stub.pendingWrites[key]})
+	}
+	stub.pendingKeys = nil
+	stub.pendingWrites = nil
 	stub.TxID = ""
 }
 
+// recordWrite records the write of the key by the current transaction for the History
+func (stub *MockStub) recordWrite(key string, value []byte) {
+	if stub.TxID == "" {
+		return
+	}
+	if stub.pendingWrites == nil {
+		stub.pendingWrites = make(map[string][]byte)
+	}
+	if _, ok := stub.pendingWrites[key]; !ok {
+		stub.pendingKeys = append(stub.pendingKeys, key)
+	}
+	stub.pendingWrites[key] = value
+}
+
 // Register a peer chaincode with this MockStub
 // invokableChaincodeName is the name or hash of the peer
 // otherStub is a MockStub of the peer, already intialised
@@ -131,6 +169,26 @@ func (stub *MockStub) GetState(key string) ([]byte, error) {
 	return value, nil
 }
 
+// GetStateMultiple retrieves the values for the given keys from the ledger
+func (stub *MockStub) GetStateMultiple(keys []string) ([][]byte, error) {
+	values := make([][]byte, len(keys))
+	for i, key := range keys {
+		values[i] = stub.State[key]
+	}
+	mockLogger.Debug("MockStub", stub.Name, "Getting", keys, values)
+	return values, nil
+}
+
+// GetStateHash returns the SHA256 hash of the value of the given key, or nil if the key does not exist
+func (stub *MockStub) GetStateHash(key string) ([]byte, error) {
+	value, ok := stub.State[key]
+	if !ok {
+		return nil, nil
+	}
+	hash := sha256.Sum256(value)
+	return hash[:], nil
+}
+
 // PutState writes the specified `value` and `key` into the ledger.
 func (stub *MockStub) PutState(key string, value []byte) error {
 	if stub.TxID == "" {
@@ -140,6 +198,7 @@ func (stub *MockStub) PutState(key string, value []byte) error {
 
 	mockLogger.Debug("MockStub", stub.Name, "Putting", key, value)
 	stub.State[key] = value
+	stub.recordWrite(key, value)
 
 	// insert key into ordered list of keys
 	for elem := stub.Keys.Front(); elem != nil; elem = elem.Next() {
@@ -174,10 +233,20 @@ func (stub *MockStub) PutState(key string, value []byte) error {
 	return nil
 }
 
+// PutStateWithTTL writes the specified `value` and `key` into the ledger. As the
+// mock does not commit blocks, the key does not expire.
+func (stub *MockStub) PutStateWithTTL(key string, value []byte, ttl uint64) error {
+	if ttl == 0 {
+		return errors.New("ttl must be greater than zero")
+	}
+	return stub.PutState(key, value)
+}
+
 // DelState removes the specified `key` and its value from the ledger.
 func (stub *MockStub) DelState(key string) error {
 	mockLogger.Debug("MockStub", stub.Name, "Deleting", key, stub.State[key])
 	delete(stub.State, key)
+	stub.recordWrite(key, nil)
 
 	for elem := stub.Keys.Front(); elem != nil; elem = elem.Next() {
 		if strings.Compare(key, elem.Value.(string)) == 0 {
@@ -188,10 +257,63 @@ func (stub *MockStub) DelState(key string) error {
 	return nil
 }
 
+// DelStateByRange removes the keys in the range [startKey, endKey) and their values from the ledger.
+func (stub *MockStub) DelStateByRange(startKey, endKey string) error {
+	var keys []string
+	for elem := stub.Keys.Front(); elem != nil; elem = elem.Next() {
+		key := elem.Value.(string)
+		if key >= startKey && (endKey == "" || key < endKey) {
+			keys = append(keys, key)
+		}
+	}
+	for _, key := range keys {
+		if err := stub.DelState(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (stub *MockStub) GetStateByRange(startKey, endKey string) (StateQueryIteratorInterface, error) {
 	return NewMockStateRangeQueryIterator(stub, startKey, endKey), nil
 }
 
+// GetStateByRangeWithPagination returns a page of at most pageSize keys of the
+// range [startKey, endKey), starting from the given bookmark.
+func (stub *MockStub) GetStateByRangeWithPagination(startKey, endKey string, pageSize int32, bookmark string) (StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	if pageSize <= 0 {
+		return nil, nil, errors.New("pageSize must be greater than zero")
+	}
+	if bookmark > startKey {
+		startKey = bookmark
+	}
+	var page []string
+	metadata := &pb.QueryResponseMetadata{}
+	for elem := stub.Keys.Front(); elem != nil; elem = elem.Next() {
+		key := elem.Value.(string)
+		if key < startKey {
+			continue
+		}
+		if endKey != "" && key >= endKey {
+			break
+		}
+		if int32(len(page)) == pageSize {
+			metadata.Bookmark = key
+			break
+		}
+		page = append(page, key)
+	}
+	metadata.FetchedRecordsCount = int32(len(page))
+	iter := &MockStateRangeQueryIterator{Stub: stub}
+	if len(page) > 0 {
+		// the EndKey of the iterator is inclusive
+		iter.StartKey = page[0]
+		iter.EndKey = page[len(page)-1]
+		iter.Current = stub.Keys.Front()
+	}
+	return iter, metadata, nil
+}
+
 // GetQueryResult function can be invoked by a chaincode to perform a
 // rich query against state database.  Only supported by state database implementations
 // that support rich query.  The query string is in the syntax of the underlying
@@ -204,10 +326,109 @@ func (stub *MockStub) GetQueryResult(query string) (StateQueryIteratorInterface,
 	return nil, errors.New("Not Implemented")
 }
 
+// GetQueryResultWithFields function can be invoked by a chaincode to perform a
+// rich query against state database, returning only the given fields of the
+// matching JSON documents
+func (stub *MockStub) GetQueryResultWithFields(query string, fields []string) (StateQueryIteratorInterface, error) {
+	// Not implemented since the mock engine does not have a query engine.
+	return nil, errors.New("Not Implemented")
+}
+
 // GetHistoryForKey function can be invoked by a chaincode to return a history of
 // key values across time. GetHistoryForKey is intended to be used for read-only queries.
+// As on a peer, the history contains the writes of the ended transactions only and
+// the keys are the ids of the transactions.
 func (stub *MockStub) GetHistoryForKey(key string) (StateQueryIteratorInterface, error) {
-	return nil, errors.New("Not Implemented")
+	iter := &queryResultsIterator{}
+	for _, modification := range stub.History[key] {
+		iter.keysAndValues = append(iter.keysAndValues, &pb.QueryStateKeyValue{Key: modification.TxID, Value: modification.Value})
+	}
+	return iter, nil
+}
+
+// GetHistoryForKeyWithPagination returns a page of at most pageSize records of
+// the history of the key, oldest first, starting from the given bookmark. An
+// empty bookmark starts from the oldest record and the bookmark is the position
+// of the first record of the page in the history. The returned metadata contains
+// the number of the records in the page and the bookmark of the next page, which
+// is empty when there are no more records. This is only available to the unit
+// tests, as the peer does not page through the history of a key.
+func (stub *MockStub) GetHistoryForKeyWithPagination(key string, pageSize int32, bookmark string) (StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	if pageSize <= 0 {
+		return nil, nil, errors.New("pageSize must be greater than zero")
+	}
+	start := 0
+	if bookmark != "" {
+		var err error
+		if start, err = strconv.Atoi(bookmark); err != nil || start < 0 {
+			return nil, nil, fmt.Errorf("Invalid bookmark [%s] for the history of a key", bookmark)
+		}
+	}
+	history := stub.History[key]
+	page := &queryResultsIterator{}
+	metadata := &pb.QueryResponseMetadata{}
+	for position := start; position < len(history); position++ {
+		if int32(len(page.keysAndValues)) == pageSize {
+			metadata.Bookmark = strconv.Itoa(position)
+			break
+		}
+		page.keysAndValues = append(page.keysAndValues, &pb.QueryStateKeyValue{Key: history[position].TxID, Value: history[position].Value})
+	}
+	metadata.FetchedRecordsCount = int32(len(page.keysAndValues))
+	return page, metadata, nil
+}
+
+// GetHistoryForKeyWithOptions returns the history of the key narrowed down and
+// ordered as per the options. The MockStub does not record the blocks of the
+// writes, hence a range of blocks is not supported.
+func (stub *MockStub) GetHistoryForKeyWithOptions(key string, options *pb.HistoryQueryOptions) (StateQueryIteratorInterface, error) {
+	if options == nil {
+		return nil, errors.New("options must be set, use GetHistoryForKey for the whole history of the key")
+	}
+	if options.StartBlock != 0 || options.EndBlock != 0 {
+		return nil, errors.New("Not Implemented")
+	}
+	var modifications []*MockKeyModification
+	for _, modification := range stub.History[key] {
+		if modification.Value != nil || options.IncludeDeletes {
+			modifications = append(modifications, modification)
+		}
+	}
+	iter := &queryResultsIterator{}
+	for i := range modifications {
+		if options.Limit > 0 && int32(len(iter.keysAndValues)) == options.Limit {
+			break
+		}
+		modification := modifications[i]
+		if options.Reverse {
+			modification = modifications[len(modifications)-1-i]
+		}
+		iter.keysAndValues = append(iter.keysAndValues, &pb.QueryStateKeyValue{Key: modification.TxID, Value: modification.Value})
+	}
+	return iter, nil
+}
+
+// GetTotalForKeyPrefix function can be invoked by a chaincode to add up the
+// numeric field with the given name in the JSON values of the keys that begin
+// with the given prefix. The sum and the count of the values that contributed
+// to it are returned.
+func (stub *MockStub) GetTotalForKeyPrefix(keyPrefix string, fieldName string) (float64, uint64, error) {
+	var sum float64
+	var count uint64
+	for key, value := range stub.State {
+		if !strings.HasPrefix(key, keyPrefix) {
+			continue
+		}
+		fields := make(map[string]interface{})
+		if err := json.Unmarshal(value, &fields); err != nil {
+			continue
+		}
+		if number, ok := fields[fieldName].(float64); ok {
+			sum += number
+			count++
+		}
+	}
+	return sum, count, nil
 }
 
 //GetStateByPartialCompositeKey function can be invoked by a chaincode to query the
@@ -220,6 +441,12 @@ func (stub *MockStub) GetStateByPartialCompositeKey(objectType string, attribute
 	return getStateByPartialCompositeKey(stub, objectType, attributes)
 }
 
+// GetStateByPartialCompositeKeyWithPagination returns a page of the composite
+// keys whose prefix matches the given partial composite key.
+func (stub *MockStub) GetStateByPartialCompositeKeyWithPagination(objectType string, keys []string, pageSize int32, bookmark string) (StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	return getStateByPartialCompositeKeyWithPagination(stub, objectType, keys, pageSize, bookmark)
+}
+
 // CreateCompositeKey combines the list of attributes
 //to form a composite key.
 func (stub *MockStub) CreateCompositeKey(objectType string, attributes []string) (string, error) {
@@ -289,6 +516,7 @@ func NewMockStub(name string, cc Chaincode) *MockStub {
 	s.State = make(map[string][]byte)
 	s.Invokables = make(map[string]*MockStub)
 	s.Keys = list.New()
+	s.History = make(map[string][]*MockKeyModification)
 
 	return s
 }
@@ -17,11 +17,15 @@ limitations under the License.
 package shim
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 
+	pb "github.com/hyperledger/fabric/protos/peer"
 	"github.com/spf13/viper"
 )
 
@@ -170,6 +174,220 @@ func TestGetStateByPartialCompositeKey(t *testing.T) {
 	}
 }
 
+func TestGetStateMultiple(t *testing.T) {
+	stub := NewMockStub("GetStateMultipleTest", nil)
+	stub.MockTransactionStart("init")
+	stub.PutState("key1", []byte("value1"))
+	stub.PutState("key2", []byte("value2"))
+	stub.MockTransactionEnd("init")
+
+	values, err := stub.GetStateMultiple([]string{"key2", "missing", "key1"})
+	if err != nil || len(values) != 3 {
+		fmt.Println("Expected 3 values, got", values, err)
+		t.FailNow()
+	}
+	if string(values[0]) != "value2" || values[1] != nil || string(values[2]) != "value1" {
+		fmt.Println("Expected the values in the order of the keys, got", values)
+		t.FailNow()
+	}
+}
+
+func TestGetStateHash(t *testing.T) {
+	stub := NewMockStub("GetStateHashTest", nil)
+	stub.MockTransactionStart("init")
+	stub.PutState("key1", []byte("value1"))
+	stub.MockTransactionEnd("init")
+
+	expectedHash := sha256.Sum256([]byte("value1"))
+	hash, err := stub.GetStateHash("key1")
+	if err != nil || !bytes.Equal(hash, expectedHash[:]) {
+		fmt.Println("Expected the SHA256 hash of the value, got", hash, err)
+		t.FailNow()
+	}
+	hash, err = stub.GetStateHash("missing")
+	if err != nil || hash != nil {
+		fmt.Println("Expected nil hash for a missing key, got", hash, err)
+		t.FailNow()
+	}
+}
+
+func TestGetStateByPartialCompositeKeyWithPagination(t *testing.T) {
+	stub := NewMockStub("GetStateByPartialCompositeKeyWithPaginationTest", nil)
+	stub.MockTransactionStart("init")
+	var expectKeys []string
+	for _, color := range []string{"blue", "green", "red"} {
+		compositeKey, _ := stub.CreateCompositeKey("marble", []string{"set-1", color})
+		stub.PutState(compositeKey, []byte(color))
+		expectKeys = append(expectKeys, compositeKey)
+	}
+	otherKey, _ := stub.CreateCompositeKey("marble", []string{"set-2", "red"})
+	stub.PutState(otherKey, []byte("red"))
+	stub.MockTransactionEnd("init")
+
+	var keys []string
+	bookmark := ""
+	for page := 0; ; page++ {
+		rqi, metadata, err := stub.GetStateByPartialCompositeKeyWithPagination("marble", []string{"set-1"}, 2, bookmark)
+		if err != nil {
+			fmt.Println("Page", page, "failed", err)
+			t.FailNow()
+		}
+		count := int32(0)
+		for rqi.HasNext() {
+			key, _, _ := rqi.Next()
+			keys = append(keys, key)
+			count++
+		}
+		if count != metadata.FetchedRecordsCount {
+			fmt.Println("Expected", count, "fetched records, got", metadata.FetchedRecordsCount)
+			t.FailNow()
+		}
+		bookmark = metadata.Bookmark
+		if bookmark == "" {
+			break
+		}
+	}
+	if len(keys) != len(expectKeys) {
+		fmt.Println("Expected keys", expectKeys, "got", keys)
+		t.FailNow()
+	}
+	for i := range keys {
+		if keys[i] != expectKeys[i] {
+			fmt.Println("Expected key", expectKeys[i], "got", keys[i])
+			t.FailNow()
+		}
+	}
+}
+
+func TestGetHistoryForKey(t *testing.T) {
+	stub := NewMockStub("GetHistoryForKeyTest", nil)
+	stub.MockTransactionStart("tx1")
+	stub.PutState("key1", []byte("value1"))
+	stub.PutState("key1", []byte("value2"))
+	// the writes of a transaction enter the history when it ends
+	rqi, _ := stub.GetHistoryForKey("key1")
+	if rqi.HasNext() {
+		fmt.Println("Expected no history before the end of the transaction")
+		t.FailNow()
+	}
+	stub.MockTransactionEnd("tx1")
+	stub.MockTransactionStart("tx2")
+	stub.DelState("key1")
+	stub.MockTransactionEnd("tx2")
+	stub.MockTransactionStart("tx3")
+	stub.PutState("key1", []byte("value3"))
+	stub.MockTransactionEnd("tx3")
+
+	expectTxIDs := []string{"tx1", "tx2", "tx3"}
+	expectValues := []string{"value2", "", "value3"}
+	rqi, _ = stub.GetHistoryForKey("key1")
+	for i := range expectTxIDs {
+		txID, value, err := rqi.Next()
+		if err != nil || txID != expectTxIDs[i] || string(value) != expectValues[i] {
+			fmt.Println("Expected", expectTxIDs[i], expectValues[i], "got", txID, string(value), err)
+			t.FailNow()
+		}
+	}
+	if rqi.HasNext() {
+		fmt.Println("Expected", len(expectTxIDs), "history records")
+		t.FailNow()
+	}
+	rqi.Close()
+
+	var txIDs []string
+	bookmark := ""
+	for page := 0; ; page++ {
+		rqi, metadata, err := stub.GetHistoryForKeyWithPagination("key1", 2, bookmark)
+		if err != nil {
+			fmt.Println("Page", page, "failed", err)
+			t.FailNow()
+		}
+		for rqi.HasNext() {
+			txID, _, _ := rqi.Next()
+			txIDs = append(txIDs, txID)
+		}
+		bookmark = metadata.Bookmark
+		if bookmark == "" {
+			break
+		}
+	}
+	if strings.Join(txIDs, ",") != strings.Join(expectTxIDs, ",") {
+		fmt.Println("Expected history", expectTxIDs, "got", txIDs)
+		t.FailNow()
+	}
+	if _, _, err := stub.GetHistoryForKeyWithPagination("key1", 2, "not-a-bookmark"); err == nil {
+		fmt.Println("Expected an error for an invalid bookmark")
+		t.FailNow()
+	}
+
+	for _, testCase := range []struct {
+		options       *pb.HistoryQueryOptions
+		expectedTxIDs []string
+	}{
+		{&pb.HistoryQueryOptions{}, []string{"tx1", "tx3"}},
+		{&pb.HistoryQueryOptions{IncludeDeletes: true, Reverse: true}, []string{"tx3", "tx2", "tx1"}},
+		{&pb.HistoryQueryOptions{IncludeDeletes: true, Reverse: true, Limit: 2}, []string{"tx3", "tx2"}},
+	} {
+		rqi, err := stub.GetHistoryForKeyWithOptions("key1", testCase.options)
+		if err != nil {
+			fmt.Println("History with options", testCase.options, "failed", err)
+			t.FailNow()
+		}
+		txIDs = nil
+		for rqi.HasNext() {
+			txID, _, _ := rqi.Next()
+			txIDs = append(txIDs, txID)
+		}
+		if strings.Join(txIDs, ",") != strings.Join(testCase.expectedTxIDs, ",") {
+			fmt.Println("Expected history", testCase.expectedTxIDs, "with options", testCase.options, "got", txIDs)
+			t.FailNow()
+		}
+	}
+	if _, err := stub.GetHistoryForKeyWithOptions("key1", nil); err == nil {
+		fmt.Println("Expected an error for missing options")
+		t.FailNow()
+	}
+}
+
+func TestDelStateByRange(t *testing.T) {
+	stub := NewMockStub("DelStateByRangeTest", nil)
+	stub.MockTransactionStart("init")
+	for _, key := range []string{"key1", "key2", "key3", "key4"} {
+		stub.PutState(key, []byte("value"))
+	}
+	stub.DelStateByRange("key2", "key4")
+	stub.MockTransactionEnd("init")
+
+	values, _ := stub.GetStateMultiple([]string{"key1", "key2", "key3", "key4"})
+	if values[0] == nil || values[1] != nil || values[2] != nil || values[3] == nil {
+		fmt.Println("Expected key2 and key3 to be deleted, got", values)
+		t.FailNow()
+	}
+}
+
+func TestGetTotalForKeyPrefix(t *testing.T) {
+	stub := NewMockStub("GetTotalForKeyPrefixTest", nil)
+	stub.MockTransactionStart("init")
+	for _, marble := range []*Marble{{"marble", "marble1", "red", 5, "tom"}, {"marble", "marble2", "blue", 7, "jerry"}} {
+		marbleJSONBytes, _ := json.Marshal(marble)
+		stub.PutState(marble.Name, marbleJSONBytes)
+	}
+	stub.PutState("marble3", []byte("not-json"))
+	stub.PutState("other", []byte(`{"size":100}`))
+	stub.MockTransactionEnd("init")
+
+	sum, count, err := stub.GetTotalForKeyPrefix("marble", "size")
+	if err != nil || sum != 12 || count != 2 {
+		fmt.Println("Expected sum 12 and count 2, got", sum, count, err)
+		t.FailNow()
+	}
+	sum, count, _ = stub.GetTotalForKeyPrefix("marble", "color")
+	if sum != 0 || count != 0 {
+		fmt.Println("Expected sum 0 and count 0 for a non-numeric field, got", sum, count)
+		t.FailNow()
+	}
+}
+
 func TestGetStateByPartialCompositeKeyCollision(t *testing.T) {
 	stub := NewMockStub("GetStateByPartialCompositeKeyCollisionTest", nil)
 	stub.MockTransactionStart("init")
@@ -60,6 +60,11 @@ func (lc *LedgerCommitter) Commit(block *common.Block) error {
 	}
 
 	if err := lc.ledger.Commit(block); err != nil {
+		if _, ok := err.(*ledger.BlockAlreadyCommittedError); ok {
+			// redelivery of a block that is already committed, nothing to do
+			logger.Debugf("Skipping block: %s", err)
+			return nil
+		}
 		return err
 	}
 
@@ -68,6 +73,10 @@ func (lc *LedgerCommitter) Commit(block *common.Block) error {
 		logger.Errorf("Error sending block event %s", err)
 		return fmt.Errorf("Error sending block event %s", err)
 	}
+	if err := producer.SendProducerFilteredBlockEvent(block); err != nil {
+		logger.Errorf("Error sending filtered block event %s", err)
+		return fmt.Errorf("Error sending filtered block event %s", err)
+	}
 
 	return nil
 }
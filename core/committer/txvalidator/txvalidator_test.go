@@ -121,3 +121,28 @@ func TestNewTxValidator_DuplicateTransactions(t *testing.T) {
 	txsfltr := util.TxValidationFlags(block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER])
 	assert.True(t, txsfltr.IsInvalid(0))
 }
+
+func TestNewTxValidator_DuplicateTransactionsInBlock(t *testing.T) {
+	viper.Set("peer.fileSystemPath", "/tmp/fabric/txvalidatortest")
+	ledgermgmt.InitializeTestEnv()
+	defer ledgermgmt.CleanupTestEnv()
+	ledger, _ := ledgermgmt.CreateLedger("TestLedger")
+	defer ledger.Close()
+
+	tValidator := &txValidator{&mocktxvalidator.Support{LedgerVal: ledger}, &validator.MockVsccValidator{}}
+
+	simulator, _ := ledger.NewTxSimulator()
+	simulator.SetState("ns1", "key1", []byte("value1"))
+	simulator.Done()
+	simRes, _ := simulator.GetTxSimulationResults()
+	block := testutil.ConstructBlock(t, [][]byte{simRes, simRes}, true)
+	// the second transaction re-submits the first one
+	block.Data.Data[1] = block.Data.Data[0]
+
+	err := tValidator.Validate(block)
+	assert.NoError(t, err)
+
+	txsfltr := util.TxValidationFlags(block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER])
+	assert.True(t, txsfltr.IsSetTo(1, peer.TxValidationCode_DUPLICATE_TXID))
+	assert.False(t, txsfltr.IsSetTo(0, peer.TxValidationCode_DUPLICATE_TXID))
+}
@@ -99,6 +99,9 @@ func (v *txValidator) Validate(block *common.Block) error {
 	defer logger.Debug("END Block Validation")
 	// Initialize trans as valid here, then set invalidation reason code upon invalidation below
 	txsfltr := ledgerUtil.NewTxValidationFlags(len(block.Data.Data))
+	// the ids of the endorser transactions of the block that are not duplicates of earlier ones, so that
+	// every peer marks the same later occurrences of an id as duplicates
+	txIDsInBlock := make(map[string]bool)
 	for tIdx, d := range block.Data.Data {
 		if d != nil {
 			if env, err := utils.GetEnvelopeFromBlock(d); err != nil {
@@ -140,11 +143,24 @@ func (v *txValidator) Validate(block *common.Block) error {
 				if common.HeaderType(chdr.Type) == common.HeaderType_ENDORSER_TRANSACTION {
 					// Check duplicate transactions
 					txID := chdr.TxId
-					if _, err := v.support.Ledger().GetTransactionByID(txID); err == nil {
+					if txIDsInBlock[txID] {
+						logger.Error("Duplicate transaction found in the same block, ", txID, ", skipping")
+						txsfltr.SetFlag(tIdx, peer.TxValidationCode_DUPLICATE_TXID)
+						continue
+					}
+					exists, err := v.support.Ledger().TxExists(txID)
+					if err != nil {
+						// the transaction cannot be validated the same way as on the other peers
+						err = fmt.Errorf("Error looking up transaction %s in the ledger: %s", txID, err)
+						logger.Critical(err)
+						return err
+					}
+					if exists {
 						logger.Error("Duplicate transaction found, ", txID, ", skipping")
 						txsfltr.SetFlag(tIdx, peer.TxValidationCode_DUPLICATE_TXID)
 						continue
 					}
+					txIDsInBlock[txID] = true
 
 					//the payload is used to get headers
 					logger.Debug("Validating transaction vscc tx validate")
@@ -0,0 +1,85 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package privdata
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// collectionSeparator separates the name of a chaincode from the suffix of the key
+// under which the collection config package of the chaincode is stored by lccc
+const collectionSeparator = "~"
+
+// collectionSuffix is the suffix of the key under which the collection config package of a chaincode is stored by lccc
+const collectionSuffix = "collection"
+
+// BuildCollectionKVSKey returns the key under which lccc stores the collection config package of the given chaincode
+func BuildCollectionKVSKey(ccname string) string {
+	return ccname + collectionSeparator + collectionSuffix
+}
+
+// IsCollectionConfigKey returns true if the given key of lccc holds a collection config package
+func IsCollectionConfigKey(key string) bool {
+	return strings.HasSuffix(key, collectionSeparator+collectionSuffix)
+}
+
+// GetCCNameFromCollectionKVSKey returns the name of the chaincode whose collection config package is stored under the given key
+func GetCCNameFromCollectionKVSKey(key string) string {
+	return strings.TrimSuffix(key, collectionSeparator+collectionSuffix)
+}
+
+// CollectionConfig is the configuration of a private data collection of a chaincode
+type CollectionConfig struct {
+	// Name is the name of the collection
+	Name string `json:"name"`
+	// BlockToLive is the number of blocks for which the private data of the collection is retained
+	// after the block that committed it. The private data is purged once the ledger grows past that.
+	// A value of zero means that the private data is never purged
+	BlockToLive uint64 `json:"blockToLive"`
+}
+
+// CollectionConfigPackage is the set of collection configs of a chaincode that is supplied
+// with the deploy or upgrade of the chaincode and is stored by lccc
+type CollectionConfigPackage struct {
+	Configs []*CollectionConfig `json:"configs"`
+}
+
+// UnmarshalCollectionConfigPackage parses the collection config package stored by lccc
+func UnmarshalCollectionConfigPackage(configBytes []byte) (*CollectionConfigPackage, error) {
+	pkg := &CollectionConfigPackage{}
+	if err := json.Unmarshal(configBytes, pkg); err != nil {
+		return nil, fmt.Errorf("Error unmarshalling collection config package: %s", err)
+	}
+	return pkg, nil
+}
+
+// Marshal serializes the collection config package in the form that is stored by lccc
+func (pkg *CollectionConfigPackage) Marshal() ([]byte, error) {
+	return json.Marshal(pkg)
+}
+
+// GetConfig returns the config of the named collection, or nil if the package has no such collection
+func (pkg *CollectionConfigPackage) GetConfig(collection string) *CollectionConfig {
+	for _, config := range pkg.Configs {
+		if config.Name == collection {
+			return config
+		}
+	}
+	return nil
+}
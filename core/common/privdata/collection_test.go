@@ -0,0 +1,46 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package privdata
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectionKVSKey(t *testing.T) {
+	key := BuildCollectionKVSKey("mycc")
+	assert.Equal(t, "mycc~collection", key)
+	assert.True(t, IsCollectionConfigKey(key))
+	assert.False(t, IsCollectionConfigKey("mycc"))
+	assert.Equal(t, "mycc", GetCCNameFromCollectionKVSKey(key))
+}
+
+func TestCollectionConfigPackage(t *testing.T) {
+	pkg := &CollectionConfigPackage{Configs: []*CollectionConfig{{Name: "coll1", BlockToLive: 10}, {Name: "coll2"}}}
+	pkgBytes, err := pkg.Marshal()
+	assert.NoError(t, err)
+
+	unmarshalledPkg, err := UnmarshalCollectionConfigPackage(pkgBytes)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(10), unmarshalledPkg.GetConfig("coll1").BlockToLive)
+	assert.Equal(t, uint64(0), unmarshalledPkg.GetConfig("coll2").BlockToLive)
+	assert.Nil(t, unmarshalledPkg.GetConfig("coll3"))
+
+	_, err = UnmarshalCollectionConfigPackage([]byte("not-a-config-package"))
+	assert.Error(t, err)
+}
@@ -21,16 +21,20 @@ import (
 
 	"github.com/golang/protobuf/proto"
 	"github.com/op/go-logging"
+	"github.com/spf13/viper"
 	"golang.org/x/net/context"
+	"google.golang.org/grpc"
 
 	"errors"
 
+	"github.com/hyperledger/fabric/common/tracing"
 	"github.com/hyperledger/fabric/common/util"
 	"github.com/hyperledger/fabric/core/chaincode"
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 	"github.com/hyperledger/fabric/core/common/ccprovider"
 	"github.com/hyperledger/fabric/core/common/validation"
 	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/ledgermgmt"
 	"github.com/hyperledger/fabric/core/peer"
 	syscc "github.com/hyperledger/fabric/core/scc"
 	"github.com/hyperledger/fabric/msp"
@@ -46,11 +50,16 @@ var endorserLogger = logging.MustGetLogger("endorser")
 
 // Endorser provides the Endorser service ProcessProposal
 type Endorser struct {
+	// queryCache caches the responses of the read-only queries and is nil if the cache is not enabled
+	queryCache *queryCache
 }
 
 // NewEndorserServer creates and returns a new Endorser server instance.
 func NewEndorserServer() pb.EndorserServer {
 	e := new(Endorser)
+	if maxEntries := viper.GetInt("peer.endorser.queryCache.maxEntries"); maxEntries > 0 {
+		e.queryCache = newQueryCache(maxEntries)
+	}
 	return e
 }
 
@@ -302,6 +311,12 @@ func (e *Endorser) endorseProposal(ctx context.Context, chainID string, txid str
 
 // ProcessProposal process the Proposal
 func (e *Endorser) ProcessProposal(ctx context.Context, signedProp *pb.SignedProposal) (*pb.ProposalResponse, error) {
+	// no proposal is endorsed while the peer is in maintenance mode
+	if ledgermgmt.IsInMaintenanceMode() {
+		err := ledgermgmt.ErrMaintenanceMode
+		return &pb.ProposalResponse{Response: &pb.Response{Status: 500, Message: err.Error()}}, grpc.Errorf(ledger.GRPCCode(err), "%s", err)
+	}
+
 	// at first, we check whether the message is valid
 	prop, hdr, hdrExt, err := validation.ValidateProposalMessage(signedProp)
 	if err != nil {
@@ -320,6 +335,13 @@ func (e *Endorser) ProcessProposal(ctx context.Context, signedProp *pb.SignedPro
 
 	chainID := chdr.ChannelId
 
+	// continue the trace of the client, if any, so that the spans of the simulation
+	// and of the ledger queries are reported as a part of it
+	span, ctx := tracing.StartSpan(tracing.ExtractFromGRPC(ctx), "endorser.ProcessProposal")
+	span.SetTag("channel", chainID)
+	span.SetTag("txID", chdr.TxId)
+	defer span.Finish()
+
 	// Check for uniqueness of prop.TxID with ledger
 	// Notice that ValidateProposalMessage has already verified
 	// that TxID is computed propertly
@@ -335,8 +357,11 @@ func (e *Endorser) ProcessProposal(ctx context.Context, signedProp *pb.SignedPro
 		if lgr == nil {
 			return nil, errors.New(fmt.Sprintf("Failure while looking up the ledger %s", chainID))
 		}
-		if _, err := lgr.GetTransactionByID(txid); err == nil {
-			return nil, fmt.Errorf("Duplicate transaction found [%s]. Creator [%x]. [%s]", txid, shdr.Creator, err)
+		if exists, err := lgr.TxExists(txid); err != nil {
+			// the uniqueness cannot be established if the lookup fails
+			return nil, grpc.Errorf(ledger.GRPCCode(err), "Failed to look up transaction [%s] in the ledger: %s", txid, err)
+		} else if exists {
+			return nil, fmt.Errorf("Duplicate transaction found [%s]. Creator [%x]", txid, shdr.Creator)
 		}
 
 		// check ACL - we verify that this proposal
@@ -360,6 +385,9 @@ func (e *Endorser) ProcessProposal(ctx context.Context, signedProp *pb.SignedPro
 		if txsim, err = e.getTxSimulator(chainID); err != nil {
 			return &pb.ProposalResponse{Response: &pb.Response{Status: 500, Message: err.Error()}}, err
 		}
+		if traceable, ok := txsim.(ledger.TraceableQueryExecutor); ok {
+			traceable.SetTraceContext(ctx)
+		}
 		if historyQueryExecutor, err = e.getHistoryQueryExecutor(chainID); err != nil {
 			return &pb.ProposalResponse{Response: &pb.Response{Status: 500, Message: err.Error()}}, err
 		}
@@ -368,7 +396,8 @@ func (e *Endorser) ProcessProposal(ctx context.Context, signedProp *pb.SignedPro
 		// around separately, since eventually it gets added to context anyways
 		ctx = context.WithValue(ctx, chaincode.HistoryQueryExecutorKey, historyQueryExecutor)
 
-		defer txsim.Done()
+		// the simulator may be replaced by the query cache
+		defer func() { txsim.Done() }()
 	}
 	//this could be a request to a chainless SysCC
 
@@ -378,7 +407,17 @@ func (e *Endorser) ProcessProposal(ctx context.Context, signedProp *pb.SignedPro
 	//       to validate the supplied action before endorsing it
 
 	//1 -- simulate
-	cd, res, simulationResult, ccevent, err := e.simulateProposal(ctx, chainID, txid, signedProp, prop, hdrExt.ChaincodeId, txsim)
+	simulateSpan, _ := tracing.StartSpan(ctx, "endorser.SimulateProposal")
+	var cd *ccprovider.ChaincodeData
+	var res *pb.Response
+	var simulationResult []byte
+	var ccevent *pb.ChaincodeEvent
+	if e.queryCache != nil && chainID != "" && !syscc.IsSysCC(hdrExt.ChaincodeId.Name) {
+		cd, res, simulationResult, ccevent, txsim, err = e.simulateQuery(ctx, chainID, txid, signedProp, prop, hdrExt.ChaincodeId, shdr.Creator, txsim)
+	} else {
+		cd, res, simulationResult, ccevent, err = e.simulateProposal(ctx, chainID, txid, signedProp, prop, hdrExt.ChaincodeId, txsim)
+	}
+	simulateSpan.Finish()
 	if err != nil {
 		return &pb.ProposalResponse{Response: &pb.Response{Status: 500, Message: err.Error()}}, err
 	}
@@ -391,7 +430,9 @@ func (e *Endorser) ProcessProposal(ctx context.Context, signedProp *pb.SignedPro
 	if chainID == "" {
 		pResp = &pb.ProposalResponse{Response: res}
 	} else {
+		endorseSpan, _ := tracing.StartSpan(ctx, "endorser.EndorseProposal")
 		pResp, err = e.endorseProposal(ctx, chainID, txid, signedProp, prop, res, simulationResult, ccevent, hdrExt.PayloadVisibility, hdrExt.ChaincodeId, txsim, cd)
+		endorseSpan.Finish()
 		if err != nil {
 			return &pb.ProposalResponse{Response: &pb.Response{Status: 500, Message: err.Error()}}, err
 		}
@@ -0,0 +1,385 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package endorser
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"sync/atomic"
+
+	"github.com/golang/protobuf/proto"
+	commonledger "github.com/hyperledger/fabric/common/ledger"
+	"github.com/hyperledger/fabric/common/metrics"
+	"github.com/hyperledger/fabric/core/chaincode"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/core/common/ccprovider"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/rwset"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
+	"github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric/protos/peer"
+	"golang.org/x/net/context"
+)
+
+const (
+	queryCacheHitLabel  = "hit"
+	queryCacheMissLabel = "miss"
+)
+
+var queryCacheLookups = metrics.NewCounterVec("endorser_query_cache_lookups_total",
+	"Number of the lookups of the cache of the read-only query responses by channel and result (hit or miss).", "channel", "result")
+
+// queryCache caches the responses of the read-only chaincode queries so that the identical queries that the clients,
+// such as dashboards, repeat are endorsed without executing the chaincode again. An entry is keyed by the channel, the
+// chaincode, the creator, and the proposal payload (the arguments and the transient data), and carries the versions of
+// the keys that the query read. The entry is served only while the keys remain at those versions, and is dropped once
+// a commit updates any of them. A query is cached only if it neither writes nor performs a read whose result is not
+// captured by the versions of the keys read, such as a range query or a rich query. The cache assumes that the result
+// of a query does not depend on the transaction id or the timestamp of the proposal
+type queryCache struct {
+	maxEntries int
+	lock       sync.Mutex
+	// order holds the entries, the most recently used at the front
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+// queryCacheEntry is the response of a query along with the versions of the keys that the query read
+type queryCacheEntry struct {
+	key       string
+	cd        *ccprovider.ChaincodeData
+	response  *pb.Response
+	event     *pb.ChaincodeEvent
+	simResult []byte
+	reads     []*rwset.NsReadWriteSet
+	// height is the height of the state at which the versions of the reads were last found current
+	height uint64
+}
+
+func newQueryCache(maxEntries int) *queryCache {
+	return &queryCache{maxEntries: maxEntries, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+// queryCacheKey returns the key of the entry of a query
+func queryCacheKey(chainID string, chaincodeName string, creator []byte, proposalPayload []byte) string {
+	h := sha256.New()
+	for _, part := range [][]byte{[]byte(chainID), []byte(chaincodeName), creator, proposalPayload} {
+		h.Write(proto.EncodeVarint(uint64(len(part))))
+		h.Write(part)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// get returns the entry of the given key if the keys that the query read are still at the versions of the entry.
+// The versions are read through the simulator only if the state has grown since the entry was last checked, in
+// which case the simulator is done on return. stale reports that the entry was found stale after the simulator
+// recorded the reads of the entry, in which case the simulator must not be used for simulating the query
+func (c *queryCache) get(chainID string, key string, txsim ledger.TxSimulator) (entry *queryCacheEntry, stale bool, err error) {
+	c.lock.Lock()
+	element, ok := c.entries[key]
+	if ok {
+		c.order.MoveToFront(element)
+		entry = element.Value.(*queryCacheEntry)
+	}
+	entryHeight := uint64(0)
+	if entry != nil {
+		entryHeight = entry.height
+	}
+	c.lock.Unlock()
+	if entry == nil {
+		queryCacheLookups.Add(1, chainID, queryCacheMissLabel)
+		return nil, false, nil
+	}
+
+	info, err := txsim.GetBlockchainInfo()
+	if err != nil {
+		return nil, false, err
+	}
+	if info.Height != entryHeight {
+		current, err := readVersions(txsim, entry.reads)
+		if err != nil {
+			return nil, true, err
+		}
+		if !current {
+			c.remove(key)
+			queryCacheLookups.Add(1, chainID, queryCacheMissLabel)
+			return nil, true, nil
+		}
+		c.lock.Lock()
+		if entry.height < info.Height {
+			entry.height = info.Height
+		}
+		c.lock.Unlock()
+	}
+	queryCacheLookups.Add(1, chainID, queryCacheHitLabel)
+	return entry, false, nil
+}
+
+// readVersions reads the keys of the given reads through the simulator and reports whether the keys are at the same versions
+func readVersions(txsim ledger.TxSimulator, reads []*rwset.NsReadWriteSet) (bool, error) {
+	for _, nsReads := range reads {
+		keys := make([]string, len(nsReads.Reads))
+		for i, read := range nsReads.Reads {
+			keys[i] = read.Key
+		}
+		if _, err := txsim.GetStateMultipleKeys(nsReads.NameSpace, keys); err != nil {
+			return false, err
+		}
+	}
+	simResult, err := txsim.GetTxSimulationResults()
+	if err != nil {
+		return false, err
+	}
+	txRWSet := &rwset.TxReadWriteSet{}
+	if err := txRWSet.Unmarshal(simResult); err != nil {
+		return false, err
+	}
+	versions := make(map[string]map[string]*version.Height)
+	for _, nsRWSet := range txRWSet.NsRWs {
+		nsVersions := make(map[string]*version.Height)
+		for _, read := range nsRWSet.Reads {
+			nsVersions[read.Key] = read.Version
+		}
+		versions[nsRWSet.NameSpace] = nsVersions
+	}
+	for _, nsReads := range reads {
+		for _, read := range nsReads.Reads {
+			currentVersion, ok := versions[nsReads.NameSpace][read.Key]
+			if !ok || !version.AreSame(currentVersion, read.Version) {
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
+
+// put caches the response of a query simulated at the given height. The query is not cached if its simulation
+// results contain anything other than the reads of keys
+func (c *queryCache) put(key string, height uint64, cd *ccprovider.ChaincodeData, response *pb.Response, event *pb.ChaincodeEvent, simResult []byte) {
+	txRWSet := &rwset.TxReadWriteSet{}
+	if err := txRWSet.Unmarshal(simResult); err != nil {
+		endorserLogger.Debugf("Not caching the query as its simulation results cannot be decoded: %s", err)
+		return
+	}
+	for _, nsRWSet := range txRWSet.NsRWs {
+		if len(nsRWSet.Writes) > 0 || len(nsRWSet.RangeQueriesInfo) > 0 || len(nsRWSet.RangeDeletes) > 0 {
+			return
+		}
+	}
+	entry := &queryCacheEntry{key: key, cd: cd, response: response, event: event, simResult: simResult, reads: txRWSet.NsRWs, height: height}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if element, ok := c.entries[key]; ok {
+		c.order.Remove(element)
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	if c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*queryCacheEntry).key)
+	}
+}
+
+func (c *queryCache) remove(key string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if element, ok := c.entries[key]; ok {
+		c.order.Remove(element)
+		delete(c.entries, key)
+	}
+}
+
+// simulateQuery serves the proposal from the query cache if possible and simulates it otherwise, caching the response
+// if the simulation qualifies. The returned simulator is the one to be used for the rest of the proposal, which is a
+// new one if the given simulator recorded the reads of a stale entry. Both the simulators are to be done by the caller
+func (e *Endorser) simulateQuery(ctx context.Context, chainID string, txid string, signedProp *pb.SignedProposal, prop *pb.Proposal, cid *pb.ChaincodeID, creator []byte, txsim ledger.TxSimulator) (*ccprovider.ChaincodeData, *pb.Response, []byte, *pb.ChaincodeEvent, ledger.TxSimulator, error) {
+	key := queryCacheKey(chainID, cid.Name, creator, prop.Payload)
+	entry, stale, err := e.queryCache.get(chainID, key, txsim)
+	if err != nil {
+		return nil, nil, nil, nil, txsim, err
+	}
+	if entry != nil {
+		return entry.cd, entry.response, entry.simResult, entry.event, txsim, nil
+	}
+	if stale {
+		if txsim, err = e.getTxSimulator(chainID); err != nil {
+			return nil, nil, nil, nil, txsim, err
+		}
+		if traceable, ok := txsim.(ledger.TraceableQueryExecutor); ok {
+			traceable.SetTraceContext(ctx)
+		}
+	}
+
+	// the height is read before the simulation, which holds the state at that height until the results are taken
+	info, err := txsim.GetBlockchainInfo()
+	if err != nil {
+		return nil, nil, nil, nil, txsim, err
+	}
+	recorder := &queryRecorder{TxSimulator: txsim}
+	if historyQueryExecutor, ok := ctx.Value(chaincode.HistoryQueryExecutorKey).(ledger.HistoryQueryExecutor); ok {
+		ctx = context.WithValue(ctx, chaincode.HistoryQueryExecutorKey, &historyQueryRecorder{historyQueryExecutor, recorder})
+	}
+	cd, res, simResult, ccevent, err := e.simulateProposal(ctx, chainID, txid, signedProp, prop, cid, recorder)
+	if err != nil {
+		return nil, nil, nil, nil, txsim, err
+	}
+	if res.Status == shim.OK && !recorder.isUncacheable() {
+		e.queryCache.put(key, info.Height, cd, res, ccevent, simResult)
+	}
+	return cd, res, simResult, ccevent, txsim, nil
+}
+
+// queryRecorder wraps the simulator of a query that is a candidate for the query cache and records whether the
+// query invoked anything other than the reads of keys, which makes the query uncacheable
+type queryRecorder struct {
+	ledger.TxSimulator
+	uncacheable int32
+}
+
+func (r *queryRecorder) markUncacheable() {
+	atomic.StoreInt32(&r.uncacheable, 1)
+}
+
+func (r *queryRecorder) isUncacheable() bool {
+	return atomic.LoadInt32(&r.uncacheable) == 1
+}
+
+func (r *queryRecorder) GetStateHash(namespace string, key string) ([]byte, error) {
+	r.markUncacheable()
+	return r.TxSimulator.GetStateHash(namespace, key)
+}
+
+func (r *queryRecorder) GetPrivateDataHash(namespace string, collection string, key string) ([]byte, error) {
+	r.markUncacheable()
+	return r.TxSimulator.GetPrivateDataHash(namespace, collection, key)
+}
+
+func (r *queryRecorder) GetStateRangeScanIterator(namespace string, startKey string, endKey string) (commonledger.ResultsIterator, error) {
+	r.markUncacheable()
+	return r.TxSimulator.GetStateRangeScanIterator(namespace, startKey, endKey)
+}
+
+func (r *queryRecorder) GetStateRangeScanIteratorWithPagination(namespace string, startKey string, endKey string, pageSize int32, bookmark string) (ledger.PaginatedResultsIterator, error) {
+	r.markUncacheable()
+	return r.TxSimulator.GetStateRangeScanIteratorWithPagination(namespace, startKey, endKey, pageSize, bookmark)
+}
+
+func (r *queryRecorder) ExecuteQuery(namespace, query string) (commonledger.ResultsIterator, error) {
+	r.markUncacheable()
+	return r.TxSimulator.ExecuteQuery(namespace, query)
+}
+
+func (r *queryRecorder) ExecuteQueryWithFields(namespace, query string, fields []string) (commonledger.ResultsIterator, error) {
+	r.markUncacheable()
+	return r.TxSimulator.ExecuteQueryWithFields(namespace, query, fields)
+}
+
+func (r *queryRecorder) GetTotalForKeyPrefix(namespace string, keyPrefix string, fieldName string) (*ledger.Aggregate, error) {
+	r.markUncacheable()
+	return r.TxSimulator.GetTotalForKeyPrefix(namespace, keyPrefix, fieldName)
+}
+
+func (r *queryRecorder) GetSequenceCount(namespace string, name string) (uint64, error) {
+	r.markUncacheable()
+	return r.TxSimulator.GetSequenceCount(namespace, name)
+}
+
+func (r *queryRecorder) ListNamespaces() ([]*ledger.NamespaceInfo, error) {
+	r.markUncacheable()
+	return r.TxSimulator.ListNamespaces()
+}
+
+func (r *queryRecorder) GetNamespaceSize(namespace string) (*ledger.NamespaceSize, error) {
+	r.markUncacheable()
+	return r.TxSimulator.GetNamespaceSize(namespace)
+}
+
+func (r *queryRecorder) GetBlockchainInfo() (*common.BlockchainInfo, error) {
+	r.markUncacheable()
+	return r.TxSimulator.GetBlockchainInfo()
+}
+
+func (r *queryRecorder) SetState(namespace string, key string, value []byte) error {
+	r.markUncacheable()
+	return r.TxSimulator.SetState(namespace, key, value)
+}
+
+func (r *queryRecorder) SetStateWithTTL(namespace string, key string, value []byte, ttl uint64) error {
+	r.markUncacheable()
+	return r.TxSimulator.SetStateWithTTL(namespace, key, value, ttl)
+}
+
+func (r *queryRecorder) DeleteState(namespace string, key string) error {
+	r.markUncacheable()
+	return r.TxSimulator.DeleteState(namespace, key)
+}
+
+func (r *queryRecorder) DeleteStateByRange(namespace string, startKey string, endKey string) error {
+	r.markUncacheable()
+	return r.TxSimulator.DeleteStateByRange(namespace, startKey, endKey)
+}
+
+func (r *queryRecorder) SetPrivateData(namespace string, collection string, key string, value []byte) error {
+	r.markUncacheable()
+	return r.TxSimulator.SetPrivateData(namespace, collection, key, value)
+}
+
+func (r *queryRecorder) DeletePrivateData(namespace string, collection string, key string) error {
+	r.markUncacheable()
+	return r.TxSimulator.DeletePrivateData(namespace, collection, key)
+}
+
+func (r *queryRecorder) NextSequenceValue(namespace string, name string, txID string) (uint64, error) {
+	r.markUncacheable()
+	return r.TxSimulator.NextSequenceValue(namespace, name, txID)
+}
+
+func (r *queryRecorder) SetStateMultipleKeys(namespace string, kvs map[string][]byte) error {
+	r.markUncacheable()
+	return r.TxSimulator.SetStateMultipleKeys(namespace, kvs)
+}
+
+func (r *queryRecorder) ExecuteUpdate(query string) error {
+	r.markUncacheable()
+	return r.TxSimulator.ExecuteUpdate(query)
+}
+
+// historyQueryRecorder wraps the history query executor of a query that is a candidate for the query cache. The history
+// of a key is not captured by the version of the key in the read set, hence a history query makes the query uncacheable
+type historyQueryRecorder struct {
+	ledger.HistoryQueryExecutor
+	recorder *queryRecorder
+}
+
+func (r *historyQueryRecorder) GetHistoryForKey(namespace string, key string) (commonledger.ResultsIterator, error) {
+	r.recorder.markUncacheable()
+	return r.HistoryQueryExecutor.GetHistoryForKey(namespace, key)
+}
+
+func (r *historyQueryRecorder) GetRecentHistoryForKey(namespace string, key string, limit int) (commonledger.ResultsIterator, error) {
+	r.recorder.markUncacheable()
+	return r.HistoryQueryExecutor.GetRecentHistoryForKey(namespace, key, limit)
+}
+
+func (r *historyQueryRecorder) GetHistoryForKeyWithOptions(namespace string, key string,
+	options *ledger.HistoryQueryOptions) (commonledger.ResultsIterator, error) {
+	r.recorder.markUncacheable()
+	return r.HistoryQueryExecutor.GetHistoryForKeyWithOptions(namespace, key, options)
+}
@@ -0,0 +1,130 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package endorser
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/rwset"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
+	"github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// mockQuerySimulator serves the reads of the query cache from a map of the versions of the keys at a given height
+type mockQuerySimulator struct {
+	ledger.TxSimulator
+	height   uint64
+	versions map[string]*version.Height
+	rwset    *rwset.RWSet
+}
+
+func newMockQuerySimulator(height uint64, versions map[string]*version.Height) *mockQuerySimulator {
+	return &mockQuerySimulator{height: height, versions: versions, rwset: rwset.NewRWSet()}
+}
+
+func (s *mockQuerySimulator) GetBlockchainInfo() (*common.BlockchainInfo, error) {
+	return &common.BlockchainInfo{Height: s.height}, nil
+}
+
+func (s *mockQuerySimulator) GetStateMultipleKeys(namespace string, keys []string) ([][]byte, error) {
+	values := make([][]byte, len(keys))
+	for i, key := range keys {
+		s.rwset.AddToReadSet(namespace, key, s.versions[key])
+		values[i] = []byte(key)
+	}
+	return values, nil
+}
+
+func (s *mockQuerySimulator) GetTxSimulationResults() ([]byte, error) {
+	return s.rwset.GetTxReadWriteSet().Marshal()
+}
+
+func queryCacheTestSimResult(t *testing.T, versions map[string]*version.Height, write bool) []byte {
+	rws := rwset.NewRWSet()
+	for key, ver := range versions {
+		rws.AddToReadSet("ns", key, ver)
+	}
+	if write {
+		rws.AddToWriteSet("ns", "key3", []byte("value3"))
+	}
+	simResult, err := rws.GetTxReadWriteSet().Marshal()
+	testutil.AssertNoError(t, err, "")
+	return simResult
+}
+
+func TestQueryCache(t *testing.T) {
+	versions := map[string]*version.Height{"key1": version.NewHeight(1, 0), "key2": version.NewHeight(2, 0)}
+	response := &pb.Response{Status: 200, Payload: []byte("result")}
+	cache := newQueryCache(2)
+	key := queryCacheKey("testchain", "cc", []byte("creator"), []byte("payload"))
+	cache.put(key, 3, nil, response, nil, queryCacheTestSimResult(t, versions, false))
+
+	// the entry is served without reading the keys at the height at which it was cached
+	txsim := newMockQuerySimulator(3, nil)
+	entry, stale, err := cache.get("testchain", key, txsim)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, stale, false)
+	testutil.AssertEquals(t, entry.response, response)
+	testutil.AssertEquals(t, len(txsim.rwset.GetTxReadWriteSet().NsRWs), 0)
+
+	// the entry is served after a commit that left the keys read at the same versions
+	entry, stale, err = cache.get("testchain", key, newMockQuerySimulator(4, versions))
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, stale, false)
+	testutil.AssertNotNil(t, entry)
+
+	// the entry is dropped after a commit that updated a key read
+	updated := map[string]*version.Height{"key1": version.NewHeight(1, 0), "key2": version.NewHeight(5, 0)}
+	entry, stale, err = cache.get("testchain", key, newMockQuerySimulator(6, updated))
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, stale, true)
+	testutil.AssertNil(t, entry)
+	entry, stale, _ = cache.get("testchain", key, newMockQuerySimulator(6, updated))
+	testutil.AssertEquals(t, stale, false)
+	testutil.AssertNil(t, entry)
+
+	// a query that writes is not cached
+	cache.put(key, 6, nil, response, nil, queryCacheTestSimResult(t, updated, true))
+	entry, _, _ = cache.get("testchain", key, newMockQuerySimulator(6, updated))
+	testutil.AssertNil(t, entry)
+}
+
+func TestQueryCacheEviction(t *testing.T) {
+	versions := map[string]*version.Height{"key1": version.NewHeight(1, 0)}
+	cache := newQueryCache(2)
+	keys := []string{
+		queryCacheKey("testchain", "cc", []byte("creator"), []byte("payload1")),
+		queryCacheKey("testchain", "cc", []byte("creator"), []byte("payload2")),
+		queryCacheKey("testchain", "cc", []byte("creator"), []byte("payload3")),
+	}
+	cache.put(keys[0], 1, nil, &pb.Response{Status: 200}, nil, queryCacheTestSimResult(t, versions, false))
+	cache.put(keys[1], 1, nil, &pb.Response{Status: 200}, nil, queryCacheTestSimResult(t, versions, false))
+	// a lookup makes the first entry the most recently used, hence the second entry is evicted
+	entry, _, _ := cache.get("testchain", keys[0], newMockQuerySimulator(1, nil))
+	testutil.AssertNotNil(t, entry)
+	cache.put(keys[2], 1, nil, &pb.Response{Status: 200}, nil, queryCacheTestSimResult(t, versions, false))
+
+	entry, _, _ = cache.get("testchain", keys[0], newMockQuerySimulator(1, nil))
+	testutil.AssertNotNil(t, entry)
+	entry, _, _ = cache.get("testchain", keys[1], newMockQuerySimulator(1, nil))
+	testutil.AssertNil(t, entry)
+	entry, _, _ = cache.get("testchain", keys[2], newMockQuerySimulator(1, nil))
+	testutil.AssertNotNil(t, entry)
+}
@@ -0,0 +1,189 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package compare
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/hyperledger/fabric/core/ledger"
+)
+
+// Source provides the fingerprints of the ledger of a channel on a peer
+type Source interface {
+	// Name identifies the source in the report, e.g., the address of the peer
+	Name() string
+	// GetBlockFingerprint returns the fingerprint of the block with the given number.
+	// blockNumber of math.MaxUint64 returns the fingerprint of the last block
+	GetBlockFingerprint(blockNumber uint64) (*ledger.BlockFingerprint, error)
+	// GetStateFingerprint returns the hashes of the contents of the state database per namespace
+	GetStateFingerprint() (*ledger.StateFingerprint, error)
+}
+
+// Divergence describes the first block at which two ledgers diverge
+type Divergence struct {
+	BlockNumber uint64
+	// BlockHashDiffers is true if the blocks themselves differ. Otherwise the blocks are the same but the
+	// commit hashes differ, that is, the peers validated the block differently or applied different state updates
+	BlockHashDiffers bool
+}
+
+// Result captures the outcome of a comparison of two ledgers
+type Result struct {
+	SourceA, SourceB string
+	HeightA, HeightB uint64
+	// Divergence is the first divergent block below the common height of the ledgers, nil if there is none
+	Divergence *Divergence
+	// StateHeightA and StateHeightB are the heights of the state databases. The state databases are compared
+	// only if they are at the same height
+	StateHeightA, StateHeightB uint64
+	// DivergentNamespaces lists the namespaces whose contents differ in the state databases
+	DivergentNamespaces []string
+}
+
+// StateCompared tells whether the state databases were at the same height and hence compared
+func (r *Result) StateCompared() bool {
+	return r.StateHeightA == r.StateHeightB
+}
+
+// Diverged tells whether a divergence is found in the blocks or in the state databases
+func (r *Result) Diverged() bool {
+	return r.Divergence != nil || len(r.DivergentNamespaces) > 0
+}
+
+func (r *Result) String() string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Height of [%s]: %d, height of [%s]: %d\n", r.SourceA, r.HeightA, r.SourceB, r.HeightB)
+	switch {
+	case r.Divergence != nil && r.Divergence.BlockHashDiffers:
+		fmt.Fprintf(&buf, "First divergence at block [%d]: the blocks differ\n", r.Divergence.BlockNumber)
+	case r.Divergence != nil:
+		fmt.Fprintf(&buf, "First divergence at block [%d]: the blocks are the same but the commit hashes differ\n", r.Divergence.BlockNumber)
+	default:
+		fmt.Fprintf(&buf, "Blocks are the same up to the common height\n")
+	}
+	switch {
+	case !r.StateCompared():
+		fmt.Fprintf(&buf, "State databases are not compared as they are at different heights (%d and %d)\n", r.StateHeightA, r.StateHeightB)
+	case len(r.DivergentNamespaces) > 0:
+		fmt.Fprintf(&buf, "State databases at height [%d] differ in namespaces %v\n", r.StateHeightA, r.DivergentNamespaces)
+	default:
+		fmt.Fprintf(&buf, "State databases at height [%d] are the same\n", r.StateHeightA)
+	}
+	return buf.String()
+}
+
+// Compare compares the ledgers provided by the two sources. As the block hashes and the commit hashes chain the
+// preceding blocks, a divergence persists in all the subsequent blocks and hence the first divergent block is
+// located by a binary search over the blocks below the common height
+func Compare(a, b Source) (*Result, error) {
+	result := &Result{SourceA: a.Name(), SourceB: b.Name()}
+	lastA, err := a.GetBlockFingerprint(math.MaxUint64)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get the last block from [%s]: %s", a.Name(), err)
+	}
+	lastB, err := b.GetBlockFingerprint(math.MaxUint64)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get the last block from [%s]: %s", b.Name(), err)
+	}
+	result.HeightA, result.HeightB = lastA.BlockNumber+1, lastB.BlockNumber+1
+	commonHeight := result.HeightA
+	if result.HeightB < commonHeight {
+		commonHeight = result.HeightB
+	}
+	if result.Divergence, err = findDivergence(a, b, commonHeight); err != nil {
+		return nil, err
+	}
+	if err := compareState(a, b, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// findDivergence returns the first block below the given height at which the ledgers diverge
+func findDivergence(a, b Source, height uint64) (*Divergence, error) {
+	fingerprints := func(blockNumber uint64) (*ledger.BlockFingerprint, *ledger.BlockFingerprint, error) {
+		fingerprintA, err := a.GetBlockFingerprint(blockNumber)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Failed to get block [%d] from [%s]: %s", blockNumber, a.Name(), err)
+		}
+		fingerprintB, err := b.GetBlockFingerprint(blockNumber)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Failed to get block [%d] from [%s]: %s", blockNumber, b.Name(), err)
+		}
+		return fingerprintA, fingerprintB, nil
+	}
+	if height == 0 {
+		return nil, nil
+	}
+	fingerprintA, fingerprintB, err := fingerprints(height - 1)
+	if err != nil {
+		return nil, err
+	}
+	if sameBlock(fingerprintA, fingerprintB) {
+		return nil, nil
+	}
+	// invariant: the blocks below low are the same and the block at high diverges
+	low, high := uint64(0), height-1
+	divergentA, divergentB := fingerprintA, fingerprintB
+	for low < high {
+		mid := low + (high-low)/2
+		if fingerprintA, fingerprintB, err = fingerprints(mid); err != nil {
+			return nil, err
+		}
+		if sameBlock(fingerprintA, fingerprintB) {
+			low = mid + 1
+		} else {
+			high, divergentA, divergentB = mid, fingerprintA, fingerprintB
+		}
+	}
+	return &Divergence{BlockNumber: high, BlockHashDiffers: !bytes.Equal(divergentA.BlockHash, divergentB.BlockHash)}, nil
+}
+
+func sameBlock(a, b *ledger.BlockFingerprint) bool {
+	return bytes.Equal(a.BlockHash, b.BlockHash) && bytes.Equal(a.CommitHash, b.CommitHash)
+}
+
+// compareState compares the state databases of the sources if these are at the same height
+func compareState(a, b Source, result *Result) error {
+	stateA, err := a.GetStateFingerprint()
+	if err != nil {
+		return fmt.Errorf("Failed to get the state fingerprint from [%s]: %s", a.Name(), err)
+	}
+	stateB, err := b.GetStateFingerprint()
+	if err != nil {
+		return fmt.Errorf("Failed to get the state fingerprint from [%s]: %s", b.Name(), err)
+	}
+	result.StateHeightA, result.StateHeightB = stateA.Height, stateB.Height
+	if !result.StateCompared() {
+		return nil
+	}
+	for namespace, hashA := range stateA.NamespaceHashes {
+		if hashB, ok := stateB.NamespaceHashes[namespace]; !ok || !bytes.Equal(hashA, hashB) {
+			result.DivergentNamespaces = append(result.DivergentNamespaces, namespace)
+		}
+	}
+	for namespace := range stateB.NamespaceHashes {
+		if _, ok := stateA.NamespaceHashes[namespace]; !ok {
+			result.DivergentNamespaces = append(result.DivergentNamespaces, namespace)
+		}
+	}
+	sort.Strings(result.DivergentNamespaces)
+	return nil
+}
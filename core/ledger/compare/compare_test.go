@@ -0,0 +1,124 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package compare
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger"
+)
+
+type fakeSource struct {
+	name           string
+	blocks         []*ledger.BlockFingerprint
+	state          *ledger.StateFingerprint
+	numBlockLookup int
+}
+
+func (s *fakeSource) Name() string {
+	return s.name
+}
+
+func (s *fakeSource) GetBlockFingerprint(blockNumber uint64) (*ledger.BlockFingerprint, error) {
+	s.numBlockLookup++
+	if blockNumber == math.MaxUint64 {
+		blockNumber = uint64(len(s.blocks) - 1)
+	}
+	if blockNumber >= uint64(len(s.blocks)) {
+		return nil, fmt.Errorf("block [%d] not found", blockNumber)
+	}
+	return s.blocks[blockNumber], nil
+}
+
+func (s *fakeSource) GetStateFingerprint() (*ledger.StateFingerprint, error) {
+	return s.state, nil
+}
+
+// newFakeSource constructs the block fingerprints such that the blocks from blockDivergence onwards
+// differ and the commit hashes from commitDivergence onwards differ
+func newFakeSource(name string, height int, blockDivergence int, commitDivergence int) *fakeSource {
+	source := &fakeSource{name: name, state: &ledger.StateFingerprint{Height: uint64(height),
+		NamespaceHashes: map[string][]byte{"ns1": []byte("hash1"), "ns2": []byte("hash2")}}}
+	for i := 0; i < height; i++ {
+		fingerprint := &ledger.BlockFingerprint{BlockNumber: uint64(i),
+			BlockHash: []byte(fmt.Sprintf("block-%d", i)), CommitHash: []byte(fmt.Sprintf("commit-%d", i))}
+		if i >= blockDivergence {
+			fingerprint.BlockHash = []byte(fmt.Sprintf("%s-block-%d", name, i))
+		}
+		if i >= commitDivergence {
+			fingerprint.CommitHash = []byte(fmt.Sprintf("%s-commit-%d", name, i))
+		}
+		source.blocks = append(source.blocks, fingerprint)
+	}
+	return source
+}
+
+func TestCompareSameLedgers(t *testing.T) {
+	a := newFakeSource("peerA", 100, 100, 100)
+	b := newFakeSource("peerB", 90, 100, 100)
+	result, err := Compare(a, b)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, result.HeightA, uint64(100))
+	testutil.AssertEquals(t, result.HeightB, uint64(90))
+	testutil.AssertNil(t, result.Divergence)
+	testutil.AssertEquals(t, result.StateCompared(), false)
+	testutil.AssertEquals(t, result.Diverged(), false)
+
+	b = newFakeSource("peerB", 100, 100, 100)
+	result, err = Compare(a, b)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, result.StateCompared(), true)
+	testutil.AssertEquals(t, result.Diverged(), false)
+}
+
+func TestCompareDivergentBlocks(t *testing.T) {
+	a := newFakeSource("peerA", 1000, 1000, 1000)
+	b := newFakeSource("peerB", 1000, 637, 637)
+	result, err := Compare(a, b)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, result.Divergence, &Divergence{BlockNumber: 637, BlockHashDiffers: true})
+	// the divergence is located by a binary search
+	testutil.AssertEquals(t, a.numBlockLookup <= 13, true)
+
+	b = newFakeSource("peerB", 1000, 1000, 0)
+	result, err = Compare(a, b)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, result.Divergence, &Divergence{BlockNumber: 0, BlockHashDiffers: false})
+	testutil.AssertEquals(t, result.Diverged(), true)
+}
+
+func TestCompareDivergentState(t *testing.T) {
+	a := newFakeSource("peerA", 10, 10, 10)
+	b := newFakeSource("peerB", 10, 10, 10)
+	b.state.NamespaceHashes["ns2"] = []byte("hash3")
+	b.state.NamespaceHashes["ns3"] = []byte("hash4")
+	result, err := Compare(a, b)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertNil(t, result.Divergence)
+	testutil.AssertEquals(t, result.DivergentNamespaces, []string{"ns2", "ns3"})
+	testutil.AssertEquals(t, result.Diverged(), true)
+}
+
+func TestCompareSourceError(t *testing.T) {
+	a := newFakeSource("peerA", 10, 10, 10)
+	b := newFakeSource("peerB", 0, 10, 10)
+	_, err := Compare(a, b)
+	testutil.AssertError(t, err, "Expected an error for a source without blocks")
+}
@@ -0,0 +1,171 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ledger
+
+import (
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+)
+
+// BlockAlreadyCommittedError is returned by a Commit call if the block number of the supplied block
+// is below the current height of the ledger. The ledger is left untouched in this case, so a caller
+// that redelivers an already committed block (e.g., a state transfer retry) can safely ignore this error
+type BlockAlreadyCommittedError struct {
+	BlockNum uint64
+	Height   uint64
+}
+
+func (e *BlockAlreadyCommittedError) Error() string {
+	return fmt.Sprintf("Block [%d] is already committed, ledger height is [%d]", e.BlockNum, e.Height)
+}
+
+// The errors below classify the failures of the ledger operations by their kind so that
+// the callers can branch on the kind of a failure with a type switch rather than on the error message
+
+// NotFoundError is returned if the requested ledger, block, transaction, or entry does not exist
+type NotFoundError struct {
+	Msg string
+}
+
+func (e *NotFoundError) Error() string {
+	return e.Msg
+}
+
+// ConflictError is returned if an operation conflicts with the current state of the ledger,
+// for instance, creating a ledger that already exists or opening a ledger that is already opened
+type ConflictError struct {
+	Msg string
+}
+
+func (e *ConflictError) Error() string {
+	return e.Msg
+}
+
+// NotEnabledError is returned if an operation is not supported or is disabled by the configuration,
+// for instance, a history query on a ledger that does not maintain the history database
+type NotEnabledError struct {
+	Msg string
+}
+
+func (e *NotEnabledError) Error() string {
+	return e.Msg
+}
+
+// CorruptionError is returned if the data of the ledger is found to be inconsistent,
+// for instance, a database that is ahead of the block storage
+type CorruptionError struct {
+	Msg string
+}
+
+func (e *CorruptionError) Error() string {
+	return e.Msg
+}
+
+// UnavailableError is returned if an operation cannot be performed at the moment but may succeed
+// on a retry, for instance, while the peer is in maintenance mode or the state database is unreachable
+type UnavailableError struct {
+	Msg string
+}
+
+func (e *UnavailableError) Error() string {
+	return e.Msg
+}
+
+// ResourceExhaustedError is returned if an expensive query is rejected because the queries of its kind already
+// run at the concurrency limit of the channel and the queue of the waiting queries is full or the wait timed out
+type ResourceExhaustedError struct {
+	Msg string
+}
+
+func (e *ResourceExhaustedError) Error() string {
+	return e.Msg
+}
+
+// QueryMemoryExceededError is returned if the results of a query materialize more bytes than the memory budget of a
+// query allows, or than the memory budget shared by the queries in progress on the peer allows. The query is aborted
+type QueryMemoryExceededError struct {
+	Query  string
+	Bytes  int64
+	Limit  int64
+	Global bool
+}
+
+func (e *QueryMemoryExceededError) Error() string {
+	if e.Global {
+		return fmt.Sprintf("Query [%s] aborted as the results of the queries in progress would hold [%d] bytes, above the global query memory budget of [%d] bytes",
+			e.Query, e.Bytes, e.Limit)
+	}
+	return fmt.Sprintf("Query [%s] aborted as its results would hold [%d] bytes, above the query memory budget of [%d] bytes", e.Query, e.Bytes, e.Limit)
+}
+
+// DataFormatError is returned if a ledger store holds its data in a format other than the one that this version
+// of the peer reads and writes. The data of an older format is converted in place by upgrading the ledger stores
+type DataFormatError struct {
+	Store          string
+	Format         string
+	ExpectedFormat string
+}
+
+func (e *DataFormatError) Error() string {
+	return fmt.Sprintf("The data of the %s is in format [%s] while format [%s] is expected", e.Store, e.Format, e.ExpectedFormat)
+}
+
+// InvalidKeyError is returned if a transaction writes a key that contains the characters reserved by the ledger
+type InvalidKeyError struct {
+	Msg string
+}
+
+func (e *InvalidKeyError) Error() string {
+	return e.Msg
+}
+
+// InvalidBlockError is returned if a block is malformed, for instance, a genesis block whose data hash
+// does not match its data or that does not carry a channel config
+type InvalidBlockError struct {
+	Msg string
+}
+
+func (e *InvalidBlockError) Error() string {
+	return e.Msg
+}
+
+// GRPCCode returns the gRPC status code that corresponds to the kind of the given error
+func GRPCCode(err error) codes.Code {
+	switch err.(type) {
+	case nil:
+		return codes.OK
+	case *NotFoundError:
+		return codes.NotFound
+	case *ConflictError, *BlockAlreadyCommittedError:
+		return codes.AlreadyExists
+	case *NotEnabledError:
+		return codes.Unimplemented
+	case *CorruptionError:
+		return codes.DataLoss
+	case *UnavailableError:
+		return codes.Unavailable
+	case *ResourceExhaustedError, *QueryMemoryExceededError:
+		return codes.ResourceExhausted
+	case *DataFormatError:
+		return codes.FailedPrecondition
+	case *InvalidKeyError, *InvalidBlockError:
+		return codes.InvalidArgument
+	default:
+		return codes.Unknown
+	}
+}
@@ -0,0 +1,120 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package export
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+)
+
+// avroMagic starts the Avro object container files
+var avroMagic = []byte{'O', 'b', 'j', 1}
+
+// avroBlockSize is the number of records written per data block of an Avro file
+const avroBlockSize = 1000
+
+// avroEncoder implements the binary encoding of the Avro primitive types. The longs are
+// written as zig-zag variable length integers, which is the encoding of binary.PutVarint
+type avroEncoder struct {
+	buf bytes.Buffer
+	tmp [binary.MaxVarintLen64]byte
+}
+
+func (e *avroEncoder) writeLong(v int64) {
+	n := binary.PutVarint(e.tmp[:], v)
+	e.buf.Write(e.tmp[:n])
+}
+
+func (e *avroEncoder) writeBytes(b []byte) {
+	e.writeLong(int64(len(b)))
+	e.buf.Write(b)
+}
+
+func (e *avroEncoder) writeString(s string) {
+	e.writeLong(int64(len(s)))
+	e.buf.WriteString(s)
+}
+
+func (e *avroEncoder) writeBoolean(v bool) {
+	if v {
+		e.buf.WriteByte(1)
+	} else {
+		e.buf.WriteByte(0)
+	}
+}
+
+// avroWriter writes an Avro object container file with the null codec. The records are
+// buffered and written in blocks, each followed by the sync marker of the file
+type avroWriter struct {
+	out        io.Writer
+	syncMarker []byte
+	records    avroEncoder
+	count      int64
+}
+
+func newAvroWriter(out io.Writer, schema string) (*avroWriter, error) {
+	syncMarker := make([]byte, 16)
+	if _, err := rand.Read(syncMarker); err != nil {
+		return nil, err
+	}
+	header := &avroEncoder{}
+	header.buf.Write(avroMagic)
+	// the metadata is a map of bytes, written as a single block of two entries followed by an empty block
+	header.writeLong(2)
+	header.writeString("avro.schema")
+	header.writeBytes([]byte(schema))
+	header.writeString("avro.codec")
+	header.writeBytes([]byte("null"))
+	header.writeLong(0)
+	header.buf.Write(syncMarker)
+	if _, err := out.Write(header.buf.Bytes()); err != nil {
+		return nil, err
+	}
+	return &avroWriter{out: out, syncMarker: syncMarker}, nil
+}
+
+func (w *avroWriter) Write(record Record) error {
+	record.encodeAvro(&w.records)
+	w.count++
+	if w.count == avroBlockSize {
+		return w.flushBlock()
+	}
+	return nil
+}
+
+func (w *avroWriter) Close() error {
+	if w.count == 0 {
+		return nil
+	}
+	return w.flushBlock()
+}
+
+func (w *avroWriter) flushBlock() error {
+	block := &avroEncoder{}
+	block.writeLong(w.count)
+	block.writeLong(int64(w.records.buf.Len()))
+	block.buf.Write(w.records.buf.Bytes())
+	block.buf.Write(w.syncMarker)
+	if _, err := w.out.Write(block.buf.Bytes()); err != nil {
+		return err
+	}
+	w.records.buf.Reset()
+	w.count = 0
+	return nil
+}
@@ -0,0 +1,150 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package export writes the records exported from a ledger in the formats ingested by the data warehouses
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Format is the format of an export file
+type Format string
+
+const (
+	// FormatNDJSON writes a JSON object per record, one per line
+	FormatNDJSON Format = "ndjson"
+	// FormatAvro writes an Avro object container file that embeds the schema of the records
+	FormatAvro Format = "avro"
+)
+
+// FileExtension returns the extension of the files of the format
+func (f Format) FileExtension() string {
+	return "." + string(f)
+}
+
+// StateRecordSchema is the Avro schema of StateRecord
+const StateRecordSchema = `{"type":"record","name":"StateRecord","namespace":"org.hyperledger.fabric.export","fields":[` +
+	`{"name":"namespace","type":"string"},` +
+	`{"name":"key","type":"string"},` +
+	`{"name":"value","type":"bytes"},` +
+	`{"name":"blockNum","type":"long"},` +
+	`{"name":"txNum","type":"long"}]}`
+
+// HistoryRecordSchema is the Avro schema of HistoryRecord
+const HistoryRecordSchema = `{"type":"record","name":"HistoryRecord","namespace":"org.hyperledger.fabric.export","fields":[` +
+	`{"name":"namespace","type":"string"},` +
+	`{"name":"key","type":"string"},` +
+	`{"name":"value","type":"bytes"},` +
+	`{"name":"isDelete","type":"boolean"},` +
+	`{"name":"txID","type":"string"},` +
+	`{"name":"blockNum","type":"long"},` +
+	`{"name":"txNum","type":"long"},` +
+	`{"name":"timestamp","type":{"type":"long","logicalType":"timestamp-millis"}},` +
+	`{"name":"creatorMspId","type":"string"},` +
+	`{"name":"creator","type":"string"}]}`
+
+// Record is a record of an export file
+type Record interface {
+	encodeAvro(e *avroEncoder)
+}
+
+// StateRecord is a key-value of the state database along with its version, which is the height of the
+// transaction that wrote it. As in the state database, TxNum is the position of the transaction in the block plus one
+type StateRecord struct {
+	Namespace string `json:"namespace"`
+	Key       string `json:"key"`
+	Value     []byte `json:"value"`
+	BlockNum  uint64 `json:"blockNum"`
+	TxNum     uint64 `json:"txNum"`
+}
+
+func (r *StateRecord) encodeAvro(e *avroEncoder) {
+	e.writeString(r.Namespace)
+	e.writeString(r.Key)
+	e.writeBytes(r.Value)
+	e.writeLong(int64(r.BlockNum))
+	e.writeLong(int64(r.TxNum))
+}
+
+// HistoryRecord is a write of a key by a valid transaction. BlockNum and TxNum are the height of the transaction,
+// as in StateRecord, and Timestamp is the time, in milliseconds since the epoch, in the channel header of the transaction.
+// Creator is the subject of the certificate of the creator of the transaction, or its hash as per the RedactionRules.
+// CreatorMSPID and Creator are empty unless the export includes the creators
+type HistoryRecord struct {
+	Namespace    string `json:"namespace"`
+	Key          string `json:"key"`
+	Value        []byte `json:"value"`
+	IsDelete     bool   `json:"isDelete"`
+	TxID         string `json:"txID"`
+	BlockNum     uint64 `json:"blockNum"`
+	TxNum        uint64 `json:"txNum"`
+	Timestamp    int64  `json:"timestamp"`
+	CreatorMSPID string `json:"creatorMspId"`
+	Creator      string `json:"creator"`
+}
+
+func (r *HistoryRecord) encodeAvro(e *avroEncoder) {
+	e.writeString(r.Namespace)
+	e.writeString(r.Key)
+	e.writeBytes(r.Value)
+	e.writeBoolean(r.IsDelete)
+	e.writeString(r.TxID)
+	e.writeLong(int64(r.BlockNum))
+	e.writeLong(int64(r.TxNum))
+	e.writeLong(r.Timestamp)
+	e.writeString(r.CreatorMSPID)
+	e.writeString(r.Creator)
+}
+
+// Writer writes records to an export file
+type Writer interface {
+	// Write writes a record
+	Write(record Record) error
+	// Close flushes the buffered records. The underlying io.Writer is not closed
+	Close() error
+}
+
+// NewWriter returns a Writer of the given format. The schema is the Avro schema of the records,
+// which is embedded in the Avro files and is ignored by the other formats
+func NewWriter(format Format, out io.Writer, schema string) (Writer, error) {
+	switch format {
+	case FormatNDJSON:
+		buf := bufio.NewWriter(out)
+		return &ndjsonWriter{buf: buf, encoder: json.NewEncoder(buf)}, nil
+	case FormatAvro:
+		return newAvroWriter(out, schema)
+	default:
+		return nil, fmt.Errorf("Unsupported export format [%s]", format)
+	}
+}
+
+type ndjsonWriter struct {
+	buf     *bufio.Writer
+	encoder *json.Encoder
+}
+
+func (w *ndjsonWriter) Write(record Record) error {
+	// Encode terminates each record with a newline
+	return w.encoder.Encode(record)
+}
+
+func (w *ndjsonWriter) Close() error {
+	return w.buf.Flush()
+}
@@ -0,0 +1,139 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+)
+
+func TestNDJSONWriter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w, err := NewWriter(FormatNDJSON, buf, StateRecordSchema)
+	testutil.AssertNoError(t, err, "")
+	records := []*StateRecord{
+		{Namespace: "ns", Key: "key1", Value: []byte("value1"), BlockNum: 1, TxNum: 0},
+		{Namespace: "ns", Key: "key2", Value: []byte("value2"), BlockNum: 2, TxNum: 3},
+	}
+	for _, r := range records {
+		testutil.AssertNoError(t, w.Write(r), "")
+	}
+	testutil.AssertNoError(t, w.Close(), "")
+
+	scanner := bufio.NewScanner(buf)
+	var decoded []*StateRecord
+	for scanner.Scan() {
+		r := &StateRecord{}
+		testutil.AssertNoError(t, json.Unmarshal(scanner.Bytes(), r), "")
+		decoded = append(decoded, r)
+	}
+	testutil.AssertEquals(t, decoded, records)
+}
+
+func TestAvroWriter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w, err := NewWriter(FormatAvro, buf, HistoryRecordSchema)
+	testutil.AssertNoError(t, err, "")
+	// enough records to span more than one data block
+	var records []*HistoryRecord
+	for i := 0; i < avroBlockSize+10; i++ {
+		r := &HistoryRecord{Namespace: "ns", Key: fmt.Sprintf("key%d", i), Value: []byte(fmt.Sprintf("value%d", i)),
+			IsDelete: i%2 == 0, TxID: fmt.Sprintf("tx%d", i), BlockNum: uint64(i), TxNum: uint64(i % 3), Timestamp: int64(-i),
+			CreatorMSPID: "Org1MSP", Creator: fmt.Sprintf("CN=user%d", i%2)}
+		if r.IsDelete {
+			r.Value = nil
+		}
+		records = append(records, r)
+		testutil.AssertNoError(t, w.Write(r), "")
+	}
+	testutil.AssertNoError(t, w.Close(), "")
+
+	d := &avroDecoder{buf.Bytes()}
+	testutil.AssertEquals(t, d.read(4), avroMagic)
+	metadata := make(map[string]string)
+	for count := d.readLong(); count != 0; count = d.readLong() {
+		for i := int64(0); i < count; i++ {
+			key := d.readString()
+			metadata[key] = string(d.readBytes())
+		}
+	}
+	testutil.AssertEquals(t, metadata["avro.schema"], HistoryRecordSchema)
+	testutil.AssertEquals(t, metadata["avro.codec"], "null")
+	syncMarker := d.read(16)
+
+	var decoded []*HistoryRecord
+	blocks := 0
+	for len(d.data) > 0 {
+		count := d.readLong()
+		size := d.readLong()
+		remaining := len(d.data)
+		for i := int64(0); i < count; i++ {
+			r := &HistoryRecord{Namespace: d.readString(), Key: d.readString(), Value: d.readBytes(), IsDelete: d.readBoolean(),
+				TxID: d.readString(), BlockNum: uint64(d.readLong()), TxNum: uint64(d.readLong()), Timestamp: d.readLong(),
+				CreatorMSPID: d.readString(), Creator: d.readString()}
+			decoded = append(decoded, r)
+		}
+		testutil.AssertEquals(t, int64(remaining-len(d.data)), size)
+		testutil.AssertEquals(t, d.read(16), syncMarker)
+		blocks++
+	}
+	testutil.AssertEquals(t, blocks, 2)
+	testutil.AssertEquals(t, decoded, records)
+}
+
+func TestUnsupportedFormat(t *testing.T) {
+	_, err := NewWriter(Format("csv"), &bytes.Buffer{}, StateRecordSchema)
+	testutil.AssertError(t, err, "Expected an error for an unsupported format")
+}
+
+type avroDecoder struct {
+	data []byte
+}
+
+func (d *avroDecoder) read(n int) []byte {
+	b := d.data[:n]
+	d.data = d.data[n:]
+	return b
+}
+
+func (d *avroDecoder) readLong() int64 {
+	v, n := binary.Varint(d.data)
+	d.data = d.data[n:]
+	return v
+}
+
+func (d *avroDecoder) readBytes() []byte {
+	n := d.readLong()
+	if n == 0 {
+		return nil
+	}
+	return d.read(int(n))
+}
+
+func (d *avroDecoder) readString() string {
+	return string(d.readBytes())
+}
+
+func (d *avroDecoder) readBoolean() bool {
+	return d.read(1)[0] == 1
+}
@@ -0,0 +1,132 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package export
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/core/ledger/util"
+	"github.com/hyperledger/fabric/msp"
+)
+
+// collectionSeparator separates the namespace from the collection in a DropValues entry
+const collectionSeparator = "/"
+
+// creatorHashKeySize is the size of the random key of the hashes of the creators of an export
+const creatorHashKeySize = 32
+
+// RedactionRules select the data withheld from an export, so that the export can be shared, e.g., for a support case,
+// without leaking the business data of the channel. The keys, the versions, and the transaction ids are kept
+type RedactionRules struct {
+	// DropValues lists the namespaces whose values are exported empty. An entry ns/coll selects the hashes of the private data
+	// of the collection coll of the chaincode ns. An entry ns selects the namespace of the chaincode ns along with all the
+	// namespaces derived from it, such as the hashes of the private data of all its collections
+	DropValues []string
+	// HashCreators exports the creators of the transactions as the hex-encoded HMAC-SHA256 of their serialized identities,
+	// keyed with a random key generated for each export. The hash tells apart the creators within an export without revealing
+	// them, and cannot be matched against the hashes of known identities nor across exports
+	HashCreators bool
+}
+
+// Validate checks that the DropValues entries are well formed
+func (r *RedactionRules) Validate() error {
+	if r == nil {
+		return nil
+	}
+	for _, entry := range r.DropValues {
+		parts := strings.Split(entry, collectionSeparator)
+		if len(parts) > 2 || parts[0] == "" || (len(parts) == 2 && parts[1] == "") {
+			return fmt.Errorf("Invalid redaction entry [%s], expected a namespace or a namespace%scollection", entry, collectionSeparator)
+		}
+	}
+	return nil
+}
+
+// DropsValue tells whether the values of the given namespace of the state database are withheld from the export
+func (r *RedactionRules) DropsValue(namespace string) bool {
+	if r == nil {
+		return false
+	}
+	for _, entry := range r.DropValues {
+		parts := strings.SplitN(entry, collectionSeparator, 2)
+		if len(parts) == 2 {
+			if namespace == util.DeriveHashedDataNs(parts[0], parts[1]) {
+				return true
+			}
+			continue
+		}
+		if namespace == entry || util.IsDerivedNsOf(namespace, entry) {
+			return true
+		}
+	}
+	return false
+}
+
+// CreatorRedactor returns the creators of the transactions as exported by a single export
+type CreatorRedactor struct {
+	include bool
+	hashKey []byte
+}
+
+// NewCreatorRedactor returns the CreatorRedactor of an export. The creators are exported if the rules hash them,
+// or else, in clear, if include is set. Otherwise, the history records of the export carry no creators
+func (r *RedactionRules) NewCreatorRedactor(include bool) (*CreatorRedactor, error) {
+	if r == nil || !r.HashCreators {
+		return &CreatorRedactor{include: include}, nil
+	}
+	hashKey := make([]byte, creatorHashKeySize)
+	if _, err := rand.Read(hashKey); err != nil {
+		return nil, fmt.Errorf("Failed to generate the key of the hashes of the creators: %s", err)
+	}
+	return &CreatorRedactor{include: true, hashKey: hashKey}, nil
+}
+
+// Creator returns the MSP id and the creator of a transaction as exported, given the serialized identity of the creator.
+// The creator is the subject of the certificate of the identity, or the hash of the identity if the rules hash the creators.
+// The identities that cannot be decoded are still hashed, but have neither an MSP id nor a subject.
+// Both are empty if the creators are not exported
+func (c *CreatorRedactor) Creator(serializedIdentity []byte) (string, string) {
+	if !c.include {
+		return "", ""
+	}
+	sID := &msp.SerializedIdentity{}
+	if err := proto.Unmarshal(serializedIdentity, sID); err != nil {
+		sID = &msp.SerializedIdentity{}
+	}
+	if c.hashKey != nil {
+		mac := hmac.New(sha256.New, c.hashKey)
+		mac.Write(serializedIdentity)
+		return sID.Mspid, hex.EncodeToString(mac.Sum(nil))
+	}
+	block, _ := pem.Decode(sID.IdBytes)
+	if block == nil {
+		return sID.Mspid, ""
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return sID.Mspid, ""
+	}
+	return sID.Mspid, cert.Subject.String()
+}
@@ -0,0 +1,83 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package export
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/msp"
+)
+
+func TestRedactionRulesValidate(t *testing.T) {
+	testutil.AssertNoError(t, (*RedactionRules)(nil).Validate(), "")
+	testutil.AssertNoError(t, (&RedactionRules{DropValues: []string{"ns1", "ns2/coll1"}}).Validate(), "")
+	for _, entry := range []string{"", "/coll1", "ns1/", "ns1/coll1/extra"} {
+		testutil.AssertError(t, (&RedactionRules{DropValues: []string{entry}}).Validate(), entry)
+	}
+}
+
+func TestRedactionRulesDropsValue(t *testing.T) {
+	rules := &RedactionRules{DropValues: []string{"ns1", "ns2/coll1"}}
+	testutil.AssertEquals(t, rules.DropsValue("ns1"), true)
+	testutil.AssertEquals(t, rules.DropsValue("ns1$$hcoll1"), true)
+	testutil.AssertEquals(t, rules.DropsValue("ns10"), false)
+	testutil.AssertEquals(t, rules.DropsValue("ns2"), false)
+	testutil.AssertEquals(t, rules.DropsValue("ns2$$hcoll1"), true)
+	testutil.AssertEquals(t, rules.DropsValue("ns2$$hcoll2"), false)
+	testutil.AssertEquals(t, (*RedactionRules)(nil).DropsValue("ns1"), false)
+}
+
+func TestCreatorRedactor(t *testing.T) {
+	serializedIdentity, err := msp.NewSerializedIdentity("Org1MSP", []byte("not a certificate"))
+	testutil.AssertNoError(t, err, "")
+
+	// the creators are not exported unless requested
+	redactor, err := (*RedactionRules)(nil).NewCreatorRedactor(false)
+	testutil.AssertNoError(t, err, "")
+	mspID, creator := redactor.Creator(serializedIdentity)
+	testutil.AssertEquals(t, mspID, "")
+	testutil.AssertEquals(t, creator, "")
+
+	redactor, err = (*RedactionRules)(nil).NewCreatorRedactor(true)
+	testutil.AssertNoError(t, err, "")
+	mspID, creator = redactor.Creator(serializedIdentity)
+	testutil.AssertEquals(t, mspID, "Org1MSP")
+	testutil.AssertEquals(t, creator, "")
+
+	// the hash is keyed, so that it cannot be matched against the plain hash of the identity nor across exports
+	redactor, err = (&RedactionRules{HashCreators: true}).NewCreatorRedactor(false)
+	testutil.AssertNoError(t, err, "")
+	mspID, creator = redactor.Creator(serializedIdentity)
+	testutil.AssertEquals(t, mspID, "Org1MSP")
+	testutil.AssertEquals(t, len(creator), 64)
+	hash := sha256.Sum256(serializedIdentity)
+	testutil.AssertNotEquals(t, creator, hex.EncodeToString(hash[:]))
+	_, sameCreator := redactor.Creator(serializedIdentity)
+	testutil.AssertEquals(t, sameCreator, creator)
+	otherRedactor, err := (&RedactionRules{HashCreators: true}).NewCreatorRedactor(false)
+	testutil.AssertNoError(t, err, "")
+	_, otherCreator := otherRedactor.Creator(serializedIdentity)
+	testutil.AssertNotEquals(t, otherCreator, creator)
+
+	// an identity that cannot be decoded is hashed all the same
+	mspID, creator = redactor.Creator([]byte("garbage"))
+	testutil.AssertEquals(t, mspID, "")
+	testutil.AssertEquals(t, len(creator), 64)
+}
@@ -0,0 +1,115 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package benchmark measures the throughput of the full commit path of the ledger, i.e., the validation of the
+// blocks and their commit to the block storage, the state database and the history database, for a set of
+// workloads. The benchmarks are run via `go test -bench . ./core/ledger/kvledger/benchmark` and their results
+// can be recorded as a baseline and compared against it, so that the regressions in the serialization or the
+// locking on the commit path are caught:
+//
+//	go test -run TestCommitRegression ./core/ledger/kvledger/benchmark -baseline /path/to/baseline.json -update
+//	go test -run TestCommitRegression ./core/ledger/kvledger/benchmark -baseline /path/to/baseline.json
+//
+// The baseline is specific to the machine it is recorded on and is hence not part of the repository
+package benchmark
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+)
+
+// Workload describes the blocks committed by a benchmark. Each transaction writes its keys without reading them,
+// so that all the transactions are valid and the blocks can be generated ahead of the commits
+type Workload struct {
+	Name        string
+	TxsPerBlock int
+	KeysPerTx   int
+	ValueSize   int
+}
+
+// Workloads are the workloads run by the benchmarks
+var Workloads = []*Workload{
+	{Name: "SmallBlocks", TxsPerBlock: 10, KeysPerTx: 1, ValueSize: 100},
+	{Name: "LargeBlocks", TxsPerBlock: 200, KeysPerTx: 2, ValueSize: 100},
+	{Name: "LargeValues", TxsPerBlock: 20, KeysPerTx: 1, ValueSize: 64 * 1024},
+}
+
+// Result is the outcome of a benchmark, per block committed
+type Result struct {
+	NsPerOp     int64   `json:"nsPerOp"`
+	AllocsPerOp int64   `json:"allocsPerOp"`
+	BytesPerOp  int64   `json:"bytesPerOp"`
+	TxsPerSec   float64 `json:"txsPerSec"`
+}
+
+// Baseline maps the names of the benchmarks to their recorded results
+type Baseline map[string]*Result
+
+// LoadBaseline loads the baseline recorded to the given file
+func LoadBaseline(path string) (Baseline, error) {
+	fileBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	baseline := Baseline{}
+	if err := json.Unmarshal(fileBytes, &baseline); err != nil {
+		return nil, fmt.Errorf("Error while decoding the baseline [%s]: %s", path, err)
+	}
+	return baseline, nil
+}
+
+// Save records the baseline to the given file
+func (b Baseline) Save(path string) error {
+	fileBytes, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, fileBytes, 0644)
+}
+
+// Regressions compares the given results with the baseline and describes each of the results whose time or
+// allocations per block exceed the baseline by more than the given tolerance, e.g., 0.2 for 20%. The benchmarks
+// that are not in the baseline are not compared
+func (b Baseline) Regressions(results Baseline, tolerance float64) []string {
+	var names []string
+	for name := range results {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var regressions []string
+	for _, name := range names {
+		baseline, ok := b[name]
+		if !ok {
+			continue
+		}
+		result := results[name]
+		if exceeds(result.NsPerOp, baseline.NsPerOp, tolerance) {
+			regressions = append(regressions, fmt.Sprintf("%s takes %d ns per block, %d ns in the baseline",
+				name, result.NsPerOp, baseline.NsPerOp))
+		}
+		if exceeds(result.AllocsPerOp, baseline.AllocsPerOp, tolerance) {
+			regressions = append(regressions, fmt.Sprintf("%s makes %d allocations per block, %d in the baseline",
+				name, result.AllocsPerOp, baseline.AllocsPerOp))
+		}
+	}
+	return regressions
+}
+
+func exceeds(value int64, baseline int64, tolerance float64) bool {
+	return float64(value) > float64(baseline)*(1+tolerance)
+}
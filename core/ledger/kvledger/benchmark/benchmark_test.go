@@ -0,0 +1,218 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package benchmark
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger"
+	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+	ledgertestutil "github.com/hyperledger/fabric/core/ledger/testutil"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/spf13/viper"
+)
+
+var (
+	baselinePath = flag.String("baseline", "", "file of the baseline that TestCommitRegression compares the results with")
+	update       = flag.Bool("update", false, "record the results of TestCommitRegression as the baseline")
+	tolerance    = flag.Float64("tolerance", 0.2, "fraction by which a result may exceed the baseline")
+)
+
+const benchmarkLedgerID = "benchmark"
+
+func TestMain(m *testing.M) {
+	ledgertestutil.SetupCoreYAMLConfig("./../../../../peer")
+	viper.Set("peer.fileSystemPath", "/tmp/fabric/ledgertests/kvledger/benchmark")
+	os.Exit(m.Run())
+}
+
+// commitPath is a configuration of the stores that the blocks are committed to
+type commitPath struct {
+	name          string
+	stateDatabase string
+	history       bool
+}
+
+var commitPaths = []*commitPath{
+	{name: "LevelDB", stateDatabase: "goleveldb"},
+	{name: "LevelDBWithHistory", stateDatabase: "goleveldb", history: true},
+	{name: "CouchDBWithHistory", stateDatabase: "CouchDB", history: true},
+}
+
+func BenchmarkCommitLevelDB(b *testing.B) {
+	runWorkloads(b, commitPaths[0])
+}
+
+func BenchmarkCommitLevelDBWithHistory(b *testing.B) {
+	runWorkloads(b, commitPaths[1])
+}
+
+func BenchmarkCommitCouchDBWithHistory(b *testing.B) {
+	runWorkloads(b, commitPaths[2])
+}
+
+func runWorkloads(b *testing.B, path *commitPath) {
+	for _, w := range Workloads {
+		b.Run(w.Name, func(b *testing.B) {
+			benchmarkCommit(b, path, w)
+		})
+	}
+}
+
+// benchmarkCommit commits b.N blocks of the given workload to a new ledger. Only the commits are timed,
+// the blocks are generated with the timer stopped
+func benchmarkCommit(b *testing.B, path *commitPath, w *Workload) {
+	if path.stateDatabase == "CouchDB" && !couchDBReachable() {
+		b.Skipf("CouchDB is not reachable at [%s]", ledgerconfig.GetCouchDBDefinition().URL)
+	}
+	defer viper.Set("ledger.state.stateDatabase", viper.GetString("ledger.state.stateDatabase"))
+	defer viper.Set("ledger.state.historyDatabase", viper.GetBool("ledger.state.historyDatabase"))
+	viper.Set("ledger.state.stateDatabase", path.stateDatabase)
+	viper.Set("ledger.state.historyDatabase", path.history)
+
+	b.StopTimer()
+	os.RemoveAll(ledgerconfig.GetRootPath())
+	defer os.RemoveAll(ledgerconfig.GetRootPath())
+	provider, err := kvledger.NewProvider()
+	if err != nil {
+		b.Fatalf("Error while creating the ledger provider: %s", err)
+	}
+	defer provider.Close()
+	peerLedger, err := provider.Create(benchmarkLedgerID)
+	if err != nil {
+		b.Fatalf("Error while creating the ledger: %s", err)
+	}
+	// the databases of the ledger in CouchDB outlive the file system of the peer and are hence dropped
+	defer provider.Destroy(benchmarkLedgerID)
+	defer peerLedger.Close()
+
+	value := make([]byte, w.ValueSize)
+	bg := testutil.NewBlockGenerator(nil)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		block := nextBlock(b, peerLedger, bg, w, i, value)
+		b.StartTimer()
+		if err := peerLedger.Commit(block); err != nil {
+			b.Fatalf("Error while committing block [%d]: %s", block.Header.Number, err)
+		}
+		b.StopTimer()
+	}
+	b.ReportMetric(float64(b.N*w.TxsPerBlock)/b.Elapsed().Seconds(), "txs/s")
+}
+
+// nextBlock generates the next block of the workload. The keys of the block are distinct from the keys of
+// the previous blocks so that the state grows as the blocks are committed
+func nextBlock(b *testing.B, peerLedger ledger.PeerLedger, bg *testutil.BlockGenerator, w *Workload, blockIndex int, value []byte) *common.Block {
+	var simulationResults [][]byte
+	for tx := 0; tx < w.TxsPerBlock; tx++ {
+		simulator, err := peerLedger.NewTxSimulator()
+		if err != nil {
+			b.Fatalf("Error while creating a simulator: %s", err)
+		}
+		for k := 0; k < w.KeysPerTx; k++ {
+			simulator.SetState("benchmark", fmt.Sprintf("key_%d_%d_%d", blockIndex, tx, k), value)
+		}
+		simulator.Done()
+		simRes, err := simulator.GetTxSimulationResults()
+		if err != nil {
+			b.Fatalf("Error while simulating a transaction: %s", err)
+		}
+		simulationResults = append(simulationResults, simRes)
+	}
+	return bg.NextBlock(simulationResults, false)
+}
+
+func couchDBReachable() bool {
+	conn, err := net.DialTimeout("tcp", ledgerconfig.GetCouchDBDefinition().URL, time.Second)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// TestCommitRegression runs the benchmarks and compares their results with the baseline given by -baseline,
+// or records the results as the baseline if -update is given. The test is skipped without a baseline
+func TestCommitRegression(t *testing.T) {
+	if *baselinePath == "" {
+		t.Skip("No baseline given, see -baseline")
+	}
+	results := Baseline{}
+	for _, path := range commitPaths {
+		for _, w := range Workloads {
+			path, w := path, w
+			var skipped bool
+			r := testing.Benchmark(func(b *testing.B) {
+				defer func() { skipped = b.Skipped() }()
+				benchmarkCommit(b, path, w)
+			})
+			if skipped || r.N == 0 {
+				t.Logf("Skipped %s/%s", path.name, w.Name)
+				continue
+			}
+			name := fmt.Sprintf("%s/%s", path.name, w.Name)
+			results[name] = &Result{NsPerOp: r.NsPerOp(), AllocsPerOp: r.AllocsPerOp(), BytesPerOp: r.AllocedBytesPerOp(),
+				TxsPerSec: float64(w.TxsPerBlock) * float64(time.Second) / float64(r.NsPerOp())}
+			t.Logf("%s: %d ns/block, %d allocs/block, %.0f txs/s", name, r.NsPerOp(), r.AllocsPerOp(), results[name].TxsPerSec)
+		}
+	}
+	if *update {
+		if err := results.Save(*baselinePath); err != nil {
+			t.Fatalf("Error while recording the baseline: %s", err)
+		}
+		return
+	}
+	baseline, err := LoadBaseline(*baselinePath)
+	if err != nil {
+		t.Fatalf("Error while loading the baseline: %s", err)
+	}
+	for _, regression := range baseline.Regressions(results, *tolerance) {
+		t.Error(regression)
+	}
+}
+
+func TestRegressions(t *testing.T) {
+	baseline := Baseline{
+		"LevelDB/SmallBlocks": {NsPerOp: 1000, AllocsPerOp: 100},
+		"LevelDB/LargeBlocks": {NsPerOp: 1000, AllocsPerOp: 100},
+	}
+	results := Baseline{
+		"LevelDB/SmallBlocks": {NsPerOp: 1100, AllocsPerOp: 130},
+		"LevelDB/LargeBlocks": {NsPerOp: 1300, AllocsPerOp: 90},
+		"CouchDB/SmallBlocks": {NsPerOp: 5000, AllocsPerOp: 500},
+	}
+	testutil.AssertEquals(t, baseline.Regressions(results, 0.2), []string{
+		"LevelDB/LargeBlocks takes 1300 ns per block, 1000 ns in the baseline",
+		"LevelDB/SmallBlocks makes 130 allocations per block, 100 in the baseline",
+	})
+	testutil.AssertEquals(t, len(baseline.Regressions(results, 0.5)), 0)
+
+	path := ledgerconfig.GetRootPath() + "-baseline.json"
+	defer os.Remove(path)
+	testutil.AssertNoError(t, baseline.Save(path), "")
+	loaded, err := LoadBaseline(path)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, loaded, baseline)
+}
@@ -0,0 +1,228 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package ccevents
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
+	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+	lutils "github.com/hyperledger/fabric/core/ledger/util"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/peer"
+	putils "github.com/hyperledger/fabric/protos/utils"
+	logging "github.com/op/go-logging"
+)
+
+var logger = logging.MustGetLogger("ccevents")
+
+var savePointKey = []byte{0x00}
+var entryKeyPrefix = []byte{0x01}
+var firstIndexedBlockKey = []byte{0x02}
+var nsSep = []byte{0x00}
+var nsEnd = []byte{0x01}
+
+// Provider provides handles to the index of the chaincode events of the ledgers
+type Provider struct {
+	dbProvider *leveldbhelper.Provider
+}
+
+// NewProvider instantiates Provider
+func NewProvider() *Provider {
+	dbPath := ledgerconfig.GetChaincodeEventsPath()
+	logger.Debugf("constructing chaincode events Provider dbPath=%s", dbPath)
+	return &Provider{leveldbhelper.NewProvider(&leveldbhelper.Conf{DBPath: dbPath})}
+}
+
+// NewReadOnlyProvider instantiates Provider that opens the existing databases in read-only mode
+func NewReadOnlyProvider() *Provider {
+	dbPath := ledgerconfig.GetChaincodeEventsPath()
+	logger.Debugf("constructing read-only chaincode events Provider dbPath=%s", dbPath)
+	return &Provider{leveldbhelper.NewProvider(&leveldbhelper.Conf{DBPath: dbPath, ReadOnly: true})}
+}
+
+// GetIndex returns the index of the chaincode events of the given ledger
+func (p *Provider) GetIndex(ledgerID string) *Index {
+	return &Index{p.dbProvider.GetDBHandle(ledgerID), ledgerID}
+}
+
+// Drop removes the index of the chaincode events of the given ledger
+func (p *Provider) Drop(ledgerID string) error {
+	return p.dbProvider.GetDBHandle(ledgerID).DeleteAll()
+}
+
+// Close closes the underlying db
+func (p *Provider) Close() {
+	p.dbProvider.Close()
+}
+
+// Index maintains the chaincode events set by the valid transactions of a ledger. An event is recorded
+// against the name of the chaincode and the position of the transaction so that the events of a chaincode
+// in a range of blocks can be looked up without reading the blocks. Only the hash of the payload is recorded
+type Index struct {
+	db       *leveldbhelper.DBHandle
+	ledgerID string
+}
+
+// Commit records the chaincode events set by the valid transactions in the block
+func (idx *Index) Commit(block *common.Block) error {
+	blockNum := block.Header.Number
+	batch := leveldbhelper.NewUpdateBatch()
+	txsFilter := lutils.TxValidationFlags(block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER])
+	for txIndex, envBytes := range block.Data.Data {
+		if len(txsFilter) > txIndex && txsFilter.IsInvalid(txIndex) {
+			continue
+		}
+		event, err := extractChaincodeEvent(envBytes)
+		if err != nil {
+			return err
+		}
+		if event == nil || event.ChaincodeId == "" {
+			continue
+		}
+		payloadHash := sha256.Sum256(event.Payload)
+		eventInfo := &peer.ChaincodeEventInfo{ChaincodeId: event.ChaincodeId, TxId: event.TxId, EventName: event.EventName,
+			PayloadHash: payloadHash[:], BlockNumber: blockNum, TxNumber: uint64(txIndex)}
+		eventInfoBytes, err := proto.Marshal(eventInfo)
+		if err != nil {
+			return err
+		}
+		logger.Debugf("Channel [%s]: Indexing event [%s] of chaincode [%s] set by transaction [%d] in block [%d]",
+			idx.ledgerID, event.EventName, event.ChaincodeId, txIndex, blockNum)
+		batch.Put(encodeEntryKey(event.ChaincodeId, blockNum, uint64(txIndex)), eventInfoBytes)
+	}
+	batch.Put(savePointKey, version.NewHeight(blockNum, 0).ToBytes())
+	return idx.db.WriteBatch(batch, true)
+}
+
+// MarkStartingSavepoint marks the given savepoint as the starting point of the index, for a ledger that
+// is created from a snapshot. The events of the blocks up to the savepoint are not available for indexing
+func (idx *Index) MarkStartingSavepoint(savepoint *version.Height) error {
+	batch := leveldbhelper.NewUpdateBatch()
+	firstIndexedBlock := make([]byte, 8)
+	binary.BigEndian.PutUint64(firstIndexedBlock, savepoint.BlockNum+1)
+	batch.Put(firstIndexedBlockKey, firstIndexedBlock)
+	batch.Put(savePointKey, savepoint.ToBytes())
+	return idx.db.WriteBatch(batch, true)
+}
+
+// GetEventsByChaincode returns the events of the given chaincode set by the valid transactions in the blocks
+// from startBlock to endBlock, both inclusive, in the order of the commit of the transactions
+func (idx *Index) GetEventsByChaincode(chaincodeName string, startBlock, endBlock uint64) ([]*peer.ChaincodeEventInfo, error) {
+	if startBlock > endBlock {
+		return nil, fmt.Errorf("start block [%d] is greater than end block [%d]", startBlock, endBlock)
+	}
+	firstIndexedBlockBytes, err := idx.db.Get(firstIndexedBlockKey)
+	if err != nil {
+		return nil, err
+	}
+	if firstIndexedBlockBytes != nil {
+		if firstIndexedBlock := binary.BigEndian.Uint64(firstIndexedBlockBytes); startBlock < firstIndexedBlock {
+			return nil, &ledger.NotFoundError{Msg: fmt.Sprintf(
+				"events of the blocks below block [%d] are not indexed as the ledger is created from a snapshot", firstIndexedBlock)}
+		}
+	}
+	endKey := encodeChaincodeEndKey(chaincodeName)
+	if endBlock < math.MaxUint64 {
+		endKey = encodeEntryKey(chaincodeName, endBlock+1, 0)
+	}
+	itr := idx.db.GetIterator(encodeEntryKey(chaincodeName, startBlock, 0), endKey)
+	defer itr.Release()
+	var events []*peer.ChaincodeEventInfo
+	for itr.Next() {
+		eventInfo := &peer.ChaincodeEventInfo{}
+		if err := proto.Unmarshal(itr.Value(), eventInfo); err != nil {
+			return nil, err
+		}
+		events = append(events, eventInfo)
+	}
+	return events, itr.Error()
+}
+
+// GetLastSavepoint implements method in interface kvledger.Recoverer
+func (idx *Index) GetLastSavepoint() (*version.Height, error) {
+	versionBytes, err := idx.db.Get(savePointKey)
+	if err != nil || versionBytes == nil {
+		return nil, err
+	}
+	height, _ := version.NewHeightFromBytes(versionBytes)
+	return height, nil
+}
+
+// ShouldRecover implements method in interface kvledger.Recoverer
+func (idx *Index) ShouldRecover(lastAvailableBlock uint64) (bool, uint64, error) {
+	savepoint, err := idx.GetLastSavepoint()
+	if err != nil {
+		return false, 0, err
+	}
+	if savepoint == nil {
+		return true, 0, nil
+	}
+	return savepoint.BlockNum != lastAvailableBlock, savepoint.BlockNum + 1, nil
+}
+
+// CommitLostBlock implements method in interface kvledger.Recoverer
+func (idx *Index) CommitLostBlock(block *common.Block) error {
+	return idx.Commit(block)
+}
+
+// extractChaincodeEvent returns the chaincode event set by an endorser transaction, if any
+func extractChaincodeEvent(envBytes []byte) (*peer.ChaincodeEvent, error) {
+	env, err := putils.GetEnvelopeFromBlock(envBytes)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := putils.GetPayload(env)
+	if err != nil {
+		return nil, err
+	}
+	chdr, err := putils.UnmarshalChannelHeader(payload.Header.ChannelHeader)
+	if err != nil {
+		return nil, err
+	}
+	if common.HeaderType(chdr.Type) != common.HeaderType_ENDORSER_TRANSACTION {
+		return nil, nil
+	}
+	respPayload, err := putils.GetActionFromEnvelope(envBytes)
+	if err != nil {
+		return nil, err
+	}
+	if len(respPayload.Events) == 0 {
+		return nil, nil
+	}
+	return putils.GetChaincodeEvents(respPayload.Events)
+}
+
+func encodeEntryKey(ccName string, blockNum uint64, txNum uint64) []byte {
+	key := append(append([]byte{}, entryKeyPrefix...), []byte(ccName)...)
+	key = append(key, nsSep...)
+	heightBytes := make([]byte, 16)
+	binary.BigEndian.PutUint64(heightBytes, blockNum)
+	binary.BigEndian.PutUint64(heightBytes[8:], txNum)
+	return append(key, heightBytes...)
+}
+
+// encodeChaincodeEndKey returns the key that follows all the entries of the given chaincode
+func encodeChaincodeEndKey(ccName string) []byte {
+	key := append(append([]byte{}, entryKeyPrefix...), []byte(ccName)...)
+	return append(key, nsEnd...)
+}
@@ -0,0 +1,140 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package ccevents
+
+import (
+	"crypto/sha256"
+	"os"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
+	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+	lutils "github.com/hyperledger/fabric/core/ledger/util"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/peer"
+	ptestutils "github.com/hyperledger/fabric/protos/testutils"
+	putils "github.com/hyperledger/fabric/protos/utils"
+	"github.com/spf13/viper"
+)
+
+func TestMain(m *testing.M) {
+	viper.Set("peer.fileSystemPath", "/tmp/fabric/ledgertests/ccevents")
+	os.Exit(m.Run())
+}
+
+func TestEntryKeyOrdering(t *testing.T) {
+	testutil.AssertEquals(t, string(encodeEntryKey("mycc", 2, 300)) < string(encodeEntryKey("mycc", 3, 0)), true)
+	testutil.AssertEquals(t, string(encodeEntryKey("mycc", 2, 300)) < string(encodeChaincodeEndKey("mycc")), true)
+	testutil.AssertEquals(t, string(encodeChaincodeEndKey("mycc")) < string(encodeEntryKey("mycc2", 0, 0)), true)
+}
+
+func TestGetEventsByChaincode(t *testing.T) {
+	os.RemoveAll(ledgerconfig.GetChaincodeEventsPath())
+	defer os.RemoveAll(ledgerconfig.GetChaincodeEventsPath())
+	provider := NewProvider()
+	defer provider.Close()
+	index := provider.GetIndex("testLedger")
+
+	block1 := constructBlock(t, 1, []*peer.ChaincodeEvent{
+		{ChaincodeId: "mycc", EventName: "evt1", Payload: []byte("payload1")},
+		nil,
+		{ChaincodeId: "mycc2", EventName: "evt2", Payload: []byte("payload2")},
+	})
+	block2 := constructBlock(t, 2, []*peer.ChaincodeEvent{
+		{ChaincodeId: "mycc", EventName: "evt3", Payload: []byte("payload3")},
+		{ChaincodeId: "mycc", EventName: "evt4", Payload: []byte("payload4")},
+	})
+	// the event of an invalid transaction is not indexed
+	lutils.TxValidationFlags(block2.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER]).SetFlag(1, peer.TxValidationCode_MVCC_READ_CONFLICT)
+	testutil.AssertNoError(t, index.Commit(block1), "")
+	testutil.AssertNoError(t, index.Commit(block2), "")
+	savepoint, _ := index.GetLastSavepoint()
+	testutil.AssertEquals(t, savepoint, version.NewHeight(2, 0))
+
+	events, err := index.GetEventsByChaincode("mycc", 0, 10)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, len(events), 2)
+	testutil.AssertEquals(t, events[0].EventName, "evt1")
+	testutil.AssertEquals(t, events[0].BlockNumber, uint64(1))
+	testutil.AssertEquals(t, events[0].TxNumber, uint64(0))
+	payloadHash := sha256.Sum256([]byte("payload1"))
+	testutil.AssertEquals(t, events[0].PayloadHash, payloadHash[:])
+	testutil.AssertEquals(t, events[1].EventName, "evt3")
+	testutil.AssertEquals(t, events[1].BlockNumber, uint64(2))
+
+	events, _ = index.GetEventsByChaincode("mycc", 2, 2)
+	testutil.AssertEquals(t, len(events), 1)
+	testutil.AssertEquals(t, events[0].EventName, "evt3")
+	events, _ = index.GetEventsByChaincode("mycc", 0, 1)
+	testutil.AssertEquals(t, len(events), 1)
+	testutil.AssertEquals(t, events[0].EventName, "evt1")
+	events, _ = index.GetEventsByChaincode("mycc2", 0, ^uint64(0))
+	testutil.AssertEquals(t, len(events), 1)
+	testutil.AssertEquals(t, events[0].TxNumber, uint64(2))
+	events, _ = index.GetEventsByChaincode("myc", 0, 10)
+	testutil.AssertEquals(t, len(events), 0)
+
+	_, err = index.GetEventsByChaincode("mycc", 3, 2)
+	testutil.AssertError(t, err, "Expected an error for a start block greater than the end block")
+
+	shouldRecover, firstBlockNum, _ := index.ShouldRecover(4)
+	testutil.AssertEquals(t, shouldRecover, true)
+	testutil.AssertEquals(t, firstBlockNum, uint64(3))
+}
+
+func TestMarkStartingSavepoint(t *testing.T) {
+	os.RemoveAll(ledgerconfig.GetChaincodeEventsPath())
+	defer os.RemoveAll(ledgerconfig.GetChaincodeEventsPath())
+	provider := NewProvider()
+	defer provider.Close()
+	index := provider.GetIndex("testLedger")
+
+	testutil.AssertNoError(t, index.MarkStartingSavepoint(version.NewHeight(5, 2)), "")
+	savepoint, _ := index.GetLastSavepoint()
+	testutil.AssertEquals(t, savepoint, version.NewHeight(5, 2))
+	testutil.AssertNoError(t, index.Commit(constructBlock(t, 6, []*peer.ChaincodeEvent{{ChaincodeId: "mycc", EventName: "evt"}})), "")
+
+	_, err := index.GetEventsByChaincode("mycc", 5, 10)
+	_, ok := err.(*ledger.NotFoundError)
+	testutil.AssertEquals(t, ok, true)
+	events, err := index.GetEventsByChaincode("mycc", 6, 10)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, len(events), 1)
+
+	// the index of other ledgers is independent
+	events, _ = provider.GetIndex("otherLedger").GetEventsByChaincode("mycc", 0, 10)
+	testutil.AssertEquals(t, len(events), 0)
+}
+
+func constructBlock(t *testing.T, blockNum uint64, events []*peer.ChaincodeEvent) *common.Block {
+	block := common.NewBlock(blockNum, []byte{})
+	for _, event := range events {
+		var eventBytes []byte
+		if event != nil {
+			event.TxId = "tx"
+			eventBytes, _ = proto.Marshal(event)
+		}
+		env, _, err := ptestutils.ConstructUnsingedTxEnv("testLedger", "mycc", nil, []byte("results"), eventBytes, nil)
+		testutil.AssertNoError(t, err, "")
+		block.Data.Data = append(block.Data.Data, putils.MarshalOrPanic(env))
+	}
+	putils.InitBlockMetadata(block)
+	block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER] = lutils.NewTxValidationFlags(len(events))
+	return block
+}
@@ -0,0 +1,237 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"sync"
+
+	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/rwset"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
+	lutils "github.com/hyperledger/fabric/core/ledger/util"
+	"github.com/hyperledger/fabric/protos/common"
+	putils "github.com/hyperledger/fabric/protos/utils"
+)
+
+var (
+	commitDecoratorProviders     []ledger.CommitDecoratorProvider
+	commitDecoratorProvidersLock sync.Mutex
+)
+
+// RegisterCommitDecoratorProvider registers a provider of the commit decorators. The providers registered
+// before the construction of the ledger provider are used for all the ledgers opened by the ledger provider
+func RegisterCommitDecoratorProvider(provider ledger.CommitDecoratorProvider) {
+	commitDecoratorProvidersLock.Lock()
+	defer commitDecoratorProvidersLock.Unlock()
+	commitDecoratorProviders = append(commitDecoratorProviders, provider)
+}
+
+func registeredCommitDecoratorProviders() []ledger.CommitDecoratorProvider {
+	commitDecoratorProvidersLock.Lock()
+	defer commitDecoratorProvidersLock.Unlock()
+	return append([]ledger.CommitDecoratorProvider{}, commitDecoratorProviders...)
+}
+
+// commitDecorator delivers the committed blocks of a ledger to a decorator and tracks the savepoint of the decorator
+type commitDecorator struct {
+	name      string
+	ledgerID  string
+	decorator ledger.CommitDecorator
+	db        *leveldbhelper.DBHandle
+}
+
+// openCommitDecorators returns the decorators of the given ledger from all the registered providers
+func (provider *Provider) openCommitDecorators(ledgerID string) ([]*commitDecorator, error) {
+	var decorators []*commitDecorator
+	for _, p := range provider.commitDecoratorProviders {
+		decorator, err := p.NewDecorator(ledgerID)
+		if err != nil {
+			closeCommitDecorators(decorators)
+			return nil, err
+		}
+		decorators = append(decorators, &commitDecorator{p.Name(), ledgerID, decorator,
+			provider.commitDecoratorsDBProvider.GetDBHandle(ledgerID)})
+	}
+	return decorators, nil
+}
+
+// dropCommitDecorators removes the derived data and the savepoints of the decorators of the given ledger
+func (provider *Provider) dropCommitDecorators(ledgerID string) error {
+	if provider.commitDecoratorsDBProvider == nil {
+		return nil
+	}
+	for _, p := range provider.commitDecoratorProviders {
+		if err := p.Drop(ledgerID); err != nil {
+			return err
+		}
+	}
+	return provider.commitDecoratorsDBProvider.GetDBHandle(ledgerID).DeleteAll()
+}
+
+func closeCommitDecorators(decorators []*commitDecorator) {
+	for _, d := range decorators {
+		d.decorator.Close()
+	}
+}
+
+// commit delivers the valid write sets of the block to the decorator and then advances the savepoint
+func (d *commitDecorator) commit(block *common.Block) error {
+	event, err := newCommitEvent(d.ledgerID, block)
+	if err != nil {
+		return err
+	}
+	if err := d.decorator.HandleCommit(event); err != nil {
+		return err
+	}
+	return d.markSavepoint(version.NewHeight(block.Header.Number, 0))
+}
+
+// markSavepoint records the given height as the savepoint of the decorator
+func (d *commitDecorator) markSavepoint(savepoint *version.Height) error {
+	return d.db.Put([]byte(d.name), savepoint.ToBytes(), true)
+}
+
+// GetLastSavepoint implements method in interface kvledger.Recoverer
+func (d *commitDecorator) GetLastSavepoint() (*version.Height, error) {
+	versionBytes, err := d.db.Get([]byte(d.name))
+	if err != nil || versionBytes == nil {
+		return nil, err
+	}
+	height, _ := version.NewHeightFromBytes(versionBytes)
+	return height, nil
+}
+
+// ShouldRecover implements method in interface kvledger.Recoverer
+func (d *commitDecorator) ShouldRecover(lastAvailableBlock uint64) (bool, uint64, error) {
+	savepoint, err := d.GetLastSavepoint()
+	if err != nil {
+		return false, 0, err
+	}
+	if savepoint == nil {
+		return true, 0, nil
+	}
+	return savepoint.BlockNum != lastAvailableBlock, savepoint.BlockNum + 1, nil
+}
+
+// CommitLostBlock implements method in interface kvledger.Recoverer
+func (d *commitDecorator) CommitLostBlock(block *common.Block) error {
+	return d.commit(block)
+}
+
+// newCommitEvent collects the public writes of the valid endorser transactions in the block, along with the deletes
+// that the commit of the block derived from the range deletes of the transactions and from the expiries of the keys
+func newCommitEvent(ledgerID string, block *common.Block) (*ledger.CommitEvent, error) {
+	event := &ledger.CommitEvent{LedgerID: ledgerID, BlockNumber: block.Header.Number}
+	derivedDeletes, err := lutils.GetDerivedDeletes(block)
+	if err != nil {
+		return nil, err
+	}
+	txsFilter := lutils.TxValidationFlags(block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER])
+	for txIndex, envBytes := range block.Data.Data {
+		if len(txsFilter) > txIndex && txsFilter.IsInvalid(txIndex) {
+			continue
+		}
+		txWriteSet, err := extractTxWriteSet(envBytes)
+		if err != nil {
+			return nil, err
+		}
+		if txWriteSet != nil {
+			txWriteSet.TxNum = uint64(txIndex)
+			derivedDeletes = addDerivedDeletes(txWriteSet, derivedDeletes)
+			event.TxWriteSets = append(event.TxWriteSets, txWriteSet)
+		}
+	}
+	// the remaining deletes are the expiries, which follow all the transactions of the block
+	if len(derivedDeletes) > 0 {
+		expiries := &ledger.TxWriteSet{TxNum: uint64(len(block.Data.Data))}
+		addDerivedDeletes(expiries, derivedDeletes)
+		event.TxWriteSets = append(event.TxWriteSets, expiries)
+	}
+	return event, nil
+}
+
+// addDerivedDeletes adds the derived deletes of the transaction ahead of its writes, as the writes of a transaction
+// supersede its range deletes, and returns the derived deletes of the following transactions
+func addDerivedDeletes(txWriteSet *ledger.TxWriteSet, derivedDeletes []*lutils.DerivedDelete) []*lutils.DerivedDelete {
+	var nsDeletes []*ledger.NsWrites
+	for len(derivedDeletes) > 0 && derivedDeletes[0].TxNum <= txWriteSet.TxNum+1 {
+		d := derivedDeletes[0]
+		derivedDeletes = derivedDeletes[1:]
+		if len(nsDeletes) == 0 || nsDeletes[len(nsDeletes)-1].Namespace != d.Namespace {
+			nsDeletes = append(nsDeletes, &ledger.NsWrites{Namespace: d.Namespace})
+		}
+		last := nsDeletes[len(nsDeletes)-1]
+		last.Writes = append(last.Writes, &ledger.KV{Key: d.Key})
+	}
+	txWriteSet.NsWrites = append(nsDeletes, txWriteSet.NsWrites...)
+	return derivedDeletes
+}
+
+// extractTxWriteSet returns the public writes of an endorser transaction. nil is returned for the other transactions.
+// The write-sets of the namespaces that carry the time-to-live of the keys are not committed to the state and are skipped
+func extractTxWriteSet(envBytes []byte) (*ledger.TxWriteSet, error) {
+	txID, txRWSet, err := extractTxRWSet(envBytes)
+	if err != nil || txRWSet == nil {
+		return nil, err
+	}
+	txWriteSet := &ledger.TxWriteSet{TxID: txID}
+	for _, nsRWSet := range txRWSet.NsRWs {
+		if _, ok := lutils.SplitTTLNs(nsRWSet.NameSpace); ok || len(nsRWSet.Writes) == 0 {
+			continue
+		}
+		nsWrites := &ledger.NsWrites{Namespace: nsRWSet.NameSpace}
+		for _, kvWrite := range nsRWSet.Writes {
+			var value []byte
+			if !kvWrite.IsDelete {
+				value = kvWrite.Value
+			}
+			nsWrites.Writes = append(nsWrites.Writes, &ledger.KV{Key: kvWrite.Key, Value: value})
+		}
+		txWriteSet.NsWrites = append(txWriteSet.NsWrites, nsWrites)
+	}
+	return txWriteSet, nil
+}
+
+// extractTxRWSet returns the id and the public read-write set of an endorser transaction. A nil read-write set
+// is returned for the other transactions
+func extractTxRWSet(envBytes []byte) (string, *rwset.TxReadWriteSet, error) {
+	env, err := putils.GetEnvelopeFromBlock(envBytes)
+	if err != nil {
+		return "", nil, err
+	}
+	payload, err := putils.GetPayload(env)
+	if err != nil {
+		return "", nil, err
+	}
+	chdr, err := putils.UnmarshalChannelHeader(payload.Header.ChannelHeader)
+	if err != nil {
+		return "", nil, err
+	}
+	if common.HeaderType(chdr.Type) != common.HeaderType_ENDORSER_TRANSACTION {
+		return chdr.TxId, nil, nil
+	}
+	respPayload, err := putils.GetActionFromEnvelope(envBytes)
+	if err != nil {
+		return "", nil, err
+	}
+	txRWSet := &rwset.TxReadWriteSet{}
+	if err := txRWSet.Unmarshal(respPayload.Results); err != nil {
+		return "", nil, err
+	}
+	return chdr.TxId, txRWSet, nil
+}
@@ -0,0 +1,230 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
+	lutils "github.com/hyperledger/fabric/core/ledger/util"
+	"github.com/spf13/viper"
+)
+
+func TestCommitDecorator(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	decoratorProvider := &mockCommitDecoratorProvider{}
+	RegisterCommitDecoratorProvider(decoratorProvider)
+	defer func() { commitDecoratorProviders = nil }()
+	provider, _ := NewProvider()
+	defer provider.Close()
+	l, _ := provider.Create("testLedger")
+
+	bg := testutil.NewBlockGenerator(t)
+	s, _ := l.NewTxSimulator()
+	s.SetState("ns1", "key1", []byte("value1"))
+	s.SetState("ns2", "key2", []byte("value2"))
+	s.Done()
+	res, _ := s.GetTxSimulationResults()
+	testutil.AssertNoError(t, l.Commit(bg.NextBlock([][]byte{res}, false)), "")
+
+	// the second transaction is invalidated by the first one and its writes are not delivered
+	s1, _ := l.NewTxSimulator()
+	s1.GetState("ns1", "key1")
+	s1.DeleteState("ns1", "key1")
+	s1.Done()
+	res1, _ := s1.GetTxSimulationResults()
+	s2, _ := l.NewTxSimulator()
+	s2.GetState("ns1", "key1")
+	s2.SetState("ns1", "key3", []byte("value3"))
+	s2.Done()
+	res2, _ := s2.GetTxSimulationResults()
+	testutil.AssertNoError(t, l.Commit(bg.NextBlock([][]byte{res1, res2}, false)), "")
+
+	events := decoratorProvider.decorators["testLedger"].events
+	testutil.AssertEquals(t, len(events), 2)
+	testutil.AssertEquals(t, events[0].LedgerID, "testLedger")
+	testutil.AssertEquals(t, events[0].BlockNumber, uint64(0))
+	testutil.AssertEquals(t, len(events[0].TxWriteSets), 1)
+	testutil.AssertEquals(t, events[0].TxWriteSets[0].NsWrites, []*ledger.NsWrites{
+		{Namespace: "ns1", Writes: []*ledger.KV{{Key: "key1", Value: []byte("value1")}}},
+		{Namespace: "ns2", Writes: []*ledger.KV{{Key: "key2", Value: []byte("value2")}}},
+	})
+	testutil.AssertEquals(t, events[1].BlockNumber, uint64(1))
+	testutil.AssertEquals(t, len(events[1].TxWriteSets), 1)
+	testutil.AssertEquals(t, events[1].TxWriteSets[0].TxNum, uint64(0))
+	testutil.AssertEquals(t, events[1].TxWriteSets[0].NsWrites, []*ledger.NsWrites{
+		{Namespace: "ns1", Writes: []*ledger.KV{{Key: "key1", Value: nil}}},
+	})
+
+	// the blocks missed by the decorator are redelivered when the ledger is opened
+	db := provider.(*Provider).commitDecoratorsDBProvider.GetDBHandle("testLedger")
+	testutil.AssertNoError(t, db.Put([]byte(decoratorProvider.Name()), version.NewHeight(0, 0).ToBytes(), true), "")
+	l.Close()
+	testutil.AssertEquals(t, decoratorProvider.decorators["testLedger"].closed, true)
+	l, _ = provider.Open("testLedger")
+	events = decoratorProvider.decorators["testLedger"].events
+	testutil.AssertEquals(t, len(events), 1)
+	testutil.AssertEquals(t, events[0].BlockNumber, uint64(1))
+	l.Close()
+
+	// the derived data is dropped along with the ledger
+	testutil.AssertNoError(t, provider.Destroy("testLedger"), "")
+	testutil.AssertEquals(t, decoratorProvider.dropped, []string{"testLedger"})
+}
+
+func TestRangeDeletesAndExpiriesReachConsumers(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	historyEnabled := viper.GetBool("ledger.state.historyDatabase")
+	viper.Set("ledger.state.historyDatabase", true)
+	defer viper.Set("ledger.state.historyDatabase", historyEnabled)
+	decoratorProvider := &mockCommitDecoratorProvider{}
+	RegisterCommitDecoratorProvider(decoratorProvider)
+	defer func() { commitDecoratorProviders = nil }()
+	provider, _ := NewProvider()
+	defer provider.Close()
+	l, _ := provider.Create("testLedger")
+	defer l.Close()
+
+	// key4 expires on the commit of block 2
+	bg := testutil.NewBlockGenerator(t)
+	s, _ := l.NewTxSimulator()
+	s.SetState("ns", "key1", []byte("value1"))
+	s.SetState("ns", "key2", []byte("value2"))
+	s.SetState("ns", "key3", []byte("value3"))
+	s.SetStateWithTTL("ns", "key4", []byte("value4"), 2)
+	s.Done()
+	res, _ := s.GetTxSimulationResults()
+	testutil.AssertNoError(t, l.Commit(bg.NextBlock([][]byte{res}, false)), "")
+
+	// the range delete removes key1, while the write of key2 by the same transaction supersedes its delete
+	s, _ = l.NewTxSimulator()
+	s.DeleteStateByRange("ns", "key1", "key3")
+	s.SetState("ns", "key2", []byte("value2_updated"))
+	s.Done()
+	res, _ = s.GetTxSimulationResults()
+	testutil.AssertNoError(t, l.Commit(bg.NextBlock([][]byte{res}, false)), "")
+
+	s, _ = l.NewTxSimulator()
+	s.SetState("ns", "key5", []byte("value5"))
+	s.Done()
+	res, _ = s.GetTxSimulationResults()
+	testutil.AssertNoError(t, l.Commit(bg.NextBlock([][]byte{res}, false)), "")
+	qe, _ := l.NewQueryExecutor()
+	value, _ := qe.GetState("ns", "key4")
+	qe.Done()
+	testutil.AssertNil(t, value)
+
+	// the decorators see the range delete and the expiry, but not the write-set of the time-to-live
+	events := decoratorProvider.decorators["testLedger"].events
+	testutil.AssertEquals(t, len(events), 3)
+	testutil.AssertEquals(t, events[0].TxWriteSets[0].NsWrites, []*ledger.NsWrites{
+		{Namespace: "ns", Writes: []*ledger.KV{{Key: "key1", Value: []byte("value1")}, {Key: "key2", Value: []byte("value2")},
+			{Key: "key3", Value: []byte("value3")}, {Key: "key4", Value: []byte("value4")}}},
+	})
+	testutil.AssertEquals(t, events[1].TxWriteSets[0].NsWrites, []*ledger.NsWrites{
+		{Namespace: "ns", Writes: []*ledger.KV{{Key: "key1"}}},
+		{Namespace: "ns", Writes: []*ledger.KV{{Key: "key2", Value: []byte("value2_updated")}}},
+	})
+	rangeDeleteTxID := events[1].TxWriteSets[0].TxID
+	testutil.AssertEquals(t, len(events[2].TxWriteSets), 2)
+	testutil.AssertEquals(t, *events[2].TxWriteSets[1], ledger.TxWriteSet{TxNum: 1,
+		NsWrites: []*ledger.NsWrites{{Namespace: "ns", Writes: []*ledger.KV{{Key: "key4"}}}}})
+
+	// the state updates carry the deletes
+	kvs, err := l.GetStateUpdatesBetween("ns", 1, 3)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, kvs, []*ledger.KV{{Key: "key1"}, {Key: "key2", Value: []byte("value2_updated")}, {Key: "key4"},
+		{Key: "key5", Value: []byte("value5")}})
+	kvs, err = l.GetStateUpdatesBetween(lutils.DeriveTTLNs("ns"), 0, 3)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, len(kvs), 0)
+
+	// the history records the deletes, and the time-to-live is not a key of the history
+	qhistory, _ := l.NewHistoryQueryExecutor()
+	testutil.AssertEquals(t, readHistory(t, qhistory, "ns", "key1"), []*ledger.KeyModification{
+		{TxID: events[0].TxWriteSets[0].TxID, Value: []byte("value1")}, {TxID: rangeDeleteTxID}})
+	testutil.AssertEquals(t, readHistory(t, qhistory, "ns", "key4"), []*ledger.KeyModification{
+		{TxID: events[0].TxWriteSets[0].TxID, Value: []byte("value4")}, {}})
+	testutil.AssertEquals(t, len(readHistory(t, qhistory, lutils.DeriveTTLNs("ns"), "key4")), 0)
+	value, err = qhistory.GetStateAsOf("ns", "key1", 1)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, value, []byte("value1"))
+	value, err = qhistory.GetStateAsOf("ns", "key1", 2)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertNil(t, value)
+	value, err = qhistory.GetStateAsOf("ns", "key4", 2)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, value, []byte("value4"))
+	value, err = qhistory.GetStateAsOf("ns", "key4", 3)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertNil(t, value)
+}
+
+func readHistory(t *testing.T, qhistory ledger.HistoryQueryExecutor, namespace string, key string) []*ledger.KeyModification {
+	itr, err := qhistory.GetHistoryForKey(namespace, key)
+	testutil.AssertNoError(t, err, "")
+	defer itr.Close()
+	var modifications []*ledger.KeyModification
+	for {
+		result, err := itr.Next()
+		testutil.AssertNoError(t, err, "")
+		if result == nil {
+			return modifications
+		}
+		modifications = append(modifications, result.(*ledger.KeyModification))
+	}
+}
+
+type mockCommitDecoratorProvider struct {
+	decorators map[string]*mockCommitDecorator
+	dropped    []string
+}
+
+func (p *mockCommitDecoratorProvider) Name() string {
+	return "mockDecorator"
+}
+
+func (p *mockCommitDecoratorProvider) NewDecorator(ledgerID string) (ledger.CommitDecorator, error) {
+	if p.decorators == nil {
+		p.decorators = make(map[string]*mockCommitDecorator)
+	}
+	p.decorators[ledgerID] = &mockCommitDecorator{}
+	return p.decorators[ledgerID], nil
+}
+
+func (p *mockCommitDecoratorProvider) Drop(ledgerID string) error {
+	p.dropped = append(p.dropped, ledgerID)
+	return nil
+}
+
+type mockCommitDecorator struct {
+	events []*ledger.CommitEvent
+	closed bool
+}
+
+func (d *mockCommitDecorator) HandleCommit(event *ledger.CommitEvent) error {
+	d.events = append(d.events, event)
+	return nil
+}
+
+func (d *mockCommitDecorator) Close() {
+	d.closed = true
+}
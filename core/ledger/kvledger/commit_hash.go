@@ -0,0 +1,111 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"math"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/hyperledger/fabric/protos/common"
+)
+
+// computeCommitHash chains the hash of the previous block with the validation results
+// and the state updates of the given block. Peers that apply the same updates arrive at the same
+// commit hash and hence a mismatch across peers indicates a divergence in the state
+func computeCommitHash(previousCommitHash []byte, block *common.Block, updateBytes []byte) ([]byte, error) {
+	txsFilter := block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER]
+	var valueBytes []byte
+	valueBytes = append(valueBytes, proto.EncodeVarint(uint64(len(txsFilter)))...)
+	valueBytes = append(valueBytes, txsFilter...)
+	valueBytes = append(valueBytes, proto.EncodeVarint(uint64(len(updateBytes)))...)
+	valueBytes = append(valueBytes, updateBytes...)
+	valueBytes = append(valueBytes, previousCommitHash...)
+	return computeHash(valueBytes)
+}
+
+// setCommitHash records the commit hash in the block metadata
+func setCommitHash(block *common.Block, commitHash []byte) error {
+	metadataBytes, err := proto.Marshal(&common.Metadata{Value: commitHash})
+	if err != nil {
+		return err
+	}
+	for len(block.Metadata.Metadata) <= int(common.BlockMetadataIndex_COMMIT_HASH) {
+		block.Metadata.Metadata = append(block.Metadata.Metadata, []byte{})
+	}
+	block.Metadata.Metadata[common.BlockMetadataIndex_COMMIT_HASH] = metadataBytes
+	return nil
+}
+
+// getCommitHash returns the commit hash recorded in the block metadata.
+// nil is returned for a block that does not carry a commit hash
+func getCommitHash(block *common.Block) ([]byte, error) {
+	if block.Metadata == nil || len(block.Metadata.Metadata) <= int(common.BlockMetadataIndex_COMMIT_HASH) {
+		return nil, nil
+	}
+	metadataBytes := block.Metadata.Metadata[common.BlockMetadataIndex_COMMIT_HASH]
+	if len(metadataBytes) == 0 {
+		return nil, nil
+	}
+	metadata := &common.Metadata{}
+	if err := proto.Unmarshal(metadataBytes, metadata); err != nil {
+		return nil, err
+	}
+	return metadata.Value, nil
+}
+
+// GetCommitHash returns the commit hash recorded in the block with the given number.
+// blockNumber of math.MaxUint64 returns the commit hash of the last block.
+// nil is returned for a block committed without a commit hash.
+// For a ledger created from a snapshot, the commit hash of the last block of the snapshot is also served
+func (l *kvLedger) GetCommitHash(blockNumber uint64) ([]byte, error) {
+	if blockNumber == math.MaxUint64 {
+		info, err := l.blockStore.GetBlockchainInfo()
+		if err != nil {
+			return nil, err
+		}
+		blockNumber = info.Height - 1
+	}
+	block, err := l.blockStore.RetrieveBlockByNumber(blockNumber)
+	if err != nil {
+		snapshotInfo, snapshotErr := l.blockStore.GetSnapshotInfo()
+		if snapshotErr == nil && snapshotInfo != nil && snapshotInfo.LastBlockNum == blockNumber {
+			return snapshotInfo.CommitHash, nil
+		}
+		return nil, err
+	}
+	return getCommitHash(block)
+}
+
+// loadLastCommitHash loads the commit hash of the last block in the block storage.
+// For a ledger created from a snapshot, the chain of commit hashes continues from the
+// commit hash recorded in the snapshot as the last block is not available
+func (l *kvLedger) loadLastCommitHash() error {
+	info, err := l.blockStore.GetBlockchainInfo()
+	if err != nil {
+		return err
+	}
+	if info.Height == 0 {
+		return nil
+	}
+	l.commitHash, err = l.GetCommitHash(info.Height - 1)
+	if err != nil {
+		logger.With(flogging.Fields{"channel": l.ledgerID}).Debugf("Last block is not available in block storage, starting a new chain of commit hashes: %s", err)
+		return nil
+	}
+	return nil
+}
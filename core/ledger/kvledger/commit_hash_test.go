@@ -0,0 +1,91 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	ledgerpackage "github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/protos/common"
+)
+
+func TestCommitHash(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	provider, _ := NewProvider()
+	ledger1, _ := provider.Create("testLedger1")
+	ledger2, _ := provider.Create("testLedger2")
+	ledger3, _ := provider.Create("testLedger3")
+
+	bg := testutil.NewBlockGenerator(t)
+	var commitHashes [][]byte
+	for i := 0; i < 3; i++ {
+		block := bg.NextBlock([][]byte{constructSimResForCommitHash(t, ledger1, fmt.Sprintf("value%d", i))}, false)
+		testutil.AssertNoError(t, ledger1.Commit(block), "")
+		commitHash1, _ := ledger1.GetCommitHash(uint64(i))
+		testutil.AssertNotNil(t, commitHash1)
+		testutil.AssertNoError(t, ledger2.Commit(block), "")
+		commitHash2, _ := ledger2.GetCommitHash(uint64(i))
+		// ledgers applying the same updates arrive at the same commit hash
+		testutil.AssertEquals(t, commitHash2, commitHash1)
+		commitHashes = append(commitHashes, commitHash1)
+	}
+	// commit hash is chained and hence differs across blocks with identical updates
+	testutil.AssertNotEquals(t, commitHashes[1], commitHashes[0])
+	lastCommitHash, _ := ledger1.GetCommitHash(math.MaxUint64)
+	testutil.AssertEquals(t, lastCommitHash, commitHashes[2])
+
+	// a ledger applying different updates arrives at a different commit hash
+	block := testutil.NewBlockGenerator(t).NextBlock([][]byte{constructSimResForCommitHash(t, ledger3, "differentValue")}, false)
+	testutil.AssertNoError(t, ledger3.Commit(block), "")
+	commitHash3, _ := ledger3.GetCommitHash(0)
+	testutil.AssertNotEquals(t, commitHash3, commitHashes[0])
+	ledger1.Close()
+	ledger2.Close()
+	ledger3.Close()
+	provider.Close()
+
+	// chaining continues from the last commit hash after reopening the ledger
+	provider, _ = NewProvider()
+	defer provider.Close()
+	ledger1, _ = provider.Open("testLedger1")
+	defer ledger1.Close()
+	testutil.AssertEquals(t, ledger1.(*kvLedger).commitHash, commitHashes[2])
+	block = bg.NextBlock([][]byte{constructSimResForCommitHash(t, ledger1, "value3")}, false)
+	testutil.AssertNoError(t, ledger1.Commit(block), "")
+	commitHash, _ := ledger1.GetCommitHash(3)
+	testutil.AssertNotNil(t, commitHash)
+	testutil.AssertEquals(t, ledger1.(*kvLedger).commitHash, commitHash)
+}
+
+func TestGetCommitHashWithoutMetadata(t *testing.T) {
+	commitHash, err := getCommitHash(&common.Block{Metadata: &common.BlockMetadata{Metadata: [][]byte{{}, {}, {}, {}}}})
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertNil(t, commitHash)
+}
+
+func constructSimResForCommitHash(t *testing.T, ledger ledgerpackage.PeerLedger, value string) []byte {
+	simulator, _ := ledger.NewTxSimulator()
+	simulator.SetState("ns1", "key1", []byte(value))
+	simulator.Done()
+	simRes, err := simulator.GetTxSimulationResults()
+	testutil.AssertNoError(t, err, "")
+	return simRes
+}
@@ -0,0 +1,41 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"time"
+
+	"github.com/hyperledger/fabric/common/flogging"
+)
+
+// Compact implements method in interface `ledger.PeerLedger`
+func (l *kvLedger) Compact() error {
+	if l.readOnly {
+		return ErrLedgerReadOnly
+	}
+	startTime := time.Now()
+	if err := l.blockStore.CompactIndex(); err != nil {
+		return err
+	}
+	if l.config.HistoryDatabase {
+		if err := l.historyDB.Compact(); err != nil {
+			return err
+		}
+	}
+	logger.With(flogging.Fields{"channel": l.ledgerID}).Infof("Compacted the block index and the history database in %s", time.Since(startTime))
+	return nil
+}
@@ -0,0 +1,92 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/util"
+	"github.com/hyperledger/fabric/protos/common"
+	putils "github.com/hyperledger/fabric/protos/utils"
+)
+
+// RegisterConfigBlockListener implements method in interface `ledger.PeerLedger`
+func (l *kvLedger) RegisterConfigBlockListener(listener ledger.ConfigBlockListener) {
+	l.configBlockListenersLock.Lock()
+	defer l.configBlockListenersLock.Unlock()
+	l.configBlockListeners = append(l.configBlockListeners, listener)
+}
+
+// notifyConfigBlockListeners notifies the registered listeners if the committed block is a valid config block
+func (l *kvLedger) notifyConfigBlockListeners(block *common.Block) {
+	l.configBlockListenersLock.RLock()
+	listeners := l.configBlockListeners
+	l.configBlockListenersLock.RUnlock()
+	if len(listeners) == 0 {
+		return
+	}
+	configEnvelope, err := extractConfigEnvelope(block)
+	if err != nil {
+		logger.With(flogging.Fields{"channel": l.ledgerID, "block": block.Header.Number}).Errorf("Error while extracting config from block: %s", err)
+		return
+	}
+	if configEnvelope == nil {
+		return
+	}
+	logger.With(flogging.Fields{"channel": l.ledgerID, "block": block.Header.Number}).Debugf("Notifying the commit of config block to [%d] listener(s)",
+		len(listeners))
+	event := &ledger.ConfigBlockEvent{LedgerID: l.ledgerID, BlockNumber: block.Header.Number, ConfigEnvelope: configEnvelope}
+	for _, listener := range listeners {
+		listener.HandleConfigBlockCommit(event)
+	}
+}
+
+// extractConfigEnvelope returns the config envelope from the block if the block is a config block
+// and the config transaction is marked valid. nil is returned for any other block
+func extractConfigEnvelope(block *common.Block) (*common.ConfigEnvelope, error) {
+	if len(block.Data.Data) != 1 {
+		return nil, nil
+	}
+	txsFilter := util.TxValidationFlags(block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER])
+	if len(txsFilter) == 0 || txsFilter.IsInvalid(0) {
+		return nil, nil
+	}
+	env, err := putils.GetEnvelopeFromBlock(block.Data.Data[0])
+	if err != nil {
+		return nil, err
+	}
+	payload, err := putils.GetPayload(env)
+	if err != nil {
+		return nil, err
+	}
+	if payload.Header == nil {
+		return nil, nil
+	}
+	chdr, err := putils.UnmarshalChannelHeader(payload.Header.ChannelHeader)
+	if err != nil {
+		return nil, err
+	}
+	if common.HeaderType(chdr.Type) != common.HeaderType_CONFIG {
+		return nil, nil
+	}
+	configEnvelope := &common.ConfigEnvelope{}
+	if err := proto.Unmarshal(payload.Data, configEnvelope); err != nil {
+		return nil, err
+	}
+	return configEnvelope, nil
+}
@@ -0,0 +1,91 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger"
+	lutils "github.com/hyperledger/fabric/core/ledger/util"
+	"github.com/hyperledger/fabric/protos/common"
+	putils "github.com/hyperledger/fabric/protos/utils"
+)
+
+func TestConfigBlockListener(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	provider, _ := NewProvider()
+	defer provider.Close()
+	l, _ := provider.Create("testLedger")
+	defer l.Close()
+	listener := &mockConfigBlockListener{}
+	l.RegisterConfigBlockListener(listener)
+
+	// a block with an endorser transaction is not notified
+	bg := testutil.NewBlockGenerator(t)
+	s, _ := l.NewTxSimulator()
+	s.SetState("ns", "key", []byte("value"))
+	s.Done()
+	res, _ := s.GetTxSimulationResults()
+	block0 := bg.NextBlock([][]byte{res}, false)
+	testutil.AssertNoError(t, l.Commit(block0), "")
+	testutil.AssertEquals(t, len(listener.events), 0)
+
+	configEnvelope := &common.ConfigEnvelope{Config: &common.Config{
+		Header: putils.MakeChannelHeader(common.HeaderType_CONFIG, 0, "testLedger", 0)}}
+	block1 := constructConfigBlock(t, 1, block0.Header.Hash(), configEnvelope)
+	testutil.AssertNoError(t, l.Commit(block1), "")
+	testutil.AssertEquals(t, len(listener.events), 1)
+	testutil.AssertEquals(t, listener.events[0].LedgerID, "testLedger")
+	testutil.AssertEquals(t, listener.events[0].BlockNumber, uint64(1))
+	testutil.AssertEquals(t, proto.Equal(listener.events[0].ConfigEnvelope, configEnvelope), true)
+}
+
+func TestExtractConfigEnvelopeFromInvalidTx(t *testing.T) {
+	block := constructConfigBlock(t, 0, nil, &common.ConfigEnvelope{})
+	txsFilter := lutils.NewTxValidationFlags(1)
+	txsFilter.SetFlag(0, 1)
+	block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER] = txsFilter
+	configEnvelope, err := extractConfigEnvelope(block)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertNil(t, configEnvelope)
+}
+
+func constructConfigBlock(t *testing.T, blockNum uint64, previousHash []byte, configEnvelope *common.ConfigEnvelope) *common.Block {
+	payload := &common.Payload{
+		Header: putils.MakePayloadHeader(
+			putils.MakeChannelHeader(common.HeaderType_CONFIG, 0, "testLedger", 0),
+			putils.MakeSignatureHeader(nil, nil)),
+		Data: putils.MarshalOrPanic(configEnvelope),
+	}
+	env := &common.Envelope{Payload: putils.MarshalOrPanic(payload)}
+	block := common.NewBlock(blockNum, previousHash)
+	block.Data.Data = append(block.Data.Data, putils.MarshalOrPanic(env))
+	block.Header.DataHash = block.Data.Hash()
+	block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER] = lutils.NewTxValidationFlags(1)
+	return block
+}
+
+type mockConfigBlockListener struct {
+	events []*ledger.ConfigBlockEvent
+}
+
+func (m *mockConfigBlockListener) HandleConfigBlockCommit(event *ledger.ConfigBlockEvent) {
+	m.events = append(m.events, event)
+}
@@ -0,0 +1,207 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package confighistory
+
+import (
+	"encoding/binary"
+
+	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
+	"github.com/hyperledger/fabric/core/common/privdata"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/rwset"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
+	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+	lutils "github.com/hyperledger/fabric/core/ledger/util"
+	"github.com/hyperledger/fabric/protos/common"
+	putils "github.com/hyperledger/fabric/protos/utils"
+	logging "github.com/op/go-logging"
+)
+
+var logger = logging.MustGetLogger("confighistory")
+
+// LifecycleNamespace is the namespace in which lccc stores the collection config packages of the chaincodes
+const LifecycleNamespace = "lccc"
+
+var savePointKey = []byte{0x00}
+var entryKeyPrefix = []byte{0x01}
+var nsSep = []byte{0x00}
+
+// Provider provides handles to the config history of the ledgers
+type Provider struct {
+	dbProvider *leveldbhelper.Provider
+}
+
+// NewProvider instantiates Provider
+func NewProvider() *Provider {
+	dbPath := ledgerconfig.GetConfigHistoryPath()
+	logger.Debugf("constructing config history Provider dbPath=%s", dbPath)
+	return &Provider{leveldbhelper.NewProvider(&leveldbhelper.Conf{DBPath: dbPath})}
+}
+
+// NewReadOnlyProvider instantiates Provider that opens the existing databases in read-only mode
+func NewReadOnlyProvider() *Provider {
+	dbPath := ledgerconfig.GetConfigHistoryPath()
+	logger.Debugf("constructing read-only config history Provider dbPath=%s", dbPath)
+	return &Provider{leveldbhelper.NewProvider(&leveldbhelper.Conf{DBPath: dbPath, ReadOnly: true})}
+}
+
+// GetMgr returns the config history of the given ledger
+func (p *Provider) GetMgr(ledgerID string) *Mgr {
+	return &Mgr{p.dbProvider.GetDBHandle(ledgerID), ledgerID}
+}
+
+// Drop removes the config history of the given ledger
+func (p *Provider) Drop(ledgerID string) error {
+	return p.dbProvider.GetDBHandle(ledgerID).DeleteAll()
+}
+
+// Close closes the underlying db
+func (p *Provider) Close() {
+	p.dbProvider.Close()
+}
+
+// Mgr maintains the history of the collection config packages of the chaincodes of a ledger. A collection config
+// package is recorded against the number of the block that committed it, so that the package that was in force
+// at any height of the ledger can be looked up
+type Mgr struct {
+	db       *leveldbhelper.DBHandle
+	ledgerID string
+}
+
+// Commit records the collection config packages written to the lifecycle namespace by the valid transactions in the block
+func (m *Mgr) Commit(block *common.Block) error {
+	blockNum := block.Header.Number
+	batch := leveldbhelper.NewUpdateBatch()
+	txsFilter := lutils.TxValidationFlags(block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER])
+	for txIndex, envBytes := range block.Data.Data {
+		if len(txsFilter) > txIndex && txsFilter.IsInvalid(txIndex) {
+			continue
+		}
+		writes, err := extractLifecycleWrites(envBytes)
+		if err != nil {
+			return err
+		}
+		for _, kvWrite := range writes {
+			if kvWrite.IsDelete || !privdata.IsCollectionConfigKey(kvWrite.Key) {
+				continue
+			}
+			ccName := privdata.GetCCNameFromCollectionKVSKey(kvWrite.Key)
+			logger.Debugf("Channel [%s]: Recording collection config of chaincode [%s] committed by block [%d]", m.ledgerID, ccName, blockNum)
+			batch.Put(encodeEntryKey(ccName, blockNum), kvWrite.Value)
+		}
+	}
+	batch.Put(savePointKey, version.NewHeight(blockNum, 0).ToBytes())
+	return m.db.WriteBatch(batch, true)
+}
+
+// ImportCollectionConfigs records the given collection config packages, as found in the state imported from a snapshot,
+// and marks the given savepoint as the starting point of the history
+func (m *Mgr) ImportCollectionConfigs(configs map[string]*ledger.CollectionConfigInfo, savepoint *version.Height) error {
+	batch := leveldbhelper.NewUpdateBatch()
+	for ccName, configInfo := range configs {
+		batch.Put(encodeEntryKey(ccName, configInfo.CommittingBlockNum), configInfo.CollectionConfig)
+	}
+	batch.Put(savePointKey, savepoint.ToBytes())
+	return m.db.WriteBatch(batch, true)
+}
+
+// MostRecentCollectionConfigBelow returns the most recent collection config package of the given chaincode
+// that was committed by a block below the given block number. A nil value is returned if there is none
+func (m *Mgr) MostRecentCollectionConfigBelow(blockNum uint64, chaincodeName string) (*ledger.CollectionConfigInfo, error) {
+	itr := m.db.GetIterator(encodeEntryKey(chaincodeName, 0), encodeEntryKey(chaincodeName, blockNum))
+	defer itr.Release()
+	if !itr.Last() {
+		return nil, itr.Error()
+	}
+	_, committingBlockNum := decodeEntryKey(itr.Key())
+	collectionConfig := make([]byte, len(itr.Value()))
+	copy(collectionConfig, itr.Value())
+	return &ledger.CollectionConfigInfo{CollectionConfig: collectionConfig, CommittingBlockNum: committingBlockNum}, nil
+}
+
+// GetLastSavepoint implements method in interface kvledger.Recoverer
+func (m *Mgr) GetLastSavepoint() (*version.Height, error) {
+	versionBytes, err := m.db.Get(savePointKey)
+	if err != nil || versionBytes == nil {
+		return nil, err
+	}
+	height, _ := version.NewHeightFromBytes(versionBytes)
+	return height, nil
+}
+
+// ShouldRecover implements method in interface kvledger.Recoverer
+func (m *Mgr) ShouldRecover(lastAvailableBlock uint64) (bool, uint64, error) {
+	savepoint, err := m.GetLastSavepoint()
+	if err != nil {
+		return false, 0, err
+	}
+	if savepoint == nil {
+		return true, 0, nil
+	}
+	return savepoint.BlockNum != lastAvailableBlock, savepoint.BlockNum + 1, nil
+}
+
+// CommitLostBlock implements method in interface kvledger.Recoverer
+func (m *Mgr) CommitLostBlock(block *common.Block) error {
+	return m.Commit(block)
+}
+
+// extractLifecycleWrites returns the writes of an endorser transaction to the lifecycle namespace
+func extractLifecycleWrites(envBytes []byte) ([]*rwset.KVWrite, error) {
+	env, err := putils.GetEnvelopeFromBlock(envBytes)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := putils.GetPayload(env)
+	if err != nil {
+		return nil, err
+	}
+	chdr, err := putils.UnmarshalChannelHeader(payload.Header.ChannelHeader)
+	if err != nil {
+		return nil, err
+	}
+	if common.HeaderType(chdr.Type) != common.HeaderType_ENDORSER_TRANSACTION {
+		return nil, nil
+	}
+	respPayload, err := putils.GetActionFromEnvelope(envBytes)
+	if err != nil {
+		return nil, err
+	}
+	txRWSet := &rwset.TxReadWriteSet{}
+	if err := txRWSet.Unmarshal(respPayload.Results); err != nil {
+		return nil, err
+	}
+	for _, nsRWSet := range txRWSet.NsRWs {
+		if nsRWSet.NameSpace == LifecycleNamespace {
+			return nsRWSet.Writes, nil
+		}
+	}
+	return nil, nil
+}
+
+func encodeEntryKey(ccName string, blockNum uint64) []byte {
+	key := append(append([]byte{}, entryKeyPrefix...), []byte(ccName)...)
+	key = append(key, nsSep...)
+	blockNumBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(blockNumBytes, blockNum)
+	return append(key, blockNumBytes...)
+}
+
+func decodeEntryKey(key []byte) (string, uint64) {
+	ccName := string(key[len(entryKeyPrefix) : len(key)-8-len(nsSep)])
+	return ccName, binary.BigEndian.Uint64(key[len(key)-8:])
+}
@@ -0,0 +1,72 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package confighistory
+
+import (
+	"os"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
+	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+	"github.com/spf13/viper"
+)
+
+func TestMain(m *testing.M) {
+	viper.Set("peer.fileSystemPath", "/tmp/fabric/ledgertests/confighistory")
+	os.Exit(m.Run())
+}
+
+func TestEntryKeyEncoding(t *testing.T) {
+	ccName, blockNum := decodeEntryKey(encodeEntryKey("mycc", 300))
+	testutil.AssertEquals(t, ccName, "mycc")
+	testutil.AssertEquals(t, blockNum, uint64(300))
+}
+
+func TestMostRecentCollectionConfigBelow(t *testing.T) {
+	os.RemoveAll(ledgerconfig.GetConfigHistoryPath())
+	defer os.RemoveAll(ledgerconfig.GetConfigHistoryPath())
+	provider := NewProvider()
+	defer provider.Close()
+	mgr := provider.GetMgr("testLedger")
+
+	testutil.AssertNoError(t, mgr.ImportCollectionConfigs(map[string]*ledger.CollectionConfigInfo{
+		"mycc":  {CollectionConfig: []byte("mycc-config-5"), CommittingBlockNum: 5},
+		"mycc2": {CollectionConfig: []byte("mycc2-config-8"), CommittingBlockNum: 8},
+	}, version.NewHeight(10, 0)), "")
+	savepoint, _ := mgr.GetLastSavepoint()
+	testutil.AssertEquals(t, savepoint, version.NewHeight(10, 0))
+
+	configInfo, err := mgr.MostRecentCollectionConfigBelow(5, "mycc")
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertNil(t, configInfo)
+	configInfo, _ = mgr.MostRecentCollectionConfigBelow(6, "mycc")
+	testutil.AssertEquals(t, configInfo, &ledger.CollectionConfigInfo{CollectionConfig: []byte("mycc-config-5"), CommittingBlockNum: 5})
+	configInfo, _ = mgr.MostRecentCollectionConfigBelow(20, "mycc2")
+	testutil.AssertEquals(t, configInfo, &ledger.CollectionConfigInfo{CollectionConfig: []byte("mycc2-config-8"), CommittingBlockNum: 8})
+	configInfo, _ = mgr.MostRecentCollectionConfigBelow(20, "myc")
+	testutil.AssertNil(t, configInfo)
+
+	shouldRecover, firstBlockNum, _ := mgr.ShouldRecover(12)
+	testutil.AssertEquals(t, shouldRecover, true)
+	testutil.AssertEquals(t, firstBlockNum, uint64(11))
+
+	// the history of other ledgers is independent
+	configInfo, _ = provider.GetMgr("otherLedger").MostRecentCollectionConfigBelow(20, "mycc")
+	testutil.AssertNil(t, configInfo)
+}
@@ -0,0 +1,339 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/hyperledger/fabric/common/ledger/blkstorage"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/history/historydb/historyleveldb"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb/statecouchdb"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
+	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+)
+
+// dataFormatV1 is the format of the data that the ledger stores held before the stores recorded the format
+// of their data. A store that holds data but records no format is taken to hold its data in this format
+const dataFormatV1 = "1.0"
+
+// dataFormatV2 prefixes the namespace and the key in the keys of the history database by their lengths
+const dataFormatV2 = "2.0"
+
+// dataFormatV3 encodes the keys byte by byte in the ids of the documents of the CouchDB state databases
+const dataFormatV3 = "3.0"
+
+// currentDataFormat is the format of the data that this version of the peer reads and writes
+var currentDataFormat = dataFormatV3
+
+// dataFormats lists the known formats of the data of the ledger stores from the oldest to the newest
+var dataFormats = []string{dataFormatV1, dataFormatV2, dataFormatV3}
+
+// the names of the ledger stores that record the format of their data
+const (
+	idStoreName       = "id store"
+	blockStoreName    = "block store"
+	stateDBName       = "state DB"
+	systemStateDBName = "system state DB"
+	historyDBName     = "history DB"
+)
+
+// dataFormatStore is a ledger store that records the format of its data
+type dataFormatStore interface {
+	// GetDataFormat returns the format of the data of the store, the empty string if no format is recorded
+	GetDataFormat() (string, error)
+	// SetDataFormat records the format of the data of the store
+	SetDataFormat(format string) error
+}
+
+// emptyStore is a ledger store that tells whether it holds any data. A store that holds no data and records
+// no format is a new store and records the current format rather than dataFormatV1
+type emptyStore interface {
+	IsEmpty() (bool, error)
+}
+
+// dataFormatUpgrade converts the data of a store in place from a format to the next one in dataFormats.
+// The block stores are given to the upgrades of the stores that derive their data from the blocks
+type dataFormatUpgrade func(store dataFormatStore, blockStoreProvider blkstorage.BlockStoreProvider) error
+
+// dataFormatUpgrades holds the upgrades of the stores by the name of the store and the format that the upgrade
+// converts from. A store that has no upgrade from a format keeps its data as is when moving to the next format.
+// A change of the format of a store adds the new format to dataFormats and the upgrade of the store here
+var dataFormatUpgrades = map[string]map[string]dataFormatUpgrade{
+	historyDBName: {dataFormatV1: resetHistoryDBs},
+	stateDBName:   {dataFormatV2: encodeCouchDBKeys},
+}
+
+// DataFormatUpgrade describes the upgrade of a ledger store by UpgradeDBs
+type DataFormatUpgrade struct {
+	Store string
+	// LedgerID is set for the stores kept per ledger, i.e., the CouchDB state databases
+	LedgerID string
+	From     string
+	To       string
+}
+
+// checkDataFormats checks the format of the data of the stores that every ledger uses
+func checkDataFormats(idStore *idStore, blockStoreProvider interface{}, historydbProvider interface{}, readOnly bool) error {
+	if err := checkDataFormat(idStoreName, idStore, readOnly); err != nil {
+		return err
+	}
+	if err := checkDataFormat(blockStoreName, blockStoreProvider, readOnly); err != nil {
+		return err
+	}
+	return checkDataFormat(historyDBName, historydbProvider, readOnly)
+}
+
+// checkDataFormat returns a ledger.DataFormatError if the given store holds its data in a format other than
+// the current one. A store that records no format records the format of its data, as given by unrecordedDataFormat,
+// unless opened in read-only mode. A store that does not record the format of its data is not checked
+func checkDataFormat(name string, store interface{}, readOnly bool) error {
+	formatStore, ok := store.(dataFormatStore)
+	if !ok {
+		return nil
+	}
+	format, err := formatStore.GetDataFormat()
+	if err != nil {
+		return err
+	}
+	if format == "" {
+		if format, err = unrecordedDataFormat(store); err != nil {
+			return err
+		}
+		if !readOnly {
+			if err := formatStore.SetDataFormat(format); err != nil {
+				return err
+			}
+		}
+	}
+	if format != currentDataFormat {
+		return &ledger.DataFormatError{Store: name, Format: format, ExpectedFormat: currentDataFormat}
+	}
+	return nil
+}
+
+// unrecordedDataFormat returns the format of the data of a store that records no format,
+// the current format for a new store and dataFormatV1 for a store that holds data
+func unrecordedDataFormat(store interface{}) (string, error) {
+	if s, ok := store.(emptyStore); ok {
+		empty, err := s.IsEmpty()
+		if err != nil {
+			return "", err
+		}
+		if empty {
+			return currentDataFormat, nil
+		}
+	}
+	return dataFormatV1, nil
+}
+
+// UpgradeDBs converts, in place, the data of the ledger stores to the format that this version of the peer reads
+// and writes. The upgrades from the recorded format of a store are applied one format at a time and the format is
+// recorded after each of them, so an interrupted upgrade resumes from where it stopped when invoked again.
+// The stores of a newer or unknown format are left untouched and fail the upgrade with a ledger.DataFormatError.
+// This is an offline operation and must not be invoked while the peer is running
+func UpgradeDBs() ([]*DataFormatUpgrade, error) {
+	shared, err := getSharedLevelDB(false)
+	if err != nil {
+		return nil, err
+	}
+	idStore := openIDStore(ledgerconfig.GetLedgerProviderPath(), shared)
+	defer idStore.close()
+	blockStoreProvider := newBlockStoreProvider(shared)
+	defer blockStoreProvider.Close()
+	historydbProvider := newHistoryDBProvider(shared)
+	defer historydbProvider.Close()
+
+	var upgrades []*DataFormatUpgrade
+	upgrade := func(name string, ledgerID string, store interface{}) error {
+		formatStore, ok := store.(dataFormatStore)
+		if !ok {
+			return nil
+		}
+		from, to, err := upgradeDataFormat(name, formatStore, blockStoreProvider)
+		if err != nil {
+			return err
+		}
+		upgrades = append(upgrades, &DataFormatUpgrade{Store: name, LedgerID: ledgerID, From: from, To: to})
+		return nil
+	}
+	if err := upgrade(idStoreName, "", idStore); err != nil {
+		return nil, err
+	}
+	if err := upgrade(blockStoreName, "", blockStoreProvider); err != nil {
+		return nil, err
+	}
+	if err := upgrade(historyDBName, "", historydbProvider); err != nil {
+		return nil, err
+	}
+
+	// the state DB is chosen by the configuration of each ledger. The leveldb state databases record
+	// a single format for all the ledgers and the CouchDB state databases a format per ledger
+	provider := &Provider{idStore: idStore}
+	defer func() {
+		if provider.levelDBProvider != nil {
+			provider.levelDBProvider.Close()
+		}
+		if provider.couchDBProvider != nil {
+			provider.couchDBProvider.Close()
+		}
+		if provider.systemVDBProvider != nil {
+			provider.systemVDBProvider.Close()
+		}
+	}()
+	ledgerIDs, err := idStore.getAllLedgerIds()
+	if err != nil {
+		return nil, err
+	}
+	upgradedVDBProviders := make(map[statedb.VersionedDBProvider]bool)
+	for _, ledgerID := range ledgerIDs {
+		config, err := provider.getLedgerConfig(ledgerID)
+		if err != nil {
+			return nil, err
+		}
+		vdbProvider, err := provider.getVDBProvider(config)
+		if err != nil {
+			return nil, err
+		}
+		if len(config.SystemNamespaces) > 0 && provider.systemVDBProvider == nil {
+			if err := upgrade(systemStateDBName, "", provider.getSystemVDBProvider()); err != nil {
+				return nil, err
+			}
+		}
+		if !upgradedVDBProviders[vdbProvider] {
+			upgradedVDBProviders[vdbProvider] = true
+			if err := upgrade(stateDBName, "", vdbProvider); err != nil {
+				return nil, err
+			}
+		}
+		if _, ok := vdbProvider.(dataFormatStore); ok {
+			continue
+		}
+		vDB, err := vdbProvider.GetDBHandle(ledgerID)
+		if err != nil {
+			return nil, err
+		}
+		if err := upgrade(stateDBName, ledgerID, vDB); err != nil {
+			return nil, err
+		}
+	}
+	return upgrades, nil
+}
+
+// upgradeDataFormat applies to the store the upgrades from its recorded format to the current format
+// and returns the format that the store was in and the format that it is in now
+func upgradeDataFormat(name string, store dataFormatStore, blockStoreProvider blkstorage.BlockStoreProvider) (string, string, error) {
+	recorded, err := store.GetDataFormat()
+	if err != nil {
+		return "", "", err
+	}
+	from := recorded
+	if from == "" {
+		if from, err = unrecordedDataFormat(store); err != nil {
+			return "", "", err
+		}
+	}
+	index := dataFormatIndex(from)
+	if index < 0 || index > dataFormatIndex(currentDataFormat) {
+		return "", "", &ledger.DataFormatError{Store: name, Format: from, ExpectedFormat: currentDataFormat}
+	}
+	for format := from; format != currentDataFormat; index++ {
+		next := dataFormats[index+1]
+		if apply := dataFormatUpgrades[name][format]; apply != nil {
+			logger.Infof("Upgrading the data of the %s from format [%s] to [%s]", name, format, next)
+			if err := apply(store, blockStoreProvider); err != nil {
+				return "", "", err
+			}
+		}
+		if err := store.SetDataFormat(next); err != nil {
+			return "", "", err
+		}
+		format = next
+	}
+	if recorded == "" && from == currentDataFormat {
+		if err := store.SetDataFormat(currentDataFormat); err != nil {
+			return "", "", err
+		}
+	}
+	return from, currentDataFormat, nil
+}
+
+// dataFormatIndex returns the index of the given format in dataFormats, -1 for an unknown format
+func dataFormatIndex(format string) int {
+	for i, f := range dataFormats {
+		if f == format {
+			return i
+		}
+	}
+	return -1
+}
+
+// resetHistoryDBs upgrades the history databases from dataFormatV1, whose keys end the namespace and the key
+// with a separator that the keys may contain, by dropping them. The recovery rebuilds each history database
+// by replaying the blocks when the ledger is next opened. The history database of a ledger created from
+// a snapshot is marked to start after the snapshot, as the blocks up to the snapshot are not available
+func resetHistoryDBs(store dataFormatStore, blockStoreProvider blkstorage.BlockStoreProvider) error {
+	historydbProvider := store.(*historyleveldb.HistoryDBProvider)
+	ledgerIDs, err := historydbProvider.List()
+	if err != nil {
+		return err
+	}
+	for _, ledgerID := range ledgerIDs {
+		logger.With(flogging.Fields{"channel": ledgerID}).Info("Dropping the history database to be rebuilt from the blocks")
+		if err := historydbProvider.Drop(ledgerID); err != nil {
+			return err
+		}
+		exists, err := blockStoreProvider.Exists(ledgerID)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			continue
+		}
+		blockStore, err := blockStoreProvider.OpenBlockStore(ledgerID)
+		if err != nil {
+			return err
+		}
+		snapshotInfo, err := blockStore.GetSnapshotInfo()
+		blockStore.Shutdown()
+		if err != nil {
+			return err
+		}
+		if snapshotInfo == nil {
+			continue
+		}
+		historyDB, err := historydbProvider.GetDBHandle(ledgerID)
+		if err != nil {
+			return err
+		}
+		if err := historyDB.MarkStartingSavepoint(version.NewHeight(snapshotInfo.LastBlockNum, 0)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeCouchDBKeys upgrades a CouchDB state database from dataFormatV2, whose ids of the documents hold the keys
+// as is, by moving the documents of the keys with non-ASCII characters to the ids of their encoded keys.
+// The leveldb state databases hold the keys as is in both formats
+func encodeCouchDBKeys(store dataFormatStore, blockStoreProvider blkstorage.BlockStoreProvider) error {
+	vdb, ok := store.(*statecouchdb.VersionedDB)
+	if !ok {
+		return nil
+	}
+	return vdb.EncodeKeys()
+}
@@ -0,0 +1,176 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/blkstorage"
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/history/historydb/historyleveldb"
+	"github.com/spf13/viper"
+)
+
+func TestDataFormatRecorded(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	p, _ := NewProvider()
+	provider := p.(*Provider)
+	defer provider.Close()
+	_, err := provider.Create("testLedger")
+	testutil.AssertNoError(t, err, "")
+
+	for _, store := range []interface{}{provider.idStore, provider.blockStoreProvider, provider.historydbProvider, provider.levelDBProvider} {
+		format, err := store.(dataFormatStore).GetDataFormat()
+		testutil.AssertNoError(t, err, "")
+		testutil.AssertEquals(t, format, currentDataFormat)
+	}
+	// the format of the id store is not taken for a ledger id
+	ledgerIDs, err := provider.idStore.getAllLedgerIds()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, ledgerIDs, []string{"testLedger"})
+}
+
+func TestDataFormatMismatch(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	provider, _ := NewProvider()
+	_, err := provider.Create("testLedger")
+	testutil.AssertNoError(t, err, "")
+	provider.Close()
+
+	// a history DB written by a newer version of the peer is neither opened nor upgraded
+	historydbProvider := historyleveldb.NewHistoryDBProvider()
+	testutil.AssertNoError(t, historydbProvider.SetDataFormat("9.0"), "")
+	historydbProvider.Close()
+	_, err = NewProvider()
+	testutil.AssertEquals(t, err, &ledger.DataFormatError{Store: historyDBName, Format: "9.0", ExpectedFormat: currentDataFormat})
+	_, err = UpgradeDBs()
+	testutil.AssertEquals(t, err, &ledger.DataFormatError{Store: historyDBName, Format: "9.0", ExpectedFormat: currentDataFormat})
+}
+
+func TestUpgradeDBs(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	provider, _ := NewProvider()
+	_, err := provider.Create("testLedger")
+	testutil.AssertNoError(t, err, "")
+	provider.Close()
+
+	// a new format that changes the data of the block store
+	defer func(formats []string, current string) {
+		dataFormats = formats
+		currentDataFormat = current
+		delete(dataFormatUpgrades, blockStoreName)
+	}(dataFormats, currentDataFormat)
+	previous := currentDataFormat
+	next := "99.0"
+	dataFormats = append(dataFormats, next)
+	currentDataFormat = next
+	applied := 0
+	dataFormatUpgrades[blockStoreName] = map[string]dataFormatUpgrade{
+		previous: func(store dataFormatStore, blockStoreProvider blkstorage.BlockStoreProvider) error {
+			applied++
+			return nil
+		},
+	}
+
+	_, err = NewProvider()
+	testutil.AssertEquals(t, err, &ledger.DataFormatError{Store: idStoreName, Format: previous, ExpectedFormat: next})
+	upgrades, err := UpgradeDBs()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, applied, 1)
+	testutil.AssertEquals(t, upgrades, []*DataFormatUpgrade{
+		{Store: idStoreName, From: previous, To: next},
+		{Store: blockStoreName, From: previous, To: next},
+		{Store: historyDBName, From: previous, To: next},
+		{Store: stateDBName, From: previous, To: next},
+	})
+
+	provider, err = NewProvider()
+	testutil.AssertNoError(t, err, "")
+	l, err := provider.Open("testLedger")
+	testutil.AssertNoError(t, err, "")
+	l.Close()
+	provider.Close()
+
+	// the stores already in the current format are left as they are
+	upgrades, err = UpgradeDBs()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, applied, 1)
+	testutil.AssertEquals(t, upgrades[1], &DataFormatUpgrade{Store: blockStoreName, From: next, To: next})
+}
+
+func TestUpgradeHistoryKeys(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	historyEnabled := viper.GetBool("ledger.state.historyDatabase")
+	viper.Set("ledger.state.historyDatabase", true)
+	defer viper.Set("ledger.state.historyDatabase", historyEnabled)
+	p, _ := NewProvider()
+	l, err := p.Create("testLedger")
+	testutil.AssertNoError(t, err, "")
+	bg := testutil.NewBlockGenerator(t)
+	for i := 0; i < 2; i++ {
+		s, _ := l.NewTxSimulator()
+		s.SetState("ns", "key", []byte(fmt.Sprintf("value%d", i)))
+		s.SetState("ns", "key\x00attr\x00", []byte(fmt.Sprintf("value%d", i)))
+		s.Done()
+		res, _ := s.GetTxSimulationResults()
+		testutil.AssertNoError(t, l.Commit(bg.NextBlock([][]byte{res}, false)), "")
+	}
+	l.Close()
+
+	// the stores as left by a peer that did not record the format of their data
+	provider := p.(*Provider)
+	for _, store := range []interface{}{provider.idStore, provider.blockStoreProvider, provider.historydbProvider, provider.levelDBProvider} {
+		testutil.AssertNoError(t, store.(dataFormatStore).SetDataFormat(dataFormatV1), "")
+	}
+	provider.Close()
+	_, err = NewProvider()
+	testutil.AssertEquals(t, err, &ledger.DataFormatError{Store: idStoreName, Format: dataFormatV1, ExpectedFormat: currentDataFormat})
+
+	upgrades, err := UpgradeDBs()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, upgrades[2], &DataFormatUpgrade{Store: historyDBName, From: dataFormatV1, To: currentDataFormat})
+	historydbProvider := historyleveldb.NewHistoryDBProvider()
+	ledgerIDs, err := historydbProvider.List()
+	historydbProvider.Close()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, len(ledgerIDs), 0)
+
+	// the history database is rebuilt from the blocks when the ledger is opened
+	p, err = NewProvider()
+	testutil.AssertNoError(t, err, "")
+	defer p.Close()
+	l, err = p.Open("testLedger")
+	testutil.AssertNoError(t, err, "")
+	defer l.Close()
+	qhistory, _ := l.NewHistoryQueryExecutor()
+	for _, key := range []string{"key", "key\x00attr\x00"} {
+		itr, err := qhistory.GetHistoryForKey("ns", key)
+		testutil.AssertNoError(t, err, "")
+		count := 0
+		for kmod, _ := itr.Next(); kmod != nil; kmod, _ = itr.Next() {
+			count++
+		}
+		itr.Close()
+		testutil.AssertEquals(t, count, 2)
+	}
+}
@@ -0,0 +1,42 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"github.com/hyperledger/fabric/core/ledger"
+)
+
+// GetDiskUsage implements method in interface `ledger.PeerLedger`
+func (l *kvLedger) GetDiskUsage() (*ledger.DiskUsage, error) {
+	blockStoreBytes, err := l.blockStore.GetDiskUsage()
+	if err != nil {
+		return nil, err
+	}
+	stateDBBytes, err := l.versionedDB.GetDiskUsage()
+	if err != nil {
+		return nil, err
+	}
+	diskUsage := &ledger.DiskUsage{BlockStoreBytes: uint64(blockStoreBytes), StateDBBytes: uint64(stateDBBytes)}
+	if l.config.HistoryDatabase {
+		historyDBBytes, err := l.historyDB.GetDiskUsage()
+		if err != nil {
+			return nil, err
+		}
+		diskUsage.HistoryDBBytes = uint64(historyDBBytes)
+	}
+	return diskUsage, nil
+}
@@ -0,0 +1,54 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/spf13/viper"
+)
+
+func TestGetDiskUsage(t *testing.T) {
+	historyEnabled := viper.GetBool("ledger.state.historyDatabase")
+	viper.Set("ledger.state.historyDatabase", true)
+	defer viper.Set("ledger.state.historyDatabase", historyEnabled)
+	env := newTestEnv(t)
+	defer env.cleanup()
+	provider, _ := NewProvider()
+	defer provider.Close()
+	l, _ := provider.Create("diskUsageLedger")
+	defer l.Close()
+
+	diskUsage, err := l.GetDiskUsage()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, diskUsage.BlockStoreBytes, uint64(0))
+
+	bg := testutil.NewBlockGenerator(t)
+	for i := 0; i < 5; i++ {
+		s, _ := l.NewTxSimulator()
+		s.SetState("ns", "key", []byte("value"))
+		s.Done()
+		res, _ := s.GetTxSimulationResults()
+		testutil.AssertNoError(t, l.Commit(bg.NextBlock([][]byte{res}, false)), "")
+	}
+	diskUsage, err = l.GetDiskUsage()
+	testutil.AssertNoError(t, err, "")
+	// the block files are counted in full while the leveldb sizes are approximate and exclude
+	// the data not yet flushed from the memory
+	testutil.AssertEquals(t, diskUsage.BlockStoreBytes > 0, true)
+}
@@ -0,0 +1,286 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/hyperledger/fabric/common/ledger/util"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/export"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/rwset"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
+	lutils "github.com/hyperledger/fabric/core/ledger/util"
+	"github.com/hyperledger/fabric/protos/common"
+	putils "github.com/hyperledger/fabric/protos/utils"
+)
+
+const (
+	exportStateFileName   = "state"
+	exportHistoryFileName = "history"
+)
+
+// ExportOptions selects the data exported by ExportLedgerData
+type ExportOptions struct {
+	// Namespace restricts the export to a single namespace. All the namespaces are exported if empty
+	Namespace string
+	// History adds the writes of the valid transactions of the blocks available in the block store
+	History bool
+	// Format is the format of the export files
+	Format export.Format
+	// Creators adds the MSP ids and the subjects of the certificates of the creators of the transactions to the history.
+	// The creators are left empty unless Creators is set or the Redaction hashes them
+	Creators bool
+	// Redaction withholds the values of the selected namespaces from the export and hashes the identities of the creators
+	// of the transactions. Nothing is withheld if nil
+	Redaction *export.RedactionRules
+}
+
+// ExportSummary describes the files written by ExportLedgerData
+type ExportSummary struct {
+	// Height is the height of the state database that is exported. The history covers the blocks below this height
+	Height         uint64
+	StateRecords   int
+	HistoryRecords int
+	// RedactedValues is the number of the state and history records exported without their values
+	RedactedValues int
+	Files          []string
+}
+
+// ExportLedgerData exports the committed state of the given ledger, and optionally the history of the writes,
+// to the files state.<format> and history.<format> in the given directory, which must be empty or absent.
+// The ledger is opened in read-only mode, hence this is an offline operation that does not load the peer
+func ExportLedgerData(ledgerID string, outputDir string, opts *ExportOptions) (*ExportSummary, error) {
+	provider, err := NewReadOnlyProvider()
+	if err != nil {
+		return nil, err
+	}
+	defer provider.Close()
+	l, err := provider.Open(ledgerID)
+	if err != nil {
+		return nil, err
+	}
+	defer l.Close()
+	return l.(*kvLedger).exportData(outputDir, opts)
+}
+
+func (l *kvLedger) exportData(outputDir string, opts *ExportOptions) (*ExportSummary, error) {
+	if err := opts.Redaction.Validate(); err != nil {
+		return nil, err
+	}
+	empty, err := util.CreateDirIfMissing(outputDir)
+	if err != nil {
+		return nil, err
+	}
+	if !empty {
+		return nil, &ledger.ConflictError{Msg: fmt.Sprintf("Export directory [%s] is not empty", outputDir)}
+	}
+	itr, savepoint, err := l.txtmgmt.NewStateSnapshotIterator()
+	if err != nil {
+		return nil, err
+	}
+	defer itr.Close()
+	summary := &ExportSummary{}
+	if savepoint != nil {
+		summary.Height = savepoint.BlockNum + 1
+	}
+	exportLogger := logger.With(flogging.Fields{"channel": l.ledgerID, "height": summary.Height})
+	exportLogger.Infof("Exporting ledger data in format [%s] to directory [%s]", opts.Format, outputDir)
+
+	stateFile := filepath.Join(outputDir, exportStateFileName+opts.Format.FileExtension())
+	err = writeExportFile(stateFile, opts.Format, export.StateRecordSchema, func(w export.Writer) error {
+		for {
+			queryResult, err := itr.Next()
+			if err != nil {
+				return err
+			}
+			if queryResult == nil {
+				return nil
+			}
+			kv := queryResult.(*statedb.VersionedKV)
+			if opts.Namespace != "" && kv.Namespace != opts.Namespace {
+				continue
+			}
+			record := &export.StateRecord{Namespace: kv.Namespace, Key: kv.Key, Value: kv.Value,
+				BlockNum: kv.Version.BlockNum, TxNum: kv.Version.TxNum}
+			if opts.Redaction.DropsValue(kv.Namespace) {
+				record.Value = nil
+				summary.RedactedValues++
+			}
+			if err := w.Write(record); err != nil {
+				return err
+			}
+			summary.StateRecords++
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	summary.Files = append(summary.Files, stateFile)
+
+	if opts.History && summary.Height > 0 {
+		historyFile := filepath.Join(outputDir, exportHistoryFileName+opts.Format.FileExtension())
+		err = writeExportFile(historyFile, opts.Format, export.HistoryRecordSchema, func(w export.Writer) error {
+			return l.exportHistory(w, opts, summary)
+		})
+		if err != nil {
+			return nil, err
+		}
+		summary.Files = append(summary.Files, historyFile)
+	}
+	exportLogger.Infof("Exported [%d] state records and [%d] history records, [%d] of them without the values",
+		summary.StateRecords, summary.HistoryRecords, summary.RedactedValues)
+	return summary, nil
+}
+
+// exportHistory writes the writes of the valid endorser transactions of the blocks below the height of the summary.
+// For a ledger created from a snapshot, the history starts at the first block after the snapshot
+func (l *kvLedger) exportHistory(w export.Writer, opts *ExportOptions, summary *ExportSummary) error {
+	creators, err := opts.Redaction.NewCreatorRedactor(opts.Creators)
+	if err != nil {
+		return err
+	}
+	var firstBlockNum uint64
+	snapshotInfo, err := l.blockStore.GetSnapshotInfo()
+	if err != nil {
+		return err
+	}
+	if snapshotInfo != nil {
+		firstBlockNum = snapshotInfo.LastBlockNum + 1
+	}
+	for blockNum := firstBlockNum; blockNum < summary.Height; blockNum++ {
+		block, err := l.blockStore.RetrieveBlockByNumber(blockNum)
+		if err != nil {
+			return err
+		}
+		derivedDeletes, err := lutils.GetDerivedDeletes(block)
+		if err != nil {
+			return err
+		}
+		// writeDerivedDeletes writes the records of the range deletes of a transaction, or of the expiries of the block
+		writeDerivedDeletes := func(record export.HistoryRecord) error {
+			for len(derivedDeletes) > 0 && derivedDeletes[0].TxNum <= record.TxNum {
+				d := derivedDeletes[0]
+				derivedDeletes = derivedDeletes[1:]
+				if opts.Namespace != "" && d.Namespace != opts.Namespace {
+					continue
+				}
+				record.Namespace, record.Key = d.Namespace, d.Key
+				if err := w.Write(&record); err != nil {
+					return err
+				}
+				summary.HistoryRecords++
+			}
+			return nil
+		}
+		txsFilter := lutils.TxValidationFlags(block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER])
+		for txNum, envBytes := range block.Data.Data {
+			if len(txsFilter) > txNum && txsFilter.IsInvalid(txNum) {
+				continue
+			}
+			env, err := putils.GetEnvelopeFromBlock(envBytes)
+			if err != nil {
+				return err
+			}
+			payload, err := putils.GetPayload(env)
+			if err != nil {
+				return err
+			}
+			chdr, err := putils.UnmarshalChannelHeader(payload.Header.ChannelHeader)
+			if err != nil {
+				return err
+			}
+			if common.HeaderType(chdr.Type) != common.HeaderType_ENDORSER_TRANSACTION {
+				continue
+			}
+			respPayload, err := putils.GetActionFromEnvelope(envBytes)
+			if err != nil {
+				return err
+			}
+			txRWSet := &rwset.TxReadWriteSet{}
+			if err := txRWSet.Unmarshal(respPayload.Results); err != nil {
+				return err
+			}
+			shdr, err := putils.GetSignatureHeader(payload.Header.SignatureHeader)
+			if err != nil {
+				return err
+			}
+			creatorMSPID, creator := creators.Creator(shdr.Creator)
+			var timestamp int64
+			if chdr.Timestamp != nil {
+				timestamp = chdr.Timestamp.Seconds*1000 + int64(chdr.Timestamp.Nanos)/1000000
+			}
+			// the range deletes of a transaction precede its writes, which supersede them
+			err = writeDerivedDeletes(export.HistoryRecord{IsDelete: true, TxID: chdr.TxId, BlockNum: blockNum, TxNum: uint64(txNum + 1),
+				Timestamp: timestamp, CreatorMSPID: creatorMSPID, Creator: creator})
+			if err != nil {
+				return err
+			}
+			for _, nsRWSet := range txRWSet.NsRWs {
+				if opts.Namespace != "" && nsRWSet.NameSpace != opts.Namespace {
+					continue
+				}
+				// the time-to-live of the keys is not a write to the state
+				if _, ok := lutils.SplitTTLNs(nsRWSet.NameSpace); ok {
+					continue
+				}
+				dropValues := opts.Redaction.DropsValue(nsRWSet.NameSpace)
+				for _, kvWrite := range nsRWSet.Writes {
+					record := &export.HistoryRecord{Namespace: nsRWSet.NameSpace, Key: kvWrite.Key, Value: kvWrite.Value,
+						IsDelete: kvWrite.IsDelete, TxID: chdr.TxId, BlockNum: blockNum, TxNum: uint64(txNum + 1), Timestamp: timestamp,
+						CreatorMSPID: creatorMSPID, Creator: creator}
+					if dropValues && !kvWrite.IsDelete {
+						record.Value = nil
+						summary.RedactedValues++
+					}
+					if err := w.Write(record); err != nil {
+						return err
+					}
+					summary.HistoryRecords++
+				}
+			}
+		}
+		// the keys that expire on the block are deleted after all its transactions and by none of them
+		if err := writeDerivedDeletes(export.HistoryRecord{IsDelete: true, BlockNum: blockNum, TxNum: uint64(len(block.Data.Data) + 1)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeExportFile creates the given file and writes the records with the given function
+func writeExportFile(path string, format export.Format, schema string, write func(w export.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w, err := export.NewWriter(format, f, schema)
+	if err != nil {
+		return err
+	}
+	if err := write(w); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return f.Sync()
+}
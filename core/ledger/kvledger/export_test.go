@@ -0,0 +1,175 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/export"
+)
+
+func TestExportLedgerData(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	provider, _ := NewProvider()
+	l, _ := provider.Create(constructTestLedgerID(0))
+	// the transactions are signed so that they have creators
+	bg := testutil.NewBlockGenerator(t)
+
+	s, _ := l.NewTxSimulator()
+	s.SetState("ns1", "key1", []byte("value1"))
+	s.SetState("ns1", "key2", []byte("value2"))
+	s.SetState("ns2", "key1", []byte("value1"))
+	s.Done()
+	res1, _ := s.GetTxSimulationResults()
+	testutil.AssertNoError(t, l.Commit(bg.NextBlock([][]byte{res1}, true)), "")
+
+	s, _ = l.NewTxSimulator()
+	s.SetState("ns1", "key1", []byte("value1_updated"))
+	s.DeleteState("ns1", "key2")
+	s.Done()
+	res2, _ := s.GetTxSimulationResults()
+	testutil.AssertNoError(t, l.Commit(bg.NextBlock([][]byte{res2}, true)), "")
+	l.Close()
+	provider.Close()
+
+	exportDir, err := ioutil.TempDir("", "ledger-export-")
+	testutil.AssertNoError(t, err, "")
+	defer os.RemoveAll(exportDir)
+
+	summary, err := ExportLedgerData(constructTestLedgerID(0), filepath.Join(exportDir, "ns1"),
+		&ExportOptions{Namespace: "ns1", History: true, Format: export.FormatNDJSON})
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, summary.Height, uint64(2))
+	testutil.AssertEquals(t, summary.StateRecords, 1)
+	testutil.AssertEquals(t, summary.HistoryRecords, 4)
+
+	var stateRecords []*export.StateRecord
+	readNDJSON(t, filepath.Join(exportDir, "ns1", "state.ndjson"), func(line []byte) {
+		r := &export.StateRecord{}
+		testutil.AssertNoError(t, json.Unmarshal(line, r), "")
+		stateRecords = append(stateRecords, r)
+	})
+	testutil.AssertEquals(t, len(stateRecords), 1)
+	testutil.AssertEquals(t, *stateRecords[0], export.StateRecord{
+		Namespace: "ns1", Key: "key1", Value: []byte("value1_updated"), BlockNum: 1, TxNum: 1})
+
+	var historyRecords []*export.HistoryRecord
+	readNDJSON(t, filepath.Join(exportDir, "ns1", "history.ndjson"), func(line []byte) {
+		r := &export.HistoryRecord{}
+		testutil.AssertNoError(t, json.Unmarshal(line, r), "")
+		historyRecords = append(historyRecords, r)
+	})
+	testutil.AssertEquals(t, len(historyRecords), 4)
+	// the creators are not exported unless requested
+	for _, r := range historyRecords {
+		testutil.AssertEquals(t, r.CreatorMSPID, "")
+		testutil.AssertEquals(t, r.Creator, "")
+	}
+	deleteRecord := historyRecords[3]
+	testutil.AssertEquals(t, deleteRecord.Key, "key2")
+	testutil.AssertEquals(t, deleteRecord.IsDelete, true)
+	testutil.AssertEquals(t, deleteRecord.BlockNum, uint64(1))
+	testutil.AssertEquals(t, deleteRecord.TxNum, uint64(1))
+	testutil.AssertNotEquals(t, deleteRecord.TxID, "")
+
+	// all the namespaces are exported without the history
+	summary, err = ExportLedgerData(constructTestLedgerID(0), filepath.Join(exportDir, "all"), &ExportOptions{Format: export.FormatAvro})
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, summary.StateRecords, 2)
+	testutil.AssertEquals(t, summary.Files, []string{filepath.Join(exportDir, "all", "state.avro")})
+
+	// the values of ns1 are withheld and the creators are hashed
+	summary, err = ExportLedgerData(constructTestLedgerID(0), filepath.Join(exportDir, "redacted"), &ExportOptions{History: true,
+		Format: export.FormatNDJSON, Redaction: &export.RedactionRules{DropValues: []string{"ns1"}, HashCreators: true}})
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, summary.StateRecords, 2)
+	testutil.AssertEquals(t, summary.HistoryRecords, 5)
+	testutil.AssertEquals(t, summary.RedactedValues, 4)
+	stateRecords = nil
+	readNDJSON(t, filepath.Join(exportDir, "redacted", "state.ndjson"), func(line []byte) {
+		r := &export.StateRecord{}
+		testutil.AssertNoError(t, json.Unmarshal(line, r), "")
+		stateRecords = append(stateRecords, r)
+	})
+	testutil.AssertEquals(t, len(stateRecords[0].Value), 0)
+	testutil.AssertEquals(t, stateRecords[1].Value, []byte("value1"))
+	historyRecords = nil
+	readNDJSON(t, filepath.Join(exportDir, "redacted", "history.ndjson"), func(line []byte) {
+		r := &export.HistoryRecord{}
+		testutil.AssertNoError(t, json.Unmarshal(line, r), "")
+		historyRecords = append(historyRecords, r)
+	})
+	for _, r := range historyRecords {
+		if r.Namespace == "ns1" {
+			testutil.AssertEquals(t, len(r.Value), 0)
+		} else {
+			testutil.AssertEquals(t, r.Value, []byte("value1"))
+		}
+		testutil.AssertEquals(t, len(r.Creator), 64)
+		testutil.AssertEquals(t, r.Creator, historyRecords[0].Creator)
+	}
+	hashedCreator := historyRecords[0].Creator
+
+	// the creators are exported in clear when requested, and their hashes differ from an export to another
+	_, err = ExportLedgerData(constructTestLedgerID(0), filepath.Join(exportDir, "creators"), &ExportOptions{History: true,
+		Format: export.FormatNDJSON, Creators: true})
+	testutil.AssertNoError(t, err, "")
+	readNDJSON(t, filepath.Join(exportDir, "creators", "history.ndjson"), func(line []byte) {
+		r := &export.HistoryRecord{}
+		testutil.AssertNoError(t, json.Unmarshal(line, r), "")
+		testutil.AssertEquals(t, r.CreatorMSPID, "DEFAULT")
+		testutil.AssertNotEquals(t, r.Creator, "")
+	})
+	_, err = ExportLedgerData(constructTestLedgerID(0), filepath.Join(exportDir, "rehashed"), &ExportOptions{History: true,
+		Format: export.FormatNDJSON, Creators: true, Redaction: &export.RedactionRules{HashCreators: true}})
+	testutil.AssertNoError(t, err, "")
+	readNDJSON(t, filepath.Join(exportDir, "rehashed", "history.ndjson"), func(line []byte) {
+		r := &export.HistoryRecord{}
+		testutil.AssertNoError(t, json.Unmarshal(line, r), "")
+		testutil.AssertEquals(t, len(r.Creator), 64)
+		testutil.AssertNotEquals(t, r.Creator, hashedCreator)
+	})
+
+	// the redaction entries must be well formed
+	_, err = ExportLedgerData(constructTestLedgerID(0), filepath.Join(exportDir, "invalid"),
+		&ExportOptions{Format: export.FormatNDJSON, Redaction: &export.RedactionRules{DropValues: []string{"ns1/"}}})
+	testutil.AssertError(t, err, "")
+
+	// the export directory must be empty
+	_, err = ExportLedgerData(constructTestLedgerID(0), filepath.Join(exportDir, "all"), &ExportOptions{Format: export.FormatAvro})
+	_, ok := err.(*ledger.ConflictError)
+	testutil.AssertEquals(t, ok, true)
+}
+
+func readNDJSON(t *testing.T, path string, decode func(line []byte)) {
+	f, err := os.Open(path)
+	testutil.AssertNoError(t, err, "")
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		decode(scanner.Bytes())
+	}
+	testutil.AssertNoError(t, scanner.Err(), "")
+}
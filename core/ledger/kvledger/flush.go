@@ -0,0 +1,65 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package kvledger
+
+import (
+	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/hyperledger/fabric/core/ledger"
+)
+
+// Flush implements method in interface `ledger.PeerLedger`
+func (l *kvLedger) Flush() (*ledger.StoreHeights, error) {
+	if l.historyCommitter != nil {
+		l.historyCommitter.waitUntilCaughtUp()
+	}
+	if err := l.blockStore.Sync(); err != nil {
+		return nil, err
+	}
+	if err := l.versionedDB.Sync(); err != nil {
+		return nil, err
+	}
+	if l.config.HistoryDatabase {
+		if err := l.historyDB.Sync(); err != nil {
+			return nil, err
+		}
+	}
+
+	heights := &ledger.StoreHeights{}
+	info, err := l.blockStore.GetBlockchainInfo()
+	if err != nil {
+		return nil, err
+	}
+	heights.BlockStore = info.Height
+	stateSavepoint, err := l.versionedDB.GetLatestSavePoint()
+	if err != nil {
+		return nil, err
+	}
+	if stateSavepoint != nil {
+		heights.StateDB = stateSavepoint.BlockNum + 1
+	}
+	if l.config.HistoryDatabase {
+		historySavepoint, err := l.historyDB.GetLastSavepoint()
+		if err != nil {
+			return nil, err
+		}
+		if historySavepoint != nil {
+			heights.HistoryDB = historySavepoint.BlockNum + 1
+		}
+	}
+	logger.With(flogging.Fields{"channel": l.ledgerID}).Infof("Flushed ledger at block store height [%d], state database height [%d], history database height [%d]",
+		heights.BlockStore, heights.StateDB, heights.HistoryDB)
+	return heights, nil
+}
@@ -0,0 +1,91 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package kvledger
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/spf13/viper"
+)
+
+func TestFlush(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	historyEnabled := viper.GetBool("ledger.state.historyDatabase")
+	viper.Set("ledger.state.historyDatabase", true)
+	defer viper.Set("ledger.state.historyDatabase", historyEnabled)
+	viper.Set("ledger.state.historyAsyncCommit", true)
+	defer viper.Set("ledger.state.historyAsyncCommit", false)
+
+	provider, _ := NewProvider()
+	defer provider.Close()
+	l, _ := provider.Create("testLedger")
+	defer l.Close()
+	bg := testutil.NewBlockGenerator(t)
+	for i := 0; i < 3; i++ {
+		s, _ := l.NewTxSimulator()
+		s.SetState("ns", "key", []byte(fmt.Sprintf("value%d", i)))
+		s.Done()
+		res, _ := s.GetTxSimulationResults()
+		testutil.AssertNoError(t, l.Commit(bg.NextBlock([][]byte{res}, false)), "")
+	}
+	// the flush waits for the history database to catch up
+	heights, err := l.Flush()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, heights, &ledger.StoreHeights{BlockStore: 3, StateDB: 3, HistoryDB: 3})
+	testutil.AssertEquals(t, l.(*kvLedger).historyCommitter.lag(), uint64(0))
+}
+
+func TestCommitWithSyncPolicies(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	viper.Set("ledger.blockchain.fsync.blocks", 2)
+	viper.Set("ledger.blockchain.fsync.interval", "20ms")
+	viper.Set("ledger.state.fsync.interval", "20ms")
+	defer viper.Set("ledger.blockchain.fsync.blocks", 1)
+	defer viper.Set("ledger.blockchain.fsync.interval", "")
+	defer viper.Set("ledger.state.fsync.interval", "")
+
+	provider, _ := NewProvider()
+	l, _ := provider.Create("testLedger")
+	bg := testutil.NewBlockGenerator(t)
+	for i := 0; i < 5; i++ {
+		s, _ := l.NewTxSimulator()
+		s.SetState("ns", "key", []byte(fmt.Sprintf("value%d", i)))
+		s.Done()
+		res, _ := s.GetTxSimulationResults()
+		testutil.AssertNoError(t, l.Commit(bg.NextBlock([][]byte{res}, false)), "")
+	}
+	// the last block is flushed by the interval
+	time.Sleep(100 * time.Millisecond)
+	l.Close()
+	provider.Close()
+
+	provider, _ = NewProvider()
+	defer provider.Close()
+	l, _ = provider.Open("testLedger")
+	defer l.Close()
+	info, _ := l.GetBlockchainInfo()
+	testutil.AssertEquals(t, info.Height, uint64(5))
+	q, _ := l.NewQueryExecutor()
+	defer q.Done()
+	val, _ := q.GetState("ns", "key")
+	testutil.AssertEquals(t, val, []byte("value4"))
+}
@@ -0,0 +1,141 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+	"github.com/hyperledger/fabric/protos/common"
+	putils "github.com/hyperledger/fabric/protos/utils"
+)
+
+// ordererAddressesKey is the key of the value of the channel config that lists the addresses of the orderers
+const ordererAddressesKey = "OrdererAddresses"
+
+// CreateFromGenesisBlock implements the corresponding method from interface ledger.PeerLedgerProvider
+func (provider *Provider) CreateFromGenesisBlock(genesisBlock *common.Block, bootstrapMethod string) (ledger.PeerLedger, error) {
+	if provider.readOnly {
+		return nil, ErrLedgerReadOnly
+	}
+	ledgerID, ordererEndpoints, err := validateGenesisBlock(genesisBlock)
+	if err != nil {
+		return nil, err
+	}
+	logger.With(flogging.Fields{"channel": ledgerID}).Infof("Creating ledger from genesis block with bootstrap method [%s] and orderer endpoints %v",
+		bootstrapMethod, ordererEndpoints)
+	exists, err := provider.idStore.ledgerIDExists(ledgerID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrLedgerIDExists
+	}
+	// the ledger remains under construction till the genesis block is committed and the provenance is recorded
+	config := ledgerconfig.GetChannelConfig(ledgerID)
+	if err := provider.idStore.createLedgerID(ledgerID, ledger.LedgerStatusUnderConstruction, config, 0); err != nil {
+		return nil, err
+	}
+	l, err := provider.openLedger(ledgerID)
+	if err != nil {
+		provider.removeIncompleteLedger(ledgerID)
+		return nil, err
+	}
+	recordProvenance := func(metadata *ledgerMetadata) {
+		metadata.bootstrapMethod = bootstrapMethod
+		metadata.ordererEndpoints = ordererEndpoints
+	}
+	if err := provider.idStore.updateLedgerMetadata(ledgerID, recordProvenance); err != nil {
+		l.Close()
+		provider.removeIncompleteLedger(ledgerID)
+		return nil, err
+	}
+	if err := l.Commit(genesisBlock); err != nil {
+		l.Close()
+		provider.removeIncompleteLedger(ledgerID)
+		return nil, err
+	}
+	if err := provider.idStore.updateLedgerStatus(ledgerID, ledger.LedgerStatusActive); err != nil {
+		l.Close()
+		provider.removeIncompleteLedger(ledgerID)
+		return nil, err
+	}
+	return l, nil
+}
+
+// validateGenesisBlock checks that the block is block 0, that its data hash matches its data, and that its only
+// transaction is a config transaction that carries the config of a channel. The channel of the block is returned
+// along with the addresses of the orderers listed in the config, if any
+func validateGenesisBlock(block *common.Block) (string, []string, error) {
+	if block == nil || block.Header == nil || block.Data == nil {
+		return "", nil, &ledger.InvalidBlockError{Msg: "Genesis block must have a header and data"}
+	}
+	if block.Header.Number != 0 {
+		return "", nil, &ledger.InvalidBlockError{Msg: fmt.Sprintf("Genesis block must be block [0], got block [%d]", block.Header.Number)}
+	}
+	if !bytes.Equal(block.Header.DataHash, block.Data.Hash()) {
+		return "", nil, &ledger.InvalidBlockError{Msg: "Data hash of the genesis block does not match its data"}
+	}
+	if len(block.Data.Data) != 1 {
+		return "", nil, &ledger.InvalidBlockError{Msg: fmt.Sprintf("Genesis block must contain exactly one transaction, got [%d]", len(block.Data.Data))}
+	}
+	invalid := func(err error) error {
+		return &ledger.InvalidBlockError{Msg: fmt.Sprintf("Malformed transaction in the genesis block: %s", err)}
+	}
+	env, err := putils.GetEnvelopeFromBlock(block.Data.Data[0])
+	if err != nil {
+		return "", nil, invalid(err)
+	}
+	payload, err := putils.GetPayload(env)
+	if err != nil {
+		return "", nil, invalid(err)
+	}
+	if payload.Header == nil {
+		return "", nil, invalid(fmt.Errorf("missing header"))
+	}
+	chdr, err := putils.UnmarshalChannelHeader(payload.Header.ChannelHeader)
+	if err != nil {
+		return "", nil, invalid(err)
+	}
+	if common.HeaderType(chdr.Type) != common.HeaderType_CONFIG {
+		return "", nil, &ledger.InvalidBlockError{Msg: fmt.Sprintf("Genesis block must contain a config transaction, got a transaction of type [%s]",
+			common.HeaderType(chdr.Type))}
+	}
+	if chdr.ChannelId == "" {
+		return "", nil, invalid(fmt.Errorf("missing channel id"))
+	}
+	configEnvelope := &common.ConfigEnvelope{}
+	if err := proto.Unmarshal(payload.Data, configEnvelope); err != nil {
+		return "", nil, invalid(err)
+	}
+	if configEnvelope.Config == nil || configEnvelope.Config.Channel == nil {
+		return "", nil, &ledger.InvalidBlockError{Msg: "Genesis block does not carry a channel config"}
+	}
+	var ordererEndpoints []string
+	if value, ok := configEnvelope.Config.Channel.Values[ordererAddressesKey]; ok && value != nil {
+		addresses := &common.OrdererAddresses{}
+		if err := proto.Unmarshal(value.Value, addresses); err != nil {
+			return "", nil, invalid(err)
+		}
+		ordererEndpoints = addresses.Addresses
+	}
+	return chdr.ChannelId, ordererEndpoints, nil
+}
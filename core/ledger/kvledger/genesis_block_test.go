@@ -0,0 +1,93 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"testing"
+
+	configtxtest "github.com/hyperledger/fabric/common/configtx/test"
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/protos/common"
+	putils "github.com/hyperledger/fabric/protos/utils"
+)
+
+func TestCreateFromGenesisBlock(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	provider, _ := NewProvider()
+	defer provider.Close()
+
+	genesisBlock, err := configtxtest.MakeGenesisBlock("testchain")
+	testutil.AssertNoError(t, err, "")
+	l, err := provider.CreateFromGenesisBlock(genesisBlock, ledger.BootstrapMethodJoin)
+	testutil.AssertNoError(t, err, "")
+	defer l.Close()
+	bcInfo, _ := l.GetBlockchainInfo()
+	testutil.AssertEquals(t, bcInfo.Height, uint64(1))
+
+	_, expectedEndpoints, err := validateGenesisBlock(genesisBlock)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertNotEquals(t, len(expectedEndpoints), 0)
+	metadata, err := provider.GetLedgerMetadata("testchain")
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, metadata.Status, ledger.LedgerStatusActive)
+	testutil.AssertEquals(t, metadata.BootstrapMethod, ledger.BootstrapMethodJoin)
+	testutil.AssertEquals(t, metadata.OrdererEndpoints, expectedEndpoints)
+	testutil.AssertEquals(t, metadata.GenesisBlockHash, bcInfo.CurrentBlockHash)
+
+	_, err = provider.CreateFromGenesisBlock(genesisBlock, ledger.BootstrapMethodJoin)
+	testutil.AssertEquals(t, err, ErrLedgerIDExists)
+}
+
+func TestCreateFromInvalidGenesisBlock(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	provider, _ := NewProvider()
+	defer provider.Close()
+
+	tamper := func(modify func(block *common.Block)) *common.Block {
+		block, err := configtxtest.MakeGenesisBlock("testchain")
+		testutil.AssertNoError(t, err, "")
+		modify(block)
+		return block
+	}
+	for name, block := range map[string]*common.Block{
+		"nil block":      nil,
+		"not block 0":    tamper(func(block *common.Block) { block.Header.Number = 1 }),
+		"data hash":      tamper(func(block *common.Block) { block.Header.DataHash = []byte("hash") }),
+		"no transaction": testutil.NewBlockGenerator(t).NextBlock([][]byte{}, false),
+		"not config":     testutil.NewBlockGenerator(t).NextBlock([][]byte{[]byte("simRes")}, false),
+		"no channel config": tamper(func(block *common.Block) {
+			env, _ := putils.GetEnvelopeFromBlock(block.Data.Data[0])
+			payload, _ := putils.GetPayload(env)
+			payload.Data = putils.MarshalOrPanic(&common.ConfigEnvelope{})
+			env.Payload = putils.MarshalOrPanic(payload)
+			block.Data.Data[0] = putils.MarshalOrPanic(env)
+			block.Header.DataHash = block.Data.Hash()
+		}),
+	} {
+		_, err := provider.CreateFromGenesisBlock(block, ledger.BootstrapMethodJoin)
+		_, ok := err.(*ledger.InvalidBlockError)
+		if !ok {
+			t.Fatalf("Expected an invalid block error for [%s], got [%v]", name, err)
+		}
+	}
+	exists, err := provider.Exists("testchain")
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, exists, false)
+}
@@ -0,0 +1,52 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"hash"
+
+	"github.com/hyperledger/fabric/bccsp"
+	"github.com/hyperledger/fabric/bccsp/factory"
+	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+)
+
+// All the hashes computed by the ledger go through the BCCSP so that a deployment can plug in
+// a BCCSP with the hash function mandated for it. The hash function is chosen by 'ledger.hashAlgorithm',
+// except for the block hashes, which are computed by blkstorage.ComputeBlockHash
+
+// getHashOpts returns the options of the hash function configured for the ledger
+func getHashOpts() (bccsp.HashOpts, error) {
+	return bccsp.GetHashOpt(ledgerconfig.GetHashAlgorithm())
+}
+
+// computeHash computes the hash of the data with the hash function configured for the ledger
+func computeHash(data []byte) ([]byte, error) {
+	hashOpts, err := getHashOpts()
+	if err != nil {
+		return nil, err
+	}
+	return factory.GetDefault().Hash(data, hashOpts)
+}
+
+// newHash returns a hash.Hash of the given hash function
+func newHash(hashAlgorithm string) (hash.Hash, error) {
+	hashOpts, err := bccsp.GetHashOpt(hashAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+	return factory.GetDefault().GetHash(hashOpts)
+}
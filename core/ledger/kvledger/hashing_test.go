@@ -0,0 +1,83 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hyperledger/fabric/bccsp"
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/spf13/viper"
+)
+
+func TestConfiguredHashAlgorithm(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	viper.Set("ledger.hashAlgorithm", bccsp.SHA3_256)
+	defer viper.Set("ledger.hashAlgorithm", nil)
+	snapshotDir, err := ioutil.TempDir("", "kvledger-snapshot-")
+	testutil.AssertNoError(t, err, "")
+	defer os.RemoveAll(snapshotDir)
+
+	provider, err := NewProvider()
+	testutil.AssertNoError(t, err, "")
+	l, _ := provider.Create("testLedger")
+	bg := testutil.NewBlockGenerator(t)
+	var blocks []*common.Block
+	for i := 0; i < 2; i++ {
+		s, _ := l.NewTxSimulator()
+		s.SetState("ns", "key", []byte(fmt.Sprintf("value%d", i)))
+		s.Done()
+		res, _ := s.GetTxSimulationResults()
+		block := bg.NextBlock([][]byte{res}, false)
+		testutil.AssertNoError(t, l.Commit(block), "")
+		blocks = append(blocks, block)
+	}
+
+	// the block hashes stay SHA256 and hence, chain the blocks as the orderer does
+	bcInfo, _ := l.GetBlockchainInfo()
+	testutil.AssertEquals(t, bcInfo.CurrentBlockHash, blocks[1].Header.Hash())
+	testutil.AssertEquals(t, bcInfo.PreviousBlockHash, blocks[0].Header.Hash())
+	retrievedBlock, err := l.GetBlockByHash(blocks[1].Header.PreviousHash)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, retrievedBlock.Header.Number, uint64(0))
+
+	testutil.AssertNoError(t, l.GenerateSnapshot(filepath.Join(snapshotDir, "snapshot")), "")
+	metadata, _ := LoadSnapshotMetadata(filepath.Join(snapshotDir, "snapshot"))
+	testutil.AssertEquals(t, metadata.HashAlgorithm, bccsp.SHA3_256)
+	testutil.AssertEquals(t, metadata.LastBlockHash, blocks[1].Header.Hash())
+	l.Close()
+	provider.Close()
+	env.cleanup()
+
+	// a snapshot cannot be loaded in a ledger configured with a different hash function
+	viper.Set("ledger.hashAlgorithm", bccsp.SHA256)
+	provider, _ = NewProvider()
+	_, _, err = provider.CreateFromSnapshot(filepath.Join(snapshotDir, "snapshot"))
+	testutil.AssertError(t, err, "Expected an error as the snapshot is generated with a different hash function")
+	provider.Close()
+
+	// an unknown hash function is rejected
+	viper.Set("ledger.hashAlgorithm", "unknown")
+	_, err = NewProvider()
+	testutil.AssertError(t, err, "Expected an error for an unknown hash function")
+}
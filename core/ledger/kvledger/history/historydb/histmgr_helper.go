@@ -18,41 +18,109 @@ package historydb
 
 import (
 	"bytes"
+	"fmt"
+	"math"
 
 	"github.com/hyperledger/fabric/common/ledger/util"
 )
 
-var compositeKeySep = []byte{0x00}
+// KeyFormatV1 is the format of the History Keys that orders the history of a key by ascending height
+const KeyFormatV1 = "v1"
+
+// KeyFormatV2 is the format of the History Keys that stores the block number and the transaction number inverted,
+// so that the history of a key is ordered by descending height and the most recent modifications are read first
+const KeyFormatV2 = "v2"
+
+// IsKnownKeyFormat returns true if the given format is a format of the History Keys
+func IsKnownKeyFormat(format string) bool {
+	return format == KeyFormatV1 || format == KeyFormatV2
+}
 
 //ConstructCompositeHistoryKey builds the History Key of namespace~key~blocknum~trannum
-// using an order preserving encoding so that history query results are ordered by height
+// using an order preserving encoding so that history query results are ordered by height.
+// The namespace and the key are prefixed by their lengths rather than followed by a separator,
+// so that the history of a key is not mixed with the history of the keys that extend it past
+// a byte equal to the separator, such as the composite keys of the chaincodes
 func ConstructCompositeHistoryKey(ns string, key string, blocknum uint64, trannum uint64) []byte {
 
-	var compositeKey []byte
-	compositeKey = append(compositeKey, []byte(ns)...)
-	compositeKey = append(compositeKey, compositeKeySep...)
-	compositeKey = append(compositeKey, []byte(key)...)
-	compositeKey = append(compositeKey, compositeKeySep...)
+	compositeKey := constructHistoryKeyPrefix(ns, key)
 	compositeKey = append(compositeKey, util.EncodeOrderPreservingVarUint64(blocknum)...)
 	compositeKey = append(compositeKey, util.EncodeOrderPreservingVarUint64(trannum)...)
 
 	return compositeKey
 }
 
+// ConstructCompositeHistoryKeyInFormat builds the History Key of namespace~key~blocknum~trannum in the given format
+func ConstructCompositeHistoryKeyInFormat(format string, ns string, key string, blocknum uint64, trannum uint64) []byte {
+	if format == KeyFormatV2 {
+		return ConstructCompositeHistoryKey(ns, key, math.MaxUint64-blocknum, math.MaxUint64-trannum)
+	}
+	return ConstructCompositeHistoryKey(ns, key, blocknum, trannum)
+}
+
+// DecodeHistoryKeyHeight decodes the block number and the transaction number that follow namespace~key in a History Key
+// and returns them along with the format of the key. A key is taken to be in KeyFormatV2 if its block number field has
+// the highest bit set, which holds for the inverted block numbers as the block numbers stay below 2^63
+func DecodeHistoryKeyHeight(blockNumTranNumBytes []byte) (uint64, uint64, string) {
+	blocknum, bytesConsumed := util.DecodeOrderPreservingVarUint64(blockNumTranNumBytes)
+	trannum, _ := util.DecodeOrderPreservingVarUint64(blockNumTranNumBytes[bytesConsumed:])
+	if blocknum > math.MaxInt64 {
+		return math.MaxUint64 - blocknum, math.MaxUint64 - trannum, KeyFormatV2
+	}
+	return blocknum, trannum, KeyFormatV1
+}
+
+// DecodeCompositeHistoryKey splits a History Key into the namespace, the key, the block number and the transaction number,
+// and returns the format of the key
+func DecodeCompositeHistoryKey(historyKey []byte) (string, string, uint64, uint64, string, error) {
+	ns, rest, err := decodeLengthPrefixed(historyKey)
+	if err != nil {
+		return "", "", 0, 0, "", err
+	}
+	key, rest, err := decodeLengthPrefixed(rest)
+	if err != nil {
+		return "", "", 0, 0, "", err
+	}
+	if len(rest) < 2 {
+		return "", "", 0, 0, "", fmt.Errorf("History key [%#v] has no height", historyKey)
+	}
+	blocknum, trannum, format := DecodeHistoryKeyHeight(rest)
+	return ns, key, blocknum, trannum, format, nil
+}
+
+// decodeLengthPrefixed decodes a length-prefixed string from the start of the bytes and returns it with the remaining bytes
+func decodeLengthPrefixed(b []byte) (string, []byte, error) {
+	if len(b) == 0 || int(b[0]) > 8 || len(b) < int(b[0])+1 {
+		return "", nil, fmt.Errorf("Malformed length prefix in history key bytes [%#v]", b)
+	}
+	length, bytesConsumed := util.DecodeOrderPreservingVarUint64(b)
+	if uint64(len(b)-bytesConsumed) < length {
+		return "", nil, fmt.Errorf("Truncated history key bytes [%#v]", b)
+	}
+	end := bytesConsumed + int(length)
+	return string(b[bytesConsumed:end]), b[end:], nil
+}
+
 //ConstructPartialCompositeHistoryKey builds a partial History Key namespace~key~
 // for use in history key range queries
 func ConstructPartialCompositeHistoryKey(ns string, key string, endkey bool) []byte {
-	var compositeKey []byte
-	compositeKey = append(compositeKey, []byte(ns)...)
-	compositeKey = append(compositeKey, compositeKeySep...)
-	compositeKey = append(compositeKey, []byte(key)...)
-	compositeKey = append(compositeKey, compositeKeySep...)
+	compositeKey := constructHistoryKeyPrefix(ns, key)
 	if endkey {
 		compositeKey = append(compositeKey, []byte{0xff}...)
 	}
 	return compositeKey
 }
 
+// constructHistoryKeyPrefix returns the length-prefixed namespace and key that start the History Keys of the key
+func constructHistoryKeyPrefix(ns string, key string) []byte {
+	var prefix []byte
+	prefix = append(prefix, util.EncodeOrderPreservingVarUint64(uint64(len(ns)))...)
+	prefix = append(prefix, []byte(ns)...)
+	prefix = append(prefix, util.EncodeOrderPreservingVarUint64(uint64(len(key)))...)
+	prefix = append(prefix, []byte(key)...)
+	return prefix
+}
+
 //SplitCompositeHistoryKey splits the key bytes using a separator
 func SplitCompositeHistoryKey(bytesToSplit []byte, separator []byte) ([]byte, []byte) {
 	split := bytes.SplitN(bytesToSplit, separator, 2)
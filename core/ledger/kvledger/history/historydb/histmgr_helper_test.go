@@ -17,13 +17,12 @@ limitations under the License.
 package historydb
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/hyperledger/fabric/common/ledger/testutil"
 )
 
-var strKeySep = string(compositeKeySep)
-
 func TestConstructCompositeKey(t *testing.T) {
 	compositeKey := ConstructCompositeHistoryKey("ns1", "key1", 1, 1)
 	testutil.AssertNotNil(t, compositeKey)
@@ -34,15 +33,52 @@ func TestConstructPartialCompositeKey(t *testing.T) {
 	compositeStartKey := ConstructPartialCompositeHistoryKey("ns1", "key1", false)
 	compositeEndKey := ConstructPartialCompositeHistoryKey("ns1", "key1", true)
 
-	testutil.AssertEquals(t, compositeStartKey, []byte("ns1"+strKeySep+"key1"+strKeySep))
-	testutil.AssertEquals(t, compositeEndKey, []byte("ns1"+strKeySep+"key1"+strKeySep+string([]byte{0xff})))
+	testutil.AssertEquals(t, compositeStartKey, []byte("\x01\x03ns1\x01\x04key1"))
+	testutil.AssertEquals(t, compositeEndKey, []byte("\x01\x03ns1\x01\x04key1\xff"))
+}
+
+func TestCompositeKeyOfKeyWithSeparator(t *testing.T) {
+	// the history keys of a key do not fall in the range of the keys that it is a prefix of
+	compositeKey := ConstructCompositeHistoryKey("ns1", "key1\x00attr1\x00", 1, 1)
+	for _, key := range []string{"key1", "key1\x00", "key1\x00attr1"} {
+		compositeStartKey := ConstructPartialCompositeHistoryKey("ns1", key, false)
+		compositeEndKey := ConstructPartialCompositeHistoryKey("ns1", key, true)
+		testutil.AssertEquals(t, bytes.Compare(compositeKey, compositeStartKey) >= 0 && bytes.Compare(compositeKey, compositeEndKey) < 0, false)
+	}
+	compositeStartKey := ConstructPartialCompositeHistoryKey("ns1", "key1\x00attr1\x00", false)
+	testutil.AssertEquals(t, bytes.HasPrefix(compositeKey, compositeStartKey), true)
 }
 
 func TestSplitCompositeKey(t *testing.T) {
-	compositeFullKey := []byte("ns1" + strKeySep + "key1" + strKeySep + "extra bytes to split")
 	compositePartialKey := ConstructPartialCompositeHistoryKey("ns1", "key1", false)
+	compositeFullKey := append(compositePartialKey, []byte("extra bytes to split")...)
 
 	_, extraBytes := SplitCompositeHistoryKey(compositeFullKey, compositePartialKey)
 	// second position should hold the extra bytes that were split off
 	testutil.AssertEquals(t, extraBytes, []byte("extra bytes to split"))
 }
+
+func TestCompositeKeyInFormatV2(t *testing.T) {
+	// the keys in KeyFormatV2 order the history of a key by descending height
+	keys := [][]byte{
+		ConstructCompositeHistoryKeyInFormat(KeyFormatV2, "ns1", "key1", 2, 1),
+		ConstructCompositeHistoryKeyInFormat(KeyFormatV2, "ns1", "key1", 1, 2),
+		ConstructCompositeHistoryKeyInFormat(KeyFormatV2, "ns1", "key1", 1, 1),
+	}
+	for i := 1; i < len(keys); i++ {
+		testutil.AssertEquals(t, bytes.Compare(keys[i-1], keys[i]) < 0, true)
+	}
+
+	for _, format := range []string{KeyFormatV1, KeyFormatV2} {
+		compositeKey := ConstructCompositeHistoryKeyInFormat(format, "ns1", "key1\x00attr1", 5, 3)
+		ns, key, blocknum, trannum, decodedFormat, err := DecodeCompositeHistoryKey(compositeKey)
+		testutil.AssertNoError(t, err, "")
+		testutil.AssertEquals(t, ns, "ns1")
+		testutil.AssertEquals(t, key, "key1\x00attr1")
+		testutil.AssertEquals(t, blocknum, uint64(5))
+		testutil.AssertEquals(t, trannum, uint64(3))
+		testutil.AssertEquals(t, decodedFormat, format)
+	}
+	_, _, _, _, _, err := DecodeCompositeHistoryKey([]byte("\x01\x03ns1\x01\x09key1"))
+	testutil.AssertError(t, err, "Expected an error for a truncated key")
+}
@@ -27,6 +27,8 @@ import (
 type HistoryDBProvider interface {
 	// GetDBHandle returns a handle to a HistoryDB
 	GetDBHandle(id string) (HistoryDB, error)
+	// Drop removes all the data of the HistoryDB with the given id
+	Drop(id string) error
 	// Close closes all the HistoryDB instances and releases any resources held by HistoryDBProvider
 	Close()
 }
@@ -36,6 +38,13 @@ type HistoryDB interface {
 	NewHistoryQueryExecutor(blockStore blkstorage.BlockStore) (ledger.HistoryQueryExecutor, error)
 	Commit(block *common.Block) error
 	GetLastSavepoint() (*version.Height, error)
+	MarkStartingSavepoint(savepoint *version.Height) error
 	ShouldRecover(lastAvailableBlock uint64) (bool, uint64, error)
 	CommitLostBlock(block *common.Block) error
+	// GetDiskUsage returns the number of bytes that the history index occupies on the disk
+	GetDiskUsage() (int64, error)
+	// Sync flushes the commits made so far to the disk
+	Sync() error
+	// Compact compacts the history index, which reduces the number of the files read by the history queries
+	Compact() error
 }
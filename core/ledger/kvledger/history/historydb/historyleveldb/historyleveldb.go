@@ -17,24 +17,51 @@ limitations under the License.
 package historyleveldb
 
 import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/common/flogging"
 	"github.com/hyperledger/fabric/common/ledger/blkstorage"
 	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
+	"github.com/hyperledger/fabric/common/metrics"
 	"github.com/hyperledger/fabric/core/ledger"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/history/historydb"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/rwset"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
 	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+	lutils "github.com/hyperledger/fabric/core/ledger/util"
 	"github.com/hyperledger/fabric/protos/common"
 	putils "github.com/hyperledger/fabric/protos/utils"
-	logging "github.com/op/go-logging"
 )
 
-var logger = logging.MustGetLogger("historyleveldb")
+var logger = flogging.MustGetFieldLogger("historyleveldb")
 
 var compositeKeySep = []byte{0x00}
 var savePointKey = []byte{0x00}
 var emptyValue = []byte{}
 
+// the records of the deletes that are not in the write-set of a transaction are marked by their value, so that
+// the queries resolve them without looking for the write in the transaction. An expiry has no transaction
+var rangeDeleteValue = []byte{0x01}
+var expiryValue = []byte{0x02}
+
+// keyFormatKey holds the format of the History Keys of the database and keyFormatMigrationKey the format that the keys
+// are being migrated to. The history keys start with the length of the namespace, which is encoded in a byte below 0xff
+var keyFormatKey = []byte{0xff}
+var keyFormatMigrationKey = []byte{0xff, 0x01}
+
+// maxKeyFormatMigrationBatchSize is the number of the History Keys rewritten in a batch by a key format migration
+const maxKeyFormatMigrationBatchSize = 10000
+
+// the history records of a block and the savepoint are written in a single batch, whose size is reported per channel
+var (
+	batchEntries = metrics.NewHistogramVec("ledger_history_batch_entries",
+		"Number of the entries written to the history database in the batch of a block, including the savepoint.",
+		[]float64{1, 10, 100, 1000, 10000, 100000}, "channel")
+	batchBytes = metrics.NewHistogramVec("ledger_history_batch_bytes",
+		"Number of the bytes of the keys and the values written to the history database in the batch of a block.",
+		[]float64{1024, 16 * 1024, 256 * 1024, 1024 * 1024, 16 * 1024 * 1024}, "channel")
+)
+
 // HistoryDBProvider implements interface HistoryDBProvider
 type HistoryDBProvider struct {
 	dbProvider *leveldbhelper.Provider
@@ -44,13 +71,151 @@ type HistoryDBProvider struct {
 func NewHistoryDBProvider() *HistoryDBProvider {
 	dbPath := ledgerconfig.GetHistoryLevelDBPath()
 	logger.Debugf("constructing HistoryDBProvider dbPath=%s", dbPath)
-	dbProvider := leveldbhelper.NewProvider(&leveldbhelper.Conf{DBPath: dbPath})
+	dbProvider := leveldbhelper.NewProvider(&leveldbhelper.Conf{DBPath: dbPath, Tuning: ledgerconfig.GetHistoryLevelDBTuning()})
+	return &HistoryDBProvider{dbProvider}
+}
+
+// NewReadOnlyHistoryDBProvider instantiates HistoryDBProvider that opens the existing databases in read-only mode
+func NewReadOnlyHistoryDBProvider() *HistoryDBProvider {
+	dbPath := ledgerconfig.GetHistoryLevelDBPath()
+	logger.Debugf("constructing read-only HistoryDBProvider dbPath=%s", dbPath)
+	dbProvider := leveldbhelper.NewProvider(&leveldbhelper.Conf{DBPath: dbPath, ReadOnly: true, Tuning: ledgerconfig.GetHistoryLevelDBTuning()})
+	return &HistoryDBProvider{dbProvider}
+}
+
+// NewSharedHistoryDBProvider instantiates HistoryDBProvider over the given provider of a leveldb shared with other stores
+func NewSharedHistoryDBProvider(dbProvider *leveldbhelper.Provider) *HistoryDBProvider {
 	return &HistoryDBProvider{dbProvider}
 }
 
 // GetDBHandle gets the handle to a named database
 func (provider *HistoryDBProvider) GetDBHandle(dbName string) (historydb.HistoryDB, error) {
-	return newHistoryDB(provider.dbProvider.GetDBHandle(dbName), dbName), nil
+	historyDB := newHistoryDB(provider.dbProvider.GetDBHandle(dbName), dbName)
+	if err := historyDB.loadKeyFormat(); err != nil {
+		return nil, err
+	}
+	return historyDB, nil
+}
+
+// Drop removes all the keys of the named database
+func (provider *HistoryDBProvider) Drop(dbName string) error {
+	return provider.dbProvider.GetDBHandle(dbName).DeleteAll()
+}
+
+// List returns the names of the history databases that hold data
+func (provider *HistoryDBProvider) List() ([]string, error) {
+	return provider.dbProvider.ListDBNames()
+}
+
+// IsEmpty returns true if none of the history databases holds data
+func (provider *HistoryDBProvider) IsEmpty() (bool, error) {
+	dbNames, err := provider.dbProvider.ListDBNames()
+	return len(dbNames) == 0, err
+}
+
+// GetDataFormat returns the format of the data of the history databases, the empty string if no format is recorded
+func (provider *HistoryDBProvider) GetDataFormat() (string, error) {
+	return provider.dbProvider.GetDataFormat()
+}
+
+// SetDataFormat records the format of the data of the history databases
+func (provider *HistoryDBProvider) SetDataFormat(format string) error {
+	return provider.dbProvider.SetDataFormat(format)
+}
+
+// GetKeyFormat returns the format of the History Keys of the named database and the format that an interrupted
+// migration of the keys was migrating them to, if any
+func (provider *HistoryDBProvider) GetKeyFormat(dbName string) (string, string, error) {
+	db := provider.dbProvider.GetDBHandle(dbName)
+	format, err := recordedKeyFormat(db)
+	if err != nil {
+		return "", "", err
+	}
+	migratingTo, err := db.Get(keyFormatMigrationKey)
+	if err != nil {
+		return "", "", err
+	}
+	return format, string(migratingTo), nil
+}
+
+// MigrateKeyFormat rewrites, in place, the History Keys of the named database to the given format and returns the
+// number of the rewritten keys. The migration is recorded before the first batch of the rewritten keys, so that the
+// database is not opened while it holds keys of both the formats, and is completed by invoking it again after an
+// interruption, as the keys already in the given format are left as is. This is an offline operation
+func (provider *HistoryDBProvider) MigrateKeyFormat(dbName string, format string) (int, error) {
+	if !historydb.IsKnownKeyFormat(format) {
+		return 0, fmt.Errorf("Unknown history key format [%s]", format)
+	}
+	dbLogger := logger.With(flogging.Fields{"channel": dbName})
+	current, migratingTo, err := provider.GetKeyFormat(dbName)
+	if err != nil {
+		return 0, err
+	}
+	if migratingTo != "" && migratingTo != format {
+		return 0, fmt.Errorf("History database of channel [%s] is being migrated to key format [%s]", dbName, migratingTo)
+	}
+	if migratingTo == "" && current == format {
+		return 0, nil
+	}
+	db := provider.dbProvider.GetDBHandle(dbName)
+	if err := db.Put(keyFormatMigrationKey, []byte(format), true); err != nil {
+		return 0, err
+	}
+	dbLogger.Infof("Migrating the history keys from format [%s] to [%s]", current, format)
+
+	migrated := 0
+	dbBatch := leveldbhelper.NewUpdateBatch()
+	itr := db.GetIterator(nil, nil)
+	defer itr.Release()
+	for itr.Next() {
+		historyKey := append([]byte{}, itr.Key()...)
+		if len(historyKey) < 2 || historyKey[0] == keyFormatKey[0] {
+			// the savepoint and the records of the key format
+			continue
+		}
+		ns, key, blockNum, tranNum, keyFormat, err := historydb.DecodeCompositeHistoryKey(historyKey)
+		if err != nil {
+			return migrated, err
+		}
+		if keyFormat == format {
+			continue
+		}
+		dbBatch.Delete(historyKey)
+		dbBatch.Put(historydb.ConstructCompositeHistoryKeyInFormat(format, ns, key, blockNum, tranNum), append([]byte{}, itr.Value()...))
+		migrated++
+		if migrated%maxKeyFormatMigrationBatchSize == 0 {
+			if err := db.WriteBatch(dbBatch, false); err != nil {
+				return migrated, err
+			}
+			dbBatch = leveldbhelper.NewUpdateBatch()
+			dbLogger.Debugf("Migrated [%d] history keys", migrated)
+		}
+	}
+	if err := itr.Error(); err != nil {
+		return migrated, err
+	}
+	dbBatch.Put(keyFormatKey, []byte(format))
+	dbBatch.Delete(keyFormatMigrationKey)
+	if err := db.WriteBatch(dbBatch, true); err != nil {
+		return migrated, err
+	}
+	dbLogger.Infof("Migrated [%d] history keys to format [%s]", migrated, format)
+	return migrated, nil
+}
+
+// recordedKeyFormat returns the format of the History Keys of the database. A database that records no format either
+// predates the recording of the formats, and holds KeyFormatV1 keys, or has not been committed to yet, in which case
+// it holds no savepoint and the empty string is returned
+func recordedKeyFormat(db *leveldbhelper.DBHandle) (string, error) {
+	format, err := db.Get(keyFormatKey)
+	if err != nil || format != nil {
+		return string(format), err
+	}
+	savepoint, err := db.Get(savePointKey)
+	if err != nil || savepoint == nil {
+		return "", err
+	}
+	return historydb.KeyFormatV1, nil
 }
 
 // Close closes the underlying db
@@ -62,11 +227,54 @@ func (provider *HistoryDBProvider) Close() {
 type historyDB struct {
 	db     *leveldbhelper.DBHandle
 	dbName string
+	// queryAdmission limits the history queries that run concurrently on the channel
+	queryAdmission *lutils.QueryAdmission
+	// keyFormat is the format of the History Keys, which is recorded with the first commit to the database
+	keyFormat         string
+	keyFormatRecorded bool
 }
 
 // newHistoryDB constructs an instance of HistoryDB
 func newHistoryDB(db *leveldbhelper.DBHandle, dbName string) *historyDB {
-	return &historyDB{db, dbName}
+	queryAdmission := lutils.NewQueryAdmission("history queries", ledgerconfig.GetHistoryQueryConcurrencyLimit(),
+		ledgerconfig.GetQueryAdmissionQueueSize(), ledgerconfig.GetQueryAdmissionQueueTimeout())
+	return &historyDB{db: db, dbName: dbName, queryAdmission: queryAdmission}
+}
+
+// loadKeyFormat negotiates the format of the History Keys of the database. The existing keys are read and written in their
+// recorded format regardless of the configuration, until the database is migrated to the configured format, and a new
+// database takes the configured format
+func (historyDB *historyDB) loadKeyFormat() error {
+	dbLogger := logger.With(flogging.Fields{"channel": historyDB.dbName})
+	migratingTo, err := historyDB.db.Get(keyFormatMigrationKey)
+	if err != nil {
+		return err
+	}
+	if migratingTo != nil {
+		return fmt.Errorf("Migration of the history keys of channel [%s] to format [%s] has not completed, it must be run again", historyDB.dbName, migratingTo)
+	}
+	format, err := recordedKeyFormat(historyDB.db)
+	if err != nil {
+		return err
+	}
+	configured := ledgerconfig.GetHistoryKeyFormat()
+	if !historydb.IsKnownKeyFormat(configured) {
+		return fmt.Errorf("Unknown history key format [%s] configured in ledger.state.historyKeyFormat", configured)
+	}
+	switch {
+	case format == "":
+		historyDB.keyFormat = configured
+	case historydb.IsKnownKeyFormat(format):
+		historyDB.keyFormat = format
+		historyDB.keyFormatRecorded = true
+	default:
+		return fmt.Errorf("History database of channel [%s] holds keys in the unknown format [%s]", historyDB.dbName, format)
+	}
+	if historyDB.keyFormat != configured {
+		dbLogger.Warningf("History keys are in format [%s] while format [%s] is configured. The keys are kept in format [%s] until the history database is migrated",
+			historyDB.keyFormat, configured, historyDB.keyFormat)
+	}
+	return nil
 }
 
 // Open implements method in HistoryDB interface
@@ -89,8 +297,12 @@ func (historyDB *historyDB) Commit(block *common.Block) error {
 
 	dbBatch := leveldbhelper.NewUpdateBatch()
 
-	logger.Debugf("Channel [%s]: Updating history database for blockNo [%v] with [%d] transactions",
-		historyDB.dbName, blockNo, len(block.Data.Data))
+	blockLogger := logger.With(flogging.Fields{"channel": historyDB.dbName, "block": blockNo})
+	blockLogger.Debugf("Updating history database with [%d] transactions", len(block.Data.Data))
+	derivedDeletes, err := lutils.GetDerivedDeletes(block)
+	if err != nil {
+		return err
+	}
 
 	//TODO add check for invalid trans in bit array
 	for _, envBytes := range block.Data.Data {
@@ -120,47 +332,78 @@ func (historyDB *historyDB) Commit(block *common.Block) error {
 			}
 
 			//preparation for extracting RWSet from transaction
-			txRWSet := &rwset.TxReadWriteSet{}
+			txRWSet := rwset.AcquireTxReadWriteSet()
 
 			// Get the Result from the Action and then Unmarshal
 			// it into a TxReadWriteSet using custom unmarshalling
 			if err = txRWSet.Unmarshal(respPayload.Results); err != nil {
+				txRWSet.Release()
 				return err
 			}
 			// for each transaction, loop through the namespaces and writesets
 			// and add a history record for each write
 			for _, nsRWSet := range txRWSet.NsRWs {
 				ns := nsRWSet.NameSpace
+				// the time-to-live of the keys is not a write to the state
+				if _, ok := lutils.SplitTTLNs(ns); ok {
+					continue
+				}
 
 				for _, kvWrite := range nsRWSet.Writes {
 					writeKey := kvWrite.Key
 
 					//composite key for history records is in the form ns~key~blockNo~tranNo
-					compositeHistoryKey := historydb.ConstructCompositeHistoryKey(ns, writeKey, blockNo, tranNo)
+					compositeHistoryKey := historydb.ConstructCompositeHistoryKeyInFormat(historyDB.keyFormat, ns, writeKey, blockNo, tranNo)
 
 					// No value is required, write an empty byte array (emptyValue) since Put() of nil is not allowed
 					dbBatch.Put(compositeHistoryKey, emptyValue)
 				}
 			}
+			txRWSet.Release()
 
 		} else {
-			logger.Debugf("Skipping transaction [%d] since it is not an endorsement transaction\n", tranNo)
+			blockLogger.With(flogging.Fields{"tx": chdr.TxId}).Debugf("Skipping transaction [%d] since it is not an endorsement transaction", tranNo)
+		}
+	}
+
+	// add a history record for each delete of a key in a range deleted by a transaction or of a key that expires.
+	// The record of an expiry takes the transaction number that follows the transactions of the block
+	for _, d := range derivedDeletes {
+		value := rangeDeleteValue
+		if d.IsExpiry(len(block.Data.Data)) {
+			value = expiryValue
 		}
+		dbBatch.Put(historydb.ConstructCompositeHistoryKeyInFormat(historyDB.keyFormat, d.Namespace, d.Key, blockNo, d.TxNum), value)
 	}
 
 	// add savepoint for recovery purpose
 	height := version.NewHeight(blockNo, tranNo)
 	dbBatch.Put(savePointKey, height.ToBytes())
+	if !historyDB.keyFormatRecorded {
+		dbBatch.Put(keyFormatKey, []byte(historyDB.keyFormat))
+	}
 
-	// write the block's history records and savepoint to LevelDB
+	// write the block's history records and savepoint to LevelDB in a single batch, which is flushed
+	// to the disk along with the other writes by the sync policy of the ledger rather than per block
 	if err := historyDB.db.WriteBatch(dbBatch, false); err != nil {
 		return err
 	}
+	historyDB.keyFormatRecorded = true
+	observeBatchSize(historyDB.dbName, dbBatch)
 
-	logger.Debugf("Channel [%s]: Updates committed to history database for blockNo [%v]", historyDB.dbName, blockNo)
+	blockLogger.Debug("Updates committed to history database")
 	return nil
 }
 
+func observeBatchSize(dbName string, dbBatch *leveldbhelper.UpdateBatch) {
+	numBytes := 0
+	for key, value := range dbBatch.KVs {
+		numBytes += len(key) + len(value)
+	}
+	batchEntries.Observe(float64(len(dbBatch.KVs)), dbName)
+	batchBytes.Observe(float64(numBytes), dbName)
+}
+
 // NewHistoryQueryExecutor implements method in HistoryDB interface
 func (historyDB *historyDB) NewHistoryQueryExecutor(blockStore blkstorage.BlockStore) (ledger.HistoryQueryExecutor, error) {
 	return &LevelHistoryDBQueryExecutor{historyDB, blockStore}, nil
@@ -176,11 +419,21 @@ func (historyDB *historyDB) GetLastSavepoint() (*version.Height, error) {
 	return height, nil
 }
 
+// MarkStartingSavepoint implements method in HistoryDB interface.
+// This is used for a ledger created from a snapshot, for which the history starts after the snapshot height
+func (historyDB *historyDB) MarkStartingSavepoint(savepoint *version.Height) error {
+	dbBatch := leveldbhelper.NewUpdateBatch()
+	dbBatch.Put(savePointKey, savepoint.ToBytes())
+	dbBatch.Put(keyFormatKey, []byte(historyDB.keyFormat))
+	if err := historyDB.db.WriteBatch(dbBatch, true); err != nil {
+		return err
+	}
+	historyDB.keyFormatRecorded = true
+	return nil
+}
+
 // ShouldRecover implements method in interface kvledger.Recoverer
 func (historyDB *historyDB) ShouldRecover(lastAvailableBlock uint64) (bool, uint64, error) {
-	if !ledgerconfig.IsHistoryDBEnabled() {
-		return false, 0, nil
-	}
 	savepoint, err := historyDB.GetLastSavepoint()
 	if err != nil {
 		return false, 0, err
@@ -198,3 +451,20 @@ func (historyDB *historyDB) CommitLostBlock(block *common.Block) error {
 	}
 	return nil
 }
+
+// GetDiskUsage implements method in HistoryDB interface. The size is approximated as the
+// history dbs of all the channels are stored in a single leveldb
+func (historyDB *historyDB) GetDiskUsage() (int64, error) {
+	return historyDB.db.ApproximateSize()
+}
+
+// Sync implements method in HistoryDB interface
+func (historyDB *historyDB) Sync() error {
+	return historyDB.db.Sync()
+}
+
+// Compact implements method in HistoryDB interface. Only the keys of the channel are compacted
+// as the history dbs of all the channels are stored in a single leveldb
+func (historyDB *historyDB) Compact() error {
+	return historyDB.db.Compact()
+}
@@ -17,15 +17,19 @@ limitations under the License.
 package historyleveldb
 
 import (
-	"errors"
+	"bytes"
+	"fmt"
+	"math"
 
+	"github.com/hyperledger/fabric/common/flogging"
 	commonledger "github.com/hyperledger/fabric/common/ledger"
 	"github.com/hyperledger/fabric/common/ledger/blkstorage"
-	"github.com/hyperledger/fabric/common/ledger/util"
+	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
 	"github.com/hyperledger/fabric/core/ledger"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/history/historydb"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/rwset"
 	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+	lutils "github.com/hyperledger/fabric/core/ledger/util"
 	"github.com/hyperledger/fabric/protos/common"
 	putils "github.com/hyperledger/fabric/protos/utils"
 	"github.com/syndtr/goleveldb/leveldb/iterator"
@@ -39,19 +43,193 @@ type LevelHistoryDBQueryExecutor struct {
 
 // GetHistoryForKey implements method in interface `ledger.HistoryQueryExecutor`
 func (q *LevelHistoryDBQueryExecutor) GetHistoryForKey(namespace string, key string) (commonledger.ResultsIterator, error) {
+	permit, err := q.historyDB.queryAdmission.Admit()
+	if err != nil {
+		return nil, err
+	}
+
+	// the history is returned from the oldest modification, hence the keys in KeyFormatV2 are read backwards
+	return q.scanHistory("GetHistoryForKey", namespace, key, q.historyDB.keyFormat == historydb.KeyFormatV2, 0, permit), nil
+}
+
+// GetRecentHistoryForKey implements method in interface `ledger.HistoryQueryExecutor`. The keys in KeyFormatV2 are read
+// in their order, whereas the keys in KeyFormatV1 are read backwards, which is slower for the keys with a long history
+func (q *LevelHistoryDBQueryExecutor) GetRecentHistoryForKey(namespace string, key string, limit int) (commonledger.ResultsIterator, error) {
+	permit, err := q.historyDB.queryAdmission.Admit()
+	if err != nil {
+		return nil, err
+	}
+	return q.scanHistory("GetRecentHistoryForKey", namespace, key, q.historyDB.keyFormat == historydb.KeyFormatV1, limit, permit), nil
+}
+
+// GetHistoryForKeyWithOptions implements method in interface `ledger.HistoryQueryExecutor`. The blocks of the options are
+// mapped to a range of the history keys, so the records of the other blocks are not read. A nil options returns the
+// history as GetHistoryForKey does
+func (q *LevelHistoryDBQueryExecutor) GetHistoryForKeyWithOptions(namespace string, key string,
+	options *ledger.HistoryQueryOptions) (commonledger.ResultsIterator, error) {
+	if options == nil {
+		options = &ledger.HistoryQueryOptions{IncludeDeletes: true}
+	}
+	if options.EndBlock != 0 && options.EndBlock < options.StartBlock {
+		return nil, fmt.Errorf("End block [%d] of the history query is below the start block [%d]", options.EndBlock, options.StartBlock)
+	}
+	permit, err := q.historyDB.queryAdmission.Admit()
+	if err != nil {
+		return nil, err
+	}
+
+	// the transaction numbers start at 1, hence the key of a block number and the transaction 0 separates the records
+	// of the blocks below the number from the others, which follow it in KeyFormatV1 and precede it in KeyFormatV2
+	var fromKey, toKey []byte
+	if options.StartBlock > 0 {
+		fromKey = historydb.ConstructCompositeHistoryKeyInFormat(q.historyDB.keyFormat, namespace, key, options.StartBlock, 0)
+	}
+	if options.EndBlock > 0 && options.EndBlock < math.MaxUint64 {
+		toKey = historydb.ConstructCompositeHistoryKeyInFormat(q.historyDB.keyFormat, namespace, key, options.EndBlock+1, 0)
+	}
+	// the keys in KeyFormatV2 are ordered from the latest modification, hence they are read backwards
+	// to return the history from the oldest modification
+	reverse := options.Reverse
+	if q.historyDB.keyFormat == historydb.KeyFormatV2 {
+		fromKey, toKey = toKey, fromKey
+		reverse = !reverse
+	}
+	startKey := historydb.ConstructPartialCompositeHistoryKey(namespace, key, false)
+	endKey := historydb.ConstructPartialCompositeHistoryKey(namespace, key, true)
+	if fromKey != nil {
+		startKey = fromKey
+	}
+	if toKey != nil {
+		endKey = toKey
+	}
+	scanner := q.scanHistoryRange("GetHistoryForKeyWithOptions", namespace, key, startKey, endKey, reverse, options.Limit, permit)
+	scanner.skipDeletes = !options.IncludeDeletes
+	return scanner, nil
+}
+
+// scanHistory returns a scanner over the history records of the key, which reads the records backwards if reverse is set
+// and stops after the given number of records if the limit is positive
+func (q *LevelHistoryDBQueryExecutor) scanHistory(queryName string, namespace string, key string, reverse bool, limit int,
+	permit *lutils.QueryPermit) *historyScanner {
+	compositeStartKey := historydb.ConstructPartialCompositeHistoryKey(namespace, key, false)
+	compositeEndKey := historydb.ConstructPartialCompositeHistoryKey(namespace, key, true)
+	return q.scanHistoryRange(queryName, namespace, key, compositeStartKey, compositeEndKey, reverse, limit, permit)
+}
 
-	if ledgerconfig.IsHistoryDBEnabled() == false {
-		return nil, errors.New("History tracking not enabled - historyDatabase is false")
+// scanHistoryRange returns a scanner over the history records of the key between the given history keys
+func (q *LevelHistoryDBQueryExecutor) scanHistoryRange(queryName string, namespace string, key string, startKey []byte, endKey []byte,
+	reverse bool, limit int, permit *lutils.QueryPermit) *historyScanner {
+	// range scan to find the history records starting with namespace~key
+	dbItr := q.historyDB.db.GetIterator(startKey, endKey)
+	scanner := newHistoryScanner(historydb.ConstructPartialCompositeHistoryKey(namespace, key, false), namespace, key, dbItr, q.blockStore)
+	scanner.reverse = reverse
+	scanner.limit = limit
+	scanner.permit = permit
+	scanner.slowQueryTimer = lutils.StartSlowQueryTimer(queryName, ledgerconfig.GetSlowQueryThreshold(),
+		flogging.Fields{"channel": q.historyDB.dbName, "namespace": namespace, "keyHash": lutils.KeyHashForLog(key)})
+	scanner.memoryBudget = lutils.StartQueryMemoryBudget(queryName, ledgerconfig.GetQueryMemoryBudget(), ledgerconfig.GetGlobalQueryMemoryBudget())
+	return scanner
+}
+
+// GetStateAsOf implements method in interface `ledger.HistoryQueryExecutor`.
+// The history records of the key are ordered by the block number and the transaction number, so the value at the
+// height is that of the last record below the height that belongs to a valid transaction, which is the first such
+// record for the keys in KeyFormatV2
+func (q *LevelHistoryDBQueryExecutor) GetStateAsOf(namespace string, key string, blockHeight uint64) ([]byte, error) {
+	permit, err := q.historyDB.queryAdmission.Admit()
+	if err != nil {
+		return nil, err
+	}
+	defer permit.Release()
+	if blockHeight == 0 {
+		return nil, nil
+	}
+	if err := q.checkHistoryHeight(blockHeight); err != nil {
+		return nil, err
 	}
 
-	var compositeStartKey []byte
-	var compositeEndKey []byte
-	compositeStartKey = historydb.ConstructPartialCompositeHistoryKey(namespace, key, false)
-	compositeEndKey = historydb.ConstructPartialCompositeHistoryKey(namespace, key, true)
+	keyLogger := logger.With(flogging.Fields{"channel": q.historyDB.dbName, "namespace": namespace, "keyHash": lutils.KeyHashForLog(key)})
+	compositePartialKey := historydb.ConstructPartialCompositeHistoryKey(namespace, key, false)
+	// the transaction numbers start at 1, hence the records of the blocks below the height end before the transaction 0
+	// of the height in KeyFormatV1 and start after it in KeyFormatV2
+	heightKey := historydb.ConstructCompositeHistoryKeyInFormat(q.historyDB.keyFormat, namespace, key, blockHeight, 0)
+	var dbItr *leveldbhelper.Iterator
+	var first, next func() bool
+	if q.historyDB.keyFormat == historydb.KeyFormatV2 {
+		dbItr = q.historyDB.db.GetIterator(heightKey, historydb.ConstructPartialCompositeHistoryKey(namespace, key, true))
+		first, next = dbItr.First, dbItr.Next
+	} else {
+		dbItr = q.historyDB.db.GetIterator(compositePartialKey, heightKey)
+		first, next = dbItr.Last, dbItr.Prev
+	}
+	defer dbItr.Release()
+	var txsFilter lutils.TxValidationFlags
+	filterBlockNum := uint64(math.MaxUint64)
+	for ok := first(); ok; ok = next() {
+		_, blockNumTranNumBytes := historydb.SplitCompositeHistoryKey(dbItr.Key(), compositePartialKey)
+		blockNum, tranNum, _ := historydb.DecodeHistoryKeyHeight(blockNumTranNumBytes)
+		// the history database records the writes of the invalid transactions as well
+		if blockNum != filterBlockNum {
+			block, err := q.blockStore.RetrieveBlockByNumber(blockNum)
+			if err != nil {
+				return nil, err
+			}
+			txsFilter = lutils.TxValidationFlags(block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER])
+			filterBlockNum = blockNum
+		}
+		if int(tranNum) <= len(txsFilter) && txsFilter.IsInvalid(int(tranNum-1)) {
+			keyLogger.With(flogging.Fields{"block": blockNum}).Debugf("Skipping history record of invalid transaction number [%d]", tranNum)
+			continue
+		}
+		if isDerivedDelete(dbItr.Value()) {
+			keyLogger.With(flogging.Fields{"block": blockNum}).Debugf("Found the key deleted as of height [%d] by a range delete or an expiry", blockHeight)
+			return nil, nil
+		}
+		tranEnvelope, err := q.blockStore.RetrieveTxByBlockNumTranNum(blockNum, tranNum)
+		if err != nil {
+			return nil, err
+		}
+		txID, value, err := getTxIDandKeyWriteValueFromTran(tranEnvelope, namespace, key)
+		if err != nil {
+			return nil, err
+		}
+		keyLogger.With(flogging.Fields{"block": blockNum, "tx": txID}).Debugf("Found the value as of height [%d]", blockHeight)
+		return value, nil
+	}
+	if err := dbItr.Error(); err != nil {
+		return nil, err
+	}
+	// the history of a ledger created from a snapshot starts after the snapshot,
+	// hence a key written only before the snapshot has no record
+	snapshotInfo, err := q.blockStore.GetSnapshotInfo()
+	if err != nil {
+		return nil, err
+	}
+	if snapshotInfo != nil {
+		return nil, &ledger.NotFoundError{Msg: fmt.Sprintf("The value of the key as of height [%d] is not known as the ledger is created from a snapshot at height [%d] and the key is not written since",
+			blockHeight, snapshotInfo.LastBlockNum+1)}
+	}
+	return nil, nil
+}
 
-	// range scan to find any history records starting with namespace~key
-	dbItr := q.historyDB.db.GetIterator(compositeStartKey, compositeEndKey)
-	return newHistoryScanner(compositeStartKey, namespace, key, dbItr, q.blockStore), nil
+// checkHistoryHeight returns an error if the history database has not reached the given height,
+// either because the height is above the height of the ledger or because the history database lags behind
+func (q *LevelHistoryDBQueryExecutor) checkHistoryHeight(blockHeight uint64) error {
+	savepoint, err := q.historyDB.GetLastSavepoint()
+	if err != nil {
+		return err
+	}
+	if savepoint != nil && savepoint.BlockNum+1 >= blockHeight {
+		return nil
+	}
+	info, err := q.blockStore.GetBlockchainInfo()
+	if err != nil {
+		return err
+	}
+	if blockHeight > info.Height {
+		return &ledger.NotFoundError{Msg: fmt.Sprintf("Block height [%d] is above the ledger height [%d]", blockHeight, info.Height)}
+	}
+	return &ledger.UnavailableError{Msg: fmt.Sprintf("History database has not reached block height [%d] yet", blockHeight)}
 }
 
 //historyScanner implements ResultsIterator for iterating through history results
@@ -61,25 +239,73 @@ type historyScanner struct {
 	key                 string
 	dbItr               iterator.Iterator
 	blockStore          blkstorage.BlockStore
+	slowQueryTimer      *lutils.SlowQueryTimer
+	memoryBudget        *lutils.QueryMemoryBudget
+	permit              *lutils.QueryPermit
+	// reverse is set to read the records backwards and limit, if positive, is the number of the records to return
+	reverse  bool
+	limit    int
+	started  bool
+	returned int
+	// skipDeletes is set to skip the records of the deletes of the key
+	skipDeletes bool
 }
 
 func newHistoryScanner(compositePartialKey []byte, namespace string, key string,
 	dbItr iterator.Iterator, blockStore blkstorage.BlockStore) *historyScanner {
-	return &historyScanner{compositePartialKey, namespace, key, dbItr, blockStore}
+	return &historyScanner{compositePartialKey: compositePartialKey, namespace: namespace, key: key, dbItr: dbItr, blockStore: blockStore}
 }
 
 func (scanner *historyScanner) Next() (commonledger.QueryResult, error) {
-	if !scanner.dbItr.Next() {
+	if scanner.limit > 0 && scanner.returned >= scanner.limit {
+		return nil, nil
+	}
+	for {
+		modification, err := scanner.nextModification()
+		if err != nil || modification == nil {
+			return nil, err
+		}
+		// the value of a delete is nil
+		if scanner.skipDeletes && modification.Value == nil {
+			continue
+		}
+		if err := scanner.memoryBudget.Charge(len(modification.TxID) + len(modification.Value)); err != nil {
+			return nil, err
+		}
+		scanner.returned++
+		scanner.slowQueryTimer.ResultReturned()
+		return modification, nil
+	}
+}
+
+// nextModification reads the next history record and retrieves the modification of the key from the block storage
+func (scanner *historyScanner) nextModification() (*ledger.KeyModification, error) {
+	var ok bool
+	switch {
+	case !scanner.reverse:
+		ok = scanner.dbItr.Next()
+	case !scanner.started:
+		ok = scanner.dbItr.Last()
+	default:
+		ok = scanner.dbItr.Prev()
+	}
+	scanner.started = true
+	if !ok {
 		return nil, nil
 	}
 	historyKey := scanner.dbItr.Key() // history key is in the form namespace~key~blocknum~trannum
 
 	// SplitCompositeKey(namespace~key~blocknum~trannum, namespace~key~) will return the blocknum~trannum in second position
 	_, blockNumTranNumBytes := historydb.SplitCompositeHistoryKey(historyKey, scanner.compositePartialKey)
-	blockNum, bytesConsumed := util.DecodeOrderPreservingVarUint64(blockNumTranNumBytes[0:])
-	tranNum, _ := util.DecodeOrderPreservingVarUint64(blockNumTranNumBytes[bytesConsumed:])
-	logger.Debugf("Found history record for namespace:%s key:%s at blockNumTranNum %v:%v\n",
-		scanner.namespace, scanner.key, blockNum, tranNum)
+	blockNum, tranNum, _ := historydb.DecodeHistoryKeyHeight(blockNumTranNumBytes)
+	keyLogger := logger.With(flogging.Fields{"namespace": scanner.namespace, "keyHash": lutils.KeyHashForLog(scanner.key)})
+	keyLogger.With(flogging.Fields{"block": blockNum}).Debugf("Found history record at transaction number [%d]", tranNum)
+
+	// an expiry does not belong to a transaction
+	if bytes.Equal(scanner.dbItr.Value(), expiryValue) {
+		keyLogger.With(flogging.Fields{"block": blockNum}).Debug("Found historic key expiry")
+		return &ledger.KeyModification{}, nil
+	}
 
 	// Get the transaction from block storage that is associated with this history record
 	tranEnvelope, err := scanner.blockStore.RetrieveTxByBlockNumTranNum(blockNum, tranNum)
@@ -87,24 +313,54 @@ func (scanner *historyScanner) Next() (commonledger.QueryResult, error) {
 		return nil, err
 	}
 
+	// a key in a range deleted by the transaction is not in the write-set of the transaction
+	if bytes.Equal(scanner.dbItr.Value(), rangeDeleteValue) {
+		txID, err := getTxIDFromTran(tranEnvelope)
+		if err != nil {
+			return nil, err
+		}
+		keyLogger.With(flogging.Fields{"block": blockNum, "tx": txID}).Debug("Found historic key range delete")
+		return &ledger.KeyModification{TxID: txID}, nil
+	}
+
 	// Get the txid and key write value associated with this transaction
 	txID, keyValue, err := getTxIDandKeyWriteValueFromTran(tranEnvelope, scanner.namespace, scanner.key)
 	if err != nil {
 		return nil, err
 	}
-	logger.Debugf("Found historic key value for namespace:%s key:%s from transaction %s\n",
-		scanner.namespace, scanner.key, txID)
+	keyLogger.With(flogging.Fields{"block": blockNum, "tx": txID}).Debug("Found historic key value")
 	return &ledger.KeyModification{TxID: txID, Value: keyValue}, nil
 }
 
 func (scanner *historyScanner) Close() {
+	scanner.slowQueryTimer.Stop()
+	scanner.memoryBudget.Release()
 	scanner.dbItr.Release()
+	scanner.permit.Release()
+}
+
+// isDerivedDelete tells whether the value of a history record marks a range delete or an expiry
+func isDerivedDelete(historyValue []byte) bool {
+	return bytes.Equal(historyValue, rangeDeleteValue) || bytes.Equal(historyValue, expiryValue)
+}
+
+// getTxIDFromTran returns the id of a transaction
+func getTxIDFromTran(tranEnvelope *common.Envelope) (string, error) {
+	payload, err := putils.GetPayload(tranEnvelope)
+	if err != nil {
+		return "", err
+	}
+	chdr, err := putils.UnmarshalChannelHeader(payload.Header.ChannelHeader)
+	if err != nil {
+		return "", err
+	}
+	return chdr.TxId, nil
 }
 
 // getTxIDandKeyWriteValueFromTran inspects a transaction for writes to a given key
 func getTxIDandKeyWriteValueFromTran(
 	tranEnvelope *common.Envelope, namespace string, key string) (string, []byte, error) {
-	logger.Debugf("Entering getTxIDandKeyWriteValueFromTran()\n", namespace, key)
+	logger.With(flogging.Fields{"namespace": namespace, "keyHash": lutils.KeyHashForLog(key)}).Debug("Entering getTxIDandKeyWriteValueFromTran()")
 
 	// extract action from the envelope
 	payload, err := putils.GetPayload(tranEnvelope)
@@ -129,7 +385,8 @@ func getTxIDandKeyWriteValueFromTran(
 
 	txID := chdr.TxId
 
-	txRWSet := &rwset.TxReadWriteSet{}
+	txRWSet := rwset.AcquireTxReadWriteSet()
+	defer txRWSet.Release()
 
 	// Get the Result from the Action and then Unmarshal
 	// it into a TxReadWriteSet using custom unmarshalling
@@ -146,9 +403,9 @@ func getTxIDandKeyWriteValueFromTran(
 					return txID, kvWrite.Value, nil
 				}
 			} // end keys loop
-			return txID, nil, errors.New("Key not found in namespace's writeset")
+			return txID, nil, &ledger.CorruptionError{Msg: "Key not found in namespace's writeset"}
 		} // end if
 	} //end namespaces loop
-	return txID, nil, errors.New("Namespace not found in transaction's ReadWriteSets")
+	return txID, nil, &ledger.CorruptionError{Msg: "Namespace not found in transaction's ReadWriteSets"}
 
 }
@@ -17,13 +17,23 @@ limitations under the License.
 package historyleveldb
 
 import (
+	"math"
+	"net/http/httptest"
 	"os"
 	"strconv"
+	"strings"
 	"testing"
 
 	configtxtest "github.com/hyperledger/fabric/common/configtx/test"
+	commonledger "github.com/hyperledger/fabric/common/ledger"
+	"github.com/hyperledger/fabric/common/ledger/blkstorage"
 	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/common/metrics"
 	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/history/historydb"
+	lutils "github.com/hyperledger/fabric/core/ledger/util"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/peer"
 	"github.com/spf13/viper"
 )
 
@@ -124,20 +134,155 @@ func TestHistory(t *testing.T) {
 	testutil.AssertEquals(t, count, 3)
 }
 
-//TestSavepoint tests that save points get written after each block and get returned via GetBlockNumfromSavepoint
-func TestHistoryDisabled(t *testing.T) {
+func TestGetStateAsOf(t *testing.T) {
+	for _, format := range []string{historydb.KeyFormatV1, historydb.KeyFormatV2} {
+		t.Run(format, func(t *testing.T) { testGetStateAsOf(t, format) })
+	}
+}
+
+func testGetStateAsOf(t *testing.T, format string) {
+	viper.Set("ledger.state.historyKeyFormat", format)
+	defer viper.Set("ledger.state.historyKeyFormat", "")
+	env := NewTestHistoryEnv(t)
+	defer env.cleanup()
+	store1, err := env.testBlockStorageEnv.provider.OpenBlockStore("ledger1")
+	testutil.AssertNoError(t, err, "Error upon provider.OpenBlockStore()")
+	defer store1.Shutdown()
+	bg := testutil.NewBlockGenerator(t)
+	commitBlock := func(invalidTx int, writes ...func(simulator ledger.TxSimulator)) {
+		var simulationResults [][]byte
+		for _, write := range writes {
+			simulator, _ := env.txmgr.NewTxSimulator()
+			write(simulator)
+			simulator.Done()
+			simRes, _ := simulator.GetTxSimulationResults()
+			simulationResults = append(simulationResults, simRes)
+		}
+		block := bg.NextBlock(simulationResults, false)
+		if invalidTx >= 0 {
+			txsFilter := lutils.TxValidationFlags(block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER])
+			txsFilter.SetFlag(invalidTx, peer.TxValidationCode_MVCC_READ_CONFLICT)
+		}
+		testutil.AssertNoError(t, store1.AddBlock(block), "")
+		testutil.AssertNoError(t, env.testHistoryDB.Commit(block), "")
+	}
+	setValue := func(value string) func(simulator ledger.TxSimulator) {
+		return func(simulator ledger.TxSimulator) { simulator.SetState("ns1", "key7", []byte(value)) }
+	}
+
+	commitBlock(-1, setValue("value1"))
+	// the write of the invalid transaction is skipped
+	commitBlock(1, setValue("value2"), setValue("value3"))
+	commitBlock(-1, func(simulator ledger.TxSimulator) { simulator.DeleteState("ns1", "key7") })
+	commitBlock(-1, setValue("value4"))
+
+	qhistory, err := env.testHistoryDB.NewHistoryQueryExecutor(store1)
+	testutil.AssertNoError(t, err, "Error upon NewHistoryQueryExecutor")
+	for height, expectedValue := range [][]byte{nil, []byte("value1"), []byte("value2"), nil, []byte("value4")} {
+		value, err := qhistory.GetStateAsOf("ns1", "key7", uint64(height))
+		testutil.AssertNoError(t, err, "")
+		testutil.AssertEquals(t, value, expectedValue)
+	}
+	value, err := qhistory.GetStateAsOf("ns1", "key8", 4)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertNil(t, value)
+
+	_, err = qhistory.GetStateAsOf("ns1", "key7", 5)
+	_, ok := err.(*ledger.NotFoundError)
+	testutil.AssertEquals(t, ok, true)
+}
+
+func TestGetHistoryForKeyWithOptions(t *testing.T) {
+	for _, format := range []string{historydb.KeyFormatV1, historydb.KeyFormatV2} {
+		t.Run(format, func(t *testing.T) { testGetHistoryForKeyWithOptions(t, format) })
+	}
+}
 
+func testGetHistoryForKeyWithOptions(t *testing.T, format string) {
+	viper.Set("ledger.state.historyKeyFormat", format)
+	defer viper.Set("ledger.state.historyKeyFormat", "")
 	env := NewTestHistoryEnv(t)
 	defer env.cleanup()
+	store1, err := env.testBlockStorageEnv.provider.OpenBlockStore("ledger1")
+	testutil.AssertNoError(t, err, "Error upon provider.OpenBlockStore()")
+	defer store1.Shutdown()
+	bg := testutil.NewBlockGenerator(t)
+	// each block commits a transaction per value, and an empty value deletes the key
+	commitBlock := func(values ...string) {
+		var simulationResults [][]byte
+		for _, value := range values {
+			simulator, _ := env.txmgr.NewTxSimulator()
+			if value == "" {
+				simulator.DeleteState("ns1", "key7")
+			} else {
+				simulator.SetState("ns1", "key7", []byte(value))
+			}
+			simulator.Done()
+			simRes, _ := simulator.GetTxSimulationResults()
+			simulationResults = append(simulationResults, simRes)
+		}
+		block := bg.NextBlock(simulationResults, false)
+		testutil.AssertNoError(t, store1.AddBlock(block), "")
+		testutil.AssertNoError(t, env.testHistoryDB.Commit(block), "")
+	}
+	commitBlock("value1")
+	commitBlock("value2", "value3")
+	commitBlock("")
+	commitBlock("value4")
+
+	qhistory, err := env.testHistoryDB.NewHistoryQueryExecutor(store1)
+	testutil.AssertNoError(t, err, "Error upon NewHistoryQueryExecutor")
+	for _, testCase := range []struct {
+		options        *ledger.HistoryQueryOptions
+		expectedValues []string
+	}{
+		{nil, []string{"value1", "value2", "value3", "", "value4"}},
+		{&ledger.HistoryQueryOptions{IncludeDeletes: true}, []string{"value1", "value2", "value3", "", "value4"}},
+		{&ledger.HistoryQueryOptions{}, []string{"value1", "value2", "value3", "value4"}},
+		{&ledger.HistoryQueryOptions{Reverse: true}, []string{"value4", "value3", "value2", "value1"}},
+		{&ledger.HistoryQueryOptions{StartBlock: 1, EndBlock: 2, IncludeDeletes: true}, []string{"value2", "value3", ""}},
+		{&ledger.HistoryQueryOptions{StartBlock: 1, EndBlock: 1, Reverse: true}, []string{"value3", "value2"}},
+		{&ledger.HistoryQueryOptions{StartBlock: 1, Limit: 2}, []string{"value2", "value3"}},
+		{&ledger.HistoryQueryOptions{StartBlock: 1, Limit: 1, Reverse: true}, []string{"value4"}},
+		{&ledger.HistoryQueryOptions{EndBlock: math.MaxUint64, Limit: 2, Reverse: true}, []string{"value4", "value3"}},
+		{&ledger.HistoryQueryOptions{StartBlock: 4}, nil},
+	} {
+		itr, err := qhistory.GetHistoryForKeyWithOptions("ns1", "key7", testCase.options)
+		testutil.AssertNoError(t, err, "")
+		testutil.AssertEquals(t, historyValues(t, itr), testCase.expectedValues)
+	}
 
-	viper.Set("ledger.state.historyDatabase", "false")
+	_, err = qhistory.GetHistoryForKeyWithOptions("ns1", "key7", &ledger.HistoryQueryOptions{StartBlock: 2, EndBlock: 1})
+	testutil.AssertError(t, err, "Expected an error for an end block below the start block")
+}
+
+func TestHistoryQueryAdmission(t *testing.T) {
+	viper.Set("ledger.state.queryAdmission.historyQueries", 1)
+	defer viper.Set("ledger.state.queryAdmission.historyQueries", 0)
+	viper.Set("ledger.state.queryAdmission.queueSize", 0)
+	defer viper.Set("ledger.state.queryAdmission.queueSize", 100)
 
-	//no need to pass blockstore into history executore, it won't be used in this test
-	qhistory, err := env.testHistoryDB.NewHistoryQueryExecutor(nil)
+	env := NewTestHistoryEnv(t)
+	defer env.cleanup()
+	store1, err := env.testBlockStorageEnv.provider.OpenBlockStore("ledger1")
+	testutil.AssertNoError(t, err, "Error upon provider.OpenBlockStore()")
+	defer store1.Shutdown()
+
+	qhistory, err := env.testHistoryDB.NewHistoryQueryExecutor(store1)
 	testutil.AssertNoError(t, err, "Error upon NewHistoryQueryExecutor")
+	itr1, err := qhistory.GetHistoryForKey("ns1", "key1")
+	testutil.AssertNoError(t, err, "Error upon GetHistoryForKey()")
+
+	// a second history query is rejected while the first one is open
+	_, err = qhistory.GetHistoryForKey("ns1", "key2")
+	testutil.AssertError(t, err, "Expected the second history query to be rejected")
+	_, ok := err.(*ledger.ResourceExhaustedError)
+	testutil.AssertEquals(t, ok, true)
 
-	_, err2 := qhistory.GetHistoryForKey("ns1", "key7")
-	testutil.AssertError(t, err2, "Error should have been returned for GetHistoryForKey() when history disabled")
+	itr1.Close()
+	itr2, err := qhistory.GetHistoryForKey("ns1", "key2")
+	testutil.AssertNoError(t, err, "Error upon GetHistoryForKey() after the first query is closed")
+	itr2.Close()
 }
 
 //TestGenesisBlockNoError tests that Genesis blocks are ignored by history processing
@@ -153,3 +298,151 @@ func TestGenesisBlockNoError(t *testing.T) {
 	err = env.testHistoryDB.Commit(block)
 	testutil.AssertNoError(t, err, "")
 }
+
+func TestHistoryBatchMetrics(t *testing.T) {
+	env := NewTestHistoryEnv(t)
+	defer env.cleanup()
+	historyDB, err := env.testHistoryDBProvider.GetDBHandle("BatchMetricsDB")
+	testutil.AssertNoError(t, err, "")
+
+	bg := testutil.NewBlockGenerator(t)
+	var simulationResults [][]byte
+	for i := 0; i < 2; i++ {
+		simulator, _ := env.txmgr.NewTxSimulator()
+		simulator.SetState("ns1", "key1", []byte("value"))
+		simulator.SetState("ns1", "key"+strconv.Itoa(i+2), []byte("value"))
+		simulator.Done()
+		simRes, _ := simulator.GetTxSimulationResults()
+		simulationResults = append(simulationResults, simRes)
+	}
+	testutil.AssertNoError(t, historyDB.Commit(bg.NextBlock(simulationResults, false)), "")
+
+	// the four records of the block, the savepoint and the key format recorded by the first commit are written in a single batch
+	recorder := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	exposition := recorder.Body.String()
+	for _, expected := range []string{
+		`ledger_history_batch_entries_count{channel="BatchMetricsDB"} 1`,
+		`ledger_history_batch_entries_sum{channel="BatchMetricsDB"} 6`,
+		`ledger_history_batch_bytes_count{channel="BatchMetricsDB"} 1`,
+	} {
+		testutil.AssertEquals(t, strings.Contains(exposition, expected), true)
+	}
+}
+
+func TestHistoryKeyFormats(t *testing.T) {
+	for _, format := range []string{historydb.KeyFormatV1, historydb.KeyFormatV2} {
+		t.Run(format, func(t *testing.T) {
+			viper.Set("ledger.state.historyKeyFormat", format)
+			defer viper.Set("ledger.state.historyKeyFormat", "")
+			env := NewTestHistoryEnv(t)
+			defer env.cleanup()
+			store1, err := env.testBlockStorageEnv.provider.OpenBlockStore("ledger1")
+			testutil.AssertNoError(t, err, "")
+			defer store1.Shutdown()
+			commitHistory(t, env, env.testHistoryDB, store1, testutil.NewBlockGenerator(t), "value1", "value2", "value3")
+
+			qhistory, err := env.testHistoryDB.NewHistoryQueryExecutor(store1)
+			testutil.AssertNoError(t, err, "")
+			itr, err := qhistory.GetHistoryForKey("ns1", "key7")
+			testutil.AssertNoError(t, err, "")
+			testutil.AssertEquals(t, historyValues(t, itr), []string{"value1", "value2", "value3"})
+			itr, err = qhistory.GetRecentHistoryForKey("ns1", "key7", 0)
+			testutil.AssertNoError(t, err, "")
+			testutil.AssertEquals(t, historyValues(t, itr), []string{"value3", "value2", "value1"})
+			itr, err = qhistory.GetRecentHistoryForKey("ns1", "key7", 2)
+			testutil.AssertNoError(t, err, "")
+			testutil.AssertEquals(t, historyValues(t, itr), []string{"value3", "value2"})
+
+			recorded, migratingTo, err := env.testHistoryDBProvider.(*HistoryDBProvider).GetKeyFormat("TestHistoryDB")
+			testutil.AssertNoError(t, err, "")
+			testutil.AssertEquals(t, recorded, format)
+			testutil.AssertEquals(t, migratingTo, "")
+		})
+	}
+}
+
+func TestHistoryKeyFormatMigration(t *testing.T) {
+	env := NewTestHistoryEnv(t)
+	defer env.cleanup()
+	provider := env.testHistoryDBProvider.(*HistoryDBProvider)
+	store1, err := env.testBlockStorageEnv.provider.OpenBlockStore("ledger1")
+	testutil.AssertNoError(t, err, "")
+	defer store1.Shutdown()
+	bg := testutil.NewBlockGenerator(t)
+	commitHistory(t, env, env.testHistoryDB, store1, bg, "value1", "value2")
+
+	// the existing database keeps its format when another format is configured
+	viper.Set("ledger.state.historyKeyFormat", historydb.KeyFormatV2)
+	defer viper.Set("ledger.state.historyKeyFormat", "")
+	reopenedDB, err := provider.GetDBHandle("TestHistoryDB")
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, reopenedDB.(*historyDB).keyFormat, historydb.KeyFormatV1)
+
+	migrated, err := provider.MigrateKeyFormat("TestHistoryDB", historydb.KeyFormatV2)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, migrated, 2)
+	reopenedDB, err = provider.GetDBHandle("TestHistoryDB")
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, reopenedDB.(*historyDB).keyFormat, historydb.KeyFormatV2)
+	commitHistory(t, env, reopenedDB, store1, bg, "value3")
+	qhistory, err := reopenedDB.NewHistoryQueryExecutor(store1)
+	testutil.AssertNoError(t, err, "")
+	itr, err := qhistory.GetHistoryForKey("ns1", "key7")
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, historyValues(t, itr), []string{"value1", "value2", "value3"})
+	value, err := qhistory.GetStateAsOf("ns1", "key7", 2)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, value, []byte("value2"))
+
+	// a database is not opened while a migration is incomplete and the migration is completed by running it again
+	db := provider.dbProvider.GetDBHandle("TestHistoryDB")
+	testutil.AssertNoError(t, db.Put(keyFormatMigrationKey, []byte(historydb.KeyFormatV1), true), "")
+	_, err = provider.GetDBHandle("TestHistoryDB")
+	testutil.AssertError(t, err, "Expected an error for an incomplete migration")
+	_, err = provider.MigrateKeyFormat("TestHistoryDB", historydb.KeyFormatV2)
+	testutil.AssertError(t, err, "Expected an error for a migration to another format than the incomplete one")
+	migrated, err = provider.MigrateKeyFormat("TestHistoryDB", historydb.KeyFormatV1)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, migrated, 3)
+	migrated, err = provider.MigrateKeyFormat("TestHistoryDB", historydb.KeyFormatV1)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, migrated, 0)
+	reopenedDB, err = provider.GetDBHandle("TestHistoryDB")
+	testutil.AssertNoError(t, err, "")
+	qhistory, err = reopenedDB.NewHistoryQueryExecutor(store1)
+	testutil.AssertNoError(t, err, "")
+	itr, err = qhistory.GetRecentHistoryForKey("ns1", "key7", 0)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, historyValues(t, itr), []string{"value3", "value2", "value1"})
+
+	_, err = provider.MigrateKeyFormat("TestHistoryDB", "v3")
+	testutil.AssertError(t, err, "Expected an error for an unknown format")
+}
+
+// commitHistory commits a block per value, each with a transaction that sets ns1/key7 to the value
+func commitHistory(t *testing.T, env *levelDBLockBasedHistoryEnv, historyDB historydb.HistoryDB, store blkstorage.BlockStore,
+	bg *testutil.BlockGenerator, values ...string) {
+	for _, value := range values {
+		simulator, _ := env.txmgr.NewTxSimulator()
+		simulator.SetState("ns1", "key7", []byte(value))
+		simulator.Done()
+		simRes, _ := simulator.GetTxSimulationResults()
+		block := bg.NextBlock([][]byte{simRes}, false)
+		testutil.AssertNoError(t, store.AddBlock(block), "")
+		testutil.AssertNoError(t, historyDB.Commit(block), "")
+	}
+}
+
+func historyValues(t *testing.T, itr commonledger.ResultsIterator) []string {
+	defer itr.Close()
+	var values []string
+	for {
+		kmod, err := itr.Next()
+		testutil.AssertNoError(t, err, "")
+		if kmod == nil {
+			return values
+		}
+		values = append(values, string(kmod.(*ledger.KeyModification).Value))
+	}
+}
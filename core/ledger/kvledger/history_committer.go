@@ -0,0 +1,109 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/history/historydb"
+	"github.com/hyperledger/fabric/protos/common"
+)
+
+// maxPendingHistoryBlocks is the number of blocks that can be queued for the history database
+// before a commit waits for the history database to catch up
+const maxPendingHistoryBlocks = 100
+
+// asyncHistoryCommitter commits the blocks to the history database in the background so that
+// the history database is kept out of the critical path of a commit. The blocks that are queued but
+// not committed at the time of a crash are recommitted by the recovery, based on the savepoint of the history database
+type asyncHistoryCommitter struct {
+	// the heights are accessed atomically and are kept first for the alignment on 32-bit platforms
+	submittedHeight uint64
+	historyDBHeight uint64
+	ledgerID        string
+	historyDB       historydb.HistoryDB
+	pendingBlocks   chan *common.Block
+	done            chan struct{}
+	// caughtUp is broadcast after each commit to the history database
+	caughtUp *sync.Cond
+}
+
+func newAsyncHistoryCommitter(ledgerID string, historyDB historydb.HistoryDB, height uint64) *asyncHistoryCommitter {
+	c := &asyncHistoryCommitter{
+		submittedHeight: height,
+		historyDBHeight: height,
+		ledgerID:        ledgerID,
+		historyDB:       historyDB,
+		pendingBlocks:   make(chan *common.Block, maxPendingHistoryBlocks),
+		done:            make(chan struct{}),
+		caughtUp:        sync.NewCond(&sync.Mutex{}),
+	}
+	historyCommitLag.Set(0, ledgerID)
+	go c.run()
+	return c
+}
+
+// submit queues the block for the commit to the history database
+func (c *asyncHistoryCommitter) submit(block *common.Block) {
+	// the lag is reported under the lock so that the reports of submit and run are not reordered
+	c.caughtUp.L.Lock()
+	atomic.StoreUint64(&c.submittedHeight, block.Header.Number+1)
+	historyCommitLag.Set(float64(c.lag()), c.ledgerID)
+	c.caughtUp.L.Unlock()
+	c.pendingBlocks <- block
+}
+
+// lag returns the number of blocks that are committed to the state database but not yet to the history database
+func (c *asyncHistoryCommitter) lag() uint64 {
+	return atomic.LoadUint64(&c.submittedHeight) - atomic.LoadUint64(&c.historyDBHeight)
+}
+
+// waitUntilCaughtUp waits for the blocks submitted so far to be committed to the history database
+func (c *asyncHistoryCommitter) waitUntilCaughtUp() {
+	c.caughtUp.L.Lock()
+	defer c.caughtUp.L.Unlock()
+	for c.lag() > 0 {
+		c.caughtUp.Wait()
+	}
+}
+
+// close waits for the queued blocks to be committed to the history database
+func (c *asyncHistoryCommitter) close() {
+	close(c.pendingBlocks)
+	<-c.done
+}
+
+func (c *asyncHistoryCommitter) run() {
+	defer close(c.done)
+	for block := range c.pendingBlocks {
+		logger.With(flogging.Fields{"channel": c.ledgerID, "block": block.Header.Number}).Debug("Committing block transactions to history database")
+		start := time.Now()
+		if err := c.historyDB.Commit(block); err != nil {
+			panic(fmt.Errorf(`Error during commit to history db:%s`, err))
+		}
+		observeCommitDuration(c.ledgerID, historyDBMetricLabel, start)
+		c.caughtUp.L.Lock()
+		atomic.StoreUint64(&c.historyDBHeight, block.Header.Number+1)
+		historyCommitLag.Set(float64(c.lag()), c.ledgerID)
+		c.caughtUp.Broadcast()
+		c.caughtUp.L.Unlock()
+	}
+}
@@ -0,0 +1,101 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/history/historydb"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/spf13/viper"
+)
+
+func TestAsyncHistoryCommit(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	historyEnabled := viper.GetBool("ledger.state.historyDatabase")
+	viper.Set("ledger.state.historyDatabase", true)
+	defer viper.Set("ledger.state.historyDatabase", historyEnabled)
+	viper.Set("ledger.state.historyAsyncCommit", true)
+	defer viper.Set("ledger.state.historyAsyncCommit", false)
+
+	provider, _ := NewProvider()
+	defer provider.Close()
+	l, _ := provider.Create("testLedger")
+	testutil.AssertNotNil(t, l.(*kvLedger).historyCommitter)
+	bg := testutil.NewBlockGenerator(t)
+	for i := 0; i < 3; i++ {
+		s, _ := l.NewTxSimulator()
+		s.SetState("ns", "key", []byte(fmt.Sprintf("value%d", i)))
+		s.Done()
+		res, _ := s.GetTxSimulationResults()
+		testutil.AssertNoError(t, l.Commit(bg.NextBlock([][]byte{res}, false)), "")
+	}
+	historyDB := l.(*kvLedger).historyDB
+	// closing the ledger waits for the history database to catch up
+	l.Close()
+	savepoint, _ := historyDB.GetLastSavepoint()
+	testutil.AssertEquals(t, savepoint.BlockNum, uint64(2))
+
+	l, _ = provider.Open("testLedger")
+	defer l.Close()
+	qhistory, _ := l.NewHistoryQueryExecutor()
+	itr, err := qhistory.GetHistoryForKey("ns", "key")
+	testutil.AssertNoError(t, err, "")
+	count := 0
+	for {
+		kmod, _ := itr.Next()
+		if kmod == nil {
+			break
+		}
+		count++
+	}
+	testutil.AssertEquals(t, count, 3)
+}
+
+func TestAsyncHistoryCommitterLag(t *testing.T) {
+	historyDB := &blockingHistoryDB{proceed: make(chan struct{})}
+	committer := newAsyncHistoryCommitter("lagLedger", historyDB, 5)
+	testutil.AssertEquals(t, committer.lag(), uint64(0))
+	testutil.AssertEquals(t, strings.Contains(scrapeMetrics(), `ledger_history_commit_lag_blocks{channel="lagLedger"} 0`), true)
+	for i := 5; i < 8; i++ {
+		committer.submit(&common.Block{Header: &common.BlockHeader{Number: uint64(i)}})
+	}
+	testutil.AssertEquals(t, committer.lag(), uint64(3))
+	testutil.AssertEquals(t, strings.Contains(scrapeMetrics(), `ledger_history_commit_lag_blocks{channel="lagLedger"} 3`), true)
+	close(historyDB.proceed)
+	committer.close()
+	testutil.AssertEquals(t, committer.lag(), uint64(0))
+	testutil.AssertEquals(t, strings.Contains(scrapeMetrics(), `ledger_history_commit_lag_blocks{channel="lagLedger"} 0`), true)
+	testutil.AssertEquals(t, historyDB.committedBlocks, []uint64{5, 6, 7})
+}
+
+// blockingHistoryDB holds the commits till the proceed channel is closed
+type blockingHistoryDB struct {
+	historydb.HistoryDB
+	proceed         chan struct{}
+	committedBlocks []uint64
+}
+
+func (db *blockingHistoryDB) Commit(block *common.Block) error {
+	<-db.proceed
+	db.committedBlocks = append(db.committedBlocks, block.Header.Number)
+	return nil
+}
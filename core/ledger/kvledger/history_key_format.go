@@ -0,0 +1,44 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+)
+
+// MigrateHistoryKeyFormat rewrites, in place, the keys of the history database of the given ledger to the given format,
+// v1 or v2, and returns the number of the rewritten keys. The history database keeps the keys in its recorded format
+// regardless of ledger.state.historyKeyFormat, which applies to the new history databases only, hence an existing
+// history database is switched to another format by this migration. An interrupted migration is completed by invoking
+// it again. This is an offline operation and must not be invoked while the peer is running
+func MigrateHistoryKeyFormat(ledgerID string, format string) (int, error) {
+	shared, err := getSharedLevelDB(false)
+	if err != nil {
+		return 0, err
+	}
+	idStore := openIDStore(ledgerconfig.GetLedgerProviderPath(), shared)
+	defer idStore.close()
+	if err := checkLedgerActive(idStore, ledgerID); err != nil {
+		return 0, err
+	}
+	historydbProvider := newHistoryDBProvider(shared)
+	defer historydbProvider.Close()
+	if err := checkDataFormat(historyDBName, historydbProvider, false); err != nil {
+		return 0, err
+	}
+	return historydbProvider.MigrateKeyFormat(ledgerID, format)
+}
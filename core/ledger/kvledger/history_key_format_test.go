@@ -0,0 +1,65 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	ledgerpackage "github.com/hyperledger/fabric/core/ledger"
+	"github.com/spf13/viper"
+)
+
+func TestMigrateHistoryKeyFormat(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	defer viper.Set("ledger.state.historyDatabase", viper.GetBool("ledger.state.historyDatabase"))
+	viper.Set("ledger.state.historyDatabase", true)
+
+	provider, _ := NewProvider()
+	ledger, _ := provider.Create("testLedger")
+	bg := testutil.NewBlockGenerator(t)
+	for i := 0; i < 3; i++ {
+		simulator, _ := ledger.NewTxSimulator()
+		simulator.SetState("ns1", "key1", []byte(fmt.Sprintf("value1.%d", i)))
+		simulator.Done()
+		simRes, _ := simulator.GetTxSimulationResults()
+		testutil.AssertNoError(t, ledger.Commit(bg.NextBlock([][]byte{simRes}, false)), "")
+	}
+	ledger.Close()
+	provider.Close()
+
+	_, err := MigrateHistoryKeyFormat("non-existing-ledger", "v2")
+	testutil.AssertEquals(t, err, ErrNonExistingLedgerID)
+	migrated, err := MigrateHistoryKeyFormat("testLedger", "v2")
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, migrated, 3)
+
+	provider, _ = NewProvider()
+	defer provider.Close()
+	ledger, _ = provider.Open("testLedger")
+	defer ledger.Close()
+	qhistory, _ := ledger.NewHistoryQueryExecutor()
+	itr, err := qhistory.GetRecentHistoryForKey("ns1", "key1", 1)
+	testutil.AssertNoError(t, err, "")
+	defer itr.Close()
+	result, _ := itr.Next()
+	testutil.AssertEquals(t, result.(*ledgerpackage.KeyModification).Value, []byte("value1.2"))
+	result, _ = itr.Next()
+	testutil.AssertNil(t, result)
+}
@@ -0,0 +1,301 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package invalidtxs
+
+import (
+	"encoding/binary"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
+	"github.com/hyperledger/fabric/common/metrics"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
+	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+	lutils "github.com/hyperledger/fabric/core/ledger/util"
+	"github.com/hyperledger/fabric/msp"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/peer"
+	putils "github.com/hyperledger/fabric/protos/utils"
+	logging "github.com/op/go-logging"
+)
+
+var logger = logging.MustGetLogger("invalidtxs")
+
+var invalidTransactions = metrics.NewCounterVec("ledger_invalid_transactions_total",
+	"Number of the transactions invalidated at commit by the validation code, the MSP of the creator, and the chaincode.",
+	"channel", "validation_code", "creator_msp", "chaincode")
+
+var savePointKey = []byte{0x00}
+var entryKeyPrefix = []byte{0x01}
+var entryCountKey = []byte{0x02}
+
+// Provider provides handles to the logs of the invalidated transactions of the ledgers
+type Provider struct {
+	dbProvider *leveldbhelper.Provider
+}
+
+// NewProvider instantiates Provider
+func NewProvider() *Provider {
+	dbPath := ledgerconfig.GetInvalidTxLogPath()
+	logger.Debugf("constructing invalid transactions log Provider dbPath=%s", dbPath)
+	return &Provider{leveldbhelper.NewProvider(&leveldbhelper.Conf{DBPath: dbPath})}
+}
+
+// NewReadOnlyProvider instantiates Provider that opens the existing databases in read-only mode
+func NewReadOnlyProvider() *Provider {
+	dbPath := ledgerconfig.GetInvalidTxLogPath()
+	logger.Debugf("constructing read-only invalid transactions log Provider dbPath=%s", dbPath)
+	return &Provider{leveldbhelper.NewProvider(&leveldbhelper.Conf{DBPath: dbPath, ReadOnly: true})}
+}
+
+// GetLog returns the log of the invalidated transactions of the given ledger
+func (p *Provider) GetLog(ledgerID string) *Log {
+	return &Log{p.dbProvider.GetDBHandle(ledgerID), ledgerID}
+}
+
+// Drop removes the log of the invalidated transactions of the given ledger
+func (p *Provider) Drop(ledgerID string) error {
+	return p.dbProvider.GetDBHandle(ledgerID).DeleteAll()
+}
+
+// Close closes the underlying db
+func (p *Provider) Close() {
+	p.dbProvider.Close()
+}
+
+// Log maintains a rolling log of the transactions of a ledger that are invalidated at commit, along with the validation
+// code, the MSP of the creator, and the chaincode invoked, so that the operators can spot the clients that produce
+// endorsement policy failures or MVCC conflicts. Only the most recent ledger.invalidTxLog.maxEntries transactions are kept
+type Log struct {
+	db       *leveldbhelper.DBHandle
+	ledgerID string
+}
+
+// Commit logs the invalidated transactions in the block and reports them to the metrics
+func (l *Log) Commit(block *common.Block) error {
+	invalidTxs, err := l.commit(block)
+	if err != nil {
+		return err
+	}
+	for _, invalidTx := range invalidTxs {
+		invalidTransactions.Add(1, l.ledgerID, invalidTx.ValidationCode.String(), invalidTx.CreatorMSPID, invalidTx.Chaincode)
+	}
+	return nil
+}
+
+// commit logs the invalidated transactions in the block and drops the oldest entries in excess of the maximum
+func (l *Log) commit(block *common.Block) ([]*ledger.InvalidTransaction, error) {
+	blockNum := block.Header.Number
+	maxEntries := uint64(ledgerconfig.GetInvalidTxLogMaxEntries())
+	txsFilter := lutils.TxValidationFlags(block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER])
+	var invalidTxs []*ledger.InvalidTransaction
+	for txIndex, envBytes := range block.Data.Data {
+		if len(txsFilter) <= txIndex || !txsFilter.IsInvalid(txIndex) {
+			continue
+		}
+		invalidTx := extractInvalidTransaction(envBytes)
+		invalidTx.BlockNum = blockNum
+		invalidTx.TxNum = uint64(txIndex)
+		invalidTx.ValidationCode = txsFilter.Flag(txIndex)
+		logger.Debugf("Channel [%s]: Logging invalid transaction [%s] at position [%d] in block [%d] with validation code [%s]",
+			l.ledgerID, invalidTx.TxID, txIndex, blockNum, invalidTx.ValidationCode)
+		invalidTxs = append(invalidTxs, invalidTx)
+	}
+	batch := leveldbhelper.NewUpdateBatch()
+	logged := invalidTxs
+	if uint64(len(logged)) > maxEntries {
+		logged = logged[uint64(len(logged))-maxEntries:]
+	}
+	count, err := l.getEntryCount()
+	if err != nil {
+		return nil, err
+	}
+	if excess := count + uint64(len(logged)); excess > maxEntries {
+		count -= l.dropOldestEntries(batch, excess-maxEntries)
+	}
+	for _, invalidTx := range logged {
+		batch.Put(encodeEntryKey(version.NewHeight(invalidTx.BlockNum, invalidTx.TxNum)), encodeEntryValue(invalidTx))
+	}
+	count += uint64(len(logged))
+	batch.Put(entryCountKey, encodeCount(count))
+	batch.Put(savePointKey, version.NewHeight(blockNum, 0).ToBytes())
+	if err := l.db.WriteBatch(batch, true); err != nil {
+		return nil, err
+	}
+	return invalidTxs, nil
+}
+
+// dropOldestEntries adds the deletes of the given number of the oldest entries to the batch and returns the number of
+// the entries deleted, which is lower if there are fewer entries
+func (l *Log) dropOldestEntries(batch *leveldbhelper.UpdateBatch, num uint64) uint64 {
+	itr := l.db.GetIterator(entryKeyPrefix, entryCountKey)
+	defer itr.Release()
+	var dropped uint64
+	for dropped < num && itr.Next() {
+		batch.Delete(append([]byte{}, itr.Key()...))
+		dropped++
+	}
+	return dropped
+}
+
+// MarkStartingSavepoint marks the given savepoint as the starting point of the log, for a ledger that
+// is created from a snapshot
+func (l *Log) MarkStartingSavepoint(savepoint *version.Height) error {
+	return l.db.Put(savePointKey, savepoint.ToBytes(), true)
+}
+
+// GetInvalidTransactions returns the most recent invalidated transactions in the log, up to the given number of the
+// transactions, or all the transactions in the log if the number is zero. The transactions are ordered by the position
+func (l *Log) GetInvalidTransactions(maxEntries int) ([]*ledger.InvalidTransaction, error) {
+	count, err := l.getEntryCount()
+	if err != nil {
+		return nil, err
+	}
+	var skip uint64
+	if maxEntries > 0 && count > uint64(maxEntries) {
+		skip = count - uint64(maxEntries)
+	}
+	itr := l.db.GetIterator(entryKeyPrefix, entryCountKey)
+	defer itr.Release()
+	var invalidTxs []*ledger.InvalidTransaction
+	for itr.Next() {
+		if skip > 0 {
+			skip--
+			continue
+		}
+		invalidTx, err := decodeEntry(itr.Key(), itr.Value())
+		if err != nil {
+			return nil, err
+		}
+		invalidTxs = append(invalidTxs, invalidTx)
+	}
+	return invalidTxs, nil
+}
+
+func (l *Log) getEntryCount() (uint64, error) {
+	countBytes, err := l.db.Get(entryCountKey)
+	if err != nil || countBytes == nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(countBytes), nil
+}
+
+// GetLastSavepoint implements method in interface kvledger.Recoverer
+func (l *Log) GetLastSavepoint() (*version.Height, error) {
+	versionBytes, err := l.db.Get(savePointKey)
+	if err != nil || versionBytes == nil {
+		return nil, err
+	}
+	height, _ := version.NewHeightFromBytes(versionBytes)
+	return height, nil
+}
+
+// ShouldRecover implements method in interface kvledger.Recoverer
+func (l *Log) ShouldRecover(lastAvailableBlock uint64) (bool, uint64, error) {
+	savepoint, err := l.GetLastSavepoint()
+	if err != nil {
+		return false, 0, err
+	}
+	if savepoint == nil {
+		return true, 0, nil
+	}
+	return savepoint.BlockNum != lastAvailableBlock, savepoint.BlockNum + 1, nil
+}
+
+// CommitLostBlock implements method in interface kvledger.Recoverer
+// The transactions are not reported to the metrics again as they were reported before the crash
+func (l *Log) CommitLostBlock(block *common.Block) error {
+	_, err := l.commit(block)
+	return err
+}
+
+// extractInvalidTransaction returns the id of a transaction along with the MSP of its creator and, for an endorser
+// transaction, the chaincode invoked. An invalid transaction may be malformed, in which case the fields that could
+// not be extracted are left empty
+func extractInvalidTransaction(envBytes []byte) *ledger.InvalidTransaction {
+	invalidTx := &ledger.InvalidTransaction{}
+	env, err := putils.GetEnvelopeFromBlock(envBytes)
+	if err != nil {
+		return invalidTx
+	}
+	payload, err := putils.GetPayload(env)
+	if err != nil || payload.Header == nil {
+		return invalidTx
+	}
+	chdr, err := putils.UnmarshalChannelHeader(payload.Header.ChannelHeader)
+	if err != nil {
+		return invalidTx
+	}
+	invalidTx.TxID = chdr.TxId
+	if shdr, err := putils.GetSignatureHeader(payload.Header.SignatureHeader); err == nil {
+		creator := &msp.SerializedIdentity{}
+		if err := proto.Unmarshal(shdr.Creator, creator); err == nil {
+			invalidTx.CreatorMSPID = creator.Mspid
+		}
+	}
+	if common.HeaderType(chdr.Type) != common.HeaderType_ENDORSER_TRANSACTION {
+		return invalidTx
+	}
+	ccHdrExt := &peer.ChaincodeHeaderExtension{}
+	if err := proto.Unmarshal(chdr.Extension, ccHdrExt); err == nil && ccHdrExt.ChaincodeId != nil {
+		invalidTx.Chaincode = ccHdrExt.ChaincodeId.Name
+	}
+	return invalidTx
+}
+
+// encodeEntryKey appends the position of the transaction to the prefix, so that the entries are ordered by the position
+func encodeEntryKey(height *version.Height) []byte {
+	return append(append([]byte{}, entryKeyPrefix...), height.ToBytes()...)
+}
+
+// encodeEntryValue serializes the id of the transaction, the validation code, the MSP of the creator, and the chaincode
+func encodeEntryValue(invalidTx *ledger.InvalidTransaction) []byte {
+	buffer := proto.NewBuffer([]byte{})
+	buffer.EncodeStringBytes(invalidTx.TxID)
+	buffer.EncodeVarint(uint64(invalidTx.ValidationCode))
+	buffer.EncodeStringBytes(invalidTx.CreatorMSPID)
+	buffer.EncodeStringBytes(invalidTx.Chaincode)
+	return buffer.Bytes()
+}
+
+func decodeEntry(key []byte, value []byte) (*ledger.InvalidTransaction, error) {
+	height, _ := version.NewHeightFromBytes(key[len(entryKeyPrefix):])
+	invalidTx := &ledger.InvalidTransaction{BlockNum: height.BlockNum, TxNum: height.TxNum}
+	buffer := proto.NewBuffer(value)
+	var err error
+	if invalidTx.TxID, err = buffer.DecodeStringBytes(); err != nil {
+		return nil, err
+	}
+	validationCode, err := buffer.DecodeVarint()
+	if err != nil {
+		return nil, err
+	}
+	invalidTx.ValidationCode = peer.TxValidationCode(validationCode)
+	if invalidTx.CreatorMSPID, err = buffer.DecodeStringBytes(); err != nil {
+		return nil, err
+	}
+	if invalidTx.Chaincode, err = buffer.DecodeStringBytes(); err != nil {
+		return nil, err
+	}
+	return invalidTx, nil
+}
+
+func encodeCount(count uint64) []byte {
+	countBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(countBytes, count)
+	return countBytes
+}
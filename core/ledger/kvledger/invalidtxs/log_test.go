@@ -0,0 +1,144 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package invalidtxs
+
+import (
+	"os"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
+	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+	lutils "github.com/hyperledger/fabric/core/ledger/util"
+	"github.com/hyperledger/fabric/msp"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/peer"
+	putils "github.com/hyperledger/fabric/protos/utils"
+	"github.com/spf13/viper"
+)
+
+func TestMain(m *testing.M) {
+	viper.Set("peer.fileSystemPath", "/tmp/fabric/ledgertests/invalidtxs")
+	os.Exit(m.Run())
+}
+
+func TestInvalidTransactionsLog(t *testing.T) {
+	viper.Set("ledger.invalidTxLog.maxEntries", 3)
+	defer viper.Set("ledger.invalidTxLog.maxEntries", 0)
+	os.RemoveAll(ledgerconfig.GetInvalidTxLogPath())
+	defer os.RemoveAll(ledgerconfig.GetInvalidTxLogPath())
+	provider := NewProvider()
+	defer provider.Close()
+	log := provider.GetLog("testLedger")
+
+	block1 := constructBlock(1, []*txSpec{
+		{txID: "tx1", creatorMSP: "Org1MSP", chaincode: "cc1", code: peer.TxValidationCode_MVCC_READ_CONFLICT},
+		{txID: "tx2", creatorMSP: "Org1MSP", chaincode: "cc1", code: peer.TxValidationCode_VALID},
+		{txID: "tx3", creatorMSP: "Org2MSP", chaincode: "cc2", code: peer.TxValidationCode_ENDORSEMENT_POLICY_FAILURE},
+	})
+	// a malformed transaction is logged with the fields that could not be extracted left empty
+	block1.Data.Data = append(block1.Data.Data, []byte("malformed"))
+	block1.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER] = append(
+		block1.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER], uint8(peer.TxValidationCode_BAD_PAYLOAD))
+	testutil.AssertNoError(t, log.Commit(block1), "")
+	invalidTxs, err := log.GetInvalidTransactions(0)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, invalidTxs, []*ledger.InvalidTransaction{
+		{TxID: "tx1", BlockNum: 1, TxNum: 0, ValidationCode: peer.TxValidationCode_MVCC_READ_CONFLICT, CreatorMSPID: "Org1MSP", Chaincode: "cc1"},
+		{TxID: "tx3", BlockNum: 1, TxNum: 2, ValidationCode: peer.TxValidationCode_ENDORSEMENT_POLICY_FAILURE, CreatorMSPID: "Org2MSP", Chaincode: "cc2"},
+		{BlockNum: 1, TxNum: 3, ValidationCode: peer.TxValidationCode_BAD_PAYLOAD},
+	})
+
+	// the oldest entries are dropped beyond the maximum number of the entries
+	block2 := constructBlock(2, []*txSpec{
+		{txID: "tx4", creatorMSP: "Org1MSP", chaincode: "cc1", code: peer.TxValidationCode_PHANTOM_READ_CONFLICT},
+		{txID: "tx5", creatorMSP: "Org2MSP", chaincode: "cc2", code: peer.TxValidationCode_MVCC_READ_CONFLICT},
+	})
+	testutil.AssertNoError(t, log.Commit(block2), "")
+	invalidTxs, _ = log.GetInvalidTransactions(0)
+	testutil.AssertEquals(t, len(invalidTxs), 3)
+	testutil.AssertEquals(t, invalidTxs[0].TxNum, uint64(3))
+	testutil.AssertEquals(t, invalidTxs[1].TxID, "tx4")
+	testutil.AssertEquals(t, invalidTxs[2].TxID, "tx5")
+	invalidTxs, _ = log.GetInvalidTransactions(1)
+	testutil.AssertEquals(t, len(invalidTxs), 1)
+	testutil.AssertEquals(t, invalidTxs[0].TxID, "tx5")
+
+	// only the most recent entries of a block with more invalid transactions than the maximum are logged
+	block3 := constructBlock(3, []*txSpec{
+		{txID: "tx6", code: peer.TxValidationCode_MVCC_READ_CONFLICT},
+		{txID: "tx7", code: peer.TxValidationCode_MVCC_READ_CONFLICT},
+		{txID: "tx8", code: peer.TxValidationCode_MVCC_READ_CONFLICT},
+		{txID: "tx9", code: peer.TxValidationCode_MVCC_READ_CONFLICT},
+	})
+	testutil.AssertNoError(t, log.CommitLostBlock(block3), "")
+	invalidTxs, _ = log.GetInvalidTransactions(0)
+	testutil.AssertEquals(t, len(invalidTxs), 3)
+	testutil.AssertEquals(t, invalidTxs[0].TxID, "tx7")
+	testutil.AssertEquals(t, invalidTxs[2].TxID, "tx9")
+	savepoint, _ := log.GetLastSavepoint()
+	testutil.AssertEquals(t, savepoint, version.NewHeight(3, 0))
+	shouldRecover, firstBlockNum, _ := log.ShouldRecover(3)
+	testutil.AssertEquals(t, shouldRecover, false)
+	testutil.AssertEquals(t, firstBlockNum, uint64(4))
+
+	// the logs of other ledgers are independent
+	invalidTxs, _ = provider.GetLog("otherLedger").GetInvalidTransactions(0)
+	testutil.AssertEquals(t, len(invalidTxs), 0)
+	testutil.AssertNoError(t, provider.Drop("testLedger"), "")
+	invalidTxs, _ = log.GetInvalidTransactions(0)
+	testutil.AssertEquals(t, len(invalidTxs), 0)
+}
+
+func TestMarkStartingSavepoint(t *testing.T) {
+	os.RemoveAll(ledgerconfig.GetInvalidTxLogPath())
+	defer os.RemoveAll(ledgerconfig.GetInvalidTxLogPath())
+	provider := NewProvider()
+	defer provider.Close()
+	log := provider.GetLog("testLedger")
+
+	testutil.AssertNoError(t, log.MarkStartingSavepoint(version.NewHeight(5, 2)), "")
+	shouldRecover, firstBlockNum, _ := log.ShouldRecover(6)
+	testutil.AssertEquals(t, shouldRecover, true)
+	testutil.AssertEquals(t, firstBlockNum, uint64(6))
+}
+
+type txSpec struct {
+	txID       string
+	creatorMSP string
+	chaincode  string
+	code       peer.TxValidationCode
+}
+
+func constructBlock(blockNum uint64, txs []*txSpec) *common.Block {
+	block := common.NewBlock(blockNum, []byte{})
+	txsFilter := lutils.NewTxValidationFlags(len(txs))
+	for txIndex, tx := range txs {
+		chdr := putils.MakeChannelHeader(common.HeaderType_ENDORSER_TRANSACTION, 0, "testLedger", 0)
+		chdr.TxId = tx.txID
+		chdr.Extension = putils.MarshalOrPanic(&peer.ChaincodeHeaderExtension{ChaincodeId: &peer.ChaincodeID{Name: tx.chaincode}})
+		creator := putils.MarshalOrPanic(&msp.SerializedIdentity{Mspid: tx.creatorMSP, IdBytes: []byte("cert")})
+		payload := &common.Payload{Header: putils.MakePayloadHeader(chdr, putils.MakeSignatureHeader(creator, []byte("nonce")))}
+		env := &common.Envelope{Payload: putils.MarshalOrPanic(payload)}
+		block.Data.Data = append(block.Data.Data, putils.MarshalOrPanic(env))
+		txsFilter.SetFlag(txIndex, tx.code)
+	}
+	putils.InitBlockMetadata(block)
+	block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER] = txsFilter
+	return block
+}
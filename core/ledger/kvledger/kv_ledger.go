@@ -17,134 +17,357 @@ limitations under the License.
 package kvledger
 
 import (
-	"errors"
 	"fmt"
+	"sort"
+	"sync"
+	"time"
 
+	"github.com/hyperledger/fabric/common/flogging"
 	commonledger "github.com/hyperledger/fabric/common/ledger"
 	"github.com/hyperledger/fabric/common/ledger/blkstorage"
+	"github.com/hyperledger/fabric/common/ledger/util"
+	"github.com/hyperledger/fabric/common/tracing"
 	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/ccevents"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/confighistory"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/history/historydb"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/invalidtxs"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/statetrie"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/txmgr"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/txmgr/lockbasedtxmgr"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txresults"
 	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+	"github.com/hyperledger/fabric/core/ledger/pvtdatapolicy"
+	"github.com/hyperledger/fabric/core/ledger/pvtdatastorage"
 	"github.com/hyperledger/fabric/protos/common"
 	"github.com/hyperledger/fabric/protos/peer"
-	logging "github.com/op/go-logging"
+	"golang.org/x/net/context"
 )
 
-var logger = logging.MustGetLogger("kvledger")
+var logger = flogging.MustGetFieldLogger("kvledger")
 
 // KVLedger provides an implementation of `ledger.PeerLedger`.
 // This implementation provides a key-value based data model
 type kvLedger struct {
 	ledgerID   string
 	blockStore blkstorage.BlockStore
-	txtmgmt    txmgr.TxMgr
-	historyDB  historydb.HistoryDB
+	// pvtdataStore maintains the private write sets of the transactions in the blocks
+	pvtdataStore pvtdatastorage.Store
+	txtmgmt      txmgr.TxMgr
+	// versionedDB is the state database that backs the txtmgmt
+	versionedDB statedb.VersionedDB
+	historyDB   historydb.HistoryDB
+	// configHistoryMgr maintains the history of the collection configs of the chaincodes
+	configHistoryMgr *confighistory.Mgr
+	// ccEventsIndex maintains the index of the chaincode events set by the valid transactions
+	ccEventsIndex *ccevents.Index
+	// txResultsArchive maintains the results of the valid transactions, if the archive is enabled
+	txResultsArchive *txresults.Archive
+	// invalidTxsLog maintains the rolling log of the invalidated transactions, if the log is enabled
+	invalidTxsLog *invalidtxs.Log
+	// stateTrie maintains the root of the state after each block for the proofs of the values of the keys,
+	// and is nil if the trie is not enabled
+	stateTrie *statetrie.Store
+	// writeAheadLog records the block being committed and is nil if the log is not enabled or in read-only mode
+	writeAheadLog *writeAheadLog
+	// commitDecorators maintain the data derived from the committed write sets in their own stores
+	commitDecorators []*commitDecorator
+	commitHash []byte
+	config     *ledgerconfig.ChannelConfig
+	readOnly   bool
+	// historyCommitter is non-nil if the history database is updated asynchronously
+	historyCommitter *asyncHistoryCommitter
+	// dbSyncer flushes the state database and the history database according to the sync policy
+	// and is nil in read-only mode
+	dbSyncer *util.Syncer
+	// recentKeys tracks the keys most recently written to the state database for warming it up after a restart
+	// and is nil if the tracking is not enabled or in read-only mode
+	recentKeys *recentKeys
+	// namespaceSizes maintains the sizes of the namespaces and is nil if the sizes are not enabled
+	namespaceSizes *namespaceSizes
+	// txIDFilter answers most of the lookups of the transaction ids that are not in the block storage without
+	// looking up the block index and is nil if the filter is not enabled
+	txIDFilter *txIDFilter
+	// namespaceStats counts the operations on the state per namespace and is nil if the counting is not enabled
+	namespaceStats *namespaceStats
+	// genesisBlockHashRecorder records the hash of the genesis block in the id store on the commit of the block
+	// and is nil if the hash is already recorded or the ledger has no genesis block
+	genesisBlockHashRecorder func(genesisBlockHash []byte) error
+
+	configBlockListeners     []ledger.ConfigBlockListener
+	configBlockListenersLock sync.RWMutex
 }
 
 // NewKVLedger constructs new `KVLedger`
-func newKVLedger(ledgerID string, blockStore blkstorage.BlockStore,
-	versionedDB statedb.VersionedDB, historyDB historydb.HistoryDB) (*kvLedger, error) {
+// A read-only `KVLedger` does not recover the state DB and history DB and does not allow commits
+func newKVLedger(ledgerID string, blockStore blkstorage.BlockStore, pvtdataStore pvtdatastorage.Store, versionedDB statedb.VersionedDB,
+	historyDB historydb.HistoryDB, configHistoryMgr *confighistory.Mgr, ccEventsIndex *ccevents.Index, txResultsArchive *txresults.Archive,
+	invalidTxsLog *invalidtxs.Log, stateTrie *statetrie.Store, commitDecorators []*commitDecorator, config *ledgerconfig.ChannelConfig,
+	readOnly bool) (*kvLedger, error) {
 
-	logger.Debugf("Creating KVLedger ledgerID=%s: ", ledgerID)
-
-	//Initialize transaction manager using state database
-	var txmgmt txmgr.TxMgr
-	txmgmt = lockbasedtxmgr.NewLockBasedTxMgr(versionedDB)
+	logger.With(flogging.Fields{"channel": ledgerID}).Debug("Creating KVLedger")
 
 	// Create a kvLedger for this chain/ledger, which encasulates the underlying
 	// id store, blockstore, txmgr (state database), history database
-	l := &kvLedger{ledgerID, blockStore, txmgmt, historyDB}
+	l := &kvLedger{ledgerID: ledgerID, blockStore: blockStore, pvtdataStore: pvtdataStore, versionedDB: versionedDB,
+		historyDB: historyDB, configHistoryMgr: configHistoryMgr, ccEventsIndex: ccEventsIndex, txResultsArchive: txResultsArchive,
+		invalidTxsLog: invalidTxsLog, commitDecorators: commitDecorators, config: config, readOnly: readOnly}
+
+	//Initialize transaction manager using state database
+	lockBasedTxMgr := lockbasedtxmgr.NewLockBasedTxMgr(versionedDB, l.getBlockchainInfoAt)
+	if ledgerconfig.IsNamespaceStatsEnabled() {
+		l.namespaceStats = newNamespaceStats(ledgerID)
+		lockBasedTxMgr.SetRichQueryListener(l.namespaceStats.addRichQuery)
+	}
+	var txmgmt txmgr.TxMgr
+	txmgmt = lockBasedTxMgr
+	l.txtmgmt = txmgmt
+	// the expiry of the private data is computed from the collection configs in the state
+	pvtdataStore.Init(pvtdatapolicy.NewBTLPolicy(txmgmt))
 
+	if ledgerconfig.IsStateTrieEnabled() {
+		l.stateTrie = stateTrie
+	}
 	//Recover both state DB and history DB if they are out of sync with block storage
-	if err := l.recoverDBs(); err != nil {
-		panic(fmt.Errorf(`Error during state DB recovery:%s`, err))
+	if readOnly {
+		logger.With(flogging.Fields{"channel": ledgerID}).Debug("Skipping recovery of state DB and history DB for a read-only ledger")
+	} else {
+		// the block that was being committed is completed first, the savepoints then cover the writes
+		// that were not flushed before the block
+		if ledgerconfig.IsWriteAheadLogEnabled() {
+			l.writeAheadLog = newWriteAheadLog(ledgerID, writeAheadLogPath(ledgerID))
+			if err := l.recoverFromWriteAheadLog(); err != nil {
+				return nil, err
+			}
+		}
+		if err := l.syncPvtdataStoreWithBlockStore(); err != nil {
+			return nil, err
+		}
+		if err := l.recoverDBs(); err != nil {
+			panic(fmt.Errorf(`Error during state DB recovery:%s`, err))
+		}
+	}
+
+	// the state trie is brought in line after the recovery so that a rebuild reads the recovered state database
+	if l.stateTrie != nil && !readOnly {
+		if err := l.syncStateTrie(); err != nil {
+			return nil, err
+		}
+	}
+
+	//Load the commit hash of the last block for chaining the commit hash of the next block
+	if err := l.loadLastCommitHash(); err != nil {
+		return nil, err
+	}
+	bcInfo, err := blockStore.GetBlockchainInfo()
+	if err != nil {
+		return nil, err
+	}
+	blockchainHeight.Set(float64(bcInfo.Height), ledgerID)
+
+	if !readOnly && config.HistoryDatabase && ledgerconfig.IsHistoryDBAsyncCommitEnabled() {
+		info, err := blockStore.GetBlockchainInfo()
+		if err != nil {
+			return nil, err
+		}
+		logger.With(flogging.Fields{"channel": ledgerID}).Debug("History database is updated asynchronously")
+		l.historyCommitter = newAsyncHistoryCommitter(ledgerID, historyDB, info.Height)
+	}
+	if !readOnly {
+		l.dbSyncer = util.NewSyncer(ledgerconfig.GetLevelDBSyncPolicy(), l.syncDBs)
+	}
+	if keysPerNamespace := ledgerconfig.GetStateWarmUpKeysPerNamespace(); !readOnly && keysPerNamespace > 0 {
+		l.recentKeys = newRecentKeys(ledgerID, recentKeysPath(ledgerID), keysPerNamespace)
+	}
+	if expectedTxs := ledgerconfig.GetTxIDFilterExpectedTxs(); expectedTxs > 0 {
+		l.txIDFilter = newTxIDFilter(ledgerID, expectedTxs)
+		go l.txIDFilter.load(blockStore)
+	}
+	// the sizes are loaded after the recovery so that they correspond to the recovered state database
+	if !readOnly && ledgerconfig.IsNamespaceSizesEnabled() {
+		if l.namespaceSizes, err = newNamespaceSizes(ledgerID, namespaceSizesPath(ledgerID), versionedDB); err != nil {
+			return nil, err
+		}
+		lockBasedTxMgr.SetNamespaceSizeTracker(l.namespaceSizes)
 	}
 
 	return l, nil
 }
 
+// syncDBs flushes the writes to the state database and the history database to the disk
+func (l *kvLedger) syncDBs() error {
+	if err := l.versionedDB.Sync(); err != nil {
+		return err
+	}
+	if l.config.HistoryDatabase {
+		return l.historyDB.Sync()
+	}
+	return nil
+}
+
+// syncPvtdataStoreWithBlockStore completes the commit of the private data that was pending when the peer stopped.
+// The pending private data is committed if the block made it to the block storage and is discarded otherwise
+func (l *kvLedger) syncPvtdataStoreWithBlockStore() error {
+	pending, pendingBlock, err := l.pvtdataStore.HasPendingBatch()
+	if err != nil || !pending {
+		return err
+	}
+	info, err := l.blockStore.GetBlockchainInfo()
+	if err != nil {
+		return err
+	}
+	if pendingBlock < info.Height {
+		logger.With(flogging.Fields{"channel": l.ledgerID, "block": pendingBlock}).Info("Committing the pending private data of block")
+		return l.pvtdataStore.Commit()
+	}
+	logger.With(flogging.Fields{"channel": l.ledgerID, "block": pendingBlock}).Info("Discarding the pending private data of block as the block is not in the block storage")
+	return l.pvtdataStore.Rollback()
+}
+
+// recoverables returns the stores that are recovered by recommitting the blocks that they lag behind
+func (l *kvLedger) recoverables() []*namedRecoverable {
+	recoverables := []*namedRecoverable{{"state DB", l.txtmgmt}, {"config history", l.configHistoryMgr},
+		{"chaincode events index", l.ccEventsIndex}}
+	if l.config.HistoryDatabase {
+		recoverables = append(recoverables, &namedRecoverable{"history DB", l.historyDB})
+	}
+	if ledgerconfig.IsTxResultsArchiveEnabled() {
+		recoverables = append(recoverables, &namedRecoverable{"transaction results archive", l.txResultsArchive})
+	}
+	if ledgerconfig.IsInvalidTxLogEnabled() {
+		recoverables = append(recoverables, &namedRecoverable{"invalid transactions log", l.invalidTxsLog})
+	}
+	for _, d := range l.commitDecorators {
+		recoverables = append(recoverables, &namedRecoverable{fmt.Sprintf("commit decorator [%s]", d.name), d})
+	}
+	return recoverables
+}
+
 //Recover the state database and history database (if exist)
 //by recommitting last valid blocks
 func (l *kvLedger) recoverDBs() error {
-	logger.Debugf("Entering recoverDB()")
-	//If there is no block in blockstorage, nothing to recover.
+	logger.Debug("Entering recoverDB()")
 	info, _ := l.blockStore.GetBlockchainInfo()
+	recoverables := l.recoverables()
+	//Cross-check the savepoints against the block storage before attempting any repair
+	if err := checkSavepointConsistency(info.Height, recoverables); err != nil {
+		return err
+	}
+	//If there is no block in blockstorage, nothing to recover.
 	if info.Height == 0 {
 		logger.Debug("Block storage is empty.")
 		return nil
 	}
 	lastAvailableBlockNum := info.Height - 1
-	recoverables := []recoverable{l.txtmgmt, l.historyDB}
 	recoverers := []*recoverer{}
-	for _, recoverable := range recoverables {
-		recoverFlag, firstBlockNum, err := recoverable.ShouldRecover(lastAvailableBlockNum)
+	for _, r := range recoverables {
+		recoverFlag, firstBlockNum, err := r.recoverable.ShouldRecover(lastAvailableBlockNum)
 		if err != nil {
 			return err
 		}
 		if recoverFlag {
-			recoverers = append(recoverers, &recoverer{firstBlockNum, recoverable})
+			recoverers = append(recoverers, &recoverer{r.name, firstBlockNum, r.recoverable})
 		}
 	}
 	if len(recoverers) == 0 {
+		logger.With(flogging.Fields{"channel": l.ledgerID}).Debugf("Savepoints are consistent with block storage height [%d]", info.Height)
 		return nil
 	}
-	if len(recoverers) == 1 {
-		return l.recommitLostBlocks(recoverers[0].firstBlockNum, lastAvailableBlockNum, recoverers[0].recoverable)
-	}
-
-	// both dbs need to be recovered
-	if recoverers[0].firstBlockNum > recoverers[1].firstBlockNum {
-		// swap (put the lagger db at 0 index)
-		recoverers[0], recoverers[1] = recoverers[1], recoverers[0]
+	for _, r := range recoverers {
+		logger.With(flogging.Fields{"channel": l.ledgerID}).Infof("%s is lagging behind block storage, replaying blocks [%d] to [%d]",
+			r.name, r.firstBlockNum, lastAvailableBlockNum)
 	}
-	if recoverers[0].firstBlockNum != recoverers[1].firstBlockNum {
-		// bring the lagger db equal to the the other db
-		if err := l.recommitLostBlocks(recoverers[0].firstBlockNum, recoverers[1].firstBlockNum-1,
-			recoverers[0].recoverable); err != nil {
+	// put the most lagging recoverer first and bring each recoverer up to the next one,
+	// so that every block is retrieved only once
+	sort.Sort(recoverersByFirstBlock(recoverers))
+	for i, r := range recoverers {
+		lastBlockNum := lastAvailableBlockNum
+		if i+1 < len(recoverers) {
+			if recoverers[i+1].firstBlockNum == r.firstBlockNum {
+				continue
+			}
+			lastBlockNum = recoverers[i+1].firstBlockNum - 1
+		}
+		if err := l.recommitLostBlocks(r.firstBlockNum, lastBlockNum, recoverers[:i+1]...); err != nil {
 			return err
 		}
 	}
-	// get both the db upto block storage
-	return l.recommitLostBlocks(recoverers[1].firstBlockNum, lastAvailableBlockNum,
-		recoverers[0].recoverable, recoverers[1].recoverable)
+	for _, r := range recoverers {
+		dbRepairCount.Add(1, l.ledgerID, r.name)
+	}
+	return nil
 }
 
-//recommitLostBlocks retrieves blocks in specified range and commit the write set to either
-//state DB or history DB or both
-func (l *kvLedger) recommitLostBlocks(firstBlockNum uint64, lastBlockNum uint64, recoverables ...recoverable) error {
+// recoveryProgressInterval is the number of the recommitted blocks after which the progress of the recovery is logged
+const recoveryProgressInterval = 10000
+
+//recommitLostBlocks retrieves blocks in specified range and commit the write set to the
+//given recoverers
+func (l *kvLedger) recommitLostBlocks(firstBlockNum uint64, lastBlockNum uint64, recoverers ...*recoverer) error {
 	var err error
 	var block *common.Block
 	for blockNumber := firstBlockNum; blockNumber <= lastBlockNum; blockNumber++ {
 		if block, err = l.GetBlockByNumber(blockNumber); err != nil {
 			return err
 		}
-		for _, r := range recoverables {
-			if err := r.CommitLostBlock(block); err != nil {
+		for _, r := range recoverers {
+			if err := r.recoverable.CommitLostBlock(block); err != nil {
 				return err
 			}
 		}
+		if (blockNumber-firstBlockNum+1)%recoveryProgressInterval == 0 {
+			logger.With(flogging.Fields{"channel": l.ledgerID}).Infof("Recommitted blocks [%d] to [%d] of [%d]",
+				firstBlockNum, blockNumber, lastBlockNum)
+		}
+	}
+	for _, r := range recoverers {
+		logger.With(flogging.Fields{"channel": l.ledgerID}).Infof("Repaired %s by recommitting blocks [%d] to [%d]",
+			r.name, firstBlockNum, lastBlockNum)
 	}
 	return nil
 }
 
-// GetTransactionByID retrieves a transaction by id
+// TxExists returns whether a transaction with the given id is in the block storage, whatever its validation code.
+// The block index is looked up only if the filter of the transaction ids cannot rule out the id. The transactions of
+// the blocks included in the snapshot from which the ledger is bootstrapped, if any, are not known
+func (l *kvLedger) TxExists(txID string) (bool, error) {
+	if l.txIDFilter != nil && !l.txIDFilter.mightContain(txID) {
+		return false, nil
+	}
+	exists, err := l.blockStore.TxIDExists(txID)
+	if err != nil {
+		return false, toLedgerError(err)
+	}
+	return exists, nil
+}
+
+// GetTransactionByID retrieves a transaction by id along with its validation code
+// and the location of the transaction in the ledger
 func (l *kvLedger) GetTransactionByID(txID string) (*peer.ProcessedTransaction, error) {
 
 	tranEnv, err := l.blockStore.RetrieveTxByID(txID)
 	if err != nil {
-		return nil, err
+		return nil, toLedgerError(err)
 	}
 
 	txVResult, err := l.blockStore.RetrieveTxValidationCodeByTxID(txID)
 
 	if err != nil {
-		return nil, err
+		return nil, toLedgerError(err)
 	}
 
-	processedTran := &peer.ProcessedTransaction{TransactionEnvelope: tranEnv, ValidationCode: int32(txVResult)}
+	blockNum, tranNum, err := l.blockStore.RetrieveTxLocByTxID(txID)
+	if err != nil {
+		return nil, toLedgerError(err)
+	}
+
+	processedTran := &peer.ProcessedTransaction{
+		TransactionEnvelope: tranEnv,
+		ValidationCode:      int32(txVResult),
+		BlockNumber:         blockNum,
+		TxIndex:             tranNum,
+	}
 	return processedTran, nil
 }
 
@@ -156,7 +379,8 @@ func (l *kvLedger) GetBlockchainInfo() (*common.BlockchainInfo, error) {
 // GetBlockByNumber returns block at a given height
 // blockNumber of  math.MaxUint64 will return last block
 func (l *kvLedger) GetBlockByNumber(blockNumber uint64) (*common.Block, error) {
-	return l.blockStore.RetrieveBlockByNumber(blockNumber)
+	block, err := l.blockStore.RetrieveBlockByNumber(blockNumber)
+	return block, toLedgerError(err)
 
 }
 
@@ -170,21 +394,107 @@ func (l *kvLedger) GetBlocksIterator(startBlockNumber uint64) (commonledger.Resu
 
 // GetBlockByHash returns a block given it's hash
 func (l *kvLedger) GetBlockByHash(blockHash []byte) (*common.Block, error) {
-	return l.blockStore.RetrieveBlockByHash(blockHash)
+	block, err := l.blockStore.RetrieveBlockByHash(blockHash)
+	return block, toLedgerError(err)
 }
 
 // GetBlockByTxID returns a block which contains a transaction
 func (l *kvLedger) GetBlockByTxID(txID string) (*common.Block, error) {
-	return l.blockStore.RetrieveBlockByTxID(txID)
+	block, err := l.blockStore.RetrieveBlockByTxID(txID)
+	return block, toLedgerError(err)
 }
 
+// GetTxValidationCodeByTxID returns the validation code of the transaction with the given id
 func (l *kvLedger) GetTxValidationCodeByTxID(txID string) (peer.TxValidationCode, error) {
-	return l.blockStore.RetrieveTxValidationCodeByTxID(txID)
+	txValidationCode, err := l.blockStore.RetrieveTxValidationCodeByTxID(txID)
+	return txValidationCode, toLedgerError(err)
+}
+
+// toLedgerError translates the errors of the block storage to the error kinds of the ledger
+func toLedgerError(err error) error {
+	switch err {
+	case blkstorage.ErrNotFoundInIndex:
+		return &ledger.NotFoundError{Msg: err.Error()}
+	case blkstorage.ErrAttrNotIndexed:
+		return &ledger.NotEnabledError{Msg: err.Error()}
+	default:
+		return err
+	}
+}
+
+// GetPvtDataByNum returns the private write sets of the transactions in the given block
+func (l *kvLedger) GetPvtDataByNum(blockNum uint64, filter ledger.PvtNsCollFilter) ([]*ledger.TxPvtData, error) {
+	return l.pvtdataStore.GetPvtDataByBlockNum(blockNum, filter)
+}
+
+// GetMissingPvtDataInfoForMostRecentBlocks returns the private write sets recorded as missing in the most recent blocks
+func (l *kvLedger) GetMissingPvtDataInfoForMostRecentBlocks(maxBlocks int) (ledger.MissingPvtDataInfo, error) {
+	return l.pvtdataStore.GetMissingPvtDataInfoForMostRecentBlocks(maxBlocks)
+}
+
+// CommitPvtDataOfOldBlocks commits the reconciled private write sets of the already committed blocks.
+// The private write sets are opaque to the ledger and are not applied to the state database,
+// hence only the private data store is patched
+func (l *kvLedger) CommitPvtDataOfOldBlocks(blocksPvtData []*ledger.BlockPvtData) error {
+	if l.readOnly {
+		return ErrLedgerReadOnly
+	}
+	return l.pvtdataStore.CommitPvtDataOfOldBlocks(blocksPvtData)
+}
+
+// GetCollectionConfigAt returns the collection config package of the chaincode that was in force at the given height
+func (l *kvLedger) GetCollectionConfigAt(chaincodeName string, height uint64) (*ledger.CollectionConfigInfo, error) {
+	return l.configHistoryMgr.MostRecentCollectionConfigBelow(height, chaincodeName)
+}
+
+// GetEventsByChaincode returns the events of the given chaincode set by the valid transactions in the blocks from startBlock
+// to endBlock, both inclusive. Only the hashes of the payloads of the events are returned
+func (l *kvLedger) GetEventsByChaincode(chaincodeName string, startBlock, endBlock uint64) ([]*peer.ChaincodeEventInfo, error) {
+	return l.ccEventsIndex.GetEventsByChaincode(chaincodeName, startBlock, endBlock)
+}
+
+// GetTxResultByID returns the result of the execution of the chaincode by the valid transaction with the given id
+func (l *kvLedger) GetTxResultByID(txID string) (*ledger.TxResult, error) {
+	if !ledgerconfig.IsTxResultsArchiveEnabled() {
+		return nil, &ledger.NotEnabledError{Msg: "The archive of the transaction results is disabled"}
+	}
+	return l.txResultsArchive.GetTxResultByID(txID)
+}
+
+// GetInvalidTransactions returns the most recent transactions in the log of the invalidated transactions
+func (l *kvLedger) GetInvalidTransactions(maxEntries int) ([]*ledger.InvalidTransaction, error) {
+	if !ledgerconfig.IsInvalidTxLogEnabled() {
+		return nil, &ledger.NotEnabledError{Msg: "The log of the invalid transactions is disabled - ledger.invalidTxLog.maxEntries is not set"}
+	}
+	return l.invalidTxsLog.GetInvalidTransactions(maxEntries)
 }
 
 //Prune prunes the blocks/transactions that satisfy the given policy
 func (l *kvLedger) Prune(policy commonledger.PrunePolicy) error {
-	return errors.New("Not yet implemented")
+	return &ledger.NotEnabledError{Msg: "Not yet implemented"}
+}
+
+// getBlockchainInfoAt returns the blockchain info for the given height. The block storage is ahead of the
+// state database by a block during a commit and hence the given height may be one less than the height of the block storage
+func (l *kvLedger) getBlockchainInfoAt(height uint64) (*common.BlockchainInfo, error) {
+	bcInfo, err := l.blockStore.GetBlockchainInfo()
+	if err != nil {
+		return nil, err
+	}
+	if bcInfo.Height == height {
+		return bcInfo, nil
+	}
+	if height == 0 || bcInfo.Height != height+1 {
+		return nil, &ledger.CorruptionError{Msg: fmt.Sprintf("Height [%d] of the state database is not in sync with height [%d] of the block storage", height, bcInfo.Height)}
+	}
+	lastBlock, err := l.blockStore.RetrieveBlockByNumber(height - 1)
+	if err != nil {
+		return nil, err
+	}
+	return &common.BlockchainInfo{
+		Height:            height,
+		CurrentBlockHash:  bcInfo.PreviousBlockHash,
+		PreviousBlockHash: lastBlock.Header.PreviousHash}, nil
 }
 
 // NewTxSimulator returns new `ledger.TxSimulator`
@@ -199,49 +509,266 @@ func (l *kvLedger) NewQueryExecutor() (ledger.QueryExecutor, error) {
 	return l.txtmgmt.NewQueryExecutor()
 }
 
+// NewQueryExecutorAtHeight gives handle to a query executor that reads the state as of the given recent height
+func (l *kvLedger) NewQueryExecutorAtHeight(height uint64) (ledger.QueryExecutor, error) {
+	return l.txtmgmt.NewQueryExecutorAtHeight(height)
+}
+
 // NewHistoryQueryExecutor gives handle to a history query executor.
 // A client can obtain more than one 'HistoryQueryExecutor's for parallel execution.
 // Any synchronization should be performed at the implementation level if required
 // Pass the ledger blockstore so that historical values can be looked up from the chain
 func (l *kvLedger) NewHistoryQueryExecutor() (ledger.HistoryQueryExecutor, error) {
+	if !l.config.HistoryDatabase {
+		return &disabledHistoryQueryExecutor{}, nil
+	}
 	return l.historyDB.NewHistoryQueryExecutor(l.blockStore)
 }
 
+// disabledHistoryQueryExecutor is used for a ledger that does not maintain the history database
+type disabledHistoryQueryExecutor struct {
+}
+
+// GetHistoryForKey implements method in interface `ledger.HistoryQueryExecutor`
+func (q *disabledHistoryQueryExecutor) GetHistoryForKey(namespace string, key string) (commonledger.ResultsIterator, error) {
+	return nil, &ledger.NotEnabledError{Msg: "History tracking not enabled - historyDatabase is false"}
+}
+
+// GetRecentHistoryForKey implements method in interface `ledger.HistoryQueryExecutor`
+func (q *disabledHistoryQueryExecutor) GetRecentHistoryForKey(namespace string, key string, limit int) (commonledger.ResultsIterator, error) {
+	return nil, &ledger.NotEnabledError{Msg: "History tracking not enabled - historyDatabase is false"}
+}
+
+// GetHistoryForKeyWithOptions implements method in interface `ledger.HistoryQueryExecutor`
+func (q *disabledHistoryQueryExecutor) GetHistoryForKeyWithOptions(namespace string, key string,
+	options *ledger.HistoryQueryOptions) (commonledger.ResultsIterator, error) {
+	return nil, &ledger.NotEnabledError{Msg: "History tracking not enabled - historyDatabase is false"}
+}
+
+// GetStateAsOf implements method in interface `ledger.HistoryQueryExecutor`
+func (q *disabledHistoryQueryExecutor) GetStateAsOf(namespace string, key string, blockHeight uint64) ([]byte, error) {
+	return nil, &ledger.NotEnabledError{Msg: "History tracking not enabled - historyDatabase is false"}
+}
+
 // Commit commits the valid block (returned in the method RemoveInvalidTransactionsAndPrepare) and related state changes
 func (l *kvLedger) Commit(block *common.Block) error {
+	return l.CommitWithPvtData(block, nil, nil)
+}
+
+// CommitWithPvtData commits the block along with the private write sets of its transactions.
+// The private data is prepared in the private data store before the block is added to the block storage
+// and is committed after, so that the private data store is recovered along with the block storage after a crash
+func (l *kvLedger) CommitWithPvtData(block *common.Block, pvtData []*ledger.TxPvtData, missingPvtData []*ledger.MissingPvtData) error {
+	if l.readOnly {
+		return ErrLedgerReadOnly
+	}
 	var err error
 	blockNo := block.Header.Number
+	blockLogger := logger.With(flogging.Fields{"channel": l.ledgerID, "block": blockNo})
+	commitSpan, ctx := tracing.StartSpan(context.Background(), "ledger.Commit")
+	commitSpan.SetTag("channel", l.ledgerID)
+	commitSpan.SetTag("block", fmt.Sprintf("%d", blockNo))
+	defer commitSpan.Finish()
 
-	logger.Debugf("Channel [%s]: Validating block [%d]", l.ledgerID, blockNo)
-	err = l.txtmgmt.ValidateAndPrepare(block, true)
+	info, err := l.blockStore.GetBlockchainInfo()
 	if err != nil {
 		return err
 	}
+	if blockNo < info.Height {
+		blockLogger.Warningf("Ignoring block as it is already committed, ledger height is [%d]", info.Height)
+		return &ledger.BlockAlreadyCommittedError{BlockNum: blockNo, Height: info.Height}
+	}
 
-	logger.Debugf("Channel [%s]: Committing block [%d] to storage", l.ledgerID, blockNo)
-	if err = l.blockStore.AddBlock(block); err != nil {
+	blockLogger.Debug("Validating block")
+	validateSpan, _ := tracing.StartSpan(ctx, "ledger.ValidateAndPrepare")
+	updateBytes, err := l.txtmgmt.ValidateAndPrepare(block, true)
+	validateSpan.Finish()
+	if err != nil {
 		return err
 	}
-	logger.Infof("Channel [%s]: Created block [%d] with %d transaction(s)", l.ledgerID, block.Header.Number, len(block.Data.Data))
+	commitHash, err := computeCommitHash(l.commitHash, block, updateBytes)
+	if err != nil {
+		return err
+	}
+	if err = setCommitHash(block, commitHash); err != nil {
+		return err
+	}
+	if l.writeAheadLog != nil {
+		if err = l.writeAheadLog.begin(&commitIntent{blockNum: blockNo, stores: l.commitStores()}); err != nil {
+			return err
+		}
+	}
+	if l.stateTrie != nil {
+		// the trie is committed ahead of the block storage as the root goes into the block. The root is
+		// removed if the block does not make it to the block storage, at the latest when the ledger is opened
+		if err = l.commitStateRoot(block, updateBytes); err != nil {
+			l.abortCommit(blockNo, false, blockLogger)
+			return err
+		}
+	}
 
-	logger.Debugf("Channel [%s]: Committing block [%d] transactions to state database", l.ledgerID, blockNo)
+	blockLogger.Debug("Committing block to storage")
+	if err = l.pvtdataStore.Prepare(blockNo, pvtData, missingPvtData); err != nil {
+		l.abortCommit(blockNo, false, blockLogger)
+		return err
+	}
+	blockStoreStart := time.Now()
+	blockStoreSpan, _ := tracing.StartSpan(ctx, "ledger.BlockStore")
+	err = l.blockStore.AddBlock(block)
+	blockStoreSpan.Finish()
+	if err != nil {
+		l.abortCommit(blockNo, true, blockLogger)
+		return err
+	}
+	observeCommitDuration(l.ledgerID, blockStoreMetricLabel, blockStoreStart)
+	if l.txIDFilter != nil {
+		l.txIDFilter.addBlock(block)
+	}
+	if err = l.pvtdataStore.Commit(); err != nil {
+		panic(fmt.Errorf(`Error during commit to pvtdata store:%s`, err))
+	}
+	recordBlockStats(l.ledgerID, block)
+	l.commitHash = commitHash
+	blockLogger.Infof("Created block with %d transaction(s)", len(block.Data.Data))
+
+	blockLogger.Debug("Committing block transactions to state database")
+	stateDBStart := time.Now()
+	stateDBSpan, _ := tracing.StartSpan(ctx, "ledger.StateDB")
 	if err = l.txtmgmt.Commit(); err != nil {
 		panic(fmt.Errorf(`Error during commit to txmgr:%s`, err))
 	}
+	stateDBSpan.Finish()
+	observeCommitDuration(l.ledgerID, stateDBMetricLabel, stateDBStart)
+	if err := l.dbSyncer.BlockWritten(); err != nil {
+		panic(fmt.Errorf(`Error during flush of state db and history db:%s`, err))
+	}
+	if l.recentKeys != nil {
+		if err := l.recentKeys.addBlock(block); err != nil {
+			blockLogger.Warningf("Error while tracking the recent keys of the state database: %s", err)
+		}
+	}
+	if l.namespaceStats != nil {
+		if err := l.namespaceStats.addBlock(block); err != nil {
+			blockLogger.Warningf("Error while counting the operations of the namespaces: %s", err)
+		}
+	}
 
-	// History database could be written in parallel with state and/or async as a future optimization
-	if ledgerconfig.IsHistoryDBEnabled() {
-		logger.Debugf("Channel [%s]: Committing block [%d] transactions to history database", l.ledgerID, blockNo)
+	if l.historyCommitter != nil {
+		l.historyCommitter.submit(block)
+	} else if l.config.HistoryDatabase {
+		blockLogger.Debug("Committing block transactions to history database")
+		historyDBStart := time.Now()
+		historyDBSpan, _ := tracing.StartSpan(ctx, "ledger.HistoryDB")
 		if err := l.historyDB.Commit(block); err != nil {
 			panic(fmt.Errorf(`Error during commit to history db:%s`, err))
 		}
+		historyDBSpan.Finish()
+		observeCommitDuration(l.ledgerID, historyDBMetricLabel, historyDBStart)
+	}
+
+	blockLogger.Debug("Committing block collection configs to config history")
+	if err := l.configHistoryMgr.Commit(block); err != nil {
+		panic(fmt.Errorf(`Error during commit to config history:%s`, err))
 	}
 
+	blockLogger.Debug("Indexing block chaincode events")
+	if err := l.ccEventsIndex.Commit(block); err != nil {
+		panic(fmt.Errorf(`Error during commit to chaincode events index:%s`, err))
+	}
+
+	if ledgerconfig.IsTxResultsArchiveEnabled() {
+		blockLogger.Debug("Archiving block transaction results")
+		if err := l.txResultsArchive.Commit(block); err != nil {
+			panic(fmt.Errorf(`Error during commit to transaction results archive:%s`, err))
+		}
+	}
+
+	if ledgerconfig.IsInvalidTxLogEnabled() {
+		blockLogger.Debug("Logging block invalid transactions")
+		if err := l.invalidTxsLog.Commit(block); err != nil {
+			panic(fmt.Errorf(`Error during commit to invalid transactions log:%s`, err))
+		}
+	}
+
+	for _, d := range l.commitDecorators {
+		blockLogger.Debugf("Delivering block to commit decorator [%s]", d.name)
+		if err := d.commit(block); err != nil {
+			panic(fmt.Errorf(`Error during commit to commit decorator [%s]:%s`, d.name, err))
+		}
+	}
+
+	l.endWriteAheadLog(blockLogger)
+	l.recordGenesisBlockHash(block)
+	l.notifyConfigBlockListeners(block)
 	return nil
 }
 
+// commitStateRoot commits the state updates of the block to the state trie and records the resulting root in the block
+func (l *kvLedger) commitStateRoot(block *common.Block, updateBytes []byte) error {
+	updates, err := decodeStateUpdates(updateBytes)
+	if err != nil {
+		return err
+	}
+	stateRoot, err := l.stateTrie.Commit(block.Header.Number, updates)
+	if err != nil {
+		return err
+	}
+	return setStateRoot(block, stateRoot)
+}
+
+// abortCommit undoes what a failed commit of the block has written since the intent of the block was logged, i.e.,
+// the private data if they were prepared and the state root, and clears the intent. A failure is only logged, as
+// the leftovers are removed at the latest when the ledger is opened
+func (l *kvLedger) abortCommit(blockNo uint64, pvtdataPrepared bool, blockLogger *flogging.FieldLogger) {
+	if pvtdataPrepared {
+		if err := l.pvtdataStore.Rollback(); err != nil {
+			blockLogger.Errorf("Error while discarding the private data of block: %s", err)
+		}
+	}
+	if l.stateTrie != nil {
+		if err := l.stateTrie.Truncate(blockNo); err != nil {
+			blockLogger.Errorf("Error while removing the state root of block: %s", err)
+		}
+	}
+	l.endWriteAheadLog(blockLogger)
+}
+
+// endWriteAheadLog clears the intent of the block. An intent left behind is only completed again when the ledger is opened
+func (l *kvLedger) endWriteAheadLog(blockLogger *flogging.FieldLogger) {
+	if l.writeAheadLog == nil {
+		return
+	}
+	if err := l.writeAheadLog.end(); err != nil {
+		blockLogger.Warningf("Error while clearing the write-ahead log: %s", err)
+	}
+}
+
 // Close closes `KVLedger`
 func (l *kvLedger) Close() {
+	if l.historyCommitter != nil {
+		l.historyCommitter.close()
+		l.historyCommitter = nil
+	}
+	if l.dbSyncer != nil {
+		if err := l.dbSyncer.Close(); err != nil {
+			logger.With(flogging.Fields{"channel": l.ledgerID}).Errorf("Error while flushing state db and history db: %s", err)
+		}
+	}
+	if l.recentKeys != nil {
+		if err := l.recentKeys.persist(); err != nil {
+			logger.With(flogging.Fields{"channel": l.ledgerID}).Errorf("Error while persisting the recent keys of the state database: %s", err)
+		}
+	}
+	if l.namespaceSizes != nil {
+		if err := l.namespaceSizes.persist(); err != nil {
+			logger.With(flogging.Fields{"channel": l.ledgerID}).Errorf("Error while persisting the sizes of the namespaces: %s", err)
+		}
+	}
+	if l.txIDFilter != nil {
+		l.txIDFilter.close()
+	}
 	l.blockStore.Shutdown()
+	l.pvtdataStore.Shutdown()
 	l.txtmgmt.Shutdown()
+	closeCommitDecorators(l.commitDecorators)
 }
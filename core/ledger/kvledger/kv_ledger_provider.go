@@ -17,35 +17,79 @@ limitations under the License.
 package kvledger
 
 import (
-	"errors"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
 
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/flogging"
 	"github.com/hyperledger/fabric/common/ledger/blkstorage"
 	"github.com/hyperledger/fabric/common/ledger/blkstorage/fsblkstorage"
+	"github.com/hyperledger/fabric/common/ledger/util"
 	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
 	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/ccevents"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/confighistory"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/history/historydb"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/history/historydb/historyleveldb"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/invalidtxs"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/statetrie"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb/statecouchdb"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb/stateleveldb"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txresults"
 	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+	"github.com/hyperledger/fabric/core/ledger/pvtdatastorage"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
 )
 
 var (
 	// ErrLedgerIDExists is thrown by a CreateLedger call if a ledger with the given id already exists
-	ErrLedgerIDExists = errors.New("LedgerID already exists")
+	ErrLedgerIDExists = &ledger.ConflictError{Msg: "LedgerID already exists"}
 	// ErrNonExistingLedgerID is thrown by a OpenLedger call if a ledger with the given id does not exist
-	ErrNonExistingLedgerID = errors.New("LedgerID does not exist")
+	ErrNonExistingLedgerID = &ledger.NotFoundError{Msg: "LedgerID does not exist"}
 	// ErrLedgerNotOpened is thrown by a CloseLedger call if a ledger with the given id has not been opened
-	ErrLedgerNotOpened = errors.New("Ledger is not opened yet")
+	ErrLedgerNotOpened = &ledger.UnavailableError{Msg: "Ledger is not opened yet"}
+	// ErrLedgerNotActive is thrown by a OpenLedger call if the ledger with the given id is not in the active status
+	ErrLedgerNotActive = &ledger.UnavailableError{Msg: "Ledger is not active"}
+	// ErrLedgerReadOnly is thrown by the calls that modify the ledgers when the ledgers are opened in read-only mode
+	ErrLedgerReadOnly = &ledger.NotEnabledError{Msg: "Ledger is opened in read-only mode"}
 )
 
 // Provider implements interface ledger.PeerLedgerProvider
 type Provider struct {
 	idStore            *idStore
 	blockStoreProvider blkstorage.BlockStoreProvider
-	vdbProvider        statedb.VersionedDBProvider
 	historydbProvider  historydb.HistoryDBProvider
+	// pvtdataStoreProvider maintains the private write sets of the transactions
+	pvtdataStoreProvider pvtdatastorage.Provider
+	// configHistoryProvider maintains the history of the collection configs
+	configHistoryProvider *confighistory.Provider
+	// ccEventsProvider maintains the index of the chaincode events
+	ccEventsProvider *ccevents.Provider
+	// txResultsProvider maintains the archive of the results of the transactions
+	txResultsProvider *txresults.Provider
+	// invalidTxsProvider maintains the logs of the invalidated transactions
+	invalidTxsProvider *invalidtxs.Provider
+	// stateTrieProvider maintains the state tries
+	stateTrieProvider *statetrie.Provider
+	readOnly             bool
+	// the state database providers are constructed on the first use as
+	// the state database is chosen by the configuration of each channel.
+	// vdbProviderLock guards the construction as the ledgers may be opened in parallel
+	levelDBProvider statedb.VersionedDBProvider
+	couchDBProvider statedb.VersionedDBProvider
+	// systemVDBProvider keeps the system namespaces of the ledgers that store them apart from the state database
+	systemVDBProvider statedb.VersionedDBProvider
+	vdbProviderLock   sync.Mutex
+	// commitDecoratorProviders are the providers registered when the ledger provider is constructed.
+	// commitDecoratorsDBProvider maintains the savepoints of the decorators and is nil in read-only mode
+	commitDecoratorProviders   []ledger.CommitDecoratorProvider
+	commitDecoratorsDBProvider *leveldbhelper.Provider
 }
 
 // NewProvider instantiates a new Provider.
@@ -54,47 +98,300 @@ func NewProvider() (ledger.PeerLedgerProvider, error) {
 
 	logger.Info("Initializing ledger provider")
 
+	shared, err := getSharedLevelDB(false)
+	if err != nil {
+		return nil, err
+	}
+
 	// Initialize the ID store (inventory of chainIds/ledgerIds)
-	idStore := openIDStore(ledgerconfig.GetLedgerProviderPath())
+	idStore := openIDStore(ledgerconfig.GetLedgerProviderPath(), shared)
+	if err := checkSharedLevelDBSetting(idStore, shared); err != nil {
+		idStore.close()
+		return nil, err
+	}
 
 	// Initialize the block storage
-	attrsToIndex := []blkstorage.IndexableAttr{
-		blkstorage.IndexableAttrBlockHash,
-		blkstorage.IndexableAttrBlockNum,
-		blkstorage.IndexableAttrTxID,
-		blkstorage.IndexableAttrBlockNumTranNum,
-		blkstorage.IndexableAttrBlockTxID,
-		blkstorage.IndexableAttrTxValidationCode,
+	blockStoreProvider := newBlockStoreProvider(shared)
+
+	// Initialize the history database (index for history of values by key)
+	historydbProvider := newHistoryDBProvider(shared)
+
+	// Refuse to use the stores that hold their data in a format other than the current one
+	if err := checkDataFormats(idStore, blockStoreProvider, historydbProvider, false); err != nil {
+		historydbProvider.Close()
+		blockStoreProvider.Close()
+		idStore.close()
+		return nil, err
+	}
+
+	// Initialize the private data store
+	pvtdataStoreProvider := pvtdatastorage.NewProvider()
+
+	// Initialize the history of the collection configs
+	configHistoryProvider := confighistory.NewProvider()
+
+	// Initialize the index of the chaincode events
+	ccEventsProvider := ccevents.NewProvider()
+
+	// Initialize the archive of the results of the transactions
+	txResultsProvider := txresults.NewProvider()
+
+	// Initialize the log of the invalidated transactions
+	invalidTxsProvider := invalidtxs.NewProvider()
+
+	// Initialize the state tries
+	stateTrieProvider, err := statetrie.NewProvider()
+	if err != nil {
+		return nil, err
 	}
-	indexConfig := &blkstorage.IndexConfig{AttrsToIndex: attrsToIndex}
-	blockStoreProvider := fsblkstorage.NewProvider(
-		fsblkstorage.NewConf(ledgerconfig.GetBlockStorePath(), ledgerconfig.GetMaxBlockfileSize()),
-		indexConfig)
 
-	// Initialize the versioned database (state database)
-	var vdbProvider statedb.VersionedDBProvider
-	if !ledgerconfig.IsCouchDBEnabled() {
-		logger.Debug("Constructing leveldb VersionedDBProvider")
-		vdbProvider = stateleveldb.NewVersionedDBProvider()
+	// Initialize the savepoints of the commit decorators
+	commitDecoratorsDBProvider := leveldbhelper.NewProvider(&leveldbhelper.Conf{DBPath: ledgerconfig.GetCommitDecoratorsPath()})
+
+	provider := &Provider{idStore: idStore, blockStoreProvider: blockStoreProvider, historydbProvider: historydbProvider,
+		pvtdataStoreProvider: pvtdataStoreProvider, configHistoryProvider: configHistoryProvider, ccEventsProvider: ccEventsProvider,
+		txResultsProvider: txResultsProvider, invalidTxsProvider: invalidTxsProvider, stateTrieProvider: stateTrieProvider,
+		commitDecoratorProviders: registeredCommitDecoratorProviders(), commitDecoratorsDBProvider: commitDecoratorsDBProvider}
+	// Clean up the ledgers whose creation or deletion was interrupted by a crash
+	if err := provider.recoverIncompleteLedgers(); err != nil {
+		return nil, err
+	}
+	logger.Info("ledger provider Initialized")
+	return provider, nil
+}
+
+// NewReadOnlyProvider instantiates a new Provider that opens the existing ledgers in read-only mode.
+// The stores are opened without exclusive locks and are not modified, neither by a recovery nor by a commit.
+// This is intended for the offline tools that read the ledgers while the peer is stopped
+// or read a copy of the ledgers from a filesystem snapshot
+func NewReadOnlyProvider() (ledger.PeerLedgerProvider, error) {
+	logger.Info("Initializing ledger provider in read-only mode")
+	shared, err := getSharedLevelDB(true)
+	if err != nil {
+		return nil, err
+	}
+	idStore := openReadOnlyIDStore(ledgerconfig.GetLedgerProviderPath(), shared)
+	if err := checkSharedLevelDBSetting(idStore, shared); err != nil {
+		idStore.close()
+		return nil, err
+	}
+	conf := fsblkstorage.NewReadOnlyConf(ledgerconfig.GetBlockStorePath())
+	configureBlockIndex(conf, shared)
+	blockStoreProvider := fsblkstorage.NewProvider(conf, blockStoreIndexConfig())
+	var historydbProvider *historyleveldb.HistoryDBProvider
+	if shared != nil {
+		historydbProvider = historyleveldb.NewSharedHistoryDBProvider(leveldbhelper.NewSharedProvider(shared.conf, shared.prefixes.History))
 	} else {
-		logger.Debug("Constructing CouchDB VersionedDBProvider")
+		historydbProvider = historyleveldb.NewReadOnlyHistoryDBProvider()
+	}
+	if err := checkDataFormats(idStore, blockStoreProvider, historydbProvider, true); err != nil {
+		historydbProvider.Close()
+		blockStoreProvider.Close()
+		idStore.close()
+		return nil, err
+	}
+	pvtdataStoreProvider := pvtdatastorage.NewReadOnlyProvider()
+	configHistoryProvider := confighistory.NewReadOnlyProvider()
+	ccEventsProvider := ccevents.NewReadOnlyProvider()
+	txResultsProvider := txresults.NewReadOnlyProvider()
+	invalidTxsProvider := invalidtxs.NewReadOnlyProvider()
+	stateTrieProvider, err := statetrie.NewReadOnlyProvider()
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("ledger provider Initialized in read-only mode")
+	return &Provider{idStore: idStore, blockStoreProvider: blockStoreProvider, historydbProvider: historydbProvider,
+		pvtdataStoreProvider: pvtdataStoreProvider, configHistoryProvider: configHistoryProvider, ccEventsProvider: ccEventsProvider,
+		txResultsProvider: txResultsProvider, invalidTxsProvider: invalidTxsProvider, stateTrieProvider: stateTrieProvider, readOnly: true}, nil
+}
+
+// OpenReadOnlyBlockStore opens the block store of the given ledger in read-only mode, without opening the
+// state and the history databases. This is intended for the offline tools that only inspect the blocks.
+// The returned provider is to be closed once the block store is no longer used
+func OpenReadOnlyBlockStore(ledgerID string) (blkstorage.BlockStoreProvider, blkstorage.BlockStore, error) {
+	shared, err := getSharedLevelDB(true)
+	if err != nil {
+		return nil, nil, err
+	}
+	conf := fsblkstorage.NewReadOnlyConf(ledgerconfig.GetBlockStorePath())
+	configureBlockIndex(conf, shared)
+	blockStoreProvider := fsblkstorage.NewProvider(conf, blockStoreIndexConfig())
+	if err := checkDataFormat(blockStoreName, blockStoreProvider, true); err != nil {
+		blockStoreProvider.Close()
+		return nil, nil, err
+	}
+	exists, err := blockStoreProvider.Exists(ledgerID)
+	if err != nil {
+		blockStoreProvider.Close()
+		return nil, nil, err
+	}
+	if !exists {
+		blockStoreProvider.Close()
+		return nil, nil, ErrNonExistingLedgerID
+	}
+	blockStore, err := blockStoreProvider.OpenBlockStore(ledgerID)
+	if err != nil {
+		blockStoreProvider.Close()
+		return nil, nil, err
+	}
+	return blockStoreProvider, blockStore, nil
+}
+
+// getVDBProvider returns the provider of the state database chosen by the given channel configuration
+func (provider *Provider) getVDBProvider(config *ledgerconfig.ChannelConfig) (statedb.VersionedDBProvider, error) {
+	provider.vdbProviderLock.Lock()
+	defer provider.vdbProviderLock.Unlock()
+	if !config.IsCouchDBEnabled() {
+		if provider.levelDBProvider == nil {
+			if provider.readOnly {
+				logger.Debug("Constructing read-only leveldb VersionedDBProvider")
+				provider.levelDBProvider = stateleveldb.NewReadOnlyVersionedDBProvider()
+			} else {
+				logger.Debug("Constructing leveldb VersionedDBProvider")
+				provider.levelDBProvider = stateleveldb.NewVersionedDBProvider()
+			}
+		}
+		return provider.levelDBProvider, nil
+	}
+	if provider.couchDBProvider == nil {
+		var couchDBProvider *statecouchdb.VersionedDBProvider
 		var err error
-		vdbProvider, err = statecouchdb.NewVersionedDBProvider()
+		if provider.readOnly {
+			logger.Debug("Constructing read-only CouchDB VersionedDBProvider")
+			couchDBProvider, err = statecouchdb.NewReadOnlyVersionedDBProvider()
+		} else {
+			logger.Debug("Constructing CouchDB VersionedDBProvider")
+			couchDBProvider, err = statecouchdb.NewVersionedDBProvider()
+		}
 		if err != nil {
 			return nil, err
 		}
+		provider.couchDBProvider = couchDBProvider
 	}
+	return provider.couchDBProvider, nil
+}
 
-	// Initialize the history database (index for history of values by key)
-	var historydbProvider historydb.HistoryDBProvider
-	historydbProvider = historyleveldb.NewHistoryDBProvider()
+// setQueryLimit applies the query limit of the channel to the state database that supports rich queries
+func setQueryLimit(vDB statedb.VersionedDB, config *ledgerconfig.ChannelConfig) {
+	if couchDB, ok := vDB.(*statecouchdb.VersionedDB); ok {
+		couchDB.SetQueryLimit(config.QueryLimit)
+	}
+}
 
-	logger.Info("ledger provider Initialized")
-	return &Provider{idStore, blockStoreProvider, vdbProvider, historydbProvider}, nil
+// getLedgerConfig returns the configuration persisted with the ledger. The configuration is resolved
+// from the peer configuration for the ledgers created before the configuration was persisted
+func (provider *Provider) getLedgerConfig(ledgerID string) (*ledgerconfig.ChannelConfig, error) {
+	metadata, err := provider.idStore.getLedgerMetadata(ledgerID)
+	if err != nil {
+		return nil, err
+	}
+	if metadata == nil {
+		return nil, ErrNonExistingLedgerID
+	}
+	if metadata.config == nil {
+		return ledgerconfig.GetChannelConfig(ledgerID), nil
+	}
+	return metadata.config, nil
+}
+
+func newBlockStoreProvider(shared *sharedLevelDB) blkstorage.BlockStoreProvider {
+	conf := fsblkstorage.NewConf(ledgerconfig.GetBlockStorePath(), ledgerconfig.GetMaxBlockfileSize())
+	conf.SetSyncPolicy(ledgerconfig.GetBlockfileSyncPolicy())
+	configureBlockIndex(conf, shared)
+	return fsblkstorage.NewProvider(conf, blockStoreIndexConfig())
+}
+
+func newHistoryDBProvider(shared *sharedLevelDB) *historyleveldb.HistoryDBProvider {
+	if shared != nil {
+		return historyleveldb.NewSharedHistoryDBProvider(leveldbhelper.NewSharedProvider(shared.conf, shared.prefixes.History))
+	}
+	return historyleveldb.NewHistoryDBProvider()
+}
+
+// configureBlockIndex keeps the block index either in its dedicated leveldb or in the shared leveldb
+func configureBlockIndex(conf *fsblkstorage.Conf, shared *sharedLevelDB) {
+	if shared == nil {
+		conf.SetIndexTuning(ledgerconfig.GetBlockIndexLevelDBTuning())
+		return
+	}
+	conf.SetIndexTuning(shared.conf.Tuning)
+	conf.SetSharedIndexDB(shared.conf.DBPath, shared.prefixes.BlockIndex)
+}
+
+// sharedLevelDB is the leveldb shared by the block index, the history database, and the id store
+type sharedLevelDB struct {
+	conf     *leveldbhelper.Conf
+	prefixes *ledgerconfig.SharedLevelDBPrefixes
+}
+
+// getSharedLevelDB returns nil if the shared leveldb is not enabled and the stores use their dedicated leveldbs
+func getSharedLevelDB(readOnly bool) (*sharedLevelDB, error) {
+	if !ledgerconfig.IsSharedLevelDBEnabled() {
+		return nil, nil
+	}
+	prefixes, err := ledgerconfig.GetSharedLevelDBPrefixes()
+	if err != nil {
+		return nil, err
+	}
+	conf := &leveldbhelper.Conf{DBPath: ledgerconfig.GetSharedLevelDBPath(), ReadOnly: readOnly, Tuning: ledgerconfig.GetSharedLevelDBTuning()}
+	return &sharedLevelDB{conf, prefixes}, nil
+}
+
+// checkSharedLevelDBSetting returns an error if the id store opened as per the setting of the shared leveldb holds
+// no ledger whereas the id store of the other setting does. The ledgers are not moved between the dedicated and the
+// shared leveldbs, hence the ledgers would be missing from the inventory and their block indexes and histories orphaned
+func checkSharedLevelDBSetting(idStore *idStore, shared *sharedLevelDB) error {
+	empty, err := idStore.IsEmpty()
+	if err != nil || !empty {
+		return err
+	}
+	otherPath := ledgerconfig.GetSharedLevelDBPath()
+	var otherShared *sharedLevelDB
+	if shared != nil {
+		otherPath = ledgerconfig.GetLedgerProviderPath()
+	} else {
+		prefixes, err := ledgerconfig.GetSharedLevelDBPrefixes()
+		if err != nil {
+			return err
+		}
+		otherShared = &sharedLevelDB{&leveldbhelper.Conf{DBPath: otherPath, ReadOnly: true, Tuning: ledgerconfig.GetSharedLevelDBTuning()}, prefixes}
+	}
+	otherEmpty, err := util.DirEmpty(otherPath)
+	if os.IsNotExist(err) || otherEmpty {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	otherIDStore := openReadOnlyIDStore(otherPath, otherShared)
+	defer otherIDStore.close()
+	if otherEmpty, err = otherIDStore.IsEmpty(); err != nil || otherEmpty {
+		return err
+	}
+	return fmt.Errorf("The ledgers of the peer are kept in the leveldbs at [%s] whereas ledger.sharedLevelDB.enabled is %t. "+
+		"The ledgers are not moved when the setting is changed, set ledger.sharedLevelDB.enabled back to %t",
+		otherPath, shared != nil, shared == nil)
+}
+
+func blockStoreIndexConfig() *blkstorage.IndexConfig {
+	attrsToIndex := []blkstorage.IndexableAttr{
+		blkstorage.IndexableAttrBlockHash,
+		blkstorage.IndexableAttrBlockNum,
+		blkstorage.IndexableAttrTxID,
+		blkstorage.IndexableAttrBlockNumTranNum,
+		blkstorage.IndexableAttrBlockTxID,
+		blkstorage.IndexableAttrTxValidationCode,
+		blkstorage.IndexableAttrTxBlockNumTranNum,
+		blkstorage.IndexableAttrBlockTimestamp,
+	}
+	return &blkstorage.IndexConfig{AttrsToIndex: attrsToIndex}
 }
 
 // Create implements the corresponding method from interface ledger.PeerLedgerProvider
 func (provider *Provider) Create(ledgerID string) (ledger.PeerLedger, error) {
+	if provider.readOnly {
+		return nil, ErrLedgerReadOnly
+	}
 	exists, err := provider.idStore.ledgerIDExists(ledgerID)
 	if err != nil {
 		return nil, err
@@ -102,23 +399,98 @@ func (provider *Provider) Create(ledgerID string) (ledger.PeerLedger, error) {
 	if exists {
 		return nil, ErrLedgerIDExists
 	}
-	provider.idStore.createLedgerID(ledgerID)
-	return provider.Open(ledgerID)
+	// the ledger remains under construction till all the underlying stores are created
+	config := ledgerconfig.GetChannelConfig(ledgerID)
+	logger.With(flogging.Fields{"channel": ledgerID}).Debugf("Creating ledger with configuration %+v", config)
+	if err := provider.idStore.createLedgerID(ledgerID, ledger.LedgerStatusUnderConstruction, config, 0); err != nil {
+		return nil, err
+	}
+	l, err := provider.openLedger(ledgerID)
+	if err != nil {
+		provider.removeIncompleteLedger(ledgerID)
+		return nil, err
+	}
+	if err := provider.idStore.updateLedgerStatus(ledgerID, ledger.LedgerStatusActive); err != nil {
+		l.Close()
+		provider.removeIncompleteLedger(ledgerID)
+		return nil, err
+	}
+	return l, nil
 }
 
 // Open implements the corresponding method from interface ledger.PeerLedgerProvider
 func (provider *Provider) Open(ledgerID string) (ledger.PeerLedger, error) {
 
-	logger.Debugf("Open() opening kvledger: %s", ledgerID)
+	logger.With(flogging.Fields{"channel": ledgerID}).Debug("Opening kvledger")
 
-	// Check the ID store to ensure that the chainId/ledgerId exists
-	exists, err := provider.idStore.ledgerIDExists(ledgerID)
+	// Check the ID store to ensure that the chainId/ledgerId exists and is active
+	metadata, err := provider.idStore.getLedgerMetadata(ledgerID)
 	if err != nil {
 		return nil, err
 	}
-	if !exists {
+	if metadata == nil {
 		return nil, ErrNonExistingLedgerID
 	}
+	if metadata.status != ledger.LedgerStatusActive {
+		logger.With(flogging.Fields{"channel": ledgerID}).Warningf("Cannot open ledger with status [%s]", metadata.status)
+		return nil, ErrLedgerNotActive
+	}
+	return provider.openLedger(ledgerID)
+}
+
+func (provider *Provider) openLedger(ledgerID string) (*kvLedger, error) {
+	config, err := provider.getLedgerConfig(ledgerID)
+	if err != nil {
+		return nil, err
+	}
+	vdbProvider, err := provider.getVDBProvider(config)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDataFormat(stateDBName, vdbProvider, provider.readOnly); err != nil {
+		return nil, err
+	}
+	if err := provider.runScheduledMaintenance(ledgerID); err != nil {
+		return nil, err
+	}
+
+	// Drop the state database and history database if marked for a rebuild (e.g., after a rollback).
+	// These are then rebuilt from the block storage by the recovery during the creation of kvLedger
+	rebuildDBs, err := provider.idStore.isRebuildDBsFlagSet(ledgerID)
+	if err != nil {
+		return nil, err
+	}
+	if rebuildDBs && provider.readOnly {
+		logger.With(flogging.Fields{"channel": ledgerID}).Warning("State DB and history DB are pending a rebuild and may not be in sync with block storage")
+		rebuildDBs = false
+	}
+	if rebuildDBs {
+		logger.With(flogging.Fields{"channel": ledgerID}).Info("Dropping state DB and history DB for a rebuild from block storage")
+		if err := vdbProvider.Drop(ledgerID); err != nil {
+			return nil, err
+		}
+		if err := provider.dropSystemState(ledgerID, config); err != nil {
+			return nil, err
+		}
+		if err := provider.historydbProvider.Drop(ledgerID); err != nil {
+			return nil, err
+		}
+		if err := provider.configHistoryProvider.Drop(ledgerID); err != nil {
+			return nil, err
+		}
+		if err := provider.ccEventsProvider.Drop(ledgerID); err != nil {
+			return nil, err
+		}
+		if err := provider.txResultsProvider.Drop(ledgerID); err != nil {
+			return nil, err
+		}
+		if err := provider.invalidTxsProvider.Drop(ledgerID); err != nil {
+			return nil, err
+		}
+		if err := provider.dropCommitDecorators(ledgerID); err != nil {
+			return nil, err
+		}
+	}
 
 	// Get the block store for a chain/ledger
 	blockStore, err := provider.blockStoreProvider.OpenBlockStore(ledgerID)
@@ -127,23 +499,67 @@ func (provider *Provider) Open(ledgerID string) (ledger.PeerLedger, error) {
 	}
 
 	// Get the versioned database (state database) for a chain/ledger
-	vDB, err := provider.vdbProvider.GetDBHandle(ledgerID)
+	vDB, err := vdbProvider.GetDBHandle(ledgerID)
 	if err != nil {
+		blockStore.Shutdown()
+		return nil, err
+	}
+	if err := checkDataFormat(stateDBName, vDB, provider.readOnly); err != nil {
+		blockStore.Shutdown()
+		return nil, err
+	}
+	setQueryLimit(vDB, config)
+	if vDB, err = provider.openSystemState(ledgerID, vDB, config); err != nil {
+		blockStore.Shutdown()
 		return nil, err
 	}
 
 	// Get the history database (index for history of values by key) for a chain/ledger
 	historyDB, err := provider.historydbProvider.GetDBHandle(ledgerID)
 	if err != nil {
+		blockStore.Shutdown()
+		return nil, err
+	}
+
+	// Get the private data store for a chain/ledger
+	pvtdataStore, err := provider.pvtdataStoreProvider.OpenStore(ledgerID)
+	if err != nil {
+		blockStore.Shutdown()
 		return nil, err
 	}
 
+	// Get the commit decorators (maintainers of the derived data) for a chain/ledger
+	var commitDecorators []*commitDecorator
+	if !provider.readOnly {
+		if commitDecorators, err = provider.openCommitDecorators(ledgerID); err != nil {
+			blockStore.Shutdown()
+			return nil, err
+		}
+	}
+
 	// Create a kvLedger for this chain/ledger, which encasulates the underlying data stores
-	// (id store, blockstore, state database, history database)
-	l, err := newKVLedger(ledgerID, blockStore, vDB, historyDB)
+	// (id store, blockstore, private data store, state database, history database)
+	l, err := newKVLedger(ledgerID, blockStore, pvtdataStore, vDB, historyDB,
+		provider.configHistoryProvider.GetMgr(ledgerID), provider.ccEventsProvider.GetIndex(ledgerID),
+		provider.txResultsProvider.GetArchive(ledgerID), provider.invalidTxsProvider.GetLog(ledgerID), provider.stateTrieProvider.GetStore(ledgerID),
+		commitDecorators, config, provider.readOnly)
 	if err != nil {
+		blockStore.Shutdown()
+		closeCommitDecorators(commitDecorators)
 		return nil, err
 	}
+	if rebuildDBs {
+		if err := provider.idStore.clearRebuildDBsFlag(ledgerID); err != nil {
+			l.Close()
+			return nil, err
+		}
+	}
+	if !provider.readOnly {
+		if err := provider.trackGenesisBlockHash(l); err != nil {
+			l.Close()
+			return nil, err
+		}
+	}
 	return l, nil
 }
 
@@ -153,39 +569,493 @@ func (provider *Provider) Exists(ledgerID string) (bool, error) {
 }
 
 // List implements the corresponding method from interface ledger.PeerLedgerProvider
-func (provider *Provider) List() ([]string, error) {
-	return provider.idStore.getAllLedgerIds()
+func (provider *Provider) List() ([]*ledger.LedgerInfo, error) {
+	ledgerIDs, err := provider.idStore.getAllLedgerIds()
+	if err != nil {
+		return nil, err
+	}
+	var ledgerInfos []*ledger.LedgerInfo
+	for _, ledgerID := range ledgerIDs {
+		metadata, err := provider.idStore.getLedgerMetadata(ledgerID)
+		if err != nil {
+			return nil, err
+		}
+		height, err := provider.blockStoreProvider.GetHeight(ledgerID)
+		if err != nil {
+			return nil, err
+		}
+		ledgerInfos = append(ledgerInfos, &ledger.LedgerInfo{LedgerID: ledgerID, Height: height, Status: metadata.status})
+	}
+	return ledgerInfos, nil
+}
+
+// Destroy implements the corresponding method from interface ledger.PeerLedgerProvider
+func (provider *Provider) Destroy(ledgerID string) error {
+	if provider.readOnly {
+		return ErrLedgerReadOnly
+	}
+	exists, err := provider.idStore.ledgerIDExists(ledgerID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrNonExistingLedgerID
+	}
+	logger.With(flogging.Fields{"channel": ledgerID}).Info("Destroying ledger")
+	// the deleting status lets an interrupted deletion to be completed during the next start
+	if err := provider.idStore.updateLedgerStatus(ledgerID, ledger.LedgerStatusDeleting); err != nil {
+		return err
+	}
+	if err := provider.removeLedgerData(ledgerID); err != nil {
+		return err
+	}
+	logger.With(flogging.Fields{"channel": ledgerID}).Info("Destroyed ledger")
+	return nil
 }
 
 // Close implements the corresponding method from interface ledger.PeerLedgerProvider
 func (provider *Provider) Close() {
 	provider.idStore.close()
 	provider.blockStoreProvider.Close()
-	provider.vdbProvider.Close()
+	if provider.levelDBProvider != nil {
+		provider.levelDBProvider.Close()
+	}
+	if provider.couchDBProvider != nil {
+		provider.couchDBProvider.Close()
+	}
+	if provider.systemVDBProvider != nil {
+		provider.systemVDBProvider.Close()
+	}
 	provider.historydbProvider.Close()
+	provider.pvtdataStoreProvider.Close()
+	provider.configHistoryProvider.Close()
+	provider.ccEventsProvider.Close()
+	provider.txResultsProvider.Close()
+	provider.invalidTxsProvider.Close()
+	provider.stateTrieProvider.Close()
+	if provider.commitDecoratorsDBProvider != nil {
+		provider.commitDecoratorsDBProvider.Close()
+	}
+}
+
+// removeLedgerData removes the data of the ledger from all the stores. The ledger id is removed
+// only after all the data is removed so that a failed attempt can be retried
+func (provider *Provider) removeLedgerData(ledgerID string) error {
+	config, err := provider.getLedgerConfig(ledgerID)
+	if err != nil {
+		return err
+	}
+	vdbProvider, err := provider.getVDBProvider(config)
+	if err != nil {
+		return err
+	}
+	if err := provider.blockStoreProvider.Drop(ledgerID); err != nil {
+		return err
+	}
+	if err := vdbProvider.Drop(ledgerID); err != nil {
+		return err
+	}
+	if err := provider.dropSystemState(ledgerID, config); err != nil {
+		return err
+	}
+	if err := provider.historydbProvider.Drop(ledgerID); err != nil {
+		return err
+	}
+	if err := provider.pvtdataStoreProvider.Drop(ledgerID); err != nil {
+		return err
+	}
+	if err := provider.configHistoryProvider.Drop(ledgerID); err != nil {
+		return err
+	}
+	if err := provider.ccEventsProvider.Drop(ledgerID); err != nil {
+		return err
+	}
+	if err := provider.txResultsProvider.Drop(ledgerID); err != nil {
+		return err
+	}
+	if err := provider.invalidTxsProvider.Drop(ledgerID); err != nil {
+		return err
+	}
+	if err := provider.stateTrieProvider.Drop(ledgerID); err != nil {
+		return err
+	}
+	if err := provider.dropCommitDecorators(ledgerID); err != nil {
+		return err
+	}
+	if err := dropRecentKeys(ledgerID); err != nil {
+		return err
+	}
+	if err := dropNamespaceSizes(ledgerID); err != nil {
+		return err
+	}
+	if err := dropWriteAheadLog(ledgerID); err != nil {
+		return err
+	}
+	return provider.idStore.deleteLedgerID(ledgerID)
+}
+
+// removeIncompleteLedger removes a ledger whose creation or deletion could not be completed.
+// The ledger is marked as failed if its data cannot be removed
+func (provider *Provider) removeIncompleteLedger(ledgerID string) {
+	if err := provider.removeLedgerData(ledgerID); err != nil {
+		logger.With(flogging.Fields{"channel": ledgerID}).Errorf("Marking ledger as failed as its data could not be removed: %s", err)
+		if err := provider.idStore.updateLedgerStatus(ledgerID, ledger.LedgerStatusFailed); err != nil {
+			logger.With(flogging.Fields{"channel": ledgerID}).Errorf("Error while marking ledger as failed: %s", err)
+		}
+	}
+}
+
+func (provider *Provider) recoverIncompleteLedgers() error {
+	ledgerIDs, err := provider.idStore.getAllLedgerIds()
+	if err != nil {
+		return err
+	}
+	for _, ledgerID := range ledgerIDs {
+		metadata, err := provider.idStore.getLedgerMetadata(ledgerID)
+		if err != nil {
+			return err
+		}
+		if metadata.status == ledger.LedgerStatusUnderConstruction || metadata.status == ledger.LedgerStatusDeleting {
+			logger.With(flogging.Fields{"channel": ledgerID}).Infof("Removing ledger left with status [%s]", metadata.status)
+			provider.removeIncompleteLedger(ledgerID)
+		}
+	}
+	return nil
+}
+
+// ledgerMetadata is stored as the value of a ledger id in the idStore
+type ledgerMetadata struct {
+	status     ledger.LedgerStatus
+	rebuildDBs bool
+	config     *ledgerconfig.ChannelConfig
+	// createdAt is the creation time of the ledger in nanoseconds since the epoch,
+	// 0 for the ledgers created before the time was recorded
+	createdAt        int64
+	creationBlock    uint64
+	genesisBlockHash []byte
+	labels           map[string]string
+	// bootstrapMethod and ordererEndpoints are the provenance of a ledger created from a genesis block
+	bootstrapMethod  string
+	ordererEndpoints []string
+	// rebuildBlockIndex and rollbackHeight are the maintenance scheduled along with rebuildDBs for the next open
+	rebuildBlockIndex bool
+	rollbackHeight    uint64
+}
+
+// hasCreationInfo tells whether the metadata carries any of the fields that follow the config
+func (m *ledgerMetadata) hasCreationInfo() bool {
+	return m.createdAt != 0 || m.creationBlock != 0 || m.genesisBlockHash != nil || len(m.labels) > 0 || m.hasSystemNamespaces() ||
+		m.hasProvenance() || m.hasScheduledMaintenance()
+}
+
+// hasProvenance tells whether the metadata carries the provenance, which is encoded after the system namespaces
+func (m *ledgerMetadata) hasProvenance() bool {
+	return m.bootstrapMethod != "" || len(m.ordererEndpoints) > 0
+}
+
+// hasScheduledMaintenance tells whether the metadata carries a block index rebuild or a rollback, which are encoded after the provenance
+func (m *ledgerMetadata) hasScheduledMaintenance() bool {
+	return m.rebuildBlockIndex || m.rollbackHeight != 0
+}
+
+// hasSystemNamespaces tells whether the config lists system namespaces, which are encoded after the labels
+func (m *ledgerMetadata) hasSystemNamespaces() bool {
+	return m.config != nil && len(m.config.SystemNamespaces) > 0
+}
+
+func (m *ledgerMetadata) marshal() ([]byte, error) {
+	buffer := proto.NewBuffer([]byte{})
+	if err := buffer.EncodeVarint(uint64(m.status)); err != nil {
+		return nil, err
+	}
+	var rebuildDBsMarker uint64
+	if m.rebuildDBs {
+		rebuildDBsMarker = 1
+	}
+	if err := buffer.EncodeVarint(rebuildDBsMarker); err != nil {
+		return nil, err
+	}
+	if m.config == nil && !m.hasCreationInfo() {
+		return buffer.Bytes(), nil
+	}
+	// a nil config followed by the creation info is encoded as an empty config, i.e., with an empty state database
+	config := m.config
+	if config == nil {
+		config = &ledgerconfig.ChannelConfig{}
+	}
+	if err := buffer.EncodeStringBytes(config.StateDatabase); err != nil {
+		return nil, err
+	}
+	var historyDatabaseMarker uint64
+	if config.HistoryDatabase {
+		historyDatabaseMarker = 1
+	}
+	if err := buffer.EncodeVarint(historyDatabaseMarker); err != nil {
+		return nil, err
+	}
+	if err := buffer.EncodeVarint(uint64(config.QueryLimit)); err != nil {
+		return nil, err
+	}
+	if !m.hasCreationInfo() {
+		return buffer.Bytes(), nil
+	}
+	if err := buffer.EncodeVarint(uint64(m.createdAt)); err != nil {
+		return nil, err
+	}
+	if err := buffer.EncodeVarint(m.creationBlock); err != nil {
+		return nil, err
+	}
+	if err := buffer.EncodeRawBytes(m.genesisBlockHash); err != nil {
+		return nil, err
+	}
+	labelKeys := make([]string, 0, len(m.labels))
+	for key := range m.labels {
+		labelKeys = append(labelKeys, key)
+	}
+	sort.Strings(labelKeys)
+	if err := buffer.EncodeVarint(uint64(len(labelKeys))); err != nil {
+		return nil, err
+	}
+	for _, key := range labelKeys {
+		if err := buffer.EncodeStringBytes(key); err != nil {
+			return nil, err
+		}
+		if err := buffer.EncodeStringBytes(m.labels[key]); err != nil {
+			return nil, err
+		}
+	}
+	if !m.hasSystemNamespaces() && !m.hasProvenance() && !m.hasScheduledMaintenance() {
+		return buffer.Bytes(), nil
+	}
+	var systemNamespaces []string
+	if m.config != nil {
+		systemNamespaces = m.config.SystemNamespaces
+	}
+	if err := buffer.EncodeVarint(uint64(len(systemNamespaces))); err != nil {
+		return nil, err
+	}
+	for _, ns := range systemNamespaces {
+		if err := buffer.EncodeStringBytes(ns); err != nil {
+			return nil, err
+		}
+	}
+	if !m.hasProvenance() && !m.hasScheduledMaintenance() {
+		return buffer.Bytes(), nil
+	}
+	if err := buffer.EncodeStringBytes(m.bootstrapMethod); err != nil {
+		return nil, err
+	}
+	if err := buffer.EncodeVarint(uint64(len(m.ordererEndpoints))); err != nil {
+		return nil, err
+	}
+	for _, endpoint := range m.ordererEndpoints {
+		if err := buffer.EncodeStringBytes(endpoint); err != nil {
+			return nil, err
+		}
+	}
+	if !m.hasScheduledMaintenance() {
+		return buffer.Bytes(), nil
+	}
+	var rebuildBlockIndexMarker uint64
+	if m.rebuildBlockIndex {
+		rebuildBlockIndexMarker = 1
+	}
+	if err := buffer.EncodeVarint(rebuildBlockIndexMarker); err != nil {
+		return nil, err
+	}
+	if err := buffer.EncodeVarint(m.rollbackHeight); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// unmarshal decodes the metadata. An empty value represents an active ledger
+// as the ledger ids created before the introduction of the status carry no value.
+// Similarly, the config is left nil for the ledgers created before the config was persisted
+// and the creation info is left empty for the ledgers created before it was recorded
+func (m *ledgerMetadata) unmarshal(b []byte) error {
+	if len(b) == 0 {
+		m.status = ledger.LedgerStatusActive
+		return nil
+	}
+	buffer := proto.NewBuffer(b)
+	status, err := buffer.DecodeVarint()
+	if err != nil {
+		return err
+	}
+	m.status = ledger.LedgerStatus(status)
+	rebuildDBsMarker, err := buffer.DecodeVarint()
+	if err != nil {
+		return err
+	}
+	m.rebuildDBs = rebuildDBsMarker == 1
+	stateDatabase, err := buffer.DecodeStringBytes()
+	if err == io.ErrUnexpectedEOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	historyDatabaseMarker, err := buffer.DecodeVarint()
+	if err != nil {
+		return err
+	}
+	queryLimit, err := buffer.DecodeVarint()
+	if err != nil {
+		return err
+	}
+	if stateDatabase != "" {
+		m.config = &ledgerconfig.ChannelConfig{
+			StateDatabase:   stateDatabase,
+			HistoryDatabase: historyDatabaseMarker == 1,
+			QueryLimit:      int(queryLimit),
+		}
+	}
+	createdAt, err := buffer.DecodeVarint()
+	if err == io.ErrUnexpectedEOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	m.createdAt = int64(createdAt)
+	if m.creationBlock, err = buffer.DecodeVarint(); err != nil {
+		return err
+	}
+	genesisBlockHash, err := buffer.DecodeRawBytes(true)
+	if err != nil {
+		return err
+	}
+	if len(genesisBlockHash) > 0 {
+		m.genesisBlockHash = genesisBlockHash
+	}
+	numLabels, err := buffer.DecodeVarint()
+	if err != nil {
+		return err
+	}
+	if numLabels > 0 {
+		m.labels = make(map[string]string)
+	}
+	for i := uint64(0); i < numLabels; i++ {
+		key, err := buffer.DecodeStringBytes()
+		if err != nil {
+			return err
+		}
+		value, err := buffer.DecodeStringBytes()
+		if err != nil {
+			return err
+		}
+		m.labels[key] = value
+	}
+	numSystemNamespaces, err := buffer.DecodeVarint()
+	if err == io.ErrUnexpectedEOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for i := uint64(0); i < numSystemNamespaces; i++ {
+		ns, err := buffer.DecodeStringBytes()
+		if err != nil {
+			return err
+		}
+		if m.config != nil {
+			m.config.SystemNamespaces = append(m.config.SystemNamespaces, ns)
+		}
+	}
+	bootstrapMethod, err := buffer.DecodeStringBytes()
+	if err == io.ErrUnexpectedEOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	m.bootstrapMethod = bootstrapMethod
+	numOrdererEndpoints, err := buffer.DecodeVarint()
+	if err != nil {
+		return err
+	}
+	for i := uint64(0); i < numOrdererEndpoints; i++ {
+		endpoint, err := buffer.DecodeStringBytes()
+		if err != nil {
+			return err
+		}
+		m.ordererEndpoints = append(m.ordererEndpoints, endpoint)
+	}
+	rebuildBlockIndexMarker, err := buffer.DecodeVarint()
+	if err == io.ErrUnexpectedEOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	m.rebuildBlockIndex = rebuildBlockIndexMarker == 1
+	if m.rollbackHeight, err = buffer.DecodeVarint(); err != nil {
+		return err
+	}
+	return nil
 }
 
 type idStore struct {
-	db *leveldbhelper.DB
+	db idStoreDB
+	// updateLock serializes the read-modify-write updates of the metadata as a ledger
+	// records its genesis block hash on a commit, which runs outside the provider
+	updateLock sync.Mutex
+}
+
+// idStoreDataFormatKey is the key of the format of the data of the id store. The key is not a valid ledger id
+var idStoreDataFormatKey = []byte("_dataFormat")
+
+// idStoreDB is either the dedicated leveldb of the id store or a handle to the shared leveldb
+type idStoreDB interface {
+	Get(key []byte) ([]byte, error)
+	Put(key []byte, value []byte, sync bool) error
+	Delete(key []byte, sync bool) error
+	GetIterator(startKey []byte, endKey []byte) iterator.Iterator
+	Close()
+}
+
+type sharedIDStoreDB struct {
+	*leveldbhelper.DBHandle
+	provider *leveldbhelper.Provider
+}
+
+func (db *sharedIDStoreDB) GetIterator(startKey []byte, endKey []byte) iterator.Iterator {
+	return db.DBHandle.GetIterator(startKey, endKey)
+}
+
+func (db *sharedIDStoreDB) Close() {
+	db.provider.Close()
+}
+
+func openIDStore(path string, shared *sharedLevelDB) *idStore {
+	return openIDStoreDB(&leveldbhelper.Conf{DBPath: path}, shared)
 }
 
-func openIDStore(path string) *idStore {
-	db := leveldbhelper.CreateDB(&leveldbhelper.Conf{DBPath: path})
+func openReadOnlyIDStore(path string, shared *sharedLevelDB) *idStore {
+	return openIDStoreDB(&leveldbhelper.Conf{DBPath: path, ReadOnly: true}, shared)
+}
+
+func openIDStoreDB(conf *leveldbhelper.Conf, shared *sharedLevelDB) *idStore {
+	if shared != nil {
+		provider := leveldbhelper.NewSharedProvider(shared.conf, shared.prefixes.IDStore)
+		return &idStore{db: &sharedIDStoreDB{provider.GetDBHandle(""), provider}}
+	}
+	db := leveldbhelper.CreateDB(conf)
 	db.Open()
-	return &idStore{db}
+	return &idStore{db: db}
 }
 
-func (s *idStore) createLedgerID(ledgerID string) error {
-	key := []byte(ledgerID)
-	val := []byte{}
-	err := error(nil)
-	if val, err = s.db.Get(key); err != nil {
+// createLedgerID records the ledger id along with the creation time and the number of the first block of the ledger
+func (s *idStore) createLedgerID(ledgerID string, status ledger.LedgerStatus, config *ledgerconfig.ChannelConfig, creationBlock uint64) error {
+	exists, err := s.ledgerIDExists(ledgerID)
+	if err != nil {
 		return err
 	}
-	if val != nil {
+	if exists {
 		return ErrLedgerIDExists
 	}
-	return s.db.Put(key, val, true)
+	return s.putLedgerMetadata(ledgerID, &ledgerMetadata{status: status, config: config, createdAt: time.Now().UnixNano(), creationBlock: creationBlock})
 }
 
 func (s *idStore) ledgerIDExists(ledgerID string) (bool, error) {
@@ -198,18 +1068,99 @@ func (s *idStore) ledgerIDExists(ledgerID string) (bool, error) {
 	return val != nil, nil
 }
 
+// getLedgerMetadata returns nil if the ledger id does not exist
+func (s *idStore) getLedgerMetadata(ledgerID string) (*ledgerMetadata, error) {
+	val, err := s.db.Get([]byte(ledgerID))
+	if err != nil || val == nil {
+		return nil, err
+	}
+	metadata := &ledgerMetadata{}
+	if err := metadata.unmarshal(val); err != nil {
+		return nil, err
+	}
+	return metadata, nil
+}
+
+func (s *idStore) putLedgerMetadata(ledgerID string, metadata *ledgerMetadata) error {
+	val, err := metadata.marshal()
+	if err != nil {
+		return err
+	}
+	return s.db.Put([]byte(ledgerID), val, true)
+}
+
+func (s *idStore) updateLedgerMetadata(ledgerID string, update func(metadata *ledgerMetadata)) error {
+	s.updateLock.Lock()
+	defer s.updateLock.Unlock()
+	metadata, err := s.getLedgerMetadata(ledgerID)
+	if err != nil {
+		return err
+	}
+	if metadata == nil {
+		return ErrNonExistingLedgerID
+	}
+	update(metadata)
+	return s.putLedgerMetadata(ledgerID, metadata)
+}
+
+func (s *idStore) updateLedgerStatus(ledgerID string, status ledger.LedgerStatus) error {
+	return s.updateLedgerMetadata(ledgerID, func(metadata *ledgerMetadata) { metadata.status = status })
+}
+
+func (s *idStore) deleteLedgerID(ledgerID string) error {
+	return s.db.Delete([]byte(ledgerID), true)
+}
+
+// setRebuildDBsFlag marks the state DB and history DB of the ledger for a rebuild during the next open
+func (s *idStore) setRebuildDBsFlag(ledgerID string) error {
+	return s.updateLedgerMetadata(ledgerID, func(metadata *ledgerMetadata) { metadata.rebuildDBs = true })
+}
+
+func (s *idStore) clearRebuildDBsFlag(ledgerID string) error {
+	return s.updateLedgerMetadata(ledgerID, func(metadata *ledgerMetadata) { metadata.rebuildDBs = false })
+}
+
+func (s *idStore) isRebuildDBsFlagSet(ledgerID string) (bool, error) {
+	metadata, err := s.getLedgerMetadata(ledgerID)
+	if err != nil || metadata == nil {
+		return false, err
+	}
+	return metadata.rebuildDBs, nil
+}
+
 func (s *idStore) getAllLedgerIds() ([]string, error) {
 	var ids []string
 	itr := s.db.GetIterator(nil, nil)
 	itr.First()
 	for itr.Valid() {
-		key := string(itr.Key())
-		ids = append(ids, key)
+		if !bytes.Equal(itr.Key(), idStoreDataFormatKey) {
+			ids = append(ids, string(itr.Key()))
+		}
 		itr.Next()
 	}
 	return ids, nil
 }
 
+// IsEmpty returns true if the id store holds no ledger id
+func (s *idStore) IsEmpty() (bool, error) {
+	ledgerIDs, err := s.getAllLedgerIds()
+	return len(ledgerIDs) == 0, err
+}
+
+// GetDataFormat returns the format of the data of the id store, the empty string if no format is recorded
+func (s *idStore) GetDataFormat() (string, error) {
+	format, err := s.db.Get(idStoreDataFormatKey)
+	if err != nil {
+		return "", err
+	}
+	return string(format), nil
+}
+
+// SetDataFormat records the format of the data of the id store
+func (s *idStore) SetDataFormat(format string) error {
+	return s.db.Put(idStoreDataFormatKey, []byte(format), true)
+}
+
 func (s *idStore) close() {
 	s.db.Close()
 }
@@ -18,10 +18,15 @@ package kvledger
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/hyperledger/fabric/common/ledger/blkstorage"
 	"github.com/hyperledger/fabric/common/ledger/testutil"
 	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+	"github.com/spf13/viper"
 )
 
 func TestLedgerProvider(t *testing.T) {
@@ -43,11 +48,11 @@ func TestLedgerProvider(t *testing.T) {
 
 	provider, _ = NewProvider()
 	defer provider.Close()
-	ledgerIds, _ := provider.List()
-	testutil.AssertEquals(t, len(ledgerIds), numLedgers)
-	t.Logf("ledgerIDs=%#v", ledgerIds)
+	ledgerInfos, _ := provider.List()
+	testutil.AssertEquals(t, len(ledgerInfos), numLedgers)
 	for i := 0; i < numLedgers; i++ {
-		testutil.AssertEquals(t, ledgerIds[i], constructTestLedgerID(i))
+		testutil.AssertEquals(t, ledgerInfos[i], &ledger.LedgerInfo{
+			LedgerID: constructTestLedgerID(i), Height: 0, Status: ledger.LedgerStatusActive})
 	}
 	_, err = provider.Create(constructTestLedgerID(2))
 	testutil.AssertEquals(t, err, ErrLedgerIDExists)
@@ -102,6 +107,311 @@ func TestMultipleLedgerBasicRW(t *testing.T) {
 	}
 }
 
+func TestLedgerProviderSharedLevelDB(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	viper.Set("ledger.sharedLevelDB.enabled", true)
+	defer viper.Set("ledger.sharedLevelDB.enabled", false)
+	historyEnabled := viper.GetBool("ledger.state.historyDatabase")
+	viper.Set("ledger.state.historyDatabase", true)
+	defer viper.Set("ledger.state.historyDatabase", historyEnabled)
+
+	provider, err := NewProvider()
+	testutil.AssertNoError(t, err, "")
+	for i := 0; i < 2; i++ {
+		l, err := provider.Create(constructTestLedgerID(i))
+		testutil.AssertNoError(t, err, "")
+		s, _ := l.NewTxSimulator()
+		s.SetState("ns", "testKey", []byte(fmt.Sprintf("testValue_%d", i)))
+		s.Done()
+		res, _ := s.GetTxSimulationResults()
+		testutil.AssertNoError(t, l.Commit(testutil.ConstructBlock(t, [][]byte{res}, false)), "")
+		l.Close()
+	}
+	provider.Close()
+
+	// the block index, the history database, and the id store are kept in the shared leveldb
+	for _, path := range []string{ledgerconfig.GetLedgerProviderPath(), ledgerconfig.GetHistoryLevelDBPath(),
+		filepath.Join(ledgerconfig.GetBlockStorePath(), "index")} {
+		_, err := os.Stat(path)
+		testutil.AssertEquals(t, os.IsNotExist(err), true)
+	}
+
+	provider, err = NewProvider()
+	testutil.AssertNoError(t, err, "")
+	defer provider.Close()
+	ledgerIDs, _ := provider.List()
+	testutil.AssertEquals(t, len(ledgerIDs), 2)
+	for i := 0; i < 2; i++ {
+		l, err := provider.Open(constructTestLedgerID(i))
+		testutil.AssertNoError(t, err, "")
+		block, err := l.GetBlockByNumber(0)
+		testutil.AssertNoError(t, err, "")
+		testutil.AssertEquals(t, block.Header.Number, uint64(0))
+		q, _ := l.NewHistoryQueryExecutor()
+		itr, err := q.GetHistoryForKey("ns", "testKey")
+		testutil.AssertNoError(t, err, "")
+		kmod, _ := itr.Next()
+		testutil.AssertNotNil(t, kmod)
+		itr.Close()
+		l.Close()
+	}
+}
+
+func TestLedgerProviderSharedLevelDBSettingChange(t *testing.T) {
+	for _, sharedEnabled := range []bool{false, true} {
+		env := newTestEnv(t)
+		viper.Set("ledger.sharedLevelDB.enabled", sharedEnabled)
+		provider, err := NewProvider()
+		testutil.AssertNoError(t, err, "")
+		l, err := provider.Create(constructTestLedgerID(0))
+		testutil.AssertNoError(t, err, "")
+		l.Close()
+		provider.Close()
+
+		// the existing ledger is not moved when the setting is flipped, hence the provider refuses to start
+		viper.Set("ledger.sharedLevelDB.enabled", !sharedEnabled)
+		_, err = NewProvider()
+		testutil.AssertError(t, err, fmt.Sprintf("Expected an error when ledger.sharedLevelDB.enabled is flipped to %t", !sharedEnabled))
+		_, err = NewReadOnlyProvider()
+		testutil.AssertError(t, err, fmt.Sprintf("Expected an error in read-only mode when ledger.sharedLevelDB.enabled is flipped to %t", !sharedEnabled))
+
+		// the ledger is opened again once the setting is restored
+		viper.Set("ledger.sharedLevelDB.enabled", sharedEnabled)
+		provider, err = NewProvider()
+		testutil.AssertNoError(t, err, "")
+		exists, _ := provider.Exists(constructTestLedgerID(0))
+		testutil.AssertEquals(t, exists, true)
+		provider.Close()
+		viper.Set("ledger.sharedLevelDB.enabled", false)
+		env.cleanup()
+	}
+}
+
+func TestLedgerProviderDestroy(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	provider, _ := NewProvider()
+	defer provider.Close()
+	for i := 0; i < 2; i++ {
+		l, _ := provider.Create(constructTestLedgerID(i))
+		s, _ := l.NewTxSimulator()
+		s.SetState("ns", "testKey", []byte(fmt.Sprintf("testValue_%d", i)))
+		s.Done()
+		res, _ := s.GetTxSimulationResults()
+		testutil.AssertNoError(t, l.Commit(testutil.ConstructBlock(t, [][]byte{res}, false)), "")
+		l.Close()
+	}
+
+	testutil.AssertEquals(t, provider.Destroy(constructTestLedgerID(2)), ErrNonExistingLedgerID)
+	testutil.AssertNoError(t, provider.Destroy(constructTestLedgerID(0)), "")
+	exists, _ := provider.Exists(constructTestLedgerID(0))
+	testutil.AssertEquals(t, exists, false)
+	ledgerInfos, _ := provider.List()
+	testutil.AssertEquals(t, ledgerInfos, []*ledger.LedgerInfo{
+		{LedgerID: constructTestLedgerID(1), Height: 1, Status: ledger.LedgerStatusActive}})
+
+	// a ledger created again with the same id starts afresh
+	l, err := provider.Create(constructTestLedgerID(0))
+	testutil.AssertNoError(t, err, "")
+	bcInfo, _ := l.GetBlockchainInfo()
+	testutil.AssertEquals(t, bcInfo.Height, uint64(0))
+	q, _ := l.NewQueryExecutor()
+	val, _ := q.GetState("ns", "testKey")
+	q.Done()
+	testutil.AssertNil(t, val)
+	l.Close()
+
+	// the other ledger remains intact
+	l, _ = provider.Open(constructTestLedgerID(1))
+	defer l.Close()
+	bcInfo, _ = l.GetBlockchainInfo()
+	testutil.AssertEquals(t, bcInfo.Height, uint64(1))
+	q, _ = l.NewQueryExecutor()
+	val, _ = q.GetState("ns", "testKey")
+	q.Done()
+	testutil.AssertEquals(t, val, []byte("testValue_1"))
+}
+
+func TestLedgerProviderRecoversIncompleteLedgers(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	provider, _ := NewProvider()
+	for i := 0; i < 3; i++ {
+		l, _ := provider.Create(constructTestLedgerID(i))
+		s, _ := l.NewTxSimulator()
+		s.SetState("ns", "testKey", []byte("testValue"))
+		s.Done()
+		res, _ := s.GetTxSimulationResults()
+		l.Commit(testutil.ConstructBlock(t, [][]byte{res}, false))
+		l.Close()
+	}
+	// simulate crashes in the middle of a ledger creation and a ledger deletion
+	idStore := provider.(*Provider).idStore
+	testutil.AssertNoError(t, idStore.updateLedgerStatus(constructTestLedgerID(1), ledger.LedgerStatusUnderConstruction), "")
+	testutil.AssertNoError(t, idStore.updateLedgerStatus(constructTestLedgerID(2), ledger.LedgerStatusDeleting), "")
+	_, err := provider.Open(constructTestLedgerID(1))
+	testutil.AssertEquals(t, err, ErrLedgerNotActive)
+	ledgerInfos, _ := provider.List()
+	testutil.AssertEquals(t, ledgerInfos, []*ledger.LedgerInfo{
+		{LedgerID: constructTestLedgerID(0), Height: 1, Status: ledger.LedgerStatusActive},
+		{LedgerID: constructTestLedgerID(1), Height: 1, Status: ledger.LedgerStatusUnderConstruction},
+		{LedgerID: constructTestLedgerID(2), Height: 1, Status: ledger.LedgerStatusDeleting},
+	})
+	provider.Close()
+
+	provider, _ = NewProvider()
+	defer provider.Close()
+	ledgerInfos, _ = provider.List()
+	testutil.AssertEquals(t, ledgerInfos, []*ledger.LedgerInfo{
+		{LedgerID: constructTestLedgerID(0), Height: 1, Status: ledger.LedgerStatusActive}})
+
+	// the ledger ids are available for creation again with no leftover data
+	for i := 1; i < 3; i++ {
+		l, err := provider.Create(constructTestLedgerID(i))
+		testutil.AssertNoError(t, err, "")
+		bcInfo, _ := l.GetBlockchainInfo()
+		testutil.AssertEquals(t, bcInfo.Height, uint64(0))
+		q, _ := l.NewQueryExecutor()
+		val, _ := q.GetState("ns", "testKey")
+		q.Done()
+		testutil.AssertNil(t, val)
+		l.Close()
+	}
+}
+
+func TestOpenReadOnlyBlockStore(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	provider, _ := NewProvider()
+	l, _ := provider.Create(constructTestLedgerID(0))
+	s, _ := l.NewTxSimulator()
+	s.SetState("ns", "testKey", []byte("testValue"))
+	s.Done()
+	res, _ := s.GetTxSimulationResults()
+	bg := testutil.NewBlockGenerator(t)
+	block := bg.NextBlock([][]byte{res}, false)
+	testutil.AssertNoError(t, l.Commit(block), "")
+	l.Close()
+	provider.Close()
+
+	blockStoreProvider, blockStore, err := OpenReadOnlyBlockStore(constructTestLedgerID(0))
+	testutil.AssertNoError(t, err, "")
+	defer blockStoreProvider.Close()
+	defer blockStore.Shutdown()
+	retrievedBlock, err := blockStore.RetrieveBlockByNumber(0)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, retrievedBlock.Header, block.Header)
+	testutil.AssertEquals(t, blockStore.AddBlock(bg.NextBlock([][]byte{res}, false)), blkstorage.ErrReadOnly)
+
+	_, _, err = OpenReadOnlyBlockStore(constructTestLedgerID(1))
+	testutil.AssertEquals(t, err, ErrNonExistingLedgerID)
+}
+
+func TestReadOnlyLedgerProvider(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	provider, _ := NewProvider()
+	l, _ := provider.Create(constructTestLedgerID(0))
+	s, _ := l.NewTxSimulator()
+	s.SetState("ns", "testKey", []byte("testValue"))
+	s.Done()
+	res, _ := s.GetTxSimulationResults()
+	bg := testutil.NewBlockGenerator(t)
+	testutil.AssertNoError(t, l.Commit(bg.NextBlock([][]byte{res}, false)), "")
+	l.Close()
+	provider.Close()
+
+	// multiple read-only providers can be opened at the same time
+	readOnlyProvider1, err := NewReadOnlyProvider()
+	testutil.AssertNoError(t, err, "")
+	defer readOnlyProvider1.Close()
+	readOnlyProvider2, err := NewReadOnlyProvider()
+	testutil.AssertNoError(t, err, "")
+	defer readOnlyProvider2.Close()
+	for _, p := range []ledger.PeerLedgerProvider{readOnlyProvider1, readOnlyProvider2} {
+		ledgerInfos, _ := p.List()
+		testutil.AssertEquals(t, ledgerInfos, []*ledger.LedgerInfo{
+			{LedgerID: constructTestLedgerID(0), Height: 1, Status: ledger.LedgerStatusActive}})
+		l, err := p.Open(constructTestLedgerID(0))
+		testutil.AssertNoError(t, err, "")
+		bcInfo, _ := l.GetBlockchainInfo()
+		testutil.AssertEquals(t, bcInfo.Height, uint64(1))
+		q, _ := l.NewQueryExecutor()
+		val, _ := q.GetState("ns", "testKey")
+		q.Done()
+		testutil.AssertEquals(t, val, []byte("testValue"))
+		testutil.AssertEquals(t, l.Commit(bg.NextBlock([][]byte{res}, false)), ErrLedgerReadOnly)
+		l.Close()
+	}
+	_, err = readOnlyProvider1.Create(constructTestLedgerID(1))
+	testutil.AssertEquals(t, err, ErrLedgerReadOnly)
+	testutil.AssertEquals(t, readOnlyProvider1.Destroy(constructTestLedgerID(0)), ErrLedgerReadOnly)
+}
+
+func TestLedgerProviderChannelOverrides(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	overrideKey := "ledger.channelOverrides." + constructTestLedgerID(1) + ".historyDatabase"
+	historyEnabled := viper.GetBool("ledger.state.historyDatabase")
+	viper.Set("ledger.state.historyDatabase", true)
+	defer viper.Set("ledger.state.historyDatabase", historyEnabled)
+	viper.Set(overrideKey, false)
+	defer viper.Set(overrideKey, nil)
+
+	provider, _ := NewProvider()
+	for i := 0; i < 2; i++ {
+		l, _ := provider.Create(constructTestLedgerID(i))
+		l.Close()
+	}
+	provider.Close()
+
+	// the configuration resolved at the creation is retained even if the peer configuration changes afterwards
+	viper.Set(overrideKey, nil)
+	provider, _ = NewProvider()
+	defer provider.Close()
+	for i, historyEnabled := range []bool{true, false} {
+		l, err := provider.Open(constructTestLedgerID(i))
+		testutil.AssertNoError(t, err, "")
+		testutil.AssertEquals(t, l.(*kvLedger).config.HistoryDatabase, historyEnabled)
+		s, _ := l.NewTxSimulator()
+		s.SetState("ns", "testKey", []byte("testValue"))
+		s.Done()
+		res, _ := s.GetTxSimulationResults()
+		testutil.AssertNoError(t, l.Commit(testutil.ConstructBlock(t, [][]byte{res}, false)), "")
+		qhistory, _ := l.NewHistoryQueryExecutor()
+		_, err = qhistory.GetHistoryForKey("ns", "testKey")
+		if historyEnabled {
+			testutil.AssertNoError(t, err, "")
+		} else {
+			testutil.AssertError(t, err, "Expected an error as history database is disabled for the channel")
+		}
+		l.Close()
+	}
+}
+
+func TestLedgerMetadataMarshaling(t *testing.T) {
+	metadata := &ledgerMetadata{status: ledger.LedgerStatusFailed, rebuildDBs: true}
+	b, err := metadata.marshal()
+	testutil.AssertNoError(t, err, "")
+	unmarshaled := &ledgerMetadata{}
+	testutil.AssertNoError(t, unmarshaled.unmarshal(b), "")
+	testutil.AssertEquals(t, unmarshaled, metadata)
+
+	metadata = &ledgerMetadata{status: ledger.LedgerStatusActive, config: &ledgerconfig.ChannelConfig{
+		StateDatabase: "goleveldb", HistoryDatabase: true, QueryLimit: 50}}
+	b, err = metadata.marshal()
+	testutil.AssertNoError(t, err, "")
+	unmarshaled = &ledgerMetadata{}
+	testutil.AssertNoError(t, unmarshaled.unmarshal(b), "")
+	testutil.AssertEquals(t, unmarshaled, metadata)
+
+	// an empty value stored by an earlier version represents an active ledger
+	unmarshaled = &ledgerMetadata{}
+	testutil.AssertNoError(t, unmarshaled.unmarshal([]byte{}), "")
+	testutil.AssertEquals(t, unmarshaled, &ledgerMetadata{status: ledger.LedgerStatusActive})
+}
+
 func constructTestLedgerID(i int) string {
 	return fmt.Sprintf("ledger_%06d", i)
 }
@@ -22,12 +22,16 @@ import (
 	"testing"
 
 	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/common/privdata"
 	ledgerpackage "github.com/hyperledger/fabric/core/ledger"
 	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
 	ledgertestutil "github.com/hyperledger/fabric/core/ledger/testutil"
 	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/peer"
 	putils "github.com/hyperledger/fabric/protos/utils"
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
 )
 
 func TestKVLedgerBlockStorage(t *testing.T) {
@@ -97,6 +101,9 @@ func TestKVLedgerBlockStorage(t *testing.T) {
 	// get the tran envelope from the retrieved ProcessedTransaction
 	retrievedTxEnv2 := processedTran2.TransactionEnvelope
 	testutil.AssertEquals(t, retrievedTxEnv2, txEnv2)
+	testutil.AssertEquals(t, processedTran2.ValidationCode, int32(peer.TxValidationCode_VALID))
+	testutil.AssertEquals(t, processedTran2.BlockNumber, uint64(1))
+	testutil.AssertEquals(t, processedTran2.TxIndex, uint64(0))
 
 }
 
@@ -530,3 +537,388 @@ func TestLedgerWithCouchDbEnabledWithBinaryAndJSONData(t *testing.T) {
 
 	}
 }
+
+func TestKVLedgerDuplicateBlockCommit(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	provider, _ := NewProvider()
+	defer provider.Close()
+	ledger, _ := provider.Create("testLedger")
+	defer ledger.Close()
+
+	bg := testutil.NewBlockGenerator(t)
+	simulator, _ := ledger.NewTxSimulator()
+	simulator.SetState("ns1", "key1", []byte("value1"))
+	simulator.Done()
+	simRes, _ := simulator.GetTxSimulationResults()
+	block0 := bg.NextBlock([][]byte{simRes}, false)
+	testutil.AssertNoError(t, ledger.Commit(block0), "")
+
+	simulator, _ = ledger.NewTxSimulator()
+	simulator.SetState("ns1", "key1", []byte("value2"))
+	simulator.Done()
+	simRes, _ = simulator.GetTxSimulationResults()
+	block1 := bg.NextBlock([][]byte{simRes}, false)
+	testutil.AssertNoError(t, ledger.Commit(block1), "")
+
+	// redelivery of an already committed block should not change the ledger
+	err := ledger.Commit(block0)
+	testutil.AssertEquals(t, err, &ledgerpackage.BlockAlreadyCommittedError{BlockNum: 0, Height: 2})
+
+	bcInfo, _ := ledger.GetBlockchainInfo()
+	testutil.AssertEquals(t, bcInfo.Height, uint64(2))
+	stateDBSavepoint, _ := ledger.(*kvLedger).txtmgmt.GetLastSavepoint()
+	testutil.AssertEquals(t, stateDBSavepoint.BlockNum, uint64(1))
+	qe, _ := ledger.NewQueryExecutor()
+	value, _ := qe.GetState("ns1", "key1")
+	qe.Done()
+	testutil.AssertEquals(t, value, []byte("value2"))
+}
+
+func TestQueryExecutorBlockchainInfo(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	provider, _ := NewProvider()
+	defer provider.Close()
+	ledger, _ := provider.Create("testLedger")
+	defer ledger.Close()
+
+	qe, _ := ledger.NewQueryExecutor()
+	bcInfo, err := qe.GetBlockchainInfo()
+	qe.Done()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, bcInfo, &common.BlockchainInfo{Height: 0})
+
+	bg := testutil.NewBlockGenerator(t)
+	var blocks []*common.Block
+	for i := 0; i < 2; i++ {
+		simulator, _ := ledger.NewTxSimulator()
+		simulator.SetState("ns1", "key1", []byte(fmt.Sprintf("value%d", i)))
+		simulator.Done()
+		simRes, _ := simulator.GetTxSimulationResults()
+		block := bg.NextBlock([][]byte{simRes}, false)
+		testutil.AssertNoError(t, ledger.Commit(block), "")
+		blocks = append(blocks, block)
+	}
+	expectedBCInfo := &common.BlockchainInfo{Height: 2,
+		CurrentBlockHash: blocks[1].Header.Hash(), PreviousBlockHash: blocks[0].Header.Hash()}
+	simulator, _ := ledger.NewTxSimulator()
+	bcInfo, err = simulator.GetBlockchainInfo()
+	simulator.Done()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, bcInfo, expectedBCInfo)
+
+	// the block storage moves ahead of the state database during a commit. The query executor
+	// continues to report the blockchain info that corresponds to the state it reads
+	qe, _ = ledger.NewQueryExecutor()
+	defer qe.Done()
+	testutil.AssertNoError(t, ledger.(*kvLedger).blockStore.AddBlock(bg.NextBlock([][]byte{}, false)), "")
+	bcInfo, err = qe.GetBlockchainInfo()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, bcInfo, expectedBCInfo)
+	value, _ := qe.GetState("ns1", "key1")
+	testutil.AssertEquals(t, value, []byte("value1"))
+}
+
+func TestLedgerErrorKinds(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	provider, _ := NewProvider()
+	defer provider.Close()
+	l, _ := provider.Create("testLedger")
+	defer l.Close()
+
+	_, err := l.GetTransactionByID("missingTx")
+	_, ok := err.(*ledgerpackage.NotFoundError)
+	testutil.AssertEquals(t, ok, true)
+	_, err = l.GetBlockByHash([]byte("missingHash"))
+	_, ok = err.(*ledgerpackage.NotFoundError)
+	testutil.AssertEquals(t, ok, true)
+	testutil.AssertEquals(t, ledgerpackage.GRPCCode(err), codes.NotFound)
+
+	_, err = provider.Create("testLedger")
+	_, ok = err.(*ledgerpackage.ConflictError)
+	testutil.AssertEquals(t, ok, true)
+	_, err = provider.Open("missingLedger")
+	_, ok = err.(*ledgerpackage.NotFoundError)
+	testutil.AssertEquals(t, ok, true)
+}
+
+func TestCommitWithPvtData(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	provider, _ := NewProvider()
+	defer provider.Close()
+	ledger, _ := provider.Create("testLedger")
+
+	bg := testutil.NewBlockGenerator(t)
+	pvtData := []*ledgerpackage.TxPvtData{
+		{SeqInBlock: 0, WriteSets: []*ledgerpackage.CollPvtWriteSet{
+			{Namespace: "ns1", CollectionName: "coll1", WriteSet: []byte("pvt-ws-1")},
+			{Namespace: "ns1", CollectionName: "coll2", WriteSet: []byte("pvt-ws-2")},
+		}},
+	}
+	simulator, _ := ledger.NewTxSimulator()
+	simulator.SetState("ns1", "key1", []byte("value1"))
+	simulator.Done()
+	simRes, _ := simulator.GetTxSimulationResults()
+	testutil.AssertNoError(t, ledger.CommitWithPvtData(bg.NextBlock([][]byte{simRes}, false), pvtData, nil), "")
+	testutil.AssertNoError(t, ledger.Commit(bg.NextBlock([][]byte{}, false)), "")
+
+	retrievedPvtData, err := ledger.GetPvtDataByNum(0, nil)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, retrievedPvtData, pvtData)
+	filter := ledgerpackage.NewPvtNsCollFilter()
+	filter.Add("ns1", "coll2")
+	retrievedPvtData, _ = ledger.GetPvtDataByNum(0, filter)
+	testutil.AssertEquals(t, len(retrievedPvtData[0].WriteSets), 1)
+	testutil.AssertEquals(t, retrievedPvtData[0].WriteSets[0].CollectionName, "coll2")
+	retrievedPvtData, err = ledger.GetPvtDataByNum(1, nil)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertNil(t, retrievedPvtData)
+
+	// simulate a crash after the block is added to the block storage but before the private data is committed
+	block := bg.NextBlock([][]byte{}, false)
+	kvl := ledger.(*kvLedger)
+	testutil.AssertNoError(t, kvl.pvtdataStore.Prepare(2, pvtData, nil), "")
+	testutil.AssertNoError(t, kvl.blockStore.AddBlock(block), "")
+	ledger.Close()
+
+	// the pending private data is committed when the ledger is opened again
+	ledger, _ = provider.Open("testLedger")
+	retrievedPvtData, err = ledger.GetPvtDataByNum(2, nil)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, retrievedPvtData, pvtData)
+
+	// simulate a crash before the block is added to the block storage
+	testutil.AssertNoError(t, ledger.(*kvLedger).pvtdataStore.Prepare(3, pvtData, nil), "")
+	ledger.Close()
+
+	// the pending private data is discarded when the ledger is opened again
+	ledger, _ = provider.Open("testLedger")
+	defer ledger.Close()
+	_, err = ledger.GetPvtDataByNum(3, nil)
+	testutil.AssertError(t, err, "Expected an error for the private data of a block that is not committed")
+	testutil.AssertNoError(t, ledger.CommitWithPvtData(bg.NextBlock([][]byte{}, false), pvtData, nil), "")
+	retrievedPvtData, _ = ledger.GetPvtDataByNum(3, nil)
+	testutil.AssertEquals(t, retrievedPvtData, pvtData)
+}
+
+func TestMissingPvtData(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	provider, _ := NewProvider()
+	defer provider.Close()
+	ledger, _ := provider.Create("testLedger")
+	defer ledger.Close()
+
+	bg := testutil.NewBlockGenerator(t)
+	missingPvtData := []*ledgerpackage.MissingPvtData{{SeqInBlock: 0, Namespace: "ns1", CollectionName: "coll1"}}
+	testutil.AssertNoError(t, ledger.CommitWithPvtData(bg.NextBlock([][]byte{}, false), nil, missingPvtData), "")
+	missingDataInfo, err := ledger.GetMissingPvtDataInfoForMostRecentBlocks(10)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, missingDataInfo, ledgerpackage.MissingPvtDataInfo{0: missingPvtData})
+
+	reconciledPvtData := []*ledgerpackage.TxPvtData{
+		{SeqInBlock: 0, WriteSets: []*ledgerpackage.CollPvtWriteSet{{Namespace: "ns1", CollectionName: "coll1", WriteSet: []byte("pvt-ws-1")}}},
+	}
+	testutil.AssertNoError(t, ledger.CommitPvtDataOfOldBlocks([]*ledgerpackage.BlockPvtData{{BlockNum: 0, PvtData: reconciledPvtData}}), "")
+	retrievedPvtData, _ := ledger.GetPvtDataByNum(0, nil)
+	testutil.AssertEquals(t, retrievedPvtData, reconciledPvtData)
+	missingDataInfo, _ = ledger.GetMissingPvtDataInfoForMostRecentBlocks(10)
+	testutil.AssertNil(t, missingDataInfo)
+}
+
+func TestPvtDataExpiry(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	viper.Set("ledger.pvtdataStore.purgeInterval", 1)
+	defer viper.Set("ledger.pvtdataStore.purgeInterval", 0)
+	provider, _ := NewProvider()
+	defer provider.Close()
+	ledger, _ := provider.Create("testLedger")
+	defer ledger.Close()
+
+	bg := testutil.NewBlockGenerator(t)
+	collConfigPkg := &privdata.CollectionConfigPackage{Configs: []*privdata.CollectionConfig{{Name: "coll1", BlockToLive: 1}}}
+	collConfigBytes, _ := collConfigPkg.Marshal()
+	simulator, _ := ledger.NewTxSimulator()
+	simulator.SetState("lccc", privdata.BuildCollectionKVSKey("ns1"), collConfigBytes)
+	simulator.Done()
+	simRes, _ := simulator.GetTxSimulationResults()
+	testutil.AssertNoError(t, ledger.Commit(bg.NextBlock([][]byte{simRes}, false)), "")
+
+	pvtData := []*ledgerpackage.TxPvtData{
+		{SeqInBlock: 0, WriteSets: []*ledgerpackage.CollPvtWriteSet{
+			{Namespace: "ns1", CollectionName: "coll1", WriteSet: []byte("pvt-ws-1")},
+			{Namespace: "ns1", CollectionName: "coll2", WriteSet: []byte("pvt-ws-2")},
+		}},
+	}
+	testutil.AssertNoError(t, ledger.CommitWithPvtData(bg.NextBlock([][]byte{}, false), pvtData, nil), "")
+	testutil.AssertNoError(t, ledger.Commit(bg.NextBlock([][]byte{}, false)), "")
+	retrievedPvtData, _ := ledger.GetPvtDataByNum(1, nil)
+	testutil.AssertEquals(t, retrievedPvtData, pvtData)
+
+	// the private data of coll1 is purged at block 3, the undefined collection coll2 never expires
+	testutil.AssertNoError(t, ledger.Commit(bg.NextBlock([][]byte{}, false)), "")
+	retrievedPvtData, _ = ledger.GetPvtDataByNum(1, nil)
+	testutil.AssertEquals(t, retrievedPvtData, []*ledgerpackage.TxPvtData{
+		{SeqInBlock: 0, WriteSets: []*ledgerpackage.CollPvtWriteSet{{Namespace: "ns1", CollectionName: "coll2", WriteSet: []byte("pvt-ws-2")}}},
+	})
+	purgeStats := ledger.(*kvLedger).pvtdataStore.GetPurgeStats()
+	testutil.AssertEquals(t, purgeStats.NumPurgedWriteSets, uint64(1))
+	testutil.AssertEquals(t, purgeStats.LastPurgeBlock, uint64(3))
+}
+
+func TestCollectionConfigHistory(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	provider, _ := NewProvider()
+	defer provider.Close()
+	ledger, _ := provider.Create("testLedger")
+
+	bg := testutil.NewBlockGenerator(t)
+	commitLifecycleWrites := func(collConfig []byte) {
+		simulator, _ := ledger.NewTxSimulator()
+		if collConfig != nil {
+			simulator.SetState("lccc", "mycc~collection", collConfig)
+		}
+		simulator.SetState("lccc", "mycc", []byte("chaincode-data"))
+		simulator.Done()
+		simRes, _ := simulator.GetTxSimulationResults()
+		testutil.AssertNoError(t, ledger.Commit(bg.NextBlock([][]byte{simRes}, false)), "")
+	}
+	commitLifecycleWrites([]byte("config-1"))
+	commitLifecycleWrites(nil)
+	commitLifecycleWrites([]byte("config-2"))
+
+	configInfo, err := ledger.GetCollectionConfigAt("mycc", 0)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertNil(t, configInfo)
+	configInfo, _ = ledger.GetCollectionConfigAt("mycc", 2)
+	testutil.AssertEquals(t, configInfo, &ledgerpackage.CollectionConfigInfo{CollectionConfig: []byte("config-1"), CommittingBlockNum: 0})
+	configInfo, _ = ledger.GetCollectionConfigAt("mycc", 3)
+	testutil.AssertEquals(t, configInfo, &ledgerpackage.CollectionConfigInfo{CollectionConfig: []byte("config-2"), CommittingBlockNum: 2})
+	configInfo, _ = ledger.GetCollectionConfigAt("othercc", 3)
+	testutil.AssertNil(t, configInfo)
+
+	// the config history is rebuilt from the block storage if lost
+	testutil.AssertNoError(t, provider.(*Provider).configHistoryProvider.Drop("testLedger"), "")
+	ledger.Close()
+	ledger, _ = provider.Open("testLedger")
+	defer ledger.Close()
+	configInfo, _ = ledger.GetCollectionConfigAt("mycc", 3)
+	testutil.AssertEquals(t, configInfo, &ledgerpackage.CollectionConfigInfo{CollectionConfig: []byte("config-2"), CommittingBlockNum: 2})
+}
+
+func TestTxResultsArchive(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	provider, _ := NewProvider()
+	defer provider.Close()
+	l, _ := provider.Create("testLedger")
+
+	simulator, _ := l.NewTxSimulator()
+	simulator.SetState("ns1", "key1", []byte("value1"))
+	simulator.Done()
+	simRes, _ := simulator.GetTxSimulationResults()
+	bg := testutil.NewBlockGenerator(t)
+	block0 := bg.NextBlock([][]byte{simRes}, false)
+	testutil.AssertNoError(t, l.Commit(block0), "")
+	txID := getTxIDFromBlock(t, block0, 0)
+
+	_, err := l.GetTxResultByID(txID)
+	_, ok := err.(*ledgerpackage.NotEnabledError)
+	testutil.AssertEquals(t, ok, true)
+	l.Close()
+
+	// the archive catches up with the blocks committed while it was disabled when the ledger is opened
+	viper.Set("ledger.state.txResultsArchive", true)
+	defer viper.Set("ledger.state.txResultsArchive", false)
+	l, _ = provider.Open("testLedger")
+	defer l.Close()
+	txResult, err := l.GetTxResultByID(txID)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, txResult.BlockNum, uint64(0))
+	ccAction, err := putils.GetChaincodeAction(txResult.ProposalResponsePayload.Extension)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, ccAction.Results, simRes)
+
+	block1 := bg.NextBlock([][]byte{simRes}, false)
+	testutil.AssertNoError(t, l.Commit(block1), "")
+	txID = getTxIDFromBlock(t, block1, 0)
+	txResult, err = l.GetTxResultByID(txID)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, txResult.BlockNum, uint64(1))
+	_, err = l.GetTxResultByID("missingTx")
+	_, ok = err.(*ledgerpackage.NotFoundError)
+	testutil.AssertEquals(t, ok, true)
+}
+
+func TestInvalidTransactionsLog(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	provider, _ := NewProvider()
+	defer provider.Close()
+	l, _ := provider.Create("testLedger")
+	defer l.Close()
+	_, err := l.GetInvalidTransactions(0)
+	_, ok := err.(*ledgerpackage.NotEnabledError)
+	testutil.AssertEquals(t, ok, true)
+
+	viper.Set("ledger.invalidTxLog.maxEntries", 10)
+	defer viper.Set("ledger.invalidTxLog.maxEntries", 0)
+	var simResults [][]byte
+	for i := 0; i < 2; i++ {
+		simulator, _ := l.NewTxSimulator()
+		simulator.GetState("ns1", "key1")
+		simulator.SetState("ns1", "key1", []byte(fmt.Sprintf("value%d", i)))
+		simulator.Done()
+		simRes, _ := simulator.GetTxSimulationResults()
+		simResults = append(simResults, simRes)
+	}
+	// the second transaction conflicts with the first one in the same block
+	bg := testutil.NewBlockGenerator(t)
+	block0 := bg.NextBlock(simResults, false)
+	testutil.AssertNoError(t, l.Commit(block0), "")
+	invalidTxs, err := l.GetInvalidTransactions(0)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, len(invalidTxs), 1)
+	testutil.AssertEquals(t, invalidTxs[0].TxID, getTxIDFromBlock(t, block0, 1))
+	testutil.AssertEquals(t, invalidTxs[0].TxNum, uint64(1))
+	testutil.AssertEquals(t, invalidTxs[0].ValidationCode, peer.TxValidationCode_MVCC_READ_CONFLICT)
+}
+
+func TestTxExists(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	viper.Set("ledger.txIDFilter.expectedTxs", 1000)
+	defer viper.Set("ledger.txIDFilter.expectedTxs", 0)
+	provider, _ := NewProvider()
+	defer provider.Close()
+	l, _ := provider.Create("testLedger")
+
+	bg := testutil.NewBlockGenerator(t)
+	block0 := bg.NextBlock([][]byte{[]byte("simRes0"), []byte("simRes1")}, false)
+	testutil.AssertNoError(t, l.Commit(block0), "")
+	txID := getTxIDFromBlock(t, block0, 1)
+	exists, err := l.TxExists(txID)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, exists, true)
+	exists, err = l.TxExists("missingTx")
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, exists, false)
+	l.Close()
+
+	// the filter is loaded from the block index when the ledger is reopened
+	l, _ = provider.Open("testLedger")
+	defer l.Close()
+	<-l.(*kvLedger).txIDFilter.done
+	testutil.AssertEquals(t, l.(*kvLedger).txIDFilter.mightContain("missingTx"), false)
+	exists, err = l.TxExists(txID)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, exists, true)
+	exists, err = l.TxExists("missingTx")
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, exists, false)
+}
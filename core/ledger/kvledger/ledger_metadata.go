@@ -0,0 +1,117 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/hyperledger/fabric/common/ledger/blkstorage"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/protos/common"
+)
+
+// GetLedgerMetadata implements the corresponding method from interface ledger.PeerLedgerProvider
+func (provider *Provider) GetLedgerMetadata(ledgerID string) (*ledger.LedgerMetadata, error) {
+	metadata, err := provider.idStore.getLedgerMetadata(ledgerID)
+	if err != nil {
+		return nil, err
+	}
+	if metadata == nil {
+		return nil, ErrNonExistingLedgerID
+	}
+	ledgerMetadata := &ledger.LedgerMetadata{LedgerID: ledgerID, Status: metadata.status, CreationBlock: metadata.creationBlock,
+		GenesisBlockHash: metadata.genesisBlockHash, Labels: metadata.labels, BootstrapMethod: metadata.bootstrapMethod,
+		OrdererEndpoints: metadata.ordererEndpoints, PendingMaintenance: scheduledMaintenance(metadata)}
+	if metadata.createdAt != 0 {
+		ledgerMetadata.CreatedAt = time.Unix(0, metadata.createdAt)
+	}
+	return ledgerMetadata, nil
+}
+
+// SetLedgerLabels implements the corresponding method from interface ledger.PeerLedgerProvider
+func (provider *Provider) SetLedgerLabels(ledgerID string, labels map[string]string) error {
+	if provider.readOnly {
+		return ErrLedgerReadOnly
+	}
+	var newLabels map[string]string
+	for key, value := range labels {
+		if key == "" {
+			return fmt.Errorf("Label key must not be empty")
+		}
+		if newLabels == nil {
+			newLabels = make(map[string]string)
+		}
+		newLabels[key] = value
+	}
+	return provider.idStore.updateLedgerMetadata(ledgerID, func(metadata *ledgerMetadata) { metadata.labels = newLabels })
+}
+
+// trackGenesisBlockHash records the hash of the genesis block of the opened ledger unless already recorded.
+// The hash is recorded right away if the block is committed, e.g., by a version of the peer that did not
+// record the hash, or else on the commit of the block. A ledger created from a snapshot has no genesis block
+func (provider *Provider) trackGenesisBlockHash(l *kvLedger) error {
+	metadata, err := provider.idStore.getLedgerMetadata(l.ledgerID)
+	if err != nil || metadata == nil {
+		return err
+	}
+	if metadata.creationBlock != 0 || metadata.genesisBlockHash != nil {
+		return nil
+	}
+	snapshotInfo, err := l.blockStore.GetSnapshotInfo()
+	if err != nil || snapshotInfo != nil {
+		return err
+	}
+	record := func(genesisBlockHash []byte) error {
+		return provider.idStore.updateLedgerMetadata(l.ledgerID, func(metadata *ledgerMetadata) { metadata.genesisBlockHash = genesisBlockHash })
+	}
+	info, err := l.blockStore.GetBlockchainInfo()
+	if err != nil {
+		return err
+	}
+	if info.Height == 0 {
+		l.genesisBlockHashRecorder = record
+		return nil
+	}
+	genesisBlock, err := l.blockStore.RetrieveBlockByNumber(0)
+	if err != nil {
+		return err
+	}
+	genesisBlockHash, err := blkstorage.ComputeBlockHash(genesisBlock.Header)
+	if err != nil {
+		return err
+	}
+	return record(genesisBlockHash)
+}
+
+// recordGenesisBlockHash records the hash of the committed block if the block is the genesis block of the ledger.
+// A failure is only logged as the block is committed by now and the hash is recorded on the next open of the ledger
+func (l *kvLedger) recordGenesisBlockHash(block *common.Block) {
+	if l.genesisBlockHashRecorder == nil || block.Header.Number != 0 {
+		return
+	}
+	genesisBlockHash, err := blkstorage.ComputeBlockHash(block.Header)
+	if err == nil {
+		err = l.genesisBlockHashRecorder(genesisBlockHash)
+	}
+	if err != nil {
+		logger.With(flogging.Fields{"channel": l.ledgerID}).Warningf("Error while recording the hash of the genesis block: %s", err)
+		return
+	}
+	l.genesisBlockHashRecorder = nil
+}
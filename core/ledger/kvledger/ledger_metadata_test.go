@@ -0,0 +1,112 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+)
+
+func TestLedgerMetadataEncoding(t *testing.T) {
+	config := &ledgerconfig.ChannelConfig{StateDatabase: "goleveldb", HistoryDatabase: true, QueryLimit: 100}
+	for _, metadata := range []*ledgerMetadata{
+		{status: ledger.LedgerStatusActive},
+		{status: ledger.LedgerStatusActive, config: config},
+		{status: ledger.LedgerStatusActive, config: config, createdAt: 1000, creationBlock: 5},
+		{status: ledger.LedgerStatusActive, config: config, genesisBlockHash: []byte("hash"), labels: map[string]string{"app": "a", "env": "test"}},
+		{status: ledger.LedgerStatusActive, config: &ledgerconfig.ChannelConfig{StateDatabase: "goleveldb", SystemNamespaces: []string{"lccc"}}},
+		// a ledger created before the config was persisted may be labeled
+		{status: ledger.LedgerStatusActive, labels: map[string]string{"app": "a"}},
+		{status: ledger.LedgerStatusActive, config: config, bootstrapMethod: ledger.BootstrapMethodJoin, ordererEndpoints: []string{"orderer0:7050", "orderer1:7050"}},
+		{status: ledger.LedgerStatusActive, config: &ledgerconfig.ChannelConfig{StateDatabase: "goleveldb", SystemNamespaces: []string{"lccc"}},
+			bootstrapMethod: ledger.BootstrapMethodDefaultChain},
+		{status: ledger.LedgerStatusActive, config: config, rebuildDBs: true, rollbackHeight: 3},
+		{status: ledger.LedgerStatusActive, rebuildBlockIndex: true},
+	} {
+		b, err := metadata.marshal()
+		testutil.AssertNoError(t, err, "")
+		decoded := &ledgerMetadata{}
+		testutil.AssertNoError(t, decoded.unmarshal(b), "")
+		testutil.AssertEquals(t, decoded, metadata)
+	}
+}
+
+func TestLedgerMetadata(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	provider, _ := NewProvider()
+	defer provider.Close()
+	l, err := provider.Create("testLedger")
+	testutil.AssertNoError(t, err, "")
+	defer l.Close()
+
+	metadata, err := provider.GetLedgerMetadata("testLedger")
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, metadata.CreatedAt.IsZero(), false)
+	testutil.AssertEquals(t, metadata.CreationBlock, uint64(0))
+	testutil.AssertNil(t, metadata.GenesisBlockHash)
+
+	// the hash of the genesis block is recorded on the commit of the block
+	simulator, _ := l.NewTxSimulator()
+	simulator.SetState("ns1", "key1", []byte("value1"))
+	simulator.Done()
+	simRes, _ := simulator.GetTxSimulationResults()
+	block0 := testutil.NewBlockGenerator(t).NextBlock([][]byte{simRes}, false)
+	testutil.AssertNoError(t, l.Commit(block0), "")
+	metadata, _ = provider.GetLedgerMetadata("testLedger")
+	bcInfo, _ := l.GetBlockchainInfo()
+	testutil.AssertEquals(t, metadata.GenesisBlockHash, bcInfo.CurrentBlockHash)
+
+	testutil.AssertNoError(t, provider.SetLedgerLabels("testLedger", map[string]string{"app": "marbles"}), "")
+	metadata, _ = provider.GetLedgerMetadata("testLedger")
+	testutil.AssertEquals(t, metadata.Labels, map[string]string{"app": "marbles"})
+	testutil.AssertEquals(t, metadata.GenesisBlockHash, bcInfo.CurrentBlockHash)
+	testutil.AssertNoError(t, provider.SetLedgerLabels("testLedger", nil), "")
+	metadata, _ = provider.GetLedgerMetadata("testLedger")
+	testutil.AssertNil(t, metadata.Labels)
+
+	testutil.AssertError(t, provider.SetLedgerLabels("testLedger", map[string]string{"": "x"}), "Expected an error for an empty label key")
+	testutil.AssertEquals(t, provider.SetLedgerLabels("nonExistingLedger", nil), ErrNonExistingLedgerID)
+	_, err = provider.GetLedgerMetadata("nonExistingLedger")
+	testutil.AssertEquals(t, err, ErrNonExistingLedgerID)
+}
+
+func TestGenesisBlockHashRecordedOnOpen(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	p, _ := NewProvider()
+	provider := p.(*Provider)
+	defer provider.Close()
+	l, _ := provider.Create("testLedger")
+	block0 := testutil.NewBlockGenerator(t).NextBlock([][]byte{}, false)
+	// the genesis block committed by a version of the peer that did not record the hash
+	l.(*kvLedger).genesisBlockHashRecorder = nil
+	testutil.AssertNoError(t, l.Commit(block0), "")
+	metadata, _ := provider.GetLedgerMetadata("testLedger")
+	testutil.AssertNil(t, metadata.GenesisBlockHash)
+	l.Close()
+
+	l, err := provider.Open("testLedger")
+	testutil.AssertNoError(t, err, "")
+	defer l.Close()
+	metadata, _ = provider.GetLedgerMetadata("testLedger")
+	bcInfo, _ := l.GetBlockchainInfo()
+	testutil.AssertEquals(t, metadata.GenesisBlockHash, bcInfo.CurrentBlockHash)
+}
@@ -0,0 +1,92 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/hyperledger/fabric/core/ledger"
+)
+
+// ScheduleMaintenance implements the corresponding method from interface ledger.PeerLedgerProvider
+func (provider *Provider) ScheduleMaintenance(ledgerID string, schedule *ledger.MaintenanceSchedule) error {
+	if provider.readOnly {
+		return ErrLedgerReadOnly
+	}
+	if err := checkLedgerActive(provider.idStore, ledgerID); err != nil {
+		return err
+	}
+	if schedule.RollbackHeight != 0 {
+		height, err := provider.blockStoreProvider.GetHeight(ledgerID)
+		if err != nil {
+			return err
+		}
+		if schedule.RollbackHeight >= height {
+			return fmt.Errorf("Rollback height [%d] must be below the height [%d] of ledger [%s]", schedule.RollbackHeight, height, ledgerID)
+		}
+	}
+	err := provider.idStore.updateLedgerMetadata(ledgerID, func(metadata *ledgerMetadata) {
+		metadata.rebuildBlockIndex = metadata.rebuildBlockIndex || schedule.RebuildBlockIndex
+		metadata.rebuildDBs = metadata.rebuildDBs || schedule.RebuildDBs
+		if schedule.RollbackHeight != 0 && (metadata.rollbackHeight == 0 || schedule.RollbackHeight < metadata.rollbackHeight) {
+			metadata.rollbackHeight = schedule.RollbackHeight
+		}
+	})
+	if err != nil {
+		return err
+	}
+	logger.With(flogging.Fields{"channel": ledgerID}).Infof("Scheduled maintenance for the next open: rebuildBlockIndex=%t, rollbackHeight=%d, rebuildDBs=%t",
+		schedule.RebuildBlockIndex, schedule.RollbackHeight, schedule.RebuildDBs)
+	return nil
+}
+
+// runScheduledMaintenance rebuilds the block index and rolls back the ledger if scheduled. Both run before the block store
+// is opened. A rollback marks the state DB and history DB for a rebuild, which then happens along with a scheduled rebuild
+func (provider *Provider) runScheduledMaintenance(ledgerID string) error {
+	metadata, err := provider.idStore.getLedgerMetadata(ledgerID)
+	if err != nil || metadata == nil || !metadata.hasScheduledMaintenance() {
+		return err
+	}
+	if provider.readOnly {
+		logger.With(flogging.Fields{"channel": ledgerID}).Warning("Scheduled maintenance does not run in read-only mode")
+		return nil
+	}
+	if metadata.rebuildBlockIndex {
+		logger.With(flogging.Fields{"channel": ledgerID}).Info("Rebuilding block index as scheduled")
+		if err := provider.blockStoreProvider.RebuildBlockIndex(ledgerID); err != nil {
+			return err
+		}
+	}
+	if metadata.rollbackHeight != 0 {
+		if err := rollbackStores(provider.idStore, provider.blockStoreProvider, provider.pvtdataStoreProvider, ledgerID, metadata.rollbackHeight); err != nil {
+			return err
+		}
+	}
+	return provider.idStore.updateLedgerMetadata(ledgerID, func(metadata *ledgerMetadata) {
+		metadata.rebuildBlockIndex = false
+		metadata.rollbackHeight = 0
+	})
+}
+
+// scheduledMaintenance returns the maintenance scheduled in the given metadata or nil if none
+func scheduledMaintenance(metadata *ledgerMetadata) *ledger.MaintenanceSchedule {
+	if !metadata.rebuildDBs && !metadata.hasScheduledMaintenance() {
+		return nil
+	}
+	return &ledger.MaintenanceSchedule{RebuildBlockIndex: metadata.rebuildBlockIndex, RollbackHeight: metadata.rollbackHeight, RebuildDBs: metadata.rebuildDBs}
+}
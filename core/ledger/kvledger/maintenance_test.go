@@ -0,0 +1,74 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	ledgerpackage "github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/protos/common"
+)
+
+func TestScheduleMaintenance(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	provider, _ := NewProvider()
+	ledger, _ := provider.Create("testLedger")
+	bg := testutil.NewBlockGenerator(t)
+	var blocks []*common.Block
+	for i := 0; i < 5; i++ {
+		simulator, _ := ledger.NewTxSimulator()
+		simulator.SetState("ns1", "key1", []byte(fmt.Sprintf("value1.%d", i)))
+		simulator.Done()
+		simRes, _ := simulator.GetTxSimulationResults()
+		block := bg.NextBlock([][]byte{simRes}, false)
+		testutil.AssertNoError(t, ledger.Commit(block), "")
+		blocks = append(blocks, block)
+	}
+
+	testutil.AssertEquals(t, provider.ScheduleMaintenance("non-existing-ledger", &ledgerpackage.MaintenanceSchedule{RebuildDBs: true}), ErrNonExistingLedgerID)
+	testutil.AssertError(t, provider.ScheduleMaintenance("testLedger", &ledgerpackage.MaintenanceSchedule{RollbackHeight: 5}),
+		"Expected an error for a rollback to the current height")
+	testutil.AssertNoError(t, provider.ScheduleMaintenance("testLedger", &ledgerpackage.MaintenanceSchedule{RollbackHeight: 4}), "")
+	testutil.AssertNoError(t, provider.ScheduleMaintenance("testLedger", &ledgerpackage.MaintenanceSchedule{RollbackHeight: 3, RebuildBlockIndex: true}), "")
+	// of two rollbacks, the one to the lower height is kept
+	testutil.AssertNoError(t, provider.ScheduleMaintenance("testLedger", &ledgerpackage.MaintenanceSchedule{RollbackHeight: 4}), "")
+	metadata, _ := provider.GetLedgerMetadata("testLedger")
+	testutil.AssertEquals(t, metadata.PendingMaintenance, &ledgerpackage.MaintenanceSchedule{RebuildBlockIndex: true, RollbackHeight: 3})
+
+	// the scheduled maintenance does not affect the opened ledger
+	bcInfo, _ := ledger.GetBlockchainInfo()
+	testutil.AssertEquals(t, bcInfo.Height, uint64(5))
+	ledger.Close()
+	provider.Close()
+
+	provider, _ = NewProvider()
+	defer provider.Close()
+	ledger, _ = provider.Open("testLedger")
+	defer ledger.Close()
+	bcInfo, _ = ledger.GetBlockchainInfo()
+	testutil.AssertEquals(t, bcInfo, &common.BlockchainInfo{
+		Height: 3, CurrentBlockHash: blocks[2].Header.Hash(), PreviousBlockHash: blocks[1].Header.Hash()})
+	qe, _ := ledger.NewQueryExecutor()
+	value, _ := qe.GetState("ns1", "key1")
+	qe.Done()
+	testutil.AssertEquals(t, value, []byte("value1.2"))
+	metadata, _ = provider.GetLedgerMetadata("testLedger")
+	testutil.AssertNil(t, metadata.PendingMaintenance)
+}
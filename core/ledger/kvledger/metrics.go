@@ -0,0 +1,64 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"time"
+
+	"github.com/hyperledger/fabric/common/metrics"
+	lutils "github.com/hyperledger/fabric/core/ledger/util"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/peer"
+)
+
+// the stores whose commit durations are reported by blockCommitDuration
+const (
+	blockStoreMetricLabel = "blockstore"
+	stateDBMetricLabel    = "statedb"
+	historyDBMetricLabel  = "historydb"
+)
+
+var (
+	blockCommitDuration = metrics.NewHistogramVec("ledger_block_commit_duration_seconds",
+		"Time taken in seconds for committing a block to a store of the ledger.", metrics.DefaultBuckets, "channel", "store")
+	blockchainHeight = metrics.NewGaugeVec("ledger_blockchain_height",
+		"Height of the blockchain of the ledger.", "channel")
+	transactionCount = metrics.NewCounterVec("ledger_transaction_count",
+		"Number of the committed transactions by the validation code.", "channel", "validation_code")
+	historyCommitLag = metrics.NewGaugeVec("ledger_history_commit_lag_blocks",
+		"Number of the blocks committed to the state database but not yet to the history database.", "channel")
+	dbRepairCount = metrics.NewCounterVec("ledger_db_repair_count",
+		"Number of the repairs of a database of the ledger that was lagging behind the block storage at ledger open.", "channel", "db")
+)
+
+// observeCommitDuration reports the time elapsed since the given start of a commit to the given store
+func observeCommitDuration(ledgerID string, store string, start time.Time) {
+	blockCommitDuration.Observe(time.Since(start).Seconds(), ledgerID, store)
+}
+
+// recordBlockStats reports the height of the ledger and the validation codes of the transactions of a committed block
+func recordBlockStats(ledgerID string, block *common.Block) {
+	blockchainHeight.Set(float64(block.Header.Number+1), ledgerID)
+	txsFilter := lutils.TxValidationFlags(block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER])
+	for txIndex := range block.Data.Data {
+		validationCode := peer.TxValidationCode_VALID
+		if len(txsFilter) > txIndex {
+			validationCode = txsFilter.Flag(txIndex)
+		}
+		transactionCount.Add(1, ledgerID, validationCode.String())
+	}
+}
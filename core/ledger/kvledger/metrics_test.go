@@ -0,0 +1,97 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/common/metrics"
+	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+	"github.com/hyperledger/fabric/protos/common"
+)
+
+func TestLedgerMetrics(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	provider, _ := NewProvider()
+	defer provider.Close()
+	l, _ := provider.Create("metricsLedger")
+	defer l.Close()
+
+	bg := testutil.NewBlockGenerator(t)
+	for i := 0; i < 2; i++ {
+		s, _ := l.NewTxSimulator()
+		s.SetState("ns", "key", []byte("value"))
+		s.Done()
+		res, _ := s.GetTxSimulationResults()
+		testutil.AssertNoError(t, l.Commit(bg.NextBlock([][]byte{res}, false)), "")
+	}
+
+	exposition := scrapeMetrics()
+	for _, expected := range []string{
+		`ledger_blockchain_height{channel="metricsLedger"} 2`,
+		`ledger_transaction_count{channel="metricsLedger",validation_code="VALID"} 2`,
+		`ledger_block_commit_duration_seconds_count{channel="metricsLedger",store="blockstore"} 2`,
+		`ledger_block_commit_duration_seconds_count{channel="metricsLedger",store="statedb"} 2`,
+	} {
+		testutil.AssertEquals(t, strings.Contains(exposition, expected), true)
+	}
+}
+
+func TestDBRepairMetrics(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	provider, _ := NewProvider()
+	l, _ := provider.Create("repairLedger")
+
+	bg := testutil.NewBlockGenerator(t)
+	blocks := make([]*common.Block, 2)
+	for i := range blocks {
+		s, _ := l.NewTxSimulator()
+		s.SetState("ns", "key", []byte("value"))
+		s.Done()
+		res, _ := s.GetTxSimulationResults()
+		blocks[i] = bg.NextBlock([][]byte{res}, false)
+	}
+	testutil.AssertNoError(t, l.Commit(blocks[0]), "")
+	// the second block reaches the block storage only, as if the peer failed before committing it to the other databases
+	testutil.AssertNoError(t, l.(*kvLedger).blockStore.AddBlock(blocks[1]), "")
+	l.Close()
+	provider.Close()
+
+	provider, _ = NewProvider()
+	defer provider.Close()
+	l, err := provider.Open("repairLedger")
+	testutil.AssertNoError(t, err, "")
+	defer l.Close()
+
+	exposition := scrapeMetrics()
+	testutil.AssertEquals(t, strings.Contains(exposition, `ledger_db_repair_count{channel="repairLedger",db="state DB"} 1`), true)
+	if ledgerconfig.IsHistoryDBEnabled() {
+		testutil.AssertEquals(t, strings.Contains(exposition, `ledger_db_repair_count{channel="repairLedger",db="history DB"} 1`), true)
+	}
+}
+
+// scrapeMetrics returns the exposition of the metrics that are registered with the default registry
+func scrapeMetrics() string {
+	recorder := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	return recorder.Body.String()
+}
@@ -0,0 +1,243 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/hyperledger/fabric/common/metrics"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
+	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+)
+
+var (
+	namespaceKeys = metrics.NewGaugeVec("ledger_namespace_keys",
+		"Number of the keys of a namespace in the state database.", "channel", "namespace")
+	namespaceSizeBytes = metrics.NewGaugeVec("ledger_namespace_size_bytes",
+		"Number of the bytes of the keys and the values of a namespace in the state database.", "channel", "namespace")
+)
+
+// namespaceSizes maintains the number of the keys and the bytes of each namespace of the state database. The sizes
+// are updated from the updates of each block and the values that they replace, and are persisted to the given file,
+// along with the savepoint of the state database, when the ledger is closed. The sizes are recomputed by a scan of
+// the state database if the persisted savepoint does not match, i.e., if the ledger was not closed cleanly
+type namespaceSizes struct {
+	ledgerID   string
+	path       string
+	db         statedb.VersionedDB
+	lock       sync.Mutex
+	namespaces map[string]*ledger.NamespaceSize
+	// stale is set if the values replaced by the updates of a block could not be read
+	stale bool
+}
+
+// persistedNamespaceSizes is the content of the file to which the sizes are persisted
+type persistedNamespaceSizes struct {
+	Savepoint  []byte
+	Namespaces []*ledger.NamespaceSize
+}
+
+// newNamespaceSizes loads the sizes persisted to the given file, or computes them from the state database
+// if the file is missing or does not correspond to the savepoint of the state database
+func newNamespaceSizes(ledgerID string, path string, db statedb.VersionedDB) (*namespaceSizes, error) {
+	s := &namespaceSizes{ledgerID: ledgerID, path: path, db: db, namespaces: make(map[string]*ledger.NamespaceSize)}
+	savepoint, err := db.GetLatestSavePoint()
+	if err != nil {
+		return nil, err
+	}
+	if s.load(savepoint) {
+		return s, nil
+	}
+	if err := s.compute(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// load returns false if the sizes are not persisted for the given savepoint
+func (s *namespaceSizes) load(savepoint *version.Height) bool {
+	sizesLogger := logger.With(flogging.Fields{"channel": s.ledgerID})
+	fileBytes, err := ioutil.ReadFile(s.path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			sizesLogger.Warningf("Ignoring the persisted sizes of the namespaces: %s", err)
+		}
+		return false
+	}
+	persisted := &persistedNamespaceSizes{}
+	if err := json.Unmarshal(fileBytes, persisted); err != nil {
+		sizesLogger.Warningf("Ignoring the persisted sizes of the namespaces: %s", err)
+		return false
+	}
+	var savepointBytes []byte
+	if savepoint != nil {
+		savepointBytes = savepoint.ToBytes()
+	}
+	if string(persisted.Savepoint) != string(savepointBytes) {
+		sizesLogger.Info("Ignoring the persisted sizes of the namespaces as they do not correspond to the state database")
+		return false
+	}
+	for _, nsSize := range persisted.Namespaces {
+		s.namespaces[nsSize.Namespace] = nsSize
+		s.reportMetrics(nsSize)
+	}
+	return true
+}
+
+// compute scans the state database for the sizes of the namespaces
+func (s *namespaceSizes) compute() error {
+	logger.With(flogging.Fields{"channel": s.ledgerID}).Info("Computing the sizes of the namespaces from the state database")
+	itr, err := s.db.GetFullScanIterator()
+	if err != nil {
+		return err
+	}
+	defer itr.Close()
+	for {
+		queryResult, err := itr.Next()
+		if err != nil {
+			return err
+		}
+		if queryResult == nil {
+			break
+		}
+		vkv := queryResult.(*statedb.VersionedKV)
+		nsSize := s.getOrCreate(vkv.Namespace)
+		nsSize.KeyCount++
+		nsSize.Size += uint64(len(vkv.Key) + len(vkv.Value))
+	}
+	for _, nsSize := range s.namespaces {
+		s.reportMetrics(nsSize)
+	}
+	return nil
+}
+
+// Update implements method in interface `lockbasedtxmgr.NamespaceSizeTracker`.
+// The values replaced by the updates are read from the state database. If they cannot be read, the size
+// of the namespace are left as they are, and are recomputed when the ledger is opened next as they are not persisted
+func (s *namespaceSizes) Update(batch *statedb.UpdateBatch) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, namespace := range batch.GetUpdatedNamespaces() {
+		updates := batch.GetUpdates(namespace)
+		keys := make([]string, 0, len(updates))
+		for key := range updates {
+			keys = append(keys, key)
+		}
+		oldValues, err := s.db.GetStateMultipleKeys(namespace, keys)
+		if err != nil {
+			logger.With(flogging.Fields{"channel": s.ledgerID, "namespace": namespace}).Warningf(
+				"Error while reading the values replaced by the updates, the sizes of the namespaces are not persisted: %s", err)
+			s.stale = true
+			continue
+		}
+		nsSize := s.getOrCreate(namespace)
+		for i, key := range keys {
+			if oldValue := oldValues[i]; oldValue != nil {
+				nsSize.KeyCount--
+				nsSize.Size -= uint64(len(key) + len(oldValue.Value))
+			}
+			if newValue := updates[key]; newValue.Value != nil {
+				nsSize.KeyCount++
+				nsSize.Size += uint64(len(key) + len(newValue.Value))
+			}
+		}
+		s.reportMetrics(nsSize)
+		if nsSize.KeyCount == 0 {
+			delete(s.namespaces, namespace)
+		}
+	}
+}
+
+// Get implements method in interface `lockbasedtxmgr.NamespaceSizeTracker`
+func (s *namespaceSizes) Get(namespace string) *ledger.NamespaceSize {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if nsSize, ok := s.namespaces[namespace]; ok {
+		nsSizeCopy := *nsSize
+		return &nsSizeCopy
+	}
+	return &ledger.NamespaceSize{Namespace: namespace}
+}
+
+// getOrCreate is expected to be invoked with the lock held, or before the sizes are shared
+func (s *namespaceSizes) getOrCreate(namespace string) *ledger.NamespaceSize {
+	nsSize, ok := s.namespaces[namespace]
+	if !ok {
+		nsSize = &ledger.NamespaceSize{Namespace: namespace}
+		s.namespaces[namespace] = nsSize
+	}
+	return nsSize
+}
+
+func (s *namespaceSizes) reportMetrics(nsSize *ledger.NamespaceSize) {
+	namespaceKeys.Set(float64(nsSize.KeyCount), s.ledgerID, nsSize.Namespace)
+	namespaceSizeBytes.Set(float64(nsSize.Size), s.ledgerID, nsSize.Namespace)
+}
+
+// persist writes the sizes, along with the savepoint of the state database, to the file. The file is
+// replaced atomically so that a crash during the write does not leave a partial file. This is expected
+// to be invoked when no more blocks are committed
+func (s *namespaceSizes) persist() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.stale {
+		return nil
+	}
+	savepoint, err := s.db.GetLatestSavePoint()
+	if err != nil {
+		return err
+	}
+	persisted := &persistedNamespaceSizes{}
+	if savepoint != nil {
+		persisted.Savepoint = savepoint.ToBytes()
+	}
+	for _, nsSize := range s.namespaces {
+		persisted.Namespaces = append(persisted.Namespaces, nsSize)
+	}
+	fileBytes, err := json.Marshal(persisted)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	tempPath := s.path + ".tmp"
+	if err := ioutil.WriteFile(tempPath, fileBytes, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tempPath, s.path)
+}
+
+// namespaceSizesPath returns the path of the file to which the sizes of the namespaces of the given ledger are persisted
+func namespaceSizesPath(ledgerID string) string {
+	return filepath.Join(ledgerconfig.GetNamespaceSizesPath(), ledgerID)
+}
+
+// dropNamespaceSizes removes the file of the sizes of the namespaces of the given ledger
+func dropNamespaceSizes(ledgerID string) error {
+	if err := os.Remove(namespaceSizesPath(ledgerID)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"os"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/spf13/viper"
+)
+
+func TestNamespaceSizes(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	viper.Set("ledger.state.namespaceSizes", true)
+	defer viper.Set("ledger.state.namespaceSizes", false)
+	provider, _ := NewProvider()
+	l, _ := provider.Create("testLedger")
+
+	bg := testutil.NewBlockGenerator(t)
+	simulateAndCommit := func(update func(s ledger.TxSimulator)) {
+		s, _ := l.NewTxSimulator()
+		update(s)
+		s.Done()
+		res, _ := s.GetTxSimulationResults()
+		testutil.AssertNoError(t, l.Commit(bg.NextBlock([][]byte{res}, false)), "")
+	}
+	simulateAndCommit(func(s ledger.TxSimulator) {
+		s.SetState("ns1", "key1", []byte("value1"))
+		s.SetState("ns1", "key2", []byte("value2"))
+		s.SetState("ns2", "key1", []byte("value3"))
+	})
+	simulateAndCommit(func(s ledger.TxSimulator) {
+		s.SetState("ns1", "key1", []byte("value1_updated"))
+		s.SetState("ns1", "key3", []byte("value3"))
+		s.DeleteState("ns2", "key1")
+		s.DeleteState("ns2", "missingkey")
+	})
+	expectedSizes := map[string]*ledger.NamespaceSize{
+		"ns1": {Namespace: "ns1", KeyCount: 3, Size: 4 + 14 + 4 + 6 + 4 + 6},
+		"ns2": {Namespace: "ns2"},
+	}
+	checkSizes := func(l ledger.PeerLedger) {
+		qe, _ := l.NewQueryExecutor()
+		defer qe.Done()
+		for namespace, expectedSize := range expectedSizes {
+			size, err := qe.GetNamespaceSize(namespace)
+			testutil.AssertNoError(t, err, "")
+			testutil.AssertEquals(t, size, expectedSize)
+		}
+	}
+	checkSizes(l)
+
+	// the sizes are persisted when the ledger is closed
+	l.Close()
+	provider.Close()
+	provider, _ = NewProvider()
+	l, _ = provider.Open("testLedger")
+	testutil.AssertEquals(t, len(l.(*kvLedger).namespaceSizes.namespaces), 1)
+	checkSizes(l)
+	l.Close()
+	provider.Close()
+
+	// the sizes are recomputed from the state database if they are not persisted
+	testutil.AssertNoError(t, os.Remove(namespaceSizesPath("testLedger")), "")
+	provider, _ = NewProvider()
+	defer provider.Close()
+	l, _ = provider.Open("testLedger")
+	defer l.Close()
+	checkSizes(l)
+}
+
+func TestNamespaceSizesNotEnabled(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	provider, _ := NewProvider()
+	defer provider.Close()
+	l, _ := provider.Create("testLedger")
+	defer l.Close()
+	qe, _ := l.NewQueryExecutor()
+	defer qe.Done()
+	_, err := qe.GetNamespaceSize("ns1")
+	_, ok := err.(*ledger.NotEnabledError)
+	testutil.AssertEquals(t, ok, true)
+}
@@ -0,0 +1,152 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/hyperledger/fabric/common/metrics"
+	"github.com/hyperledger/fabric/core/ledger"
+	lutils "github.com/hyperledger/fabric/core/ledger/util"
+	"github.com/hyperledger/fabric/protos/common"
+)
+
+// the operations on the state of a namespace reported by namespaceOperations
+const (
+	readOperationLabel       = "read"
+	rangeQueryOperationLabel = "range_query"
+	richQueryOperationLabel  = "rich_query"
+	writeOperationLabel      = "write"
+	deleteOperationLabel     = "delete"
+)
+
+var (
+	namespaceOperations = metrics.NewCounterVec("ledger_namespace_operations_total",
+		"Number of the operations on the state of a namespace.", "channel", "namespace", "operation")
+	namespaceWrittenBytes = metrics.NewCounterVec("ledger_namespace_written_bytes_total",
+		"Number of the bytes of the keys and the values written to the state of a namespace.", "channel", "namespace")
+)
+
+// namespaceStats maintains the number of the operations on the state of the namespaces of a ledger since the ledger was opened.
+// The operations recorded in the read-write sets of the valid transactions are aggregated per block, whereas the rich
+// queries are counted when executed, as the read-write sets do not record them
+type namespaceStats struct {
+	ledgerID   string
+	lock       sync.Mutex
+	namespaces map[string]*ledger.NamespaceStats
+}
+
+func newNamespaceStats(ledgerID string) *namespaceStats {
+	return &namespaceStats{ledgerID: ledgerID, namespaces: make(map[string]*ledger.NamespaceStats)}
+}
+
+// addBlock adds the operations of the valid endorser transactions in the block
+func (s *namespaceStats) addBlock(block *common.Block) error {
+	blockStats := make(map[string]*ledger.NamespaceStats)
+	txsFilter := lutils.TxValidationFlags(block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER])
+	for txIndex, envBytes := range block.Data.Data {
+		if len(txsFilter) > txIndex && txsFilter.IsInvalid(txIndex) {
+			continue
+		}
+		_, txRWSet, err := extractTxRWSet(envBytes)
+		if err != nil {
+			return err
+		}
+		if txRWSet == nil {
+			continue
+		}
+		for _, nsRWSet := range txRWSet.NsRWs {
+			nsStats, ok := blockStats[nsRWSet.NameSpace]
+			if !ok {
+				nsStats = &ledger.NamespaceStats{Namespace: nsRWSet.NameSpace, Blocks: 1}
+				blockStats[nsRWSet.NameSpace] = nsStats
+			}
+			nsStats.Reads += uint64(len(nsRWSet.Reads))
+			nsStats.RangeQueries += uint64(len(nsRWSet.RangeQueriesInfo))
+			for _, kvWrite := range nsRWSet.Writes {
+				if kvWrite.IsDelete {
+					nsStats.Deletes++
+					continue
+				}
+				nsStats.Writes++
+				nsStats.WrittenBytes += uint64(len(kvWrite.Key) + len(kvWrite.Value))
+			}
+		}
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for namespace, blockNsStats := range blockStats {
+		nsStats := s.getOrCreate(namespace)
+		nsStats.Blocks += blockNsStats.Blocks
+		nsStats.Reads += blockNsStats.Reads
+		nsStats.RangeQueries += blockNsStats.RangeQueries
+		nsStats.Writes += blockNsStats.Writes
+		nsStats.Deletes += blockNsStats.Deletes
+		nsStats.WrittenBytes += blockNsStats.WrittenBytes
+		namespaceOperations.Add(float64(blockNsStats.Reads), s.ledgerID, namespace, readOperationLabel)
+		namespaceOperations.Add(float64(blockNsStats.RangeQueries), s.ledgerID, namespace, rangeQueryOperationLabel)
+		namespaceOperations.Add(float64(blockNsStats.Writes), s.ledgerID, namespace, writeOperationLabel)
+		namespaceOperations.Add(float64(blockNsStats.Deletes), s.ledgerID, namespace, deleteOperationLabel)
+		namespaceWrittenBytes.Add(float64(blockNsStats.WrittenBytes), s.ledgerID, namespace)
+	}
+	return nil
+}
+
+// addRichQuery counts a rich query executed on the namespace
+func (s *namespaceStats) addRichQuery(namespace string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.getOrCreate(namespace).RichQueries++
+	namespaceOperations.Add(1, s.ledgerID, namespace, richQueryOperationLabel)
+}
+
+// getOrCreate is expected to be invoked with the lock held
+func (s *namespaceStats) getOrCreate(namespace string) *ledger.NamespaceStats {
+	nsStats, ok := s.namespaces[namespace]
+	if !ok {
+		nsStats = &ledger.NamespaceStats{Namespace: namespace}
+		s.namespaces[namespace] = nsStats
+	}
+	return nsStats
+}
+
+// get returns a copy of the stats of the namespaces, sorted by namespace
+func (s *namespaceStats) get() []*ledger.NamespaceStats {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var namespaces []string
+	for namespace := range s.namespaces {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+	var stats []*ledger.NamespaceStats
+	for _, namespace := range namespaces {
+		nsStatsCopy := *s.namespaces[namespace]
+		stats = append(stats, &nsStatsCopy)
+	}
+	return stats
+}
+
+// GetNamespaceStats implements method in interface `ledger.PeerLedger`
+func (l *kvLedger) GetNamespaceStats() ([]*ledger.NamespaceStats, error) {
+	if l.namespaceStats == nil {
+		return nil, &ledger.NotEnabledError{Msg: "Namespace statistics not enabled - ledger.state.namespaceStats is false"}
+	}
+	return l.namespaceStats.get(), nil
+}
@@ -0,0 +1,83 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/spf13/viper"
+)
+
+func TestNamespaceStats(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	viper.Set("ledger.state.namespaceStats", true)
+	defer viper.Set("ledger.state.namespaceStats", false)
+	provider, _ := NewProvider()
+	defer provider.Close()
+	l, _ := provider.Create("testLedger")
+	defer l.Close()
+
+	bg := testutil.NewBlockGenerator(t)
+	simulateAndCommit := func(update func(s ledger.TxSimulator)) {
+		s, _ := l.NewTxSimulator()
+		update(s)
+		s.Done()
+		res, _ := s.GetTxSimulationResults()
+		testutil.AssertNoError(t, l.Commit(bg.NextBlock([][]byte{res}, false)), "")
+	}
+	simulateAndCommit(func(s ledger.TxSimulator) {
+		s.SetState("ns1", "key1", []byte("value1"))
+		s.SetState("ns1", "key2", []byte("value2"))
+		s.SetState("ns2", "key1", []byte("value3"))
+	})
+	simulateAndCommit(func(s ledger.TxSimulator) {
+		s.GetState("ns1", "key1")
+		itr, _ := s.GetStateRangeScanIterator("ns1", "key1", "key3")
+		for {
+			kv, _ := itr.Next()
+			if kv == nil {
+				break
+			}
+		}
+		itr.Close()
+		s.DeleteState("ns1", "key2")
+	})
+	l.(*kvLedger).namespaceStats.addRichQuery("ns2")
+
+	stats, err := l.GetNamespaceStats()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, stats, []*ledger.NamespaceStats{
+		{Namespace: "ns1", Blocks: 2, Reads: 1, RangeQueries: 1, Writes: 2, Deletes: 1, WrittenBytes: 20},
+		{Namespace: "ns2", Blocks: 1, RichQueries: 1, Writes: 1, WrittenBytes: 10},
+	})
+}
+
+func TestNamespaceStatsNotEnabled(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	provider, _ := NewProvider()
+	defer provider.Close()
+	l, _ := provider.Create("testLedger")
+	defer l.Close()
+
+	_, err := l.GetNamespaceStats()
+	_, ok := err.(*ledger.NotEnabledError)
+	testutil.AssertEquals(t, ok, true)
+}
@@ -16,9 +16,18 @@ limitations under the License.
 
 package kvledger
 
-import "github.com/hyperledger/fabric/protos/common"
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
+	"github.com/hyperledger/fabric/protos/common"
+)
 
 type recoverable interface {
+	// GetLastSavepoint returns the height upto which the recoverable is consistent with the block storage.
+	// A nil height indicates that nothing has been committed yet
+	GetLastSavepoint() (*version.Height, error)
 	// ShouldRecover return whether recovery is need.
 	// If the recovery is needed, this method also returns the block number to start recovery from.
 	// lastAvailableBlock is the max block number that has been committed to the block storage
@@ -28,6 +37,40 @@ type recoverable interface {
 }
 
 type recoverer struct {
+	name          string
 	firstBlockNum uint64
 	recoverable   recoverable
 }
+
+// recoverersByFirstBlock sorts the recoverers by the block number to start the recovery from
+type recoverersByFirstBlock []*recoverer
+
+func (r recoverersByFirstBlock) Len() int           { return len(r) }
+func (r recoverersByFirstBlock) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
+func (r recoverersByFirstBlock) Less(i, j int) bool { return r[i].firstBlockNum < r[j].firstBlockNum }
+
+// namedRecoverable pairs a recoverable with a name used for reporting
+type namedRecoverable struct {
+	name        string
+	recoverable recoverable
+}
+
+// checkSavepointConsistency cross-checks the savepoints of the given recoverables against the height
+// of the block storage. A savepoint that is ahead of the block storage cannot be repaired by recommitting
+// blocks and hence an error is returned for such a recoverable
+func checkSavepointConsistency(blockStoreHeight uint64, recoverables []*namedRecoverable) error {
+	for _, r := range recoverables {
+		savepoint, err := r.recoverable.GetLastSavepoint()
+		if err != nil {
+			return err
+		}
+		if savepoint == nil {
+			continue
+		}
+		if savepoint.BlockNum >= blockStoreHeight {
+			return &ledger.CorruptionError{Msg: fmt.Sprintf("Savepoint of %s [%d] is ahead of the block storage height [%d]",
+				r.name, savepoint.BlockNum, blockStoreHeight)}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
+	"github.com/hyperledger/fabric/protos/common"
+)
+
+type mockRecoverable struct {
+	savepoint *version.Height
+}
+
+func (r *mockRecoverable) GetLastSavepoint() (*version.Height, error) {
+	return r.savepoint, nil
+}
+
+func (r *mockRecoverable) ShouldRecover(lastAvailableBlock uint64) (bool, uint64, error) {
+	if r.savepoint == nil {
+		return true, 0, nil
+	}
+	return r.savepoint.BlockNum != lastAvailableBlock, r.savepoint.BlockNum + 1, nil
+}
+
+func (r *mockRecoverable) CommitLostBlock(block *common.Block) error {
+	r.savepoint = version.NewHeight(block.Header.Number, uint64(len(block.Data.Data)))
+	return nil
+}
+
+func TestCheckSavepointConsistency(t *testing.T) {
+	behind := &namedRecoverable{"behind", &mockRecoverable{version.NewHeight(2, 1)}}
+	empty := &namedRecoverable{"empty", &mockRecoverable{}}
+	ahead := &namedRecoverable{"ahead", &mockRecoverable{version.NewHeight(5, 1)}}
+
+	testutil.AssertNoError(t, checkSavepointConsistency(5, []*namedRecoverable{behind, empty}), "")
+	testutil.AssertError(t, checkSavepointConsistency(5, []*namedRecoverable{behind, ahead}),
+		"Expected an error for a savepoint ahead of the block storage")
+	testutil.AssertError(t, checkSavepointConsistency(0, []*namedRecoverable{behind}),
+		"Expected an error for a savepoint when the block storage is empty")
+}
@@ -0,0 +1,134 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+)
+
+// RebuildBlockIndex drops the index of the block store of the given ledger and rebuilds it from the block files.
+// This is an offline operation and must not be invoked while the peer is running
+func RebuildBlockIndex(ledgerID string) error {
+	shared, err := getSharedLevelDB(false)
+	if err != nil {
+		return err
+	}
+	idStore := openIDStore(ledgerconfig.GetLedgerProviderPath(), shared)
+	defer idStore.close()
+	if err := checkLedgerActive(idStore, ledgerID); err != nil {
+		return err
+	}
+	blockStoreProvider := newBlockStoreProvider(shared)
+	defer blockStoreProvider.Close()
+	if err := checkDataFormat(blockStoreName, blockStoreProvider, false); err != nil {
+		return err
+	}
+	logger.With(flogging.Fields{"channel": ledgerID}).Info("Rebuilding block index")
+	if err := blockStoreProvider.RebuildBlockIndex(ledgerID); err != nil {
+		return err
+	}
+	logger.With(flogging.Fields{"channel": ledgerID}).Info("Rebuilt block index")
+	return nil
+}
+
+// RebuildStateDB drops the state database of the given ledger and rebuilds it by replaying the blocks in the block store.
+// This is an offline operation and must not be invoked while the peer is running
+func RebuildStateDB(ledgerID string) error {
+	return rebuildDB(ledgerID, "state DB", func(provider *Provider, config *ledgerconfig.ChannelConfig) error {
+		vdbProvider, err := provider.getVDBProvider(config)
+		if err != nil {
+			return err
+		}
+		if err := vdbProvider.Drop(ledgerID); err != nil {
+			return err
+		}
+		return provider.dropSystemState(ledgerID, config)
+	})
+}
+
+// RebuildHistoryDB drops the history database of the given ledger and rebuilds it by replaying the blocks in the block store.
+// This is an offline operation and must not be invoked while the peer is running
+func RebuildHistoryDB(ledgerID string) error {
+	return rebuildDB(ledgerID, "history DB", func(provider *Provider, config *ledgerconfig.ChannelConfig) error {
+		if !config.HistoryDatabase {
+			return &ledger.NotEnabledError{Msg: fmt.Sprintf("History database is not enabled for ledger [%s]", ledgerID)}
+		}
+		return provider.historydbProvider.Drop(ledgerID)
+	})
+}
+
+// rebuildDB drops a database of the ledger with the given drop function and opens the ledger,
+// which brings the dropped database up to the height of the block store by the recovery
+func rebuildDB(ledgerID string, name string, drop func(provider *Provider, config *ledgerconfig.ChannelConfig) error) error {
+	p, err := NewProvider()
+	if err != nil {
+		return err
+	}
+	provider := p.(*Provider)
+	defer provider.Close()
+	if err := checkLedgerActive(provider.idStore, ledgerID); err != nil {
+		return err
+	}
+	config, err := provider.getLedgerConfig(ledgerID)
+	if err != nil {
+		return err
+	}
+	// the blocks up to the snapshot are not available in the block store for a replay
+	blockStore, err := provider.blockStoreProvider.OpenBlockStore(ledgerID)
+	if err != nil {
+		return err
+	}
+	snapshotInfo, err := blockStore.GetSnapshotInfo()
+	blockStore.Shutdown()
+	if err != nil {
+		return err
+	}
+	if snapshotInfo != nil {
+		return &ledger.NotEnabledError{Msg: fmt.Sprintf("Cannot rebuild the %s of ledger [%s] as the ledger is bootstrapped from a snapshot", name, ledgerID)}
+	}
+
+	logger.With(flogging.Fields{"channel": ledgerID}).Infof("Dropping %s for a rebuild from block storage", name)
+	if err := drop(provider, config); err != nil {
+		return err
+	}
+	l, err := provider.openLedger(ledgerID)
+	if err != nil {
+		return err
+	}
+	l.Close()
+	logger.With(flogging.Fields{"channel": ledgerID}).Infof("Rebuilt %s", name)
+	return nil
+}
+
+// checkLedgerActive returns an error if the ledger with the given id does not exist or is not active
+func checkLedgerActive(idStore *idStore, ledgerID string) error {
+	metadata, err := idStore.getLedgerMetadata(ledgerID)
+	if err != nil {
+		return err
+	}
+	if metadata == nil {
+		return ErrNonExistingLedgerID
+	}
+	if metadata.status != ledger.LedgerStatusActive {
+		return ErrLedgerNotActive
+	}
+	return nil
+}
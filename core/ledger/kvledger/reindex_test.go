@@ -0,0 +1,97 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	ledgerpackage "github.com/hyperledger/fabric/core/ledger"
+	"github.com/spf13/viper"
+)
+
+func TestRebuildLedgerDBs(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	defer viper.Set("ledger.state.historyDatabase", viper.GetBool("ledger.state.historyDatabase"))
+	viper.Set("ledger.state.historyDatabase", true)
+
+	provider, _ := NewProvider()
+	ledger, _ := provider.Create("testLedger")
+	bg := testutil.NewBlockGenerator(t)
+	for i := 0; i < 5; i++ {
+		simulator, _ := ledger.NewTxSimulator()
+		simulator.SetState("ns1", "key1", []byte(fmt.Sprintf("value1.%d", i)))
+		simulator.Done()
+		simRes, _ := simulator.GetTxSimulationResults()
+		testutil.AssertNoError(t, ledger.Commit(bg.NextBlock([][]byte{simRes}, false)), "")
+	}
+	ledger.Close()
+	provider.Close()
+
+	testutil.AssertEquals(t, RebuildBlockIndex("non-existing-ledger"), ErrNonExistingLedgerID)
+	testutil.AssertEquals(t, RebuildStateDB("non-existing-ledger"), ErrNonExistingLedgerID)
+	testutil.AssertEquals(t, RebuildHistoryDB("non-existing-ledger"), ErrNonExistingLedgerID)
+	testutil.AssertNoError(t, RebuildBlockIndex("testLedger"), "")
+	testutil.AssertNoError(t, RebuildStateDB("testLedger"), "")
+	testutil.AssertNoError(t, RebuildHistoryDB("testLedger"), "")
+
+	provider, _ = NewProvider()
+	defer provider.Close()
+	ledger, _ = provider.Open("testLedger")
+	defer ledger.Close()
+	bcInfo, _ := ledger.GetBlockchainInfo()
+	testutil.AssertEquals(t, bcInfo.Height, uint64(5))
+	block, err := ledger.GetBlockByNumber(4)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, block.Header.Number, uint64(4))
+
+	qe, _ := ledger.NewQueryExecutor()
+	value, _ := qe.GetState("ns1", "key1")
+	qe.Done()
+	testutil.AssertEquals(t, value, []byte("value1.4"))
+	stateDBSavepoint, _ := ledger.(*kvLedger).txtmgmt.GetLastSavepoint()
+	testutil.AssertEquals(t, stateDBSavepoint.BlockNum, uint64(4))
+
+	qhistory, _ := ledger.NewHistoryQueryExecutor()
+	itr, _ := qhistory.GetHistoryForKey("ns1", "key1")
+	count := 0
+	for {
+		result, _ := itr.Next()
+		if result == nil {
+			break
+		}
+		count++
+	}
+	itr.Close()
+	testutil.AssertEquals(t, count, 5)
+}
+
+func TestRebuildHistoryDBNotEnabled(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	defer viper.Set("ledger.state.historyDatabase", viper.GetBool("ledger.state.historyDatabase"))
+	viper.Set("ledger.state.historyDatabase", false)
+
+	provider, _ := NewProvider()
+	ledger, _ := provider.Create("testLedger")
+	ledger.Close()
+	provider.Close()
+	_, ok := RebuildHistoryDB("testLedger").(*ledgerpackage.NotEnabledError)
+	testutil.AssertEquals(t, ok, true)
+}
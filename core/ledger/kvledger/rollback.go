@@ -0,0 +1,86 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/hyperledger/fabric/common/ledger/blkstorage"
+	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+	"github.com/hyperledger/fabric/core/ledger/pvtdatastorage"
+)
+
+// RollbackKVLedger rolls back the ledger with the given id such that only the blocks below the given height remain.
+// The block storage is truncated and the state DB and history DB of the ledger are marked for a rebuild,
+// which happens from the remaining blocks when the ledger is opened next time.
+// This is an offline operation and must not be invoked while the peer is running
+func RollbackKVLedger(ledgerID string, height uint64) error {
+	shared, err := getSharedLevelDB(false)
+	if err != nil {
+		return err
+	}
+	idStore := openIDStore(ledgerconfig.GetLedgerProviderPath(), shared)
+	defer idStore.close()
+	if err := checkLedgerActive(idStore, ledgerID); err != nil {
+		return err
+	}
+
+	blockStoreProvider := newBlockStoreProvider(shared)
+	defer blockStoreProvider.Close()
+	if err := checkDataFormat(blockStoreName, blockStoreProvider, false); err != nil {
+		return err
+	}
+	pvtdataStoreProvider := pvtdatastorage.NewProvider()
+	defer pvtdataStoreProvider.Close()
+	if err := rollbackStores(idStore, blockStoreProvider, pvtdataStoreProvider, ledgerID, height); err != nil {
+		return err
+	}
+	logger.With(flogging.Fields{"channel": ledgerID}).Infof("Rolled back ledger to height [%d]. State DB and history DB are rebuilt when the ledger is opened next time", height)
+	return nil
+}
+
+// rollbackStores truncates the block storage and the private data store of the ledger with the given id
+// to the given height and marks the state DB and history DB of the ledger for a rebuild
+func rollbackStores(idStore *idStore, blockStoreProvider blkstorage.BlockStoreProvider, pvtdataStoreProvider pvtdatastorage.Provider,
+	ledgerID string, height uint64) error {
+	logger.With(flogging.Fields{"channel": ledgerID}).Infof("Rolling back ledger to height [%d]", height)
+	if err := blockStoreProvider.RollbackBlockStore(ledgerID, height); err != nil {
+		return err
+	}
+	pvtdataStore, err := pvtdataStoreProvider.OpenStore(ledgerID)
+	if err != nil {
+		return err
+	}
+	// the private data left pending by a crash is settled first, as the private data cannot be truncated while a batch is pending
+	pending, pendingBlock, err := pvtdataStore.HasPendingBatch()
+	if err != nil {
+		return err
+	}
+	if pending && pendingBlock < height {
+		err = pvtdataStore.Commit()
+	} else if pending {
+		err = pvtdataStore.Rollback()
+	}
+	if err != nil {
+		return err
+	}
+	if err := pvtdataStore.Truncate(height); err != nil {
+		return err
+	}
+	// the savepoints of the state DB and history DB are ahead of the block storage now
+	// and hence both the databases are dropped and rebuilt during the next open
+	return idStore.setRebuildDBsFlag(ledgerID)
+}
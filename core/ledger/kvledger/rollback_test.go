@@ -0,0 +1,98 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	ledgerpackage "github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+	"github.com/hyperledger/fabric/protos/common"
+)
+
+func TestRollbackKVLedger(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	provider, _ := NewProvider()
+	ledger, _ := provider.Create("testLedger")
+	bg := testutil.NewBlockGenerator(t)
+	var blocks []*common.Block
+	for i := 0; i < 10; i++ {
+		simulator, _ := ledger.NewTxSimulator()
+		simulator.SetState("ns1", "key1", []byte(fmt.Sprintf("value1.%d", i)))
+		simulator.SetState("ns1", fmt.Sprintf("key-%d", i), []byte("value"))
+		simulator.Done()
+		simRes, _ := simulator.GetTxSimulationResults()
+		block := bg.NextBlock([][]byte{simRes}, false)
+		testutil.AssertNoError(t, ledger.Commit(block), "")
+		blocks = append(blocks, block)
+	}
+	ledger.Close()
+	provider.Close()
+
+	testutil.AssertEquals(t, RollbackKVLedger("non-existing-ledger", 5), ErrNonExistingLedgerID)
+	testutil.AssertError(t, RollbackKVLedger("testLedger", 11), "Expected an error for rollback beyond the current height")
+	testutil.AssertNoError(t, RollbackKVLedger("testLedger", 5), "")
+
+	provider, _ = NewProvider()
+	defer provider.Close()
+	ledger, _ = provider.Open("testLedger")
+	defer ledger.Close()
+	bcInfo, _ := ledger.GetBlockchainInfo()
+	testutil.AssertEquals(t, bcInfo, &common.BlockchainInfo{
+		Height: 5, CurrentBlockHash: blocks[4].Header.Hash(), PreviousBlockHash: blocks[3].Header.Hash()})
+	rebuildDBs, _ := provider.(*Provider).idStore.isRebuildDBsFlagSet("testLedger")
+	testutil.AssertEquals(t, rebuildDBs, false)
+
+	qe, _ := ledger.NewQueryExecutor()
+	value, _ := qe.GetState("ns1", "key1")
+	testutil.AssertEquals(t, value, []byte("value1.4"))
+	value, _ = qe.GetState("ns1", "key-4")
+	testutil.AssertEquals(t, value, []byte("value"))
+	value, _ = qe.GetState("ns1", "key-5")
+	testutil.AssertNil(t, value)
+	qe.Done()
+	stateDBSavepoint, _ := ledger.(*kvLedger).txtmgmt.GetLastSavepoint()
+	testutil.AssertEquals(t, stateDBSavepoint.BlockNum, uint64(4))
+	lastPvtdataBlock, _ := ledger.(*kvLedger).pvtdataStore.LastCommittedBlock()
+	testutil.AssertEquals(t, lastPvtdataBlock, uint64(4))
+
+	if ledgerconfig.IsHistoryDBEnabled() {
+		qhistory, _ := ledger.NewHistoryQueryExecutor()
+		itr, _ := qhistory.GetHistoryForKey("ns1", "key1")
+		count := 0
+		for {
+			kmod, err := itr.Next()
+			testutil.AssertNoError(t, err, "Error upon Next()")
+			if kmod == nil {
+				break
+			}
+			testutil.AssertNotNil(t, kmod.(*ledgerpackage.KeyModification).Value)
+			count++
+		}
+		testutil.AssertEquals(t, count, 5)
+		historyDBSavepoint, _ := ledger.(*kvLedger).historyDB.GetLastSavepoint()
+		testutil.AssertEquals(t, historyDBSavepoint.BlockNum, uint64(4))
+	}
+
+	// the ledger continues from the rolled back height
+	testutil.AssertNoError(t, ledger.Commit(blocks[5]), "")
+	bcInfo, _ = ledger.GetBlockchainInfo()
+	testutil.AssertEquals(t, bcInfo.Height, uint64(6))
+}
@@ -0,0 +1,579 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/bccsp"
+	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/hyperledger/fabric/common/ledger/blkstorage"
+	"github.com/hyperledger/fabric/common/ledger/util"
+	"github.com/hyperledger/fabric/core/common/privdata"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/confighistory"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
+	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+	lutils "github.com/hyperledger/fabric/core/ledger/util"
+	"github.com/hyperledger/fabric/protos/common"
+	putils "github.com/hyperledger/fabric/protos/utils"
+)
+
+// SnapshotMetadataFileName is the name of the metadata file of a snapshot. This file is written
+// last and hence its presence in a snapshot directory marks a complete snapshot
+const SnapshotMetadataFileName = "snapshot_metadata.json"
+
+const (
+	snapshotPubStateFileName       = "public_state.data"
+	snapshotPvtStateHashesFileName = "private_state_hashes.data"
+	snapshotConfigBlockFileName    = "last_config_block.data"
+	// maxSnapshotImportBatchSize is the number of key-values that are loaded in a single
+	// batch in the state database while creating a ledger from a snapshot
+	maxSnapshotImportBatchSize = 1000
+)
+
+// SnapshotMetadata captures the details of a ledger snapshot. This is persisted as a JSON file
+// alongside the data files of the snapshot
+type SnapshotMetadata struct {
+	ChannelName             string
+	LastBlockNumber         uint64
+	LastBlockHash           []byte
+	PreviousBlockHash       []byte
+	StateDBSavepointTxNum   uint64
+	PubStateDataHash        []byte
+	LastConfigBlockNumber   uint64
+	LastConfigBlockDataHash []byte
+	// HashAlgorithm is the hash function used for all the hashes in the snapshot.
+	// This is empty for the snapshots generated before the hash function became configurable, which used SHA256
+	HashAlgorithm string `json:",omitempty"`
+	// CommitHash is the commit hash of the last block. This lets the peers verify a snapshot
+	// against the commit hashes of the other peers and continue the chain of commit hashes
+	CommitHash []byte `json:",omitempty"`
+	// PvtStateHashesDataHash is the hash of the file that holds the hashes of the private data.
+	// This is empty for the snapshots generated before the hashes were exported separately from the public state
+	PvtStateHashesDataHash []byte `json:",omitempty"`
+}
+
+// GenerateSnapshot generates a snapshot of the ledger in the given directory. The snapshot corresponds to
+// the last block committed to the state database and includes the export of the public state, the export
+// of the hashes of the private data, the hash information of the last block, and the last config block of the channel
+func (l *kvLedger) GenerateSnapshot(snapshotDir string) error {
+	empty, err := util.CreateDirIfMissing(snapshotDir)
+	if err != nil {
+		return err
+	}
+	if !empty {
+		return &ledger.ConflictError{Msg: fmt.Sprintf("Snapshot directory [%s] is not empty", snapshotDir)}
+	}
+	// hold the state database steady while exporting so that the export corresponds to a single block
+	itr, savepoint, err := l.txtmgmt.NewStateSnapshotIterator()
+	if err != nil {
+		return err
+	}
+	defer itr.Close()
+	if savepoint == nil {
+		return fmt.Errorf("Cannot generate a snapshot of the ledger [%s] as no block is committed yet", l.ledgerID)
+	}
+	logger.With(flogging.Fields{"channel": l.ledgerID, "block": savepoint.BlockNum}).Infof("Generating snapshot in directory [%s]", snapshotDir)
+
+	hashAlgorithm := ledgerconfig.GetHashAlgorithm()
+	pubStateDataHash, pvtStateHashesDataHash, err := exportState(itr, filepath.Join(snapshotDir, snapshotPubStateFileName),
+		filepath.Join(snapshotDir, snapshotPvtStateHashesFileName), hashAlgorithm)
+	if err != nil {
+		return err
+	}
+	lastBlock, err := l.blockStore.RetrieveBlockByNumber(savepoint.BlockNum)
+	if err != nil {
+		return err
+	}
+	lastConfigBlockNum, err := putils.GetLastConfigIndexFromBlock(lastBlock)
+	if err != nil {
+		return err
+	}
+	lastConfigBlock, err := l.blockStore.RetrieveBlockByNumber(lastConfigBlockNum)
+	if err != nil {
+		return err
+	}
+	lastConfigBlockBytes, err := proto.Marshal(lastConfigBlock)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(snapshotDir, snapshotConfigBlockFileName), lastConfigBlockBytes, 0644); err != nil {
+		return err
+	}
+	lastConfigBlockDataHash, err := computeHash(lastConfigBlockBytes)
+	if err != nil {
+		return err
+	}
+	lastBlockHash, err := blkstorage.ComputeBlockHash(lastBlock.Header)
+	if err != nil {
+		return err
+	}
+	commitHash, err := getCommitHash(lastBlock)
+	if err != nil {
+		return err
+	}
+
+	metadata := &SnapshotMetadata{
+		ChannelName:             l.ledgerID,
+		LastBlockNumber:         savepoint.BlockNum,
+		LastBlockHash:           lastBlockHash,
+		PreviousBlockHash:       lastBlock.Header.PreviousHash,
+		StateDBSavepointTxNum:   savepoint.TxNum,
+		PubStateDataHash:        pubStateDataHash,
+		LastConfigBlockNumber:   lastConfigBlockNum,
+		LastConfigBlockDataHash: lastConfigBlockDataHash,
+		HashAlgorithm:           hashAlgorithm,
+		CommitHash:              commitHash,
+		PvtStateHashesDataHash:  pvtStateHashesDataHash,
+	}
+	metadataBytes, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+	// metadata file is written last so that its presence marks a complete snapshot
+	if err := ioutil.WriteFile(filepath.Join(snapshotDir, SnapshotMetadataFileName), metadataBytes, 0644); err != nil {
+		return err
+	}
+	logger.With(flogging.Fields{"channel": l.ledgerID, "block": savepoint.BlockNum}).Info("Generated snapshot")
+	return nil
+}
+
+// GetSnapshotConfigBlock implements the corresponding method from interface ledger.PeerLedger
+func (l *kvLedger) GetSnapshotConfigBlock() (*common.Block, error) {
+	snapshotInfo, err := l.blockStore.GetSnapshotInfo()
+	if err != nil || snapshotInfo == nil || snapshotInfo.LastConfigBlock == nil {
+		return nil, err
+	}
+	block := &common.Block{}
+	if err := proto.Unmarshal(snapshotInfo.LastConfigBlock, block); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+// CreateFromSnapshot implements the corresponding method from interface ledger.PeerLedgerProvider
+func (provider *Provider) CreateFromSnapshot(snapshotDir string) (ledger.PeerLedger, string, error) {
+	if provider.readOnly {
+		return nil, "", ErrLedgerReadOnly
+	}
+	metadata, err := LoadSnapshotMetadata(snapshotDir)
+	if err != nil {
+		return nil, "", err
+	}
+	ledgerID := metadata.ChannelName
+	logger.With(flogging.Fields{"channel": ledgerID, "block": metadata.LastBlockNumber}).Info("Creating ledger from snapshot")
+	exists, err := provider.idStore.ledgerIDExists(ledgerID)
+	if err != nil {
+		return nil, "", err
+	}
+	if exists {
+		return nil, "", ErrLedgerIDExists
+	}
+	// the hashes of the snapshot files are verified, and the commit hashes of the new ledger continued, with
+	// the hash function configured for the ledger and hence, the snapshot must have been generated with it
+	hashAlgorithm := metadata.HashAlgorithm
+	if hashAlgorithm == "" {
+		hashAlgorithm = bccsp.SHA256
+	}
+	if hashAlgorithm != ledgerconfig.GetHashAlgorithm() {
+		return nil, "", &ledger.ConflictError{Msg: fmt.Sprintf("Snapshot is generated with hash function [%s] whereas the ledger is configured with hash function [%s]",
+			hashAlgorithm, ledgerconfig.GetHashAlgorithm())}
+	}
+	if err := verifySnapshotFile(filepath.Join(snapshotDir, snapshotConfigBlockFileName), metadata.LastConfigBlockDataHash, hashAlgorithm); err != nil {
+		return nil, "", err
+	}
+	if err := verifySnapshotFile(filepath.Join(snapshotDir, snapshotPubStateFileName), metadata.PubStateDataHash, hashAlgorithm); err != nil {
+		return nil, "", err
+	}
+	if metadata.PvtStateHashesDataHash != nil {
+		if err := verifySnapshotFile(filepath.Join(snapshotDir, snapshotPvtStateHashesFileName), metadata.PvtStateHashesDataHash, hashAlgorithm); err != nil {
+			return nil, "", err
+		}
+	}
+
+	// the ledger remains under construction till all the data is loaded successfully
+	config := ledgerconfig.GetChannelConfig(ledgerID)
+	if err := provider.idStore.createLedgerID(ledgerID, ledger.LedgerStatusUnderConstruction, config, metadata.LastBlockNumber+1); err != nil {
+		return nil, "", err
+	}
+	l, err := provider.loadFromSnapshot(snapshotDir, metadata, config)
+	if err != nil {
+		provider.removeIncompleteLedger(ledgerID)
+		return nil, "", err
+	}
+	if err := provider.idStore.updateLedgerStatus(ledgerID, ledger.LedgerStatusActive); err != nil {
+		l.Close()
+		provider.removeIncompleteLedger(ledgerID)
+		return nil, "", err
+	}
+	logger.With(flogging.Fields{"channel": ledgerID, "block": metadata.LastBlockNumber}).Info("Created ledger from snapshot")
+	return l, ledgerID, nil
+}
+
+func (provider *Provider) loadFromSnapshot(snapshotDir string, metadata *SnapshotMetadata,
+	config *ledgerconfig.ChannelConfig) (*kvLedger, error) {
+	ledgerID := metadata.ChannelName
+	vdbProvider, err := provider.getVDBProvider(config)
+	if err != nil {
+		return nil, err
+	}
+	lastConfigBlockBytes, err := ioutil.ReadFile(filepath.Join(snapshotDir, snapshotConfigBlockFileName))
+	if err != nil {
+		return nil, err
+	}
+	blockStore, err := provider.blockStoreProvider.CreateBlockStoreFromSnapshot(ledgerID,
+		&blkstorage.SnapshotInfo{
+			LastBlockNum:      metadata.LastBlockNumber,
+			LastBlockHash:     metadata.LastBlockHash,
+			PreviousBlockHash: metadata.PreviousBlockHash,
+			LastConfigBlock:   lastConfigBlockBytes,
+			CommitHash:        metadata.CommitHash,
+		})
+	if err != nil {
+		return nil, err
+	}
+	savepoint := version.NewHeight(metadata.LastBlockNumber, metadata.StateDBSavepointTxNum)
+	vDB, err := vdbProvider.GetDBHandle(ledgerID)
+	if err != nil {
+		blockStore.Shutdown()
+		return nil, err
+	}
+	setQueryLimit(vDB, config)
+	if vDB, err = provider.openSystemState(ledgerID, vDB, config); err != nil {
+		blockStore.Shutdown()
+		return nil, err
+	}
+	if err := importPubState(vDB, filepath.Join(snapshotDir, snapshotPubStateFileName), savepoint); err != nil {
+		blockStore.Shutdown()
+		return nil, err
+	}
+	// the hashes of the private data are needed for validating and endorsing the private data transactions
+	if metadata.PvtStateHashesDataHash != nil {
+		if err := importPubState(vDB, filepath.Join(snapshotDir, snapshotPvtStateHashesFileName), savepoint); err != nil {
+			blockStore.Shutdown()
+			return nil, err
+		}
+	}
+	historyDB, err := provider.historydbProvider.GetDBHandle(ledgerID)
+	if err != nil {
+		blockStore.Shutdown()
+		return nil, err
+	}
+	if err := historyDB.MarkStartingSavepoint(savepoint); err != nil {
+		blockStore.Shutdown()
+		return nil, err
+	}
+	// the collection configs committed before the snapshot are recovered from the imported state
+	configHistoryMgr := provider.configHistoryProvider.GetMgr(ledgerID)
+	collectionConfigs, err := collectCollectionConfigs(vDB)
+	if err != nil {
+		blockStore.Shutdown()
+		return nil, err
+	}
+	if err := configHistoryMgr.ImportCollectionConfigs(collectionConfigs, savepoint); err != nil {
+		blockStore.Shutdown()
+		return nil, err
+	}
+	// the events of the blocks included in the snapshot are not available for indexing
+	ccEventsIndex := provider.ccEventsProvider.GetIndex(ledgerID)
+	if err := ccEventsIndex.MarkStartingSavepoint(savepoint); err != nil {
+		blockStore.Shutdown()
+		return nil, err
+	}
+	// the results of the transactions in the blocks included in the snapshot are not available for archiving
+	txResultsArchive := provider.txResultsProvider.GetArchive(ledgerID)
+	if err := txResultsArchive.MarkStartingSavepoint(savepoint); err != nil {
+		blockStore.Shutdown()
+		return nil, err
+	}
+	// the transactions invalidated in the blocks included in the snapshot are not available for logging
+	invalidTxsLog := provider.invalidTxsProvider.GetLog(ledgerID)
+	if err := invalidTxsLog.MarkStartingSavepoint(savepoint); err != nil {
+		blockStore.Shutdown()
+		return nil, err
+	}
+	pvtdataStore, err := provider.pvtdataStoreProvider.OpenStore(ledgerID)
+	if err != nil {
+		blockStore.Shutdown()
+		return nil, err
+	}
+	// the decorators maintain the data derived from the blocks committed after the snapshot
+	commitDecorators, err := provider.openCommitDecorators(ledgerID)
+	if err != nil {
+		blockStore.Shutdown()
+		return nil, err
+	}
+	for _, d := range commitDecorators {
+		if err := d.markSavepoint(savepoint); err != nil {
+			blockStore.Shutdown()
+			closeCommitDecorators(commitDecorators)
+			return nil, err
+		}
+	}
+	l, err := newKVLedger(ledgerID, blockStore, pvtdataStore, vDB, historyDB, configHistoryMgr, ccEventsIndex, txResultsArchive,
+		invalidTxsLog, provider.stateTrieProvider.GetStore(ledgerID), commitDecorators, config, false)
+	if err != nil {
+		blockStore.Shutdown()
+		closeCommitDecorators(commitDecorators)
+		return nil, err
+	}
+	return l, nil
+}
+
+// collectCollectionConfigs returns the collection config packages present in the state along with the numbers of the blocks that committed them
+func collectCollectionConfigs(vDB statedb.VersionedDB) (map[string]*ledger.CollectionConfigInfo, error) {
+	itr, err := vDB.GetStateRangeScanIterator(confighistory.LifecycleNamespace, "", "")
+	if err != nil {
+		return nil, err
+	}
+	defer itr.Close()
+	configs := make(map[string]*ledger.CollectionConfigInfo)
+	for {
+		result, err := itr.Next()
+		if err != nil {
+			return nil, err
+		}
+		if result == nil {
+			return configs, nil
+		}
+		kv := result.(*statedb.VersionedKV)
+		if !privdata.IsCollectionConfigKey(kv.Key) {
+			continue
+		}
+		configs[privdata.GetCCNameFromCollectionKVSKey(kv.Key)] = &ledger.CollectionConfigInfo{
+			CollectionConfig: kv.Value, CommittingBlockNum: kv.Version.BlockNum}
+	}
+}
+
+// LoadSnapshotMetadata loads the metadata of the snapshot in the given directory
+func LoadSnapshotMetadata(snapshotDir string) (*SnapshotMetadata, error) {
+	metadataBytes, err := ioutil.ReadFile(filepath.Join(snapshotDir, SnapshotMetadataFileName))
+	if err != nil {
+		return nil, err
+	}
+	metadata := &SnapshotMetadata{}
+	if err := json.Unmarshal(metadataBytes, metadata); err != nil {
+		return nil, err
+	}
+	return metadata, nil
+}
+
+func verifySnapshotFile(filePath string, expectedHash []byte, hashAlgorithm string) error {
+	hash, err := newHash(hashAlgorithm)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := io.Copy(hash, f); err != nil {
+		return err
+	}
+	if !bytes.Equal(hash.Sum(nil), expectedHash) {
+		return &ledger.CorruptionError{Msg: fmt.Sprintf("Hash of the snapshot file [%s] does not match the hash recorded in the snapshot metadata", filePath)}
+	}
+	return nil
+}
+
+// exportPubState writes all the key-values from the iterator to the given file and returns the hash of the file contents.
+// Each key-value is written as a length prefixed record
+func exportPubState(itr statedb.ResultsIterator, filePath string, hashAlgorithm string) ([]byte, error) {
+	writer, err := newSnapshotFileWriter(filePath, hashAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+	defer writer.close()
+	for {
+		queryResult, err := itr.Next()
+		if err != nil {
+			return nil, err
+		}
+		if queryResult == nil {
+			break
+		}
+		if err := writer.write(queryResult.(*statedb.VersionedKV)); err != nil {
+			return nil, err
+		}
+	}
+	return writer.done()
+}
+
+// exportState writes the key-values from the iterator to the given files, the hashes of the private data
+// to the file of the private state hashes and the other key-values to the file of the public state,
+// and returns the hashes of the contents of the two files
+func exportState(itr statedb.ResultsIterator, pubStateFilePath string, pvtStateHashesFilePath string, hashAlgorithm string) ([]byte, []byte, error) {
+	pubStateWriter, err := newSnapshotFileWriter(pubStateFilePath, hashAlgorithm)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer pubStateWriter.close()
+	pvtStateHashesWriter, err := newSnapshotFileWriter(pvtStateHashesFilePath, hashAlgorithm)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer pvtStateHashesWriter.close()
+	for {
+		queryResult, err := itr.Next()
+		if err != nil {
+			return nil, nil, err
+		}
+		if queryResult == nil {
+			break
+		}
+		kv := queryResult.(*statedb.VersionedKV)
+		writer := pubStateWriter
+		if lutils.IsHashedDataNs(kv.Namespace) {
+			writer = pvtStateHashesWriter
+		}
+		if err := writer.write(kv); err != nil {
+			return nil, nil, err
+		}
+	}
+	pubStateDataHash, err := pubStateWriter.done()
+	if err != nil {
+		return nil, nil, err
+	}
+	pvtStateHashesDataHash, err := pvtStateHashesWriter.done()
+	if err != nil {
+		return nil, nil, err
+	}
+	return pubStateDataHash, pvtStateHashesDataHash, nil
+}
+
+// snapshotFileWriter writes the key-values to a data file of a snapshot and computes the hash of the file contents
+type snapshotFileWriter struct {
+	f      *os.File
+	hash   hash.Hash
+	writer *bufio.Writer
+}
+
+func newSnapshotFileWriter(filePath string, hashAlgorithm string) (*snapshotFileWriter, error) {
+	hash, err := newHash(hashAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Create(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return &snapshotFileWriter{f, hash, bufio.NewWriter(io.MultiWriter(f, hash))}, nil
+}
+
+// write writes the key-value as a length prefixed record
+func (w *snapshotFileWriter) write(kv *statedb.VersionedKV) error {
+	buffer := proto.NewBuffer([]byte{})
+	if err := buffer.EncodeStringBytes(kv.Namespace); err != nil {
+		return err
+	}
+	if err := buffer.EncodeStringBytes(kv.Key); err != nil {
+		return err
+	}
+	if err := buffer.EncodeRawBytes(kv.Value); err != nil {
+		return err
+	}
+	if err := buffer.EncodeRawBytes(kv.Version.ToBytes()); err != nil {
+		return err
+	}
+	if _, err := w.writer.Write(proto.EncodeVarint(uint64(len(buffer.Bytes())))); err != nil {
+		return err
+	}
+	_, err := w.writer.Write(buffer.Bytes())
+	return err
+}
+
+// done flushes the file to the disk and returns the hash of the file contents
+func (w *snapshotFileWriter) done() ([]byte, error) {
+	if err := w.writer.Flush(); err != nil {
+		return nil, err
+	}
+	if err := w.f.Sync(); err != nil {
+		return nil, err
+	}
+	return w.hash.Sum(nil), nil
+}
+
+func (w *snapshotFileWriter) close() {
+	w.f.Close()
+}
+
+// importPubState loads the key-values from the given file in the state database in batches.
+// Each batch records the savepoint of the snapshot
+func importPubState(vDB statedb.VersionedDB, filePath string, savepoint *version.Height) error {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	reader := bufio.NewReader(f)
+	batch := statedb.NewUpdateBatch()
+	batchSize := 0
+	for {
+		recordLen, err := binary.ReadUvarint(reader)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		record := make([]byte, recordLen)
+		if _, err := io.ReadFull(reader, record); err != nil {
+			return err
+		}
+		buffer := proto.NewBuffer(record)
+		ns, err := buffer.DecodeStringBytes()
+		if err != nil {
+			return err
+		}
+		key, err := buffer.DecodeStringBytes()
+		if err != nil {
+			return err
+		}
+		value, err := buffer.DecodeRawBytes(true)
+		if err != nil {
+			return err
+		}
+		versionBytes, err := buffer.DecodeRawBytes(false)
+		if err != nil {
+			return err
+		}
+		ver, _ := version.NewHeightFromBytes(versionBytes)
+		batch.Put(ns, key, value, ver)
+		batchSize++
+		if batchSize == maxSnapshotImportBatchSize {
+			if err := vDB.ApplyUpdates(batch, savepoint); err != nil {
+				return err
+			}
+			batch = statedb.NewUpdateBatch()
+			batchSize = 0
+		}
+	}
+	return vDB.ApplyUpdates(batch, savepoint)
+}
@@ -0,0 +1,152 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+	lutils "github.com/hyperledger/fabric/core/ledger/util"
+	"github.com/hyperledger/fabric/protos/common"
+)
+
+func TestGenerateSnapshotAndCreateFromSnapshot(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	snapshotDir, err := ioutil.TempDir("", "kvledger-snapshot-")
+	testutil.AssertNoError(t, err, "")
+	defer os.RemoveAll(snapshotDir)
+	snapshotDir = filepath.Join(snapshotDir, "snapshot")
+
+	provider, _ := NewProvider()
+	ledger, _ := provider.Create("testLedger")
+	bg := testutil.NewBlockGenerator(t)
+	var blocks []*common.Block
+	for i := 0; i < 3; i++ {
+		simulator, _ := ledger.NewTxSimulator()
+		simulator.SetState("ns1", fmt.Sprintf("key%d", i), []byte(fmt.Sprintf("value%d", i)))
+		simulator.SetState("ns2", "key", []byte(fmt.Sprintf("value%d", i)))
+		simulator.SetPrivateData("ns1", "coll1", fmt.Sprintf("pvtkey%d", i), []byte(fmt.Sprintf("pvtvalue%d", i)))
+		simulator.Done()
+		simRes, _ := simulator.GetTxSimulationResults()
+		block := bg.NextBlock([][]byte{simRes}, false)
+		testutil.AssertNoError(t, ledger.Commit(block), "")
+		blocks = append(blocks, block)
+	}
+	testutil.AssertNoError(t, ledger.GenerateSnapshot(snapshotDir), "")
+	testutil.AssertError(t, ledger.GenerateSnapshot(snapshotDir), "Expected an error for a non-empty snapshot directory")
+	commitHash, _ := ledger.GetCommitHash(2)
+	metadata, _ := LoadSnapshotMetadata(snapshotDir)
+	testutil.AssertEquals(t, metadata.CommitHash, commitHash)
+	testutil.AssertNotNil(t, metadata.PvtStateHashesDataHash)
+	pvtStateHashesFileInfo, err := os.Stat(filepath.Join(snapshotDir, snapshotPvtStateHashesFileName))
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, pvtStateHashesFileInfo.Size() > 0, true)
+	configBlock, _ := ledger.GetSnapshotConfigBlock()
+	testutil.AssertNil(t, configBlock)
+	ledger.Close()
+	provider.Close()
+	env.cleanup()
+
+	provider, _ = NewProvider()
+	defer provider.Close()
+	ledger, ledgerID, err := provider.CreateFromSnapshot(snapshotDir)
+	testutil.AssertNoError(t, err, "")
+	defer ledger.Close()
+	testutil.AssertEquals(t, ledgerID, "testLedger")
+	_, _, err = provider.CreateFromSnapshot(snapshotDir)
+	testutil.AssertEquals(t, err, ErrLedgerIDExists)
+
+	bcInfo, _ := ledger.GetBlockchainInfo()
+	testutil.AssertEquals(t, bcInfo, &common.BlockchainInfo{
+		Height: 3, CurrentBlockHash: blocks[2].Header.Hash(), PreviousBlockHash: blocks[1].Header.Hash()})
+	qe, _ := ledger.NewQueryExecutor()
+	for i := 0; i < 3; i++ {
+		value, _ := qe.GetState("ns1", fmt.Sprintf("key%d", i))
+		testutil.AssertEquals(t, value, []byte(fmt.Sprintf("value%d", i)))
+	}
+	value, _ := qe.GetState("ns2", "key")
+	testutil.AssertEquals(t, value, []byte("value2"))
+	// the hashes of the private data are restored so that the private data transactions can be validated and endorsed
+	for i := 0; i < 3; i++ {
+		hash, err := qe.GetPrivateDataHash("ns1", "coll1", fmt.Sprintf("pvtkey%d", i))
+		testutil.AssertNoError(t, err, "")
+		testutil.AssertEquals(t, hash, lutils.ComputePvtDataHash([]byte(fmt.Sprintf("pvtvalue%d", i))))
+	}
+	qe.Done()
+
+	// the commit hash and the last config block of the snapshot are retained in the ledger
+	snapshotCommitHash, _ := ledger.GetCommitHash(2)
+	testutil.AssertEquals(t, snapshotCommitHash, commitHash)
+	testutil.AssertEquals(t, ledger.(*kvLedger).commitHash, commitHash)
+	configBlock, err = ledger.GetSnapshotConfigBlock()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, configBlock.Header.Hash(), blocks[metadata.LastConfigBlockNumber].Header.Hash())
+
+	// the ledger created from the snapshot continues with the next block
+	simulator, _ := ledger.NewTxSimulator()
+	simulator.SetState("ns2", "key", []byte("value3"))
+	simulator.Done()
+	simRes, _ := simulator.GetTxSimulationResults()
+	testutil.AssertNoError(t, ledger.Commit(bg.NextBlock([][]byte{simRes}, false)), "")
+	bcInfo, _ = ledger.GetBlockchainInfo()
+	testutil.AssertEquals(t, bcInfo.Height, uint64(4))
+	if ledgerconfig.IsHistoryDBEnabled() {
+		historyDBSavepoint, _ := ledger.(*kvLedger).historyDB.GetLastSavepoint()
+		testutil.AssertEquals(t, historyDBSavepoint.BlockNum, uint64(3))
+	}
+}
+
+func TestCreateFromTamperedSnapshot(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	snapshotDir, err := ioutil.TempDir("", "kvledger-snapshot-")
+	testutil.AssertNoError(t, err, "")
+	defer os.RemoveAll(snapshotDir)
+	snapshotDir = filepath.Join(snapshotDir, "snapshot")
+
+	provider, _ := NewProvider()
+	ledger, _ := provider.Create("testLedger")
+	simulator, _ := ledger.NewTxSimulator()
+	simulator.SetState("ns1", "key1", []byte("value1"))
+	simulator.Done()
+	simRes, _ := simulator.GetTxSimulationResults()
+	ledger.Commit(testutil.ConstructBlock(t, [][]byte{simRes}, false))
+	testutil.AssertNoError(t, ledger.GenerateSnapshot(snapshotDir), "")
+	ledger.Close()
+	provider.Close()
+	env.cleanup()
+
+	provider, _ = NewProvider()
+	defer provider.Close()
+	for _, fileName := range []string{snapshotPubStateFileName, snapshotPvtStateHashesFileName} {
+		filePath := filepath.Join(snapshotDir, fileName)
+		original, err := ioutil.ReadFile(filePath)
+		testutil.AssertNoError(t, err, "")
+		testutil.AssertNoError(t, ioutil.WriteFile(filePath, append(original, []byte("junk")...), 0644), "")
+		_, _, err = provider.CreateFromSnapshot(snapshotDir)
+		testutil.AssertError(t, err, fmt.Sprintf("Expected an error for a tampered snapshot file [%s]", fileName))
+		exists, _ := provider.Exists("testLedger")
+		testutil.AssertEquals(t, exists, false)
+		testutil.AssertNoError(t, ioutil.WriteFile(filePath, original, 0644), "")
+	}
+}
@@ -0,0 +1,76 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
+	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+)
+
+// GetStateFingerprint implements method in interface `ledger.PeerLedger`.
+// The hash of a namespace is the XOR of the hashes of its key-values (including their versions), which makes
+// the hash independent of the iteration order of the state database
+func (l *kvLedger) GetStateFingerprint() (*ledger.StateFingerprint, error) {
+	itr, savepoint, err := l.txtmgmt.NewStateSnapshotIterator()
+	if err != nil {
+		return nil, err
+	}
+	defer itr.Close()
+	fingerprint := &ledger.StateFingerprint{NamespaceHashes: make(map[string][]byte)}
+	if savepoint == nil {
+		return fingerprint, nil
+	}
+	fingerprint.Height = savepoint.BlockNum + 1
+	hash, err := newHash(ledgerconfig.GetHashAlgorithm())
+	if err != nil {
+		return nil, err
+	}
+	for {
+		queryResult, err := itr.Next()
+		if err != nil {
+			return nil, err
+		}
+		if queryResult == nil {
+			break
+		}
+		kv := queryResult.(*statedb.VersionedKV)
+		buffer := proto.NewBuffer([]byte{})
+		if err := buffer.EncodeStringBytes(kv.Key); err != nil {
+			return nil, err
+		}
+		if err := buffer.EncodeRawBytes(kv.Value); err != nil {
+			return nil, err
+		}
+		if err := buffer.EncodeRawBytes(kv.Version.ToBytes()); err != nil {
+			return nil, err
+		}
+		hash.Reset()
+		hash.Write(buffer.Bytes())
+		kvHash := hash.Sum(nil)
+		nsHash, ok := fingerprint.NamespaceHashes[kv.Namespace]
+		if !ok {
+			nsHash = make([]byte, len(kvHash))
+			fingerprint.NamespaceHashes[kv.Namespace] = nsHash
+		}
+		for i := range kvHash {
+			nsHash[i] ^= kvHash[i]
+		}
+	}
+	return fingerprint, nil
+}
@@ -0,0 +1,73 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger"
+)
+
+func TestGetStateFingerprint(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	provider, _ := NewProvider()
+	defer provider.Close()
+	ledger1, _ := provider.Create("testLedger1")
+	defer ledger1.Close()
+	ledger2, _ := provider.Create("testLedger2")
+	defer ledger2.Close()
+
+	fingerprint, err := ledger1.GetStateFingerprint()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, fingerprint.Height, uint64(0))
+	testutil.AssertEquals(t, len(fingerprint.NamespaceHashes), 0)
+
+	simulateAndCommit := func(l ledger.PeerLedger, bg *testutil.BlockGenerator, kvs map[string]string) {
+		s, _ := l.NewTxSimulator()
+		for key, value := range kvs {
+			ns := key[:3]
+			s.SetState(ns, key, []byte(value))
+		}
+		s.Done()
+		res, _ := s.GetTxSimulationResults()
+		testutil.AssertNoError(t, l.Commit(bg.NextBlock([][]byte{res}, false)), "")
+	}
+	bg1 := testutil.NewBlockGenerator(t)
+	bg2 := testutil.NewBlockGenerator(t)
+	simulateAndCommit(ledger1, bg1, map[string]string{"ns1key1": "value1", "ns1key2": "value2", "ns2key1": "value3"})
+	simulateAndCommit(ledger2, bg2, map[string]string{"ns1key1": "value1", "ns1key2": "value2", "ns2key1": "value3"})
+
+	fingerprint1, err := ledger1.GetStateFingerprint()
+	testutil.AssertNoError(t, err, "")
+	fingerprint2, err := ledger2.GetStateFingerprint()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, fingerprint1.Height, uint64(1))
+	testutil.AssertEquals(t, len(fingerprint1.NamespaceHashes), 2)
+	testutil.AssertEquals(t, fingerprint1, fingerprint2)
+
+	// a diverging value changes the hash of its namespace only
+	simulateAndCommit(ledger1, bg1, map[string]string{"ns1key2": "value4"})
+	simulateAndCommit(ledger2, bg2, map[string]string{"ns1key2": "value5"})
+	fingerprint1, _ = ledger1.GetStateFingerprint()
+	fingerprint2, _ = ledger2.GetStateFingerprint()
+	testutil.AssertEquals(t, fingerprint1.Height, uint64(2))
+	testutil.AssertEquals(t, bytes.Equal(fingerprint1.NamespaceHashes["ns1"], fingerprint2.NamespaceHashes["ns1"]), false)
+	testutil.AssertEquals(t, fingerprint1.NamespaceHashes["ns2"], fingerprint2.NamespaceHashes["ns2"])
+}
@@ -0,0 +1,287 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
+	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+	"github.com/hyperledger/fabric/core/ledger/util/couchdb"
+)
+
+// the types of the state database
+const (
+	goLevelDBStateDatabase = "goleveldb"
+	couchDBStateDatabase   = "CouchDB"
+)
+
+// maxStateMigrationBatchSize is the number of key-values written to the target state database in a single batch
+const maxStateMigrationBatchSize = 1000
+
+// StateMigrationSummary captures the height of the migrated state database and the number of key-values migrated
+type StateMigrationSummary struct {
+	Height    uint64
+	KeyValues uint64
+}
+
+// nsDigest captures the number of the key-values of a namespace and the XOR of their hashes
+type nsDigest struct {
+	count uint64
+	hash  []byte
+}
+
+// MigrateStateDB copies the state database of the given ledger to a state database of the given type, "goleveldb" or
+// "CouchDB", verifies the copy against the source by the number and the hashes of the key-values of each namespace,
+// and switches the ledger to the target type. The source database is dropped if dropSource is true and is otherwise left
+// intact, so that the ledger can be switched back by migrating it again. This is an offline operation and must not be
+// invoked while the peer is running
+func MigrateStateDB(ledgerID string, targetStateDatabase string, dropSource bool) (*StateMigrationSummary, error) {
+	if targetStateDatabase != goLevelDBStateDatabase && targetStateDatabase != couchDBStateDatabase {
+		return nil, fmt.Errorf("Invalid state database [%s], expected [%s] or [%s]", targetStateDatabase, goLevelDBStateDatabase, couchDBStateDatabase)
+	}
+	p, err := NewProvider()
+	if err != nil {
+		return nil, err
+	}
+	provider := p.(*Provider)
+	defer provider.Close()
+	if err := checkLedgerActive(provider.idStore, ledgerID); err != nil {
+		return nil, err
+	}
+	config, err := provider.getLedgerConfig(ledgerID)
+	if err != nil {
+		return nil, err
+	}
+	if config.StateDatabase == targetStateDatabase {
+		return nil, &ledger.ConflictError{Msg: fmt.Sprintf("The state database of ledger [%s] is already [%s]", ledgerID, targetStateDatabase)}
+	}
+	targetConfig := *config
+	targetConfig.StateDatabase = targetStateDatabase
+
+	sourceProvider, err := provider.getVDBProvider(config)
+	if err != nil {
+		return nil, err
+	}
+	sourceDB, err := sourceProvider.GetDBHandle(ledgerID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDataFormat(stateDBName, sourceDB, false); err != nil {
+		return nil, err
+	}
+	targetProvider, err := provider.getVDBProvider(&targetConfig)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDataFormat(stateDBName, targetProvider, false); err != nil {
+		return nil, err
+	}
+	// a target database left behind by an interrupted migration is discarded
+	if err := targetProvider.Drop(ledgerID); err != nil {
+		return nil, err
+	}
+	targetDB, err := targetProvider.GetDBHandle(ledgerID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDataFormat(stateDBName, targetDB, false); err != nil {
+		return nil, err
+	}
+
+	migrationLogger := logger.With(flogging.Fields{"channel": ledgerID})
+	migrationLogger.Infof("Migrating state DB from [%s] to [%s]", config.StateDatabase, targetStateDatabase)
+	summary, err := copyStateDB(sourceDB, targetDB)
+	if err != nil {
+		return nil, err
+	}
+	migrationLogger.Infof("Copied [%d] key-values of state DB at height [%d], verifying", summary.KeyValues, summary.Height)
+	if err := verifyStateDBCopy(sourceDB, targetDB); err != nil {
+		if dropErr := targetProvider.Drop(ledgerID); dropErr != nil {
+			migrationLogger.Errorf("Error while dropping the target state DB: %s", dropErr)
+		}
+		return nil, err
+	}
+
+	if err := provider.idStore.updateLedgerMetadata(ledgerID, func(metadata *ledgerMetadata) {
+		metadata.config = &targetConfig
+	}); err != nil {
+		return nil, err
+	}
+	if dropSource {
+		migrationLogger.Infof("Dropping the source state DB [%s]", config.StateDatabase)
+		if err := sourceProvider.Drop(ledgerID); err != nil {
+			return nil, err
+		}
+	}
+	migrationLogger.Infof("Migrated state DB to [%s]", targetStateDatabase)
+	return summary, nil
+}
+
+// copyStateDB writes the key-values of the source database to the target database in batches.
+// Each batch records the savepoint of the source
+func copyStateDB(sourceDB statedb.VersionedDB, targetDB statedb.VersionedDB) (*StateMigrationSummary, error) {
+	savepoint, err := sourceDB.GetLatestSavePoint()
+	if err != nil {
+		return nil, err
+	}
+	summary := &StateMigrationSummary{}
+	if savepoint == nil {
+		return summary, nil
+	}
+	summary.Height = savepoint.BlockNum + 1
+	itr, err := sourceDB.GetFullScanIterator()
+	if err != nil {
+		return nil, err
+	}
+	defer itr.Close()
+	batch := statedb.NewUpdateBatch()
+	batchSize := 0
+	for {
+		queryResult, err := itr.Next()
+		if err != nil {
+			return nil, err
+		}
+		if queryResult == nil {
+			break
+		}
+		kv := queryResult.(*statedb.VersionedKV)
+		batch.Put(kv.Namespace, kv.Key, kv.Value, kv.Version)
+		batchSize++
+		summary.KeyValues++
+		if batchSize == maxStateMigrationBatchSize {
+			if err := targetDB.ApplyUpdates(batch, savepoint); err != nil {
+				return nil, err
+			}
+			batch = statedb.NewUpdateBatch()
+			batchSize = 0
+		}
+	}
+	if err := targetDB.ApplyUpdates(batch, savepoint); err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// verifyStateDBCopy returns a CorruptionError if the savepoints or the digests of the namespaces of the databases differ
+func verifyStateDBCopy(sourceDB statedb.VersionedDB, targetDB statedb.VersionedDB) error {
+	sourceSavepoint, err := sourceDB.GetLatestSavePoint()
+	if err != nil {
+		return err
+	}
+	targetSavepoint, err := targetDB.GetLatestSavePoint()
+	if err != nil {
+		return err
+	}
+	if sourceSavepoint != nil && (targetSavepoint == nil || sourceSavepoint.Compare(targetSavepoint) != 0) {
+		return &ledger.CorruptionError{Msg: fmt.Sprintf("Savepoint of the target state DB [%v] does not match the source [%v]", targetSavepoint, sourceSavepoint)}
+	}
+	sourceDigests, err := computeStateDigests(sourceDB)
+	if err != nil {
+		return err
+	}
+	targetDigests, err := computeStateDigests(targetDB)
+	if err != nil {
+		return err
+	}
+	if len(sourceDigests) != len(targetDigests) {
+		return &ledger.CorruptionError{Msg: fmt.Sprintf("Target state DB has [%d] namespaces, the source has [%d]", len(targetDigests), len(sourceDigests))}
+	}
+	for namespace, sourceDigest := range sourceDigests {
+		targetDigest, ok := targetDigests[namespace]
+		if !ok {
+			return &ledger.CorruptionError{Msg: fmt.Sprintf("Namespace [%s] is missing from the target state DB", namespace)}
+		}
+		if targetDigest.count != sourceDigest.count {
+			return &ledger.CorruptionError{Msg: fmt.Sprintf("Namespace [%s] has [%d] key-values in the target state DB, the source has [%d]",
+				namespace, targetDigest.count, sourceDigest.count)}
+		}
+		if !bytes.Equal(targetDigest.hash, sourceDigest.hash) {
+			return &ledger.CorruptionError{Msg: fmt.Sprintf("Hash of namespace [%s] in the target state DB does not match the source", namespace)}
+		}
+	}
+	return nil
+}
+
+// computeStateDigests scans the database and computes the digest of each namespace. The JSON values are hashed in
+// a canonical form as CouchDB does not preserve the formatting and the order of the fields of the JSON values
+func computeStateDigests(db statedb.VersionedDB) (map[string]*nsDigest, error) {
+	hash, err := newHash(ledgerconfig.GetHashAlgorithm())
+	if err != nil {
+		return nil, err
+	}
+	itr, err := db.GetFullScanIterator()
+	if err != nil {
+		return nil, err
+	}
+	defer itr.Close()
+	digests := make(map[string]*nsDigest)
+	for {
+		queryResult, err := itr.Next()
+		if err != nil {
+			return nil, err
+		}
+		if queryResult == nil {
+			return digests, nil
+		}
+		kv := queryResult.(*statedb.VersionedKV)
+		buffer := proto.NewBuffer([]byte{})
+		if err := buffer.EncodeStringBytes(kv.Key); err != nil {
+			return nil, err
+		}
+		if err := buffer.EncodeRawBytes(canonicalValue(kv.Value)); err != nil {
+			return nil, err
+		}
+		if err := buffer.EncodeRawBytes(kv.Version.ToBytes()); err != nil {
+			return nil, err
+		}
+		hash.Reset()
+		hash.Write(buffer.Bytes())
+		kvHash := hash.Sum(nil)
+		digest, ok := digests[kv.Namespace]
+		if !ok {
+			digest = &nsDigest{hash: make([]byte, len(kvHash))}
+			digests[kv.Namespace] = digest
+		}
+		digest.count++
+		for i := range kvHash {
+			digest.hash[i] ^= kvHash[i]
+		}
+	}
+}
+
+// canonicalValue returns the JSON object values, which CouchDB stores as documents, re-encoded with the fields sorted
+// and without whitespace. The other values are returned unchanged
+func canonicalValue(value []byte) []byte {
+	if !couchdb.IsJSON(string(value)) {
+		return value
+	}
+	var doc interface{}
+	if err := json.Unmarshal(value, &doc); err != nil {
+		return value
+	}
+	canonical, err := json.Marshal(doc)
+	if err != nil {
+		return value
+	}
+	return canonical
+}
@@ -0,0 +1,92 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb/stateleveldb"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
+)
+
+func TestMigrateStateDBInvalidTarget(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	provider, _ := NewProvider()
+	_, err := provider.Create("testLedger")
+	testutil.AssertNoError(t, err, "")
+	provider.Close()
+
+	_, err = MigrateStateDB("testLedger", "mysql", false)
+	testutil.AssertError(t, err, "Expected an error for an unknown state database")
+	_, err = MigrateStateDB("testLedger", "goleveldb", false)
+	_, ok := err.(*ledger.ConflictError)
+	testutil.AssertEquals(t, ok, true)
+	_, err = MigrateStateDB("nonExistingLedger", "CouchDB", false)
+	testutil.AssertEquals(t, err, ErrNonExistingLedgerID)
+}
+
+func TestCopyAndVerifyStateDB(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	vdbProvider := stateleveldb.NewVersionedDBProvider()
+	defer vdbProvider.Close()
+	sourceDB, _ := vdbProvider.GetDBHandle("source")
+	targetDB, _ := vdbProvider.GetDBHandle("target")
+
+	batch := statedb.NewUpdateBatch()
+	for i := 0; i < maxStateMigrationBatchSize+10; i++ {
+		batch.Put("ns1", fmt.Sprintf("key%04d", i), []byte("value"), version.NewHeight(1, uint64(i)))
+	}
+	batch.Put("ns2", "key1", []byte(`{"b": 1, "a": "x"}`), version.NewHeight(2, 1))
+	testutil.AssertNoError(t, sourceDB.ApplyUpdates(batch, version.NewHeight(2, 1)), "")
+
+	summary, err := copyStateDB(sourceDB, targetDB)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, summary, &StateMigrationSummary{Height: 3, KeyValues: uint64(maxStateMigrationBatchSize + 11)})
+	testutil.AssertNoError(t, verifyStateDBCopy(sourceDB, targetDB), "")
+	savepoint, _ := targetDB.GetLatestSavePoint()
+	testutil.AssertEquals(t, savepoint, version.NewHeight(2, 1))
+
+	// a JSON value that differs only in the formatting matches
+	batch = statedb.NewUpdateBatch()
+	batch.Put("ns2", "key1", []byte(`{"a":"x","b":1}`), version.NewHeight(2, 1))
+	testutil.AssertNoError(t, targetDB.ApplyUpdates(batch, version.NewHeight(2, 1)), "")
+	testutil.AssertNoError(t, verifyStateDBCopy(sourceDB, targetDB), "")
+
+	// a missing key-value or a different version is detected
+	batch = statedb.NewUpdateBatch()
+	batch.Delete("ns2", "key1", version.NewHeight(2, 1))
+	testutil.AssertNoError(t, targetDB.ApplyUpdates(batch, version.NewHeight(2, 1)), "")
+	_, ok := verifyStateDBCopy(sourceDB, targetDB).(*ledger.CorruptionError)
+	testutil.AssertEquals(t, ok, true)
+	batch = statedb.NewUpdateBatch()
+	batch.Put("ns2", "key1", []byte(`{"a":"x","b":1}`), version.NewHeight(2, 2))
+	testutil.AssertNoError(t, targetDB.ApplyUpdates(batch, version.NewHeight(2, 1)), "")
+	_, ok = verifyStateDBCopy(sourceDB, targetDB).(*ledger.CorruptionError)
+	testutil.AssertEquals(t, ok, true)
+}
+
+func TestCanonicalValue(t *testing.T) {
+	testutil.AssertEquals(t, canonicalValue([]byte(`{ "b": [1, 2], "a": {"d": null, "c": "x"} }`)), []byte(`{"a":{"c":"x","d":null},"b":[1,2]}`))
+	testutil.AssertEquals(t, canonicalValue([]byte("not json")), []byte("not json"))
+	testutil.AssertEquals(t, canonicalValue([]byte("[1, 2]")), []byte("[1, 2]"))
+}
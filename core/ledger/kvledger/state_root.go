@@ -0,0 +1,142 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/statetrie"
+	"github.com/hyperledger/fabric/protos/common"
+)
+
+// decodeStateUpdates decodes the updates serialized by the transaction manager for the commit hash
+func decodeStateUpdates(updateBytes []byte) ([]*statetrie.Update, error) {
+	var updates []*statetrie.Update
+	buffer := proto.NewBuffer(updateBytes)
+	for {
+		update := &statetrie.Update{}
+		var err error
+		update.Namespace, err = buffer.DecodeStringBytes()
+		if err == io.ErrUnexpectedEOF {
+			return updates, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if update.Key, err = buffer.DecodeStringBytes(); err != nil {
+			return nil, err
+		}
+		deleteMarker, err := buffer.DecodeVarint()
+		if err != nil {
+			return nil, err
+		}
+		update.IsDelete = deleteMarker == 1
+		if update.Value, err = buffer.DecodeRawBytes(true); err != nil {
+			return nil, err
+		}
+		updates = append(updates, update)
+	}
+}
+
+// setStateRoot records the root of the state trie in the block metadata
+func setStateRoot(block *common.Block, stateRoot []byte) error {
+	metadataBytes, err := proto.Marshal(&common.Metadata{Value: stateRoot})
+	if err != nil {
+		return err
+	}
+	for len(block.Metadata.Metadata) <= int(common.BlockMetadataIndex_STATE_ROOT) {
+		block.Metadata.Metadata = append(block.Metadata.Metadata, []byte{})
+	}
+	block.Metadata.Metadata[common.BlockMetadataIndex_STATE_ROOT] = metadataBytes
+	return nil
+}
+
+// syncStateTrie brings the state trie in line with the block storage. The roots of the blocks past the block
+// storage, i.e., of a block whose commit was interrupted or of the blocks removed by a rollback, are removed.
+// The trie is rebuilt from the state database if it does not have the root of the last block, as for a ledger
+// that did not maintain the trie or that is created from a snapshot
+func (l *kvLedger) syncStateTrie() error {
+	info, err := l.blockStore.GetBlockchainInfo()
+	if err != nil {
+		return err
+	}
+	lastBlockNum, ok, err := l.stateTrie.GetLastBlock()
+	if err != nil {
+		return err
+	}
+	if ok && lastBlockNum >= info.Height {
+		if err := l.stateTrie.Truncate(info.Height); err != nil {
+			return err
+		}
+		lastBlockNum, ok = info.Height-1, info.Height > 0
+	}
+	if info.Height == 0 || (ok && lastBlockNum == info.Height-1) {
+		return nil
+	}
+	itr, savepoint, err := l.txtmgmt.NewStateSnapshotIterator()
+	if err != nil {
+		return err
+	}
+	defer itr.Close()
+	if savepoint == nil || savepoint.BlockNum != info.Height-1 {
+		return &ledger.CorruptionError{Msg: fmt.Sprintf("State database is not in sync with height [%d] of the block storage", info.Height)}
+	}
+	stateRoot, err := l.stateTrie.Rebuild(savepoint.BlockNum, itr)
+	if err != nil {
+		return err
+	}
+	logger.With(flogging.Fields{"channel": l.ledgerID, "block": savepoint.BlockNum}).Infof("Rebuilt the state trie with root [%x]", stateRoot)
+	return nil
+}
+
+// GetStateRoot returns the root of the state trie after the block with the given number.
+// blockNumber of math.MaxUint64 returns the root after the last block
+func (l *kvLedger) GetStateRoot(blockNumber uint64) ([]byte, error) {
+	if l.stateTrie == nil {
+		return nil, &ledger.NotEnabledError{Msg: "State trie not enabled - ledger.state.stateTrie.enabled is false"}
+	}
+	if blockNumber == math.MaxUint64 {
+		info, err := l.blockStore.GetBlockchainInfo()
+		if err != nil {
+			return nil, err
+		}
+		blockNumber = info.Height - 1
+	}
+	return l.stateTrie.GetStateRoot(blockNumber)
+}
+
+// GetStateProof returns a proof of the value of the given key, or of its absence, as of the block with the
+// given number, which can be verified via stateproof.Verify. blockNumber of math.MaxUint64 proves the
+// value as of the last block
+func (l *kvLedger) GetStateProof(namespace string, key string, blockNumber uint64) (*common.StateProof, error) {
+	if l.stateTrie == nil {
+		return nil, &ledger.NotEnabledError{Msg: "State trie not enabled - ledger.state.stateTrie.enabled is false"}
+	}
+	if blockNumber == math.MaxUint64 {
+		info, err := l.blockStore.GetBlockchainInfo()
+		if err != nil {
+			return nil, err
+		}
+		blockNumber = info.Height - 1
+	}
+	return l.stateTrie.GetProof(namespace, key, blockNumber)
+}
@@ -0,0 +1,118 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/ledger/stateproof"
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	ledgerpackage "github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/statetrie"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/spf13/viper"
+)
+
+func TestStateRoot(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	defer viper.Set("ledger.state.stateTrie.enabled", false)
+
+	// the first ledger maintains the trie from the start and the second one from its third block
+	viper.Set("ledger.state.stateTrie.enabled", false)
+	provider, _ := NewProvider()
+	ledger2, _ := provider.Create("testLedger2")
+	bg := testutil.NewBlockGenerator(t)
+	var blocks []*common.Block
+	for i := 0; i < 2; i++ {
+		block := bg.NextBlock([][]byte{constructSimResForCommitHash(t, ledger2, fmt.Sprintf("value%d", i))}, false)
+		testutil.AssertNoError(t, ledger2.Commit(block), "")
+		blocks = append(blocks, block)
+	}
+	_, err := ledger2.GetStateProof("ns1", "key1", math.MaxUint64)
+	_, ok := err.(*ledgerpackage.NotEnabledError)
+	testutil.AssertEquals(t, ok, true)
+	ledger2.Close()
+	provider.Close()
+
+	viper.Set("ledger.state.stateTrie.enabled", true)
+	provider, _ = NewProvider()
+	defer provider.Close()
+	ledger1, _ := provider.Create("testLedger1")
+	defer ledger1.Close()
+	for _, block := range blocks {
+		testutil.AssertNoError(t, ledger1.Commit(proto.Clone(block).(*common.Block)), "")
+	}
+	ledger2, _ = provider.Open("testLedger2")
+	defer ledger2.Close()
+	// the trie of the second ledger is rebuilt from its state
+	stateRoot2, err := ledger2.GetStateRoot(1)
+	testutil.AssertNoError(t, err, "")
+	stateRoot1, _ := ledger1.GetStateRoot(1)
+	testutil.AssertEquals(t, stateRoot2, stateRoot1)
+	_, err = ledger2.GetStateRoot(0)
+	testutil.AssertError(t, err, "the root of block 0 is not recorded")
+
+	block := bg.NextBlock([][]byte{constructSimResForCommitHash(t, ledger1, "value2")}, false)
+	testutil.AssertNoError(t, ledger1.Commit(proto.Clone(block).(*common.Block)), "")
+	testutil.AssertNoError(t, ledger2.Commit(block), "")
+	stateRoot1, _ = ledger1.GetStateRoot(math.MaxUint64)
+	stateRoot2, _ = ledger2.GetStateRoot(2)
+	testutil.AssertNotNil(t, stateRoot1)
+	testutil.AssertEquals(t, stateRoot2, stateRoot1)
+
+	// the root is recorded in the block
+	committedBlock, _ := ledger2.GetBlockByNumber(2)
+	metadata := &common.Metadata{}
+	testutil.AssertNoError(t, proto.Unmarshal(committedBlock.Metadata.Metadata[common.BlockMetadataIndex_STATE_ROOT], metadata), "")
+	testutil.AssertEquals(t, metadata.Value, stateRoot2)
+
+	proof, err := ledger2.GetStateProof("ns1", "key1", math.MaxUint64)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, proof.StateRoot, stateRoot2)
+	testutil.AssertNoError(t, stateproof.Verify(proof, []byte("value2")), "")
+	proof, err = ledger1.GetStateProof("ns1", "key1", 0)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertNoError(t, stateproof.Verify(proof, []byte("value0")), "")
+	proof, err = ledger1.GetStateProof("ns1", "key2", 2)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertNoError(t, stateproof.Verify(proof, nil), "")
+}
+
+func TestDecodeStateUpdates(t *testing.T) {
+	buffer := proto.NewBuffer(nil)
+	buffer.EncodeStringBytes("ns1")
+	buffer.EncodeStringBytes("key1")
+	buffer.EncodeVarint(0)
+	buffer.EncodeRawBytes([]byte("value1"))
+	buffer.EncodeStringBytes("ns1")
+	buffer.EncodeStringBytes("key2")
+	buffer.EncodeVarint(1)
+	buffer.EncodeRawBytes(nil)
+	updates, err := decodeStateUpdates(buffer.Bytes())
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, updates, []*statetrie.Update{
+		{Namespace: "ns1", Key: "key1", Value: []byte("value1")},
+		{Namespace: "ns1", Key: "key2", Value: []byte{}, IsDelete: true},
+	})
+	updates, err = decodeStateUpdates(nil)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertNil(t, updates)
+}
@@ -0,0 +1,83 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/hyperledger/fabric/core/ledger"
+)
+
+// GetStateUpdatesBetween implements method in interface `ledger.PeerLedger`.
+// The writes are replayed from the block store, a later write to a key replacing an earlier one
+func (l *kvLedger) GetStateUpdatesBetween(namespace string, fromHeight, toHeight uint64) ([]*ledger.KV, error) {
+	if fromHeight > toHeight {
+		return nil, fmt.Errorf("From height [%d] is above the to height [%d]", fromHeight, toHeight)
+	}
+	info, err := l.blockStore.GetBlockchainInfo()
+	if err != nil {
+		return nil, err
+	}
+	if toHeight > info.Height {
+		return nil, &ledger.NotFoundError{Msg: fmt.Sprintf("Block height [%d] is above the ledger height [%d]", toHeight, info.Height)}
+	}
+	snapshotInfo, err := l.blockStore.GetSnapshotInfo()
+	if err != nil {
+		return nil, err
+	}
+	if snapshotInfo != nil && fromHeight <= snapshotInfo.LastBlockNum {
+		return nil, &ledger.NotFoundError{Msg: fmt.Sprintf("The blocks below height [%d] are not available as the ledger is created from a snapshot",
+			snapshotInfo.LastBlockNum+1)}
+	}
+
+	updates := make(map[string][]byte)
+	for blockNum := fromHeight; blockNum < toHeight; blockNum++ {
+		block, err := l.blockStore.RetrieveBlockByNumber(blockNum)
+		if err != nil {
+			return nil, err
+		}
+		event, err := newCommitEvent(l.ledgerID, block)
+		if err != nil {
+			return nil, err
+		}
+		for _, txWriteSet := range event.TxWriteSets {
+			for _, nsWrites := range txWriteSet.NsWrites {
+				if nsWrites.Namespace != namespace {
+					continue
+				}
+				for _, kv := range nsWrites.Writes {
+					updates[kv.Key] = kv.Value
+				}
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(updates))
+	for key := range updates {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	kvs := make([]*ledger.KV, len(keys))
+	for i, key := range keys {
+		kvs[i] = &ledger.KV{Key: key, Value: updates[key]}
+	}
+	logger.With(flogging.Fields{"channel": l.ledgerID, "namespace": namespace}).Debugf("Found [%d] updated keys between heights [%d] and [%d]",
+		len(kvs), fromHeight, toHeight)
+	return kvs, nil
+}
@@ -0,0 +1,78 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger"
+)
+
+func TestGetStateUpdatesBetween(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	provider, _ := NewProvider()
+	defer provider.Close()
+	l, _ := provider.Create("testLedger")
+	defer l.Close()
+
+	bg := testutil.NewBlockGenerator(t)
+	commit := func(simulate func(s ledger.TxSimulator)) {
+		s, _ := l.NewTxSimulator()
+		simulate(s)
+		s.Done()
+		res, _ := s.GetTxSimulationResults()
+		testutil.AssertNoError(t, l.Commit(bg.NextBlock([][]byte{res}, false)), "")
+	}
+	commit(func(s ledger.TxSimulator) {
+		s.SetState("ns", "key1", []byte("value1"))
+		s.SetState("ns", "key2", []byte("value2"))
+		s.SetState("otherns", "key1", []byte("value1"))
+	})
+	commit(func(s ledger.TxSimulator) {
+		s.SetState("ns", "key1", []byte("value1_1"))
+		s.SetState("ns", "key3", []byte("value3"))
+	})
+	commit(func(s ledger.TxSimulator) {
+		s.DeleteState("ns", "key2")
+		s.SetState("ns", "key3", []byte("value3_1"))
+	})
+
+	updates, err := l.GetStateUpdatesBetween("ns", 0, 3)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, updates, []*ledger.KV{
+		{Key: "key1", Value: []byte("value1_1")},
+		{Key: "key2", Value: nil},
+		{Key: "key3", Value: []byte("value3_1")},
+	})
+	updates, err = l.GetStateUpdatesBetween("ns", 1, 2)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, updates, []*ledger.KV{
+		{Key: "key1", Value: []byte("value1_1")},
+		{Key: "key3", Value: []byte("value3")},
+	})
+	updates, err = l.GetStateUpdatesBetween("ns", 3, 3)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, len(updates), 0)
+
+	_, err = l.GetStateUpdatesBetween("ns", 2, 1)
+	testutil.AssertError(t, err, "Expected an error for a from height above the to height")
+	_, err = l.GetStateUpdatesBetween("ns", 0, 4)
+	_, ok := err.(*ledger.NotFoundError)
+	testutil.AssertEquals(t, ok, true)
+}
@@ -0,0 +1,222 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"container/list"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+	lutils "github.com/hyperledger/fabric/core/ledger/util"
+	"github.com/hyperledger/fabric/protos/common"
+)
+
+// warmUpBatchSize is the number of keys read from the state database in a single call during a warm-up
+const warmUpBatchSize = 100
+
+// recentKeys tracks the keys most recently written to the state database, up to the given number of keys per namespace.
+// The keys are persisted to the given file when the ledger is closed and are loaded when the ledger is opened, so that
+// the state database can be warmed up with the keys after a restart
+type recentKeys struct {
+	path             string
+	keysPerNamespace int
+	lock             sync.Mutex
+	namespaces       map[string]*recentNsKeys
+}
+
+// recentNsKeys holds the recent keys of a namespace, the most recent at the front
+type recentNsKeys struct {
+	order    *list.List
+	elements map[string]*list.Element
+}
+
+// newRecentKeys constructs a tracker of the recent keys and loads the keys persisted to the given file, if any.
+// A file that cannot be read is ignored as the keys only serve to warm up the state database
+func newRecentKeys(ledgerID string, path string, keysPerNamespace int) *recentKeys {
+	r := &recentKeys{path: path, keysPerNamespace: keysPerNamespace, namespaces: make(map[string]*recentNsKeys)}
+	fileBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.With(flogging.Fields{"channel": ledgerID}).Warningf("Ignoring the recent keys of the state database: %s", err)
+		}
+		return r
+	}
+	persisted := make(map[string][]string)
+	if err := json.Unmarshal(fileBytes, &persisted); err != nil {
+		logger.With(flogging.Fields{"channel": ledgerID}).Warningf("Ignoring the recent keys of the state database: %s", err)
+		return r
+	}
+	for ns, keys := range persisted {
+		// the keys are persisted the most recent first
+		for i := len(keys) - 1; i >= 0; i-- {
+			r.add(ns, keys[i])
+		}
+	}
+	return r
+}
+
+// addBlock tracks the keys written by the valid transactions of the block. The keys deleted by the block are no longer tracked
+func (r *recentKeys) addBlock(block *common.Block) error {
+	event, err := newCommitEvent("", block)
+	if err != nil {
+		return err
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for _, txWriteSet := range event.TxWriteSets {
+		for _, nsWrites := range txWriteSet.NsWrites {
+			if lutils.IsDerivedNs(nsWrites.Namespace) {
+				continue
+			}
+			for _, kv := range nsWrites.Writes {
+				if kv.Value == nil {
+					r.remove(nsWrites.Namespace, kv.Key)
+				} else {
+					r.add(nsWrites.Namespace, kv.Key)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (r *recentKeys) add(ns string, key string) {
+	nsKeys, ok := r.namespaces[ns]
+	if !ok {
+		nsKeys = &recentNsKeys{order: list.New(), elements: make(map[string]*list.Element)}
+		r.namespaces[ns] = nsKeys
+	}
+	if element, ok := nsKeys.elements[key]; ok {
+		nsKeys.order.MoveToFront(element)
+		return
+	}
+	nsKeys.elements[key] = nsKeys.order.PushFront(key)
+	if nsKeys.order.Len() > r.keysPerNamespace {
+		oldest := nsKeys.order.Back()
+		nsKeys.order.Remove(oldest)
+		delete(nsKeys.elements, oldest.Value.(string))
+	}
+}
+
+func (r *recentKeys) remove(ns string, key string) {
+	nsKeys, ok := r.namespaces[ns]
+	if !ok {
+		return
+	}
+	if element, ok := nsKeys.elements[key]; ok {
+		nsKeys.order.Remove(element)
+		delete(nsKeys.elements, key)
+	}
+	if nsKeys.order.Len() == 0 {
+		delete(r.namespaces, ns)
+	}
+}
+
+// getKeys returns up to the given number of the recent keys of each namespace, the most recent first.
+// All the tracked keys are returned if the number is zero
+func (r *recentKeys) getKeys(keysPerNamespace int) map[string][]string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	keys := make(map[string][]string, len(r.namespaces))
+	for ns, nsKeys := range r.namespaces {
+		for element := nsKeys.order.Front(); element != nil; element = element.Next() {
+			if keysPerNamespace > 0 && len(keys[ns]) == keysPerNamespace {
+				break
+			}
+			keys[ns] = append(keys[ns], element.Value.(string))
+		}
+	}
+	return keys
+}
+
+// persist writes the recent keys to the file. The file is replaced atomically so that a crash
+// during the write does not leave a partial file
+func (r *recentKeys) persist() error {
+	fileBytes, err := json.Marshal(r.getKeys(0))
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return err
+	}
+	tempPath := r.path + ".tmp"
+	if err := ioutil.WriteFile(tempPath, fileBytes, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tempPath, r.path)
+}
+
+// WarmUpState implements method in interface `ledger.PeerLedger`.
+// The recent keys are read through a query executor, which fills the caches of the state database
+// with the keys, without being recorded anywhere
+func (l *kvLedger) WarmUpState(keysPerNamespace int) (uint64, error) {
+	if l.recentKeys == nil {
+		return 0, &ledger.NotEnabledError{Msg: "Tracking of the recent keys not enabled - ledger.state.warmUp.keysPerNamespace is not set"}
+	}
+	keys := l.recentKeys.getKeys(keysPerNamespace)
+	namespaces := make([]string, 0, len(keys))
+	for ns := range keys {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+	qe, err := l.txtmgmt.NewQueryExecutor()
+	if err != nil {
+		return 0, err
+	}
+	defer qe.Done()
+	var keysLoaded uint64
+	for _, ns := range namespaces {
+		nsKeys := keys[ns]
+		for start := 0; start < len(nsKeys); start += warmUpBatchSize {
+			end := start + warmUpBatchSize
+			if end > len(nsKeys) {
+				end = len(nsKeys)
+			}
+			values, err := qe.GetStateMultipleKeys(ns, nsKeys[start:end])
+			if err != nil {
+				return keysLoaded, err
+			}
+			for _, value := range values {
+				if value != nil {
+					keysLoaded++
+				}
+			}
+		}
+	}
+	logger.With(flogging.Fields{"channel": l.ledgerID}).Infof("Warmed up the state database with [%d] keys of [%d] namespaces", keysLoaded, len(namespaces))
+	return keysLoaded, nil
+}
+
+// recentKeysPath returns the path of the file to which the recent keys of the given ledger are persisted
+func recentKeysPath(ledgerID string) string {
+	return filepath.Join(ledgerconfig.GetStateWarmUpPath(), ledgerID)
+}
+
+// dropRecentKeys removes the file of the recent keys of the given ledger
+func dropRecentKeys(ledgerID string) error {
+	if err := os.Remove(recentKeysPath(ledgerID)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
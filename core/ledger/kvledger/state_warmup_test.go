@@ -0,0 +1,85 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/spf13/viper"
+)
+
+func TestWarmUpState(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	viper.Set("ledger.state.warmUp.keysPerNamespace", 2)
+	defer viper.Set("ledger.state.warmUp.keysPerNamespace", 0)
+	provider, _ := NewProvider()
+	l, _ := provider.Create("testLedger")
+
+	bg := testutil.NewBlockGenerator(t)
+	simulateAndCommit := func(update func(s ledger.TxSimulator)) {
+		s, _ := l.NewTxSimulator()
+		update(s)
+		s.Done()
+		res, _ := s.GetTxSimulationResults()
+		testutil.AssertNoError(t, l.Commit(bg.NextBlock([][]byte{res}, false)), "")
+	}
+	simulateAndCommit(func(s ledger.TxSimulator) {
+		s.SetState("ns1", "key1", []byte("value1"))
+		s.SetState("ns1", "key2", []byte("value2"))
+		s.SetState("ns1", "key3", []byte("value3"))
+		s.SetState("ns2", "key1", []byte("value4"))
+	})
+	simulateAndCommit(func(s ledger.TxSimulator) {
+		s.SetState("ns1", "key1", []byte("value5"))
+	})
+	simulateAndCommit(func(s ledger.TxSimulator) {
+		s.DeleteState("ns2", "key1")
+	})
+
+	// the oldest key of a namespace is evicted beyond the capacity and a deleted key is no longer tracked
+	expectedKeys := map[string][]string{"ns1": {"key1", "key3"}}
+	testutil.AssertEquals(t, l.(*kvLedger).recentKeys.getKeys(0), expectedKeys)
+	keysLoaded, err := l.WarmUpState(0)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, keysLoaded, uint64(2))
+	keysLoaded, _ = l.WarmUpState(1)
+	testutil.AssertEquals(t, keysLoaded, uint64(1))
+
+	// the recent keys are persisted when the ledger is closed
+	l.Close()
+	provider.Close()
+	provider, _ = NewProvider()
+	l, _ = provider.Open("testLedger")
+	testutil.AssertEquals(t, l.(*kvLedger).recentKeys.getKeys(0), expectedKeys)
+	keysLoaded, _ = l.WarmUpState(0)
+	testutil.AssertEquals(t, keysLoaded, uint64(2))
+	l.Close()
+	provider.Close()
+
+	// the warm-up is not available if the keys are not tracked
+	viper.Set("ledger.state.warmUp.keysPerNamespace", 0)
+	provider, _ = NewProvider()
+	defer provider.Close()
+	l, _ = provider.Open("testLedger")
+	defer l.Close()
+	_, err = l.WarmUpState(0)
+	_, ok := err.(*ledger.NotEnabledError)
+	testutil.AssertEquals(t, ok, true)
+}
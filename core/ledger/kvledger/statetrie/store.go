@@ -0,0 +1,277 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statetrie
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/hyperledger/fabric/bccsp"
+	"github.com/hyperledger/fabric/bccsp/factory"
+	"github.com/hyperledger/fabric/common/ledger/stateproof"
+	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
+	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+	"github.com/hyperledger/fabric/protos/common"
+	logging "github.com/op/go-logging"
+)
+
+var logger = logging.MustGetLogger("statetrie")
+
+var savePointKey = []byte{0x00}
+var rootKeyPrefix = []byte{0x01}
+var nodeKeyPrefix = []byte{0x02}
+
+// rebuildFlushInterval is the number of the keys inserted by a rebuild after which the nodes are written out
+const rebuildFlushInterval = 10000
+
+// Update is a write of a key to the state, or its delete
+type Update struct {
+	Namespace string
+	Key       string
+	Value     []byte
+	IsDelete  bool
+}
+
+// Provider provides handles to the state tries of the ledgers
+type Provider struct {
+	dbProvider    *leveldbhelper.Provider
+	hashAlgorithm string
+	hashOpts      bccsp.HashOpts
+}
+
+// NewProvider instantiates Provider
+func NewProvider() (*Provider, error) {
+	dbPath := ledgerconfig.GetStateTriePath()
+	logger.Debugf("constructing state trie Provider dbPath=%s", dbPath)
+	return newProvider(&leveldbhelper.Conf{DBPath: dbPath})
+}
+
+// NewReadOnlyProvider instantiates Provider that opens the existing databases in read-only mode
+func NewReadOnlyProvider() (*Provider, error) {
+	dbPath := ledgerconfig.GetStateTriePath()
+	logger.Debugf("constructing read-only state trie Provider dbPath=%s", dbPath)
+	return newProvider(&leveldbhelper.Conf{DBPath: dbPath, ReadOnly: true})
+}
+
+func newProvider(conf *leveldbhelper.Conf) (*Provider, error) {
+	hashAlgorithm := ledgerconfig.GetHashAlgorithm()
+	hashOpts, err := bccsp.GetHashOpt(hashAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+	return &Provider{leveldbhelper.NewProvider(conf), hashAlgorithm, hashOpts}, nil
+}
+
+// GetStore returns the state trie of the given ledger
+func (p *Provider) GetStore(ledgerID string) *Store {
+	return &Store{db: p.dbProvider.GetDBHandle(ledgerID), ledgerID: ledgerID, hashAlgorithm: p.hashAlgorithm, hashOpts: p.hashOpts}
+}
+
+// Drop removes the state trie of the given ledger
+func (p *Provider) Drop(ledgerID string) error {
+	return p.dbProvider.GetDBHandle(ledgerID).DeleteAll()
+}
+
+// Close closes the underlying db
+func (p *Provider) Close() {
+	p.dbProvider.Close()
+}
+
+// Store maintains the state trie of a ledger, along with its root after each block, so that the value of a key,
+// or its absence, as of a block can be proven against the root recorded in the block. The nodes replaced by the
+// later blocks are kept, as they make up the tries of the earlier blocks
+type Store struct {
+	db            *leveldbhelper.DBHandle
+	ledgerID      string
+	hashAlgorithm string
+	hashOpts      bccsp.HashOpts
+}
+
+// Commit applies the updates of the given block to the trie of the previous block and returns the new root.
+// The root of an empty state is nil
+func (s *Store) Commit(blockNum uint64, updates []*Update) ([]byte, error) {
+	lastBlockNum, ok, err := s.GetLastBlock()
+	if err != nil {
+		return nil, err
+	}
+	var rootHash []byte
+	if ok {
+		if blockNum != lastBlockNum+1 {
+			return nil, fmt.Errorf("Block [%d] does not follow block [%d] of the state trie of ledger [%s]", blockNum, lastBlockNum, s.ledgerID)
+		}
+		if rootHash, err = s.db.Get(constructRootKey(lastBlockNum)); err != nil {
+			return nil, err
+		}
+	}
+	t := newTrie(s.hashOpts, s.loadNode, rootHash)
+	for _, update := range updates {
+		if err := s.apply(t, update.Namespace, update.Key, update.Value, update.IsDelete); err != nil {
+			return nil, err
+		}
+	}
+	return s.writeRoot(t, blockNum, leveldbhelper.NewUpdateBatch())
+}
+
+// Rebuild builds the trie of the given block from all the keys of the state, for a ledger that did not maintain the
+// trie up to the block. The roots of the earlier blocks, if any, are kept
+func (s *Store) Rebuild(blockNum uint64, itr statedb.ResultsIterator) ([]byte, error) {
+	logger.Infof("Channel [%s]: Rebuilding the state trie of block [%d] from the state", s.ledgerID, blockNum)
+	t := newTrie(s.hashOpts, s.loadNode, nil)
+	batch := leveldbhelper.NewUpdateBatch()
+	inserted := 0
+	for {
+		queryResult, err := itr.Next()
+		if err != nil {
+			return nil, err
+		}
+		if queryResult == nil {
+			break
+		}
+		kv := queryResult.(*statedb.VersionedKV)
+		if err := s.apply(t, kv.Namespace, kv.Key, kv.Value, false); err != nil {
+			return nil, err
+		}
+		if inserted++; inserted%rebuildFlushInterval == 0 {
+			// the nodes are written out and released so that the trie of a large state is not held in memory
+			rootHash, err := t.commit(func(hash []byte, nodeBytes []byte) { batch.Put(constructNodeKey(hash), nodeBytes) })
+			if err != nil {
+				return nil, err
+			}
+			if err := s.db.WriteBatch(batch, false); err != nil {
+				return nil, err
+			}
+			batch = leveldbhelper.NewUpdateBatch()
+			t = newTrie(s.hashOpts, s.loadNode, rootHash)
+		}
+	}
+	return s.writeRoot(t, blockNum, batch)
+}
+
+func (s *Store) apply(t *trie, namespace string, key string, value []byte, isDelete bool) error {
+	path, err := stateproof.KeyPath(s.hashOpts, namespace, key)
+	if err != nil {
+		return err
+	}
+	if isDelete {
+		return t.delete(path)
+	}
+	valueHash, err := factory.GetDefault().Hash(value, s.hashOpts)
+	if err != nil {
+		return err
+	}
+	return t.put(path, valueHash)
+}
+
+func (s *Store) writeRoot(t *trie, blockNum uint64, batch *leveldbhelper.UpdateBatch) ([]byte, error) {
+	rootHash, err := t.commit(func(hash []byte, nodeBytes []byte) { batch.Put(constructNodeKey(hash), nodeBytes) })
+	if err != nil {
+		return nil, err
+	}
+	// the root of an empty state is recorded as an empty value
+	batch.Put(constructRootKey(blockNum), append([]byte{}, rootHash...))
+	batch.Put(savePointKey, version.NewHeight(blockNum, 0).ToBytes())
+	if err := s.db.WriteBatch(batch, true); err != nil {
+		return nil, err
+	}
+	return rootHash, nil
+}
+
+// GetLastBlock returns the number of the last block whose root is recorded, false if none is recorded
+func (s *Store) GetLastBlock() (uint64, bool, error) {
+	savepointBytes, err := s.db.Get(savePointKey)
+	if err != nil || savepointBytes == nil {
+		return 0, false, err
+	}
+	savepoint, _ := version.NewHeightFromBytes(savepointBytes)
+	return savepoint.BlockNum, true, nil
+}
+
+// Truncate removes the roots of the blocks from the given height onwards, after a block that did not make it to
+// the block storage or a rollback of the ledger. The nodes of the removed tries are left in place
+func (s *Store) Truncate(height uint64) error {
+	lastBlockNum, ok, err := s.GetLastBlock()
+	if err != nil || !ok || lastBlockNum < height {
+		return err
+	}
+	logger.Infof("Channel [%s]: Removing the state roots of blocks [%d] to [%d]", s.ledgerID, height, lastBlockNum)
+	batch := leveldbhelper.NewUpdateBatch()
+	itr := s.db.GetIterator(constructRootKey(height), nodeKeyPrefix)
+	for itr.Next() {
+		batch.Delete(append([]byte{}, itr.Key()...))
+	}
+	itr.Release()
+	if err := itr.Error(); err != nil {
+		return err
+	}
+	if height == 0 {
+		batch.Delete(savePointKey)
+	} else {
+		batch.Put(savePointKey, version.NewHeight(height-1, 0).ToBytes())
+	}
+	return s.db.WriteBatch(batch, true)
+}
+
+// GetStateRoot returns the root of the trie after the given block, nil for an empty state. A NotFoundError
+// is returned if the root of the block is not recorded
+func (s *Store) GetStateRoot(blockNum uint64) ([]byte, error) {
+	rootHash, err := s.db.Get(constructRootKey(blockNum))
+	if err != nil {
+		return nil, err
+	}
+	if rootHash == nil {
+		return nil, &ledger.NotFoundError{Msg: fmt.Sprintf("State root of block [%d] is not recorded", blockNum)}
+	}
+	if len(rootHash) == 0 {
+		return nil, nil
+	}
+	return rootHash, nil
+}
+
+// GetProof returns a proof of the value of the given key, or of its absence, as of the given block
+func (s *Store) GetProof(namespace string, key string, blockNum uint64) (*common.StateProof, error) {
+	rootHash, err := s.GetStateRoot(blockNum)
+	if err != nil {
+		return nil, err
+	}
+	path, err := stateproof.KeyPath(s.hashOpts, namespace, key)
+	if err != nil {
+		return nil, err
+	}
+	nodes, valueHash, err := newTrie(s.hashOpts, s.loadNode, rootHash).prove(path)
+	if err != nil {
+		return nil, err
+	}
+	return &common.StateProof{Namespace: namespace, Key: key, BlockNumber: blockNum, StateRoot: rootHash,
+		ValueHash: valueHash, Nodes: nodes, HashAlgorithm: s.hashAlgorithm}, nil
+}
+
+func (s *Store) loadNode(hash []byte) ([]byte, error) {
+	return s.db.Get(constructNodeKey(hash))
+}
+
+func constructRootKey(blockNum uint64) []byte {
+	blockNumBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(blockNumBytes, blockNum)
+	return append(append([]byte{}, rootKeyPrefix...), blockNumBytes...)
+}
+
+func constructNodeKey(hash []byte) []byte {
+	return append(append([]byte{}, nodeKeyPrefix...), hash...)
+}
@@ -0,0 +1,202 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statetrie
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/stateproof"
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
+	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+	"github.com/spf13/viper"
+)
+
+func TestMain(m *testing.M) {
+	viper.Set("peer.fileSystemPath", "/tmp/fabric/ledgertests/statetrie")
+	os.Exit(m.Run())
+}
+
+func newTestStore(t *testing.T) (*Provider, *Store) {
+	os.RemoveAll(ledgerconfig.GetStateTriePath())
+	provider, err := NewProvider()
+	testutil.AssertNoError(t, err, "")
+	return provider, provider.GetStore("testLedger")
+}
+
+func TestStateProofs(t *testing.T) {
+	provider, store := newTestStore(t)
+	defer os.RemoveAll(ledgerconfig.GetStateTriePath())
+	defer provider.Close()
+
+	root0, err := store.Commit(0, nil)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertNil(t, root0)
+
+	var updates []*Update
+	for i := 0; i < 50; i++ {
+		updates = append(updates, &Update{Namespace: "ns1", Key: fmt.Sprintf("key%d", i), Value: []byte(fmt.Sprintf("value%d", i))})
+	}
+	root1, err := store.Commit(1, updates)
+	testutil.AssertNoError(t, err, "")
+	root2, err := store.Commit(2, []*Update{
+		{Namespace: "ns1", Key: "key1", Value: []byte("value1-updated")},
+		{Namespace: "ns1", Key: "key2", IsDelete: true},
+		{Namespace: "ns2", Key: "key1", Value: []byte("value")},
+	})
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertNotEquals(t, root2, root1)
+
+	// the proofs of the earlier blocks remain valid after the later blocks
+	proof, err := store.GetProof("ns1", "key1", 1)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, proof.StateRoot, root1)
+	testutil.AssertNoError(t, stateproof.Verify(proof, []byte("value1")), "")
+	testutil.AssertError(t, stateproof.Verify(proof, []byte("value1-updated")), "the value is not the value as of block 1")
+	proof, err = store.GetProof("ns1", "key1", 2)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertNoError(t, stateproof.Verify(proof, []byte("value1-updated")), "")
+
+	// the absence of a key is proven
+	proof, err = store.GetProof("ns1", "key2", 2)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertNil(t, proof.ValueHash)
+	testutil.AssertNoError(t, stateproof.Verify(proof, nil), "")
+	testutil.AssertError(t, stateproof.Verify(proof, []byte("value2")), "the key is absent as of block 2")
+	proof, err = store.GetProof("ns2", "key1", 1)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertNoError(t, stateproof.Verify(proof, nil), "")
+
+	// a proof that does not hash to the root is rejected
+	proof, err = store.GetProof("ns1", "key3", 2)
+	testutil.AssertNoError(t, err, "")
+	proof.Nodes[len(proof.Nodes)-1] = append([]byte{}, proof.Nodes[len(proof.Nodes)-1]...)
+	proof.Nodes[len(proof.Nodes)-1][len(proof.Nodes[len(proof.Nodes)-1])-1] ^= 0xff
+	testutil.AssertError(t, stateproof.Verify(proof, []byte("value3")), "the last node is tampered with")
+	proof, err = store.GetProof("ns1", "key3", 2)
+	testutil.AssertNoError(t, err, "")
+	proof.Nodes = proof.Nodes[:len(proof.Nodes)-1]
+	testutil.AssertError(t, stateproof.Verify(proof, []byte("value3")), "the proof is truncated")
+
+	_, err = store.Commit(4, nil)
+	testutil.AssertError(t, err, "block 3 is missing")
+	_, err = store.GetProof("ns1", "key1", 3)
+	_, ok := err.(*ledger.NotFoundError)
+	testutil.AssertEquals(t, ok, true)
+}
+
+func TestStateRootIsCanonical(t *testing.T) {
+	provider, store := newTestStore(t)
+	defer os.RemoveAll(ledgerconfig.GetStateTriePath())
+	defer provider.Close()
+
+	// the keys are written and deleted across the blocks so that the branches are split and collapsed
+	state := make(map[string][]byte)
+	blockNum := uint64(0)
+	for round := 0; round < 5; round++ {
+		var updates []*Update
+		for i := round; i < 200; i += 2 {
+			key := fmt.Sprintf("key%d", i)
+			if (i+round)%3 == 0 {
+				updates = append(updates, &Update{Namespace: "ns", Key: key, IsDelete: true})
+				delete(state, key)
+			} else {
+				value := []byte(fmt.Sprintf("value%d-%d", i, round))
+				updates = append(updates, &Update{Namespace: "ns", Key: key, Value: value})
+				state[key] = value
+			}
+		}
+		_, err := store.Commit(blockNum, updates)
+		testutil.AssertNoError(t, err, "")
+		blockNum++
+	}
+	root, err := store.GetStateRoot(blockNum - 1)
+	testutil.AssertNoError(t, err, "")
+
+	// the trie rebuilt from the state has the same root as the trie updated block by block
+	rebuildProvider, rebuilt := provider, provider.GetStore("rebuiltLedger")
+	var kvs []*statedb.VersionedKV
+	for key, value := range state {
+		kvs = append(kvs, &statedb.VersionedKV{CompositeKey: statedb.CompositeKey{Namespace: "ns", Key: key},
+			VersionedValue: statedb.VersionedValue{Value: value}})
+	}
+	rebuiltRoot, err := rebuilt.Rebuild(blockNum-1, &testIterator{kvs: kvs})
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, rebuiltRoot, root)
+	testutil.AssertNoError(t, rebuildProvider.Drop("rebuiltLedger"), "")
+
+	// deleting all the keys brings the trie back to the empty root
+	var updates []*Update
+	for key := range state {
+		updates = append(updates, &Update{Namespace: "ns", Key: key, IsDelete: true})
+	}
+	root, err = store.Commit(blockNum, updates)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertNil(t, root)
+	root, err = store.GetStateRoot(blockNum)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertNil(t, root)
+}
+
+func TestTruncate(t *testing.T) {
+	provider, store := newTestStore(t)
+	defer os.RemoveAll(ledgerconfig.GetStateTriePath())
+	defer provider.Close()
+
+	for blockNum := uint64(0); blockNum < 3; blockNum++ {
+		_, err := store.Commit(blockNum, []*Update{{Namespace: "ns", Key: "key", Value: []byte{byte(blockNum)}}})
+		testutil.AssertNoError(t, err, "")
+	}
+	testutil.AssertNoError(t, store.Truncate(2), "")
+	lastBlockNum, ok, err := store.GetLastBlock()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, ok, true)
+	testutil.AssertEquals(t, lastBlockNum, uint64(1))
+	_, err = store.GetStateRoot(2)
+	testutil.AssertError(t, err, "the root of block 2 is removed")
+
+	// the next block is applied to the trie of the last remaining block
+	_, err = store.Commit(2, []*Update{{Namespace: "ns", Key: "key", Value: []byte("other")}})
+	testutil.AssertNoError(t, err, "")
+	proof, err := store.GetProof("ns", "key", 1)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertNoError(t, stateproof.Verify(proof, []byte{1}), "")
+
+	testutil.AssertNoError(t, store.Truncate(0), "")
+	_, ok, err = store.GetLastBlock()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, ok, false)
+}
+
+type testIterator struct {
+	kvs []*statedb.VersionedKV
+}
+
+func (itr *testIterator) Next() (statedb.QueryResult, error) {
+	if len(itr.kvs) == 0 {
+		return nil, nil
+	}
+	kv := itr.kvs[0]
+	itr.kvs = itr.kvs[1:]
+	return kv, nil
+}
+
+func (itr *testIterator) Close() {
+}
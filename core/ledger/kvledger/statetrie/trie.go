@@ -0,0 +1,349 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statetrie
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/hyperledger/fabric/bccsp"
+	"github.com/hyperledger/fabric/bccsp/factory"
+	"github.com/hyperledger/fabric/common/ledger/stateproof"
+	"github.com/hyperledger/fabric/core/ledger"
+)
+
+// The state trie is a Merkle Patricia trie over the hashes of the composite keys, split into nibbles, so that
+// all the paths have the same length and the values are held only by the leaves. A leaf holds the rest of its
+// path and the hash of the value, an extension holds a shared part of the paths and the hash of a branch, and a
+// branch holds the hashes of its 16 children. Each node is stored under its hash, which its parent holds, and
+// the trie is never updated in place, so that the root of each block remains valid after the next blocks. The
+// shape of the trie is a function of its keys only, as a branch has at least two children and an extension
+// is always followed by a branch, so that every peer arrives at the same root for the same state. The encoding
+// of the nodes and the paths are shared with the verifiers of package stateproof
+const (
+	leafNode      = stateproof.LeafNode
+	extensionNode = stateproof.ExtensionNode
+	branchNode    = stateproof.BranchNode
+)
+
+// node is a decoded node of the trie
+type node struct {
+	kind     byte
+	path     []byte
+	value    []byte
+	child    *ref
+	children [16]*ref
+}
+
+// ref refers to a node either by its hash, in which case the node is loaded on the first use, or
+// directly for a node created by an update, which is hashed and stored when the update is committed
+type ref struct {
+	hash []byte
+	node *node
+}
+
+// nodeLoader loads the encoded node stored under the given hash
+type nodeLoader func(hash []byte) ([]byte, error)
+
+// trie applies the updates to the trie of the given root
+type trie struct {
+	hashOpts bccsp.HashOpts
+	load     nodeLoader
+	root     *ref
+}
+
+func newTrie(hashOpts bccsp.HashOpts, load nodeLoader, rootHash []byte) *trie {
+	t := &trie{hashOpts: hashOpts, load: load}
+	if len(rootHash) != 0 {
+		t.root = &ref{hash: rootHash}
+	}
+	return t
+}
+
+// put sets the hash of the value at the given path
+func (t *trie) put(path []byte, valueHash []byte) error {
+	root, err := t.insert(t.root, path, valueHash)
+	if err != nil {
+		return err
+	}
+	t.root = root
+	return nil
+}
+
+// delete removes the given path, if present
+func (t *trie) delete(path []byte) error {
+	root, err := t.remove(t.root, path)
+	if err != nil {
+		return err
+	}
+	t.root = root
+	return nil
+}
+
+func (t *trie) resolve(r *ref) (*node, error) {
+	if r.node != nil {
+		return r.node, nil
+	}
+	nodeBytes, err := t.load(r.hash)
+	if err != nil {
+		return nil, err
+	}
+	if nodeBytes == nil {
+		return nil, &ledger.CorruptionError{Msg: fmt.Sprintf("Node [%x] of the state trie is missing", r.hash)}
+	}
+	n, err := decodeNode(nodeBytes)
+	if err != nil {
+		return nil, err
+	}
+	r.node = n
+	return n, nil
+}
+
+func newLeaf(path []byte, valueHash []byte) *ref {
+	return &ref{node: &node{kind: leafNode, path: path, value: valueHash}}
+}
+
+func newExtension(path []byte, child *ref) *ref {
+	return &ref{node: &node{kind: extensionNode, path: path, child: child}}
+}
+
+func concat(a []byte, b []byte) []byte {
+	return append(append(make([]byte, 0, len(a)+len(b)), a...), b...)
+}
+
+func commonPrefixLength(a []byte, b []byte) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+// split returns a branch that holds the given node at the given nibble and a new leaf at the nibble of the path,
+// preceded by an extension over the common prefix, if any
+func split(prefix []byte, existingNibble byte, existing *ref, path []byte, valueHash []byte) *ref {
+	branch := &node{kind: branchNode}
+	branch.children[existingNibble] = existing
+	branch.children[path[0]] = newLeaf(path[1:], valueHash)
+	if len(prefix) == 0 {
+		return &ref{node: branch}
+	}
+	return newExtension(prefix, &ref{node: branch})
+}
+
+func (t *trie) insert(r *ref, path []byte, valueHash []byte) (*ref, error) {
+	if r == nil {
+		return newLeaf(path, valueHash), nil
+	}
+	n, err := t.resolve(r)
+	if err != nil {
+		return nil, err
+	}
+	switch n.kind {
+	case leafNode:
+		if bytes.Equal(n.path, path) {
+			if bytes.Equal(n.value, valueHash) {
+				return r, nil
+			}
+			return newLeaf(path, valueHash), nil
+		}
+		c := commonPrefixLength(n.path, path)
+		return split(path[:c], n.path[c], newLeaf(n.path[c+1:], n.value), path[c:], valueHash), nil
+	case extensionNode:
+		c := commonPrefixLength(n.path, path)
+		if c == len(n.path) {
+			child, err := t.insert(n.child, path[c:], valueHash)
+			if err != nil || child == n.child {
+				return r, err
+			}
+			return newExtension(n.path, child), nil
+		}
+		existing := n.child
+		if c+1 < len(n.path) {
+			existing = newExtension(n.path[c+1:], n.child)
+		}
+		return split(path[:c], n.path[c], existing, path[c:], valueHash), nil
+	default:
+		child, err := t.insert(n.children[path[0]], path[1:], valueHash)
+		if err != nil || child == n.children[path[0]] {
+			return r, err
+		}
+		branch := &node{kind: branchNode, children: n.children}
+		branch.children[path[0]] = child
+		return &ref{node: branch}, nil
+	}
+}
+
+func (t *trie) remove(r *ref, path []byte) (*ref, error) {
+	if r == nil {
+		return nil, nil
+	}
+	n, err := t.resolve(r)
+	if err != nil {
+		return nil, err
+	}
+	switch n.kind {
+	case leafNode:
+		if bytes.Equal(n.path, path) {
+			return nil, nil
+		}
+		return r, nil
+	case extensionNode:
+		if !bytes.HasPrefix(path, n.path) {
+			return r, nil
+		}
+		child, err := t.remove(n.child, path[len(n.path):])
+		if err != nil || child == n.child {
+			return r, err
+		}
+		return t.prefixed(n.path, child)
+	default:
+		child, err := t.remove(n.children[path[0]], path[1:])
+		if err != nil || child == n.children[path[0]] {
+			return r, err
+		}
+		branch := &node{kind: branchNode, children: n.children}
+		branch.children[path[0]] = child
+		remaining := -1
+		for nibble, c := range branch.children {
+			if c == nil {
+				continue
+			}
+			if remaining != -1 {
+				return &ref{node: branch}, nil
+			}
+			remaining = nibble
+		}
+		// a branch left with a single child is replaced by the child under a longer path
+		return t.prefixed([]byte{byte(remaining)}, branch.children[remaining])
+	}
+}
+
+// prefixed returns the given node under a path longer by the given prefix, merging the paths of the
+// leaves and the extensions so that the trie keeps its canonical shape
+func (t *trie) prefixed(prefix []byte, r *ref) (*ref, error) {
+	n, err := t.resolve(r)
+	if err != nil {
+		return nil, err
+	}
+	switch n.kind {
+	case leafNode:
+		return newLeaf(concat(prefix, n.path), n.value), nil
+	case extensionNode:
+		return newExtension(concat(prefix, n.path), n.child), nil
+	default:
+		return newExtension(prefix, r), nil
+	}
+}
+
+// commit hashes the nodes created by the updates and passes them to the given function to be stored.
+// The root hash is returned, nil for an empty trie
+func (t *trie) commit(store func(hash []byte, nodeBytes []byte)) ([]byte, error) {
+	if t.root == nil {
+		return nil, nil
+	}
+	return t.commitNode(t.root, store)
+}
+
+func (t *trie) commitNode(r *ref, store func(hash []byte, nodeBytes []byte)) ([]byte, error) {
+	if r.hash != nil {
+		return r.hash, nil
+	}
+	n := r.node
+	switch n.kind {
+	case extensionNode:
+		if _, err := t.commitNode(n.child, store); err != nil {
+			return nil, err
+		}
+	case branchNode:
+		for _, child := range n.children {
+			if child == nil {
+				continue
+			}
+			if _, err := t.commitNode(child, store); err != nil {
+				return nil, err
+			}
+		}
+	}
+	nodeBytes := encodeNode(n)
+	hash, err := factory.GetDefault().Hash(nodeBytes, t.hashOpts)
+	if err != nil {
+		return nil, err
+	}
+	store(hash, nodeBytes)
+	r.hash = hash
+	return hash, nil
+}
+
+// prove returns the encoded nodes on the given path from the root, along with the hash of the value
+// at the path, nil if the path is absent
+func (t *trie) prove(path []byte) ([][]byte, []byte, error) {
+	var nodes [][]byte
+	r := t.root
+	for r != nil {
+		nodeBytes, err := t.load(r.hash)
+		if err != nil {
+			return nil, nil, err
+		}
+		if nodeBytes == nil {
+			return nil, nil, &ledger.CorruptionError{Msg: fmt.Sprintf("Node [%x] of the state trie is missing", r.hash)}
+		}
+		nodes = append(nodes, nodeBytes)
+		n, err := stateproof.DecodeNode(nodeBytes)
+		if err != nil {
+			return nil, nil, &ledger.CorruptionError{Msg: err.Error()}
+		}
+		childHash, rest, valueHash, done := stateproof.Step(n, path)
+		if done {
+			return nodes, valueHash, nil
+		}
+		r = &ref{hash: childHash}
+		path = rest
+	}
+	return nodes, nil, nil
+}
+
+// encodeNode encodes the node, whose children are committed, via the codec of the verifiers
+func encodeNode(n *node) []byte {
+	encoded := &stateproof.Node{Kind: n.kind, Path: n.path, Value: n.value}
+	if n.child != nil {
+		encoded.Child = n.child.hash
+	}
+	for i, child := range n.children {
+		if child != nil {
+			encoded.Children[i] = child.hash
+		}
+	}
+	return stateproof.EncodeNode(encoded)
+}
+
+func decodeNode(nodeBytes []byte) (*node, error) {
+	decoded, err := stateproof.DecodeNode(nodeBytes)
+	if err != nil {
+		return nil, &ledger.CorruptionError{Msg: err.Error()}
+	}
+	n := &node{kind: decoded.Kind, path: decoded.Path, value: decoded.Value}
+	if decoded.Child != nil {
+		n.child = &ref{hash: decoded.Child}
+	}
+	for i, childHash := range decoded.Children {
+		if childHash != nil {
+			n.children[i] = &ref{hash: childHash}
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,146 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/hyperledger/fabric/common/ledger/util"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb/stateleveldb"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb/systemstatedb"
+	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+)
+
+// SystemStateSnapshotMetadataFileName is the name of the metadata file of a snapshot of the system namespaces.
+// This file is written last and hence its presence in a snapshot directory marks a complete snapshot
+const SystemStateSnapshotMetadataFileName = "system_state_metadata.json"
+
+const systemStateSnapshotDataFileName = "system_state.data"
+
+// SystemStateSnapshotMetadata captures the details of a snapshot of the system namespaces of the state.
+// This is persisted as a JSON file alongside the data file of the snapshot
+type SystemStateSnapshotMetadata struct {
+	ChannelName           string
+	LastBlockNumber       uint64
+	StateDBSavepointTxNum uint64
+	Namespaces            []string
+	SystemStateDataHash   []byte
+	HashAlgorithm         string
+}
+
+// getSystemVDBProvider returns the provider of the databases that keep the system namespaces apart from the
+// state databases. The provider is constructed on the first use
+func (provider *Provider) getSystemVDBProvider() statedb.VersionedDBProvider {
+	provider.vdbProviderLock.Lock()
+	defer provider.vdbProviderLock.Unlock()
+	if provider.systemVDBProvider == nil {
+		logger.Debug("Constructing system state VersionedDBProvider")
+		provider.systemVDBProvider = stateleveldb.NewSystemVersionedDBProvider(provider.readOnly)
+	}
+	return provider.systemVDBProvider
+}
+
+// openSystemState returns the state database of the ledger that keeps the system namespaces listed in the
+// configuration in the system state database. The given state database is returned if the configuration lists none
+func (provider *Provider) openSystemState(ledgerID string, vDB statedb.VersionedDB, config *ledgerconfig.ChannelConfig) (statedb.VersionedDB, error) {
+	if len(config.SystemNamespaces) == 0 {
+		return vDB, nil
+	}
+	systemVDBProvider := provider.getSystemVDBProvider()
+	if err := checkDataFormat(systemStateDBName, systemVDBProvider, provider.readOnly); err != nil {
+		return nil, err
+	}
+	systemVDB, err := systemVDBProvider.GetDBHandle(ledgerID)
+	if err != nil {
+		return nil, err
+	}
+	return systemstatedb.NewVersionedDB(vDB, systemVDB, config.SystemNamespaces, ledgerconfig.GetSystemStateSyncPolicy()), nil
+}
+
+// dropSystemState removes the system namespaces of the ledger from the system state database
+func (provider *Provider) dropSystemState(ledgerID string, config *ledgerconfig.ChannelConfig) error {
+	if len(config.SystemNamespaces) == 0 {
+		return nil
+	}
+	return provider.getSystemVDBProvider().Drop(ledgerID)
+}
+
+// GenerateSystemStateSnapshot implements the corresponding method from interface ledger.PeerLedger
+func (l *kvLedger) GenerateSystemStateSnapshot(snapshotDir string) error {
+	if len(l.config.SystemNamespaces) == 0 {
+		return &ledger.NotEnabledError{Msg: fmt.Sprintf("System state database is not enabled for ledger [%s]", l.ledgerID)}
+	}
+	empty, err := util.CreateDirIfMissing(snapshotDir)
+	if err != nil {
+		return err
+	}
+	if !empty {
+		return &ledger.ConflictError{Msg: fmt.Sprintf("Snapshot directory [%s] is not empty", snapshotDir)}
+	}
+	itr, savepoint, err := l.txtmgmt.NewNamespacesSnapshotIterator(l.config.SystemNamespaces)
+	if err != nil {
+		return err
+	}
+	defer itr.Close()
+	if savepoint == nil {
+		return fmt.Errorf("Cannot generate a snapshot of the system state of the ledger [%s] as no block is committed yet", l.ledgerID)
+	}
+	logger.With(flogging.Fields{"channel": l.ledgerID, "block": savepoint.BlockNum}).Infof("Generating system state snapshot in directory [%s]", snapshotDir)
+
+	hashAlgorithm := ledgerconfig.GetHashAlgorithm()
+	dataHash, err := exportPubState(itr, filepath.Join(snapshotDir, systemStateSnapshotDataFileName), hashAlgorithm)
+	if err != nil {
+		return err
+	}
+	metadata := &SystemStateSnapshotMetadata{
+		ChannelName:           l.ledgerID,
+		LastBlockNumber:       savepoint.BlockNum,
+		StateDBSavepointTxNum: savepoint.TxNum,
+		Namespaces:            l.config.SystemNamespaces,
+		SystemStateDataHash:   dataHash,
+		HashAlgorithm:         hashAlgorithm,
+	}
+	metadataBytes, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+	// metadata file is written last so that its presence marks a complete snapshot
+	if err := ioutil.WriteFile(filepath.Join(snapshotDir, SystemStateSnapshotMetadataFileName), metadataBytes, 0644); err != nil {
+		return err
+	}
+	logger.With(flogging.Fields{"channel": l.ledgerID, "block": savepoint.BlockNum}).Info("Generated system state snapshot")
+	return nil
+}
+
+// LoadSystemStateSnapshotMetadata loads the metadata of the snapshot of the system namespaces in the given directory
+func LoadSystemStateSnapshotMetadata(snapshotDir string) (*SystemStateSnapshotMetadata, error) {
+	metadataBytes, err := ioutil.ReadFile(filepath.Join(snapshotDir, SystemStateSnapshotMetadataFileName))
+	if err != nil {
+		return nil, err
+	}
+	metadata := &SystemStateSnapshotMetadata{}
+	if err := json.Unmarshal(metadataBytes, metadata); err != nil {
+		return nil, err
+	}
+	return metadata, nil
+}
@@ -0,0 +1,97 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb/systemstatedb"
+	"github.com/spf13/viper"
+)
+
+func TestSystemStateDatabase(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	viper.Set("ledger.state.systemStateDatabase.enabled", true)
+	defer viper.Set("ledger.state.systemStateDatabase.enabled", false)
+	snapshotsDir, err := ioutil.TempDir("", "kvledger-sysstate-")
+	testutil.AssertNoError(t, err, "")
+	defer os.RemoveAll(snapshotsDir)
+
+	p, _ := NewProvider()
+	provider := p.(*Provider)
+	defer provider.Close()
+	l, err := provider.Create("testLedger")
+	testutil.AssertNoError(t, err, "")
+	bg := testutil.NewBlockGenerator(t)
+	for i := 0; i < 3; i++ {
+		simulator, _ := l.NewTxSimulator()
+		simulator.SetState("lccc", fmt.Sprintf("cc%d", i), []byte(fmt.Sprintf("cc%d-def", i)))
+		simulator.SetState("ns1", "key", []byte(fmt.Sprintf("value%d", i)))
+		simulator.Done()
+		simRes, _ := simulator.GetTxSimulationResults()
+		testutil.AssertNoError(t, l.Commit(bg.NextBlock([][]byte{simRes}, false)), "")
+	}
+	config, _ := provider.getLedgerConfig("testLedger")
+	testutil.AssertEquals(t, config.SystemNamespaces, []string{"lccc"})
+	systemDB := l.(*kvLedger).versionedDB.(*systemstatedb.VersionedDB).SystemDB()
+	vv, _ := systemDB.GetState("lccc", "cc1")
+	testutil.AssertEquals(t, vv.Value, []byte("cc1-def"))
+	vv, _ = systemDB.GetState("ns1", "key")
+	testutil.AssertNil(t, vv)
+
+	snapshotDir := filepath.Join(snapshotsDir, "snapshot")
+	testutil.AssertNoError(t, l.GenerateSystemStateSnapshot(snapshotDir), "")
+	metadata, err := LoadSystemStateSnapshotMetadata(snapshotDir)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, metadata.LastBlockNumber, uint64(2))
+	testutil.AssertEquals(t, metadata.Namespaces, []string{"lccc"})
+	testutil.AssertNoError(t, verifySnapshotFile(filepath.Join(snapshotDir, systemStateSnapshotDataFileName),
+		metadata.SystemStateDataHash, metadata.HashAlgorithm), "")
+	l.Close()
+
+	// a lost system state database is recovered from the block store
+	testutil.AssertNoError(t, provider.getSystemVDBProvider().Drop("testLedger"), "")
+	l, err = provider.Open("testLedger")
+	testutil.AssertNoError(t, err, "")
+	qe, _ := l.NewQueryExecutor()
+	for i := 0; i < 3; i++ {
+		value, _ := qe.GetState("lccc", fmt.Sprintf("cc%d", i))
+		testutil.AssertEquals(t, value, []byte(fmt.Sprintf("cc%d-def", i)))
+	}
+	value, _ := qe.GetState("ns1", "key")
+	testutil.AssertEquals(t, value, []byte("value2"))
+	qe.Done()
+	l.Close()
+
+	// the ledgers created with the system state database disabled keep the system namespaces in the state database
+	viper.Set("ledger.state.systemStateDatabase.enabled", false)
+	l, err = provider.Create("testLedger2")
+	testutil.AssertNoError(t, err, "")
+	defer l.Close()
+	_, ok := l.(*kvLedger).versionedDB.(*systemstatedb.VersionedDB)
+	testutil.AssertEquals(t, ok, false)
+	err = l.GenerateSystemStateSnapshot(filepath.Join(snapshotsDir, "snapshot2"))
+	_, ok = err.(*ledger.NotEnabledError)
+	testutil.AssertEquals(t, ok, true)
+}
@@ -0,0 +1,86 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/common/tracing"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/spf13/viper"
+	"golang.org/x/net/context"
+)
+
+type testSpanReporter struct {
+	lock  sync.Mutex
+	spans map[string]*tracing.Span
+}
+
+func (r *testSpanReporter) Report(span *tracing.Span) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.spans[span.Name] = span
+}
+
+func TestLedgerTracing(t *testing.T) {
+	historyEnabled := viper.GetBool("ledger.state.historyDatabase")
+	viper.Set("ledger.state.historyDatabase", true)
+	defer viper.Set("ledger.state.historyDatabase", historyEnabled)
+	env := newTestEnv(t)
+	defer env.cleanup()
+	provider, _ := NewProvider()
+	defer provider.Close()
+	l, _ := provider.Create("tracingLedger")
+	defer l.Close()
+
+	reporter := &testSpanReporter{spans: make(map[string]*tracing.Span)}
+	tracing.SetReporter(reporter)
+	defer tracing.SetReporter(nil)
+
+	bg := testutil.NewBlockGenerator(t)
+	s, _ := l.NewTxSimulator()
+	s.SetState("ns", "key", []byte("value"))
+	s.Done()
+	res, _ := s.GetTxSimulationResults()
+	testutil.AssertNoError(t, l.Commit(bg.NextBlock([][]byte{res}, false)), "")
+
+	commitSpan := reporter.spans["ledger.Commit"]
+	testutil.AssertNotNil(t, commitSpan)
+	testutil.AssertEquals(t, commitSpan.Tags["channel"], "tracingLedger")
+	testutil.AssertEquals(t, commitSpan.Tags["block"], "0")
+	for _, name := range []string{"ledger.ValidateAndPrepare", "ledger.BlockStore", "ledger.StateDB", "ledger.HistoryDB"} {
+		span := reporter.spans[name]
+		testutil.AssertNotNil(t, span)
+		testutil.AssertEquals(t, span.TraceID, commitSpan.TraceID)
+		testutil.AssertEquals(t, span.ParentID, commitSpan.SpanID)
+	}
+
+	// the queries are traced as the children of the span set on the query executor
+	parentSpan, ctx := tracing.StartSpan(context.Background(), "endorser.ProcessProposal")
+	qe, _ := l.NewQueryExecutor()
+	qe.(ledger.TraceableQueryExecutor).SetTraceContext(ctx)
+	value, err := qe.GetState("ns", "key")
+	qe.Done()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, value, []byte("value"))
+	getStateSpan := reporter.spans["ledger.GetState"]
+	testutil.AssertNotNil(t, getStateSpan)
+	testutil.AssertEquals(t, getStateSpan.ParentID, parentSpan.SpanID)
+	testutil.AssertEquals(t, getStateSpan.Tags["namespace"], "ns")
+}
@@ -0,0 +1,65 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/hyperledger/fabric/protos/common"
+)
+
+// GetTransactionProof returns a proof of the existence of the transaction with the given id that can be verified
+// offline via utils.VerifyTransactionProof. The proof carries the data of the block that contains the transaction
+// and the headers of the blocks from that block up to the anchor block. anchorBlockNum of math.MaxUint64 anchors
+// the proof to the last block
+func (l *kvLedger) GetTransactionProof(txID string, anchorBlockNum uint64) (*common.TransactionProof, error) {
+	blockNum, txNum, err := l.blockStore.RetrieveTxLocByTxID(txID)
+	if err != nil {
+		return nil, toLedgerError(err)
+	}
+	if anchorBlockNum == math.MaxUint64 {
+		info, err := l.blockStore.GetBlockchainInfo()
+		if err != nil {
+			return nil, err
+		}
+		anchorBlockNum = info.Height - 1
+	}
+	if anchorBlockNum < blockNum {
+		return nil, fmt.Errorf("Anchor block [%d] precedes block [%d] that contains transaction [%s]", anchorBlockNum, blockNum, txID)
+	}
+
+	block, err := l.blockStore.RetrieveBlockByNumber(blockNum)
+	if err != nil {
+		return nil, toLedgerError(err)
+	}
+	proof := &common.TransactionProof{
+		TxId:            txID,
+		TxIndex:         uint32(txNum),
+		Headers:         []*common.BlockHeader{block.Header},
+		Data:            block.Data,
+		ValidationFlags: block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER],
+	}
+	for num := blockNum + 1; num <= anchorBlockNum; num++ {
+		if block, err = l.blockStore.RetrieveBlockByNumber(num); err != nil {
+			return nil, toLedgerError(err)
+		}
+		proof.Headers = append(proof.Headers, block.Header)
+	}
+	proof.LastBlockSignatures = block.Metadata.Metadata[common.BlockMetadataIndex_SIGNATURES]
+	return proof, nil
+}
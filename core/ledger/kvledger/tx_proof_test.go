@@ -0,0 +1,121 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"math"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+func TestTransactionProof(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	provider, _ := NewProvider()
+	defer provider.Close()
+	ledger, _ := provider.Create("testLedger")
+	defer ledger.Close()
+
+	bg := testutil.NewBlockGenerator(t)
+	var blocks []*common.Block
+	for i := 0; i < 4; i++ {
+		block := bg.NextBlock([][]byte{
+			constructSimResForCommitHash(t, ledger, "value1"),
+			constructSimResForCommitHash(t, ledger, "value2"),
+		}, false)
+		testutil.AssertNoError(t, ledger.Commit(block), "")
+		blocks = append(blocks, block)
+	}
+	txID := getTxIDFromBlock(t, blocks[1], 1)
+
+	// proof anchored to the last block
+	proof, err := ledger.GetTransactionProof(txID, math.MaxUint64)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, proof.TxIndex, uint32(1))
+	testutil.AssertEquals(t, len(proof.Headers), 3)
+	validationCode, err := utils.VerifyTransactionProof(proof, blocks[3].Header.Hash())
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, validationCode, peer.TxValidationCode_VALID)
+
+	// proof anchored to the block that contains the transaction
+	proof, err = ledger.GetTransactionProof(txID, 1)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, len(proof.Headers), 1)
+	_, err = utils.VerifyTransactionProof(proof, blocks[1].Header.Hash())
+	testutil.AssertNoError(t, err, "")
+	_, err = utils.VerifyTransactionProof(proof, blocks[2].Header.Hash())
+	testutil.AssertError(t, err, "Expected an error for a proof anchored to a different block")
+
+	_, err = ledger.GetTransactionProof(txID, 0)
+	testutil.AssertError(t, err, "Expected an error for an anchor block that precedes the transaction")
+	_, err = ledger.GetTransactionProof("unknownTxID", math.MaxUint64)
+	testutil.AssertError(t, err, "Expected an error for an unknown transaction")
+}
+
+func TestVerifyTamperedTransactionProof(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	provider, _ := NewProvider()
+	defer provider.Close()
+	ledger, _ := provider.Create("testLedger")
+	defer ledger.Close()
+
+	bg := testutil.NewBlockGenerator(t)
+	for i := 0; i < 3; i++ {
+		block := bg.NextBlock([][]byte{
+			constructSimResForCommitHash(t, ledger, "value1"),
+			constructSimResForCommitHash(t, ledger, "value2"),
+		}, false)
+		testutil.AssertNoError(t, ledger.Commit(block), "")
+	}
+	block, _ := ledger.GetBlockByNumber(0)
+	txID := getTxIDFromBlock(t, block, 0)
+	otherTxID := getTxIDFromBlock(t, block, 1)
+
+	// a proof claiming a different transaction at the index
+	proof, _ := ledger.GetTransactionProof(txID, math.MaxUint64)
+	proof.TxId = otherTxID
+	_, err := utils.VerifyTransactionProof(proof, nil)
+	testutil.AssertError(t, err, "Expected an error for a mismatched transaction id")
+
+	// a proof with altered block data
+	proof, _ = ledger.GetTransactionProof(txID, math.MaxUint64)
+	proof.Data.Data[0], proof.Data.Data[1] = proof.Data.Data[1], proof.Data.Data[0]
+	proof.TxIndex = 1
+	_, err = utils.VerifyTransactionProof(proof, nil)
+	testutil.AssertError(t, err, "Expected an error for altered block data")
+
+	// a proof with a broken chain of headers
+	proof, _ = ledger.GetTransactionProof(txID, math.MaxUint64)
+	proof.Headers = append(proof.Headers[:1], proof.Headers[2:]...)
+	_, err = utils.VerifyTransactionProof(proof, nil)
+	testutil.AssertError(t, err, "Expected an error for a broken chain of headers")
+}
+
+func getTxIDFromBlock(t *testing.T, block *common.Block, txIndex int) string {
+	env, err := utils.GetEnvelopeFromBlock(block.Data.Data[txIndex])
+	testutil.AssertNoError(t, err, "")
+	payload, err := utils.GetPayload(env)
+	testutil.AssertNoError(t, err, "")
+	chdr, err := utils.UnmarshalChannelHeader(payload.Header.ChannelHeader)
+	testutil.AssertNoError(t, err, "")
+	return chdr.TxId
+}
@@ -0,0 +1,161 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"errors"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/hyperledger/fabric/common/ledger/blkstorage"
+	"github.com/hyperledger/fabric/protos/common"
+	putils "github.com/hyperledger/fabric/protos/utils"
+)
+
+const (
+	// txIDFilterBitsPerTx and txIDFilterHashes give a false positive rate of about 1% when the
+	// filter holds the expected number of transaction ids
+	txIDFilterBitsPerTx = 10
+	txIDFilterHashes    = 7
+)
+
+var errTxIDFilterClosed = errors.New("transaction id filter is closed")
+
+// txIDFilter is a bloom filter of the ids of the transactions in the block storage. A negative answer means that the id
+// is not in the block storage while a positive answer is to be confirmed with the block index. The filter is loaded from
+// the block index in the background and answers positively until it is loaded
+type txIDFilter struct {
+	ledgerID string
+	lock     sync.RWMutex
+	bits     []uint64
+	numBits  uint64
+	loaded   bool
+	closed   bool
+	done     chan struct{}
+}
+
+// newTxIDFilter constructs an empty filter sized for the given number of transactions
+func newTxIDFilter(ledgerID string, expectedTxs int) *txIDFilter {
+	numWords := (uint64(expectedTxs)*txIDFilterBitsPerTx + 63) / 64
+	return &txIDFilter{ledgerID: ledgerID, bits: make([]uint64, numWords), numBits: numWords * 64, done: make(chan struct{})}
+}
+
+// load adds the ids in the block index to the filter. It is meant to run in its own goroutine after the filter
+// is constructed and before any block is committed, so that the ids of the blocks committed while loading are
+// added by the commits. The filter keeps answering positively if the index cannot be read
+func (f *txIDFilter) load(blockStore blkstorage.BlockStore) {
+	defer close(f.done)
+	start := time.Now()
+	count := 0
+	err := blockStore.ForEachTxID(func(txID string) error {
+		f.lock.RLock()
+		closed := f.closed
+		f.lock.RUnlock()
+		if closed {
+			return errTxIDFilterClosed
+		}
+		f.add(txID)
+		count++
+		return nil
+	})
+	if err == errTxIDFilterClosed {
+		return
+	}
+	if err != nil {
+		logger.With(flogging.Fields{"channel": f.ledgerID}).Warningf("Looking up the transaction ids in the block index only as the filter cannot be loaded: %s", err)
+		return
+	}
+	f.lock.Lock()
+	f.loaded = true
+	f.lock.Unlock()
+	logger.With(flogging.Fields{"channel": f.ledgerID}).Infof("Loaded the filter of the transaction ids with %d id(s) in %s", count, time.Since(start))
+}
+
+// addBlock adds the ids of the transactions of the block to the filter. The invalid transactions are added
+// as well since they are in the block index
+func (f *txIDFilter) addBlock(block *common.Block) {
+	for _, envBytes := range block.Data.Data {
+		txID, err := extractTxIDFromEnvelope(envBytes)
+		if err != nil {
+			// such a transaction is indexed by the block storage without an id
+			continue
+		}
+		f.add(txID)
+	}
+}
+
+func (f *txIDFilter) add(txID string) {
+	h1, h2 := txIDFilterHashValues(txID)
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	for i := uint64(0); i < txIDFilterHashes; i++ {
+		bit := (h1 + i*h2) % f.numBits
+		f.bits[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+// mightContain returns false only if the transaction id is not in the block storage
+func (f *txIDFilter) mightContain(txID string) bool {
+	h1, h2 := txIDFilterHashValues(txID)
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	if !f.loaded {
+		return true
+	}
+	for i := uint64(0); i < txIDFilterHashes; i++ {
+		bit := (h1 + i*h2) % f.numBits
+		if f.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// close stops the loading of the filter, if still in progress, and waits for it to end
+func (f *txIDFilter) close() {
+	f.lock.Lock()
+	f.closed = true
+	f.lock.Unlock()
+	<-f.done
+}
+
+// txIDFilterHashValues derives the positions of the bits of an id from the two halves of its hash. The step is odd
+// so that the positions do not collapse to one
+func txIDFilterHashValues(txID string) (uint64, uint64) {
+	h := fnv.New64a()
+	h.Write([]byte(txID))
+	sum := h.Sum64()
+	return sum & 0xffffffff, (sum >> 32) | 1
+}
+
+func extractTxIDFromEnvelope(envBytes []byte) (string, error) {
+	env, err := putils.GetEnvelopeFromBlock(envBytes)
+	if err != nil {
+		return "", err
+	}
+	payload, err := putils.GetPayload(env)
+	if err != nil {
+		return "", err
+	}
+	chdr, err := putils.UnmarshalChannelHeader(payload.Header.ChannelHeader)
+	if err != nil {
+		return "", err
+	}
+	return chdr.TxId, nil
+}
@@ -0,0 +1,47 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+)
+
+func TestTxIDFilter(t *testing.T) {
+	f := newTxIDFilter("testLedger", 100)
+	f.add("tx0")
+	// the filter does not rule out any id until loaded
+	testutil.AssertEquals(t, f.mightContain("missingTx"), true)
+	f.loaded = true
+	for i := 0; i < 100; i++ {
+		f.add(fmt.Sprintf("tx%d", i))
+	}
+	for i := 0; i < 100; i++ {
+		testutil.AssertEquals(t, f.mightContain(fmt.Sprintf("tx%d", i)), true)
+	}
+	falsePositives := 0
+	for i := 0; i < 1000; i++ {
+		if f.mightContain(fmt.Sprintf("missingTx%d", i)) {
+			falsePositives++
+		}
+	}
+	if falsePositives > 50 {
+		t.Fatalf("Expected a false positive rate of about 1%%, got %d false positives out of 1000", falsePositives)
+	}
+}
@@ -19,6 +19,7 @@ package rwset
 import (
 	"bytes"
 	"fmt"
+	"io"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
@@ -54,6 +55,24 @@ func (w *KVWrite) SetValue(value []byte) {
 	w.IsDelete = value == nil
 }
 
+// KVRangeDelete - a range of keys [StartKey, EndKey) that a transaction wants to delete during simulation.
+// An empty EndKey denotes the end of the namespace. The range is expanded to the keys present at the time of
+// the commit of the transaction
+type KVRangeDelete struct {
+	StartKey string
+	EndKey   string
+}
+
+// NewKVRangeDelete constructs a new `KVRangeDelete`
+func NewKVRangeDelete(startKey string, endKey string) *KVRangeDelete {
+	return &KVRangeDelete{startKey, endKey}
+}
+
+// Contains returns true iff the given key falls in the range
+func (rd *KVRangeDelete) Contains(key string) bool {
+	return key >= rd.StartKey && (rd.EndKey == "" || key < rd.EndKey)
+}
+
 // RangeQueryInfo captures a range query executed by a transaction
 // and the tuples <key,version> that are read by the transaction
 // This it to be used to perform a phantom-read validation during commit
@@ -86,6 +105,7 @@ type NsReadWriteSet struct {
 	Reads            []*KVRead
 	Writes           []*KVWrite
 	RangeQueriesInfo []*RangeQueryInfo
+	RangeDeletes     []*KVRangeDelete
 }
 
 // TxReadWriteSet - a collection of all the reads and writes collected as a result of a transaction simulation
@@ -318,6 +338,29 @@ func (w *KVWrite) Unmarshal(buf *proto.Buffer) error {
 	return nil
 }
 
+// Marshal serializes a `KVRangeDelete`
+func (rd *KVRangeDelete) Marshal(buf *proto.Buffer) error {
+	if err := buf.EncodeStringBytes(rd.StartKey); err != nil {
+		return err
+	}
+	if err := buf.EncodeStringBytes(rd.EndKey); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Unmarshal deserializes a `KVRangeDelete`
+func (rd *KVRangeDelete) Unmarshal(buf *proto.Buffer) error {
+	var err error
+	if rd.StartKey, err = buf.DecodeStringBytes(); err != nil {
+		return err
+	}
+	if rd.EndKey, err = buf.DecodeStringBytes(); err != nil {
+		return err
+	}
+	return nil
+}
+
 // Marshal serializes a `NsReadWriteSet`
 func (nsRW *NsReadWriteSet) Marshal(buf *proto.Buffer) error {
 	var err error
@@ -362,11 +405,9 @@ func (nsRW *NsReadWriteSet) Unmarshal(buf *proto.Buffer) error {
 		return err
 	}
 	for i := 0; i < int(numReads); i++ {
-		r := &KVRead{}
-		if err = r.Unmarshal(buf); err != nil {
+		if err = nsRW.nextRead().Unmarshal(buf); err != nil {
 			return err
 		}
-		nsRW.Reads = append(nsRW.Reads, r)
 	}
 
 	var numWrites uint64
@@ -374,11 +415,9 @@ func (nsRW *NsReadWriteSet) Unmarshal(buf *proto.Buffer) error {
 		return err
 	}
 	for i := 0; i < int(numWrites); i++ {
-		w := &KVWrite{}
-		if err = w.Unmarshal(buf); err != nil {
+		if err = nsRW.nextWrite().Unmarshal(buf); err != nil {
 			return err
 		}
-		nsRW.Writes = append(nsRW.Writes, w)
 	}
 
 	var numRangeQueriesInfo uint64
@@ -407,9 +446,34 @@ func (txRW *TxReadWriteSet) Marshal() ([]byte, error) {
 			return nil, err
 		}
 	}
+	if !txRW.hasRangeDeletes() {
+		return buf.Bytes(), nil
+	}
+	// The range deletes are appended after all the namespaces so that the serialized form
+	// of a read-write set without range deletes remains unchanged
+	for i := 0; i < len(txRW.NsRWs); i++ {
+		rangeDeletes := txRW.NsRWs[i].RangeDeletes
+		if err = buf.EncodeVarint(uint64(len(rangeDeletes))); err != nil {
+			return nil, err
+		}
+		for j := 0; j < len(rangeDeletes); j++ {
+			if err = rangeDeletes[j].Marshal(buf); err != nil {
+				return nil, err
+			}
+		}
+	}
 	return buf.Bytes(), nil
 }
 
+func (txRW *TxReadWriteSet) hasRangeDeletes() bool {
+	for _, nsRW := range txRW.NsRWs {
+		if len(nsRW.RangeDeletes) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
 // Unmarshal deserializes a `TxReadWriteSet`
 func (txRW *TxReadWriteSet) Unmarshal(b []byte) error {
 	buf := proto.NewBuffer(b)
@@ -418,12 +482,28 @@ func (txRW *TxReadWriteSet) Unmarshal(b []byte) error {
 	if numEntries, err = buf.DecodeVarint(); err != nil {
 		return err
 	}
+	first := len(txRW.NsRWs)
 	for i := 0; i < int(numEntries); i++ {
-		nsRW := &NsReadWriteSet{}
-		if err = nsRW.Unmarshal(buf); err != nil {
+		if err = txRW.nextNsRW().Unmarshal(buf); err != nil {
 			return err
 		}
-		txRW.NsRWs = append(txRW.NsRWs, nsRW)
+	}
+	for i := first; i < first+int(numEntries); i++ {
+		var numRangeDeletes uint64
+		if numRangeDeletes, err = buf.DecodeVarint(); err != nil {
+			if i == first && err == io.ErrUnexpectedEOF {
+				// the read-write set does not contain range deletes
+				return nil
+			}
+			return err
+		}
+		for j := 0; j < int(numRangeDeletes); j++ {
+			rd := &KVRangeDelete{}
+			if err = rd.Unmarshal(buf); err != nil {
+				return err
+			}
+			txRW.NsRWs[i].RangeDeletes = append(txRW.NsRWs[i].RangeDeletes, rd)
+		}
 	}
 	return nil
 }
@@ -438,6 +518,11 @@ func (w *KVWrite) String() string {
 	return fmt.Sprintf("%s=[%#v]", w.Key, w.Value)
 }
 
+// String prints a `KVRangeDelete`
+func (rd *KVRangeDelete) String() string {
+	return fmt.Sprintf("[%s, %s)", rd.StartKey, rd.EndKey)
+}
+
 // String prints a range query info
 func (rqi *RangeQueryInfo) String() string {
 	return fmt.Sprintf("StartKey=%s, EndKey=%s, ItrExhausted=%t, Results=%#v, Hash=%#v",
@@ -465,6 +550,14 @@ func (nsRW *NsReadWriteSet) String() string {
 		buffer.WriteString(rqi.String())
 		buffer.WriteString("\n")
 	}
+	if len(nsRW.RangeDeletes) > 0 {
+		buffer.WriteString("RangeDeletes=\n")
+		for _, rd := range nsRW.RangeDeletes {
+			buffer.WriteString("\t")
+			buffer.WriteString(rd.String())
+			buffer.WriteString("\n")
+		}
+	}
 	return buffer.String()
 }
 
@@ -29,10 +29,11 @@ type nsRWs struct {
 	writeMap         map[string]*KVWrite
 	rangeQueriesMap  map[rangeQueryKey]*RangeQueryInfo //for phantom read validation
 	rangeQueriesKeys []rangeQueryKey
+	rangeDeletes     []*KVRangeDelete
 }
 
 func newNsRWs() *nsRWs {
-	return &nsRWs{make(map[string]*KVRead), make(map[string]*KVWrite), make(map[rangeQueryKey]*RangeQueryInfo), nil}
+	return &nsRWs{make(map[string]*KVRead), make(map[string]*KVWrite), make(map[rangeQueryKey]*RangeQueryInfo), nil, nil}
 }
 
 type rangeQueryKey struct {
@@ -74,6 +75,36 @@ func (rws *RWSet) AddToRangeQuerySet(ns string, rqi *RangeQueryInfo) {
 	}
 }
 
+// AddToRangeDeleteSet adds a range of keys to be deleted. The writes of the keys in the range that are
+// already in the write-set are dropped, as the range delete supersedes them, whereas the later writes
+// of the keys in the range are applied after the range delete
+func (rws *RWSet) AddToRangeDeleteSet(ns string, startKey string, endKey string) {
+	nsRWs := rws.getOrCreateNsRW(ns)
+	rws.RemoveRangeFromWriteSet(ns, startKey, endKey)
+	nsRWs.rangeDeletes = append(nsRWs.rangeDeletes, NewKVRangeDelete(startKey, endKey))
+}
+
+// RemoveFromWriteSet removes a key from the write-set
+func (rws *RWSet) RemoveFromWriteSet(ns string, key string) {
+	if nsRWs, ok := rws.rwMap[ns]; ok {
+		delete(nsRWs.writeMap, key)
+	}
+}
+
+// RemoveRangeFromWriteSet removes the keys in the range [startKey, endKey) from the write-set
+func (rws *RWSet) RemoveRangeFromWriteSet(ns string, startKey string, endKey string) {
+	nsRWs, ok := rws.rwMap[ns]
+	if !ok {
+		return
+	}
+	rd := NewKVRangeDelete(startKey, endKey)
+	for key := range nsRWs.writeMap {
+		if rd.Contains(key) {
+			delete(nsRWs.writeMap, key)
+		}
+	}
+}
+
 // GetFromWriteSet return the value of a key from the write-set
 func (rws *RWSet) GetFromWriteSet(ns string, key string) ([]byte, bool) {
 	nsRWs, ok := rws.rwMap[ns]
@@ -116,7 +147,8 @@ func (rws *RWSet) GetTxReadWriteSet() *TxReadWriteSet {
 		for _, key := range nsReadWriteMap.rangeQueriesKeys {
 			rangeQueriesInfo = append(rangeQueriesInfo, rangeQueriesMap[key])
 		}
-		nsRWs := &NsReadWriteSet{NameSpace: ns, Reads: reads, Writes: writes, RangeQueriesInfo: rangeQueriesInfo}
+		nsRWs := &NsReadWriteSet{NameSpace: ns, Reads: reads, Writes: writes,
+			RangeQueriesInfo: rangeQueriesInfo, RangeDeletes: nsReadWriteMap.rangeDeletes}
 		txRWSet.NsRWs = append(txRWSet.NsRWs, nsRWs)
 	}
 	return txRWSet
@@ -55,14 +55,38 @@ func TestRWSetHolder(t *testing.T) {
 	ns1RWSet := &NsReadWriteSet{"ns1",
 		[]*KVRead{&KVRead{"key1", version.NewHeight(1, 1)}, &KVRead{"key2", version.NewHeight(1, 2)}},
 		[]*KVWrite{&KVWrite{"key2", false, []byte("value2")}},
-		[]*RangeQueryInfo{rqi1, rqi3}}
+		[]*RangeQueryInfo{rqi1, rqi3}, nil}
 
 	ns2RWSet := &NsReadWriteSet{"ns2",
 		[]*KVRead{&KVRead{"key2", version.NewHeight(1, 2)}},
 		[]*KVWrite{&KVWrite{"key3", false, []byte("value3")}},
-		[]*RangeQueryInfo{}}
+		[]*RangeQueryInfo{}, nil}
 
 	expectedTxRWSet := &TxReadWriteSet{[]*NsReadWriteSet{ns1RWSet, ns2RWSet}}
 	t.Logf("Actual=%s\n Expected=%s", txRWSet, expectedTxRWSet)
 	testutil.AssertEquals(t, txRWSet, expectedTxRWSet)
 }
+
+func TestRangeDeleteSet(t *testing.T) {
+	rwSet := NewRWSet()
+	rwSet.AddToWriteSet("ns1", "key1", []byte("value1"))
+	rwSet.AddToWriteSet("ns1", "key2", []byte("value2"))
+	rwSet.AddToWriteSet("ns1", "key4", []byte("value4"))
+	rwSet.AddToRangeDeleteSet("ns1", "key2", "key4")
+	rwSet.AddToWriteSet("ns1", "key3", []byte("value3"))
+	rwSet.AddToRangeDeleteSet("ns1", "key5", "")
+
+	// the write of key2 is superseded by the range delete whereas the later write of key3 is kept
+	txRWSet := rwSet.GetTxReadWriteSet()
+	expectedNsRWSet := &NsReadWriteSet{"ns1",
+		[]*KVRead{},
+		[]*KVWrite{&KVWrite{"key1", false, []byte("value1")}, &KVWrite{"key3", false, []byte("value3")},
+			&KVWrite{"key4", false, []byte("value4")}},
+		[]*RangeQueryInfo{},
+		[]*KVRangeDelete{&KVRangeDelete{"key2", "key4"}, &KVRangeDelete{"key5", ""}}}
+	testutil.AssertEquals(t, txRWSet, &TxReadWriteSet{[]*NsReadWriteSet{expectedNsRWSet}})
+
+	testutil.AssertEquals(t, expectedNsRWSet.RangeDeletes[0].Contains("key3"), true)
+	testutil.AssertEquals(t, expectedNsRWSet.RangeDeletes[0].Contains("key4"), false)
+	testutil.AssertEquals(t, expectedNsRWSet.RangeDeletes[1].Contains("key9"), true)
+}
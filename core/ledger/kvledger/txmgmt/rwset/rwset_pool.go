@@ -0,0 +1,98 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rwset
+
+import "sync"
+
+// txRWSetPool holds the read-write sets released by the paths that unmarshal a read-write set per transaction and
+// discard it once the transaction is processed, such as the validation and the history database. A released
+// read-write set keeps its namespaces and their reads and writes, which the next unmarshal into it reuses
+var txRWSetPool = sync.Pool{New: func() interface{} { return &TxReadWriteSet{} }}
+
+// maxPooledNsEntries bounds the reads and the writes of a namespace kept by a released read-write set, so that
+// a single large transaction does not pin its memory in the pool
+const maxPooledNsEntries = 1024
+
+// AcquireTxReadWriteSet returns an empty `TxReadWriteSet` from the pool, to be unmarshalled into and released
+// once processed
+func AcquireTxReadWriteSet() *TxReadWriteSet {
+	return txRWSetPool.Get().(*TxReadWriteSet)
+}
+
+// Release returns the read-write set to the pool. Neither the read-write set nor its namespaces, reads, writes,
+// range query infos or range deletes may be used after the release. The keys and the values taken from them
+// remain valid as they are not reused
+func (txRW *TxReadWriteSet) Release() {
+	for _, nsRW := range txRW.NsRWs {
+		if cap(nsRW.Reads) > maxPooledNsEntries || cap(nsRW.Writes) > maxPooledNsEntries {
+			return
+		}
+	}
+	txRW.NsRWs = txRW.NsRWs[:0]
+	txRWSetPool.Put(txRW)
+}
+
+// nextNsRW appends an empty namespace to the read-write set, reusing a released one if any
+func (txRW *TxReadWriteSet) nextNsRW() *NsReadWriteSet {
+	n := len(txRW.NsRWs)
+	if n < cap(txRW.NsRWs) {
+		txRW.NsRWs = txRW.NsRWs[:n+1]
+		if nsRW := txRW.NsRWs[n]; nsRW != nil {
+			*nsRW = NsReadWriteSet{Reads: nsRW.Reads[:0], Writes: nsRW.Writes[:0]}
+			return nsRW
+		}
+	} else {
+		txRW.NsRWs = append(txRW.NsRWs, nil)
+	}
+	nsRW := &NsReadWriteSet{}
+	txRW.NsRWs[n] = nsRW
+	return nsRW
+}
+
+// nextRead appends an empty read to the namespace, reusing a released one if any
+func (nsRW *NsReadWriteSet) nextRead() *KVRead {
+	n := len(nsRW.Reads)
+	if n < cap(nsRW.Reads) {
+		nsRW.Reads = nsRW.Reads[:n+1]
+		if r := nsRW.Reads[n]; r != nil {
+			*r = KVRead{}
+			return r
+		}
+	} else {
+		nsRW.Reads = append(nsRW.Reads, nil)
+	}
+	r := &KVRead{}
+	nsRW.Reads[n] = r
+	return r
+}
+
+// nextWrite appends an empty write to the namespace, reusing a released one if any
+func (nsRW *NsReadWriteSet) nextWrite() *KVWrite {
+	n := len(nsRW.Writes)
+	if n < cap(nsRW.Writes) {
+		nsRW.Writes = nsRW.Writes[:n+1]
+		if w := nsRW.Writes[n]; w != nil {
+			*w = KVWrite{}
+			return w
+		}
+	} else {
+		nsRW.Writes = append(nsRW.Writes, nil)
+	}
+	w := &KVWrite{}
+	nsRW.Writes[n] = w
+	return w
+}
@@ -0,0 +1,65 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rwset
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
+)
+
+func TestTxRWSetReuse(t *testing.T) {
+	txRW1 := &TxReadWriteSet{NsRWs: []*NsReadWriteSet{
+		{"ns1", []*KVRead{{"key1", version.NewHeight(1, 1)}, {"key2", version.NewHeight(1, 2)}},
+			[]*KVWrite{{"key1", false, []byte("value1")}}, nil, []*KVRangeDelete{{"a", "b"}}},
+		{"ns2", nil, []*KVWrite{{"key3", false, []byte("value3")}, {"key4", false, []byte("value4")}}, nil, nil},
+	}}
+	txRW2 := &TxReadWriteSet{NsRWs: []*NsReadWriteSet{
+		{"ns3", []*KVRead{{"key5", nil}}, []*KVWrite{{"key6", true, nil}}, nil, nil},
+	}}
+	b1, err := txRW1.Marshal()
+	testutil.AssertNoError(t, err, "")
+	b2, err := txRW2.Marshal()
+	testutil.AssertNoError(t, err, "")
+
+	pooled := &TxReadWriteSet{}
+	testutil.AssertNoError(t, pooled.Unmarshal(b1), "")
+	value1 := pooled.NsRWs[0].Writes[0].Value
+	pooled.Release()
+
+	// the read-write set, its first namespace, its first read and its first write are reused
+	reused := AcquireTxReadWriteSet()
+	testutil.AssertNoError(t, reused.Unmarshal(b2), "")
+	b, err := reused.Marshal()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, b, b2)
+	testutil.AssertNil(t, reused.NsRWs[0].Reads[0].Version)
+	testutil.AssertNil(t, reused.NsRWs[0].RangeDeletes)
+	testutil.AssertEquals(t, len(reused.NsRWs), 1)
+	testutil.AssertEquals(t, len(reused.NsRWs[0].Writes), 1)
+	// the values taken from a released read-write set remain valid
+	testutil.AssertEquals(t, value1, []byte("value1"))
+	reused.Release()
+
+	reused = AcquireTxReadWriteSet()
+	testutil.AssertNoError(t, reused.Unmarshal(b1), "")
+	b, err = reused.Marshal()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, b, b1)
+	reused.Release()
+}
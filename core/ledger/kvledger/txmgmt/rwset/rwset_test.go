@@ -28,7 +28,7 @@ func TestNilTxRWSet(t *testing.T) {
 	nsRW1 := &NsReadWriteSet{"ns1",
 		[]*KVRead{&KVRead{"key1", nil}},
 		[]*KVWrite{&KVWrite{"key1", false, []byte("value1")}},
-		nil}
+		nil, nil}
 	txRW.NsRWs = append(txRW.NsRWs, nsRW1)
 	b, err := txRW.Marshal()
 	testutil.AssertNoError(t, err, "Error while marshalling changeset")
@@ -45,35 +45,35 @@ func TestTxRWSetMarshalUnmarshal(t *testing.T) {
 	nsRW1 := &NsReadWriteSet{"ns1",
 		[]*KVRead{&KVRead{"key1", version.NewHeight(1, 1)}},
 		[]*KVWrite{&KVWrite{"key2", false, []byte("value2")}},
-		nil}
+		nil, nil}
 
 	nsRW2 := &NsReadWriteSet{"ns2",
 		[]*KVRead{&KVRead{"key3", version.NewHeight(1, 2)}},
 		[]*KVWrite{&KVWrite{"key4", true, nil}},
-		nil}
+		nil, nil}
 
 	nsRW3 := &NsReadWriteSet{"ns3",
 		[]*KVRead{&KVRead{"key5", version.NewHeight(1, 3)}},
 		[]*KVWrite{&KVWrite{"key6", false, []byte("value6")}, &KVWrite{"key7", false, []byte("value7")}},
-		nil}
+		nil, nil}
 
 	nsRW4 := &NsReadWriteSet{"ns4",
 		[]*KVRead{&KVRead{"key8", version.NewHeight(1, 3)}},
 		[]*KVWrite{&KVWrite{"key9", false, []byte("value9")}, &KVWrite{"key10", false, []byte("value10")}},
 		[]*RangeQueryInfo{&RangeQueryInfo{"startKey1", "endKey1", true, nil,
-			&MerkleSummary{20, 1, []Hash{testutil.ConstructRandomBytes(t, 10)}}}}}
+			&MerkleSummary{20, 1, []Hash{testutil.ConstructRandomBytes(t, 10)}}}}, nil}
 
 	nsRW5 := &NsReadWriteSet{"ns5",
 		nil,
 		nil,
-		[]*RangeQueryInfo{&RangeQueryInfo{"startKey2", "endKey2", false, []*KVRead{&KVRead{"key11", version.NewHeight(1, 3)}}, nil}}}
+		[]*RangeQueryInfo{&RangeQueryInfo{"startKey2", "endKey2", false, []*KVRead{&KVRead{"key11", version.NewHeight(1, 3)}}, nil}}, nil}
 
 	nsRW6 := &NsReadWriteSet{"ns6",
 		nil,
 		nil,
 		[]*RangeQueryInfo{
 			&RangeQueryInfo{"startKey2", "endKey2", false, []*KVRead{&KVRead{"key11", version.NewHeight(1, 3)}}, nil},
-			&RangeQueryInfo{"startKey3", "endKey3", true, []*KVRead{&KVRead{"key12", version.NewHeight(2, 4)}}, nil}}}
+			&RangeQueryInfo{"startKey3", "endKey3", true, []*KVRead{&KVRead{"key12", version.NewHeight(2, 4)}}, nil}}, nil}
 
 	txRW.NsRWs = append(txRW.NsRWs, nsRW1, nsRW2, nsRW3, nsRW4, nsRW5, nsRW6)
 	t.Logf("Testing txRWSet = %s", txRW)
@@ -85,3 +85,36 @@ func TestTxRWSetMarshalUnmarshal(t *testing.T) {
 	testutil.AssertNoError(t, err, "Error while unmarshalling changeset")
 	testutil.AssertEquals(t, deserializedRWSet, txRW)
 }
+
+func TestTxRWSetMarshalUnmarshalRangeDeletes(t *testing.T) {
+	txRW := &TxReadWriteSet{}
+	nsRW1 := &NsReadWriteSet{"ns1",
+		[]*KVRead{&KVRead{"key1", version.NewHeight(1, 1)}},
+		[]*KVWrite{&KVWrite{"key2", false, []byte("value2")}},
+		nil,
+		[]*KVRangeDelete{&KVRangeDelete{"key3", "key5"}, &KVRangeDelete{"key7", ""}}}
+
+	nsRW2 := &NsReadWriteSet{"ns2",
+		nil,
+		[]*KVWrite{&KVWrite{"key4", true, nil}},
+		nil,
+		nil}
+
+	txRW.NsRWs = append(txRW.NsRWs, nsRW1, nsRW2)
+	t.Logf("Testing txRWSet = %s", txRW)
+	b, err := txRW.Marshal()
+	testutil.AssertNoError(t, err, "Error while marshalling changeset")
+
+	deserializedRWSet := &TxReadWriteSet{}
+	err = deserializedRWSet.Unmarshal(b)
+	testutil.AssertNoError(t, err, "Error while unmarshalling changeset")
+	testutil.AssertEquals(t, deserializedRWSet, txRW)
+
+	// the serialized form without range deletes is unchanged
+	nsRW1.RangeDeletes = nil
+	b, err = txRW.Marshal()
+	testutil.AssertNoError(t, err, "Error while marshalling changeset")
+	deserializedRWSet = &TxReadWriteSet{}
+	testutil.AssertNoError(t, deserializedRWSet.Unmarshal(b), "Error while unmarshalling changeset")
+	testutil.AssertEquals(t, deserializedRWSet, txRW)
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package commontests
 
 import (
+	"sort"
 	"strings"
 	"testing"
 
@@ -163,6 +164,110 @@ func TestIterator(t *testing.T, dbProvider statedb.VersionedDBProvider) {
 	testItr(t, itr4, []string{"key5", "key6"})
 }
 
+// TestFullScanIterator tests the iterator over all the namespaces
+func TestFullScanIterator(t *testing.T, dbProvider statedb.VersionedDBProvider) {
+	db, err := dbProvider.GetDBHandle("testfullscaniterator")
+	testutil.AssertNoError(t, err, "")
+	db.Open()
+	defer db.Close()
+	batch := statedb.NewUpdateBatch()
+	batch.Put("ns1", "key1", []byte("value1"), version.NewHeight(1, 1))
+	batch.Put("ns1", "key2", []byte("value2"), version.NewHeight(1, 2))
+	batch.Put("ns2", "key3", []byte("value3"), version.NewHeight(1, 3))
+	batch.Put("ns3", "key4", []byte("value4"), version.NewHeight(1, 4))
+	savePoint := version.NewHeight(2, 5)
+	db.ApplyUpdates(batch, savePoint)
+
+	itr, err := db.GetFullScanIterator()
+	testutil.AssertNoError(t, err, "")
+	defer itr.Close()
+	expectedKVs := []*statedb.CompositeKey{
+		{Namespace: "ns1", Key: "key1"},
+		{Namespace: "ns1", Key: "key2"},
+		{Namespace: "ns2", Key: "key3"},
+		{Namespace: "ns3", Key: "key4"},
+	}
+	for _, expectedKV := range expectedKVs {
+		queryResult, err := itr.Next()
+		testutil.AssertNoError(t, err, "")
+		vkv := queryResult.(*statedb.VersionedKV)
+		testutil.AssertEquals(t, vkv.CompositeKey, *expectedKV)
+		testutil.AssertEquals(t, vkv.VersionedValue, *batch.Get(expectedKV.Namespace, expectedKV.Key))
+	}
+	last, err := itr.Next()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertNil(t, last)
+}
+
+// TestListNamespaces tests the namespaces listed by the state db
+func TestListNamespaces(t *testing.T, dbProvider statedb.VersionedDBProvider) {
+	db, err := dbProvider.GetDBHandle("testlistnamespaces")
+	testutil.AssertNoError(t, err, "")
+	db.Open()
+	defer db.Close()
+	counts, err := db.ListNamespaces()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, len(counts), 0)
+
+	batch := statedb.NewUpdateBatch()
+	batch.Put("ns1", "key1", []byte("value1"), version.NewHeight(1, 1))
+	batch.Put("ns1", "key2", []byte("value2"), version.NewHeight(1, 2))
+	batch.Put("ns2", "key3", []byte("value3"), version.NewHeight(1, 3))
+	batch.Put("ns3", "key4", []byte("value4"), version.NewHeight(1, 4))
+	batch.Put("ns3", "key5", []byte("value5"), version.NewHeight(1, 5))
+	batch.Put("ns3", "key6", []byte("value6"), version.NewHeight(1, 6))
+	db.ApplyUpdates(batch, version.NewHeight(1, 6))
+	batch = statedb.NewUpdateBatch()
+	batch.Delete("ns2", "key3", version.NewHeight(2, 1))
+	batch.Delete("ns3", "key4", version.NewHeight(2, 2))
+	db.ApplyUpdates(batch, version.NewHeight(2, 2))
+
+	counts, err = db.ListNamespaces()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, counts, map[string]uint64{"ns1": 2, "ns3": 2})
+}
+
+// TestRangeScanOrder tests that the range scans return the keys in their byte order, whatever the bytes of the keys
+// and of the namespaces, so that the chaincodes get the same results from every state db
+func TestRangeScanOrder(t *testing.T, dbProvider statedb.VersionedDBProvider) {
+	db, err := dbProvider.GetDBHandle("testrangescanorder")
+	testutil.AssertNoError(t, err, "")
+	db.Open()
+	defer db.Close()
+	keys := []string{"key", "Key", "_key", "\x1fkey", "key\x00", "key\x00attr\x00", "key\x01", "key1", "key~", "key\x7f",
+		"ke\u00e9", "ke\u00ff", "ke\u0100", "ke\u4e16", "ke\U0001f600", "ke\xc3", "ke\xc3(", "ke\xff", "ke\xff\xfe"}
+	namespaces := []string{"ns", "_ns", "ns/1:2"}
+	batch := statedb.NewUpdateBatch()
+	for _, ns := range namespaces {
+		for i, key := range keys {
+			batch.Put(ns, key, []byte(ns+key), version.NewHeight(1, uint64(i)))
+		}
+	}
+	db.ApplyUpdates(batch, version.NewHeight(1, uint64(len(keys))))
+	sort.Strings(keys)
+
+	for _, ns := range namespaces {
+		for _, key := range keys {
+			vv, err := db.GetState(ns, key)
+			testutil.AssertNoError(t, err, "")
+			testutil.AssertEquals(t, vv.Value, []byte(ns+key))
+		}
+		itr, err := db.GetStateRangeScanIterator(ns, "", "")
+		testutil.AssertNoError(t, err, "")
+		testItr(t, itr, keys)
+
+		start := sort.SearchStrings(keys, "ke\u00ff")
+		end := sort.SearchStrings(keys, "ke\xff")
+		itr, err = db.GetStateRangeScanIterator(ns, "ke\u00ff", "ke\xff")
+		testutil.AssertNoError(t, err, "")
+		testItr(t, itr, keys[start:end])
+	}
+
+	counts, err := db.ListNamespaces()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, counts, map[string]uint64{"ns": uint64(len(keys)), "_ns": uint64(len(keys)), "ns/1:2": uint64(len(keys))})
+}
+
 func testItr(t *testing.T, itr statedb.ResultsIterator, expectedKeys []string) {
 	defer itr.Close()
 	for _, expectedKey := range expectedKeys {
@@ -0,0 +1,88 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statecouchdb
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf8"
+)
+
+// The ids of the documents of the keys are made of the encoded namespace, the composite key separator and the
+// encoded key. The encoding maps any namespace and any key to a valid CouchDB document id, i.e., a UTF-8 string
+// that does not start with an underscore, and is reversed when the ids are read back, so that neither the
+// range scans nor the queries expose it
+
+// namespaceEscape prefixes the namespaces that start with an underscore, which CouchDB reserves for its own
+// documents, and the namespaces that start with namespaceEscape, so that the escaped namespaces do not collide
+const namespaceEscape = "\x01"
+
+// constructCompositeKey returns the id of the document of a key of the namespace
+func constructCompositeKey(ns string, key string) []byte {
+	compositeKey := []byte(encodeNamespace(ns))
+	compositeKey = append(compositeKey, compositeKeySep...)
+	compositeKey = append(compositeKey, []byte(encodeKey(key))...)
+	return compositeKey
+}
+
+// splitCompositeKey returns the namespace and the key of the id of a document
+func splitCompositeKey(compositeKey []byte) (string, string) {
+	split := bytes.SplitN(compositeKey, compositeKeySep, 2)
+	return decodeNamespace(string(split[0])), decodeKey(string(split[1]))
+}
+
+// encodeNamespace escapes the namespaces that would start the id of a document with an underscore
+// and encodes the namespace as the keys are encoded
+func encodeNamespace(ns string) string {
+	if strings.HasPrefix(ns, "_") || strings.HasPrefix(ns, namespaceEscape) {
+		ns = namespaceEscape + ns
+	}
+	return encodeKey(ns)
+}
+
+// decodeNamespace returns the namespace encoded by encodeNamespace
+func decodeNamespace(encodedNamespace string) string {
+	return strings.TrimPrefix(decodeKey(encodedNamespace), namespaceEscape)
+}
+
+// encodeKey encodes a key byte by byte, each byte as the character of the same code point, so that the ids of
+// the documents are valid UTF-8 whatever the bytes of the keys. CouchDB orders the ids of the documents by their
+// UTF-8 bytes, i.e., by code point, hence the range scans return the keys in the byte order of the keys, as the
+// leveldb state databases do. The keys made of ASCII characters are their own encoding
+func encodeKey(key string) string {
+	ascii := true
+	for i := 0; i < len(key) && ascii; i++ {
+		ascii = key[i] < utf8.RuneSelf
+	}
+	if ascii {
+		return key
+	}
+	encodedKey := make([]byte, 0, 2*len(key))
+	for i := 0; i < len(key); i++ {
+		encodedKey = append(encodedKey, string(rune(key[i]))...)
+	}
+	return string(encodedKey)
+}
+
+// decodeKey returns the key encoded by encodeKey
+func decodeKey(encodedKey string) string {
+	key := make([]byte, 0, len(encodedKey))
+	for _, r := range encodedKey {
+		key = append(key, byte(r))
+	}
+	return string(key)
+}
@@ -0,0 +1,73 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statecouchdb
+
+import "github.com/hyperledger/fabric/core/ledger/util/couchdb"
+
+const (
+	// initialFetchSize is the number of documents fetched from CouchDB by the first request of a scan,
+	// before the size of the documents is known
+	initialFetchSize = 100
+	// maxFetchSize caps the number of documents fetched from CouchDB in a single request, so that
+	// a scan over tiny documents does not fetch an unbounded number of them at once
+	maxFetchSize = 10000
+)
+
+// fetchSizer adapts the number of documents that a scan fetches from CouchDB in a single request to the size of
+// the documents, so that a request fetches about the given number of bytes. A namespace with large documents is
+// scanned with small requests to bound the memory held by a scan, and a namespace with tiny documents with large
+// requests to save round trips
+type fetchSizer struct {
+	budget int
+	size   int
+}
+
+func newFetchSizer(budget int) *fetchSizer {
+	return &fetchSizer{budget: budget, size: initialFetchSize}
+}
+
+// next returns the number of documents to fetch by the next request, which is at most the given limit, if any
+func (s *fetchSizer) next(limit int) int {
+	if limit > 0 && limit < s.size {
+		return limit
+	}
+	return s.size
+}
+
+// observe adjusts the number of documents to fetch to the average size of the fetched documents
+func (s *fetchSizer) observe(results []couchdb.QueryResult) {
+	if len(results) == 0 {
+		return
+	}
+	totalBytes := 0
+	for _, result := range results {
+		totalBytes += len(result.Value)
+		for _, attachment := range result.Attachments {
+			totalBytes += len(attachment.AttachmentBytes)
+		}
+	}
+	averageBytes := totalBytes / len(results)
+	if averageBytes == 0 {
+		averageBytes = 1
+	}
+	s.size = s.budget / averageBytes
+	if s.size < 1 {
+		s.size = 1
+	} else if s.size > maxFetchSize {
+		s.size = maxFetchSize
+	}
+}
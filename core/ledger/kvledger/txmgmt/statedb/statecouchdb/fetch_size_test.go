@@ -0,0 +1,102 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statecouchdb
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
+	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+	"github.com/hyperledger/fabric/core/ledger/util/couchdb"
+)
+
+func TestFetchSizer(t *testing.T) {
+	sizer := newFetchSizer(1000)
+	testutil.AssertEquals(t, sizer.next(0), initialFetchSize)
+	testutil.AssertEquals(t, sizer.next(10), 10)
+
+	// large documents shrink the fetches, down to a single document
+	sizer.observe([]couchdb.QueryResult{{Value: make([]byte, 100)}, {Value: make([]byte, 300)}})
+	testutil.AssertEquals(t, sizer.next(0), 5)
+	sizer.observe([]couchdb.QueryResult{{Value: make([]byte, 10)}, {Attachments: []couchdb.Attachment{{AttachmentBytes: make([]byte, 5000)}}}})
+	testutil.AssertEquals(t, sizer.next(0), 1)
+
+	// tiny documents grow the fetches, up to the cap
+	sizer.observe([]couchdb.QueryResult{{Value: make([]byte, 4)}})
+	testutil.AssertEquals(t, sizer.next(0), 250)
+	sizer.observe([]couchdb.QueryResult{{}})
+	testutil.AssertEquals(t, sizer.next(0), 1000)
+	sizer = newFetchSizer(1 << 30)
+	sizer.observe([]couchdb.QueryResult{{Value: make([]byte, 4)}})
+	testutil.AssertEquals(t, sizer.next(0), maxFetchSize)
+
+	// an empty page leaves the size unchanged
+	sizer.observe(nil)
+	testutil.AssertEquals(t, sizer.next(0), maxFetchSize)
+}
+
+func TestRangeScanPages(t *testing.T) {
+	if ledgerconfig.IsCouchDBEnabled() == true {
+
+		env := NewTestVDBEnv(t)
+		env.Cleanup("testrangescanpages")
+		defer env.Cleanup("testrangescanpages")
+		db, err := env.DBProvider.GetDBHandle("testrangescanpages")
+		testutil.AssertNoError(t, err, "")
+		// a budget of a few documents makes the scans fetch many pages
+		db.(*VersionedDB).fetchBudget = 500
+
+		batch := statedb.NewUpdateBatch()
+		for i := 0; i < 250; i++ {
+			batch.Put("ns1", fmt.Sprintf("key%03d", i), []byte(fmt.Sprintf(`{"value":%d}`, i)), version.NewHeight(1, uint64(i+1)))
+		}
+		batch.Put("ns2", "key000", []byte(`{"value":0}`), version.NewHeight(1, 251))
+		testutil.AssertNoError(t, db.ApplyUpdates(batch, version.NewHeight(1, 251)), "")
+
+		countResults := func(itr statedb.ResultsIterator) []string {
+			defer itr.Close()
+			var keys []string
+			for {
+				queryResult, err := itr.Next()
+				testutil.AssertNoError(t, err, "")
+				if queryResult == nil {
+					return keys
+				}
+				keys = append(keys, queryResult.(*statedb.VersionedKV).Key)
+			}
+		}
+		itr, err := db.GetStateRangeScanIterator("ns1", "", "")
+		testutil.AssertNoError(t, err, "")
+		keys := countResults(itr)
+		testutil.AssertEquals(t, len(keys), 250)
+		testutil.AssertEquals(t, keys[249], "key249")
+
+		itr, _ = db.GetStateRangeScanIterator("ns1", "key100", "key200")
+		keys = countResults(itr)
+		testutil.AssertEquals(t, len(keys), 100)
+		testutil.AssertEquals(t, keys[0], "key100")
+
+		itr, _ = db.(*VersionedDB).GetStateRangeScanIteratorWithLimit("ns1", "key010", "", 75)
+		keys = countResults(itr)
+		testutil.AssertEquals(t, len(keys), 75)
+		testutil.AssertEquals(t, keys[74], "key084")
+
+	}
+}
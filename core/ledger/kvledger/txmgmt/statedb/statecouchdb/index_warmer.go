@@ -0,0 +1,104 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statecouchdb
+
+import (
+	"sync"
+
+	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/hyperledger/fabric/core/ledger/util/couchdb"
+)
+
+// indexWarmer brings the views of a CouchDB database, which include the indexes of the rich queries, up to date in the
+// background after the commits so that the next queries do not wait for CouchDB to index the new documents. A commit
+// that happens while the views are being warmed requests one more round, so that the rounds do not pile up
+type indexWarmer struct {
+	dbName      string
+	db          *couchdb.CouchDatabase
+	concurrency int
+	trigger     chan struct{}
+	stop        chan struct{}
+	done        chan struct{}
+	closeOnce   sync.Once
+}
+
+// newIndexWarmer constructs a warmer that warms at most the given number of views of the database in parallel
+func newIndexWarmer(dbName string, db *couchdb.CouchDatabase, concurrency int) *indexWarmer {
+	w := &indexWarmer{dbName: dbName, db: db, concurrency: concurrency, trigger: make(chan struct{}, 1),
+		stop: make(chan struct{}), done: make(chan struct{})}
+	go w.run()
+	return w
+}
+
+// notifyCommit requests a round of warming without blocking the commit
+func (w *indexWarmer) notifyCommit() {
+	select {
+	case w.trigger <- struct{}{}:
+	default:
+	}
+}
+
+func (w *indexWarmer) run() {
+	defer close(w.done)
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-w.trigger:
+			w.warm()
+		}
+	}
+}
+
+// warm lists the views of the database and warms them. A view that cannot be warmed is only logged
+// as the warming merely saves the latency of the next query
+func (w *indexWarmer) warm() {
+	warmLogger := logger.With(flogging.Fields{"channel": w.dbName})
+	views, err := w.db.ListViews()
+	if err != nil {
+		warmLogger.Warningf("Error while listing the views to warm: %s", err)
+		return
+	}
+	viewsChan := make(chan couchdb.ViewName, len(views))
+	for _, view := range views {
+		viewsChan <- view
+	}
+	close(viewsChan)
+
+	var wg sync.WaitGroup
+	for i := 0; i < w.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for view := range viewsChan {
+				if err := w.db.WarmView(view.DesignDoc, view.View); err != nil {
+					warmLogger.Warningf("Error while warming view [%s] of design document [%s]: %s", view.View, view.DesignDoc, err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	warmLogger.Debugf("Warmed %d view(s)", len(views))
+}
+
+// close stops the warmer after the round in progress, if any
+func (w *indexWarmer) close() {
+	w.closeOnce.Do(func() {
+		close(w.stop)
+		<-w.done
+	})
+}
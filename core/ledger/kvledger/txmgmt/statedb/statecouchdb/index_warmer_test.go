@@ -0,0 +1,79 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statecouchdb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger/util/couchdb"
+)
+
+func TestIndexWarmer(t *testing.T) {
+	// a fake CouchDB with a database that has a design document of two views
+	var lock sync.Mutex
+	warmed := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/":
+			w.Write([]byte(`{"couchdb":"Welcome","version":"2.0.0"}`))
+		case r.URL.Path == "/testwarmer":
+			w.Write([]byte(`{"db_name":"testwarmer"}`))
+		case strings.HasSuffix(r.URL.Path, "/_all_docs"):
+			w.Write([]byte(`{"rows":[{"id":"_design/indexOwner","doc":{"views":{"byOwner":{},"byColor":{}}}}]}`))
+		default:
+			lock.Lock()
+			warmed[r.URL.Path]++
+			lock.Unlock()
+			w.Write([]byte(`{"rows":[]}`))
+		}
+	}))
+	defer server.Close()
+	couchInstance, err := couchdb.CreateCouchInstance(strings.TrimPrefix(server.URL, "http://"), "", "")
+	testutil.AssertNoError(t, err, "")
+	db, err := couchdb.CreateCouchDatabase(*couchInstance, "testwarmer")
+	testutil.AssertNoError(t, err, "")
+
+	w := newIndexWarmer("testwarmer", db, 2)
+	w.notifyCommit()
+	// a commit never blocks on the warmer
+	for i := 0; i < 10; i++ {
+		w.notifyCommit()
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		lock.Lock()
+		done := warmed["/testwarmer/_design/indexOwner/_view/byOwner"] > 0 && warmed["/testwarmer/_design/indexOwner/_view/byColor"] > 0
+		lock.Unlock()
+		if done {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Views not warmed: %v", warmed)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	w.close()
+	// closing again is harmless
+	w.close()
+}
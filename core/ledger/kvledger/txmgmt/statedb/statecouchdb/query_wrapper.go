@@ -98,6 +98,28 @@ func ApplyQueryWrapper(namespace, queryString string) (string, error) {
 
 }
 
+//setQueryFields sets the "fields" of the query to the given fields, replacing the
+//fields specified in the query if any. The fields are wrapped by ApplyQueryWrapper
+func setQueryFields(queryString string, fields []string) (string, error) {
+
+	jsonQueryMap := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(queryString), &jsonQueryMap); err != nil {
+		return "", err
+	}
+
+	queryFields := make([]interface{}, 0, len(fields))
+	for _, field := range fields {
+		queryFields = append(queryFields, field)
+	}
+	jsonQueryMap[jsonQueryFields] = queryFields
+
+	editedQuery, err := json.Marshal(jsonQueryMap)
+	if err != nil {
+		return "", err
+	}
+	return string(editedQuery), nil
+}
+
 //setNamespaceInSelector adds an additional heirarchy in the "selector"
 //{"owner": {"$eq": "tom"}}
 //would be mapped as (assuming a namespace of "marble"):
@@ -119,6 +119,37 @@ func TestQueryWithFields(t *testing.T) {
 
 }
 
+//TestQuerySetFields tests replacing the fields of a query
+func TestQuerySetFields(t *testing.T) {
+
+	rawQuery := []byte(`{"selector":{"owner": {"$eq": "tom"}},"fields": ["owner", "asset_name"]}`)
+
+	queryWithFields, err := setQueryFields(string(rawQuery), []string{"color", "size"})
+
+	//Make sure the query did not throw an exception
+	testutil.AssertNoError(t, err, "Unexpected error thrown when for query JSON")
+
+	wrappedQuery, err := ApplyQueryWrapper("ns1", queryWithFields)
+	testutil.AssertNoError(t, err, "Unexpected error thrown when for query JSON")
+
+	//replaced field should only be in the selector
+	testutil.AssertEquals(t, strings.Count(wrappedQuery, "\"data.owner\""), 1)
+
+	//replaced field should be removed
+	testutil.AssertEquals(t, strings.Count(wrappedQuery, "\"data.asset_name\""), 0)
+
+	//field value should be wrapped
+	testutil.AssertEquals(t, strings.Count(wrappedQuery, "\"data.color\""), 1)
+
+	//field value should be wrapped
+	testutil.AssertEquals(t, strings.Count(wrappedQuery, "\"data.size\""), 1)
+
+	//invalid query should throw an error
+	_, err = setQueryFields("not a query", []string{"color"})
+	testutil.AssertError(t, err, "Expected error for invalid query JSON")
+
+}
+
 //TestQueryWithSortFields tests sorting fields
 func TestQueryWithSortFields(t *testing.T) {
 
@@ -19,20 +19,22 @@ package statecouchdb
 import (
 	"bytes"
 	"encoding/json"
-	"errors"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 
+	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/hyperledger/fabric/core/ledger"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
 	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+	lutils "github.com/hyperledger/fabric/core/ledger/util"
 	"github.com/hyperledger/fabric/core/ledger/util/couchdb"
-	logging "github.com/op/go-logging"
 )
 
-var logger = logging.MustGetLogger("statecouchdb")
+var logger = flogging.MustGetFieldLogger("statecouchdb")
 
 var compositeKeySep = []byte{0x00}
 var lastKeyIndicator = byte(0x01)
@@ -45,18 +47,32 @@ type VersionedDBProvider struct {
 	databases     map[string]*VersionedDB
 	mux           sync.Mutex
 	openCounts    uint64
+	// readOnly is set if the provider opens the existing databases only, without writing to CouchDB
+	readOnly bool
 }
 
 // NewVersionedDBProvider instantiates VersionedDBProvider
 func NewVersionedDBProvider() (*VersionedDBProvider, error) {
-	logger.Debugf("constructing CouchDB VersionedDBProvider")
+	logger.Debug("constructing CouchDB VersionedDBProvider")
 	couchDBDef := ledgerconfig.GetCouchDBDefinition()
 	couchInstance, err := couchdb.CreateCouchInstance(couchDBDef.URL, couchDBDef.Username, couchDBDef.Password)
 	if err != nil {
 		return nil, err
 	}
 
-	return &VersionedDBProvider{couchInstance, make(map[string]*VersionedDB), sync.Mutex{}, 0}, nil
+	return &VersionedDBProvider{couchInstance, make(map[string]*VersionedDB), sync.Mutex{}, 0, false}, nil
+}
+
+// NewReadOnlyVersionedDBProvider instantiates VersionedDBProvider that opens the existing databases only.
+// Neither the databases nor their indexes are created, and a missing database is reported as an error
+func NewReadOnlyVersionedDBProvider() (*VersionedDBProvider, error) {
+	logger.Debug("constructing read-only CouchDB VersionedDBProvider")
+	couchDBDef := ledgerconfig.GetCouchDBDefinition()
+	couchInstance, err := couchdb.CreateCouchInstance(couchDBDef.URL, couchDBDef.Username, couchDBDef.Password)
+	if err != nil {
+		return nil, err
+	}
+	return &VersionedDBProvider{couchInstance, make(map[string]*VersionedDB), sync.Mutex{}, 0, true}, nil
 }
 
 // GetDBHandle gets the handle to a named database
@@ -67,7 +83,7 @@ func (provider *VersionedDBProvider) GetDBHandle(dbName string) (statedb.Version
 	vdb := provider.databases[dbName]
 	if vdb == nil {
 		var err error
-		vdb, err = newVersionedDB(provider.couchInstance, dbName)
+		vdb, err = newVersionedDB(provider.couchInstance, dbName, provider.readOnly)
 		if err != nil {
 			return nil, err
 		}
@@ -76,6 +92,30 @@ func (provider *VersionedDBProvider) GetDBHandle(dbName string) (statedb.Version
 	return vdb, nil
 }
 
+// Drop drops the named database
+func (provider *VersionedDBProvider) Drop(dbName string) error {
+	provider.mux.Lock()
+	defer provider.mux.Unlock()
+
+	if provider.readOnly {
+		return fmt.Errorf("Cannot drop the database [%s] as the CouchDB VersionedDBProvider is read-only", dbName)
+	}
+	vdb := provider.databases[dbName]
+	if vdb == nil {
+		var err error
+		vdb, err = newVersionedDB(provider.couchInstance, dbName, false)
+		if err != nil {
+			return err
+		}
+	}
+	if _, err := vdb.db.DropDatabase(); err != nil {
+		return err
+	}
+	// the database gets created again on the next GetDBHandle
+	delete(provider.databases, dbName)
+	return nil
+}
+
 // Close closes the underlying db instance
 func (provider *VersionedDBProvider) Close() {
 	// No close needed on Couch
@@ -83,18 +123,42 @@ func (provider *VersionedDBProvider) Close() {
 
 // VersionedDB implements VersionedDB interface
 type VersionedDB struct {
-	db     *couchdb.CouchDatabase
-	dbName string
+	db         *couchdb.CouchDatabase
+	dbName     string
+	queryLimit int
+	// fetchBudget is the number of bytes of documents that a scan fetches per request
+	fetchBudget int
+	// chunkSize is the size above which the binary values are split into several attachments, 0 if not split
+	chunkSize int
+	// indexWarmer warms the views of the database after the commits and is nil if the warming is not enabled
+	indexWarmer *indexWarmer
 }
 
-// newVersionedDB constructs an instance of VersionedDB
-func newVersionedDB(couchInstance *couchdb.CouchInstance, dbName string) (*VersionedDB, error) {
-	// CreateCouchDatabase creates a CouchDB database object, as well as the underlying database if it does not exist
-	db, err := couchdb.CreateCouchDatabase(*couchInstance, dbName)
+// newVersionedDB constructs an instance of VersionedDB. A read-only instance opens an existing database only
+// and does not warm the indexes
+func newVersionedDB(couchInstance *couchdb.CouchInstance, dbName string, readOnly bool) (*VersionedDB, error) {
+	var db *couchdb.CouchDatabase
+	var err error
+	if readOnly {
+		db, err = couchdb.OpenCouchDatabase(*couchInstance, dbName)
+	} else {
+		// CreateCouchDatabase creates a CouchDB database object, as well as the underlying database if it does not exist
+		db, err = couchdb.CreateCouchDatabase(*couchInstance, dbName)
+	}
 	if err != nil {
 		return nil, err
 	}
-	return &VersionedDB{db, dbName}, nil
+	vdb := &VersionedDB{db: db, dbName: dbName, queryLimit: ledgerconfig.GetQueryLimit(),
+		fetchBudget: ledgerconfig.GetCouchDBFetchBudget(), chunkSize: ledgerconfig.GetStateValueChunkSize()}
+	if ledgerconfig.IsCouchDBIndexWarmingEnabled() && !readOnly {
+		vdb.indexWarmer = newIndexWarmer(dbName, db, ledgerconfig.GetCouchDBIndexWarmingConcurrency())
+	}
+	return vdb, nil
+}
+
+// SetQueryLimit sets the limit on the number of records to return per query
+func (vdb *VersionedDB) SetQueryLimit(queryLimit int) {
+	vdb.queryLimit = queryLimit
 }
 
 // Open implements method in VersionedDB interface
@@ -106,11 +170,14 @@ func (vdb *VersionedDB) Open() error {
 // Close implements method in VersionedDB interface
 func (vdb *VersionedDB) Close() {
 	// no need to close db since a shared couch instance is used
+	if vdb.indexWarmer != nil {
+		vdb.indexWarmer.close()
+	}
 }
 
 // GetState implements method in VersionedDB interface
 func (vdb *VersionedDB) GetState(namespace string, key string) (*statedb.VersionedValue, error) {
-	logger.Debugf("GetState(). ns=%s, key=%s", namespace, key)
+	logger.With(flogging.Fields{"channel": vdb.dbName, "namespace": namespace, "keyHash": lutils.KeyHashForLog(key)}).Debug("GetState()")
 
 	compositeKey := constructCompositeKey(namespace, key)
 
@@ -144,11 +211,9 @@ func removeDataWrapper(wrappedValue []byte, attachments []couchdb.Attachment) ([
 
 	// handle binary or json data
 	if jsonResult[dataWrapper] == nil && attachments != nil { // binary attachment
-		// get binary data from attachment
-		for _, attachment := range attachments {
-			if attachment.Name == binaryWrapper {
-				returnValue = attachment.AttachmentBytes
-			}
+		// get binary data from attachment, reassembled from the chunks if the value is split
+		if binaryValue, ok := joinBinaryAttachments(attachments); ok {
+			returnValue = binaryValue
 		}
 	} else {
 		//place the result json in the data key
@@ -178,13 +243,23 @@ func removeDataWrapper(wrappedValue []byte, attachments []couchdb.Attachment) ([
 // GetStateMultipleKeys implements method in VersionedDB interface
 func (vdb *VersionedDB) GetStateMultipleKeys(namespace string, keys []string) ([]*statedb.VersionedValue, error) {
 
-	vals := make([]*statedb.VersionedValue, len(keys))
+	//the documents are read in a single request if the server supports _bulk_get
+	compositeKeys := make([]string, len(keys))
 	for i, key := range keys {
-		val, err := vdb.GetState(namespace, key)
-		if err != nil {
-			return nil, err
+		compositeKeys[i] = string(constructCompositeKey(namespace, key))
+	}
+	couchDocs, err := vdb.db.ReadDocs(compositeKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	vals := make([]*statedb.VersionedValue, len(keys))
+	for i, couchDoc := range couchDocs {
+		if couchDoc == nil {
+			continue
 		}
-		vals[i] = val
+		returnValue, returnVersion := removeDataWrapper(couchDoc.JSONValue, couchDoc.Attachments)
+		vals[i] = &statedb.VersionedValue{Value: returnValue, Version: &returnVersion}
 	}
 	return vals, nil
 
@@ -194,52 +269,112 @@ func (vdb *VersionedDB) GetStateMultipleKeys(namespace string, keys []string) ([
 // startKey is inclusive
 // endKey is exclusive
 func (vdb *VersionedDB) GetStateRangeScanIterator(namespace string, startKey string, endKey string) (statedb.ResultsIterator, error) {
+	return vdb.GetStateRangeScanIteratorWithLimit(namespace, startKey, endKey, 0)
+}
+
+// GetStateRangeScanIteratorWithLimit implements method in RangeScanLimiter interface.
+// The results are fetched from CouchDB in pages whose size is adapted to the size of the documents.
+// A limit of zero fetches all the results of the range
+func (vdb *VersionedDB) GetStateRangeScanIteratorWithLimit(namespace string, startKey string, endKey string, limit int32) (statedb.ResultsIterator, error) {
 
 	compositeStartKey := constructCompositeKey(namespace, startKey)
 	compositeEndKey := constructCompositeKey(namespace, endKey)
 	if endKey == "" {
 		compositeEndKey[len(compositeEndKey)-1] = lastKeyIndicator
 	}
-	queryResult, err := vdb.db.ReadDocRange(string(compositeStartKey), string(compositeEndKey), 1000, 0)
-	if err != nil {
-		logger.Debugf("Error calling ReadDocRange(): %s\n", err.Error())
+	scanner := newKVScanner(vdb.db, namespace, string(compositeStartKey), string(compositeEndKey), int(limit), vdb.fetchBudget)
+	// the first page is fetched eagerly so that an error is returned by the construction of the iterator
+	if err := scanner.fetchNextPage(); err != nil {
+		logger.With(flogging.Fields{"channel": vdb.dbName, "namespace": namespace}).Debugf("Error calling ReadDocRange(): %s", err)
 		return nil, err
 	}
-	logger.Debugf("Exiting GetStateRangeScanIterator")
-	return newKVScanner(namespace, *queryResult), nil
+	logger.Debug("Exiting GetStateRangeScanIterator")
+	return scanner, nil
+
+}
 
+// GetFullScanIterator implements method in VersionedDB interface
+func (vdb *VersionedDB) GetFullScanIterator() (statedb.ResultsIterator, error) {
+	return newFullScanner(vdb.db, vdb.fetchBudget), nil
+}
+
+// ListNamespaces implements method in VersionedDB interface.
+// The documents of a namespace are not read but counted from the offsets of the first documents of the
+// namespace and of the next namespace, hence the counts are approximate while the blocks are committed
+func (vdb *VersionedDB) ListNamespaces() (map[string]uint64, error) {
+	counts := make(map[string]uint64)
+	resp, err := vdb.db.ReadDocIDs("", 1)
+	if err != nil {
+		return nil, err
+	}
+	for len(resp.Rows) > 0 {
+		id := resp.Rows[0].ID
+		if !strings.Contains(id, string(compositeKeySep)) {
+			// skip the documents that do not correspond to a key-value (such as the savepoint document)
+			if resp, err = vdb.db.ReadDocIDs(id+string(compositeKeySep), 1); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		namespace, _ := splitCompositeKey([]byte(id))
+		nextNamespaceKey := constructCompositeKey(namespace, "")
+		nextNamespaceKey[len(nextNamespaceKey)-1] = lastKeyIndicator
+		next, err := vdb.db.ReadDocIDs(string(nextNamespaceKey), 1)
+		if err != nil {
+			return nil, err
+		}
+		nextOffset := next.Offset
+		if len(next.Rows) == 0 {
+			nextOffset = next.TotalRows
+		}
+		counts[namespace] = uint64(nextOffset - resp.Offset)
+		resp = next
+	}
+	return counts, nil
 }
 
 // ExecuteQuery implements method in VersionedDB interface
 func (vdb *VersionedDB) ExecuteQuery(namespace, query string) (statedb.ResultsIterator, error) {
 
-	//TODO - limit is currently set at 1000,  eventually this will need to be changed
-	//to reflect a config option and potentially return an exception if the threshold is exceeded
+	//TODO - potentially return an exception if the query limit is exceeded
 	// skip (paging) is not utilized by fabric
+	queryLogger := logger.With(flogging.Fields{"channel": vdb.dbName, "namespace": namespace})
 	queryString, err := ApplyQueryWrapper(namespace, query)
 	if err != nil {
-		logger.Debugf("Error calling QueryDocuments(): %s\n", err.Error())
+		queryLogger.Debugf("Error calling QueryDocuments(): %s", err)
+		return nil, err
+	}
+
+	stream, err := vdb.db.QueryDocumentsStream(queryString, vdb.queryLimit, 0)
+	if err != nil {
+		queryLogger.Debugf("Error calling QueryDocumentsStream(): %s", err)
 		return nil, err
 	}
+	queryLogger.Debug("Exiting ExecuteQuery")
+	return newQueryScanner(stream), nil
+}
 
-	queryResult, err := vdb.db.QueryDocuments(queryString, 1000, 0)
+// ExecuteQueryWithFields implements method in FieldsQueryExecutor interface. The fields are passed
+// to CouchDB as the fields of the query, replacing the fields specified in the query if any
+func (vdb *VersionedDB) ExecuteQueryWithFields(namespace, query string, fields []string) (statedb.ResultsIterator, error) {
+	queryWithFields, err := setQueryFields(query, fields)
 	if err != nil {
-		logger.Debugf("Error calling QueryDocuments(): %s\n", err.Error())
 		return nil, err
 	}
-	logger.Debugf("Exiting ExecuteQuery")
-	return newQueryScanner(*queryResult), nil
+	return vdb.ExecuteQuery(namespace, queryWithFields)
 }
 
 // ApplyUpdates implements method in VersionedDB interface
 func (vdb *VersionedDB) ApplyUpdates(batch *statedb.UpdateBatch, height *version.Height) error {
 
+	blockLogger := logger.With(flogging.Fields{"channel": vdb.dbName, "block": height.BlockNum})
 	namespaces := batch.GetUpdatedNamespaces()
 	for _, ns := range namespaces {
 		updates := batch.GetUpdates(ns)
 		for k, vv := range updates {
 			compositeKey := constructCompositeKey(ns, k)
-			logger.Debugf("Channel [%s]: Applying key=[%#v]", vdb.dbName, compositeKey)
+			keyLogger := blockLogger.With(flogging.Fields{"namespace": ns, "keyHash": lutils.KeyHashForLog(k)})
+			keyLogger.Debug("Applying key")
 
 			//convert nils to deletes
 			if vv.Value == nil {
@@ -247,34 +382,16 @@ func (vdb *VersionedDB) ApplyUpdates(batch *statedb.UpdateBatch, height *version
 				vdb.db.DeleteDoc(string(compositeKey), "")
 
 			} else {
-				couchDoc := &couchdb.CouchDoc{}
-
-				//Check to see if the value is a valid JSON
-				//If this is not a valid JSON, then store as an attachment
-				if couchdb.IsJSON(string(vv.Value)) {
-					// Handle it as json
-					couchDoc.JSONValue = addVersionAndChainCodeID(vv.Value, ns, vv.Version)
-				} else { // if the data is not JSON, save as binary attachment in Couch
-					//Create an attachment structure and load the bytes
-					attachment := &couchdb.Attachment{}
-					attachment.AttachmentBytes = vv.Value
-					attachment.ContentType = "application/octet-stream"
-					attachment.Name = binaryWrapper
-
-					attachments := []couchdb.Attachment{}
-					attachments = append(attachments, *attachment)
-					couchDoc.Attachments = append(couchDoc.Attachments, *attachment)
-					couchDoc.JSONValue = addVersionAndChainCodeID(nil, ns, vv.Version)
-				}
+				couchDoc := vdb.createCouchDoc(ns, vv.Value, vv.Version)
 
 				// SaveDoc using couchdb client and use attachment to persist the binary data
 				rev, err := vdb.db.SaveDoc(string(compositeKey), "", couchDoc)
 				if err != nil {
-					logger.Errorf("Error during Commit(): %s\n", err.Error())
+					keyLogger.Errorf("Error during Commit(): %s", err)
 					return err
 				}
 				if rev != "" {
-					logger.Debugf("Saved document revision number: %s\n", rev)
+					keyLogger.Debugf("Saved document revision number: %s", rev)
 				}
 			}
 		}
@@ -283,13 +400,33 @@ func (vdb *VersionedDB) ApplyUpdates(batch *statedb.UpdateBatch, height *version
 	// Record a savepoint at a given height
 	err := vdb.recordSavepoint(height)
 	if err != nil {
-		logger.Errorf("Error during recordSavepoint: %s\n", err.Error())
+		blockLogger.Errorf("Error during recordSavepoint: %s", err)
 		return err
 	}
 
+	if vdb.indexWarmer != nil {
+		vdb.indexWarmer.notifyCommit()
+	}
 	return nil
 }
 
+// createCouchDoc creates the document that holds the given value of a key of the namespace
+func (vdb *VersionedDB) createCouchDoc(ns string, value []byte, version *version.Height) *couchdb.CouchDoc {
+	couchDoc := &couchdb.CouchDoc{}
+
+	//Check to see if the value is a valid JSON
+	//If this is not a valid JSON, then store as an attachment
+	if couchdb.IsJSON(string(value)) {
+		// Handle it as json
+		couchDoc.JSONValue = addVersionAndChainCodeID(value, ns, version)
+	} else { // if the data is not JSON, save as binary attachment in Couch
+		//Create the attachments, splitting the bytes if they exceed the chunk size
+		couchDoc.Attachments = createBinaryAttachments(value, vdb.chunkSize)
+		couchDoc.JSONValue = addVersionAndChainCodeID(nil, ns, version)
+	}
+	return couchDoc
+}
+
 //addVersionAndChainCodeID adds keys for version and chaincodeID to the JSON value
 func addVersionAndChainCodeID(value []byte, chaincodeID string, version *version.Height) []byte {
 
@@ -327,6 +464,48 @@ type couchSavepointData struct {
 	UpdateSeq string `json:"UpdateSeq"`
 }
 
+// dataFormatDocID is the id of the document that records the format of the data of the database.
+// As the id has no composite key separator, the document is not taken for a key by the scans
+const dataFormatDocID = "statedb_dataformat"
+
+// Data format document for couchdb
+type couchDataFormatData struct {
+	Format string `json:"Format"`
+}
+
+// IsEmpty returns true if no block is committed to the database
+func (vdb *VersionedDB) IsEmpty() (bool, error) {
+	savepoint, err := vdb.GetLatestSavePoint()
+	return savepoint == nil, err
+}
+
+// GetDataFormat returns the format of the data of the database, the empty string if no format is recorded.
+// Unlike the leveldb state databases, each CouchDB database records its format as the channels share no database
+func (vdb *VersionedDB) GetDataFormat() (string, error) {
+	couchDoc, _, err := vdb.db.ReadDoc(dataFormatDocID)
+	if err != nil {
+		return "", err
+	}
+	if couchDoc == nil || couchDoc.JSONValue == nil {
+		return "", nil
+	}
+	formatDoc := &couchDataFormatData{}
+	if err := json.Unmarshal(couchDoc.JSONValue, formatDoc); err != nil {
+		return "", err
+	}
+	return formatDoc.Format, nil
+}
+
+// SetDataFormat records the format of the data of the database
+func (vdb *VersionedDB) SetDataFormat(format string) error {
+	formatDocJSON, err := json.Marshal(&couchDataFormatData{Format: format})
+	if err != nil {
+		return err
+	}
+	_, err = vdb.db.SaveDoc(dataFormatDocID, "", &couchdb.CouchDoc{JSONValue: formatDocJSON, Attachments: nil})
+	return err
+}
+
 // recordSavepoint Record a savepoint in statedb.
 // Couch parallelizes writes in cluster or sharded setup and ordering is not guaranteed.
 // Hence we need to fence the savepoint with sync. So ensure_full_commit is called before AND after writing savepoint document
@@ -337,15 +516,15 @@ func (vdb *VersionedDB) recordSavepoint(height *version.Height) error {
 	// ensure full commit to flush all changes until now to disk
 	dbResponse, err := vdb.db.EnsureFullCommit()
 	if err != nil || dbResponse.Ok != true {
-		logger.Errorf("Failed to perform full commit\n")
-		return errors.New("Failed to perform full commit")
+		logger.With(flogging.Fields{"channel": vdb.dbName}).Error("Failed to perform full commit")
+		return &ledger.UnavailableError{Msg: "Failed to perform full commit"}
 	}
 
 	// construct savepoint document
 	// UpdateSeq would be useful if we want to get all db changes since a logical savepoint
 	dbInfo, _, err := vdb.db.GetDatabaseInfo()
 	if err != nil {
-		logger.Errorf("Failed to get DB info %s\n", err.Error())
+		logger.With(flogging.Fields{"channel": vdb.dbName}).Errorf("Failed to get DB info: %s", err)
 		return err
 	}
 	savepointDoc.BlockNum = height.BlockNum
@@ -354,22 +533,22 @@ func (vdb *VersionedDB) recordSavepoint(height *version.Height) error {
 
 	savepointDocJSON, err := json.Marshal(savepointDoc)
 	if err != nil {
-		logger.Errorf("Failed to create savepoint data %s\n", err.Error())
+		logger.With(flogging.Fields{"channel": vdb.dbName}).Errorf("Failed to create savepoint data: %s", err)
 		return err
 	}
 
 	// SaveDoc using couchdb client and use JSON format
 	_, err = vdb.db.SaveDoc(savepointDocID, "", &couchdb.CouchDoc{JSONValue: savepointDocJSON, Attachments: nil})
 	if err != nil {
-		logger.Errorf("Failed to save the savepoint to DB %s\n", err.Error())
+		logger.With(flogging.Fields{"channel": vdb.dbName}).Errorf("Failed to save the savepoint to DB: %s", err)
 		return err
 	}
 
 	// ensure full commit to flush savepoint to disk
 	dbResponse, err = vdb.db.EnsureFullCommit()
 	if err != nil || dbResponse.Ok != true {
-		logger.Errorf("Failed to perform full commit\n")
-		return errors.New("Failed to perform full commit")
+		logger.With(flogging.Fields{"channel": vdb.dbName}).Error("Failed to perform full commit")
+		return &ledger.UnavailableError{Msg: "Failed to perform full commit"}
 	}
 	return nil
 }
@@ -380,45 +559,186 @@ func (vdb *VersionedDB) GetLatestSavePoint() (*version.Height, error) {
 	var err error
 	couchDoc, _, err := vdb.db.ReadDoc(savepointDocID)
 	if err != nil {
-		logger.Errorf("Failed to read savepoint data %s\n", err.Error())
+		logger.With(flogging.Fields{"channel": vdb.dbName}).Errorf("Failed to read savepoint data: %s", err)
 		return &version.Height{BlockNum: 0, TxNum: 0}, err
 	}
 
-	// ReadDoc() not found (404) will result in nil response, in these cases return height 0
-	if couchDoc.JSONValue == nil {
-		return &version.Height{BlockNum: 0, TxNum: 0}, nil
+	// ReadDoc() not found (404) will result in nil response, in these cases return a nil height
+	// so that the savepoint is treated as missing (consistent with the leveldb implementation)
+	if couchDoc == nil || couchDoc.JSONValue == nil {
+		return nil, nil
 	}
 
 	savepointDoc := &couchSavepointData{}
 	err = json.Unmarshal(couchDoc.JSONValue, &savepointDoc)
 	if err != nil {
-		logger.Errorf("Failed to unmarshal savepoint data %s\n", err.Error())
+		logger.With(flogging.Fields{"channel": vdb.dbName}).Errorf("Failed to unmarshal savepoint data: %s", err)
 		return &version.Height{BlockNum: 0, TxNum: 0}, err
 	}
 
 	return &version.Height{BlockNum: savepointDoc.BlockNum, TxNum: savepointDoc.TxNum}, nil
 }
 
-func constructCompositeKey(ns string, key string) []byte {
-	compositeKey := []byte(ns)
-	compositeKey = append(compositeKey, compositeKeySep...)
-	compositeKey = append(compositeKey, []byte(key)...)
-	return compositeKey
+// GetDiskUsage implements method in VersionedDB interface. The size of the database file,
+// including the data yet to be reclaimed by a compaction, is reported by CouchDB in its database info
+func (vdb *VersionedDB) GetDiskUsage() (int64, error) {
+	dbInfo, _, err := vdb.db.GetDatabaseInfo()
+	if err != nil {
+		return 0, err
+	}
+	return dbInfo.FileSize(), nil
+}
+
+// Sync implements method in VersionedDB interface. CouchDB is asked to fsync the database file
+func (vdb *VersionedDB) Sync() error {
+	_, err := vdb.db.EnsureFullCommit()
+	return err
+}
+
+// keyEncodingDocID is the id of the document that records the progress of EncodeKeys
+const keyEncodingDocID = "statedb_keyencoding"
+
+// Key encoding progress document for couchdb
+type couchKeyEncodingData struct {
+	// IDs are the ids of the documents to move to the ids of encoded keys, in the order they are moved
+	IDs []string `json:"IDs"`
+	// Done is the number of the documents moved
+	Done int `json:"Done"`
+}
+
+// EncodeKeys moves the documents written before the keys were encoded by encodeKey to the ids of their encoded
+// keys, i.e., the documents of the keys with non-ASCII characters. The ids to move are recorded before any document
+// is moved and the progress after each document, so that an interrupted call resumes from where it stopped.
+// The longer ids are moved first, as the encoding of a key may be the id of the document of a longer key
+func (vdb *VersionedDB) EncodeKeys() error {
+	progress, err := vdb.readKeyEncodingProgress()
+	if err != nil {
+		return err
+	}
+	if progress == nil {
+		ids, err := vdb.listUnencodedKeys()
+		if err != nil {
+			return err
+		}
+		sort.SliceStable(ids, func(i, j int) bool { return len(ids[i]) > len(ids[j]) })
+		progress = &couchKeyEncodingData{IDs: ids}
+		if err := vdb.saveKeyEncodingProgress(progress); err != nil {
+			return err
+		}
+	}
+	for ; progress.Done < len(progress.IDs); progress.Done++ {
+		if err := vdb.moveToEncodedKey(progress.IDs[progress.Done]); err != nil {
+			return err
+		}
+		// the progress is saved as a document moved again would be read from the id of another key
+		if err := vdb.saveKeyEncodingProgress(&couchKeyEncodingData{IDs: progress.IDs, Done: progress.Done + 1}); err != nil {
+			return err
+		}
+	}
+	logger.With(flogging.Fields{"channel": vdb.dbName}).Infof("Encoded the keys of %d documents", len(progress.IDs))
+	return vdb.db.DeleteDoc(keyEncodingDocID, "")
+}
+
+// listUnencodedKeys returns the ids of the documents whose key has non-ASCII characters
+func (vdb *VersionedDB) listUnencodedKeys() ([]string, error) {
+	const pageSize = 1000
+	var ids []string
+	startKey := ""
+	for {
+		resp, err := vdb.db.ReadDocIDs(startKey, pageSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range resp.Rows {
+			split := strings.SplitN(row.ID, string(compositeKeySep), 2)
+			if len(split) == 2 && encodeKey(split[1]) != split[1] {
+				ids = append(ids, row.ID)
+			}
+		}
+		if len(resp.Rows) < pageSize {
+			return ids, nil
+		}
+		// the next page starts right after the last id of the page
+		startKey = resp.Rows[len(resp.Rows)-1].ID + string(compositeKeySep)
+	}
+}
+
+// moveToEncodedKey moves the document of the given id, written before the keys were encoded, to the id of its encoded key
+func (vdb *VersionedDB) moveToEncodedKey(id string) error {
+	couchDoc, _, err := vdb.db.ReadDoc(id)
+	if err != nil || couchDoc == nil {
+		// the document was moved before the interruption of a previous call
+		return err
+	}
+	split := strings.SplitN(id, string(compositeKeySep), 2)
+	value, version := removeDataWrapper(couchDoc.JSONValue, couchDoc.Attachments)
+	if _, err := vdb.db.SaveDoc(string(constructCompositeKey(split[0], split[1])), "", vdb.createCouchDoc(split[0], value, &version)); err != nil {
+		return err
+	}
+	return vdb.db.DeleteDoc(id, "")
+}
+
+func (vdb *VersionedDB) readKeyEncodingProgress() (*couchKeyEncodingData, error) {
+	couchDoc, _, err := vdb.db.ReadDoc(keyEncodingDocID)
+	if err != nil || couchDoc == nil || couchDoc.JSONValue == nil {
+		return nil, err
+	}
+	progress := &couchKeyEncodingData{}
+	if err := json.Unmarshal(couchDoc.JSONValue, progress); err != nil {
+		return nil, err
+	}
+	return progress, nil
 }
 
-func splitCompositeKey(compositeKey []byte) (string, string) {
-	split := bytes.SplitN(compositeKey, compositeKeySep, 2)
-	return string(split[0]), string(split[1])
+func (vdb *VersionedDB) saveKeyEncodingProgress(progress *couchKeyEncodingData) error {
+	progressJSON, err := json.Marshal(progress)
+	if err != nil {
+		return err
+	}
+	_, err = vdb.db.SaveDoc(keyEncodingDocID, "", &couchdb.CouchDoc{JSONValue: progressJSON, Attachments: nil})
+	return err
 }
 
+// kvScanner pages through the documents of a range of keys. A page starts after the last document of the
+// previous page, rather than skipping the documents of the previous pages, which CouchDB would have to read again
 type kvScanner struct {
-	cursor    int
+	db        *couchdb.CouchDatabase
 	namespace string
+	startKey  string
+	endKey    string
+	// remaining is the number of results left to fetch, if the scan is limited
+	remaining int
+	limited   bool
+	sizer     *fetchSizer
+	cursor    int
 	results   []couchdb.QueryResult
+	done      bool
 }
 
-func newKVScanner(namespace string, queryResults []couchdb.QueryResult) *kvScanner {
-	return &kvScanner{-1, namespace, queryResults}
+func newKVScanner(db *couchdb.CouchDatabase, namespace string, startKey string, endKey string, limit int, fetchBudget int) *kvScanner {
+	return &kvScanner{db: db, namespace: namespace, startKey: startKey, endKey: endKey,
+		remaining: limit, limited: limit > 0, sizer: newFetchSizer(fetchBudget), cursor: -1}
+}
+
+// fetchNextPage fetches the page of documents that follows the current page
+func (scanner *kvScanner) fetchNextPage() error {
+	skip := 0
+	if len(scanner.results) > 0 {
+		// the start key of the next page is the last key of the current page, which is skipped
+		scanner.startKey = scanner.results[len(scanner.results)-1].ID
+		skip = 1
+	}
+	pageSize := scanner.sizer.next(scanner.remaining)
+	queryResult, err := scanner.db.ReadDocRange(scanner.startKey, scanner.endKey, pageSize, skip)
+	if err != nil {
+		return err
+	}
+	scanner.results = *queryResult
+	scanner.cursor = -1
+	scanner.sizer.observe(scanner.results)
+	scanner.remaining -= len(scanner.results)
+	scanner.done = len(scanner.results) < pageSize || (scanner.limited && scanner.remaining <= 0)
+	return nil
 }
 
 func (scanner *kvScanner) Next() (statedb.QueryResult, error) {
@@ -426,7 +746,16 @@ func (scanner *kvScanner) Next() (statedb.QueryResult, error) {
 	scanner.cursor++
 
 	if scanner.cursor >= len(scanner.results) {
-		return nil, nil
+		if scanner.done {
+			return nil, nil
+		}
+		if err := scanner.fetchNextPage(); err != nil {
+			return nil, err
+		}
+		scanner.cursor++
+		if scanner.cursor >= len(scanner.results) {
+			return nil, nil
+		}
 	}
 
 	selectedKV := scanner.results[scanner.cursor]
@@ -442,28 +771,27 @@ func (scanner *kvScanner) Next() (statedb.QueryResult, error) {
 }
 
 func (scanner *kvScanner) Close() {
-	scanner = nil
+	scanner.results = nil
+	scanner.done = true
 }
 
+// queryScanner decodes the results of a query from the response of CouchDB as they are consumed,
+// so that the memory used by a query does not grow with the number of its results
 type queryScanner struct {
-	cursor  int
-	results []couchdb.QueryResult
+	stream *couchdb.QueryResultStream
 }
 
-func newQueryScanner(queryResults []couchdb.QueryResult) *queryScanner {
-	return &queryScanner{-1, queryResults}
+func newQueryScanner(stream *couchdb.QueryResultStream) *queryScanner {
+	return &queryScanner{stream}
 }
 
 func (scanner *queryScanner) Next() (statedb.QueryResult, error) {
 
-	scanner.cursor++
-
-	if scanner.cursor >= len(scanner.results) {
-		return nil, nil
+	selectedResultRecord, err := scanner.stream.Next()
+	if err != nil || selectedResultRecord == nil {
+		return nil, err
 	}
 
-	selectedResultRecord := scanner.results[scanner.cursor]
-
 	namespace, key := splitCompositeKey([]byte(selectedResultRecord.ID))
 
 	//remove the data wrapper and return the value and version
@@ -477,5 +805,56 @@ func (scanner *queryScanner) Next() (statedb.QueryResult, error) {
 }
 
 func (scanner *queryScanner) Close() {
-	scanner = nil
+	scanner.stream.Close()
+}
+
+// fullScanner pages through all the documents of the database and skips the documents
+// that do not correspond to a key-value (such as the savepoint document)
+type fullScanner struct {
+	db      *couchdb.CouchDatabase
+	sizer   *fetchSizer
+	skip    int
+	cursor  int
+	results []couchdb.QueryResult
+	done    bool
+}
+
+func newFullScanner(db *couchdb.CouchDatabase, fetchBudget int) *fullScanner {
+	return &fullScanner{db: db, sizer: newFetchSizer(fetchBudget), cursor: -1}
+}
+
+func (scanner *fullScanner) Next() (statedb.QueryResult, error) {
+	for {
+		scanner.cursor++
+		if scanner.cursor >= len(scanner.results) {
+			if scanner.done {
+				return nil, nil
+			}
+			pageSize := scanner.sizer.next(0)
+			queryResult, err := scanner.db.ReadDocRange("", "", pageSize, scanner.skip)
+			if err != nil {
+				logger.Debugf("Error calling ReadDocRange(): %s", err)
+				return nil, err
+			}
+			scanner.results = *queryResult
+			scanner.sizer.observe(scanner.results)
+			scanner.skip += len(scanner.results)
+			scanner.done = len(scanner.results) < pageSize
+			scanner.cursor = -1
+			continue
+		}
+		selectedKV := scanner.results[scanner.cursor]
+		if !bytes.Contains([]byte(selectedKV.ID), compositeKeySep) {
+			continue
+		}
+		namespace, key := splitCompositeKey([]byte(selectedKV.ID))
+		returnValue, returnVersion := removeDataWrapper(selectedKV.Value, selectedKV.Attachments)
+		return &statedb.VersionedKV{
+			CompositeKey:   statedb.CompositeKey{Namespace: namespace, Key: key},
+			VersionedValue: statedb.VersionedValue{Value: returnValue, Version: &returnVersion}}, nil
+	}
+}
+
+func (scanner *fullScanner) Close() {
+	scanner.results = nil
 }
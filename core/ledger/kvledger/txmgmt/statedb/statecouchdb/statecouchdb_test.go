@@ -18,7 +18,9 @@ package statecouchdb
 
 import (
 	"os"
+	"strings"
 	"testing"
+	"unicode/utf8"
 
 	"github.com/hyperledger/fabric/common/ledger/testutil"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
@@ -85,6 +87,39 @@ func TestIterator(t *testing.T) {
 	}
 }
 
+func TestFullScanIterator(t *testing.T) {
+	if ledgerconfig.IsCouchDBEnabled() == true {
+
+		env := NewTestVDBEnv(t)
+		env.Cleanup("testfullscaniterator")
+		defer env.Cleanup("testfullscaniterator")
+		commontests.TestFullScanIterator(t, env.DBProvider)
+
+	}
+}
+
+func TestListNamespaces(t *testing.T) {
+	if ledgerconfig.IsCouchDBEnabled() == true {
+
+		env := NewTestVDBEnv(t)
+		env.Cleanup("testlistnamespaces")
+		defer env.Cleanup("testlistnamespaces")
+		commontests.TestListNamespaces(t, env.DBProvider)
+
+	}
+}
+
+func TestRangeScanOrder(t *testing.T) {
+	if ledgerconfig.IsCouchDBEnabled() == true {
+
+		env := NewTestVDBEnv(t)
+		env.Cleanup("testrangescanorder")
+		defer env.Cleanup("testrangescanorder")
+		commontests.TestRangeScanOrder(t, env.DBProvider)
+
+	}
+}
+
 func TestEncodeDecodeValueAndVersion(t *testing.T) {
 	testValueAndVersionEncoding(t, []byte("value1"), version.NewHeight(1, 2))
 	testValueAndVersionEncoding(t, []byte{}, version.NewHeight(50, 50))
@@ -102,6 +137,12 @@ func TestCompositeKey(t *testing.T) {
 
 		testCompositeKey(t, "ns", "key")
 		testCompositeKey(t, "ns", "")
+		testCompositeKey(t, "ns", "k\u00e9y\x00")
+		testCompositeKey(t, "ns", "k\xffy")
+		testCompositeKey(t, "ns", "_key\x01\x1f\x7f")
+		testCompositeKey(t, "_ns", "key")
+		testCompositeKey(t, "\x01ns", "key")
+		testCompositeKey(t, "\x01_ns", "key")
 
 	}
 }
@@ -109,11 +150,37 @@ func TestCompositeKey(t *testing.T) {
 func testCompositeKey(t *testing.T, ns string, key string) {
 	compositeKey := constructCompositeKey(ns, key)
 	t.Logf("compositeKey=%#v", compositeKey)
+	testutil.AssertEquals(t, utf8.Valid(compositeKey), true)
+	testutil.AssertEquals(t, strings.HasPrefix(string(compositeKey), "_"), false)
 	ns1, key1 := splitCompositeKey(compositeKey)
 	testutil.AssertEquals(t, ns1, ns)
 	testutil.AssertEquals(t, key1, key)
 }
 
+func TestKeyEncoding(t *testing.T) {
+	keys := []string{"", "key", "key\x00", "key\x7f", "k\u00e9y", "k\u0100y", "k\U0001f600y", "k\xc3y", "k\xffy"}
+	for i, key := range keys {
+		encodedKey := encodeKey(key)
+		testutil.AssertEquals(t, utf8.ValidString(encodedKey), true)
+		testutil.AssertEquals(t, decodeKey(encodedKey), key)
+		for _, otherKey := range keys[i+1:] {
+			// the encoded keys compare as the keys
+			testutil.AssertEquals(t, strings.Compare(encodedKey, encodeKey(otherKey)), strings.Compare(key, otherKey))
+		}
+	}
+	testutil.AssertEquals(t, encodeKey("key\x00attr\x00"), "key\x00attr\x00")
+}
+
+func TestDocIDs(t *testing.T) {
+	// the ids of the keys of the namespaces that start with an underscore do not start with an underscore
+	testutil.AssertEquals(t, string(constructCompositeKey("_ns", "key")), "\x01_ns\x00key")
+	testutil.AssertEquals(t, string(constructCompositeKey("ns", "_key")), "ns\x00_key")
+	// the escaped namespaces do not collide
+	testutil.AssertEquals(t, string(constructCompositeKey("\x01_ns", "key")), "\x01\x01_ns\x00key")
+	// the ids of the keys of a namespace are ordered as the keys
+	testutil.AssertEquals(t, string(constructCompositeKey("_ns", "key1")) < string(constructCompositeKey("_ns", "key\xff")), true)
+}
+
 // The following tests are unique to couchdb, they are not used in leveldb
 //  query test
 func TestQuery(t *testing.T) {
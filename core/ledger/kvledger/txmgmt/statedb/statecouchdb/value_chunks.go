@@ -0,0 +1,79 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statecouchdb
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric/core/ledger/util/couchdb"
+)
+
+// binaryChunkPrefix prefixes the names of the attachments that hold the chunks of a split binary value.
+// The index in the name is padded so that the names sort in the order of the chunks
+var binaryChunkPrefix = binaryWrapper + "_"
+
+// createBinaryAttachments returns the attachments that hold the binary value. The value is split into
+// attachments of the given size if it is larger, and is held by a single attachment otherwise
+func createBinaryAttachments(value []byte, chunkSize int) []couchdb.Attachment {
+	if chunkSize <= 0 || len(value) <= chunkSize {
+		return []couchdb.Attachment{{Name: binaryWrapper, ContentType: "application/octet-stream", AttachmentBytes: value}}
+	}
+	var attachments []couchdb.Attachment
+	for start := 0; start < len(value); start += chunkSize {
+		end := start + chunkSize
+		if end > len(value) {
+			end = len(value)
+		}
+		attachments = append(attachments, couchdb.Attachment{
+			Name:            fmt.Sprintf("%s%08d", binaryChunkPrefix, len(attachments)),
+			ContentType:     "application/octet-stream",
+			AttachmentBytes: value[start:end],
+		})
+	}
+	return attachments
+}
+
+// joinBinaryAttachments returns the binary value held by the attachments, reassembled from its chunks if it is split.
+// false is returned if the attachments do not hold a binary value
+func joinBinaryAttachments(attachments []couchdb.Attachment) ([]byte, bool) {
+	var chunks []couchdb.Attachment
+	for _, attachment := range attachments {
+		if attachment.Name == binaryWrapper {
+			return attachment.AttachmentBytes, true
+		}
+		if strings.HasPrefix(attachment.Name, binaryChunkPrefix) {
+			chunks = append(chunks, attachment)
+		}
+	}
+	if len(chunks) == 0 {
+		return nil, false
+	}
+	sort.Sort(attachmentsByName(chunks))
+	var value []byte
+	for _, chunk := range chunks {
+		value = append(value, chunk.AttachmentBytes...)
+	}
+	return value, true
+}
+
+type attachmentsByName []couchdb.Attachment
+
+func (a attachmentsByName) Len() int           { return len(a) }
+func (a attachmentsByName) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a attachmentsByName) Less(i, j int) bool { return a[i].Name < a[j].Name }
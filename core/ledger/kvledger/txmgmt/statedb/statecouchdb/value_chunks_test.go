@@ -0,0 +1,49 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statecouchdb
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger/util/couchdb"
+)
+
+func TestBinaryAttachments(t *testing.T) {
+	value := []byte("0123456789")
+
+	// a value that does not exceed the chunk size is held by a single attachment
+	attachments := createBinaryAttachments(value, 0)
+	testutil.AssertEquals(t, len(attachments), 1)
+	testutil.AssertEquals(t, attachments[0].Name, binaryWrapper)
+	attachments = createBinaryAttachments(value, 10)
+	testutil.AssertEquals(t, len(attachments), 1)
+
+	attachments = createBinaryAttachments(value, 4)
+	testutil.AssertEquals(t, len(attachments), 3)
+	testutil.AssertEquals(t, attachments[0].Name, "valueBytes_00000000")
+	testutil.AssertEquals(t, attachments[2].AttachmentBytes, []byte("89"))
+
+	// the chunks are reassembled in the order of their names
+	attachments[0], attachments[2] = attachments[2], attachments[0]
+	joinedValue, ok := joinBinaryAttachments(attachments)
+	testutil.AssertEquals(t, ok, true)
+	testutil.AssertEquals(t, joinedValue, value)
+
+	_, ok = joinBinaryAttachments([]couchdb.Attachment{{Name: "other"}})
+	testutil.AssertEquals(t, ok, false)
+}
@@ -27,6 +27,8 @@ import (
 type VersionedDBProvider interface {
 	// GetDBHandle returns a handle to a VersionedDB
 	GetDBHandle(id string) (VersionedDB, error)
+	// Drop removes all the data of the VersionedDB with the given id
+	Drop(id string) error
 	// Close closes all the VersionedDB instances and releases any resources held by VersionedDBProvider
 	Close()
 }
@@ -42,6 +44,11 @@ type VersionedDB interface {
 	// endKey is exclusive
 	// The returned ResultsIterator contains results of type *VersionedKV
 	GetStateRangeScanIterator(namespace string, startKey string, endKey string) (ResultsIterator, error)
+	// GetFullScanIterator returns an iterator that contains all the key-values in the db across all the namespaces.
+	// The returned ResultsIterator contains results of type *VersionedKV
+	GetFullScanIterator() (ResultsIterator, error)
+	// ListNamespaces returns the number of the keys of each namespace that has keys in the db
+	ListNamespaces() (map[string]uint64, error)
 	// ExecuteQuery executes the given query and returns an iterator that contains results of type *VersionedKV.
 	ExecuteQuery(namespace, query string) (ResultsIterator, error)
 	// ApplyUpdates applies the batch to the underlying db.
@@ -51,12 +58,32 @@ type VersionedDB interface {
 	// GetLatestSavePoint returns the height of the highest transaction upto which
 	// the state db is consistent
 	GetLatestSavePoint() (*version.Height, error)
+	// GetDiskUsage returns the number of bytes that the db occupies on the disk
+	GetDiskUsage() (int64, error)
+	// Sync flushes the updates applied so far to the disk
+	Sync() error
 	// Open opens the db
 	Open() error
 	// Close closes the db
 	Close()
 }
 
+// FieldsQueryExecutor is implemented by the VersionedDBs that project the fields of the query results natively.
+// For the other VersionedDBs, the fields are projected from the values of the results via ProjectFields
+type FieldsQueryExecutor interface {
+	// ExecuteQueryWithFields executes the given query and returns an iterator that contains results of
+	// type *VersionedQueryRecord, whose records only contain the given fields of the JSON documents
+	ExecuteQueryWithFields(namespace, query string, fields []string) (ResultsIterator, error)
+}
+
+// RangeScanLimiter is implemented by the VersionedDBs that fetch the results of a range scan eagerly, so that
+// a paginated range scan only fetches the results of the page
+type RangeScanLimiter interface {
+	// GetStateRangeScanIteratorWithLimit returns an iterator like GetStateRangeScanIterator that contains
+	// at most limit results
+	GetStateRangeScanIteratorWithLimit(namespace string, startKey string, endKey string, limit int32) (ResultsIterator, error)
+}
+
 // CompositeKey encloses Namespace and Key components
 type CompositeKey struct {
 	Namespace string
@@ -18,9 +18,9 @@ package stateleveldb
 
 import (
 	"bytes"
-	"errors"
 
 	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
+	"github.com/hyperledger/fabric/core/ledger"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
 	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
@@ -43,7 +43,24 @@ type VersionedDBProvider struct {
 func NewVersionedDBProvider() *VersionedDBProvider {
 	dbPath := ledgerconfig.GetStateLevelDBPath()
 	logger.Debugf("constructing VersionedDBProvider dbPath=%s", dbPath)
-	dbProvider := leveldbhelper.NewProvider(&leveldbhelper.Conf{DBPath: dbPath})
+	dbProvider := leveldbhelper.NewProvider(&leveldbhelper.Conf{DBPath: dbPath, Tuning: ledgerconfig.GetStateLevelDBTuning()})
+	return &VersionedDBProvider{dbProvider}
+}
+
+// NewReadOnlyVersionedDBProvider instantiates VersionedDBProvider that opens the existing databases in read-only mode
+func NewReadOnlyVersionedDBProvider() *VersionedDBProvider {
+	dbPath := ledgerconfig.GetStateLevelDBPath()
+	logger.Debugf("constructing read-only VersionedDBProvider dbPath=%s", dbPath)
+	dbProvider := leveldbhelper.NewProvider(&leveldbhelper.Conf{DBPath: dbPath, ReadOnly: true, Tuning: ledgerconfig.GetStateLevelDBTuning()})
+	return &VersionedDBProvider{dbProvider}
+}
+
+// NewSystemVersionedDBProvider instantiates VersionedDBProvider on the level db that keeps the system namespaces
+// of the state apart from the data of the chaincodes. The existing databases are opened in read-only mode if readOnly is true
+func NewSystemVersionedDBProvider(readOnly bool) *VersionedDBProvider {
+	dbPath := ledgerconfig.GetSystemStateLevelDBPath()
+	logger.Debugf("constructing system VersionedDBProvider dbPath=%s", dbPath)
+	dbProvider := leveldbhelper.NewProvider(&leveldbhelper.Conf{DBPath: dbPath, ReadOnly: readOnly, Tuning: ledgerconfig.GetSystemStateLevelDBTuning()})
 	return &VersionedDBProvider{dbProvider}
 }
 
@@ -52,6 +69,27 @@ func (provider *VersionedDBProvider) GetDBHandle(dbName string) (statedb.Version
 	return newVersionedDB(provider.dbProvider.GetDBHandle(dbName), dbName), nil
 }
 
+// Drop removes all the keys of the named database
+func (provider *VersionedDBProvider) Drop(dbName string) error {
+	return provider.dbProvider.GetDBHandle(dbName).DeleteAll()
+}
+
+// IsEmpty returns true if none of the state databases holds data
+func (provider *VersionedDBProvider) IsEmpty() (bool, error) {
+	dbNames, err := provider.dbProvider.ListDBNames()
+	return len(dbNames) == 0, err
+}
+
+// GetDataFormat returns the format of the data of the state databases, the empty string if no format is recorded
+func (provider *VersionedDBProvider) GetDataFormat() (string, error) {
+	return provider.dbProvider.GetDataFormat()
+}
+
+// SetDataFormat records the format of the data of the state databases
+func (provider *VersionedDBProvider) SetDataFormat(format string) error {
+	return provider.dbProvider.SetDataFormat(format)
+}
+
 // Close closes the underlying db
 func (provider *VersionedDBProvider) Close() {
 	provider.dbProvider.Close()
@@ -61,11 +99,23 @@ func (provider *VersionedDBProvider) Close() {
 type versionedDB struct {
 	db     *leveldbhelper.DBHandle
 	dbName string
+	// chunkSize is the size above which the values are split into chunks, 0 if the values are not split
+	chunkSize int
+	// hasChunks is true if the database may contain a split value. The reads of the empty values
+	// and the updates look up the chunks only if this is true
+	hasChunks bool
+	// dedupMinSize is the size from which the values are stored once under the hash of their content,
+	// 0 if the values are not deduplicated
+	dedupMinSize int
+	// hasDedup is true if the database may contain a deduplicated value, like hasChunks
+	hasDedup bool
 }
 
 // newVersionedDB constructs an instance of VersionedDB
 func newVersionedDB(db *leveldbhelper.DBHandle, dbName string) *versionedDB {
-	return &versionedDB{db, dbName}
+	return &versionedDB{db: db, dbName: dbName,
+		chunkSize: ledgerconfig.GetStateValueChunkSize(), hasChunks: hasChunkedValues(db),
+		dedupMinSize: ledgerconfig.GetStateDedupMinValueSize(), hasDedup: hasDedupValues(db)}
 }
 
 // Open implements method in VersionedDB interface
@@ -91,6 +141,9 @@ func (vdb *versionedDB) GetState(namespace string, key string) (*statedb.Version
 		return nil, nil
 	}
 	val, ver := statedb.DecodeValue(dbVal)
+	if val, err = vdb.readValue(namespace, key, val); err != nil {
+		return nil, err
+	}
 	return &statedb.VersionedValue{Value: val, Version: ver}, nil
 }
 
@@ -117,17 +170,43 @@ func (vdb *versionedDB) GetStateRangeScanIterator(namespace string, startKey str
 		compositeEndKey[len(compositeEndKey)-1] = lastKeyIndicator
 	}
 	dbItr := vdb.db.GetIterator(compositeStartKey, compositeEndKey)
-	return newKVScanner(namespace, dbItr), nil
+	return newKVScanner(vdb, namespace, dbItr), nil
+}
+
+// GetFullScanIterator implements method in VersionedDB interface
+func (vdb *versionedDB) GetFullScanIterator() (statedb.ResultsIterator, error) {
+	dbItr := vdb.db.GetIterator(nil, nil)
+	return newFullScanner(vdb, dbItr), nil
+}
+
+// ListNamespaces implements method in VersionedDB interface.
+// Only the keys are read, the chunks of the split values and the deduplicated values being skipped as they
+// sort before the composite keys
+func (vdb *versionedDB) ListNamespaces() (map[string]uint64, error) {
+	dbItr := vdb.db.GetIterator(dedupKeysEnd, nil)
+	defer dbItr.Release()
+	counts := make(map[string]uint64)
+	for dbItr.Next() {
+		namespace, _ := splitCompositeKey(dbItr.Key())
+		counts[namespace]++
+	}
+	if err := dbItr.Error(); err != nil {
+		return nil, err
+	}
+	return counts, nil
 }
 
 // ExecuteQuery implements method in VersionedDB interface
 func (vdb *versionedDB) ExecuteQuery(namespace, query string) (statedb.ResultsIterator, error) {
-	return nil, errors.New("ExecuteQuery not supported for leveldb")
+	return nil, &ledger.NotEnabledError{Msg: "ExecuteQuery not supported for leveldb"}
 }
 
 // ApplyUpdates implements method in VersionedDB interface
 func (vdb *versionedDB) ApplyUpdates(batch *statedb.UpdateBatch, height *version.Height) error {
 	dbBatch := leveldbhelper.NewUpdateBatch()
+	chunksWritten := false
+	dedupWritten := false
+	dedup := newDedupUpdates()
 	namespaces := batch.GetUpdatedNamespaces()
 	for _, ns := range namespaces {
 		updates := batch.GetUpdates(ns)
@@ -135,17 +214,44 @@ func (vdb *versionedDB) ApplyUpdates(batch *statedb.UpdateBatch, height *version
 			compositeKey := constructCompositeKey(ns, k)
 			logger.Debugf("Channel [%s]: Applying key=[%#v]", vdb.dbName, compositeKey)
 
+			if vdb.hasChunks {
+				if err := vdb.deleteChunks(dbBatch, ns, k); err != nil {
+					return err
+				}
+			}
+			if vdb.hasDedup {
+				if err := vdb.deleteDedupRef(dbBatch, dedup, ns, k); err != nil {
+					return err
+				}
+			}
 			if vv.Value == nil {
 				dbBatch.Delete(compositeKey)
+			} else if vdb.dedupMinSize > 0 && len(vv.Value) >= vdb.dedupMinSize {
+				if err := putDedupValue(dbBatch, dedup, ns, k, vv); err != nil {
+					return err
+				}
+				dedupWritten = true
+			} else if vdb.chunkSize > 0 && len(vv.Value) > vdb.chunkSize {
+				putChunkedValue(dbBatch, ns, k, vv, vdb.chunkSize)
+				chunksWritten = true
 			} else {
 				dbBatch.Put(compositeKey, statedb.EncodeValue(vv.Value, vv.Version))
 			}
 		}
 	}
+	if err := vdb.applyDedupUpdates(dbBatch, dedup); err != nil {
+		return err
+	}
 	dbBatch.Put(savePointKey, height.ToBytes())
 	if err := vdb.db.WriteBatch(dbBatch, false); err != nil {
 		return err
 	}
+	if chunksWritten {
+		vdb.hasChunks = true
+	}
+	if dedupWritten {
+		vdb.hasDedup = true
+	}
 	return nil
 }
 
@@ -162,6 +268,26 @@ func (vdb *versionedDB) GetLatestSavePoint() (*version.Height, error) {
 	return version, nil
 }
 
+// GetDiskUsage implements method in VersionedDB interface. The size is approximated as the
+// state dbs of all the channels are stored in a single leveldb
+func (vdb *versionedDB) GetDiskUsage() (int64, error) {
+	return vdb.db.ApproximateSize()
+}
+
+// Sync implements method in VersionedDB interface
+func (vdb *versionedDB) Sync() error {
+	return vdb.db.Sync()
+}
+
+// readValue returns the value of the given key, resolving the deduplicated and the split values
+func (vdb *versionedDB) readValue(ns string, key string, storedValue []byte) ([]byte, error) {
+	value, deduplicated, err := vdb.readDedupValue(ns, key, storedValue)
+	if err != nil || deduplicated {
+		return value, err
+	}
+	return vdb.readChunkedValue(ns, key, storedValue)
+}
+
 func constructCompositeKey(ns string, key string) []byte {
 	return append(append([]byte(ns), compositeKeySep...), []byte(key)...)
 }
@@ -172,12 +298,13 @@ func splitCompositeKey(compositeKey []byte) (string, string) {
 }
 
 type kvScanner struct {
+	vdb       *versionedDB
 	namespace string
 	dbItr     iterator.Iterator
 }
 
-func newKVScanner(namespace string, dbItr iterator.Iterator) *kvScanner {
-	return &kvScanner{namespace, dbItr}
+func newKVScanner(vdb *versionedDB, namespace string, dbItr iterator.Iterator) *kvScanner {
+	return &kvScanner{vdb, namespace, dbItr}
 }
 
 func (scanner *kvScanner) Next() (statedb.QueryResult, error) {
@@ -190,6 +317,10 @@ func (scanner *kvScanner) Next() (statedb.QueryResult, error) {
 	copy(dbValCopy, dbVal)
 	_, key := splitCompositeKey(dbKey)
 	value, version := statedb.DecodeValue(dbValCopy)
+	value, err := scanner.vdb.readValue(scanner.namespace, key, value)
+	if err != nil {
+		return nil, err
+	}
 	return &statedb.VersionedKV{
 		CompositeKey:   statedb.CompositeKey{Namespace: scanner.namespace, Key: key},
 		VersionedValue: statedb.VersionedValue{Value: value, Version: version}}, nil
@@ -198,3 +329,38 @@ func (scanner *kvScanner) Next() (statedb.QueryResult, error) {
 func (scanner *kvScanner) Close() {
 	scanner.dbItr.Release()
 }
+
+type fullScanner struct {
+	vdb   *versionedDB
+	dbItr iterator.Iterator
+}
+
+func newFullScanner(vdb *versionedDB, dbItr iterator.Iterator) *fullScanner {
+	return &fullScanner{vdb, dbItr}
+}
+
+func (scanner *fullScanner) Next() (statedb.QueryResult, error) {
+	for scanner.dbItr.Next() {
+		dbKey := scanner.dbItr.Key()
+		if bytes.Equal(dbKey, savePointKey) || bytes.HasPrefix(dbKey, chunkKeyPrefix) || bytes.HasPrefix(dbKey, dedupKeyPrefix) {
+			continue
+		}
+		dbVal := scanner.dbItr.Value()
+		dbValCopy := make([]byte, len(dbVal))
+		copy(dbValCopy, dbVal)
+		namespace, key := splitCompositeKey(dbKey)
+		value, version := statedb.DecodeValue(dbValCopy)
+		value, err := scanner.vdb.readValue(namespace, key, value)
+		if err != nil {
+			return nil, err
+		}
+		return &statedb.VersionedKV{
+			CompositeKey:   statedb.CompositeKey{Namespace: namespace, Key: key},
+			VersionedValue: statedb.VersionedValue{Value: value, Version: version}}, nil
+	}
+	return nil, nil
+}
+
+func (scanner *fullScanner) Close() {
+	scanner.dbItr.Release()
+}
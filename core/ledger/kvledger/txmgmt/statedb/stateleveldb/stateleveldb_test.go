@@ -56,6 +56,24 @@ func TestIterator(t *testing.T) {
 	commontests.TestIterator(t, env.DBProvider)
 }
 
+func TestFullScanIterator(t *testing.T) {
+	env := NewTestVDBEnv(t)
+	defer env.Cleanup()
+	commontests.TestFullScanIterator(t, env.DBProvider)
+}
+
+func TestListNamespaces(t *testing.T) {
+	env := NewTestVDBEnv(t)
+	defer env.Cleanup()
+	commontests.TestListNamespaces(t, env.DBProvider)
+}
+
+func TestRangeScanOrder(t *testing.T) {
+	env := NewTestVDBEnv(t)
+	defer env.Cleanup()
+	commontests.TestRangeScanOrder(t, env.DBProvider)
+}
+
 func TestEncodeDecodeValueAndVersion(t *testing.T) {
 	testValueAndVersionEncodeing(t, []byte("value1"), version.NewHeight(1, 2))
 	testValueAndVersionEncodeing(t, []byte{}, version.NewHeight(50, 50))
@@ -80,3 +98,126 @@ func testCompositeKey(t *testing.T, dbName string, ns string, key string) {
 	testutil.AssertEquals(t, ns1, ns)
 	testutil.AssertEquals(t, key1, key)
 }
+
+func TestValueChunks(t *testing.T) {
+	env := NewTestVDBEnv(t)
+	defer env.Cleanup()
+	viper.Set("ledger.state.valueChunkSize", 4)
+	defer viper.Set("ledger.state.valueChunkSize", 0)
+	db, err := env.DBProvider.GetDBHandle("testvaluechunks")
+	testutil.AssertNoError(t, err, "")
+
+	batch := statedb.NewUpdateBatch()
+	batch.Put("ns1", "key1", []byte("0123456789"), version.NewHeight(1, 1))
+	batch.Put("ns1", "key2", []byte("abc"), version.NewHeight(1, 2))
+	batch.Put("ns1", "key3", []byte{}, version.NewHeight(1, 3))
+	testutil.AssertNoError(t, db.ApplyUpdates(batch, version.NewHeight(1, 3)), "")
+	testutil.AssertEquals(t, hasChunkedValues(db.(*versionedDB).db), true)
+
+	vv, _ := db.GetState("ns1", "key1")
+	testutil.AssertEquals(t, vv, &statedb.VersionedValue{Value: []byte("0123456789"), Version: version.NewHeight(1, 1)})
+	vv, _ = db.GetState("ns1", "key3")
+	testutil.AssertEquals(t, vv, &statedb.VersionedValue{Value: []byte{}, Version: version.NewHeight(1, 3)})
+
+	// the scans return the reassembled values and not the chunks
+	expectedValues := map[string][]byte{"key1": []byte("0123456789"), "key2": []byte("abc"), "key3": {}}
+	itr, _ := db.GetStateRangeScanIterator("ns1", "", "")
+	testutil.AssertEquals(t, collectValues(t, itr), expectedValues)
+	itr, _ = db.GetFullScanIterator()
+	testutil.AssertEquals(t, collectValues(t, itr), expectedValues)
+
+	// the chunks are removed when the value is overwritten with a smaller value or deleted
+	batch = statedb.NewUpdateBatch()
+	batch.Put("ns1", "key1", []byte("xyz"), version.NewHeight(2, 1))
+	batch.Put("ns1", "key4", []byte("0123456789"), version.NewHeight(2, 2))
+	testutil.AssertNoError(t, db.ApplyUpdates(batch, version.NewHeight(2, 2)), "")
+	vv, _ = db.GetState("ns1", "key1")
+	testutil.AssertEquals(t, vv.Value, []byte("xyz"))
+	batch = statedb.NewUpdateBatch()
+	batch.Delete("ns1", "key4", version.NewHeight(3, 1))
+	testutil.AssertNoError(t, db.ApplyUpdates(batch, version.NewHeight(3, 1)), "")
+	vv, _ = db.GetState("ns1", "key4")
+	testutil.AssertNil(t, vv)
+	testutil.AssertEquals(t, hasChunkedValues(db.(*versionedDB).db), false)
+}
+
+func TestValueDedup(t *testing.T) {
+	env := NewTestVDBEnv(t)
+	defer env.Cleanup()
+	viper.Set("ledger.state.dedupMinValueSize", 8)
+	defer viper.Set("ledger.state.dedupMinValueSize", 0)
+	db, err := env.DBProvider.GetDBHandle("testvaluededup")
+	testutil.AssertNoError(t, err, "")
+	vdb := db.(*versionedDB)
+
+	template := []byte("a template document")
+	batch := statedb.NewUpdateBatch()
+	batch.Put("ns1", "key1", template, version.NewHeight(1, 1))
+	batch.Put("ns1", "key2", template, version.NewHeight(1, 2))
+	batch.Put("ns2", "key1", template, version.NewHeight(1, 3))
+	batch.Put("ns2", "key2", []byte("small"), version.NewHeight(1, 4))
+	testutil.AssertNoError(t, db.ApplyUpdates(batch, version.NewHeight(1, 4)), "")
+	testutil.AssertEquals(t, hasDedupValues(vdb.db), true)
+	testutil.AssertEquals(t, countDedupContents(vdb), 1)
+
+	vv, _ := db.GetState("ns2", "key1")
+	testutil.AssertEquals(t, vv, &statedb.VersionedValue{Value: template, Version: version.NewHeight(1, 3)})
+	vv, _ = db.GetState("ns2", "key2")
+	testutil.AssertEquals(t, vv.Value, []byte("small"))
+
+	// the scans and the namespace counts resolve the references and skip the contents
+	itr, _ := db.GetStateRangeScanIterator("ns1", "", "")
+	testutil.AssertEquals(t, collectValues(t, itr), map[string][]byte{"key1": template, "key2": template})
+	itr, _ = db.GetFullScanIterator()
+	testutil.AssertEquals(t, len(collectValues(t, itr)), 2)
+	counts, err := db.ListNamespaces()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, counts, map[string]uint64{"ns1": 2, "ns2": 2})
+
+	// the content remains while a key refers to it
+	batch = statedb.NewUpdateBatch()
+	batch.Put("ns1", "key1", []byte("another document"), version.NewHeight(2, 1))
+	batch.Delete("ns1", "key2", version.NewHeight(2, 2))
+	testutil.AssertNoError(t, db.ApplyUpdates(batch, version.NewHeight(2, 2)), "")
+	testutil.AssertEquals(t, countDedupContents(vdb), 2)
+	vv, _ = db.GetState("ns2", "key1")
+	testutil.AssertEquals(t, vv.Value, template)
+	vv, _ = db.GetState("ns1", "key1")
+	testutil.AssertEquals(t, vv.Value, []byte("another document"))
+
+	// and is removed with its last reference
+	batch = statedb.NewUpdateBatch()
+	batch.Put("ns2", "key1", []byte("small"), version.NewHeight(3, 1))
+	testutil.AssertNoError(t, db.ApplyUpdates(batch, version.NewHeight(3, 1)), "")
+	testutil.AssertEquals(t, countDedupContents(vdb), 1)
+	vv, _ = db.GetState("ns2", "key1")
+	testutil.AssertEquals(t, vv.Value, []byte("small"))
+	batch = statedb.NewUpdateBatch()
+	batch.Delete("ns1", "key1", version.NewHeight(4, 1))
+	testutil.AssertNoError(t, db.ApplyUpdates(batch, version.NewHeight(4, 1)), "")
+	testutil.AssertEquals(t, hasDedupValues(vdb.db), false)
+}
+
+func countDedupContents(vdb *versionedDB) int {
+	itr := vdb.db.GetIterator(dedupContentKeyPrefix, dedupCountKeyPrefix)
+	defer itr.Release()
+	count := 0
+	for itr.Next() {
+		count++
+	}
+	return count
+}
+
+func collectValues(t *testing.T, itr statedb.ResultsIterator) map[string][]byte {
+	defer itr.Close()
+	values := make(map[string][]byte)
+	for {
+		queryResult, err := itr.Next()
+		testutil.AssertNoError(t, err, "")
+		if queryResult == nil {
+			return values
+		}
+		kv := queryResult.(*statedb.VersionedKV)
+		values[kv.Key] = kv.Value
+	}
+}
@@ -0,0 +1,116 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stateleveldb
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
+)
+
+// chunkKeyPrefix prefixes the keys of the chunks of the values split by ApplyUpdates. The prefix sorts before
+// the namespaces, which begin with a printable character, so that the range scans do not return the chunks.
+// The key of a split value holds only the version, and the chunk 0 holds the number of the chunks that follow it
+var chunkKeyPrefix = []byte{0x01}
+var chunkKeysEnd = []byte{0x02}
+
+// constructChunkKey returns the key of the chunk with the given index of the value of the given key. The index is
+// encoded with a fixed width so that the chunk keys of a key cannot collide with the chunk keys of a longer key
+func constructChunkKey(ns string, key string, index uint32) []byte {
+	chunkKey := append(append(append([]byte{}, chunkKeyPrefix...), constructCompositeKey(ns, key)...), compositeKeySep...)
+	indexBytes := make([]byte, 4)
+	binary.BigEndian.PutUint32(indexBytes, index)
+	return append(chunkKey, indexBytes...)
+}
+
+// hasChunkedValues returns true if the database contains a split value
+func hasChunkedValues(db *leveldbhelper.DBHandle) bool {
+	itr := db.GetIterator(chunkKeyPrefix, chunkKeysEnd)
+	defer itr.Release()
+	return itr.Next()
+}
+
+// putChunkedValue adds to the batch the value split into chunks of the given size
+func putChunkedValue(dbBatch *leveldbhelper.UpdateBatch, ns string, key string, vv *statedb.VersionedValue, chunkSize int) {
+	var numChunks uint32
+	for start := 0; start < len(vv.Value); start += chunkSize {
+		end := start + chunkSize
+		if end > len(vv.Value) {
+			end = len(vv.Value)
+		}
+		numChunks++
+		dbBatch.Put(constructChunkKey(ns, key, numChunks), vv.Value[start:end])
+	}
+	numChunksBytes := make([]byte, 4)
+	binary.BigEndian.PutUint32(numChunksBytes, numChunks)
+	dbBatch.Put(constructChunkKey(ns, key, 0), numChunksBytes)
+	dbBatch.Put(constructCompositeKey(ns, key), statedb.EncodeValue(nil, vv.Version))
+}
+
+// getNumChunks returns the number of the chunks of the split value of the given key, 0 if the value is not split
+func (vdb *versionedDB) getNumChunks(ns string, key string) (uint32, error) {
+	numChunksBytes, err := vdb.db.Get(constructChunkKey(ns, key, 0))
+	if err != nil || numChunksBytes == nil {
+		return 0, err
+	}
+	if len(numChunksBytes) != 4 {
+		return 0, &ledger.CorruptionError{Msg: fmt.Sprintf("Invalid number of chunks of the value of key [%s] of namespace [%s]", key, ns)}
+	}
+	return binary.BigEndian.Uint32(numChunksBytes), nil
+}
+
+// readChunkedValue reassembles the value of the given key if it is split. The value stored in the key, which is empty
+// for a split value, is returned unchanged if the value is not split
+func (vdb *versionedDB) readChunkedValue(ns string, key string, storedValue []byte) ([]byte, error) {
+	if len(storedValue) != 0 || !vdb.hasChunks {
+		return storedValue, nil
+	}
+	numChunks, err := vdb.getNumChunks(ns, key)
+	if err != nil || numChunks == 0 {
+		return storedValue, err
+	}
+	var value []byte
+	for index := uint32(1); index <= numChunks; index++ {
+		chunk, err := vdb.db.Get(constructChunkKey(ns, key, index))
+		if err != nil {
+			return nil, err
+		}
+		if chunk == nil {
+			return nil, &ledger.CorruptionError{Msg: fmt.Sprintf("Chunk [%d] of the value of key [%s] of namespace [%s] is missing", index, key, ns)}
+		}
+		value = append(value, chunk...)
+	}
+	return value, nil
+}
+
+// deleteChunks adds to the batch the deletes of the chunks of the value of the given key, if it is split
+func (vdb *versionedDB) deleteChunks(dbBatch *leveldbhelper.UpdateBatch, ns string, key string) error {
+	numChunks, err := vdb.getNumChunks(ns, key)
+	if err != nil {
+		return err
+	}
+	if numChunks == 0 {
+		return nil
+	}
+	for index := uint32(0); index <= numChunks; index++ {
+		dbBatch.Delete(constructChunkKey(ns, key, index))
+	}
+	return nil
+}
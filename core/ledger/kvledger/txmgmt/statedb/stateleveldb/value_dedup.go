@@ -0,0 +1,154 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stateleveldb
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/hyperledger/fabric/bccsp"
+	"github.com/hyperledger/fabric/bccsp/factory"
+	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
+)
+
+// The deduplicated values are stored once under the hash of their content, with the number of the keys that
+// refer to them, and the key of a deduplicated value holds only the version, like the key of a split value.
+// The prefixes sort after the chunks and before the namespaces, so that the range scans do not return them
+var dedupKeyPrefix = []byte{0x02}
+var dedupRefKeyPrefix = []byte{0x02, 'r'}
+var dedupContentKeyPrefix = []byte{0x02, 'c'}
+var dedupCountKeyPrefix = []byte{0x02, 'n'}
+var dedupKeysEnd = []byte{0x03}
+
+var dedupHashOpts = &bccsp.SHA256Opts{}
+
+// constructDedupRefKey returns the key that holds the hash of the deduplicated value of the given key
+func constructDedupRefKey(ns string, key string) []byte {
+	return append(append([]byte{}, dedupRefKeyPrefix...), constructCompositeKey(ns, key)...)
+}
+
+func constructDedupContentKey(hash []byte) []byte {
+	return append(append([]byte{}, dedupContentKeyPrefix...), hash...)
+}
+
+func constructDedupCountKey(hash []byte) []byte {
+	return append(append([]byte{}, dedupCountKeyPrefix...), hash...)
+}
+
+// hasDedupValues returns true if the database contains a deduplicated value
+func hasDedupValues(db *leveldbhelper.DBHandle) bool {
+	itr := db.GetIterator(dedupKeyPrefix, dedupKeysEnd)
+	defer itr.Release()
+	return itr.Next()
+}
+
+// dedupUpdates accumulates the changes of the reference counts of the deduplicated values of a batch, as several
+// keys of a batch may refer to the same content
+type dedupUpdates struct {
+	deltas   map[string]int64
+	contents map[string][]byte
+}
+
+func newDedupUpdates() *dedupUpdates {
+	return &dedupUpdates{deltas: make(map[string]int64), contents: make(map[string][]byte)}
+}
+
+// putDedupValue adds to the batch the reference of the given key to the content of its value
+func putDedupValue(dbBatch *leveldbhelper.UpdateBatch, updates *dedupUpdates, ns string, key string, vv *statedb.VersionedValue) error {
+	hash, err := factory.GetDefault().Hash(vv.Value, dedupHashOpts)
+	if err != nil {
+		return err
+	}
+	updates.deltas[string(hash)]++
+	updates.contents[string(hash)] = vv.Value
+	dbBatch.Put(constructDedupRefKey(ns, key), hash)
+	dbBatch.Put(constructCompositeKey(ns, key), statedb.EncodeValue(nil, vv.Version))
+	return nil
+}
+
+// deleteDedupRef adds to the batch the delete of the reference of the given key, if its value is deduplicated
+func (vdb *versionedDB) deleteDedupRef(dbBatch *leveldbhelper.UpdateBatch, updates *dedupUpdates, ns string, key string) error {
+	refKey := constructDedupRefKey(ns, key)
+	hash, err := vdb.db.Get(refKey)
+	if err != nil || hash == nil {
+		return err
+	}
+	updates.deltas[string(hash)]--
+	dbBatch.Delete(refKey)
+	return nil
+}
+
+// applyDedupUpdates adds to the batch the new reference counts. The content is written when it gains its first
+// reference and deleted with its last one
+func (vdb *versionedDB) applyDedupUpdates(dbBatch *leveldbhelper.UpdateBatch, updates *dedupUpdates) error {
+	for h, delta := range updates.deltas {
+		if delta == 0 {
+			continue
+		}
+		hash := []byte(h)
+		countKey := constructDedupCountKey(hash)
+		countBytes, err := vdb.db.Get(countKey)
+		if err != nil {
+			return err
+		}
+		var count int64
+		if countBytes != nil {
+			if len(countBytes) != 8 {
+				return &ledger.CorruptionError{Msg: fmt.Sprintf("Invalid reference count of the deduplicated value [%x]", hash)}
+			}
+			count = int64(binary.BigEndian.Uint64(countBytes))
+		}
+		newCount := count + delta
+		if newCount < 0 {
+			return &ledger.CorruptionError{Msg: fmt.Sprintf("Deduplicated value [%x] has more references removed than recorded", hash)}
+		}
+		if newCount == 0 {
+			dbBatch.Delete(countKey)
+			dbBatch.Delete(constructDedupContentKey(hash))
+			continue
+		}
+		if count == 0 {
+			dbBatch.Put(constructDedupContentKey(hash), updates.contents[h])
+		}
+		newCountBytes := make([]byte, 8)
+		binary.BigEndian.PutUint64(newCountBytes, uint64(newCount))
+		dbBatch.Put(countKey, newCountBytes)
+	}
+	return nil
+}
+
+// readDedupValue returns the content referred to by the given key if its value is deduplicated. The value stored
+// in the key, which is empty for a deduplicated value, is returned unchanged if the value is not deduplicated
+func (vdb *versionedDB) readDedupValue(ns string, key string, storedValue []byte) ([]byte, bool, error) {
+	if len(storedValue) != 0 || !vdb.hasDedup {
+		return storedValue, false, nil
+	}
+	hash, err := vdb.db.Get(constructDedupRefKey(ns, key))
+	if err != nil || hash == nil {
+		return storedValue, false, err
+	}
+	content, err := vdb.db.Get(constructDedupContentKey(hash))
+	if err != nil {
+		return nil, false, err
+	}
+	if content == nil {
+		return nil, false, &ledger.CorruptionError{Msg: fmt.Sprintf("Deduplicated value [%x] of key [%s] of namespace [%s] is missing", hash, key, ns)}
+	}
+	return content, true, nil
+}
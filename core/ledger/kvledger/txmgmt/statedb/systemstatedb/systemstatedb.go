@@ -0,0 +1,310 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package systemstatedb
+
+import (
+	"github.com/hyperledger/fabric/common/ledger/util"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
+	logging "github.com/op/go-logging"
+)
+
+var logger = logging.MustGetLogger("systemstatedb")
+
+// VersionedDB keeps the system namespaces of the state, such as the namespace of the lifecycle chaincode, in a
+// VersionedDB of their own so that the operations on the data of the chaincodes do not impact the system state.
+// The system database has its own caches and is flushed to the disk by its own policy
+type VersionedDB struct {
+	db         statedb.VersionedDB
+	systemDB   statedb.VersionedDB
+	namespaces map[string]bool
+	syncer     *util.Syncer
+}
+
+// NewVersionedDB constructs a VersionedDB that keeps the given namespaces in systemDB and the other namespaces in db.
+// The writes to systemDB are flushed to the disk by the given policy, counting the blocks that update the system namespaces
+func NewVersionedDB(db statedb.VersionedDB, systemDB statedb.VersionedDB, namespaces []string, syncPolicy util.SyncPolicy) *VersionedDB {
+	vdb := &VersionedDB{db: db, systemDB: systemDB, namespaces: make(map[string]bool)}
+	for _, ns := range namespaces {
+		vdb.namespaces[ns] = true
+	}
+	vdb.syncer = util.NewSyncer(syncPolicy, systemDB.Sync)
+	return vdb
+}
+
+// SystemDB returns the VersionedDB that keeps the system namespaces
+func (vdb *VersionedDB) SystemDB() statedb.VersionedDB {
+	return vdb.systemDB
+}
+
+// IsSystemNamespace tells whether the given namespace is kept in the system database
+func (vdb *VersionedDB) IsSystemNamespace(namespace string) bool {
+	return vdb.namespaces[namespace]
+}
+
+func (vdb *VersionedDB) dbFor(namespace string) statedb.VersionedDB {
+	if vdb.namespaces[namespace] {
+		return vdb.systemDB
+	}
+	return vdb.db
+}
+
+// Open implements method in VersionedDB interface
+func (vdb *VersionedDB) Open() error {
+	if err := vdb.systemDB.Open(); err != nil {
+		return err
+	}
+	return vdb.db.Open()
+}
+
+// Close implements method in VersionedDB interface
+func (vdb *VersionedDB) Close() {
+	if err := vdb.syncer.Close(); err != nil {
+		logger.Errorf("Error while flushing the system state database: %s", err)
+	}
+	vdb.systemDB.Close()
+	vdb.db.Close()
+}
+
+// GetState implements method in VersionedDB interface
+func (vdb *VersionedDB) GetState(namespace string, key string) (*statedb.VersionedValue, error) {
+	return vdb.dbFor(namespace).GetState(namespace, key)
+}
+
+// GetStateMultipleKeys implements method in VersionedDB interface
+func (vdb *VersionedDB) GetStateMultipleKeys(namespace string, keys []string) ([]*statedb.VersionedValue, error) {
+	return vdb.dbFor(namespace).GetStateMultipleKeys(namespace, keys)
+}
+
+// GetStateRangeScanIterator implements method in VersionedDB interface
+func (vdb *VersionedDB) GetStateRangeScanIterator(namespace string, startKey string, endKey string) (statedb.ResultsIterator, error) {
+	return vdb.dbFor(namespace).GetStateRangeScanIterator(namespace, startKey, endKey)
+}
+
+// GetStateRangeScanIteratorWithLimit implements method in RangeScanLimiter interface.
+// The limit is passed on to the database of the namespace if that database fetches the results eagerly
+func (vdb *VersionedDB) GetStateRangeScanIteratorWithLimit(namespace string, startKey string, endKey string, limit int32) (statedb.ResultsIterator, error) {
+	db := vdb.dbFor(namespace)
+	if limiter, ok := db.(statedb.RangeScanLimiter); ok {
+		return limiter.GetStateRangeScanIteratorWithLimit(namespace, startKey, endKey, limit)
+	}
+	return db.GetStateRangeScanIterator(namespace, startKey, endKey)
+}
+
+// ExecuteQuery implements method in VersionedDB interface
+func (vdb *VersionedDB) ExecuteQuery(namespace, query string) (statedb.ResultsIterator, error) {
+	return vdb.dbFor(namespace).ExecuteQuery(namespace, query)
+}
+
+// ExecuteQueryWithFields implements method in FieldsQueryExecutor interface. The fields are projected from
+// the records of the results if the database of the namespace does not project them natively
+func (vdb *VersionedDB) ExecuteQueryWithFields(namespace, query string, fields []string) (statedb.ResultsIterator, error) {
+	db := vdb.dbFor(namespace)
+	if fieldsQueryExecutor, ok := db.(statedb.FieldsQueryExecutor); ok {
+		return fieldsQueryExecutor.ExecuteQueryWithFields(namespace, query, fields)
+	}
+	itr, err := db.ExecuteQuery(namespace, query)
+	if err != nil {
+		return nil, err
+	}
+	return &fieldsProjector{itr, fields}, nil
+}
+
+// GetFullScanIterator implements method in VersionedDB interface.
+// The key-values of the two databases are merged in the order of the namespaces and the keys
+func (vdb *VersionedDB) GetFullScanIterator() (statedb.ResultsIterator, error) {
+	systemItr, err := vdb.systemDB.GetFullScanIterator()
+	if err != nil {
+		return nil, err
+	}
+	itr, err := vdb.db.GetFullScanIterator()
+	if err != nil {
+		systemItr.Close()
+		return nil, err
+	}
+	return &mergedIterator{itrs: [2]statedb.ResultsIterator{systemItr, itr}}, nil
+}
+
+// ListNamespaces implements method in VersionedDB interface
+func (vdb *VersionedDB) ListNamespaces() (map[string]uint64, error) {
+	counts, err := vdb.db.ListNamespaces()
+	if err != nil {
+		return nil, err
+	}
+	systemCounts, err := vdb.systemDB.ListNamespaces()
+	if err != nil {
+		return nil, err
+	}
+	for ns, count := range systemCounts {
+		counts[ns] += count
+	}
+	return counts, nil
+}
+
+// ApplyUpdates implements method in VersionedDB interface. The updates of the system namespaces are applied first,
+// hence the system database may be ahead of the other one after a crash. Each database skips the updates of the
+// heights below its savepoint, so that the blocks recommitted from the lower savepoint are applied only where missing
+func (vdb *VersionedDB) ApplyUpdates(batch *statedb.UpdateBatch, height *version.Height) error {
+	systemBatch, otherBatch, hasSystemUpdates := vdb.splitBatch(batch)
+	applied, err := applyAboveSavepoint(vdb.systemDB, systemBatch, height)
+	if err != nil {
+		return err
+	}
+	if applied && hasSystemUpdates {
+		if err := vdb.syncer.BlockWritten(); err != nil {
+			return err
+		}
+	}
+	_, err = applyAboveSavepoint(vdb.db, otherBatch, height)
+	return err
+}
+
+func (vdb *VersionedDB) splitBatch(batch *statedb.UpdateBatch) (*statedb.UpdateBatch, *statedb.UpdateBatch, bool) {
+	systemBatch := statedb.NewUpdateBatch()
+	otherBatch := statedb.NewUpdateBatch()
+	hasSystemUpdates := false
+	for _, ns := range batch.GetUpdatedNamespaces() {
+		target := otherBatch
+		if vdb.namespaces[ns] {
+			target = systemBatch
+			hasSystemUpdates = true
+		}
+		for key, vv := range batch.GetUpdates(ns) {
+			if vv.Value == nil {
+				target.Delete(ns, key, vv.Version)
+			} else {
+				target.Put(ns, key, vv.Value, vv.Version)
+			}
+		}
+	}
+	return systemBatch, otherBatch, hasSystemUpdates
+}
+
+// applyAboveSavepoint applies the batch unless the db holds a savepoint above the given height
+func applyAboveSavepoint(db statedb.VersionedDB, batch *statedb.UpdateBatch, height *version.Height) (bool, error) {
+	savepoint, err := db.GetLatestSavePoint()
+	if err != nil {
+		return false, err
+	}
+	if savepoint != nil && height.Compare(savepoint) < 0 {
+		return false, nil
+	}
+	return true, db.ApplyUpdates(batch, height)
+}
+
+// GetLatestSavePoint implements method in VersionedDB interface. The lower of the savepoints of the two databases
+// is returned, i.e., nil if either database holds no savepoint
+func (vdb *VersionedDB) GetLatestSavePoint() (*version.Height, error) {
+	savepoint, err := vdb.db.GetLatestSavePoint()
+	if err != nil || savepoint == nil {
+		return nil, err
+	}
+	systemSavepoint, err := vdb.systemDB.GetLatestSavePoint()
+	if err != nil || systemSavepoint == nil {
+		return nil, err
+	}
+	if systemSavepoint.Compare(savepoint) < 0 {
+		return systemSavepoint, nil
+	}
+	return savepoint, nil
+}
+
+// GetDiskUsage implements method in VersionedDB interface
+func (vdb *VersionedDB) GetDiskUsage() (int64, error) {
+	size, err := vdb.db.GetDiskUsage()
+	if err != nil {
+		return 0, err
+	}
+	systemSize, err := vdb.systemDB.GetDiskUsage()
+	if err != nil {
+		return 0, err
+	}
+	return size + systemSize, nil
+}
+
+// Sync implements method in VersionedDB interface
+func (vdb *VersionedDB) Sync() error {
+	if err := vdb.syncer.Sync(); err != nil {
+		return err
+	}
+	return vdb.db.Sync()
+}
+
+// fieldsProjector projects the given fields from the records of the results of a query
+type fieldsProjector struct {
+	statedb.ResultsIterator
+	fields []string
+}
+
+func (itr *fieldsProjector) Next() (statedb.QueryResult, error) {
+	queryResult, err := itr.ResultsIterator.Next()
+	if err != nil || queryResult == nil {
+		return nil, err
+	}
+	record := queryResult.(*statedb.VersionedQueryRecord)
+	return &statedb.VersionedQueryRecord{Namespace: record.Namespace, Key: record.Key, Version: record.Version,
+		Record: statedb.ProjectFields(record.Record, itr.fields)}, nil
+}
+
+// mergedIterator merges the key-values of two full scan iterators, each ordered by the namespaces and the keys
+type mergedIterator struct {
+	itrs  [2]statedb.ResultsIterator
+	heads [2]*statedb.VersionedKV
+	done  [2]bool
+}
+
+func (itr *mergedIterator) Next() (statedb.QueryResult, error) {
+	for i := range itr.itrs {
+		if itr.heads[i] != nil || itr.done[i] {
+			continue
+		}
+		result, err := itr.itrs[i].Next()
+		if err != nil {
+			return nil, err
+		}
+		if result == nil {
+			itr.done[i] = true
+			continue
+		}
+		itr.heads[i] = result.(*statedb.VersionedKV)
+	}
+	next := -1
+	for i, head := range itr.heads {
+		if head != nil && (next == -1 || lessThan(head, itr.heads[next])) {
+			next = i
+		}
+	}
+	if next == -1 {
+		return nil, nil
+	}
+	kv := itr.heads[next]
+	itr.heads[next] = nil
+	return kv, nil
+}
+
+func (itr *mergedIterator) Close() {
+	for _, i := range itr.itrs {
+		i.Close()
+	}
+}
+
+func lessThan(kv1 *statedb.VersionedKV, kv2 *statedb.VersionedKV) bool {
+	if kv1.Namespace != kv2.Namespace {
+		return kv1.Namespace < kv2.Namespace
+	}
+	return kv1.Key < kv2.Key
+}
@@ -0,0 +1,138 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package systemstatedb
+
+import (
+	"os"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/common/ledger/util"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb/stateleveldb"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
+	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+	"github.com/spf13/viper"
+)
+
+func TestMain(m *testing.M) {
+	viper.Set("peer.fileSystemPath", "/tmp/fabric/ledgertests/kvledger/txmgmt/statedb/systemstatedb")
+	os.Exit(m.Run())
+}
+
+func newTestDBs(t *testing.T) (*VersionedDB, func()) {
+	os.RemoveAll(ledgerconfig.GetRootPath())
+	provider := stateleveldb.NewVersionedDBProvider()
+	systemProvider := stateleveldb.NewSystemVersionedDBProvider(false)
+	db, err := provider.GetDBHandle("testDB")
+	testutil.AssertNoError(t, err, "")
+	systemDB, err := systemProvider.GetDBHandle("testDB")
+	testutil.AssertNoError(t, err, "")
+	vdb := NewVersionedDB(db, systemDB, []string{"lccc"}, util.SyncPolicy{Blocks: 1})
+	return vdb, func() {
+		vdb.Close()
+		provider.Close()
+		systemProvider.Close()
+		os.RemoveAll(ledgerconfig.GetRootPath())
+	}
+}
+
+func TestSystemNamespacesKeptApart(t *testing.T) {
+	vdb, cleanup := newTestDBs(t)
+	defer cleanup()
+	batch := statedb.NewUpdateBatch()
+	batch.Put("lccc", "cc1", []byte("cc1-def"), version.NewHeight(1, 1))
+	batch.Put("ns1", "key1", []byte("value1"), version.NewHeight(1, 2))
+	batch.Put("ns1", "key2", []byte("value2"), version.NewHeight(1, 2))
+	batch.Put("a", "key1", []byte("value1"), version.NewHeight(1, 3))
+	testutil.AssertNoError(t, vdb.ApplyUpdates(batch, version.NewHeight(1, 3)), "")
+
+	vv, _ := vdb.GetState("lccc", "cc1")
+	testutil.AssertEquals(t, vv.Value, []byte("cc1-def"))
+	vv, _ = vdb.SystemDB().GetState("lccc", "cc1")
+	testutil.AssertEquals(t, vv.Value, []byte("cc1-def"))
+	vv, _ = vdb.db.GetState("lccc", "cc1")
+	testutil.AssertNil(t, vv)
+	vv, _ = vdb.SystemDB().GetState("ns1", "key1")
+	testutil.AssertNil(t, vv)
+	vv, _ = vdb.GetState("ns1", "key1")
+	testutil.AssertEquals(t, vv.Value, []byte("value1"))
+
+	counts, _ := vdb.ListNamespaces()
+	testutil.AssertEquals(t, counts, map[string]uint64{"lccc": 1, "ns1": 2, "a": 1})
+
+	// the full scan merges the two databases in the order of the namespaces and the keys
+	itr, err := vdb.GetFullScanIterator()
+	testutil.AssertNoError(t, err, "")
+	var keys []statedb.CompositeKey
+	for {
+		result, err := itr.Next()
+		testutil.AssertNoError(t, err, "")
+		if result == nil {
+			break
+		}
+		keys = append(keys, result.(*statedb.VersionedKV).CompositeKey)
+	}
+	itr.Close()
+	testutil.AssertEquals(t, keys, []statedb.CompositeKey{{Namespace: "a", Key: "key1"}, {Namespace: "lccc", Key: "cc1"},
+		{Namespace: "ns1", Key: "key1"}, {Namespace: "ns1", Key: "key2"}})
+}
+
+func TestSavepoints(t *testing.T) {
+	vdb, cleanup := newTestDBs(t)
+	defer cleanup()
+	savepoint, _ := vdb.GetLatestSavePoint()
+	testutil.AssertNil(t, savepoint)
+
+	batch := statedb.NewUpdateBatch()
+	batch.Put("lccc", "cc1", []byte("cc1-def"), version.NewHeight(1, 1))
+	batch.Put("ns1", "key1", []byte("value1"), version.NewHeight(1, 1))
+	testutil.AssertNoError(t, vdb.ApplyUpdates(batch, version.NewHeight(1, 1)), "")
+	savepoint, _ = vdb.GetLatestSavePoint()
+	testutil.AssertEquals(t, savepoint, version.NewHeight(1, 1))
+
+	// the system database ahead of the other one, as after a crash between the two writes
+	batch = statedb.NewUpdateBatch()
+	batch.Put("lccc", "cc1", []byte("cc1-def-v2"), version.NewHeight(2, 1))
+	testutil.AssertNoError(t, vdb.SystemDB().ApplyUpdates(batch, version.NewHeight(2, 1)), "")
+	savepoint, _ = vdb.GetLatestSavePoint()
+	testutil.AssertEquals(t, savepoint, version.NewHeight(1, 1))
+
+	// the block is recommitted from the lower savepoint
+	batch = statedb.NewUpdateBatch()
+	batch.Put("lccc", "cc1", []byte("cc1-def-v2"), version.NewHeight(2, 1))
+	batch.Put("ns1", "key1", []byte("value2"), version.NewHeight(2, 1))
+	testutil.AssertNoError(t, vdb.ApplyUpdates(batch, version.NewHeight(2, 1)), "")
+	savepoint, _ = vdb.GetLatestSavePoint()
+	testutil.AssertEquals(t, savepoint, version.NewHeight(2, 1))
+	vv, _ := vdb.GetState("ns1", "key1")
+	testutil.AssertEquals(t, vv.Value, []byte("value2"))
+
+	// a block below the savepoint of a database is not applied to that database
+	batch = statedb.NewUpdateBatch()
+	batch.Put("ns1", "key1", []byte("value4"), version.NewHeight(4, 1))
+	testutil.AssertNoError(t, vdb.db.ApplyUpdates(batch, version.NewHeight(4, 1)), "")
+	batch = statedb.NewUpdateBatch()
+	batch.Put("lccc", "cc1", []byte("cc1-def-v3"), version.NewHeight(3, 1))
+	batch.Put("ns1", "key1", []byte("value3"), version.NewHeight(3, 1))
+	testutil.AssertNoError(t, vdb.ApplyUpdates(batch, version.NewHeight(3, 1)), "")
+	vv, _ = vdb.GetState("lccc", "cc1")
+	testutil.AssertEquals(t, vv.Value, []byte("cc1-def-v3"))
+	vv, _ = vdb.GetState("ns1", "key1")
+	testutil.AssertEquals(t, vv.Value, []byte("value4"))
+	testutil.AssertNoError(t, vdb.Sync(), "")
+}
@@ -16,7 +16,12 @@ limitations under the License.
 
 package statedb
 
-import "github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
+)
 
 //EncodeValue appends the value to the version, allows storage of version and value in binary form
 func EncodeValue(value []byte, version *version.Height) []byte {
@@ -33,3 +38,48 @@ func DecodeValue(encodedValue []byte) ([]byte, *version.Height) {
 	value := encodedValue[n:]
 	return value, version
 }
+
+// ProjectFields returns the JSON document that contains only the given fields of the value. Nested fields
+// are given as dotted paths, such as "address.city", and the fields missing from the value are skipped.
+// A value that is not a JSON object is returned unchanged
+func ProjectFields(value []byte, fields []string) []byte {
+	doc := make(map[string]interface{})
+	if err := json.Unmarshal(value, &doc); err != nil {
+		return value
+	}
+	projected := make(map[string]interface{})
+	for _, field := range fields {
+		path := strings.Split(field, ".")
+		fieldValue, ok := lookupField(doc, path)
+		if !ok {
+			continue
+		}
+		target := projected
+		for _, name := range path[:len(path)-1] {
+			next, ok := target[name].(map[string]interface{})
+			if !ok {
+				next = make(map[string]interface{})
+				target[name] = next
+			}
+			target = next
+		}
+		target[path[len(path)-1]] = fieldValue
+	}
+	projectedValue, err := json.Marshal(projected)
+	if err != nil {
+		return value
+	}
+	return projectedValue
+}
+
+func lookupField(doc map[string]interface{}, path []string) (interface{}, bool) {
+	fieldValue, ok := doc[path[0]]
+	if !ok || len(path) == 1 {
+		return fieldValue, ok
+	}
+	nested, ok := fieldValue.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	return lookupField(nested, path[1:])
+}
@@ -52,3 +52,20 @@ func TestEncodeDecodeJSON(t *testing.T) {
 	testutil.AssertEquals(t, decodedVersion, version2)
 
 }
+
+// TestProjectFields tests projecting top level and nested fields of a JSON value
+func TestProjectFields(t *testing.T) {
+
+	bytesJSON := []byte(`{"asset_name":"marble1","color":"blue","size":35,"owner":{"name":"jerry","org":"org1"}}`)
+
+	projectedValue := ProjectFields(bytesJSON, []string{"asset_name", "owner.name", "missing", "color.missing"})
+	testutil.AssertEquals(t, string(projectedValue), `{"asset_name":"marble1","owner":{"name":"jerry"}}`)
+
+	projectedValue = ProjectFields(bytesJSON, []string{"size", "owner"})
+	testutil.AssertEquals(t, string(projectedValue), `{"owner":{"name":"jerry","org":"org1"},"size":35}`)
+
+	//non-JSON values should be returned unchanged
+	bytesString := []byte("value1")
+	testutil.AssertEquals(t, ProjectFields(bytesString, []string{"owner"}), bytesString)
+
+}
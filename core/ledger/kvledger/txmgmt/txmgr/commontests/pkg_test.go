@@ -78,7 +78,7 @@ func (env *levelDBLockBasedEnv) init(t *testing.T) {
 	testDB, err := testDBEnv.DBProvider.GetDBHandle("TestDB")
 	testutil.AssertNoError(t, err, "")
 
-	txMgr := lockbasedtxmgr.NewLockBasedTxMgr(testDB)
+	txMgr := lockbasedtxmgr.NewLockBasedTxMgr(testDB, nil)
 	env.testDBEnv = testDBEnv
 	env.testDB = testDB
 	env.txmgr = txMgr
@@ -118,7 +118,7 @@ func (env *couchDBLockBasedEnv) init(t *testing.T) {
 	testDB, err := testDBEnv.DBProvider.GetDBHandle(couchTestChainID)
 	testutil.AssertNoError(t, err, "")
 
-	txMgr := lockbasedtxmgr.NewLockBasedTxMgr(testDB)
+	txMgr := lockbasedtxmgr.NewLockBasedTxMgr(testDB, nil)
 	env.testDBEnv = testDBEnv
 	env.testDB = testDB
 	env.txmgr = txMgr
@@ -151,7 +151,7 @@ func newTxMgrTestHelper(t *testing.T, txMgr txmgr.TxMgr) *txMgrTestHelper {
 
 func (h *txMgrTestHelper) validateAndCommitRWSet(txRWSet []byte) {
 	block := h.bg.NextBlock([][]byte{txRWSet}, false)
-	err := h.txMgr.ValidateAndPrepare(block, true)
+	_, err := h.txMgr.ValidateAndPrepare(block, true)
 	testutil.AssertNoError(h.t, err, "")
 	txsFltr := util.TxValidationFlags(block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER])
 	invalidTxNum := 0
@@ -167,7 +167,7 @@ func (h *txMgrTestHelper) validateAndCommitRWSet(txRWSet []byte) {
 
 func (h *txMgrTestHelper) checkRWsetInvalid(txRWSet []byte) {
 	block := h.bg.NextBlock([][]byte{txRWSet}, false)
-	err := h.txMgr.ValidateAndPrepare(block, true)
+	_, err := h.txMgr.ValidateAndPrepare(block, true)
 	testutil.AssertNoError(h.t, err, "")
 	txsFltr := util.TxValidationFlags(block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER])
 	invalidTxNum := 0
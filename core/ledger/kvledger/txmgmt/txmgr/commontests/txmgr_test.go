@@ -20,11 +20,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/hyperledger/fabric/common/ledger/testutil"
 	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/rwset"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
 	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+	ledgerutil "github.com/hyperledger/fabric/core/ledger/util"
+	"github.com/spf13/viper"
 )
 
 func TestTxSimulatorWithNoExistingData(t *testing.T) {
@@ -490,6 +494,294 @@ func testGetSetMultipeKeys(t *testing.T, env testEnv) {
 	}
 }
 
+func TestPrivateDataHash(t *testing.T) {
+	for _, testEnv := range testEnvs {
+		t.Run(testEnv.getName(), func(t *testing.T) {
+			testEnv.init(t)
+			testPrivateDataHash(t, testEnv)
+			testEnv.cleanup()
+		})
+	}
+}
+
+func testPrivateDataHash(t *testing.T, env testEnv) {
+	txMgr := env.getTxMgr()
+	txMgrHelper := newTxMgrTestHelper(t, txMgr)
+	// simulate tx1 that writes private data to two collections
+	s1, _ := txMgr.NewTxSimulator()
+	s1.SetPrivateData("ns1", "coll2", "key1", []byte("pvt-value2"))
+	s1.SetPrivateData("ns1", "coll1", "key1", []byte("pvt-value1"))
+	s1.SetPrivateData("ns1", "coll1", "key2", []byte("pvt-value3"))
+	s1.Done()
+	txRWSet1, _ := s1.GetTxSimulationResults()
+	pvtWriteSets, err := s1.GetPvtSimulationResults()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, len(pvtWriteSets), 2)
+	testutil.AssertEquals(t, pvtWriteSets[0].CollectionName, "coll1")
+	pvtRWSet := &rwset.TxReadWriteSet{}
+	testutil.AssertNoError(t, pvtRWSet.Unmarshal(pvtWriteSets[1].WriteSet), "")
+	testutil.AssertEquals(t, pvtRWSet.NsRWs[0].Writes, []*rwset.KVWrite{rwset.NewKVWrite("key1", []byte("pvt-value2"))})
+	txMgrHelper.validateAndCommitRWSet(txRWSet1)
+
+	// the hashes are queryable, whereas the private values are not present in the state
+	qe, _ := txMgr.NewQueryExecutor()
+	hash, err := qe.GetPrivateDataHash("ns1", "coll1", "key1")
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, hash, ledgerutil.ComputePvtDataHash([]byte("pvt-value1")))
+	hash, _ = qe.GetPrivateDataHash("ns1", "coll2", "key1")
+	testutil.AssertEquals(t, hash, ledgerutil.ComputePvtDataHash([]byte("pvt-value2")))
+	hash, _ = qe.GetPrivateDataHash("ns1", "coll2", "key2")
+	testutil.AssertNil(t, hash)
+	value, _ := qe.GetState("ns1", "key1")
+	testutil.AssertNil(t, value)
+	qe.Done()
+
+	// simulate tx2 that deletes a private key
+	s2, _ := txMgr.NewTxSimulator()
+	s2.DeletePrivateData("ns1", "coll1", "key1")
+	s2.Done()
+	txRWSet2, _ := s2.GetTxSimulationResults()
+	txMgrHelper.validateAndCommitRWSet(txRWSet2)
+	qe, _ = txMgr.NewQueryExecutor()
+	defer qe.Done()
+	hash, _ = qe.GetPrivateDataHash("ns1", "coll1", "key1")
+	testutil.AssertNil(t, hash)
+	hash, _ = qe.GetPrivateDataHash("ns1", "coll1", "key2")
+	testutil.AssertEquals(t, hash, ledgerutil.ComputePvtDataHash([]byte("pvt-value3")))
+}
+
+func TestStateHash(t *testing.T) {
+	viper.Set("ledger.state.storeValueHashes", true)
+	defer viper.Set("ledger.state.storeValueHashes", false)
+	for _, testEnv := range testEnvs {
+		t.Run(testEnv.getName(), func(t *testing.T) {
+			testEnv.init(t)
+			testStateHash(t, testEnv)
+			testEnv.cleanup()
+		})
+	}
+}
+
+func testStateHash(t *testing.T, env testEnv) {
+	txMgr := env.getTxMgr()
+	txMgrHelper := newTxMgrTestHelper(t, txMgr)
+	largeValue := make([]byte, 1<<20)
+	for i := range largeValue {
+		largeValue[i] = byte(i)
+	}
+	s1, _ := txMgr.NewTxSimulator()
+	s1.SetState("ns1", "key1", largeValue)
+	s1.SetState("ns1", "key2", []byte("value2"))
+	s1.Done()
+	txRWSet1, _ := s1.GetTxSimulationResults()
+	txMgrHelper.validateAndCommitRWSet(txRWSet1)
+
+	// the hashes are stored alongside the values
+	qe, _ := txMgr.NewQueryExecutor()
+	storedHash, err := qe.GetState(ledgerutil.DeriveValueHashNs("ns1"), "key1")
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, storedHash, ledgerutil.ComputeValueHash(largeValue))
+	qe.Done()
+
+	// the key of which the hash is retrieved is recorded as read with the version of the value
+	s2, _ := txMgr.NewTxSimulator()
+	hash, err := s2.GetStateHash("ns1", "key1")
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, hash, ledgerutil.ComputeValueHash(largeValue))
+	hash, err = s2.GetStateHash("ns1", "key3")
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertNil(t, hash)
+	s2.GetState("ns1", "key2")
+	s2.Done()
+	simRes, _ := s2.GetTxSimulationResults()
+	txRWSet2 := &rwset.TxReadWriteSet{}
+	testutil.AssertNoError(t, txRWSet2.Unmarshal(simRes), "")
+	reads := txRWSet2.NsRWs[0].Reads
+	testutil.AssertEquals(t, len(reads), 3)
+	testutil.AssertEquals(t, reads[0].Key, "key1")
+	testutil.AssertNotNil(t, reads[0].Version)
+	testutil.AssertEquals(t, reads[0].Version, reads[1].Version)
+	testutil.AssertEquals(t, reads[2], rwset.NewKVRead("key3", nil))
+
+	// the hash of a deleted key is deleted
+	s3, _ := txMgr.NewTxSimulator()
+	s3.DeleteState("ns1", "key1")
+	s3.Done()
+	txRWSet3, _ := s3.GetTxSimulationResults()
+	txMgrHelper.validateAndCommitRWSet(txRWSet3)
+	qe, _ = txMgr.NewQueryExecutor()
+	defer qe.Done()
+	storedHash, _ = qe.GetState(ledgerutil.DeriveValueHashNs("ns1"), "key1")
+	testutil.AssertNil(t, storedHash)
+	hash, _ = qe.GetStateHash("ns1", "key1")
+	testutil.AssertNil(t, hash)
+
+	// the hash is computed from the value if the hashes are not stored
+	viper.Set("ledger.state.storeValueHashes", false)
+	defer viper.Set("ledger.state.storeValueHashes", true)
+	hash, _ = qe.GetStateHash("ns1", "key2")
+	testutil.AssertEquals(t, hash, ledgerutil.ComputeValueHash([]byte("value2")))
+}
+
+func TestGetTotalForKeyPrefix(t *testing.T) {
+	for _, testEnv := range testEnvs {
+		t.Run(testEnv.getName(), func(t *testing.T) {
+			testEnv.init(t)
+			testGetTotalForKeyPrefix(t, testEnv)
+			testEnv.cleanup()
+		})
+	}
+}
+
+func testGetTotalForKeyPrefix(t *testing.T, env testEnv) {
+	txMgr := env.getTxMgr()
+	txMgrHelper := newTxMgrTestHelper(t, txMgr)
+	// simulate tx1 that creates the balances of a few accounts along with the values that are not to be counted
+	s1, _ := txMgr.NewTxSimulator()
+	s1.SetState("ns", "acct_1", []byte(`{"owner":"alice","balance":100}`))
+	s1.SetState("ns", "acct_2", []byte(`{"owner":"bob","balance":50.5}`))
+	s1.SetState("ns", "acct_3", []byte(`{"owner":"carol","balance":"unknown"}`))
+	s1.SetState("ns", "acct_4", []byte("not-json"))
+	s1.SetState("ns", "acctx", []byte(`{"balance":1000}`))
+	s1.SetState("ns", "other_1", []byte(`{"balance":1000}`))
+	s1.Done()
+	txRWSet1, _ := s1.GetTxSimulationResults()
+	txMgrHelper.validateAndCommitRWSet(txRWSet1)
+
+	qe, _ := txMgr.NewQueryExecutor()
+	aggregate, err := qe.GetTotalForKeyPrefix("ns", "acct_", "balance")
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, aggregate, &ledger.Aggregate{Sum: 150.5, Count: 2})
+	aggregate, err = qe.GetTotalForKeyPrefix("ns", "acct_", "missing")
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, aggregate, &ledger.Aggregate{})
+	qe.Done()
+
+	// simulate tx2 that relies on the total and tx3 that adds an account under the prefix
+	s2, _ := txMgr.NewTxSimulator()
+	aggregate, _ = s2.GetTotalForKeyPrefix("ns", "acct_", "balance")
+	testutil.AssertEquals(t, aggregate.Sum, 150.5)
+	s2.SetState("ns", "total", []byte("150.5"))
+	s2.Done()
+	txRWSet2, _ := s2.GetTxSimulationResults()
+
+	s3, _ := txMgr.NewTxSimulator()
+	s3.SetState("ns", "acct_15", []byte(`{"owner":"dave","balance":10}`))
+	s3.Done()
+	txRWSet3, _ := s3.GetTxSimulationResults()
+
+	// tx3 makes tx2 invalid as it adds a key under the prefix
+	txMgrHelper.validateAndCommitRWSet(txRWSet3)
+	txMgrHelper.checkRWsetInvalid(txRWSet2)
+}
+
+func TestListNamespaces(t *testing.T) {
+	for _, testEnv := range testEnvs {
+		t.Run(testEnv.getName(), func(t *testing.T) {
+			testEnv.init(t)
+			testListNamespaces(t, testEnv)
+			testEnv.cleanup()
+		})
+	}
+}
+
+func testListNamespaces(t *testing.T, env testEnv) {
+	txMgr := env.getTxMgr()
+	txMgrHelper := newTxMgrTestHelper(t, txMgr)
+	s1, _ := txMgr.NewTxSimulator()
+	s1.SetState("ns2", "key1", []byte("value1"))
+	s1.SetState("ns1", "key1", []byte("value1"))
+	s1.SetState("ns1", "key2", []byte("value2"))
+	s1.SetState("ns1", "key3", []byte("value3"))
+	s1.Done()
+	txRWSet1, _ := s1.GetTxSimulationResults()
+	txMgrHelper.validateAndCommitRWSet(txRWSet1)
+
+	qe, _ := txMgr.NewQueryExecutor()
+	defer qe.Done()
+	namespaces, err := qe.ListNamespaces()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, namespaces, []*ledger.NamespaceInfo{
+		{Namespace: "ns1", KeyCount: 3},
+		{Namespace: "ns2", KeyCount: 1},
+	})
+}
+
+func TestSequence(t *testing.T) {
+	for _, testEnv := range testEnvs {
+		t.Run(testEnv.getName(), func(t *testing.T) {
+			testEnv.init(t)
+			testSequence(t, testEnv)
+			testEnv.cleanup()
+		})
+	}
+}
+
+func testSequence(t *testing.T, env testEnv) {
+	txMgr := env.getTxMgr()
+	txMgrHelper := newTxMgrTestHelper(t, txMgr)
+	// simulate tx1 that draws three values from a sequence
+	s1, _ := txMgr.NewTxSimulator()
+	values := make(map[uint64]bool)
+	var lastValue uint64
+	for i := 0; i < 3; i++ {
+		value, err := s1.NextSequenceValue("ns", "seq", "tx1")
+		testutil.AssertNoError(t, err, "")
+		testutil.AssertEquals(t, i == 0 || value > lastValue, true)
+		values[value] = true
+		lastValue = value
+	}
+	s1.Done()
+	txRWSet1, _ := s1.GetTxSimulationResults()
+	txMgrHelper.validateAndCommitRWSet(txRWSet1)
+
+	// the sequence counters are not visible in the namespace of the chaincode
+	qe, _ := txMgr.NewQueryExecutor()
+	count, err := qe.GetSequenceCount("ns", "seq")
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, count, uint64(3))
+	count, _ = qe.GetSequenceCount("ns", "other")
+	testutil.AssertEquals(t, count, uint64(0))
+	itr, _ := qe.GetStateRangeScanIterator("ns", "", "")
+	result, _ := itr.Next()
+	testutil.AssertNil(t, result)
+	itr.Close()
+	qe.Done()
+
+	// simulate two concurrent transactions that draw from different counters of the sequence
+	s2, _ := txMgr.NewTxSimulator()
+	value2, _ := s2.NextSequenceValue("ns", "seq", "tx2")
+	s2.Done()
+	var s3 ledger.TxSimulator
+	var value3 uint64
+	for i := 3; ; i++ {
+		s3, _ = txMgr.NewTxSimulator()
+		value3, _ = s3.NextSequenceValue("ns", "seq", fmt.Sprintf("tx%d", i))
+		s3.Done()
+		if value3%16 != value2%16 {
+			break
+		}
+	}
+	// simulate a transaction that draws from the same counter as tx2
+	s4, _ := txMgr.NewTxSimulator()
+	value4, _ := s4.NextSequenceValue("ns", "seq", "tx2")
+	s4.Done()
+	testutil.AssertEquals(t, value4, value2)
+
+	txRWSet2, _ := s2.GetTxSimulationResults()
+	txRWSet3, _ := s3.GetTxSimulationResults()
+	txRWSet4, _ := s4.GetTxSimulationResults()
+	txMgrHelper.validateAndCommitRWSet(txRWSet2)
+	txMgrHelper.validateAndCommitRWSet(txRWSet3)
+	txMgrHelper.checkRWsetInvalid(txRWSet4)
+	testutil.AssertEquals(t, values[value2] || values[value3] || value2 == value3, false)
+
+	qe, _ = txMgr.NewQueryExecutor()
+	defer qe.Done()
+	count, _ = qe.GetSequenceCount("ns", "seq")
+	testutil.AssertEquals(t, count, uint64(5))
+}
+
 func createTestKey(i int) string {
 	if i == 0 {
 		return ""
@@ -584,3 +876,344 @@ func testExecuteQuery(t *testing.T, env testEnv) {
 	testutil.AssertEquals(t, counter, 3)
 
 }
+
+func TestKeyExpiry(t *testing.T) {
+	for _, testEnv := range testEnvs {
+		t.Run(testEnv.getName(), func(t *testing.T) {
+			testEnv.init(t)
+			testKeyExpiry(t, testEnv)
+			testEnv.cleanup()
+		})
+	}
+}
+
+func testKeyExpiry(t *testing.T, env testEnv) {
+	txMgr := env.getTxMgr()
+	txMgrHelper := newTxMgrTestHelper(t, txMgr)
+	// simulate tx1 that writes keys that expire after 2 and 3 blocks, a key that does not expire and
+	// a key whose expiry is cleared by a later write in the same transaction
+	s1, _ := txMgr.NewTxSimulator()
+	testutil.AssertError(t, s1.SetStateWithTTL("ns", "key1", []byte("value1"), 0), "Expected error for a zero ttl")
+	s1.SetStateWithTTL("ns", "key1", []byte("value1"), 2)
+	s1.SetStateWithTTL("ns", "key2", []byte("value2"), 2)
+	s1.SetStateWithTTL("ns", "key3", []byte("value3"), 3)
+	s1.SetState("ns", "key4", []byte("value4"))
+	s1.SetStateWithTTL("ns", "key5", []byte("value5"), 2)
+	s1.SetState("ns", "key5", []byte("value5_new"))
+	s1.Done()
+	txRWSet1, _ := s1.GetTxSimulationResults()
+	txMgrHelper.validateAndCommitRWSet(txRWSet1)
+
+	// simulate tx2 that writes key2 again, which cancels its expiry
+	s2, _ := txMgr.NewTxSimulator()
+	s2.SetState("ns", "key2", []byte("value2_new"))
+	s2.Done()
+	txRWSet2, _ := s2.GetTxSimulationResults()
+	txMgrHelper.validateAndCommitRWSet(txRWSet2)
+
+	// key1 expires on the commit of the second block after the block of tx1
+	s3, _ := txMgr.NewTxSimulator()
+	s3.SetState("ns", "other", []byte("other"))
+	s3.Done()
+	txRWSet3, _ := s3.GetTxSimulationResults()
+	txMgrHelper.validateAndCommitRWSet(txRWSet3)
+
+	qe, _ := txMgr.NewQueryExecutor()
+	values, _ := qe.GetStateMultipleKeys("ns", []string{"key1", "key2", "key3", "key4", "key5"})
+	testutil.AssertEquals(t, values, [][]byte{nil, []byte("value2_new"), []byte("value3"), []byte("value4"), []byte("value5_new")})
+	qe.Done()
+
+	// key3 expires on the commit of the third block after the block of tx1
+	s4, _ := txMgr.NewTxSimulator()
+	s4.SetState("ns", "other", []byte("other"))
+	s4.Done()
+	txRWSet4, _ := s4.GetTxSimulationResults()
+	txMgrHelper.validateAndCommitRWSet(txRWSet4)
+
+	qe, _ = txMgr.NewQueryExecutor()
+	values, _ = qe.GetStateMultipleKeys("ns", []string{"key1", "key2", "key3", "key4", "key5"})
+	testutil.AssertEquals(t, values, [][]byte{nil, []byte("value2_new"), nil, []byte("value4"), []byte("value5_new")})
+	qe.Done()
+}
+
+func TestInvalidKeys(t *testing.T) {
+	for _, testEnv := range testEnvs {
+		t.Run(testEnv.getName(), func(t *testing.T) {
+			testEnv.init(t)
+			testInvalidKeys(t, testEnv)
+			testEnv.cleanup()
+		})
+	}
+}
+
+func testInvalidKeys(t *testing.T, env testEnv) {
+	txMgr := env.getTxMgr()
+	s, _ := txMgr.NewTxSimulator()
+	defer s.Done()
+	for _, key := range []string{"key\xff", "key\U0010ffff", "key\x00attr", "\x00key"} {
+		err := s.SetState("ns", key, []byte("value"))
+		_, ok := err.(*ledger.InvalidKeyError)
+		testutil.AssertEquals(t, ok, true)
+		err = s.SetPrivateData("ns", "coll", key, []byte("value"))
+		_, ok = err.(*ledger.InvalidKeyError)
+		testutil.AssertEquals(t, ok, true)
+		// a key written before the check can be deleted
+		testutil.AssertNoError(t, s.DeleteState("ns", key), "")
+	}
+	for _, key := range []string{"key", "type\x00attr1\x00attr2\x00", "k\u00e9y"} {
+		testutil.AssertNoError(t, s.SetState("ns", key, []byte("value")), "")
+	}
+}
+
+func TestRangeQueryWithPagination(t *testing.T) {
+	for _, testEnv := range testEnvs {
+		t.Run(testEnv.getName(), func(t *testing.T) {
+			testEnv.init(t)
+			testRangeQueryWithPagination(t, testEnv)
+			testEnv.cleanup()
+		})
+	}
+}
+
+func testRangeQueryWithPagination(t *testing.T, env testEnv) {
+	txMgr := env.getTxMgr()
+	txMgrHelper := newTxMgrTestHelper(t, txMgr)
+	s1, _ := txMgr.NewTxSimulator()
+	for i := 1; i <= 5; i++ {
+		s1.SetState("ns", fmt.Sprintf("key%d", i), []byte(fmt.Sprintf("value%d", i)))
+	}
+	s1.Done()
+	txRWSet1, _ := s1.GetTxSimulationResults()
+	txMgrHelper.validateAndCommitRWSet(txRWSet1)
+
+	qe, _ := txMgr.NewQueryExecutor()
+	defer qe.Done()
+	_, err := qe.GetStateRangeScanIteratorWithPagination("ns", "key1", "key5", 0, "")
+	testutil.AssertError(t, err, "Expected error for a zero page size")
+	_, err = qe.GetStateRangeScanIteratorWithPagination("ns", "key1", "key5", 2, "key5")
+	testutil.AssertError(t, err, "Expected error for a bookmark outside the range")
+
+	var pages [][]string
+	bookmark := ""
+	for {
+		itr, err := qe.GetStateRangeScanIteratorWithPagination("ns", "key1", "key5", 2, bookmark)
+		testutil.AssertNoError(t, err, "")
+		var keys []string
+		for {
+			queryResult, _ := itr.Next()
+			if queryResult == nil {
+				break
+			}
+			keys = append(keys, queryResult.(*ledger.KV).Key)
+		}
+		pages = append(pages, keys)
+		bookmark = itr.Bookmark()
+		itr.Close()
+		if bookmark == "" {
+			break
+		}
+	}
+	testutil.AssertEquals(t, pages, [][]string{{"key1", "key2"}, {"key3", "key4"}})
+}
+
+func TestIdleIteratorRelease(t *testing.T) {
+	viper.Set("ledger.state.iteratorIdleTimeout", "50ms")
+	defer viper.Set("ledger.state.iteratorIdleTimeout", "")
+	for _, testEnv := range testEnvs {
+		t.Run(testEnv.getName(), func(t *testing.T) {
+			testEnv.init(t)
+			testIdleIteratorRelease(t, testEnv)
+			testEnv.cleanup()
+		})
+	}
+}
+
+func testIdleIteratorRelease(t *testing.T, env testEnv) {
+	txMgr := env.getTxMgr()
+	txMgrHelper := newTxMgrTestHelper(t, txMgr)
+	s1, _ := txMgr.NewTxSimulator()
+	s1.SetState("ns", "key1", []byte("value1"))
+	s1.SetState("ns", "key2", []byte("value2"))
+	s1.Done()
+	txRWSet1, _ := s1.GetTxSimulationResults()
+	txMgrHelper.validateAndCommitRWSet(txRWSet1)
+
+	qe, _ := txMgr.NewQueryExecutor()
+	itr, _ := qe.GetStateRangeScanIterator("ns", "", "")
+	queryResult, err := itr.Next()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, queryResult.(*ledger.KV).Key, "key1")
+	// an iterator in use is not released
+	time.Sleep(30 * time.Millisecond)
+	_, err = itr.Next()
+	testutil.AssertNoError(t, err, "")
+	// the idle iterator is released and cannot be used further
+	time.Sleep(200 * time.Millisecond)
+	_, err = itr.Next()
+	testutil.AssertError(t, err, "Expected error from a released iterator")
+	itr.Close()
+	qe.Done()
+}
+
+func TestQueryExecutorAtHeight(t *testing.T) {
+	for _, testEnv := range testEnvs {
+		t.Run(testEnv.getName(), func(t *testing.T) {
+			testEnv.init(t)
+			_, err := testEnv.getTxMgr().NewQueryExecutorAtHeight(0)
+			_, ok := err.(*ledger.NotEnabledError)
+			testutil.AssertEquals(t, ok, true)
+			testEnv.cleanup()
+		})
+	}
+	viper.Set("ledger.state.recentVersions", 2)
+	defer viper.Set("ledger.state.recentVersions", 0)
+	for _, testEnv := range testEnvs {
+		t.Run(testEnv.getName(), func(t *testing.T) {
+			testEnv.init(t)
+			testQueryExecutorAtHeight(t, testEnv)
+			testEnv.cleanup()
+		})
+	}
+}
+
+func testQueryExecutorAtHeight(t *testing.T, env testEnv) {
+	txMgr := env.getTxMgr()
+	txMgrHelper := newTxMgrTestHelper(t, txMgr)
+	commit := func(update func(s ledger.TxSimulator)) {
+		s, _ := txMgr.NewTxSimulator()
+		update(s)
+		s.Done()
+		txRWSet, _ := s.GetTxSimulationResults()
+		txMgrHelper.validateAndCommitRWSet(txRWSet)
+	}
+	checkState := func(qe ledger.QueryExecutor, expected map[string]string, keys ...string) {
+		values, err := qe.GetStateMultipleKeys("ns", keys)
+		testutil.AssertNoError(t, err, "")
+		for i, key := range keys {
+			value, err := qe.GetState("ns", key)
+			testutil.AssertNoError(t, err, "")
+			if expectedValue, ok := expected[key]; ok {
+				testutil.AssertEquals(t, string(value), expectedValue)
+				testutil.AssertEquals(t, string(values[i]), expectedValue)
+			} else {
+				testutil.AssertNil(t, value)
+				testutil.AssertNil(t, values[i])
+			}
+		}
+		itr, err := qe.GetStateRangeScanIterator("ns", "", "")
+		testutil.AssertNoError(t, err, "")
+		defer itr.Close()
+		found := make(map[string]string)
+		var lastKey string
+		for {
+			queryResult, err := itr.Next()
+			testutil.AssertNoError(t, err, "")
+			if queryResult == nil {
+				break
+			}
+			kv := queryResult.(*ledger.KV)
+			testutil.AssertEquals(t, kv.Key > lastKey, true)
+			lastKey = kv.Key
+			found[kv.Key] = string(kv.Value)
+		}
+		testutil.AssertEquals(t, found, expected)
+	}
+
+	qe0, err := txMgr.NewQueryExecutorAtHeight(0)
+	testutil.AssertNoError(t, err, "")
+	_, err = txMgr.NewQueryExecutorAtHeight(1)
+	testutil.AssertError(t, err, "Expected error for a height beyond the height of the state")
+
+	commit(func(s ledger.TxSimulator) {
+		s.SetState("ns", "key1", []byte("value1"))
+		s.SetState("ns", "key2", []byte("value2"))
+	})
+	// the query executors at a height do not block the commits
+	qe1, err := txMgr.NewQueryExecutorAtHeight(1)
+	testutil.AssertNoError(t, err, "")
+	commit(func(s ledger.TxSimulator) {
+		s.SetState("ns", "key1", []byte("value1_1"))
+		s.DeleteState("ns", "key2")
+		s.SetState("ns", "key3", []byte("value3"))
+	})
+	commit(func(s ledger.TxSimulator) {
+		s.SetState("ns", "key0", []byte("value0"))
+		s.SetState("ns", "key4", []byte("value4"))
+	})
+
+	checkState(qe1, map[string]string{"key1": "value1", "key2": "value2"}, "key0", "key1", "key2", "key3", "key4")
+	qe2, err := txMgr.NewQueryExecutorAtHeight(2)
+	testutil.AssertNoError(t, err, "")
+	checkState(qe2, map[string]string{"key1": "value1_1", "key3": "value3"}, "key0", "key1", "key2", "key3", "key4")
+	qe3, err := txMgr.NewQueryExecutorAtHeight(3)
+	testutil.AssertNoError(t, err, "")
+	checkState(qe3, map[string]string{"key0": "value0", "key1": "value1_1", "key3": "value3", "key4": "value4"},
+		"key0", "key1", "key2", "key3", "key4")
+	bcInfo, err := qe2.GetBlockchainInfo()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, bcInfo.Height, uint64(2))
+	_, err = qe2.ListNamespaces()
+	testutil.AssertError(t, err, "Expected error for listing the namespaces at a height")
+
+	// only the values replaced by the two most recent blocks are retained
+	_, err = qe0.GetState("ns", "key1")
+	testutil.AssertError(t, err, "Expected error for a height that is not retained")
+	_, err = txMgr.NewQueryExecutorAtHeight(0)
+	testutil.AssertError(t, err, "Expected error for a height that is not retained")
+	commit(func(s ledger.TxSimulator) {
+		s.SetState("ns", "key5", []byte("value5"))
+	})
+	_, err = qe1.GetState("ns", "key1")
+	testutil.AssertError(t, err, "Expected error for a height that is not retained")
+	checkState(qe2, map[string]string{"key1": "value1_1", "key3": "value3"}, "key1", "key5")
+	for _, qe := range []ledger.QueryExecutor{qe0, qe1, qe2, qe3} {
+		qe.Done()
+	}
+}
+
+func TestQueryMemoryBudget(t *testing.T) {
+	viper.Set("ledger.state.queryMemoryBudget.perQuery", "20")
+	viper.Set("ledger.state.queryMemoryBudget.global", "25")
+	defer viper.Set("ledger.state.queryMemoryBudget.perQuery", "")
+	defer viper.Set("ledger.state.queryMemoryBudget.global", "")
+	for _, testEnv := range testEnvs {
+		t.Run(testEnv.getName(), func(t *testing.T) {
+			testEnv.init(t)
+			testQueryMemoryBudget(t, testEnv)
+			testEnv.cleanup()
+		})
+	}
+}
+
+func testQueryMemoryBudget(t *testing.T, env testEnv) {
+	txMgr := env.getTxMgr()
+	txMgrHelper := newTxMgrTestHelper(t, txMgr)
+	s1, _ := txMgr.NewTxSimulator()
+	for i := 1; i <= 3; i++ {
+		s1.SetState("ns", fmt.Sprintf("key%d", i), []byte(fmt.Sprintf("value%d", i)))
+	}
+	s1.Done()
+	txRWSet1, _ := s1.GetTxSimulationResults()
+	txMgrHelper.validateAndCommitRWSet(txRWSet1)
+
+	qe, _ := txMgr.NewQueryExecutor()
+	defer qe.Done()
+	// each result holds 10 bytes, hence the query is aborted at the third result
+	itr1, _ := qe.GetStateRangeScanIterator("ns", "", "")
+	for i := 0; i < 2; i++ {
+		_, err := itr1.Next()
+		testutil.AssertNoError(t, err, "")
+	}
+	_, err := itr1.Next()
+	_, ok := err.(*ledger.QueryMemoryExceededError)
+	testutil.AssertEquals(t, ok, true)
+
+	// the results of the first query count against the global budget until it is closed
+	itr2, _ := qe.GetStateRangeScanIterator("ns", "", "")
+	_, err = itr2.Next()
+	testutil.AssertEquals(t, err.(*ledger.QueryMemoryExceededError).Global, true)
+	itr1.Close()
+	_, err = itr2.Next()
+	testutil.AssertNoError(t, err, "")
+	itr2.Close()
+}
@@ -17,25 +17,55 @@ limitations under the License.
 package lockbasedtxmgr
 
 import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/hyperledger/fabric/common/flogging"
 	commonledger "github.com/hyperledger/fabric/common/ledger"
+	"github.com/hyperledger/fabric/common/tracing"
 	"github.com/hyperledger/fabric/core/ledger"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/rwset"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
 	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+	ledgerutil "github.com/hyperledger/fabric/core/ledger/util"
+	"github.com/hyperledger/fabric/protos/common"
+	"golang.org/x/net/context"
 )
 
 type queryHelper struct {
-	txmgr       *LockBasedTxMgr
+	txmgr *LockBasedTxMgr
+	// db is the state database that the queries read. For a query executor at a height, the
+	// state as of the height is read, without holding the read lock on the commits
+	db          statedb.VersionedDB
+	atHeight    bool
 	rwset       *rwset.RWSet
 	itrs        []*resultsItr
 	err         error
 	doneInvoked bool
+	// permits are held by the rich queries and are released, at the latest, by done()
+	permits []*ledgerutil.QueryPermit
+	// traceCtx carries the span under which the spans of the queries are reported
+	traceCtx context.Context
+}
+
+// startSpan starts a span for a query on the given namespace. The queries are traced only when
+// the query executor is given a trace context, so that the queries made during validation are not traced
+func (h *queryHelper) startSpan(name string, namespace string) *tracing.Span {
+	if h.traceCtx == nil {
+		return nil
+	}
+	span, _ := tracing.StartSpan(h.traceCtx, name)
+	span.SetTag("namespace", namespace)
+	return span
 }
 
 func (h *queryHelper) getState(ns string, key string) ([]byte, error) {
 	h.checkDone()
-	versionedValue, err := h.txmgr.db.GetState(ns, key)
+	defer h.startSpan("ledger.GetState", ns).Finish()
+	versionedValue, err := h.db.GetState(ns, key)
 	if err != nil {
 		return nil, err
 	}
@@ -46,11 +76,42 @@ func (h *queryHelper) getState(ns string, key string) ([]byte, error) {
 	return val, nil
 }
 
+// getStateHash returns the hash of the value of the given key. The hash stored alongside the value is returned
+// if the hashes of the values are stored, otherwise the hash is computed from the value. In either case, the
+// version of the key is added to the read set, as a read of the value would
+func (h *queryHelper) getStateHash(ns string, key string) ([]byte, error) {
+	h.checkDone()
+	defer h.startSpan("ledger.GetStateHash", ns).Finish()
+	var versionedValue *statedb.VersionedValue
+	var err error
+	isHash := false
+	if ledgerconfig.IsValueHashStoreEnabled() {
+		if versionedValue, err = h.db.GetState(ledgerutil.DeriveValueHashNs(ns), key); err != nil {
+			return nil, err
+		}
+		isHash = versionedValue != nil
+	}
+	if !isHash {
+		if versionedValue, err = h.db.GetState(ns, key); err != nil {
+			return nil, err
+		}
+	}
+	val, ver := decomposeVersionedValue(versionedValue)
+	if h.rwset != nil {
+		h.rwset.AddToReadSet(ns, key, ver)
+	}
+	if isHash || val == nil {
+		return val, nil
+	}
+	return ledgerutil.ComputeValueHash(val), nil
+}
+
 func (h *queryHelper) getStateMultipleKeys(namespace string, keys []string) ([][]byte, error) {
 	h.checkDone()
-	versionedValues, err := h.txmgr.db.GetStateMultipleKeys(namespace, keys)
+	defer h.startSpan("ledger.GetStateMultipleKeys", namespace).Finish()
+	versionedValues, err := h.db.GetStateMultipleKeys(namespace, keys)
 	if err != nil {
-		return nil, nil
+		return nil, err
 	}
 	values := make([][]byte, len(versionedValues))
 	for i, versionedValue := range versionedValues {
@@ -65,29 +126,218 @@ func (h *queryHelper) getStateMultipleKeys(namespace string, keys []string) ([][
 
 func (h *queryHelper) getStateRangeScanIterator(namespace string, startKey string, endKey string) (commonledger.ResultsIterator, error) {
 	h.checkDone()
-	itr, err := newResultsItr(namespace, startKey, endKey, h.txmgr.db, h.rwset,
+	defer h.startSpan("ledger.GetStateRangeScanIterator", namespace).Finish()
+	itr, err := newResultsItr(namespace, startKey, endKey, 0, h.db, h.rwset,
 		ledgerconfig.IsQueryReadsHashingEnabled(), ledgerconfig.GetMaxDegreeQueryReadsHashing())
 	if err != nil {
 		return nil, err
 	}
+	itr.slowQueryTimer = ledgerutil.StartSlowQueryTimer("GetStateByRange", ledgerconfig.GetSlowQueryThreshold(),
+		flogging.Fields{"namespace": namespace, "startKeyHash": ledgerutil.KeyHashForLog(startKey), "endKeyHash": ledgerutil.KeyHashForLog(endKey)})
+	itr.memoryBudget = startQueryMemoryBudget("GetStateByRange")
 	h.itrs = append(h.itrs, itr)
 	return itr, nil
 }
 
+// getStateRangeScanIteratorWithPagination scans the range from the bookmark, if given, and stops after pageSize results.
+// The key that follows the page is fetched as well, so that it is returned as the bookmark. For a simulation, this key
+// is recorded along with the results of the page, which is conservative but keeps the range query info unchanged
+func (h *queryHelper) getStateRangeScanIteratorWithPagination(namespace string, startKey string, endKey string,
+	pageSize int32, bookmark string) (ledger.PaginatedResultsIterator, error) {
+	h.checkDone()
+	defer h.startSpan("ledger.GetStateRangeScanIteratorWithPagination", namespace).Finish()
+	if pageSize <= 0 {
+		return nil, fmt.Errorf("page size must be greater than zero, found [%d]", pageSize)
+	}
+	if bookmark != "" {
+		if bookmark < startKey || (endKey != "" && bookmark >= endKey) {
+			return nil, fmt.Errorf("bookmark [%s] is outside the range [%s, %s)", bookmark, startKey, endKey)
+		}
+		startKey = bookmark
+	}
+	itr, err := newResultsItr(namespace, startKey, endKey, pageSize+1, h.db, h.rwset,
+		ledgerconfig.IsQueryReadsHashingEnabled(), ledgerconfig.GetMaxDegreeQueryReadsHashing())
+	if err != nil {
+		return nil, err
+	}
+	itr.slowQueryTimer = ledgerutil.StartSlowQueryTimer("GetStateByRangeWithPagination", ledgerconfig.GetSlowQueryThreshold(),
+		flogging.Fields{"namespace": namespace, "startKeyHash": ledgerutil.KeyHashForLog(startKey), "endKeyHash": ledgerutil.KeyHashForLog(endKey)})
+	itr.memoryBudget = startQueryMemoryBudget("GetStateByRangeWithPagination")
+	h.itrs = append(h.itrs, itr)
+	return &paginatedResultsItr{resultsItr: itr, pageSize: pageSize}, nil
+}
+
 func (h *queryHelper) executeQuery(namespace, query string) (commonledger.ResultsIterator, error) {
-	dbItr, err := h.txmgr.db.ExecuteQuery(namespace, query)
+	defer h.startSpan("ledger.ExecuteQuery", namespace).Finish()
+	permit, err := h.admitRichQuery()
+	if err != nil {
+		return nil, err
+	}
+	slowQueryTimer := ledgerutil.StartSlowQueryTimer("GetQueryResult", ledgerconfig.GetSlowQueryThreshold(),
+		flogging.Fields{"namespace": namespace, "queryHash": ledgerutil.KeyHashForLog(query)})
+	dbItr, err := h.db.ExecuteQuery(namespace, query)
+	if err != nil {
+		permit.Release()
+		return nil, err
+	}
+	h.notifyRichQuery(namespace)
+	return &queryResultsItr{DBItr: dbItr, RWSet: h.rwset, slowQueryTimer: slowQueryTimer, memoryBudget: startQueryMemoryBudget("GetQueryResult"), permit: permit}, nil
+}
+
+// startQueryMemoryBudget starts the accounting of the memory of a query of the given kind against the configured budgets
+func startQueryMemoryBudget(kind string) *ledgerutil.QueryMemoryBudget {
+	return ledgerutil.StartQueryMemoryBudget(kind, ledgerconfig.GetQueryMemoryBudget(), ledgerconfig.GetGlobalQueryMemoryBudget())
+}
+
+// admitRichQuery waits for the admission of a rich query by the txmgr. The permit is released when
+// the results iterator of the query is closed or, if the iterator is leaked, when done() is invoked
+func (h *queryHelper) admitRichQuery() (*ledgerutil.QueryPermit, error) {
+	permit, err := h.txmgr.richQueryAdmission.Admit()
+	if err != nil {
+		return nil, err
+	}
+	if permit != nil {
+		h.permits = append(h.permits, permit)
+	}
+	return permit, nil
+}
+
+// notifyRichQuery notifies the rich query listener of the txmgr, if any, of a rich query executed on the namespace
+func (h *queryHelper) notifyRichQuery(namespace string) {
+	if h.txmgr.richQueryListener != nil {
+		h.txmgr.richQueryListener(namespace)
+	}
+}
+
+// executeQueryWithFields executes the query and restricts the records of the results to the given fields. The
+// projection is pushed down to the state database when it implements statedb.FieldsQueryExecutor
+func (h *queryHelper) executeQueryWithFields(namespace, query string, fields []string) (commonledger.ResultsIterator, error) {
+	if len(fields) == 0 {
+		return h.executeQuery(namespace, query)
+	}
+	defer h.startSpan("ledger.ExecuteQuery", namespace).Finish()
+	permit, err := h.admitRichQuery()
+	if err != nil {
+		return nil, err
+	}
+	slowQueryTimer := ledgerutil.StartSlowQueryTimer("GetQueryResult", ledgerconfig.GetSlowQueryThreshold(),
+		flogging.Fields{"namespace": namespace, "queryHash": ledgerutil.KeyHashForLog(query)})
+	if fieldsQueryExecutor, ok := h.db.(statedb.FieldsQueryExecutor); ok {
+		dbItr, err := fieldsQueryExecutor.ExecuteQueryWithFields(namespace, query, fields)
+		if err != nil {
+			permit.Release()
+			return nil, err
+		}
+		h.notifyRichQuery(namespace)
+		return &queryResultsItr{DBItr: dbItr, RWSet: h.rwset, slowQueryTimer: slowQueryTimer, memoryBudget: startQueryMemoryBudget("GetQueryResult"), permit: permit}, nil
+	}
+	dbItr, err := h.db.ExecuteQuery(namespace, query)
+	if err != nil {
+		permit.Release()
+		return nil, err
+	}
+	h.notifyRichQuery(namespace)
+	return &queryResultsItr{DBItr: dbItr, RWSet: h.rwset, slowQueryTimer: slowQueryTimer, memoryBudget: startQueryMemoryBudget("GetQueryResult"),
+		fields: fields, permit: permit}, nil
+}
+
+// getTotalForKeyPrefix scans the keys that begin with the given prefix and adds up the numeric field with the given name
+// in their JSON values. The scan is performed via the same iterator as a range query so that, for a simulation, the keys
+// that contributed to the total are recorded for the phantom read validation during commit
+func (h *queryHelper) getTotalForKeyPrefix(namespace string, keyPrefix string, fieldName string) (*ledger.Aggregate, error) {
+	h.checkDone()
+	itr, err := newResultsItr(namespace, keyPrefix, keyPrefix+string(utf8.MaxRune), 0, h.db, h.rwset,
+		ledgerconfig.IsQueryReadsHashingEnabled(), ledgerconfig.GetMaxDegreeQueryReadsHashing())
+	if err != nil {
+		return nil, err
+	}
+	h.itrs = append(h.itrs, itr)
+	aggregate := &ledger.Aggregate{}
+	for {
+		queryResult, err := itr.Next()
+		if err != nil {
+			return nil, err
+		}
+		if queryResult == nil {
+			break
+		}
+		kv := queryResult.(*ledger.KV)
+		fields := make(map[string]interface{})
+		if err := json.Unmarshal(kv.Value, &fields); err != nil {
+			logger.Debugf("Skipping key [%s] as the value is not a JSON object", kv.Key)
+			continue
+		}
+		number, ok := fields[fieldName].(float64)
+		if !ok {
+			logger.Debugf("Skipping key [%s] as the value does not contain a numeric field [%s]", kv.Key, fieldName)
+			continue
+		}
+		aggregate.Sum += number
+		aggregate.Count++
+	}
+	return aggregate, nil
+}
+
+// listNamespaces returns the namespaces of the state database ordered by the name
+func (h *queryHelper) listNamespaces() ([]*ledger.NamespaceInfo, error) {
+	h.checkDone()
+	counts, err := h.db.ListNamespaces()
+	if err != nil {
+		return nil, err
+	}
+	namespaces := make([]string, 0, len(counts))
+	for namespace := range counts {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+	infos := make([]*ledger.NamespaceInfo, len(namespaces))
+	for i, namespace := range namespaces {
+		infos[i] = &ledger.NamespaceInfo{Namespace: namespace, KeyCount: counts[namespace]}
+	}
+	return infos, nil
+}
+
+// getNamespaceSize returns the size of the namespace maintained by the size tracker of the txmgr
+func (h *queryHelper) getNamespaceSize(namespace string) (*ledger.NamespaceSize, error) {
+	h.checkDone()
+	if h.atHeight {
+		return nil, errNotSupportedAtHeight
+	}
+	if h.txmgr.nsSizeTracker == nil {
+		return nil, &ledger.NotEnabledError{Msg: "Namespace sizes not enabled - ledger.state.namespaceSizes is false"}
+	}
+	return h.txmgr.nsSizeTracker.Get(namespace), nil
+}
+
+// getBlockchainInfo returns the blockchain info for the height recorded in the savepoint of the state database.
+// The savepoint does not change till done() is invoked as the commits wait for the read lock to be released
+func (h *queryHelper) getBlockchainInfo() (*common.BlockchainInfo, error) {
+	h.checkDone()
+	savepoint, err := h.db.GetLatestSavePoint()
 	if err != nil {
 		return nil, err
 	}
-	return &queryResultsItr{DBItr: dbItr, RWSet: h.rwset}, nil
+	var height uint64
+	if savepoint != nil {
+		height = savepoint.BlockNum + 1
+	}
+	if h.txmgr.bcInfoRetriever == nil {
+		return &common.BlockchainInfo{Height: height}, nil
+	}
+	return h.txmgr.bcInfoRetriever(height)
 }
 
 func (h *queryHelper) done() {
 	if h.doneInvoked {
 		return
 	}
-	defer h.txmgr.commitRWLock.RUnlock()
+	if !h.atHeight {
+		defer h.txmgr.commitRWLock.RUnlock()
+	}
 	h.doneInvoked = true
+	for _, permit := range h.permits {
+		permit.Release()
+	}
 	for _, itr := range h.itrs {
 		itr.Close()
 		if h.rwset != nil {
@@ -124,15 +374,27 @@ type resultsItr struct {
 	rwSet                   *rwset.RWSet
 	rangeQueryInfo          *rwset.RangeQueryInfo
 	rangeQueryResultsHelper *rwset.RangeQueryResultsHelper
+	slowQueryTimer          *ledgerutil.SlowQueryTimer
+	memoryBudget            *ledgerutil.QueryMemoryBudget
+	guard                   itrGuard
 }
 
-func newResultsItr(ns string, startKey string, endKey string,
+// newResultsItr constructs a resultsItr over the given range. A non-zero limit is passed on to the
+// state databases that implement statedb.RangeScanLimiter, whereas the others are scanned lazily anyway
+func newResultsItr(ns string, startKey string, endKey string, limit int32,
 	db statedb.VersionedDB, rwSet *rwset.RWSet, enableHashing bool, maxDegree int) (*resultsItr, error) {
-	dbItr, err := db.GetStateRangeScanIterator(ns, startKey, endKey)
+	var dbItr statedb.ResultsIterator
+	var err error
+	if limiter, ok := db.(statedb.RangeScanLimiter); ok && limit > 0 {
+		dbItr, err = limiter.GetStateRangeScanIteratorWithLimit(ns, startKey, endKey, limit)
+	} else {
+		dbItr, err = db.GetStateRangeScanIterator(ns, startKey, endKey)
+	}
 	if err != nil {
 		return nil, err
 	}
 	itr := &resultsItr{ns: ns, dbItr: dbItr}
+	itr.guard.start(ledgerconfig.GetIteratorIdleTimeout(), itr.releaseIdle)
 	// it's a simulation request so, enable capture of range query info
 	if rwSet != nil {
 		itr.rwSet = rwSet
@@ -156,15 +418,25 @@ func newResultsItr(ns string, startKey string, endKey string,
 // set the EndKey and ItrExhausted in the Close() function but it may not be desirable to change
 // transactional behaviour based on whether the Close() was invoked or not
 func (itr *resultsItr) Next() (commonledger.QueryResult, error) {
+	if err := itr.guard.enter(); err != nil {
+		return nil, err
+	}
+	defer itr.guard.Unlock()
 	queryResult, err := itr.dbItr.Next()
 	if err != nil {
 		return nil, err
 	}
-	itr.updateRangeQueryInfo(queryResult)
 	if queryResult == nil {
+		itr.updateRangeQueryInfo(nil)
 		return nil, nil
 	}
 	versionedKV := queryResult.(*statedb.VersionedKV)
+	// the result is charged before it is recorded, so that the range of an aborted query does not extend to it
+	if err := itr.memoryBudget.Charge(len(versionedKV.Key) + len(versionedKV.Value)); err != nil {
+		return nil, err
+	}
+	itr.updateRangeQueryInfo(queryResult)
+	itr.slowQueryTimer.ResultReturned()
 	return &ledger.KV{Key: versionedKV.Key, Value: versionedKV.Value}, nil
 }
 
@@ -194,12 +466,70 @@ func (itr *resultsItr) updateRangeQueryInfo(queryResult statedb.QueryResult) {
 
 // Close implements method in interface ledger.ResultsIterator
 func (itr *resultsItr) Close() {
+	if !itr.guard.close() {
+		return
+	}
+	itr.slowQueryTimer.Stop()
+	itr.memoryBudget.Release()
+	itr.dbItr.Close()
+}
+
+// releaseIdle closes the underlying db iterator of an iterator that was leaked by the caller
+func (itr *resultsItr) releaseIdle() {
+	logger.Warningf("Releasing a range query iterator on namespace [%s] that was not used for [%s] and was not closed",
+		itr.ns, itr.guard.timeout)
+	releasedIdleIterators.Add(1, itr.ns)
+	itr.slowQueryTimer.Stop()
+	itr.memoryBudget.Release()
 	itr.dbItr.Close()
 }
 
+// paginatedResultsItr implements interface ledger.PaginatedResultsIterator
+// It returns at most pageSize results of the underlying resultsItr, and consumes one more
+// result, if present, as the bookmark
+type paginatedResultsItr struct {
+	*resultsItr
+	pageSize int32
+	fetched  int32
+	done     bool
+	bookmark string
+}
+
+// Next implements method in interface ledger.ResultsIterator
+func (itr *paginatedResultsItr) Next() (commonledger.QueryResult, error) {
+	if itr.done {
+		return nil, nil
+	}
+	queryResult, err := itr.resultsItr.Next()
+	if err != nil {
+		return nil, err
+	}
+	if queryResult == nil {
+		itr.done = true
+		return nil, nil
+	}
+	if itr.fetched == itr.pageSize {
+		itr.done = true
+		itr.bookmark = queryResult.(*ledger.KV).Key
+		return nil, nil
+	}
+	itr.fetched++
+	return queryResult, nil
+}
+
+// Bookmark implements method in interface ledger.PaginatedResultsIterator
+func (itr *paginatedResultsItr) Bookmark() string {
+	return itr.bookmark
+}
+
 type queryResultsItr struct {
-	DBItr statedb.ResultsIterator
-	RWSet *rwset.RWSet
+	DBItr          statedb.ResultsIterator
+	RWSet          *rwset.RWSet
+	slowQueryTimer *ledgerutil.SlowQueryTimer
+	memoryBudget   *ledgerutil.QueryMemoryBudget
+	// fields, when set, are projected from the records returned by the state database
+	fields []string
+	permit *ledgerutil.QueryPermit
 }
 
 // Next implements method in interface ledger.ResultsIterator
@@ -213,17 +543,28 @@ func (itr *queryResultsItr) Next() (commonledger.QueryResult, error) {
 		return nil, nil
 	}
 	versionedQueryRecord := queryResult.(*statedb.VersionedQueryRecord)
+	if err := itr.memoryBudget.Charge(len(versionedQueryRecord.Key) + len(versionedQueryRecord.Record)); err != nil {
+		return nil, err
+	}
+	itr.slowQueryTimer.ResultReturned()
 	logger.Debugf("queryResultsItr.Next() returned a record:%s", string(versionedQueryRecord.Record))
 
 	if itr.RWSet != nil {
 		itr.RWSet.AddToReadSet(versionedQueryRecord.Namespace, versionedQueryRecord.Key, versionedQueryRecord.Version)
 	}
-	return &ledger.QueryRecord{Namespace: versionedQueryRecord.Namespace, Key: versionedQueryRecord.Key, Record: versionedQueryRecord.Record}, nil
+	record := versionedQueryRecord.Record
+	if itr.fields != nil {
+		record = statedb.ProjectFields(record, itr.fields)
+	}
+	return &ledger.QueryRecord{Namespace: versionedQueryRecord.Namespace, Key: versionedQueryRecord.Key, Record: record}, nil
 }
 
 // Close implements method in interface ledger.ResultsIterator
 func (itr *queryResultsItr) Close() {
+	itr.slowQueryTimer.Stop()
+	itr.memoryBudget.Release()
 	itr.DBItr.Close()
+	itr.permit.Release()
 }
 
 func decomposeVersionedValue(versionedValue *statedb.VersionedValue) ([]byte, *version.Height) {
@@ -0,0 +1,93 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lockbasedtxmgr
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric/common/metrics"
+)
+
+var releasedIdleIterators = metrics.NewCounterVec("ledger_released_idle_iterators_total",
+	"Number of the results iterators released by the ledger after being idle without being closed.", "namespace")
+
+// errIteratorReleased is returned by the Next call on an iterator that was released for being idle
+var errIteratorReleased = errors.New("the iterator was released after being idle for longer than the idle timeout")
+
+// itrGuard serializes the calls on a results iterator and releases the iterator if it is not used for the idle timeout.
+// An iterator that is neither consumed nor closed holds a snapshot of the state database, which blocks the compaction
+// of LevelDB, until the query executor is done. The Next calls on the iterator must be enclosed by enter and Unlock
+type itrGuard struct {
+	sync.Mutex
+	timeout  time.Duration
+	timer    *time.Timer
+	lastUsed time.Time
+	closed   bool
+	released bool
+}
+
+// start starts the idle timer. The given release func is invoked, with the lock held, if the timer
+// expires before the iterator is closed. The timer is not started if the timeout is not positive
+func (g *itrGuard) start(timeout time.Duration, release func()) {
+	if timeout <= 0 {
+		return
+	}
+	g.timeout = timeout
+	g.lastUsed = time.Now()
+	g.timer = time.AfterFunc(timeout, func() {
+		g.Lock()
+		defer g.Unlock()
+		if g.closed {
+			return
+		}
+		// the timer is re-armed for the remaining duration if the iterator was used in the meantime
+		if idle := time.Since(g.lastUsed); idle < g.timeout {
+			g.timer.Reset(g.timeout - idle)
+			return
+		}
+		g.released = true
+		release()
+	})
+}
+
+// enter acquires the lock for a call on the iterator and records the use of the iterator. The lock is not held on an error
+func (g *itrGuard) enter() error {
+	g.Lock()
+	if g.released {
+		g.Unlock()
+		return errIteratorReleased
+	}
+	g.lastUsed = time.Now()
+	return nil
+}
+
+// close marks the iterator closed and stops the idle timer. It returns false if the iterator
+// has already been closed or released, in which case the underlying iterator must not be closed again
+func (g *itrGuard) close() bool {
+	g.Lock()
+	defer g.Unlock()
+	if g.closed || g.released {
+		return false
+	}
+	g.closed = true
+	if g.timer != nil {
+		g.timer.Stop()
+	}
+	return true
+}
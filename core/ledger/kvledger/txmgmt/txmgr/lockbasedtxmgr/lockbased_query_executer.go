@@ -19,6 +19,10 @@ package lockbasedtxmgr
 import (
 	"github.com/hyperledger/fabric/common/ledger"
 	"github.com/hyperledger/fabric/common/util"
+	coreledger "github.com/hyperledger/fabric/core/ledger"
+	ledgerutil "github.com/hyperledger/fabric/core/ledger/util"
+	"github.com/hyperledger/fabric/protos/common"
+	"golang.org/x/net/context"
 )
 
 // LockBasedQueryExecutor is a query executor used in `LockBasedTxMgr`
@@ -28,12 +32,19 @@ type lockBasedQueryExecutor struct {
 }
 
 func newQueryExecutor(txmgr *LockBasedTxMgr) *lockBasedQueryExecutor {
-	helper := &queryHelper{txmgr: txmgr, rwset: nil}
+	helper := &queryHelper{txmgr: txmgr, db: txmgr.db, rwset: nil}
 	id := util.GenerateUUID()
 	logger.Debugf("constructing new query executor [%s]", id)
 	return &lockBasedQueryExecutor{helper, id}
 }
 
+func newQueryExecutorAtHeight(txmgr *LockBasedTxMgr, state *stateAtHeight) *lockBasedQueryExecutor {
+	helper := &queryHelper{txmgr: txmgr, db: state, rwset: nil, atHeight: true}
+	id := util.GenerateUUID()
+	logger.Debugf("constructing new query executor [%s] at height [%d]", id, state.height)
+	return &lockBasedQueryExecutor{helper, id}
+}
+
 // GetState implements method in interface `ledger.QueryExecutor`
 func (q *lockBasedQueryExecutor) GetState(ns string, key string) ([]byte, error) {
 	return q.helper.getState(ns, key)
@@ -44,6 +55,16 @@ func (q *lockBasedQueryExecutor) GetStateMultipleKeys(namespace string, keys []s
 	return q.helper.getStateMultipleKeys(namespace, keys)
 }
 
+// GetStateHash implements method in interface `ledger.QueryExecutor`
+func (q *lockBasedQueryExecutor) GetStateHash(namespace string, key string) ([]byte, error) {
+	return q.helper.getStateHash(namespace, key)
+}
+
+// GetPrivateDataHash implements method in interface `ledger.QueryExecutor`
+func (q *lockBasedQueryExecutor) GetPrivateDataHash(namespace string, collection string, key string) ([]byte, error) {
+	return q.helper.getState(ledgerutil.DeriveHashedDataNs(namespace, collection), ledgerutil.DeriveHashedKey(key))
+}
+
 // GetStateRangeScanIterator implements method in interface `ledger.QueryExecutor`
 // startKey is included in the results and endKey is excluded. An empty startKey refers to the first available key
 // and an empty endKey refers to the last available key. For scanning all the keys, both the startKey and the endKey
@@ -52,11 +73,52 @@ func (q *lockBasedQueryExecutor) GetStateRangeScanIterator(namespace string, sta
 	return q.helper.getStateRangeScanIterator(namespace, startKey, endKey)
 }
 
+// GetStateRangeScanIteratorWithPagination implements method in interface `ledger.QueryExecutor`
+func (q *lockBasedQueryExecutor) GetStateRangeScanIteratorWithPagination(namespace string, startKey string, endKey string,
+	pageSize int32, bookmark string) (coreledger.PaginatedResultsIterator, error) {
+	return q.helper.getStateRangeScanIteratorWithPagination(namespace, startKey, endKey, pageSize, bookmark)
+}
+
 // ExecuteQuery implements method in interface `ledger.QueryExecutor`
 func (q *lockBasedQueryExecutor) ExecuteQuery(namespace, query string) (ledger.ResultsIterator, error) {
 	return q.helper.executeQuery(namespace, query)
 }
 
+// ExecuteQueryWithFields implements method in interface `ledger.QueryExecutor`
+func (q *lockBasedQueryExecutor) ExecuteQueryWithFields(namespace, query string, fields []string) (ledger.ResultsIterator, error) {
+	return q.helper.executeQueryWithFields(namespace, query, fields)
+}
+
+// GetTotalForKeyPrefix implements method in interface `ledger.QueryExecutor`
+func (q *lockBasedQueryExecutor) GetTotalForKeyPrefix(namespace string, keyPrefix string, fieldName string) (*coreledger.Aggregate, error) {
+	return q.helper.getTotalForKeyPrefix(namespace, keyPrefix, fieldName)
+}
+
+// GetSequenceCount implements method in interface `ledger.QueryExecutor`
+func (q *lockBasedQueryExecutor) GetSequenceCount(namespace string, name string) (uint64, error) {
+	return q.helper.getSequenceCount(namespace, name)
+}
+
+// ListNamespaces implements method in interface `ledger.QueryExecutor`
+func (q *lockBasedQueryExecutor) ListNamespaces() ([]*coreledger.NamespaceInfo, error) {
+	return q.helper.listNamespaces()
+}
+
+// GetNamespaceSize implements method in interface `ledger.QueryExecutor`
+func (q *lockBasedQueryExecutor) GetNamespaceSize(namespace string) (*coreledger.NamespaceSize, error) {
+	return q.helper.getNamespaceSize(namespace)
+}
+
+// GetBlockchainInfo implements method in interface `ledger.QueryExecutor`
+func (q *lockBasedQueryExecutor) GetBlockchainInfo() (*common.BlockchainInfo, error) {
+	return q.helper.getBlockchainInfo()
+}
+
+// SetTraceContext implements method in interface `ledger.TraceableQueryExecutor`
+func (q *lockBasedQueryExecutor) SetTraceContext(ctx context.Context) {
+	q.helper.traceCtx = ctx
+}
+
 // Done implements method in interface `ledger.QueryExecutor`
 func (q *lockBasedQueryExecutor) Done() {
 	logger.Debugf("Done with transaction simulation / query execution [%s]", q.id)
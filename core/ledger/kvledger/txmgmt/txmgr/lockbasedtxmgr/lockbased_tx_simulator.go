@@ -18,23 +18,37 @@ package lockbasedtxmgr
 
 import (
 	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
 
+	commonledgerutil "github.com/hyperledger/fabric/common/ledger/util"
 	"github.com/hyperledger/fabric/common/util"
+	"github.com/hyperledger/fabric/core/ledger"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/rwset"
+	ledgerutil "github.com/hyperledger/fabric/core/ledger/util"
 )
 
+// compositeKeyDelimiter separates the attributes of the composite keys built by the chaincodes
+const compositeKeyDelimiter = rune(0)
+
 // LockBasedTxSimulator is a transaction simulator used in `LockBasedTxMgr`
 type lockBasedTxSimulator struct {
 	lockBasedQueryExecutor
 	rwset *rwset.RWSet
+	// pvtWrites holds the private writes of the transaction, keyed by the namespace and then by the collection
+	pvtWrites map[string]map[string]*rwset.RWSet
+	// sequenceCounters holds the counters of the sequences incremented by the transaction, keyed by the namespace and the key of the counter
+	sequenceCounters map[string]uint64
 }
 
 func newLockBasedTxSimulator(txmgr *LockBasedTxMgr) *lockBasedTxSimulator {
 	rwset := rwset.NewRWSet()
-	helper := &queryHelper{txmgr: txmgr, rwset: rwset}
+	helper := &queryHelper{txmgr: txmgr, db: txmgr.db, rwset: rwset}
 	id := util.GenerateUUID()
 	logger.Debugf("constructing new tx simulator [%s]", id)
-	return &lockBasedTxSimulator{lockBasedQueryExecutor{helper, id}, rwset}
+	return &lockBasedTxSimulator{lockBasedQueryExecutor{helper, id}, rwset, nil, nil}
 }
 
 // GetState implements method in interface `ledger.TxSimulator`
@@ -45,7 +59,29 @@ func (s *lockBasedTxSimulator) GetState(ns string, key string) ([]byte, error) {
 // SetState implements method in interface `ledger.TxSimulator`
 func (s *lockBasedTxSimulator) SetState(ns string, key string, value []byte) error {
 	s.helper.checkDone()
+	if value != nil {
+		if err := validateKey(ns, key); err != nil {
+			return err
+		}
+	}
 	s.rwset.AddToWriteSet(ns, key, value)
+	// a later write of the key supersedes the time-to-live set by an earlier write
+	s.rwset.RemoveFromWriteSet(ledgerutil.DeriveTTLNs(ns), key)
+	return nil
+}
+
+// SetStateWithTTL implements method in interface `ledger.TxSimulator`
+func (s *lockBasedTxSimulator) SetStateWithTTL(ns string, key string, value []byte, ttl uint64) error {
+	if ttl == 0 {
+		return errors.New("ttl must be greater than zero")
+	}
+	if value == nil {
+		return errors.New("ttl cannot be set for a delete")
+	}
+	if err := s.SetState(ns, key, value); err != nil {
+		return err
+	}
+	s.rwset.AddToWriteSet(ledgerutil.DeriveTTLNs(ns), key, commonledgerutil.EncodeOrderPreservingVarUint64(ttl))
 	return nil
 }
 
@@ -54,6 +90,55 @@ func (s *lockBasedTxSimulator) DeleteState(ns string, key string) error {
 	return s.SetState(ns, key, nil)
 }
 
+// DeleteStateByRange implements method in interface `ledger.TxSimulator`
+func (s *lockBasedTxSimulator) DeleteStateByRange(ns string, startKey string, endKey string) error {
+	s.helper.checkDone()
+	s.rwset.AddToRangeDeleteSet(ns, startKey, endKey)
+	s.rwset.RemoveRangeFromWriteSet(ledgerutil.DeriveTTLNs(ns), startKey, endKey)
+	return nil
+}
+
+// SetPrivateData implements method in interface `ledger.TxSimulator`
+func (s *lockBasedTxSimulator) SetPrivateData(ns string, coll string, key string, value []byte) error {
+	s.helper.checkDone()
+	if value != nil {
+		if err := validateKey(ns, key); err != nil {
+			return err
+		}
+	}
+	if s.pvtWrites == nil {
+		s.pvtWrites = make(map[string]map[string]*rwset.RWSet)
+	}
+	collWrites, ok := s.pvtWrites[ns]
+	if !ok {
+		collWrites = make(map[string]*rwset.RWSet)
+		s.pvtWrites[ns] = collWrites
+	}
+	writes, ok := collWrites[coll]
+	if !ok {
+		writes = rwset.NewRWSet()
+		collWrites[coll] = writes
+	}
+	writes.AddToWriteSet(ns, key, value)
+
+	var valueHash []byte
+	if value != nil {
+		valueHash = ledgerutil.ComputePvtDataHash(value)
+	}
+	s.rwset.AddToWriteSet(ledgerutil.DeriveHashedDataNs(ns, coll), ledgerutil.DeriveHashedKey(key), valueHash)
+	return nil
+}
+
+// DeletePrivateData implements method in interface `ledger.TxSimulator`
+func (s *lockBasedTxSimulator) DeletePrivateData(ns string, coll string, key string) error {
+	return s.SetPrivateData(ns, coll, key, nil)
+}
+
+// NextSequenceValue implements method in interface `ledger.TxSimulator`
+func (s *lockBasedTxSimulator) NextSequenceValue(namespace string, name string, txID string) (uint64, error) {
+	return s.nextSequenceValue(namespace, name, txID)
+}
+
 // SetStateMultipleKeys implements method in interface `ledger.TxSimulator`
 func (s *lockBasedTxSimulator) SetStateMultipleKeys(namespace string, kvs map[string][]byte) error {
 	for k, v := range kvs {
@@ -64,6 +149,25 @@ func (s *lockBasedTxSimulator) SetStateMultipleKeys(namespace string, kvs map[st
 	return nil
 }
 
+// validateKey returns a ledger.InvalidKeyError if the given key contains the characters reserved by the ledger.
+// A key is a valid UTF-8 string that does not contain U+10FFFF, which ends the ranges of the queries by partial
+// composite key, and that contains U+0000 only as the delimiter of the attributes of a composite key, which ends
+// with the delimiter. The keys are checked on a write only, so that a key written before the check can be deleted
+func validateKey(ns string, key string) error {
+	if !utf8.ValidString(key) {
+		return &ledger.InvalidKeyError{Msg: fmt.Sprintf("Key [%x] of namespace [%s] is not a valid UTF-8 string", key, ns)}
+	}
+	if index := strings.IndexRune(key, utf8.MaxRune); index >= 0 {
+		return &ledger.InvalidKeyError{Msg: fmt.Sprintf("Key [%q] of namespace [%s] contains the reserved character %U at position [%d]",
+			key, ns, utf8.MaxRune, index)}
+	}
+	if index := strings.IndexRune(key, compositeKeyDelimiter); index >= 0 && key[len(key)-1] != byte(compositeKeyDelimiter) {
+		return &ledger.InvalidKeyError{Msg: fmt.Sprintf("Key [%q] of namespace [%s] contains the composite key delimiter %U at position [%d] but is not a composite key",
+			key, ns, compositeKeyDelimiter, index)}
+	}
+	return nil
+}
+
 // GetTxSimulationResults implements method in interface `ledger.TxSimulator`
 func (s *lockBasedTxSimulator) GetTxSimulationResults() ([]byte, error) {
 	logger.Debugf("Simulation completed, getting simulation results")
@@ -74,7 +178,28 @@ func (s *lockBasedTxSimulator) GetTxSimulationResults() ([]byte, error) {
 	return s.rwset.GetTxReadWriteSet().Marshal()
 }
 
+// GetPvtSimulationResults implements method in interface `ledger.TxSimulator`
+func (s *lockBasedTxSimulator) GetPvtSimulationResults() ([]*ledger.CollPvtWriteSet, error) {
+	var pvtWriteSets []*ledger.CollPvtWriteSet
+	for _, ns := range ledgerutil.GetSortedKeys(s.pvtWrites) {
+		collWrites := s.pvtWrites[ns]
+		colls := make([]string, 0, len(collWrites))
+		for coll := range collWrites {
+			colls = append(colls, coll)
+		}
+		sort.Strings(colls)
+		for _, coll := range colls {
+			writeSet, err := collWrites[coll].GetTxReadWriteSet().Marshal()
+			if err != nil {
+				return nil, err
+			}
+			pvtWriteSets = append(pvtWriteSets, &ledger.CollPvtWriteSet{Namespace: ns, CollectionName: coll, WriteSet: writeSet})
+		}
+	}
+	return pvtWriteSets, nil
+}
+
 // ExecuteUpdate implements method in interface `ledger.TxSimulator`
 func (s *lockBasedTxSimulator) ExecuteUpdate(query string) error {
-	return errors.New("Not supported")
+	return &ledger.NotEnabledError{Msg: "Not supported"}
 }
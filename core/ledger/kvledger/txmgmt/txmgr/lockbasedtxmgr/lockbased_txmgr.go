@@ -17,33 +17,81 @@ limitations under the License.
 package lockbasedtxmgr
 
 import (
+	"sort"
 	"sync"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric/core/ledger"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/validator"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/validator/statebasedval"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
+	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+	ledgerutil "github.com/hyperledger/fabric/core/ledger/util"
 	"github.com/hyperledger/fabric/protos/common"
 	"github.com/op/go-logging"
 )
 
 var logger = logging.MustGetLogger("lockbasedtxmgr")
 
+// BlockchainInfoRetriever returns the blockchain info for the given height of the blockchain.
+// This is supplied by the ledger so that a query executor can report the blockchain info
+// that corresponds to the state it reads
+type BlockchainInfoRetriever func(height uint64) (*common.BlockchainInfo, error)
+
+// NamespaceSizeTracker maintains the number of the keys and the bytes of each namespace of the state database.
+// This is supplied by the ledger so that a query executor can report the size of a namespace
+type NamespaceSizeTracker interface {
+	// Update is invoked with the updates of each block before they are applied to the state database,
+	// while the state database still holds the values that the updates replace
+	Update(batch *statedb.UpdateBatch)
+	// Get returns the size of the given namespace
+	Get(namespace string) *ledger.NamespaceSize
+}
+
 // LockBasedTxMgr a simple implementation of interface `txmgmt.TxMgr`.
 // This implementation uses a read-write lock to prevent conflicts between transaction simulation and committing
 type LockBasedTxMgr struct {
-	db           statedb.VersionedDB
-	validator    validator.Validator
-	batch        *statedb.UpdateBatch
-	currentBlock *common.Block
-	commitRWLock sync.RWMutex
+	db              statedb.VersionedDB
+	validator       validator.Validator
+	batch           *statedb.UpdateBatch
+	currentBlock    *common.Block
+	commitRWLock    sync.RWMutex
+	bcInfoRetriever BlockchainInfoRetriever
+	// richQueryAdmission limits the rich queries that run concurrently on the state database
+	richQueryAdmission *ledgerutil.QueryAdmission
+	// richQueryListener, if set, is notified of the namespace of each rich query executed
+	richQueryListener func(namespace string)
+	// nsSizeTracker, if set, maintains the sizes of the namespaces
+	nsSizeTracker NamespaceSizeTracker
+	// recentVersions, if set, retains the values replaced by the recent blocks for the query executors at a height
+	recentVersions *recentVersions
 }
 
-// NewLockBasedTxMgr constructs a new instance of NewLockBasedTxMgr
-func NewLockBasedTxMgr(db statedb.VersionedDB) *LockBasedTxMgr {
+// NewLockBasedTxMgr constructs a new instance of NewLockBasedTxMgr.
+// If bcInfoRetriever is nil, the query executors report only the height in the blockchain info
+func NewLockBasedTxMgr(db statedb.VersionedDB, bcInfoRetriever BlockchainInfoRetriever) *LockBasedTxMgr {
 	db.Open()
-	return &LockBasedTxMgr{db: db, validator: statebasedval.NewValidator(db)}
+	richQueryAdmission := ledgerutil.NewQueryAdmission("rich queries", ledgerconfig.GetRichQueryConcurrencyLimit(),
+		ledgerconfig.GetQueryAdmissionQueueSize(), ledgerconfig.GetQueryAdmissionQueueTimeout())
+	txmgr := &LockBasedTxMgr{db: db, validator: statebasedval.NewValidator(db), bcInfoRetriever: bcInfoRetriever,
+		richQueryAdmission: richQueryAdmission}
+	if maxBlocks := ledgerconfig.GetRecentStateVersions(); maxBlocks > 0 {
+		txmgr.recentVersions = newRecentVersions(maxBlocks)
+	}
+	return txmgr
+}
+
+// SetRichQueryListener sets the listener that is notified of the namespace of each rich query executed. This is
+// expected to be invoked before the txmgr is used
+func (txmgr *LockBasedTxMgr) SetRichQueryListener(listener func(namespace string)) {
+	txmgr.richQueryListener = listener
+}
+
+// SetNamespaceSizeTracker sets the tracker that maintains the sizes of the namespaces. This is
+// expected to be invoked before the txmgr is used for the commits
+func (txmgr *LockBasedTxMgr) SetNamespaceSizeTracker(tracker NamespaceSizeTracker) {
+	txmgr.nsSizeTracker = tracker
 }
 
 // GetLastSavepoint returns the block num recorded in savepoint,
@@ -52,6 +100,83 @@ func (txmgr *LockBasedTxMgr) GetLastSavepoint() (*version.Height, error) {
 	return txmgr.db.GetLatestSavePoint()
 }
 
+// NewStateSnapshotIterator implements method in interface `txmgmt.TxMgr`
+// The returned iterator covers all the key-values in the state database as of the returned savepoint.
+// Commits to the state database are blocked until the iterator is closed
+func (txmgr *LockBasedTxMgr) NewStateSnapshotIterator() (statedb.ResultsIterator, *version.Height, error) {
+	txmgr.commitRWLock.RLock()
+	savepoint, err := txmgr.db.GetLatestSavePoint()
+	if err != nil {
+		txmgr.commitRWLock.RUnlock()
+		return nil, nil, err
+	}
+	itr, err := txmgr.db.GetFullScanIterator()
+	if err != nil {
+		txmgr.commitRWLock.RUnlock()
+		return nil, nil, err
+	}
+	return &snapshotIterator{itr, txmgr}, savepoint, nil
+}
+
+// NewNamespacesSnapshotIterator implements method in interface `txmgmt.TxMgr`
+// The returned iterator covers the key-values of the given namespaces, in the given order, as of the returned savepoint.
+// Only the given namespaces are scanned. Commits to the state database are blocked until the iterator is closed
+func (txmgr *LockBasedTxMgr) NewNamespacesSnapshotIterator(namespaces []string) (statedb.ResultsIterator, *version.Height, error) {
+	txmgr.commitRWLock.RLock()
+	savepoint, err := txmgr.db.GetLatestSavePoint()
+	if err != nil {
+		txmgr.commitRWLock.RUnlock()
+		return nil, nil, err
+	}
+	return &snapshotIterator{&namespacesIterator{db: txmgr.db, namespaces: namespaces}, txmgr}, savepoint, nil
+}
+
+// namespacesIterator scans the namespaces one after the other
+type namespacesIterator struct {
+	db         statedb.VersionedDB
+	namespaces []string
+	current    statedb.ResultsIterator
+}
+
+func (itr *namespacesIterator) Next() (statedb.QueryResult, error) {
+	for {
+		if itr.current == nil {
+			if len(itr.namespaces) == 0 {
+				return nil, nil
+			}
+			current, err := itr.db.GetStateRangeScanIterator(itr.namespaces[0], "", "")
+			if err != nil {
+				return nil, err
+			}
+			itr.current = current
+			itr.namespaces = itr.namespaces[1:]
+		}
+		result, err := itr.current.Next()
+		if err != nil || result != nil {
+			return result, err
+		}
+		itr.current.Close()
+		itr.current = nil
+	}
+}
+
+func (itr *namespacesIterator) Close() {
+	if itr.current != nil {
+		itr.current.Close()
+	}
+}
+
+// snapshotIterator releases the read lock on the txmgr when closed
+type snapshotIterator struct {
+	statedb.ResultsIterator
+	txmgr *LockBasedTxMgr
+}
+
+func (itr *snapshotIterator) Close() {
+	itr.ResultsIterator.Close()
+	itr.txmgr.commitRWLock.RUnlock()
+}
+
 // NewQueryExecutor implements method in interface `txmgmt.TxMgr`
 func (txmgr *LockBasedTxMgr) NewQueryExecutor() (ledger.QueryExecutor, error) {
 	qe := newQueryExecutor(txmgr)
@@ -59,6 +184,19 @@ func (txmgr *LockBasedTxMgr) NewQueryExecutor() (ledger.QueryExecutor, error) {
 	return qe, nil
 }
 
+// NewQueryExecutorAtHeight implements method in interface `txmgmt.TxMgr`
+// The returned query executor reads the state as of the given height, i.e., after the commit of the block height-1,
+// without blocking the commits. The height should be within the recent blocks whose replaced values are retained
+func (txmgr *LockBasedTxMgr) NewQueryExecutorAtHeight(height uint64) (ledger.QueryExecutor, error) {
+	if txmgr.recentVersions == nil {
+		return nil, &ledger.NotEnabledError{Msg: "Recent state versions not enabled - ledger.state.recentVersions is not set"}
+	}
+	if err := txmgr.recentVersions.checkHeight(txmgr.db, height); err != nil {
+		return nil, err
+	}
+	return newQueryExecutorAtHeight(txmgr, &stateAtHeight{db: txmgr.db, recentVersions: txmgr.recentVersions, height: height}), nil
+}
+
 // NewTxSimulator implements method in interface `txmgmt.TxMgr`
 func (txmgr *LockBasedTxMgr) NewTxSimulator() (ledger.TxSimulator, error) {
 	logger.Debugf("constructing new tx simulator")
@@ -68,15 +206,19 @@ func (txmgr *LockBasedTxMgr) NewTxSimulator() (ledger.TxSimulator, error) {
 }
 
 // ValidateAndPrepare implements method in interface `txmgmt.TxMgr`
-func (txmgr *LockBasedTxMgr) ValidateAndPrepare(block *common.Block, doMVCCValidation bool) error {
+func (txmgr *LockBasedTxMgr) ValidateAndPrepare(block *common.Block, doMVCCValidation bool) ([]byte, error) {
 	logger.Debugf("Validating new block with num trans = [%d]", len(block.Data.Data))
 	batch, err := txmgr.validator.ValidateAndPrepareBatch(block, doMVCCValidation)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	updateBytes, err := deterministicBytesForUpdates(batch)
+	if err != nil {
+		return nil, err
 	}
 	txmgr.currentBlock = block
 	txmgr.batch = batch
-	return err
+	return updateBytes, nil
 }
 
 // Shutdown implements method in interface `txmgmt.TxMgr`
@@ -94,8 +236,20 @@ func (txmgr *LockBasedTxMgr) Commit() error {
 		panic("validateAndPrepare() method should have been called before calling commit()")
 	}
 	defer func() { txmgr.batch = nil }()
+	if txmgr.nsSizeTracker != nil {
+		txmgr.nsSizeTracker.Update(txmgr.batch)
+	}
+	blockNum := txmgr.currentBlock.Header.Number
+	if txmgr.recentVersions != nil {
+		if err := txmgr.recentVersions.record(txmgr.db, txmgr.batch, blockNum); err != nil {
+			return err
+		}
+	}
 	if err := txmgr.db.ApplyUpdates(txmgr.batch,
-		version.NewHeight(txmgr.currentBlock.Header.Number, uint64(len(txmgr.currentBlock.Data.Data)))); err != nil {
+		version.NewHeight(blockNum, uint64(len(txmgr.currentBlock.Data.Data)))); err != nil {
+		if txmgr.recentVersions != nil {
+			txmgr.recentVersions.discard(blockNum)
+		}
 		return err
 	}
 	logger.Debugf("Updates committed to state database")
@@ -122,7 +276,7 @@ func (txmgr *LockBasedTxMgr) ShouldRecover(lastAvailableBlock uint64) (bool, uin
 // CommitLostBlock implements method in interface kvledger.Recoverer
 func (txmgr *LockBasedTxMgr) CommitLostBlock(block *common.Block) error {
 	logger.Debugf("Constructing updateSet for the block %d", block.Header.Number)
-	if err := txmgr.ValidateAndPrepare(block, false); err != nil {
+	if _, err := txmgr.ValidateAndPrepare(block, false); err != nil {
 		return err
 	}
 	logger.Debugf("Committing block %d to state database", block.Header.Number)
@@ -131,3 +285,39 @@ func (txmgr *LockBasedTxMgr) CommitLostBlock(block *common.Block) error {
 	}
 	return nil
 }
+
+// deterministicBytesForUpdates serializes the updates in the sorted order of namespaces and keys so that
+// the same updates produce the same bytes on every peer. A delete is serialized with an empty value
+// and a delete marker. The versions are not included as they are derived from the block itself
+func deterministicBytesForUpdates(batch *statedb.UpdateBatch) ([]byte, error) {
+	buffer := proto.NewBuffer([]byte{})
+	namespaces := batch.GetUpdatedNamespaces()
+	sort.Strings(namespaces)
+	for _, ns := range namespaces {
+		updates := batch.GetUpdates(ns)
+		keys := make([]string, 0, len(updates))
+		for key := range updates {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			var deleteMarker uint64
+			if updates[key].Value == nil {
+				deleteMarker = 1
+			}
+			if err := buffer.EncodeStringBytes(ns); err != nil {
+				return nil, err
+			}
+			if err := buffer.EncodeStringBytes(key); err != nil {
+				return nil, err
+			}
+			if err := buffer.EncodeVarint(deleteMarker); err != nil {
+				return nil, err
+			}
+			if err := buffer.EncodeRawBytes(updates[key].Value); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return buffer.Bytes(), nil
+}
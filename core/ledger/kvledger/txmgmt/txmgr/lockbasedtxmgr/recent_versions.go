@@ -0,0 +1,345 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lockbasedtxmgr
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
+)
+
+// errNotSupportedAtHeight is returned for the queries that a query executor at a height cannot serve
+var errNotSupportedAtHeight = errors.New("the query is not supported by a query executor at a height")
+
+// recentVersions retains the values replaced by the most recent blocks committed to the state database, so that the
+// state as of a recent height can be read by overlaying the replaced values on the current state. The values replaced
+// by a block are recorded before the updates of the block are applied, so that a reader that finds an update of the
+// block in the state database also finds the value that the update replaced
+type recentVersions struct {
+	lock      sync.RWMutex
+	maxBlocks int
+	// height is the height of the state database, including the blocks being applied. It is read from the
+	// savepoint of the state database when a query executor at a height is requested before any block is recorded
+	height      uint64
+	initialized bool
+	// blocks holds the values replaced by the most recent blocks in the order of the commits
+	blocks []*replacedValues
+}
+
+// replacedValues holds the values replaced by the updates of a block. A nil value stands for a key that did not exist
+type replacedValues struct {
+	blockNum uint64
+	values   map[string]map[string]*statedb.VersionedValue
+	// sortedKeys holds the replaced keys of each namespace in the sorted order, for the range scans
+	sortedKeys map[string][]string
+}
+
+func newRecentVersions(maxBlocks int) *recentVersions {
+	return &recentVersions{maxBlocks: maxBlocks}
+}
+
+// record reads the values that the given batch replaces from the state database and retains them for the given block.
+// The values replaced by the oldest block are discarded when more than maxBlocks blocks are retained
+func (v *recentVersions) record(db statedb.VersionedDB, batch *statedb.UpdateBatch, blockNum uint64) error {
+	replaced := &replacedValues{blockNum: blockNum,
+		values:     make(map[string]map[string]*statedb.VersionedValue),
+		sortedKeys: make(map[string][]string)}
+	for _, ns := range batch.GetUpdatedNamespaces() {
+		updates := batch.GetUpdates(ns)
+		keys := make([]string, 0, len(updates))
+		for key := range updates {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		values, err := db.GetStateMultipleKeys(ns, keys)
+		if err != nil {
+			return err
+		}
+		nsValues := make(map[string]*statedb.VersionedValue, len(keys))
+		for i, key := range keys {
+			nsValues[key] = values[i]
+		}
+		replaced.values[ns] = nsValues
+		replaced.sortedKeys[ns] = keys
+	}
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	v.blocks = append(v.blocks, replaced)
+	if len(v.blocks) > v.maxBlocks {
+		v.blocks[0] = nil
+		v.blocks = v.blocks[1:]
+	}
+	v.height = blockNum + 1
+	v.initialized = true
+	return nil
+}
+
+// discard removes the values recorded for the given block, which could not be applied to the state database
+func (v *recentVersions) discard(blockNum uint64) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	last := len(v.blocks) - 1
+	if last < 0 || v.blocks[last].blockNum != blockNum {
+		return
+	}
+	v.blocks = v.blocks[:last]
+	v.height = blockNum
+}
+
+// checkHeight returns an error if the state as of the given height cannot be read
+func (v *recentVersions) checkHeight(db statedb.VersionedDB, height uint64) error {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	if !v.initialized {
+		// no block is being applied as the blocks are recorded before they are applied
+		savepoint, err := db.GetLatestSavePoint()
+		if err != nil {
+			return err
+		}
+		if savepoint != nil {
+			v.height = savepoint.BlockNum + 1
+		}
+		v.initialized = true
+	}
+	if height > v.height {
+		return fmt.Errorf("height [%d] is beyond the height [%d] of the state", height, v.height)
+	}
+	return v.checkRetained(height)
+}
+
+// checkRetained returns an error if the values replaced by the blocks committed since the given height are
+// not retained anymore. It is expected to be invoked with the lock held
+func (v *recentVersions) checkRetained(height uint64) error {
+	oldest := v.height
+	if len(v.blocks) > 0 {
+		oldest = v.blocks[0].blockNum
+	}
+	if height < oldest {
+		return fmt.Errorf("height [%d] precedes the oldest height [%d] retained for the reads of the recent state", height, oldest)
+	}
+	return nil
+}
+
+// valueAt returns the value of the given key as of the given height if the key was updated by a block committed since
+// the height. The value is found in the first such block. It is expected to be invoked with the lock held
+func (v *recentVersions) valueAt(ns string, key string, height uint64) (*statedb.VersionedValue, bool) {
+	for _, replaced := range v.blocks {
+		if replaced.blockNum < height {
+			continue
+		}
+		if value, ok := replaced.values[ns][key]; ok {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+// overlay replaces the given current values of the given keys with their values as of the given height
+func (v *recentVersions) overlay(ns string, keys []string, values []*statedb.VersionedValue, height uint64) error {
+	v.lock.RLock()
+	defer v.lock.RUnlock()
+	if err := v.checkRetained(height); err != nil {
+		return err
+	}
+	for i, key := range keys {
+		if value, ok := v.valueAt(ns, key, height); ok {
+			values[i] = value
+		}
+	}
+	return nil
+}
+
+// nextReplacedKey returns the smallest key of the given namespace in the range [fromKey, endKey) that was updated by a
+// block committed since the given height, along with its value as of the height. An empty endKey leaves the range open
+func (v *recentVersions) nextReplacedKey(ns string, fromKey string, endKey string, height uint64) (string, *statedb.VersionedValue, bool, error) {
+	v.lock.RLock()
+	defer v.lock.RUnlock()
+	if err := v.checkRetained(height); err != nil {
+		return "", nil, false, err
+	}
+	found := false
+	var nextKey string
+	for _, replaced := range v.blocks {
+		if replaced.blockNum < height {
+			continue
+		}
+		keys := replaced.sortedKeys[ns]
+		i := sort.SearchStrings(keys, fromKey)
+		if i == len(keys) || (endKey != "" && keys[i] >= endKey) {
+			continue
+		}
+		if !found || keys[i] < nextKey {
+			nextKey = keys[i]
+			found = true
+		}
+	}
+	if !found {
+		return "", nil, false, nil
+	}
+	value, _ := v.valueAt(ns, nextKey, height)
+	return nextKey, value, true, nil
+}
+
+// stateAtHeight implements the reads of interface statedb.VersionedDB for the state as of a recent height by overlaying
+// the values replaced by the blocks committed since the height on the current state. The reads are not blocked by the
+// commits, and fail once the values replaced since the height are not retained anymore
+type stateAtHeight struct {
+	db             statedb.VersionedDB
+	recentVersions *recentVersions
+	height         uint64
+}
+
+// GetState implements method in interface `statedb.VersionedDB`
+func (s *stateAtHeight) GetState(namespace string, key string) (*statedb.VersionedValue, error) {
+	values, err := s.GetStateMultipleKeys(namespace, []string{key})
+	if err != nil {
+		return nil, err
+	}
+	return values[0], nil
+}
+
+// GetStateMultipleKeys implements method in interface `statedb.VersionedDB`
+func (s *stateAtHeight) GetStateMultipleKeys(namespace string, keys []string) ([]*statedb.VersionedValue, error) {
+	values, err := s.db.GetStateMultipleKeys(namespace, keys)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.recentVersions.overlay(namespace, keys, values, s.height); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// GetStateRangeScanIterator implements method in interface `statedb.VersionedDB`
+func (s *stateAtHeight) GetStateRangeScanIterator(namespace string, startKey string, endKey string) (statedb.ResultsIterator, error) {
+	dbItr, err := s.db.GetStateRangeScanIterator(namespace, startKey, endKey)
+	if err != nil {
+		return nil, err
+	}
+	return &rangeItrAtHeight{state: s, ns: namespace, fromKey: startKey, endKey: endKey, dbItr: dbItr}, nil
+}
+
+// GetFullScanIterator implements method in interface `statedb.VersionedDB`
+func (s *stateAtHeight) GetFullScanIterator() (statedb.ResultsIterator, error) {
+	return nil, errNotSupportedAtHeight
+}
+
+// ListNamespaces implements method in interface `statedb.VersionedDB`
+func (s *stateAtHeight) ListNamespaces() (map[string]uint64, error) {
+	return nil, errNotSupportedAtHeight
+}
+
+// ExecuteQuery implements method in interface `statedb.VersionedDB`
+func (s *stateAtHeight) ExecuteQuery(namespace, query string) (statedb.ResultsIterator, error) {
+	return nil, errNotSupportedAtHeight
+}
+
+// ApplyUpdates implements method in interface `statedb.VersionedDB`
+func (s *stateAtHeight) ApplyUpdates(batch *statedb.UpdateBatch, height *version.Height) error {
+	return errors.New("the state as of a height is read only")
+}
+
+// GetLatestSavePoint implements method in interface `statedb.VersionedDB`
+// Only the block number of the returned savepoint is meaningful, as the number of the transactions is not retained
+func (s *stateAtHeight) GetLatestSavePoint() (*version.Height, error) {
+	if s.height == 0 {
+		return nil, nil
+	}
+	return version.NewHeight(s.height-1, 0), nil
+}
+
+// GetDiskUsage implements method in interface `statedb.VersionedDB`
+func (s *stateAtHeight) GetDiskUsage() (int64, error) {
+	return s.db.GetDiskUsage()
+}
+
+// Sync implements method in interface `statedb.VersionedDB`
+func (s *stateAtHeight) Sync() error {
+	return nil
+}
+
+// Open implements method in interface `statedb.VersionedDB`
+func (s *stateAtHeight) Open() error {
+	return nil
+}
+
+// Close implements method in interface `statedb.VersionedDB`
+func (s *stateAtHeight) Close() {
+}
+
+// rangeItrAtHeight merges the results of a range scan of the current state with the keys updated since the height.
+// The updated keys are looked up afresh for each result, so that the keys updated by the blocks committed during
+// the scan are accounted for as well
+type rangeItrAtHeight struct {
+	state   *stateAtHeight
+	ns      string
+	fromKey string
+	endKey  string
+	dbItr   statedb.ResultsIterator
+	dbNext  *statedb.VersionedKV
+	dbDone  bool
+}
+
+// Next implements method in interface `statedb.ResultsIterator`
+func (itr *rangeItrAtHeight) Next() (statedb.QueryResult, error) {
+	for {
+		if itr.dbNext == nil && !itr.dbDone {
+			result, err := itr.dbItr.Next()
+			if err != nil {
+				return nil, err
+			}
+			if result == nil {
+				itr.dbDone = true
+			} else {
+				itr.dbNext = result.(*statedb.VersionedKV)
+			}
+		}
+		key, value, replaced, err := itr.state.recentVersions.nextReplacedKey(itr.ns, itr.fromKey, itr.endKey, itr.state.height)
+		if err != nil {
+			return nil, err
+		}
+		if itr.dbNext != nil && (!replaced || itr.dbNext.Key < key) {
+			// a key not updated since the height holds the same value as at the height
+			result := itr.dbNext
+			itr.dbNext = nil
+			itr.fromKey = result.Key + "\x00"
+			return result, nil
+		}
+		if !replaced {
+			return nil, nil
+		}
+		if itr.dbNext != nil && itr.dbNext.Key == key {
+			itr.dbNext = nil
+		}
+		itr.fromKey = key + "\x00"
+		if value == nil {
+			continue
+		}
+		return &statedb.VersionedKV{
+			CompositeKey:   statedb.CompositeKey{Namespace: itr.ns, Key: key},
+			VersionedValue: statedb.VersionedValue{Value: value.Value, Version: value.Version}}, nil
+	}
+}
+
+// Close implements method in interface `statedb.ResultsIterator`
+func (itr *rangeItrAtHeight) Close() {
+	itr.dbItr.Close()
+}
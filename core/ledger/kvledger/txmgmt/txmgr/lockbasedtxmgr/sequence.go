@@ -0,0 +1,96 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lockbasedtxmgr
+
+import (
+	"fmt"
+	"hash/fnv"
+
+	"github.com/hyperledger/fabric/common/ledger/util"
+	ledgerutil "github.com/hyperledger/fabric/core/ledger/util"
+)
+
+// sequenceShards is the number of the counters that a sequence is split into. A transaction that draws
+// a value from a sequence reads and increments only one of the counters, so that the concurrent transactions
+// on a sequence conflict only when they happen to pick the same counter. The value drawn from the counter c
+// of the shard s is c*sequenceShards+s, which keeps the values unique across the shards. Changing this number
+// breaks the uniqueness of the values of the existing sequences
+const sequenceShards = 16
+
+// sequenceShardKey returns the key under which the counter of the given shard of the given sequence is stored
+func sequenceShardKey(name string, shard uint64) string {
+	return fmt.Sprintf("%s\x00%d", name, shard)
+}
+
+// pickSequenceShard returns the shard of the given sequence that the given transaction draws from. The shard is
+// derived from the txID so that all the endorsers of a transaction pick the same shard and produce the same results
+func pickSequenceShard(txID string, name string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(txID))
+	h.Write([]byte(name))
+	return h.Sum64() % sequenceShards
+}
+
+func decodeSequenceCounter(value []byte) uint64 {
+	if value == nil {
+		return 0
+	}
+	counter, _ := util.DecodeOrderPreservingVarUint64(value)
+	return counter
+}
+
+// getSequenceCount returns the number of the values drawn from the given sequence, i.e., the sum of its counters
+func (h *queryHelper) getSequenceCount(namespace string, name string) (uint64, error) {
+	keys := make([]string, sequenceShards)
+	for shard := range keys {
+		keys[shard] = sequenceShardKey(name, uint64(shard))
+	}
+	values, err := h.getStateMultipleKeys(ledgerutil.DeriveSequenceNs(namespace), keys)
+	if err != nil {
+		return 0, err
+	}
+	var count uint64
+	for _, value := range values {
+		count += decodeSequenceCounter(value)
+	}
+	return count, nil
+}
+
+// nextSequenceValue draws the next value from the given sequence. Only the counter of the shard picked for the
+// transaction is read and incremented. The subsequent draws by the same transaction continue from the counter as
+// incremented by this transaction, as the reads of the simulator do not reflect its own writes
+func (s *lockBasedTxSimulator) nextSequenceValue(namespace string, name string, txID string) (uint64, error) {
+	s.helper.checkDone()
+	sequenceNs := ledgerutil.DeriveSequenceNs(namespace)
+	shard := pickSequenceShard(txID, name)
+	key := sequenceShardKey(name, shard)
+	if s.sequenceCounters == nil {
+		s.sequenceCounters = make(map[string]uint64)
+	}
+	counterKey := sequenceNs + "\x00" + key
+	counter, ok := s.sequenceCounters[counterKey]
+	if !ok {
+		value, err := s.helper.getState(sequenceNs, key)
+		if err != nil {
+			return 0, err
+		}
+		counter = decodeSequenceCounter(value)
+	}
+	s.sequenceCounters[counterKey] = counter + 1
+	s.rwset.AddToWriteSet(sequenceNs, key, util.EncodeOrderPreservingVarUint64(counter+1))
+	return counter*sequenceShards + shard, nil
+}
@@ -18,6 +18,7 @@ package txmgr
 
 import (
 	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
 	"github.com/hyperledger/fabric/protos/common"
 )
@@ -25,9 +26,15 @@ import (
 // TxMgr - an interface that a transaction manager should implement
 type TxMgr interface {
 	NewQueryExecutor() (ledger.QueryExecutor, error)
+	// NewQueryExecutorAtHeight returns a query executor that reads the state as of the given recent height
+	NewQueryExecutorAtHeight(height uint64) (ledger.QueryExecutor, error)
 	NewTxSimulator() (ledger.TxSimulator, error)
-	ValidateAndPrepare(block *common.Block, doMVCCValidation bool) error
+	// ValidateAndPrepare validates the block and prepares the state updates for commit.
+	// It returns a deterministic serialization of the prepared updates
+	ValidateAndPrepare(block *common.Block, doMVCCValidation bool) ([]byte, error)
 	GetLastSavepoint() (*version.Height, error)
+	NewStateSnapshotIterator() (statedb.ResultsIterator, *version.Height, error)
+	NewNamespacesSnapshotIterator(namespaces []string) (statedb.ResultsIterator, *version.Height, error)
 	ShouldRecover(lastAvailableBlock uint64) (bool, uint64, error)
 	CommitLostBlock(block *common.Block) error
 	Commit() error
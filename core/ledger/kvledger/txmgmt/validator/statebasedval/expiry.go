@@ -0,0 +1,116 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statebasedval
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric/common/ledger/util"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/rwset"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
+	ledgerutil "github.com/hyperledger/fabric/core/ledger/util"
+)
+
+// expiryScheduleNs is the namespace of the state database that holds the schedule of the expiry of the keys.
+// The key of an entry is formed by the zero padded number of the block on the commit of which the key expires,
+// followed by the namespace and the key, so that the entries that are due are found by a range scan. The value
+// of an entry is the version of the write that set the expiry, so that the expiry is skipped if the key has been
+// written again in the meantime
+const expiryScheduleNs = "$$expiry"
+
+func constructExpiryScheduleKey(expiryBlockNum uint64, ns string, key string) string {
+	return fmt.Sprintf("%020d\x00%s\x00%s", expiryBlockNum, ns, key)
+}
+
+func splitExpiryScheduleKey(scheduleKey string) (string, string, error) {
+	split := strings.SplitN(scheduleKey, "\x00", 3)
+	if len(split) != 3 {
+		return "", "", fmt.Errorf("invalid expiry schedule key [%q]", scheduleKey)
+	}
+	return split[1], split[2], nil
+}
+
+// addExpiriesToBatch schedules the expiry of the keys for which the transaction has set a time-to-live.
+// The time-to-live of the keys is carried by the write-set of a namespace derived from the namespace of the
+// chaincode, which is not committed to the state database
+func addExpiriesToBatch(nsRWSet *rwset.NsReadWriteSet, txHeight *version.Height, batch *statedb.UpdateBatch) {
+	ns, ok := ledgerutil.SplitTTLNs(nsRWSet.NameSpace)
+	if !ok {
+		return
+	}
+	for _, kvWrite := range nsRWSet.Writes {
+		ttl, _ := util.DecodeOrderPreservingVarUint64(kvWrite.Value)
+		if kvWrite.IsDelete || ttl == 0 {
+			continue
+		}
+		scheduleKey := constructExpiryScheduleKey(txHeight.BlockNum+ttl, ns, kvWrite.Key)
+		batch.Put(expiryScheduleNs, scheduleKey, txHeight.ToBytes(), txHeight)
+	}
+}
+
+// addExpiredKeysToBatch deletes the keys that expire on the commit of the given block, along with their entries
+// in the expiry schedule. A key is deleted only if its version, as of the preceding valid transactions in the
+// block, is the version of the write that set the expiry. The deletes of the expired keys are returned
+func (v *Validator) addExpiredKeysToBatch(blockNum uint64, expiryHeight *version.Height, updates *statedb.UpdateBatch) ([]*ledgerutil.DerivedDelete, error) {
+	combinedItr, err := newCombinedIterator(v.db, updates, expiryScheduleNs, "",
+		constructExpiryScheduleKey(blockNum+1, "", ""), false)
+	if err != nil {
+		return nil, err
+	}
+	var dueEntries []*statedb.VersionedKV
+	for {
+		queryResult, err := combinedItr.Next()
+		if err != nil {
+			combinedItr.Close()
+			return nil, err
+		}
+		if queryResult == nil {
+			break
+		}
+		dueEntries = append(dueEntries, queryResult.(*statedb.VersionedKV))
+	}
+	combinedItr.Close()
+
+	var expiries []*ledgerutil.DerivedDelete
+	for _, entry := range dueEntries {
+		updates.Delete(expiryScheduleNs, entry.Key, expiryHeight)
+		ns, key, err := splitExpiryScheduleKey(entry.Key)
+		if err != nil {
+			return nil, err
+		}
+		vv := updates.Get(ns, key)
+		if vv == nil {
+			if vv, err = v.db.GetState(ns, key); err != nil {
+				return nil, err
+			}
+		}
+		if vv == nil || vv.Value == nil {
+			continue
+		}
+		expiringVersion, _ := version.NewHeightFromBytes(entry.Value)
+		if !version.AreSame(vv.Version, expiringVersion) {
+			logger.Debugf("Skipping the expiry of key [%s:%s] that has been written after the expiry was set", ns, key)
+			continue
+		}
+		logger.Debugf("Key [%s:%s] expired on block [%d]", ns, key, blockNum)
+		updates.Delete(ns, key, expiryHeight)
+		expiries = append(expiries, &ledgerutil.DerivedDelete{TxNum: expiryHeight.TxNum, Namespace: ns, Key: key})
+	}
+	return expiries, nil
+}
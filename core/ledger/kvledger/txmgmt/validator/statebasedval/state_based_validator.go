@@ -20,6 +20,7 @@ import (
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/rwset"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
+	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
 	"github.com/hyperledger/fabric/core/ledger/util"
 	"github.com/hyperledger/fabric/protos/common"
 	"github.com/hyperledger/fabric/protos/peer"
@@ -32,12 +33,13 @@ var logger = logging.MustGetLogger("statevalidator")
 // Validator validates a tx against the latest committed state
 // and preceding valid transactions with in the same block
 type Validator struct {
-	db statedb.VersionedDB
+	db               statedb.VersionedDB
+	storeValueHashes bool
 }
 
 // NewValidator constructs StateValidator
 func NewValidator(db statedb.VersionedDB) *Validator {
-	return &Validator{db}
+	return &Validator{db: db, storeValueHashes: ledgerconfig.IsValueHashStoreEnabled()}
 }
 
 //validate endorser transaction
@@ -48,12 +50,13 @@ func (v *Validator) validateEndorserTX(envBytes []byte, doMVCCValidation bool, u
 		return nil, peer.TxValidationCode_NIL_TXACTION, nil
 	}
 
-	//preparation for extracting RWSet from transaction
-	txRWSet := &rwset.TxReadWriteSet{}
+	//preparation for extracting RWSet from transaction. The read-write set is released by the caller
+	txRWSet := rwset.AcquireTxReadWriteSet()
 
 	// Get the Result from the Action
 	// and then Unmarshal it into a TxReadWriteSet using custom unmarshalling
 	if err = txRWSet.Unmarshal(respPayload.Results); err != nil {
+		txRWSet.Release()
 		return nil, peer.TxValidationCode_INVALID_OTHER_REASON, nil
 	}
 
@@ -62,8 +65,10 @@ func (v *Validator) validateEndorserTX(envBytes []byte, doMVCCValidation bool, u
 	//mvccvalidation, may invalidate transaction
 	if doMVCCValidation {
 		if txResult, err = v.validateTx(txRWSet, updates); err != nil {
+			txRWSet.Release()
 			return nil, txResult, err
 		} else if txResult != peer.TxValidationCode_VALID {
+			txRWSet.Release()
 			txRWSet = nil
 		}
 	}
@@ -80,6 +85,7 @@ func (v *Validator) validateConfigTX(env *common.Envelope) (bool, error) {
 func (v *Validator) ValidateAndPrepareBatch(block *common.Block, doMVCCValidation bool) (*statedb.UpdateBatch, error) {
 	logger.Debugf("New block arrived for validation:%#v, doMVCCValidation=%t", block, doMVCCValidation)
 	updates := statedb.NewUpdateBatch()
+	var derivedDeletes []*util.DerivedDelete
 	logger.Debugf("Validating a block with [%d] transactions", len(block.Data.Data))
 
 	// Committer validator has already set validation flags based on well formed tran checks
@@ -126,7 +132,13 @@ func (v *Validator) ValidateAndPrepareBatch(block *common.Block, doMVCCValidatio
 			//txRWSet != nil => t is valid
 			if txRWSet != nil {
 				committingTxHeight := version.NewHeight(block.Header.Number, uint64(txIndex+1))
+				rangeDeletes, err := v.addRangeDeletesToBatch(txRWSet, committingTxHeight, updates)
+				if err != nil {
+					return nil, err
+				}
+				derivedDeletes = append(derivedDeletes, rangeDeletes...)
 				addWriteSetToBatch(txRWSet, committingTxHeight, updates)
+				txRWSet.Release()
 				txsFilter.SetFlag(txIndex, peer.TxValidationCode_VALID)
 			}
 		} else if common.HeaderType(chdr.Type) == common.HeaderType_CONFIG {
@@ -153,12 +165,50 @@ func (v *Validator) ValidateAndPrepareBatch(block *common.Block, doMVCCValidatio
 
 	}
 	block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER] = txsFilter
+
+	// the keys that expire on this block are deleted after the writes of all the valid transactions in the block
+	expiryHeight := version.NewHeight(block.Header.Number, uint64(len(block.Data.Data)+1))
+	expiries, err := v.addExpiredKeysToBatch(block.Header.Number, expiryHeight, updates)
+	if err != nil {
+		return nil, err
+	}
+	// the deletes that are not in the write-sets of the transactions are recorded in the block, so that the consumers
+	// of the committed blocks, such as the history database and the commit decorators, see them as well
+	if err := util.SetDerivedDeletes(block, append(derivedDeletes, expiries...)); err != nil {
+		return nil, err
+	}
+	if v.storeValueHashes {
+		addValueHashesToBatch(updates)
+	}
 	return updates, nil
 }
 
+// addValueHashesToBatch adds the hashes of the values of the batch, so that the hash of a value is stored
+// alongside the value with the same version. The hashes are added after all the writes, range deletes and
+// expiries of the block have been added, so that the hash of a key that is deleted is deleted as well
+func addValueHashesToBatch(batch *statedb.UpdateBatch) {
+	for _, ns := range batch.GetUpdatedNamespaces() {
+		if util.IsDerivedNs(ns) {
+			continue
+		}
+		hashNs := util.DeriveValueHashNs(ns)
+		for key, vv := range batch.GetUpdates(ns) {
+			if vv.Value == nil {
+				batch.Delete(hashNs, key, vv.Version)
+			} else {
+				batch.Put(hashNs, key, util.ComputeValueHash(vv.Value), vv.Version)
+			}
+		}
+	}
+}
+
 func addWriteSetToBatch(txRWSet *rwset.TxReadWriteSet, txHeight *version.Height, batch *statedb.UpdateBatch) {
 	for _, nsRWSet := range txRWSet.NsRWs {
 		ns := nsRWSet.NameSpace
+		if _, ok := util.SplitTTLNs(ns); ok {
+			addExpiriesToBatch(nsRWSet, txHeight, batch)
+			continue
+		}
 		for _, kvWrite := range nsRWSet.Writes {
 			if kvWrite.IsDelete {
 				batch.Delete(ns, kvWrite.Key, txHeight)
@@ -169,6 +219,55 @@ func addWriteSetToBatch(txRWSet *rwset.TxReadWriteSet, txHeight *version.Height,
 	}
 }
 
+// addRangeDeletesToBatch expands the range deletes of a valid transaction to the keys that are present in the
+// statedb (latest state as of last committed block) + updates (prepared by the writes of preceding valid transactions
+// in the current block) and adds the deletes of these keys to the batch. As all the peers commit the same blocks
+// on the same state, the expansion is deterministic. The writes of the transaction are added to the batch after
+// the range deletes and hence, supersede them. The deletes of the keys that the transaction does not write are returned
+func (v *Validator) addRangeDeletesToBatch(txRWSet *rwset.TxReadWriteSet, txHeight *version.Height, batch *statedb.UpdateBatch) ([]*util.DerivedDelete, error) {
+	var derivedDeletes []*util.DerivedDelete
+	for _, nsRWSet := range txRWSet.NsRWs {
+		ns := nsRWSet.NameSpace
+		written := make(map[string]bool)
+		for _, kvWrite := range nsRWSet.Writes {
+			written[kvWrite.Key] = true
+		}
+		for _, rangeDelete := range nsRWSet.RangeDeletes {
+			keys, err := v.getKeysInRange(ns, rangeDelete.StartKey, rangeDelete.EndKey, batch)
+			if err != nil {
+				return nil, err
+			}
+			logger.Debugf("Expanded range delete %s in namespace [%s] to [%d] keys", rangeDelete, ns, len(keys))
+			for _, key := range keys {
+				batch.Delete(ns, key, txHeight)
+				if !written[key] {
+					derivedDeletes = append(derivedDeletes, &util.DerivedDelete{TxNum: txHeight.TxNum, Namespace: ns, Key: key})
+				}
+			}
+		}
+	}
+	return derivedDeletes, nil
+}
+
+func (v *Validator) getKeysInRange(ns string, startKey string, endKey string, updates *statedb.UpdateBatch) ([]string, error) {
+	combinedItr, err := newCombinedIterator(v.db, updates, ns, startKey, endKey, false)
+	if err != nil {
+		return nil, err
+	}
+	defer combinedItr.Close()
+	var keys []string
+	for {
+		queryResult, err := combinedItr.Next()
+		if err != nil {
+			return nil, err
+		}
+		if queryResult == nil {
+			return keys, nil
+		}
+		keys = append(keys, queryResult.(*statedb.VersionedKV).Key)
+	}
+}
+
 func (v *Validator) validateTx(txRWSet *rwset.TxReadWriteSet, updates *statedb.UpdateBatch) (peer.TxValidationCode, error) {
 	for _, nsRWSet := range txRWSet.NsRWs {
 		ns := nsRWSet.NameSpace
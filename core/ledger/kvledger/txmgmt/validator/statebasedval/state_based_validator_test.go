@@ -199,6 +199,53 @@ func TestPhantomHashBasedValidation(t *testing.T) {
 	checkValidation(t, validator, []*rwset.RWSet{rwset2}, []int{1})
 }
 
+func TestRangeDeleteExpansion(t *testing.T) {
+	testDBEnv := stateleveldb.NewTestVDBEnv(t)
+	defer testDBEnv.Cleanup()
+
+	db, err := testDBEnv.DBProvider.GetDBHandle("TestDB")
+	testutil.AssertNoError(t, err, "")
+
+	//populate db with initial data
+	batch := statedb.NewUpdateBatch()
+	batch.Put("ns1", "key1", []byte("value1"), version.NewHeight(1, 1))
+	batch.Put("ns1", "key2", []byte("value2"), version.NewHeight(1, 2))
+	batch.Put("ns1", "key3", []byte("value3"), version.NewHeight(1, 3))
+	batch.Put("ns1", "key4", []byte("value4"), version.NewHeight(1, 4))
+	batch.Put("ns1", "key5", []byte("value5"), version.NewHeight(1, 5))
+	db.ApplyUpdates(batch, version.NewHeight(1, 5))
+
+	validator := NewValidator(db)
+
+	//rwset1 adds a key in the range that is deleted by rwset2 in the same block
+	rwset1 := rwset.NewRWSet()
+	rwset1.AddToWriteSet("ns1", "key35", []byte("value35"))
+
+	//rwset2 deletes the range [key2, key5) except for key3 that is written after the range delete
+	rwset2 := rwset.NewRWSet()
+	rwset2.AddToWriteSet("ns1", "key4", []byte("value4_new"))
+	rwset2.AddToRangeDeleteSet("ns1", "key2", "key5")
+	rwset2.AddToWriteSet("ns1", "key3", []byte("value3_new"))
+
+	simulationResults := [][]byte{}
+	for _, readWriteSet := range []*rwset.RWSet{rwset1, rwset2} {
+		sr, err := readWriteSet.GetTxReadWriteSet().Marshal()
+		testutil.AssertNoError(t, err, "")
+		simulationResults = append(simulationResults, sr)
+	}
+	block := testutil.ConstructBlock(t, simulationResults, false)
+	block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER] = util.NewTxValidationFlags(len(block.Data.Data))
+	updates, err := validator.ValidateAndPrepareBatch(block, true)
+	testutil.AssertNoError(t, err, "")
+
+	testutil.AssertNil(t, updates.Get("ns1", "key1"))
+	testutil.AssertNil(t, updates.Get("ns1", "key2").Value)
+	testutil.AssertEquals(t, updates.Get("ns1", "key3").Value, []byte("value3_new"))
+	testutil.AssertNil(t, updates.Get("ns1", "key35").Value)
+	testutil.AssertNil(t, updates.Get("ns1", "key4").Value)
+	testutil.AssertNil(t, updates.Get("ns1", "key5"))
+}
+
 func checkValidation(t *testing.T, validator *Validator, rwsets []*rwset.RWSet, invalidTxIndexes []int) {
 	simulationResults := [][]byte{}
 	for _, readWriteSet := range rwsets {
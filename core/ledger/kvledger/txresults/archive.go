@@ -0,0 +1,219 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package txresults
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
+	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+	lutils "github.com/hyperledger/fabric/core/ledger/util"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/peer"
+	putils "github.com/hyperledger/fabric/protos/utils"
+	logging "github.com/op/go-logging"
+)
+
+var logger = logging.MustGetLogger("txresults")
+
+var savePointKey = []byte{0x00}
+var entryKeyPrefix = []byte{0x01}
+var firstArchivedBlockKey = []byte{0x02}
+
+// Provider provides handles to the archive of the results of the transactions of the ledgers
+type Provider struct {
+	dbProvider *leveldbhelper.Provider
+}
+
+// NewProvider instantiates Provider
+func NewProvider() *Provider {
+	dbPath := ledgerconfig.GetTxResultsPath()
+	logger.Debugf("constructing transaction results Provider dbPath=%s", dbPath)
+	return &Provider{leveldbhelper.NewProvider(&leveldbhelper.Conf{DBPath: dbPath})}
+}
+
+// NewReadOnlyProvider instantiates Provider that opens the existing databases in read-only mode
+func NewReadOnlyProvider() *Provider {
+	dbPath := ledgerconfig.GetTxResultsPath()
+	logger.Debugf("constructing read-only transaction results Provider dbPath=%s", dbPath)
+	return &Provider{leveldbhelper.NewProvider(&leveldbhelper.Conf{DBPath: dbPath, ReadOnly: true})}
+}
+
+// GetArchive returns the archive of the results of the transactions of the given ledger
+func (p *Provider) GetArchive(ledgerID string) *Archive {
+	return &Archive{p.dbProvider.GetDBHandle(ledgerID), ledgerID}
+}
+
+// Drop removes the archive of the results of the transactions of the given ledger
+func (p *Provider) Drop(ledgerID string) error {
+	return p.dbProvider.GetDBHandle(ledgerID).DeleteAll()
+}
+
+// Close closes the underlying db
+func (p *Provider) Close() {
+	p.dbProvider.Close()
+}
+
+// Archive maintains the results of the execution of the chaincodes by the valid endorser transactions of a ledger,
+// i.e., the proposal response payloads that the transactions carry, which hold the response of the chaincode and
+// its events along with the read-write set. The results are recorded against the id of the transaction so that the
+// applications can fetch the value returned by a chaincode after the transaction is committed
+type Archive struct {
+	db       *leveldbhelper.DBHandle
+	ledgerID string
+}
+
+// Commit records the results of the valid endorser transactions in the block
+func (a *Archive) Commit(block *common.Block) error {
+	blockNum := block.Header.Number
+	batch := leveldbhelper.NewUpdateBatch()
+	txsFilter := lutils.TxValidationFlags(block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER])
+	for txIndex, envBytes := range block.Data.Data {
+		if len(txsFilter) > txIndex && txsFilter.IsInvalid(txIndex) {
+			continue
+		}
+		txID, respPayloadBytes, err := extractProposalResponsePayload(envBytes)
+		if err != nil {
+			return err
+		}
+		if respPayloadBytes == nil {
+			continue
+		}
+		logger.Debugf("Channel [%s]: Archiving the result of transaction [%s] at position [%d] in block [%d]",
+			a.ledgerID, txID, txIndex, blockNum)
+		batch.Put(encodeEntryKey(txID), encodeEntryValue(version.NewHeight(blockNum, uint64(txIndex)), respPayloadBytes))
+	}
+	batch.Put(savePointKey, version.NewHeight(blockNum, 0).ToBytes())
+	return a.db.WriteBatch(batch, true)
+}
+
+// MarkStartingSavepoint marks the given savepoint as the starting point of the archive, for a ledger that
+// is created from a snapshot. The results of the transactions in the blocks up to the savepoint are not available
+func (a *Archive) MarkStartingSavepoint(savepoint *version.Height) error {
+	batch := leveldbhelper.NewUpdateBatch()
+	firstArchivedBlock := make([]byte, 8)
+	binary.BigEndian.PutUint64(firstArchivedBlock, savepoint.BlockNum+1)
+	batch.Put(firstArchivedBlockKey, firstArchivedBlock)
+	batch.Put(savePointKey, savepoint.ToBytes())
+	return a.db.WriteBatch(batch, true)
+}
+
+// GetTxResultByID returns the result of the valid transaction with the given id. A ledger.NotFoundError
+// is returned for a transaction that is not committed, is invalid or is not an endorser transaction
+func (a *Archive) GetTxResultByID(txID string) (*ledger.TxResult, error) {
+	entryBytes, err := a.db.Get(encodeEntryKey(txID))
+	if err != nil {
+		return nil, err
+	}
+	if entryBytes == nil {
+		msg := fmt.Sprintf("no result is archived for transaction [%s]", txID)
+		firstArchivedBlockBytes, err := a.db.Get(firstArchivedBlockKey)
+		if err != nil {
+			return nil, err
+		}
+		if firstArchivedBlockBytes != nil {
+			msg = fmt.Sprintf("%s, the results of the transactions in the blocks below block [%d] are not archived as the ledger is created from a snapshot",
+				msg, binary.BigEndian.Uint64(firstArchivedBlockBytes))
+		}
+		return nil, &ledger.NotFoundError{Msg: msg}
+	}
+	height, respPayloadBytes := decodeEntryValue(entryBytes)
+	respPayload := &peer.ProposalResponsePayload{}
+	if err := proto.Unmarshal(respPayloadBytes, respPayload); err != nil {
+		return nil, err
+	}
+	return &ledger.TxResult{TxID: txID, BlockNum: height.BlockNum, TxNum: height.TxNum, ProposalResponsePayload: respPayload}, nil
+}
+
+// GetLastSavepoint implements method in interface kvledger.Recoverer
+func (a *Archive) GetLastSavepoint() (*version.Height, error) {
+	versionBytes, err := a.db.Get(savePointKey)
+	if err != nil || versionBytes == nil {
+		return nil, err
+	}
+	height, _ := version.NewHeightFromBytes(versionBytes)
+	return height, nil
+}
+
+// ShouldRecover implements method in interface kvledger.Recoverer
+func (a *Archive) ShouldRecover(lastAvailableBlock uint64) (bool, uint64, error) {
+	savepoint, err := a.GetLastSavepoint()
+	if err != nil {
+		return false, 0, err
+	}
+	if savepoint == nil {
+		return true, 0, nil
+	}
+	return savepoint.BlockNum != lastAvailableBlock, savepoint.BlockNum + 1, nil
+}
+
+// CommitLostBlock implements method in interface kvledger.Recoverer
+func (a *Archive) CommitLostBlock(block *common.Block) error {
+	return a.Commit(block)
+}
+
+// extractProposalResponsePayload returns the id of a transaction and, for an endorser transaction, the bytes of the
+// proposal response payload that the transaction carries, as endorsed
+func extractProposalResponsePayload(envBytes []byte) (string, []byte, error) {
+	env, err := putils.GetEnvelopeFromBlock(envBytes)
+	if err != nil {
+		return "", nil, err
+	}
+	payload, err := putils.GetPayload(env)
+	if err != nil {
+		return "", nil, err
+	}
+	chdr, err := putils.UnmarshalChannelHeader(payload.Header.ChannelHeader)
+	if err != nil {
+		return "", nil, err
+	}
+	if common.HeaderType(chdr.Type) != common.HeaderType_ENDORSER_TRANSACTION {
+		return chdr.TxId, nil, nil
+	}
+	tx, err := putils.GetTransaction(payload.Data)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(tx.Actions) == 0 {
+		return chdr.TxId, nil, nil
+	}
+	ccActionPayload, err := putils.GetChaincodeActionPayload(tx.Actions[0].Payload)
+	if err != nil {
+		return "", nil, err
+	}
+	if ccActionPayload.Action == nil {
+		return chdr.TxId, nil, nil
+	}
+	return chdr.TxId, ccActionPayload.Action.ProposalResponsePayload, nil
+}
+
+func encodeEntryKey(txID string) []byte {
+	return append(append([]byte{}, entryKeyPrefix...), []byte(txID)...)
+}
+
+// encodeEntryValue prefixes the bytes of the proposal response payload with the position of the transaction
+func encodeEntryValue(height *version.Height, respPayloadBytes []byte) []byte {
+	return append(height.ToBytes(), respPayloadBytes...)
+}
+
+func decodeEntryValue(entryBytes []byte) (*version.Height, []byte) {
+	height, n := version.NewHeightFromBytes(entryBytes)
+	return height, entryBytes[n:]
+}
@@ -0,0 +1,119 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package txresults
+
+import (
+	"os"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
+	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+	lutils "github.com/hyperledger/fabric/core/ledger/util"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/peer"
+	ptestutils "github.com/hyperledger/fabric/protos/testutils"
+	putils "github.com/hyperledger/fabric/protos/utils"
+	"github.com/spf13/viper"
+)
+
+func TestMain(m *testing.M) {
+	viper.Set("peer.fileSystemPath", "/tmp/fabric/ledgertests/txresults")
+	os.Exit(m.Run())
+}
+
+func TestGetTxResultByID(t *testing.T) {
+	os.RemoveAll(ledgerconfig.GetTxResultsPath())
+	defer os.RemoveAll(ledgerconfig.GetTxResultsPath())
+	provider := NewProvider()
+	defer provider.Close()
+	archive := provider.GetArchive("testLedger")
+
+	block1, txIDs1 := constructBlock(t, 1, []*peer.Response{{Status: 200, Payload: []byte("result1")}, {Status: 200, Message: "done"}})
+	block2, txIDs2 := constructBlock(t, 2, []*peer.Response{{Status: 200, Payload: []byte("result3")}, {Status: 200, Payload: []byte("result4")}})
+	// the result of an invalid transaction is not archived
+	lutils.TxValidationFlags(block2.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER]).SetFlag(1, peer.TxValidationCode_MVCC_READ_CONFLICT)
+	testutil.AssertNoError(t, archive.Commit(block1), "")
+	testutil.AssertNoError(t, archive.Commit(block2), "")
+	savepoint, _ := archive.GetLastSavepoint()
+	testutil.AssertEquals(t, savepoint, version.NewHeight(2, 0))
+
+	txResult, err := archive.GetTxResultByID(txIDs1[0])
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, txResult.TxID, txIDs1[0])
+	testutil.AssertEquals(t, txResult.BlockNum, uint64(1))
+	testutil.AssertEquals(t, txResult.TxNum, uint64(0))
+	ccAction, err := putils.GetChaincodeAction(txResult.ProposalResponsePayload.Extension)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, ccAction.Response.Payload, []byte("result1"))
+	testutil.AssertEquals(t, ccAction.Results, []byte("results"))
+
+	txResult, _ = archive.GetTxResultByID(txIDs1[1])
+	testutil.AssertEquals(t, txResult.TxNum, uint64(1))
+	ccAction, _ = putils.GetChaincodeAction(txResult.ProposalResponsePayload.Extension)
+	testutil.AssertEquals(t, ccAction.Response.Message, "done")
+
+	txResult, _ = archive.GetTxResultByID(txIDs2[0])
+	testutil.AssertEquals(t, txResult.BlockNum, uint64(2))
+
+	_, err = archive.GetTxResultByID(txIDs2[1])
+	_, ok := err.(*ledger.NotFoundError)
+	testutil.AssertEquals(t, ok, true)
+
+	shouldRecover, firstBlockNum, _ := archive.ShouldRecover(4)
+	testutil.AssertEquals(t, shouldRecover, true)
+	testutil.AssertEquals(t, firstBlockNum, uint64(3))
+}
+
+func TestMarkStartingSavepoint(t *testing.T) {
+	os.RemoveAll(ledgerconfig.GetTxResultsPath())
+	defer os.RemoveAll(ledgerconfig.GetTxResultsPath())
+	provider := NewProvider()
+	defer provider.Close()
+	archive := provider.GetArchive("testLedger")
+
+	testutil.AssertNoError(t, archive.MarkStartingSavepoint(version.NewHeight(5, 2)), "")
+	savepoint, _ := archive.GetLastSavepoint()
+	testutil.AssertEquals(t, savepoint, version.NewHeight(5, 2))
+	block, txIDs := constructBlock(t, 6, []*peer.Response{{Status: 200}})
+	testutil.AssertNoError(t, archive.Commit(block), "")
+
+	_, err := archive.GetTxResultByID("unknownTx")
+	testutil.AssertEquals(t, err, &ledger.NotFoundError{Msg: "no result is archived for transaction [unknownTx], " +
+		"the results of the transactions in the blocks below block [6] are not archived as the ledger is created from a snapshot"})
+	txResult, err := archive.GetTxResultByID(txIDs[0])
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, txResult.BlockNum, uint64(6))
+
+	// the archives of other ledgers are independent
+	_, err = provider.GetArchive("otherLedger").GetTxResultByID(txIDs[0])
+	testutil.AssertEquals(t, err, &ledger.NotFoundError{Msg: "no result is archived for transaction [" + txIDs[0] + "]"})
+}
+
+func constructBlock(t *testing.T, blockNum uint64, responses []*peer.Response) (*common.Block, []string) {
+	block := common.NewBlock(blockNum, []byte{})
+	var txIDs []string
+	for _, response := range responses {
+		env, txID, err := ptestutils.ConstructUnsingedTxEnv("testLedger", "mycc", response, []byte("results"), nil, nil)
+		testutil.AssertNoError(t, err, "")
+		block.Data.Data = append(block.Data.Data, putils.MarshalOrPanic(env))
+		txIDs = append(txIDs, txID)
+	}
+	putils.InitBlockMetadata(block)
+	block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER] = lutils.NewTxValidationFlags(len(responses))
+	return block, txIDs
+}
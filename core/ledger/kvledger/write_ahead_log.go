@@ -0,0 +1,259 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+	"github.com/hyperledger/fabric/protos/common"
+)
+
+// The names of the stores of a ledger that are not recoverables, as recorded in the write-ahead log
+const (
+	blockStorageStoreName = "block storage"
+	pvtdataStoreName      = "private data store"
+	stateTrieStoreName    = "state trie"
+)
+
+// commitIntent is the intent to apply a block to the named stores of a ledger
+type commitIntent struct {
+	blockNum uint64
+	stores   []string
+}
+
+// writeAheadLog records the intent to apply a block before the block is applied to any of the stores of the ledger,
+// and is cleared once the block is applied to all the stores. An intent found when the ledger is opened is that of
+// the block that was being committed when the peer stopped, and is rolled back if the block did not make it to the
+// block storage and rolled forward otherwise. The log holds at most one intent, which is replaced atomically
+type writeAheadLog struct {
+	ledgerID string
+	path     string
+}
+
+func newWriteAheadLog(ledgerID string, path string) *writeAheadLog {
+	return &writeAheadLog{ledgerID: ledgerID, path: path}
+}
+
+// begin records the given intent and flushes it to the disk before returning
+func (w *writeAheadLog) begin(intent *commitIntent) error {
+	buf := proto.NewBuffer(nil)
+	buf.EncodeVarint(intent.blockNum)
+	buf.EncodeVarint(uint64(len(intent.stores)))
+	for _, store := range intent.stores {
+		buf.EncodeStringBytes(store)
+	}
+	dir := filepath.Dir(w.path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	tempPath := w.path + ".tmp"
+	if err := writeFileAndSync(tempPath, buf.Bytes()); err != nil {
+		return err
+	}
+	if err := os.Rename(tempPath, w.path); err != nil {
+		return err
+	}
+	// the rename is flushed along with the directory
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+func writeFileAndSync(path string, fileBytes []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(fileBytes); err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// end clears the intent once the block is applied to all the stores. The removal is not flushed as an intent
+// that reappears after a crash is rolled forward again, which the stores that already applied the block skip
+func (w *writeAheadLog) end() error {
+	if err := os.Remove(w.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// pending returns the recorded intent, nil if there is none
+func (w *writeAheadLog) pending() (*commitIntent, error) {
+	fileBytes, err := ioutil.ReadFile(w.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	buf := proto.NewBuffer(fileBytes)
+	intent := &commitIntent{}
+	if intent.blockNum, err = buf.DecodeVarint(); err != nil {
+		return nil, w.corrupted(err)
+	}
+	numStores, err := buf.DecodeVarint()
+	if err != nil {
+		return nil, w.corrupted(err)
+	}
+	for i := uint64(0); i < numStores; i++ {
+		store, err := buf.DecodeStringBytes()
+		if err != nil {
+			return nil, w.corrupted(err)
+		}
+		intent.stores = append(intent.stores, store)
+	}
+	return intent, nil
+}
+
+func (w *writeAheadLog) corrupted(err error) error {
+	return &ledger.CorruptionError{Msg: fmt.Sprintf("Write-ahead log [%s] cannot be decoded: %s", w.path, err)}
+}
+
+func writeAheadLogPath(ledgerID string) string {
+	return filepath.Join(ledgerconfig.GetWriteAheadLogPath(), ledgerID)
+}
+
+// dropWriteAheadLog removes the write-ahead log of the given ledger
+func dropWriteAheadLog(ledgerID string) error {
+	if err := os.Remove(writeAheadLogPath(ledgerID)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// commitStores returns the names of the stores that a block is applied to
+func (l *kvLedger) commitStores() []string {
+	stores := []string{blockStorageStoreName, pvtdataStoreName}
+	if l.stateTrie != nil {
+		stores = append(stores, stateTrieStoreName)
+	}
+	for _, r := range l.recoverables() {
+		stores = append(stores, r.name)
+	}
+	return stores
+}
+
+// recoverFromWriteAheadLog completes the commit of the block that was being committed when the peer stopped, if any.
+// The stores that are applied ahead of the block storage are rolled back if the block did not make it to the block
+// storage, and the block is applied to the stores that had not applied it otherwise. The stores that lag behind by
+// more than the block, as the writes to them are flushed lazily, are left to the recovery from the savepoints
+func (l *kvLedger) recoverFromWriteAheadLog() error {
+	intent, err := l.writeAheadLog.pending()
+	if err != nil || intent == nil {
+		return err
+	}
+	info, err := l.blockStore.GetBlockchainInfo()
+	if err != nil {
+		return err
+	}
+	walLogger := logger.With(flogging.Fields{"channel": l.ledgerID, "block": intent.blockNum})
+	if intent.blockNum >= info.Height {
+		walLogger.Info("Rolling back the commit of block as the block is not in the block storage")
+		if err := l.rollBackIntent(intent); err != nil {
+			return err
+		}
+		return l.writeAheadLog.end()
+	}
+	walLogger.Info("Rolling forward the commit of block")
+	if err := l.rollForwardIntent(intent); err != nil {
+		return err
+	}
+	return l.writeAheadLog.end()
+}
+
+func (l *kvLedger) rollBackIntent(intent *commitIntent) error {
+	for _, store := range intent.stores {
+		switch store {
+		case pvtdataStoreName:
+			pending, _, err := l.pvtdataStore.HasPendingBatch()
+			if err != nil {
+				return err
+			}
+			if pending {
+				if err := l.pvtdataStore.Rollback(); err != nil {
+					return err
+				}
+			}
+		case stateTrieStoreName:
+			if l.stateTrie != nil {
+				if err := l.stateTrie.Truncate(intent.blockNum); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (l *kvLedger) rollForwardIntent(intent *commitIntent) error {
+	recoverables := make(map[string]recoverable)
+	for _, r := range l.recoverables() {
+		recoverables[r.name] = r.recoverable
+	}
+	var block *common.Block
+	for _, store := range intent.stores {
+		if store == pvtdataStoreName {
+			pending, _, err := l.pvtdataStore.HasPendingBatch()
+			if err != nil {
+				return err
+			}
+			if pending {
+				if err := l.pvtdataStore.Commit(); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		r, ok := recoverables[store]
+		if !ok {
+			// the block storage and the state trie are complete once the block is in the block storage
+			continue
+		}
+		recoverFlag, firstBlockNum, err := r.ShouldRecover(intent.blockNum)
+		if err != nil {
+			return err
+		}
+		if !recoverFlag || firstBlockNum != intent.blockNum {
+			continue
+		}
+		if block == nil {
+			if block, err = l.GetBlockByNumber(intent.blockNum); err != nil {
+				return err
+			}
+		}
+		logger.With(flogging.Fields{"channel": l.ledgerID, "block": intent.blockNum}).Infof("Applying block to %s", store)
+		if err := r.CommitLostBlock(block); err != nil {
+			return err
+		}
+	}
+	return nil
+}
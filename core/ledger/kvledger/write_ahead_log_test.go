@@ -0,0 +1,242 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvledger
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/ledger/blkstorage"
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/history/historydb"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/txmgr"
+	"github.com/hyperledger/fabric/core/ledger/pvtdatastorage"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/spf13/viper"
+)
+
+// errCrash is raised by the faulty stores to simulate a crash of the peer in the middle of a commit
+var errCrash = fmt.Errorf("crash")
+
+type crashingBlockStore struct {
+	blkstorage.BlockStore
+}
+
+func (s *crashingBlockStore) AddBlock(block *common.Block) error {
+	panic(errCrash)
+}
+
+type crashingTxMgr struct {
+	txmgr.TxMgr
+}
+
+func (m *crashingTxMgr) Commit() error {
+	panic(errCrash)
+}
+
+type crashingHistoryDB struct {
+	historydb.HistoryDB
+}
+
+func (h *crashingHistoryDB) Commit(block *common.Block) error {
+	panic(errCrash)
+}
+
+type failingPvtdataStore struct {
+	pvtdatastorage.Store
+}
+
+func (s *failingPvtdataStore) Prepare(blockNum uint64, pvtData []*ledger.TxPvtData, missingPvtData []*ledger.MissingPvtData) error {
+	return fmt.Errorf("failed to prepare private data")
+}
+
+type failingBlockStore struct {
+	blkstorage.BlockStore
+}
+
+func (s *failingBlockStore) AddBlock(block *common.Block) error {
+	return fmt.Errorf("failed to add block")
+}
+
+// commitAndCrash commits the block to the ledger, expecting the commit to crash
+func commitAndCrash(t *testing.T, l *kvLedger, block *common.Block) {
+	defer func() {
+		testutil.AssertEquals(t, recover(), errCrash)
+	}()
+	l.Commit(block)
+}
+
+func TestWriteAheadLog(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	w := newWriteAheadLog("testLedger", writeAheadLogPath("testLedger"))
+	intent, err := w.pending()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertNil(t, intent)
+
+	testutil.AssertNoError(t, w.begin(&commitIntent{blockNum: 5, stores: []string{blockStorageStoreName, "state DB"}}), "")
+	intent, err = w.pending()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, intent, &commitIntent{blockNum: 5, stores: []string{blockStorageStoreName, "state DB"}})
+	testutil.AssertNoError(t, w.begin(&commitIntent{blockNum: 6}), "")
+	intent, _ = w.pending()
+	testutil.AssertEquals(t, intent, &commitIntent{blockNum: 6})
+
+	testutil.AssertNoError(t, w.end(), "")
+	testutil.AssertNoError(t, w.end(), "")
+	intent, err = w.pending()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertNil(t, intent)
+}
+
+func TestWriteAheadLogRecovery(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	historyEnabled := viper.GetBool("ledger.state.historyDatabase")
+	viper.Set("ledger.state.historyDatabase", true)
+	defer viper.Set("ledger.state.historyDatabase", historyEnabled)
+	defer viper.Set("ledger.writeAheadLog.enabled", false)
+	defer viper.Set("ledger.state.stateTrie.enabled", false)
+	viper.Set("ledger.writeAheadLog.enabled", true)
+	viper.Set("ledger.state.stateTrie.enabled", true)
+
+	provider, _ := NewProvider()
+	peerLedger, _ := provider.Create("testLedger")
+	bg := testutil.NewBlockGenerator(t)
+	block0 := bg.NextBlock([][]byte{constructSimResForCommitHash(t, peerLedger, "value0")}, false)
+	testutil.AssertNoError(t, peerLedger.Commit(block0), "")
+	intent, _ := peerLedger.(*kvLedger).writeAheadLog.pending()
+	testutil.AssertNil(t, intent)
+
+	reopen := func() *kvLedger {
+		peerLedger.Close()
+		provider.Close()
+		provider, _ = NewProvider()
+		peerLedger, _ = provider.Open("testLedger")
+		return peerLedger.(*kvLedger)
+	}
+	assertState := func(l *kvLedger, height uint64, value string) {
+		info, _ := l.GetBlockchainInfo()
+		testutil.AssertEquals(t, info.Height, height)
+		qe, _ := l.NewQueryExecutor()
+		defer qe.Done()
+		v, _ := qe.GetState("ns1", "key1")
+		testutil.AssertEquals(t, string(v), value)
+		stateRoot, err := l.GetStateRoot(height - 1)
+		testutil.AssertNoError(t, err, "")
+		testutil.AssertNotNil(t, stateRoot)
+		_, err = l.GetStateRoot(height)
+		testutil.AssertError(t, err, fmt.Sprintf("the root of block %d is not recorded", height))
+		intent, _ := l.writeAheadLog.pending()
+		testutil.AssertNil(t, intent)
+	}
+
+	// the peer crashes before the block is added to the block storage, after the state trie applied the block
+	l := peerLedger.(*kvLedger)
+	block1 := bg.NextBlock([][]byte{constructSimResForCommitHash(t, l, "value1")}, false)
+	blockStore := l.blockStore
+	l.blockStore = &crashingBlockStore{blockStore}
+	commitAndCrash(t, l, proto.Clone(block1).(*common.Block))
+	l.blockStore = blockStore
+	intent, _ = l.writeAheadLog.pending()
+	testutil.AssertEquals(t, intent.blockNum, uint64(1))
+	lastBlock, _, _ := l.stateTrie.GetLastBlock()
+	testutil.AssertEquals(t, lastBlock, uint64(1))
+	l = reopen()
+	assertState(l, 1, "value0")
+	lastBlock, _, _ = l.stateTrie.GetLastBlock()
+	testutil.AssertEquals(t, lastBlock, uint64(0))
+
+	// the peer crashes after the block is added to the block storage, before the state database applied the block
+	txMgr := l.txtmgmt
+	l.txtmgmt = &crashingTxMgr{txMgr}
+	commitAndCrash(t, l, block1)
+	l.txtmgmt = txMgr
+	l = reopen()
+	assertState(l, 2, "value1")
+
+	// the peer crashes after the state database applied the block, before the history database applied the block
+	block2 := bg.NextBlock([][]byte{constructSimResForCommitHash(t, l, "value2")}, false)
+	historyDB := l.historyDB
+	l.historyDB = &crashingHistoryDB{historyDB}
+	commitAndCrash(t, l, block2)
+	l.historyDB = historyDB
+	l = reopen()
+	assertState(l, 3, "value2")
+	hqe, _ := l.NewHistoryQueryExecutor()
+	itr, err := hqe.GetHistoryForKey("ns1", "key1")
+	testutil.AssertNoError(t, err, "")
+	numEntries := 0
+	for result, _ := itr.Next(); result != nil; result, _ = itr.Next() {
+		numEntries++
+	}
+	itr.Close()
+	testutil.AssertEquals(t, numEntries, 3)
+
+	// the ledger keeps committing after the recoveries
+	block3 := bg.NextBlock([][]byte{constructSimResForCommitHash(t, l, "value3")}, false)
+	testutil.AssertNoError(t, l.Commit(block3), "")
+	assertState(l, 4, "value3")
+	peerLedger.Close()
+	provider.Close()
+}
+
+func TestWriteAheadLogFailedCommit(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	defer viper.Set("ledger.writeAheadLog.enabled", false)
+	defer viper.Set("ledger.state.stateTrie.enabled", false)
+	viper.Set("ledger.writeAheadLog.enabled", true)
+	viper.Set("ledger.state.stateTrie.enabled", true)
+
+	provider, _ := NewProvider()
+	defer provider.Close()
+	peerLedger, _ := provider.Create("testLedger")
+	defer peerLedger.Close()
+	l := peerLedger.(*kvLedger)
+	bg := testutil.NewBlockGenerator(t)
+	testutil.AssertNoError(t, l.Commit(bg.NextBlock([][]byte{constructSimResForCommitHash(t, l, "value0")}, false)), "")
+	block1 := bg.NextBlock([][]byte{constructSimResForCommitHash(t, l, "value1")}, false)
+
+	// a failed commit removes the state root of the block and clears the intent of the block
+	assertAborted := func() {
+		info, _ := l.GetBlockchainInfo()
+		testutil.AssertEquals(t, info.Height, uint64(1))
+		lastBlock, _, _ := l.stateTrie.GetLastBlock()
+		testutil.AssertEquals(t, lastBlock, uint64(0))
+		intent, _ := l.writeAheadLog.pending()
+		testutil.AssertNil(t, intent)
+	}
+	pvtdataStore := l.pvtdataStore
+	l.pvtdataStore = &failingPvtdataStore{pvtdataStore}
+	testutil.AssertError(t, l.Commit(proto.Clone(block1).(*common.Block)), "Expected an error as the private data cannot be prepared")
+	l.pvtdataStore = pvtdataStore
+	assertAborted()
+
+	// the private data prepared for the block are discarded as well
+	blockStore := l.blockStore
+	l.blockStore = &failingBlockStore{blockStore}
+	testutil.AssertError(t, l.Commit(proto.Clone(block1).(*common.Block)), "Expected an error as the block cannot be added")
+	l.blockStore = blockStore
+	assertAborted()
+
+	testutil.AssertNoError(t, l.Commit(block1), "")
+	info, _ := l.GetBlockchainInfo()
+	testutil.AssertEquals(t, info.Height, uint64(2))
+}
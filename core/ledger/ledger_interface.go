@@ -17,21 +17,180 @@ limitations under the License.
 package ledger
 
 import (
+	"fmt"
+	"time"
+
 	commonledger "github.com/hyperledger/fabric/common/ledger"
 	"github.com/hyperledger/fabric/protos/common"
 	"github.com/hyperledger/fabric/protos/peer"
+	"golang.org/x/net/context"
+)
+
+// LedgerStatus represents the status of a ledger in the PeerLedgerProvider
+type LedgerStatus int32
+
+const (
+	// LedgerStatusActive indicates a ledger that is available for use
+	LedgerStatusActive LedgerStatus = iota
+	// LedgerStatusUnderConstruction indicates a ledger whose creation is in progress
+	LedgerStatusUnderConstruction
+	// LedgerStatusDeleting indicates a ledger whose deletion is in progress
+	LedgerStatusDeleting
+	// LedgerStatusFailed indicates a ledger whose data could not be cleaned up after an
+	// incomplete creation or deletion. Such a ledger can only be destroyed
+	LedgerStatusFailed
+)
+
+func (s LedgerStatus) String() string {
+	switch s {
+	case LedgerStatusActive:
+		return "ACTIVE"
+	case LedgerStatusUnderConstruction:
+		return "UNDER_CONSTRUCTION"
+	case LedgerStatusDeleting:
+		return "DELETING"
+	case LedgerStatusFailed:
+		return "FAILED"
+	}
+	return fmt.Sprintf("UNKNOWN(%d)", int32(s))
+}
+
+// LedgerInfo captures the height and the status of a ledger
+type LedgerInfo struct {
+	LedgerID string
+	Height   uint64
+	Status   LedgerStatus
+}
+
+// LedgerMetadata captures what is recorded about a ledger when the ledger is created, along with the labels that
+// an operator sets on the ledger to tell apart the ledgers of a peer, e.g., by the application or the environment
+type LedgerMetadata struct {
+	LedgerID string
+	Status   LedgerStatus
+	// CreatedAt is the time of the creation of the ledger, the zero time for a ledger created before the time was recorded
+	CreatedAt time.Time
+	// CreationBlock is the number of the first block of the ledger, which is above 0 for a ledger created from a snapshot
+	CreationBlock uint64
+	// GenesisBlockHash is the hash of the block 0 of the ledger. It is nil till the block is committed
+	// and for a ledger created from a snapshot
+	GenesisBlockHash []byte
+	Labels           map[string]string
+	// BootstrapMethod tells how the ledger was created from its genesis block, e.g., BootstrapMethodJoin. It is empty
+	// for a ledger created otherwise and for a ledger created before the provenance was recorded
+	BootstrapMethod string
+	// OrdererEndpoints are the addresses of the orderers listed in the config of the genesis block of the ledger
+	OrdererEndpoints []string
+	// PendingMaintenance is the maintenance scheduled to run when the ledger is opened next time, nil if none
+	PendingMaintenance *MaintenanceSchedule
+}
+
+// MaintenanceSchedule captures the maintenance operations that cannot run on an opened ledger and hence are
+// scheduled to run when the ledger is opened next time, i.e., when the peer restarts. The block index is rebuilt
+// before the rollback and the databases are rebuilt last, by replaying the blocks that remain
+type MaintenanceSchedule struct {
+	// RebuildBlockIndex rebuilds the index of the block store from the block files
+	RebuildBlockIndex bool
+	// RollbackHeight rolls back the ledger such that only the blocks below the height remain, 0 for no rollback.
+	// A rollback rebuilds the state database and the history database
+	RollbackHeight uint64
+	// RebuildDBs rebuilds the state database and the history database
+	RebuildDBs bool
+}
+
+// The methods by which a ledger is bootstrapped from a genesis block
+const (
+	// BootstrapMethodJoin is the join of the peer to a channel with the genesis block supplied by an administrator
+	BootstrapMethodJoin = "join"
+	// BootstrapMethodDefaultChain is the creation of the default chain when the peer starts in development mode
+	BootstrapMethodDefaultChain = "defaultChain"
 )
 
+// DiskUsage captures the number of bytes that the stores of a ledger occupy on the disk. The sizes of the
+// stores that are shared by the ledgers, such as the leveldb indexes, are approximated from their key ranges
+type DiskUsage struct {
+	BlockStoreBytes uint64
+	StateDBBytes    uint64
+	HistoryDBBytes  uint64
+}
+
+// StoreHeights captures the heights of the stores of a ledger, that is, the number of blocks whose updates a store
+// contains. The state database and the history database lag behind the block store after a crash till the recovery
+type StoreHeights struct {
+	BlockStore uint64
+	StateDB    uint64
+	HistoryDB  uint64
+}
+
+// BlockFingerprint captures the hash of the header of a block and the commit hash recorded in the block.
+// CommitHash is nil for a block committed without a commit hash
+type BlockFingerprint struct {
+	BlockNumber uint64
+	BlockHash   []byte
+	CommitHash  []byte
+}
+
+// StateFingerprint captures the hashes of the contents of the state database per namespace, as of the given height.
+// The hash of a namespace does not depend on the order in which the state database returns the key-values and
+// hence the fingerprints are comparable across the peers that use the same type of state database
+type StateFingerprint struct {
+	Height          uint64
+	NamespaceHashes map[string][]byte
+}
+
+// NamespaceStats captures the number of the operations on the state of a namespace since the ledger was opened.
+// The reads, the range queries, the writes, and the deletes are counted from the valid transactions of the committed
+// blocks, whereas the rich queries are counted when they are executed. Blocks is the number of blocks that touched the namespace
+type NamespaceStats struct {
+	Namespace    string
+	Blocks       uint64
+	Reads        uint64
+	RangeQueries uint64
+	RichQueries  uint64
+	Writes       uint64
+	Deletes      uint64
+	WrittenBytes uint64
+}
+
+// NamespaceInfo captures a namespace of the state database and the number of its keys
+type NamespaceInfo struct {
+	Namespace string
+	KeyCount  uint64
+}
+
+// NamespaceSize captures the number of the keys of a namespace and the number of the bytes of their keys and values.
+// The size does not include the encoding overhead of the state database and hence is approximate
+type NamespaceSize struct {
+	Namespace string
+	KeyCount  uint64
+	Size      uint64
+}
+
 // PeerLedgerProvider provides handle to ledger instances
 type PeerLedgerProvider interface {
 	// Create creates a new ledger with a given unique id
 	Create(ledgerID string) (PeerLedger, error)
-	// Open opens an already created ledger
+	// CreateFromGenesisBlock creates a new ledger from the given genesis block with the given bootstrap method.
+	// The block is validated and committed, and the bootstrap method is recorded along with the orderer endpoints
+	// listed in the block, before the ledger becomes active. The id of the ledger is the channel of the block
+	CreateFromGenesisBlock(genesisBlock *common.Block, bootstrapMethod string) (PeerLedger, error)
+	// CreateFromSnapshot creates a new ledger from the snapshot in the given directory.
+	// The id of the ledger is derived from the snapshot and is returned along with the ledger
+	CreateFromSnapshot(snapshotDir string) (PeerLedger, string, error)
+	// Open opens an already created ledger. Only a ledger with the status LedgerStatusActive can be opened
 	Open(ledgerID string) (PeerLedger, error)
 	// Exists tells whether the ledger with given id exists
 	Exists(ledgerID string) (bool, error)
-	// List lists the ids of the existing ledgers
-	List() ([]string, error)
+	// List lists the existing ledgers along with their heights and statuses
+	List() ([]*LedgerInfo, error)
+	// GetLedgerMetadata returns the metadata of the ledger with the given id
+	GetLedgerMetadata(ledgerID string) (*LedgerMetadata, error)
+	// SetLedgerLabels replaces the labels of the ledger with the given id. Nil labels remove all the labels
+	SetLedgerLabels(ledgerID string, labels map[string]string) error
+	// ScheduleMaintenance adds the given operations to the maintenance scheduled for the ledger with the given id.
+	// Of two scheduled rollbacks, the one to the lower height is kept
+	ScheduleMaintenance(ledgerID string, schedule *MaintenanceSchedule) error
+	// Destroy removes all the data of the ledger with the given id irrespective of its status. The ledger should not be open
+	Destroy(ledgerID string) error
 	// Close closes the PeerLedgerProvider
 	Close()
 }
@@ -40,8 +199,12 @@ type PeerLedgerProvider interface {
 // that tells apart valid transactions from invalid ones
 type PeerLedger interface {
 	commonledger.Ledger
-	// GetTransactionByID retrieves a transaction by id
+	// GetTransactionByID retrieves a transaction by id along with its validation code,
+	// the number of the block that contains the transaction and the position of the transaction in the block
 	GetTransactionByID(txID string) (*peer.ProcessedTransaction, error)
+	// TxExists returns whether a transaction with the given id is in the ledger, whatever its validation code,
+	// without retrieving the transaction. It serves to detect the duplicate transaction ids
+	TxExists(txID string) (bool, error)
 	// GetBlockByHash returns a block given it's hash
 	GetBlockByHash(blockHash []byte) (*common.Block, error)
 	// GetBlockByTxID returns a block which contains a transaction
@@ -57,12 +220,253 @@ type PeerLedger interface {
 	// A client can obtain more than one 'QueryExecutor's for parallel execution.
 	// Any synchronization should be performed at the implementation level if required
 	NewQueryExecutor() (QueryExecutor, error)
+	// NewQueryExecutorAtHeight gives handle to a query executor that reads the state as of the given height, i.e.,
+	// after the commit of the block height-1, so that several queries read the same state while the commits continue.
+	// The height should be within the number of the recent blocks configured by ledger.state.recentVersions. Rich
+	// queries are not supported. A NotEnabledError is returned if the recent versions of the state are not retained
+	NewQueryExecutorAtHeight(height uint64) (QueryExecutor, error)
 	// NewHistoryQueryExecutor gives handle to a history query executor.
 	// A client can obtain more than one 'HistoryQueryExecutor's for parallel execution.
 	// Any synchronization should be performed at the implementation level if required
 	NewHistoryQueryExecutor() (HistoryQueryExecutor, error)
 	//Prune prunes the blocks/transactions that satisfy the given policy
 	Prune(policy commonledger.PrunePolicy) error
+	// GetCommitHash returns the commit hash recorded in the block with the given number. The commit hash
+	// chains the hashes of the state updates applied by the blocks and can be compared across peers to detect
+	// a divergence in the state. blockNumber of math.MaxUint64 returns the commit hash of the last block
+	GetCommitHash(blockNumber uint64) ([]byte, error)
+	// GetTransactionProof returns a self-contained proof of the existence of the transaction with the given id
+	// that can be verified offline. The proof is anchored to the block with the given number, which should not
+	// precede the block that contains the transaction. anchorBlockNum of math.MaxUint64 anchors the proof to the last block
+	GetTransactionProof(txID string, anchorBlockNum uint64) (*common.TransactionProof, error)
+	// GetStateRoot returns the root of the state trie after the block with the given number, nil for an empty
+	// state. blockNumber of math.MaxUint64 returns the root after the last block
+	GetStateRoot(blockNumber uint64) ([]byte, error)
+	// GetStateProof returns a proof of the value of the given key, or of its absence, as of the block with the
+	// given number against the state root of the block. blockNumber of math.MaxUint64 proves the value as of the last block
+	GetStateProof(namespace string, key string, blockNumber uint64) (*common.StateProof, error)
+	// GenerateSnapshot generates a snapshot of the ledger in the given directory.
+	// The snapshot corresponds to the last block committed to the state database
+	GenerateSnapshot(snapshotDir string) error
+	// GenerateSystemStateSnapshot generates a snapshot of the system namespaces of the state in the given directory.
+	// Only the system state database is read. A NotEnabledError is returned if the ledger keeps the system namespaces
+	// in the state database
+	GenerateSystemStateSnapshot(snapshotDir string) error
+	// GetSnapshotConfigBlock returns the last config block included in the snapshot from which the ledger was
+	// created. This block is retained as the blocks included in the snapshot are not available in the ledger.
+	// nil is returned for a ledger that is not created from a snapshot
+	GetSnapshotConfigBlock() (*common.Block, error)
+	// GetDiskUsage returns the number of bytes that the block store, the state database, and the history database
+	// of the ledger occupy on the disk. The history database is reported as empty when it is disabled for the ledger
+	GetDiskUsage() (*DiskUsage, error)
+	// GetStateFingerprint returns the hashes of the contents of the state database per namespace. Commits to the
+	// ledger are blocked while the state database is scanned so that the hashes correspond to a single height
+	GetStateFingerprint() (*StateFingerprint, error)
+	// WarmUpState reads up to the given number of the keys most recently written to the state database per namespace,
+	// or all the tracked keys if the number is zero, into the caches of the state database, and returns the number of
+	// keys read. A NotEnabledError is returned if the recent keys are not tracked
+	WarmUpState(keysPerNamespace int) (uint64, error)
+	// GetNamespaceStats returns the number of the operations on the state per namespace since the ledger was opened,
+	// sorted by namespace. A NotEnabledError is returned if the operations are not counted
+	GetNamespaceStats() ([]*NamespaceStats, error)
+	// Flush waits for the pending commits to the history database, flushes the block store, the state database, and the
+	// history database to the disk, and returns the heights of the stores. The heights are stable only if no block is
+	// committed concurrently, which ledgermgmt.QuiesceCommits ensures. The history database is reported at height 0 when disabled
+	Flush() (*StoreHeights, error)
+	// Compact compacts the leveldb block index and the history database of the ledger, which drops the overwritten
+	// entries and reduces the number of the files read per lookup. The history database is skipped when disabled
+	Compact() error
+	// RegisterConfigBlockListener registers a listener that is notified after a config block is committed to the ledger
+	RegisterConfigBlockListener(listener ConfigBlockListener)
+	// CommitWithPvtData commits the block and the private write sets of the transactions in the block.
+	// The private data is committed atomically with the block. The private write sets that the peer
+	// could not obtain are passed as missingPvtData and are recorded for a later reconciliation
+	CommitWithPvtData(block *common.Block, pvtData []*TxPvtData, missingPvtData []*MissingPvtData) error
+	// GetPvtDataByNum returns the private write sets of the transactions in the block with the given number.
+	// The write sets are filtered by the given filter, a nil filter returns all the write sets
+	GetPvtDataByNum(blockNum uint64, filter PvtNsCollFilter) ([]*TxPvtData, error)
+	// GetMissingPvtDataInfoForMostRecentBlocks returns the private write sets that are recorded as missing
+	// in the most recent blocks, up to maxBlocks number of blocks that have missing private data.
+	// The missing private data that has expired is not returned
+	GetMissingPvtDataInfoForMostRecentBlocks(maxBlocks int) (MissingPvtDataInfo, error)
+	// CommitPvtDataOfOldBlocks commits the private write sets of the already committed blocks that were
+	// recorded as missing. The write sets that are not recorded as missing, or have expired, are ignored
+	CommitPvtDataOfOldBlocks(blocksPvtData []*BlockPvtData) error
+	// GetCollectionConfigAt returns the collection config package of the given chaincode that was in force at the given height,
+	// i.e., the package committed most recently by a block below the given height. A nil value is returned if there is none
+	GetCollectionConfigAt(chaincodeName string, height uint64) (*CollectionConfigInfo, error)
+	// GetEventsByChaincode returns the events of the given chaincode set by the valid transactions in the blocks from startBlock
+	// to endBlock, both inclusive, so that the applications can replay the events they missed. The events are indexed with the
+	// hashes of their payloads. The events of the blocks included in the snapshot from which the ledger was created are not available
+	GetEventsByChaincode(chaincodeName string, startBlock, endBlock uint64) ([]*peer.ChaincodeEventInfo, error)
+	// GetTxResultByID returns the result of the execution of the chaincode by the valid transaction with the given id, so that
+	// the applications can fetch the value returned by the chaincode after the transaction is committed. A NotEnabledError is
+	// returned if the archive of the results is disabled. The results of the transactions in the blocks included in the snapshot
+	// from which the ledger was created are not available
+	GetTxResultByID(txID string) (*TxResult, error)
+	// GetInvalidTransactions returns the most recent transactions invalidated at commit, up to the given number of the
+	// transactions or all the logged transactions if the number is zero, ordered by the position. Only the number of
+	// the transactions configured by ledger.invalidTxLog.maxEntries are logged. A NotEnabledError is returned if the
+	// invalidated transactions are not logged
+	GetInvalidTransactions(maxEntries int) ([]*InvalidTransaction, error)
+	// GetStateUpdatesBetween returns the net changes to the keys of the given namespace made by the valid transactions in the
+	// blocks from fromHeight (inclusive) to toHeight (exclusive), i.e., the last value written to each key, ordered by the key.
+	// A nil value indicates that the key was deleted. The expiry of the keys by ttl is not included. The changes of the blocks
+	// included in the snapshot from which the ledger was created are not available
+	GetStateUpdatesBetween(namespace string, fromHeight, toHeight uint64) ([]*KV, error)
+}
+
+// TxResult encapsulates the proposal response payload of a valid transaction along with the position of the transaction.
+// The extension of the payload holds the chaincode action, i.e., the response of the chaincode, its events and the read-write set
+type TxResult struct {
+	TxID                    string
+	BlockNum                uint64
+	TxNum                   uint64
+	ProposalResponsePayload *peer.ProposalResponsePayload
+}
+
+// InvalidTransaction encapsulates a transaction invalidated at commit along with its position, the validation code,
+// the MSP of its creator, and the chaincode it invoked. The fields that could not be extracted from a malformed
+// transaction are left empty
+type InvalidTransaction struct {
+	TxID           string
+	BlockNum       uint64
+	TxNum          uint64
+	ValidationCode peer.TxValidationCode
+	CreatorMSPID   string
+	Chaincode      string
+}
+
+// CollectionConfigInfo encapsulates the collection config package of a chaincode
+// along with the number of the block that committed the package
+type CollectionConfigInfo struct {
+	CollectionConfig   []byte
+	CommittingBlockNum uint64
+}
+
+// TxPvtData contains the private write sets of a transaction along with the position of the transaction in the block
+type TxPvtData struct {
+	SeqInBlock uint64
+	WriteSets  []*CollPvtWriteSet
+}
+
+// CollPvtWriteSet contains the private writes of a transaction to a collection of a chaincode.
+// The writes are serialized by the transaction simulator and are opaque to the ledger
+type CollPvtWriteSet struct {
+	Namespace      string
+	CollectionName string
+	WriteSet       []byte
+}
+
+// MissingPvtData identifies the private write set of a transaction to a collection that was not available
+// when the block was committed, for instance, because the peer was not yet a member of the collection or
+// because the peers that had the private data were not reachable
+type MissingPvtData struct {
+	SeqInBlock     uint64
+	Namespace      string
+	CollectionName string
+}
+
+// MissingPvtDataInfo contains the missing private write sets keyed by the number of the block
+type MissingPvtDataInfo map[uint64][]*MissingPvtData
+
+// Add adds a missing private write set of the given block
+func (info MissingPvtDataInfo) Add(blockNum uint64, missing *MissingPvtData) {
+	info[blockNum] = append(info[blockNum], missing)
+}
+
+// BlockPvtData contains the private write sets of the transactions in a block that is already committed
+type BlockPvtData struct {
+	BlockNum uint64
+	PvtData  []*TxPvtData
+}
+
+// PvtNsCollFilter selects the collections of the namespaces whose private write sets are to be retrieved
+type PvtNsCollFilter map[string]map[string]bool
+
+// NewPvtNsCollFilter constructs an empty PvtNsCollFilter
+func NewPvtNsCollFilter() PvtNsCollFilter {
+	return make(map[string]map[string]bool)
+}
+
+// Add adds the given collection of the given namespace to the filter
+func (filter PvtNsCollFilter) Add(ns string, coll string) {
+	collFilter, ok := filter[ns]
+	if !ok {
+		collFilter = make(map[string]bool)
+		filter[ns] = collFilter
+	}
+	collFilter[coll] = true
+}
+
+// Has returns true if the filter selects the given collection of the given namespace
+func (filter PvtNsCollFilter) Has(ns string, coll string) bool {
+	collFilter, ok := filter[ns]
+	if !ok {
+		return false
+	}
+	return collFilter[coll]
+}
+
+// ConfigBlockEvent carries the details of a config block committed to the ledger
+type ConfigBlockEvent struct {
+	LedgerID       string
+	BlockNumber    uint64
+	ConfigEnvelope *common.ConfigEnvelope
+}
+
+// ConfigBlockListener is notified of the config blocks committed to a ledger. The notification is delivered
+// synchronously in the commit path after the block is committed and hence a listener should return promptly
+type ConfigBlockListener interface {
+	HandleConfigBlockCommit(event *ConfigBlockEvent)
+}
+
+// HistoryAccessChecker restricts the reads of the history of the keys, i.e., of the values that a key held in the past,
+// beyond the checks that authorize the reads of the current state. The creator is the serialized identity of the reader
+type HistoryAccessChecker interface {
+	// CheckHistoryAccess returns an error if the creator may not read the history of the given key
+	CheckHistoryAccess(creator []byte, channelID string, namespace string, key string) error
+}
+
+// CommitDecoratorProvider constructs the commit decorators of the ledgers. A commit decorator maintains
+// data derived from the committed state (e.g., a full-text index) in a store of its own
+type CommitDecoratorProvider interface {
+	// Name returns the unique name of the decorator. The name is used for tracking the savepoint of the decorator
+	Name() string
+	// NewDecorator returns the decorator of the given ledger
+	NewDecorator(ledgerID string) (CommitDecorator, error)
+	// Drop removes the derived data of the given ledger. The data is then rebuilt from the block storage
+	Drop(ledgerID string) error
+}
+
+// CommitDecorator receives the valid write sets of each block committed to a ledger.
+// The ledger tracks the savepoint of the decorator and redelivers the blocks that the decorator missed due to a crash.
+// A block may hence be delivered more than once and the decorator is expected to handle a block idempotently
+type CommitDecorator interface {
+	HandleCommit(event *CommitEvent) error
+	Close()
+}
+
+// CommitEvent carries the write sets of the valid transactions in a committed block. The keys that expire on the block
+// are deleted by a last write set that has no TxID and whose TxNum is the number of the transactions in the block
+type CommitEvent struct {
+	LedgerID    string
+	BlockNumber uint64
+	TxWriteSets []*TxWriteSet
+}
+
+// TxWriteSet contains the public writes of a valid transaction. TxNum is the position of the transaction in the block.
+// The writes include the deletes of the keys in the ranges deleted by the transaction
+type TxWriteSet struct {
+	TxID     string
+	TxNum    uint64
+	NsWrites []*NsWrites
+}
+
+// NsWrites contains the writes of a transaction to a namespace. A write with a nil value is a delete
+type NsWrites struct {
+	Namespace string
+	Writes    []*KV
 }
 
 // ValidatedLedger represents the 'final ledger' after filtering out invalid transactions from PeerLedger.
@@ -81,24 +485,94 @@ type QueryExecutor interface {
 	GetState(namespace string, key string) ([]byte, error)
 	// GetStateMultipleKeys gets the values for multiple keys in a single call
 	GetStateMultipleKeys(namespace string, keys []string) ([][]byte, error)
+	// GetStateHash returns the SHA256 hash of the value of the given key, or nil if the key does not exist. The version
+	// of the key is recorded as read, as for GetState, so that a chaincode that only compares or verifies large values
+	// does not need to retrieve them
+	GetStateHash(namespace string, key string) ([]byte, error)
+	// GetPrivateDataHash returns the hash of the value of the given private key of the given collection. The hashes
+	// of the private keys and values are maintained in the state database by all the peers, including the peers
+	// that are not members of the collection, so that a private value presented off-chain can be verified
+	GetPrivateDataHash(namespace string, collection string, key string) ([]byte, error)
 	// GetStateRangeScanIterator returns an iterator that contains all the key-values between given key ranges.
 	// startKey is included in the results and endKey is excluded. An empty startKey refers to the first available key
 	// and an empty endKey refers to the last available key. For scanning all the keys, both the startKey and the endKey
 	// can be supplied as empty strings. However, a full scan shuold be used judiciously for performance reasons.
 	// The returned ResultsIterator contains results of type *KV
 	GetStateRangeScanIterator(namespace string, startKey string, endKey string) (commonledger.ResultsIterator, error)
+	// GetStateRangeScanIteratorWithPagination returns an iterator that contains at most pageSize key-values of the given
+	// key range, starting from the given bookmark. An empty bookmark refers to the startKey. Once the iterator is exhausted,
+	// its Bookmark returns the key to pass for fetching the next page, or an empty string if the range has no more keys
+	GetStateRangeScanIteratorWithPagination(namespace string, startKey string, endKey string, pageSize int32, bookmark string) (PaginatedResultsIterator, error)
 	// ExecuteQuery executes the given query and returns an iterator that contains results of type specific to the underlying data store.
 	// Only used for state databases that support query
 	// For a chaincode, the namespace corresponds to the chaincodeId
 	ExecuteQuery(namespace, query string) (commonledger.ResultsIterator, error)
+	// ExecuteQueryWithFields executes the given query like ExecuteQuery, and restricts the records of the results to
+	// the given fields of the JSON documents. Nested fields are given as dotted paths. The projection is performed by
+	// the state database when it supports it, and on the query results otherwise
+	ExecuteQueryWithFields(namespace, query string, fields []string) (commonledger.ResultsIterator, error)
+	// GetTotalForKeyPrefix returns the sum of the numeric field with the given name in the JSON values of the keys
+	// that begin with the given prefix, along with the count of the values that contributed to the sum. The values that
+	// are not JSON or that do not contain a numeric field with the given name are skipped. The keys are recorded as a range
+	// query in the read set so that a transaction that relies on the total is invalidated by a concurrent change to any of the keys
+	GetTotalForKeyPrefix(namespace string, keyPrefix string, fieldName string) (*Aggregate, error)
+	// GetSequenceCount returns the number of the values drawn from the given sequence of the given namespace.
+	// For a simulation, all the counters of the sequence are recorded in the read set and hence the transaction
+	// conflicts with any concurrent transaction that draws a value from the sequence
+	GetSequenceCount(namespace string, name string) (uint64, error)
+	// ListNamespaces returns the namespaces that have keys in the state database, ordered by the name, along with the
+	// number of their keys. The hashed namespaces of the private data collections are listed as well. The counts are
+	// exact for goleveldb, and approximate for CouchDB. The namespaces are not recorded in the read set of a simulation
+	ListNamespaces() ([]*NamespaceInfo, error)
+	// GetNamespaceSize returns the number of the keys of the given namespace and the number of the bytes of their keys
+	// and values. The size is maintained at commit, without scanning the namespace, so that the chaincodes and the operators
+	// can check quotas cheaply. The size does not reflect the updates of the current simulation and is not recorded in its read set
+	GetNamespaceSize(namespace string) (*NamespaceSize, error)
+	// GetBlockchainInfo returns the height and the hashes of the last block that correspond to the state
+	// visible to this QueryExecutor. This allows the logic to be pinned to a height without invoking qscc
+	GetBlockchainInfo() (*common.BlockchainInfo, error)
 	// Done releases resources occupied by the QueryExecutor
 	Done()
 }
 
+// PaginatedResultsIterator is a ResultsIterator over a page of the results of a query
+type PaginatedResultsIterator interface {
+	commonledger.ResultsIterator
+	// Bookmark returns the bookmark of the next page. It is only valid once the iterator is exhausted
+	Bookmark() string
+}
+
+// TraceableQueryExecutor is implemented by the query executors and the transaction simulators that report
+// the spans of their queries. The spans are reported as the children of the span carried by the given context
+type TraceableQueryExecutor interface {
+	SetTraceContext(ctx context.Context)
+}
+
 // HistoryQueryExecutor executes the history queries
 type HistoryQueryExecutor interface {
 	// GetHistoryForKey retrieves the history of values for a key.
 	GetHistoryForKey(namespace string, key string) (commonledger.ResultsIterator, error)
+	// GetRecentHistoryForKey retrieves the history of values for a key from the most recent modification.
+	// At most limit modifications are returned if the limit is positive
+	GetRecentHistoryForKey(namespace string, key string, limit int) (commonledger.ResultsIterator, error)
+	// GetHistoryForKeyWithOptions retrieves the history of values for a key, narrowed down and ordered as per the options
+	GetHistoryForKeyWithOptions(namespace string, key string, options *HistoryQueryOptions) (commonledger.ResultsIterator, error)
+	// GetStateAsOf returns the value that a key had at the given block height, that is, after the commit of the blocks
+	// below the height. The value is nil if the key did not exist at the height. The expiry of a key written with a ttl
+	// is not recorded by the history database and hence a value is returned for the heights after the expiry as well
+	GetStateAsOf(namespace string, key string, blockHeight uint64) ([]byte, error)
+}
+
+// HistoryQueryOptions narrow down and order the history of a key. The history is returned from the oldest modification
+// unless Reverse is set, and is restricted to the blocks from StartBlock to EndBlock, inclusive. An EndBlock of zero does
+// not bound the history. At most Limit modifications are returned if the limit is positive, and the deletes of the key
+// are skipped unless IncludeDeletes is set
+type HistoryQueryOptions struct {
+	Limit          int
+	Reverse        bool
+	StartBlock     uint64
+	EndBlock       uint64
+	IncludeDeletes bool
 }
 
 // TxSimulator simulates a transaction on a consistent snapshot of the 'as recent state as possible'
@@ -107,8 +581,28 @@ type TxSimulator interface {
 	QueryExecutor
 	// SetState sets the given value for the given namespace and key. For a chaincode, the namespace corresponds to the chaincodeId
 	SetState(namespace string, key string, value []byte) error
+	// SetStateWithTTL sets the given value for the given namespace and key, which expires after the given number of
+	// blocks. The key is deleted on the commit of the ttl-th block after the block that commits the transaction,
+	// unless it has been written again in the meantime. A write of the key without a ttl clears the expiry
+	SetStateWithTTL(namespace string, key string, value []byte, ttl uint64) error
 	// DeleteState deletes the given namespace and key
 	DeleteState(namespace string, key string) error
+	// DeleteStateByRange deletes the keys in the range [startKey, endKey) of the given namespace. An empty endKey
+	// denotes the end of the namespace. The range is recorded in the simulation results and expanded to the keys
+	// present at the time of the commit, so the keys are not read during the simulation. The expanded deletes are
+	// applied to the state database only and hence, are not recorded in the history database
+	DeleteStateByRange(namespace string, startKey string, endKey string) error
+	// SetPrivateData sets the given value for the given private key of the given collection. The value is
+	// returned by GetPvtSimulationResults, whereas the hashes of the key and the value are added to the public
+	// write set so that they are committed to the state database. A nil value deletes the private key
+	SetPrivateData(namespace string, collection string, key string, value []byte) error
+	// DeletePrivateData deletes the given private key of the given collection
+	DeletePrivateData(namespace string, collection string, key string) error
+	// NextSequenceValue draws the next value from the given sequence of the given namespace. The values drawn from
+	// a sequence are unique and increase with the successive draws within a transaction and across the transactions
+	// that pick the same counter of the sequence, but are not contiguous. The sequence is split into counters so that
+	// the concurrent transactions that draw from it rarely conflict. The counter is picked based on the given txID
+	NextSequenceValue(namespace string, name string, txID string) (uint64, error)
 	// SetMultipleKeys sets the values for multiple keys in a single call
 	SetStateMultipleKeys(namespace string, kvs map[string][]byte) error
 	// ExecuteUpdate for supporting rich data model (see comments on QueryExecutor above)
@@ -122,6 +616,15 @@ type TxSimulator interface {
 	// of information in different way in order to support different data-models or optimize the information representations.
 	// TODO detailed illustration of a couple of representations.
 	GetTxSimulationResults() ([]byte, error)
+	// GetPvtSimulationResults returns the private write sets of the transaction, one per collection, in the form
+	// that is supplied to CommitWithPvtData. It is to be invoked after GetTxSimulationResults
+	GetPvtSimulationResults() ([]*CollPvtWriteSet, error)
+}
+
+// Aggregate holds the result of GetTotalForKeyPrefix
+type Aggregate struct {
+	Sum   float64
+	Count uint64
 }
 
 // KV - QueryResult for KV-based datamodel. Holds a key and corresponding value. A nil value indicates a non-existent key.
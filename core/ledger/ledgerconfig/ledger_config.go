@@ -17,8 +17,16 @@ limitations under the License.
 package ledgerconfig
 
 import (
+	"fmt"
 	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/hyperledger/fabric/bccsp"
+	"github.com/hyperledger/fabric/common/ledger/util"
+	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
 	"github.com/spf13/viper"
 )
 
@@ -30,6 +38,15 @@ var historyDatabase = true
 
 var maxBlockFileSize = 0
 
+const defaultQueryLimit = 1000
+const defaultCouchDBFetchBudget = 4 * 1024 * 1024
+const defaultCouchDBIndexWarmingConcurrency = 2
+const defaultPvtdataStorePurgeInterval = 100
+const defaultTransientStoreBlocksToLive = 1000
+const defaultQuiesceTimeout = 5 * time.Minute
+const defaultIteratorIdleTimeout = 5 * time.Minute
+const defaultSystemNamespace = "lccc"
+
 // CouchDBDef contains parameters
 type CouchDBDef struct {
 	URL      string
@@ -63,11 +80,122 @@ func GetStateLevelDBPath() string {
 	return filepath.Join(GetRootPath(), "stateLeveldb")
 }
 
+// GetSystemStateLevelDBPath returns the filesystem path that is used to maintain the level db of the system namespaces of the state
+func GetSystemStateLevelDBPath() string {
+	return filepath.Join(GetRootPath(), "systemStateLeveldb")
+}
+
+// GetSystemStateSnapshotsPath returns the filesystem path that is used to maintain the snapshots of the system namespaces of the state
+func GetSystemStateSnapshotsPath() string {
+	return filepath.Join(GetSnapshotsPath(), "systemState")
+}
+
+// GetLedgerSnapshotsPath returns the filesystem path that is used to maintain the snapshots of the ledgers generated on the request of an administrator
+func GetLedgerSnapshotsPath() string {
+	return filepath.Join(GetSnapshotsPath(), "ledger")
+}
+
 // GetHistoryLevelDBPath returns the filesystem path that is used to maintain the history level db
 func GetHistoryLevelDBPath() string {
 	return filepath.Join(GetRootPath(), "historyLeveldb")
 }
 
+// GetSnapshotsPath returns the filesystem path that is used to maintain the snapshots served to and fetched from the other peers
+func GetSnapshotsPath() string {
+	return filepath.Join(GetRootPath(), "snapshots")
+}
+
+// GetConfigHistoryPath returns the filesystem path that is used to maintain the history of the collection configs
+func GetConfigHistoryPath() string {
+	return filepath.Join(GetRootPath(), "configHistory")
+}
+
+// GetChaincodeEventsPath returns the filesystem path that is used to maintain the index of the chaincode events
+func GetChaincodeEventsPath() string {
+	return filepath.Join(GetRootPath(), "chaincodeEvents")
+}
+
+// GetTxResultsPath returns the filesystem path that is used to maintain the archive of the results of the transactions
+func GetTxResultsPath() string {
+	return filepath.Join(GetRootPath(), "txResults")
+}
+
+// GetInvalidTxLogPath returns the filesystem path that is used to maintain the logs of the invalidated transactions
+func GetInvalidTxLogPath() string {
+	return filepath.Join(GetRootPath(), "invalidTxLog")
+}
+
+// GetStateTriePath returns the filesystem path that is used to maintain the state tries of the ledgers
+func GetStateTriePath() string {
+	return filepath.Join(GetRootPath(), "stateTrie")
+}
+
+// GetWriteAheadLogPath returns the filesystem path that is used to maintain the write-ahead logs of the commits of the ledgers
+func GetWriteAheadLogPath() string {
+	return filepath.Join(GetRootPath(), "writeAheadLog")
+}
+
+// GetCommitDecoratorsPath returns the filesystem path that is used to maintain the savepoints of the commit decorators
+func GetCommitDecoratorsPath() string {
+	return filepath.Join(GetRootPath(), "commitDecorators")
+}
+
+// GetChangeDataCapturePath returns the filesystem path that is used to maintain the queue and the cursor of the change data capture
+func GetChangeDataCapturePath() string {
+	return filepath.Join(GetRootPath(), "changeDataCapture")
+}
+
+// GetStateWarmUpPath returns the filesystem path that is used to maintain the keys most recently written to the state
+// databases, with which the state databases are warmed up after a restart
+func GetStateWarmUpPath() string {
+	return filepath.Join(GetRootPath(), "stateWarmUp")
+}
+
+// GetNamespaceSizesPath returns the filesystem path that is used to persist the sizes of the namespaces when the ledgers are closed
+func GetNamespaceSizesPath() string {
+	return filepath.Join(GetRootPath(), "namespaceSizes")
+}
+
+// GetStateWarmUpKeysPerNamespace returns the number of keys most recently written to the state database that are tracked
+// per namespace for warming up the state database after a restart. The keys are not tracked if the number is not set
+func GetStateWarmUpKeysPerNamespace() int {
+	keysPerNamespace := viper.GetInt("ledger.state.warmUp.keysPerNamespace")
+	if keysPerNamespace < 0 {
+		return 0
+	}
+	return keysPerNamespace
+}
+
+// GetTransientStorePath returns the filesystem path that is used to maintain the transient store
+func GetTransientStorePath() string {
+	return filepath.Join(GetRootPath(), "transientStore")
+}
+
+// GetTransientStoreBlocksToLive returns the number of blocks after which the data persisted in the
+// transient store is purged, if the transaction is not committed by then. Defaults to 1000
+func GetTransientStoreBlocksToLive() uint64 {
+	blocksToLive := viper.GetInt("ledger.transientStore.blocksToLive")
+	if blocksToLive <= 0 {
+		return defaultTransientStoreBlocksToLive
+	}
+	return uint64(blocksToLive)
+}
+
+// GetPvtDataStorePath returns the filesystem path that is used to maintain the private data store
+func GetPvtDataStorePath() string {
+	return filepath.Join(GetRootPath(), "pvtdataStore")
+}
+
+// GetPvtdataStorePurgeInterval returns the interval, in number of blocks, at which the expired
+// private data is purged from the private data store. Defaults to 100
+func GetPvtdataStorePurgeInterval() uint64 {
+	purgeInterval := viper.GetInt("ledger.pvtdataStore.purgeInterval")
+	if purgeInterval <= 0 {
+		return defaultPvtdataStorePurgeInterval
+	}
+	return uint64(purgeInterval)
+}
+
 // GetBlockStorePath returns the filesystem path that is used by the block store
 func GetBlockStorePath() string {
 	return filepath.Join(GetRootPath(), "blocks")
@@ -93,6 +221,404 @@ func IsHistoryDBEnabled() bool {
 	return viper.GetBool("ledger.state.historyDatabase")
 }
 
+// GetHashAlgorithm returns the name of the hash function that the BCCSP uses for computing
+// the commit hashes and the hashes of the snapshot files. Defaults to SHA256
+func GetHashAlgorithm() string {
+	hashAlgorithm := viper.GetString("ledger.hashAlgorithm")
+	if hashAlgorithm == "" {
+		return bccsp.SHA256
+	}
+	return hashAlgorithm
+}
+
+// IsValueHashStoreEnabled returns true if the hashes of the values are to be stored in the state database
+// alongside the values, so that the hash of a value can be retrieved without reading the value
+func IsValueHashStoreEnabled() bool {
+	return viper.GetBool("ledger.state.storeValueHashes")
+}
+
+// IsHistoryDBAsyncCommitEnabled returns true if the history database is to be updated
+// asynchronously after the commit of a block to the state database
+func IsHistoryDBAsyncCommitEnabled() bool {
+	return viper.GetBool("ledger.state.historyAsyncCommit")
+}
+
+// GetHistoryKeyFormat returns the format of the keys of a new history database, v1 or v2. Defaults to v1
+func GetHistoryKeyFormat() string {
+	format := viper.GetString("ledger.state.historyKeyFormat")
+	if format == "" {
+		return "v1"
+	}
+	return format
+}
+
+// GetQueryLimit returns the limit on the number of records to return per query
+func GetQueryLimit() int {
+	queryLimit := viper.GetInt("ledger.state.couchDBConfig.queryLimit")
+	if queryLimit <= 0 {
+		return defaultQueryLimit
+	}
+	return queryLimit
+}
+
+// GetCouchDBFetchBudget returns the number of bytes of documents that a range scan or a full scan of CouchDB
+// targets per request. The number of documents fetched per request is adapted to the size of the documents. Defaults to 4MB
+func GetCouchDBFetchBudget() int {
+	fetchBudget := int(viper.GetSizeInBytes("ledger.state.couchDBConfig.fetchBudget"))
+	if fetchBudget <= 0 {
+		return defaultCouchDBFetchBudget
+	}
+	return fetchBudget
+}
+
+// IsCouchDBIndexWarmingEnabled returns true if the views of the CouchDB state database, including the indexes of the
+// rich queries, are brought up to date in the background after each commit
+func IsCouchDBIndexWarmingEnabled() bool {
+	return viper.GetBool("ledger.state.couchDBConfig.indexWarming.enabled")
+}
+
+// GetCouchDBIndexWarmingConcurrency returns the number of views of a CouchDB database that are warmed in parallel. Defaults to 2
+func GetCouchDBIndexWarmingConcurrency() int {
+	concurrency := viper.GetInt("ledger.state.couchDBConfig.indexWarming.concurrency")
+	if concurrency <= 0 {
+		return defaultCouchDBIndexWarmingConcurrency
+	}
+	return concurrency
+}
+
+// GetSlowQueryThreshold returns the duration above which the range scans, the rich queries, and the history queries
+// are logged as slow queries. The slow query log is disabled if the threshold is not set
+func GetSlowQueryThreshold() time.Duration {
+	return viper.GetDuration("ledger.state.slowQueryThreshold")
+}
+
+// GetIteratorIdleTimeout returns the duration after which a range query iterator that is neither consumed nor closed
+// is released, so that a leaked iterator does not hold the state database. Defaults to 5 minutes
+func GetIteratorIdleTimeout() time.Duration {
+	timeout := viper.GetDuration("ledger.state.iteratorIdleTimeout")
+	if timeout <= 0 {
+		return defaultIteratorIdleTimeout
+	}
+	return timeout
+}
+
+// GetRichQueryConcurrencyLimit returns the number of rich queries that can run concurrently on a channel.
+// The rich queries are not limited if not set
+func GetRichQueryConcurrencyLimit() int {
+	return viper.GetInt("ledger.state.queryAdmission.richQueries")
+}
+
+// GetHistoryQueryConcurrencyLimit returns the number of history queries that can run concurrently on a channel.
+// The history queries are not limited if not set
+func GetHistoryQueryConcurrencyLimit() int {
+	return viper.GetInt("ledger.state.queryAdmission.historyQueries")
+}
+
+// GetQueryAdmissionQueueSize returns the number of the limited queries of a kind that can wait on a channel
+// for a query of the kind to complete. A query that finds the queue full is rejected
+func GetQueryAdmissionQueueSize() int {
+	return viper.GetInt("ledger.state.queryAdmission.queueSize")
+}
+
+// GetQueryAdmissionQueueTimeout returns the duration after which a waiting query is rejected.
+// A query waits until it is admitted if not set
+func GetQueryAdmissionQueueTimeout() time.Duration {
+	return viper.GetDuration("ledger.state.queryAdmission.queueTimeout")
+}
+
+// GetQueryMemoryBudget returns the number of the bytes of the keys and the values that the results of a range query,
+// a rich query, or a history query can materialize before the query is aborted. Not limited if not set
+func GetQueryMemoryBudget() int64 {
+	return int64(viper.GetSizeInBytes("ledger.state.queryMemoryBudget.perQuery"))
+}
+
+// GetGlobalQueryMemoryBudget returns the number of the bytes that the results of the queries in progress on the peer
+// can materialize together before a query is aborted. Not limited if not set
+func GetGlobalQueryMemoryBudget() int64 {
+	return int64(viper.GetSizeInBytes("ledger.state.queryMemoryBudget.global"))
+}
+
+// IsNamespaceStatsEnabled returns true if the operations on the state are counted per namespace
+func IsNamespaceStatsEnabled() bool {
+	return viper.GetBool("ledger.state.namespaceStats")
+}
+
+// IsNamespaceSizesEnabled returns true if the number of the keys and the bytes of each namespace are maintained at commit
+func IsNamespaceSizesEnabled() bool {
+	return viper.GetBool("ledger.state.namespaceSizes")
+}
+
+// IsTxResultsArchiveEnabled returns true if the proposal response payloads of the valid transactions are archived at commit
+func IsTxResultsArchiveEnabled() bool {
+	return viper.GetBool("ledger.state.txResultsArchive")
+}
+
+// GetRecentStateVersions returns the number of the most recent blocks whose replaced values are retained in memory,
+// so that the state can be read as of the heights of these blocks. The values are not retained if not set
+func GetRecentStateVersions() int {
+	recentVersions := viper.GetInt("ledger.state.recentVersions")
+	if recentVersions < 0 {
+		return 0
+	}
+	return recentVersions
+}
+
+// GetInvalidTxLogMaxEntries returns the number of the most recent invalidated transactions kept in the log of a ledger.
+// The invalidated transactions are not logged if not set
+func GetInvalidTxLogMaxEntries() int {
+	maxEntries := viper.GetInt("ledger.invalidTxLog.maxEntries")
+	if maxEntries < 0 {
+		return 0
+	}
+	return maxEntries
+}
+
+// IsStateTrieEnabled returns true if the peer maintains a Merkle Patricia trie over the state and records
+// its root after each block in the STATE_ROOT metadata of the block
+func IsStateTrieEnabled() bool {
+	return viper.GetBool("ledger.state.stateTrie.enabled")
+}
+
+// IsWriteAheadLogEnabled returns true if the intent to commit a block is logged before the block is applied to the stores
+func IsWriteAheadLogEnabled() bool {
+	return viper.GetBool("ledger.writeAheadLog.enabled")
+}
+
+// IsInvalidTxLogEnabled returns true if the invalidated transactions are logged at commit
+func IsInvalidTxLogEnabled() bool {
+	return GetInvalidTxLogMaxEntries() > 0
+}
+
+// GetTxIDFilterExpectedTxs returns the number of transactions for which the bloom filter of the transaction ids
+// of a ledger is sized. The ids are looked up in the block index only if not set
+func GetTxIDFilterExpectedTxs() int {
+	expectedTxs := viper.GetInt("ledger.txIDFilter.expectedTxs")
+	if expectedTxs < 0 {
+		return 0
+	}
+	return expectedTxs
+}
+
+// GetStateValueChunkSize returns the size above which the values are split into chunks in the state database.
+// The values are not split if not set
+func GetStateValueChunkSize() int {
+	return int(viper.GetSizeInBytes("ledger.state.valueChunkSize"))
+}
+
+// GetStateDedupMinValueSize returns the size from which the values are stored once by the hash of their content
+// in the goleveldb state database. The values are not deduplicated if not set
+func GetStateDedupMinValueSize() int {
+	return int(viper.GetSizeInBytes("ledger.state.dedupMinValueSize"))
+}
+
+// GetStateLevelDBTuning returns the tuning of the goleveldb state database
+func GetStateLevelDBTuning() leveldbhelper.Tuning {
+	return getLevelDBTuning("ledger.state.levelDB")
+}
+
+// GetSystemStateLevelDBTuning returns the tuning of the goleveldb database of the system namespaces of the state
+func GetSystemStateLevelDBTuning() leveldbhelper.Tuning {
+	return getLevelDBTuning("ledger.state.systemStateDatabase.levelDB")
+}
+
+// GetHistoryLevelDBTuning returns the tuning of the goleveldb history database
+func GetHistoryLevelDBTuning() leveldbhelper.Tuning {
+	return getLevelDBTuning("ledger.state.historyLevelDB")
+}
+
+// GetBlockIndexLevelDBTuning returns the tuning of the goleveldb database that indexes the blocks
+func GetBlockIndexLevelDBTuning() leveldbhelper.Tuning {
+	return getLevelDBTuning("ledger.blockchain.indexLevelDB")
+}
+
+// getLevelDBTuning reads the tuning of a goleveldb database from the given section of the configuration.
+// The sizes can be given with a unit, such as 64MB. The options that are not set retain the defaults of goleveldb
+func getLevelDBTuning(section string) leveldbhelper.Tuning {
+	return leveldbhelper.Tuning{
+		BlockCacheSize:      int(viper.GetSizeInBytes(section + ".blockCacheSize")),
+		WriteBufferSize:     int(viper.GetSizeInBytes(section + ".writeBufferSize")),
+		CompactionTableSize: int(viper.GetSizeInBytes(section + ".compactionTableSize")),
+		CompactionL0Trigger: viper.GetInt(section + ".compactionL0Trigger"),
+		BloomFilterBits:     viper.GetInt(section + ".bloomFilterBits"),
+	}
+}
+
+// GetBlockfileSyncPolicy returns the policy by which the blocks appended to the block files are flushed to the disk.
+// Defaults to flushing every block. A relaxed policy trades the durability of the last blocks for the throughput:
+// a crash of the operating system may lose the blocks that are not flushed yet, while the state and history
+// databases may retain them, which is reported at the next start and repaired by rebuilding the databases
+func GetBlockfileSyncPolicy() util.SyncPolicy {
+	policy := getSyncPolicy("ledger.blockchain.fsync")
+	if policy.IsDisabled() {
+		policy.Blocks = 1
+	}
+	return policy
+}
+
+// GetLevelDBSyncPolicy returns the policy by which the writes to the goleveldb state and history databases are
+// flushed to the disk. By default, the writes are not flushed by the commits as the databases are recovered
+// from the block files after a crash
+func GetLevelDBSyncPolicy() util.SyncPolicy {
+	return getSyncPolicy("ledger.state.fsync")
+}
+
+// GetSystemStateSyncPolicy returns the policy by which the writes to the system state database are flushed to the disk.
+// Defaults to flushing every block that updates the system namespaces
+func GetSystemStateSyncPolicy() util.SyncPolicy {
+	policy := getSyncPolicy("ledger.state.systemStateDatabase.fsync")
+	if policy.IsDisabled() {
+		policy.Blocks = 1
+	}
+	return policy
+}
+
+func getSyncPolicy(section string) util.SyncPolicy {
+	return util.SyncPolicy{
+		Blocks:   viper.GetInt(section + ".blocks"),
+		Interval: viper.GetDuration(section + ".interval"),
+	}
+}
+
+// SharedLevelDBPrefixes contains the prefixes of the names of the dbs of the stores that share a leveldb
+type SharedLevelDBPrefixes struct {
+	BlockIndex string
+	History    string
+	IDStore    string
+}
+
+// IsSharedLevelDBEnabled returns true if the block index, the history database, and the id store share a single leveldb.
+// This reduces the file handles and the compactions on the peers that host many channels. The ledgers are not moved
+// between the dedicated and the shared leveldbs, hence the setting cannot be changed once the peer has ledgers and
+// the ledger provider refuses to start if the ledgers are kept in the leveldbs of the other setting
+func IsSharedLevelDBEnabled() bool {
+	return viper.GetBool("ledger.sharedLevelDB.enabled")
+}
+
+// GetSharedLevelDBPath returns the filesystem path that is used to maintain the shared leveldb
+func GetSharedLevelDBPath() string {
+	return filepath.Join(GetRootPath(), "sharedLeveldb")
+}
+
+// GetSharedLevelDBTuning returns the tuning of the shared goleveldb database
+func GetSharedLevelDBTuning() leveldbhelper.Tuning {
+	return getLevelDBTuning("ledger.sharedLevelDB")
+}
+
+// GetSharedLevelDBPrefixes returns the prefixes of the stores in the shared leveldb. The prefixes are to be
+// non-empty and none of them may be a prefix of another, otherwise the keys of the stores could collide
+func GetSharedLevelDBPrefixes() (*SharedLevelDBPrefixes, error) {
+	prefixes := &SharedLevelDBPrefixes{
+		BlockIndex: getSharedLevelDBPrefix("blockIndex", "blockIndex/"),
+		History:    getSharedLevelDBPrefix("history", "history/"),
+		IDStore:    getSharedLevelDBPrefix("idStore", "ledgerProvider/"),
+	}
+	all := []string{prefixes.BlockIndex, prefixes.History, prefixes.IDStore}
+	for i, prefix := range all {
+		if strings.ContainsRune(prefix, 0) {
+			return nil, fmt.Errorf("shared leveldb prefix [%q] contains a null character", prefix)
+		}
+		for j, other := range all {
+			if i != j && strings.HasPrefix(other, prefix) {
+				return nil, fmt.Errorf("shared leveldb prefix [%s] is a prefix of [%s]", prefix, other)
+			}
+		}
+	}
+	return prefixes, nil
+}
+
+func getSharedLevelDBPrefix(store string, defaultPrefix string) string {
+	prefix := viper.GetString("ledger.sharedLevelDB.prefixes." + store)
+	if prefix == "" {
+		return defaultPrefix
+	}
+	return prefix
+}
+
+// GetLedgerOpenParallelism returns the maximum number of ledgers that are opened, and recovered, in parallel
+// when the peer starts. Defaults to the number of CPUs
+func GetLedgerOpenParallelism() int {
+	parallelism := viper.GetInt("ledger.openParallelism")
+	if parallelism <= 0 {
+		return runtime.NumCPU()
+	}
+	return parallelism
+}
+
+// GetQuiesceTimeout returns the duration after which the commits that are quiesced for a backup are
+// released if they are not released explicitly. Defaults to 5 minutes
+func GetQuiesceTimeout() time.Duration {
+	timeout := viper.GetDuration("ledger.quiesceTimeout")
+	if timeout <= 0 {
+		return defaultQuiesceTimeout
+	}
+	return timeout
+}
+
+// GetCompactionInterval returns the interval at which the block index and the history database of the opened
+// ledgers are compacted in the background. The background compaction is disabled if the interval is not set
+func GetCompactionInterval() time.Duration {
+	return viper.GetDuration("ledger.compactionInterval")
+}
+
+// ChannelConfig contains the ledger configuration of a channel. The configuration is resolved
+// when the ledger of the channel is created and is persisted with the ledger
+type ChannelConfig struct {
+	StateDatabase   string
+	HistoryDatabase bool
+	QueryLimit      int
+	// SystemNamespaces are the namespaces kept in the system state database, apart from the state database.
+	// Empty if the state of all the namespaces is kept in the state database
+	SystemNamespaces []string
+}
+
+// IsCouchDBEnabled tells whether the state database of the channel is stored in CouchDB
+func (c *ChannelConfig) IsCouchDBEnabled() bool {
+	return c.StateDatabase == "CouchDB"
+}
+
+// GetChannelConfig returns the ledger configuration for the given channel.
+// The peer level configuration is overridden by the settings under ledger.channelOverrides.<channelID>
+func GetChannelConfig(channelID string) *ChannelConfig {
+	config := &ChannelConfig{
+		StateDatabase:   viper.GetString("ledger.state.stateDatabase"),
+		HistoryDatabase: IsHistoryDBEnabled(),
+		QueryLimit:      GetQueryLimit(),
+	}
+	overridesKey := "ledger.channelOverrides." + channelID
+	if viper.IsSet(overridesKey + ".stateDatabase") {
+		config.StateDatabase = viper.GetString(overridesKey + ".stateDatabase")
+	}
+	if viper.IsSet(overridesKey + ".historyDatabase") {
+		config.HistoryDatabase = viper.GetBool(overridesKey + ".historyDatabase")
+	}
+	if viper.IsSet(overridesKey+".queryLimit") && viper.GetInt(overridesKey+".queryLimit") > 0 {
+		config.QueryLimit = viper.GetInt(overridesKey + ".queryLimit")
+	}
+	systemStateDatabase := viper.GetBool("ledger.state.systemStateDatabase.enabled")
+	if viper.IsSet(overridesKey + ".systemStateDatabase") {
+		systemStateDatabase = viper.GetBool(overridesKey + ".systemStateDatabase")
+	}
+	if systemStateDatabase {
+		config.SystemNamespaces = getSystemNamespaces()
+	}
+	if config.StateDatabase == "" {
+		config.StateDatabase = "goleveldb"
+	}
+	return config
+}
+
+// getSystemNamespaces returns the sorted namespaces that are kept in the system state database.
+// Defaults to the namespace of the lifecycle system chaincode
+func getSystemNamespaces() []string {
+	namespaces := viper.GetStringSlice("ledger.state.systemStateDatabase.namespaces")
+	if len(namespaces) == 0 {
+		return []string{defaultSystemNamespace}
+	}
+	sorted := append([]string(nil), namespaces...)
+	sort.Strings(sorted)
+	return sorted
+}
+
 // IsQueryReadsHashingEnabled enables or disables computing of hash
 // of range query results for phantom item validation
 func IsQueryReadsHashingEnabled() bool {
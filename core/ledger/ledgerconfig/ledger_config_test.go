@@ -17,9 +17,13 @@ limitations under the License.
 package ledgerconfig
 
 import (
+	"runtime"
 	"testing"
+	"time"
 
 	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/common/ledger/util"
+	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
 	ledgertestutil "github.com/hyperledger/fabric/core/ledger/testutil"
 	"github.com/spf13/viper"
 )
@@ -78,6 +82,123 @@ func TestIsHistoryDBEnabledFalse(t *testing.T) {
 	testutil.AssertEquals(t, updatedValue, false) //test config returns false
 }
 
+func TestIsHistoryDBAsyncCommitEnabled(t *testing.T) {
+	setUpCoreYAMLConfig()
+	testutil.AssertEquals(t, IsHistoryDBAsyncCommitEnabled(), false)
+	viper.Set("ledger.state.historyAsyncCommit", true)
+	defer viper.Set("ledger.state.historyAsyncCommit", false)
+	testutil.AssertEquals(t, IsHistoryDBAsyncCommitEnabled(), true)
+}
+
+func TestGetLedgerOpenParallelism(t *testing.T) {
+	setUpCoreYAMLConfig()
+	testutil.AssertEquals(t, GetLedgerOpenParallelism(), runtime.NumCPU())
+	viper.Set("ledger.openParallelism", 2)
+	defer viper.Set("ledger.openParallelism", 0)
+	testutil.AssertEquals(t, GetLedgerOpenParallelism(), 2)
+}
+
+func TestGetPvtdataStorePurgeInterval(t *testing.T) {
+	setUpCoreYAMLConfig()
+	testutil.AssertEquals(t, GetPvtdataStorePurgeInterval(), uint64(100))
+	viper.Set("ledger.pvtdataStore.purgeInterval", 0)
+	defer viper.Set("ledger.pvtdataStore.purgeInterval", 100)
+	testutil.AssertEquals(t, GetPvtdataStorePurgeInterval(), uint64(defaultPvtdataStorePurgeInterval))
+	viper.Set("ledger.pvtdataStore.purgeInterval", 1)
+	testutil.AssertEquals(t, GetPvtdataStorePurgeInterval(), uint64(1))
+}
+
+func TestGetLevelDBTuning(t *testing.T) {
+	setUpCoreYAMLConfig()
+	testutil.AssertEquals(t, GetStateLevelDBTuning(), leveldbhelper.Tuning{})
+	viper.Set("ledger.state.levelDB.blockCacheSize", "64MB")
+	viper.Set("ledger.state.levelDB.bloomFilterBits", 10)
+	defer viper.Set("ledger.state.levelDB.blockCacheSize", "")
+	defer viper.Set("ledger.state.levelDB.bloomFilterBits", "")
+	testutil.AssertEquals(t, GetStateLevelDBTuning(), leveldbhelper.Tuning{BlockCacheSize: 64 * 1024 * 1024, BloomFilterBits: 10})
+	testutil.AssertEquals(t, GetHistoryLevelDBTuning(), leveldbhelper.Tuning{})
+}
+
+func TestGetSyncPolicy(t *testing.T) {
+	setUpCoreYAMLConfig()
+	testutil.AssertEquals(t, GetBlockfileSyncPolicy(), util.SyncPolicy{Blocks: 1})
+	testutil.AssertEquals(t, GetLevelDBSyncPolicy().IsDisabled(), true)
+	viper.Set("ledger.blockchain.fsync.blocks", 0)
+	viper.Set("ledger.blockchain.fsync.interval", "10ms")
+	viper.Set("ledger.state.fsync.blocks", 100)
+	defer viper.Set("ledger.blockchain.fsync.blocks", 1)
+	defer viper.Set("ledger.blockchain.fsync.interval", "")
+	defer viper.Set("ledger.state.fsync.blocks", 0)
+	testutil.AssertEquals(t, GetBlockfileSyncPolicy(), util.SyncPolicy{Interval: 10 * time.Millisecond})
+	testutil.AssertEquals(t, GetLevelDBSyncPolicy(), util.SyncPolicy{Blocks: 100})
+}
+
+func TestGetSharedLevelDBPrefixes(t *testing.T) {
+	setUpCoreYAMLConfig()
+	testutil.AssertEquals(t, IsSharedLevelDBEnabled(), false)
+	prefixes, err := GetSharedLevelDBPrefixes()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, prefixes, &SharedLevelDBPrefixes{BlockIndex: "blockIndex/", History: "history/", IDStore: "ledgerProvider/"})
+
+	viper.Set("ledger.sharedLevelDB.prefixes.history", "h/")
+	defer viper.Set("ledger.sharedLevelDB.prefixes.history", "history/")
+	prefixes, err = GetSharedLevelDBPrefixes()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, prefixes.History, "h/")
+
+	viper.Set("ledger.sharedLevelDB.prefixes.idStore", "h/ids/")
+	defer viper.Set("ledger.sharedLevelDB.prefixes.idStore", "ledgerProvider/")
+	_, err = GetSharedLevelDBPrefixes()
+	testutil.AssertError(t, err, "Expected an error for a prefix of another prefix")
+}
+
+func TestGetQueryLimit(t *testing.T) {
+	setUpCoreYAMLConfig()
+	testutil.AssertEquals(t, GetQueryLimit(), 1000)
+	viper.Set("ledger.state.couchDBConfig.queryLimit", 0)
+	defer viper.Set("ledger.state.couchDBConfig.queryLimit", 1000)
+	testutil.AssertEquals(t, GetQueryLimit(), defaultQueryLimit)
+}
+
+func TestGetHashAlgorithm(t *testing.T) {
+	setUpCoreYAMLConfig()
+	testutil.AssertEquals(t, GetHashAlgorithm(), "SHA256")
+	viper.Set("ledger.hashAlgorithm", "SHA3_256")
+	defer viper.Set("ledger.hashAlgorithm", "SHA256")
+	testutil.AssertEquals(t, GetHashAlgorithm(), "SHA3_256")
+	viper.Set("ledger.hashAlgorithm", "")
+	testutil.AssertEquals(t, GetHashAlgorithm(), "SHA256")
+}
+
+func TestGetChannelConfig(t *testing.T) {
+	setUpCoreYAMLConfig()
+	defer ledgertestutil.ResetConfigToDefaultValues()
+	viper.Set("ledger.state.historyDatabase", true)
+	testutil.AssertEquals(t, GetChannelConfig("ch1"), &ChannelConfig{StateDatabase: "goleveldb", HistoryDatabase: true, QueryLimit: 1000})
+
+	overrides := map[string]interface{}{
+		"ledger.channelOverrides.ch1.stateDatabase":   "CouchDB",
+		"ledger.channelOverrides.ch1.historyDatabase": false,
+		"ledger.channelOverrides.ch1.queryLimit":      50,
+	}
+	for key, value := range overrides {
+		viper.Set(key, value)
+		defer viper.Set(key, nil)
+	}
+	config := GetChannelConfig("ch1")
+	testutil.AssertEquals(t, config, &ChannelConfig{StateDatabase: "CouchDB", HistoryDatabase: false, QueryLimit: 50})
+	testutil.AssertEquals(t, config.IsCouchDBEnabled(), true)
+	// the overrides apply only to the configured channel
+	testutil.AssertEquals(t, GetChannelConfig("ch2"), &ChannelConfig{StateDatabase: "goleveldb", HistoryDatabase: true, QueryLimit: 1000})
+
+	viper.Set("ledger.channelOverrides.ch2.systemStateDatabase", true)
+	defer viper.Set("ledger.channelOverrides.ch2.systemStateDatabase", nil)
+	testutil.AssertEquals(t, GetChannelConfig("ch2").SystemNamespaces, []string{"lccc"})
+	viper.Set("ledger.state.systemStateDatabase.namespaces", []string{"lccc", "cscc"})
+	defer viper.Set("ledger.state.systemStateDatabase.namespaces", nil)
+	testutil.AssertEquals(t, GetChannelConfig("ch2").SystemNamespaces, []string{"cscc", "lccc"})
+}
+
 func setUpCoreYAMLConfig() {
 	//call a helper method to load the core.yaml
 	ledgertestutil.SetupCoreYAMLConfig("./../../../peer")
@@ -17,23 +17,36 @@ limitations under the License.
 package ledgermgmt
 
 import (
-	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"fmt"
 
+	"github.com/hyperledger/fabric/common/ledger/blkstorage"
+	"github.com/hyperledger/fabric/common/ledger/util"
 	"github.com/hyperledger/fabric/core/ledger"
 	"github.com/hyperledger/fabric/core/ledger/kvledger"
+	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/utils"
 	logging "github.com/op/go-logging"
 )
 
 var logger = logging.MustGetLogger("ledgermgmt")
 
-// ErrLedgerAlreadyOpened is thrown by a CreateLedger call if a ledger with the given id is already opened
-var ErrLedgerAlreadyOpened = errors.New("Ledger already opened")
+// ErrLedgerAlreadyOpened is thrown by a OpenLedger or DestroyLedger call if a ledger with the given id is already opened
+var ErrLedgerAlreadyOpened = &ledger.ConflictError{Msg: "Ledger already opened"}
 
 // ErrLedgerMgmtNotInitialized is thrown when ledger mgmt is used before initializing this
-var ErrLedgerMgmtNotInitialized = errors.New("ledger mgmt should be initialized before using")
+var ErrLedgerMgmtNotInitialized = &ledger.UnavailableError{Msg: "ledger mgmt should be initialized before using"}
+
+// ErrMaintenanceMode is thrown by a Commit call on a ledger while the peer is in maintenance mode
+var ErrMaintenanceMode = &ledger.UnavailableError{Msg: "Peer is in maintenance mode"}
 
 var openedLedgers map[string]ledger.PeerLedger
 var ledgerProvider ledger.PeerLedgerProvider
@@ -41,6 +54,32 @@ var lock sync.Mutex
 var initialized bool
 var once sync.Once
 
+// maintenanceMode is guarded by maintenanceLock. A commit holds the read lock for its duration
+// so that the switch to maintenance mode waits for the commits in progress
+var maintenanceMode bool
+var maintenanceLock sync.RWMutex
+
+// ErrCommitsQuiesced is thrown by a QuiesceCommits call while the commits are already quiesced
+var ErrCommitsQuiesced = &ledger.ConflictError{Msg: "Commits are already quiesced"}
+
+// ErrCommitsNotQuiesced is thrown by a ReleaseCommits call while the commits are not quiesced
+var ErrCommitsNotQuiesced = &ledger.ConflictError{Msg: "Commits are not quiesced"}
+
+// quiesceLock is held for write while the commits are quiesced. A commit holds the read lock for its duration
+// so that a commit that arrives during the quiescence waits for the release instead of failing.
+// quiesced, quiesceTimer, and quiesceGeneration are guarded by quiesceStateLock
+var quiesceLock sync.RWMutex
+var quiesceStateLock sync.Mutex
+var quiesced bool
+var quiesceTimer *time.Timer
+var quiesceGeneration uint64
+
+// compactionStop is closed to stop the background compaction of the ledgers and compactionDone is closed when the
+// background compaction has stopped. Both are nil if the background compaction is not running and are guarded by compactionLock
+var compactionLock sync.Mutex
+var compactionStop chan struct{}
+var compactionDone chan struct{}
+
 // Initialize initializes ledgermgmt
 func Initialize() {
 	once.Do(func() {
@@ -53,12 +92,16 @@ func initialize() {
 	lock.Lock()
 	defer lock.Unlock()
 	initialized = true
+	maintenanceMode = false
 	openedLedgers = make(map[string]ledger.PeerLedger)
 	provider, err := kvledger.NewProvider()
 	if err != nil {
 		panic(fmt.Errorf("Error in instantiating ledger provider: %s", err))
 	}
 	ledgerProvider = provider
+	if interval := ledgerconfig.GetCompactionInterval(); interval > 0 {
+		startCompactionSchedule(interval)
+	}
 	logger.Info("ledger mgmt initialized")
 }
 
@@ -80,6 +123,47 @@ func CreateLedger(id string) (ledger.PeerLedger, error) {
 	return l, nil
 }
 
+// CreateLedgerFromGenesisBlock creates a new ledger from the given genesis block, which is validated and committed,
+// and records the given bootstrap method along with the orderer endpoints listed in the block
+func CreateLedgerFromGenesisBlock(genesisBlock *common.Block, bootstrapMethod string) (ledger.PeerLedger, error) {
+	id, err := utils.GetChainIDFromBlock(genesisBlock)
+	if err != nil {
+		return nil, &ledger.InvalidBlockError{Msg: fmt.Sprintf("Cannot get the channel of the genesis block: %s", err)}
+	}
+	logger.Infof("Creating ledger with id = %s from genesis block with bootstrap method [%s]", id, bootstrapMethod)
+	lock.Lock()
+	defer lock.Unlock()
+	if !initialized {
+		return nil, ErrLedgerMgmtNotInitialized
+	}
+	l, err := ledgerProvider.CreateFromGenesisBlock(genesisBlock, bootstrapMethod)
+	if err != nil {
+		return nil, err
+	}
+	l = wrapLedger(id, l)
+	openedLedgers[id] = l
+	logger.Infof("Created ledger with id = %s from genesis block", id)
+	return l, nil
+}
+
+// CreateLedgerFromSnapshot creates a new ledger from the snapshot in the given directory
+func CreateLedgerFromSnapshot(snapshotDir string) (ledger.PeerLedger, error) {
+	logger.Infof("Creating ledger from snapshot at %s", snapshotDir)
+	lock.Lock()
+	defer lock.Unlock()
+	if !initialized {
+		return nil, ErrLedgerMgmtNotInitialized
+	}
+	l, id, err := ledgerProvider.CreateFromSnapshot(snapshotDir)
+	if err != nil {
+		return nil, err
+	}
+	l = wrapLedger(id, l)
+	openedLedgers[id] = l
+	logger.Infof("Created ledger with id = %s from snapshot", id)
+	return l, nil
+}
+
 // OpenLedger returns a ledger for the given id
 func OpenLedger(id string) (ledger.PeerLedger, error) {
 	logger.Infof("Opening leadger with id = %s", id)
@@ -102,8 +186,126 @@ func OpenLedger(id string) (ledger.PeerLedger, error) {
 	return l, nil
 }
 
-// GetLedgerIDs returns the ids of the ledgers created
+// BulkOpenError is returned by OpenLedgers if one or more of the ledgers fail to open.
+// Errs contains the error for each of the ledgers that failed to open
+type BulkOpenError struct {
+	Errs map[string]error
+}
+
+func (e *BulkOpenError) Error() string {
+	var ids []string
+	for id := range e.Errs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	var msgs []string
+	for _, id := range ids {
+		msgs = append(msgs, fmt.Sprintf("[%s]: %s", id, e.Errs[id]))
+	}
+	return fmt.Sprintf("Failed to open %d ledger(s): %s", len(ids), strings.Join(msgs, ", "))
+}
+
+// OpenLedgers opens the ledgers with the given ids. The ledgers are opened, and recovered from the block storage
+// if required, in parallel by at most ledger.openParallelism workers so that the time taken is determined
+// by the slowest ledger rather than the sum of all. The ledgers that are opened successfully are returned
+// even if some of the ledgers fail to open, in which case the error is a *BulkOpenError
+func OpenLedgers(ids []string) (map[string]ledger.PeerLedger, error) {
+	logger.Infof("Opening %d ledgers", len(ids))
+	lock.Lock()
+	defer lock.Unlock()
+	if !initialized {
+		return nil, ErrLedgerMgmtNotInitialized
+	}
+
+	ledgers := make(map[string]ledger.PeerLedger)
+	errs := make(map[string]error)
+	queued := make(map[string]bool)
+	idsChan := make(chan string, len(ids))
+	for _, id := range ids {
+		if _, ok := openedLedgers[id]; ok {
+			errs[id] = ErrLedgerAlreadyOpened
+			continue
+		}
+		if !queued[id] {
+			queued[id] = true
+			idsChan <- id
+		}
+	}
+	close(idsChan)
+
+	var resultLock sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < ledgerconfig.GetLedgerOpenParallelism(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range idsChan {
+				l, err := ledgerProvider.Open(id)
+				resultLock.Lock()
+				if err != nil {
+					logger.Errorf("Failed to open ledger with id = %s: %s", id, err)
+					errs[id] = err
+				} else {
+					ledgers[id] = l
+				}
+				resultLock.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	for id, l := range ledgers {
+		l = wrapLedger(id, l)
+		ledgers[id] = l
+		openedLedgers[id] = l
+	}
+	logger.Infof("Opened %d ledgers", len(ledgers))
+	if len(errs) > 0 {
+		return ledgers, &BulkOpenError{errs}
+	}
+	return ledgers, nil
+}
+
+// DestroyLedger removes all the data of the ledger with the given id. The ledger should be closed before this call
+func DestroyLedger(id string) error {
+	logger.Infof("Destroying ledger with id = %s", id)
+	lock.Lock()
+	defer lock.Unlock()
+	if !initialized {
+		return ErrLedgerMgmtNotInitialized
+	}
+	if _, ok := openedLedgers[id]; ok {
+		return ErrLedgerAlreadyOpened
+	}
+	if err := ledgerProvider.Destroy(id); err != nil {
+		return err
+	}
+	logger.Infof("Destroyed ledger with id = %s", id)
+	return nil
+}
+
+// GetLedgerIDs returns the ids of the active ledgers
 func GetLedgerIDs() ([]string, error) {
+	lock.Lock()
+	defer lock.Unlock()
+	if !initialized {
+		return nil, ErrLedgerMgmtNotInitialized
+	}
+	ledgerInfos, err := ledgerProvider.List()
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, ledgerInfo := range ledgerInfos {
+		if ledgerInfo.Status == ledger.LedgerStatusActive {
+			ids = append(ids, ledgerInfo.LedgerID)
+		}
+	}
+	return ids, nil
+}
+
+// ListLedgers returns the heights and the statuses of all the ledgers
+func ListLedgers() ([]*ledger.LedgerInfo, error) {
 	lock.Lock()
 	defer lock.Unlock()
 	if !initialized {
@@ -112,9 +314,303 @@ func GetLedgerIDs() ([]string, error) {
 	return ledgerProvider.List()
 }
 
+// GetLedgerMetadata returns the creation info and the labels of the ledger with the given id
+func GetLedgerMetadata(id string) (*ledger.LedgerMetadata, error) {
+	lock.Lock()
+	defer lock.Unlock()
+	if !initialized {
+		return nil, ErrLedgerMgmtNotInitialized
+	}
+	return ledgerProvider.GetLedgerMetadata(id)
+}
+
+// SetLedgerLabels replaces the labels of the ledger with the given id
+func SetLedgerLabels(id string, labels map[string]string) error {
+	lock.Lock()
+	defer lock.Unlock()
+	if !initialized {
+		return ErrLedgerMgmtNotInitialized
+	}
+	return ledgerProvider.SetLedgerLabels(id, labels)
+}
+
+// GetDiskUsage returns the number of bytes that the stores of the opened ledger with the given id occupy on the disk
+func GetDiskUsage(id string) (*ledger.DiskUsage, error) {
+	lock.Lock()
+	defer lock.Unlock()
+	l, err := getOpenedLedger(id)
+	if err != nil {
+		return nil, err
+	}
+	return l.GetDiskUsage()
+}
+
+// GetBlockFingerprint returns the hash and the commit hash of the block with the given number of the opened ledger
+// with the given id. blockNumber of math.MaxUint64 returns those of the last block
+func GetBlockFingerprint(id string, blockNumber uint64) (*ledger.BlockFingerprint, error) {
+	lock.Lock()
+	defer lock.Unlock()
+	l, err := getOpenedLedger(id)
+	if err != nil {
+		return nil, err
+	}
+	block, err := l.GetBlockByNumber(blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	// the block hash is computed as the block store computes it, so that it matches the hashes in the blockchain info
+	blockHash, err := blkstorage.ComputeBlockHash(block.Header)
+	if err != nil {
+		return nil, err
+	}
+	commitHash, err := l.GetCommitHash(block.Header.Number)
+	if err != nil {
+		return nil, err
+	}
+	return &ledger.BlockFingerprint{BlockNumber: block.Header.Number, BlockHash: blockHash, CommitHash: commitHash}, nil
+}
+
+// GetStateFingerprint returns the hashes of the contents of the state database per namespace of the opened ledger with the given id
+func GetStateFingerprint(id string) (*ledger.StateFingerprint, error) {
+	lock.Lock()
+	defer lock.Unlock()
+	l, err := getOpenedLedger(id)
+	if err != nil {
+		return nil, err
+	}
+	return l.GetStateFingerprint()
+}
+
+// WarmUpState reads the keys most recently written to the state database of the opened ledger with the given id into
+// the caches of the state database, up to the given number of keys per namespace, and returns the number of keys read
+func WarmUpState(id string, keysPerNamespace int) (uint64, error) {
+	lock.Lock()
+	defer lock.Unlock()
+	l, err := getOpenedLedger(id)
+	if err != nil {
+		return 0, err
+	}
+	return l.WarmUpState(keysPerNamespace)
+}
+
+// GetNamespaceStats returns the number of the operations on the state per namespace of the opened ledger with the given id
+func GetNamespaceStats(id string) ([]*ledger.NamespaceStats, error) {
+	lock.Lock()
+	defer lock.Unlock()
+	l, err := getOpenedLedger(id)
+	if err != nil {
+		return nil, err
+	}
+	return l.GetNamespaceStats()
+}
+
+// GetInvalidTransactions returns the most recent transactions in the log of the invalidated transactions of the
+// opened ledger with the given id, up to the given number of the transactions or all the logged transactions if zero
+func GetInvalidTransactions(id string, maxEntries int) ([]*ledger.InvalidTransaction, error) {
+	lock.Lock()
+	defer lock.Unlock()
+	l, err := getOpenedLedger(id)
+	if err != nil {
+		return nil, err
+	}
+	return l.GetInvalidTransactions(maxEntries)
+}
+
+// CompactLedger compacts the block index and the history database of the opened ledger with the given id.
+// The lock is not held during the compaction, which may take long for a large ledger
+func CompactLedger(id string) error {
+	lock.Lock()
+	l, err := getOpenedLedger(id)
+	lock.Unlock()
+	if err != nil {
+		return err
+	}
+	return l.Compact()
+}
+
+// SnapshotSystemState generates a snapshot of the system namespaces of the state of the opened ledger with the given id
+// and returns the directory of the snapshot along with the number of the last block included in it. The snapshots are kept
+// in the system state snapshots directory and are named after the ledger and the block, hence a second request at the same
+// block returns the existing snapshot
+func SnapshotSystemState(id string) (string, uint64, error) {
+	lock.Lock()
+	l, err := getOpenedLedger(id)
+	lock.Unlock()
+	if err != nil {
+		return "", 0, err
+	}
+	snapshotsDir := ledgerconfig.GetSystemStateSnapshotsPath()
+	if _, err := util.CreateDirIfMissing(snapshotsDir); err != nil {
+		return "", 0, err
+	}
+	// the snapshot is generated in a temporary directory and is moved in place once complete
+	tempDir, err := ioutil.TempDir(snapshotsDir, "."+id+"-")
+	if err != nil {
+		return "", 0, err
+	}
+	defer os.RemoveAll(tempDir)
+	tempSnapshotDir := filepath.Join(tempDir, "snapshot")
+	if err := l.GenerateSystemStateSnapshot(tempSnapshotDir); err != nil {
+		return "", 0, err
+	}
+	metadata, err := kvledger.LoadSystemStateSnapshotMetadata(tempSnapshotDir)
+	if err != nil {
+		return "", 0, err
+	}
+	snapshotDir := filepath.Join(snapshotsDir, fmt.Sprintf("%s-%d", id, metadata.LastBlockNumber))
+	if _, err := os.Stat(snapshotDir); err == nil {
+		return snapshotDir, metadata.LastBlockNumber, nil
+	}
+	if err := os.Rename(tempSnapshotDir, snapshotDir); err != nil {
+		return "", 0, err
+	}
+	logger.Infof("Channel [%s]: Generated system state snapshot at block [%d] in directory [%s]", id, metadata.LastBlockNumber, snapshotDir)
+	return snapshotDir, metadata.LastBlockNumber, nil
+}
+
+// GenerateSnapshot generates a snapshot of the opened ledger with the given id and returns the directory of the snapshot
+// along with the number of the last block included in it. The snapshots are kept in the ledger snapshots directory and are
+// named after the ledger and the block, hence a second request at the same block returns the existing snapshot
+func GenerateSnapshot(id string) (string, uint64, error) {
+	lock.Lock()
+	l, err := getOpenedLedger(id)
+	lock.Unlock()
+	if err != nil {
+		return "", 0, err
+	}
+	snapshotsDir := ledgerconfig.GetLedgerSnapshotsPath()
+	if _, err := util.CreateDirIfMissing(snapshotsDir); err != nil {
+		return "", 0, err
+	}
+	// the snapshot is generated in a temporary directory and is moved in place once complete
+	tempDir, err := ioutil.TempDir(snapshotsDir, "."+id+"-")
+	if err != nil {
+		return "", 0, err
+	}
+	defer os.RemoveAll(tempDir)
+	tempSnapshotDir := filepath.Join(tempDir, "snapshot")
+	if err := l.GenerateSnapshot(tempSnapshotDir); err != nil {
+		return "", 0, err
+	}
+	metadata, err := kvledger.LoadSnapshotMetadata(tempSnapshotDir)
+	if err != nil {
+		return "", 0, err
+	}
+	snapshotDir := filepath.Join(snapshotsDir, fmt.Sprintf("%s-%d", id, metadata.LastBlockNumber))
+	if _, err := os.Stat(snapshotDir); err == nil {
+		return snapshotDir, metadata.LastBlockNumber, nil
+	}
+	if err := os.Rename(tempSnapshotDir, snapshotDir); err != nil {
+		return "", 0, err
+	}
+	logger.Infof("Channel [%s]: Generated snapshot at block [%d] in directory [%s]", id, metadata.LastBlockNumber, snapshotDir)
+	return snapshotDir, metadata.LastBlockNumber, nil
+}
+
+// PruneLedger prunes the opened ledger with the given id
+func PruneLedger(id string) error {
+	lock.Lock()
+	l, err := getOpenedLedger(id)
+	lock.Unlock()
+	if err != nil {
+		return err
+	}
+	return l.Prune(nil)
+}
+
+// ScheduleMaintenance schedules the given maintenance operations to run on the ledger with the given id when the
+// ledger is opened next time. The operations that cannot run on an opened ledger, such as a rollback, are thus
+// requested from a running peer and take effect when the peer restarts
+func ScheduleMaintenance(id string, schedule *ledger.MaintenanceSchedule) error {
+	lock.Lock()
+	defer lock.Unlock()
+	if !initialized {
+		return ErrLedgerMgmtNotInitialized
+	}
+	return ledgerProvider.ScheduleMaintenance(id, schedule)
+}
+
+// startCompactionSchedule starts compacting the opened ledgers, one after the other, at the given interval
+func startCompactionSchedule(interval time.Duration) {
+	compactionLock.Lock()
+	defer compactionLock.Unlock()
+	if compactionStop != nil {
+		return
+	}
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	compactionStop = stop
+	compactionDone = done
+	logger.Infof("Compacting the ledgers every %s", interval)
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				compactOpenedLedgers(stop)
+			}
+		}
+	}()
+}
+
+// stopCompactionSchedule stops the background compaction and waits for the compaction in progress, if any, to finish
+func stopCompactionSchedule() {
+	compactionLock.Lock()
+	defer compactionLock.Unlock()
+	if compactionStop == nil {
+		return
+	}
+	close(compactionStop)
+	<-compactionDone
+	compactionStop = nil
+	compactionDone = nil
+}
+
+// compactOpenedLedgers compacts the ledgers that are opened, skipping the ledgers that are closed meanwhile
+func compactOpenedLedgers(stop chan struct{}) {
+	lock.Lock()
+	var ids []string
+	for id := range openedLedgers {
+		ids = append(ids, id)
+	}
+	lock.Unlock()
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		if err := CompactLedger(id); err != nil {
+			if _, ok := err.(*ledger.NotFoundError); ok {
+				continue
+			}
+			logger.Warningf("Error while compacting ledger [%s]: %s", id, err)
+		}
+	}
+}
+
+// getOpenedLedger returns the opened ledger with the given id. This is expected to be invoked with the lock held
+func getOpenedLedger(id string) (ledger.PeerLedger, error) {
+	if !initialized {
+		return nil, ErrLedgerMgmtNotInitialized
+	}
+	l, ok := openedLedgers[id]
+	if !ok {
+		return nil, &ledger.NotFoundError{Msg: fmt.Sprintf("Ledger [%s] is not opened", id)}
+	}
+	return l, nil
+}
+
 // Close closes all the opened ledgers and any resources held for ledger management
 func Close() {
 	logger.Infof("Closing ledger mgmt")
+	stopCompactionSchedule()
 	lock.Lock()
 	defer lock.Unlock()
 	if !initialized {
@@ -128,6 +624,108 @@ func Close() {
 	logger.Infof("ledger mgmt closed")
 }
 
+// EnterMaintenanceMode switches the peer to maintenance mode. The ledgers remain open for the queries
+// but no block is committed till the peer exits the maintenance mode. This allows the heavyweight operations
+// such as a snapshot or a rebuild of a database to run on a ledger that is not changing underneath.
+// This returns after the commits that are already in progress finish
+func EnterMaintenanceMode() {
+	maintenanceLock.Lock()
+	defer maintenanceLock.Unlock()
+	maintenanceMode = true
+	logger.Info("Entered maintenance mode. Commits are disabled")
+}
+
+// ExitMaintenanceMode switches the peer back to the normal operation
+func ExitMaintenanceMode() {
+	maintenanceLock.Lock()
+	defer maintenanceLock.Unlock()
+	maintenanceMode = false
+	logger.Info("Exited maintenance mode. Commits are enabled")
+}
+
+// IsInMaintenanceMode returns true if the peer is in maintenance mode
+func IsInMaintenanceMode() bool {
+	maintenanceLock.RLock()
+	defer maintenanceLock.RUnlock()
+	return maintenanceMode
+}
+
+// QuiesceCommits waits for the commits in progress to finish, blocks the further commits, and flushes the stores of
+// all the opened ledgers to the disk. The returned heights of the stores of each ledger remain unchanged till the
+// ReleaseCommits call and hence the block store, the state database, and the history database can be copied by
+// an external backup tool as a consistent set. The commits are released automatically after the given timeout
+// so that a backup tool that fails to release the commits does not halt the peer
+func QuiesceCommits(timeout time.Duration) (map[string]*ledger.StoreHeights, error) {
+	quiesceStateLock.Lock()
+	defer quiesceStateLock.Unlock()
+	if quiesced {
+		return nil, ErrCommitsQuiesced
+	}
+	quiesceLock.Lock()
+	heights, err := flushOpenedLedgers()
+	if err != nil {
+		quiesceLock.Unlock()
+		return nil, err
+	}
+	quiesced = true
+	quiesceGeneration++
+	generation := quiesceGeneration
+	quiesceTimer = time.AfterFunc(timeout, func() {
+		quiesceStateLock.Lock()
+		defer quiesceStateLock.Unlock()
+		// the commits may have been released, and quiesced again, while the timer fired
+		if quiesced && quiesceGeneration == generation {
+			logger.Warningf("Commits were not released within %s, releasing the commits", timeout)
+			releaseCommitsWithoutLock()
+		}
+	})
+	logger.Infof("Quiesced the commits of %d ledger(s)", len(heights))
+	return heights, nil
+}
+
+// ReleaseCommits resumes the commits that are blocked by QuiesceCommits
+func ReleaseCommits() error {
+	quiesceStateLock.Lock()
+	defer quiesceStateLock.Unlock()
+	if !quiesced {
+		return ErrCommitsNotQuiesced
+	}
+	releaseCommitsWithoutLock()
+	return nil
+}
+
+func releaseCommitsWithoutLock() {
+	quiesceTimer.Stop()
+	quiesceTimer = nil
+	quiesced = false
+	quiesceLock.Unlock()
+	logger.Info("Released the commits")
+}
+
+// AreCommitsQuiesced returns true if the commits are quiesced
+func AreCommitsQuiesced() bool {
+	quiesceStateLock.Lock()
+	defer quiesceStateLock.Unlock()
+	return quiesced
+}
+
+func flushOpenedLedgers() (map[string]*ledger.StoreHeights, error) {
+	lock.Lock()
+	defer lock.Unlock()
+	if !initialized {
+		return nil, ErrLedgerMgmtNotInitialized
+	}
+	heights := make(map[string]*ledger.StoreHeights)
+	for id, l := range openedLedgers {
+		h, err := l.Flush()
+		if err != nil {
+			return nil, fmt.Errorf("Error while flushing ledger [%s]: %s", id, err)
+		}
+		heights[id] = h
+	}
+	return heights, nil
+}
+
 func wrapLedger(id string, l ledger.PeerLedger) ledger.PeerLedger {
 	return &closableLedger{id, l}
 }
@@ -145,6 +743,25 @@ func (l *closableLedger) Close() {
 	l.closeWithoutLock()
 }
 
+// Commit commits the block to the actual ledger unless the peer is in maintenance mode
+func (l *closableLedger) Commit(block *common.Block) error {
+	return l.CommitWithPvtData(block, nil, nil)
+}
+
+// CommitWithPvtData commits the block and the private data to the actual ledger unless the peer is in maintenance mode.
+// The commit waits while the commits are quiesced
+func (l *closableLedger) CommitWithPvtData(block *common.Block, pvtData []*ledger.TxPvtData, missingPvtData []*ledger.MissingPvtData) error {
+	quiesceLock.RLock()
+	defer quiesceLock.RUnlock()
+	maintenanceLock.RLock()
+	defer maintenanceLock.RUnlock()
+	if maintenanceMode {
+		logger.Debugf("Channel [%s]: Rejecting block [%d] as the peer is in maintenance mode", l.id, block.Header.Number)
+		return ErrMaintenanceMode
+	}
+	return l.PeerLedger.CommitWithPvtData(block, pvtData, missingPvtData)
+}
+
 func (l *closableLedger) closeWithoutLock() {
 	l.PeerLedger.Close()
 	delete(openedLedgers, l.id)
@@ -18,12 +18,15 @@ package ledgermgmt
 
 import (
 	"fmt"
+	"math"
 	"testing"
 
 	"os"
+	"time"
 
 	"github.com/hyperledger/fabric/common/ledger/testutil"
 	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger"
 	"github.com/spf13/viper"
 )
 
@@ -56,6 +59,17 @@ func TestLedgerMgmt(t *testing.T) {
 	l, err = OpenLedger(ledgerID)
 	testutil.AssertEquals(t, err, ErrLedgerAlreadyOpened)
 
+	// an opened ledger cannot be destroyed
+	testutil.AssertEquals(t, DestroyLedger(ledgerID), ErrLedgerAlreadyOpened)
+	destroyedLedgerID := constructTestLedgerID(3)
+	ledgers[3].Close()
+	testutil.AssertNoError(t, DestroyLedger(destroyedLedgerID), "")
+	ledgerIDs, _ := GetLedgerIDs()
+	testutil.AssertEquals(t, len(ledgerIDs), numLedgers-1)
+	ledgerInfos, _ := ListLedgers()
+	testutil.AssertEquals(t, len(ledgerInfos), numLedgers-1)
+	testutil.AssertEquals(t, ledgerInfos[0].Status, ledger.LedgerStatusActive)
+
 	// close all opened ledgers and ledger mgmt
 	Close()
 	// Restart ledger mgmt with existing ledgers
@@ -65,6 +79,198 @@ func TestLedgerMgmt(t *testing.T) {
 	Close()
 }
 
+func TestMaintenanceMode(t *testing.T) {
+	InitializeTestEnv()
+	defer CleanupTestEnv()
+	l, _ := CreateLedger(constructTestLedgerID(0))
+	bg := testutil.NewBlockGenerator(t)
+	testutil.AssertNoError(t, l.Commit(bg.NextBlock([][]byte{}, false)), "")
+
+	EnterMaintenanceMode()
+	testutil.AssertEquals(t, IsInMaintenanceMode(), true)
+	block := bg.NextBlock([][]byte{}, false)
+	testutil.AssertEquals(t, l.Commit(block), ErrMaintenanceMode)
+	// the ledger remains available for the queries
+	bcInfo, err := l.GetBlockchainInfo()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, bcInfo.Height, uint64(1))
+
+	ExitMaintenanceMode()
+	testutil.AssertEquals(t, IsInMaintenanceMode(), false)
+	testutil.AssertNoError(t, l.Commit(block), "")
+	bcInfo, _ = l.GetBlockchainInfo()
+	testutil.AssertEquals(t, bcInfo.Height, uint64(2))
+}
+
+func TestOpenLedgers(t *testing.T) {
+	InitializeTestEnv()
+	defer CleanupTestEnv()
+	viper.Set("ledger.openParallelism", 3)
+	defer viper.Set("ledger.openParallelism", 0)
+
+	numLedgers := 10
+	var ledgerIDs []string
+	for i := 0; i < numLedgers; i++ {
+		l, _ := CreateLedger(constructTestLedgerID(i))
+		l.Close()
+		ledgerIDs = append(ledgerIDs, constructTestLedgerID(i))
+	}
+	alreadyOpened, _ := OpenLedger(constructTestLedgerID(0))
+	defer alreadyOpened.Close()
+
+	ledgers, err := OpenLedgers(append(ledgerIDs, "nonExistingLedger"))
+	testutil.AssertEquals(t, len(ledgers), numLedgers-1)
+	bulkOpenErr, ok := err.(*BulkOpenError)
+	testutil.AssertEquals(t, ok, true)
+	testutil.AssertEquals(t, len(bulkOpenErr.Errs), 2)
+	testutil.AssertEquals(t, bulkOpenErr.Errs[constructTestLedgerID(0)], ErrLedgerAlreadyOpened)
+	testutil.AssertError(t, bulkOpenErr.Errs["nonExistingLedger"], "")
+
+	// the ledgers opened in bulk are registered as opened
+	_, err = OpenLedger(constructTestLedgerID(5))
+	testutil.AssertEquals(t, err, ErrLedgerAlreadyOpened)
+	ledgers[constructTestLedgerID(5)].Close()
+	l, err := OpenLedger(constructTestLedgerID(5))
+	testutil.AssertNoError(t, err, "")
+	l.Close()
+}
+
+func TestGetDiskUsage(t *testing.T) {
+	InitializeTestEnv()
+	defer CleanupTestEnv()
+	l, _ := CreateLedger(constructTestLedgerID(0))
+	bg := testutil.NewBlockGenerator(t)
+	testutil.AssertNoError(t, l.Commit(bg.NextBlock([][]byte{}, false)), "")
+
+	diskUsage, err := GetDiskUsage(constructTestLedgerID(0))
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, diskUsage.BlockStoreBytes > 0, true)
+
+	_, err = GetDiskUsage(constructTestLedgerID(1))
+	_, ok := err.(*ledger.NotFoundError)
+	testutil.AssertEquals(t, ok, true)
+}
+
+func TestCompactLedger(t *testing.T) {
+	InitializeTestEnv()
+	defer CleanupTestEnv()
+	l, _ := CreateLedger(constructTestLedgerID(0))
+	bg := testutil.NewBlockGenerator(t)
+	testutil.AssertNoError(t, l.Commit(bg.NextBlock([][]byte{}, false)), "")
+	testutil.AssertNoError(t, CompactLedger(constructTestLedgerID(0)), "")
+
+	err := CompactLedger(constructTestLedgerID(1))
+	_, ok := err.(*ledger.NotFoundError)
+	testutil.AssertEquals(t, ok, true)
+}
+
+func TestSnapshotSystemState(t *testing.T) {
+	viper.Set("ledger.state.systemStateDatabase.enabled", true)
+	defer viper.Set("ledger.state.systemStateDatabase.enabled", false)
+	InitializeTestEnv()
+	defer CleanupTestEnv()
+	l, _ := CreateLedger(constructTestLedgerID(0))
+	bg := testutil.NewBlockGenerator(t)
+	testutil.AssertNoError(t, l.Commit(bg.NextBlock([][]byte{}, false)), "")
+	dir, blockNum, err := SnapshotSystemState(constructTestLedgerID(0))
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, blockNum, uint64(0))
+	metadata, err := kvledger.LoadSystemStateSnapshotMetadata(dir)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, metadata.ChannelName, constructTestLedgerID(0))
+	// the snapshot of the same block is returned again
+	sameDir, _, err := SnapshotSystemState(constructTestLedgerID(0))
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, sameDir, dir)
+
+	_, _, err = SnapshotSystemState(constructTestLedgerID(1))
+	_, ok := err.(*ledger.NotFoundError)
+	testutil.AssertEquals(t, ok, true)
+}
+
+func TestCompactionSchedule(t *testing.T) {
+	viper.Set("ledger.compactionInterval", "10ms")
+	defer viper.Set("ledger.compactionInterval", "")
+	InitializeTestEnv()
+	l, _ := CreateLedger(constructTestLedgerID(0))
+	bg := testutil.NewBlockGenerator(t)
+	for i := 0; i < 5; i++ {
+		testutil.AssertNoError(t, l.Commit(bg.NextBlock([][]byte{}, false)), "")
+		time.Sleep(10 * time.Millisecond)
+	}
+	// closing the ledger mgmt stops the background compaction
+	CleanupTestEnv()
+	testutil.AssertEquals(t, compactionStop == nil, true)
+}
+
+func TestGetFingerprints(t *testing.T) {
+	InitializeTestEnv()
+	defer CleanupTestEnv()
+	l, _ := CreateLedger(constructTestLedgerID(0))
+	bg := testutil.NewBlockGenerator(t)
+	block := bg.NextBlock([][]byte{}, false)
+	testutil.AssertNoError(t, l.Commit(block), "")
+
+	blockFingerprint, err := GetBlockFingerprint(constructTestLedgerID(0), math.MaxUint64)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, blockFingerprint.BlockNumber, uint64(0))
+	testutil.AssertEquals(t, blockFingerprint.BlockHash, block.Header.Hash())
+	bcInfo, _ := l.GetBlockchainInfo()
+	testutil.AssertEquals(t, blockFingerprint.BlockHash, bcInfo.CurrentBlockHash)
+	stateFingerprint, err := GetStateFingerprint(constructTestLedgerID(0))
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, stateFingerprint.Height, uint64(1))
+
+	_, err = GetBlockFingerprint(constructTestLedgerID(1), 0)
+	_, ok := err.(*ledger.NotFoundError)
+	testutil.AssertEquals(t, ok, true)
+	_, err = GetStateFingerprint(constructTestLedgerID(1))
+	_, ok = err.(*ledger.NotFoundError)
+	testutil.AssertEquals(t, ok, true)
+}
+
+func TestQuiesceCommits(t *testing.T) {
+	InitializeTestEnv()
+	defer CleanupTestEnv()
+	viper.Set("ledger.state.historyDatabase", true)
+	defer viper.Set("ledger.state.historyDatabase", false)
+	l, _ := CreateLedger(constructTestLedgerID(0))
+	bg := testutil.NewBlockGenerator(t)
+	testutil.AssertNoError(t, l.Commit(bg.NextBlock([][]byte{}, false)), "")
+
+	heights, err := QuiesceCommits(time.Minute)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, AreCommitsQuiesced(), true)
+	testutil.AssertEquals(t, heights[constructTestLedgerID(0)], &ledger.StoreHeights{BlockStore: 1, StateDB: 1, HistoryDB: 1})
+	_, err = QuiesceCommits(time.Minute)
+	testutil.AssertEquals(t, err, ErrCommitsQuiesced)
+
+	// a commit during the quiescence waits for the release
+	committed := make(chan error, 1)
+	go func() {
+		committed <- l.Commit(bg.NextBlock([][]byte{}, false))
+	}()
+	select {
+	case <-committed:
+		t.Fatal("Block is committed while the commits are quiesced")
+	case <-time.After(100 * time.Millisecond):
+	}
+	bcInfo, _ := l.GetBlockchainInfo()
+	testutil.AssertEquals(t, bcInfo.Height, uint64(1))
+
+	testutil.AssertNoError(t, ReleaseCommits(), "")
+	testutil.AssertNoError(t, <-committed, "")
+	bcInfo, _ = l.GetBlockchainInfo()
+	testutil.AssertEquals(t, bcInfo.Height, uint64(2))
+	testutil.AssertEquals(t, ReleaseCommits(), ErrCommitsNotQuiesced)
+
+	// the commits are released after the timeout
+	_, err = QuiesceCommits(10 * time.Millisecond)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertNoError(t, l.Commit(bg.NextBlock([][]byte{}, false)), "")
+	testutil.AssertEquals(t, AreCommitsQuiesced(), false)
+}
+
 func constructTestLedgerID(i int) string {
 	return fmt.Sprintf("ledger_%06d", i)
 }
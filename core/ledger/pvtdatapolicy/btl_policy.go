@@ -0,0 +1,102 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pvtdatapolicy
+
+import (
+	"math"
+
+	"github.com/hyperledger/fabric/core/common/privdata"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/confighistory"
+	logging "github.com/op/go-logging"
+)
+
+var logger = logging.MustGetLogger("pvtdatapolicy")
+
+// NeverExpires is the expiring block of the private data of a collection that has a zero BlockToLive
+const NeverExpires = uint64(math.MaxUint64)
+
+// BTLPolicy BlockToLive policy for the pvt data
+type BTLPolicy interface {
+	// GetBTL returns BlockToLive for a given namespace and collection
+	GetBTL(ns string, coll string) (uint64, error)
+	// GetExpiringBlock returns the block number by which the pvtdata for given namespace,collection, and committingBlock should expire
+	GetExpiringBlock(namespace string, collection string, committingBlock uint64) (uint64, error)
+}
+
+// QueryExecutorFactory creates the query executors over the state that holds the collection configs
+type QueryExecutorFactory interface {
+	NewQueryExecutor() (ledger.QueryExecutor, error)
+}
+
+// LSCCBasedBTLPolicy implements interface BTLPolicy.
+// This implementation loads the BTL policy from the collection configs stored by lccc in the state.
+// A collection whose config is not found is treated as having a zero BlockToLive
+type LSCCBasedBTLPolicy struct {
+	qeFactory QueryExecutorFactory
+}
+
+// NewBTLPolicy constructs an instance of LSCCBasedBTLPolicy
+func NewBTLPolicy(qeFactory QueryExecutorFactory) BTLPolicy {
+	return &LSCCBasedBTLPolicy{qeFactory}
+}
+
+// GetBTL implements corresponding function in interface `BTLPolicy`
+func (p *LSCCBasedBTLPolicy) GetBTL(namespace string, collection string) (uint64, error) {
+	qe, err := p.qeFactory.NewQueryExecutor()
+	if err != nil {
+		return 0, err
+	}
+	defer qe.Done()
+	configBytes, err := qe.GetState(confighistory.LifecycleNamespace, privdata.BuildCollectionKVSKey(namespace))
+	if err != nil {
+		return 0, err
+	}
+	if configBytes == nil {
+		logger.Debugf("No collection config found for chaincode [%s], treating the private data as never expiring", namespace)
+		return 0, nil
+	}
+	pkg, err := privdata.UnmarshalCollectionConfigPackage(configBytes)
+	if err != nil {
+		return 0, err
+	}
+	config := pkg.GetConfig(collection)
+	if config == nil {
+		logger.Debugf("Collection [%s:%s] is not defined, treating the private data as never expiring", namespace, collection)
+		return 0, nil
+	}
+	return config.BlockToLive, nil
+}
+
+// GetExpiringBlock implements function from the interface `BTLPolicy`
+func (p *LSCCBasedBTLPolicy) GetExpiringBlock(namespace string, collection string, committingBlock uint64) (uint64, error) {
+	btl, err := p.GetBTL(namespace, collection)
+	if err != nil {
+		return 0, err
+	}
+	return ComputeExpiringBlock(btl, committingBlock), nil
+}
+
+// ComputeExpiringBlock returns the number of the block at the commit of which the private data that is
+// committed in the given block is purged. The private data remains available as long as the ledger
+// height does not exceed committingBlock+btl+1
+func ComputeExpiringBlock(btl uint64, committingBlock uint64) uint64 {
+	if btl == 0 || btl >= NeverExpires-committingBlock-1 {
+		return NeverExpires
+	}
+	return committingBlock + btl + 1
+}
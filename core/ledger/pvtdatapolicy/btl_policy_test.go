@@ -0,0 +1,84 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pvtdatapolicy
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/core/common/privdata"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBTLPolicy(t *testing.T) {
+	pkg := &privdata.CollectionConfigPackage{Configs: []*privdata.CollectionConfig{
+		{Name: "coll1", BlockToLive: 100},
+		{Name: "coll2"},
+	}}
+	pkgBytes, err := pkg.Marshal()
+	assert.NoError(t, err)
+	btlPolicy := NewBTLPolicy(&mockQEFactory{map[string][]byte{privdata.BuildCollectionKVSKey("ns1"): pkgBytes}})
+
+	btl, err := btlPolicy.GetBTL("ns1", "coll1")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(100), btl)
+
+	expiringBlk, err := btlPolicy.GetExpiringBlock("ns1", "coll1", 50)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(151), expiringBlk)
+
+	for _, nsColl := range [][]string{{"ns1", "coll2"}, {"ns1", "coll3"}, {"ns2", "coll1"}} {
+		expiringBlk, err = btlPolicy.GetExpiringBlock(nsColl[0], nsColl[1], 50)
+		assert.NoError(t, err)
+		assert.Equal(t, NeverExpires, expiringBlk)
+	}
+}
+
+func TestBTLPolicyInvalidConfig(t *testing.T) {
+	btlPolicy := NewBTLPolicy(&mockQEFactory{map[string][]byte{privdata.BuildCollectionKVSKey("ns1"): []byte("invalid")}})
+	_, err := btlPolicy.GetBTL("ns1", "coll1")
+	assert.Error(t, err)
+}
+
+func TestComputeExpiringBlock(t *testing.T) {
+	assert.Equal(t, uint64(12), ComputeExpiringBlock(1, 10))
+	assert.Equal(t, NeverExpires, ComputeExpiringBlock(0, 10))
+	assert.Equal(t, NeverExpires, ComputeExpiringBlock(NeverExpires-5, 10))
+}
+
+type mockQEFactory struct {
+	lcccState map[string][]byte
+}
+
+func (f *mockQEFactory) NewQueryExecutor() (ledger.QueryExecutor, error) {
+	return &mockQE{lcccState: f.lcccState}, nil
+}
+
+type mockQE struct {
+	ledger.QueryExecutor
+	lcccState map[string][]byte
+}
+
+func (qe *mockQE) GetState(namespace string, key string) ([]byte, error) {
+	if namespace != "lccc" {
+		return nil, nil
+	}
+	return qe.lcccState[key], nil
+}
+
+func (qe *mockQE) Done() {
+}
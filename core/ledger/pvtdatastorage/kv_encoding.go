@@ -0,0 +1,133 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pvtdatastorage
+
+import (
+	"bytes"
+	"math"
+
+	"github.com/hyperledger/fabric/common/ledger/util"
+)
+
+var (
+	pendingCommitKey     = []byte{0}
+	lastCommittedBlkKey  = []byte{1}
+	pvtDataKeyPrefix     = []byte{2}
+	expiryKeyPrefix      = []byte{3}
+	missingDataKeyPrefix = []byte{4}
+	nsCollSep            = []byte{0x00}
+	emptyValue           = []byte{}
+	// missingDataMarker is the value of the expiry entry of a missing private write set
+	missingDataMarker = []byte{1}
+)
+
+// pvtDataKey identifies a private write set by the block, the transaction, the namespace, and the collection
+type pvtDataKey struct {
+	blockNum   uint64
+	txNum      uint64
+	ns         string
+	collection string
+}
+
+func encodePK(key *pvtDataKey) []byte {
+	return appendTxNsColl(constructBlockPrefix(key.blockNum), key)
+}
+
+func decodePK(encKey []byte) *pvtDataKey {
+	blockNum, blockNumBytes := util.DecodeOrderPreservingVarUint64(encKey[len(pvtDataKeyPrefix):])
+	return decodeTxNsColl(blockNum, encKey[len(pvtDataKeyPrefix)+blockNumBytes:])
+}
+
+// encodeMissingDataKey encodes the key that records a missing private write set. The block number is
+// encoded in the descending order so that the missing data of the most recent blocks is found first
+func encodeMissingDataKey(key *pvtDataKey) []byte {
+	return appendTxNsColl(constructMissingDataPrefix(key.blockNum), key)
+}
+
+func decodeMissingDataKey(encKey []byte) *pvtDataKey {
+	invertedBlockNum, blockNumBytes := util.DecodeOrderPreservingVarUint64(encKey[len(missingDataKeyPrefix):])
+	return decodeTxNsColl(math.MaxUint64-invertedBlockNum, encKey[len(missingDataKeyPrefix)+blockNumBytes:])
+}
+
+func appendTxNsColl(encKey []byte, key *pvtDataKey) []byte {
+	encKey = append(encKey, util.EncodeOrderPreservingVarUint64(key.txNum)...)
+	encKey = append(encKey, []byte(key.ns)...)
+	encKey = append(encKey, nsCollSep...)
+	return append(encKey, []byte(key.collection)...)
+}
+
+func decodeTxNsColl(blockNum uint64, encTxNsColl []byte) *pvtDataKey {
+	txNum, txNumBytes := util.DecodeOrderPreservingVarUint64(encTxNsColl)
+	nsColl := bytes.SplitN(encTxNsColl[txNumBytes:], nsCollSep, 2)
+	return &pvtDataKey{blockNum, txNum, string(nsColl[0]), string(nsColl[1])}
+}
+
+type nsColl struct {
+	ns         string
+	collection string
+}
+
+// expiryKey identifies a private write set that is to be purged at the commit of the block expiringBlk.
+// The expiry keys are ordered by the expiring block so that the expired write sets are found by a range scan
+type expiryKey struct {
+	expiringBlk uint64
+	dataKey     *pvtDataKey
+}
+
+func encodeExpiryKey(key *expiryKey) []byte {
+	return append(constructExpiryPrefix(key.expiringBlk), encodePK(key.dataKey)[len(pvtDataKeyPrefix):]...)
+}
+
+func decodeExpiryKey(encKey []byte) *expiryKey {
+	expiringBlk, expiringBlkBytes := util.DecodeOrderPreservingVarUint64(encKey[len(expiryKeyPrefix):])
+	encDataKey := append(append([]byte{}, pvtDataKeyPrefix...), encKey[len(expiryKeyPrefix)+expiringBlkBytes:]...)
+	return &expiryKey{expiringBlk, decodePK(encDataKey)}
+}
+
+// constructExpiryPrefix returns the prefix that is shared by the expiry keys of the write sets that expire at the given block
+func constructExpiryPrefix(expiringBlk uint64) []byte {
+	return append(append([]byte{}, expiryKeyPrefix...), util.EncodeOrderPreservingVarUint64(expiringBlk)...)
+}
+
+// constructMissingDataPrefix returns the prefix that is shared by the keys of all the missing private write sets of a block
+func constructMissingDataPrefix(blockNum uint64) []byte {
+	return append(append([]byte{}, missingDataKeyPrefix...), util.EncodeOrderPreservingVarUint64(math.MaxUint64-blockNum)...)
+}
+
+// constructMissingDataRangeEnd returns the key that follows the keys of the missing private write sets of
+// the given block. As the keys are in the descending order of the block number, the range
+// [missingDataKeyPrefix, constructMissingDataRangeEnd(blockNum)) covers the blocks at or above the given block
+func constructMissingDataRangeEnd(blockNum uint64) []byte {
+	if blockNum == 0 {
+		return []byte{missingDataKeyPrefix[0] + 1}
+	}
+	return constructMissingDataPrefix(blockNum - 1)
+}
+
+// constructBlockPrefix returns the prefix that is shared by the keys of all the private write sets of a block
+func constructBlockPrefix(blockNum uint64) []byte {
+	return append(append([]byte{}, pvtDataKeyPrefix...), util.EncodeOrderPreservingVarUint64(blockNum)...)
+}
+
+func encodeBlockNum(blockNum uint64) []byte {
+	return util.EncodeOrderPreservingVarUint64(blockNum)
+}
+
+func decodeBlockNum(blockNumBytes []byte) uint64 {
+	blockNum, _ := util.DecodeOrderPreservingVarUint64(blockNumBytes)
+	return blockNum
+}
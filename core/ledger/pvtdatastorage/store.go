@@ -0,0 +1,99 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pvtdatastorage
+
+import (
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/pvtdatapolicy"
+)
+
+var (
+	// ErrIllegalCall is returned by a call that is not allowed in the current state of the store,
+	// for instance, a Prepare call while a batch is already pending or a Commit call without a pending batch
+	ErrIllegalCall = &ledger.ConflictError{Msg: "Call is not allowed in the current state of the store"}
+	// ErrIllegalArgs is returned by a Prepare call if the block number is not next to the last committed block
+	ErrIllegalArgs = &ledger.ConflictError{Msg: "Block number is not next to the last committed block"}
+	// ErrOutOfRange is returned by a query for a block that is not committed to the store
+	ErrOutOfRange = &ledger.NotFoundError{Msg: "Block is not committed to the private data store"}
+)
+
+// Provider provides handles to the private data stores of the ledgers
+type Provider interface {
+	// OpenStore returns a handle to the private data store of the given ledger
+	OpenStore(ledgerID string) (Store, error)
+	// Drop removes all the private data of the given ledger
+	Drop(ledgerID string) error
+	// Close closes the provider
+	Close()
+}
+
+// Store maintains the private write sets of the transactions, keyed by the block number,
+// the position of the transaction in the block, the namespace, and the collection.
+// The private data of a block is committed in two phases, so that it can be committed atomically with the block.
+// The private data is first staged by a Prepare call, before the block is added to the block storage,
+// and is then made visible by a Commit call, after the block is added. A Rollback call discards
+// the staged private data if the block could not be added. If the peer crashes in between,
+// the pending batch is committed or rolled back when the ledger is opened next time, depending on
+// whether the block made it to the block storage.
+// The private data of a collection is purged once its BlockToLive expires. The expiry of the private data
+// is recorded when the private data is prepared, and the expired private data is purged at the commit of
+// every block whose number is a multiple of the purge interval ('ledger.pvtdataStore.purgeInterval')
+type Store interface {
+	// Init sets the BlockToLive policy that is used for computing the expiry of the private data.
+	// Init must be invoked before a Prepare or GetPvtDataByBlockNum call
+	Init(btlPolicy pvtdatapolicy.BTLPolicy)
+	// GetPvtDataByBlockNum returns the private write sets of the transactions in the given block.
+	// The write sets are filtered by the given filter, a nil filter returns all the write sets.
+	// The expired write sets are not returned, even if they are not purged yet
+	GetPvtDataByBlockNum(blockNum uint64, filter ledger.PvtNsCollFilter) ([]*ledger.TxPvtData, error)
+	// Prepare stages the private data of the given block. The block number must be next to the last committed block.
+	// The private write sets that are missing are recorded so that they can be reconciled later
+	Prepare(blockNum uint64, pvtData []*ledger.TxPvtData, missingPvtData []*ledger.MissingPvtData) error
+	// Commit makes the pending private data visible
+	Commit() error
+	// Rollback discards the pending private data
+	Rollback() error
+	// GetMissingPvtDataInfoForMostRecentBlocks returns the missing private write sets of the most recent blocks,
+	// up to maxBlocks number of blocks that have missing private data. The expired write sets are not returned
+	GetMissingPvtDataInfoForMostRecentBlocks(maxBlocks int) (ledger.MissingPvtDataInfo, error)
+	// CommitPvtDataOfOldBlocks commits the reconciled private write sets of the committed blocks.
+	// Only the write sets that are recorded as missing, and have not expired, are committed
+	CommitPvtDataOfOldBlocks(blocksPvtData []*ledger.BlockPvtData) error
+	// Truncate removes the private data of the blocks at or above the given height. This is used
+	// for rolling back a ledger and must not be invoked while a batch is pending
+	Truncate(height uint64) error
+	// IsEmpty returns true if no block is committed to the store yet
+	IsEmpty() (bool, error)
+	// LastCommittedBlock returns the number of the last block committed to the store
+	LastCommittedBlock() (uint64, error)
+	// HasPendingBatch returns the number of the block whose private data is pending, if any
+	HasPendingBatch() (bool, uint64, error)
+	// GetPurgeStats returns the statistics of the purging of the expired private data since the store was opened
+	GetPurgeStats() PurgeStats
+	// Shutdown closes the store
+	Shutdown()
+}
+
+// PurgeStats contains the statistics of the purging of the expired private data
+type PurgeStats struct {
+	// NumPurges is the number of purges that removed at least one write set
+	NumPurges uint64
+	// NumPurgedWriteSets is the total number of the write sets purged
+	NumPurgedWriteSets uint64
+	// LastPurgeBlock is the number of the block at the commit of which the last such purge was performed
+	LastPurgeBlock uint64
+}
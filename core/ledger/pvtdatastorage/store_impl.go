@@ -0,0 +1,478 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pvtdatastorage
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
+	"github.com/hyperledger/fabric/common/metrics"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+	"github.com/hyperledger/fabric/core/ledger/pvtdatapolicy"
+	logging "github.com/op/go-logging"
+)
+
+var logger = logging.MustGetLogger("pvtdatastorage")
+
+var (
+	purgeCount = metrics.NewCounterVec("ledger_pvtdata_purges_total",
+		"Number of the purges of the expired private data that deleted at least one private write set.", "channel")
+	purgedWriteSets = metrics.NewCounterVec("ledger_pvtdata_purged_write_sets_total",
+		"Number of the expired private write sets deleted by the purges.", "channel")
+	lastPurgeBlock = metrics.NewGaugeVec("ledger_pvtdata_last_purge_block",
+		"Number of the block at which the expired private data was last purged.", "channel")
+)
+
+type provider struct {
+	dbProvider *leveldbhelper.Provider
+}
+
+type store struct {
+	db            *leveldbhelper.DBHandle
+	ledgerID      string
+	btlPolicy     pvtdatapolicy.BTLPolicy
+	purgeInterval uint64
+
+	isEmpty            bool
+	lastCommittedBlock uint64
+	batchPending       bool
+	pendingBlock       uint64
+
+	// purgerLock serializes the purging of the expired private data with the commit of the reconciled private data
+	purgerLock     sync.Mutex
+	purgeStatsLock sync.Mutex
+	purgeStats     PurgeStats
+}
+
+// NewProvider instantiates a Provider
+func NewProvider() Provider {
+	dbPath := ledgerconfig.GetPvtDataStorePath()
+	logger.Debugf("constructing private data store provider dbPath=%s", dbPath)
+	return &provider{leveldbhelper.NewProvider(&leveldbhelper.Conf{DBPath: dbPath})}
+}
+
+// NewReadOnlyProvider instantiates a Provider that opens the existing stores in read-only mode
+func NewReadOnlyProvider() Provider {
+	dbPath := ledgerconfig.GetPvtDataStorePath()
+	logger.Debugf("constructing read-only private data store provider dbPath=%s", dbPath)
+	return &provider{leveldbhelper.NewProvider(&leveldbhelper.Conf{DBPath: dbPath, ReadOnly: true})}
+}
+
+// OpenStore implements the corresponding method in the interface Provider
+func (p *provider) OpenStore(ledgerID string) (Store, error) {
+	s := &store{db: p.dbProvider.GetDBHandle(ledgerID), ledgerID: ledgerID,
+		purgeInterval: ledgerconfig.GetPvtdataStorePurgeInterval()}
+	if err := s.initState(); err != nil {
+		return nil, err
+	}
+	logger.Debugf("Channel [%s]: Opened private data store. isEmpty=%t, lastCommittedBlock=%d, batchPending=%t",
+		ledgerID, s.isEmpty, s.lastCommittedBlock, s.batchPending)
+	return s, nil
+}
+
+// Drop implements the corresponding method in the interface Provider
+func (p *provider) Drop(ledgerID string) error {
+	return p.dbProvider.GetDBHandle(ledgerID).DeleteAll()
+}
+
+// Close implements the corresponding method in the interface Provider
+func (p *provider) Close() {
+	p.dbProvider.Close()
+}
+
+// Init implements the corresponding method in the interface Store
+func (s *store) Init(btlPolicy pvtdatapolicy.BTLPolicy) {
+	s.btlPolicy = btlPolicy
+}
+
+func (s *store) initState() error {
+	lastCommittedBlkBytes, err := s.db.Get(lastCommittedBlkKey)
+	if err != nil {
+		return err
+	}
+	s.isEmpty = lastCommittedBlkBytes == nil
+	if !s.isEmpty {
+		s.lastCommittedBlock = decodeBlockNum(lastCommittedBlkBytes)
+	}
+	pendingBlockBytes, err := s.db.Get(pendingCommitKey)
+	if err != nil {
+		return err
+	}
+	s.batchPending = pendingBlockBytes != nil
+	if s.batchPending {
+		s.pendingBlock = decodeBlockNum(pendingBlockBytes)
+	}
+	return nil
+}
+
+// GetPvtDataByBlockNum implements the corresponding method in the interface Store
+func (s *store) GetPvtDataByBlockNum(blockNum uint64, filter ledger.PvtNsCollFilter) ([]*ledger.TxPvtData, error) {
+	if s.isEmpty || blockNum > s.lastCommittedBlock {
+		return nil, ErrOutOfRange
+	}
+	itr := s.db.GetIterator(constructBlockPrefix(blockNum), constructBlockPrefix(blockNum+1))
+	defer itr.Release()
+
+	var pvtData []*ledger.TxPvtData
+	var currentTxPvtData *ledger.TxPvtData
+	expiringBlks := make(map[nsColl]uint64)
+	for itr.Next() {
+		key := decodePK(itr.Key())
+		if filter != nil && !filter.Has(key.ns, key.collection) {
+			continue
+		}
+		expiringBlk, ok := expiringBlks[nsColl{key.ns, key.collection}]
+		if !ok {
+			var err error
+			if expiringBlk, err = s.btlPolicy.GetExpiringBlock(key.ns, key.collection, blockNum); err != nil {
+				return nil, err
+			}
+			expiringBlks[nsColl{key.ns, key.collection}] = expiringBlk
+		}
+		if expiringBlk <= s.lastCommittedBlock {
+			continue
+		}
+		if currentTxPvtData == nil || currentTxPvtData.SeqInBlock != key.txNum {
+			currentTxPvtData = &ledger.TxPvtData{SeqInBlock: key.txNum}
+			pvtData = append(pvtData, currentTxPvtData)
+		}
+		value := itr.Value()
+		writeSet := make([]byte, len(value))
+		copy(writeSet, value)
+		currentTxPvtData.WriteSets = append(currentTxPvtData.WriteSets,
+			&ledger.CollPvtWriteSet{Namespace: key.ns, CollectionName: key.collection, WriteSet: writeSet})
+	}
+	if err := itr.Error(); err != nil {
+		return nil, err
+	}
+	return pvtData, nil
+}
+
+// Prepare implements the corresponding method in the interface Store
+func (s *store) Prepare(blockNum uint64, pvtData []*ledger.TxPvtData, missingPvtData []*ledger.MissingPvtData) error {
+	if s.batchPending {
+		return ErrIllegalCall
+	}
+	if !s.isEmpty && blockNum != s.lastCommittedBlock+1 {
+		return ErrIllegalArgs
+	}
+	batch := leveldbhelper.NewUpdateBatch()
+	for _, txPvtData := range pvtData {
+		for _, writeSet := range txPvtData.WriteSets {
+			value := writeSet.WriteSet
+			if value == nil {
+				value = emptyValue
+			}
+			key := &pvtDataKey{blockNum, txPvtData.SeqInBlock, writeSet.Namespace, writeSet.CollectionName}
+			batch.Put(encodePK(key), value)
+			expiringBlk, err := s.btlPolicy.GetExpiringBlock(key.ns, key.collection, blockNum)
+			if err != nil {
+				return err
+			}
+			if expiringBlk != pvtdatapolicy.NeverExpires {
+				batch.Put(encodeExpiryKey(&expiryKey{expiringBlk, key}), emptyValue)
+			}
+		}
+	}
+	for _, missing := range missingPvtData {
+		key := &pvtDataKey{blockNum, missing.SeqInBlock, missing.Namespace, missing.CollectionName}
+		batch.Put(encodeMissingDataKey(key), emptyValue)
+		expiringBlk, err := s.btlPolicy.GetExpiringBlock(key.ns, key.collection, blockNum)
+		if err != nil {
+			return err
+		}
+		if expiringBlk != pvtdatapolicy.NeverExpires {
+			batch.Put(encodeExpiryKey(&expiryKey{expiringBlk, key}), missingDataMarker)
+		}
+	}
+	batch.Put(pendingCommitKey, encodeBlockNum(blockNum))
+	if err := s.db.WriteBatch(batch, true); err != nil {
+		return err
+	}
+	s.batchPending = true
+	s.pendingBlock = blockNum
+	logger.Debugf("Channel [%s]: Prepared private data of [%d] transaction(s) in block [%d], [%d] write set(s) are missing",
+		s.ledgerID, len(pvtData), blockNum, len(missingPvtData))
+	return nil
+}
+
+// Commit implements the corresponding method in the interface Store
+func (s *store) Commit() error {
+	if !s.batchPending {
+		return ErrIllegalCall
+	}
+	s.purgerLock.Lock()
+	defer s.purgerLock.Unlock()
+	batch := leveldbhelper.NewUpdateBatch()
+	numPurged := 0
+	if s.pendingBlock%s.purgeInterval == 0 {
+		var err error
+		if numPurged, err = s.addPurgesToBatch(batch, s.pendingBlock); err != nil {
+			return err
+		}
+	}
+	batch.Delete(pendingCommitKey)
+	batch.Put(lastCommittedBlkKey, encodeBlockNum(s.pendingBlock))
+	if err := s.db.WriteBatch(batch, true); err != nil {
+		return err
+	}
+	s.batchPending = false
+	s.isEmpty = false
+	s.lastCommittedBlock = s.pendingBlock
+	logger.Debugf("Channel [%s]: Committed private data of block [%d]", s.ledgerID, s.lastCommittedBlock)
+	if numPurged > 0 {
+		s.updatePurgeStats(numPurged, s.lastCommittedBlock)
+		logger.Infof("Channel [%s]: Purged [%d] expired private write set(s) at block [%d]", s.ledgerID, numPurged, s.lastCommittedBlock)
+	}
+	return nil
+}
+
+// addPurgesToBatch deletes the private write sets, and the records of the missing private write sets, that expire
+// at or before the given block, along with their expiry entries, and returns the number of the write sets deleted
+func (s *store) addPurgesToBatch(batch *leveldbhelper.UpdateBatch, blockNum uint64) (int, error) {
+	itr := s.db.GetIterator(expiryKeyPrefix, constructExpiryPrefix(blockNum+1))
+	defer itr.Release()
+	numPurged := 0
+	for itr.Next() {
+		key := decodeExpiryKey(itr.Key())
+		batch.Delete(itr.Key())
+		if bytes.Equal(itr.Value(), missingDataMarker) {
+			batch.Delete(encodeMissingDataKey(key.dataKey))
+			continue
+		}
+		batch.Delete(encodePK(key.dataKey))
+		numPurged++
+	}
+	return numPurged, itr.Error()
+}
+
+func (s *store) updatePurgeStats(numPurged int, blockNum uint64) {
+	s.purgeStatsLock.Lock()
+	defer s.purgeStatsLock.Unlock()
+	s.purgeStats.NumPurges++
+	s.purgeStats.NumPurgedWriteSets += uint64(numPurged)
+	s.purgeStats.LastPurgeBlock = blockNum
+	purgeCount.Add(1, s.ledgerID)
+	purgedWriteSets.Add(float64(numPurged), s.ledgerID)
+	lastPurgeBlock.Set(float64(blockNum), s.ledgerID)
+}
+
+// GetPurgeStats implements the corresponding method in the interface Store
+func (s *store) GetPurgeStats() PurgeStats {
+	s.purgeStatsLock.Lock()
+	defer s.purgeStatsLock.Unlock()
+	return s.purgeStats
+}
+
+// Rollback implements the corresponding method in the interface Store
+func (s *store) Rollback() error {
+	if !s.batchPending {
+		return ErrIllegalCall
+	}
+	batch := leveldbhelper.NewUpdateBatch()
+	if err := s.addDeletesToBatch(batch, constructBlockPrefix(s.pendingBlock), constructBlockPrefix(s.pendingBlock+1)); err != nil {
+		return err
+	}
+	if err := s.addExpiryDeletesToBatch(batch, s.pendingBlock); err != nil {
+		return err
+	}
+	if err := s.addDeletesToBatch(batch, constructMissingDataPrefix(s.pendingBlock), constructMissingDataRangeEnd(s.pendingBlock)); err != nil {
+		return err
+	}
+	batch.Delete(pendingCommitKey)
+	if err := s.db.WriteBatch(batch, true); err != nil {
+		return err
+	}
+	s.batchPending = false
+	logger.Debugf("Channel [%s]: Rolled back private data of block [%d]", s.ledgerID, s.pendingBlock)
+	return nil
+}
+
+// GetMissingPvtDataInfoForMostRecentBlocks implements the corresponding method in the interface Store
+func (s *store) GetMissingPvtDataInfoForMostRecentBlocks(maxBlocks int) (ledger.MissingPvtDataInfo, error) {
+	if s.isEmpty || maxBlocks <= 0 {
+		return nil, nil
+	}
+	lastCommittedBlock := s.lastCommittedBlock
+	// the missing data keys are in the descending order of the block number
+	itr := s.db.GetIterator(constructMissingDataPrefix(lastCommittedBlock), []byte{missingDataKeyPrefix[0] + 1})
+	defer itr.Release()
+
+	var missingDataInfo ledger.MissingPvtDataInfo
+	expiringBlks := make(map[nsColl]uint64)
+	for itr.Next() {
+		key := decodeMissingDataKey(itr.Key())
+		nc := nsColl{key.ns, key.collection}
+		expiringBlk, ok := expiringBlks[nc]
+		if !ok {
+			var err error
+			if expiringBlk, err = s.btlPolicy.GetExpiringBlock(key.ns, key.collection, key.blockNum); err != nil {
+				return nil, err
+			}
+			expiringBlks[nc] = expiringBlk
+		}
+		if expiringBlk <= lastCommittedBlock {
+			continue
+		}
+		if missingDataInfo == nil {
+			missingDataInfo = make(ledger.MissingPvtDataInfo)
+		}
+		if _, ok := missingDataInfo[key.blockNum]; !ok && len(missingDataInfo) == maxBlocks {
+			break
+		}
+		missingDataInfo.Add(key.blockNum, &ledger.MissingPvtData{SeqInBlock: key.txNum, Namespace: key.ns, CollectionName: key.collection})
+	}
+	if err := itr.Error(); err != nil {
+		return nil, err
+	}
+	return missingDataInfo, nil
+}
+
+// CommitPvtDataOfOldBlocks implements the corresponding method in the interface Store
+func (s *store) CommitPvtDataOfOldBlocks(blocksPvtData []*ledger.BlockPvtData) error {
+	s.purgerLock.Lock()
+	defer s.purgerLock.Unlock()
+	batch := leveldbhelper.NewUpdateBatch()
+	numCommitted := 0
+	for _, blockPvtData := range blocksPvtData {
+		if s.isEmpty || blockPvtData.BlockNum > s.lastCommittedBlock {
+			logger.Debugf("Channel [%s]: Ignoring the private data of block [%d] as the block is not committed", s.ledgerID, blockPvtData.BlockNum)
+			continue
+		}
+		for _, txPvtData := range blockPvtData.PvtData {
+			for _, writeSet := range txPvtData.WriteSets {
+				key := &pvtDataKey{blockPvtData.BlockNum, txPvtData.SeqInBlock, writeSet.Namespace, writeSet.CollectionName}
+				committed, err := s.addReconciledWriteSetToBatch(batch, key, writeSet.WriteSet)
+				if err != nil {
+					return err
+				}
+				if committed {
+					numCommitted++
+				}
+			}
+		}
+	}
+	if err := s.db.WriteBatch(batch, true); err != nil {
+		return err
+	}
+	logger.Infof("Channel [%s]: Committed [%d] reconciled private write set(s)", s.ledgerID, numCommitted)
+	return nil
+}
+
+// addReconciledWriteSetToBatch adds the given write set to the batch in place of the record of the missing write set.
+// A write set that is not recorded as missing, or has expired, is not added
+func (s *store) addReconciledWriteSetToBatch(batch *leveldbhelper.UpdateBatch, key *pvtDataKey, writeSet []byte) (bool, error) {
+	missingDataKey := encodeMissingDataKey(key)
+	missing, err := s.db.Get(missingDataKey)
+	if err != nil || missing == nil {
+		return false, err
+	}
+	expiringBlk, err := s.btlPolicy.GetExpiringBlock(key.ns, key.collection, key.blockNum)
+	if err != nil {
+		return false, err
+	}
+	if expiringBlk <= s.lastCommittedBlock {
+		return false, nil
+	}
+	if writeSet == nil {
+		writeSet = emptyValue
+	}
+	batch.Put(encodePK(key), writeSet)
+	batch.Delete(missingDataKey)
+	if expiringBlk != pvtdatapolicy.NeverExpires {
+		batch.Put(encodeExpiryKey(&expiryKey{expiringBlk, key}), emptyValue)
+	}
+	return true, nil
+}
+
+// Truncate implements the corresponding method in the interface Store
+func (s *store) Truncate(height uint64) error {
+	if s.batchPending {
+		return ErrIllegalCall
+	}
+	if s.isEmpty || height > s.lastCommittedBlock {
+		return nil
+	}
+	batch := leveldbhelper.NewUpdateBatch()
+	if err := s.addDeletesToBatch(batch, constructBlockPrefix(height), []byte{pvtDataKeyPrefix[0] + 1}); err != nil {
+		return err
+	}
+	if err := s.addExpiryDeletesToBatch(batch, height); err != nil {
+		return err
+	}
+	if err := s.addDeletesToBatch(batch, missingDataKeyPrefix, constructMissingDataRangeEnd(height)); err != nil {
+		return err
+	}
+	if height == 0 {
+		batch.Delete(lastCommittedBlkKey)
+	} else {
+		batch.Put(lastCommittedBlkKey, encodeBlockNum(height-1))
+	}
+	if err := s.db.WriteBatch(batch, true); err != nil {
+		return err
+	}
+	s.isEmpty = height == 0
+	if !s.isEmpty {
+		s.lastCommittedBlock = height - 1
+	}
+	logger.Debugf("Channel [%s]: Truncated private data store to height [%d]", s.ledgerID, height)
+	return nil
+}
+
+func (s *store) addDeletesToBatch(batch *leveldbhelper.UpdateBatch, startKey []byte, endKey []byte) error {
+	itr := s.db.GetIterator(startKey, endKey)
+	defer itr.Release()
+	for itr.Next() {
+		batch.Delete(itr.Key())
+	}
+	return itr.Error()
+}
+
+// addExpiryDeletesToBatch deletes the expiry entries of the private write sets of the blocks at or above the given height.
+// The expiry entries are ordered by the expiring block and hence all of them are scanned
+func (s *store) addExpiryDeletesToBatch(batch *leveldbhelper.UpdateBatch, height uint64) error {
+	itr := s.db.GetIterator(expiryKeyPrefix, []byte{expiryKeyPrefix[0] + 1})
+	defer itr.Release()
+	for itr.Next() {
+		if decodeExpiryKey(itr.Key()).dataKey.blockNum >= height {
+			batch.Delete(itr.Key())
+		}
+	}
+	return itr.Error()
+}
+
+// IsEmpty implements the corresponding method in the interface Store
+func (s *store) IsEmpty() (bool, error) {
+	return s.isEmpty, nil
+}
+
+// LastCommittedBlock implements the corresponding method in the interface Store
+func (s *store) LastCommittedBlock() (uint64, error) {
+	return s.lastCommittedBlock, nil
+}
+
+// HasPendingBatch implements the corresponding method in the interface Store
+func (s *store) HasPendingBatch() (bool, uint64, error) {
+	return s.batchPending, s.pendingBlock, nil
+}
+
+// Shutdown implements the corresponding method in the interface Store
+func (s *store) Shutdown() {
+	// do nothing because shared db is used
+}
@@ -0,0 +1,403 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pvtdatastorage
+
+import (
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/common/metrics"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+	"github.com/hyperledger/fabric/core/ledger/pvtdatapolicy"
+	"github.com/spf13/viper"
+)
+
+func TestMain(m *testing.M) {
+	viper.Set("peer.fileSystemPath", "/tmp/fabric/ledgertests/pvtdatastorage")
+	os.Exit(m.Run())
+}
+
+type testEnv struct {
+	t         testing.TB
+	provider  Provider
+	store     Store
+	btlPolicy testBTLPolicy
+}
+
+func newTestEnv(t testing.TB) *testEnv {
+	removeStorePath(t)
+	provider := NewProvider()
+	store, err := provider.OpenStore("testLedger")
+	testutil.AssertNoError(t, err, "")
+	btlPolicy := make(testBTLPolicy)
+	store.Init(btlPolicy)
+	return &testEnv{t, provider, store, btlPolicy}
+}
+
+func (env *testEnv) reopen() {
+	env.store.Shutdown()
+	env.provider.Close()
+	env.provider = NewProvider()
+	store, err := env.provider.OpenStore("testLedger")
+	testutil.AssertNoError(env.t, err, "")
+	store.Init(env.btlPolicy)
+	env.store = store
+}
+
+func (env *testEnv) cleanup() {
+	env.store.Shutdown()
+	env.provider.Close()
+	removeStorePath(env.t)
+}
+
+func removeStorePath(t testing.TB) {
+	if err := os.RemoveAll(ledgerconfig.GetPvtDataStorePath()); err != nil {
+		t.Fatalf("Err: %s", err)
+	}
+}
+
+func TestKVEncoding(t *testing.T) {
+	key := &pvtDataKey{blockNum: 10, txNum: 300, ns: "ns1", collection: "coll1"}
+	testutil.AssertEquals(t, decodePK(encodePK(key)), key)
+	testutil.AssertEquals(t, decodeBlockNum(encodeBlockNum(1000)), uint64(1000))
+	testutil.AssertEquals(t, decodeMissingDataKey(encodeMissingDataKey(key)), key)
+	expKey := &expiryKey{expiringBlk: 20, dataKey: key}
+	testutil.AssertEquals(t, decodeExpiryKey(encodeExpiryKey(expKey)), expKey)
+}
+
+func TestStoreCommitAndRetrieve(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	store := env.store
+
+	isEmpty, _ := store.IsEmpty()
+	testutil.AssertEquals(t, isEmpty, true)
+	_, err := store.GetPvtDataByBlockNum(0, nil)
+	testutil.AssertEquals(t, err, ErrOutOfRange)
+
+	testData := samplePvtData()
+	testutil.AssertNoError(t, store.Prepare(0, testData, nil), "")
+	// the prepared data is not visible till committed
+	_, err = store.GetPvtDataByBlockNum(0, nil)
+	testutil.AssertEquals(t, err, ErrOutOfRange)
+	testutil.AssertEquals(t, store.Prepare(0, testData, nil), ErrIllegalCall)
+	testutil.AssertNoError(t, store.Commit(), "")
+	testutil.AssertEquals(t, store.Commit(), ErrIllegalCall)
+
+	pvtData, err := store.GetPvtDataByBlockNum(0, nil)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, pvtData, testData)
+
+	filter := ledger.NewPvtNsCollFilter()
+	filter.Add("ns1", "coll2")
+	pvtData, err = store.GetPvtDataByBlockNum(0, filter)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, pvtData, []*ledger.TxPvtData{
+		{SeqInBlock: 2, WriteSets: []*ledger.CollPvtWriteSet{{Namespace: "ns1", CollectionName: "coll2", WriteSet: []byte("ws-2-ns1-coll2")}}},
+	})
+
+	// a block without private data is also recorded
+	testutil.AssertEquals(t, store.Prepare(2, nil, nil), ErrIllegalArgs)
+	testutil.AssertNoError(t, store.Prepare(1, nil, nil), "")
+	testutil.AssertNoError(t, store.Commit(), "")
+	pvtData, err = store.GetPvtDataByBlockNum(1, nil)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertNil(t, pvtData)
+	lastCommittedBlock, _ := store.LastCommittedBlock()
+	testutil.AssertEquals(t, lastCommittedBlock, uint64(1))
+}
+
+func TestStoreRollback(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	store := env.store
+
+	testutil.AssertEquals(t, store.Rollback(), ErrIllegalCall)
+	testutil.AssertNoError(t, store.Prepare(0, samplePvtData(), nil), "")
+	testutil.AssertNoError(t, store.Rollback(), "")
+	pending, _, _ := store.HasPendingBatch()
+	testutil.AssertEquals(t, pending, false)
+	isEmpty, _ := store.IsEmpty()
+	testutil.AssertEquals(t, isEmpty, true)
+
+	// the same block can be prepared again after a rollback
+	testutil.AssertNoError(t, store.Prepare(0, nil, nil), "")
+	testutil.AssertNoError(t, store.Commit(), "")
+	pvtData, err := store.GetPvtDataByBlockNum(0, nil)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertNil(t, pvtData)
+}
+
+func TestStoreStateRestoredOnReopen(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	testutil.AssertNoError(t, env.store.Prepare(0, samplePvtData(), nil), "")
+	testutil.AssertNoError(t, env.store.Commit(), "")
+	testutil.AssertNoError(t, env.store.Prepare(1, samplePvtData(), nil), "")
+	env.reopen()
+
+	pending, pendingBlock, _ := env.store.HasPendingBatch()
+	testutil.AssertEquals(t, pending, true)
+	testutil.AssertEquals(t, pendingBlock, uint64(1))
+	lastCommittedBlock, _ := env.store.LastCommittedBlock()
+	testutil.AssertEquals(t, lastCommittedBlock, uint64(0))
+	testutil.AssertNoError(t, env.store.Commit(), "")
+	pvtData, err := env.store.GetPvtDataByBlockNum(1, nil)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, pvtData, samplePvtData())
+}
+
+func TestStoreTruncate(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	store := env.store
+
+	for i := uint64(0); i < 5; i++ {
+		testutil.AssertNoError(t, store.Prepare(i, samplePvtData(), nil), "")
+		testutil.AssertNoError(t, store.Commit(), "")
+	}
+	testutil.AssertNoError(t, store.Truncate(3), "")
+	lastCommittedBlock, _ := store.LastCommittedBlock()
+	testutil.AssertEquals(t, lastCommittedBlock, uint64(2))
+	_, err := store.GetPvtDataByBlockNum(3, nil)
+	testutil.AssertEquals(t, err, ErrOutOfRange)
+	pvtData, _ := store.GetPvtDataByBlockNum(2, nil)
+	testutil.AssertEquals(t, pvtData, samplePvtData())
+
+	// the truncated blocks can be committed again
+	testutil.AssertNoError(t, store.Prepare(3, nil, nil), "")
+	testutil.AssertNoError(t, store.Commit(), "")
+	pvtData, _ = store.GetPvtDataByBlockNum(3, nil)
+	testutil.AssertNil(t, pvtData)
+
+	testutil.AssertNoError(t, store.Truncate(0), "")
+	isEmpty, _ := store.IsEmpty()
+	testutil.AssertEquals(t, isEmpty, true)
+}
+
+func TestStorePurge(t *testing.T) {
+	viper.Set("ledger.pvtdataStore.purgeInterval", 2)
+	defer viper.Set("ledger.pvtdataStore.purgeInterval", 0)
+	env := newTestEnv(t)
+	defer env.cleanup()
+	env.btlPolicy[nsColl{"ns1", "coll1"}] = 1
+	env.btlPolicy[nsColl{"ns1", "coll2"}] = 2
+	store := env.store
+	purgedWriteSetsSample := `ledger_pvtdata_purged_write_sets_total{channel="testLedger"}`
+	initialPurgedWriteSets := metricValue(purgedWriteSetsSample)
+
+	testutil.AssertNoError(t, store.Prepare(0, samplePvtData(), nil), "")
+	testutil.AssertNoError(t, store.Commit(), "")
+	testutil.AssertNoError(t, store.Prepare(1, nil, nil), "")
+	testutil.AssertNoError(t, store.Commit(), "")
+	// the private data is available till the ledger height is committingBlock+btl+1
+	pvtData, _ := store.GetPvtDataByBlockNum(0, nil)
+	testutil.AssertEquals(t, pvtData, samplePvtData())
+
+	// ns1:coll1 expires at block 2, which is also a purge block
+	testutil.AssertNoError(t, store.Prepare(2, nil, nil), "")
+	testutil.AssertNoError(t, store.Commit(), "")
+	pvtData, _ = store.GetPvtDataByBlockNum(0, nil)
+	testutil.AssertEquals(t, pvtData, []*ledger.TxPvtData{
+		{SeqInBlock: 0, WriteSets: []*ledger.CollPvtWriteSet{{Namespace: "ns2", CollectionName: "coll1", WriteSet: []byte("ws-0-ns2-coll1")}}},
+		{SeqInBlock: 2, WriteSets: []*ledger.CollPvtWriteSet{{Namespace: "ns1", CollectionName: "coll2", WriteSet: []byte("ws-2-ns1-coll2")}}},
+	})
+	testutil.AssertEquals(t, store.GetPurgeStats(), PurgeStats{NumPurges: 1, NumPurgedWriteSets: 1, LastPurgeBlock: 2})
+
+	// ns1:coll2 expires at block 3 but is purged only at block 4. The expired data is not returned in the meantime
+	testutil.AssertNoError(t, store.Prepare(3, nil, nil), "")
+	testutil.AssertNoError(t, store.Commit(), "")
+	testutil.AssertEquals(t, store.GetPurgeStats().NumPurges, uint64(1))
+	expectedPvtData := []*ledger.TxPvtData{
+		{SeqInBlock: 0, WriteSets: []*ledger.CollPvtWriteSet{{Namespace: "ns2", CollectionName: "coll1", WriteSet: []byte("ws-0-ns2-coll1")}}},
+	}
+	pvtData, _ = store.GetPvtDataByBlockNum(0, nil)
+	testutil.AssertEquals(t, pvtData, expectedPvtData)
+	testutil.AssertNoError(t, store.Prepare(4, nil, nil), "")
+	testutil.AssertNoError(t, store.Commit(), "")
+	testutil.AssertEquals(t, store.GetPurgeStats(), PurgeStats{NumPurges: 2, NumPurgedWriteSets: 2, LastPurgeBlock: 4})
+	pvtData, _ = store.GetPvtDataByBlockNum(0, nil)
+	testutil.AssertEquals(t, pvtData, expectedPvtData)
+	// the purges are exported as metrics
+	testutil.AssertEquals(t, metricValue(purgedWriteSetsSample)-initialPurgedWriteSets, float64(2))
+	testutil.AssertEquals(t, metricValue(`ledger_pvtdata_last_purge_block{channel="testLedger"}`), float64(4))
+}
+
+// metricValue returns the value of the given sample of the metrics registered with the default registry, or 0 if the sample is not exposed
+func metricValue(sample string) float64 {
+	recorder := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	for _, line := range strings.Split(recorder.Body.String(), "\n") {
+		if strings.HasPrefix(line, sample+" ") {
+			value, _ := strconv.ParseFloat(strings.TrimPrefix(line, sample+" "), 64)
+			return value
+		}
+	}
+	return 0
+}
+
+func TestStoreExpiryEntriesRemovedOnRollbackAndTruncate(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	env.btlPolicy[nsColl{"ns1", "coll1"}] = 1
+	store := env.store
+
+	testutil.AssertNoError(t, store.Prepare(0, samplePvtData(), nil), "")
+	testutil.AssertNoError(t, store.Rollback(), "")
+	testutil.AssertEquals(t, env.numExpiryEntries(), 0)
+
+	testutil.AssertNoError(t, store.Prepare(0, samplePvtData(), nil), "")
+	testutil.AssertNoError(t, store.Commit(), "")
+	testutil.AssertNoError(t, store.Prepare(1, samplePvtData(), nil), "")
+	testutil.AssertNoError(t, store.Commit(), "")
+	testutil.AssertEquals(t, env.numExpiryEntries(), 2)
+	testutil.AssertNoError(t, store.Truncate(1), "")
+	testutil.AssertEquals(t, env.numExpiryEntries(), 1)
+}
+
+func TestStoreMissingPvtData(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+	env.btlPolicy[nsColl{"ns2", "coll1"}] = 1
+	store := env.store
+
+	testutil.AssertNoError(t, store.Prepare(0, nil, []*ledger.MissingPvtData{
+		{SeqInBlock: 0, Namespace: "ns2", CollectionName: "coll1"},
+		{SeqInBlock: 0, Namespace: "ns1", CollectionName: "coll1"},
+	}), "")
+	testutil.AssertNoError(t, store.Commit(), "")
+	testutil.AssertNoError(t, store.Prepare(1, nil, []*ledger.MissingPvtData{{SeqInBlock: 1, Namespace: "ns1", CollectionName: "coll2"}}), "")
+	testutil.AssertNoError(t, store.Commit(), "")
+	// the missing data of a pending block is not reported
+	testutil.AssertNoError(t, store.Prepare(2, nil, []*ledger.MissingPvtData{{SeqInBlock: 0, Namespace: "ns1", CollectionName: "coll1"}}), "")
+
+	missingDataInfo, err := store.GetMissingPvtDataInfoForMostRecentBlocks(1)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, missingDataInfo, ledger.MissingPvtDataInfo{
+		1: {{SeqInBlock: 1, Namespace: "ns1", CollectionName: "coll2"}},
+	})
+	missingDataInfo, _ = store.GetMissingPvtDataInfoForMostRecentBlocks(10)
+	testutil.AssertEquals(t, missingDataInfo, ledger.MissingPvtDataInfo{
+		1: {{SeqInBlock: 1, Namespace: "ns1", CollectionName: "coll2"}},
+		0: {{SeqInBlock: 0, Namespace: "ns1", CollectionName: "coll1"}, {SeqInBlock: 0, Namespace: "ns2", CollectionName: "coll1"}},
+	})
+
+	// the missing data of a rolled back block is discarded
+	testutil.AssertNoError(t, store.Rollback(), "")
+	testutil.AssertNoError(t, store.Prepare(2, nil, nil), "")
+	testutil.AssertNoError(t, store.Commit(), "")
+
+	// only the write sets recorded as missing, and not expired, are committed. ns2:coll1 of block 0 has expired at block 2
+	testutil.AssertNoError(t, store.CommitPvtDataOfOldBlocks([]*ledger.BlockPvtData{
+		{BlockNum: 0, PvtData: []*ledger.TxPvtData{{SeqInBlock: 0, WriteSets: []*ledger.CollPvtWriteSet{
+			{Namespace: "ns1", CollectionName: "coll1", WriteSet: []byte("ws-0-ns1-coll1")},
+			{Namespace: "ns2", CollectionName: "coll1", WriteSet: []byte("ws-0-ns2-coll1")},
+			{Namespace: "ns3", CollectionName: "coll1", WriteSet: []byte("ws-0-ns3-coll1")},
+		}}}},
+		{BlockNum: 5, PvtData: []*ledger.TxPvtData{{SeqInBlock: 0, WriteSets: []*ledger.CollPvtWriteSet{
+			{Namespace: "ns1", CollectionName: "coll1", WriteSet: []byte("ws-0-ns1-coll1")},
+		}}}},
+	}), "")
+	pvtData, _ := store.GetPvtDataByBlockNum(0, nil)
+	testutil.AssertEquals(t, pvtData, []*ledger.TxPvtData{
+		{SeqInBlock: 0, WriteSets: []*ledger.CollPvtWriteSet{{Namespace: "ns1", CollectionName: "coll1", WriteSet: []byte("ws-0-ns1-coll1")}}},
+	})
+	_, err = store.GetPvtDataByBlockNum(5, nil)
+	testutil.AssertEquals(t, err, ErrOutOfRange)
+	missingDataInfo, _ = store.GetMissingPvtDataInfoForMostRecentBlocks(10)
+	testutil.AssertEquals(t, missingDataInfo, ledger.MissingPvtDataInfo{
+		1: {{SeqInBlock: 1, Namespace: "ns1", CollectionName: "coll2"}},
+	})
+
+	// the missing data of the truncated blocks is discarded. ns2:coll1 of block 0 is not expired
+	// anymore at the lowered height, as the expired missing data is not purged yet
+	testutil.AssertNoError(t, store.Truncate(1), "")
+	missingDataInfo, _ = store.GetMissingPvtDataInfoForMostRecentBlocks(10)
+	testutil.AssertEquals(t, missingDataInfo, ledger.MissingPvtDataInfo{
+		0: {{SeqInBlock: 0, Namespace: "ns2", CollectionName: "coll1"}},
+	})
+}
+
+func TestStorePurgeMissingPvtData(t *testing.T) {
+	viper.Set("ledger.pvtdataStore.purgeInterval", 1)
+	defer viper.Set("ledger.pvtdataStore.purgeInterval", 0)
+	env := newTestEnv(t)
+	defer env.cleanup()
+	env.btlPolicy[nsColl{"ns1", "coll1"}] = 1
+	store := env.store
+
+	testutil.AssertNoError(t, store.Prepare(0, nil, []*ledger.MissingPvtData{{SeqInBlock: 0, Namespace: "ns1", CollectionName: "coll1"}}), "")
+	testutil.AssertNoError(t, store.Commit(), "")
+	testutil.AssertNoError(t, store.Prepare(1, nil, []*ledger.MissingPvtData{{SeqInBlock: 0, Namespace: "ns1", CollectionName: "coll1"}}), "")
+	testutil.AssertNoError(t, store.Commit(), "")
+	testutil.AssertNoError(t, store.CommitPvtDataOfOldBlocks([]*ledger.BlockPvtData{
+		{BlockNum: 1, PvtData: []*ledger.TxPvtData{{SeqInBlock: 0, WriteSets: []*ledger.CollPvtWriteSet{
+			{Namespace: "ns1", CollectionName: "coll1", WriteSet: []byte("ws-1-ns1-coll1")},
+		}}}},
+	}), "")
+
+	// the record of the missing data of block 0 is purged at block 2, the reconciled data of block 1 is purged at block 3
+	testutil.AssertNoError(t, store.Prepare(2, nil, nil), "")
+	testutil.AssertNoError(t, store.Commit(), "")
+	testutil.AssertEquals(t, env.numExpiryEntries(), 1)
+	testutil.AssertEquals(t, store.GetPurgeStats().NumPurgedWriteSets, uint64(0))
+	testutil.AssertNoError(t, store.Prepare(3, nil, nil), "")
+	testutil.AssertNoError(t, store.Commit(), "")
+	testutil.AssertEquals(t, env.numExpiryEntries(), 0)
+	testutil.AssertEquals(t, store.GetPurgeStats().NumPurgedWriteSets, uint64(1))
+	missingDataInfo, _ := store.GetMissingPvtDataInfoForMostRecentBlocks(10)
+	testutil.AssertNil(t, missingDataInfo)
+}
+
+func (env *testEnv) numExpiryEntries() int {
+	itr := env.store.(*store).db.GetIterator(expiryKeyPrefix, []byte{expiryKeyPrefix[0] + 1})
+	defer itr.Release()
+	count := 0
+	for itr.Next() {
+		count++
+	}
+	return count
+}
+
+// testBTLPolicy is a BlockToLive policy backed by a map. The collections absent in the map never expire
+type testBTLPolicy map[nsColl]uint64
+
+func (p testBTLPolicy) GetBTL(ns string, coll string) (uint64, error) {
+	return p[nsColl{ns, coll}], nil
+}
+
+func (p testBTLPolicy) GetExpiringBlock(ns string, coll string, committingBlock uint64) (uint64, error) {
+	return pvtdatapolicy.ComputeExpiringBlock(p[nsColl{ns, coll}], committingBlock), nil
+}
+
+func samplePvtData() []*ledger.TxPvtData {
+	return []*ledger.TxPvtData{
+		{SeqInBlock: 0, WriteSets: []*ledger.CollPvtWriteSet{
+			{Namespace: "ns1", CollectionName: "coll1", WriteSet: []byte("ws-0-ns1-coll1")},
+			{Namespace: "ns2", CollectionName: "coll1", WriteSet: []byte("ws-0-ns2-coll1")},
+		}},
+		{SeqInBlock: 2, WriteSets: []*ledger.CollPvtWriteSet{
+			{Namespace: "ns1", CollectionName: "coll2", WriteSet: []byte("ws-2-ns1-coll2")},
+		}},
+	}
+}
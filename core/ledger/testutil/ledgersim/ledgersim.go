@@ -0,0 +1,271 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ledgersim is a sandbox that commits synthetic blocks to a ledger, which keeps its data in the real
+// block, state, and history stores, and reports the throughput of the commits. The workload is generated from
+// a seed so that the effect of a tuning change on the throughput can be measured reproducibly
+package ledgersim
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/util"
+	"github.com/hyperledger/fabric/core/ledger"
+	lutils "github.com/hyperledger/fabric/core/ledger/util"
+	"github.com/hyperledger/fabric/protos/common"
+	ptestutils "github.com/hyperledger/fabric/protos/testutils"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+// KeyDistribution is the distribution of the keys that the synthetic transactions read and write
+type KeyDistribution string
+
+const (
+	// Sequential picks the keys of the key space in order, wrapping around at its end
+	Sequential KeyDistribution = "sequential"
+	// Uniform picks every key of the key space with the same probability
+	Uniform KeyDistribution = "uniform"
+	// Zipf picks the first keys of the key space far more often than the others, as for the hot keys of an application
+	Zipf KeyDistribution = "zipf"
+)
+
+// zipfExponent is the exponent of the Zipf distribution of the keys
+const zipfExponent = 1.1
+
+// Config is the workload of a simulation
+type Config struct {
+	// Namespace is the namespace of the keys
+	Namespace string
+	// Blocks is the number of the blocks to commit
+	Blocks int
+	// TxsPerBlock is the number of the transactions of a block
+	TxsPerBlock int
+	// ReadsPerTx is the number of the keys read by a transaction
+	ReadsPerTx int
+	// WritesPerTx is the number of the keys written by a transaction
+	WritesPerTx int
+	// ValueSize is the size, in bytes, of the written values
+	ValueSize int
+	// KeySpace is the number of the distinct keys
+	KeySpace int
+	// Distribution is the distribution of the keys read and written by the transactions
+	Distribution KeyDistribution
+	// Seed seeds the generation of the keys and the values
+	Seed int64
+}
+
+// DefaultConfig returns the workload of a simulation of 100 blocks of 100 transactions that write
+// 2 uniformly distributed keys each, out of 10000, with values of 100 bytes
+func DefaultConfig() *Config {
+	return &Config{
+		Namespace:    "ledgersim",
+		Blocks:       100,
+		TxsPerBlock:  100,
+		WritesPerTx:  2,
+		ValueSize:    100,
+		KeySpace:     10000,
+		Distribution: Uniform,
+		Seed:         1,
+	}
+}
+
+func (conf *Config) validate() error {
+	if conf.Namespace == "" {
+		return errors.New("the namespace must not be empty")
+	}
+	if conf.Blocks <= 0 || conf.TxsPerBlock <= 0 || conf.KeySpace <= 0 {
+		return errors.New("the numbers of the blocks, the transactions per block and the keys must be greater than zero")
+	}
+	if conf.ReadsPerTx < 0 || conf.WritesPerTx < 0 || conf.ValueSize < 0 {
+		return errors.New("the numbers of the reads and the writes per transaction and the size of the values must not be negative")
+	}
+	switch conf.Distribution {
+	case Sequential, Uniform, Zipf:
+		return nil
+	default:
+		return fmt.Errorf("unknown key distribution [%s]", conf.Distribution)
+	}
+}
+
+// Result is the outcome of a simulation
+type Result struct {
+	Blocks int
+	Txs    int
+	// InvalidTxs is the number of the transactions invalidated at commit,
+	// because of the keys that they read and an earlier transaction wrote
+	InvalidTxs int
+	Writes     int
+	// SimulationTime is the time spent simulating the transactions and constructing the blocks
+	SimulationTime time.Duration
+	// CommitTime is the time spent committing the blocks
+	CommitTime time.Duration
+	// MaxBlockCommitTime is the longest commit of a block
+	MaxBlockCommitTime time.Duration
+}
+
+// BlocksPerSecond returns the number of the blocks committed per second of commit time
+func (r *Result) BlocksPerSecond() float64 {
+	return perSecond(r.Blocks, r.CommitTime)
+}
+
+// TxsPerSecond returns the number of the transactions committed per second of commit time
+func (r *Result) TxsPerSecond() float64 {
+	return perSecond(r.Txs, r.CommitTime)
+}
+
+func (r *Result) String() string {
+	return fmt.Sprintf("Committed %d blocks, %d transactions (%d invalid) and %d writes in %s: %.1f blocks/s, %.1f txs/s, longest block commit %s, simulation %s",
+		r.Blocks, r.Txs, r.InvalidTxs, r.Writes, r.CommitTime, r.BlocksPerSecond(), r.TxsPerSecond(), r.MaxBlockCommitTime, r.SimulationTime)
+}
+
+func perSecond(count int, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(count) / elapsed.Seconds()
+}
+
+// Run simulates the transactions of the workload against the given ledger, puts them in blocks that follow
+// the last block of the ledger and commits the blocks one at a time. The transactions of a block are simulated
+// before the block is committed, hence a transaction that reads a key written by an earlier transaction
+// of the same block is invalidated at commit
+func Run(l ledger.PeerLedger, conf *Config) (*Result, error) {
+	if err := conf.validate(); err != nil {
+		return nil, err
+	}
+	info, err := l.GetBlockchainInfo()
+	if err != nil {
+		return nil, err
+	}
+	gen := newGenerator(conf)
+	result := &Result{}
+	blockNum, previousHash := info.Height, info.CurrentBlockHash
+	for i := 0; i < conf.Blocks; i++ {
+		start := time.Now()
+		block, err := gen.nextBlock(l, blockNum, previousHash)
+		if err != nil {
+			return nil, err
+		}
+		result.SimulationTime += time.Since(start)
+
+		start = time.Now()
+		if err := l.Commit(block); err != nil {
+			return nil, err
+		}
+		elapsed := time.Since(start)
+		result.CommitTime += elapsed
+		if elapsed > result.MaxBlockCommitTime {
+			result.MaxBlockCommitTime = elapsed
+		}
+
+		txsFilter := lutils.TxValidationFlags(block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER])
+		for txNum := 0; txNum < conf.TxsPerBlock; txNum++ {
+			if txsFilter.IsInvalid(txNum) {
+				result.InvalidTxs++
+			}
+		}
+		result.Blocks++
+		result.Txs += conf.TxsPerBlock
+		result.Writes += conf.TxsPerBlock * conf.WritesPerTx
+		blockNum++
+		previousHash = block.Header.Hash()
+	}
+	return result, nil
+}
+
+// generator generates the synthetic transactions and blocks of a workload
+type generator struct {
+	conf    *Config
+	rand    *rand.Rand
+	zipf    *rand.Zipf
+	nextKey int
+}
+
+func newGenerator(conf *Config) *generator {
+	gen := &generator{conf: conf, rand: rand.New(rand.NewSource(conf.Seed))}
+	if conf.Distribution == Zipf {
+		gen.zipf = rand.NewZipf(gen.rand, zipfExponent, 1, uint64(conf.KeySpace-1))
+	}
+	return gen
+}
+
+// key returns the next key of the workload as picked by the distribution of the keys
+func (gen *generator) key() string {
+	var keyNum int
+	switch gen.conf.Distribution {
+	case Sequential:
+		keyNum = gen.nextKey
+		gen.nextKey = (gen.nextKey + 1) % gen.conf.KeySpace
+	case Zipf:
+		keyNum = int(gen.zipf.Uint64())
+	default:
+		keyNum = gen.rand.Intn(gen.conf.KeySpace)
+	}
+	return fmt.Sprintf("key%09d", keyNum)
+}
+
+func (gen *generator) value() []byte {
+	value := make([]byte, gen.conf.ValueSize)
+	gen.rand.Read(value)
+	return value
+}
+
+// nextBlock simulates the transactions of the next block and constructs the block
+func (gen *generator) nextBlock(l ledger.PeerLedger, blockNum uint64, previousHash []byte) (*common.Block, error) {
+	block := common.NewBlock(blockNum, previousHash)
+	for i := 0; i < gen.conf.TxsPerBlock; i++ {
+		simulationResults, err := gen.simulateTx(l)
+		if err != nil {
+			return nil, err
+		}
+		env, _, err := ptestutils.ConstructUnsingedTxEnv(util.GetTestChainID(), gen.conf.Namespace, nil, simulationResults, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		envBytes, err := proto.Marshal(env)
+		if err != nil {
+			return nil, err
+		}
+		block.Data.Data = append(block.Data.Data, envBytes)
+	}
+	block.Header.DataHash = block.Data.Hash()
+	utils.InitBlockMetadata(block)
+	block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER] = lutils.NewTxValidationFlags(gen.conf.TxsPerBlock)
+	return block, nil
+}
+
+func (gen *generator) simulateTx(l ledger.PeerLedger) ([]byte, error) {
+	s, err := l.NewTxSimulator()
+	if err != nil {
+		return nil, err
+	}
+	defer s.Done()
+	for i := 0; i < gen.conf.ReadsPerTx; i++ {
+		if _, err := s.GetState(gen.conf.Namespace, gen.key()); err != nil {
+			return nil, err
+		}
+	}
+	for i := 0; i < gen.conf.WritesPerTx; i++ {
+		if err := s.SetState(gen.conf.Namespace, gen.key(), gen.value()); err != nil {
+			return nil, err
+		}
+	}
+	return s.GetTxSimulationResults()
+}
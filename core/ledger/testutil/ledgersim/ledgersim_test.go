@@ -0,0 +1,97 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ledgersim
+
+import (
+	"os"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger/kvledger"
+	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+	"github.com/spf13/viper"
+)
+
+func TestRun(t *testing.T) {
+	viper.Set("peer.fileSystemPath", "/tmp/fabric/ledgertests/ledgersim")
+	os.RemoveAll(ledgerconfig.GetRootPath())
+	defer os.RemoveAll(ledgerconfig.GetRootPath())
+	provider, err := kvledger.NewProvider()
+	testutil.AssertNoError(t, err, "")
+	defer provider.Close()
+	l, err := provider.Create("testLedger")
+	testutil.AssertNoError(t, err, "")
+	defer l.Close()
+
+	conf := DefaultConfig()
+	conf.Blocks = 3
+	conf.TxsPerBlock = 5
+	conf.KeySpace = 12
+	conf.Distribution = Sequential
+	result, err := Run(l, conf)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, result.Blocks, 3)
+	testutil.AssertEquals(t, result.Txs, 15)
+	testutil.AssertEquals(t, result.Writes, 30)
+	testutil.AssertEquals(t, result.InvalidTxs, 0)
+	info, _ := l.GetBlockchainInfo()
+	testutil.AssertEquals(t, info.Height, uint64(3))
+
+	// the keys wrap around the key space
+	qe, _ := l.NewQueryExecutor()
+	values, _ := qe.GetStateMultipleKeys(conf.Namespace, []string{"key000000000", "key000000011", "key000000012"})
+	qe.Done()
+	testutil.AssertEquals(t, len(values[0]), conf.ValueSize)
+	testutil.AssertEquals(t, len(values[1]), conf.ValueSize)
+	testutil.AssertNil(t, values[2])
+
+	// a transaction that reads a key written earlier in its block is invalidated
+	conf.Blocks = 1
+	conf.ReadsPerTx = 1
+	conf.WritesPerTx = 1
+	conf.KeySpace = 1
+	result, err = Run(l, conf)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, result.InvalidTxs, 4)
+}
+
+func TestInvalidConfig(t *testing.T) {
+	for _, update := range []func(conf *Config){
+		func(conf *Config) { conf.Blocks = 0 },
+		func(conf *Config) { conf.ValueSize = -1 },
+		func(conf *Config) { conf.Namespace = "" },
+		func(conf *Config) { conf.Distribution = "other" },
+	} {
+		conf := DefaultConfig()
+		update(conf)
+		_, err := Run(nil, conf)
+		testutil.AssertError(t, err, "Expected an error for an invalid workload")
+	}
+}
+
+func TestDistributionsAreReproducible(t *testing.T) {
+	for _, distribution := range []KeyDistribution{Sequential, Uniform, Zipf} {
+		conf := DefaultConfig()
+		conf.Distribution = distribution
+		gen1, gen2 := newGenerator(conf), newGenerator(conf)
+		for i := 0; i < 100; i++ {
+			key := gen1.key()
+			testutil.AssertEquals(t, gen2.key(), key)
+			testutil.AssertEquals(t, key < "key000010000", true)
+		}
+	}
+}
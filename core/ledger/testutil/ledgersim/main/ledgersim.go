@@ -0,0 +1,84 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// ledgersim commits synthetic blocks to a new ledger and prints the throughput of the commits. The ledger
+// keeps its data in the stores configured by the core.yaml, with the overrides given on the command line, e.g.,
+//
+//	go run ledgersim.go -blocks 1000 -txs 200 -writes 4 -valuesize 1000 -distribution zipf -history
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/hyperledger/fabric/core/ledger/kvledger"
+	"github.com/hyperledger/fabric/core/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger/testutil/ledgersim"
+	"github.com/spf13/viper"
+)
+
+const ledgerID = "ledgersim"
+
+func main() {
+	conf := ledgersim.DefaultConfig()
+	configPath := flag.String("config", "./../../../../../peer", "the directory of the core.yaml")
+	dataPath := flag.String("path", "/tmp/fabric/ledgersim", "the directory of the ledger data, removed before the simulation")
+	stateDatabase := flag.String("statedb", "", "the state database, goleveldb or CouchDB, overriding the core.yaml")
+	history := flag.Bool("history", false, "maintain the history database")
+	flag.IntVar(&conf.Blocks, "blocks", conf.Blocks, "the number of the blocks")
+	flag.IntVar(&conf.TxsPerBlock, "txs", conf.TxsPerBlock, "the number of the transactions per block")
+	flag.IntVar(&conf.ReadsPerTx, "reads", conf.ReadsPerTx, "the number of the keys read per transaction")
+	flag.IntVar(&conf.WritesPerTx, "writes", conf.WritesPerTx, "the number of the keys written per transaction")
+	flag.IntVar(&conf.ValueSize, "valuesize", conf.ValueSize, "the size of the values in bytes")
+	flag.IntVar(&conf.KeySpace, "keys", conf.KeySpace, "the number of the distinct keys")
+	distribution := flag.String("distribution", string(conf.Distribution), "the distribution of the keys: sequential, uniform or zipf")
+	flag.Int64Var(&conf.Seed, "seed", conf.Seed, "the seed of the workload")
+	flag.Parse()
+	conf.Distribution = ledgersim.KeyDistribution(*distribution)
+
+	testutil.SetupCoreYAMLConfig(*configPath)
+	viper.Set("peer.fileSystemPath", *dataPath)
+	viper.Set("ledger.state.historyDatabase", *history)
+	if *stateDatabase != "" {
+		viper.Set("ledger.state.stateDatabase", *stateDatabase)
+	}
+	os.RemoveAll(*dataPath)
+
+	if err := run(conf); err != nil {
+		fmt.Fprintf(os.Stderr, "Simulation failed: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(conf *ledgersim.Config) error {
+	provider, err := kvledger.NewProvider()
+	if err != nil {
+		return err
+	}
+	defer provider.Close()
+	l, err := provider.Create(ledgerID)
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+	result, err := ledgersim.Run(l, conf)
+	if err != nil {
+		return err
+	}
+	fmt.Println(result)
+	return nil
+}
@@ -0,0 +1,144 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testutil
+
+import (
+	"sync"
+
+	commonledger "github.com/hyperledger/fabric/common/ledger"
+	"github.com/hyperledger/fabric/core/ledger"
+)
+
+// MockHistoryQueryExecutor is an in-memory ledger.HistoryQueryExecutor for the unit tests of the components
+// that run history queries without a ledger. The history is recorded by Commit in the order of the commits
+type MockHistoryQueryExecutor struct {
+	lock    sync.RWMutex
+	history map[string]map[string][]*mockHistoryRecord
+}
+
+type mockHistoryRecord struct {
+	blockNum uint64
+	txID     string
+	value    []byte
+}
+
+// NewMockHistoryQueryExecutor constructs an empty MockHistoryQueryExecutor
+func NewMockHistoryQueryExecutor() *MockHistoryQueryExecutor {
+	return &MockHistoryQueryExecutor{history: make(map[string]map[string][]*mockHistoryRecord)}
+}
+
+// Commit records the writes of a transaction of the given block to the keys of the namespace.
+// A nil value records a delete
+func (m *MockHistoryQueryExecutor) Commit(blockNum uint64, txID string, namespace string, writes map[string][]byte) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	nsHistory, ok := m.history[namespace]
+	if !ok {
+		nsHistory = make(map[string][]*mockHistoryRecord)
+		m.history[namespace] = nsHistory
+	}
+	for key, value := range writes {
+		nsHistory[key] = append(nsHistory[key], &mockHistoryRecord{blockNum, txID, value})
+	}
+}
+
+// GetHistoryForKey implements method in interface `ledger.HistoryQueryExecutor`
+func (m *MockHistoryQueryExecutor) GetHistoryForKey(namespace string, key string) (commonledger.ResultsIterator, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	records := m.history[namespace][key]
+	itr := &mockHistoryIterator{}
+	for _, record := range records {
+		itr.results = append(itr.results, &ledger.KeyModification{TxID: record.txID, Value: record.value})
+	}
+	return itr, nil
+}
+
+// GetRecentHistoryForKey implements method in interface `ledger.HistoryQueryExecutor`
+func (m *MockHistoryQueryExecutor) GetRecentHistoryForKey(namespace string, key string, limit int) (commonledger.ResultsIterator, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	records := m.history[namespace][key]
+	itr := &mockHistoryIterator{}
+	for i := len(records) - 1; i >= 0 && (limit <= 0 || len(itr.results) < limit); i-- {
+		itr.results = append(itr.results, &ledger.KeyModification{TxID: records[i].txID, Value: records[i].value})
+	}
+	return itr, nil
+}
+
+// GetHistoryForKeyWithOptions implements method in interface `ledger.HistoryQueryExecutor`
+func (m *MockHistoryQueryExecutor) GetHistoryForKeyWithOptions(namespace string, key string,
+	options *ledger.HistoryQueryOptions) (commonledger.ResultsIterator, error) {
+	if options == nil {
+		return m.GetHistoryForKey(namespace, key)
+	}
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	var records []*mockHistoryRecord
+	for _, record := range m.history[namespace][key] {
+		if record.blockNum < options.StartBlock || (options.EndBlock > 0 && record.blockNum > options.EndBlock) ||
+			(record.value == nil && !options.IncludeDeletes) {
+			continue
+		}
+		records = append(records, record)
+	}
+	itr := &mockHistoryIterator{}
+	for i := range records {
+		if options.Limit > 0 && len(itr.results) == options.Limit {
+			break
+		}
+		record := records[i]
+		if options.Reverse {
+			record = records[len(records)-1-i]
+		}
+		itr.results = append(itr.results, &ledger.KeyModification{TxID: record.txID, Value: record.value})
+	}
+	return itr, nil
+}
+
+// GetStateAsOf implements method in interface `ledger.HistoryQueryExecutor`
+func (m *MockHistoryQueryExecutor) GetStateAsOf(namespace string, key string, blockHeight uint64) ([]byte, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	records := m.history[namespace][key]
+	for i := len(records) - 1; i >= 0; i-- {
+		if records[i].blockNum < blockHeight {
+			return records[i].value, nil
+		}
+	}
+	return nil, nil
+}
+
+// mockHistoryIterator iterates over the history of a key as of the query
+type mockHistoryIterator struct {
+	results []*ledger.KeyModification
+}
+
+// Next implements method in interface `ledger.ResultsIterator`
+func (itr *mockHistoryIterator) Next() (commonledger.QueryResult, error) {
+	if len(itr.results) == 0 {
+		return nil, nil
+	}
+	result := itr.results[0]
+	itr.results = itr.results[1:]
+	return result, nil
+}
+
+// Close implements method in interface `ledger.ResultsIterator`
+func (itr *mockHistoryIterator) Close() {
+	itr.results = nil
+}
@@ -31,15 +31,22 @@ import (
 	"net/textproto"
 	"net/url"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 	"unicode/utf8"
 
+	"github.com/hyperledger/fabric/common/metrics"
 	logging "github.com/op/go-logging"
 )
 
 var logger = logging.MustGetLogger("couchdb")
 
+var requestDuration = metrics.NewHistogramVec("couchdb_request_duration_seconds",
+	"Time taken in seconds for a request to CouchDB to complete.", metrics.DefaultBuckets, "database", "method")
+
 // DBOperationResponse is body for successful database calls.
 type DBOperationResponse struct {
 	Ok  bool
@@ -69,6 +76,15 @@ type DBInfo struct {
 	InstanceStartTime string `json:"instance_start_time"`
 }
 
+// FileSize returns the size of the database file. CouchDB 2.x reports the size under sizes.file
+// while the earlier versions report it as disk_size
+func (dbInfo *DBInfo) FileSize() int64 {
+	if dbInfo.Sizes.File > 0 {
+		return int64(dbInfo.Sizes.File)
+	}
+	return int64(dbInfo.DiskSize)
+}
+
 //ConnectionInfo is a structure for capturing the database info and version
 type ConnectionInfo struct {
 	Couchdb string `json:"couchdb"`
@@ -78,6 +94,45 @@ type ConnectionInfo struct {
 	} `json:"vendor"`
 }
 
+//ServerFeatures captures the version of a CouchDB server and the optional features that it supports.
+//The features are derived from the version when the instance is created, and are confirmed by probing
+//the endpoints of the first database created on the instance
+type ServerFeatures struct {
+	Vendor  string
+	Version string
+	//Clustered is set if the server is a node of a cluster of more than one node
+	Clustered bool
+	//Mango is set if the server supports the Mango queries (_find), on which the rich queries rely
+	Mango bool
+	//BulkGet is set if the server reads multiple documents in a single request (_bulk_get)
+	BulkGet bool
+}
+
+//serverFeatures holds the features of a server, which are shared by the copies of a CouchInstance
+type serverFeatures struct {
+	lock     sync.RWMutex
+	features ServerFeatures
+	probed   bool
+}
+
+//clusterMembership is used for processing the response of the _membership endpoint of CouchDB
+type clusterMembership struct {
+	AllNodes     []string `json:"all_nodes"`
+	ClusterNodes []string `json:"cluster_nodes"`
+}
+
+//bulkGetResponse is used for processing the response of the _bulk_get endpoint of CouchDB.
+//Each result holds either the document or the error reported for its id
+type bulkGetResponse struct {
+	Results []struct {
+		ID   string `json:"id"`
+		Docs []struct {
+			OK    json.RawMessage `json:"ok"`
+			Error *DBReturn       `json:"error"`
+		} `json:"docs"`
+	} `json:"results"`
+}
+
 //RangeQueryResponse is used for processing REST range query responses from CouchDB
 type RangeQueryResponse struct {
 	TotalRows int `json:"total_rows"`
@@ -126,7 +181,8 @@ type CouchConnectionDef struct {
 
 //CouchInstance represents a CouchDB instance
 type CouchInstance struct {
-	conf CouchConnectionDef //connection configuration
+	conf     CouchConnectionDef //connection configuration
+	features *serverFeatures    //features of the server, nil if not detected
 }
 
 //CouchDatabase represents a database within a CouchDB instance
@@ -313,6 +369,98 @@ func (couchInstance *CouchInstance) VerifyConnection() (*ConnectionInfo, *DBRetu
 
 }
 
+//ReadClusterMembership method provides function to retrieve the nodes of the cluster that the server belongs to
+func (couchInstance *CouchInstance) ReadClusterMembership() ([]string, *DBReturn, error) {
+
+	membershipURL, err := url.Parse(couchInstance.conf.URL)
+	if err != nil {
+		logger.Errorf("URL parse error: %s", err.Error())
+		return nil, nil, err
+	}
+	membershipURL.Path = "/_membership"
+
+	resp, couchDBReturn, err := couchInstance.handleRequest(http.MethodGet, membershipURL.String(), nil, "", "")
+	if err != nil {
+		return nil, couchDBReturn, err
+	}
+	defer resp.Body.Close()
+
+	membership := &clusterMembership{}
+	if err := json.NewDecoder(resp.Body).Decode(membership); err != nil {
+		return nil, couchDBReturn, err
+	}
+	return membership.ClusterNodes, couchDBReturn, nil
+}
+
+//Features returns the features of the server, or nil if they have not been detected,
+//in which case the server is expected to support all the features
+func (couchInstance *CouchInstance) Features() *ServerFeatures {
+	if couchInstance.features == nil {
+		return nil
+	}
+	couchInstance.features.lock.RLock()
+	defer couchInstance.features.lock.RUnlock()
+	features := couchInstance.features.features
+	return &features
+}
+
+//probeFeatures confirms the support of the optional endpoints by the server on the given database.
+//The endpoints are probed once per instance, on the first database created on the instance
+func (dbclient *CouchDatabase) probeFeatures() error {
+	serverFeatures := dbclient.couchInstance.features
+	if serverFeatures == nil {
+		return nil
+	}
+	serverFeatures.lock.Lock()
+	defer serverFeatures.lock.Unlock()
+	if serverFeatures.probed {
+		return nil
+	}
+	var err error
+	features := &serverFeatures.features
+	if features.Mango {
+		if features.Mango, err = dbclient.probeEndpoint("_find", `{"selector":{"_id":{"$gt":null}},"limit":0}`); err != nil {
+			return err
+		}
+		if !features.Mango {
+			logger.Warningf("CouchDB %s version %s does not support the Mango queries (_find), the rich queries are disabled", features.Vendor, features.Version)
+		}
+	}
+	if features.BulkGet {
+		if features.BulkGet, err = dbclient.probeEndpoint("_bulk_get", `{"docs":[{"id":"fabric_feature_probe"}]}`); err != nil {
+			return err
+		}
+		if !features.BulkGet {
+			logger.Warningf("CouchDB %s version %s does not support _bulk_get, the documents are read one at a time", features.Vendor, features.Version)
+		}
+	}
+	serverFeatures.probed = true
+	return nil
+}
+
+//probeEndpoint posts the given body to the given endpoint of the database. The endpoint is not supported
+//if CouchDB reports that it does not exist or that it does not support the method
+func (dbclient *CouchDatabase) probeEndpoint(endpoint string, body string) (bool, error) {
+
+	probeURL, err := url.Parse(dbclient.couchInstance.conf.URL)
+	if err != nil {
+		logger.Errorf("URL parse error: %s", err.Error())
+		return false, err
+	}
+	probeURL.Path = dbclient.dbName + "/" + endpoint
+
+	resp, couchDBReturn, err := dbclient.couchInstance.handleRequest(http.MethodPost, probeURL.String(), strings.NewReader(body), "", "")
+	if err != nil {
+		if couchDBReturn != nil && (couchDBReturn.StatusCode == http.StatusNotFound || couchDBReturn.StatusCode == http.StatusMethodNotAllowed) {
+			logger.Debugf("Endpoint %s not supported, status code=%d", endpoint, couchDBReturn.StatusCode)
+			return false, nil
+		}
+		return false, fmt.Errorf("Error probing the support of %s by CouchDB: %s", endpoint, err)
+	}
+	resp.Body.Close()
+	return true, nil
+}
+
 //DropDatabase provides method to drop an existing database
 func (dbclient *CouchDatabase) DropDatabase() (*DBOperationResponse, error) {
 
@@ -681,6 +829,94 @@ func (dbclient *CouchDatabase) ReadDoc(id string) (*CouchDoc, string, error) {
 	return &couchDoc, revision, nil
 }
 
+//ReadDocs method provides function to retrieve multiple documents from the database by id in a single request.
+//The documents are returned in the order of the ids, and the document of an id that does not exist is nil.
+//The documents are read one at a time if the server does not support _bulk_get. As for ReadDocRange,
+//the documents with attachments are read again by ReadDoc
+func (dbclient *CouchDatabase) ReadDocs(ids []string) ([]*CouchDoc, error) {
+
+	logger.Debugf("Entering ReadDocs()  number of ids=%d", len(ids))
+
+	couchDocs := make([]*CouchDoc, len(ids))
+	if features := dbclient.couchInstance.Features(); features != nil && !features.BulkGet {
+		for i, id := range ids {
+			couchDoc, _, err := dbclient.ReadDoc(id)
+			if err != nil {
+				return nil, err
+			}
+			couchDocs[i] = couchDoc
+		}
+		return couchDocs, nil
+	}
+
+	type docID struct {
+		ID string `json:"id"`
+	}
+	request := struct {
+		Docs []docID `json:"docs"`
+	}{}
+	for _, id := range ids {
+		if !utf8.ValidString(id) {
+			return nil, fmt.Errorf("doc id [%x] not a valid utf8 string", id)
+		}
+		request.Docs = append(request.Docs, docID{id})
+	}
+	requestJSON, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	bulkGetURL, err := url.Parse(dbclient.couchInstance.conf.URL)
+	if err != nil {
+		logger.Errorf("URL parse error: %s", err.Error())
+		return nil, err
+	}
+	bulkGetURL.Path = dbclient.dbName + "/_bulk_get"
+
+	resp, _, err := dbclient.couchInstance.handleRequest(http.MethodPost, bulkGetURL.String(), bytes.NewReader(requestJSON), "", "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	response := &bulkGetResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		return nil, err
+	}
+	if len(response.Results) != len(ids) {
+		return nil, fmt.Errorf("CouchDB returned %d results for %d ids", len(response.Results), len(ids))
+	}
+
+	for i, result := range response.Results {
+		if len(result.Docs) == 0 {
+			continue
+		}
+		doc := result.Docs[0]
+		if doc.Error != nil {
+			if doc.Error.Error == "not_found" {
+				continue
+			}
+			return nil, fmt.Errorf("Couch DB Error reading doc id [%x]: %s", result.ID, doc.Error.Reason)
+		}
+		jsonDoc := &Doc{}
+		if err := json.Unmarshal(doc.OK, jsonDoc); err != nil {
+			return nil, err
+		}
+		if jsonDoc.Attachments != nil {
+			couchDoc, _, err := dbclient.ReadDoc(jsonDoc.ID)
+			if err != nil {
+				return nil, err
+			}
+			couchDocs[i] = couchDoc
+			continue
+		}
+		couchDocs[i] = &CouchDoc{JSONValue: doc.OK}
+	}
+
+	logger.Debugf("Exiting ReadDocs()")
+	return couchDocs, nil
+}
+
 //ReadDocRange method provides function to a range of documents based on the start and end keys
 //startKey and endKey can also be empty strings.  If startKey and endKey are empty, all documents are returned
 //TODO This function provides a limit option to specify the max number of entries.   This will
@@ -791,6 +1027,143 @@ func (dbclient *CouchDatabase) ReadDocRange(startKey, endKey string, limit, skip
 
 }
 
+//ReadDocIDs method provides function to read the ids of the documents from the start key, without their content.
+//The offset of the response is the number of the documents before the start key, which allows to count the
+//documents in a range of keys without reading them
+func (dbclient *CouchDatabase) ReadDocIDs(startKey string, limit int) (*RangeQueryResponse, error) {
+
+	logger.Debugf("Entering ReadDocIDs()  startKey=%s", startKey)
+
+	rangeURL, err := url.Parse(dbclient.couchInstance.conf.URL)
+	if err != nil {
+		logger.Errorf("URL parse error: %s", err.Error())
+		return nil, err
+	}
+	rangeURL.Path = dbclient.dbName + "/_all_docs"
+
+	queryParms := rangeURL.Query()
+	queryParms.Set("limit", strconv.Itoa(limit))
+	if startKey != "" {
+		if startKey, err = encodeForJSON(startKey); err != nil {
+			return nil, err
+		}
+		queryParms.Add("startkey", "\""+startKey+"\"")
+	}
+	rangeURL.RawQuery = queryParms.Encode()
+
+	resp, _, err := dbclient.couchInstance.handleRequest(http.MethodGet, rangeURL.String(), nil, "", "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	jsonResponseRaw, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	var jsonResponse = &RangeQueryResponse{}
+	if err := json.Unmarshal(jsonResponseRaw, jsonResponse); err != nil {
+		return nil, err
+	}
+
+	logger.Debugf("Exiting ReadDocIDs()")
+
+	return jsonResponse, nil
+}
+
+//ViewName identifies a view of a design document of the database. The JSON indexes of the rich queries are views
+//of the design documents that CouchDB creates for them
+type ViewName struct {
+	// DesignDoc is the name of the design document, i.e., its id without the "_design/" prefix
+	DesignDoc string
+	View      string
+}
+
+//ListViews method provides function to list the views of the design documents of the database
+func (dbclient *CouchDatabase) ListViews() ([]ViewName, error) {
+
+	logger.Debugf("Entering ListViews()")
+
+	listURL, err := url.Parse(dbclient.couchInstance.conf.URL)
+	if err != nil {
+		logger.Errorf("URL parse error: %s", err.Error())
+		return nil, err
+	}
+	listURL.Path = dbclient.dbName + "/_all_docs"
+
+	// the design documents sort between "_design/" and "_design0" as '0' follows '/'
+	queryParms := listURL.Query()
+	queryParms.Set("include_docs", "true")
+	queryParms.Set("startkey", "\"_design/\"")
+	queryParms.Set("endkey", "\"_design0\"")
+	listURL.RawQuery = queryParms.Encode()
+
+	resp, _, err := dbclient.couchInstance.handleRequest(http.MethodGet, listURL.String(), nil, "", "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var jsonResponse struct {
+		Rows []struct {
+			ID  string `json:"id"`
+			Doc struct {
+				Views map[string]json.RawMessage `json:"views"`
+			} `json:"doc"`
+		} `json:"rows"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&jsonResponse); err != nil {
+		return nil, err
+	}
+	var views []ViewName
+	for _, row := range jsonResponse.Rows {
+		designDoc := strings.TrimPrefix(row.ID, "_design/")
+		viewNames := make([]string, 0, len(row.Doc.Views))
+		for view := range row.Doc.Views {
+			viewNames = append(viewNames, view)
+		}
+		sort.Strings(viewNames)
+		for _, view := range viewNames {
+			views = append(views, ViewName{DesignDoc: designDoc, View: view})
+		}
+	}
+
+	logger.Debugf("Exiting ListViews()")
+
+	return views, nil
+}
+
+//WarmView method provides function to trigger the update of a view without waiting for it. The view is queried for
+//no rows with stale=update_after, so that CouchDB answers from the view as is and brings the view up to date afterwards
+func (dbclient *CouchDatabase) WarmView(designDoc, view string) error {
+
+	logger.Debugf("Entering WarmView()  designDoc=%s view=%s", designDoc, view)
+
+	warmURL, err := url.Parse(dbclient.couchInstance.conf.URL)
+	if err != nil {
+		logger.Errorf("URL parse error: %s", err.Error())
+		return err
+	}
+	warmURL.Path = dbclient.dbName
+	// the names can contain a '/', so encode separately
+	warmURL = &url.URL{Opaque: warmURL.String() + "/_design/" + encodePathElement(designDoc) + "/_view/" + encodePathElement(view)}
+
+	queryParms := warmURL.Query()
+	queryParms.Set("limit", "0")
+	queryParms.Set("stale", "update_after")
+	warmURL.RawQuery = queryParms.Encode()
+
+	resp, _, err := dbclient.couchInstance.handleRequest(http.MethodGet, warmURL.String(), nil, "", "")
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	logger.Debugf("Exiting WarmView()")
+
+	return nil
+}
+
 //DeleteDoc method provides function to delete a document from the database by id
 func (dbclient *CouchDatabase) DeleteDoc(id, rev string) error {
 
@@ -847,6 +1220,40 @@ func (dbclient *CouchDatabase) QueryDocuments(query string, limit, skip int) (*[
 
 	var results []QueryResult
 
+	stream, err := dbclient.QueryDocumentsStream(query, limit, skip)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+
+	for {
+		result, err := stream.Next()
+		if err != nil {
+			return nil, err
+		}
+		if result == nil {
+			break
+		}
+		results = append(results, *result)
+	}
+	logger.Debugf("Exiting QueryDocuments()")
+
+	return &results, nil
+
+}
+
+//QueryDocumentsStream method provides function for processing a query like QueryDocuments,
+//but the documents are decoded from the response one at a time as the stream is consumed,
+//so that the whole response is not held in memory. The stream must be closed by the caller
+func (dbclient *CouchDatabase) QueryDocumentsStream(query string, limit, skip int) (*QueryResultStream, error) {
+
+	logger.Debugf("Entering QueryDocumentsStream()  query=%s", query)
+
+	if features := dbclient.couchInstance.Features(); features != nil && !features.Mango {
+		return nil, fmt.Errorf("Rich queries are not supported by CouchDB %s version %s, which does not provide the Mango query endpoint _find. "+
+			"Rich queries require Apache CouchDB 2.0.0 or later", features.Vendor, features.Version)
+	}
+
 	queryURL, err := url.Parse(dbclient.couchInstance.conf.URL)
 	if err != nil {
 		logger.Errorf("URL parse error: %s", err.Error())
@@ -861,69 +1268,106 @@ func (dbclient *CouchDatabase) QueryDocuments(query string, limit, skip int) (*[
 
 	queryURL.RawQuery = queryParms.Encode()
 
-	//Set up a buffer for the data to be pushed to couchdb
-	data := new(bytes.Buffer)
-
-	data.ReadFrom(bytes.NewReader([]byte(query)))
-
-	resp, _, err := dbclient.couchInstance.handleRequest(http.MethodPost, queryURL.String(), data, "", "")
+	resp, _, err := dbclient.couchInstance.handleRequest(http.MethodPost, queryURL.String(), bytes.NewReader([]byte(query)), "", "")
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
 	if logger.IsEnabledFor(logging.DEBUG) {
-		dump, err2 := httputil.DumpResponse(resp, true)
+		//only the headers are dumped, the body is consumed by the stream
+		dump, err2 := httputil.DumpResponse(resp, false)
 		if err2 != nil {
 			log.Fatal(err2)
 		}
 		logger.Debugf("%s", dump)
 	}
 
-	//handle as JSON document
-	jsonResponseRaw, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
+	stream := &QueryResultStream{dbclient: dbclient, body: resp.Body, decoder: json.NewDecoder(resp.Body)}
+	if err := stream.seekDocs(); err != nil {
+		stream.Close()
 		return nil, err
 	}
+	return stream, nil
+}
 
-	var jsonResponse = &QueryResponse{}
+//QueryResultStream decodes the documents of a query response as they are requested
+type QueryResultStream struct {
+	dbclient *CouchDatabase
+	body     io.ReadCloser
+	decoder  *json.Decoder
+	done     bool
+}
 
-	err2 := json.Unmarshal(jsonResponseRaw, &jsonResponse)
-	if err2 != nil {
-		return nil, err2
+//seekDocs advances the decoder to the first element of the docs array of the response.
+//The other fields of the response, such as the warning, are skipped
+func (stream *QueryResultStream) seekDocs() error {
+	if err := stream.expectDelim('{'); err != nil {
+		return err
 	}
-
-	for _, row := range jsonResponse.Docs {
-
-		var jsonDoc = &Doc{}
-		err3 := json.Unmarshal(row, &jsonDoc)
-		if err3 != nil {
-			return nil, err3
+	for stream.decoder.More() {
+		token, err := stream.decoder.Token()
+		if err != nil {
+			return err
+		}
+		if token == "docs" {
+			return stream.expectDelim('[')
+		}
+		var skipped json.RawMessage
+		if err := stream.decoder.Decode(&skipped); err != nil {
+			return err
 		}
+	}
+	//the response does not contain any documents
+	stream.done = true
+	return nil
+}
 
-		if jsonDoc.Attachments != nil {
+func (stream *QueryResultStream) expectDelim(delim json.Delim) error {
+	token, err := stream.decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("Unexpected token %v in the query response, expected %v", token, delim)
+	}
+	return nil
+}
 
-			logger.Debugf("Adding JSON docment and attachments for id: %s", jsonDoc.ID)
+//Next returns the next document of the query response, or nil if there are no more documents
+func (stream *QueryResultStream) Next() (*QueryResult, error) {
+	if stream.done || !stream.decoder.More() {
+		stream.done = true
+		return nil, nil
+	}
 
-			couchDoc, _, err := dbclient.ReadDoc(jsonDoc.ID)
-			if err != nil {
-				return nil, err
-			}
-			var addDocument = &QueryResult{ID: jsonDoc.ID, Value: couchDoc.JSONValue, Attachments: couchDoc.Attachments}
-			results = append(results, *addDocument)
+	var row json.RawMessage
+	if err := stream.decoder.Decode(&row); err != nil {
+		return nil, err
+	}
 
-		} else {
-			logger.Debugf("Adding json docment for id: %s", jsonDoc.ID)
-			var addDocument = &QueryResult{ID: jsonDoc.ID, Value: row, Attachments: nil}
+	var jsonDoc = &Doc{}
+	if err := json.Unmarshal(row, &jsonDoc); err != nil {
+		return nil, err
+	}
 
-			results = append(results, *addDocument)
+	if jsonDoc.Attachments != nil {
+
+		logger.Debugf("Adding JSON docment and attachments for id: %s", jsonDoc.ID)
 
+		couchDoc, _, err := stream.dbclient.ReadDoc(jsonDoc.ID)
+		if err != nil {
+			return nil, err
 		}
+		return &QueryResult{ID: jsonDoc.ID, Value: couchDoc.JSONValue, Attachments: couchDoc.Attachments}, nil
 	}
-	logger.Debugf("Exiting QueryDocuments()")
 
-	return &results, nil
+	logger.Debugf("Adding json docment for id: %s", jsonDoc.ID)
+	return &QueryResult{ID: jsonDoc.ID, Value: row, Attachments: nil}, nil
+}
 
+//Close closes the underlying response
+func (stream *QueryResultStream) Close() {
+	stream.body.Close()
 }
 
 //handleRequest method is a generic http request handler
@@ -983,7 +1427,9 @@ func (couchInstance *CouchInstance) handleRequest(method, connectURL string, dat
 	client.Transport = transport
 
 	//Execute http request
+	start := time.Now()
 	resp, err := client.Do(req)
+	requestDuration.Observe(time.Since(start).Seconds(), getDatabaseName(req.URL), method)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -1025,13 +1471,15 @@ func IsJSON(s string) bool {
 	return json.Unmarshal([]byte(s), &js) == nil
 }
 
-// encodePathElement uses Golang for encoding and in addition, replaces a '/' by %2F.
-// Otherwise, in the regular encoding, a '/' is treated as a path separator in the url
+// encodePathElement uses Golang for encoding and in addition, replaces a '/' by %2F and a '+' by %2B.
+// Otherwise, in the regular encoding, a '/' is treated as a path separator in the url.
+// The path is escaped as is, as the string of the url would prefix by "./" a path with a ':' in its first element
 func encodePathElement(str string) string {
 	u := &url.URL{}
 	u.Path = str
-	encodedStr := u.String()
+	encodedStr := u.EscapedPath()
 	encodedStr = strings.Replace(encodedStr, "/", "%2F", -1)
+	encodedStr = strings.Replace(encodedStr, "+", "%2B", -1)
 	return encodedStr
 }
 
@@ -1045,3 +1493,16 @@ func encodeForJSON(str string) (string, error) {
 	buffer := buf.Bytes()
 	return string(buffer[1 : len(buffer)-2]), nil
 }
+
+//getDatabaseName returns the name of the database targeted by the request URL.
+//An empty name is returned for the requests to the server, such as the version check
+func getDatabaseName(requestURL *url.URL) string {
+	path := strings.TrimPrefix(requestURL.Path, "/")
+	if i := strings.Index(path, "/"); i >= 0 {
+		path = path[:i]
+	}
+	if strings.HasPrefix(path, "_") {
+		return ""
+	}
+	return path
+}
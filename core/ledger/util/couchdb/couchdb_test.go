@@ -19,7 +19,10 @@ package couchdb
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 	"unicode/utf8"
 
@@ -86,6 +89,13 @@ func TestDBBadConnectionDef(t *testing.T) {
 
 }
 
+func TestEncodePathElement(t *testing.T) {
+	testutil.AssertEquals(t, encodePathElement("ns\x00key"), "ns%00key")
+	testutil.AssertEquals(t, encodePathElement("ns\x00asset:1"), "ns%00asset:1")
+	testutil.AssertEquals(t, encodePathElement("ns\x00a/b+c d?e#f%"), "ns%00a%2Fb%2Bc%20d%3Fe%23f%25")
+	testutil.AssertEquals(t, encodePathElement("ns\x00\t\x1f\u00e9"), "ns%00%09%1F%C3%A9")
+}
+
 func TestDBCreateSaveWithoutRevision(t *testing.T) {
 
 	if ledgerconfig.IsCouchDBEnabled() == true {
@@ -466,4 +476,209 @@ func TestCouchDBVersion(t *testing.T) {
 	err = checkCouchDBVersion("0.0.0.0")
 	testutil.AssertError(t, err, fmt.Sprintf("Error should have been thrown for invalid version"))
 
+	err = checkCouchDBVersion("")
+	testutil.AssertError(t, err, fmt.Sprintf("Error should have been thrown for an unparsable version"))
+
+}
+
+func TestQueryDocumentsStream(t *testing.T) {
+	// the response is served in chunks by a fake CouchDB, the documents are decoded as the stream is consumed
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"warning":"no matching index found","docs":[`))
+		w.(http.Flusher).Flush()
+		w.Write([]byte(`{"_id":"ns\u0000key1","_rev":"1-a","asset_name":"marble1"},`))
+		w.(http.Flusher).Flush()
+		w.Write([]byte(`{"_id":"ns\u0000key2","_rev":"1-b","asset_name":"marble2"}],"bookmark":"nil"}`))
+	}))
+	defer server.Close()
+	conf, err := CreateConnectionDefinition(strings.TrimPrefix(server.URL, "http://"), username, password)
+	testutil.AssertNoError(t, err, "")
+	db := &CouchDatabase{couchInstance: CouchInstance{conf: *conf}, dbName: "testdb"}
+
+	stream, err := db.QueryDocumentsStream(`{"selector":{}}`, 1000, 0)
+	testutil.AssertNoError(t, err, "")
+	defer stream.Close()
+	var ids []string
+	for {
+		result, err := stream.Next()
+		testutil.AssertNoError(t, err, "")
+		if result == nil {
+			break
+		}
+		ids = append(ids, result.ID)
+	}
+	testutil.AssertEquals(t, ids, []string{"ns\x00key1", "ns\x00key2"})
+
+	results, err := db.QueryDocuments(`{"selector":{}}`, 1000, 0)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, len(*results), 2)
+}
+
+func TestListAndWarmViews(t *testing.T) {
+	// a fake CouchDB that serves two design documents and records the views that are queried
+	var warmed []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "/_all_docs") {
+			testutil.AssertEquals(t, r.URL.Query().Get("startkey"), `"_design/"`)
+			w.Write([]byte(`{"total_rows":2,"offset":0,"rows":[` +
+				`{"id":"_design/indexOwner","doc":{"_id":"_design/indexOwner","language":"query","views":{"byOwner":{},"byColor":{}}}},` +
+				`{"id":"_design/app/v1","doc":{"_id":"_design/app/v1","views":{"total":{}}}}]}`))
+			return
+		}
+		testutil.AssertEquals(t, r.URL.Query().Get("stale"), "update_after")
+		testutil.AssertEquals(t, r.URL.Query().Get("limit"), "0")
+		warmed = append(warmed, r.URL.EscapedPath())
+		w.Write([]byte(`{"total_rows":0,"offset":0,"rows":[]}`))
+	}))
+	defer server.Close()
+	conf, err := CreateConnectionDefinition(strings.TrimPrefix(server.URL, "http://"), username, password)
+	testutil.AssertNoError(t, err, "")
+	db := &CouchDatabase{couchInstance: CouchInstance{conf: *conf}, dbName: "testdb"}
+
+	views, err := db.ListViews()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, views, []ViewName{{DesignDoc: "indexOwner", View: "byColor"}, {DesignDoc: "indexOwner", View: "byOwner"},
+		{DesignDoc: "app/v1", View: "total"}})
+	for _, view := range views {
+		testutil.AssertNoError(t, db.WarmView(view.DesignDoc, view.View), "")
+	}
+	testutil.AssertEquals(t, warmed, []string{"/testdb/_design/indexOwner/_view/byColor", "/testdb/_design/indexOwner/_view/byOwner",
+		"/testdb/_design/app%2Fv1/_view/total"})
+}
+
+// newFakeCouchDB serves the root, membership and database endpoints of a CouchDB of the given version,
+// and the optional endpoints that are listed as supported
+func newFakeCouchDB(t *testing.T, version string, clusterNodes int, supported ...string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/":
+			w.Write([]byte(fmt.Sprintf(`{"couchdb":"Welcome","version":"%s","vendor":{"name":"The Apache Software Foundation"}}`, version)))
+		case r.URL.Path == "/_membership":
+			nodes, _ := json.Marshal(make([]string, clusterNodes))
+			w.Write([]byte(fmt.Sprintf(`{"all_nodes":%s,"cluster_nodes":%s}`, nodes, nodes)))
+		case r.URL.Path == "/testdb":
+			w.Write([]byte(`{"db_name":"testdb","update_seq":"1","doc_count":0}`))
+		default:
+			endpoint := strings.TrimPrefix(r.URL.Path, "/testdb/")
+			for _, s := range supported {
+				if s == endpoint {
+					testutil.AssertEquals(t, r.Method, http.MethodPost)
+					if endpoint == "_find" {
+						w.Write([]byte(`{"docs":[]}`))
+					} else {
+						w.Write([]byte(`{"results":[{"id":"fabric_feature_probe","docs":[{"error":{"id":"fabric_feature_probe","error":"not_found","reason":"missing"}}]}]}`))
+					}
+					return
+				}
+			}
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"not_found","reason":"Database does not exist."}`))
+		}
+	}))
+}
+
+func TestServerFeatureDetection(t *testing.T) {
+	server := newFakeCouchDB(t, "2.0.0", 3, "_find", "_bulk_get")
+	defer server.Close()
+	couchInstance, err := CreateCouchInstance(strings.TrimPrefix(server.URL, "http://"), username, password)
+	testutil.AssertNoError(t, err, "")
+	_, err = CreateCouchDatabase(*couchInstance, "testdb")
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, *couchInstance.Features(), ServerFeatures{Vendor: "The Apache Software Foundation", Version: "2.0.0",
+		Clustered: true, Mango: true, BulkGet: true})
+
+	// a server without the optional endpoints has the rich queries disabled and reads the documents one at a time
+	server = newFakeCouchDB(t, "2.0.0", 1)
+	defer server.Close()
+	couchInstance, err = CreateCouchInstance(strings.TrimPrefix(server.URL, "http://"), username, password)
+	testutil.AssertNoError(t, err, "")
+	db, err := CreateCouchDatabase(*couchInstance, "testdb")
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, *couchInstance.Features(), ServerFeatures{Vendor: "The Apache Software Foundation", Version: "2.0.0"})
+	_, err = db.QueryDocuments(`{"selector":{}}`, 1000, 0)
+	testutil.AssertError(t, err, "Rich queries should fail without _find")
+	testutil.AssertEquals(t, strings.Contains(err.Error(), "Rich queries require Apache CouchDB 2.0.0 or later"), true)
+	docs, err := db.ReadDocs([]string{"key1"})
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, docs, []*CouchDoc{nil})
+
+	// a server older than 2.0.0 is rejected with an actionable error
+	server = newFakeCouchDB(t, "1.6.1", 1)
+	defer server.Close()
+	_, err = CreateCouchInstance(strings.TrimPrefix(server.URL, "http://"), username, password)
+	testutil.AssertError(t, err, "CouchDB 1.6.1 should be rejected")
+	testutil.AssertEquals(t, strings.Contains(err.Error(), "goleveldb"), true)
+}
+
+func TestReadDocs(t *testing.T) {
+	// a fake CouchDB that serves _bulk_get, with a missing document and a document with an attachment
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/testdb/_bulk_get" {
+			request := struct {
+				Docs []struct {
+					ID string `json:"id"`
+				} `json:"docs"`
+			}{}
+			testutil.AssertNoError(t, json.NewDecoder(r.Body).Decode(&request), "")
+			testutil.AssertEquals(t, len(request.Docs), 3)
+			w.Write([]byte(`{"results":[` +
+				`{"id":"key1","docs":[{"ok":{"_id":"key1","_rev":"1-a","asset_name":"marble1"}}]},` +
+				`{"id":"key2","docs":[{"error":{"id":"key2","rev":"undefined","error":"not_found","reason":"missing"}}]},` +
+				`{"id":"key3","docs":[{"ok":{"_id":"key3","_rev":"1-c","_attachments":{"valueBytes":{"stub":true}}}}]}]}`))
+			return
+		}
+		testutil.AssertEquals(t, r.URL.Path, "/testdb/key3")
+		w.Header().Set("Etag", `"1-c"`)
+		w.Write([]byte(`{"_id":"key3","_rev":"1-c"}`))
+	}))
+	defer server.Close()
+	conf, err := CreateConnectionDefinition(strings.TrimPrefix(server.URL, "http://"), username, password)
+	testutil.AssertNoError(t, err, "")
+	db := &CouchDatabase{couchInstance: CouchInstance{conf: *conf}, dbName: "testdb"}
+
+	docs, err := db.ReadDocs([]string{"key1", "key2", "key3"})
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, len(docs), 3)
+	testutil.AssertEquals(t, string(docs[0].JSONValue), `{"_id":"key1","_rev":"1-a","asset_name":"marble1"}`)
+	testutil.AssertNil(t, docs[1])
+	testutil.AssertNotNil(t, docs[2])
+}
+
+func TestOpenCouchDatabase(t *testing.T) {
+	// a fake CouchDB that serves the database testdb and records the methods of the requests to the databases
+	var methods []string
+	fakeCouchDB := newFakeCouchDB(t, "2.0.0", 1, "_find", "_bulk_get")
+	defer fakeCouchDB.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missingdb" {
+			methods = append(methods, r.Method)
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"not_found","reason":"Database does not exist."}`))
+			return
+		}
+		if strings.HasPrefix(r.URL.Path, "/testdb") {
+			methods = append(methods, r.Method)
+		}
+		fakeCouchDB.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	couchInstance, err := CreateCouchInstance(strings.TrimPrefix(server.URL, "http://"), username, password)
+	testutil.AssertNoError(t, err, "")
+
+	_, err = OpenCouchDatabase(*couchInstance, "missingdb")
+	testutil.AssertError(t, err, "Opening a missing database should fail")
+	testutil.AssertEquals(t, methods, []string{http.MethodGet})
+
+	// the existing database is opened without any write
+	methods = nil
+	db, err := OpenCouchDatabase(*couchInstance, "testdb")
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, db.dbName, "testdb")
+	for _, method := range methods {
+		testutil.AssertEquals(t, method == http.MethodGet || method == http.MethodPost, true)
+	}
 }
@@ -18,6 +18,7 @@ package couchdb
 
 import (
 	"fmt"
+	"net/http"
 	"regexp"
 	"strconv"
 	"strings"
@@ -55,6 +56,8 @@ func CreateCouchInstance(couchDBConnectURL string, id string, pw string) (*Couch
 		return nil, errVersion
 	}
 
+	couchInstance.features = &serverFeatures{features: detectServerFeatures(couchInstance, connectInfo)}
+
 	return couchInstance, nil
 }
 
@@ -65,14 +68,42 @@ func checkCouchDBVersion(version string) error {
 	majorVersion := strings.Split(version, ".")
 
 	//check to see that the major version number is at least 2
-	majorVersionInt, _ := strconv.Atoi(majorVersion[0])
+	majorVersionInt, err := strconv.Atoi(majorVersion[0])
+	if err != nil {
+		return fmt.Errorf("Unable to parse the CouchDB version [%s] reported by the server, check that ledger.state.couchDBConfig.couchDBAddress refers to a CouchDB server", version)
+	}
 	if majorVersionInt < 2 {
-		return fmt.Errorf("CouchDB must be at least version 2.0.0.  Detected version %s", version)
+		return fmt.Errorf("CouchDB must be at least version 2.0.0.  Detected version %s. "+
+			"Upgrade CouchDB or set ledger.state.stateDatabase to goleveldb", version)
 	}
 
 	return nil
 }
 
+//detectServerFeatures derives the features of the server from its version, and checks whether the server
+//is a node of a cluster. The support of the optional endpoints is confirmed by CreateCouchDatabase
+func detectServerFeatures(couchInstance *CouchInstance, connectInfo *ConnectionInfo) ServerFeatures {
+
+	//Mango queries and _bulk_get have been part of CouchDB since 2.0.0, which checkCouchDBVersion requires
+	features := ServerFeatures{
+		Vendor:  connectInfo.Vendor.Name,
+		Version: connectInfo.Version,
+		Mango:   true,
+		BulkGet: true,
+	}
+
+	//the membership of the cluster may be restricted to the server admins, in which case the server is assumed to be a single node
+	clusterNodes, _, err := couchInstance.ReadClusterMembership()
+	if err != nil {
+		logger.Debugf("Unable to read the cluster membership of CouchDB: %s", err.Error())
+	} else {
+		features.Clustered = len(clusterNodes) > 1
+	}
+
+	logger.Infof("Connected to CouchDB %s version %s, clustered=%t", features.Vendor, features.Version, features.Clustered)
+	return features
+}
+
 //CreateCouchDatabase creates a CouchDB database object, as well as the underlying database if it does not exist
 func CreateCouchDatabase(couchInstance CouchInstance, dbName string) (*CouchDatabase, error) {
 
@@ -91,6 +122,44 @@ func CreateCouchDatabase(couchInstance CouchInstance, dbName string) (*CouchData
 		return nil, err
 	}
 
+	//confirm the support of the optional endpoints, so that the queries fail fast if they are not supported
+	err = couchDBDatabase.probeFeatures()
+	if err != nil {
+		logger.Errorf("Error during CouchDB feature detection for dbName: %s  error: %s\n", dbName, err.Error())
+		return nil, err
+	}
+
+	return &couchDBDatabase, nil
+}
+
+//OpenCouchDatabase creates a CouchDB database object for an existing database. Unlike CreateCouchDatabase,
+//nothing is written to CouchDB and an error is returned if the database does not exist
+func OpenCouchDatabase(couchInstance CouchInstance, dbName string) (*CouchDatabase, error) {
+
+	databaseName, err := mapAndValidateDatabaseName(dbName)
+	if err != nil {
+		logger.Errorf("Error during CouchDB OpenCouchDatabase() for dbName: %s  error: %s\n", dbName, err.Error())
+		return nil, err
+	}
+
+	couchDBDatabase := CouchDatabase{couchInstance: couchInstance, dbName: databaseName}
+
+	_, couchDBReturn, err := couchDBDatabase.GetDatabaseInfo()
+	if err != nil {
+		if couchDBReturn != nil && couchDBReturn.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("CouchDB database [%s] for dbName [%s] does not exist", databaseName, dbName)
+		}
+		logger.Errorf("Error during CouchDB OpenCouchDatabase() for dbName: %s  error: %s\n", dbName, err.Error())
+		return nil, err
+	}
+
+	//the probes of the optional endpoints only read from the database
+	err = couchDBDatabase.probeFeatures()
+	if err != nil {
+		logger.Errorf("Error during CouchDB feature detection for dbName: %s  error: %s\n", dbName, err.Error())
+		return nil, err
+	}
+
 	return &couchDBDatabase, nil
 }
 
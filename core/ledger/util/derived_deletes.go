@@ -0,0 +1,107 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"io"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/protos/common"
+)
+
+// DerivedDelete is the delete of a key that the peer derives while preparing the updates of a block, rather than
+// reads from the write-set of a transaction, i.e., the delete of a key in a range deleted by a transaction or of a
+// key that expires on the block. TxNum is the transaction number of the delete in the updates of the block, i.e.,
+// the position of the transaction in the block plus one, or the number of the transactions plus one for an expiry
+type DerivedDelete struct {
+	TxNum     uint64
+	Namespace string
+	Key       string
+}
+
+// IsExpiry tells whether the delete is the expiry of the key on a block with the given number of transactions
+func (d *DerivedDelete) IsExpiry(numTxs int) bool {
+	return d.TxNum > uint64(numTxs)
+}
+
+// SetDerivedDeletes records the derived deletes in the DERIVED_DELETES metadata of the block.
+// The metadata is cleared if there are no derived deletes, and not added to a block that does not have it
+func SetDerivedDeletes(block *common.Block, deletes []*DerivedDelete) error {
+	if len(deletes) == 0 && len(block.Metadata.Metadata) <= int(common.BlockMetadataIndex_DERIVED_DELETES) {
+		return nil
+	}
+	metadataBytes := []byte{}
+	if len(deletes) > 0 {
+		buffer := proto.NewBuffer(nil)
+		for _, d := range deletes {
+			if err := buffer.EncodeVarint(d.TxNum); err != nil {
+				return err
+			}
+			if err := buffer.EncodeStringBytes(d.Namespace); err != nil {
+				return err
+			}
+			if err := buffer.EncodeStringBytes(d.Key); err != nil {
+				return err
+			}
+		}
+		var err error
+		if metadataBytes, err = proto.Marshal(&common.Metadata{Value: buffer.Bytes()}); err != nil {
+			return err
+		}
+	}
+	for len(block.Metadata.Metadata) <= int(common.BlockMetadataIndex_DERIVED_DELETES) {
+		block.Metadata.Metadata = append(block.Metadata.Metadata, []byte{})
+	}
+	block.Metadata.Metadata[common.BlockMetadataIndex_DERIVED_DELETES] = metadataBytes
+	return nil
+}
+
+// GetDerivedDeletes returns the derived deletes recorded in the block metadata, in the order of their transaction numbers.
+// nil is returned for a block without derived deletes, as well as for a block committed before they were recorded
+func GetDerivedDeletes(block *common.Block) ([]*DerivedDelete, error) {
+	if block.Metadata == nil || len(block.Metadata.Metadata) <= int(common.BlockMetadataIndex_DERIVED_DELETES) {
+		return nil, nil
+	}
+	metadataBytes := block.Metadata.Metadata[common.BlockMetadataIndex_DERIVED_DELETES]
+	if len(metadataBytes) == 0 {
+		return nil, nil
+	}
+	metadata := &common.Metadata{}
+	if err := proto.Unmarshal(metadataBytes, metadata); err != nil {
+		return nil, err
+	}
+	var deletes []*DerivedDelete
+	buffer := proto.NewBuffer(metadata.Value)
+	for {
+		d := &DerivedDelete{}
+		var err error
+		d.TxNum, err = buffer.DecodeVarint()
+		if err == io.ErrUnexpectedEOF {
+			return deletes, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if d.Namespace, err = buffer.DecodeStringBytes(); err != nil {
+			return nil, err
+		}
+		if d.Key, err = buffer.DecodeStringBytes(); err != nil {
+			return nil, err
+		}
+		deletes = append(deletes, d)
+	}
+}
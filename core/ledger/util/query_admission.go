@@ -0,0 +1,100 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric/core/ledger"
+)
+
+// QueryAdmission limits the number of the queries of a kind, such as the rich queries of a channel, that run
+// concurrently. A query that finds the limit reached waits in a queue of a bounded size and is rejected with a
+// ledger.ResourceExhaustedError if the queue is full or if it is not admitted within the queue timeout.
+// The methods of a nil admission admit every query, so that a nil admission is used when the queries are not limited
+type QueryAdmission struct {
+	kind         string
+	slots        chan struct{}
+	queue        chan struct{}
+	queueTimeout time.Duration
+}
+
+// NewQueryAdmission constructs an admission for the queries of the given kind. A nil admission is returned
+// if maxConcurrent is not positive. A non-positive queueTimeout lets a queued query wait until it is admitted
+func NewQueryAdmission(kind string, maxConcurrent int, queueSize int, queueTimeout time.Duration) *QueryAdmission {
+	if maxConcurrent <= 0 {
+		return nil
+	}
+	if queueSize < 0 {
+		queueSize = 0
+	}
+	return &QueryAdmission{
+		kind:         kind,
+		slots:        make(chan struct{}, maxConcurrent),
+		queue:        make(chan struct{}, queueSize),
+		queueTimeout: queueTimeout,
+	}
+}
+
+// Admit admits a query, waiting in the queue if the limit is reached. The returned permit
+// is to be released when the query completes, that is, when its results iterator is closed
+func (a *QueryAdmission) Admit() (*QueryPermit, error) {
+	if a == nil {
+		return nil, nil
+	}
+	select {
+	case a.slots <- struct{}{}:
+		return &QueryPermit{admission: a}, nil
+	default:
+	}
+	select {
+	case a.queue <- struct{}{}:
+	default:
+		return nil, &ledger.ResourceExhaustedError{Msg: fmt.Sprintf(
+			"too many concurrent %s: limit of %d reached and %d queries waiting", a.kind, cap(a.slots), cap(a.queue))}
+	}
+	defer func() { <-a.queue }()
+	if a.queueTimeout <= 0 {
+		a.slots <- struct{}{}
+		return &QueryPermit{admission: a}, nil
+	}
+	timer := time.NewTimer(a.queueTimeout)
+	defer timer.Stop()
+	select {
+	case a.slots <- struct{}{}:
+		return &QueryPermit{admission: a}, nil
+	case <-timer.C:
+		return nil, &ledger.ResourceExhaustedError{Msg: fmt.Sprintf(
+			"too many concurrent %s: not admitted within %s", a.kind, a.queueTimeout)}
+	}
+}
+
+// QueryPermit is held by an admitted query. The methods of a nil permit do nothing
+type QueryPermit struct {
+	admission *QueryAdmission
+	once      sync.Once
+}
+
+// Release releases the permit so that a waiting query is admitted. Only the first call has an effect
+func (p *QueryPermit) Release() {
+	if p == nil {
+		return
+	}
+	p.once.Do(func() { <-p.admission.slots })
+}
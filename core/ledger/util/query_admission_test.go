@@ -0,0 +1,71 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryAdmission(t *testing.T) {
+	// the queries are not limited by a nil admission
+	admission := NewQueryAdmission("rich queries", 0, 10, time.Second)
+	assert.Nil(t, admission)
+	permit, err := admission.Admit()
+	assert.NoError(t, err)
+	permit.Release()
+
+	admission = NewQueryAdmission("rich queries", 1, 1, 50*time.Millisecond)
+	permit1, err := admission.Admit()
+	assert.NoError(t, err)
+
+	// a queued query is admitted once the running query releases its permit
+	admitted := make(chan *QueryPermit)
+	go func() {
+		permit, err := admission.Admit()
+		assert.NoError(t, err)
+		admitted <- permit
+	}()
+	time.Sleep(10 * time.Millisecond)
+	// the queue is full
+	_, err = admission.Admit()
+	assert.IsType(t, &ledger.ResourceExhaustedError{}, err)
+	permit1.Release()
+	// a second release has no effect
+	permit1.Release()
+	permit2 := <-admitted
+
+	// a queued query is rejected after the queue timeout
+	_, err = admission.Admit()
+	assert.IsType(t, &ledger.ResourceExhaustedError{}, err)
+	assert.Contains(t, err.Error(), "not admitted within")
+	permit2.Release()
+	permit3, err := admission.Admit()
+	assert.NoError(t, err)
+	permit3.Release()
+
+	// the queries are rejected right away without a queue
+	admission = NewQueryAdmission("history queries", 1, 0, time.Second)
+	permit4, err := admission.Admit()
+	assert.NoError(t, err)
+	_, err = admission.Admit()
+	assert.IsType(t, &ledger.ResourceExhaustedError{}, err)
+	permit4.Release()
+}
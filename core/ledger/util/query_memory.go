@@ -0,0 +1,79 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"sync/atomic"
+
+	"github.com/hyperledger/fabric/core/ledger"
+)
+
+// queryMemoryInUse is the number of the bytes materialized by the results of the queries in progress on the peer
+var queryMemoryInUse int64
+
+// QueryMemoryBudget accounts for the bytes of the keys and the values materialized by the results of a query against
+// the budget of the query and against the global budget shared by the queries in progress on the peer. The bytes of
+// a query count against the global budget until the query completes, that is, until its results iterator is closed.
+// The methods of a nil budget do nothing, so that a nil budget is used when the memory of the queries is not limited
+type QueryMemoryBudget struct {
+	kind        string
+	queryLimit  int64
+	globalLimit int64
+	used        int64
+}
+
+// StartQueryMemoryBudget starts the accounting of the memory of a query of the given kind. A non-positive limit does not
+// limit the memory and a nil budget is returned if neither of the limits is positive
+func StartQueryMemoryBudget(kind string, queryLimit int64, globalLimit int64) *QueryMemoryBudget {
+	if queryLimit <= 0 && globalLimit <= 0 {
+		return nil
+	}
+	return &QueryMemoryBudget{kind: kind, queryLimit: queryLimit, globalLimit: globalLimit}
+}
+
+// Charge accounts for the given number of the bytes materialized by a result of the query. A ledger.QueryMemoryExceededError
+// is returned, and the bytes are not accounted for, if either budget would be exceeded, in which case the query is aborted
+func (b *QueryMemoryBudget) Charge(numBytes int) error {
+	if b == nil {
+		return nil
+	}
+	n := int64(numBytes)
+	if b.queryLimit > 0 && b.used+n > b.queryLimit {
+		return &ledger.QueryMemoryExceededError{Query: b.kind, Bytes: b.used + n, Limit: b.queryLimit}
+	}
+	inUse := atomic.AddInt64(&queryMemoryInUse, n)
+	if b.globalLimit > 0 && inUse > b.globalLimit {
+		atomic.AddInt64(&queryMemoryInUse, -n)
+		return &ledger.QueryMemoryExceededError{Query: b.kind, Bytes: inUse, Limit: b.globalLimit, Global: true}
+	}
+	b.used += n
+	return nil
+}
+
+// Release returns the bytes of the query to the global budget. Only the first call has an effect
+func (b *QueryMemoryBudget) Release() {
+	if b == nil {
+		return
+	}
+	atomic.AddInt64(&queryMemoryInUse, -b.used)
+	b.used = 0
+}
+
+// QueryMemoryInUse returns the number of the bytes materialized by the results of the queries in progress on the peer
+func QueryMemoryInUse() int64 {
+	return atomic.LoadInt64(&queryMemoryInUse)
+}
@@ -0,0 +1,57 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryMemoryBudget(t *testing.T) {
+	// the memory is not limited by a nil budget
+	budget := StartQueryMemoryBudget("GetStateByRange", 0, 0)
+	assert.Nil(t, budget)
+	assert.NoError(t, budget.Charge(1000))
+	budget.Release()
+
+	budget1 := StartQueryMemoryBudget("GetStateByRange", 100, 150)
+	assert.NoError(t, budget1.Charge(60))
+	assert.NoError(t, budget1.Charge(40))
+	err := budget1.Charge(1)
+	assert.IsType(t, &ledger.QueryMemoryExceededError{}, err)
+	assert.False(t, err.(*ledger.QueryMemoryExceededError).Global)
+	assert.Equal(t, int64(100), QueryMemoryInUse())
+
+	// the bytes of the queries in progress count against the global budget
+	budget2 := StartQueryMemoryBudget("GetQueryResult", 100, 150)
+	assert.NoError(t, budget2.Charge(50))
+	err = budget2.Charge(1)
+	assert.IsType(t, &ledger.QueryMemoryExceededError{}, err)
+	assert.True(t, err.(*ledger.QueryMemoryExceededError).Global)
+	assert.Equal(t, ledger.GRPCCode(err), ledger.GRPCCode(&ledger.ResourceExhaustedError{}))
+	assert.Equal(t, int64(150), QueryMemoryInUse())
+
+	budget1.Release()
+	// a second release has no effect
+	budget1.Release()
+	assert.Equal(t, int64(50), QueryMemoryInUse())
+	assert.NoError(t, budget2.Charge(50))
+	budget2.Release()
+	assert.Equal(t, int64(0), QueryMemoryInUse())
+}
@@ -0,0 +1,76 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"time"
+
+	"github.com/hyperledger/fabric/common/flogging"
+)
+
+var slowQueryLogger = flogging.MustGetFieldLogger("slowquery")
+
+// SlowQueryTimer measures the duration of a query, from the start of the query until its results iterator is closed,
+// and logs the query with its duration and the number of results if the duration exceeds the threshold.
+// The methods of a nil timer do nothing, so that a nil timer is used when the slow query log is disabled
+type SlowQueryTimer struct {
+	kind        string
+	fields      flogging.Fields
+	threshold   time.Duration
+	start       time.Time
+	resultCount int
+	stopped     bool
+}
+
+// StartSlowQueryTimer starts a timer for a query of the given kind. The fields identify the query in the log
+// and should carry the hashes of the keys and of the query rather than the keys and the query themselves.
+// A nil timer is returned if the threshold is not positive
+func StartSlowQueryTimer(kind string, threshold time.Duration, fields flogging.Fields) *SlowQueryTimer {
+	if threshold <= 0 {
+		return nil
+	}
+	return &SlowQueryTimer{kind: kind, fields: fields, threshold: threshold, start: time.Now()}
+}
+
+// ResultReturned counts a result returned by the query
+func (t *SlowQueryTimer) ResultReturned() {
+	if t == nil {
+		return
+	}
+	t.resultCount++
+}
+
+// Stop stops the timer and logs the query if it took longer than the threshold. Only the first call has an effect
+func (t *SlowQueryTimer) Stop() {
+	if t == nil || t.stopped {
+		return
+	}
+	t.stopped = true
+	duration := time.Since(t.start)
+	if duration < t.threshold {
+		return
+	}
+	fields := flogging.Fields{
+		"query":      t.kind,
+		"durationMs": float64(duration.Nanoseconds()) / 1e6,
+		"results":    t.resultCount,
+	}
+	for k, v := range t.fields {
+		fields[k] = v
+	}
+	slowQueryLogger.With(fields).Warningf("Slow query took longer than %s", t.threshold)
+}
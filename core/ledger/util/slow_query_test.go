@@ -0,0 +1,62 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/op/go-logging"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlowQueryTimer(t *testing.T) {
+	logging.InitForTesting(flogging.DefaultLevel())
+	buf := &bytes.Buffer{}
+	flogging.SetLoggingFormat(flogging.JSONFormat, buf)
+	defer flogging.SetLoggingFormat("", os.Stderr)
+
+	// a timer is not started when the slow query log is disabled
+	timer := StartSlowQueryTimer("GetHistoryForKey", 0, nil)
+	assert.Nil(t, timer)
+	timer.ResultReturned()
+	timer.Stop()
+
+	timer = StartSlowQueryTimer("GetStateByRange", time.Nanosecond, flogging.Fields{"namespace": "ns", "startKeyHash": KeyHashForLog("key1")})
+	timer.ResultReturned()
+	timer.ResultReturned()
+	time.Sleep(time.Millisecond)
+	timer.Stop()
+	timer.Stop()
+	logged := buf.String()
+	assert.Equal(t, 1, strings.Count(logged, `"module":"slowquery"`))
+	assert.Contains(t, logged, `"query":"GetStateByRange"`)
+	assert.Contains(t, logged, `"results":2`)
+	assert.Contains(t, logged, `"namespace":"ns"`)
+	assert.Contains(t, logged, `"startKeyHash":"`+KeyHashForLog("key1")+`"`)
+	assert.NotContains(t, logged, "key1")
+
+	// a query that completes within the threshold is not logged
+	buf.Reset()
+	timer = StartSlowQueryTimer("GetQueryResult", time.Hour, flogging.Fields{"namespace": "ns"})
+	timer.Stop()
+	assert.NotContains(t, buf.String(), "slowquery")
+}
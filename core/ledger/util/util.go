@@ -17,10 +17,33 @@ limitations under the License.
 package util
 
 import (
+	"encoding/hex"
 	"reflect"
 	"sort"
+	"strings"
+
+	commonutil "github.com/hyperledger/fabric/common/util"
 )
 
+// hashedDataNsSeparator separates the namespace of a chaincode from the name of a collection in the namespace
+// of the state database that holds the hashes of the private data of the collection
+const hashedDataNsSeparator = "$$h"
+
+// sequenceNsSeparator suffixes the namespace of a chaincode to form the namespace of the state database
+// that holds the counters of the sequences of the chaincode
+const sequenceNsSeparator = "$$s"
+
+// valueHashNsSeparator suffixes the namespace of a chaincode to form the namespace of the state database
+// that holds the hashes of the values of the chaincode
+const valueHashNsSeparator = "$$v"
+
+// derivedNsMarker is contained in the namespaces of the state database that are derived from the namespace of a chaincode
+const derivedNsMarker = "$$"
+
+// ttlNsSeparator suffixes the namespace of a chaincode to form the namespace of the write-set that carries
+// the time-to-live of the keys written by a transaction
+const ttlNsSeparator = "$$t"
+
 // GetSortedKeys returns the keys of the map in a sorted order. This function assumes that the keys are string
 func GetSortedKeys(m interface{}) []string {
 	mapVal := reflect.ValueOf(m)
@@ -32,3 +55,71 @@ func GetSortedKeys(m interface{}) []string {
 	sort.Strings(keys)
 	return keys
 }
+
+// DeriveHashedDataNs returns the namespace of the state database that holds the hashes of the private keys
+// and values of the given collection of the given chaincode
+func DeriveHashedDataNs(namespace string, collection string) string {
+	return namespace + hashedDataNsSeparator + collection
+}
+
+// IsHashedDataNs returns true if the given namespace of the state database holds the hashes of private data
+func IsHashedDataNs(namespace string) bool {
+	return strings.Contains(namespace, hashedDataNsSeparator)
+}
+
+// DeriveSequenceNs returns the namespace of the state database that holds the counters of the sequences of the given chaincode
+func DeriveSequenceNs(namespace string) string {
+	return namespace + sequenceNsSeparator
+}
+
+// DeriveValueHashNs returns the namespace of the state database that holds the hashes of the values of the given chaincode
+func DeriveValueHashNs(namespace string) string {
+	return namespace + valueHashNsSeparator
+}
+
+// IsDerivedNs returns true if the given namespace of the state database is derived from the namespace of a chaincode,
+// such as the namespaces that hold the hashes of private data, the counters of sequences, or the hashes of values
+func IsDerivedNs(namespace string) bool {
+	return strings.Contains(namespace, derivedNsMarker)
+}
+
+// IsDerivedNsOf returns true if the given namespace of the state database is derived from the namespace of the given chaincode
+func IsDerivedNsOf(namespace string, chaincodeNs string) bool {
+	return strings.HasPrefix(namespace, chaincodeNs+derivedNsMarker)
+}
+
+// ComputeValueHash computes the hash of a value that is stored in the state database alongside the value
+func ComputeValueHash(value []byte) []byte {
+	return commonutil.ComputeSHA256(value)
+}
+
+// DeriveTTLNs returns the namespace of the write-set that carries the time-to-live of the keys of the given chaincode
+func DeriveTTLNs(namespace string) string {
+	return namespace + ttlNsSeparator
+}
+
+// SplitTTLNs returns the namespace of the chaincode and true if the given namespace of the write-set carries
+// the time-to-live of keys
+func SplitTTLNs(namespace string) (string, bool) {
+	if !strings.HasSuffix(namespace, ttlNsSeparator) {
+		return "", false
+	}
+	return strings.TrimSuffix(namespace, ttlNsSeparator), true
+}
+
+// ComputePvtDataHash computes the hash of a private key or value. The hash is computed using SHA256, so that
+// a peer that is not a member of the collection can verify a private value presented to it off-chain
+func ComputePvtDataHash(data []byte) []byte {
+	return commonutil.ComputeSHA256(data)
+}
+
+// DeriveHashedKey returns the key of the state database under which the hash of the value of the given private key is stored
+func DeriveHashedKey(key string) string {
+	return hex.EncodeToString(ComputePvtDataHash([]byte(key)))
+}
+
+// KeyHashForLog returns a short hash of the key for the logs, so that the keys, which may carry
+// sensitive data, are not logged in the clear while the log records of the same key can still be correlated
+func KeyHashForLog(key string) string {
+	return hex.EncodeToString(commonutil.ComputeSHA256([]byte(key))[:8])
+}
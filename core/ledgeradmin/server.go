@@ -0,0 +1,256 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ledgeradmin
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/cauthdsl"
+	"github.com/hyperledger/fabric/common/policies"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/ledgermgmt"
+	"github.com/hyperledger/fabric/msp"
+	"github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric/protos/utils"
+	"github.com/op/go-logging"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+var logger = logging.MustGetLogger("ledgeradmin")
+
+// maxRequestSkew is the maximum difference between the timestamp of a request and the time of the peer.
+// The tx ids of the accepted requests are remembered for as long as their timestamp is within this skew
+const maxRequestSkew = 15 * time.Minute
+
+// Server implements the ledger admin service of the peer. The requests are authorized against
+// a policy that is satisfied by the signature of an admin of the local MSP of the peer
+type Server struct {
+	policy policies.Policy
+	// acceptedTxIDs maps the tx ids of the accepted requests to the time after which the timestamp of
+	// the request is out of the skew and hence the request cannot be replayed anymore
+	acceptedTxIDs map[string]time.Time
+	lock          sync.Mutex
+}
+
+// NewServer constructs a Server that authorizes the requests against the given policy
+func NewServer(policy policies.Policy) *Server {
+	return &Server{policy: policy, acceptedTxIDs: make(map[string]time.Time)}
+}
+
+// NewLocalMSPAdminsPolicy returns a policy that is satisfied by the signature of an admin of the given local MSP
+func NewLocalMSPAdminsPolicy(localMSP msp.MSP) (policies.Policy, error) {
+	mspID, err := localMSP.GetIdentifier()
+	if err != nil {
+		return nil, err
+	}
+	policyBytes, err := proto.Marshal(cauthdsl.SignedByMspAdmin(mspID))
+	if err != nil {
+		return nil, err
+	}
+	return cauthdsl.NewPolicyProvider(localMSP).NewPolicy(policyBytes)
+}
+
+// Prune implements the corresponding method from interface pb.LedgerAdminServer
+func (s *Server) Prune(ctx context.Context, env *common.Envelope) (*pb.LedgerAdminResponse, error) {
+	request, err := s.validateRequest(env, "prune")
+	if err != nil {
+		return nil, err
+	}
+	if err := ledgermgmt.PruneLedger(request.ChannelId); err != nil {
+		return nil, grpc.Errorf(ledger.GRPCCode(err), "Failed to prune the ledger [%s]: %s", request.ChannelId, err)
+	}
+	return &pb.LedgerAdminResponse{ChannelId: request.ChannelId}, nil
+}
+
+// Reindex implements the corresponding method from interface pb.LedgerAdminServer
+func (s *Server) Reindex(ctx context.Context, env *common.Envelope) (*pb.LedgerAdminResponse, error) {
+	request, err := s.validateRequest(env, "reindex")
+	if err != nil {
+		return nil, err
+	}
+	if !request.BlockIndex && !request.Databases {
+		return nil, grpc.Errorf(codes.InvalidArgument, "Reindex of the ledger [%s] selects neither the block index nor the databases", request.ChannelId)
+	}
+	schedule := &ledger.MaintenanceSchedule{RebuildBlockIndex: request.BlockIndex, RebuildDBs: request.Databases}
+	if err := ledgermgmt.ScheduleMaintenance(request.ChannelId, schedule); err != nil {
+		return nil, grpc.Errorf(ledger.GRPCCode(err), "Failed to schedule the reindex of the ledger [%s]: %s", request.ChannelId, err)
+	}
+	return &pb.LedgerAdminResponse{ChannelId: request.ChannelId, Scheduled: true}, nil
+}
+
+// Snapshot implements the corresponding method from interface pb.LedgerAdminServer
+func (s *Server) Snapshot(ctx context.Context, env *common.Envelope) (*pb.LedgerAdminResponse, error) {
+	request, err := s.validateRequest(env, "snapshot")
+	if err != nil {
+		return nil, err
+	}
+	startTime := time.Now()
+	dir, blockNum, err := ledgermgmt.GenerateSnapshot(request.ChannelId)
+	if err != nil {
+		return nil, grpc.Errorf(ledger.GRPCCode(err), "Failed to snapshot the ledger [%s]: %s", request.ChannelId, err)
+	}
+	return &pb.LedgerAdminResponse{ChannelId: request.ChannelId, Directory: dir, BlockNumber: blockNum,
+		DurationMillis: uint64(time.Since(startTime) / time.Millisecond)}, nil
+}
+
+// Rollback implements the corresponding method from interface pb.LedgerAdminServer
+func (s *Server) Rollback(ctx context.Context, env *common.Envelope) (*pb.LedgerAdminResponse, error) {
+	request, err := s.validateRequest(env, "rollback")
+	if err != nil {
+		return nil, err
+	}
+	if request.Height == 0 {
+		return nil, grpc.Errorf(codes.InvalidArgument, "Rollback of the ledger [%s] requires a height above 0", request.ChannelId)
+	}
+	if err := ledgermgmt.ScheduleMaintenance(request.ChannelId, &ledger.MaintenanceSchedule{RollbackHeight: request.Height}); err != nil {
+		return nil, grpc.Errorf(ledger.GRPCCode(err), "Failed to schedule the rollback of the ledger [%s]: %s", request.ChannelId, err)
+	}
+	return &pb.LedgerAdminResponse{ChannelId: request.ChannelId, Scheduled: true}, nil
+}
+
+// Compact implements the corresponding method from interface pb.LedgerAdminServer
+func (s *Server) Compact(ctx context.Context, env *common.Envelope) (*pb.LedgerAdminResponse, error) {
+	request, err := s.validateRequest(env, "compact")
+	if err != nil {
+		return nil, err
+	}
+	startTime := time.Now()
+	if err := ledgermgmt.CompactLedger(request.ChannelId); err != nil {
+		return nil, grpc.Errorf(ledger.GRPCCode(err), "Failed to compact the ledger [%s]: %s", request.ChannelId, err)
+	}
+	return &pb.LedgerAdminResponse{ChannelId: request.ChannelId, DurationMillis: uint64(time.Since(startTime) / time.Millisecond)}, nil
+}
+
+// GetHealth implements the corresponding method from interface pb.LedgerAdminServer
+func (s *Server) GetHealth(ctx context.Context, env *common.Envelope) (*pb.LedgerHealthResponse, error) {
+	if _, err := s.authorize(env, "health"); err != nil {
+		return nil, err
+	}
+	ledgerInfos, err := ledgermgmt.ListLedgers()
+	if err != nil {
+		return nil, grpc.Errorf(ledger.GRPCCode(err), "Failed to list the ledgers: %s", err)
+	}
+	response := &pb.LedgerHealthResponse{
+		MaintenanceMode: ledgermgmt.IsInMaintenanceMode(),
+		CommitsQuiesced: ledgermgmt.AreCommitsQuiesced(),
+	}
+	for _, info := range ledgerInfos {
+		health := &pb.LedgerHealth{ChannelId: info.LedgerID, Height: info.Height, Status: info.Status.String()}
+		metadata, err := ledgermgmt.GetLedgerMetadata(info.LedgerID)
+		if err != nil {
+			return nil, grpc.Errorf(ledger.GRPCCode(err), "Failed to get the metadata of the ledger [%s]: %s", info.LedgerID, err)
+		}
+		if pending := metadata.PendingMaintenance; pending != nil {
+			health.RebuildBlockIndex = pending.RebuildBlockIndex
+			health.RollbackHeight = pending.RollbackHeight
+			health.RebuildDatabases = pending.RebuildDBs
+		}
+		response.Ledgers = append(response.Ledgers, health)
+	}
+	return response, nil
+}
+
+// validateRequest authorizes the envelope and checks that the request names a channel
+func (s *Server) validateRequest(env *common.Envelope, operation string) (*pb.LedgerAdminRequest, error) {
+	request, err := s.authorize(env, operation)
+	if err != nil {
+		return nil, err
+	}
+	if request.ChannelId == "" {
+		return nil, grpc.Errorf(codes.InvalidArgument, "Missing channel in the %s request", operation)
+	}
+	logger.Infof("Channel [%s]: Executing ledger admin operation [%s]", request.ChannelId, operation)
+	return request, nil
+}
+
+// authorize checks that the envelope is recent, is signed by an admin of the local MSP, is not a replay of
+// an accepted request, and carries a request for the given operation
+func (s *Server) authorize(env *common.Envelope, operation string) (*pb.LedgerAdminRequest, error) {
+	payload, err := utils.UnmarshalPayload(env.Payload)
+	if err != nil {
+		return nil, grpc.Errorf(codes.InvalidArgument, "Malformed %s request: %s", operation, err)
+	}
+	if payload.Header == nil {
+		return nil, grpc.Errorf(codes.InvalidArgument, "Missing header in the %s request", operation)
+	}
+	chdr, err := utils.UnmarshalChannelHeader(payload.Header.ChannelHeader)
+	if err != nil {
+		return nil, grpc.Errorf(codes.InvalidArgument, "Malformed %s request: %s", operation, err)
+	}
+	if err := checkTimestamp(chdr); err != nil {
+		return nil, grpc.Errorf(codes.PermissionDenied, "Rejecting %s request: %s", operation, err)
+	}
+	if chdr.TxId == "" {
+		return nil, grpc.Errorf(codes.InvalidArgument, "Missing tx id in the %s request", operation)
+	}
+	signedData, err := env.AsSignedData()
+	if err != nil {
+		return nil, grpc.Errorf(codes.InvalidArgument, "Malformed %s request: %s", operation, err)
+	}
+	if err := s.policy.Evaluate(signedData); err != nil {
+		logger.Warningf("Rejecting ledger admin operation [%s] that is not signed by an admin of the local MSP: %s", operation, err)
+		return nil, grpc.Errorf(codes.PermissionDenied, "The %s request is not authorized", operation)
+	}
+	request := &pb.LedgerAdminRequest{}
+	if err := proto.Unmarshal(payload.Data, request); err != nil {
+		return nil, grpc.Errorf(codes.InvalidArgument, "Malformed %s request: %s", operation, err)
+	}
+	if request.Operation != operation {
+		logger.Warningf("Rejecting ledger admin operation [%s] for a request signed for operation [%s]", operation, request.Operation)
+		return nil, grpc.Errorf(codes.PermissionDenied, "The request is signed for operation [%s] rather than [%s]", request.Operation, operation)
+	}
+	if err := s.acceptTxID(chdr); err != nil {
+		logger.Warningf("Rejecting ledger admin operation [%s]: %s", operation, err)
+		return nil, grpc.Errorf(codes.PermissionDenied, "Rejecting %s request: %s", operation, err)
+	}
+	return request, nil
+}
+
+// acceptTxID records the tx id of the request unless a request with the same tx id was accepted before.
+// The tx ids whose timestamp is out of the skew are dropped since checkTimestamp rejects their replays
+func (s *Server) acceptTxID(chdr *common.ChannelHeader) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	now := time.Now()
+	for txID, expiry := range s.acceptedTxIDs {
+		if now.After(expiry) {
+			delete(s.acceptedTxIDs, txID)
+		}
+	}
+	if _, ok := s.acceptedTxIDs[chdr.TxId]; ok {
+		return fmt.Errorf("tx id [%s] is a replay of an accepted request", chdr.TxId)
+	}
+	s.acceptedTxIDs[chdr.TxId] = time.Unix(chdr.Timestamp.Seconds, int64(chdr.Timestamp.Nanos)).Add(maxRequestSkew)
+	return nil
+}
+
+func checkTimestamp(chdr *common.ChannelHeader) error {
+	if chdr.Timestamp == nil {
+		return fmt.Errorf("missing timestamp")
+	}
+	skew := time.Since(time.Unix(chdr.Timestamp.Seconds, int64(chdr.Timestamp.Nanos)))
+	if skew > maxRequestSkew || skew < -maxRequestSkew {
+		return fmt.Errorf("timestamp is %s away from the time of the peer", skew)
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ledgeradmin
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric/common/crypto"
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/common/localmsp"
+	mockcrypto "github.com/hyperledger/fabric/common/mocks/crypto"
+	mockpolicies "github.com/hyperledger/fabric/common/mocks/policies"
+	"github.com/hyperledger/fabric/common/policies"
+	"github.com/hyperledger/fabric/common/util"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/ledgermgmt"
+	mspmgmt "github.com/hyperledger/fabric/msp/mgmt"
+	"github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric/protos/utils"
+	"github.com/spf13/viper"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+func TestMain(m *testing.M) {
+	viper.Set("peer.fileSystemPath", "/tmp/fabric/ledgeradmintests")
+	viper.Set("ledger.state.historyDatabase", true)
+	mspDir := os.Getenv("GOPATH") + "/src/github.com/hyperledger/fabric/msp/sampleconfig/"
+	if err := mspmgmt.LoadLocalMsp(mspDir, nil, "DEFAULT"); err != nil {
+		fmt.Printf("Failed to load the local MSP: %s\n", err)
+		os.Exit(-1)
+	}
+	os.Exit(m.Run())
+}
+
+func TestLedgerAdminOperations(t *testing.T) {
+	ledgermgmt.InitializeTestEnv()
+	defer ledgermgmt.CleanupTestEnv()
+	l, _ := ledgermgmt.CreateLedger("testchannel")
+	commitTestBlocks(t, l)
+
+	client, grpcServer := startServer(t, &mockpolicies.Policy{})
+	defer grpcServer.Stop()
+
+	_, err := client.Compact(context.Background(), signedRequest(t, &pb.LedgerAdminRequest{Operation: "compact", ChannelId: "testchannel"}))
+	testutil.AssertNoError(t, err, "")
+
+	response, err := client.Snapshot(context.Background(), signedRequest(t, &pb.LedgerAdminRequest{Operation: "snapshot", ChannelId: "testchannel"}))
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, response.BlockNumber, uint64(1))
+	_, err = os.Stat(response.Directory)
+	testutil.AssertNoError(t, err, "")
+
+	_, err = client.Reindex(context.Background(), signedRequest(t, &pb.LedgerAdminRequest{Operation: "reindex", ChannelId: "testchannel"}))
+	testutil.AssertEquals(t, grpc.Code(err), codes.InvalidArgument)
+	response, err = client.Reindex(context.Background(), signedRequest(t, &pb.LedgerAdminRequest{Operation: "reindex", ChannelId: "testchannel", Databases: true}))
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, response.Scheduled, true)
+
+	_, err = client.Rollback(context.Background(), signedRequest(t, &pb.LedgerAdminRequest{Operation: "rollback", ChannelId: "testchannel"}))
+	testutil.AssertEquals(t, grpc.Code(err), codes.InvalidArgument)
+	response, err = client.Rollback(context.Background(), signedRequest(t, &pb.LedgerAdminRequest{Operation: "rollback", ChannelId: "testchannel", Height: 1}))
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, response.Scheduled, true)
+
+	health, err := client.GetHealth(context.Background(), signedRequest(t, &pb.LedgerAdminRequest{Operation: "health"}))
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, health.MaintenanceMode, false)
+	testutil.AssertEquals(t, health.Ledgers, []*pb.LedgerHealth{
+		{ChannelId: "testchannel", Height: 2, Status: "ACTIVE", RollbackHeight: 1, RebuildDatabases: true}})
+
+	// pruning is not implemented by the ledger
+	_, err = client.Prune(context.Background(), signedRequest(t, &pb.LedgerAdminRequest{Operation: "prune", ChannelId: "testchannel"}))
+	testutil.AssertEquals(t, grpc.Code(err), codes.Unimplemented)
+	_, err = client.Compact(context.Background(), signedRequest(t, &pb.LedgerAdminRequest{Operation: "compact", ChannelId: "unknownchannel"}))
+	testutil.AssertEquals(t, grpc.Code(err), codes.NotFound)
+	_, err = client.Compact(context.Background(), signedRequest(t, &pb.LedgerAdminRequest{Operation: "compact"}))
+	testutil.AssertEquals(t, grpc.Code(err), codes.InvalidArgument)
+}
+
+func TestUnauthorizedRequests(t *testing.T) {
+	ledgermgmt.InitializeTestEnv()
+	defer ledgermgmt.CleanupTestEnv()
+	ledgermgmt.CreateLedger("testchannel")
+
+	client, grpcServer := startServer(t, &mockpolicies.Policy{Err: fmt.Errorf("Unauthorized")})
+	defer grpcServer.Stop()
+	_, err := client.Rollback(context.Background(), signedRequest(t, &pb.LedgerAdminRequest{Operation: "rollback", ChannelId: "testchannel", Height: 1}))
+	testutil.AssertEquals(t, grpc.Code(err), codes.PermissionDenied)
+	_, err = client.GetHealth(context.Background(), signedRequest(t, &pb.LedgerAdminRequest{Operation: "health"}))
+	testutil.AssertEquals(t, grpc.Code(err), codes.PermissionDenied)
+
+	client, grpcServer = startServer(t, &mockpolicies.Policy{})
+	defer grpcServer.Stop()
+	env := signedRequestAt(t, mockcrypto.FakeLocalSigner, &pb.LedgerAdminRequest{Operation: "compact", ChannelId: "testchannel"}, time.Now().Add(-time.Hour))
+	_, err = client.Compact(context.Background(), env)
+	testutil.AssertEquals(t, grpc.Code(err), codes.PermissionDenied)
+	metadata, _ := ledgermgmt.GetLedgerMetadata("testchannel")
+	testutil.AssertNil(t, metadata.PendingMaintenance)
+}
+
+func TestReplayedRequests(t *testing.T) {
+	ledgermgmt.InitializeTestEnv()
+	defer ledgermgmt.CleanupTestEnv()
+	l, _ := ledgermgmt.CreateLedger("testchannel")
+	commitTestBlocks(t, l)
+
+	client, grpcServer := startServer(t, &mockpolicies.Policy{})
+	defer grpcServer.Stop()
+
+	// a request signed for an operation cannot be used to call another operation
+	env := signedRequest(t, &pb.LedgerAdminRequest{Operation: "health", ChannelId: "testchannel", Height: 1})
+	_, err := client.Rollback(context.Background(), env)
+	testutil.AssertEquals(t, grpc.Code(err), codes.PermissionDenied)
+	_, err = client.Rollback(context.Background(), signedRequest(t, &pb.LedgerAdminRequest{ChannelId: "testchannel", Height: 1}))
+	testutil.AssertEquals(t, grpc.Code(err), codes.PermissionDenied)
+	metadata, _ := ledgermgmt.GetLedgerMetadata("testchannel")
+	testutil.AssertNil(t, metadata.PendingMaintenance)
+
+	// an accepted request cannot be replayed
+	env = signedRequest(t, &pb.LedgerAdminRequest{Operation: "compact", ChannelId: "testchannel"})
+	_, err = client.Compact(context.Background(), env)
+	testutil.AssertNoError(t, err, "")
+	_, err = client.Compact(context.Background(), env)
+	testutil.AssertEquals(t, grpc.Code(err), codes.PermissionDenied)
+
+	// a rejected request does not consume its tx id
+	env = signedRequest(t, &pb.LedgerAdminRequest{Operation: "compact", ChannelId: "testchannel"})
+	_, err = client.GetHealth(context.Background(), env)
+	testutil.AssertEquals(t, grpc.Code(err), codes.PermissionDenied)
+	_, err = client.Compact(context.Background(), env)
+	testutil.AssertNoError(t, err, "")
+}
+
+func TestLocalMSPAdminsPolicy(t *testing.T) {
+	policy, err := NewLocalMSPAdminsPolicy(mspmgmt.GetLocalMSP())
+	testutil.AssertNoError(t, err, "")
+	// the signer of the sample MSP is an admin of the MSP
+	env := signedRequestAt(t, localmsp.NewSigner(), &pb.LedgerAdminRequest{}, time.Now())
+	signedData, _ := env.AsSignedData()
+	testutil.AssertNoError(t, policy.Evaluate(signedData), "")
+	env = signedRequestAt(t, mockcrypto.FakeLocalSigner, &pb.LedgerAdminRequest{}, time.Now())
+	signedData, _ = env.AsSignedData()
+	testutil.AssertError(t, policy.Evaluate(signedData), "Expected an error for a signer outside of the local MSP")
+}
+
+// commitTestBlocks commits two blocks that set a key of namespace ns
+func commitTestBlocks(t *testing.T, l ledger.PeerLedger) {
+	bg := testutil.NewBlockGenerator(t)
+	for i := 0; i < 2; i++ {
+		simulator, _ := l.NewTxSimulator()
+		simulator.SetState("ns", "key", []byte(fmt.Sprintf("value%d", i)))
+		simulator.Done()
+		simRes, _ := simulator.GetTxSimulationResults()
+		testutil.AssertNoError(t, l.Commit(bg.NextBlock([][]byte{simRes}, false)), "")
+	}
+}
+
+// startServer serves the ledger admin service that authorizes the requests with the given policy
+func startServer(t *testing.T, policy policies.Policy) (pb.LedgerAdminClient, *grpc.Server) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	testutil.AssertNoError(t, err, "")
+	grpcServer := grpc.NewServer()
+	pb.RegisterLedgerAdminServer(grpcServer, NewServer(policy))
+	go grpcServer.Serve(lis)
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure(), grpc.WithBlock(), grpc.WithTimeout(3*time.Second))
+	testutil.AssertNoError(t, err, "")
+	return pb.NewLedgerAdminClient(conn), grpcServer
+}
+
+func signedRequest(t *testing.T, request proto.Message) *common.Envelope {
+	return signedRequestAt(t, mockcrypto.FakeLocalSigner, request, time.Now())
+}
+
+// signedRequestAt signs an envelope that carries the given request and is timestamped with the given time
+func signedRequestAt(t *testing.T, signer crypto.LocalSigner, request proto.Message, ts time.Time) *common.Envelope {
+	chdr := utils.MakeChannelHeader(common.HeaderType_MESSAGE, 0, "", 0)
+	chdr.Timestamp = &timestamp.Timestamp{Seconds: ts.Unix()}
+	chdr.TxId = util.GenerateUUID()
+	shdr, err := signer.NewSignatureHeader()
+	testutil.AssertNoError(t, err, "")
+	data, err := proto.Marshal(request)
+	testutil.AssertNoError(t, err, "")
+	payload := utils.MarshalOrPanic(&common.Payload{Header: utils.MakePayloadHeader(chdr, shdr), Data: data})
+	sig, err := signer.Sign(payload)
+	testutil.AssertNoError(t, err, "")
+	return &common.Envelope{Payload: payload, Signature: sig}
+}
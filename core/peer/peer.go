@@ -33,6 +33,7 @@ import (
 	"github.com/hyperledger/fabric/core/committer/txvalidator"
 	"github.com/hyperledger/fabric/core/ledger"
 	"github.com/hyperledger/fabric/core/ledger/ledgermgmt"
+	"github.com/hyperledger/fabric/core/transientstore"
 	"github.com/hyperledger/fabric/gossip/service"
 	mspmgmt "github.com/hyperledger/fabric/msp/mgmt"
 	"github.com/hyperledger/fabric/protos/common"
@@ -85,19 +86,23 @@ func Initialize(init func(string)) {
 	chainInitializer = init
 
 	var cb *common.Block
-	var ledger ledger.PeerLedger
 	ledgermgmt.Initialize()
 	ledgerIds, err := ledgermgmt.GetLedgerIDs()
 	if err != nil {
 		panic(fmt.Errorf("Error in initializing ledgermgmt: %s", err))
 	}
+	// The ledgers are opened, and recovered, in parallel. A ledger that fails to open
+	// is skipped and the peer continues with the rest of the chains
+	ledgers, err := ledgermgmt.OpenLedgers(ledgerIds)
+	if err != nil {
+		peerLogger.Warningf("Failed to load ledgers: %s", err)
+	}
 	for _, cid := range ledgerIds {
-		peerLogger.Infof("Loading chain %s", cid)
-		if ledger, err = ledgermgmt.OpenLedger(cid); err != nil {
-			peerLogger.Warningf("Failed to load ledger %s(%s)", cid, err)
-			peerLogger.Debugf("Error while loading ledger %s with message %s. We continue to the next ledger rather than abort.", cid, err)
+		ledger, ok := ledgers[cid]
+		if !ok {
 			continue
 		}
+		peerLogger.Infof("Loading chain %s", cid)
 		if cb, err = getCurrConfigBlockFromLedger(ledger); err != nil {
 			peerLogger.Warningf("Failed to find config block on ledger %s(%s)", cid, err)
 			peerLogger.Debugf("Error while looking for config block on ledger %s with message %s. We continue to the next ledger rather than abort.", cid, err)
@@ -133,6 +138,11 @@ func getCurrConfigBlockFromLedger(ledger ledger.PeerLedger) (*common.Block, erro
 	var currBlockNumber uint64 = math.MaxUint64
 	for currBlockNumber >= 0 {
 		if block, err = ledger.GetBlockByNumber(currBlockNumber); err != nil {
+			// the blocks included in the snapshot from which the ledger was created are not
+			// available and the last config block of the snapshot is the current config block
+			if snapshotConfigBlock, snapshotErr := ledger.GetSnapshotConfigBlock(); snapshotErr == nil && snapshotConfigBlock != nil {
+				return snapshotConfigBlock, nil
+			}
 			return nil, err
 		}
 		if block.Data != nil && len(block.Data.Data) == 1 {
@@ -211,22 +221,46 @@ func createChain(cid string, ledger ledger.PeerLedger, cb *common.Block) error {
 	return nil
 }
 
-// CreateChainFromBlock creates a new chain from config block
-func CreateChainFromBlock(cb *common.Block) error {
+// CreateChainFromBlock creates a new chain from config block. The ledger of the chain records the given
+// bootstrap method, e.g., ledger.BootstrapMethodJoin, along with the orderer endpoints listed in the block
+func CreateChainFromBlock(cb *common.Block, bootstrapMethod string) error {
 	cid, err := utils.GetChainIDFromBlock(cb)
 	if err != nil {
 		return err
 	}
 	var ledger ledger.PeerLedger
-	if ledger, err = createLedger(cid); err != nil {
+	if ledger = GetLedger(cid); ledger != nil {
+		if err := ledger.Commit(cb); err != nil {
+			peerLogger.Errorf("Unable to get genesis block committed into the ledger, chainID %v", cid)
+			return err
+		}
+	} else if ledger, err = ledgermgmt.CreateLedgerFromGenesisBlock(cb, bootstrapMethod); err != nil {
+		peerLogger.Errorf("Unable to create the ledger from the genesis block, chainID %v: %s", cid, err)
 		return err
 	}
+	return createChain(cid, ledger, cb)
+}
 
-	if err := ledger.Commit(cb); err != nil {
-		peerLogger.Errorf("Unable to get genesis block committed into the ledger, chainID %v", cid)
-		return err
+// CreateChainFromSnapshot creates a new chain from the snapshot in the given directory.
+// The chain starts with the config block included in the snapshot and continues with the
+// blocks next to the last block of the snapshot
+func CreateChainFromSnapshot(snapshotDir string) (string, error) {
+	ledger, err := ledgermgmt.CreateLedgerFromSnapshot(snapshotDir)
+	if err != nil {
+		return "", err
 	}
-	return createChain(cid, ledger, cb)
+	cb, err := ledger.GetSnapshotConfigBlock()
+	if err != nil {
+		return "", err
+	}
+	cid, err := utils.GetChainIDFromBlock(cb)
+	if err != nil {
+		return "", err
+	}
+	if err := createChain(cid, ledger, cb); err != nil {
+		return "", err
+	}
+	return cid, nil
 }
 
 // MockCreateChain used for creating a ledger for a chain for tests
@@ -269,6 +303,40 @@ func GetLedger(cid string) ledger.PeerLedger {
 	return nil
 }
 
+// transientStoreProvider provides the transient stores of the chains. It is nil if not set by the peer
+var transientStoreProvider *transientstore.Provider
+
+// SetTransientStoreProvider sets the provider of the transient stores of the chains
+func SetTransientStoreProvider(provider *transientstore.Provider) {
+	transientStoreProvider = provider
+}
+
+// GetTransientStore returns the transient store of the chain with chain ID. Note that this
+// call returns nil if the transient store provider is not set
+func GetTransientStore(cid string) *transientstore.Store {
+	if transientStoreProvider == nil {
+		return nil
+	}
+	return transientStoreProvider.OpenStore(cid)
+}
+
+// historyAccessChecker restricts the reads of the history of the keys. It is nil if not set by the peer
+var historyAccessChecker ledger.HistoryAccessChecker
+
+// SetHistoryAccessChecker sets the checker consulted by the queries of the history of a key
+func SetHistoryAccessChecker(checker ledger.HistoryAccessChecker) {
+	historyAccessChecker = checker
+}
+
+// CheckHistoryAccess returns an error if the creator may not read the history of the given key of the given
+// namespace of the chain with chain ID. Note that the reads are not restricted if the checker is not set
+func CheckHistoryAccess(creator []byte, cid string, namespace string, key string) error {
+	if historyAccessChecker == nil {
+		return nil
+	}
+	return historyAccessChecker.CheckHistoryAccess(creator, cid, namespace, key)
+}
+
 // GetPolicyManager returns the policy manager of the chain with chain ID. Note that this
 // call returns nil if chain cid has not been created.
 func GetPolicyManager(cid string) policies.Manager {
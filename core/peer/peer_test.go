@@ -27,6 +27,7 @@ import (
 	ccp "github.com/hyperledger/fabric/core/common/ccprovider"
 	"github.com/hyperledger/fabric/core/deliverservice"
 	"github.com/hyperledger/fabric/core/deliverservice/blocksprovider"
+	"github.com/hyperledger/fabric/core/ledger"
 	"github.com/hyperledger/fabric/core/mocks/ccprovider"
 	"github.com/hyperledger/fabric/gossip/service"
 	"github.com/hyperledger/fabric/msp/mgmt"
@@ -91,7 +92,7 @@ func TestCreateChainFromBlock(t *testing.T) {
 	messageCryptoService := mcs.New(&mockpolicies.PolicyManagerMgmt{})
 	service.InitGossipServiceCustomDeliveryFactory(identity, "localhost:13611", grpcServer, &mockDeliveryClientFactory{}, messageCryptoService)
 
-	err = CreateChainFromBlock(block)
+	err = CreateChainFromBlock(block, ledger.BootstrapMethodJoin)
 	if err != nil {
 		t.Fatalf("failed to create chain %s", err)
 	}
@@ -141,3 +142,23 @@ func TestGetLocalIP(t *testing.T) {
 	ip := GetLocalIP()
 	t.Log(ip)
 }
+
+type mockHistoryAccessChecker struct {
+	restrictedKey string
+}
+
+func (c *mockHistoryAccessChecker) CheckHistoryAccess(creator []byte, channelID string, namespace string, key string) error {
+	if key == c.restrictedKey {
+		return fmt.Errorf("Restricted key [%s]", key)
+	}
+	return nil
+}
+
+func TestCheckHistoryAccess(t *testing.T) {
+	defer SetHistoryAccessChecker(nil)
+	assert.NoError(t, CheckHistoryAccess([]byte("creator"), "testchainid", "ns", "key1"))
+
+	SetHistoryAccessChecker(&mockHistoryAccessChecker{restrictedKey: "key1"})
+	assert.Error(t, CheckHistoryAccess([]byte("creator"), "testchainid", "ns", "key1"))
+	assert.NoError(t, CheckHistoryAccess([]byte("creator"), "testchainid", "ns", "key2"))
+}
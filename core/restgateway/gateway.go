@@ -0,0 +1,307 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restgateway
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/msp"
+	"github.com/op/go-logging"
+)
+
+var logger = logging.MustGetLogger("restgateway")
+
+// LedgerGetter returns the ledger of the given channel or nil if the peer has not joined the channel
+type LedgerGetter func(channelID string) ledger.PeerLedger
+
+// MSPManagerGetter returns the MSP manager of the given channel or nil if the peer has not joined the channel
+type MSPManagerGetter func(channelID string) msp.MSPManager
+
+// HistoryAccessCheck returns an error if the creator, a serialized identity, may not read the history of the given key
+type HistoryAccessCheck func(creator []byte, channelID string, namespace string, key string) error
+
+// Gateway serves the read-only queries of the ledgers over HTTP:
+//
+//	GET /channels/{channel}/keys/{namespace}/{key}          the committed value of the key
+//	GET /channels/{channel}/keys/{namespace}/{key}/history  the history of the key
+//	GET /channels/{channel}/blocks/{number}                 the block with the given number
+//
+// The path segments are URL escaped, so that keys containing a slash can be queried.
+// The gateway is served over TLS with client authentication, and a request is authorized
+// for a channel when the client certificate is a valid identity of one of the MSPs of the channel.
+// The query of the history of a key is further subject to the history access check
+type Gateway struct {
+	ledgerGetter       LedgerGetter
+	mspManagerGetter   MSPManagerGetter
+	historyAccessCheck HistoryAccessCheck
+}
+
+// NewGateway constructs a Gateway. The history of the keys is readable by all the members of the channel if historyAccessCheck is nil
+func NewGateway(ledgerGetter LedgerGetter, mspManagerGetter MSPManagerGetter, historyAccessCheck HistoryAccessCheck) *Gateway {
+	return &Gateway{ledgerGetter, mspManagerGetter, historyAccessCheck}
+}
+
+// StateResponse is the response to the query of the value of a key. Value is base64 encoded
+type StateResponse struct {
+	Namespace string `json:"namespace"`
+	Key       string `json:"key"`
+	Value     []byte `json:"value"`
+}
+
+// KeyModificationResponse is an entry of the response to the query of the history of a key.
+// Value is base64 encoded, and empty when the transaction deleted the key
+type KeyModificationResponse struct {
+	TxID     string `json:"txId"`
+	Value    []byte `json:"value"`
+	IsDelete bool   `json:"isDelete"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// ServeHTTP implements http.Handler
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "only GET is supported")
+		return
+	}
+	segments, err := splitPath(r.URL.EscapedPath())
+	if err != nil || len(segments) < 4 || segments[0] != "channels" {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("unknown path %s", r.URL.Path))
+		return
+	}
+	channelID := segments[1]
+	l, creator, err := g.authorize(r, channelID)
+	if err != nil {
+		writeError(w, err.(*httpError).status, err.Error())
+		return
+	}
+
+	switch {
+	case segments[2] == "keys" && len(segments) == 5:
+		g.getState(w, l, segments[3], segments[4])
+	case segments[2] == "keys" && len(segments) == 6 && segments[5] == "history":
+		g.getHistory(w, l, creator, channelID, segments[3], segments[4])
+	case segments[2] == "blocks" && len(segments) == 4:
+		g.getBlock(w, l, segments[3])
+	default:
+		writeError(w, http.StatusNotFound, fmt.Sprintf("unknown path %s", r.URL.Path))
+	}
+}
+
+type httpError struct {
+	status int
+	msg    string
+}
+
+func (e *httpError) Error() string {
+	return e.msg
+}
+
+// authorize checks that the client certificate is a valid identity of one of the MSPs
+// of the channel, and returns the ledger of the channel along with the serialized identity of the client
+func (g *Gateway) authorize(r *http.Request, channelID string) (ledger.PeerLedger, []byte, error) {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return nil, nil, &httpError{http.StatusUnauthorized, "a client certificate is required"}
+	}
+	l := g.ledgerGetter(channelID)
+	mspManager := g.mspManagerGetter(channelID)
+	if l == nil || mspManager == nil {
+		return nil, nil, &httpError{http.StatusNotFound, fmt.Sprintf("channel [%s] not found", channelID)}
+	}
+	creator, err := validateMember(mspManager, r.TLS.PeerCertificates[0])
+	if err != nil {
+		logger.Warningf("Channel [%s]: Rejecting query of %s: %s", channelID, r.TLS.PeerCertificates[0].Subject.CommonName, err)
+		return nil, nil, &httpError{http.StatusForbidden, fmt.Sprintf("request is not authorized for channel [%s]", channelID)}
+	}
+	return l, creator, nil
+}
+
+// validateMember returns the serialized identity of the certificate for the MSP that it is a valid identity of,
+// or an error if the certificate is not a valid identity of any of the MSPs
+func validateMember(mspManager msp.MSPManager, cert *x509.Certificate) ([]byte, error) {
+	msps, err := mspManager.GetMSPs()
+	if err != nil {
+		return nil, err
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	for mspID, m := range msps {
+		serializedID, err := proto.Marshal(&msp.SerializedIdentity{Mspid: mspID, IdBytes: certPEM})
+		if err != nil {
+			return nil, err
+		}
+		id, err := m.DeserializeIdentity(serializedID)
+		if err != nil {
+			continue
+		}
+		if m.Validate(id) == nil {
+			return serializedID, nil
+		}
+	}
+	return nil, fmt.Errorf("the certificate is not valid for any MSP of the channel")
+}
+
+func (g *Gateway) getState(w http.ResponseWriter, l ledger.PeerLedger, namespace, key string) {
+	qe, err := l.NewQueryExecutor()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer qe.Done()
+	value, err := qe.GetState(namespace, key)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if value == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("key [%s] not found in namespace [%s]", key, namespace))
+		return
+	}
+	writeJSON(w, &StateResponse{Namespace: namespace, Key: key, Value: value})
+}
+
+func (g *Gateway) getHistory(w http.ResponseWriter, l ledger.PeerLedger, creator []byte, channelID, namespace, key string) {
+	if g.historyAccessCheck != nil {
+		if err := g.historyAccessCheck(creator, channelID, namespace, key); err != nil {
+			logger.Warningf("Channel [%s]: Rejecting query of the history of key [%s] of namespace [%s]: %s", channelID, key, namespace, err)
+			writeError(w, http.StatusForbidden, fmt.Sprintf("request is not authorized to read the history of key [%s] of namespace [%s]", key, namespace))
+			return
+		}
+	}
+	hqe, err := l.NewHistoryQueryExecutor()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	itr, err := hqe.GetHistoryForKey(namespace, key)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer itr.Close()
+	modifications := []*KeyModificationResponse{}
+	for {
+		result, err := itr.Next()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if result == nil {
+			break
+		}
+		modification := result.(*ledger.KeyModification)
+		modifications = append(modifications, &KeyModificationResponse{
+			TxID:     modification.TxID,
+			Value:    modification.Value,
+			IsDelete: len(modification.Value) == 0,
+		})
+	}
+	writeJSON(w, modifications)
+}
+
+func (g *Gateway) getBlock(w http.ResponseWriter, l ledger.PeerLedger, number string) {
+	blockNum, err := strconv.ParseUint(number, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid block number [%s]", number))
+		return
+	}
+	info, err := l.GetBlockchainInfo()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if blockNum >= info.Height {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("block [%d] not found", blockNum))
+		return
+	}
+	block, err := l.GetBlockByNumber(blockNum)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := (&jsonpb.Marshaler{}).Marshal(w, block); err != nil {
+		logger.Errorf("Error writing block [%d]: %s", blockNum, err)
+	}
+}
+
+// splitPath splits the escaped path into its unescaped segments
+func splitPath(escapedPath string) ([]string, error) {
+	segments := strings.Split(strings.Trim(escapedPath, "/"), "/")
+	for i, s := range segments {
+		// a plus sign in a path is not a space
+		unescaped, err := url.QueryUnescape(strings.Replace(s, "+", "%2B", -1))
+		if err != nil {
+			return nil, err
+		}
+		segments[i] = unescaped
+	}
+	return segments, nil
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logger.Errorf("Error writing response: %s", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(&errorResponse{msg})
+}
+
+// NewTLSConfig returns the TLS configuration of the gateway, which requires the clients
+// to present a certificate issued by one of the given root CAs
+func NewTLSConfig(certFile, keyFile string, clientRootCAFiles []string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("error loading the TLS key pair: %s", err)
+	}
+	if len(clientRootCAFiles) == 0 {
+		return nil, fmt.Errorf("at least one client root CA is required")
+	}
+	clientCAs := x509.NewCertPool()
+	for _, file := range clientRootCAFiles {
+		caPEM, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("error reading client root CA %s: %s", file, err)
+		}
+		if !clientCAs.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no certificate found in client root CA %s", file)
+		}
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+	}, nil
+}
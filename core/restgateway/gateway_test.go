@@ -0,0 +1,291 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restgateway
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/ledgermgmt"
+	"github.com/hyperledger/fabric/msp"
+	mspprotos "github.com/hyperledger/fabric/protos/msp"
+	"github.com/spf13/viper"
+)
+
+func TestMain(m *testing.M) {
+	viper.Set("peer.fileSystemPath", "/tmp/fabric/restgatewaytests")
+	viper.Set("ledger.state.historyDatabase", true)
+	os.Exit(m.Run())
+}
+
+// testCA issues the certificates of the members of the MSP of the test channel
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	testutil.AssertNoError(t, err, "")
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		SubjectKeyId:          []byte{1, 2, 3, 4},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	testutil.AssertNoError(t, err, "")
+	cert, err := x509.ParseCertificate(der)
+	testutil.AssertNoError(t, err, "")
+	return &testCA{cert, key}
+}
+
+// issue returns a certificate and its key for the given common name. The certificate is
+// self-signed when ca is nil
+func (ca *testCA) issue(t *testing.T, commonName string) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	testutil.AssertNoError(t, err, "")
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	parent, parentKey := template, key
+	if ca != nil {
+		parent, parentKey = ca.cert, ca.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	testutil.AssertNoError(t, err, "")
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// newMSPManager returns the MSP manager of a channel with a single MSP whose root is the CA
+func newMSPManager(t *testing.T, ca *testCA) msp.MSPManager {
+	admin := ca.issue(t, "admin")
+	conf, err := proto.Marshal(&mspprotos.FabricMSPConfig{
+		Name:      "TestMSP",
+		RootCerts: [][]byte{certPEM(ca.cert.Raw)},
+		Admins:    [][]byte{certPEM(admin.Certificate[0])},
+	})
+	testutil.AssertNoError(t, err, "")
+	m, err := msp.NewBccspMsp()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertNoError(t, m.Setup(&mspprotos.MSPConfig{Config: conf, Type: int32(msp.FABRIC)}), "")
+	mgr := msp.NewMSPManager()
+	testutil.AssertNoError(t, mgr.Setup([]msp.MSP{m}), "")
+	return mgr
+}
+
+func certPEM(der []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestQueries(t *testing.T) {
+	ledgermgmt.InitializeTestEnv()
+	defer ledgermgmt.CleanupTestEnv()
+	l, _ := ledgermgmt.CreateLedger("testchannel")
+	commitTestBlocks(t, l)
+
+	ca := newTestCA(t)
+	server := startServer(l, newMSPManager(t, ca))
+	defer server.Close()
+	client := newClient(ca.issue(t, "member"))
+
+	state := &StateResponse{}
+	testutil.AssertEquals(t, get(t, client, server.URL+"/channels/testchannel/keys/ns/key1", state), http.StatusOK)
+	testutil.AssertEquals(t, *state, StateResponse{Namespace: "ns", Key: "key1", Value: []byte("value1")})
+	testutil.AssertEquals(t, get(t, client, server.URL+"/channels/testchannel/keys/ns/a%2Fb", state), http.StatusOK)
+	testutil.AssertEquals(t, state.Value, []byte("slash"))
+	testutil.AssertEquals(t, get(t, client, server.URL+"/channels/testchannel/keys/ns/missingkey", nil), http.StatusNotFound)
+
+	var history []*KeyModificationResponse
+	testutil.AssertEquals(t, get(t, client, server.URL+"/channels/testchannel/keys/ns/key1/history", &history), http.StatusOK)
+	testutil.AssertEquals(t, len(history), 2)
+	testutil.AssertEquals(t, history[0].Value, []byte("value0"))
+	testutil.AssertEquals(t, history[1].Value, []byte("value1"))
+	testutil.AssertEquals(t, history[1].IsDelete, false)
+
+	block := make(map[string]interface{})
+	testutil.AssertEquals(t, get(t, client, server.URL+"/channels/testchannel/blocks/1", &block), http.StatusOK)
+	testutil.AssertEquals(t, block["header"].(map[string]interface{})["number"], "1")
+	testutil.AssertEquals(t, get(t, client, server.URL+"/channels/testchannel/blocks/5", nil), http.StatusNotFound)
+	testutil.AssertEquals(t, get(t, client, server.URL+"/channels/testchannel/blocks/x", nil), http.StatusBadRequest)
+
+	testutil.AssertEquals(t, get(t, client, server.URL+"/channels/missingchannel/keys/ns/key1", nil), http.StatusNotFound)
+	testutil.AssertEquals(t, get(t, client, server.URL+"/channels/testchannel/unknown/path", nil), http.StatusNotFound)
+	resp, err := client.Post(server.URL+"/channels/testchannel/keys/ns/key1", "application/json", nil)
+	testutil.AssertNoError(t, err, "")
+	resp.Body.Close()
+	testutil.AssertEquals(t, resp.StatusCode, http.StatusMethodNotAllowed)
+}
+
+func TestUnauthorizedClient(t *testing.T) {
+	ledgermgmt.InitializeTestEnv()
+	defer ledgermgmt.CleanupTestEnv()
+	l, _ := ledgermgmt.CreateLedger("testchannel")
+	commitTestBlocks(t, l)
+
+	ca := newTestCA(t)
+	server := startServer(l, newMSPManager(t, ca))
+	defer server.Close()
+
+	// a certificate that is not issued by the CA of the MSP of the channel
+	client := newClient(newTestCA(t).issue(t, "outsider"))
+	testutil.AssertEquals(t, get(t, client, server.URL+"/channels/testchannel/keys/ns/key1", nil), http.StatusForbidden)
+	client = newClient((*testCA)(nil).issue(t, "selfsigned"))
+	testutil.AssertEquals(t, get(t, client, server.URL+"/channels/testchannel/keys/ns/key1", nil), http.StatusForbidden)
+
+	// a client without certificate
+	client = &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+	testutil.AssertEquals(t, get(t, client, server.URL+"/channels/testchannel/keys/ns/key1", nil), http.StatusUnauthorized)
+}
+
+func TestHistoryAccessCheck(t *testing.T) {
+	ledgermgmt.InitializeTestEnv()
+	defer ledgermgmt.CleanupTestEnv()
+	l, _ := ledgermgmt.CreateLedger("testchannel")
+	commitTestBlocks(t, l)
+
+	ca := newTestCA(t)
+	member := ca.issue(t, "member")
+	var checkedCreator []byte
+	server := startServerWithHistoryAccessCheck(l, newMSPManager(t, ca),
+		func(creator []byte, channelID string, namespace string, key string) error {
+			checkedCreator = creator
+			if channelID == "testchannel" && namespace == "ns" && key == "key1" {
+				return fmt.Errorf("history of key [%s] is restricted", key)
+			}
+			return nil
+		})
+	defer server.Close()
+	client := newClient(member)
+
+	// the history of key1 is restricted, but not its committed value
+	testutil.AssertEquals(t, get(t, client, server.URL+"/channels/testchannel/keys/ns/key1/history", nil), http.StatusForbidden)
+	expectedCreator, err := proto.Marshal(&msp.SerializedIdentity{Mspid: "TestMSP", IdBytes: certPEM(member.Certificate[0])})
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, checkedCreator, expectedCreator)
+	testutil.AssertEquals(t, get(t, client, server.URL+"/channels/testchannel/keys/ns/key1", nil), http.StatusOK)
+
+	var history []*KeyModificationResponse
+	testutil.AssertEquals(t, get(t, client, server.URL+"/channels/testchannel/keys/ns/a%2Fb/history", &history), http.StatusOK)
+	testutil.AssertEquals(t, len(history), 1)
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "restgateway")
+	testutil.AssertNoError(t, err, "")
+	defer os.RemoveAll(dir)
+	ca := newTestCA(t)
+	server := ca.issue(t, "server")
+	keyDER, err := x509.MarshalECPrivateKey(server.PrivateKey.(*ecdsa.PrivateKey))
+	testutil.AssertNoError(t, err, "")
+	certFile, keyFile, caFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"), filepath.Join(dir, "ca.pem")
+	testutil.AssertNoError(t, ioutil.WriteFile(certFile, certPEM(server.Certificate[0]), 0600), "")
+	testutil.AssertNoError(t, ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600), "")
+	testutil.AssertNoError(t, ioutil.WriteFile(caFile, certPEM(ca.cert.Raw), 0600), "")
+
+	config, err := NewTLSConfig(certFile, keyFile, []string{caFile})
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, config.ClientAuth, tls.RequireAndVerifyClientCert)
+
+	_, err = NewTLSConfig(certFile, keyFile, nil)
+	testutil.AssertError(t, err, "Expected an error without client root CAs")
+	_, err = NewTLSConfig(certFile, keyFile, []string{keyFile})
+	testutil.AssertError(t, err, "Expected an error for a client root CA file without certificate")
+}
+
+func commitTestBlocks(t *testing.T, l ledger.PeerLedger) {
+	bg := testutil.NewBlockGenerator(t)
+	for _, kvs := range []map[string]string{{"key1": "value0", "a/b": "slash"}, {"key1": "value1"}} {
+		simulator, _ := l.NewTxSimulator()
+		for k, v := range kvs {
+			simulator.SetState("ns", k, []byte(v))
+		}
+		simulator.Done()
+		simRes, _ := simulator.GetTxSimulationResults()
+		testutil.AssertNoError(t, l.Commit(bg.NextBlock([][]byte{simRes}, false)), "")
+	}
+}
+
+// startServer serves the ledger as channel "testchannel", whose MSPs are managed by the given manager
+func startServer(l ledger.PeerLedger, mspManager msp.MSPManager) *httptest.Server {
+	return startServerWithHistoryAccessCheck(l, mspManager, nil)
+}
+
+// startServerWithHistoryAccessCheck serves the ledger as startServer does and checks the queries of the history with the given check
+func startServerWithHistoryAccessCheck(l ledger.PeerLedger, mspManager msp.MSPManager, historyAccessCheck HistoryAccessCheck) *httptest.Server {
+	gateway := NewGateway(
+		func(channelID string) ledger.PeerLedger {
+			if channelID == "testchannel" {
+				return l
+			}
+			return nil
+		},
+		func(channelID string) msp.MSPManager {
+			if channelID == "testchannel" {
+				return mspManager
+			}
+			return nil
+		},
+		historyAccessCheck)
+	server := httptest.NewUnstartedServer(gateway)
+	server.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	server.StartTLS()
+	return server
+}
+
+func newClient(cert tls.Certificate) *http.Client {
+	return &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+		Certificates:       []tls.Certificate{cert},
+		InsecureSkipVerify: true,
+	}}}
+}
+
+// get sends a GET request, decodes the response into v when the request succeeds, and returns the status code
+func get(t *testing.T, client *http.Client, url string, v interface{}) int {
+	resp, err := client.Get(url)
+	testutil.AssertNoError(t, err, "")
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK && v != nil {
+		testutil.AssertNoError(t, json.NewDecoder(resp.Body).Decode(v), "")
+	}
+	return resp.StatusCode
+}
@@ -23,10 +23,19 @@ package cscc
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
 
 	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/ledger/util"
+	"github.com/hyperledger/fabric/common/localmsp"
 	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
 	"github.com/hyperledger/fabric/core/peer"
+	"github.com/hyperledger/fabric/core/snapshot"
 	pb "github.com/hyperledger/fabric/protos/peer"
 	"github.com/hyperledger/fabric/protos/utils"
 	"github.com/op/go-logging"
@@ -42,10 +51,11 @@ var cnflogger = logging.MustGetLogger("chaincode")
 
 // These are function names from Invoke first parameter
 const (
-	JoinChain         string = "JoinChain"
-	UpdateConfigBlock string = "UpdateConfigBlock"
-	GetConfigBlock    string = "GetConfigBlock"
-	GetChannels       string = "GetChannels"
+	JoinChain           string = "JoinChain"
+	JoinChainBySnapshot string = "JoinChainBySnapshot"
+	UpdateConfigBlock   string = "UpdateConfigBlock"
+	GetConfigBlock      string = "GetConfigBlock"
+	GetChannels         string = "GetChannels"
 )
 
 // Init is called once per chain when the chain is created.
@@ -65,6 +75,9 @@ func (e *PeerConfiger) Init(stub shim.ChaincodeStubInterface) pb.Response {
 // UpdateConfigBlock
 // # args[1] is a configuration Block if args[0] is JoinChain or
 // UpdateConfigBlock; otherwise it is the chain id
+// JoinChainBySnapshot takes the minimum height of the snapshot, the endpoint of the
+// peer that serves the snapshot, and the endpoints of the peers that verify the snapshot
+// as the further arguments
 // TODO: Improve the scc interface to avoid marshal/unmarshal args
 func (e *PeerConfiger) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
 	args := stub.GetArgs()
@@ -85,6 +98,8 @@ func (e *PeerConfiger) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
 
 	if fname == JoinChain {
 		return joinChain(args[1])
+	} else if fname == JoinChainBySnapshot {
+		return joinChainBySnapshot(args[1:])
 	} else if fname == GetConfigBlock {
 		return getConfigBlock(args[1])
 	} else if fname == UpdateConfigBlock {
@@ -109,7 +124,7 @@ func joinChain(blockBytes []byte) pb.Response {
 		return shim.Error(fmt.Sprintf("Failed to reconstruct the genesis block, %s", err))
 	}
 
-	if err = peer.CreateChainFromBlock(block); err != nil {
+	if err = peer.CreateChainFromBlock(block, ledger.BootstrapMethodJoin); err != nil {
 		return shim.Error(err.Error())
 	}
 
@@ -123,6 +138,46 @@ func joinChain(blockBytes []byte) pb.Response {
 	return shim.Success(nil)
 }
 
+// joinChainBySnapshot joins the chain by bootstrapping the ledger from a snapshot fetched from
+// another peer of the chain. The snapshot is verified against the commit hashes reported by the
+// verifying peers and the peer then catches up with the blocks next to the snapshot
+func joinChainBySnapshot(args [][]byte) pb.Response {
+	if len(args) < 3 {
+		return shim.Error(fmt.Sprintf("Incorrect number of arguments, %d", len(args)+1))
+	}
+	chainID := string(args[0])
+	minHeight, err := strconv.ParseUint(string(args[1]), 10, 64)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to parse the minimum height of the snapshot, %s", err))
+	}
+	source := string(args[2])
+	var verifiers []string
+	for _, arg := range args[3:] {
+		verifiers = append(verifiers, string(arg))
+	}
+
+	if _, err := util.CreateDirIfMissing(ledgerconfig.GetSnapshotsPath()); err != nil {
+		return shim.Error(err.Error())
+	}
+	tempDir, err := ioutil.TempDir(ledgerconfig.GetSnapshotsPath(), chainID+"-")
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	defer os.RemoveAll(tempDir)
+	snapshotDir := filepath.Join(tempDir, "snapshot")
+	client := snapshot.NewClient(chainID, localmsp.NewSigner(), peer.NewPeerClientConnectionWithAddress)
+	if _, err := client.Transfer(source, verifiers, minHeight, snapshotDir); err != nil {
+		return shim.Error(fmt.Sprintf("Failed to transfer the snapshot of chain %s, %s", chainID, err))
+	}
+	if _, err := peer.CreateChainFromSnapshot(snapshotDir); err != nil {
+		return shim.Error(err.Error())
+	}
+
+	peer.InitChain(chainID)
+
+	return shim.Success(nil)
+}
+
 func updateConfigBlock(blockBytes []byte) pb.Response {
 	if blockBytes == nil {
 		return shim.Error("Configuration block must not be nil.")
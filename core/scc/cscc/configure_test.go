@@ -124,6 +124,27 @@ func TestConfigerInvokeJoinChainWrongParams(t *testing.T) {
 	}
 }
 
+func TestConfigerInvokeJoinChainBySnapshotWrongParams(t *testing.T) {
+	viper.Set("peer.fileSystemPath", "/tmp/hyperledgertest/")
+	os.Mkdir("/tmp/hyperledgertest", 0755)
+	defer os.RemoveAll("/tmp/hyperledgertest/")
+
+	e := new(PeerConfiger)
+	stub := shim.NewMockStub("PeerConfiger", e)
+
+	// Failed path: Not enough parameters
+	args := [][]byte{[]byte("JoinChainBySnapshot"), []byte("mytestchainid"), []byte("10")}
+	if res := stub.MockInvoke("1", args); res.Status == shim.OK {
+		t.Fatalf("cscc invoke JoinChainBySnapshot should have failed with invalid number of args: %v", args)
+	}
+
+	// Failed path: height is not a number
+	args = [][]byte{[]byte("JoinChainBySnapshot"), []byte("mytestchainid"), []byte("height"), []byte("peer0:7051")}
+	if res := stub.MockInvoke("1", args); res.Status == shim.OK {
+		t.Fatalf("cscc invoke JoinChainBySnapshot should have failed with invalid height: %v", args)
+	}
+}
+
 func TestConfigerInvokeJoinChainCorrectParams(t *testing.T) {
 	viper.Set("peer.fileSystemPath", "/tmp/hyperledgertest/")
 	os.Mkdir("/tmp/hyperledgertest", 0755)
@@ -25,6 +25,7 @@ import (
 	"github.com/hyperledger/fabric/common/cauthdsl"
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 	"github.com/hyperledger/fabric/core/common/ccprovider"
+	"github.com/hyperledger/fabric/core/common/privdata"
 	"github.com/hyperledger/fabric/core/common/sysccprovider"
 	pb "github.com/hyperledger/fabric/protos/peer"
 	"github.com/hyperledger/fabric/protos/utils"
@@ -373,7 +374,7 @@ func (lccc *LifeCycleSysCC) executeInstall(stub shim.ChaincodeStubInterface, dep
 }
 
 //this implements "deploy" Invoke transaction
-func (lccc *LifeCycleSysCC) executeDeploy(stub shim.ChaincodeStubInterface, chainname string, depSpec []byte, policy []byte, escc []byte, vscc []byte) error {
+func (lccc *LifeCycleSysCC) executeDeploy(stub shim.ChaincodeStubInterface, chainname string, depSpec []byte, policy []byte, escc []byte, vscc []byte, collectionConfig []byte) error {
 	cds, err := utils.GetChaincodeDeploymentSpec(depSpec)
 
 	if err != nil {
@@ -398,8 +399,20 @@ func (lccc *LifeCycleSysCC) executeDeploy(stub shim.ChaincodeStubInterface, chai
 	}
 
 	_, err = lccc.createChaincode(stub, chainname, cds.ChaincodeSpec.ChaincodeId.Name, cds.ChaincodeSpec.ChaincodeId.Version, depSpec, policy, escc, vscc)
+	if err != nil {
+		return err
+	}
 
-	return err
+	return lccc.putCollectionConfig(stub, cds.ChaincodeSpec.ChaincodeId.Name, collectionConfig)
+}
+
+// putCollectionConfig stores the collection config package of the chaincode, if supplied.
+// The ledger records the package against the block that commits it
+func (lccc *LifeCycleSysCC) putCollectionConfig(stub shim.ChaincodeStubInterface, ccname string, collectionConfig []byte) error {
+	if collectionConfig == nil {
+		return nil
+	}
+	return stub.PutState(privdata.BuildCollectionKVSKey(ccname), collectionConfig)
 }
 
 func (lccc *LifeCycleSysCC) getUpgradeVersion(cd *ccprovider.ChaincodeData, cds *pb.ChaincodeDeploymentSpec) (string, error) {
@@ -427,7 +440,7 @@ func (lccc *LifeCycleSysCC) getUpgradeVersion(cd *ccprovider.ChaincodeData, cds
 }
 
 //this implements "upgrade" Invoke transaction
-func (lccc *LifeCycleSysCC) executeUpgrade(stub shim.ChaincodeStubInterface, chainName string, depSpec []byte, policy []byte, escc []byte, vscc []byte) ([]byte, error) {
+func (lccc *LifeCycleSysCC) executeUpgrade(stub shim.ChaincodeStubInterface, chainName string, depSpec []byte, policy []byte, escc []byte, vscc []byte, collectionConfig []byte) ([]byte, error) {
 	cds, err := utils.GetChaincodeDeploymentSpec(depSpec)
 	if err != nil {
 		return nil, err
@@ -458,6 +471,10 @@ func (lccc *LifeCycleSysCC) executeUpgrade(stub shim.ChaincodeStubInterface, cha
 		return nil, err
 	}
 
+	if err = lccc.putCollectionConfig(stub, chaincodeName, collectionConfig); err != nil {
+		return nil, err
+	}
+
 	return []byte(newCD.Version), nil
 }
 
@@ -496,7 +513,7 @@ func (lccc *LifeCycleSysCC) Invoke(stub shim.ChaincodeStubInterface) pb.Response
 		}
 		return shim.Success([]byte("OK"))
 	case DEPLOY:
-		if len(args) < 3 || len(args) > 6 {
+		if len(args) < 3 || len(args) > 7 {
 			return shim.Error(InvalidArgsLenErr(len(args)).Error())
 		}
 
@@ -514,6 +531,7 @@ func (lccc *LifeCycleSysCC) Invoke(stub shim.ChaincodeStubInterface) pb.Response
 		// args[3] is a marshalled SignaturePolicyEnvelope representing the endorsement policy
 		// args[4] is the name of escc
 		// args[5] is the name of vscc
+		// args[6] is the marshalled collection config package
 		var policy []byte
 		if len(args) > 3 && args[3] != nil {
 			policy = args[3]
@@ -536,13 +554,18 @@ func (lccc *LifeCycleSysCC) Invoke(stub shim.ChaincodeStubInterface) pb.Response
 			vscc = []byte("vscc")
 		}
 
-		err := lccc.executeDeploy(stub, chainname, depSpec, policy, escc, vscc)
+		var collectionConfig []byte
+		if len(args) > 6 && args[6] != nil {
+			collectionConfig = args[6]
+		}
+
+		err := lccc.executeDeploy(stub, chainname, depSpec, policy, escc, vscc, collectionConfig)
 		if err != nil {
 			return shim.Error(err.Error())
 		}
 		return shim.Success(nil)
 	case UPGRADE:
-		if len(args) < 3 || len(args) > 6 {
+		if len(args) < 3 || len(args) > 7 {
 			return shim.Error(InvalidArgsLenErr(len(args)).Error())
 		}
 
@@ -557,6 +580,7 @@ func (lccc *LifeCycleSysCC) Invoke(stub shim.ChaincodeStubInterface) pb.Response
 		// args[3] is a marshalled SignaturePolicyEnvelope representing the endorsement policy
 		// args[4] is the name of escc
 		// args[5] is the name of vscc
+		// args[6] is the marshalled collection config package
 		var policy []byte
 		if len(args) > 3 && args[3] != nil {
 			policy = args[3]
@@ -579,7 +603,12 @@ func (lccc *LifeCycleSysCC) Invoke(stub shim.ChaincodeStubInterface) pb.Response
 			vscc = []byte("vscc")
 		}
 
-		verBytes, err := lccc.executeUpgrade(stub, chainname, depSpec, policy, escc, vscc)
+		var collectionConfig []byte
+		if len(args) > 6 && args[6] != nil {
+			collectionConfig = args[6]
+		}
+
+		verBytes, err := lccc.executeUpgrade(stub, chainname, depSpec, policy, escc, vscc, collectionConfig)
 		if err != nil {
 			return shim.Error(err.Error())
 		}
@@ -24,6 +24,7 @@ import (
 	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 	"github.com/hyperledger/fabric/core/common/ccprovider"
+	"github.com/hyperledger/fabric/core/common/privdata"
 	"github.com/hyperledger/fabric/core/common/sysccprovider"
 	//"github.com/hyperledger/fabric/core/container"
 	"archive/tar"
@@ -500,6 +501,46 @@ func TestUpgrade(t *testing.T) {
 	}
 }
 
+//TestDeployWithCollectionConfig tests that the collection config package supplied with a deploy and an upgrade is stored
+func TestDeployWithCollectionConfig(t *testing.T) {
+	scc := new(LifeCycleSysCC)
+	stub := shim.NewMockStub("lccc", scc)
+
+	if res := stub.MockInit("1", nil); res.Status != shim.OK {
+		t.Fatalf("Init failed: %s", res.Message)
+	}
+
+	cds, err := constructDeploymentSpec("example02", "github.com/hyperledger/fabric/examples/chaincode/go/chaincode_example02", "0", [][]byte{[]byte("init"), []byte("a"), []byte("100"), []byte("b"), []byte("200")}, true)
+	defer os.Remove(lccctestpath + "/example02.0")
+	var b []byte
+	if b, err = proto.Marshal(cds); err != nil || b == nil {
+		t.Fatalf("Marshal DeploymentSpec failed")
+	}
+
+	args := [][]byte{[]byte(DEPLOY), []byte("test"), b, nil, nil, nil, []byte("collection-config-0")}
+	if res := stub.MockInvoke("1", args); res.Status != shim.OK {
+		t.Fatalf("Deploy chaincode error: %s", res.Message)
+	}
+	if config := stub.State[privdata.BuildCollectionKVSKey("example02")]; string(config) != "collection-config-0" {
+		t.Fatalf("Collection config not stored on deploy, got %s", config)
+	}
+
+	newCds, err := constructDeploymentSpec("example02", "github.com/hyperledger/fabric/examples/chaincode/go/chaincode_example02", "1", [][]byte{[]byte("init"), []byte("a"), []byte("100"), []byte("b"), []byte("200")}, true)
+	defer os.Remove(lccctestpath + "/example02.1")
+	var newb []byte
+	if newb, err = proto.Marshal(newCds); err != nil || newb == nil {
+		t.Fatalf("Marshal DeploymentSpec failed")
+	}
+
+	args = [][]byte{[]byte(UPGRADE), []byte("test"), newb, nil, nil, nil, []byte("collection-config-1")}
+	if res := stub.MockInvoke("1", args); res.Status != shim.OK {
+		t.Fatalf("Upgrade chaincode error: %s", res.Message)
+	}
+	if config := stub.State[privdata.BuildCollectionKVSKey("example02")]; string(config) != "collection-config-1" {
+		t.Fatalf("Collection config not stored on upgrade, got %s", config)
+	}
+}
+
 //TestUpgradeNonExistChaincode tests upgrade non exist chaincode
 func TestUpgradeNonExistChaincode(t *testing.T) {
 	scc := new(LifeCycleSysCC)
@@ -18,6 +18,7 @@ package qscc
 
 import (
 	"fmt"
+	"math"
 	"strconv"
 
 	"github.com/op/go-logging"
@@ -34,6 +35,12 @@ import (
 // - GetBlockByNumber returns a block
 // - GetBlockByHash returns a block
 // - GetTransactionByID returns a transaction
+// - GetBlockByTxID returns the block that contains a transaction
+// - GetTxValidationCode returns the validation code of a transaction
+// - GetTransactionProof returns a proof of the existence of a transaction
+// - GetEventsByChaincode returns the events of a chaincode in a range of blocks
+// - GetTxResultByID returns the result of the execution of the chaincode by a transaction
+// - GetStateProof returns a proof of the value of a key, or of its absence, against the state root of a block
 type LedgerQuerier struct {
 }
 
@@ -41,11 +48,16 @@ var qscclogger = logging.MustGetLogger("qscc")
 
 // These are function names from Invoke first parameter
 const (
-	GetChainInfo       string = "GetChainInfo"
-	GetBlockByNumber   string = "GetBlockByNumber"
-	GetBlockByHash     string = "GetBlockByHash"
-	GetTransactionByID string = "GetTransactionByID"
-	GetBlockByTxID     string = "GetBlockByTxID"
+	GetChainInfo         string = "GetChainInfo"
+	GetBlockByNumber     string = "GetBlockByNumber"
+	GetBlockByHash       string = "GetBlockByHash"
+	GetTransactionByID   string = "GetTransactionByID"
+	GetBlockByTxID       string = "GetBlockByTxID"
+	GetTxValidationCode  string = "GetTxValidationCode"
+	GetTransactionProof  string = "GetTransactionProof"
+	GetEventsByChaincode string = "GetEventsByChaincode"
+	GetTxResultByID      string = "GetTxResultByID"
+	GetStateProof        string = "GetStateProof"
 )
 
 // Init is called once per chain when the chain is created.
@@ -64,6 +76,16 @@ func (e *LedgerQuerier) Init(stub shim.ChaincodeStubInterface) pb.Response {
 // # GetBlockByNumber: Return the block specified by block number in args[2]
 // # GetBlockByHash: Return the block specified by block hash in args[2]
 // # GetTransactionByID: Return the transaction specified by ID in args[2]
+// # GetBlockByTxID: Return the block that contains the transaction specified by ID in args[2]
+// # GetTxValidationCode: Return the validation code, as a decimal number, of the transaction specified by ID in args[2]
+// # GetTransactionProof: Return a proof of the transaction specified by ID in args[2], anchored to the block specified by number in the optional args[3]
+// # GetEventsByChaincode: Return the events of the chaincode specified by name in args[2], set in the blocks from the number in args[3]
+// to the number in args[4], both inclusive. The range is open-ended if args[4] is omitted
+// # GetTxResultByID: Return the proposal response payload of the valid transaction specified by ID in args[2], whose extension
+// holds the response of the chaincode and its events. The results are available if the archive of the results is enabled
+// # GetStateProof: Return a proof of the value of the key in args[3] of the namespace in args[2], or of its absence, as of the block
+// specified by number in the optional args[4]. The proofs are available if the state trie is enabled, and are verified by the clients
+// via package stateproof
 func (e *LedgerQuerier) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
 	args := stub.GetArgs()
 
@@ -98,6 +120,34 @@ func (e *LedgerQuerier) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
 		return getChainInfo(targetLedger)
 	case GetBlockByTxID:
 		return getBlockByTxID(targetLedger, args[2])
+	case GetTxValidationCode:
+		return getTxValidationCode(targetLedger, args[2])
+	case GetTransactionProof:
+		var anchorBlockNum []byte
+		if len(args) > 3 {
+			anchorBlockNum = args[3]
+		}
+		return getTransactionProof(targetLedger, args[2], anchorBlockNum)
+	case GetEventsByChaincode:
+		if len(args) < 4 {
+			return shim.Error(fmt.Sprintf("missing 4th argument for %s", fname))
+		}
+		var endBlockNum []byte
+		if len(args) > 4 {
+			endBlockNum = args[4]
+		}
+		return getEventsByChaincode(targetLedger, args[2], args[3], endBlockNum)
+	case GetTxResultByID:
+		return getTxResultByID(targetLedger, args[2])
+	case GetStateProof:
+		if len(args) < 4 {
+			return shim.Error(fmt.Sprintf("missing 4th argument for %s", fname))
+		}
+		var blockNum []byte
+		if len(args) > 4 {
+			blockNum = args[4]
+		}
+		return getStateProof(targetLedger, args[2], args[3], blockNum)
 	}
 
 	return shim.Error(fmt.Sprintf("Requested function %s not found.", fname))
@@ -110,7 +160,7 @@ func getTransactionByID(vledger ledger.PeerLedger, tid []byte) pb.Response {
 
 	processedTran, err := vledger.GetTransactionByID(string(tid))
 	if err != nil {
-		return shim.Error(fmt.Sprintf("Failed to get transaction with id %s, error %s", string(tid), err))
+		return ledgerError(fmt.Sprintf("Failed to get transaction with id %s, error %s", string(tid), err), err)
 	}
 
 	bytes, err := utils.Marshal(processedTran)
@@ -121,6 +171,53 @@ func getTransactionByID(vledger ledger.PeerLedger, tid []byte) pb.Response {
 	return shim.Success(bytes)
 }
 
+func getTransactionProof(vledger ledger.PeerLedger, tid []byte, anchorBlockNum []byte) pb.Response {
+	if tid == nil {
+		return shim.Error("Transaction ID must not be nil.")
+	}
+	anchor := uint64(math.MaxUint64)
+	if anchorBlockNum != nil {
+		var err error
+		if anchor, err = strconv.ParseUint(string(anchorBlockNum), 10, 64); err != nil {
+			return shim.Error(fmt.Sprintf("Failed to parse anchor block number with error %s", err))
+		}
+	}
+
+	proof, err := vledger.GetTransactionProof(string(tid), anchor)
+	if err != nil {
+		return ledgerError(fmt.Sprintf("Failed to get proof of transaction with id %s, error %s", string(tid), err), err)
+	}
+
+	bytes, err := utils.Marshal(proof)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success(bytes)
+}
+
+func getStateProof(vledger ledger.PeerLedger, namespace []byte, key []byte, blockNum []byte) pb.Response {
+	bnum := uint64(math.MaxUint64)
+	if blockNum != nil {
+		var err error
+		if bnum, err = strconv.ParseUint(string(blockNum), 10, 64); err != nil {
+			return shim.Error(fmt.Sprintf("Failed to parse block number with error %s", err))
+		}
+	}
+
+	proof, err := vledger.GetStateProof(string(namespace), string(key), bnum)
+	if err != nil {
+		return ledgerError(fmt.Sprintf("Failed to get proof of key %s of namespace %s, error %s", string(key), string(namespace), err), err)
+	}
+
+	bytes, err := utils.Marshal(proof)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success(bytes)
+}
+
 func getBlockByNumber(vledger ledger.PeerLedger, number []byte) pb.Response {
 	if number == nil {
 		return shim.Error("Block number must not be nil.")
@@ -131,7 +228,7 @@ func getBlockByNumber(vledger ledger.PeerLedger, number []byte) pb.Response {
 	}
 	block, err := vledger.GetBlockByNumber(bnum)
 	if err != nil {
-		return shim.Error(fmt.Sprintf("Failed to get block number %d, error %s", bnum, err))
+		return ledgerError(fmt.Sprintf("Failed to get block number %d, error %s", bnum, err), err)
 	}
 	// TODO: consider trim block content before returning
 	//  Specifically, trim transaction 'data' out of the transaction array Payloads
@@ -152,7 +249,7 @@ func getBlockByHash(vledger ledger.PeerLedger, hash []byte) pb.Response {
 	}
 	block, err := vledger.GetBlockByHash(hash)
 	if err != nil {
-		return shim.Error(fmt.Sprintf("Failed to get block hash %s, error %s", string(hash), err))
+		return ledgerError(fmt.Sprintf("Failed to get block hash %s, error %s", string(hash), err), err)
 	}
 	// TODO: consider trim block content before returning
 	//  Specifically, trim transaction 'data' out of the transaction array Payloads
@@ -170,7 +267,7 @@ func getBlockByHash(vledger ledger.PeerLedger, hash []byte) pb.Response {
 func getChainInfo(vledger ledger.PeerLedger) pb.Response {
 	binfo, err := vledger.GetBlockchainInfo()
 	if err != nil {
-		return shim.Error(fmt.Sprintf("Failed to get block info with error %s", err))
+		return ledgerError(fmt.Sprintf("Failed to get block info with error %s", err), err)
 	}
 	bytes, err := utils.Marshal(binfo)
 	if err != nil {
@@ -185,7 +282,7 @@ func getBlockByTxID(vledger ledger.PeerLedger, rawTxID []byte) pb.Response {
 	block, err := vledger.GetBlockByTxID(txID)
 
 	if err != nil {
-		return shim.Error(fmt.Sprintf("Failed to get block for txID %s, error %s", txID, err))
+		return ledgerError(fmt.Sprintf("Failed to get block for txID %s, error %s", txID, err), err)
 	}
 
 	bytes, err := utils.Marshal(block)
@@ -196,3 +293,73 @@ func getBlockByTxID(vledger ledger.PeerLedger, rawTxID []byte) pb.Response {
 
 	return shim.Success(bytes)
 }
+
+func getTxValidationCode(vledger ledger.PeerLedger, rawTxID []byte) pb.Response {
+	txID := string(rawTxID)
+	validationCode, err := vledger.GetTxValidationCodeByTxID(txID)
+	if err != nil {
+		return ledgerError(fmt.Sprintf("Failed to get validation code for txID %s, error %s", txID, err), err)
+	}
+	return shim.Success([]byte(strconv.Itoa(int(validationCode))))
+}
+
+func getEventsByChaincode(vledger ledger.PeerLedger, ccName []byte, startBlockNum []byte, endBlockNum []byte) pb.Response {
+	if len(ccName) == 0 {
+		return shim.Error("Chaincode name must not be empty.")
+	}
+	startBlock, err := strconv.ParseUint(string(startBlockNum), 10, 64)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to parse start block number with error %s", err))
+	}
+	endBlock := uint64(math.MaxUint64)
+	if endBlockNum != nil {
+		if endBlock, err = strconv.ParseUint(string(endBlockNum), 10, 64); err != nil {
+			return shim.Error(fmt.Sprintf("Failed to parse end block number with error %s", err))
+		}
+	}
+
+	events, err := vledger.GetEventsByChaincode(string(ccName), startBlock, endBlock)
+	if err != nil {
+		return ledgerError(fmt.Sprintf("Failed to get events of chaincode %s, error %s", string(ccName), err), err)
+	}
+
+	bytes, err := utils.Marshal(&pb.ChaincodeEventsQueryResponse{Events: events})
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success(bytes)
+}
+
+func getTxResultByID(vledger ledger.PeerLedger, tid []byte) pb.Response {
+	if tid == nil {
+		return shim.Error("Transaction ID must not be nil.")
+	}
+
+	txResult, err := vledger.GetTxResultByID(string(tid))
+	if err != nil {
+		return ledgerError(fmt.Sprintf("Failed to get result of transaction with id %s, error %s", string(tid), err), err)
+	}
+
+	bytes, err := utils.Marshal(txResult.ProposalResponsePayload)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success(bytes)
+}
+
+// ledgerError returns an error response with a status that corresponds to the kind of the ledger error
+// so that the clients can tell, for instance, a missing transaction apart from a failure of the ledger
+func ledgerError(msg string, err error) pb.Response {
+	resp := shim.Error(msg)
+	switch err.(type) {
+	case *ledger.NotFoundError:
+		resp.Status = 404
+	case *ledger.NotEnabledError:
+		resp.Status = 501
+	case *ledger.UnavailableError:
+		resp.Status = 503
+	}
+	return resp
+}
@@ -20,10 +20,12 @@ import (
 	"os"
 	"testing"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/spf13/viper"
 
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 	"github.com/hyperledger/fabric/core/peer"
+	pb "github.com/hyperledger/fabric/protos/peer"
 )
 
 func TestInit(t *testing.T) {
@@ -66,9 +68,59 @@ func TestQueryGetTransactionByID(t *testing.T) {
 	stub := shim.NewMockStub("LedgerQuerier", e)
 
 	args := [][]byte{[]byte(GetTransactionByID), []byte("mytestchainid3"), []byte("1")}
-	if res := stub.MockInvoke("1", args); res.Status == shim.OK {
+	res := stub.MockInvoke("1", args)
+	if res.Status == shim.OK {
 		t.Fatalf("qscc getTransactionByID should have failed with invalid txid: 1")
 	}
+	if res.Status != 404 {
+		t.Fatalf("qscc getTransactionByID should have returned status 404 for a missing transaction, got %d", res.Status)
+	}
+}
+
+func TestQueryGetTransactionProof(t *testing.T) {
+	viper.Set("peer.fileSystemPath", "/var/hyperledger/test9/")
+	defer os.RemoveAll("/var/hyperledger/test9/")
+	peer.MockInitialize()
+	peer.MockCreateChain("mytestchainid9")
+
+	e := new(LedgerQuerier)
+	stub := shim.NewMockStub("LedgerQuerier", e)
+
+	args := [][]byte{[]byte(GetTransactionProof), []byte("mytestchainid9"), []byte("1")}
+	if res := stub.MockInvoke("1", args); res.Status == shim.OK {
+		t.Fatalf("qscc GetTransactionProof should have failed with invalid txid: 1")
+	}
+
+	args = [][]byte{[]byte(GetTransactionProof), []byte("mytestchainid9"), []byte("1"), []byte("abc")}
+	if res := stub.MockInvoke("1", args); res.Status == shim.OK {
+		t.Fatalf("qscc GetTransactionProof should have failed with invalid anchor block number: abc")
+	}
+}
+
+func TestQueryGetStateProof(t *testing.T) {
+	viper.Set("peer.fileSystemPath", "/var/hyperledger/test14/")
+	defer os.RemoveAll("/var/hyperledger/test14/")
+	peer.MockInitialize()
+	peer.MockCreateChain("mytestchainid14")
+
+	e := new(LedgerQuerier)
+	stub := shim.NewMockStub("LedgerQuerier", e)
+
+	args := [][]byte{[]byte(GetStateProof), []byte("mytestchainid14"), []byte("ns1")}
+	if res := stub.MockInvoke("1", args); res.Status == shim.OK {
+		t.Fatalf("qscc GetStateProof should have failed with a missing key")
+	}
+
+	args = [][]byte{[]byte(GetStateProof), []byte("mytestchainid14"), []byte("ns1"), []byte("key1"), []byte("abc")}
+	if res := stub.MockInvoke("1", args); res.Status == shim.OK {
+		t.Fatalf("qscc GetStateProof should have failed with invalid block number: abc")
+	}
+
+	// the state trie is not enabled
+	args = [][]byte{[]byte(GetStateProof), []byte("mytestchainid14"), []byte("ns1"), []byte("key1")}
+	if res := stub.MockInvoke("1", args); res.Status == shim.OK {
+		t.Fatalf("qscc GetStateProof should have failed as the state trie is not enabled")
+	}
 }
 
 func TestQueryWithWrongParameters(t *testing.T) {
@@ -134,3 +186,80 @@ func TestQueryGetBlockByTxID(t *testing.T) {
 		t.Fatalf("qscc GetBlockByTxID should have failed with invalid txID: %s", txID)
 	}
 }
+
+func TestQueryGetTxValidationCode(t *testing.T) {
+	viper.Set("peer.fileSystemPath", "/var/hyperledger/test10/")
+	defer os.RemoveAll("/var/hyperledger/test10/")
+	peer.MockInitialize()
+	peer.MockCreateChain("mytestchainid10")
+
+	e := new(LedgerQuerier)
+	stub := shim.NewMockStub("LedgerQuerier", e)
+
+	args := [][]byte{[]byte(GetTxValidationCode), []byte("mytestchainid10"), []byte("1")}
+	res := stub.MockInvoke("1", args)
+	if res.Status == shim.OK {
+		t.Fatalf("qscc GetTxValidationCode should have failed with invalid txid: 1")
+	}
+	if res.Status != 404 {
+		t.Fatalf("qscc GetTxValidationCode should have returned status 404 for a missing transaction, got %d", res.Status)
+	}
+}
+
+func TestQueryGetEventsByChaincode(t *testing.T) {
+	viper.Set("peer.fileSystemPath", "/var/hyperledger/test11/")
+	defer os.RemoveAll("/var/hyperledger/test11/")
+	peer.MockInitialize()
+	peer.MockCreateChain("mytestchainid11")
+
+	e := new(LedgerQuerier)
+	stub := shim.NewMockStub("LedgerQuerier", e)
+
+	args := [][]byte{[]byte(GetEventsByChaincode), []byte("mytestchainid11"), []byte("mycc"), []byte("0")}
+	res := stub.MockInvoke("1", args)
+	if res.Status != shim.OK {
+		t.Fatalf("qscc GetEventsByChaincode failed with err: %s", res.Message)
+	}
+	events := &pb.ChaincodeEventsQueryResponse{}
+	if err := proto.Unmarshal(res.Payload, events); err != nil {
+		t.Fatalf("qscc GetEventsByChaincode returned an invalid response: %s", err)
+	}
+	if len(events.Events) != 0 {
+		t.Fatalf("qscc GetEventsByChaincode should have returned no events, got %d", len(events.Events))
+	}
+
+	args = [][]byte{[]byte(GetEventsByChaincode), []byte("mytestchainid11"), []byte("mycc"), []byte("5"), []byte("2")}
+	res = stub.MockInvoke("2", args)
+	if res.Status == shim.OK {
+		t.Fatalf("qscc GetEventsByChaincode should have failed with a start block greater than the end block")
+	}
+
+	args = [][]byte{[]byte(GetEventsByChaincode), []byte("mytestchainid11"), []byte("mycc")}
+	res = stub.MockInvoke("3", args)
+	if res.Status == shim.OK {
+		t.Fatalf("qscc GetEventsByChaincode should have failed with a missing start block")
+	}
+}
+
+func TestQueryGetTxResultByID(t *testing.T) {
+	viper.Set("peer.fileSystemPath", "/var/hyperledger/test12/")
+	defer os.RemoveAll("/var/hyperledger/test12/")
+	peer.MockInitialize()
+	peer.MockCreateChain("mytestchainid12")
+
+	e := new(LedgerQuerier)
+	stub := shim.NewMockStub("LedgerQuerier", e)
+
+	args := [][]byte{[]byte(GetTxResultByID), []byte("mytestchainid12"), []byte("1")}
+	res := stub.MockInvoke("1", args)
+	if res.Status != 501 {
+		t.Fatalf("qscc GetTxResultByID should have failed with status 501 as the archive is disabled, got %d", res.Status)
+	}
+
+	viper.Set("ledger.state.txResultsArchive", true)
+	defer viper.Set("ledger.state.txResultsArchive", false)
+	res = stub.MockInvoke("2", args)
+	if res.Status != 404 {
+		t.Fatalf("qscc GetTxResultByID should have failed with status 404 for an unknown transaction, got %d", res.Status)
+	}
+}
@@ -0,0 +1,178 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snapshot
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/crypto"
+	"github.com/hyperledger/fabric/common/ledger/util"
+	"github.com/hyperledger/fabric/core/ledger/kvledger"
+	"github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric/protos/utils"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+// Connector establishes a connection to the peer at the given endpoint
+type Connector func(endpoint string) (*grpc.ClientConn, error)
+
+// Client fetches the snapshot of a channel from the other peers of the channel.
+// The requests are signed with the given signer
+type Client struct {
+	channelID string
+	signer    crypto.LocalSigner
+	connector Connector
+}
+
+// NewClient constructs a Client for the given channel
+func NewClient(channelID string, signer crypto.LocalSigner, connector Connector) *Client {
+	return &Client{channelID, signer, connector}
+}
+
+// Transfer fetches a snapshot that covers at least the blocks below the given height from the source peer
+// in the given directory and verifies the snapshot against the commit hashes of the verifying peers.
+// The snapshot is accepted only if all the verifying peers report the commit hash recorded in the snapshot
+func (c *Client) Transfer(source string, verifiers []string, minHeight uint64, snapshotDir string) (*kvledger.SnapshotMetadata, error) {
+	metadata, err := c.Fetch(source, minHeight, snapshotDir)
+	if err != nil {
+		return nil, err
+	}
+	if metadata.ChannelName != c.channelID {
+		return nil, fmt.Errorf("Snapshot fetched from peer [%s] is of channel [%s] instead of channel [%s]", source, metadata.ChannelName, c.channelID)
+	}
+	if metadata.CommitHash == nil {
+		return nil, fmt.Errorf("Snapshot fetched from peer [%s] does not carry a commit hash", source)
+	}
+	if len(verifiers) == 0 {
+		logger.Warningf("Channel [%s]: No peers to verify the snapshot fetched from peer [%s]", c.channelID, source)
+	}
+	for _, verifier := range verifiers {
+		commitHash, err := c.GetCommitHash(verifier, metadata.LastBlockNumber)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to get the commit hash of block [%d] from peer [%s]: %s", metadata.LastBlockNumber, verifier, err)
+		}
+		if !bytes.Equal(commitHash, metadata.CommitHash) {
+			return nil, fmt.Errorf("Commit hash of block [%d] reported by peer [%s] does not match the commit hash in the snapshot fetched from peer [%s]",
+				metadata.LastBlockNumber, verifier, source)
+		}
+	}
+	logger.Infof("Channel [%s]: Verified snapshot at block [%d] fetched from peer [%s] with %d peer(s)",
+		c.channelID, metadata.LastBlockNumber, source, len(verifiers))
+	return metadata, nil
+}
+
+// Fetch fetches a snapshot that covers at least the blocks below the given height from the given peer.
+// The files of the snapshot are written in the given directory, which is expected to be empty
+func (c *Client) Fetch(endpoint string, minHeight uint64, snapshotDir string) (*kvledger.SnapshotMetadata, error) {
+	empty, err := util.CreateDirIfMissing(snapshotDir)
+	if err != nil {
+		return nil, err
+	}
+	if !empty {
+		return nil, fmt.Errorf("Snapshot directory [%s] is not empty", snapshotDir)
+	}
+	env, err := c.createRequest(&pb.SnapshotRequest{MinHeight: minHeight})
+	if err != nil {
+		return nil, err
+	}
+	conn, err := c.connector(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	stream, err := pb.NewSnapshotClient(conn).Fetch(context.Background(), env)
+	if err != nil {
+		return nil, err
+	}
+	var f *os.File
+	defer func() {
+		if f != nil {
+			f.Close()
+		}
+	}()
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if f == nil || filepath.Base(f.Name()) != chunk.FileName {
+			if f != nil {
+				if err := closeFile(f); err != nil {
+					return nil, err
+				}
+			}
+			// the file names are supplied by the other peer and hence restricted to the snapshot directory
+			if chunk.FileName == "" || filepath.Base(chunk.FileName) != chunk.FileName {
+				return nil, fmt.Errorf("Invalid file name [%s] in the snapshot sent by peer [%s]", chunk.FileName, endpoint)
+			}
+			if f, err = os.OpenFile(filepath.Join(snapshotDir, chunk.FileName), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644); err != nil {
+				return nil, err
+			}
+		}
+		if _, err := f.Write(chunk.Data); err != nil {
+			return nil, err
+		}
+	}
+	if f != nil {
+		err := closeFile(f)
+		f = nil
+		if err != nil {
+			return nil, err
+		}
+	}
+	return kvledger.LoadSnapshotMetadata(snapshotDir)
+}
+
+// GetCommitHash returns the commit hash of the given block reported by the given peer
+func (c *Client) GetCommitHash(endpoint string, blockNum uint64) ([]byte, error) {
+	env, err := c.createRequest(&pb.CommitHashRequest{BlockNumber: blockNum})
+	if err != nil {
+		return nil, err
+	}
+	conn, err := c.connector(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	response, err := pb.NewSnapshotClient(conn).GetCommitHash(context.Background(), env)
+	if err != nil {
+		return nil, err
+	}
+	return response.CommitHash, nil
+}
+
+func (c *Client) createRequest(request proto.Message) (*common.Envelope, error) {
+	return utils.CreateSignedEnvelope(common.HeaderType_MESSAGE, c.channelID, c.signer, request, 0, 0)
+}
+
+func closeFile(f *os.File) error {
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
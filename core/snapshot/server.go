@@ -0,0 +1,183 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snapshot
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/ledger/util"
+	"github.com/hyperledger/fabric/common/policies"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger"
+	"github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric/protos/utils"
+	"github.com/op/go-logging"
+	"golang.org/x/net/context"
+)
+
+var logger = logging.MustGetLogger("snapshot")
+
+// maxChunkSize is the maximum number of bytes of a file sent in a single chunk
+const maxChunkSize = 1024 * 1024
+
+// LedgerGetter returns the ledger of the given channel or nil if the peer has not joined the channel
+type LedgerGetter func(channelID string) ledger.PeerLedger
+
+// PolicyManagerGetter returns the policy manager of the given channel or nil if the peer has not joined the channel
+type PolicyManagerGetter func(channelID string) policies.Manager
+
+// Server implements the snapshot service of the peer. The requests are authorized
+// against the readers policy of the channel
+type Server struct {
+	snapshotsDir        string
+	ledgerGetter        LedgerGetter
+	policyManagerGetter PolicyManagerGetter
+}
+
+// NewServer constructs a Server that generates the snapshots in the given directory
+func NewServer(snapshotsDir string, ledgerGetter LedgerGetter, policyManagerGetter PolicyManagerGetter) *Server {
+	return &Server{snapshotsDir, ledgerGetter, policyManagerGetter}
+}
+
+// Fetch implements the corresponding method from interface pb.SnapshotServer.
+// The snapshot is generated for the request and removed once all the files are sent
+func (s *Server) Fetch(env *common.Envelope, stream pb.Snapshot_FetchServer) error {
+	request := &pb.SnapshotRequest{}
+	channelID, l, err := s.validateRequest(env, request)
+	if err != nil {
+		return err
+	}
+	if _, err := util.CreateDirIfMissing(s.snapshotsDir); err != nil {
+		return err
+	}
+	tempDir, err := ioutil.TempDir(s.snapshotsDir, channelID+"-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tempDir)
+	snapshotDir := filepath.Join(tempDir, "snapshot")
+	if err := l.GenerateSnapshot(snapshotDir); err != nil {
+		return err
+	}
+	metadata, err := kvledger.LoadSnapshotMetadata(snapshotDir)
+	if err != nil {
+		return err
+	}
+	if metadata.LastBlockNumber+1 < request.MinHeight {
+		return fmt.Errorf("Snapshot of channel [%s] is at height [%d], which is lower than the requested height [%d]",
+			channelID, metadata.LastBlockNumber+1, request.MinHeight)
+	}
+	logger.Infof("Channel [%s]: Sending snapshot at block [%d]", channelID, metadata.LastBlockNumber)
+	files, err := ioutil.ReadDir(snapshotDir)
+	if err != nil {
+		return err
+	}
+	// the metadata file is sent last so that its presence marks a complete snapshot at the receiving peer
+	for _, file := range files {
+		if file.Name() == kvledger.SnapshotMetadataFileName {
+			continue
+		}
+		if err := sendFile(stream, snapshotDir, file.Name()); err != nil {
+			return err
+		}
+	}
+	return sendFile(stream, snapshotDir, kvledger.SnapshotMetadataFileName)
+}
+
+// GetCommitHash implements the corresponding method from interface pb.SnapshotServer
+func (s *Server) GetCommitHash(ctx context.Context, env *common.Envelope) (*pb.CommitHashResponse, error) {
+	request := &pb.CommitHashRequest{}
+	_, l, err := s.validateRequest(env, request)
+	if err != nil {
+		return nil, err
+	}
+	commitHash, err := l.GetCommitHash(request.BlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.CommitHashResponse{CommitHash: commitHash}, nil
+}
+
+// validateRequest checks that the envelope is signed by a reader of the channel and
+// unmarshals the payload data into the given request
+func (s *Server) validateRequest(env *common.Envelope, request proto.Message) (string, ledger.PeerLedger, error) {
+	payload, err := utils.UnmarshalPayload(env.Payload)
+	if err != nil {
+		return "", nil, err
+	}
+	if payload.Header == nil {
+		return "", nil, fmt.Errorf("Missing header in the request")
+	}
+	chdr, err := utils.UnmarshalChannelHeader(payload.Header.ChannelHeader)
+	if err != nil {
+		return "", nil, err
+	}
+	channelID := chdr.ChannelId
+	policyManager := s.policyManagerGetter(channelID)
+	l := s.ledgerGetter(channelID)
+	if policyManager == nil || l == nil {
+		return "", nil, fmt.Errorf("Channel [%s] not found", channelID)
+	}
+	policy, ok := policyManager.GetPolicy(policies.ChannelApplicationReaders)
+	if !ok {
+		return "", nil, fmt.Errorf("Readers policy of channel [%s] not found", channelID)
+	}
+	signedData, err := env.AsSignedData()
+	if err != nil {
+		return "", nil, err
+	}
+	if err := policy.Evaluate(signedData); err != nil {
+		logger.Warningf("Channel [%s]: Rejecting snapshot request that does not satisfy the readers policy: %s", channelID, err)
+		return "", nil, fmt.Errorf("Request is not authorized for channel [%s]", channelID)
+	}
+	if err := proto.Unmarshal(payload.Data, request); err != nil {
+		return "", nil, err
+	}
+	return channelID, l, nil
+}
+
+func sendFile(stream pb.Snapshot_FetchServer, dir string, fileName string) error {
+	f, err := os.Open(filepath.Join(dir, fileName))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	buf := make([]byte, maxChunkSize)
+	sent := false
+	for {
+		n, err := f.Read(buf)
+		// an empty file is sent as a single empty chunk so that the file is created at the receiving peer
+		if n > 0 || (err == io.EOF && !sent) {
+			if err := stream.Send(&pb.SnapshotChunk{FileName: fileName, Data: buf[:n]}); err != nil {
+				return err
+			}
+			sent = true
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,148 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snapshot
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	mockcrypto "github.com/hyperledger/fabric/common/mocks/crypto"
+	mockpolicies "github.com/hyperledger/fabric/common/mocks/policies"
+	"github.com/hyperledger/fabric/common/policies"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+	"github.com/hyperledger/fabric/core/ledger/ledgermgmt"
+	pb "github.com/hyperledger/fabric/protos/peer"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc"
+)
+
+func TestMain(m *testing.M) {
+	viper.Set("peer.fileSystemPath", "/tmp/fabric/snapshottests")
+	os.Exit(m.Run())
+}
+
+func TestTransferAndImport(t *testing.T) {
+	ledgermgmt.InitializeTestEnv()
+	defer ledgermgmt.CleanupTestEnv()
+	snapshotDir, err := ioutil.TempDir("", "snapshot-transfer-")
+	testutil.AssertNoError(t, err, "")
+	defer os.RemoveAll(snapshotDir)
+
+	sourceLedger, _ := ledgermgmt.CreateLedger("testchannel")
+	commitTestBlocks(t, sourceLedger, "value")
+	divergedLedger, _ := ledgermgmt.CreateLedger("divergedchannel")
+	commitTestBlocks(t, divergedLedger, "divergedValue")
+
+	source, sourceServer := startServer(t, sourceLedger, &mockpolicies.Policy{})
+	defer sourceServer.Stop()
+	verifier, verifierServer := startServer(t, sourceLedger, &mockpolicies.Policy{})
+	defer verifierServer.Stop()
+	divergedVerifier, divergedVerifierServer := startServer(t, divergedLedger, &mockpolicies.Policy{})
+	defer divergedVerifierServer.Stop()
+	unauthorizedVerifier, unauthorizedVerifierServer := startServer(t, sourceLedger, &mockpolicies.Policy{Err: fmt.Errorf("Unauthorized")})
+	defer unauthorizedVerifierServer.Stop()
+	client := NewClient("testchannel", mockcrypto.FakeLocalSigner, connect)
+
+	// the snapshot is rejected if a verifying peer reports a different commit hash or cannot be queried
+	_, err = client.Transfer(source, []string{verifier, divergedVerifier}, 3, filepath.Join(snapshotDir, "diverged"))
+	testutil.AssertError(t, err, "Expected an error for a mismatch of commit hash")
+	_, err = client.Transfer(source, []string{unauthorizedVerifier}, 3, filepath.Join(snapshotDir, "unauthorized"))
+	testutil.AssertError(t, err, "Expected an error for an unauthorized request")
+	// the source peer cannot serve a snapshot beyond its height
+	_, err = client.Transfer(source, []string{verifier}, 4, filepath.Join(snapshotDir, "aheadOfSource"))
+	testutil.AssertError(t, err, "Expected an error for a snapshot beyond the height of the source peer")
+
+	metadata, err := client.Transfer(source, []string{verifier}, 3, filepath.Join(snapshotDir, "verified"))
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, metadata.LastBlockNumber, uint64(2))
+	expectedCommitHash, _ := sourceLedger.GetCommitHash(2)
+	testutil.AssertEquals(t, metadata.CommitHash, expectedCommitHash)
+
+	// the fetched snapshot bootstraps the ledger, which then continues with the next blocks
+	sourceLedger.Close()
+	testutil.AssertNoError(t, ledgermgmt.DestroyLedger("testchannel"), "")
+	importedLedger, err := ledgermgmt.CreateLedgerFromSnapshot(filepath.Join(snapshotDir, "verified"))
+	testutil.AssertNoError(t, err, "")
+	bcInfo, _ := importedLedger.GetBlockchainInfo()
+	testutil.AssertEquals(t, bcInfo.Height, uint64(3))
+	commitHash, _ := importedLedger.GetCommitHash(2)
+	testutil.AssertEquals(t, commitHash, expectedCommitHash)
+	qe, _ := importedLedger.NewQueryExecutor()
+	value, _ := qe.GetState("ns", "key2")
+	qe.Done()
+	testutil.AssertEquals(t, value, []byte("value2"))
+}
+
+func TestFetchUnknownChannel(t *testing.T) {
+	ledgermgmt.InitializeTestEnv()
+	defer ledgermgmt.CleanupTestEnv()
+	snapshotDir, err := ioutil.TempDir("", "snapshot-transfer-")
+	testutil.AssertNoError(t, err, "")
+	defer os.RemoveAll(snapshotDir)
+
+	l, _ := ledgermgmt.CreateLedger("testchannel")
+	commitTestBlocks(t, l, "value")
+	source, sourceServer := startServer(t, l, &mockpolicies.Policy{})
+	defer sourceServer.Stop()
+	client := NewClient("unknownchannel", mockcrypto.FakeLocalSigner, connect)
+	_, err = client.Fetch(source, 0, snapshotDir)
+	testutil.AssertError(t, err, "Expected an error for an unknown channel")
+	_, err = client.GetCommitHash(source, 0)
+	testutil.AssertError(t, err, "Expected an error for an unknown channel")
+}
+
+func commitTestBlocks(t *testing.T, l ledger.PeerLedger, valuePrefix string) {
+	bg := testutil.NewBlockGenerator(t)
+	for i := 0; i < 3; i++ {
+		simulator, _ := l.NewTxSimulator()
+		simulator.SetState("ns", fmt.Sprintf("key%d", i), []byte(fmt.Sprintf("%s%d", valuePrefix, i)))
+		simulator.Done()
+		simRes, _ := simulator.GetTxSimulationResults()
+		testutil.AssertNoError(t, l.Commit(bg.NextBlock([][]byte{simRes}, false)), "")
+	}
+}
+
+// startServer serves the given ledger for every channel and authorizes the requests with the given policy
+func startServer(t *testing.T, l ledger.PeerLedger, policy *mockpolicies.Policy) (string, *grpc.Server) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	testutil.AssertNoError(t, err, "")
+	grpcServer := grpc.NewServer()
+	server := NewServer(ledgerconfig.GetSnapshotsPath(),
+		func(channelID string) ledger.PeerLedger {
+			if channelID == "unknownchannel" {
+				return nil
+			}
+			return l
+		},
+		func(channelID string) policies.Manager {
+			return &mockpolicies.Manager{Policy: policy}
+		})
+	pb.RegisterSnapshotServer(grpcServer, server)
+	go grpcServer.Serve(lis)
+	return lis.Addr().String(), grpcServer
+}
+
+func connect(endpoint string) (*grpc.ClientConn, error) {
+	return grpc.Dial(endpoint, grpc.WithInsecure(), grpc.WithBlock(), grpc.WithTimeout(3*time.Second))
+}
@@ -0,0 +1,269 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statequery
+
+import (
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	commonledger "github.com/hyperledger/fabric/common/ledger"
+	"github.com/hyperledger/fabric/common/policies"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric/protos/utils"
+	"github.com/op/go-logging"
+	"golang.org/x/net/context"
+)
+
+var logger = logging.MustGetLogger("statequery")
+
+// LedgerGetter returns the ledger of the given channel or nil if the peer has not joined the channel
+type LedgerGetter func(channelID string) ledger.PeerLedger
+
+// PolicyManagerGetter returns the policy manager of the given channel or nil if the peer has not joined the channel
+type PolicyManagerGetter func(channelID string) policies.Manager
+
+// HistoryAccessCheck returns an error if the creator may not read the history of the given key.
+// The creator is the serialized identity that signed the request
+type HistoryAccessCheck func(creator []byte, channelID string, namespace string, key string) error
+
+// Server implements the state query service of the peer. The queries are executed directly
+// against the committed state of the channel, without executing a chaincode, and are
+// authorized against the readers policy of the channel. The queries of the past values of
+// a key are further subject to the history access check, if any
+type Server struct {
+	ledgerGetter        LedgerGetter
+	policyManagerGetter PolicyManagerGetter
+	historyAccessCheck  HistoryAccessCheck
+}
+
+// NewServer constructs a Server. The history of the keys is readable by all the readers of the channel if historyAccessCheck is nil
+func NewServer(ledgerGetter LedgerGetter, policyManagerGetter PolicyManagerGetter, historyAccessCheck HistoryAccessCheck) *Server {
+	return &Server{ledgerGetter, policyManagerGetter, historyAccessCheck}
+}
+
+// GetState implements the corresponding method from interface pb.StateQueryServer
+func (s *Server) GetState(ctx context.Context, env *common.Envelope) (*pb.QueryStateKeyValue, error) {
+	request := &pb.StateKeyRequest{}
+	l, err := s.validateRequest(env, request)
+	if err != nil {
+		return nil, err
+	}
+	qe, err := l.NewQueryExecutor()
+	if err != nil {
+		return nil, err
+	}
+	defer qe.Done()
+	value, err := qe.GetState(request.Namespace, request.Key)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.QueryStateKeyValue{Key: request.Key, Value: value}, nil
+}
+
+// GetStateByRange implements the corresponding method from interface pb.StateQueryServer
+func (s *Server) GetStateByRange(env *common.Envelope, stream pb.StateQuery_GetStateByRangeServer) error {
+	request := &pb.StateRangeRequest{}
+	l, err := s.validateRequest(env, request)
+	if err != nil {
+		return err
+	}
+	qe, err := l.NewQueryExecutor()
+	if err != nil {
+		return err
+	}
+	defer qe.Done()
+	itr, err := qe.GetStateRangeScanIterator(request.Namespace, request.StartKey, request.EndKey)
+	if err != nil {
+		return err
+	}
+	return sendKVs(itr, stream.Send)
+}
+
+// GetQueryResult implements the corresponding method from interface pb.StateQueryServer
+func (s *Server) GetQueryResult(env *common.Envelope, stream pb.StateQuery_GetQueryResultServer) error {
+	request := &pb.StateRichQueryRequest{}
+	l, err := s.validateRequest(env, request)
+	if err != nil {
+		return err
+	}
+	qe, err := l.NewQueryExecutor()
+	if err != nil {
+		return err
+	}
+	defer qe.Done()
+	itr, err := qe.ExecuteQuery(request.Namespace, request.Query)
+	if err != nil {
+		return err
+	}
+	return sendKVs(itr, stream.Send)
+}
+
+// GetHistoryForKey implements the corresponding method from interface pb.StateQueryServer
+func (s *Server) GetHistoryForKey(env *common.Envelope, stream pb.StateQuery_GetHistoryForKeyServer) error {
+	request := &pb.StateKeyRequest{}
+	l, err := s.validateRequest(env, request)
+	if err != nil {
+		return err
+	}
+	if err := s.checkHistoryAccess(env, request.Namespace, request.Key); err != nil {
+		return err
+	}
+	hqe, err := l.NewHistoryQueryExecutor()
+	if err != nil {
+		return err
+	}
+	itr, err := hqe.GetHistoryForKey(request.Namespace, request.Key)
+	if err != nil {
+		return err
+	}
+	defer itr.Close()
+	for {
+		result, err := itr.Next()
+		if err != nil {
+			return err
+		}
+		if result == nil {
+			return nil
+		}
+		modification := result.(*ledger.KeyModification)
+		if err := stream.Send(&pb.KeyModificationResult{TxId: modification.TxID, Value: modification.Value}); err != nil {
+			return err
+		}
+	}
+}
+
+// GetStateAsOf implements the corresponding method from interface pb.StateQueryServer
+func (s *Server) GetStateAsOf(ctx context.Context, env *common.Envelope) (*pb.QueryStateKeyValue, error) {
+	request := &pb.StateAsOfRequest{}
+	l, err := s.validateRequest(env, request)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkHistoryAccess(env, request.Namespace, request.Key); err != nil {
+		return nil, err
+	}
+	hqe, err := l.NewHistoryQueryExecutor()
+	if err != nil {
+		return nil, err
+	}
+	value, err := hqe.GetStateAsOf(request.Namespace, request.Key, request.BlockHeight)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.QueryStateKeyValue{Key: request.Key, Value: value}, nil
+}
+
+// GetStateUpdatesBetween implements the corresponding method from interface pb.StateQueryServer
+func (s *Server) GetStateUpdatesBetween(env *common.Envelope, stream pb.StateQuery_GetStateUpdatesBetweenServer) error {
+	request := &pb.StateUpdatesRequest{}
+	l, err := s.validateRequest(env, request)
+	if err != nil {
+		return err
+	}
+	kvs, err := l.GetStateUpdatesBetween(request.Namespace, request.FromHeight, request.ToHeight)
+	if err != nil {
+		return err
+	}
+	for _, kv := range kvs {
+		if err := stream.Send(&pb.QueryStateKeyValue{Key: kv.Key, Value: kv.Value}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateRequest checks that the envelope is signed by a reader of the channel and
+// unmarshals the payload data into the given request
+func (s *Server) validateRequest(env *common.Envelope, request proto.Message) (ledger.PeerLedger, error) {
+	payload, err := utils.UnmarshalPayload(env.Payload)
+	if err != nil {
+		return nil, err
+	}
+	if payload.Header == nil {
+		return nil, fmt.Errorf("Missing header in the request")
+	}
+	chdr, err := utils.UnmarshalChannelHeader(payload.Header.ChannelHeader)
+	if err != nil {
+		return nil, err
+	}
+	channelID := chdr.ChannelId
+	policyManager := s.policyManagerGetter(channelID)
+	l := s.ledgerGetter(channelID)
+	if policyManager == nil || l == nil {
+		return nil, fmt.Errorf("Channel [%s] not found", channelID)
+	}
+	policy, ok := policyManager.GetPolicy(policies.ChannelApplicationReaders)
+	if !ok {
+		return nil, fmt.Errorf("Readers policy of channel [%s] not found", channelID)
+	}
+	signedData, err := env.AsSignedData()
+	if err != nil {
+		return nil, err
+	}
+	if err := policy.Evaluate(signedData); err != nil {
+		logger.Warningf("Channel [%s]: Rejecting state query that does not satisfy the readers policy: %s", channelID, err)
+		return nil, fmt.Errorf("Request is not authorized for channel [%s]", channelID)
+	}
+	if err := proto.Unmarshal(payload.Data, request); err != nil {
+		return nil, err
+	}
+	logger.Debugf("Channel [%s]: Executing state query %s", channelID, request)
+	return l, nil
+}
+
+// checkHistoryAccess checks that the signer of an envelope already validated by validateRequest may read the history of the given key
+func (s *Server) checkHistoryAccess(env *common.Envelope, namespace string, key string) error {
+	if s.historyAccessCheck == nil {
+		return nil
+	}
+	payload, err := utils.UnmarshalPayload(env.Payload)
+	if err != nil {
+		return err
+	}
+	chdr, err := utils.UnmarshalChannelHeader(payload.Header.ChannelHeader)
+	if err != nil {
+		return err
+	}
+	shdr, err := utils.GetSignatureHeader(payload.Header.SignatureHeader)
+	if err != nil {
+		return err
+	}
+	if err := s.historyAccessCheck(shdr.Creator, chdr.ChannelId, namespace, key); err != nil {
+		logger.Warningf("Channel [%s]: Rejecting query of the history of key [%s] of namespace [%s]: %s", chdr.ChannelId, key, namespace, err)
+		return fmt.Errorf("Request is not authorized to read the history of key [%s] of namespace [%s]", key, namespace)
+	}
+	return nil
+}
+
+func sendKVs(itr commonledger.ResultsIterator, send func(*pb.QueryStateKeyValue) error) error {
+	defer itr.Close()
+	for {
+		result, err := itr.Next()
+		if err != nil {
+			return err
+		}
+		if result == nil {
+			return nil
+		}
+		kv := result.(*ledger.KV)
+		if err := send(&pb.QueryStateKeyValue{Key: kv.Key, Value: kv.Value}); err != nil {
+			return err
+		}
+	}
+}
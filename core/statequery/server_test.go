@@ -0,0 +1,222 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statequery
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	mockcrypto "github.com/hyperledger/fabric/common/mocks/crypto"
+	mockpolicies "github.com/hyperledger/fabric/common/mocks/policies"
+	"github.com/hyperledger/fabric/common/policies"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/ledgermgmt"
+	"github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric/protos/utils"
+	"github.com/spf13/viper"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+func TestMain(m *testing.M) {
+	viper.Set("peer.fileSystemPath", "/tmp/fabric/statequerytests")
+	viper.Set("ledger.state.historyDatabase", true)
+	os.Exit(m.Run())
+}
+
+func TestStateQueries(t *testing.T) {
+	ledgermgmt.InitializeTestEnv()
+	defer ledgermgmt.CleanupTestEnv()
+	l, _ := ledgermgmt.CreateLedger("testchannel")
+	commitTestBlocks(t, l)
+
+	client, grpcServer := startServer(t, l, &mockpolicies.Policy{}, nil)
+	defer grpcServer.Stop()
+
+	kv, err := client.GetState(context.Background(), signedRequest(t, "testchannel", &pb.StateKeyRequest{Namespace: "ns", Key: "key1"}))
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, kv.Value, []byte("value1_1"))
+	kv, err = client.GetState(context.Background(), signedRequest(t, "testchannel", &pb.StateKeyRequest{Namespace: "ns", Key: "missingkey"}))
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, len(kv.Value), 0)
+
+	rangeStream, err := client.GetStateByRange(context.Background(), signedRequest(t, "testchannel", &pb.StateRangeRequest{Namespace: "ns", StartKey: "key0", EndKey: "key2"}))
+	testutil.AssertNoError(t, err, "")
+	var keys []string
+	for {
+		kv, err := rangeStream.Recv()
+		if err == io.EOF {
+			break
+		}
+		testutil.AssertNoError(t, err, "")
+		keys = append(keys, kv.Key)
+	}
+	testutil.AssertEquals(t, keys, []string{"key0", "key1"})
+
+	historyStream, err := client.GetHistoryForKey(context.Background(), signedRequest(t, "testchannel", &pb.StateKeyRequest{Namespace: "ns", Key: "key1"}))
+	testutil.AssertNoError(t, err, "")
+	var values []string
+	for {
+		modification, err := historyStream.Recv()
+		if err == io.EOF {
+			break
+		}
+		testutil.AssertNoError(t, err, "")
+		values = append(values, string(modification.Value))
+	}
+	testutil.AssertEquals(t, len(values), 2)
+	testutil.AssertContains(t, values, "value0_1")
+	testutil.AssertContains(t, values, "value1_1")
+
+	kv, err = client.GetStateAsOf(context.Background(), signedRequest(t, "testchannel", &pb.StateAsOfRequest{Namespace: "ns", Key: "key1", BlockHeight: 1}))
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, kv.Value, []byte("value0_1"))
+	kv, err = client.GetStateAsOf(context.Background(), signedRequest(t, "testchannel", &pb.StateAsOfRequest{Namespace: "ns", Key: "key1", BlockHeight: 2}))
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, kv.Value, []byte("value1_1"))
+	_, err = client.GetStateAsOf(context.Background(), signedRequest(t, "testchannel", &pb.StateAsOfRequest{Namespace: "ns", Key: "key1", BlockHeight: 3}))
+	testutil.AssertError(t, err, "Expected an error for a height above the height of the ledger")
+
+	updatesStream, err := client.GetStateUpdatesBetween(context.Background(), signedRequest(t, "testchannel", &pb.StateUpdatesRequest{Namespace: "ns", FromHeight: 1, ToHeight: 2}))
+	testutil.AssertNoError(t, err, "")
+	values = nil
+	for {
+		kv, err := updatesStream.Recv()
+		if err == io.EOF {
+			break
+		}
+		testutil.AssertNoError(t, err, "")
+		values = append(values, kv.Key+"="+string(kv.Value))
+	}
+	testutil.AssertEquals(t, values, []string{"key0=value1_0", "key1=value1_1", "key2=value1_2"})
+
+	// the rich queries are not supported by the goleveldb state database
+	queryStream, err := client.GetQueryResult(context.Background(), signedRequest(t, "testchannel", &pb.StateRichQueryRequest{Namespace: "ns", Query: "{}"}))
+	testutil.AssertNoError(t, err, "")
+	_, err = queryStream.Recv()
+	testutil.AssertError(t, err, "Expected an error for a rich query on goleveldb")
+}
+
+func TestUnauthorizedAndUnknownChannel(t *testing.T) {
+	ledgermgmt.InitializeTestEnv()
+	defer ledgermgmt.CleanupTestEnv()
+	l, _ := ledgermgmt.CreateLedger("testchannel")
+	commitTestBlocks(t, l)
+
+	client, grpcServer := startServer(t, l, &mockpolicies.Policy{Err: fmt.Errorf("Unauthorized")}, nil)
+	defer grpcServer.Stop()
+	_, err := client.GetState(context.Background(), signedRequest(t, "testchannel", &pb.StateKeyRequest{Namespace: "ns", Key: "key1"}))
+	testutil.AssertError(t, err, "Expected an error for an unauthorized request")
+	rangeStream, err := client.GetStateByRange(context.Background(), signedRequest(t, "testchannel", &pb.StateRangeRequest{Namespace: "ns"}))
+	testutil.AssertNoError(t, err, "")
+	_, err = rangeStream.Recv()
+	testutil.AssertError(t, err, "Expected an error for an unauthorized request")
+
+	client, grpcServer = startServer(t, l, &mockpolicies.Policy{}, nil)
+	defer grpcServer.Stop()
+	_, err = client.GetState(context.Background(), signedRequest(t, "unknownchannel", &pb.StateKeyRequest{Namespace: "ns", Key: "key1"}))
+	testutil.AssertError(t, err, "Expected an error for an unknown channel")
+}
+
+func TestHistoryAccessCheck(t *testing.T) {
+	ledgermgmt.InitializeTestEnv()
+	defer ledgermgmt.CleanupTestEnv()
+	l, _ := ledgermgmt.CreateLedger("testchannel")
+	commitTestBlocks(t, l)
+
+	// the history of key1 is restricted while its current state remains readable
+	var checkedCreator []byte
+	client, grpcServer := startServer(t, l, &mockpolicies.Policy{}, func(creator []byte, channelID string, namespace string, key string) error {
+		checkedCreator = creator
+		testutil.AssertEquals(t, channelID, "testchannel")
+		testutil.AssertEquals(t, namespace, "ns")
+		if key == "key1" {
+			return fmt.Errorf("Restricted key")
+		}
+		return nil
+	})
+	defer grpcServer.Stop()
+
+	kv, err := client.GetState(context.Background(), signedRequest(t, "testchannel", &pb.StateKeyRequest{Namespace: "ns", Key: "key1"}))
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, kv.Value, []byte("value1_1"))
+
+	historyStream, err := client.GetHistoryForKey(context.Background(), signedRequest(t, "testchannel", &pb.StateKeyRequest{Namespace: "ns", Key: "key1"}))
+	testutil.AssertNoError(t, err, "")
+	_, err = historyStream.Recv()
+	testutil.AssertError(t, err, "Expected an error for a restricted history")
+	testutil.AssertEquals(t, checkedCreator, mockcrypto.FakeLocalSigner.Identity)
+
+	_, err = client.GetStateAsOf(context.Background(), signedRequest(t, "testchannel", &pb.StateAsOfRequest{Namespace: "ns", Key: "key1", BlockHeight: 1}))
+	testutil.AssertError(t, err, "Expected an error for a restricted history")
+
+	historyStream, err = client.GetHistoryForKey(context.Background(), signedRequest(t, "testchannel", &pb.StateKeyRequest{Namespace: "ns", Key: "key2"}))
+	testutil.AssertNoError(t, err, "")
+	modification, err := historyStream.Recv()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertNotEquals(t, len(modification.Value), 0)
+}
+
+// commitTestBlocks commits two blocks that set the keys key0 to key2 of namespace ns
+func commitTestBlocks(t *testing.T, l ledger.PeerLedger) {
+	bg := testutil.NewBlockGenerator(t)
+	for i := 0; i < 2; i++ {
+		simulator, _ := l.NewTxSimulator()
+		for j := 0; j < 3; j++ {
+			simulator.SetState("ns", fmt.Sprintf("key%d", j), []byte(fmt.Sprintf("value%d_%d", i, j)))
+		}
+		simulator.Done()
+		simRes, _ := simulator.GetTxSimulationResults()
+		testutil.AssertNoError(t, l.Commit(bg.NextBlock([][]byte{simRes}, false)), "")
+	}
+}
+
+// startServer serves the given ledger for every channel and authorizes the requests with the given policy and history access check
+func startServer(t *testing.T, l ledger.PeerLedger, policy *mockpolicies.Policy, historyAccessCheck HistoryAccessCheck) (pb.StateQueryClient, *grpc.Server) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	testutil.AssertNoError(t, err, "")
+	grpcServer := grpc.NewServer()
+	server := NewServer(
+		func(channelID string) ledger.PeerLedger {
+			if channelID == "unknownchannel" {
+				return nil
+			}
+			return l
+		},
+		func(channelID string) policies.Manager {
+			return &mockpolicies.Manager{Policy: policy}
+		},
+		historyAccessCheck)
+	pb.RegisterStateQueryServer(grpcServer, server)
+	go grpcServer.Serve(lis)
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure(), grpc.WithBlock(), grpc.WithTimeout(3*time.Second))
+	testutil.AssertNoError(t, err, "")
+	return pb.NewStateQueryClient(conn), grpcServer
+}
+
+func signedRequest(t *testing.T, channelID string, request proto.Message) *common.Envelope {
+	env, err := utils.CreateSignedEnvelope(common.HeaderType_MESSAGE, channelID, mockcrypto.FakeLocalSigner, request, 0, 0)
+	testutil.AssertNoError(t, err, "")
+	return env
+}
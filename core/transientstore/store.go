@@ -0,0 +1,198 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transientstore
+
+import (
+	"github.com/hyperledger/fabric/common/ledger/util"
+	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+	logging "github.com/op/go-logging"
+)
+
+var logger = logging.MustGetLogger("transientstore")
+
+var (
+	dataKeyPrefix   = []byte{0x01}
+	heightKeyPrefix = []byte{0x02}
+	txIDSep         = []byte{0x00}
+	emptyValue      = []byte{}
+)
+
+// Entry is the data persisted for a transaction along with the height of the ledger at the time it was persisted
+type Entry struct {
+	BlockHeight uint64
+	Data        []byte
+}
+
+// Provider provides handles to the transient stores of the ledgers. It is registered with the ledger as a
+// commit decorator so that the data of a transaction is purged once the transaction is committed, and the data
+// that outlives the configured number of blocks is purged regardless, e.g., for the transactions that never commit
+type Provider struct {
+	dbProvider   *leveldbhelper.Provider
+	blocksToLive uint64
+}
+
+// NewProvider constructs a Provider whose data expires after the given number of blocks
+func NewProvider(blocksToLive uint64) *Provider {
+	dbPath := ledgerconfig.GetTransientStorePath()
+	logger.Debugf("constructing transient store Provider dbPath=%s", dbPath)
+	return &Provider{leveldbhelper.NewProvider(&leveldbhelper.Conf{DBPath: dbPath}), blocksToLive}
+}
+
+// OpenStore returns the transient store of the given ledger
+func (p *Provider) OpenStore(ledgerID string) *Store {
+	return &Store{ledgerID: ledgerID, db: p.dbProvider.GetDBHandle(ledgerID), blocksToLive: p.blocksToLive}
+}
+
+// Name implements method in interface ledger.CommitDecoratorProvider
+func (p *Provider) Name() string {
+	return "transientStore"
+}
+
+// NewDecorator implements method in interface ledger.CommitDecoratorProvider
+func (p *Provider) NewDecorator(ledgerID string) (ledger.CommitDecorator, error) {
+	return p.OpenStore(ledgerID), nil
+}
+
+// Drop implements method in interface ledger.CommitDecoratorProvider. The transient data is not derived
+// from the blocks, hence it is kept and only the committed or expired data is purged as the blocks are delivered again
+func (p *Provider) Drop(ledgerID string) error {
+	return nil
+}
+
+// Close closes the underlying db
+func (p *Provider) Close() {
+	p.dbProvider.Close()
+}
+
+// Store maintains the data that the endorsers stash for a transaction until it is committed, keyed by the txID.
+// A transaction may have more than one entry, one per height at which the data was persisted
+type Store struct {
+	ledgerID     string
+	db           *leveldbhelper.DBHandle
+	blocksToLive uint64
+}
+
+// Persist stores the data of the given transaction. The blockHeight is the height of the ledger at the time of the
+// endorsement and is used for computing the expiry of the data. Persisting again at the same height replaces the data
+func (s *Store) Persist(txID string, blockHeight uint64, data []byte) error {
+	batch := leveldbhelper.NewUpdateBatch()
+	batch.Put(encodeDataKey(txID, blockHeight), data)
+	batch.Put(encodeHeightKey(blockHeight, txID), emptyValue)
+	return s.db.WriteBatch(batch, true)
+}
+
+// GetByTxID returns the entries of the given transaction in the order of the height
+func (s *Store) GetByTxID(txID string) ([]*Entry, error) {
+	prefix := constructTxIDPrefix(txID)
+	itr := s.db.GetIterator(prefix, append(append([]byte{}, prefix[:len(prefix)-1]...), txIDSep[0]+1))
+	defer itr.Release()
+	var entries []*Entry
+	for itr.Next() {
+		blockHeight, _ := util.DecodeOrderPreservingVarUint64(itr.Key()[len(prefix):])
+		data := make([]byte, len(itr.Value()))
+		copy(data, itr.Value())
+		entries = append(entries, &Entry{BlockHeight: blockHeight, Data: data})
+	}
+	if err := itr.Error(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// PurgeByTxIDs removes all the entries of the given transactions
+func (s *Store) PurgeByTxIDs(txIDs []string) error {
+	batch := leveldbhelper.NewUpdateBatch()
+	for _, txID := range txIDs {
+		entries, err := s.GetByTxID(txID)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			batch.Delete(encodeDataKey(txID, entry.BlockHeight))
+			batch.Delete(encodeHeightKey(entry.BlockHeight, txID))
+		}
+	}
+	return s.db.WriteBatch(batch, true)
+}
+
+// PurgeByHeight removes the entries persisted below the given height
+func (s *Store) PurgeByHeight(minHeightToRetain uint64) error {
+	itr := s.db.GetIterator(heightKeyPrefix, constructHeightPrefix(minHeightToRetain))
+	defer itr.Release()
+	batch := leveldbhelper.NewUpdateBatch()
+	for itr.Next() {
+		blockHeight, txID := decodeHeightKey(itr.Key())
+		batch.Delete(encodeDataKey(txID, blockHeight))
+		batch.Delete(encodeHeightKey(blockHeight, txID))
+	}
+	if err := itr.Error(); err != nil {
+		return err
+	}
+	return s.db.WriteBatch(batch, true)
+}
+
+// HandleCommit implements method in interface ledger.CommitDecorator. The entries of the committed transactions
+// are purged along with the expired entries. An entry persisted at height h expires with the commit of the
+// block h+blocksToLive-1. Purging is idempotent, hence a block delivered again is handled as well
+func (s *Store) HandleCommit(event *ledger.CommitEvent) error {
+	var txIDs []string
+	for _, txWriteSet := range event.TxWriteSets {
+		// the write set of the expiries of the block does not belong to a transaction
+		if txWriteSet.TxID != "" {
+			txIDs = append(txIDs, txWriteSet.TxID)
+		}
+	}
+	if err := s.PurgeByTxIDs(txIDs); err != nil {
+		return err
+	}
+	if event.BlockNumber+2 > s.blocksToLive {
+		minHeightToRetain := event.BlockNumber + 2 - s.blocksToLive
+		logger.Debugf("Channel [%s]: Purging transient data below height [%d]", s.ledgerID, minHeightToRetain)
+		return s.PurgeByHeight(minHeightToRetain)
+	}
+	return nil
+}
+
+// Close implements method in interface ledger.CommitDecorator. The underlying db is closed by the Provider
+func (s *Store) Close() {
+}
+
+func encodeDataKey(txID string, blockHeight uint64) []byte {
+	return append(constructTxIDPrefix(txID), util.EncodeOrderPreservingVarUint64(blockHeight)...)
+}
+
+// constructTxIDPrefix returns the prefix that is shared by the data keys of all the entries of a transaction
+func constructTxIDPrefix(txID string) []byte {
+	prefix := append(append([]byte{}, dataKeyPrefix...), []byte(txID)...)
+	return append(prefix, txIDSep...)
+}
+
+func encodeHeightKey(blockHeight uint64, txID string) []byte {
+	return append(constructHeightPrefix(blockHeight), []byte(txID)...)
+}
+
+func decodeHeightKey(encKey []byte) (uint64, string) {
+	blockHeight, n := util.DecodeOrderPreservingVarUint64(encKey[len(heightKeyPrefix):])
+	return blockHeight, string(encKey[len(heightKeyPrefix)+n:])
+}
+
+// constructHeightPrefix returns the prefix that is shared by the height keys of all the entries persisted at a height
+func constructHeightPrefix(blockHeight uint64) []byte {
+	return append(append([]byte{}, heightKeyPrefix...), util.EncodeOrderPreservingVarUint64(blockHeight)...)
+}
@@ -0,0 +1,66 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transientstore
+
+import (
+	"os"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+	"github.com/spf13/viper"
+)
+
+func TestMain(m *testing.M) {
+	viper.Set("peer.fileSystemPath", "/tmp/fabric/transientstoretests")
+	os.Exit(m.Run())
+}
+
+func TestPersistAndPurge(t *testing.T) {
+	os.RemoveAll(ledgerconfig.GetRootPath())
+	defer os.RemoveAll(ledgerconfig.GetRootPath())
+	provider := NewProvider(2)
+	defer provider.Close()
+	store := provider.OpenStore("testLedger")
+
+	testutil.AssertNoError(t, store.Persist("tx1", 5, []byte("data1-h5")), "")
+	testutil.AssertNoError(t, store.Persist("tx1", 6, []byte("data1-h6")), "")
+	testutil.AssertNoError(t, store.Persist("tx10", 5, []byte("data10")), "")
+	testutil.AssertNoError(t, store.Persist("tx2", 6, []byte("data2")), "")
+	entries, err := store.GetByTxID("tx1")
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, entries, []*Entry{{5, []byte("data1-h5")}, {6, []byte("data1-h6")}})
+	// the stores of the ledgers are isolated
+	entries, _ = provider.OpenStore("otherLedger").GetByTxID("tx1")
+	testutil.AssertEquals(t, len(entries), 0)
+
+	// the commit of tx1 purges its entries only
+	testutil.AssertNoError(t, store.HandleCommit(&ledger.CommitEvent{LedgerID: "testLedger", BlockNumber: 5,
+		TxWriteSets: []*ledger.TxWriteSet{{TxID: "tx1"}}}), "")
+	entries, _ = store.GetByTxID("tx1")
+	testutil.AssertEquals(t, len(entries), 0)
+	entries, _ = store.GetByTxID("tx10")
+	testutil.AssertEquals(t, len(entries), 1)
+
+	// the entries persisted at height 5 expire at the commit of block 6
+	testutil.AssertNoError(t, store.HandleCommit(&ledger.CommitEvent{LedgerID: "testLedger", BlockNumber: 6}), "")
+	entries, _ = store.GetByTxID("tx10")
+	testutil.AssertEquals(t, len(entries), 0)
+	entries, _ = store.GetByTxID("tx2")
+	testutil.AssertEquals(t, entries, []*Entry{{6, []byte("data2")}})
+}
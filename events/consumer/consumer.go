@@ -36,6 +36,7 @@ type EventsClient struct {
 	regTimeout  time.Duration
 	stream      ehpb.Events_ChatClient
 	adapter     EventAdapter
+	consumerID  string
 }
 
 //NewEventsClient Returns a new grpc.ClientConn to the configured local PEER.
@@ -48,7 +49,14 @@ func NewEventsClient(peerAddress string, regTimeout time.Duration, adapter Event
 		regTimeout = 60 * time.Second
 		err = fmt.Errorf("regTimeout > 60, setting to 60 sec")
 	}
-	return &EventsClient{sync.RWMutex{}, peerAddress, regTimeout, nil, adapter}, err
+	return &EventsClient{sync.RWMutex{}, peerAddress, regTimeout, nil, adapter, ""}, err
+}
+
+// SetConsumerID sets the id the client registers with. When it is set, the peer resumes the
+// BLOCK and FILTEREDBLOCK events of the interests with a chainID after the cursor committed
+// under this id, so it must be set before Start
+func (ec *EventsClient) SetConsumerID(consumerID string) {
+	ec.consumerID = consumerID
 }
 
 //newEventsClientConnectionWithAddress Returns a new grpc.ClientConn to the configured local PEER.
@@ -67,7 +75,7 @@ func (ec *EventsClient) send(emsg *ehpb.Event) error {
 
 // RegisterAsync - registers interest in a event and doesn't wait for a response
 func (ec *EventsClient) RegisterAsync(ies []*ehpb.Interest) error {
-	emsg := &ehpb.Event{Event: &ehpb.Event_Register{Register: &ehpb.Register{Events: ies}}}
+	emsg := &ehpb.Event{Event: &ehpb.Event_Register{Register: &ehpb.Register{Events: ies, ConsumerId: ec.consumerID}}}
 	var err error
 	if err = ec.send(emsg); err != nil {
 		fmt.Printf("error on Register send %s\n", err)
@@ -148,6 +156,32 @@ func (ec *EventsClient) unregister(ies []*ehpb.Interest) error {
 	return err
 }
 
+// CommitCursorAsync records blockNum as the last block of the channel processed by the
+// consumer, and doesn't wait for the response. The peer responds with a Cursor event
+func (ec *EventsClient) CommitCursorAsync(chainID string, blockNum uint64) error {
+	if ec.consumerID == "" {
+		return fmt.Errorf("consumer id must be set to commit the cursor")
+	}
+	emsg := &ehpb.Event{Event: &ehpb.Event_CursorCommit{CursorCommit: &ehpb.CursorCommit{ConsumerId: ec.consumerID, ChannelId: chainID, BlockNumber: blockNum}}}
+	if err := ec.send(emsg); err != nil {
+		return fmt.Errorf("error on cursor commit send %s", err)
+	}
+	return nil
+}
+
+// RequestCursorAsync requests the cursor of the consumer for the channel, and doesn't
+// wait for the response. The peer responds with a Cursor event
+func (ec *EventsClient) RequestCursorAsync(chainID string) error {
+	if ec.consumerID == "" {
+		return fmt.Errorf("consumer id must be set to request the cursor")
+	}
+	emsg := &ehpb.Event{Event: &ehpb.Event_CursorRequest{CursorRequest: &ehpb.CursorRequest{ConsumerId: ec.consumerID, ChannelId: chainID}}}
+	if err := ec.send(emsg); err != nil {
+		return fmt.Errorf("error on cursor request send %s", err)
+	}
+	return nil
+}
+
 // Recv recieves next event - use when client has not called Start
 func (ec *EventsClient) Recv() (*ehpb.Event, error) {
 	in, err := ec.stream.Recv()
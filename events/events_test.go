@@ -18,6 +18,7 @@ package events
 
 import (
 	"fmt"
+	"io/ioutil"
 	"net"
 	"os"
 	"sync"
@@ -45,6 +46,7 @@ type Adapter struct {
 var peerAddress string
 var adapter *Adapter
 var obcEHClient *consumer.EventsClient
+var testLedger = &mockBlocksReader{}
 
 func (a *Adapter) GetInterestedEvents() ([]*ehpb.Interest, error) {
 	return []*ehpb.Interest{
@@ -66,7 +68,7 @@ func (a *Adapter) updateCountNotify() {
 
 func (a *Adapter) Recv(msg *ehpb.Event) (bool, error) {
 	switch x := msg.Event.(type) {
-	case *ehpb.Event_Block, *ehpb.Event_ChaincodeEvent, *ehpb.Event_Register, *ehpb.Event_Unregister:
+	case *ehpb.Event_Block, *ehpb.Event_FilteredBlock, *ehpb.Event_ChaincodeEvent, *ehpb.Event_Register, *ehpb.Event_Unregister:
 		a.updateCountNotify()
 	case nil:
 		// The field is not set.
@@ -142,6 +144,71 @@ func createTestBlock(t *testing.T) *common.Block {
 	return block
 }
 
+type mockBlocksReader struct {
+	sync.RWMutex
+	blocks []*common.Block
+}
+
+func (r *mockBlocksReader) GetBlockchainInfo() (*common.BlockchainInfo, error) {
+	r.RLock()
+	defer r.RUnlock()
+	return &common.BlockchainInfo{Height: uint64(len(r.blocks))}, nil
+}
+
+func (r *mockBlocksReader) GetBlockByNumber(blockNumber uint64) (*common.Block, error) {
+	r.RLock()
+	defer r.RUnlock()
+	if blockNumber >= uint64(len(r.blocks)) {
+		return nil, fmt.Errorf("block %d not found", blockNumber)
+	}
+	return r.blocks[blockNumber], nil
+}
+
+func (r *mockBlocksReader) commit(t *testing.T) *common.Block {
+	r.Lock()
+	defer r.Unlock()
+	block := createTestBlock(t)
+	block.Header.Number = uint64(len(r.blocks))
+	r.blocks = append(r.blocks, block)
+	return block
+}
+
+// cursorAdapter registers for the filtered blocks of channel "test" and forwards the events it receives
+type cursorAdapter struct {
+	events chan *ehpb.Event
+}
+
+func (a *cursorAdapter) GetInterestedEvents() ([]*ehpb.Interest, error) {
+	return []*ehpb.Interest{&ehpb.Interest{EventType: ehpb.EventType_FILTEREDBLOCK, ChainID: "test"}}, nil
+}
+
+func (a *cursorAdapter) Recv(msg *ehpb.Event) (bool, error) {
+	a.events <- msg
+	return true, nil
+}
+
+func (a *cursorAdapter) Disconnected(err error) {}
+
+func (a *cursorAdapter) next(t *testing.T) *ehpb.Event {
+	select {
+	case e := <-a.events:
+		return e
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out on message")
+	}
+	return nil
+}
+
+func startCursorClient(t *testing.T, consumerID string) (*consumer.EventsClient, *cursorAdapter) {
+	a := &cursorAdapter{events: make(chan *ehpb.Event, 10)}
+	client, _ := consumer.NewEventsClient(peerAddress, 5*time.Second, a)
+	client.SetConsumerID(consumerID)
+	if err := client.Start(); err != nil {
+		t.Fatalf("could not start chat %s", err)
+	}
+	return client, a
+}
+
 func createTestChaincodeEvent(tid string, typ string) *ehpb.Event {
 	emsg := producer.CreateChaincodeEvent(&ehpb.ChaincodeEvent{ChaincodeId: tid, EventName: typ})
 	return emsg
@@ -234,6 +301,44 @@ func TestReceiveCCWildcard(t *testing.T) {
 	}
 }
 
+func TestReceiveFilteredBlock(t *testing.T) {
+	block := createTestBlock(t)
+	fblock := producer.CreateFilteredBlock(block)
+	if fblock.ChannelId != "test" || fblock.Number != block.Header.Number || len(fblock.FilteredTx) != 1 {
+		t.Fatalf("Unexpected filtered block %s", fblock)
+	}
+
+	adapter.count = 1
+	obcEHClient.RegisterAsync([]*ehpb.Interest{&ehpb.Interest{EventType: ehpb.EventType_FILTEREDBLOCK}})
+	select {
+	case <-adapter.notfy:
+	case <-time.After(2 * time.Second):
+		t.Fail()
+		t.Logf("timed out on messge")
+	}
+
+	adapter.count = 1
+	if err := producer.SendProducerFilteredBlockEvent(block); err != nil {
+		t.Fail()
+		t.Logf("Error sending message %s", err)
+	}
+	select {
+	case <-adapter.notfy:
+	case <-time.After(2 * time.Second):
+		t.Fail()
+		t.Logf("timed out on messge")
+	}
+
+	adapter.count = 1
+	obcEHClient.UnregisterAsync([]*ehpb.Interest{&ehpb.Interest{EventType: ehpb.EventType_FILTEREDBLOCK}})
+	select {
+	case <-adapter.notfy:
+	case <-time.After(2 * time.Second):
+		t.Fail()
+		t.Logf("timed out on messge")
+	}
+}
+
 func TestFailReceive(t *testing.T) {
 	var err error
 
@@ -302,6 +407,62 @@ func TestUnregister(t *testing.T) {
 
 }
 
+func TestConsumerCursor(t *testing.T) {
+	for i := 0; i < 3; i++ {
+		testLedger.commit(t)
+	}
+
+	client, a := startCursorClient(t, "consumer1")
+	if err := client.RequestCursorAsync("test"); err != nil {
+		t.Fatalf("Error requesting cursor %s", err)
+	}
+	if cursor := a.next(t).GetCursor(); cursor == nil || cursor.Exists {
+		t.Fatalf("Expected no cursor, got %v", cursor)
+	}
+	if err := client.CommitCursorAsync("test", 0); err != nil {
+		t.Fatalf("Error committing cursor %s", err)
+	}
+	if cursor := a.next(t).GetCursor(); cursor == nil || !cursor.Exists || cursor.BlockNumber != 0 || cursor.ChannelId != "test" {
+		t.Fatalf("Unexpected cursor %v", cursor)
+	}
+	client.RequestCursorAsync("test")
+	if cursor := a.next(t).GetCursor(); cursor == nil || !cursor.Exists || cursor.BlockNumber != 0 {
+		t.Fatalf("Unexpected cursor %v", cursor)
+	}
+	client.Stop()
+
+	// the reconnecting consumer receives the blocks after its cursor, then the new blocks only once
+	client, a = startCursorClient(t, "consumer1")
+	defer client.Stop()
+	for _, expected := range []uint64{1, 2} {
+		if fblock := a.next(t).GetFilteredBlock(); fblock == nil || fblock.Number != expected || fblock.ChannelId != "test" {
+			t.Fatalf("Expected replayed filtered block %d, got %v", expected, fblock)
+		}
+	}
+	if err := producer.SendProducerFilteredBlockEvent(testLedger.blocks[2]); err != nil {
+		t.Fatalf("Error sending message %s", err)
+	}
+	if err := producer.SendProducerFilteredBlockEvent(testLedger.commit(t)); err != nil {
+		t.Fatalf("Error sending message %s", err)
+	}
+	if fblock := a.next(t).GetFilteredBlock(); fblock == nil || fblock.Number != 3 {
+		t.Fatalf("Expected filtered block 3, got %v", fblock)
+	}
+
+	// a consumer without a cursor only receives the new blocks
+	other, b := startCursorClient(t, "consumer2")
+	defer other.Stop()
+	if err := producer.SendProducerFilteredBlockEvent(testLedger.commit(t)); err != nil {
+		t.Fatalf("Error sending message %s", err)
+	}
+	if fblock := b.next(t).GetFilteredBlock(); fblock == nil || fblock.Number != 4 {
+		t.Fatalf("Expected filtered block 4, got %v", fblock)
+	}
+	if fblock := a.next(t).GetFilteredBlock(); fblock == nil || fblock.Number != 4 {
+		t.Fatalf("Expected filtered block 4, got %v", fblock)
+	}
+}
+
 func BenchmarkMessages(b *testing.B) {
 	numMessages := 10000
 
@@ -354,6 +515,17 @@ func TestMain(m *testing.M) {
 	// Register EventHub server
 	// use a buffer of 100 and blocking timeout
 	ehServer := producer.NewEventsServer(100, 0)
+	cursorsPath, err := ioutil.TempDir("", "eventcursors")
+	if err != nil {
+		fmt.Printf("Error creating the cursors directory %s....not doing tests", err)
+		return
+	}
+	ehServer.EnableCursors(producer.NewCursorStore(cursorsPath), func(chainID string) producer.BlocksReader {
+		if chainID == "test" {
+			return testLedger
+		}
+		return nil
+	})
 	ehpb.RegisterEventsServer(grpcServer, ehServer)
 
 	fmt.Printf("Starting events server\n")
@@ -371,5 +543,7 @@ func TestMain(m *testing.M) {
 
 	time.Sleep(2 * time.Second)
 
-	os.Exit(m.Run())
+	code := m.Run()
+	os.RemoveAll(cursorsPath)
+	os.Exit(code)
 }
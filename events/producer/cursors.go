@@ -0,0 +1,128 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package producer
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
+	"github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// BlocksReader reads the committed blocks of a channel. It is implemented by ledger.PeerLedger
+type BlocksReader interface {
+	GetBlockchainInfo() (*common.BlockchainInfo, error)
+	GetBlockByNumber(blockNumber uint64) (*common.Block, error)
+}
+
+// LedgerGetter returns the blocks reader of a channel, or nil if the peer has not joined the channel
+type LedgerGetter func(chainID string) BlocksReader
+
+// CursorStore persists the delivery cursors of the consumers, that is for each consumer
+// and channel the number of the last block the consumer has processed
+type CursorStore struct {
+	db *leveldbhelper.DB
+}
+
+// NewCursorStore opens the cursor store at the given path
+func NewCursorStore(dbPath string) *CursorStore {
+	db := leveldbhelper.CreateDB(&leveldbhelper.Conf{DBPath: dbPath})
+	db.Open()
+	return &CursorStore{db}
+}
+
+// CommitCursor records blockNum as the last block of the channel processed by the consumer
+func (s *CursorStore) CommitCursor(consumerID, chainID string, blockNum uint64) error {
+	value := make([]byte, 8)
+	binary.BigEndian.PutUint64(value, blockNum)
+	return s.db.Put(encodeCursorKey(consumerID, chainID), value, true)
+}
+
+// GetCursor returns the last block of the channel processed by the consumer. The returned
+// bool is false if the consumer has not committed a cursor for the channel
+func (s *CursorStore) GetCursor(consumerID, chainID string) (uint64, bool, error) {
+	value, err := s.db.Get(encodeCursorKey(consumerID, chainID))
+	if err != nil || value == nil {
+		return 0, false, err
+	}
+	if len(value) != 8 {
+		return 0, false, fmt.Errorf("invalid cursor of consumer [%s] for channel [%s]", consumerID, chainID)
+	}
+	return binary.BigEndian.Uint64(value), true, nil
+}
+
+// Close closes the cursor store
+func (s *CursorStore) Close() {
+	s.db.Close()
+}
+
+func encodeCursorKey(consumerID, chainID string) []byte {
+	key := append([]byte(chainID), 0x00)
+	return append(key, []byte(consumerID)...)
+}
+
+// resumePoint is the first block of a channel replayed to a consumer that registers with
+// a committed cursor, along with the event types the consumer registered for the channel
+type resumePoint struct {
+	chainID    string
+	blockNum   uint64
+	eventTypes []pb.EventType
+}
+
+// getResumePoints returns the channels for which the BLOCK and FILTEREDBLOCK events after the
+// cursor of the consumer are replayed on registration
+func getResumePoints(cursors *CursorStore, reg *pb.Register) ([]*resumePoint, error) {
+	if cursors == nil || reg.ConsumerId == "" {
+		return nil, nil
+	}
+	var points []*resumePoint
+	byChain := make(map[string]*resumePoint)
+	for _, interest := range reg.Events {
+		if interest.ChainID == "" || (interest.EventType != pb.EventType_BLOCK && interest.EventType != pb.EventType_FILTEREDBLOCK) {
+			continue
+		}
+		if p, ok := byChain[interest.ChainID]; ok {
+			p.eventTypes = append(p.eventTypes, interest.EventType)
+			continue
+		}
+		blockNum, exists, err := cursors.GetCursor(reg.ConsumerId, interest.ChainID)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			continue
+		}
+		p := &resumePoint{chainID: interest.ChainID, blockNum: blockNum + 1, eventTypes: []pb.EventType{interest.EventType}}
+		byChain[interest.ChainID] = p
+		points = append(points, p)
+	}
+	return points, nil
+}
+
+// createReplayedEvent creates the event of the given type for a block read from the ledger
+func createReplayedEvent(eventType pb.EventType, block *common.Block) (*pb.Event, error) {
+	if eventType == pb.EventType_FILTEREDBLOCK {
+		return CreateFilteredBlockEvent(CreateFilteredBlock(block)), nil
+	}
+	bevent, err := createEventBlock(block)
+	if err != nil {
+		return nil, err
+	}
+	return CreateBlockEvent(bevent), nil
+}
@@ -19,6 +19,7 @@ package producer
 import (
 	"fmt"
 
+	"github.com/hyperledger/fabric/core/ledger/util"
 	"github.com/hyperledger/fabric/protos/common"
 	pb "github.com/hyperledger/fabric/protos/peer"
 	"github.com/hyperledger/fabric/protos/utils"
@@ -33,6 +34,16 @@ func init() {
 
 // SendProducerBlockEvent sends block event to clients
 func SendProducerBlockEvent(block *common.Block) error {
+	bevent, err := createEventBlock(block)
+	if err != nil {
+		return err
+	}
+	return Send(CreateBlockEvent(bevent))
+}
+
+// createEventBlock returns the block sent in the block events, that is the block
+// with the read write sets dropped from the endorser transactions
+func createEventBlock(block *common.Block) (*common.Block, error) {
 	bevent := &common.Block{}
 	bevent.Header = block.Header
 	bevent.Metadata = block.Metadata
@@ -46,31 +57,31 @@ func SendProducerBlockEvent(block *common.Block) error {
 				// get the payload from the envelope
 				payload, err := utils.GetPayload(env)
 				if err != nil {
-					return fmt.Errorf("Could not extract payload from envelope, err %s", err)
+					return nil, fmt.Errorf("Could not extract payload from envelope, err %s", err)
 				}
 
 				chdr, err := utils.UnmarshalChannelHeader(payload.Header.ChannelHeader)
 				if err != nil {
-					return err
+					return nil, err
 				}
 
 				if common.HeaderType(chdr.Type) == common.HeaderType_ENDORSER_TRANSACTION {
 					tx, err := utils.GetTransaction(payload.Data)
 					if err != nil {
-						return fmt.Errorf("Error unmarshalling transaction payload for block event: %s", err)
+						return nil, fmt.Errorf("Error unmarshalling transaction payload for block event: %s", err)
 					}
 					chaincodeActionPayload, err := utils.GetChaincodeActionPayload(tx.Actions[0].Payload)
 					if err != nil {
-						return fmt.Errorf("Error unmarshalling transaction action payload for block event: %s", err)
+						return nil, fmt.Errorf("Error unmarshalling transaction action payload for block event: %s", err)
 					}
 					propRespPayload, err := utils.GetProposalResponsePayload(chaincodeActionPayload.Action.ProposalResponsePayload)
 					if err != nil {
-						return fmt.Errorf("Error unmarshalling proposal response payload for block event: %s", err)
+						return nil, fmt.Errorf("Error unmarshalling proposal response payload for block event: %s", err)
 					}
 					//ENDORSER_ACTION, ProposalResponsePayload.Extension field contains ChaincodeAction
 					caPayload, err := utils.GetChaincodeAction(propRespPayload.Extension)
 					if err != nil {
-						return fmt.Errorf("Error unmarshalling chaincode action for block event: %s", err)
+						return nil, fmt.Errorf("Error unmarshalling chaincode action for block event: %s", err)
 					}
 					// Drop read write set from transaction before sending block event
 					// Performance issue with chaincode deploy txs and causes nodejs grpc
@@ -80,30 +91,91 @@ func SendProducerBlockEvent(block *common.Block) error {
 					caPayload.Results = nil
 					chaincodeActionPayload.Action.ProposalResponsePayload, err = utils.GetBytesProposalResponsePayload(propRespPayload.ProposalHash, caPayload.Response, caPayload.Results, caPayload.Events)
 					if err != nil {
-						return fmt.Errorf("Error marshalling tx proposal payload for block event: %s", err)
+						return nil, fmt.Errorf("Error marshalling tx proposal payload for block event: %s", err)
 					}
 					tx.Actions[0].Payload, err = utils.GetBytesChaincodeActionPayload(chaincodeActionPayload)
 					if err != nil {
-						return fmt.Errorf("Error marshalling tx action payload for block event: %s", err)
+						return nil, fmt.Errorf("Error marshalling tx action payload for block event: %s", err)
 					}
 					payload.Data, err = utils.GetBytesTransaction(tx)
 					if err != nil {
-						return fmt.Errorf("Error marshalling payload for block event: %s", err)
+						return nil, fmt.Errorf("Error marshalling payload for block event: %s", err)
 					}
 					env.Payload, err = utils.GetBytesPayload(payload)
 					if err != nil {
-						return fmt.Errorf("Error marshalling tx envelope for block event: %s", err)
+						return nil, fmt.Errorf("Error marshalling tx envelope for block event: %s", err)
 					}
 					ebytes, err = utils.GetBytesEnvelope(env)
 					if err != nil {
-						return fmt.Errorf("Cannot marshal transaction %s", err)
+						return nil, fmt.Errorf("Cannot marshal transaction %s", err)
 					}
 				}
 			}
 		}
 		bevent.Data.Data = append(bevent.Data.Data, ebytes)
 	}
-	return Send(CreateBlockEvent(bevent))
+	return bevent, nil
+}
+
+// SendProducerFilteredBlockEvent sends the filtered block event of the block to the clients
+func SendProducerFilteredBlockEvent(block *common.Block) error {
+	return Send(CreateFilteredBlockEvent(CreateFilteredBlock(block)))
+}
+
+// CreateFilteredBlock computes the filtered block of the committed block. A transaction that cannot be
+// decoded, which the validation has marked invalid, is reported with its validation code and an empty txid
+func CreateFilteredBlock(block *common.Block) *pb.FilteredBlock {
+	fblock := &pb.FilteredBlock{Number: block.Header.Number}
+	var txsFilter util.TxValidationFlags
+	if block.Metadata != nil && len(block.Metadata.Metadata) > int(common.BlockMetadataIndex_TRANSACTIONS_FILTER) {
+		txsFilter = util.TxValidationFlags(block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER])
+	}
+	for txIndex, ebytes := range block.Data.Data {
+		ftx := &pb.FilteredTransaction{}
+		if txIndex < len(txsFilter) {
+			ftx.TxValidationCode = txsFilter.Flag(txIndex)
+		}
+		channelID, err := decodeFilteredTransaction(ebytes, ftx)
+		if err != nil {
+			logger.Debugf("Block [%d]: could not decode transaction [%d] for the filtered block: %s", block.Header.Number, txIndex, err)
+		}
+		if fblock.ChannelId == "" {
+			fblock.ChannelId = channelID
+		}
+		fblock.FilteredTx = append(fblock.FilteredTx, ftx)
+	}
+	return fblock
+}
+
+// decodeFilteredTransaction sets the txid and the chaincode name of the filtered transaction from the
+// transaction envelope, and returns the channel of the transaction
+func decodeFilteredTransaction(ebytes []byte, ftx *pb.FilteredTransaction) (string, error) {
+	env, err := utils.GetEnvelopeFromBlock(ebytes)
+	if err != nil {
+		return "", err
+	}
+	payload, err := utils.GetPayload(env)
+	if err != nil {
+		return "", err
+	}
+	if payload.Header == nil {
+		return "", fmt.Errorf("transaction payload does not carry a header")
+	}
+	chdr, err := utils.UnmarshalChannelHeader(payload.Header.ChannelHeader)
+	if err != nil {
+		return "", err
+	}
+	ftx.Txid = chdr.TxId
+	if common.HeaderType(chdr.Type) == common.HeaderType_ENDORSER_TRANSACTION {
+		hdrExt, err := utils.GetChaincodeHeaderExtension(payload.Header)
+		if err != nil {
+			return chdr.ChannelId, err
+		}
+		if hdrExt.ChaincodeId != nil {
+			ftx.ChaincodeName = hdrExt.ChaincodeId.Name
+		}
+	}
+	return chdr.ChannelId, nil
 }
 
 //CreateBlockEvent creates a Event from a Block
@@ -111,6 +183,11 @@ func CreateBlockEvent(te *common.Block) *pb.Event {
 	return &pb.Event{Event: &pb.Event_Block{Block: te}}
 }
 
+// CreateFilteredBlockEvent creates an Event from a FilteredBlock
+func CreateFilteredBlockEvent(fblock *pb.FilteredBlock) *pb.Event {
+	return &pb.Event{Event: &pb.Event_FilteredBlock{FilteredBlock: fblock}}
+}
+
 //CreateChaincodeEvent creates a Event from a ChaincodeEvent
 func CreateChaincodeEvent(te *pb.ChaincodeEvent) *pb.Event {
 	return &pb.Event{Event: &pb.Event_ChaincodeEvent{ChaincodeEvent: te}}
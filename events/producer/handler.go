@@ -19,18 +19,36 @@ package producer
 import (
 	"fmt"
 	"strconv"
+	"sync"
 
 	pb "github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric/protos/utils"
 )
 
 type handler struct {
 	ChatStream       pb.Events_ChatServer
 	interestedEvents map[string]*pb.Interest
+	server           *EventsServer
+
+	// sendLock serializes the sends of the chat goroutine and of the event processor
+	sendLock sync.Mutex
+
+	// replayLock guards the fields below. While the blocks after the cursor of the
+	// consumer are replayed, the events from the event processor are held and sent
+	// after the replay. replayedHeights holds, for each replayed channel, the height
+	// up to which the block events have been replayed, so that the events of the
+	// replayed blocks are not sent twice
+	replayLock      sync.Mutex
+	replaying       bool
+	heldEvents      []*pb.Event
+	replayedHeights map[string]uint64
 }
 
-func newEventHandler(stream pb.Events_ChatServer) (*handler, error) {
+func newEventHandler(server *EventsServer, stream pb.Events_ChatServer) (*handler, error) {
 	d := &handler{
-		ChatStream: stream,
+		ChatStream:      stream,
+		server:          server,
+		replayedHeights: make(map[string]uint64),
 	}
 	d.interestedEvents = make(map[string]*pb.Interest)
 	return d, nil
@@ -50,6 +68,8 @@ func getInterestKey(interest pb.Interest) string {
 		key = "/" + strconv.Itoa(int(pb.EventType_BLOCK))
 	case pb.EventType_REJECTION:
 		key = "/" + strconv.Itoa(int(pb.EventType_REJECTION))
+	case pb.EventType_FILTEREDBLOCK:
+		key = "/" + strconv.Itoa(int(pb.EventType_FILTEREDBLOCK))
 	case pb.EventType_CHAINCODE:
 		key = "/" + strconv.Itoa(int(pb.EventType_CHAINCODE)) + "/" + interest.GetChaincodeRegInfo().ChaincodeId + "/" + interest.GetChaincodeRegInfo().EventName
 	default:
@@ -98,10 +118,11 @@ func (d *handler) HandleMessage(msg *pb.Event) error {
 	//producerLogger.Debug("Handling Event")
 	switch msg.Event.(type) {
 	case *pb.Event_Register:
-		eventsObj := msg.GetRegister()
-		if err := d.register(eventsObj.Events); err != nil {
-			return fmt.Errorf("Could not register events %s", err)
-		}
+		return d.handleRegister(msg)
+	case *pb.Event_CursorCommit:
+		return d.handleCursorCommit(msg.GetCursorCommit())
+	case *pb.Event_CursorRequest:
+		return d.handleCursorRequest(msg.GetCursorRequest())
 	case *pb.Event_Unregister:
 		eventsObj := msg.GetUnregister()
 		if err := d.deregister(eventsObj.Events); err != nil {
@@ -112,16 +133,182 @@ func (d *handler) HandleMessage(msg *pb.Event) error {
 		return fmt.Errorf("Invalide type from client %T", msg.Event)
 	}
 	//TODO return supported events.. for now just return the received msg
-	if err := d.ChatStream.Send(msg); err != nil {
-		return fmt.Errorf("Error sending response to %v:  %s", msg, err)
+	return d.sendResponse(msg)
+}
+
+func (d *handler) handleRegister(msg *pb.Event) error {
+	eventsObj := msg.GetRegister()
+	points, err := getResumePoints(d.server.cursors, eventsObj)
+	if err != nil {
+		return fmt.Errorf("Could not read the cursors of consumer %s: %s", eventsObj.ConsumerId, err)
+	}
+	if len(points) == 0 {
+		if err := d.register(eventsObj.Events); err != nil {
+			return fmt.Errorf("Could not register events %s", err)
+		}
+		return d.sendResponse(msg)
+	}
+
+	// hold the events from the event processor before registering, so that none of the
+	// blocks committed during the replay is missed or sent ahead of the replayed blocks
+	d.replayLock.Lock()
+	d.replaying = true
+	d.replayLock.Unlock()
+	defer d.endReplay()
+
+	if err := d.register(eventsObj.Events); err != nil {
+		return fmt.Errorf("Could not register events %s", err)
+	}
+	if err := d.sendResponse(msg); err != nil {
+		return err
+	}
+	for _, point := range points {
+		if err := d.replay(point); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replay sends the events of the blocks of the channel from the resume point up to the current height
+func (d *handler) replay(point *resumePoint) error {
+	if d.server.ledgerGetter == nil {
+		return nil
+	}
+	reader := d.server.ledgerGetter(point.chainID)
+	if reader == nil {
+		producerLogger.Warningf("Cannot resume the events of channel [%s]: channel not found", point.chainID)
+		return nil
+	}
+	info, err := reader.GetBlockchainInfo()
+	if err != nil {
+		return fmt.Errorf("Could not get the height of channel %s: %s", point.chainID, err)
+	}
+	for blockNum := point.blockNum; blockNum < info.Height; blockNum++ {
+		block, err := reader.GetBlockByNumber(blockNum)
+		if err != nil {
+			return fmt.Errorf("Could not get block %d of channel %s: %s", blockNum, point.chainID, err)
+		}
+		for _, eventType := range point.eventTypes {
+			event, err := createReplayedEvent(eventType, block)
+			if err != nil {
+				return err
+			}
+			if err := d.send(event); err != nil {
+				return err
+			}
+		}
+	}
+
+	d.replayLock.Lock()
+	d.replayedHeights[point.chainID] = info.Height
+	d.replayLock.Unlock()
+	producerLogger.Debugf("Replayed blocks [%d, %d) of channel [%s]", point.blockNum, info.Height, point.chainID)
+	return nil
+}
+
+// endReplay sends the events held during the replay, skipping the events of the replayed blocks
+func (d *handler) endReplay() {
+	d.replayLock.Lock()
+	defer d.replayLock.Unlock()
+	for _, event := range d.heldEvents {
+		if d.isReplayed(event) {
+			continue
+		}
+		if err := d.send(event); err != nil {
+			producerLogger.Errorf("Error sending the events held during the replay: %s", err)
+			break
+		}
+	}
+	d.heldEvents = nil
+	d.replaying = false
+}
+
+// isReplayed returns true if the event is the event of a block that has been replayed. It must be
+// called holding the replay lock
+func (d *handler) isReplayed(event *pb.Event) bool {
+	if len(d.replayedHeights) == 0 {
+		return false
+	}
+	var chainID string
+	var blockNum uint64
+	switch e := event.Event.(type) {
+	case *pb.Event_Block:
+		id, err := utils.GetChainIDFromBlock(e.Block)
+		if err != nil {
+			return false
+		}
+		chainID, blockNum = id, e.Block.Header.Number
+	case *pb.Event_FilteredBlock:
+		chainID, blockNum = e.FilteredBlock.ChannelId, e.FilteredBlock.Number
+	default:
+		return false
+	}
+	height, ok := d.replayedHeights[chainID]
+	return ok && blockNum < height
+}
+
+func (d *handler) handleCursorCommit(commit *pb.CursorCommit) error {
+	if d.server.cursors == nil {
+		return fmt.Errorf("Cursors are not enabled")
+	}
+	if commit.ConsumerId == "" || commit.ChannelId == "" {
+		return fmt.Errorf("Cursor commit must carry the consumer id and the channel id")
 	}
+	if err := d.server.cursors.CommitCursor(commit.ConsumerId, commit.ChannelId, commit.BlockNumber); err != nil {
+		return fmt.Errorf("Could not commit the cursor of consumer %s: %s", commit.ConsumerId, err)
+	}
+	return d.sendResponse(&pb.Event{Event: &pb.Event_Cursor{Cursor: &pb.Cursor{
+		ConsumerId:  commit.ConsumerId,
+		ChannelId:   commit.ChannelId,
+		BlockNumber: commit.BlockNumber,
+		Exists:      true,
+	}}})
+}
 
+func (d *handler) handleCursorRequest(req *pb.CursorRequest) error {
+	if d.server.cursors == nil {
+		return fmt.Errorf("Cursors are not enabled")
+	}
+	blockNum, exists, err := d.server.cursors.GetCursor(req.ConsumerId, req.ChannelId)
+	if err != nil {
+		return fmt.Errorf("Could not read the cursor of consumer %s: %s", req.ConsumerId, err)
+	}
+	return d.sendResponse(&pb.Event{Event: &pb.Event_Cursor{Cursor: &pb.Cursor{
+		ConsumerId:  req.ConsumerId,
+		ChannelId:   req.ChannelId,
+		BlockNumber: blockNum,
+		Exists:      exists,
+	}}})
+}
+
+func (d *handler) sendResponse(msg *pb.Event) error {
+	if err := d.send(msg); err != nil {
+		return fmt.Errorf("Error sending response to %v:  %s", msg, err)
+	}
 	return nil
 }
 
+func (d *handler) send(msg *pb.Event) error {
+	d.sendLock.Lock()
+	defer d.sendLock.Unlock()
+	return d.ChatStream.Send(msg)
+}
+
 // SendMessage sends a message to the remote PEER through the stream
 func (d *handler) SendMessage(msg *pb.Event) error {
-	err := d.ChatStream.Send(msg)
+	d.replayLock.Lock()
+	if d.replaying {
+		d.heldEvents = append(d.heldEvents, msg)
+		d.replayLock.Unlock()
+		return nil
+	}
+	skip := d.isReplayed(msg)
+	d.replayLock.Unlock()
+	if skip {
+		return nil
+	}
+	err := d.send(msg)
 	if err != nil {
 		return fmt.Errorf("Error Sending message through ChatStream: %s", err)
 	}
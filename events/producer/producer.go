@@ -31,6 +31,8 @@ var producerLogger = logging.MustGetLogger("eventhub_producer")
 
 // EventsServer implementation of the Peer service
 type EventsServer struct {
+	cursors      *CursorStore
+	ledgerGetter LedgerGetter
 }
 
 //singleton - if we want to create multiple servers, we need to subsume events.gEventConsumers into EventsServer
@@ -47,9 +49,17 @@ func NewEventsServer(bufferSize uint, timeout int) *EventsServer {
 	return globalEventsServer
 }
 
+// EnableCursors enables the delivery cursors of the consumers. The cursors committed by the consumers
+// are persisted in the store, and a consumer registering with its id receives the BLOCK and FILTEREDBLOCK
+// events of the blocks after its cursor, read from the ledger returned by the getter
+func (p *EventsServer) EnableCursors(cursors *CursorStore, ledgerGetter LedgerGetter) {
+	p.cursors = cursors
+	p.ledgerGetter = ledgerGetter
+}
+
 // Chat implementation of the the Chat bidi streaming RPC function
 func (p *EventsServer) Chat(stream pb.Events_ChatServer) error {
-	handler, err := newEventHandler(stream)
+	handler, err := newEventHandler(p, stream)
 	if err != nil {
 		return fmt.Errorf("Error creating handler during handleChat initiation: %s", err)
 	}
@@ -0,0 +1,191 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package privdata
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/gossip/util"
+	"github.com/op/go-logging"
+	"github.com/spf13/viper"
+)
+
+const (
+	defReconcileSleepInterval = time.Minute
+	defReconcileBatchSize     = 10
+)
+
+// ReconciliationFetcher fetches the missing private data from the peers that are eligible,
+// as per the collection configs, to have it
+type ReconciliationFetcher interface {
+	// FetchReconciledItems fetches the given missing private write sets.
+	// The write sets that could not be fetched are omitted from the result
+	FetchReconciledItems(missing ledger.MissingPvtDataInfo) ([]*ledger.BlockPvtData, error)
+}
+
+// PvtDataCommitter exposes the missing private data of a ledger and commits the reconciled private data
+type PvtDataCommitter interface {
+	// GetMissingPvtDataInfoForMostRecentBlocks returns the missing private write sets of the most recent blocks
+	GetMissingPvtDataInfoForMostRecentBlocks(maxBlocks int) (ledger.MissingPvtDataInfo, error)
+	// CommitPvtDataOfOldBlocks commits the reconciled private write sets of the committed blocks
+	CommitPvtDataOfOldBlocks(blocksPvtData []*ledger.BlockPvtData) error
+}
+
+// PvtDataReconciler completes the private data that was missing when the blocks were committed
+type PvtDataReconciler interface {
+	// Start starts the reconciliation in the background
+	Start()
+	// Stop stops the reconciliation
+	Stop()
+}
+
+// ReconcilerConfig holds the configuration of the reconciliation of the missing private data
+type ReconcilerConfig struct {
+	SleepInterval time.Duration
+	BatchSize     int
+	IsEnabled     bool
+}
+
+// GetReconcilerConfig reads the reconciliation configuration from 'peer.gossip.pvtData'
+func GetReconcilerConfig() *ReconcilerConfig {
+	config := &ReconcilerConfig{
+		SleepInterval: viper.GetDuration("peer.gossip.pvtData.reconcileSleepInterval"),
+		BatchSize:     viper.GetInt("peer.gossip.pvtData.reconcileBatchSize"),
+		IsEnabled:     true,
+	}
+	if config.SleepInterval <= 0 {
+		config.SleepInterval = defReconcileSleepInterval
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defReconcileBatchSize
+	}
+	if viper.IsSet("peer.gossip.pvtData.reconciliationEnabled") {
+		config.IsEnabled = viper.GetBool("peer.gossip.pvtData.reconciliationEnabled")
+	}
+	return config
+}
+
+// Reconciler periodically looks up the private data that is missing in the ledger of a channel,
+// fetches it through the ReconciliationFetcher and commits it to the ledger
+type Reconciler struct {
+	channel   string
+	config    *ReconcilerConfig
+	committer PvtDataCommitter
+	fetcher   ReconciliationFetcher
+	logger    *logging.Logger
+
+	stopChan  chan struct{}
+	startOnce sync.Once
+	stopOnce  sync.Once
+	done      sync.WaitGroup
+}
+
+// NewReconciler creates a Reconciler for the ledger of the given channel
+func NewReconciler(channel string, committer PvtDataCommitter, fetcher ReconciliationFetcher, config *ReconcilerConfig) *Reconciler {
+	return &Reconciler{
+		channel:   channel,
+		config:    config,
+		committer: committer,
+		fetcher:   fetcher,
+		logger:    util.GetLogger(util.LoggingPrivModule, ""),
+		stopChan:  make(chan struct{}),
+	}
+}
+
+// Start implements the corresponding method in the interface PvtDataReconciler
+func (r *Reconciler) Start() {
+	if !r.config.IsEnabled {
+		r.logger.Infof("Private data reconciliation is disabled for channel [%s]", r.channel)
+		return
+	}
+	r.startOnce.Do(func() {
+		r.done.Add(1)
+		go r.run()
+	})
+}
+
+// Stop implements the corresponding method in the interface PvtDataReconciler
+func (r *Reconciler) Stop() {
+	r.stopOnce.Do(func() {
+		close(r.stopChan)
+	})
+	r.done.Wait()
+}
+
+func (r *Reconciler) run() {
+	defer r.done.Done()
+	for {
+		select {
+		case <-r.stopChan:
+			return
+		case <-time.After(r.config.SleepInterval):
+			if _, err := r.reconcile(); err != nil {
+				r.logger.Errorf("Failed reconciling the missing private data of channel [%s]: %s", r.channel, err)
+			}
+		}
+	}
+}
+
+// reconcile fetches and commits the missing private data, one batch of blocks at a time, and returns the number
+// of the write sets fetched. It stops at the first batch that could not be fetched completely, as the rest
+// of the missing private data is unlikely to be available before the next iteration
+func (r *Reconciler) reconcile() (int, error) {
+	numFetched := 0
+	for {
+		select {
+		case <-r.stopChan:
+			return numFetched, nil
+		default:
+		}
+		missing, err := r.committer.GetMissingPvtDataInfoForMostRecentBlocks(r.config.BatchSize)
+		if err != nil || len(missing) == 0 {
+			return numFetched, err
+		}
+		fetched, err := r.fetcher.FetchReconciledItems(missing)
+		if err != nil {
+			return numFetched, err
+		}
+		numFetchedInBatch := 0
+		for _, blockPvtData := range fetched {
+			for _, txPvtData := range blockPvtData.PvtData {
+				numFetchedInBatch += len(txPvtData.WriteSets)
+			}
+		}
+		if numFetchedInBatch == 0 {
+			r.logger.Debugf("None of the missing private data of channel [%s] could be fetched", r.channel)
+			return numFetched, nil
+		}
+		if err := r.committer.CommitPvtDataOfOldBlocks(fetched); err != nil {
+			return numFetched, err
+		}
+		numFetched += numFetchedInBatch
+		r.logger.Infof("Reconciled [%d] missing private write set(s) of channel [%s]", numFetchedInBatch, r.channel)
+		if numFetchedInBatch < numMissing(missing) {
+			return numFetched, nil
+		}
+	}
+}
+
+func numMissing(missing ledger.MissingPvtDataInfo) int {
+	n := 0
+	for _, entries := range missing {
+		n += len(entries)
+	}
+	return n
+}
@@ -0,0 +1,186 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package privdata
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockCommitter struct {
+	sync.Mutex
+	missing   map[uint64]map[ledger.MissingPvtData]bool
+	committed []*ledger.BlockPvtData
+}
+
+func newMockCommitter(missing ledger.MissingPvtDataInfo) *mockCommitter {
+	c := &mockCommitter{missing: make(map[uint64]map[ledger.MissingPvtData]bool)}
+	for blockNum, entries := range missing {
+		c.missing[blockNum] = make(map[ledger.MissingPvtData]bool)
+		for _, entry := range entries {
+			c.missing[blockNum][*entry] = true
+		}
+	}
+	return c
+}
+
+func (c *mockCommitter) GetMissingPvtDataInfoForMostRecentBlocks(maxBlocks int) (ledger.MissingPvtDataInfo, error) {
+	c.Lock()
+	defer c.Unlock()
+	var info ledger.MissingPvtDataInfo
+	for blockNum, entries := range c.missing {
+		if len(info) == maxBlocks {
+			break
+		}
+		for entry := range entries {
+			if info == nil {
+				info = make(ledger.MissingPvtDataInfo)
+			}
+			e := entry
+			info.Add(blockNum, &e)
+		}
+	}
+	return info, nil
+}
+
+func (c *mockCommitter) CommitPvtDataOfOldBlocks(blocksPvtData []*ledger.BlockPvtData) error {
+	c.Lock()
+	defer c.Unlock()
+	for _, blockPvtData := range blocksPvtData {
+		for _, txPvtData := range blockPvtData.PvtData {
+			for _, ws := range txPvtData.WriteSets {
+				delete(c.missing[blockPvtData.BlockNum], ledger.MissingPvtData{SeqInBlock: txPvtData.SeqInBlock, Namespace: ws.Namespace, CollectionName: ws.CollectionName})
+			}
+		}
+		if len(c.missing[blockPvtData.BlockNum]) == 0 {
+			delete(c.missing, blockPvtData.BlockNum)
+		}
+	}
+	c.committed = append(c.committed, blocksPvtData...)
+	return nil
+}
+
+// mockFetcher fetches the missing private data, except for the collections in unavailable
+type mockFetcher struct {
+	unavailable map[string]bool
+	err         error
+}
+
+func (f *mockFetcher) FetchReconciledItems(missing ledger.MissingPvtDataInfo) ([]*ledger.BlockPvtData, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	var fetched []*ledger.BlockPvtData
+	for blockNum, entries := range missing {
+		blockPvtData := &ledger.BlockPvtData{BlockNum: blockNum}
+		for _, entry := range entries {
+			if f.unavailable[entry.CollectionName] {
+				continue
+			}
+			blockPvtData.PvtData = append(blockPvtData.PvtData, &ledger.TxPvtData{SeqInBlock: entry.SeqInBlock,
+				WriteSets: []*ledger.CollPvtWriteSet{{Namespace: entry.Namespace, CollectionName: entry.CollectionName, WriteSet: []byte("ws")}}})
+		}
+		fetched = append(fetched, blockPvtData)
+	}
+	return fetched, nil
+}
+
+func sampleMissingPvtData() ledger.MissingPvtDataInfo {
+	return ledger.MissingPvtDataInfo{
+		1: {{SeqInBlock: 0, Namespace: "ns1", CollectionName: "coll1"}},
+		2: {{SeqInBlock: 0, Namespace: "ns1", CollectionName: "coll1"}, {SeqInBlock: 1, Namespace: "ns1", CollectionName: "coll2"}},
+		3: {{SeqInBlock: 2, Namespace: "ns2", CollectionName: "coll1"}},
+	}
+}
+
+func TestReconcileAllInBatches(t *testing.T) {
+	committer := newMockCommitter(sampleMissingPvtData())
+	r := NewReconciler("testchannel", committer, &mockFetcher{}, &ReconcilerConfig{SleepInterval: time.Minute, BatchSize: 1, IsEnabled: true})
+	numFetched, err := r.reconcile()
+	assert.NoError(t, err)
+	assert.Equal(t, 4, numFetched)
+	assert.Len(t, committer.missing, 0)
+	assert.Len(t, committer.committed, 3)
+}
+
+func TestReconcileStopsOnPartialFetch(t *testing.T) {
+	committer := newMockCommitter(sampleMissingPvtData())
+	r := NewReconciler("testchannel", committer, &mockFetcher{unavailable: map[string]bool{"coll2": true}},
+		&ReconcilerConfig{SleepInterval: time.Minute, BatchSize: 10, IsEnabled: true})
+	numFetched, err := r.reconcile()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, numFetched)
+	missing, _ := committer.GetMissingPvtDataInfoForMostRecentBlocks(10)
+	assert.Equal(t, ledger.MissingPvtDataInfo{2: {{SeqInBlock: 1, Namespace: "ns1", CollectionName: "coll2"}}}, missing)
+
+	// nothing is committed when none of the missing private data can be fetched
+	numFetched, err = r.reconcile()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, numFetched)
+	assert.Len(t, committer.committed, 3)
+}
+
+func TestReconcileFetchError(t *testing.T) {
+	committer := newMockCommitter(sampleMissingPvtData())
+	r := NewReconciler("testchannel", committer, &mockFetcher{err: errors.New("no peers available")},
+		&ReconcilerConfig{SleepInterval: time.Minute, BatchSize: 10, IsEnabled: true})
+	_, err := r.reconcile()
+	assert.Error(t, err)
+	assert.Len(t, committer.committed, 0)
+}
+
+func TestReconcilerStartStop(t *testing.T) {
+	committer := newMockCommitter(sampleMissingPvtData())
+	r := NewReconciler("testchannel", committer, &mockFetcher{}, &ReconcilerConfig{SleepInterval: 10 * time.Millisecond, BatchSize: 10, IsEnabled: true})
+	r.Start()
+	for i := 0; i < 100; i++ {
+		if missing, _ := committer.GetMissingPvtDataInfoForMostRecentBlocks(10); len(missing) == 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	r.Stop()
+	missing, _ := committer.GetMissingPvtDataInfoForMostRecentBlocks(10)
+	assert.Len(t, missing, 0)
+	r.Stop()
+
+	// a disabled reconciler does not reconcile
+	committer = newMockCommitter(sampleMissingPvtData())
+	r = NewReconciler("testchannel", committer, &mockFetcher{}, &ReconcilerConfig{SleepInterval: 10 * time.Millisecond, BatchSize: 10})
+	r.Start()
+	time.Sleep(50 * time.Millisecond)
+	r.Stop()
+	assert.Len(t, committer.committed, 0)
+}
+
+func TestGetReconcilerConfig(t *testing.T) {
+	config := GetReconcilerConfig()
+	assert.Equal(t, &ReconcilerConfig{SleepInterval: time.Minute, BatchSize: 10, IsEnabled: true}, config)
+
+	viper.Set("peer.gossip.pvtData.reconcileSleepInterval", "5s")
+	viper.Set("peer.gossip.pvtData.reconcileBatchSize", 2)
+	viper.Set("peer.gossip.pvtData.reconciliationEnabled", false)
+	defer viper.Reset()
+	config = GetReconcilerConfig()
+	assert.Equal(t, &ReconcilerConfig{SleepInterval: 5 * time.Second, BatchSize: 2, IsEnabled: false}, config)
+}
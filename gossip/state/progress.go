@@ -0,0 +1,111 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric/common/metrics"
+)
+
+var (
+	deliveredHeight = metrics.NewGaugeVec("ledger_delivered_block_height",
+		"Height of the blockchain as delivered to the peer by the ordering service or by the other peers.", "channel")
+	commitBacklog = metrics.NewGaugeVec("ledger_commit_backlog_blocks",
+		"Number of the blocks delivered to the peer that are yet to be committed.", "channel")
+	catchUpRate = metrics.NewGaugeVec("ledger_catchup_blocks_per_second",
+		"Number of the blocks committed per second while the peer has a commit backlog, zero once the peer has caught up.", "channel")
+)
+
+// catchUpRateInterval is the minimum interval over which the catch-up rate is measured
+var catchUpRateInterval = 5 * time.Second
+
+// commitProgress tracks the blocks delivered to the peer against the blocks committed to the ledger
+// of a channel, so that the operators can see when the peer falls behind and estimate the time to catch up
+type commitProgress struct {
+	chainID         string
+	lock            sync.Mutex
+	deliveredHeight uint64
+	committedHeight uint64
+	// the catch-up rate is measured from the committed height at the start of the current interval
+	intervalStart       time.Time
+	intervalStartHeight uint64
+	rate                float64
+}
+
+func newCommitProgress(chainID string, height uint64) *commitProgress {
+	p := &commitProgress{chainID: chainID, deliveredHeight: height, committedHeight: height,
+		intervalStart: time.Now(), intervalStartHeight: height}
+	p.report()
+	return p
+}
+
+// blockDelivered records the delivery of the block with the given sequence number
+func (p *commitProgress) blockDelivered(seqNum uint64) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if seqNum+1 <= p.deliveredHeight {
+		return
+	}
+	p.deliveredHeight = seqNum + 1
+	p.report()
+}
+
+// blockCommitted records the commit of the block with the given sequence number
+func (p *commitProgress) blockCommitted(seqNum uint64) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.committedHeight = seqNum + 1
+	if p.deliveredHeight < p.committedHeight {
+		p.deliveredHeight = p.committedHeight
+	}
+	if elapsed := time.Since(p.intervalStart); elapsed >= catchUpRateInterval {
+		p.rate = float64(p.committedHeight-p.intervalStartHeight) / elapsed.Seconds()
+		p.intervalStart = time.Now()
+		p.intervalStartHeight = p.committedHeight
+	}
+	p.report()
+}
+
+// backlog returns the number of the delivered blocks that are yet to be committed
+func (p *commitProgress) backlog() uint64 {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.deliveredHeight - p.committedHeight
+}
+
+// catchUpRate returns the number of the blocks committed per second while there is a backlog,
+// zero once the peer has caught up
+func (p *commitProgress) catchUpRate() float64 {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.currentRate()
+}
+
+func (p *commitProgress) currentRate() float64 {
+	if p.deliveredHeight == p.committedHeight {
+		return 0
+	}
+	return p.rate
+}
+
+func (p *commitProgress) report() {
+	deliveredHeight.Set(float64(p.deliveredHeight), p.chainID)
+	commitBacklog.Set(float64(p.deliveredHeight-p.committedHeight), p.chainID)
+	catchUpRate.Set(p.currentRate(), p.chainID)
+}
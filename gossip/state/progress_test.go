@@ -0,0 +1,50 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker/docker/pkg/testutil/assert"
+)
+
+func TestCommitProgress(t *testing.T) {
+	defer func(interval time.Duration) { catchUpRateInterval = interval }(catchUpRateInterval)
+	catchUpRateInterval = 0
+
+	progress := newCommitProgress("testchain", 5)
+	assert.Equal(t, progress.backlog(), uint64(0))
+	assert.Equal(t, progress.catchUpRate(), float64(0))
+
+	// Blocks 5 to 14 are delivered
+	progress.blockDelivered(14)
+	// A block delivered again does not lower the delivered height
+	progress.blockDelivered(8)
+	assert.Equal(t, progress.backlog(), uint64(10))
+
+	progress.blockCommitted(5)
+	progress.blockCommitted(6)
+	assert.Equal(t, progress.backlog(), uint64(8))
+	assert.Equal(t, progress.catchUpRate() > 0, true)
+
+	for seqNum := uint64(7); seqNum <= 14; seqNum++ {
+		progress.blockCommitted(seqNum)
+	}
+	assert.Equal(t, progress.backlog(), uint64(0))
+	assert.Equal(t, progress.catchUpRate(), float64(0))
+}
@@ -81,6 +81,9 @@ type GossipStateProviderImpl struct {
 
 	committer committer.Committer
 
+	// progress tracks the delivered blocks against the committed blocks
+	progress *commitProgress
+
 	logger *logging.Logger
 
 	done sync.WaitGroup
@@ -126,6 +129,8 @@ func NewGossipStateProvider(chainID string, g gossip.Gossip, committer committer
 
 		committer: committer,
 
+		progress: newCommitProgress(chainID, height),
+
 		logger: logger,
 	}
 
@@ -237,6 +242,7 @@ func (s *GossipStateProviderImpl) handleStateResponse(msg proto.ReceivedMessage)
 	response := msg.GetGossipMessage().GetStateResponse()
 	for _, payload := range response.GetPayloads() {
 		s.logger.Debugf("Received payload with sequence number %d.", payload.SeqNum)
+		s.progress.blockDelivered(payload.SeqNum)
 		err := s.payloads.Push(payload)
 		if err != nil {
 			s.logger.Warningf("Payload with sequence number %d was received earlier", payload.SeqNum)
@@ -269,6 +275,7 @@ func (s *GossipStateProviderImpl) queueNewMessage(msg *proto.GossipMessage) {
 	if dataMsg != nil {
 		// Add new payload to ordered set
 		s.logger.Debugf("Received new payload with sequence number = [%d]", dataMsg.Payload.SeqNum)
+		s.progress.blockDelivered(dataMsg.Payload.SeqNum)
 		s.payloads.Push(dataMsg.GetPayload())
 	} else {
 		s.logger.Debug("Gossip message received is not of data message type, usually this should not happen.")
@@ -390,6 +397,7 @@ func (s *GossipStateProviderImpl) GetBlock(index uint64) *common.Block {
 // AddPayload add new payload into state
 func (s *GossipStateProviderImpl) AddPayload(payload *proto.Payload) error {
 	s.logger.Debug("Adding new payload into the buffer, seqNum = ", payload.SeqNum)
+	s.progress.blockDelivered(payload.SeqNum)
 	return s.payloads.Push(payload)
 }
 
@@ -398,6 +406,10 @@ func (s *GossipStateProviderImpl) commitBlock(block *common.Block, seqNum uint64
 		s.logger.Errorf("Got error while committing(%s)", err)
 		return err
 	}
+	s.progress.blockCommitted(seqNum)
+	if backlog := s.progress.backlog(); backlog > 0 {
+		s.logger.Debugf("Channel [%s]: Commit backlog of %d block(s) at %.2f block(s) per second", s.chainID, backlog, s.progress.catchUpRate())
+	}
 
 	// Update ledger level within node metadata
 	state := NewNodeMetastate(seqNum)
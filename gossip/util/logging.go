@@ -34,6 +34,7 @@ const (
 	LoggingElectionModule  = "gossip/election"
 	LoggingGossipModule    = "gossip/gossip"
 	LoggingMockModule      = "gossip/comm/mock"
+	LoggingPrivModule      = "gossip/privdata"
 	LoggingPullModule      = "gossip/pull"
 	LoggingServiceModule   = "gossip/service"
 	LoggingStateModule     = "gossip/state"
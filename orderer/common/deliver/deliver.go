@@ -57,13 +57,23 @@ type Support interface {
 }
 
 type deliverServer struct {
-	sm SupportManager
+	sm       SupportManager
+	throttle *throttle
 }
 
 // NewHandlerImpl creates an implementation of the Handler interface
 func NewHandlerImpl(sm SupportManager) Handler {
+	return NewThrottledHandlerImpl(sm, 0)
+}
+
+// NewThrottledHandlerImpl creates an implementation of the Handler interface
+// which limits the reads of the blocks that are not the newest of their chain
+// to the given number of bytes per second, so that the clients catching up from
+// the oldest blocks do not saturate the disk. A rate of 0 disables the limit.
+func NewThrottledHandlerImpl(sm SupportManager, bytesPerSecond uint64) Handler {
 	return &deliverServer{
-		sm: sm,
+		sm:       sm,
+		throttle: newThrottle(bytesPerSecond),
 	}
 }
 
@@ -124,6 +134,12 @@ func (ds *deliverServer) Handle(srv ab.AtomicBroadcast_DeliverServer) error {
 			stopNum = chain.Reader().Height() - 1
 		case *ab.SeekPosition_Specified:
 			stopNum = stop.Specified.Number
+		case *ab.SeekPosition_TxId, *ab.SeekPosition_Timestamp:
+			stopCursor, n := chain.Reader().Iterator(seekInfo.Stop)
+			if _, ok := stopCursor.(*ordererledger.NotFoundErrorIterator); ok {
+				return sendStatusReply(srv, cb.Status_NOT_FOUND)
+			}
+			stopNum = n
 		}
 
 		for {
@@ -143,6 +159,10 @@ func (ds *deliverServer) Handle(srv ab.AtomicBroadcast_DeliverServer) error {
 				return sendStatusReply(srv, status)
 			}
 
+			if block.Header.Number+1 < chain.Reader().Height() {
+				ds.throttle.wait(proto.Size(block))
+			}
+
 			logger.Debugf("Delivering block")
 			if err := sendBlockReply(srv, block); err != nil {
 				return err
@@ -21,6 +21,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric/common/configtx/tool/provisional"
 	configvaluesapi "github.com/hyperledger/fabric/common/configvalues"
 	mockconfigvaluesorderer "github.com/hyperledger/fabric/common/mocks/configvalues/channel/orderer"
@@ -384,3 +385,53 @@ func TestBlockingSeek(t *testing.T) {
 		t.Fatalf("Timed out waiting to get all blocks")
 	}
 }
+
+func TestThrottledSeek(t *testing.T) {
+	mm := newMockMultichainManager()
+	ledger := mm.chains[systemChainID].ledger
+	for i := 1; i < ledgerSize; i++ {
+		ledger.Append(ordererledger.CreateNextBlock(ledger, []*cb.Envelope{&cb.Envelope{Payload: []byte(fmt.Sprintf("%d", i))}}))
+	}
+
+	// Only the blocks older than the newest one are throttled
+	oldBytes := 0
+	for i := uint64(0); i < ledgerSize-1; i++ {
+		itr, _ := ledger.Iterator(seekSpecified(i))
+		block, _ := itr.Next()
+		oldBytes += proto.Size(block)
+	}
+
+	// The first second worth of bytes is read in a burst, the remaining fifth of a second is delayed
+	rate := uint64(oldBytes) * 5 / 6
+
+	m := newMockD()
+	defer close(m.recvChan)
+	ds := NewThrottledHandlerImpl(mm, rate)
+
+	go ds.Handle(m)
+
+	start := time.Now()
+	m.recvChan <- makeSeek(systemChainID, &ab.SeekInfo{Start: seekOldest, Stop: seekNewest, Behavior: ab.SeekInfo_BLOCK_UNTIL_READY})
+
+	count := uint64(0)
+	for {
+		select {
+		case deliverReply := <-m.sendChan:
+			if deliverReply.GetBlock() == nil {
+				if deliverReply.GetStatus() != cb.Status_SUCCESS {
+					t.Fatalf("Received an error on the reply channel")
+				}
+				if count != ledgerSize {
+					t.Fatalf("Expected %d blocks but got %d", ledgerSize, count)
+				}
+				if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+					t.Fatalf("Expected the delivery to be throttled, but it took %v", elapsed)
+				}
+				return
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out waiting to get all blocks")
+		}
+		count++
+	}
+}
@@ -0,0 +1,60 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deliver
+
+import (
+	"sync"
+	"time"
+)
+
+// throttle limits the rate at which the bytes of the blocks are read for the
+// deliver requests, across all of the deliver streams of the orderer. Up to a
+// second worth of bytes may be read in a burst after the throttle was idle.
+type throttle struct {
+	bytesPerSecond uint64
+
+	lock sync.Mutex
+	next time.Time // the time at which the bytes read so far are paid off
+}
+
+// newThrottle returns a throttle of the given rate, or nil if the rate is 0,
+// in which case the reads are not throttled
+func newThrottle(bytesPerSecond uint64) *throttle {
+	if bytesPerSecond == 0 {
+		return nil
+	}
+	return &throttle{bytesPerSecond: bytesPerSecond}
+}
+
+// wait blocks until the given number of bytes may be read within the rate
+func (t *throttle) wait(size int) {
+	if t == nil {
+		return
+	}
+	t.lock.Lock()
+	now := time.Now()
+	if burst := now.Add(-time.Second); t.next.Before(burst) {
+		t.next = burst
+	}
+	t.next = t.next.Add(time.Duration(uint64(size) * uint64(time.Second) / t.bytesPerSecond))
+	delay := t.next.Sub(now)
+	t.lock.Unlock()
+
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+}
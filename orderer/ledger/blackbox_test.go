@@ -21,9 +21,11 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/golang/protobuf/ptypes/timestamp"
 	. "github.com/hyperledger/fabric/orderer/ledger"
 	cb "github.com/hyperledger/fabric/protos/common"
 	ab "github.com/hyperledger/fabric/protos/orderer"
+	"github.com/hyperledger/fabric/protos/utils"
 )
 
 type ledgerTestable interface {
@@ -254,3 +256,39 @@ func testMultichain(lf ledgerTestFactory, t *testing.T) {
 		t.Fatalf("Did not properly store block 1 on chain 1")
 	}
 }
+
+func TestSeekByTxIDAndTimestamp(t *testing.T) {
+	allTest(t, testSeekByTxIDAndTimestamp)
+}
+
+func testSeekByTxIDAndTimestamp(lf ledgerTestFactory, t *testing.T) {
+	_, li := lf.New()
+	li.Append(CreateNextBlock(li, []*cb.Envelope{makeTimestampedEnvelope("tx1", 100)}))
+	li.Append(CreateNextBlock(li, []*cb.Envelope{makeTimestampedEnvelope("tx2", 200)}))
+
+	_, num := li.Iterator(&ab.SeekPosition{Type: &ab.SeekPosition_TxId{TxId: &ab.SeekTxID{TxId: "tx2"}}})
+	if num != 2 {
+		t.Fatalf("Expected iterator at the block of tx2, but got %d", num)
+	}
+	it, _ := li.Iterator(&ab.SeekPosition{Type: &ab.SeekPosition_TxId{TxId: &ab.SeekTxID{TxId: "missing"}}})
+	if _, status := it.Next(); status != cb.Status_NOT_FOUND {
+		t.Fatalf("Expected NOT_FOUND for an unknown transaction id, but got %v", status)
+	}
+
+	_, num = li.Iterator(&ab.SeekPosition{Type: &ab.SeekPosition_Timestamp{Timestamp: &ab.SeekTimestamp{Timestamp: &timestamp.Timestamp{Seconds: 150}}}})
+	if num != 2 {
+		t.Fatalf("Expected iterator at the first block after the timestamp, but got %d", num)
+	}
+	it, _ = li.Iterator(&ab.SeekPosition{Type: &ab.SeekPosition_Timestamp{Timestamp: &ab.SeekTimestamp{Timestamp: &timestamp.Timestamp{Seconds: 250}}}})
+	if _, status := it.Next(); status != cb.Status_NOT_FOUND {
+		t.Fatalf("Expected NOT_FOUND for a timestamp after the last block, but got %v", status)
+	}
+}
+
+func makeTimestampedEnvelope(txID string, seconds int64) *cb.Envelope {
+	chdr := utils.MakeChannelHeader(cb.HeaderType_ENDORSER_TRANSACTION, 0, "testchain", 0)
+	chdr.TxId = txID
+	chdr.Timestamp = &timestamp.Timestamp{Seconds: seconds}
+	payload := &cb.Payload{Header: utils.MakePayloadHeader(chdr, &cb.SignatureHeader{})}
+	return &cb.Envelope{Payload: utils.MarshalOrPanic(payload)}
+}
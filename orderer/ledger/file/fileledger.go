@@ -260,6 +260,13 @@ func (fl *fileLedger) Iterator(startPosition *ab.SeekPosition) (ordererledger.It
 			return &ordererledger.NotFoundErrorIterator{}, 0
 		}
 		return &cursor{fl: fl, blockNumber: start.Specified.Number}, start.Specified.Number
+	case *ab.SeekPosition_TxId, *ab.SeekPosition_Timestamp:
+		// the blocks are not indexed, hence the block files are scanned
+		number, found := ordererledger.ScanForBlockNumber(fl, startPosition)
+		if !found {
+			return &ordererledger.NotFoundErrorIterator{}, 0
+		}
+		return &cursor{fl: fl, blockNumber: number}, number
 	}
 
 	// This line should be unreachable, but the compiler requires it
@@ -0,0 +1,85 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package fsledger
+
+import (
+	"sync"
+
+	"github.com/hyperledger/fabric/common/ledger/blkstorage/fsblkstorage"
+	ordererledger "github.com/hyperledger/fabric/orderer/ledger"
+)
+
+type fsLedgerFactory struct {
+	provider *Provider
+	ledgers  map[string]ordererledger.ReadWriter
+	mutex    sync.Mutex
+}
+
+// New creates a new ordererledger.Factory whose ledgers are backed by the block storage in the given directory
+func New(directory string) ordererledger.Factory {
+	logger.Debugf("Initializing fsLedger at '%s'", directory)
+	flf := &fsLedgerFactory{
+		provider: NewProvider(fsblkstorage.NewConf(directory, fileSegmentSize)),
+		ledgers:  make(map[string]ordererledger.ReadWriter),
+	}
+	chainIDs, err := flf.provider.List()
+	if err != nil {
+		logger.Panicf("Error listing the chains in directory %s: %s", directory, err)
+	}
+	for _, chainID := range chainIDs {
+		if _, err := flf.GetOrCreate(chainID); err != nil {
+			logger.Warningf("Failed to initialize chain %s: %s", chainID, err)
+		}
+	}
+	return flf
+}
+
+// GetOrCreate gets an existing ledger (if it exists) or creates it if it does not
+func (flf *fsLedgerFactory) GetOrCreate(chainID string) (ordererledger.ReadWriter, error) {
+	flf.mutex.Lock()
+	defer flf.mutex.Unlock()
+
+	if l, ok := flf.ledgers[chainID]; ok {
+		return l, nil
+	}
+	exists, err := flf.provider.Exists(chainID)
+	if err != nil {
+		return nil, err
+	}
+	var l OrdererLedger
+	if exists {
+		l, err = flf.provider.Open(chainID)
+	} else {
+		l, err = flf.provider.Create(chainID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	rw := l.(*fsLedger)
+	flf.ledgers[chainID] = rw
+	return rw, nil
+}
+
+// ChainIDs returns the chain IDs the Factory is aware of
+func (flf *fsLedgerFactory) ChainIDs() []string {
+	flf.mutex.Lock()
+	defer flf.mutex.Unlock()
+	ids := make([]string, 0, len(flf.ledgers))
+	for key := range flf.ledgers {
+		ids = append(ids, key)
+	}
+	return ids
+}
@@ -18,20 +18,48 @@ package fsledger
 
 import (
 	"errors"
+	"sync"
 
+	"github.com/golang/protobuf/ptypes"
 	"github.com/hyperledger/fabric/common/ledger"
 	"github.com/hyperledger/fabric/common/ledger/blkstorage"
+	ordererledger "github.com/hyperledger/fabric/orderer/ledger"
+	"github.com/op/go-logging"
 
 	"github.com/hyperledger/fabric/protos/common"
+	ab "github.com/hyperledger/fabric/protos/orderer"
 )
 
+var logger = logging.MustGetLogger("ordererledger/fsledger")
+
+var closedChan chan struct{}
+
+func init() {
+	closedChan = make(chan struct{})
+	close(closedChan)
+}
+
 const (
 	fileSegmentSize = 64 * 1024 * 1024
 )
 
-// fsLedger - an orderer ledger implementation that persists blocks on filesystem based store
+// fsLedger - an orderer ledger implementation that persists blocks on filesystem based store.
+// fsLedger also implements ordererledger.ReadWriter so that the deliver service can seek the blocks
+// by the transaction ids and the timestamps from the indexes of the block store
 type fsLedger struct {
 	blockStore blkstorage.BlockStore
+	// signal is closed, and replaced, when a block is committed to wake up the cursors waiting for the block
+	signal     chan struct{}
+	signalLock sync.Mutex
+}
+
+type cursor struct {
+	l           *fsLedger
+	blockNumber uint64
+}
+
+func newFSLedger(blockStore blkstorage.BlockStore) *fsLedger {
+	return &fsLedger{blockStore: blockStore, signal: make(chan struct{})}
 }
 
 // GetBlockchainInfo returns basic info about blockchain
@@ -63,5 +91,93 @@ func (l *fsLedger) Close() {
 
 // Commit adds a new block
 func (l *fsLedger) Commit(block *common.Block) error {
-	return l.blockStore.AddBlock(block)
+	if err := l.blockStore.AddBlock(block); err != nil {
+		return err
+	}
+	l.signalLock.Lock()
+	close(l.signal)
+	l.signal = make(chan struct{})
+	l.signalLock.Unlock()
+	return nil
+}
+
+// Append implements the ordererledger.Writer definition
+func (l *fsLedger) Append(block *common.Block) error {
+	return l.Commit(block)
+}
+
+// Height returns the highest block number in the chain, plus one
+func (l *fsLedger) Height() uint64 {
+	info, err := l.blockStore.GetBlockchainInfo()
+	if err != nil {
+		logger.Panicf("Error retrieving the blockchain info: %s", err)
+	}
+	return info.Height
+}
+
+// Iterator implements the ordererledger.Reader definition. The positions that seek a transaction id or
+// a timestamp are resolved from the indexes of the block store
+func (l *fsLedger) Iterator(startPosition *ab.SeekPosition) (ordererledger.Iterator, uint64) {
+	var blockNumber uint64
+	var err error
+	switch start := startPosition.Type.(type) {
+	case *ab.SeekPosition_Oldest:
+		blockNumber = 0
+	case *ab.SeekPosition_Newest:
+		blockNumber = l.Height() - 1
+	case *ab.SeekPosition_Specified:
+		blockNumber = start.Specified.Number
+		if blockNumber > l.Height() {
+			return &ordererledger.NotFoundErrorIterator{}, 0
+		}
+	case *ab.SeekPosition_TxId:
+		if blockNumber, _, err = l.blockStore.RetrieveTxLocByTxID(start.TxId.TxId); err != nil {
+			logger.Debugf("Returning error iterator because the block of transaction [%s] could not be found: %s", start.TxId.TxId, err)
+			return &ordererledger.NotFoundErrorIterator{}, 0
+		}
+	case *ab.SeekPosition_Timestamp:
+		timestamp, err := ptypes.Timestamp(start.Timestamp.Timestamp)
+		if err != nil {
+			return &ordererledger.NotFoundErrorIterator{}, 0
+		}
+		if blockNumber, err = l.blockStore.RetrieveBlockNumByTimestamp(timestamp); err != nil {
+			logger.Debugf("Returning error iterator because no block could be found at or after [%s]: %s", timestamp, err)
+			return &ordererledger.NotFoundErrorIterator{}, 0
+		}
+	default:
+		return &ordererledger.NotFoundErrorIterator{}, 0
+	}
+	return &cursor{l: l, blockNumber: blockNumber}, blockNumber
+}
+
+func (l *fsLedger) getSignal() chan struct{} {
+	l.signalLock.Lock()
+	defer l.signalLock.Unlock()
+	return l.signal
+}
+
+// Next blocks until there is a new block available, or returns an error if the next block is no longer retrievable
+func (cu *cursor) Next() (*common.Block, common.Status) {
+	for {
+		signal := cu.l.getSignal()
+		if cu.blockNumber < cu.l.Height() {
+			block, err := cu.l.blockStore.RetrieveBlockByNumber(cu.blockNumber)
+			if err != nil {
+				logger.Errorf("Error retrieving block [%d]: %s", cu.blockNumber, err)
+				return nil, common.Status_SERVICE_UNAVAILABLE
+			}
+			cu.blockNumber++
+			return block, common.Status_SUCCESS
+		}
+		<-signal
+	}
+}
+
+// ReadyChan returns a channel that will close when Next is ready to be called without blocking
+func (cu *cursor) ReadyChan() <-chan struct{} {
+	signal := cu.l.getSignal()
+	if cu.blockNumber < cu.l.Height() {
+		return closedChan
+	}
+	return signal
 }
@@ -30,6 +30,8 @@ type Provider struct {
 func NewProvider(conf *fsblkstorage.Conf) *Provider {
 	attrsToIndex := []blkstorage.IndexableAttr{
 		blkstorage.IndexableAttrBlockNum,
+		blkstorage.IndexableAttrTxBlockNumTranNum,
+		blkstorage.IndexableAttrBlockTimestamp,
 	}
 	indexConfig := &blkstorage.IndexConfig{AttrsToIndex: attrsToIndex}
 	fsBlkStoreProvider := fsblkstorage.NewProvider(conf, indexConfig)
@@ -42,7 +44,7 @@ func (p *Provider) Create(ledgerID string) (OrdererLedger, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &fsLedger{blkStore}, nil
+	return newFSLedger(blkStore), nil
 }
 
 // Open implements corresponding method in the interface ledger.OrdererLedgerProvider
@@ -51,7 +53,7 @@ func (p *Provider) Open(ledgerID string) (OrdererLedger, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &fsLedger{blkStore}, nil
+	return newFSLedger(blkStore), nil
 }
 
 // Exists implements corresponding method in the interface ledger.OrdererLedgerProvider
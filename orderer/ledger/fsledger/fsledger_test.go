@@ -17,13 +17,19 @@ limitations under the License.
 package fsledger
 
 import (
+	"fmt"
 	"io/ioutil"
 	"os"
 	"testing"
 
+	"github.com/golang/protobuf/ptypes/timestamp"
 	"github.com/hyperledger/fabric/common/ledger"
 	"github.com/hyperledger/fabric/common/ledger/blkstorage/fsblkstorage"
 	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger/util"
+	"github.com/hyperledger/fabric/protos/common"
+	ab "github.com/hyperledger/fabric/protos/orderer"
+	putils "github.com/hyperledger/fabric/protos/utils"
 )
 
 var testFolder string
@@ -81,3 +87,56 @@ func cleanup(t *testing.T) {
 		t.Fatalf("Error in cleanup:%s", err)
 	}
 }
+
+func TestIteratorSeekByTxIDAndTimestamp(t *testing.T) {
+	conf := fsblkstorage.NewConf(testFolder, 0)
+	cleanup(t)
+	defer cleanup(t)
+
+	ordererLedgerProvider := NewProvider(conf)
+	defer ordererLedgerProvider.Close()
+
+	ordererLedger, _ := ordererLedgerProvider.Create("testLedger")
+	defer ordererLedger.Close()
+	fl := ordererLedger.(*fsLedger)
+
+	var prevHash []byte
+	for i, ts := range []int64{100, 200, 300} {
+		block := constructTimestampedBlock(uint64(i), prevHash, fmt.Sprintf("tx%d", i), ts)
+		testutil.AssertNoError(t, fl.Append(block), "Error appending block")
+		prevHash = block.Header.Hash()
+	}
+	testutil.AssertEquals(t, fl.Height(), uint64(3))
+
+	itr, num := fl.Iterator(&ab.SeekPosition{Type: &ab.SeekPosition_TxId{TxId: &ab.SeekTxID{TxId: "tx1"}}})
+	testutil.AssertEquals(t, num, uint64(1))
+	block, status := itr.Next()
+	testutil.AssertEquals(t, status, common.Status_SUCCESS)
+	testutil.AssertEquals(t, block.Header.Number, uint64(1))
+
+	itr, _ = fl.Iterator(&ab.SeekPosition{Type: &ab.SeekPosition_TxId{TxId: &ab.SeekTxID{TxId: "missing"}}})
+	_, status = itr.Next()
+	testutil.AssertEquals(t, status, common.Status_NOT_FOUND)
+
+	_, num = fl.Iterator(&ab.SeekPosition{Type: &ab.SeekPosition_Timestamp{Timestamp: &ab.SeekTimestamp{Timestamp: &timestamp.Timestamp{Seconds: 150}}}})
+	testutil.AssertEquals(t, num, uint64(1))
+	_, num = fl.Iterator(&ab.SeekPosition{Type: &ab.SeekPosition_Timestamp{Timestamp: &ab.SeekTimestamp{Timestamp: &timestamp.Timestamp{Seconds: 300}}}})
+	testutil.AssertEquals(t, num, uint64(2))
+
+	itr, _ = fl.Iterator(&ab.SeekPosition{Type: &ab.SeekPosition_Timestamp{Timestamp: &ab.SeekTimestamp{Timestamp: &timestamp.Timestamp{Seconds: 400}}}})
+	_, status = itr.Next()
+	testutil.AssertEquals(t, status, common.Status_NOT_FOUND)
+}
+
+func constructTimestampedBlock(blockNum uint64, prevHash []byte, txID string, seconds int64) *common.Block {
+	chdr := putils.MakeChannelHeader(common.HeaderType_ENDORSER_TRANSACTION, 0, "testLedger", 0)
+	chdr.TxId = txID
+	chdr.Timestamp = &timestamp.Timestamp{Seconds: seconds}
+	payload := &common.Payload{Header: putils.MakePayloadHeader(chdr, &common.SignatureHeader{})}
+	block := common.NewBlock(blockNum, prevHash)
+	block.Data.Data = [][]byte{putils.MarshalOrPanic(&common.Envelope{Payload: putils.MarshalOrPanic(payload)})}
+	block.Header.DataHash = block.Data.Hash()
+	putils.InitBlockMetadata(block)
+	block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER] = util.NewTxValidationFlags(1)
+	return block
+}
@@ -166,6 +166,14 @@ func (rl *ramLedger) Iterator(startPosition *ab.SeekPosition) (ordererledger.Ite
 			}
 			list = list.next // No need for nil check, because of range check above
 		}
+	case *ab.SeekPosition_TxId, *ab.SeekPosition_Timestamp:
+		// the blocks are not indexed, hence the retained blocks are scanned
+		number, found := ordererledger.ScanForBlockNumber(rl, startPosition)
+		if !found {
+			logger.Debugf("Returning error iterator because no retained block matches the seek position %v", startPosition)
+			return &ordererledger.NotFoundErrorIterator{}, 0
+		}
+		return rl.Iterator(&ab.SeekPosition{Type: &ab.SeekPosition_Specified{Specified: &ab.SeekSpecified{Number: number}}})
 	}
 	cursor := &cursor{list: list}
 	blockNum := list.block.Header.Number + 1
@@ -17,7 +17,10 @@ limitations under the License.
 package ordererledger
 
 import (
+	"github.com/golang/protobuf/ptypes"
 	cb "github.com/hyperledger/fabric/protos/common"
+	ab "github.com/hyperledger/fabric/protos/orderer"
+	"github.com/hyperledger/fabric/protos/utils"
 )
 
 var closedChan chan struct{}
@@ -39,3 +42,57 @@ func (nfei *NotFoundErrorIterator) Next() (*cb.Block, cb.Status) {
 func (nfei *NotFoundErrorIterator) ReadyChan() <-chan struct{} {
 	return closedChan
 }
+
+// ScanForBlockNumber resolves a position that seeks a transaction id or a timestamp by scanning the blocks of the
+// ledger from the oldest block, and is generally useful for the implementations of the Reader interface that do not
+// index the blocks. The returned bool is false if no block matches the position
+func ScanForBlockNumber(rl Reader, position *ab.SeekPosition) (uint64, bool) {
+	var match func(chdr *cb.ChannelHeader) bool
+	switch seek := position.Type.(type) {
+	case *ab.SeekPosition_TxId:
+		match = func(chdr *cb.ChannelHeader) bool {
+			return chdr.TxId == seek.TxId.TxId
+		}
+	case *ab.SeekPosition_Timestamp:
+		target, err := ptypes.Timestamp(seek.Timestamp.Timestamp)
+		if err != nil {
+			return 0, false
+		}
+		match = func(chdr *cb.ChannelHeader) bool {
+			timestamp, err := ptypes.Timestamp(chdr.Timestamp)
+			return err == nil && !timestamp.Before(target)
+		}
+	default:
+		return 0, false
+	}
+
+	it, number := rl.Iterator(&ab.SeekPosition{Type: &ab.SeekPosition_Oldest{Oldest: &ab.SeekOldest{}}})
+	for height := rl.Height(); number < height; number++ {
+		block, status := it.Next()
+		if status != cb.Status_SUCCESS {
+			return 0, false
+		}
+		for _, envBytes := range block.Data.Data {
+			if chdr := extractChannelHeader(envBytes); chdr != nil && match(chdr) {
+				return block.Header.Number, true
+			}
+		}
+	}
+	return 0, false
+}
+
+func extractChannelHeader(envBytes []byte) *cb.ChannelHeader {
+	env, err := utils.UnmarshalEnvelope(envBytes)
+	if err != nil {
+		return nil
+	}
+	payload, err := utils.UnmarshalPayload(env.Payload)
+	if err != nil || payload.Header == nil {
+		return nil
+	}
+	chdr, err := utils.UnmarshalChannelHeader(payload.Header.ChannelHeader)
+	if err != nil {
+		return nil
+	}
+	return chdr
+}
@@ -55,6 +55,9 @@ type General struct {
 	LocalMSPDir    string
 	LocalMSPID     string
 	BCCSP          *bccsp.FactoryOpts
+	// DeliverReadRate limits the reads of the old blocks for the deliver
+	// requests, in MB per second, 0 meaning no limit
+	DeliverReadRate uint32
 }
 
 //TLS contains config used to configure TLS
@@ -33,6 +33,7 @@ import (
 	"github.com/hyperledger/fabric/orderer/kafka"
 	ordererledger "github.com/hyperledger/fabric/orderer/ledger"
 	fileledger "github.com/hyperledger/fabric/orderer/ledger/file"
+	"github.com/hyperledger/fabric/orderer/ledger/fsledger"
 	ramledger "github.com/hyperledger/fabric/orderer/ledger/ram"
 	"github.com/hyperledger/fabric/orderer/localconfig"
 	"github.com/hyperledger/fabric/orderer/multichain"
@@ -92,7 +93,7 @@ func main() {
 
 	var lf ordererledger.Factory
 	switch conf.General.LedgerType {
-	case "file":
+	case "file", "fs":
 		location := conf.FileLedger.Location
 		if location == "" {
 			var err error
@@ -101,7 +102,11 @@ func main() {
 				panic(fmt.Errorf("Error creating temp dir: %s", err))
 			}
 		}
-		lf = fileledger.New(location)
+		if conf.General.LedgerType == "fs" {
+			lf = fsledger.New(location)
+		} else {
+			lf = fileledger.New(location)
+		}
 	case "ram":
 		fallthrough
 	default:
@@ -158,6 +163,7 @@ func main() {
 	server := NewServer(
 		manager,
 		signer,
+		uint64(conf.General.DeliverReadRate)*1024*1024,
 	)
 
 	ab.RegisterAtomicBroadcastServer(grpcServer.Server(), server)
@@ -127,7 +127,7 @@ func TestSbftPeer(t *testing.T) {
 	signer := localmsp.NewSigner()
 	manager := multichain.NewManagerImpl(lf, consenters, signer)
 
-	server := NewServer(manager, signer)
+	server := NewServer(manager, signer, 0)
 	grpcServer := grpc.NewServer()
 	grpcAddr := fmt.Sprintf("%s:%d", conf.General.ListenAddress, conf.General.ListenPort)
 	lis, err := net.Listen("tcp", grpcAddr)
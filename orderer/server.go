@@ -55,12 +55,14 @@ type server struct {
 	dh deliver.Handler
 }
 
-// NewServer creates a ab.AtomicBroadcastServer based on the broadcast target and ledger Reader
-func NewServer(ml multichain.Manager, signer crypto.LocalSigner) ab.AtomicBroadcastServer {
+// NewServer creates a ab.AtomicBroadcastServer based on the broadcast target and ledger Reader,
+// the deliver reads of the blocks older than the newest being limited to deliverBytesPerSecond
+// unless it is 0
+func NewServer(ml multichain.Manager, signer crypto.LocalSigner, deliverBytesPerSecond uint64) ab.AtomicBroadcastServer {
 	logger.Infof("Starting orderer")
 
 	s := &server{
-		dh: deliver.NewHandlerImpl(deliverSupport{Manager: ml}),
+		dh: deliver.NewThrottledHandlerImpl(deliverSupport{Manager: ml}, deliverBytesPerSecond),
 		bh: broadcast.NewHandlerImpl(broadcastSupport{
 			Manager:               ml,
 			ConfigUpdateProcessor: configupdate.New(ml.SystemChannelID(), configUpdateSupport{Manager: ml}, signer),
@@ -0,0 +1,99 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ledger
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/compare"
+	"github.com/hyperledger/fabric/core/peer"
+	pb "github.com/hyperledger/fabric/protos/peer"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+)
+
+func compareCmd() *cobra.Command {
+	return ledgerCompareCmd
+}
+
+var ledgerCompareCmd = &cobra.Command{
+	Use:   "compare <peerAddress> <peerAddress>",
+	Short: "Compares the ledgers of two peers.",
+	Long: `Compares the ledgers of the given channel on two running peers, the block hashes and the commit hashes per height and the contents of the state databases per namespace, and reports the first divergence. ` +
+		`The state databases are comparable only if both the peers use the same type of state database.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return compareLedgers(args)
+	},
+}
+
+func compareLedgers(args []string) error {
+	if channelID == "" {
+		return fmt.Errorf("Must supply channel ID")
+	}
+	if len(args) != 2 {
+		return fmt.Errorf("Expected the addresses of two peers")
+	}
+	var sources []compare.Source
+	for _, address := range args {
+		conn, err := peer.NewPeerClientConnectionWithAddress(address)
+		if err != nil {
+			return fmt.Errorf("Error trying to connect to peer [%s]: %s", address, err)
+		}
+		defer conn.Close()
+		sources = append(sources, &adminSource{address: address, client: pb.NewAdminClient(conn)})
+	}
+	result, err := compare.Compare(sources[0], sources[1])
+	if err != nil {
+		return err
+	}
+	fmt.Print(result)
+	if result.Diverged() {
+		return fmt.Errorf("Ledgers of channel [%s] diverge", channelID)
+	}
+	return nil
+}
+
+// adminSource provides the fingerprints of the ledger of a peer through its admin service
+type adminSource struct {
+	address string
+	client  pb.AdminClient
+}
+
+func (s *adminSource) Name() string {
+	return s.address
+}
+
+func (s *adminSource) GetBlockFingerprint(blockNumber uint64) (*ledger.BlockFingerprint, error) {
+	response, err := s.client.GetBlockFingerprint(context.Background(), &pb.BlockFingerprintRequest{ChannelId: channelID, BlockNumber: blockNumber})
+	if err != nil {
+		return nil, err
+	}
+	return &ledger.BlockFingerprint{BlockNumber: response.BlockNumber, BlockHash: response.BlockHash, CommitHash: response.CommitHash}, nil
+}
+
+func (s *adminSource) GetStateFingerprint() (*ledger.StateFingerprint, error) {
+	response, err := s.client.GetStateFingerprint(context.Background(), &pb.StateFingerprintRequest{ChannelId: channelID})
+	if err != nil {
+		return nil, err
+	}
+	fingerprint := &ledger.StateFingerprint{Height: response.Height, NamespaceHashes: make(map[string][]byte)}
+	for _, nsHash := range response.NamespaceHashes {
+		fingerprint.NamespaceHashes[nsHash.Namespace] = nsHash.Hash
+	}
+	return fingerprint, nil
+}
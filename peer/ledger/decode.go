@@ -0,0 +1,174 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ledger
+
+import (
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/rwset"
+	"github.com/hyperledger/fabric/core/ledger/util"
+	"github.com/hyperledger/fabric/msp"
+	"github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+// decodedBlock is the JSON friendly form of a block
+type decodedBlock struct {
+	Number       uint64                `json:"number"`
+	PreviousHash string                `json:"previous_hash"`
+	DataHash     string                `json:"data_hash"`
+	Transactions []*decodedTransaction `json:"transactions"`
+}
+
+// decodedTransaction is the JSON friendly form of a transaction envelope
+type decodedTransaction struct {
+	TxID           string           `json:"tx_id"`
+	ChannelID      string           `json:"channel_id"`
+	Type           string           `json:"type"`
+	Timestamp      string           `json:"timestamp,omitempty"`
+	CreatorMSPID   string           `json:"creator_mspid,omitempty"`
+	ChaincodeID    *pb.ChaincodeID  `json:"chaincode_id,omitempty"`
+	ValidationCode string           `json:"validation_code"`
+	Actions        []*decodedAction `json:"actions,omitempty"`
+}
+
+// decodedAction is the JSON friendly form of an endorsed chaincode action
+type decodedAction struct {
+	EndorserMSPIDs []string              `json:"endorser_mspids"`
+	Response       *pb.Response          `json:"response,omitempty"`
+	RWSet          *rwset.TxReadWriteSet `json:"rwset"`
+}
+
+func decodeBlock(block *common.Block) (*decodedBlock, error) {
+	decoded := &decodedBlock{
+		Number:       block.Header.Number,
+		PreviousHash: hex.EncodeToString(block.Header.PreviousHash),
+		DataHash:     hex.EncodeToString(block.Header.DataHash),
+	}
+	txsFilter := util.TxValidationFlags(block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER])
+	for i, envBytes := range block.Data.Data {
+		env, err := utils.GetEnvelopeFromBlock(envBytes)
+		if err != nil {
+			return nil, err
+		}
+		validationCode := pb.TxValidationCode_VALID
+		if len(txsFilter) > i {
+			validationCode = txsFilter.Flag(i)
+		}
+		tx, err := decodeTransaction(env, validationCode)
+		if err != nil {
+			return nil, fmt.Errorf("Error decoding transaction %d of block %d: %s", i, block.Header.Number, err)
+		}
+		decoded.Transactions = append(decoded.Transactions, tx)
+	}
+	return decoded, nil
+}
+
+func decodeTransaction(env *common.Envelope, validationCode pb.TxValidationCode) (*decodedTransaction, error) {
+	payload, err := utils.GetPayload(env)
+	if err != nil {
+		return nil, err
+	}
+	if payload.Header == nil {
+		return nil, fmt.Errorf("Envelope does not carry a header")
+	}
+	chdr, err := utils.UnmarshalChannelHeader(payload.Header.ChannelHeader)
+	if err != nil {
+		return nil, err
+	}
+	decoded := &decodedTransaction{
+		TxID:           chdr.TxId,
+		ChannelID:      chdr.ChannelId,
+		Type:           common.HeaderType(chdr.Type).String(),
+		ValidationCode: validationCode.String(),
+	}
+	if chdr.Timestamp != nil {
+		timestamp, err := ptypes.Timestamp(chdr.Timestamp)
+		if err != nil {
+			return nil, err
+		}
+		decoded.Timestamp = timestamp.Format(time.RFC3339Nano)
+	}
+	shdr, err := utils.GetSignatureHeader(payload.Header.SignatureHeader)
+	if err != nil {
+		return nil, err
+	}
+	decoded.CreatorMSPID = mspIDOf(shdr.Creator)
+
+	if common.HeaderType(chdr.Type) != common.HeaderType_ENDORSER_TRANSACTION {
+		return decoded, nil
+	}
+	hdrExt, err := utils.GetChaincodeHeaderExtension(payload.Header)
+	if err != nil {
+		return nil, err
+	}
+	decoded.ChaincodeID = hdrExt.ChaincodeId
+	tx, err := utils.GetTransaction(payload.Data)
+	if err != nil {
+		return nil, err
+	}
+	for _, action := range tx.Actions {
+		decodedAction, err := decodeAction(action)
+		if err != nil {
+			return nil, err
+		}
+		decoded.Actions = append(decoded.Actions, decodedAction)
+	}
+	return decoded, nil
+}
+
+func decodeAction(action *pb.TransactionAction) (*decodedAction, error) {
+	ccActionPayload, err := utils.GetChaincodeActionPayload(action.Payload)
+	if err != nil {
+		return nil, err
+	}
+	if ccActionPayload.Action == nil {
+		return nil, fmt.Errorf("Transaction action does not carry an endorsed action")
+	}
+	decoded := &decodedAction{EndorserMSPIDs: []string{}}
+	for _, endorsement := range ccActionPayload.Action.Endorsements {
+		decoded.EndorserMSPIDs = append(decoded.EndorserMSPIDs, mspIDOf(endorsement.Endorser))
+	}
+	respPayload, err := utils.GetProposalResponsePayload(ccActionPayload.Action.ProposalResponsePayload)
+	if err != nil {
+		return nil, err
+	}
+	ccAction, err := utils.GetChaincodeAction(respPayload.Extension)
+	if err != nil {
+		return nil, err
+	}
+	decoded.Response = ccAction.Response
+	decoded.RWSet = &rwset.TxReadWriteSet{}
+	if err := decoded.RWSet.Unmarshal(ccAction.Results); err != nil {
+		return nil, err
+	}
+	return decoded, nil
+}
+
+// mspIDOf returns the MSP id of the serialized identity, or an empty string if the identity cannot be decoded
+func mspIDOf(serializedIdentity []byte) string {
+	sID := &msp.SerializedIdentity{}
+	if err := proto.Unmarshal(serializedIdentity, sID); err != nil {
+		return ""
+	}
+	return sID.Mspid
+}
@@ -0,0 +1,54 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ledger
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/rwset"
+	"github.com/hyperledger/fabric/core/ledger/util"
+	"github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric/protos/peer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeBlock(t *testing.T) {
+	txRWSet := &rwset.TxReadWriteSet{NsRWs: []*rwset.NsReadWriteSet{
+		{NameSpace: "ns1", Writes: []*rwset.KVWrite{rwset.NewKVWrite("key1", []byte("value1"))}},
+	}}
+	simulationResults, err := txRWSet.Marshal()
+	assert.NoError(t, err)
+	block := testutil.ConstructBlock(t, [][]byte{simulationResults, simulationResults}, false)
+	txsFilter := util.NewTxValidationFlags(2)
+	txsFilter.SetFlag(1, pb.TxValidationCode_MVCC_READ_CONFLICT)
+	block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER] = txsFilter
+
+	decoded, err := decodeBlock(block)
+	assert.NoError(t, err)
+	assert.Equal(t, block.Header.Number, decoded.Number)
+	assert.Len(t, decoded.Transactions, 2)
+	assert.Equal(t, common.HeaderType_ENDORSER_TRANSACTION.String(), decoded.Transactions[0].Type)
+	assert.Equal(t, pb.TxValidationCode_VALID.String(), decoded.Transactions[0].ValidationCode)
+	assert.Equal(t, pb.TxValidationCode_MVCC_READ_CONFLICT.String(), decoded.Transactions[1].ValidationCode)
+	assert.Len(t, decoded.Transactions[0].Actions, 1)
+	assert.Equal(t, txRWSet, decoded.Transactions[0].Actions[0].RWSet)
+
+	_, err = decodeBlock(&common.Block{Header: &common.BlockHeader{}, Data: &common.BlockData{Data: [][]byte{[]byte("junk")}},
+		Metadata: &common.BlockMetadata{Metadata: [][]byte{{}, {}, {}, {}}}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,62 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ledger
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/core/ledger/kvledger"
+	"github.com/spf13/cobra"
+)
+
+var blockNumber uint64
+
+func dumpBlockCmd() *cobra.Command {
+	ledgerDumpBlockCmd.Flags().Uint64VarP(&blockNumber, "blockNumber", "n", 0, "The number of the block to dump")
+	return ledgerDumpBlockCmd
+}
+
+var ledgerDumpBlockCmd = &cobra.Command{
+	Use:   "dumpblock",
+	Short: "Prints a block of the ledger as JSON.",
+	Long:  `Reads a block from the block store of the ledger of the given channel and prints its header, transactions, chaincode actions, and read-write sets as JSON.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return dumpBlock()
+	},
+}
+
+func dumpBlock() error {
+	if channelID == "" {
+		return fmt.Errorf("Must supply channel ID")
+	}
+	blockStoreProvider, blockStore, err := kvledger.OpenReadOnlyBlockStore(channelID)
+	if err != nil {
+		return fmt.Errorf("Error opening the block store of channel [%s]: %s", channelID, err)
+	}
+	defer blockStoreProvider.Close()
+	defer blockStore.Shutdown()
+
+	block, err := blockStore.RetrieveBlockByNumber(blockNumber)
+	if err != nil {
+		return fmt.Errorf("Error retrieving block %d of channel [%s]: %s", blockNumber, channelID, err)
+	}
+	decoded, err := decodeBlock(block)
+	if err != nil {
+		return err
+	}
+	return printJSON(decoded)
+}
@@ -0,0 +1,69 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ledger
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/core/ledger/kvledger"
+	"github.com/spf13/cobra"
+)
+
+var txID string
+
+func dumpTxCmd() *cobra.Command {
+	ledgerDumpTxCmd.Flags().StringVar(&txID, "txid", "", "The id of the transaction to dump")
+	return ledgerDumpTxCmd
+}
+
+var ledgerDumpTxCmd = &cobra.Command{
+	Use:   "dumptx",
+	Short: "Prints a transaction of the ledger as JSON.",
+	Long:  `Reads a transaction from the block store of the ledger of the given channel and prints its header, validation code, chaincode actions, and read-write sets as JSON.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return dumpTx()
+	},
+}
+
+func dumpTx() error {
+	if channelID == "" {
+		return fmt.Errorf("Must supply channel ID")
+	}
+	if txID == "" {
+		return fmt.Errorf("Must supply transaction ID")
+	}
+	blockStoreProvider, blockStore, err := kvledger.OpenReadOnlyBlockStore(channelID)
+	if err != nil {
+		return fmt.Errorf("Error opening the block store of channel [%s]: %s", channelID, err)
+	}
+	defer blockStoreProvider.Close()
+	defer blockStore.Shutdown()
+
+	env, err := blockStore.RetrieveTxByID(txID)
+	if err != nil {
+		return fmt.Errorf("Error retrieving transaction [%s] of channel [%s]: %s", txID, channelID, err)
+	}
+	validationCode, err := blockStore.RetrieveTxValidationCodeByTxID(txID)
+	if err != nil {
+		return fmt.Errorf("Error retrieving the validation code of transaction [%s]: %s", txID, err)
+	}
+	decoded, err := decodeTransaction(env, validationCode)
+	if err != nil {
+		return err
+	}
+	return printJSON(decoded)
+}
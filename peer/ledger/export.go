@@ -0,0 +1,87 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ledger
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/core/ledger/export"
+	"github.com/hyperledger/fabric/core/ledger/kvledger"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportNamespace string
+	exportHistory   bool
+	exportFormat    string
+	exportOutputDir string
+	redactValues    []string
+	hashCreators    bool
+	exportCreators  bool
+)
+
+func exportCmd() *cobra.Command {
+	flags := ledgerExportCmd.Flags()
+	flags.StringVar(&exportNamespace, "namespace", "", "The namespace to export. All the namespaces are exported if not set")
+	flags.BoolVar(&exportHistory, "history", false, "Export the writes of the valid transactions along with the state")
+	flags.StringVar(&exportFormat, "format", string(export.FormatNDJSON), "The format of the export files, ndjson or avro")
+	flags.StringVarP(&exportOutputDir, "output", "o", "", "The directory to write the export files to, which must be empty or absent")
+	flags.StringSliceVar(&redactValues, "redact-values", nil,
+		"The namespaces, or namespace/collection pairs for the hashes of private data, whose values are exported empty")
+	flags.BoolVar(&exportCreators, "creators", false, "Export the MSP ids and the subjects of the certificates of the creators of the transactions")
+	flags.BoolVar(&hashCreators, "hash-creators", false, "Export the creators of the transactions as hashes of their identities, keyed for this export")
+	return ledgerExportCmd
+}
+
+var ledgerExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Exports the state and the history of a ledger to files.",
+	Long: `Exports the committed state of the ledger of the given channel, and optionally the history of the writes, to newline-delimited JSON or Avro files ` +
+		`for the ingestion by a data warehouse. The ledger is opened in read-only mode, from the peer file system or from a copy of it. ` +
+		`The values of selected namespaces can be redacted, so that the export can be shared, e.g., for a support case. ` +
+		`The creators of the transactions are exported, in clear or hashed, only if requested.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return exportLedger()
+	},
+}
+
+func exportLedger() error {
+	if channelID == "" {
+		return fmt.Errorf("Must supply channel ID")
+	}
+	if exportOutputDir == "" {
+		return fmt.Errorf("Must supply the output directory")
+	}
+	format := export.Format(exportFormat)
+	if format != export.FormatNDJSON && format != export.FormatAvro {
+		return fmt.Errorf("Unsupported export format [%s], expected ndjson or avro", exportFormat)
+	}
+	opts := &kvledger.ExportOptions{Namespace: exportNamespace, History: exportHistory, Format: format, Creators: exportCreators}
+	if len(redactValues) > 0 || hashCreators {
+		opts.Redaction = &export.RedactionRules{DropValues: redactValues, HashCreators: hashCreators}
+	}
+	summary, err := kvledger.ExportLedgerData(channelID, exportOutputDir, opts)
+	if err != nil {
+		return fmt.Errorf("Error exporting the ledger of channel [%s]: %s", channelID, err)
+	}
+	fmt.Printf("Exported the state of channel [%s] at height [%d]: [%d] state records, [%d] history records, [%d] redacted values\n",
+		channelID, summary.Height, summary.StateRecords, summary.HistoryRecords, summary.RedactedValues)
+	for _, file := range summary.Files {
+		fmt.Println(file)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ledger
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+const ledgerFuncName = "ledger"
+
+var channelID string
+
+// Cmd returns the cobra command for Ledger
+func Cmd() *cobra.Command {
+	ledgerCmd.PersistentFlags().StringVarP(&channelID, "channelID", "c", "", "The channel of the ledger")
+	ledgerCmd.AddCommand(dumpBlockCmd())
+	ledgerCmd.AddCommand(dumpTxCmd())
+	ledgerCmd.AddCommand(reindexCmd())
+	ledgerCmd.AddCommand(compareCmd())
+	ledgerCmd.AddCommand(exportCmd())
+	ledgerCmd.AddCommand(migrateStateCmd())
+	ledgerCmd.AddCommand(migrateHistoryCmd())
+	ledgerCmd.AddCommand(upgradeDBsCmd())
+
+	return ledgerCmd
+}
+
+var ledgerCmd = &cobra.Command{
+	Use:   ledgerFuncName,
+	Short: fmt.Sprintf("%s specific commands.", ledgerFuncName),
+	Long:  fmt.Sprintf("%s specific commands. These commands are intended for troubleshooting the ledgers.", ledgerFuncName),
+}
+
+func printJSON(v interface{}) error {
+	jsonBytes, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(jsonBytes))
+	return nil
+}
@@ -0,0 +1,61 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ledger
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/core/ledger/kvledger"
+	"github.com/spf13/cobra"
+)
+
+var (
+	migrateStateTo         string
+	migrateStateDropSource bool
+)
+
+func migrateStateCmd() *cobra.Command {
+	flags := ledgerMigrateStateCmd.Flags()
+	flags.StringVar(&migrateStateTo, "to", "", "The type of the target state database, goleveldb or CouchDB")
+	flags.BoolVar(&migrateStateDropSource, "dropsource", false, "Drop the source state database after a successful migration")
+	return ledgerMigrateStateCmd
+}
+
+var ledgerMigrateStateCmd = &cobra.Command{
+	Use:   "migratestate",
+	Short: "Migrates the state database of a ledger to another type of state database.",
+	Long:  `Copies the state database of the ledger of the given channel to a state database of the given type, verifies the copy against the source, and switches the ledger to the target state database, which avoids replaying the blocks to change the state database of an existing peer. The target type is recorded with the ledger and overrides ledger.state.stateDatabase. The peer must be stopped while this command runs.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return migrateState()
+	},
+}
+
+func migrateState() error {
+	if channelID == "" {
+		return fmt.Errorf("Must supply channel ID")
+	}
+	if migrateStateTo == "" {
+		return fmt.Errorf("Must supply the target state database with --to")
+	}
+	fmt.Printf("Migrating the state database of channel [%s] to [%s]\n", channelID, migrateStateTo)
+	summary, err := kvledger.MigrateStateDB(channelID, migrateStateTo, migrateStateDropSource)
+	if err != nil {
+		return fmt.Errorf("Error migrating the state database of channel [%s]: %s", channelID, err)
+	}
+	fmt.Printf("Migrated [%d] key-values of the state database of channel [%s] at height [%d]\n", summary.KeyValues, channelID, summary.Height)
+	return nil
+}
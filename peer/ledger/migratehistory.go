@@ -0,0 +1,56 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ledger
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/core/ledger/kvledger"
+	"github.com/spf13/cobra"
+)
+
+var migrateHistoryKeyFormat string
+
+func migrateHistoryCmd() *cobra.Command {
+	ledgerMigrateHistoryCmd.Flags().StringVar(&migrateHistoryKeyFormat, "keyformat", "", "The target format of the history keys, v1 or v2")
+	return ledgerMigrateHistoryCmd
+}
+
+var ledgerMigrateHistoryCmd = &cobra.Command{
+	Use:   "migratehistory",
+	Short: "Migrates the keys of the history database of a ledger to another key format.",
+	Long:  `Rewrites, in place, the keys of the history database of the ledger of the given channel to the given key format. The v2 format orders the history of a key from the most recent modification. An existing history database keeps its key format regardless of ledger.state.historyKeyFormat, which applies to the new history databases only. An interrupted migration is completed by running the command again. The peer must be stopped while this command runs.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return migrateHistory()
+	},
+}
+
+func migrateHistory() error {
+	if channelID == "" {
+		return fmt.Errorf("Must supply channel ID")
+	}
+	if migrateHistoryKeyFormat == "" {
+		return fmt.Errorf("Must supply the target key format with --keyformat")
+	}
+	fmt.Printf("Migrating the history keys of channel [%s] to format [%s]\n", channelID, migrateHistoryKeyFormat)
+	migrated, err := kvledger.MigrateHistoryKeyFormat(channelID, migrateHistoryKeyFormat)
+	if err != nil {
+		return fmt.Errorf("Error migrating the history keys of channel [%s]: %s", channelID, err)
+	}
+	fmt.Printf("Migrated [%d] history keys of channel [%s] to format [%s]\n", migrated, channelID, migrateHistoryKeyFormat)
+	return nil
+}
@@ -0,0 +1,77 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ledger
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/core/ledger/kvledger"
+	"github.com/spf13/cobra"
+)
+
+var (
+	reindexBlockIndex bool
+	reindexHistory    bool
+	reindexState      bool
+)
+
+func reindexCmd() *cobra.Command {
+	flags := ledgerReindexCmd.Flags()
+	flags.BoolVar(&reindexBlockIndex, "blockindex", false, "Rebuild the index of the block store from the block files")
+	flags.BoolVar(&reindexState, "state", false, "Rebuild the state database by replaying the blocks")
+	flags.BoolVar(&reindexHistory, "history", false, "Rebuild the history database by replaying the blocks")
+	return ledgerReindexCmd
+}
+
+var ledgerReindexCmd = &cobra.Command{
+	Use:   "reindex",
+	Short: "Rebuilds the indexes and the databases of a ledger.",
+	Long:  `Rebuilds the block index, the state database, and/or the history database of the ledger of the given channel from the block store. The peer must be stopped while this command runs.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return reindex()
+	},
+}
+
+func reindex() error {
+	if channelID == "" {
+		return fmt.Errorf("Must supply channel ID")
+	}
+	if !reindexBlockIndex && !reindexState && !reindexHistory {
+		return fmt.Errorf("Must supply at least one of --blockindex, --state, or --history")
+	}
+	// the block index is rebuilt first as the databases are rebuilt by replaying the blocks retrieved through the index
+	if reindexBlockIndex {
+		fmt.Printf("Rebuilding the block index of channel [%s]\n", channelID)
+		if err := kvledger.RebuildBlockIndex(channelID); err != nil {
+			return fmt.Errorf("Error rebuilding the block index of channel [%s]: %s", channelID, err)
+		}
+	}
+	if reindexState {
+		fmt.Printf("Rebuilding the state database of channel [%s]\n", channelID)
+		if err := kvledger.RebuildStateDB(channelID); err != nil {
+			return fmt.Errorf("Error rebuilding the state database of channel [%s]: %s", channelID, err)
+		}
+	}
+	if reindexHistory {
+		fmt.Printf("Rebuilding the history database of channel [%s]\n", channelID)
+		if err := kvledger.RebuildHistoryDB(channelID); err != nil {
+			return fmt.Errorf("Error rebuilding the history database of channel [%s]: %s", channelID, err)
+		}
+	}
+	fmt.Printf("Rebuilt the ledger of channel [%s]\n", channelID)
+	return nil
+}
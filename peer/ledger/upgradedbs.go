@@ -0,0 +1,57 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ledger
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/core/ledger/kvledger"
+	"github.com/spf13/cobra"
+)
+
+func upgradeDBsCmd() *cobra.Command {
+	return ledgerUpgradeDBsCmd
+}
+
+var ledgerUpgradeDBsCmd = &cobra.Command{
+	Use:   "upgradedbs",
+	Short: "Upgrades the data of the ledger stores to the format of this version of the peer.",
+	Long:  `Converts, in place, the data of the id store, the block store, the history database, and the state databases of all the ledgers to the data format that this version of the peer reads and writes. A peer refuses to open the stores that hold their data in another format. The peer must be stopped while this command runs.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return upgradeDBs()
+	},
+}
+
+func upgradeDBs() error {
+	fmt.Println("Upgrading the ledger stores")
+	upgrades, err := kvledger.UpgradeDBs()
+	if err != nil {
+		return fmt.Errorf("Error upgrading the ledger stores: %s", err)
+	}
+	for _, upgrade := range upgrades {
+		store := upgrade.Store
+		if upgrade.LedgerID != "" {
+			store = fmt.Sprintf("%s of channel [%s]", upgrade.Store, upgrade.LedgerID)
+		}
+		if upgrade.From == upgrade.To {
+			fmt.Printf("The %s is in format [%s]\n", store, upgrade.To)
+		} else {
+			fmt.Printf("Upgraded the %s from format [%s] to [%s]\n", store, upgrade.From, upgrade.To)
+		}
+	}
+	return nil
+}
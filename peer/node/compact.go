@@ -0,0 +1,58 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package node
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/peer/common"
+	pb "github.com/hyperledger/fabric/protos/peer"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+)
+
+var compactChannelID string
+
+func compactCmd() *cobra.Command {
+	nodeCompactCmd.Flags().StringVarP(&compactChannelID, "channelID", "c", "", "The channel whose ledger to compact. All the channels are compacted if not set")
+	return nodeCompactCmd
+}
+
+var nodeCompactCmd = &cobra.Command{
+	Use:   "compact",
+	Short: "Compacts the block index and the history database of the ledgers.",
+	Long:  `Compacts the goleveldb block index and history database of the ledger of each channel of the running node, which reduces the read amplification that a write-heavy channel leaves behind. The compactions run one after the other and the command returns when all of them are done.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return compact()
+	},
+}
+
+func compact() error {
+	adminClient, err := common.GetAdminClient()
+	if err != nil {
+		return err
+	}
+	response, err := adminClient.CompactLedger(context.Background(), &pb.LedgerCompactionRequest{ChannelId: compactChannelID})
+	if err != nil {
+		return fmt.Errorf("Error trying to connect to local peer: %s", err)
+	}
+	fmt.Printf("%-30s %20s\n", "CHANNEL", "DURATION(MS)")
+	for _, l := range response.Ledgers {
+		fmt.Printf("%-30s %20d\n", l.ChannelId, l.DurationMillis)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package node
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/peer/common"
+	pb "github.com/hyperledger/fabric/protos/peer"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+)
+
+var diskUsageChannelID string
+
+func diskUsageCmd() *cobra.Command {
+	nodeDiskUsageCmd.Flags().StringVarP(&diskUsageChannelID, "channelID", "c", "", "The channel whose ledger to report. All the channels are reported if not set")
+	return nodeDiskUsageCmd
+}
+
+var nodeDiskUsageCmd = &cobra.Command{
+	Use:   "diskusage",
+	Short: "Reports the disk usage of the ledgers.",
+	Long:  `Reports the number of bytes that the block store, the state database, and the history database of the ledger of each channel occupy on the disk of the running node.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return diskUsage()
+	},
+}
+
+func diskUsage() error {
+	adminClient, err := common.GetAdminClient()
+	if err != nil {
+		return err
+	}
+	response, err := adminClient.GetLedgerDiskUsage(context.Background(), &pb.LedgerDiskUsageRequest{ChannelId: diskUsageChannelID})
+	if err != nil {
+		return fmt.Errorf("Error trying to connect to local peer: %s", err)
+	}
+	fmt.Printf("%-30s %20s %20s %20s\n", "CHANNEL", "BLOCKSTORE(BYTES)", "STATEDB(BYTES)", "HISTORYDB(BYTES)")
+	for _, l := range response.Ledgers {
+		fmt.Printf("%-30s %20d %20d %20d\n", l.ChannelId, l.BlockStoreBytes, l.StateDbBytes, l.HistoryDbBytes)
+	}
+	return nil
+}
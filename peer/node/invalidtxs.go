@@ -0,0 +1,65 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package node
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/peer/common"
+	pb "github.com/hyperledger/fabric/protos/peer"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+)
+
+var invalidTxsChannelID string
+var invalidTxsMaxEntries uint32
+
+func invalidTxsCmd() *cobra.Command {
+	flags := nodeInvalidTxsCmd.Flags()
+	flags.StringVarP(&invalidTxsChannelID, "channelID", "c", "", "The channel whose invalid transactions to return. All the channels are reported if not set")
+	flags.Uint32VarP(&invalidTxsMaxEntries, "maxEntries", "n", 0, "The number of the most recent invalid transactions to return per channel. All the logged transactions are returned if not set")
+	return nodeInvalidTxsCmd
+}
+
+var nodeInvalidTxsCmd = &cobra.Command{
+	Use:   "invalidtxs",
+	Short: "Returns the transactions invalidated at commit.",
+	Long:  `Returns the most recent transactions invalidated at commit of the ledger of each channel, along with the validation code, the MSP of the creator, and the chaincode invoked, to spot the clients that produce endorsement policy failures or MVCC conflicts. The transactions are logged only if ledger.invalidTxLog.maxEntries is set.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return invalidTxs()
+	},
+}
+
+func invalidTxs() error {
+	adminClient, err := common.GetAdminClient()
+	if err != nil {
+		return err
+	}
+	response, err := adminClient.GetInvalidTransactions(context.Background(),
+		&pb.InvalidTransactionsRequest{ChannelId: invalidTxsChannelID, MaxEntries: invalidTxsMaxEntries})
+	if err != nil {
+		return fmt.Errorf("Error trying to connect to local peer: %s", err)
+	}
+	fmt.Printf("%-20s %10s %6s %-64s %-36s %-20s %-20s\n", "CHANNEL", "BLOCK", "TX", "TXID", "VALIDATION CODE", "CREATOR MSP", "CHAINCODE")
+	for _, l := range response.Ledgers {
+		for _, tx := range l.Transactions {
+			fmt.Printf("%-20s %10d %6d %-64s %-36s %-20s %-20s\n",
+				l.ChannelId, tx.BlockNum, tx.TxNum, tx.TxId, tx.ValidationCode, tx.CreatorMspId, tx.Chaincode)
+		}
+	}
+	return nil
+}
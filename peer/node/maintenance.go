@@ -0,0 +1,62 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package node
+
+import (
+	"fmt"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/hyperledger/fabric/peer/common"
+	pb "github.com/hyperledger/fabric/protos/peer"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+)
+
+func maintenanceCmd() *cobra.Command {
+	return nodeMaintenanceCmd
+}
+
+var nodeMaintenanceCmd = &cobra.Command{
+	Use:   "maintenance enter|exit",
+	Short: "Switches the node to or from maintenance mode.",
+	Long:  `Switches the running node to or from maintenance mode, in which the channels remain loaded but commits and endorsements are disabled.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return maintenance(args)
+	},
+}
+
+func maintenance(args []string) error {
+	if len(args) != 1 || (args[0] != "enter" && args[0] != "exit") {
+		return fmt.Errorf("Expected a single argument 'enter' or 'exit'")
+	}
+	adminClient, err := common.GetAdminClient()
+	if err != nil {
+		return err
+	}
+
+	var status *pb.ServerStatus
+	if args[0] == "enter" {
+		status, err = adminClient.EnterMaintenanceMode(context.Background(), &empty.Empty{})
+	} else {
+		status, err = adminClient.ExitMaintenanceMode(context.Background(), &empty.Empty{})
+	}
+	if err != nil {
+		return fmt.Errorf("Error trying to connect to local peer: %s", err)
+	}
+	fmt.Println(status)
+	return nil
+}
@@ -35,6 +35,14 @@ func Cmd() *cobra.Command {
 	nodeCmd.AddCommand(startCmd())
 	nodeCmd.AddCommand(statusCmd())
 	nodeCmd.AddCommand(stopCmd())
+	nodeCmd.AddCommand(maintenanceCmd())
+	nodeCmd.AddCommand(diskUsageCmd())
+	nodeCmd.AddCommand(warmUpCmd())
+	nodeCmd.AddCommand(nsStatsCmd())
+	nodeCmd.AddCommand(quiesceCmd())
+	nodeCmd.AddCommand(compactCmd())
+	nodeCmd.AddCommand(sysStateSnapshotCmd())
+	nodeCmd.AddCommand(invalidTxsCmd())
 
 	return nodeCmd
 }
@@ -0,0 +1,63 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package node
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/peer/common"
+	pb "github.com/hyperledger/fabric/protos/peer"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+)
+
+var nsStatsChannelID string
+
+func nsStatsCmd() *cobra.Command {
+	flags := nodeNsStatsCmd.Flags()
+	flags.StringVarP(&nsStatsChannelID, "channelID", "c", "", "The channel whose namespace statistics to return. All the channels are reported if not set")
+	return nodeNsStatsCmd
+}
+
+var nodeNsStatsCmd = &cobra.Command{
+	Use:   "nsstats",
+	Short: "Returns the statistics of the namespaces of the ledgers.",
+	Long:  `Returns, per namespace of the ledger of each channel, the number of the blocks, the reads, the range queries, the rich queries, the writes, the deletes, and the written bytes counted by the running node since it started. The operations are counted only if ledger.state.namespaceStats is true.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return nsStats()
+	},
+}
+
+func nsStats() error {
+	adminClient, err := common.GetAdminClient()
+	if err != nil {
+		return err
+	}
+	response, err := adminClient.GetNamespaceStats(context.Background(), &pb.NamespaceStatsRequest{ChannelId: nsStatsChannelID})
+	if err != nil {
+		return fmt.Errorf("Error trying to connect to local peer: %s", err)
+	}
+	fmt.Printf("%-20s %-20s %10s %12s %12s %12s %12s %12s %16s\n",
+		"CHANNEL", "NAMESPACE", "BLOCKS", "READS", "RANGE", "RICH", "WRITES", "DELETES", "WRITTEN BYTES")
+	for _, l := range response.Ledgers {
+		for _, ns := range l.Namespaces {
+			fmt.Printf("%-20s %-20s %10d %12d %12d %12d %12d %12d %16d\n",
+				l.ChannelId, ns.Namespace, ns.Blocks, ns.Reads, ns.RangeQueries, ns.RichQueries, ns.Writes, ns.Deletes, ns.WrittenBytes)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package node
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/hyperledger/fabric/peer/common"
+	pb "github.com/hyperledger/fabric/protos/peer"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+)
+
+var quiesceTimeout time.Duration
+
+func quiesceCmd() *cobra.Command {
+	nodeQuiesceCmd.Flags().DurationVarP(&quiesceTimeout, "timeout", "t", 0, "The duration after which the node releases the commits if they are not released. The node default applies if not set")
+	return nodeQuiesceCmd
+}
+
+var nodeQuiesceCmd = &cobra.Command{
+	Use:   "quiesce start|release",
+	Short: "Quiesces or releases the commits of the node for a backup.",
+	Long:  `Blocks the commits and flushes the ledgers of the running node to the disk so that the block store, the state database, and the history database can be backed up as a consistent set, or releases the commits after the backup. The heights of the stores of each ledger are reported on the start.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return quiesce(args)
+	},
+}
+
+func quiesce(args []string) error {
+	if len(args) != 1 || (args[0] != "start" && args[0] != "release") {
+		return fmt.Errorf("Expected a single argument 'start' or 'release'")
+	}
+	adminClient, err := common.GetAdminClient()
+	if err != nil {
+		return err
+	}
+
+	if args[0] == "release" {
+		status, err := adminClient.ReleaseCommits(context.Background(), &empty.Empty{})
+		if err != nil {
+			return fmt.Errorf("Error trying to connect to local peer: %s", err)
+		}
+		fmt.Println(status)
+		return nil
+	}
+	response, err := adminClient.QuiesceCommits(context.Background(), &pb.QuiesceCommitsRequest{TimeoutSeconds: uint32(quiesceTimeout / time.Second)})
+	if err != nil {
+		return fmt.Errorf("Error trying to connect to local peer: %s", err)
+	}
+	fmt.Printf("%-30s %20s %20s %20s\n", "CHANNEL", "BLOCKSTORE(HEIGHT)", "STATEDB(HEIGHT)", "HISTORYDB(HEIGHT)")
+	for _, l := range response.Ledgers {
+		fmt.Printf("%-30s %20d %20d %20d\n", l.ChannelId, l.BlockStoreHeight, l.StateDbHeight, l.HistoryDbHeight)
+	}
+	return nil
+}
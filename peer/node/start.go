@@ -32,15 +32,26 @@ import (
 	"github.com/hyperledger/fabric/common/configvalues/channel/application"
 	"github.com/hyperledger/fabric/common/configvalues/msp"
 	"github.com/hyperledger/fabric/common/genesis"
+	"github.com/hyperledger/fabric/common/metrics"
 	"github.com/hyperledger/fabric/common/policies"
+	"github.com/hyperledger/fabric/common/tracing"
 	"github.com/hyperledger/fabric/common/util"
 	"github.com/hyperledger/fabric/core"
+	"github.com/hyperledger/fabric/core/cdc"
 	"github.com/hyperledger/fabric/core/chaincode"
 	"github.com/hyperledger/fabric/core/comm"
 	"github.com/hyperledger/fabric/core/endorser"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger"
+	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
 	"github.com/hyperledger/fabric/core/ledger/ledgermgmt"
+	"github.com/hyperledger/fabric/core/ledgeradmin"
 	"github.com/hyperledger/fabric/core/peer"
 	"github.com/hyperledger/fabric/core/scc"
+	"github.com/hyperledger/fabric/core/snapshot"
+	"github.com/hyperledger/fabric/core/restgateway"
+	"github.com/hyperledger/fabric/core/statequery"
+	"github.com/hyperledger/fabric/core/transientstore"
 	"github.com/hyperledger/fabric/events/producer"
 	"github.com/hyperledger/fabric/gossip/service"
 	"github.com/hyperledger/fabric/msp/mgmt"
@@ -84,7 +95,27 @@ func initSysCCs() {
 }
 
 func serve(args []string) error {
+	// the spans of the endorsements and of the block commits are reported in the peer log if enabled
+	if viper.GetBool("peer.tracing.enabled") {
+		tracing.SetReporter(&tracing.LogReporter{})
+	}
+	// the change data capture is registered with the ledger before the ledgers are opened
+	if viper.GetBool("ledger.cdc.enabled") {
+		sink, err := cdc.NewSinkFromConfig()
+		if err != nil {
+			return fmt.Errorf("Failed to initialize the change data capture: %s", err)
+		}
+		kvledger.RegisterCommitDecoratorProvider(cdc.NewProvider(sink, viper.GetDuration("ledger.cdc.retryInterval")))
+	}
+	// the transient store is registered with the ledger so that the data of the committed transactions is purged
+	transientStoreProvider := transientstore.NewProvider(ledgerconfig.GetTransientStoreBlocksToLive())
+	kvledger.RegisterCommitDecoratorProvider(transientStoreProvider)
+	peer.SetTransientStoreProvider(transientStoreProvider)
 	ledgermgmt.Initialize()
+	if viper.GetBool("peer.maintenanceMode") {
+		logger.Info("Starting peer in maintenance mode. Commits and endorsements are disabled")
+		ledgermgmt.EnterMaintenanceMode()
+	}
 	// Parameter overrides must be processed before any paramaters are
 	// cached. Failures to cache cause the server to terminate immediately.
 	if chaincodeDevMode {
@@ -142,6 +173,20 @@ func serve(args []string) error {
 	// Register the Admin server
 	pb.RegisterAdminServer(grpcServer.Server(), core.NewAdminServer())
 
+	// Register the LedgerAdmin server, which serves the maintenance operations on the ledgers to the admins of the local MSP
+	ledgerAdminPolicy, err := ledgeradmin.NewLocalMSPAdminsPolicy(mgmt.GetLocalMSP())
+	if err != nil {
+		logger.Errorf("Failed to create the policy of the ledger admin service: %s", err)
+		return err
+	}
+	pb.RegisterLedgerAdminServer(grpcServer.Server(), ledgeradmin.NewServer(ledgerAdminPolicy))
+
+	// Register the Snapshot server, which serves the snapshots of the channels to the lagging peers
+	pb.RegisterSnapshotServer(grpcServer.Server(), snapshot.NewServer(ledgerconfig.GetSnapshotsPath(), peer.GetLedger, peer.GetPolicyManager))
+
+	// Register the StateQuery server, which serves the read-only queries of the committed state
+	pb.RegisterStateQueryServer(grpcServer.Server(), statequery.NewServer(peer.GetLedger, peer.GetPolicyManager, peer.CheckHistoryAccess))
+
 	// Register the Endorser server
 	serverEndorser := endorser.NewEndorserServer()
 	pb.RegisterEndorserServer(grpcServer.Server(), serverEndorser)
@@ -183,7 +228,7 @@ func serve(args []string) error {
 		}
 
 		//this creates testchainid and sets up gossip
-		if err = peer.CreateChainFromBlock(block); err == nil {
+		if err = peer.CreateChainFromBlock(block, ledger.BootstrapMethodDefaultChain); err == nil {
 			fmt.Printf("create chain [%s]", chainID)
 			scc.DeploySysCCs(chainID)
 			logger.Infof("Deployed system chaincodes on %s", chainID)
@@ -244,6 +289,43 @@ func serve(args []string) error {
 		}()
 	}
 
+	// Start the operations http endpoint serving the metrics if enabled
+	if viper.GetBool("peer.operations.enabled") {
+		go func() {
+			operationsListenAddress := viper.GetString("peer.operations.listenAddress")
+			logger.Infof("Starting operations server with listenAddress = %s", operationsListenAddress)
+			mux := http.NewServeMux()
+			mux.Handle("/metrics", metrics.Handler())
+			if operationsErr := http.ListenAndServe(operationsListenAddress, mux); operationsErr != nil {
+				logger.Errorf("Error starting operations server: %s", operationsErr)
+			}
+		}()
+	}
+
+	// Start the REST gateway serving the read-only ledger queries if enabled
+	if viper.GetBool("peer.restGateway.enabled") {
+		go func() {
+			restListenAddress := viper.GetString("peer.restGateway.listenAddress")
+			tlsConfig, err := restgateway.NewTLSConfig(
+				viper.GetString("peer.restGateway.tls.cert.file"),
+				viper.GetString("peer.restGateway.tls.key.file"),
+				viper.GetStringSlice("peer.restGateway.tls.clientRootCAs.files"))
+			if err != nil {
+				logger.Errorf("Error starting REST gateway: %s", err)
+				return
+			}
+			logger.Infof("Starting REST gateway with listenAddress = %s", restListenAddress)
+			restServer := &http.Server{
+				Addr:      restListenAddress,
+				Handler:   restgateway.NewGateway(peer.GetLedger, mgmt.GetManagerForChainIfExists, peer.CheckHistoryAccess),
+				TLSConfig: tlsConfig,
+			}
+			if restErr := restServer.ListenAndServeTLS("", ""); restErr != nil {
+				logger.Errorf("Error starting REST gateway: %s", restErr)
+			}
+		}()
+	}
+
 	logger.Infof("Started peer with ID=[%s], network ID=[%s], address=[%s]",
 		peerEndpoint.Id, viper.GetString("peer.networkId"), peerEndpoint.Address)
 
@@ -296,6 +378,14 @@ func createEventHubServer(secureConfig comm.SecureServerConfig) (comm.GRPCServer
 	ehServer := producer.NewEventsServer(
 		uint(viper.GetInt("peer.events.buffersize")),
 		viper.GetInt("peer.events.timeout"))
+	// The delivery cursors let the consumers resume the block events where they left off
+	cursors := producer.NewCursorStore(filepath.Join(viper.GetString("peer.fileSystemPath"), "eventCursors"))
+	ehServer.EnableCursors(cursors, func(chainID string) producer.BlocksReader {
+		if l := peer.GetLedger(chainID); l != nil {
+			return l
+		}
+		return nil
+	})
 
 	pb.RegisterEventsServer(grpcServer.Server(), ehServer)
 	return grpcServer, nil
@@ -0,0 +1,58 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package node
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/peer/common"
+	pb "github.com/hyperledger/fabric/protos/peer"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+)
+
+var sysStateSnapshotChannelID string
+
+func sysStateSnapshotCmd() *cobra.Command {
+	nodeSysStateSnapshotCmd.Flags().StringVarP(&sysStateSnapshotChannelID, "channelID", "c", "", "The channel whose system state to snapshot. All the channels that keep the system namespaces apart are snapshotted if not set")
+	return nodeSysStateSnapshotCmd
+}
+
+var nodeSysStateSnapshotCmd = &cobra.Command{
+	Use:   "sysstatesnapshot",
+	Short: "Snapshots the system namespaces of the state of the ledgers.",
+	Long:  `Snapshots the system namespaces of the state, such as the namespace of the lifecycle chaincode, of the ledger of each channel of the running node that keeps them in the system state database. Only the system state database is read, so the snapshot is quick irrespective of the size of the chaincode data. The snapshots are written on the node, in the systemState directory of the snapshots directory of the ledgers.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return sysStateSnapshot()
+	},
+}
+
+func sysStateSnapshot() error {
+	adminClient, err := common.GetAdminClient()
+	if err != nil {
+		return err
+	}
+	response, err := adminClient.SnapshotSystemState(context.Background(), &pb.SystemStateSnapshotRequest{ChannelId: sysStateSnapshotChannelID})
+	if err != nil {
+		return fmt.Errorf("Error trying to connect to local peer: %s", err)
+	}
+	fmt.Printf("%-30s %12s %s\n", "CHANNEL", "BLOCK", "DIRECTORY")
+	for _, s := range response.Snapshots {
+		fmt.Printf("%-30s %12d %s\n", s.ChannelId, s.BlockNumber, s.Directory)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package node
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/peer/common"
+	pb "github.com/hyperledger/fabric/protos/peer"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+)
+
+var (
+	warmUpChannelID        string
+	warmUpKeysPerNamespace uint32
+)
+
+func warmUpCmd() *cobra.Command {
+	flags := nodeWarmUpCmd.Flags()
+	flags.StringVarP(&warmUpChannelID, "channelID", "c", "", "The channel whose state database to warm up. All the channels are warmed up if not set")
+	flags.Uint32VarP(&warmUpKeysPerNamespace, "keys", "k", 0, "The number of the most recently written keys to read per namespace. All the tracked keys are read if not set")
+	return nodeWarmUpCmd
+}
+
+var nodeWarmUpCmd = &cobra.Command{
+	Use:   "warmup",
+	Short: "Warms up the state databases of the ledgers.",
+	Long:  `Reads the keys most recently written to the state database of the ledger of each channel into the caches of the state database of the running node, which flattens the endorsement latency after a restart. The keys are tracked only if ledger.state.warmUp.keysPerNamespace is set.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return warmUp()
+	},
+}
+
+func warmUp() error {
+	adminClient, err := common.GetAdminClient()
+	if err != nil {
+		return err
+	}
+	response, err := adminClient.WarmUpState(context.Background(), &pb.StateWarmUpRequest{ChannelId: warmUpChannelID, KeysPerNamespace: warmUpKeysPerNamespace})
+	if err != nil {
+		return fmt.Errorf("Error trying to connect to local peer: %s", err)
+	}
+	fmt.Printf("%-30s %20s\n", "CHANNEL", "KEYS")
+	for _, l := range response.Ledgers {
+		fmt.Printf("%-30s %20d\n", l.ChannelId, l.KeysLoaded)
+	}
+	return nil
+}
@@ -41,6 +41,7 @@ It has these top-level messages:
 	BlockDataHashingStructure
 	OrdererAddresses
 	BlockchainInfo
+	TransactionProof
 	MSPPrincipal
 	OrganizationUnit
 	MSPRole
@@ -148,6 +149,9 @@ const (
 	BlockMetadataIndex_LAST_CONFIG         BlockMetadataIndex = 1
 	BlockMetadataIndex_TRANSACTIONS_FILTER BlockMetadataIndex = 2
 	BlockMetadataIndex_ORDERER             BlockMetadataIndex = 3
+	BlockMetadataIndex_COMMIT_HASH         BlockMetadataIndex = 4
+	BlockMetadataIndex_STATE_ROOT          BlockMetadataIndex = 5
+	BlockMetadataIndex_DERIVED_DELETES     BlockMetadataIndex = 6
 )
 
 var BlockMetadataIndex_name = map[int32]string{
@@ -155,12 +159,18 @@ var BlockMetadataIndex_name = map[int32]string{
 	1: "LAST_CONFIG",
 	2: "TRANSACTIONS_FILTER",
 	3: "ORDERER",
+	4: "COMMIT_HASH",
+	5: "STATE_ROOT",
+	6: "DERIVED_DELETES",
 }
 var BlockMetadataIndex_value = map[string]int32{
 	"SIGNATURES":          0,
 	"LAST_CONFIG":         1,
 	"TRANSACTIONS_FILTER": 2,
 	"ORDERER":             3,
+	"COMMIT_HASH":         4,
+	"STATE_ROOT":          5,
+	"DERIVED_DELETES":     6,
 }
 
 func (x BlockMetadataIndex) String() string {
@@ -391,60 +401,62 @@ func init() {
 func init() { proto.RegisterFile("common/common.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 870 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x84, 0x55, 0xdd, 0x6e, 0xe3, 0x44,
-	0x14, 0xae, 0xe3, 0xfc, 0x34, 0xc7, 0x4d, 0x3b, 0x9d, 0x6c, 0x59, 0x53, 0x58, 0x6d, 0x64, 0xb4,
-	0xa8, 0xb4, 0x22, 0x11, 0xe5, 0x06, 0x2e, 0x9d, 0x78, 0xd2, 0xb5, 0x36, 0x6b, 0x2f, 0x63, 0x67,
-	0x11, 0xbb, 0x48, 0xd6, 0x24, 0x99, 0x26, 0x16, 0x89, 0x1d, 0xd9, 0x4e, 0xd5, 0xde, 0xf2, 0x00,
-	0x08, 0x09, 0x6e, 0x79, 0x01, 0x9e, 0x84, 0xb7, 0xe0, 0x25, 0x90, 0xb8, 0x45, 0xf6, 0xd8, 0xde,
-	0x24, 0xac, 0xb4, 0x57, 0x99, 0xef, 0x9c, 0xcf, 0xe7, 0x7c, 0xf3, 0x9d, 0x13, 0x1b, 0xda, 0xd3,
-	0x70, 0xb5, 0x0a, 0x83, 0x9e, 0xf8, 0xe9, 0xae, 0xa3, 0x30, 0x09, 0x71, 0x5d, 0xa0, 0xf3, 0xa7,
-	0xf3, 0x30, 0x9c, 0x2f, 0x79, 0x2f, 0x8b, 0x4e, 0x36, 0xb7, 0xbd, 0xc4, 0x5f, 0xf1, 0x38, 0x61,
-	0xab, 0xb5, 0x20, 0x6a, 0x1a, 0xc0, 0x88, 0xc5, 0xc9, 0x20, 0x0c, 0x6e, 0xfd, 0x39, 0x7e, 0x04,
-	0x35, 0x3f, 0x98, 0xf1, 0x7b, 0x55, 0xea, 0x48, 0x17, 0x55, 0x2a, 0x80, 0xf6, 0x16, 0x0e, 0x5f,
-	0xf2, 0x84, 0xcd, 0x58, 0xc2, 0x52, 0xc6, 0x1d, 0x5b, 0x6e, 0x78, 0xc6, 0x38, 0xa2, 0x02, 0xe0,
-	0x6f, 0x01, 0x62, 0x7f, 0x1e, 0xb0, 0x64, 0x13, 0xf1, 0x58, 0xad, 0x74, 0xe4, 0x0b, 0xe5, 0xfa,
-	0xe3, 0x6e, 0xae, 0xa8, 0x78, 0xd6, 0x29, 0x18, 0x74, 0x8b, 0xac, 0xfd, 0x08, 0xa7, 0xff, 0x23,
-	0xe0, 0x2f, 0x00, 0x95, 0x14, 0x6f, 0xc1, 0xd9, 0x8c, 0x47, 0x79, 0xc3, 0x93, 0x32, 0xfe, 0x3c,
-	0x0b, 0xe3, 0x4f, 0xa1, 0x59, 0x86, 0xd4, 0x4a, 0xc6, 0x79, 0x17, 0xd0, 0xde, 0x40, 0x3d, 0xe7,
-	0x3d, 0x83, 0xe3, 0xe9, 0x82, 0x05, 0x01, 0x5f, 0xee, 0x16, 0x6c, 0xe5, 0xd1, 0x9c, 0xf6, 0xbe,
-	0xce, 0x95, 0xf7, 0x76, 0xd6, 0xfe, 0x96, 0xa0, 0x35, 0xd8, 0x79, 0x18, 0x43, 0x35, 0x79, 0x58,
-	0x0b, 0x6f, 0x6a, 0x34, 0x3b, 0x63, 0x15, 0x1a, 0x77, 0x3c, 0x8a, 0xfd, 0x30, 0xc8, 0xea, 0xd4,
-	0x68, 0x01, 0xf1, 0x37, 0xd0, 0x2c, 0xa7, 0xa1, 0xca, 0x1d, 0xe9, 0x42, 0xb9, 0x3e, 0xef, 0x8a,
-	0x79, 0x75, 0x8b, 0x79, 0x75, 0xdd, 0x82, 0x41, 0xdf, 0x91, 0xf1, 0x13, 0x80, 0xe2, 0x2e, 0xfe,
-	0x4c, 0xad, 0x76, 0xa4, 0x8b, 0x26, 0x6d, 0xe6, 0x11, 0x73, 0x86, 0xdb, 0x50, 0x4b, 0xee, 0xd3,
-	0x4c, 0x2d, 0xcb, 0x54, 0x93, 0x7b, 0x73, 0x96, 0x0e, 0x8e, 0xaf, 0xc3, 0xe9, 0x42, 0xad, 0x8b,
-	0xd1, 0x66, 0x20, 0x75, 0x8f, 0xdf, 0x27, 0x3c, 0xc8, 0xf4, 0x35, 0x84, 0x7b, 0x65, 0x40, 0xd3,
-	0xe1, 0xc4, 0xd9, 0xb3, 0x5b, 0x85, 0xc6, 0x34, 0xe2, 0x2c, 0x09, 0x0b, 0xff, 0x0a, 0x98, 0x36,
-	0x08, 0xc2, 0x60, 0x5a, 0x0c, 0x41, 0x00, 0x8d, 0x40, 0xe3, 0x15, 0x7b, 0x58, 0x86, 0x6c, 0x86,
-	0x3f, 0x87, 0xfa, 0x96, 0xf3, 0xca, 0xf5, 0x71, 0xb1, 0x20, 0xa2, 0x34, 0xcd, 0xb3, 0xa9, 0x8b,
-	0xe9, 0x36, 0xe4, 0x75, 0xb2, 0xb3, 0xd6, 0x87, 0x43, 0x12, 0xdc, 0xf1, 0x65, 0x28, 0x1c, 0x5d,
-	0x8b, 0x92, 0x85, 0x84, 0x1c, 0x7e, 0x60, 0x17, 0x7e, 0x91, 0xa0, 0xd6, 0x5f, 0x86, 0xd3, 0x9f,
-	0xf0, 0xd5, 0x9e, 0x92, 0x76, 0xa1, 0x24, 0x4b, 0xef, 0xc9, 0x79, 0xb6, 0x25, 0x47, 0xb9, 0x3e,
-	0xdd, 0xa1, 0x1a, 0x2c, 0x61, 0x42, 0x21, 0xfe, 0x0a, 0x0e, 0x57, 0xf9, 0x1e, 0xe7, 0xc3, 0x3c,
-	0xdb, 0xa1, 0x16, 0x4b, 0x4e, 0x4b, 0x9a, 0x36, 0x07, 0x65, 0xab, 0x21, 0xfe, 0x08, 0xea, 0xc1,
-	0x66, 0x35, 0xc9, 0x55, 0x55, 0x69, 0x8e, 0xf0, 0x67, 0xd0, 0x5a, 0x47, 0xfc, 0xce, 0x0f, 0x37,
-	0xb1, 0xb7, 0x60, 0xf1, 0x22, 0xbf, 0xd9, 0x51, 0x11, 0x7c, 0xce, 0xe2, 0x05, 0xfe, 0x04, 0x9a,
-	0x69, 0x4d, 0x41, 0x90, 0x33, 0xc2, 0x61, 0x1a, 0x48, 0x93, 0xda, 0x53, 0x68, 0x96, 0x72, 0x4b,
-	0x7b, 0xa5, 0x8e, 0x5c, 0xda, 0x7b, 0x05, 0xad, 0x1d, 0x91, 0xf8, 0x7c, 0xeb, 0x36, 0x82, 0x58,
-	0xe2, 0xcb, 0x3f, 0x25, 0xa8, 0x3b, 0x09, 0x4b, 0x36, 0x31, 0x56, 0xa0, 0x31, 0xb6, 0x5e, 0x58,
-	0xf6, 0xf7, 0x16, 0x3a, 0xc0, 0x47, 0xd0, 0x70, 0xc6, 0x83, 0x01, 0x71, 0x1c, 0xf4, 0x97, 0x84,
-	0x11, 0x28, 0x7d, 0xdd, 0xf0, 0x28, 0xf9, 0x6e, 0x4c, 0x1c, 0x17, 0xfd, 0x2a, 0xe3, 0x63, 0x68,
-	0x0e, 0x6d, 0xda, 0x37, 0x0d, 0x83, 0x58, 0xe8, 0xb7, 0x0c, 0x5b, 0xb6, 0xeb, 0x0d, 0xed, 0xb1,
-	0x65, 0xa0, 0xdf, 0x65, 0xfc, 0x04, 0xd4, 0x9c, 0xed, 0x11, 0xcb, 0x35, 0xdd, 0x1f, 0x3c, 0xd7,
-	0xb6, 0xbd, 0x91, 0x4e, 0x6f, 0x08, 0xfa, 0x43, 0xc6, 0xe7, 0x70, 0x66, 0x5a, 0x2e, 0xa1, 0x96,
-	0x3e, 0xf2, 0x1c, 0x42, 0x5f, 0x13, 0xea, 0x11, 0x4a, 0x6d, 0x8a, 0xfe, 0x91, 0xb1, 0x0a, 0xed,
-	0x34, 0x64, 0x0e, 0x88, 0x37, 0xb6, 0xf4, 0xd7, 0xba, 0x39, 0xd2, 0xfb, 0x23, 0x82, 0xfe, 0x95,
-	0x2f, 0x7f, 0x96, 0x00, 0x84, 0xbf, 0x6e, 0xfa, 0x6f, 0x54, 0xa0, 0xf1, 0x92, 0x38, 0x8e, 0x7e,
-	0x43, 0xd0, 0x01, 0x06, 0xa8, 0x0f, 0x6c, 0x6b, 0x68, 0xde, 0x20, 0x09, 0x9f, 0x42, 0x4b, 0x9c,
-	0xbd, 0xf1, 0x2b, 0x43, 0x77, 0x09, 0xaa, 0x60, 0x15, 0x1e, 0x11, 0xcb, 0xb0, 0xa9, 0x43, 0xa8,
-	0xe7, 0x52, 0xdd, 0x72, 0xf4, 0x81, 0x6b, 0xda, 0x16, 0x92, 0xf1, 0x63, 0x68, 0xdb, 0xd4, 0x20,
-	0x74, 0x2f, 0x51, 0xc5, 0x67, 0x70, 0x6a, 0x90, 0x91, 0x99, 0x6a, 0x73, 0x08, 0x79, 0xe1, 0x99,
-	0xd6, 0xd0, 0x46, 0xb5, 0xcb, 0xb7, 0x80, 0x77, 0xec, 0x35, 0xd3, 0xd7, 0x2a, 0x3e, 0x06, 0x70,
-	0xcc, 0x1b, 0x4b, 0x77, 0xc7, 0x94, 0x38, 0xe8, 0x00, 0x9f, 0x80, 0x32, 0xd2, 0x1d, 0xd7, 0x2b,
-	0x35, 0x3d, 0x86, 0xf6, 0x56, 0x79, 0xc7, 0x1b, 0x9a, 0x23, 0x97, 0x50, 0x54, 0x49, 0x6f, 0x91,
-	0xf7, 0x47, 0x72, 0xff, 0xcb, 0x37, 0x57, 0x73, 0x3f, 0x59, 0x6c, 0x26, 0xe9, 0xba, 0xf5, 0x16,
-	0x0f, 0x6b, 0x1e, 0x2d, 0xf9, 0x6c, 0xce, 0xa3, 0xde, 0x2d, 0x9b, 0x44, 0xfe, 0x54, 0xbc, 0xfb,
-	0xe3, 0xfc, 0xfb, 0x30, 0xa9, 0x67, 0xf0, 0xeb, 0xff, 0x02, 0x00, 0x00, 0xff, 0xff, 0xa2, 0xa7,
-	0x76, 0x1e, 0x37, 0x06, 0x00, 0x00,
+	// 906 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x55, 0xd1, 0x6e, 0xe3, 0x44,
+	0x1b, 0xad, 0xe3, 0xc4, 0x69, 0xbe, 0x34, 0xed, 0x74, 0xb2, 0xfd, 0xd7, 0x7f, 0x61, 0xb5, 0x91,
+	0xd1, 0xa2, 0xd2, 0x8a, 0x44, 0x94, 0x1b, 0xb8, 0x74, 0xe2, 0x49, 0x6b, 0x6d, 0x6a, 0x2f, 0x33,
+	0x93, 0x22, 0x16, 0x24, 0xcb, 0x49, 0xa6, 0x49, 0x44, 0x62, 0x47, 0xb1, 0x53, 0xb5, 0xb7, 0x3c,
+	0x00, 0x02, 0xc1, 0x2d, 0x2f, 0xc0, 0x93, 0xf0, 0x16, 0xbc, 0x04, 0x12, 0xb7, 0xc8, 0x1e, 0xdb,
+	0x9b, 0x94, 0x95, 0xb8, 0xea, 0x9c, 0x33, 0x67, 0xbe, 0x39, 0x73, 0xbe, 0xaf, 0x31, 0x34, 0xc7,
+	0xe1, 0x72, 0x19, 0x06, 0x1d, 0xf9, 0xa7, 0xbd, 0x5a, 0x87, 0x71, 0x88, 0x35, 0x89, 0x4e, 0x5f,
+	0x4e, 0xc3, 0x70, 0xba, 0x10, 0x9d, 0x94, 0x1d, 0x6d, 0xee, 0x3a, 0xf1, 0x7c, 0x29, 0xa2, 0xd8,
+	0x5f, 0xae, 0xa4, 0xd0, 0x30, 0x00, 0x06, 0x7e, 0x14, 0xf7, 0xc2, 0xe0, 0x6e, 0x3e, 0xc5, 0xcf,
+	0xa0, 0x32, 0x0f, 0x26, 0xe2, 0x41, 0x57, 0x5a, 0xca, 0x59, 0x99, 0x4a, 0x60, 0x7c, 0x0b, 0xfb,
+	0x37, 0x22, 0xf6, 0x27, 0x7e, 0xec, 0x27, 0x8a, 0x7b, 0x7f, 0xb1, 0x11, 0xa9, 0xe2, 0x80, 0x4a,
+	0x80, 0xbf, 0x04, 0x88, 0xe6, 0xd3, 0xc0, 0x8f, 0x37, 0x6b, 0x11, 0xe9, 0xa5, 0x96, 0x7a, 0x56,
+	0xbf, 0xfc, 0x7f, 0x3b, 0x73, 0x94, 0x9f, 0x65, 0xb9, 0x82, 0x6e, 0x89, 0x8d, 0xef, 0xe0, 0xf8,
+	0x5f, 0x02, 0xfc, 0x09, 0xa0, 0x42, 0xe2, 0xcd, 0x84, 0x3f, 0x11, 0xeb, 0xec, 0xc2, 0xa3, 0x82,
+	0xbf, 0x4e, 0x69, 0xfc, 0x21, 0xd4, 0x0a, 0x4a, 0x2f, 0xa5, 0x9a, 0x77, 0x84, 0xf1, 0x16, 0xb4,
+	0x4c, 0xf7, 0x0a, 0x0e, 0xc7, 0x33, 0x3f, 0x08, 0xc4, 0x62, 0xb7, 0x60, 0x23, 0x63, 0x33, 0xd9,
+	0xfb, 0x6e, 0x2e, 0xbd, 0xf7, 0x66, 0xe3, 0x4f, 0x05, 0x1a, 0xbd, 0x9d, 0xc3, 0x18, 0xca, 0xf1,
+	0xe3, 0x4a, 0x66, 0x53, 0xa1, 0xe9, 0x1a, 0xeb, 0x50, 0xbd, 0x17, 0xeb, 0x68, 0x1e, 0x06, 0x69,
+	0x9d, 0x0a, 0xcd, 0x21, 0xfe, 0x02, 0x6a, 0x45, 0x37, 0x74, 0xb5, 0xa5, 0x9c, 0xd5, 0x2f, 0x4f,
+	0xdb, 0xb2, 0x5f, 0xed, 0xbc, 0x5f, 0x6d, 0x9e, 0x2b, 0xe8, 0x3b, 0x31, 0x7e, 0x01, 0x90, 0xbf,
+	0x65, 0x3e, 0xd1, 0xcb, 0x2d, 0xe5, 0xac, 0x46, 0x6b, 0x19, 0x63, 0x4f, 0x70, 0x13, 0x2a, 0xf1,
+	0x43, 0xb2, 0x53, 0x49, 0x77, 0xca, 0xf1, 0x83, 0x3d, 0x49, 0x1a, 0x27, 0x56, 0xe1, 0x78, 0xa6,
+	0x6b, 0xb2, 0xb5, 0x29, 0x48, 0xd2, 0x13, 0x0f, 0xb1, 0x08, 0x52, 0x7f, 0x55, 0x99, 0x5e, 0x41,
+	0x18, 0x26, 0x1c, 0xb1, 0x27, 0x71, 0xeb, 0x50, 0x1d, 0xaf, 0x85, 0x1f, 0x87, 0x79, 0x7e, 0x39,
+	0x4c, 0x2e, 0x08, 0xc2, 0x60, 0x9c, 0x37, 0x41, 0x02, 0x83, 0x40, 0xf5, 0x8d, 0xff, 0xb8, 0x08,
+	0xfd, 0x09, 0xfe, 0x18, 0xb4, 0xad, 0xe4, 0xeb, 0x97, 0x87, 0xf9, 0x80, 0xc8, 0xd2, 0x54, 0x9b,
+	0x15, 0x29, 0x26, 0xd3, 0x90, 0xd5, 0x49, 0xd7, 0x46, 0x17, 0xf6, 0x49, 0x70, 0x2f, 0x16, 0xa1,
+	0x4c, 0x74, 0x25, 0x4b, 0xe6, 0x16, 0x32, 0xf8, 0x1f, 0xb3, 0xf0, 0xa3, 0x02, 0x95, 0xee, 0x22,
+	0x1c, 0x7f, 0x8f, 0x2f, 0x9e, 0x38, 0x69, 0xe6, 0x4e, 0xd2, 0xed, 0x27, 0x76, 0x5e, 0x6d, 0xd9,
+	0xa9, 0x5f, 0x1e, 0xef, 0x48, 0x2d, 0x3f, 0xf6, 0xa5, 0x43, 0xfc, 0x19, 0xec, 0x2f, 0xb3, 0x39,
+	0xce, 0x9a, 0x79, 0xb2, 0x23, 0xcd, 0x87, 0x9c, 0x16, 0x32, 0x63, 0x0a, 0xf5, 0xad, 0x0b, 0xf1,
+	0xff, 0x40, 0x0b, 0x36, 0xcb, 0x51, 0xe6, 0xaa, 0x4c, 0x33, 0x84, 0x3f, 0x82, 0xc6, 0x6a, 0x2d,
+	0xee, 0xe7, 0xe1, 0x26, 0xf2, 0x66, 0x7e, 0x34, 0xcb, 0x5e, 0x76, 0x90, 0x93, 0xd7, 0x7e, 0x34,
+	0xc3, 0x1f, 0x40, 0x2d, 0xa9, 0x29, 0x05, 0x6a, 0x2a, 0xd8, 0x4f, 0x88, 0x64, 0xd3, 0x78, 0x09,
+	0xb5, 0xc2, 0x6e, 0x11, 0xaf, 0xd2, 0x52, 0x8b, 0x78, 0x2f, 0xa0, 0xb1, 0x63, 0x12, 0x9f, 0x6e,
+	0xbd, 0x46, 0x0a, 0x0b, 0x7c, 0xfe, 0xbb, 0x02, 0x1a, 0x8b, 0xfd, 0x78, 0x13, 0xe1, 0x3a, 0x54,
+	0x87, 0xce, 0x6b, 0xc7, 0xfd, 0xda, 0x41, 0x7b, 0xf8, 0x00, 0xaa, 0x6c, 0xd8, 0xeb, 0x11, 0xc6,
+	0xd0, 0x1f, 0x0a, 0x46, 0x50, 0xef, 0x9a, 0x96, 0x47, 0xc9, 0x57, 0x43, 0xc2, 0x38, 0xfa, 0x49,
+	0xc5, 0x87, 0x50, 0xeb, 0xbb, 0xb4, 0x6b, 0x5b, 0x16, 0x71, 0xd0, 0x2f, 0x29, 0x76, 0x5c, 0xee,
+	0xf5, 0xdd, 0xa1, 0x63, 0xa1, 0x5f, 0x55, 0xfc, 0x02, 0xf4, 0x4c, 0xed, 0x11, 0x87, 0xdb, 0xfc,
+	0x1b, 0x8f, 0xbb, 0xae, 0x37, 0x30, 0xe9, 0x15, 0x41, 0xbf, 0xa9, 0xf8, 0x14, 0x4e, 0x6c, 0x87,
+	0x13, 0xea, 0x98, 0x03, 0x8f, 0x11, 0x7a, 0x4b, 0xa8, 0x47, 0x28, 0x75, 0x29, 0xfa, 0x4b, 0xc5,
+	0x3a, 0x34, 0x13, 0xca, 0xee, 0x11, 0x6f, 0xe8, 0x98, 0xb7, 0xa6, 0x3d, 0x30, 0xbb, 0x03, 0x82,
+	0xfe, 0x56, 0xcf, 0x7f, 0x50, 0x00, 0x64, 0xbe, 0x3c, 0xf9, 0x6f, 0xac, 0x43, 0xf5, 0x86, 0x30,
+	0x66, 0x5e, 0x11, 0xb4, 0x87, 0x01, 0xb4, 0x9e, 0xeb, 0xf4, 0xed, 0x2b, 0xa4, 0xe0, 0x63, 0x68,
+	0xc8, 0xb5, 0x37, 0x7c, 0x63, 0x99, 0x9c, 0xa0, 0x12, 0xd6, 0xe1, 0x19, 0x71, 0x2c, 0x97, 0x32,
+	0x42, 0x3d, 0x4e, 0x4d, 0x87, 0x99, 0x3d, 0x6e, 0xbb, 0x0e, 0x52, 0xf1, 0x73, 0x68, 0xba, 0xd4,
+	0x22, 0xf4, 0xc9, 0x46, 0x19, 0x9f, 0xc0, 0xb1, 0x45, 0x06, 0x76, 0xe2, 0x8d, 0x11, 0xf2, 0xda,
+	0xb3, 0x9d, 0xbe, 0x8b, 0x2a, 0xe7, 0x3f, 0x2b, 0x80, 0x77, 0xf2, 0xb5, 0x93, 0xdf, 0x55, 0x7c,
+	0x08, 0xc0, 0xec, 0x2b, 0xc7, 0xe4, 0x43, 0x4a, 0x18, 0xda, 0xc3, 0x47, 0x50, 0x1f, 0x98, 0x8c,
+	0x7b, 0x85, 0xa9, 0xe7, 0xd0, 0xdc, 0xaa, 0xcf, 0xbc, 0xbe, 0x3d, 0xe0, 0x84, 0xa2, 0x52, 0xf2,
+	0x8c, 0xcc, 0x00, 0x52, 0x93, 0x63, 0x3d, 0xf7, 0xe6, 0xc6, 0xe6, 0xde, 0xb5, 0xc9, 0xae, 0x51,
+	0x39, 0xad, 0xcb, 0x4d, 0x4e, 0x3c, 0xea, 0xba, 0x1c, 0x55, 0x70, 0x13, 0x8e, 0x2c, 0x42, 0xed,
+	0x5b, 0x62, 0x79, 0x16, 0x19, 0x10, 0x4e, 0x18, 0xd2, 0xba, 0x9f, 0xbe, 0xbd, 0x98, 0xce, 0xe3,
+	0xd9, 0x66, 0x94, 0x4c, 0x69, 0x67, 0xf6, 0xb8, 0x12, 0xeb, 0x85, 0x98, 0x4c, 0xc5, 0xba, 0x73,
+	0xe7, 0x8f, 0xd6, 0xf3, 0xb1, 0xfc, 0x64, 0x44, 0xd9, 0x67, 0x65, 0xa4, 0xa5, 0xf0, 0xf3, 0x7f,
+	0x06, 0x00, 0x47, 0x5b, 0xab, 0xf3, 0x6e, 0x06, 0x00, 0x00,
 }
@@ -26,23 +26,98 @@ func (m *BlockchainInfo) String() string            { return proto.CompactTextSt
 func (*BlockchainInfo) ProtoMessage()               {}
 func (*BlockchainInfo) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{0} }
 
+// TransactionProof is a self-contained proof of the existence of a transaction in the ledger
+// that can be verified offline. The data of the block that contains the transaction hashes to the
+// data_hash of the first header. Each subsequent header carries the hash of its predecessor in
+// previous_hash, so that the proof is anchored to the last header, which the verifier trusts either
+// by comparing its hash with a known one or by checking the orderer signatures. As the block data
+// is hashed flat, all the transactions of the block form the path to the data hash
+type TransactionProof struct {
+	TxId    string         `protobuf:"bytes,1,opt,name=tx_id,json=txId" json:"tx_id,omitempty"`
+	TxIndex uint32         `protobuf:"varint,2,opt,name=tx_index,json=txIndex" json:"tx_index,omitempty"`
+	Headers []*BlockHeader `protobuf:"bytes,3,rep,name=headers" json:"headers,omitempty"`
+	Data    *BlockData     `protobuf:"bytes,4,opt,name=data" json:"data,omitempty"`
+	// The validation flags of the transactions of the block, as recorded by the peer that generated the proof.
+	// The flags are not covered by the block hashes
+	ValidationFlags []byte `protobuf:"bytes,5,opt,name=validation_flags,json=validationFlags,proto3" json:"validation_flags,omitempty"`
+	// The SIGNATURES metadata of the block of the last header
+	LastBlockSignatures []byte `protobuf:"bytes,6,opt,name=last_block_signatures,json=lastBlockSignatures,proto3" json:"last_block_signatures,omitempty"`
+}
+
+func (m *TransactionProof) Reset()                    { *m = TransactionProof{} }
+func (m *TransactionProof) String() string            { return proto.CompactTextString(m) }
+func (*TransactionProof) ProtoMessage()               {}
+func (*TransactionProof) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{1} }
+
+func (m *TransactionProof) GetHeaders() []*BlockHeader {
+	if m != nil {
+		return m.Headers
+	}
+	return nil
+}
+
+func (m *TransactionProof) GetData() *BlockData {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+// StateProof is a proof of the value of a key, or of its absence, in the state as of a block, against the
+// state root recorded in the STATE_ROOT metadata of the block. The nodes are the encoded nodes of the state
+// trie on the path of the key from the root, each hashed by its parent. The value_hash is the hash of the
+// value of the key and is empty if the key is absent, in which case the path ends where the key would be
+type StateProof struct {
+	Namespace     string   `protobuf:"bytes,1,opt,name=namespace" json:"namespace,omitempty"`
+	Key           string   `protobuf:"bytes,2,opt,name=key" json:"key,omitempty"`
+	BlockNumber   uint64   `protobuf:"varint,3,opt,name=block_number,json=blockNumber" json:"block_number,omitempty"`
+	StateRoot     []byte   `protobuf:"bytes,4,opt,name=state_root,json=stateRoot,proto3" json:"state_root,omitempty"`
+	ValueHash     []byte   `protobuf:"bytes,5,opt,name=value_hash,json=valueHash,proto3" json:"value_hash,omitempty"`
+	Nodes         [][]byte `protobuf:"bytes,6,rep,name=nodes,proto3" json:"nodes,omitempty"`
+	HashAlgorithm string   `protobuf:"bytes,7,opt,name=hash_algorithm,json=hashAlgorithm" json:"hash_algorithm,omitempty"`
+}
+
+func (m *StateProof) Reset()                    { *m = StateProof{} }
+func (m *StateProof) String() string            { return proto.CompactTextString(m) }
+func (*StateProof) ProtoMessage()               {}
+func (*StateProof) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{2} }
+
 func init() {
 	proto.RegisterType((*BlockchainInfo)(nil), "common.BlockchainInfo")
+	proto.RegisterType((*TransactionProof)(nil), "common.TransactionProof")
+	proto.RegisterType((*StateProof)(nil), "common.StateProof")
 }
 
 func init() { proto.RegisterFile("common/ledger.proto", fileDescriptor3) }
 
 var fileDescriptor3 = []byte{
-	// 173 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0xe2, 0x12, 0x4e, 0xce, 0xcf, 0xcd,
-	0xcd, 0xcf, 0xd3, 0xcf, 0x49, 0x4d, 0x49, 0x4f, 0x2d, 0xd2, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17,
-	0x62, 0x83, 0x08, 0x2a, 0x35, 0x31, 0x72, 0xf1, 0x39, 0xe5, 0xe4, 0x27, 0x67, 0x27, 0x67, 0x24,
-	0x66, 0xe6, 0x79, 0xe6, 0xa5, 0xe5, 0x0b, 0x89, 0x71, 0xb1, 0x65, 0xa4, 0x66, 0xa6, 0x67, 0x94,
-	0x48, 0x30, 0x2a, 0x30, 0x6a, 0xb0, 0x04, 0x41, 0x79, 0x42, 0x5a, 0x5c, 0x02, 0xc9, 0xa5, 0x45,
-	0x45, 0xa9, 0x79, 0x25, 0x60, 0x0d, 0x1e, 0x89, 0xc5, 0x19, 0x12, 0x4c, 0x0a, 0x8c, 0x1a, 0x3c,
-	0x41, 0x18, 0xe2, 0x42, 0x3a, 0x5c, 0x82, 0x05, 0x45, 0xa9, 0x65, 0x99, 0xf9, 0xa5, 0xc5, 0x08,
-	0xc5, 0xcc, 0x60, 0xc5, 0x98, 0x12, 0x4e, 0xba, 0x51, 0xda, 0xe9, 0x99, 0x25, 0x19, 0xa5, 0x49,
-	0x7a, 0xc9, 0xf9, 0xb9, 0xfa, 0x19, 0x95, 0x05, 0xa9, 0x45, 0x10, 0xc7, 0xea, 0xa7, 0x25, 0x26,
-	0x15, 0x65, 0x26, 0xeb, 0x83, 0xdd, 0x5c, 0xac, 0x0f, 0x71, 0x73, 0x12, 0x1b, 0x98, 0x6b, 0x0c,
-	0x08, 0x00, 0x00, 0xff, 0xff, 0xb5, 0x93, 0x7a, 0x44, 0xd9, 0x00, 0x00, 0x00,
+	// 447 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x64, 0x92, 0xcf, 0x8e, 0xd3, 0x30,
+	0x10, 0xc6, 0x15, 0x92, 0xb6, 0x64, 0xda, 0x5d, 0xba, 0x2e, 0xa0, 0x80, 0x40, 0x2a, 0x95, 0x56,
+	0x0a, 0x7f, 0x76, 0x2b, 0x95, 0x27, 0x60, 0x85, 0x10, 0xbd, 0x20, 0xe4, 0xe5, 0xc4, 0x25, 0x9a,
+	0x24, 0x6e, 0x62, 0x6d, 0x62, 0x57, 0xb6, 0x53, 0x75, 0xaf, 0x3c, 0x26, 0x2f, 0xc2, 0x15, 0x79,
+	0xd2, 0x52, 0xa1, 0x3d, 0xb5, 0xf3, 0xfb, 0xbe, 0x78, 0xe6, 0xb3, 0x07, 0x66, 0x85, 0x6e, 0x5b,
+	0xad, 0x96, 0x8d, 0x28, 0x2b, 0x61, 0xae, 0xb7, 0x46, 0x3b, 0xcd, 0x86, 0x3d, 0x7c, 0x79, 0x14,
+	0xfb, 0x9f, 0x5e, 0x5c, 0xfc, 0x0a, 0xe0, 0xfc, 0xa6, 0xd1, 0xc5, 0x5d, 0x51, 0xa3, 0x54, 0x6b,
+	0xb5, 0xd1, 0xec, 0x39, 0x0c, 0x6b, 0x21, 0xab, 0xda, 0x25, 0xc1, 0x3c, 0x48, 0x23, 0x7e, 0xa8,
+	0xd8, 0x3b, 0x98, 0x16, 0x9d, 0x31, 0x42, 0x39, 0xfa, 0xe0, 0x2b, 0xda, 0x3a, 0x79, 0x34, 0x0f,
+	0xd2, 0x09, 0x7f, 0xc0, 0xd9, 0x07, 0xb8, 0xd8, 0x1a, 0xb1, 0x93, 0xba, 0xb3, 0x27, 0x73, 0x48,
+	0xe6, 0x87, 0xc2, 0xe2, 0x4f, 0x00, 0xd3, 0x1f, 0x06, 0x95, 0xc5, 0xc2, 0x49, 0xad, 0xbe, 0x1b,
+	0xad, 0x37, 0x6c, 0x06, 0x03, 0xb7, 0xcf, 0x64, 0x49, 0x53, 0xc4, 0x3c, 0x72, 0xfb, 0x75, 0xc9,
+	0x5e, 0xc0, 0x63, 0x0f, 0x55, 0x29, 0xf6, 0xd4, 0xfb, 0x8c, 0x8f, 0xdc, 0x7e, 0xed, 0x4b, 0x76,
+	0x05, 0xa3, 0x5a, 0x60, 0x29, 0x8c, 0x4d, 0xc2, 0x79, 0x98, 0x8e, 0x57, 0xb3, 0xeb, 0x43, 0xd2,
+	0xbe, 0x11, 0x69, 0xfc, 0xe8, 0x61, 0x97, 0x10, 0x95, 0xe8, 0x30, 0x89, 0xe6, 0x41, 0x3a, 0x5e,
+	0x5d, 0xfc, 0xe7, 0xfd, 0x8c, 0x0e, 0x39, 0xc9, 0xec, 0x2d, 0x4c, 0x77, 0xd8, 0xc8, 0x12, 0xfd,
+	0x60, 0xd9, 0xa6, 0xc1, 0xca, 0x26, 0x03, 0xca, 0xf1, 0xe4, 0xc4, 0xbf, 0x78, 0xcc, 0x56, 0xf0,
+	0xac, 0x41, 0xeb, 0xb2, 0xdc, 0x1f, 0x91, 0x59, 0x59, 0x29, 0x74, 0x9d, 0x11, 0x36, 0x19, 0x92,
+	0x7f, 0xe6, 0x45, 0x3a, 0xfe, 0xf6, 0x9f, 0xb4, 0xf8, 0x1d, 0x00, 0xdc, 0x3a, 0x74, 0xa2, 0xcf,
+	0xfc, 0x0a, 0x62, 0x85, 0xad, 0xb0, 0x5b, 0x2c, 0xc4, 0x21, 0xf7, 0x09, 0xb0, 0x29, 0x84, 0x77,
+	0xe2, 0x9e, 0x72, 0xc7, 0xdc, 0xff, 0x65, 0x6f, 0x60, 0xd2, 0x77, 0x53, 0x5d, 0x9b, 0x0b, 0x43,
+	0x37, 0x1c, 0xf1, 0x31, 0xb1, 0x6f, 0x84, 0xd8, 0x6b, 0x00, 0xeb, 0x1b, 0x64, 0x46, 0x6b, 0x47,
+	0x69, 0x27, 0x3c, 0x26, 0xc2, 0xb5, 0x76, 0x5e, 0xde, 0x61, 0xd3, 0x89, 0xac, 0xf6, 0x2f, 0xd4,
+	0x27, 0x8b, 0x89, 0xd0, 0x3b, 0x3e, 0x85, 0x81, 0xd2, 0x25, 0x65, 0x08, 0xd3, 0x09, 0xef, 0x0b,
+	0x76, 0x09, 0xe7, 0xde, 0x9e, 0x61, 0x53, 0x69, 0x23, 0x5d, 0xdd, 0x26, 0x23, 0x9a, 0xe9, 0xcc,
+	0xd3, 0x4f, 0x47, 0x78, 0x73, 0xf5, 0xf3, 0x7d, 0x25, 0x5d, 0xdd, 0xe5, 0xfe, 0x72, 0x97, 0xf5,
+	0xfd, 0x56, 0x98, 0x7e, 0x31, 0x97, 0x1b, 0xcc, 0x8d, 0x2c, 0x96, 0xb4, 0x82, 0xf6, 0xb0, 0x90,
+	0xf9, 0x90, 0xca, 0x8f, 0x7f, 0x07, 0x00, 0x7a, 0xbe, 0xeb, 0x83, 0xc5, 0x02, 0x00, 0x00,
 }
@@ -15,6 +15,8 @@ It has these top-level messages:
 	SeekNewest
 	SeekOldest
 	SeekSpecified
+	SeekTxID
+	SeekTimestamp
 	SeekPosition
 	SeekInfo
 	DeliverResponse
@@ -38,6 +40,7 @@ import proto "github.com/golang/protobuf/proto"
 import fmt "fmt"
 import math "math"
 import common "github.com/hyperledger/fabric/protos/common"
+import google_protobuf "github.com/golang/protobuf/ptypes/timestamp"
 
 import (
 	context "golang.org/x/net/context"
@@ -74,7 +77,7 @@ var SeekInfo_SeekBehavior_value = map[string]int32{
 func (x SeekInfo_SeekBehavior) String() string {
 	return proto.EnumName(SeekInfo_SeekBehavior_name, int32(x))
 }
-func (SeekInfo_SeekBehavior) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{5, 0} }
+func (SeekInfo_SeekBehavior) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{7, 0} }
 
 type BroadcastResponse struct {
 	Status common.Status `protobuf:"varint,1,opt,name=status,enum=common.Status" json:"status,omitempty"`
@@ -110,18 +113,49 @@ func (m *SeekSpecified) String() string            { return proto.CompactTextStr
 func (*SeekSpecified) ProtoMessage()               {}
 func (*SeekSpecified) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
 
+// SeekTxID seeks the block that contains the transaction with the given id
+type SeekTxID struct {
+	TxId string `protobuf:"bytes,1,opt,name=tx_id,json=txId" json:"tx_id,omitempty"`
+}
+
+func (m *SeekTxID) Reset()                    { *m = SeekTxID{} }
+func (m *SeekTxID) String() string            { return proto.CompactTextString(m) }
+func (*SeekTxID) ProtoMessage()               {}
+func (*SeekTxID) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+
+// SeekTimestamp seeks the first block with a timestamp not earlier than the given
+// timestamp. The timestamp of a block is the latest of the timestamps in the channel
+// headers of its transactions and of the timestamps of the preceding blocks
+type SeekTimestamp struct {
+	Timestamp *google_protobuf.Timestamp `protobuf:"bytes,1,opt,name=timestamp" json:"timestamp,omitempty"`
+}
+
+func (m *SeekTimestamp) Reset()                    { *m = SeekTimestamp{} }
+func (m *SeekTimestamp) String() string            { return proto.CompactTextString(m) }
+func (*SeekTimestamp) ProtoMessage()               {}
+func (*SeekTimestamp) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
+
+func (m *SeekTimestamp) GetTimestamp() *google_protobuf.Timestamp {
+	if m != nil {
+		return m.Timestamp
+	}
+	return nil
+}
+
 type SeekPosition struct {
 	// Types that are valid to be assigned to Type:
 	//	*SeekPosition_Newest
 	//	*SeekPosition_Oldest
 	//	*SeekPosition_Specified
+	//	*SeekPosition_TxId
+	//	*SeekPosition_Timestamp
 	Type isSeekPosition_Type `protobuf_oneof:"Type"`
 }
 
 func (m *SeekPosition) Reset()                    { *m = SeekPosition{} }
 func (m *SeekPosition) String() string            { return proto.CompactTextString(m) }
 func (*SeekPosition) ProtoMessage()               {}
-func (*SeekPosition) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+func (*SeekPosition) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
 
 type isSeekPosition_Type interface {
 	isSeekPosition_Type()
@@ -136,10 +170,18 @@ type SeekPosition_Oldest struct {
 type SeekPosition_Specified struct {
 	Specified *SeekSpecified `protobuf:"bytes,3,opt,name=specified,oneof"`
 }
+type SeekPosition_TxId struct {
+	TxId *SeekTxID `protobuf:"bytes,4,opt,name=tx_id,json=txId,oneof"`
+}
+type SeekPosition_Timestamp struct {
+	Timestamp *SeekTimestamp `protobuf:"bytes,5,opt,name=timestamp,oneof"`
+}
 
 func (*SeekPosition_Newest) isSeekPosition_Type()    {}
 func (*SeekPosition_Oldest) isSeekPosition_Type()    {}
 func (*SeekPosition_Specified) isSeekPosition_Type() {}
+func (*SeekPosition_TxId) isSeekPosition_Type()      {}
+func (*SeekPosition_Timestamp) isSeekPosition_Type() {}
 
 func (m *SeekPosition) GetType() isSeekPosition_Type {
 	if m != nil {
@@ -169,12 +211,28 @@ func (m *SeekPosition) GetSpecified() *SeekSpecified {
 	return nil
 }
 
+func (m *SeekPosition) GetTxId() *SeekTxID {
+	if x, ok := m.GetType().(*SeekPosition_TxId); ok {
+		return x.TxId
+	}
+	return nil
+}
+
+func (m *SeekPosition) GetTimestamp() *SeekTimestamp {
+	if x, ok := m.GetType().(*SeekPosition_Timestamp); ok {
+		return x.Timestamp
+	}
+	return nil
+}
+
 // XXX_OneofFuncs is for the internal use of the proto package.
 func (*SeekPosition) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error, func(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error), func(msg proto.Message) (n int), []interface{}) {
 	return _SeekPosition_OneofMarshaler, _SeekPosition_OneofUnmarshaler, _SeekPosition_OneofSizer, []interface{}{
 		(*SeekPosition_Newest)(nil),
 		(*SeekPosition_Oldest)(nil),
 		(*SeekPosition_Specified)(nil),
+		(*SeekPosition_TxId)(nil),
+		(*SeekPosition_Timestamp)(nil),
 	}
 }
 
@@ -197,6 +255,16 @@ func _SeekPosition_OneofMarshaler(msg proto.Message, b *proto.Buffer) error {
 		if err := b.EncodeMessage(x.Specified); err != nil {
 			return err
 		}
+	case *SeekPosition_TxId:
+		b.EncodeVarint(4<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.TxId); err != nil {
+			return err
+		}
+	case *SeekPosition_Timestamp:
+		b.EncodeVarint(5<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Timestamp); err != nil {
+			return err
+		}
 	case nil:
 	default:
 		return fmt.Errorf("SeekPosition.Type has unexpected type %T", x)
@@ -231,6 +299,22 @@ func _SeekPosition_OneofUnmarshaler(msg proto.Message, tag, wire int, b *proto.B
 		err := b.DecodeMessage(msg)
 		m.Type = &SeekPosition_Specified{msg}
 		return true, err
+	case 4: // Type.tx_id
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(SeekTxID)
+		err := b.DecodeMessage(msg)
+		m.Type = &SeekPosition_TxId{msg}
+		return true, err
+	case 5: // Type.timestamp
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(SeekTimestamp)
+		err := b.DecodeMessage(msg)
+		m.Type = &SeekPosition_Timestamp{msg}
+		return true, err
 	default:
 		return false, nil
 	}
@@ -255,6 +339,16 @@ func _SeekPosition_OneofSizer(msg proto.Message) (n int) {
 		n += proto.SizeVarint(3<<3 | proto.WireBytes)
 		n += proto.SizeVarint(uint64(s))
 		n += s
+	case *SeekPosition_TxId:
+		s := proto.Size(x.TxId)
+		n += proto.SizeVarint(4<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *SeekPosition_Timestamp:
+		s := proto.Size(x.Timestamp)
+		n += proto.SizeVarint(5<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
 	case nil:
 	default:
 		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
@@ -279,7 +373,7 @@ type SeekInfo struct {
 func (m *SeekInfo) Reset()                    { *m = SeekInfo{} }
 func (m *SeekInfo) String() string            { return proto.CompactTextString(m) }
 func (*SeekInfo) ProtoMessage()               {}
-func (*SeekInfo) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
+func (*SeekInfo) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
 
 func (m *SeekInfo) GetStart() *SeekPosition {
 	if m != nil {
@@ -305,7 +399,7 @@ type DeliverResponse struct {
 func (m *DeliverResponse) Reset()                    { *m = DeliverResponse{} }
 func (m *DeliverResponse) String() string            { return proto.CompactTextString(m) }
 func (*DeliverResponse) ProtoMessage()               {}
-func (*DeliverResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
+func (*DeliverResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
 
 type isDeliverResponse_Type interface {
 	isDeliverResponse_Type()
@@ -416,6 +510,8 @@ func init() {
 	proto.RegisterType((*SeekNewest)(nil), "orderer.SeekNewest")
 	proto.RegisterType((*SeekOldest)(nil), "orderer.SeekOldest")
 	proto.RegisterType((*SeekSpecified)(nil), "orderer.SeekSpecified")
+	proto.RegisterType((*SeekTxID)(nil), "orderer.SeekTxID")
+	proto.RegisterType((*SeekTimestamp)(nil), "orderer.SeekTimestamp")
 	proto.RegisterType((*SeekPosition)(nil), "orderer.SeekPosition")
 	proto.RegisterType((*SeekInfo)(nil), "orderer.SeekInfo")
 	proto.RegisterType((*DeliverResponse)(nil), "orderer.DeliverResponse")
@@ -598,35 +694,41 @@ var _AtomicBroadcast_serviceDesc = grpc.ServiceDesc{
 func init() { proto.RegisterFile("orderer/ab.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 480 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x7c, 0x93, 0xcd, 0x6e, 0xd3, 0x40,
-	0x10, 0xc7, 0x63, 0x48, 0xdd, 0x76, 0x68, 0xd3, 0x74, 0xab, 0x56, 0x51, 0x0e, 0x08, 0x59, 0x02,
-	0x82, 0x00, 0x1b, 0x05, 0x89, 0x03, 0x45, 0x42, 0x31, 0x6d, 0x95, 0x88, 0x28, 0x41, 0x4e, 0x38,
-	0xc0, 0x25, 0xf2, 0xc7, 0xa4, 0x31, 0x75, 0xbc, 0xd6, 0xee, 0x26, 0xa8, 0x4f, 0xc1, 0x8b, 0xf0,
-	0x48, 0x3c, 0x0c, 0xda, 0x0f, 0x3b, 0x0a, 0x14, 0x4e, 0xc9, 0xcc, 0xfc, 0xfe, 0xb3, 0xff, 0x19,
-	0x8d, 0xa1, 0x49, 0x59, 0x82, 0x0c, 0x99, 0x17, 0x46, 0x6e, 0xc1, 0xa8, 0xa0, 0x64, 0xd7, 0x64,
-	0xda, 0x27, 0x31, 0x5d, 0x2e, 0x69, 0xee, 0xe9, 0x1f, 0x5d, 0x75, 0xce, 0xe1, 0xd8, 0x67, 0x34,
-	0x4c, 0xe2, 0x90, 0x8b, 0x00, 0x79, 0x41, 0x73, 0x8e, 0xe4, 0x09, 0xd8, 0x5c, 0x84, 0x62, 0xc5,
-	0x5b, 0xd6, 0x23, 0xab, 0xd3, 0xe8, 0x36, 0x5c, 0xa3, 0x99, 0xa8, 0x6c, 0x60, 0xaa, 0xce, 0x01,
-	0xc0, 0x04, 0xf1, 0x66, 0x84, 0xdf, 0x91, 0x8b, 0x32, 0x1a, 0x67, 0x89, 0x8c, 0x9e, 0xc2, 0xa1,
-	0x8c, 0x26, 0x05, 0xc6, 0xe9, 0x3c, 0xc5, 0x84, 0x9c, 0x81, 0x9d, 0xaf, 0x96, 0x11, 0x32, 0xd5,
-	0xb4, 0x1e, 0x98, 0xc8, 0xf9, 0x69, 0xc1, 0x81, 0x24, 0x3f, 0x51, 0x9e, 0x8a, 0x94, 0xe6, 0xe4,
-	0x25, 0xd8, 0xb9, 0xea, 0xa8, 0xc0, 0x07, 0xdd, 0x13, 0xd7, 0x4c, 0xe0, 0x6e, 0x1e, 0xeb, 0xd7,
-	0x02, 0x03, 0x49, 0x9c, 0xaa, 0x27, 0x5b, 0xf7, 0xee, 0xc0, 0xb5, 0x1b, 0x89, 0x6b, 0x88, 0xbc,
-	0x81, 0x7d, 0x5e, 0x7a, 0x6a, 0xdd, 0x57, 0x8a, 0xb3, 0x2d, 0x45, 0xe5, 0xb8, 0x5f, 0x0b, 0x36,
-	0xa8, 0x6f, 0x43, 0x7d, 0x7a, 0x5b, 0xa0, 0xf3, 0xcb, 0x82, 0x3d, 0x89, 0x0d, 0xf2, 0x39, 0x25,
-	0xcf, 0x61, 0x87, 0x8b, 0x90, 0x95, 0x4e, 0x4f, 0xb7, 0x1a, 0x95, 0x03, 0x05, 0x9a, 0x21, 0xcf,
-	0xa0, 0xce, 0x05, 0x2d, 0x8c, 0xcd, 0x7f, 0xb0, 0x0a, 0x21, 0x6f, 0x61, 0x2f, 0xc2, 0x45, 0xb8,
-	0x4e, 0x29, 0x53, 0x1e, 0x1b, 0xdd, 0x87, 0x5b, 0xb8, 0x7c, 0x5c, 0xfd, 0xf1, 0x0d, 0x15, 0x54,
-	0xbc, 0xf3, 0x4e, 0xaf, 0xb3, 0xac, 0x90, 0x53, 0x38, 0xf6, 0x87, 0xe3, 0x0f, 0x1f, 0x67, 0x9f,
-	0x47, 0xd3, 0xc1, 0x70, 0x16, 0x5c, 0xf6, 0x2e, 0xbe, 0x34, 0x6b, 0x32, 0x7d, 0xd5, 0x1b, 0x0c,
-	0x67, 0x83, 0xab, 0xd9, 0x68, 0x3c, 0x35, 0x69, 0xcb, 0xf9, 0x06, 0x47, 0x17, 0x98, 0xa5, 0x6b,
-	0x64, 0xd5, 0x35, 0x74, 0xfe, 0x7f, 0x0d, 0x72, 0xb7, 0xba, 0x4e, 0x1e, 0xc3, 0x4e, 0x94, 0xd1,
-	0xf8, 0xc6, 0x8c, 0x78, 0x58, 0x82, 0xbe, 0x4c, 0xf6, 0x6b, 0x81, 0xae, 0x96, 0xab, 0xec, 0xfe,
-	0xb0, 0xe0, 0xa8, 0x27, 0xe8, 0x32, 0x8d, 0xab, 0x13, 0x24, 0xef, 0x61, 0x7f, 0x13, 0x34, 0xcb,
-	0x06, 0x97, 0xf9, 0x1a, 0x33, 0x5a, 0x60, 0xbb, 0x5d, 0xad, 0xe1, 0xaf, 0xab, 0x75, 0x6a, 0x1d,
-	0xeb, 0x95, 0x45, 0xce, 0x61, 0xd7, 0x0c, 0x70, 0x87, 0xbc, 0x55, 0xc9, 0xff, 0x18, 0x52, 0x8b,
-	0x7d, 0xf7, 0xeb, 0x8b, 0xeb, 0x54, 0x2c, 0x56, 0x91, 0x54, 0x7a, 0x8b, 0xdb, 0x02, 0x59, 0x86,
-	0xc9, 0x35, 0x32, 0x6f, 0x1e, 0x46, 0x2c, 0x8d, 0x3d, 0xf5, 0xd1, 0x70, 0xcf, 0x74, 0x89, 0x6c,
-	0x15, 0xbf, 0xfe, 0x1d, 0x00, 0x00, 0xff, 0xff, 0xe2, 0x79, 0xcc, 0xb7, 0x76, 0x03, 0x00, 0x00,
+	// 571 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x94, 0xdb, 0x6e, 0xd3, 0x4c,
+	0x14, 0x85, 0xed, 0xfe, 0x49, 0xda, 0xec, 0xbf, 0xc7, 0xa9, 0x5a, 0x45, 0xb9, 0xa0, 0xc8, 0x12,
+	0x10, 0x04, 0xd8, 0x28, 0x48, 0x08, 0x51, 0x24, 0x54, 0xd3, 0x56, 0xb1, 0xa8, 0x12, 0xe4, 0x84,
+	0x0b, 0xb8, 0x89, 0x7c, 0x98, 0x24, 0xa6, 0xb6, 0xc7, 0x9a, 0x99, 0x84, 0xf4, 0x25, 0xe0, 0x05,
+	0x79, 0x18, 0x34, 0x07, 0x3b, 0x04, 0xa2, 0x5e, 0x25, 0x7b, 0xef, 0x6f, 0xaf, 0xf1, 0x5a, 0xf1,
+	0x04, 0x0e, 0x09, 0x8d, 0x31, 0xc5, 0xd4, 0x09, 0x42, 0xbb, 0xa0, 0x84, 0x13, 0xb4, 0xad, 0x3b,
+	0xed, 0xe3, 0x88, 0x64, 0x19, 0xc9, 0x1d, 0xf5, 0xa1, 0xa6, 0xed, 0xb3, 0x29, 0x21, 0xd3, 0x14,
+	0x3b, 0xb2, 0x0a, 0xe7, 0x13, 0x87, 0x27, 0x19, 0x66, 0x3c, 0xc8, 0x0a, 0x05, 0x58, 0xe7, 0x70,
+	0xe4, 0x52, 0x12, 0xc4, 0x51, 0xc0, 0xb8, 0x8f, 0x59, 0x41, 0x72, 0x86, 0xd1, 0x63, 0x68, 0x30,
+	0x1e, 0xf0, 0x39, 0x6b, 0x99, 0x0f, 0xcd, 0xce, 0x7e, 0x77, 0xdf, 0xd6, 0xa2, 0x43, 0xd9, 0xf5,
+	0xf5, 0xd4, 0xda, 0x05, 0x18, 0x62, 0x7c, 0xdb, 0xc7, 0xdf, 0x31, 0xe3, 0x65, 0x35, 0x48, 0x63,
+	0x51, 0x3d, 0x81, 0x3d, 0x51, 0x0d, 0x0b, 0x1c, 0x25, 0x93, 0x04, 0xc7, 0xe8, 0x14, 0x1a, 0xf9,
+	0x3c, 0x0b, 0x31, 0x95, 0xa2, 0x35, 0x5f, 0x57, 0xd6, 0x19, 0xec, 0x08, 0x70, 0xb4, 0xf4, 0x2e,
+	0xd1, 0x31, 0xd4, 0xf9, 0x72, 0x9c, 0xc4, 0x12, 0x69, 0xfa, 0x35, 0xbe, 0xf4, 0x62, 0xcb, 0x53,
+	0x4a, 0xa3, 0xf2, 0xc9, 0xd1, 0x1b, 0x68, 0x56, 0x36, 0x24, 0xf9, 0x7f, 0xb7, 0x6d, 0x2b, 0xa3,
+	0x76, 0x69, 0xd4, 0xae, 0x70, 0x7f, 0x05, 0x5b, 0x3f, 0xb6, 0x60, 0x57, 0x68, 0x7d, 0x22, 0x2c,
+	0xe1, 0x09, 0xc9, 0xd1, 0x0b, 0x68, 0xe4, 0xf2, 0xe9, 0xb5, 0xce, 0xb1, 0xad, 0xe3, 0xb4, 0x57,
+	0xc6, 0x7a, 0x86, 0xaf, 0x21, 0x81, 0x13, 0x69, 0xaf, 0xb5, 0xb5, 0x01, 0x57, 0xce, 0x05, 0xae,
+	0x20, 0xf4, 0x1a, 0x9a, 0xac, 0xf4, 0xdf, 0xfa, 0x4f, 0x6e, 0x9c, 0xae, 0x6d, 0x54, 0xe9, 0xf4,
+	0x0c, 0x7f, 0x85, 0xa2, 0x4e, 0x19, 0x43, 0x4d, 0xee, 0x1c, 0xad, 0xed, 0x88, 0xa0, 0x7a, 0x86,
+	0xca, 0x46, 0x9c, 0xb0, 0x8a, 0xa2, 0xbe, 0xe1, 0x84, 0x2a, 0x06, 0x71, 0x42, 0x85, 0xba, 0x0d,
+	0xa8, 0x8d, 0xee, 0x0a, 0x6c, 0xfd, 0x32, 0x55, 0xfa, 0x5e, 0x3e, 0x21, 0xe8, 0x19, 0xd4, 0x19,
+	0x0f, 0x68, 0x99, 0xc5, 0xc9, 0x9a, 0x50, 0x19, 0x99, 0xaf, 0x18, 0xf4, 0x14, 0x6a, 0x8c, 0x93,
+	0xa2, 0xb5, 0x75, 0x1f, 0x2b, 0x11, 0xf4, 0x16, 0x76, 0x42, 0x3c, 0x0b, 0x16, 0x09, 0xa1, 0x32,
+	0x85, 0xfd, 0xee, 0x83, 0x35, 0x5c, 0x1c, 0x2e, 0xbf, 0xb8, 0x9a, 0xf2, 0x2b, 0xde, 0x7a, 0x07,
+	0xbb, 0x7f, 0x4e, 0xd0, 0x09, 0x1c, 0xb9, 0x37, 0x83, 0x0f, 0x1f, 0xc7, 0x9f, 0xfb, 0x23, 0xef,
+	0x66, 0xec, 0x5f, 0x5d, 0x5c, 0x7e, 0x39, 0x34, 0x44, 0xfb, 0xfa, 0xc2, 0xbb, 0x19, 0x7b, 0xd7,
+	0xe3, 0xfe, 0x60, 0xa4, 0xdb, 0xa6, 0xf5, 0x0d, 0x0e, 0x2e, 0x71, 0x9a, 0x2c, 0x30, 0xad, 0xde,
+	0xed, 0xce, 0xfd, 0xef, 0xb6, 0xf8, 0xf5, 0xd4, 0x1c, 0x3d, 0x82, 0x7a, 0x98, 0x92, 0xe8, 0x56,
+	0x5b, 0xdc, 0x2b, 0x41, 0x57, 0x34, 0x7b, 0x86, 0xaf, 0xa6, 0x65, 0x94, 0xdd, 0x9f, 0x26, 0x1c,
+	0x5c, 0x70, 0x92, 0x25, 0x51, 0x75, 0xa1, 0xd0, 0x7b, 0x68, 0xae, 0x8a, 0xc3, 0x52, 0xe0, 0x2a,
+	0x5f, 0xe0, 0x94, 0x14, 0xb8, 0xdd, 0xae, 0x62, 0xf8, 0xe7, 0x0e, 0x5a, 0x46, 0xc7, 0x7c, 0x69,
+	0xa2, 0x73, 0xd8, 0xd6, 0x06, 0x36, 0xac, 0xb7, 0xaa, 0xf5, 0xbf, 0x4c, 0xaa, 0x65, 0xd7, 0xfe,
+	0xfa, 0x7c, 0x9a, 0xf0, 0xd9, 0x3c, 0x14, 0x9b, 0xce, 0xec, 0xae, 0xc0, 0x34, 0xc5, 0xf1, 0x14,
+	0x53, 0x67, 0x12, 0x84, 0x34, 0x89, 0xd4, 0xbf, 0x02, 0x73, 0xb4, 0x4a, 0xd8, 0x90, 0xf5, 0xab,
+	0xdf, 0x03, 0x00, 0xed, 0xfb, 0x75, 0x9a, 0x65, 0x04, 0x00, 0x00,
 }
@@ -12,16 +12,47 @@ It is generated from these files:
 	peer/chaincodeshim.proto
 	peer/configuration.proto
 	peer/events.proto
+	peer/ledgeradmin.proto
 	peer/peer.proto
 	peer/proposal.proto
 	peer/proposal_response.proto
 	peer/query.proto
+	peer/snapshot.proto
+	peer/statequery.proto
 	peer/transaction.proto
 
 It has these top-level messages:
 	ServerStatus
 	LogLevelRequest
 	LogLevelResponse
+	LedgerDiskUsageRequest
+	LedgerDiskUsage
+	LedgerDiskUsageResponse
+	BlockFingerprintRequest
+	BlockFingerprint
+	StateFingerprintRequest
+	StateFingerprint
+	NamespaceHash
+	QuiesceCommitsRequest
+	LedgerHeights
+	QuiesceCommitsResponse
+	StateWarmUpRequest
+	LedgerWarmUp
+	StateWarmUpResponse
+	NamespaceStatsRequest
+	NamespaceStats
+	LedgerNamespaceStats
+	NamespaceStatsResponse
+	LedgerCompactionRequest
+	LedgerCompaction
+	LedgerCompactionResponse
+	SystemStateSnapshotRequest
+	SystemStateSnapshot
+	SystemStateSnapshotResponse
+	InvalidTransactionsRequest
+	InvalidTransaction
+	LedgerInvalidTransactions
+	InvalidTransactionsResponse
 	ChaincodeID
 	ChaincodeInput
 	ChaincodeSpec
@@ -37,6 +68,8 @@ It has these top-level messages:
 	QueryStateClose
 	QueryStateKeyValue
 	QueryStateResponse
+	GetTotalForKeyPrefix
+	TotalForKeyPrefixResponse
 	AnchorPeers
 	AnchorPeer
 	ChaincodeReg
@@ -46,6 +79,10 @@ It has these top-level messages:
 	Unregister
 	SignedEvent
 	Event
+	LedgerAdminRequest
+	LedgerAdminResponse
+	LedgerHealth
+	LedgerHealthResponse
 	PeerID
 	PeerEndpoint
 	SignedProposal
@@ -61,6 +98,18 @@ It has these top-level messages:
 	ChaincodeInfo
 	ChannelQueryResponse
 	ChannelInfo
+	ChaincodeEventsQueryResponse
+	ChaincodeEventInfo
+	SnapshotRequest
+	SnapshotChunk
+	CommitHashRequest
+	CommitHashResponse
+	StateKeyRequest
+	StateRangeRequest
+	StateRichQueryRequest
+	KeyModificationResult
+	StateAsOfRequest
+	StateUpdatesRequest
 	SignedTransaction
 	ProcessedTransaction
 	Transaction
@@ -153,10 +202,436 @@ func (m *LogLevelResponse) String() string            { return proto.CompactText
 func (*LogLevelResponse) ProtoMessage()               {}
 func (*LogLevelResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
 
+type LedgerDiskUsageRequest struct {
+	ChannelId string `protobuf:"bytes,1,opt,name=channel_id,json=channelId" json:"channel_id,omitempty"`
+}
+
+func (m *LedgerDiskUsageRequest) Reset()                    { *m = LedgerDiskUsageRequest{} }
+func (m *LedgerDiskUsageRequest) String() string            { return proto.CompactTextString(m) }
+func (*LedgerDiskUsageRequest) ProtoMessage()               {}
+func (*LedgerDiskUsageRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
+
+// LedgerDiskUsage carries the number of bytes that the stores of the
+// ledger of a channel occupy on the disk. The sizes of the stores shared
+// by the channels, such as the leveldb indexes, are approximate
+type LedgerDiskUsage struct {
+	ChannelId       string `protobuf:"bytes,1,opt,name=channel_id,json=channelId" json:"channel_id,omitempty"`
+	BlockStoreBytes uint64 `protobuf:"varint,2,opt,name=block_store_bytes,json=blockStoreBytes" json:"block_store_bytes,omitempty"`
+	StateDbBytes    uint64 `protobuf:"varint,3,opt,name=state_db_bytes,json=stateDbBytes" json:"state_db_bytes,omitempty"`
+	HistoryDbBytes  uint64 `protobuf:"varint,4,opt,name=history_db_bytes,json=historyDbBytes" json:"history_db_bytes,omitempty"`
+}
+
+func (m *LedgerDiskUsage) Reset()                    { *m = LedgerDiskUsage{} }
+func (m *LedgerDiskUsage) String() string            { return proto.CompactTextString(m) }
+func (*LedgerDiskUsage) ProtoMessage()               {}
+func (*LedgerDiskUsage) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+
+type LedgerDiskUsageResponse struct {
+	Ledgers []*LedgerDiskUsage `protobuf:"bytes,1,rep,name=ledgers" json:"ledgers,omitempty"`
+}
+
+func (m *LedgerDiskUsageResponse) Reset()                    { *m = LedgerDiskUsageResponse{} }
+func (m *LedgerDiskUsageResponse) String() string            { return proto.CompactTextString(m) }
+func (*LedgerDiskUsageResponse) ProtoMessage()               {}
+func (*LedgerDiskUsageResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
+
+func (m *LedgerDiskUsageResponse) GetLedgers() []*LedgerDiskUsage {
+	if m != nil {
+		return m.Ledgers
+	}
+	return nil
+}
+
+// BlockFingerprintRequest requests the fingerprint of the block with the
+// given number. block_number of 2^64-1 requests the last block
+type BlockFingerprintRequest struct {
+	ChannelId   string `protobuf:"bytes,1,opt,name=channel_id,json=channelId" json:"channel_id,omitempty"`
+	BlockNumber uint64 `protobuf:"varint,2,opt,name=block_number,json=blockNumber" json:"block_number,omitempty"`
+}
+
+func (m *BlockFingerprintRequest) Reset()                    { *m = BlockFingerprintRequest{} }
+func (m *BlockFingerprintRequest) String() string            { return proto.CompactTextString(m) }
+func (*BlockFingerprintRequest) ProtoMessage()               {}
+func (*BlockFingerprintRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
+
+// BlockFingerprint carries the hash of the header of a block and the commit
+// hash recorded in the block, which is empty for a block committed without one
+type BlockFingerprint struct {
+	BlockNumber uint64 `protobuf:"varint,1,opt,name=block_number,json=blockNumber" json:"block_number,omitempty"`
+	BlockHash   []byte `protobuf:"bytes,2,opt,name=block_hash,json=blockHash,proto3" json:"block_hash,omitempty"`
+	CommitHash  []byte `protobuf:"bytes,3,opt,name=commit_hash,json=commitHash,proto3" json:"commit_hash,omitempty"`
+}
+
+func (m *BlockFingerprint) Reset()                    { *m = BlockFingerprint{} }
+func (m *BlockFingerprint) String() string            { return proto.CompactTextString(m) }
+func (*BlockFingerprint) ProtoMessage()               {}
+func (*BlockFingerprint) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
+
+type StateFingerprintRequest struct {
+	ChannelId string `protobuf:"bytes,1,opt,name=channel_id,json=channelId" json:"channel_id,omitempty"`
+}
+
+func (m *StateFingerprintRequest) Reset()                    { *m = StateFingerprintRequest{} }
+func (m *StateFingerprintRequest) String() string            { return proto.CompactTextString(m) }
+func (*StateFingerprintRequest) ProtoMessage()               {}
+func (*StateFingerprintRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
+
+// StateFingerprint carries the hashes of the contents of the state database
+// per namespace as of the given height
+type StateFingerprint struct {
+	Height          uint64           `protobuf:"varint,1,opt,name=height" json:"height,omitempty"`
+	NamespaceHashes []*NamespaceHash `protobuf:"bytes,2,rep,name=namespace_hashes,json=namespaceHashes" json:"namespace_hashes,omitempty"`
+}
+
+func (m *StateFingerprint) Reset()                    { *m = StateFingerprint{} }
+func (m *StateFingerprint) String() string            { return proto.CompactTextString(m) }
+func (*StateFingerprint) ProtoMessage()               {}
+func (*StateFingerprint) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
+
+func (m *StateFingerprint) GetNamespaceHashes() []*NamespaceHash {
+	if m != nil {
+		return m.NamespaceHashes
+	}
+	return nil
+}
+
+type NamespaceHash struct {
+	Namespace string `protobuf:"bytes,1,opt,name=namespace" json:"namespace,omitempty"`
+	Hash      []byte `protobuf:"bytes,2,opt,name=hash,proto3" json:"hash,omitempty"`
+}
+
+func (m *NamespaceHash) Reset()                    { *m = NamespaceHash{} }
+func (m *NamespaceHash) String() string            { return proto.CompactTextString(m) }
+func (*NamespaceHash) ProtoMessage()               {}
+func (*NamespaceHash) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
+
+// QuiesceCommitsRequest carries the number of seconds after which the peer
+// releases the commits if they are not released explicitly. The peer default
+// applies if none is given
+type QuiesceCommitsRequest struct {
+	TimeoutSeconds uint32 `protobuf:"varint,1,opt,name=timeout_seconds,json=timeoutSeconds" json:"timeout_seconds,omitempty"`
+}
+
+func (m *QuiesceCommitsRequest) Reset()                    { *m = QuiesceCommitsRequest{} }
+func (m *QuiesceCommitsRequest) String() string            { return proto.CompactTextString(m) }
+func (*QuiesceCommitsRequest) ProtoMessage()               {}
+func (*QuiesceCommitsRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
+
+// LedgerHeights carries the heights of the stores of the ledger of a channel,
+// that is, the number of blocks whose updates a store contains
+type LedgerHeights struct {
+	ChannelId        string `protobuf:"bytes,1,opt,name=channel_id,json=channelId" json:"channel_id,omitempty"`
+	BlockStoreHeight uint64 `protobuf:"varint,2,opt,name=block_store_height,json=blockStoreHeight" json:"block_store_height,omitempty"`
+	StateDbHeight    uint64 `protobuf:"varint,3,opt,name=state_db_height,json=stateDbHeight" json:"state_db_height,omitempty"`
+	HistoryDbHeight  uint64 `protobuf:"varint,4,opt,name=history_db_height,json=historyDbHeight" json:"history_db_height,omitempty"`
+}
+
+func (m *LedgerHeights) Reset()                    { *m = LedgerHeights{} }
+func (m *LedgerHeights) String() string            { return proto.CompactTextString(m) }
+func (*LedgerHeights) ProtoMessage()               {}
+func (*LedgerHeights) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
+
+type QuiesceCommitsResponse struct {
+	Ledgers []*LedgerHeights `protobuf:"bytes,1,rep,name=ledgers" json:"ledgers,omitempty"`
+}
+
+func (m *QuiesceCommitsResponse) Reset()                    { *m = QuiesceCommitsResponse{} }
+func (m *QuiesceCommitsResponse) String() string            { return proto.CompactTextString(m) }
+func (*QuiesceCommitsResponse) ProtoMessage()               {}
+func (*QuiesceCommitsResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
+
+func (m *QuiesceCommitsResponse) GetLedgers() []*LedgerHeights {
+	if m != nil {
+		return m.Ledgers
+	}
+	return nil
+}
+
+// StateWarmUpRequest requests the keys most recently written to the state
+// database of a channel, or of all the channels if none is given, to be read
+// into the caches of the state database. All the tracked keys of a namespace
+// are read if keys_per_namespace is not set
+type StateWarmUpRequest struct {
+	ChannelId        string `protobuf:"bytes,1,opt,name=channel_id,json=channelId" json:"channel_id,omitempty"`
+	KeysPerNamespace uint32 `protobuf:"varint,2,opt,name=keys_per_namespace,json=keysPerNamespace" json:"keys_per_namespace,omitempty"`
+}
+
+func (m *StateWarmUpRequest) Reset()                    { *m = StateWarmUpRequest{} }
+func (m *StateWarmUpRequest) String() string            { return proto.CompactTextString(m) }
+func (*StateWarmUpRequest) ProtoMessage()               {}
+func (*StateWarmUpRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
+
+// LedgerWarmUp carries the number of keys read from the state database of
+// the ledger of a channel
+type LedgerWarmUp struct {
+	ChannelId  string `protobuf:"bytes,1,opt,name=channel_id,json=channelId" json:"channel_id,omitempty"`
+	KeysLoaded uint64 `protobuf:"varint,2,opt,name=keys_loaded,json=keysLoaded" json:"keys_loaded,omitempty"`
+}
+
+func (m *LedgerWarmUp) Reset()                    { *m = LedgerWarmUp{} }
+func (m *LedgerWarmUp) String() string            { return proto.CompactTextString(m) }
+func (*LedgerWarmUp) ProtoMessage()               {}
+func (*LedgerWarmUp) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15} }
+
+type StateWarmUpResponse struct {
+	Ledgers []*LedgerWarmUp `protobuf:"bytes,1,rep,name=ledgers" json:"ledgers,omitempty"`
+}
+
+func (m *StateWarmUpResponse) Reset()                    { *m = StateWarmUpResponse{} }
+func (m *StateWarmUpResponse) String() string            { return proto.CompactTextString(m) }
+func (*StateWarmUpResponse) ProtoMessage()               {}
+func (*StateWarmUpResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{16} }
+
+func (m *StateWarmUpResponse) GetLedgers() []*LedgerWarmUp {
+	if m != nil {
+		return m.Ledgers
+	}
+	return nil
+}
+
+// NamespaceStatsRequest requests the number of the operations on the state
+// per namespace of a channel, or of all the channels if none is given
+type NamespaceStatsRequest struct {
+	ChannelId string `protobuf:"bytes,1,opt,name=channel_id,json=channelId" json:"channel_id,omitempty"`
+}
+
+func (m *NamespaceStatsRequest) Reset()                    { *m = NamespaceStatsRequest{} }
+func (m *NamespaceStatsRequest) String() string            { return proto.CompactTextString(m) }
+func (*NamespaceStatsRequest) ProtoMessage()               {}
+func (*NamespaceStatsRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{17} }
+
+// NamespaceStats carries the number of the operations on the state of a
+// namespace since the ledger was opened. The reads, the range queries, the
+// writes, and the deletes are those of the valid transactions, and blocks is
+// the number of blocks that touched the namespace
+type NamespaceStats struct {
+	Namespace    string `protobuf:"bytes,1,opt,name=namespace" json:"namespace,omitempty"`
+	Blocks       uint64 `protobuf:"varint,2,opt,name=blocks" json:"blocks,omitempty"`
+	Reads        uint64 `protobuf:"varint,3,opt,name=reads" json:"reads,omitempty"`
+	RangeQueries uint64 `protobuf:"varint,4,opt,name=range_queries,json=rangeQueries" json:"range_queries,omitempty"`
+	RichQueries  uint64 `protobuf:"varint,5,opt,name=rich_queries,json=richQueries" json:"rich_queries,omitempty"`
+	Writes       uint64 `protobuf:"varint,6,opt,name=writes" json:"writes,omitempty"`
+	Deletes      uint64 `protobuf:"varint,7,opt,name=deletes" json:"deletes,omitempty"`
+	WrittenBytes uint64 `protobuf:"varint,8,opt,name=written_bytes,json=writtenBytes" json:"written_bytes,omitempty"`
+}
+
+func (m *NamespaceStats) Reset()                    { *m = NamespaceStats{} }
+func (m *NamespaceStats) String() string            { return proto.CompactTextString(m) }
+func (*NamespaceStats) ProtoMessage()               {}
+func (*NamespaceStats) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{18} }
+
+// LedgerNamespaceStats carries the statistics of the namespaces of the ledger
+// of a channel
+type LedgerNamespaceStats struct {
+	ChannelId  string            `protobuf:"bytes,1,opt,name=channel_id,json=channelId" json:"channel_id,omitempty"`
+	Namespaces []*NamespaceStats `protobuf:"bytes,2,rep,name=namespaces" json:"namespaces,omitempty"`
+}
+
+func (m *LedgerNamespaceStats) Reset()                    { *m = LedgerNamespaceStats{} }
+func (m *LedgerNamespaceStats) String() string            { return proto.CompactTextString(m) }
+func (*LedgerNamespaceStats) ProtoMessage()               {}
+func (*LedgerNamespaceStats) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{19} }
+
+func (m *LedgerNamespaceStats) GetNamespaces() []*NamespaceStats {
+	if m != nil {
+		return m.Namespaces
+	}
+	return nil
+}
+
+type NamespaceStatsResponse struct {
+	Ledgers []*LedgerNamespaceStats `protobuf:"bytes,1,rep,name=ledgers" json:"ledgers,omitempty"`
+}
+
+func (m *NamespaceStatsResponse) Reset()                    { *m = NamespaceStatsResponse{} }
+func (m *NamespaceStatsResponse) String() string            { return proto.CompactTextString(m) }
+func (*NamespaceStatsResponse) ProtoMessage()               {}
+func (*NamespaceStatsResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{20} }
+
+func (m *NamespaceStatsResponse) GetLedgers() []*LedgerNamespaceStats {
+	if m != nil {
+		return m.Ledgers
+	}
+	return nil
+}
+
+// LedgerCompactionRequest requests the block index and the history database
+// of the ledger of a channel, or of all the channels if none is given, to be
+// compacted
+type LedgerCompactionRequest struct {
+	ChannelId string `protobuf:"bytes,1,opt,name=channel_id,json=channelId" json:"channel_id,omitempty"`
+}
+
+func (m *LedgerCompactionRequest) Reset()                    { *m = LedgerCompactionRequest{} }
+func (m *LedgerCompactionRequest) String() string            { return proto.CompactTextString(m) }
+func (*LedgerCompactionRequest) ProtoMessage()               {}
+func (*LedgerCompactionRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{21} }
+
+// LedgerCompaction carries the time taken to compact the ledger of a channel
+type LedgerCompaction struct {
+	ChannelId      string `protobuf:"bytes,1,opt,name=channel_id,json=channelId" json:"channel_id,omitempty"`
+	DurationMillis uint64 `protobuf:"varint,2,opt,name=duration_millis,json=durationMillis" json:"duration_millis,omitempty"`
+}
+
+func (m *LedgerCompaction) Reset()                    { *m = LedgerCompaction{} }
+func (m *LedgerCompaction) String() string            { return proto.CompactTextString(m) }
+func (*LedgerCompaction) ProtoMessage()               {}
+func (*LedgerCompaction) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{22} }
+
+type LedgerCompactionResponse struct {
+	Ledgers []*LedgerCompaction `protobuf:"bytes,1,rep,name=ledgers" json:"ledgers,omitempty"`
+}
+
+func (m *LedgerCompactionResponse) Reset()                    { *m = LedgerCompactionResponse{} }
+func (m *LedgerCompactionResponse) String() string            { return proto.CompactTextString(m) }
+func (*LedgerCompactionResponse) ProtoMessage()               {}
+func (*LedgerCompactionResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{23} }
+
+func (m *LedgerCompactionResponse) GetLedgers() []*LedgerCompaction {
+	if m != nil {
+		return m.Ledgers
+	}
+	return nil
+}
+
+// SystemStateSnapshotRequest requests a snapshot of the system namespaces of
+// the state of the ledger of a channel, or of all the channels that keep them
+// in the system state database if none is given
+type SystemStateSnapshotRequest struct {
+	ChannelId string `protobuf:"bytes,1,opt,name=channel_id,json=channelId" json:"channel_id,omitempty"`
+}
+
+func (m *SystemStateSnapshotRequest) Reset()                    { *m = SystemStateSnapshotRequest{} }
+func (m *SystemStateSnapshotRequest) String() string            { return proto.CompactTextString(m) }
+func (*SystemStateSnapshotRequest) ProtoMessage()               {}
+func (*SystemStateSnapshotRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{24} }
+
+// SystemStateSnapshot carries the block and the directory, on the peer, of a
+// snapshot of the system namespaces of the state of a channel
+type SystemStateSnapshot struct {
+	ChannelId   string `protobuf:"bytes,1,opt,name=channel_id,json=channelId" json:"channel_id,omitempty"`
+	BlockNumber uint64 `protobuf:"varint,2,opt,name=block_number,json=blockNumber" json:"block_number,omitempty"`
+	Directory   string `protobuf:"bytes,3,opt,name=directory" json:"directory,omitempty"`
+}
+
+func (m *SystemStateSnapshot) Reset()                    { *m = SystemStateSnapshot{} }
+func (m *SystemStateSnapshot) String() string            { return proto.CompactTextString(m) }
+func (*SystemStateSnapshot) ProtoMessage()               {}
+func (*SystemStateSnapshot) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{25} }
+
+type SystemStateSnapshotResponse struct {
+	Snapshots []*SystemStateSnapshot `protobuf:"bytes,1,rep,name=snapshots" json:"snapshots,omitempty"`
+}
+
+func (m *SystemStateSnapshotResponse) Reset()                    { *m = SystemStateSnapshotResponse{} }
+func (m *SystemStateSnapshotResponse) String() string            { return proto.CompactTextString(m) }
+func (*SystemStateSnapshotResponse) ProtoMessage()               {}
+func (*SystemStateSnapshotResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{26} }
+
+func (m *SystemStateSnapshotResponse) GetSnapshots() []*SystemStateSnapshot {
+	if m != nil {
+		return m.Snapshots
+	}
+	return nil
+}
+
+// InvalidTransactionsRequest requests the most recent transactions invalidated
+// at commit of a channel, or of all the channels if none is given, up to
+// max_entries transactions per channel or all the logged ones if not set
+type InvalidTransactionsRequest struct {
+	ChannelId  string `protobuf:"bytes,1,opt,name=channel_id,json=channelId" json:"channel_id,omitempty"`
+	MaxEntries uint32 `protobuf:"varint,2,opt,name=max_entries,json=maxEntries" json:"max_entries,omitempty"`
+}
+
+func (m *InvalidTransactionsRequest) Reset()                    { *m = InvalidTransactionsRequest{} }
+func (m *InvalidTransactionsRequest) String() string            { return proto.CompactTextString(m) }
+func (*InvalidTransactionsRequest) ProtoMessage()               {}
+func (*InvalidTransactionsRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{27} }
+
+// InvalidTransaction carries a transaction invalidated at commit along with
+// its position, the validation code, the MSP of its creator, and the
+// chaincode it invoked
+type InvalidTransaction struct {
+	TxId           string `protobuf:"bytes,1,opt,name=tx_id,json=txId" json:"tx_id,omitempty"`
+	BlockNum       uint64 `protobuf:"varint,2,opt,name=block_num,json=blockNum" json:"block_num,omitempty"`
+	TxNum          uint64 `protobuf:"varint,3,opt,name=tx_num,json=txNum" json:"tx_num,omitempty"`
+	ValidationCode string `protobuf:"bytes,4,opt,name=validation_code,json=validationCode" json:"validation_code,omitempty"`
+	CreatorMspId   string `protobuf:"bytes,5,opt,name=creator_msp_id,json=creatorMspId" json:"creator_msp_id,omitempty"`
+	Chaincode      string `protobuf:"bytes,6,opt,name=chaincode" json:"chaincode,omitempty"`
+}
+
+func (m *InvalidTransaction) Reset()                    { *m = InvalidTransaction{} }
+func (m *InvalidTransaction) String() string            { return proto.CompactTextString(m) }
+func (*InvalidTransaction) ProtoMessage()               {}
+func (*InvalidTransaction) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{28} }
+
+// LedgerInvalidTransactions carries the invalidated transactions of the
+// ledger of a channel
+type LedgerInvalidTransactions struct {
+	ChannelId    string                `protobuf:"bytes,1,opt,name=channel_id,json=channelId" json:"channel_id,omitempty"`
+	Transactions []*InvalidTransaction `protobuf:"bytes,2,rep,name=transactions" json:"transactions,omitempty"`
+}
+
+func (m *LedgerInvalidTransactions) Reset()                    { *m = LedgerInvalidTransactions{} }
+func (m *LedgerInvalidTransactions) String() string            { return proto.CompactTextString(m) }
+func (*LedgerInvalidTransactions) ProtoMessage()               {}
+func (*LedgerInvalidTransactions) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{29} }
+
+func (m *LedgerInvalidTransactions) GetTransactions() []*InvalidTransaction {
+	if m != nil {
+		return m.Transactions
+	}
+	return nil
+}
+
+type InvalidTransactionsResponse struct {
+	Ledgers []*LedgerInvalidTransactions `protobuf:"bytes,1,rep,name=ledgers" json:"ledgers,omitempty"`
+}
+
+func (m *InvalidTransactionsResponse) Reset()                    { *m = InvalidTransactionsResponse{} }
+func (m *InvalidTransactionsResponse) String() string            { return proto.CompactTextString(m) }
+func (*InvalidTransactionsResponse) ProtoMessage()               {}
+func (*InvalidTransactionsResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{30} }
+
+func (m *InvalidTransactionsResponse) GetLedgers() []*LedgerInvalidTransactions {
+	if m != nil {
+		return m.Ledgers
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*ServerStatus)(nil), "protos.ServerStatus")
 	proto.RegisterType((*LogLevelRequest)(nil), "protos.LogLevelRequest")
 	proto.RegisterType((*LogLevelResponse)(nil), "protos.LogLevelResponse")
+	proto.RegisterType((*LedgerDiskUsageRequest)(nil), "protos.LedgerDiskUsageRequest")
+	proto.RegisterType((*LedgerDiskUsage)(nil), "protos.LedgerDiskUsage")
+	proto.RegisterType((*LedgerDiskUsageResponse)(nil), "protos.LedgerDiskUsageResponse")
+	proto.RegisterType((*BlockFingerprintRequest)(nil), "protos.BlockFingerprintRequest")
+	proto.RegisterType((*BlockFingerprint)(nil), "protos.BlockFingerprint")
+	proto.RegisterType((*StateFingerprintRequest)(nil), "protos.StateFingerprintRequest")
+	proto.RegisterType((*StateFingerprint)(nil), "protos.StateFingerprint")
+	proto.RegisterType((*NamespaceHash)(nil), "protos.NamespaceHash")
+	proto.RegisterType((*QuiesceCommitsRequest)(nil), "protos.QuiesceCommitsRequest")
+	proto.RegisterType((*LedgerHeights)(nil), "protos.LedgerHeights")
+	proto.RegisterType((*QuiesceCommitsResponse)(nil), "protos.QuiesceCommitsResponse")
+	proto.RegisterType((*StateWarmUpRequest)(nil), "protos.StateWarmUpRequest")
+	proto.RegisterType((*LedgerWarmUp)(nil), "protos.LedgerWarmUp")
+	proto.RegisterType((*StateWarmUpResponse)(nil), "protos.StateWarmUpResponse")
+	proto.RegisterType((*NamespaceStatsRequest)(nil), "protos.NamespaceStatsRequest")
+	proto.RegisterType((*NamespaceStats)(nil), "protos.NamespaceStats")
+	proto.RegisterType((*LedgerNamespaceStats)(nil), "protos.LedgerNamespaceStats")
+	proto.RegisterType((*NamespaceStatsResponse)(nil), "protos.NamespaceStatsResponse")
+	proto.RegisterType((*LedgerCompactionRequest)(nil), "protos.LedgerCompactionRequest")
+	proto.RegisterType((*LedgerCompaction)(nil), "protos.LedgerCompaction")
+	proto.RegisterType((*LedgerCompactionResponse)(nil), "protos.LedgerCompactionResponse")
+	proto.RegisterType((*SystemStateSnapshotRequest)(nil), "protos.SystemStateSnapshotRequest")
+	proto.RegisterType((*SystemStateSnapshot)(nil), "protos.SystemStateSnapshot")
+	proto.RegisterType((*SystemStateSnapshotResponse)(nil), "protos.SystemStateSnapshotResponse")
+	proto.RegisterType((*InvalidTransactionsRequest)(nil), "protos.InvalidTransactionsRequest")
+	proto.RegisterType((*InvalidTransaction)(nil), "protos.InvalidTransaction")
+	proto.RegisterType((*LedgerInvalidTransactions)(nil), "protos.LedgerInvalidTransactions")
+	proto.RegisterType((*InvalidTransactionsResponse)(nil), "protos.InvalidTransactionsResponse")
 	proto.RegisterEnum("protos.ServerStatus_StatusCode", ServerStatus_StatusCode_name, ServerStatus_StatusCode_value)
 }
 
@@ -177,6 +652,43 @@ type AdminClient interface {
 	StopServer(ctx context.Context, in *google_protobuf.Empty, opts ...grpc.CallOption) (*ServerStatus, error)
 	GetModuleLogLevel(ctx context.Context, in *LogLevelRequest, opts ...grpc.CallOption) (*LogLevelResponse, error)
 	SetModuleLogLevel(ctx context.Context, in *LogLevelRequest, opts ...grpc.CallOption) (*LogLevelResponse, error)
+	// Switch the peer to and from the maintenance mode, in which the
+	// channels remain loaded but commits and endorsements are disabled
+	EnterMaintenanceMode(ctx context.Context, in *google_protobuf.Empty, opts ...grpc.CallOption) (*ServerStatus, error)
+	ExitMaintenanceMode(ctx context.Context, in *google_protobuf.Empty, opts ...grpc.CallOption) (*ServerStatus, error)
+	// Return the number of bytes that the ledgers of the channels occupy on
+	// the disk, for the given channel or for all the channels if none is given
+	GetLedgerDiskUsage(ctx context.Context, in *LedgerDiskUsageRequest, opts ...grpc.CallOption) (*LedgerDiskUsageResponse, error)
+	// Return the hash and the commit hash of a block of the ledger of a channel,
+	// for comparing the ledgers of the peers
+	GetBlockFingerprint(ctx context.Context, in *BlockFingerprintRequest, opts ...grpc.CallOption) (*BlockFingerprint, error)
+	// Return the hashes of the contents of the state database of a channel per
+	// namespace, for comparing the ledgers of the peers
+	GetStateFingerprint(ctx context.Context, in *StateFingerprintRequest, opts ...grpc.CallOption) (*StateFingerprint, error)
+	// Block the commits and flush the ledgers of all the channels to the disk, so
+	// that the block store, the state database, and the history database can be
+	// copied by a backup tool as a consistent set, and release the commits after
+	QuiesceCommits(ctx context.Context, in *QuiesceCommitsRequest, opts ...grpc.CallOption) (*QuiesceCommitsResponse, error)
+	ReleaseCommits(ctx context.Context, in *google_protobuf.Empty, opts ...grpc.CallOption) (*ServerStatus, error)
+	// Read the keys most recently written to the state database of a channel
+	// into the caches of the state database, so that the endorsements after a
+	// restart of the peer do not wait for the keys to be read from the disk
+	WarmUpState(ctx context.Context, in *StateWarmUpRequest, opts ...grpc.CallOption) (*StateWarmUpResponse, error)
+	// Return the number of the operations on the state per namespace of a
+	// channel, to attribute the growth of and the load on the ledger to the
+	// chaincodes
+	GetNamespaceStats(ctx context.Context, in *NamespaceStatsRequest, opts ...grpc.CallOption) (*NamespaceStatsResponse, error)
+	// Compact the block index and the history database of the ledger of a
+	// channel, which reduces the read amplification left by a write-heavy
+	// channel without restarting the peer
+	CompactLedger(ctx context.Context, in *LedgerCompactionRequest, opts ...grpc.CallOption) (*LedgerCompactionResponse, error)
+	// Snapshot the system namespaces of the state of a channel, which are
+	// read from the system state database alone
+	SnapshotSystemState(ctx context.Context, in *SystemStateSnapshotRequest, opts ...grpc.CallOption) (*SystemStateSnapshotResponse, error)
+	// Return the most recent transactions of a channel invalidated at commit,
+	// to spot the clients that produce endorsement policy failures or MVCC
+	// conflicts
+	GetInvalidTransactions(ctx context.Context, in *InvalidTransactionsRequest, opts ...grpc.CallOption) (*InvalidTransactionsResponse, error)
 }
 
 type adminClient struct {
@@ -232,6 +744,114 @@ func (c *adminClient) SetModuleLogLevel(ctx context.Context, in *LogLevelRequest
 	return out, nil
 }
 
+func (c *adminClient) EnterMaintenanceMode(ctx context.Context, in *google_protobuf.Empty, opts ...grpc.CallOption) (*ServerStatus, error) {
+	out := new(ServerStatus)
+	err := grpc.Invoke(ctx, "/protos.Admin/EnterMaintenanceMode", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) ExitMaintenanceMode(ctx context.Context, in *google_protobuf.Empty, opts ...grpc.CallOption) (*ServerStatus, error) {
+	out := new(ServerStatus)
+	err := grpc.Invoke(ctx, "/protos.Admin/ExitMaintenanceMode", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) GetLedgerDiskUsage(ctx context.Context, in *LedgerDiskUsageRequest, opts ...grpc.CallOption) (*LedgerDiskUsageResponse, error) {
+	out := new(LedgerDiskUsageResponse)
+	err := grpc.Invoke(ctx, "/protos.Admin/GetLedgerDiskUsage", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) GetBlockFingerprint(ctx context.Context, in *BlockFingerprintRequest, opts ...grpc.CallOption) (*BlockFingerprint, error) {
+	out := new(BlockFingerprint)
+	err := grpc.Invoke(ctx, "/protos.Admin/GetBlockFingerprint", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) GetStateFingerprint(ctx context.Context, in *StateFingerprintRequest, opts ...grpc.CallOption) (*StateFingerprint, error) {
+	out := new(StateFingerprint)
+	err := grpc.Invoke(ctx, "/protos.Admin/GetStateFingerprint", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) QuiesceCommits(ctx context.Context, in *QuiesceCommitsRequest, opts ...grpc.CallOption) (*QuiesceCommitsResponse, error) {
+	out := new(QuiesceCommitsResponse)
+	err := grpc.Invoke(ctx, "/protos.Admin/QuiesceCommits", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) ReleaseCommits(ctx context.Context, in *google_protobuf.Empty, opts ...grpc.CallOption) (*ServerStatus, error) {
+	out := new(ServerStatus)
+	err := grpc.Invoke(ctx, "/protos.Admin/ReleaseCommits", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) WarmUpState(ctx context.Context, in *StateWarmUpRequest, opts ...grpc.CallOption) (*StateWarmUpResponse, error) {
+	out := new(StateWarmUpResponse)
+	err := grpc.Invoke(ctx, "/protos.Admin/WarmUpState", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) GetNamespaceStats(ctx context.Context, in *NamespaceStatsRequest, opts ...grpc.CallOption) (*NamespaceStatsResponse, error) {
+	out := new(NamespaceStatsResponse)
+	err := grpc.Invoke(ctx, "/protos.Admin/GetNamespaceStats", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) CompactLedger(ctx context.Context, in *LedgerCompactionRequest, opts ...grpc.CallOption) (*LedgerCompactionResponse, error) {
+	out := new(LedgerCompactionResponse)
+	err := grpc.Invoke(ctx, "/protos.Admin/CompactLedger", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) SnapshotSystemState(ctx context.Context, in *SystemStateSnapshotRequest, opts ...grpc.CallOption) (*SystemStateSnapshotResponse, error) {
+	out := new(SystemStateSnapshotResponse)
+	err := grpc.Invoke(ctx, "/protos.Admin/SnapshotSystemState", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) GetInvalidTransactions(ctx context.Context, in *InvalidTransactionsRequest, opts ...grpc.CallOption) (*InvalidTransactionsResponse, error) {
+	out := new(InvalidTransactionsResponse)
+	err := grpc.Invoke(ctx, "/protos.Admin/GetInvalidTransactions", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Server API for Admin service
 
 type AdminServer interface {
@@ -241,6 +861,43 @@ type AdminServer interface {
 	StopServer(context.Context, *google_protobuf.Empty) (*ServerStatus, error)
 	GetModuleLogLevel(context.Context, *LogLevelRequest) (*LogLevelResponse, error)
 	SetModuleLogLevel(context.Context, *LogLevelRequest) (*LogLevelResponse, error)
+	// Switch the peer to and from the maintenance mode, in which the
+	// channels remain loaded but commits and endorsements are disabled
+	EnterMaintenanceMode(context.Context, *google_protobuf.Empty) (*ServerStatus, error)
+	ExitMaintenanceMode(context.Context, *google_protobuf.Empty) (*ServerStatus, error)
+	// Return the number of bytes that the ledgers of the channels occupy on
+	// the disk, for the given channel or for all the channels if none is given
+	GetLedgerDiskUsage(context.Context, *LedgerDiskUsageRequest) (*LedgerDiskUsageResponse, error)
+	// Return the hash and the commit hash of a block of the ledger of a channel,
+	// for comparing the ledgers of the peers
+	GetBlockFingerprint(context.Context, *BlockFingerprintRequest) (*BlockFingerprint, error)
+	// Return the hashes of the contents of the state database of a channel per
+	// namespace, for comparing the ledgers of the peers
+	GetStateFingerprint(context.Context, *StateFingerprintRequest) (*StateFingerprint, error)
+	// Block the commits and flush the ledgers of all the channels to the disk, so
+	// that the block store, the state database, and the history database can be
+	// copied by a backup tool as a consistent set, and release the commits after
+	QuiesceCommits(context.Context, *QuiesceCommitsRequest) (*QuiesceCommitsResponse, error)
+	ReleaseCommits(context.Context, *google_protobuf.Empty) (*ServerStatus, error)
+	// Read the keys most recently written to the state database of a channel
+	// into the caches of the state database, so that the endorsements after a
+	// restart of the peer do not wait for the keys to be read from the disk
+	WarmUpState(context.Context, *StateWarmUpRequest) (*StateWarmUpResponse, error)
+	// Return the number of the operations on the state per namespace of a
+	// channel, to attribute the growth of and the load on the ledger to the
+	// chaincodes
+	GetNamespaceStats(context.Context, *NamespaceStatsRequest) (*NamespaceStatsResponse, error)
+	// Compact the block index and the history database of the ledger of a
+	// channel, which reduces the read amplification left by a write-heavy
+	// channel without restarting the peer
+	CompactLedger(context.Context, *LedgerCompactionRequest) (*LedgerCompactionResponse, error)
+	// Snapshot the system namespaces of the state of a channel, which are
+	// read from the system state database alone
+	SnapshotSystemState(context.Context, *SystemStateSnapshotRequest) (*SystemStateSnapshotResponse, error)
+	// Return the most recent transactions of a channel invalidated at commit,
+	// to spot the clients that produce endorsement policy failures or MVCC
+	// conflicts
+	GetInvalidTransactions(context.Context, *InvalidTransactionsRequest) (*InvalidTransactionsResponse, error)
 }
 
 func RegisterAdminServer(s *grpc.Server, srv AdminServer) {
@@ -337,6 +994,222 @@ func _Admin_SetModuleLogLevel_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Admin_EnterMaintenanceMode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(google_protobuf.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).EnterMaintenanceMode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protos.Admin/EnterMaintenanceMode",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).EnterMaintenanceMode(ctx, req.(*google_protobuf.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_ExitMaintenanceMode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(google_protobuf.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).ExitMaintenanceMode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protos.Admin/ExitMaintenanceMode",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).ExitMaintenanceMode(ctx, req.(*google_protobuf.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_GetLedgerDiskUsage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LedgerDiskUsageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).GetLedgerDiskUsage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protos.Admin/GetLedgerDiskUsage",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).GetLedgerDiskUsage(ctx, req.(*LedgerDiskUsageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_GetBlockFingerprint_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BlockFingerprintRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).GetBlockFingerprint(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protos.Admin/GetBlockFingerprint",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).GetBlockFingerprint(ctx, req.(*BlockFingerprintRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_GetStateFingerprint_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StateFingerprintRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).GetStateFingerprint(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protos.Admin/GetStateFingerprint",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).GetStateFingerprint(ctx, req.(*StateFingerprintRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_QuiesceCommits_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QuiesceCommitsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).QuiesceCommits(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protos.Admin/QuiesceCommits",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).QuiesceCommits(ctx, req.(*QuiesceCommitsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_ReleaseCommits_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(google_protobuf.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).ReleaseCommits(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protos.Admin/ReleaseCommits",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).ReleaseCommits(ctx, req.(*google_protobuf.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_WarmUpState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StateWarmUpRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).WarmUpState(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protos.Admin/WarmUpState",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).WarmUpState(ctx, req.(*StateWarmUpRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_GetNamespaceStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NamespaceStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).GetNamespaceStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protos.Admin/GetNamespaceStats",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).GetNamespaceStats(ctx, req.(*NamespaceStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_CompactLedger_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LedgerCompactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).CompactLedger(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protos.Admin/CompactLedger",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).CompactLedger(ctx, req.(*LedgerCompactionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_SnapshotSystemState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SystemStateSnapshotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).SnapshotSystemState(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protos.Admin/SnapshotSystemState",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).SnapshotSystemState(ctx, req.(*SystemStateSnapshotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_GetInvalidTransactions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InvalidTransactionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).GetInvalidTransactions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protos.Admin/GetInvalidTransactions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).GetInvalidTransactions(ctx, req.(*InvalidTransactionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _Admin_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "protos.Admin",
 	HandlerType: (*AdminServer)(nil),
@@ -361,6 +1234,54 @@ var _Admin_serviceDesc = grpc.ServiceDesc{
 			MethodName: "SetModuleLogLevel",
 			Handler:    _Admin_SetModuleLogLevel_Handler,
 		},
+		{
+			MethodName: "EnterMaintenanceMode",
+			Handler:    _Admin_EnterMaintenanceMode_Handler,
+		},
+		{
+			MethodName: "ExitMaintenanceMode",
+			Handler:    _Admin_ExitMaintenanceMode_Handler,
+		},
+		{
+			MethodName: "GetLedgerDiskUsage",
+			Handler:    _Admin_GetLedgerDiskUsage_Handler,
+		},
+		{
+			MethodName: "GetBlockFingerprint",
+			Handler:    _Admin_GetBlockFingerprint_Handler,
+		},
+		{
+			MethodName: "GetStateFingerprint",
+			Handler:    _Admin_GetStateFingerprint_Handler,
+		},
+		{
+			MethodName: "QuiesceCommits",
+			Handler:    _Admin_QuiesceCommits_Handler,
+		},
+		{
+			MethodName: "ReleaseCommits",
+			Handler:    _Admin_ReleaseCommits_Handler,
+		},
+		{
+			MethodName: "WarmUpState",
+			Handler:    _Admin_WarmUpState_Handler,
+		},
+		{
+			MethodName: "GetNamespaceStats",
+			Handler:    _Admin_GetNamespaceStats_Handler,
+		},
+		{
+			MethodName: "CompactLedger",
+			Handler:    _Admin_CompactLedger_Handler,
+		},
+		{
+			MethodName: "SnapshotSystemState",
+			Handler:    _Admin_SnapshotSystemState_Handler,
+		},
+		{
+			MethodName: "GetInvalidTransactions",
+			Handler:    _Admin_GetInvalidTransactions_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: fileDescriptor0,
@@ -369,30 +1290,99 @@ var _Admin_serviceDesc = grpc.ServiceDesc{
 func init() { proto.RegisterFile("peer/admin.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 388 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0xac, 0x92, 0xdf, 0x8e, 0xd2, 0x40,
-	0x14, 0xc6, 0x29, 0x0a, 0xda, 0x83, 0x7f, 0xc6, 0x89, 0x51, 0x02, 0x31, 0x9a, 0x5e, 0x69, 0x4c,
-	0xda, 0x04, 0x2f, 0xbc, 0x50, 0x2f, 0xd0, 0x56, 0x34, 0x42, 0x21, 0x2d, 0xc4, 0xe8, 0x0d, 0x69,
-	0xe9, 0xa1, 0x90, 0x4c, 0x99, 0xee, 0xcc, 0x94, 0x84, 0xd7, 0xd9, 0x77, 0xda, 0xf7, 0xd9, 0xb4,
-	0x03, 0x61, 0xb3, 0xbb, 0x37, 0xfb, 0xe7, 0xea, 0xf4, 0x9c, 0xf3, 0x9d, 0x2f, 0x9d, 0x5f, 0x3e,
-	0x20, 0x39, 0xa2, 0x70, 0xa2, 0x24, 0x5b, 0x6f, 0xec, 0x5c, 0x70, 0xc5, 0x69, 0xb3, 0x2a, 0xb2,
-	0xd3, 0x4d, 0x39, 0x4f, 0x19, 0x3a, 0x55, 0x1b, 0x17, 0x4b, 0x07, 0xb3, 0x5c, 0xed, 0xb4, 0xc8,
-	0x3a, 0x35, 0xe0, 0x49, 0x88, 0x62, 0x8b, 0x22, 0x54, 0x91, 0x2a, 0x24, 0xfd, 0x0c, 0x4d, 0x59,
-	0x7d, 0xb5, 0x8d, 0x77, 0xc6, 0xfb, 0x67, 0xbd, 0xb7, 0x5a, 0x28, 0xed, 0x8b, 0x2a, 0x5b, 0x97,
-	0x1f, 0x3c, 0xc1, 0x60, 0x2f, 0xb7, 0xfe, 0x01, 0x1c, 0xa7, 0xf4, 0x29, 0x98, 0x33, 0xdf, 0xf5,
-	0x7e, 0xfe, 0xf6, 0x3d, 0x97, 0xd4, 0x68, 0x0b, 0x1e, 0x85, 0xd3, 0x7e, 0x30, 0xf5, 0x5c, 0x62,
-	0xe8, 0x66, 0x3c, 0x99, 0x78, 0x2e, 0xa9, 0x53, 0x80, 0xe6, 0xa4, 0x3f, 0x0b, 0x3d, 0x97, 0x3c,
-	0xa0, 0x26, 0x34, 0xbc, 0x20, 0x18, 0x07, 0xe4, 0x61, 0xa9, 0x99, 0xf9, 0x7f, 0xfc, 0xf1, 0x5f,
-	0x9f, 0x34, 0xac, 0x11, 0x3c, 0x1f, 0xf2, 0x74, 0x88, 0x5b, 0x64, 0x01, 0x9e, 0x14, 0x28, 0x15,
-	0x7d, 0x03, 0xc0, 0x78, 0x3a, 0xcf, 0x78, 0x52, 0x30, 0xac, 0x7e, 0xd5, 0x0c, 0x4c, 0xc6, 0xd3,
-	0x51, 0x35, 0xa0, 0x5d, 0x28, 0x9b, 0x39, 0x2b, 0x4f, 0xda, 0xf5, 0x6a, 0xfb, 0x98, 0xed, 0x2d,
-	0x2c, 0x1f, 0xc8, 0xd1, 0x4e, 0xe6, 0x7c, 0x23, 0xf1, 0x2e, 0x7e, 0xbd, 0xb3, 0x3a, 0x34, 0xfa,
-	0x25, 0x78, 0xfa, 0x05, 0xcc, 0x01, 0xaa, 0x3d, 0xc9, 0x57, 0xb6, 0x06, 0x6f, 0x1f, 0xc0, 0xdb,
-	0x5e, 0x09, 0xbe, 0xf3, 0xf2, 0x3a, 0xa2, 0x56, 0x8d, 0x7e, 0x83, 0x56, 0xa8, 0x22, 0xa1, 0xf4,
-	0xf8, 0xc6, 0xe7, 0x5f, 0x4b, 0xfe, 0x3c, 0xbf, 0xe5, 0xf5, 0x2f, 0x78, 0x31, 0x40, 0xa5, 0x5f,
-	0x7b, 0x80, 0x43, 0x5f, 0x1f, 0xc4, 0x97, 0xe8, 0x77, 0xda, 0x57, 0x17, 0x9a, 0xa3, 0x76, 0x0a,
-	0xef, 0xc5, 0xe9, 0xfb, 0xc7, 0xff, 0x1f, 0xd2, 0xb5, 0x5a, 0x15, 0xb1, 0xbd, 0xe0, 0x99, 0xb3,
-	0xda, 0xe5, 0x28, 0x18, 0x26, 0x29, 0x0a, 0x67, 0x19, 0xc5, 0x62, 0xbd, 0xd0, 0x89, 0x96, 0x4e,
-	0x99, 0xfc, 0x58, 0xa7, 0xfd, 0xd3, 0x79, 0x00, 0x00, 0x00, 0xff, 0xff, 0xaa, 0x24, 0x41, 0xca,
-	0x08, 0x03, 0x00, 0x00,
+	// 1499 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x58, 0x5d, 0x6f, 0x13, 0x47,
+	0x17, 0x8e, 0x49, 0xe2, 0xe0, 0x13, 0xc7, 0x5e, 0x26, 0x89, 0xe3, 0xd7, 0x01, 0x02, 0x03, 0x7a,
+	0xa1, 0x14, 0x39, 0x6a, 0x2a, 0x01, 0x15, 0x6d, 0x45, 0x20, 0x26, 0x44, 0x4d, 0x4c, 0x58, 0x27,
+	0xa2, 0xa5, 0x95, 0xdc, 0xf5, 0xee, 0xc1, 0x5e, 0xb1, 0x5f, 0xec, 0x8c, 0x69, 0xd2, 0x9f, 0xd3,
+	0xeb, 0x5e, 0xf4, 0xae, 0xff, 0xa1, 0x7f, 0xa9, 0x37, 0xd5, 0x7c, 0xec, 0xda, 0x5e, 0xdb, 0x24,
+	0x29, 0xbd, 0x4a, 0xe6, 0x99, 0xe7, 0x9c, 0x39, 0xdf, 0x3b, 0x63, 0x30, 0x22, 0xc4, 0x78, 0xd3,
+	0x72, 0x7c, 0x37, 0xa8, 0x47, 0x71, 0xc8, 0x43, 0x92, 0x97, 0x7f, 0x58, 0x6d, 0xbd, 0x1b, 0x86,
+	0x5d, 0x0f, 0x37, 0xe5, 0xb2, 0xd3, 0x7f, 0xbb, 0x89, 0x7e, 0xc4, 0x4f, 0x15, 0x89, 0xfe, 0x96,
+	0x83, 0x62, 0x0b, 0xe3, 0x0f, 0x18, 0xb7, 0xb8, 0xc5, 0xfb, 0x8c, 0x3c, 0x84, 0x3c, 0x93, 0xff,
+	0x55, 0x73, 0x37, 0x72, 0x77, 0x4b, 0x5b, 0x1b, 0x8a, 0xc8, 0xea, 0xc3, 0xac, 0xba, 0xfa, 0xf3,
+	0x2c, 0x74, 0xd0, 0xd4, 0x74, 0xfa, 0x03, 0xc0, 0x00, 0x25, 0x4b, 0x50, 0x38, 0x6e, 0xee, 0x34,
+	0x9e, 0xef, 0x35, 0x1b, 0x3b, 0xc6, 0x0c, 0x59, 0x84, 0x85, 0xd6, 0xd1, 0xb6, 0x79, 0xd4, 0xd8,
+	0x31, 0x72, 0x6a, 0xf1, 0xf2, 0xf0, 0xb0, 0xb1, 0x63, 0x5c, 0x22, 0x00, 0xf9, 0xc3, 0xed, 0xe3,
+	0x56, 0x63, 0xc7, 0x98, 0x25, 0x05, 0x98, 0x6f, 0x98, 0xe6, 0x4b, 0xd3, 0x98, 0x13, 0x9c, 0xe3,
+	0xe6, 0x77, 0xcd, 0x97, 0xaf, 0x9b, 0xc6, 0x3c, 0x3d, 0x80, 0xf2, 0x7e, 0xd8, 0xdd, 0xc7, 0x0f,
+	0xe8, 0x99, 0xf8, 0xbe, 0x8f, 0x8c, 0x93, 0x6b, 0x00, 0x5e, 0xd8, 0x6d, 0xfb, 0xa1, 0xd3, 0xf7,
+	0x50, 0x9a, 0x5a, 0x30, 0x0b, 0x5e, 0xd8, 0x3d, 0x90, 0x00, 0x59, 0x07, 0xb1, 0x68, 0x7b, 0x42,
+	0xa4, 0x7a, 0x49, 0xee, 0x5e, 0xf6, 0xb4, 0x0a, 0xda, 0x04, 0x63, 0xa0, 0x8e, 0x45, 0x61, 0xc0,
+	0xf0, 0x93, 0xf4, 0x3d, 0x84, 0xca, 0x3e, 0x3a, 0x5d, 0x8c, 0x77, 0x5c, 0xf6, 0xee, 0x98, 0x59,
+	0x5d, 0x1c, 0xb2, 0xd2, 0xee, 0x59, 0x41, 0x80, 0x5e, 0xdb, 0x75, 0x12, 0xad, 0x1a, 0xd9, 0x73,
+	0xe8, 0xef, 0x39, 0x28, 0x67, 0x24, 0xcf, 0x10, 0x21, 0xf7, 0xe0, 0x4a, 0xc7, 0x0b, 0xed, 0x77,
+	0x6d, 0xc6, 0xc3, 0x18, 0xdb, 0x9d, 0x53, 0x8e, 0x4c, 0x1a, 0x34, 0x67, 0x96, 0xe5, 0x46, 0x4b,
+	0xe0, 0x4f, 0x05, 0x4c, 0x6e, 0x43, 0x49, 0xe4, 0x06, 0xdb, 0x4e, 0x47, 0x13, 0x67, 0x25, 0xb1,
+	0x28, 0xd1, 0x9d, 0x8e, 0x62, 0xdd, 0x05, 0xa3, 0xe7, 0x0a, 0x6d, 0xa7, 0x03, 0xde, 0x9c, 0xe4,
+	0x95, 0x34, 0xae, 0x99, 0x74, 0x1f, 0xd6, 0xc6, 0xfc, 0xd4, 0xe1, 0xfb, 0x02, 0x16, 0x3c, 0xb9,
+	0x25, 0xca, 0x66, 0xf6, 0xee, 0xe2, 0xd6, 0x5a, 0x52, 0x36, 0x59, 0x89, 0x84, 0x47, 0x7f, 0x84,
+	0xb5, 0xa7, 0xc2, 0xe0, 0xe7, 0x6e, 0xd0, 0xc5, 0x38, 0x8a, 0xdd, 0x80, 0x9f, 0x2f, 0x6c, 0xe4,
+	0x26, 0x14, 0x55, 0x0c, 0x82, 0xbe, 0xdf, 0xc1, 0x58, 0xbb, 0xbf, 0x28, 0xb1, 0xa6, 0x84, 0x68,
+	0x1f, 0x8c, 0xac, 0xf2, 0x31, 0xb1, 0xdc, 0x98, 0x98, 0x38, 0x58, 0x51, 0x7a, 0x16, 0xeb, 0x49,
+	0xbd, 0x45, 0xb3, 0x20, 0x91, 0x17, 0x16, 0xeb, 0x91, 0x0d, 0x58, 0xb4, 0x43, 0xdf, 0x77, 0xb9,
+	0xda, 0x9f, 0x95, 0xfb, 0xa0, 0x20, 0x41, 0xa0, 0x8f, 0x60, 0x4d, 0xf4, 0x00, 0x5e, 0xd8, 0x27,
+	0xea, 0x81, 0x91, 0x95, 0x24, 0x15, 0xc8, 0xf7, 0xd0, 0xed, 0xf6, 0xb8, 0x36, 0x55, 0xaf, 0xc8,
+	0x13, 0x30, 0x02, 0xcb, 0x47, 0x16, 0x59, 0x36, 0x4a, 0x4b, 0x64, 0x09, 0x88, 0xa8, 0xaf, 0x26,
+	0x51, 0x6f, 0x26, 0xfb, 0xc2, 0x2c, 0xb3, 0x1c, 0x0c, 0x2f, 0x91, 0xd1, 0x6d, 0x58, 0x1a, 0x61,
+	0x90, 0xab, 0x50, 0x48, 0x39, 0x89, 0x71, 0x29, 0x40, 0x08, 0xcc, 0x0d, 0x05, 0x44, 0xfe, 0x4f,
+	0x9f, 0xc0, 0xea, 0xab, 0xbe, 0x8b, 0xcc, 0xc6, 0x67, 0xd2, 0x7f, 0x96, 0x38, 0x7a, 0x07, 0xca,
+	0xdc, 0xf5, 0x31, 0xec, 0xf3, 0x36, 0x43, 0x3b, 0x0c, 0x1c, 0x35, 0x49, 0x96, 0xcc, 0x92, 0x86,
+	0x5b, 0x0a, 0xa5, 0x7f, 0xe4, 0x60, 0x49, 0x55, 0xc7, 0x0b, 0xe9, 0x17, 0x3b, 0x2b, 0xef, 0xf7,
+	0x81, 0x0c, 0xd7, 0xbe, 0x8e, 0x8d, 0xca, 0xbe, 0x31, 0x28, 0x7e, 0xa5, 0x8d, 0xfc, 0x1f, 0xca,
+	0x69, 0xf5, 0x6b, 0xaa, 0x2a, 0xff, 0x25, 0x5d, 0xfe, 0x9a, 0x77, 0x0f, 0xae, 0x0c, 0xd5, 0xbf,
+	0x66, 0xaa, 0x06, 0x28, 0xa7, 0x0d, 0xa0, 0xb8, 0x74, 0x0f, 0x2a, 0x59, 0xa7, 0x75, 0x03, 0x6c,
+	0x66, 0x1b, 0x60, 0x75, 0xb4, 0x01, 0xb4, 0x8b, 0x83, 0xf2, 0xb7, 0x80, 0xc8, 0x84, 0xbf, 0xb6,
+	0x62, 0xff, 0x38, 0x3a, 0x67, 0xe5, 0xdf, 0x07, 0xf2, 0x0e, 0x4f, 0x59, 0x3b, 0xc2, 0xb8, 0x3d,
+	0xc8, 0xd7, 0x25, 0x19, 0x5e, 0x43, 0xec, 0x1c, 0x62, 0x9c, 0x26, 0x96, 0x36, 0xa1, 0xa8, 0x0e,
+	0x57, 0x67, 0x9c, 0xa5, 0x7c, 0x03, 0x16, 0xa5, 0x72, 0x2f, 0xb4, 0x1c, 0x74, 0x74, 0x5c, 0x41,
+	0x40, 0xfb, 0x12, 0xa1, 0x0d, 0x58, 0x1e, 0x31, 0x59, 0xbb, 0x5e, 0xcf, 0xba, 0xbe, 0x32, 0xea,
+	0xba, 0xa6, 0xa7, 0x9e, 0x3f, 0x80, 0xd5, 0xd4, 0x46, 0xa1, 0x8f, 0x9d, 0xb3, 0x45, 0xfe, 0xce,
+	0x41, 0x69, 0x54, 0xf0, 0x8c, 0xb2, 0xad, 0x40, 0x5e, 0x56, 0x45, 0x32, 0x20, 0xf5, 0x8a, 0xac,
+	0xc0, 0x7c, 0x8c, 0x96, 0x93, 0x8c, 0x43, 0xb5, 0x20, 0xb7, 0x60, 0x29, 0xb6, 0x82, 0x2e, 0xb6,
+	0xdf, 0xf7, 0x31, 0x76, 0xd3, 0x21, 0x58, 0x94, 0xe0, 0x2b, 0x85, 0x89, 0x19, 0x12, 0xbb, 0x76,
+	0x2f, 0xe5, 0xcc, 0xab, 0x19, 0x22, 0xb0, 0x84, 0x52, 0x81, 0xfc, 0x2f, 0xb1, 0x2b, 0xa6, 0x68,
+	0x5e, 0x9d, 0xaa, 0x56, 0xa4, 0x0a, 0x0b, 0x0e, 0x7a, 0x28, 0x36, 0x16, 0xe4, 0x46, 0xb2, 0x14,
+	0x27, 0x0b, 0x0e, 0xc7, 0x40, 0x8f, 0xdf, 0xcb, 0xea, 0x64, 0x0d, 0xaa, 0xe1, 0xeb, 0xc3, 0x8a,
+	0x0a, 0x67, 0x26, 0x04, 0x67, 0x24, 0xf5, 0x01, 0x40, 0x1a, 0x90, 0x64, 0x4a, 0x54, 0xc6, 0xa6,
+	0x84, 0x4a, 0xc3, 0x10, 0x93, 0x1e, 0x42, 0x25, 0xb3, 0x9b, 0xa4, 0xfb, 0x41, 0x36, 0xdd, 0x57,
+	0x47, 0xd3, 0x9d, 0x11, 0x4b, 0xd3, 0xfe, 0x28, 0xf9, 0x7a, 0x3c, 0x0b, 0xfd, 0xc8, 0xb2, 0xb9,
+	0x1b, 0x06, 0xe7, 0x4c, 0xfc, 0x1b, 0x30, 0xb2, 0x92, 0x67, 0xb9, 0x7d, 0x07, 0xca, 0x4e, 0x3f,
+	0xb6, 0x04, 0xb5, 0xed, 0xbb, 0x9e, 0xe7, 0x26, 0x35, 0x50, 0x4a, 0xe0, 0x03, 0x89, 0xd2, 0x26,
+	0x54, 0xc7, 0xad, 0xd2, 0x9e, 0x6e, 0x65, 0x3d, 0xad, 0x8e, 0x7a, 0x3a, 0x24, 0x92, 0x7a, 0xf9,
+	0x18, 0x6a, 0xad, 0x53, 0xc6, 0xd1, 0x97, 0x9d, 0xd2, 0x0a, 0xac, 0x88, 0xf5, 0xc2, 0xf3, 0x7e,
+	0x04, 0xfa, 0xb0, 0x3c, 0x41, 0xf8, 0xd3, 0x3f, 0x87, 0xa2, 0x4f, 0x1c, 0x37, 0x46, 0x5b, 0x0c,
+	0x33, 0x59, 0xf5, 0x05, 0x73, 0x00, 0xd0, 0xef, 0x61, 0x7d, 0xa2, 0xcd, 0x3a, 0x0c, 0x5f, 0x41,
+	0x81, 0x69, 0x2c, 0x09, 0xc4, 0x7a, 0x7a, 0x29, 0x9c, 0x20, 0x37, 0x60, 0xd3, 0x9f, 0xa0, 0xb6,
+	0x17, 0x7c, 0xb0, 0x3c, 0xd7, 0x39, 0x8a, 0xad, 0x80, 0xa9, 0x60, 0x9d, 0xb3, 0xdf, 0xc5, 0x3c,
+	0xf2, 0xad, 0x93, 0x36, 0x06, 0x5c, 0xb6, 0x9a, 0x9a, 0x72, 0xe0, 0x5b, 0x27, 0x0d, 0x85, 0xd0,
+	0xbf, 0x72, 0x40, 0xc6, 0xd5, 0x93, 0x65, 0x98, 0xe7, 0x27, 0x03, 0x8d, 0x73, 0xfc, 0x64, 0xcf,
+	0x11, 0x17, 0xb8, 0x34, 0x48, 0x3a, 0x42, 0x97, 0x93, 0x08, 0x91, 0x55, 0xc8, 0xf3, 0x13, 0xb9,
+	0xa3, 0x27, 0x02, 0x3f, 0x11, 0xf0, 0x1d, 0x28, 0x4b, 0xe5, 0xaa, 0x8c, 0xec, 0xd0, 0x41, 0x39,
+	0x13, 0x0a, 0x66, 0x69, 0x00, 0xcb, 0xcb, 0xee, 0x6d, 0x28, 0xd9, 0x31, 0x5a, 0x3c, 0x8c, 0xdb,
+	0x3e, 0x8b, 0xc4, 0xd1, 0xf3, 0x92, 0x57, 0xd4, 0xe8, 0x01, 0x8b, 0xf6, 0x1c, 0x91, 0x04, 0xbb,
+	0x67, 0xb9, 0x81, 0x54, 0x94, 0x4f, 0xbd, 0x55, 0x00, 0xfd, 0x15, 0xfe, 0xa7, 0xaa, 0x6a, 0x42,
+	0xc0, 0xce, 0x8a, 0xd4, 0xb7, 0x50, 0xe4, 0x43, 0x74, 0xdd, 0xe6, 0xb5, 0x24, 0x49, 0xe3, 0x1a,
+	0xcd, 0x11, 0x3e, 0x7d, 0x03, 0xeb, 0x13, 0xd3, 0xa4, 0x0b, 0xe0, 0x71, 0xb6, 0x0f, 0x6e, 0x8e,
+	0xf6, 0xc1, 0x24, 0xd9, 0x44, 0x62, 0xeb, 0x4f, 0x80, 0xf9, 0x6d, 0xf1, 0x2a, 0x21, 0x8f, 0xa1,
+	0xb0, 0x8b, 0x5c, 0x3f, 0x33, 0x2a, 0x75, 0xf5, 0x2a, 0xa9, 0x27, 0xaf, 0x92, 0x7a, 0x43, 0xbc,
+	0x4a, 0x6a, 0x2b, 0x93, 0x9e, 0x1b, 0x74, 0x86, 0x7c, 0x03, 0x8b, 0x2d, 0x6e, 0xc5, 0x5c, 0xc1,
+	0x17, 0x16, 0xff, 0x5a, 0x3c, 0x4e, 0xc2, 0xe8, 0x5f, 0x4a, 0xbf, 0x80, 0x2b, 0xbb, 0xc8, 0xd5,
+	0x53, 0x20, 0x79, 0x39, 0x90, 0xc1, 0x0d, 0x77, 0xf4, 0x69, 0x52, 0xab, 0x8e, 0x6f, 0xa8, 0x40,
+	0x2a, 0x4d, 0xad, 0xff, 0x46, 0xd3, 0x73, 0x58, 0x69, 0x04, 0x1c, 0xe3, 0x03, 0xcb, 0x0d, 0x38,
+	0x06, 0x56, 0x60, 0xe3, 0x81, 0xa8, 0xc5, 0x8b, 0xfa, 0xd6, 0x80, 0xe5, 0xc6, 0x89, 0xcb, 0x3f,
+	0x55, 0xcd, 0x6b, 0x20, 0xbb, 0xc8, 0xb3, 0x8f, 0x99, 0xeb, 0xd3, 0x5e, 0x01, 0xda, 0xc1, 0x8d,
+	0xa9, 0xfb, 0xa9, 0x9f, 0x26, 0x2c, 0xef, 0x22, 0x1f, 0xbb, 0xcc, 0xa7, 0x92, 0x53, 0xde, 0x10,
+	0xb5, 0xea, 0x34, 0x42, 0xaa, 0x73, 0xec, 0xbe, 0x3d, 0x78, 0xea, 0x4e, 0xbe, 0xc3, 0xd7, 0xaa,
+	0xd3, 0x08, 0x74, 0x86, 0xbc, 0x82, 0xd2, 0xe8, 0xd5, 0x90, 0x5c, 0x4b, 0xd8, 0x13, 0xef, 0xc9,
+	0xb5, 0xeb, 0xd3, 0xb6, 0x53, 0xd7, 0x9f, 0x40, 0xc9, 0x44, 0x0f, 0x2d, 0x96, 0xaa, 0xbc, 0x78,
+	0xe1, 0x2e, 0xaa, 0xdb, 0x97, 0x34, 0x98, 0xd4, 0x46, 0xec, 0x1f, 0xb9, 0x79, 0xd6, 0xd6, 0x27,
+	0xee, 0xa5, 0xb6, 0x1c, 0xc9, 0x16, 0xc8, 0xde, 0x3d, 0xa6, 0x5c, 0x24, 0xb2, 0x1e, 0x4e, 0xbe,
+	0x49, 0x48, 0xad, 0x4b, 0xfa, 0x23, 0xaa, 0x0a, 0x80, 0x6c, 0x4c, 0xfd, 0xc2, 0x6a, 0x9d, 0x37,
+	0xa6, 0x13, 0x52, 0xad, 0x3f, 0xc3, 0x72, 0xf2, 0x31, 0x1a, 0xfa, 0x3e, 0x11, 0xfa, 0xb1, 0x8f,
+	0x96, 0x56, 0x7f, 0xeb, 0xa3, 0x9c, 0xf4, 0x04, 0x1b, 0x2a, 0xbb, 0xc8, 0x27, 0x4d, 0x6a, 0x3a,
+	0x7d, 0xe8, 0xb2, 0xb1, 0x43, 0x3e, 0x32, 0x74, 0xe9, 0xcc, 0xd3, 0xcf, 0xdf, 0x7c, 0xd6, 0x75,
+	0x79, 0xaf, 0xdf, 0xa9, 0xdb, 0xa1, 0xbf, 0xd9, 0x3b, 0x8d, 0x30, 0x56, 0x43, 0x75, 0xf3, 0xad,
+	0xd5, 0x89, 0x5d, 0x5b, 0xfd, 0xa0, 0xc3, 0x36, 0x23, 0xc4, 0xb8, 0xa3, 0x7e, 0xec, 0xf9, 0xf2,
+	0x9f, 0x01, 0x00, 0x24, 0x87, 0xe9, 0xe0, 0x07, 0x12, 0x00, 0x00,
 }
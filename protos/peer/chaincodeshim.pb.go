@@ -22,25 +22,29 @@ var _ = math.Inf
 type ChaincodeMessage_Type int32
 
 const (
-	ChaincodeMessage_UNDEFINED           ChaincodeMessage_Type = 0
-	ChaincodeMessage_REGISTER            ChaincodeMessage_Type = 1
-	ChaincodeMessage_REGISTERED          ChaincodeMessage_Type = 2
-	ChaincodeMessage_INIT                ChaincodeMessage_Type = 3
-	ChaincodeMessage_READY               ChaincodeMessage_Type = 4
-	ChaincodeMessage_TRANSACTION         ChaincodeMessage_Type = 5
-	ChaincodeMessage_COMPLETED           ChaincodeMessage_Type = 6
-	ChaincodeMessage_ERROR               ChaincodeMessage_Type = 7
-	ChaincodeMessage_GET_STATE           ChaincodeMessage_Type = 8
-	ChaincodeMessage_PUT_STATE           ChaincodeMessage_Type = 9
-	ChaincodeMessage_DEL_STATE           ChaincodeMessage_Type = 10
-	ChaincodeMessage_INVOKE_CHAINCODE    ChaincodeMessage_Type = 11
-	ChaincodeMessage_RESPONSE            ChaincodeMessage_Type = 13
-	ChaincodeMessage_GET_STATE_BY_RANGE  ChaincodeMessage_Type = 14
-	ChaincodeMessage_GET_QUERY_RESULT    ChaincodeMessage_Type = 15
-	ChaincodeMessage_QUERY_STATE_NEXT    ChaincodeMessage_Type = 16
-	ChaincodeMessage_QUERY_STATE_CLOSE   ChaincodeMessage_Type = 17
-	ChaincodeMessage_KEEPALIVE           ChaincodeMessage_Type = 18
-	ChaincodeMessage_GET_HISTORY_FOR_KEY ChaincodeMessage_Type = 19
+	ChaincodeMessage_UNDEFINED                ChaincodeMessage_Type = 0
+	ChaincodeMessage_REGISTER                 ChaincodeMessage_Type = 1
+	ChaincodeMessage_REGISTERED               ChaincodeMessage_Type = 2
+	ChaincodeMessage_INIT                     ChaincodeMessage_Type = 3
+	ChaincodeMessage_READY                    ChaincodeMessage_Type = 4
+	ChaincodeMessage_TRANSACTION              ChaincodeMessage_Type = 5
+	ChaincodeMessage_COMPLETED                ChaincodeMessage_Type = 6
+	ChaincodeMessage_ERROR                    ChaincodeMessage_Type = 7
+	ChaincodeMessage_GET_STATE                ChaincodeMessage_Type = 8
+	ChaincodeMessage_PUT_STATE                ChaincodeMessage_Type = 9
+	ChaincodeMessage_DEL_STATE                ChaincodeMessage_Type = 10
+	ChaincodeMessage_INVOKE_CHAINCODE         ChaincodeMessage_Type = 11
+	ChaincodeMessage_RESPONSE                 ChaincodeMessage_Type = 13
+	ChaincodeMessage_GET_STATE_BY_RANGE       ChaincodeMessage_Type = 14
+	ChaincodeMessage_GET_QUERY_RESULT         ChaincodeMessage_Type = 15
+	ChaincodeMessage_QUERY_STATE_NEXT         ChaincodeMessage_Type = 16
+	ChaincodeMessage_QUERY_STATE_CLOSE        ChaincodeMessage_Type = 17
+	ChaincodeMessage_KEEPALIVE                ChaincodeMessage_Type = 18
+	ChaincodeMessage_GET_HISTORY_FOR_KEY      ChaincodeMessage_Type = 19
+	ChaincodeMessage_GET_TOTAL_FOR_KEY_PREFIX ChaincodeMessage_Type = 20
+	ChaincodeMessage_GET_STATE_MULTIPLE       ChaincodeMessage_Type = 21
+	ChaincodeMessage_DEL_STATE_BY_RANGE       ChaincodeMessage_Type = 22
+	ChaincodeMessage_GET_STATE_HASH           ChaincodeMessage_Type = 23
 )
 
 var ChaincodeMessage_Type_name = map[int32]string{
@@ -63,27 +67,35 @@ var ChaincodeMessage_Type_name = map[int32]string{
 	17: "QUERY_STATE_CLOSE",
 	18: "KEEPALIVE",
 	19: "GET_HISTORY_FOR_KEY",
+	20: "GET_TOTAL_FOR_KEY_PREFIX",
+	21: "GET_STATE_MULTIPLE",
+	22: "DEL_STATE_BY_RANGE",
+	23: "GET_STATE_HASH",
 }
 var ChaincodeMessage_Type_value = map[string]int32{
-	"UNDEFINED":           0,
-	"REGISTER":            1,
-	"REGISTERED":          2,
-	"INIT":                3,
-	"READY":               4,
-	"TRANSACTION":         5,
-	"COMPLETED":           6,
-	"ERROR":               7,
-	"GET_STATE":           8,
-	"PUT_STATE":           9,
-	"DEL_STATE":           10,
-	"INVOKE_CHAINCODE":    11,
-	"RESPONSE":            13,
-	"GET_STATE_BY_RANGE":  14,
-	"GET_QUERY_RESULT":    15,
-	"QUERY_STATE_NEXT":    16,
-	"QUERY_STATE_CLOSE":   17,
-	"KEEPALIVE":           18,
-	"GET_HISTORY_FOR_KEY": 19,
+	"UNDEFINED":                0,
+	"REGISTER":                 1,
+	"REGISTERED":               2,
+	"INIT":                     3,
+	"READY":                    4,
+	"TRANSACTION":              5,
+	"COMPLETED":                6,
+	"ERROR":                    7,
+	"GET_STATE":                8,
+	"PUT_STATE":                9,
+	"DEL_STATE":                10,
+	"INVOKE_CHAINCODE":         11,
+	"RESPONSE":                 13,
+	"GET_STATE_BY_RANGE":       14,
+	"GET_QUERY_RESULT":         15,
+	"QUERY_STATE_NEXT":         16,
+	"QUERY_STATE_CLOSE":        17,
+	"KEEPALIVE":                18,
+	"GET_HISTORY_FOR_KEY":      19,
+	"GET_TOTAL_FOR_KEY_PREFIX": 20,
+	"GET_STATE_MULTIPLE":       21,
+	"DEL_STATE_BY_RANGE":       22,
+	"GET_STATE_HASH":           23,
 }
 
 func (x ChaincodeMessage_Type) String() string {
@@ -132,6 +144,8 @@ func (m *ChaincodeMessage) GetChaincodeEvent() *ChaincodeEvent {
 type PutStateInfo struct {
 	Key   string `protobuf:"bytes,1,opt,name=key" json:"key,omitempty"`
 	Value []byte `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	// number of blocks after which the key expires. Zero means that the key does not expire
+	Ttl uint64 `protobuf:"varint,3,opt,name=ttl" json:"ttl,omitempty"`
 }
 
 func (m *PutStateInfo) Reset()                    { *m = PutStateInfo{} }
@@ -142,6 +156,8 @@ func (*PutStateInfo) Descriptor() ([]byte, []int) { return fileDescriptor3, []in
 type GetStateByRange struct {
 	StartKey string `protobuf:"bytes,1,opt,name=startKey" json:"startKey,omitempty"`
 	EndKey   string `protobuf:"bytes,2,opt,name=endKey" json:"endKey,omitempty"`
+	PageSize int32  `protobuf:"varint,3,opt,name=page_size,json=pageSize" json:"page_size,omitempty"`
+	Bookmark string `protobuf:"bytes,4,opt,name=bookmark" json:"bookmark,omitempty"`
 }
 
 func (m *GetStateByRange) Reset()                    { *m = GetStateByRange{} }
@@ -151,6 +167,9 @@ func (*GetStateByRange) Descriptor() ([]byte, []int) { return fileDescriptor3, [
 
 type GetQueryResult struct {
 	Query string `protobuf:"bytes,1,opt,name=query" json:"query,omitempty"`
+	// fields, when set, restricts the values of the results to the given fields
+	// of the JSON documents. Nested fields are given as dotted paths
+	Fields []string `protobuf:"bytes,2,rep,name=fields" json:"fields,omitempty"`
 }
 
 func (m *GetQueryResult) Reset()                    { *m = GetQueryResult{} }
@@ -160,6 +179,8 @@ func (*GetQueryResult) Descriptor() ([]byte, []int) { return fileDescriptor3, []
 
 type GetHistoryForKey struct {
 	Key string `protobuf:"bytes,1,opt,name=key" json:"key,omitempty"`
+	// options, when set, narrow down and order the history of the key
+	Options *HistoryQueryOptions `protobuf:"bytes,2,opt,name=options" json:"options,omitempty"`
 }
 
 func (m *GetHistoryForKey) Reset()                    { *m = GetHistoryForKey{} }
@@ -167,6 +188,13 @@ func (m *GetHistoryForKey) String() string            { return proto.CompactText
 func (*GetHistoryForKey) ProtoMessage()               {}
 func (*GetHistoryForKey) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{4} }
 
+func (m *GetHistoryForKey) GetOptions() *HistoryQueryOptions {
+	if m != nil {
+		return m.Options
+	}
+	return nil
+}
+
 type QueryStateNext struct {
 	Id string `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
 }
@@ -196,9 +224,10 @@ func (*QueryStateKeyValue) ProtoMessage()               {}
 func (*QueryStateKeyValue) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{7} }
 
 type QueryStateResponse struct {
-	KeysAndValues []*QueryStateKeyValue `protobuf:"bytes,1,rep,name=keys_and_values,json=keysAndValues" json:"keys_and_values,omitempty"`
-	HasMore       bool                  `protobuf:"varint,2,opt,name=has_more,json=hasMore" json:"has_more,omitempty"`
-	Id            string                `protobuf:"bytes,3,opt,name=id" json:"id,omitempty"`
+	KeysAndValues []*QueryStateKeyValue  `protobuf:"bytes,1,rep,name=keys_and_values,json=keysAndValues" json:"keys_and_values,omitempty"`
+	HasMore       bool                   `protobuf:"varint,2,opt,name=has_more,json=hasMore" json:"has_more,omitempty"`
+	Id            string                 `protobuf:"bytes,3,opt,name=id" json:"id,omitempty"`
+	Metadata      *QueryResponseMetadata `protobuf:"bytes,4,opt,name=metadata" json:"metadata,omitempty"`
 }
 
 func (m *QueryStateResponse) Reset()                    { *m = QueryStateResponse{} }
@@ -213,6 +242,96 @@ func (m *QueryStateResponse) GetKeysAndValues() []*QueryStateKeyValue {
 	return nil
 }
 
+func (m *QueryStateResponse) GetMetadata() *QueryResponseMetadata {
+	if m != nil {
+		return m.Metadata
+	}
+	return nil
+}
+
+type GetTotalForKeyPrefix struct {
+	KeyPrefix string `protobuf:"bytes,1,opt,name=key_prefix,json=keyPrefix" json:"key_prefix,omitempty"`
+	FieldName string `protobuf:"bytes,2,opt,name=field_name,json=fieldName" json:"field_name,omitempty"`
+}
+
+func (m *GetTotalForKeyPrefix) Reset()                    { *m = GetTotalForKeyPrefix{} }
+func (m *GetTotalForKeyPrefix) String() string            { return proto.CompactTextString(m) }
+func (*GetTotalForKeyPrefix) ProtoMessage()               {}
+func (*GetTotalForKeyPrefix) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{9} }
+
+type TotalForKeyPrefixResponse struct {
+	Sum   float64 `protobuf:"fixed64,1,opt,name=sum" json:"sum,omitempty"`
+	Count uint64  `protobuf:"varint,2,opt,name=count" json:"count,omitempty"`
+}
+
+func (m *TotalForKeyPrefixResponse) Reset()                    { *m = TotalForKeyPrefixResponse{} }
+func (m *TotalForKeyPrefixResponse) String() string            { return proto.CompactTextString(m) }
+func (*TotalForKeyPrefixResponse) ProtoMessage()               {}
+func (*TotalForKeyPrefixResponse) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{10} }
+
+// GetStateMultiple requests the values of multiple keys in a single round trip
+type GetStateMultiple struct {
+	Keys []string `protobuf:"bytes,1,rep,name=keys" json:"keys,omitempty"`
+}
+
+func (m *GetStateMultiple) Reset()                    { *m = GetStateMultiple{} }
+func (m *GetStateMultiple) String() string            { return proto.CompactTextString(m) }
+func (*GetStateMultiple) ProtoMessage()               {}
+func (*GetStateMultiple) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{11} }
+
+// GetStateMultipleResponse carries the values of the requested keys in the
+// order of the request. The value of a key that does not exist is empty
+type GetStateMultipleResponse struct {
+	Values [][]byte `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty"`
+}
+
+func (m *GetStateMultipleResponse) Reset()                    { *m = GetStateMultipleResponse{} }
+func (m *GetStateMultipleResponse) String() string            { return proto.CompactTextString(m) }
+func (*GetStateMultipleResponse) ProtoMessage()               {}
+func (*GetStateMultipleResponse) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{12} }
+
+// DelStateByRange requests the deletion of the keys in the range [startKey, endKey)
+type DelStateByRange struct {
+	StartKey string `protobuf:"bytes,1,opt,name=startKey" json:"startKey,omitempty"`
+	EndKey   string `protobuf:"bytes,2,opt,name=endKey" json:"endKey,omitempty"`
+}
+
+func (m *DelStateByRange) Reset()                    { *m = DelStateByRange{} }
+func (m *DelStateByRange) String() string            { return proto.CompactTextString(m) }
+func (*DelStateByRange) ProtoMessage()               {}
+func (*DelStateByRange) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{13} }
+
+// QueryResponseMetadata is returned along with a page of results of a
+// paginated query
+type QueryResponseMetadata struct {
+	FetchedRecordsCount int32  `protobuf:"varint,1,opt,name=fetched_records_count,json=fetchedRecordsCount" json:"fetched_records_count,omitempty"`
+	Bookmark            string `protobuf:"bytes,2,opt,name=bookmark" json:"bookmark,omitempty"`
+}
+
+func (m *QueryResponseMetadata) Reset()                    { *m = QueryResponseMetadata{} }
+func (m *QueryResponseMetadata) String() string            { return proto.CompactTextString(m) }
+func (*QueryResponseMetadata) ProtoMessage()               {}
+func (*QueryResponseMetadata) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{14} }
+
+// HistoryQueryOptions narrow down and order the history of a key. The
+// history is returned from the oldest modification unless reverse is set,
+// and is restricted to the blocks from start_block to end_block, inclusive.
+// An end_block of zero does not bound the history. A limit of zero does not
+// limit the number of the modifications, and the deletes of the key are
+// skipped unless include_deletes is set
+type HistoryQueryOptions struct {
+	Limit          int32  `protobuf:"varint,1,opt,name=limit" json:"limit,omitempty"`
+	Reverse        bool   `protobuf:"varint,2,opt,name=reverse" json:"reverse,omitempty"`
+	StartBlock     uint64 `protobuf:"varint,3,opt,name=start_block,json=startBlock" json:"start_block,omitempty"`
+	EndBlock       uint64 `protobuf:"varint,4,opt,name=end_block,json=endBlock" json:"end_block,omitempty"`
+	IncludeDeletes bool   `protobuf:"varint,5,opt,name=include_deletes,json=includeDeletes" json:"include_deletes,omitempty"`
+}
+
+func (m *HistoryQueryOptions) Reset()                    { *m = HistoryQueryOptions{} }
+func (m *HistoryQueryOptions) String() string            { return proto.CompactTextString(m) }
+func (*HistoryQueryOptions) ProtoMessage()               {}
+func (*HistoryQueryOptions) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{15} }
+
 func init() {
 	proto.RegisterType((*ChaincodeMessage)(nil), "protos.ChaincodeMessage")
 	proto.RegisterType((*PutStateInfo)(nil), "protos.PutStateInfo")
@@ -223,6 +342,13 @@ func init() {
 	proto.RegisterType((*QueryStateClose)(nil), "protos.QueryStateClose")
 	proto.RegisterType((*QueryStateKeyValue)(nil), "protos.QueryStateKeyValue")
 	proto.RegisterType((*QueryStateResponse)(nil), "protos.QueryStateResponse")
+	proto.RegisterType((*GetTotalForKeyPrefix)(nil), "protos.GetTotalForKeyPrefix")
+	proto.RegisterType((*TotalForKeyPrefixResponse)(nil), "protos.TotalForKeyPrefixResponse")
+	proto.RegisterType((*GetStateMultiple)(nil), "protos.GetStateMultiple")
+	proto.RegisterType((*GetStateMultipleResponse)(nil), "protos.GetStateMultipleResponse")
+	proto.RegisterType((*DelStateByRange)(nil), "protos.DelStateByRange")
+	proto.RegisterType((*QueryResponseMetadata)(nil), "protos.QueryResponseMetadata")
+	proto.RegisterType((*HistoryQueryOptions)(nil), "protos.HistoryQueryOptions")
 	proto.RegisterEnum("protos.ChaincodeMessage_Type", ChaincodeMessage_Type_name, ChaincodeMessage_Type_value)
 }
 
@@ -333,54 +459,78 @@ var _ChaincodeSupport_serviceDesc = grpc.ServiceDesc{
 func init() { proto.RegisterFile("peer/chaincodeshim.proto", fileDescriptor3) }
 
 var fileDescriptor3 = []byte{
-	// 777 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x8c, 0x94, 0xdd, 0x6e, 0xe2, 0x46,
-	0x14, 0xc7, 0xd7, 0x40, 0x12, 0x38, 0x49, 0x60, 0x76, 0xb2, 0x4d, 0xbd, 0x48, 0x55, 0xa9, 0x55,
-	0x55, 0x54, 0xaa, 0xa0, 0x4d, 0xa5, 0xaa, 0x17, 0x95, 0x2a, 0x02, 0x13, 0x62, 0x41, 0x6c, 0x76,
-	0xec, 0x44, 0x4b, 0x6f, 0x2c, 0x07, 0x4f, 0xc0, 0x5a, 0x60, 0x5c, 0xcf, 0xb0, 0x5a, 0x5f, 0xf7,
-	0xe1, 0xfa, 0x26, 0x7d, 0x8e, 0x6a, 0xc6, 0x36, 0xc9, 0x36, 0x8a, 0xd4, 0x2b, 0xcf, 0xff, 0x9c,
-	0xdf, 0xf9, 0x1a, 0x8d, 0x0f, 0x98, 0x09, 0x63, 0x69, 0x7f, 0xb1, 0x0a, 0xe3, 0xed, 0x82, 0x47,
-	0x4c, 0xac, 0xe2, 0x4d, 0x2f, 0x49, 0xb9, 0xe4, 0xf8, 0x50, 0x7f, 0x44, 0xfb, 0xed, 0xe7, 0x04,
-	0xfb, 0xc8, 0xb6, 0x32, 0x47, 0xda, 0x67, 0xda, 0x95, 0xa4, 0x3c, 0xe1, 0x22, 0x5c, 0x17, 0xc6,
-	0xaf, 0x97, 0x9c, 0x2f, 0xd7, 0xac, 0xaf, 0xd5, 0xfd, 0xee, 0xa1, 0x2f, 0xe3, 0x0d, 0x13, 0x32,
-	0xdc, 0x24, 0x39, 0x60, 0xfd, 0x53, 0x03, 0x34, 0x2c, 0xd3, 0xdd, 0x30, 0x21, 0xc2, 0x25, 0xc3,
-	0x3f, 0x41, 0x4d, 0x66, 0x09, 0x33, 0x8d, 0x8e, 0xd1, 0x6d, 0x5e, 0x7c, 0x95, 0xa3, 0xa2, 0xf7,
-	0x5f, 0xae, 0xe7, 0x67, 0x09, 0xa3, 0x1a, 0xc5, 0xbf, 0x42, 0x63, 0x9f, 0xda, 0xac, 0x74, 0x8c,
-	0xee, 0xf1, 0x45, 0xbb, 0x97, 0x17, 0xef, 0x95, 0xc5, 0x7b, 0x7e, 0x49, 0xd0, 0x47, 0x18, 0x9b,
-	0x70, 0x94, 0x84, 0xd9, 0x9a, 0x87, 0x91, 0x59, 0xed, 0x18, 0xdd, 0x13, 0x5a, 0x4a, 0x8c, 0xa1,
-	0x26, 0x3f, 0xc5, 0x91, 0x59, 0xeb, 0x18, 0xdd, 0x06, 0xd5, 0x67, 0xfc, 0x03, 0xd4, 0xcb, 0x11,
-	0xcd, 0x03, 0x5d, 0x06, 0x95, 0xed, 0xcd, 0x0a, 0x3b, 0xdd, 0x13, 0xf8, 0x77, 0x68, 0xed, 0xef,
-	0x2a, 0xd0, 0x97, 0x65, 0x1e, 0xea, 0xa0, 0xf3, 0x67, 0x33, 0x11, 0xe5, 0xa5, 0xcd, 0xc5, 0x67,
-	0xda, 0xfa, 0xbb, 0x02, 0x35, 0x35, 0x25, 0x3e, 0x85, 0xc6, 0xad, 0x33, 0x22, 0x57, 0xb6, 0x43,
-	0x46, 0xe8, 0x15, 0x3e, 0x81, 0x3a, 0x25, 0x63, 0xdb, 0xf3, 0x09, 0x45, 0x06, 0x6e, 0x02, 0x94,
-	0x8a, 0x8c, 0x50, 0x05, 0xd7, 0xa1, 0x66, 0x3b, 0xb6, 0x8f, 0xaa, 0xb8, 0x01, 0x07, 0x94, 0x0c,
-	0x46, 0x73, 0x54, 0xc3, 0x2d, 0x38, 0xf6, 0xe9, 0xc0, 0xf1, 0x06, 0x43, 0xdf, 0x76, 0x1d, 0x74,
-	0xa0, 0x52, 0x0e, 0xdd, 0x9b, 0xd9, 0x94, 0xf8, 0x64, 0x84, 0x0e, 0x15, 0x4a, 0x28, 0x75, 0x29,
-	0x3a, 0x52, 0x9e, 0x31, 0xf1, 0x03, 0xcf, 0x1f, 0xf8, 0x04, 0xd5, 0x95, 0x9c, 0xdd, 0x96, 0xb2,
-	0xa1, 0xe4, 0x88, 0x4c, 0x0b, 0x09, 0xf8, 0x0d, 0x20, 0xdb, 0xb9, 0x73, 0x27, 0x24, 0x18, 0x5e,
-	0x0f, 0x6c, 0x67, 0xe8, 0x8e, 0x08, 0x3a, 0xce, 0x1b, 0xf4, 0x66, 0xae, 0xe3, 0x11, 0x74, 0x8a,
-	0xcf, 0x01, 0xef, 0x13, 0x06, 0x97, 0xf3, 0x80, 0x0e, 0x9c, 0x31, 0x41, 0x4d, 0x15, 0xab, 0xec,
-	0xef, 0x6e, 0x09, 0x9d, 0x07, 0x94, 0x78, 0xb7, 0x53, 0x1f, 0xb5, 0x94, 0x35, 0xb7, 0xe4, 0xbc,
-	0x43, 0xde, 0xfb, 0x08, 0xe1, 0x2f, 0xe0, 0xf5, 0x53, 0xeb, 0x70, 0xea, 0x7a, 0x04, 0xbd, 0x56,
-	0xdd, 0x4c, 0x08, 0x99, 0x0d, 0xa6, 0xf6, 0x1d, 0x41, 0x18, 0x7f, 0x09, 0x67, 0x2a, 0xe3, 0xb5,
-	0xed, 0xf9, 0x2e, 0x9d, 0x07, 0x57, 0x2e, 0x0d, 0x26, 0x64, 0x8e, 0xce, 0xac, 0x5f, 0xe0, 0x64,
-	0xb6, 0x93, 0x9e, 0x0c, 0x25, 0xb3, 0xb7, 0x0f, 0x1c, 0x23, 0xa8, 0x7e, 0x60, 0x99, 0x7e, 0x62,
-	0x0d, 0xaa, 0x8e, 0xf8, 0x0d, 0x1c, 0x7c, 0x0c, 0xd7, 0x3b, 0xa6, 0x9f, 0xcf, 0x09, 0xcd, 0x85,
-	0x45, 0xa0, 0x35, 0x66, 0x79, 0xdc, 0x65, 0x46, 0xc3, 0xed, 0x92, 0xe1, 0x36, 0xd4, 0x85, 0x0c,
-	0x53, 0x39, 0xd9, 0xc7, 0xef, 0x35, 0x3e, 0x87, 0x43, 0xb6, 0x8d, 0x94, 0xa7, 0xa2, 0x3d, 0x85,
-	0xb2, 0xbe, 0x83, 0xe6, 0x98, 0xc9, 0x77, 0x3b, 0x96, 0x66, 0x94, 0x89, 0xdd, 0x5a, 0xaa, 0x72,
-	0x7f, 0x2a, 0x59, 0xa4, 0xc8, 0x85, 0xf5, 0x2d, 0xa0, 0x31, 0x93, 0xd7, 0xb1, 0x90, 0x3c, 0xcd,
-	0xae, 0x78, 0xaa, 0x72, 0x3e, 0x6b, 0xd5, 0xea, 0x40, 0x53, 0xa7, 0xd2, 0x6d, 0x39, 0xec, 0x93,
-	0xc4, 0x4d, 0xa8, 0xc4, 0x51, 0x81, 0x54, 0xe2, 0xc8, 0xfa, 0x06, 0x5a, 0x8f, 0xc4, 0x70, 0xcd,
-	0x05, 0x7b, 0x86, 0xfc, 0x06, 0xf8, 0x11, 0x99, 0xb0, 0xec, 0x4e, 0xcd, 0xfb, 0xbf, 0xef, 0xe5,
-	0x2f, 0xe3, 0x69, 0x38, 0x65, 0x22, 0xe1, 0x5b, 0xc1, 0xf0, 0x25, 0xb4, 0x3e, 0xb0, 0x4c, 0x04,
-	0xe1, 0x36, 0x0a, 0x34, 0x28, 0x4c, 0xa3, 0x53, 0xd5, 0x7f, 0x63, 0xf1, 0xe2, 0x9f, 0xd7, 0xa4,
-	0xa7, 0x2a, 0x64, 0xb0, 0x8d, 0xb4, 0x12, 0xf8, 0x2d, 0xd4, 0x57, 0xa1, 0x08, 0x36, 0x3c, 0xcd,
-	0x6b, 0xd6, 0xe9, 0xd1, 0x2a, 0x14, 0x37, 0x3c, 0x2d, 0x67, 0xa8, 0x96, 0x33, 0x5c, 0xbc, 0x7f,
-	0xb2, 0x3d, 0xbc, 0x5d, 0x92, 0xf0, 0x54, 0xe2, 0x11, 0xd4, 0x29, 0x5b, 0xc6, 0x42, 0xb2, 0x14,
-	0x9b, 0x2f, 0xed, 0x8e, 0xf6, 0x8b, 0x1e, 0xeb, 0x55, 0xd7, 0xf8, 0xd1, 0xb8, 0x1c, 0xc2, 0x39,
-	0x4f, 0x97, 0xbd, 0x55, 0x96, 0xb0, 0x74, 0xcd, 0xa2, 0x25, 0x4b, 0x8b, 0x80, 0x3f, 0xbe, 0x5f,
-	0xc6, 0x72, 0xb5, 0xbb, 0xef, 0x2d, 0xf8, 0xa6, 0xff, 0xc4, 0xdd, 0x7f, 0x08, 0xef, 0xd3, 0x78,
-	0x91, 0xaf, 0x3a, 0xd1, 0x57, 0xdb, 0xf0, 0x3e, 0x5f, 0x9b, 0x3f, 0xff, 0x1b, 0x00, 0x00, 0xff,
-	0xff, 0xfb, 0x9f, 0x18, 0xb2, 0x59, 0x05, 0x00, 0x00,
+	// 1161 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x56, 0xdd, 0x6e, 0xdb, 0x36,
+	0x14, 0xae, 0x63, 0x27, 0xb1, 0x4f, 0x52, 0x9b, 0x65, 0x7e, 0xaa, 0xa6, 0x2b, 0xea, 0xe9, 0x62,
+	0xcb, 0x80, 0xc1, 0xd9, 0x32, 0x0c, 0xd8, 0x80, 0x61, 0x83, 0x63, 0x33, 0x8e, 0x11, 0xc7, 0x76,
+	0x69, 0xa5, 0x68, 0xb6, 0x0b, 0x41, 0xb1, 0x4e, 0x6c, 0xc1, 0xb2, 0xa8, 0x89, 0x74, 0x51, 0xf7,
+	0x62, 0xef, 0x33, 0x60, 0xcf, 0xb0, 0xeb, 0x3d, 0xd6, 0x40, 0x4a, 0x72, 0x92, 0xa6, 0x05, 0x06,
+	0xec, 0x4a, 0xfc, 0xce, 0xf7, 0xf1, 0xfc, 0xf1, 0x4f, 0x60, 0xc5, 0x88, 0xc9, 0xd1, 0x78, 0xea,
+	0x05, 0xd1, 0x58, 0xf8, 0x28, 0xa7, 0xc1, 0xbc, 0x11, 0x27, 0x42, 0x09, 0xba, 0x61, 0x3e, 0xf2,
+	0xe0, 0xd9, 0x7d, 0x05, 0xbe, 0xc5, 0x48, 0xa5, 0x92, 0x83, 0x1d, 0x43, 0xc5, 0x89, 0x88, 0x85,
+	0xf4, 0xc2, 0xcc, 0xf8, 0x72, 0x22, 0xc4, 0x24, 0xc4, 0x23, 0x83, 0xae, 0x17, 0x37, 0x47, 0x2a,
+	0x98, 0xa3, 0x54, 0xde, 0x3c, 0x4e, 0x05, 0xf6, 0x3f, 0xeb, 0x40, 0x5a, 0xb9, 0xbb, 0x0b, 0x94,
+	0xd2, 0x9b, 0x20, 0xfd, 0x16, 0x4a, 0x6a, 0x19, 0xa3, 0x55, 0xa8, 0x17, 0x0e, 0xab, 0xc7, 0x2f,
+	0x52, 0xa9, 0x6c, 0x7c, 0xa8, 0x6b, 0x38, 0xcb, 0x18, 0xb9, 0x91, 0xd2, 0x1f, 0xa0, 0xb2, 0x72,
+	0x6d, 0xad, 0xd5, 0x0b, 0x87, 0x5b, 0xc7, 0x07, 0x8d, 0x34, 0x78, 0x23, 0x0f, 0xde, 0x70, 0x72,
+	0x05, 0xbf, 0x15, 0x53, 0x0b, 0x36, 0x63, 0x6f, 0x19, 0x0a, 0xcf, 0xb7, 0x8a, 0xf5, 0xc2, 0xe1,
+	0x36, 0xcf, 0x21, 0xa5, 0x50, 0x52, 0xef, 0x02, 0xdf, 0x2a, 0xd5, 0x0b, 0x87, 0x15, 0x6e, 0xc6,
+	0xf4, 0x6b, 0x28, 0xe7, 0x25, 0x5a, 0xeb, 0x26, 0x0c, 0xc9, 0xd3, 0x1b, 0x66, 0x76, 0xbe, 0x52,
+	0xd0, 0x5f, 0xa0, 0xb6, 0xea, 0x95, 0x6b, 0x9a, 0x65, 0x6d, 0x98, 0x49, 0xfb, 0x0f, 0x6a, 0x62,
+	0x9a, 0xe5, 0xd5, 0xf1, 0x3d, 0x6c, 0xff, 0x59, 0x84, 0x92, 0xae, 0x92, 0x3e, 0x86, 0xca, 0x65,
+	0xbf, 0xcd, 0x4e, 0xbb, 0x7d, 0xd6, 0x26, 0x8f, 0xe8, 0x36, 0x94, 0x39, 0xeb, 0x74, 0x47, 0x0e,
+	0xe3, 0xa4, 0x40, 0xab, 0x00, 0x39, 0x62, 0x6d, 0xb2, 0x46, 0xcb, 0x50, 0xea, 0xf6, 0xbb, 0x0e,
+	0x29, 0xd2, 0x0a, 0xac, 0x73, 0xd6, 0x6c, 0x5f, 0x91, 0x12, 0xad, 0xc1, 0x96, 0xc3, 0x9b, 0xfd,
+	0x51, 0xb3, 0xe5, 0x74, 0x07, 0x7d, 0xb2, 0xae, 0x5d, 0xb6, 0x06, 0x17, 0xc3, 0x1e, 0x73, 0x58,
+	0x9b, 0x6c, 0x68, 0x29, 0xe3, 0x7c, 0xc0, 0xc9, 0xa6, 0x66, 0x3a, 0xcc, 0x71, 0x47, 0x4e, 0xd3,
+	0x61, 0xa4, 0xac, 0xe1, 0xf0, 0x32, 0x87, 0x15, 0x0d, 0xdb, 0xac, 0x97, 0x41, 0xa0, 0xbb, 0x40,
+	0xba, 0xfd, 0xd7, 0x83, 0x73, 0xe6, 0xb6, 0xce, 0x9a, 0xdd, 0x7e, 0x6b, 0xd0, 0x66, 0x64, 0x2b,
+	0x4d, 0x70, 0x34, 0x1c, 0xf4, 0x47, 0x8c, 0x3c, 0xa6, 0xfb, 0x40, 0x57, 0x0e, 0xdd, 0x93, 0x2b,
+	0x97, 0x37, 0xfb, 0x1d, 0x46, 0xaa, 0x7a, 0xae, 0xb6, 0xbf, 0xba, 0x64, 0xfc, 0xca, 0xe5, 0x6c,
+	0x74, 0xd9, 0x73, 0x48, 0x4d, 0x5b, 0x53, 0x4b, 0xaa, 0xef, 0xb3, 0x37, 0x0e, 0x21, 0x74, 0x0f,
+	0x9e, 0xdc, 0xb5, 0xb6, 0x7a, 0x83, 0x11, 0x23, 0x4f, 0x74, 0x36, 0xe7, 0x8c, 0x0d, 0x9b, 0xbd,
+	0xee, 0x6b, 0x46, 0x28, 0x7d, 0x0a, 0x3b, 0xda, 0xe3, 0x59, 0x77, 0xe4, 0x0c, 0xf8, 0x95, 0x7b,
+	0x3a, 0xe0, 0xee, 0x39, 0xbb, 0x22, 0x3b, 0xf4, 0x33, 0xb0, 0x34, 0xe1, 0x0c, 0x9c, 0x66, 0x2f,
+	0x37, 0xbb, 0x43, 0xce, 0x4e, 0xbb, 0x6f, 0xc8, 0xee, 0xfd, 0x04, 0x2f, 0x2e, 0x7b, 0x4e, 0x77,
+	0xd8, 0x63, 0x64, 0x4f, 0xdb, 0x57, 0xb5, 0xde, 0x26, 0xbe, 0x4f, 0x29, 0x54, 0x6f, 0xf5, 0x67,
+	0xcd, 0xd1, 0x19, 0x79, 0x6a, 0x9f, 0xc1, 0xf6, 0x70, 0xa1, 0x46, 0xca, 0x53, 0xd8, 0x8d, 0x6e,
+	0x04, 0x25, 0x50, 0x9c, 0xe1, 0xd2, 0x6c, 0xe2, 0x0a, 0xd7, 0x43, 0xba, 0x0b, 0xeb, 0x6f, 0xbd,
+	0x70, 0x81, 0x66, 0x83, 0x6e, 0xf3, 0x14, 0x68, 0x9d, 0x52, 0xa1, 0xd9, 0x7c, 0x25, 0xae, 0x87,
+	0xf6, 0x1f, 0x50, 0xeb, 0x60, 0xea, 0xe9, 0x64, 0xc9, 0xbd, 0x68, 0x82, 0xf4, 0x00, 0xca, 0x52,
+	0x79, 0x89, 0x3a, 0x5f, 0x79, 0x5c, 0x61, 0xba, 0x0f, 0x1b, 0x18, 0xf9, 0x9a, 0x59, 0x33, 0x4c,
+	0x86, 0xe8, 0x73, 0xa8, 0xc4, 0xde, 0x04, 0x5d, 0x19, 0xbc, 0x47, 0xe3, 0x7e, 0x9d, 0x97, 0xb5,
+	0x61, 0x14, 0xbc, 0x37, 0x0e, 0xaf, 0x85, 0x98, 0xcd, 0xbd, 0x64, 0x96, 0x6d, 0xf0, 0x15, 0xb6,
+	0x7f, 0x86, 0x6a, 0x07, 0xd5, 0xab, 0x05, 0x26, 0x4b, 0x8e, 0x72, 0x11, 0x2a, 0x9d, 0xf9, 0xef,
+	0x1a, 0x66, 0xb1, 0x53, 0xa0, 0x03, 0xdf, 0x04, 0x18, 0xfa, 0xd2, 0x5a, 0xab, 0x17, 0x75, 0xe0,
+	0x14, 0xd9, 0xbf, 0x01, 0xe9, 0xa0, 0x3a, 0x0b, 0xa4, 0x12, 0xc9, 0xf2, 0x54, 0x24, 0x3a, 0x99,
+	0x87, 0xdd, 0xf8, 0x1e, 0x36, 0x45, 0xac, 0x02, 0x11, 0xc9, 0xec, 0xc0, 0x3e, 0xcf, 0x0f, 0x45,
+	0x36, 0xd3, 0x24, 0x30, 0x48, 0x25, 0x3c, 0xd7, 0xda, 0x75, 0xa8, 0x1a, 0xc2, 0xb4, 0xa7, 0x8f,
+	0xef, 0x14, 0xad, 0xc2, 0x5a, 0xe0, 0x67, 0x9e, 0xd7, 0x02, 0xdf, 0xfe, 0x1c, 0x6a, 0xb7, 0x8a,
+	0x56, 0x28, 0x24, 0x3e, 0x90, 0xfc, 0x04, 0xf4, 0x56, 0x72, 0x8e, 0xcb, 0xd7, 0xf9, 0x4a, 0xfc,
+	0x97, 0x15, 0xb3, 0xff, 0x2e, 0xdc, 0x9d, 0xce, 0x51, 0xc6, 0x22, 0x92, 0x48, 0x4f, 0xa0, 0x36,
+	0xc3, 0xa5, 0x74, 0xbd, 0xc8, 0x77, 0x8d, 0x50, 0x5a, 0x85, 0x7a, 0xd1, 0xdc, 0x44, 0x59, 0x61,
+	0x0f, 0x63, 0xf2, 0xc7, 0x7a, 0x4a, 0x33, 0xf2, 0x0d, 0x92, 0xf4, 0x19, 0x94, 0xa7, 0x9e, 0x74,
+	0xe7, 0x22, 0x49, 0x63, 0x96, 0xf9, 0xe6, 0xd4, 0x93, 0x17, 0x22, 0xc9, 0x6b, 0x28, 0xe6, 0x35,
+	0xd0, 0x1f, 0xa1, 0x3c, 0x47, 0xe5, 0xf9, 0x9e, 0xf2, 0xcc, 0x0a, 0x6e, 0x1d, 0xbf, 0xb8, 0x17,
+	0x27, 0xcf, 0xeb, 0x22, 0x13, 0xf1, 0x95, 0xdc, 0x76, 0x60, 0xb7, 0x83, 0xca, 0x11, 0xca, 0x0b,
+	0xd3, 0xe5, 0x19, 0x26, 0x78, 0x13, 0xbc, 0xa3, 0x2f, 0x00, 0x66, 0xb8, 0x74, 0x63, 0x83, 0xb2,
+	0x3e, 0x54, 0x66, 0x77, 0x69, 0xb3, 0xc2, 0x6e, 0xe4, 0xcd, 0x31, 0xdb, 0x6c, 0x15, 0x63, 0xe9,
+	0x7b, 0x73, 0xb4, 0x5b, 0xf0, 0xec, 0x81, 0xcb, 0x55, 0x73, 0x08, 0x14, 0xe5, 0x62, 0x6e, 0x7c,
+	0x16, 0xb8, 0x1e, 0xea, 0xde, 0x8e, 0xc5, 0x22, 0x52, 0xc6, 0x51, 0x89, 0xa7, 0xc0, 0xfe, 0xc2,
+	0xec, 0x1d, 0xd3, 0xa3, 0x8b, 0x45, 0xa8, 0x82, 0x38, 0x44, 0x7d, 0x11, 0xeb, 0x2e, 0x99, 0x6e,
+	0x56, 0xb8, 0x19, 0xdb, 0xc7, 0x60, 0x7d, 0xa8, 0x5b, 0xc5, 0xda, 0x87, 0x8d, 0x3b, 0xfd, 0xdf,
+	0xe6, 0x19, 0xb2, 0x19, 0xd4, 0xda, 0x18, 0xfe, 0xdf, 0x73, 0x65, 0x4f, 0x60, 0xef, 0xa3, 0x0d,
+	0xa6, 0xc7, 0xb0, 0x77, 0x83, 0x6a, 0x3c, 0x45, 0xdf, 0x4d, 0x70, 0x2c, 0x12, 0x5f, 0xba, 0x69,
+	0x85, 0x05, 0x73, 0xf8, 0x76, 0x32, 0x92, 0xa7, 0x5c, 0x4b, 0x53, 0xf7, 0xce, 0xe1, 0xda, 0x07,
+	0xe7, 0xf0, 0xaf, 0x02, 0xec, 0x7c, 0xe4, 0x2c, 0xe8, 0xce, 0x85, 0xc1, 0x3c, 0xc8, 0xfd, 0xa6,
+	0x40, 0x3f, 0x64, 0x09, 0xbe, 0xc5, 0x44, 0xae, 0x76, 0x4e, 0x06, 0xe9, 0x4b, 0xd8, 0x32, 0x45,
+	0xb9, 0xd7, 0xa1, 0x18, 0xcf, 0xb2, 0x9b, 0x06, 0x8c, 0xe9, 0x44, 0x5b, 0xf4, 0x4d, 0x81, 0x91,
+	0x9f, 0xd1, 0x25, 0x43, 0x97, 0x31, 0xf2, 0x53, 0xf2, 0x4b, 0xa8, 0x05, 0xd1, 0x38, 0x5c, 0xf8,
+	0xe8, 0xfa, 0x18, 0xa2, 0x42, 0x69, 0x5e, 0xbe, 0x32, 0xaf, 0x66, 0xe6, 0x76, 0x6a, 0x3d, 0x7e,
+	0x73, 0xe7, 0x29, 0x1f, 0x2d, 0xe2, 0x58, 0x24, 0x8a, 0xb6, 0xa1, 0xcc, 0x71, 0x12, 0x48, 0x85,
+	0x09, 0xb5, 0x3e, 0xf5, 0x90, 0x1f, 0x7c, 0x92, 0xb1, 0x1f, 0x1d, 0x16, 0xbe, 0x29, 0x9c, 0xb4,
+	0x60, 0x5f, 0x24, 0x93, 0xc6, 0x74, 0x19, 0x63, 0x12, 0xa2, 0x3f, 0xc1, 0x24, 0x9b, 0xf0, 0xeb,
+	0x57, 0x93, 0x40, 0x4d, 0x17, 0xd7, 0x8d, 0xb1, 0x98, 0x1f, 0xdd, 0xa1, 0x8f, 0x6e, 0xbc, 0xeb,
+	0x24, 0x18, 0xa7, 0xff, 0x1d, 0xf2, 0x48, 0xff, 0x9a, 0x5c, 0xa7, 0xff, 0x30, 0xdf, 0xfd, 0x3b,
+	0x00, 0x3e, 0xe9, 0x2b, 0x64, 0xe6, 0x08, 0x00, 0x00,
 }
@@ -22,10 +22,11 @@ var _ = math.Inf
 type EventType int32
 
 const (
-	EventType_REGISTER  EventType = 0
-	EventType_BLOCK     EventType = 1
-	EventType_CHAINCODE EventType = 2
-	EventType_REJECTION EventType = 3
+	EventType_REGISTER      EventType = 0
+	EventType_BLOCK         EventType = 1
+	EventType_CHAINCODE     EventType = 2
+	EventType_REJECTION     EventType = 3
+	EventType_FILTEREDBLOCK EventType = 4
 )
 
 var EventType_name = map[int32]string{
@@ -33,12 +34,14 @@ var EventType_name = map[int32]string{
 	1: "BLOCK",
 	2: "CHAINCODE",
 	3: "REJECTION",
+	4: "FILTEREDBLOCK",
 }
 var EventType_value = map[string]int32{
-	"REGISTER":  0,
-	"BLOCK":     1,
-	"CHAINCODE": 2,
-	"REJECTION": 3,
+	"REGISTER":      0,
+	"BLOCK":         1,
+	"CHAINCODE":     2,
+	"REJECTION":     3,
+	"FILTEREDBLOCK": 4,
 }
 
 func (x EventType) String() string {
@@ -160,6 +163,10 @@ func _Interest_OneofSizer(msg proto.Message) (n int) {
 // string type - "register"
 type Register struct {
 	Events []*Interest `protobuf:"bytes,1,rep,name=events" json:"events,omitempty"`
+	// consumer_id, when set, resumes the BLOCK and FILTEREDBLOCK events of the
+	// interests with a chainID after the cursor the consumer has committed
+	// for the channel
+	ConsumerId string `protobuf:"bytes,2,opt,name=consumer_id,json=consumerId" json:"consumer_id,omitempty"`
 }
 
 func (m *Register) Reset()                    { *m = Register{} }
@@ -233,6 +240,10 @@ type Event struct {
 	//	*Event_ChaincodeEvent
 	//	*Event_Rejection
 	//	*Event_Unregister
+	//	*Event_FilteredBlock
+	//	*Event_CursorCommit
+	//	*Event_CursorRequest
+	//	*Event_Cursor
 	Event isEvent_Event `protobuf_oneof:"Event"`
 	// Creator of the event, specified as a certificate chain
 	Creator []byte `protobuf:"bytes,6,opt,name=creator,proto3" json:"creator,omitempty"`
@@ -262,12 +273,28 @@ type Event_Rejection struct {
 type Event_Unregister struct {
 	Unregister *Unregister `protobuf:"bytes,5,opt,name=unregister,oneof"`
 }
+type Event_FilteredBlock struct {
+	FilteredBlock *FilteredBlock `protobuf:"bytes,7,opt,name=filtered_block,json=filteredBlock,oneof"`
+}
+type Event_CursorCommit struct {
+	CursorCommit *CursorCommit `protobuf:"bytes,8,opt,name=cursor_commit,json=cursorCommit,oneof"`
+}
+type Event_CursorRequest struct {
+	CursorRequest *CursorRequest `protobuf:"bytes,9,opt,name=cursor_request,json=cursorRequest,oneof"`
+}
+type Event_Cursor struct {
+	Cursor *Cursor `protobuf:"bytes,10,opt,name=cursor,oneof"`
+}
 
 func (*Event_Register) isEvent_Event()       {}
 func (*Event_Block) isEvent_Event()          {}
 func (*Event_ChaincodeEvent) isEvent_Event() {}
 func (*Event_Rejection) isEvent_Event()      {}
 func (*Event_Unregister) isEvent_Event()     {}
+func (*Event_FilteredBlock) isEvent_Event()  {}
+func (*Event_CursorCommit) isEvent_Event()   {}
+func (*Event_CursorRequest) isEvent_Event()  {}
+func (*Event_Cursor) isEvent_Event()         {}
 
 func (m *Event) GetEvent() isEvent_Event {
 	if m != nil {
@@ -311,6 +338,34 @@ func (m *Event) GetUnregister() *Unregister {
 	return nil
 }
 
+func (m *Event) GetFilteredBlock() *FilteredBlock {
+	if x, ok := m.GetEvent().(*Event_FilteredBlock); ok {
+		return x.FilteredBlock
+	}
+	return nil
+}
+
+func (m *Event) GetCursorCommit() *CursorCommit {
+	if x, ok := m.GetEvent().(*Event_CursorCommit); ok {
+		return x.CursorCommit
+	}
+	return nil
+}
+
+func (m *Event) GetCursorRequest() *CursorRequest {
+	if x, ok := m.GetEvent().(*Event_CursorRequest); ok {
+		return x.CursorRequest
+	}
+	return nil
+}
+
+func (m *Event) GetCursor() *Cursor {
+	if x, ok := m.GetEvent().(*Event_Cursor); ok {
+		return x.Cursor
+	}
+	return nil
+}
+
 // XXX_OneofFuncs is for the internal use of the proto package.
 func (*Event) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error, func(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error), func(msg proto.Message) (n int), []interface{}) {
 	return _Event_OneofMarshaler, _Event_OneofUnmarshaler, _Event_OneofSizer, []interface{}{
@@ -319,6 +374,10 @@ func (*Event) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error,
 		(*Event_ChaincodeEvent)(nil),
 		(*Event_Rejection)(nil),
 		(*Event_Unregister)(nil),
+		(*Event_FilteredBlock)(nil),
+		(*Event_CursorCommit)(nil),
+		(*Event_CursorRequest)(nil),
+		(*Event_Cursor)(nil),
 	}
 }
 
@@ -351,6 +410,26 @@ func _Event_OneofMarshaler(msg proto.Message, b *proto.Buffer) error {
 		if err := b.EncodeMessage(x.Unregister); err != nil {
 			return err
 		}
+	case *Event_FilteredBlock:
+		b.EncodeVarint(7<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.FilteredBlock); err != nil {
+			return err
+		}
+	case *Event_CursorCommit:
+		b.EncodeVarint(8<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.CursorCommit); err != nil {
+			return err
+		}
+	case *Event_CursorRequest:
+		b.EncodeVarint(9<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.CursorRequest); err != nil {
+			return err
+		}
+	case *Event_Cursor:
+		b.EncodeVarint(10<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Cursor); err != nil {
+			return err
+		}
 	case nil:
 	default:
 		return fmt.Errorf("Event.Event has unexpected type %T", x)
@@ -401,6 +480,38 @@ func _Event_OneofUnmarshaler(msg proto.Message, tag, wire int, b *proto.Buffer)
 		err := b.DecodeMessage(msg)
 		m.Event = &Event_Unregister{msg}
 		return true, err
+	case 7: // Event.filtered_block
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(FilteredBlock)
+		err := b.DecodeMessage(msg)
+		m.Event = &Event_FilteredBlock{msg}
+		return true, err
+	case 8: // Event.cursor_commit
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(CursorCommit)
+		err := b.DecodeMessage(msg)
+		m.Event = &Event_CursorCommit{msg}
+		return true, err
+	case 9: // Event.cursor_request
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(CursorRequest)
+		err := b.DecodeMessage(msg)
+		m.Event = &Event_CursorRequest{msg}
+		return true, err
+	case 10: // Event.cursor
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(Cursor)
+		err := b.DecodeMessage(msg)
+		m.Event = &Event_Cursor{msg}
+		return true, err
 	default:
 		return false, nil
 	}
@@ -435,6 +546,26 @@ func _Event_OneofSizer(msg proto.Message) (n int) {
 		n += proto.SizeVarint(5<<3 | proto.WireBytes)
 		n += proto.SizeVarint(uint64(s))
 		n += s
+	case *Event_FilteredBlock:
+		s := proto.Size(x.FilteredBlock)
+		n += proto.SizeVarint(7<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Event_CursorCommit:
+		s := proto.Size(x.CursorCommit)
+		n += proto.SizeVarint(8<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Event_CursorRequest:
+		s := proto.Size(x.CursorRequest)
+		n += proto.SizeVarint(9<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Event_Cursor:
+		s := proto.Size(x.Cursor)
+		n += proto.SizeVarint(10<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
 	case nil:
 	default:
 		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
@@ -442,6 +573,81 @@ func _Event_OneofSizer(msg proto.Message) (n int) {
 	return n
 }
 
+// FilteredBlock is sent to the consumers registered for the FILTEREDBLOCK
+// event type in place of the block. It carries the outcome of each transaction
+// of the block without the payloads, for the consumers that only need to
+// confirm the commit of the transactions
+type FilteredBlock struct {
+	ChannelId  string                 `protobuf:"bytes,1,opt,name=channel_id,json=channelId" json:"channel_id,omitempty"`
+	Number     uint64                 `protobuf:"varint,2,opt,name=number" json:"number,omitempty"`
+	FilteredTx []*FilteredTransaction `protobuf:"bytes,3,rep,name=filtered_tx,json=filteredTx" json:"filtered_tx,omitempty"`
+}
+
+func (m *FilteredBlock) Reset()                    { *m = FilteredBlock{} }
+func (m *FilteredBlock) String() string            { return proto.CompactTextString(m) }
+func (*FilteredBlock) ProtoMessage()               {}
+func (*FilteredBlock) Descriptor() ([]byte, []int) { return fileDescriptor5, []int{7} }
+
+func (m *FilteredBlock) GetFilteredTx() []*FilteredTransaction {
+	if m != nil {
+		return m.FilteredTx
+	}
+	return nil
+}
+
+// FilteredTransaction carries the id, the validation code, and the name of the
+// chaincode invoked by a transaction. The chaincode name is empty for the
+// transactions other than the endorser transactions
+type FilteredTransaction struct {
+	Txid             string           `protobuf:"bytes,1,opt,name=txid" json:"txid,omitempty"`
+	TxValidationCode TxValidationCode `protobuf:"varint,2,opt,name=tx_validation_code,json=txValidationCode,enum=protos.TxValidationCode" json:"tx_validation_code,omitempty"`
+	ChaincodeName    string           `protobuf:"bytes,3,opt,name=chaincode_name,json=chaincodeName" json:"chaincode_name,omitempty"`
+}
+
+func (m *FilteredTransaction) Reset()                    { *m = FilteredTransaction{} }
+func (m *FilteredTransaction) String() string            { return proto.CompactTextString(m) }
+func (*FilteredTransaction) ProtoMessage()               {}
+func (*FilteredTransaction) Descriptor() ([]byte, []int) { return fileDescriptor5, []int{8} }
+
+// CursorCommit is sent by consumers to record the number of the last block
+// of a channel they have processed
+type CursorCommit struct {
+	ConsumerId  string `protobuf:"bytes,1,opt,name=consumer_id,json=consumerId" json:"consumer_id,omitempty"`
+	ChannelId   string `protobuf:"bytes,2,opt,name=channel_id,json=channelId" json:"channel_id,omitempty"`
+	BlockNumber uint64 `protobuf:"varint,3,opt,name=block_number,json=blockNumber" json:"block_number,omitempty"`
+}
+
+func (m *CursorCommit) Reset()                    { *m = CursorCommit{} }
+func (m *CursorCommit) String() string            { return proto.CompactTextString(m) }
+func (*CursorCommit) ProtoMessage()               {}
+func (*CursorCommit) Descriptor() ([]byte, []int) { return fileDescriptor5, []int{9} }
+
+// CursorRequest is sent by consumers to read their cursor for a channel
+type CursorRequest struct {
+	ConsumerId string `protobuf:"bytes,1,opt,name=consumer_id,json=consumerId" json:"consumer_id,omitempty"`
+	ChannelId  string `protobuf:"bytes,2,opt,name=channel_id,json=channelId" json:"channel_id,omitempty"`
+}
+
+func (m *CursorRequest) Reset()                    { *m = CursorRequest{} }
+func (m *CursorRequest) String() string            { return proto.CompactTextString(m) }
+func (*CursorRequest) ProtoMessage()               {}
+func (*CursorRequest) Descriptor() ([]byte, []int) { return fileDescriptor5, []int{10} }
+
+// Cursor is sent by the producer in response to a CursorCommit or a
+// CursorRequest. Exists is false when the consumer has not committed a cursor
+// for the channel
+type Cursor struct {
+	ConsumerId  string `protobuf:"bytes,1,opt,name=consumer_id,json=consumerId" json:"consumer_id,omitempty"`
+	ChannelId   string `protobuf:"bytes,2,opt,name=channel_id,json=channelId" json:"channel_id,omitempty"`
+	BlockNumber uint64 `protobuf:"varint,3,opt,name=block_number,json=blockNumber" json:"block_number,omitempty"`
+	Exists      bool   `protobuf:"varint,4,opt,name=exists" json:"exists,omitempty"`
+}
+
+func (m *Cursor) Reset()                    { *m = Cursor{} }
+func (m *Cursor) String() string            { return proto.CompactTextString(m) }
+func (*Cursor) ProtoMessage()               {}
+func (*Cursor) Descriptor() ([]byte, []int) { return fileDescriptor5, []int{11} }
+
 func init() {
 	proto.RegisterType((*ChaincodeReg)(nil), "protos.ChaincodeReg")
 	proto.RegisterType((*Interest)(nil), "protos.Interest")
@@ -450,6 +656,11 @@ func init() {
 	proto.RegisterType((*Unregister)(nil), "protos.Unregister")
 	proto.RegisterType((*SignedEvent)(nil), "protos.SignedEvent")
 	proto.RegisterType((*Event)(nil), "protos.Event")
+	proto.RegisterType((*FilteredBlock)(nil), "protos.FilteredBlock")
+	proto.RegisterType((*FilteredTransaction)(nil), "protos.FilteredTransaction")
+	proto.RegisterType((*CursorCommit)(nil), "protos.CursorCommit")
+	proto.RegisterType((*CursorRequest)(nil), "protos.CursorRequest")
+	proto.RegisterType((*Cursor)(nil), "protos.Cursor")
 	proto.RegisterEnum("protos.EventType", EventType_name, EventType_value)
 }
 
@@ -562,42 +773,61 @@ var _Events_serviceDesc = grpc.ServiceDesc{
 func init() { proto.RegisterFile("peer/events.proto", fileDescriptor5) }
 
 var fileDescriptor5 = []byte{
-	// 587 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x94, 0x54, 0x4f, 0x6f, 0xd3, 0x4e,
-	0x10, 0xb5, 0xd3, 0x26, 0x8d, 0x27, 0x69, 0x7f, 0xe9, 0xf6, 0xa7, 0xca, 0x94, 0x3f, 0x2a, 0x46,
-	0x48, 0xa1, 0x48, 0x49, 0x09, 0x15, 0xf7, 0xda, 0xb5, 0xb0, 0x29, 0x6d, 0xd1, 0x36, 0x5c, 0xb8,
-	0x44, 0x8e, 0x33, 0x75, 0x0c, 0x8d, 0x1d, 0xad, 0xb7, 0xa8, 0xf9, 0x44, 0x9c, 0xf8, 0x8e, 0xc8,
-	0x63, 0xaf, 0x9d, 0x88, 0x13, 0x27, 0x67, 0xde, 0xbc, 0xb7, 0xfb, 0xf6, 0xcd, 0x6e, 0x60, 0x7f,
-	0x89, 0x28, 0x86, 0xf8, 0x13, 0x13, 0x99, 0x0d, 0x96, 0x22, 0x95, 0x29, 0x6b, 0xd1, 0x27, 0x3b,
-	0x3a, 0x08, 0xd3, 0xc5, 0x22, 0x4d, 0x86, 0xc5, 0xa7, 0x68, 0x1e, 0x3d, 0x21, 0x7e, 0x38, 0x0f,
-	0xe2, 0x24, 0x4c, 0x67, 0x48, 0xc2, 0xb2, 0x75, 0x48, 0x2d, 0x29, 0x82, 0x24, 0x0b, 0x42, 0x19,
-	0x2b, 0x89, 0xf5, 0x05, 0xba, 0x8e, 0xe2, 0x73, 0x8c, 0xd8, 0x4b, 0xe8, 0x56, 0xfa, 0x49, 0x3c,
-	0x33, 0xf5, 0x63, 0xbd, 0x6f, 0xf0, 0x4e, 0x85, 0xf9, 0x33, 0xf6, 0x1c, 0x80, 0x56, 0x9e, 0x24,
-	0xc1, 0x02, 0xcd, 0x06, 0x11, 0x0c, 0x42, 0xae, 0x83, 0x05, 0x5a, 0xbf, 0x74, 0x68, 0xfb, 0x89,
-	0x44, 0x81, 0x99, 0x64, 0xa7, 0x8a, 0x2b, 0x57, 0x4b, 0xa4, 0xc5, 0xf6, 0x46, 0xfb, 0xc5, 0xd6,
-	0xd9, 0xc0, 0xcd, 0x3b, 0xe3, 0xd5, 0x12, 0x4b, 0x79, 0xfe, 0x93, 0x5d, 0x00, 0xab, 0x0d, 0x08,
-	0x8c, 0x26, 0x71, 0x72, 0x97, 0xd2, 0x2e, 0x9d, 0xd1, 0xff, 0x4a, 0xb9, 0x6e, 0xd9, 0xd3, 0x78,
-	0x2f, 0x5c, 0xab, 0xfd, 0xe4, 0x2e, 0x65, 0x26, 0xec, 0x10, 0xe6, 0x5f, 0x98, 0x5b, 0x64, 0x50,
-	0x95, 0xb6, 0x01, 0x3b, 0x25, 0xc9, 0x3a, 0x83, 0x36, 0xc7, 0x28, 0xce, 0x24, 0x0a, 0xd6, 0x87,
-	0x56, 0x91, 0xb3, 0xa9, 0x1f, 0x6f, 0xf5, 0x3b, 0xa3, 0x9e, 0xda, 0x4a, 0x1d, 0x85, 0x97, 0x7d,
-	0xeb, 0x0a, 0x0c, 0x8e, 0xdf, 0x91, 0x42, 0x64, 0xaf, 0xa0, 0x21, 0x1f, 0xe9, 0x5c, 0x9d, 0xd1,
-	0x81, 0x92, 0x8c, 0xeb, 0x94, 0x79, 0x43, 0x3e, 0xb2, 0xa7, 0x60, 0xa0, 0x10, 0xa9, 0x98, 0x2c,
-	0xb2, 0xa8, 0xcc, 0xab, 0x4d, 0xc0, 0x55, 0x16, 0x59, 0x1f, 0x00, 0xbe, 0x26, 0xe2, 0xdf, 0x6d,
-	0x5c, 0x42, 0xe7, 0x36, 0x8e, 0x12, 0x9c, 0x51, 0x8a, 0xec, 0x19, 0x18, 0x59, 0x1c, 0x25, 0x81,
-	0x7c, 0x10, 0x45, 0xce, 0x5d, 0x5e, 0x03, 0xec, 0x45, 0x39, 0x06, 0x7b, 0x25, 0x31, 0x23, 0x0b,
-	0x5d, 0xbe, 0x86, 0x58, 0xbf, 0x1b, 0xd0, 0x2c, 0xd6, 0x19, 0x40, 0x5b, 0x99, 0x29, 0x8f, 0x55,
-	0x59, 0x50, 0x59, 0x79, 0x1a, 0xaf, 0x38, 0xec, 0x35, 0x34, 0xa7, 0xf7, 0x69, 0xf8, 0xa3, 0x9c,
-	0xd0, 0xee, 0xa0, 0xbc, 0x90, 0x76, 0x0e, 0x7a, 0x1a, 0x2f, 0xba, 0xec, 0x1c, 0xfe, 0xab, 0xa7,
-	0x4a, 0x1b, 0xd3, 0x5c, 0x3a, 0xa3, 0xc3, 0xbf, 0x46, 0x4a, 0x3e, 0x3c, 0x8d, 0xef, 0x85, 0x1b,
-	0x08, 0x7b, 0x07, 0x86, 0x50, 0xb9, 0x9b, 0xdb, 0x24, 0xde, 0xaf, 0xad, 0x95, 0x0d, 0x4f, 0xe3,
-	0x35, 0x8b, 0x9d, 0x01, 0x3c, 0x54, 0xd9, 0x9a, 0x4d, 0xd2, 0x30, 0xa5, 0xa9, 0x53, 0xf7, 0x34,
-	0xbe, 0xc6, 0xa3, 0xbb, 0x23, 0x30, 0x90, 0xa9, 0x30, 0x5b, 0x94, 0x94, 0x2a, 0xed, 0x9d, 0x32,
-	0xa5, 0x13, 0x1b, 0x8c, 0xea, 0xf2, 0xb2, 0x2e, 0xb4, 0xb9, 0xfb, 0xd1, 0xbf, 0x1d, 0xbb, 0xbc,
-	0xa7, 0x31, 0x03, 0x9a, 0xf6, 0xe7, 0x1b, 0xe7, 0xb2, 0xa7, 0xb3, 0x5d, 0x30, 0x1c, 0xef, 0xdc,
-	0xbf, 0x76, 0x6e, 0x2e, 0xdc, 0x5e, 0x23, 0x2f, 0xb9, 0xfb, 0xc9, 0x75, 0xc6, 0xfe, 0xcd, 0x75,
-	0x6f, 0x6b, 0x74, 0x06, 0x2d, 0x5a, 0x23, 0x63, 0x27, 0xb0, 0xed, 0xcc, 0x03, 0xc9, 0x76, 0x37,
-	0x1e, 0xc6, 0xd1, 0x66, 0x69, 0x69, 0x7d, 0xfd, 0x54, 0xb7, 0xdf, 0x7e, 0x7b, 0x13, 0xc5, 0x72,
-	0xfe, 0x30, 0xcd, 0x83, 0x1e, 0xce, 0x57, 0x4b, 0x14, 0xf7, 0x38, 0x8b, 0x50, 0x0c, 0xef, 0x82,
-	0xa9, 0x88, 0xc3, 0x61, 0xa1, 0x19, 0xe6, 0xcf, 0x7d, 0x5a, 0xfc, 0x59, 0xbc, 0xff, 0x13, 0x00,
-	0x00, 0xff, 0xff, 0x82, 0xc7, 0xec, 0x4d, 0x48, 0x04, 0x00, 0x00,
+	// 888 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x55, 0x5d, 0x8f, 0xdb, 0x44,
+	0x14, 0xb5, 0x93, 0x6c, 0x36, 0xbe, 0xf9, 0x20, 0x3b, 0x0b, 0x2b, 0xb3, 0xe5, 0xa3, 0x35, 0xaa,
+	0xb4, 0x14, 0x69, 0x53, 0x42, 0xc5, 0x0b, 0x08, 0xa9, 0xc9, 0x7a, 0xb1, 0x69, 0xbb, 0x8b, 0xa6,
+	0x59, 0x1e, 0x78, 0xb1, 0x1c, 0x7b, 0xe2, 0x18, 0x12, 0x3b, 0x1d, 0x4f, 0xaa, 0xec, 0x33, 0xfc,
+	0x04, 0x1e, 0xf8, 0x07, 0xfc, 0x4d, 0xe4, 0x3b, 0xe3, 0x8f, 0x04, 0x5e, 0x10, 0x52, 0x9f, 0x92,
+	0x39, 0xf7, 0x9e, 0x99, 0x33, 0xe7, 0x5e, 0xdf, 0x81, 0x93, 0x0d, 0x63, 0x7c, 0xc4, 0xde, 0xb2,
+	0x44, 0x64, 0x97, 0x1b, 0x9e, 0x8a, 0x94, 0xb4, 0xf1, 0x27, 0x3b, 0x3f, 0x0d, 0xd2, 0xf5, 0x3a,
+	0x4d, 0x46, 0xf2, 0x47, 0x06, 0xcf, 0x3f, 0xc4, 0xfc, 0x60, 0xe9, 0xc7, 0x49, 0x90, 0x86, 0x0c,
+	0x89, 0x2a, 0x74, 0x86, 0x21, 0xc1, 0xfd, 0x24, 0xf3, 0x03, 0x11, 0x17, 0x14, 0xeb, 0x47, 0xe8,
+	0x4d, 0x8b, 0x7c, 0xca, 0x22, 0xf2, 0x08, 0x7a, 0x25, 0xdf, 0x8b, 0x43, 0x53, 0x7f, 0xa8, 0x5f,
+	0x18, 0xb4, 0x5b, 0x62, 0x6e, 0x48, 0x3e, 0x06, 0xc0, 0x9d, 0xbd, 0xc4, 0x5f, 0x33, 0xb3, 0x81,
+	0x09, 0x06, 0x22, 0x37, 0xfe, 0x9a, 0x59, 0x7f, 0xe9, 0xd0, 0x71, 0x13, 0xc1, 0x38, 0xcb, 0x04,
+	0x79, 0x5a, 0xe4, 0x8a, 0xfb, 0x0d, 0xc3, 0xcd, 0x06, 0xe3, 0x13, 0x79, 0x74, 0x76, 0x69, 0xe7,
+	0x91, 0xd9, 0xfd, 0x86, 0x29, 0x7a, 0xfe, 0x97, 0x5c, 0x01, 0xa9, 0x04, 0x70, 0x16, 0x79, 0x71,
+	0xb2, 0x48, 0xf1, 0x94, 0xee, 0xf8, 0xfd, 0x82, 0x59, 0x97, 0xec, 0x68, 0x74, 0x18, 0xd4, 0xd6,
+	0x6e, 0xb2, 0x48, 0x89, 0x09, 0xc7, 0x88, 0xb9, 0x57, 0x66, 0x13, 0x05, 0x16, 0xcb, 0x89, 0x01,
+	0xc7, 0x2a, 0xc9, 0xba, 0x83, 0x0e, 0x65, 0x51, 0x9c, 0x09, 0xc6, 0xc9, 0x05, 0xb4, 0xa5, 0xcf,
+	0xa6, 0xfe, 0xb0, 0x79, 0xd1, 0x1d, 0x0f, 0x8b, 0xa3, 0x8a, 0xab, 0x50, 0x15, 0x27, 0x9f, 0x42,
+	0x37, 0x48, 0x93, 0x6c, 0xbb, 0x66, 0x3c, 0x37, 0x48, 0xde, 0x1f, 0x0a, 0xc8, 0x0d, 0xad, 0x57,
+	0x60, 0x50, 0xf6, 0x0b, 0x43, 0x97, 0xc9, 0x67, 0xd0, 0x10, 0x3b, 0xbc, 0x78, 0x77, 0x7c, 0x5a,
+	0xec, 0x39, 0xab, 0xca, 0x40, 0x1b, 0x62, 0x47, 0x1e, 0x80, 0xc1, 0x38, 0x4f, 0xb9, 0xb7, 0xce,
+	0x22, 0xb5, 0x61, 0x07, 0x81, 0x57, 0x59, 0x64, 0x7d, 0x0d, 0x70, 0x97, 0xf0, 0xff, 0xac, 0xd3,
+	0x7a, 0x01, 0xdd, 0xd7, 0x71, 0x94, 0xb0, 0x10, 0x6d, 0x26, 0x1f, 0x81, 0x91, 0xc5, 0x51, 0xe2,
+	0x8b, 0x2d, 0x97, 0x85, 0xe8, 0xd1, 0x0a, 0x20, 0x9f, 0xa8, 0x3a, 0x4d, 0xee, 0x05, 0xcb, 0x50,
+	0x42, 0x8f, 0xd6, 0x10, 0xeb, 0x8f, 0x16, 0x1c, 0xc9, 0x7d, 0x2e, 0xa1, 0x53, 0x88, 0x51, 0xd7,
+	0x2a, 0x25, 0x14, 0x66, 0x3a, 0x1a, 0x2d, 0x73, 0xc8, 0x63, 0x38, 0x9a, 0xaf, 0xd2, 0xe0, 0x57,
+	0x55, 0xc2, 0xfe, 0xa5, 0xea, 0xd8, 0x49, 0x0e, 0x3a, 0x1a, 0x95, 0x51, 0xf2, 0x1c, 0xde, 0xab,
+	0xca, 0x8e, 0x07, 0x63, 0xe1, 0xba, 0xe3, 0xb3, 0x7f, 0xd4, 0x1c, 0x75, 0x38, 0x1a, 0x1d, 0x04,
+	0x7b, 0x08, 0xf9, 0x12, 0x0c, 0x5e, 0xf8, 0x6e, 0xb6, 0x90, 0x7c, 0x52, 0x49, 0x53, 0x01, 0x47,
+	0xa3, 0x55, 0x16, 0x79, 0x06, 0xb0, 0x2d, 0xbd, 0x35, 0x8f, 0x90, 0x43, 0x0a, 0x4e, 0xe5, 0xba,
+	0xa3, 0xd1, 0x5a, 0x1e, 0xf9, 0x0e, 0x06, 0x8b, 0x78, 0x95, 0xdb, 0x1d, 0x7a, 0xf2, 0x6e, 0xc7,
+	0xc8, 0xfc, 0xa0, 0x60, 0x5e, 0xab, 0x68, 0x71, 0xc7, 0xfe, 0xa2, 0x0e, 0x60, 0x73, 0x72, 0xe6,
+	0x8b, 0x94, 0x9b, 0x6d, 0x74, 0xba, 0x58, 0x92, 0x6f, 0xa0, 0x1f, 0x6c, 0x79, 0x96, 0x72, 0x2f,
+	0x77, 0x29, 0x16, 0x66, 0xe7, 0xa0, 0xef, 0x31, 0x38, 0xc5, 0x98, 0xa3, 0xd1, 0x5e, 0x50, 0x5b,
+	0xe7, 0xb2, 0x14, 0x99, 0xb3, 0x37, 0x5b, 0x96, 0x09, 0xd3, 0xd8, 0x97, 0x25, 0xd9, 0x54, 0x06,
+	0x73, 0x59, 0x41, 0x1d, 0xc8, 0x5b, 0x4b, 0x02, 0x26, 0x20, 0x6f, 0xb0, 0xcf, 0x73, 0x34, 0xaa,
+	0xe2, 0x93, 0x63, 0xd5, 0x0c, 0xd6, 0xef, 0x3a, 0xf4, 0xf7, 0x2e, 0x9b, 0x0f, 0x87, 0x60, 0xe9,
+	0x27, 0x09, 0x5b, 0x55, 0xd3, 0xc3, 0x50, 0x88, 0x1b, 0x92, 0x33, 0x68, 0x27, 0xdb, 0xf5, 0x9c,
+	0x71, 0x6c, 0x87, 0x16, 0x55, 0x2b, 0xf2, 0x2d, 0x74, 0x4b, 0x4b, 0xc5, 0xce, 0x6c, 0x62, 0x6f,
+	0x3f, 0x38, 0xf4, 0xb3, 0xfe, 0xdd, 0x40, 0x91, 0x3f, 0xdb, 0x59, 0x7f, 0xea, 0x70, 0xfa, 0x2f,
+	0x39, 0x84, 0x40, 0x4b, 0xec, 0x4a, 0x19, 0xf8, 0x9f, 0x5c, 0x03, 0x11, 0x3b, 0xef, 0xad, 0xbf,
+	0x8a, 0x43, 0x3f, 0x4f, 0xf2, 0xf2, 0x06, 0x42, 0x35, 0x83, 0xb1, 0x59, 0x7e, 0xa0, 0xbb, 0x9f,
+	0xca, 0x84, 0x69, 0x3e, 0x56, 0x86, 0xe2, 0x00, 0x21, 0x8f, 0xa1, 0xea, 0x3f, 0x39, 0x09, 0xe5,
+	0xa0, 0xe9, 0x97, 0x28, 0x4e, 0xc3, 0x37, 0xd0, 0xab, 0x17, 0xed, 0x70, 0x7a, 0xe8, 0x87, 0xd3,
+	0xe3, 0xc0, 0xc0, 0xc6, 0xa1, 0x81, 0x8f, 0xa0, 0x87, 0x2d, 0xe7, 0x29, 0x1b, 0x9b, 0x68, 0x63,
+	0x17, 0xb1, 0x1b, 0x84, 0xac, 0x5b, 0xe8, 0xef, 0x55, 0xfa, 0xff, 0x9e, 0x69, 0xfd, 0xa6, 0x43,
+	0x5b, 0xee, 0xf8, 0x0e, 0xe4, 0xe7, 0x2d, 0xc2, 0x76, 0x71, 0x26, 0x32, 0xfc, 0x86, 0x3b, 0x54,
+	0xad, 0x9e, 0xdc, 0x81, 0x51, 0x3e, 0x18, 0xa4, 0x07, 0x1d, 0x6a, 0x7f, 0xef, 0xbe, 0x9e, 0xd9,
+	0x74, 0xa8, 0x11, 0x03, 0x8e, 0x26, 0x2f, 0x6f, 0xa7, 0x2f, 0x86, 0x3a, 0xe9, 0x83, 0x31, 0x75,
+	0x9e, 0xbb, 0x37, 0xd3, 0xdb, 0x2b, 0x7b, 0xd8, 0xc8, 0x97, 0xd4, 0xfe, 0xc1, 0x9e, 0xce, 0xdc,
+	0xdb, 0x9b, 0x61, 0x93, 0x9c, 0x40, 0xff, 0xda, 0x7d, 0x39, 0xb3, 0xa9, 0x7d, 0x25, 0x09, 0xad,
+	0xf1, 0x33, 0x68, 0xdb, 0x72, 0xb0, 0x3f, 0x81, 0xd6, 0x74, 0xe9, 0x0b, 0xd2, 0xdf, 0x7b, 0x9f,
+	0xce, 0xf7, 0x97, 0x96, 0x76, 0xa1, 0x3f, 0xd5, 0x27, 0x5f, 0xfc, 0xfc, 0x79, 0x14, 0x8b, 0xe5,
+	0x76, 0x9e, 0x8f, 0xb3, 0xd1, 0xf2, 0x7e, 0xc3, 0xf8, 0x8a, 0x85, 0x11, 0xe3, 0xa3, 0x85, 0x3f,
+	0xe7, 0x71, 0x30, 0x92, 0x9c, 0x51, 0xfe, 0xea, 0xce, 0xe5, 0x9b, 0xfd, 0xd5, 0xdf, 0x03, 0x00,
+	0xf1, 0x60, 0xca, 0x79, 0xcf, 0x07, 0x00, 0x00,
 }
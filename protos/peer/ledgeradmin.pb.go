@@ -0,0 +1,362 @@
+// Code generated by protoc-gen-go.
+// source: peer/ledgeradmin.proto
+// DO NOT EDIT!
+
+package peer
+
+import proto "github.com/golang/protobuf/proto"
+import fmt "fmt"
+import math "math"
+import common "github.com/hyperledger/fabric/protos/common"
+
+import (
+	context "golang.org/x/net/context"
+	grpc "google.golang.org/grpc"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+type LedgerAdminRequest struct {
+	ChannelId  string `protobuf:"bytes,1,opt,name=channel_id,json=channelId" json:"channel_id,omitempty"`
+	Height     uint64 `protobuf:"varint,2,opt,name=height" json:"height,omitempty"`
+	BlockIndex bool   `protobuf:"varint,3,opt,name=block_index,json=blockIndex" json:"block_index,omitempty"`
+	Databases  bool   `protobuf:"varint,4,opt,name=databases" json:"databases,omitempty"`
+	Operation  string `protobuf:"bytes,5,opt,name=operation" json:"operation,omitempty"`
+}
+
+func (m *LedgerAdminRequest) Reset()                    { *m = LedgerAdminRequest{} }
+func (m *LedgerAdminRequest) String() string            { return proto.CompactTextString(m) }
+func (*LedgerAdminRequest) ProtoMessage()               {}
+func (*LedgerAdminRequest) Descriptor() ([]byte, []int) { return fileDescriptor13, []int{0} }
+
+type LedgerAdminResponse struct {
+	ChannelId      string `protobuf:"bytes,1,opt,name=channel_id,json=channelId" json:"channel_id,omitempty"`
+	Scheduled      bool   `protobuf:"varint,2,opt,name=scheduled" json:"scheduled,omitempty"`
+	Directory      string `protobuf:"bytes,3,opt,name=directory" json:"directory,omitempty"`
+	BlockNumber    uint64 `protobuf:"varint,4,opt,name=block_number,json=blockNumber" json:"block_number,omitempty"`
+	DurationMillis uint64 `protobuf:"varint,5,opt,name=duration_millis,json=durationMillis" json:"duration_millis,omitempty"`
+}
+
+func (m *LedgerAdminResponse) Reset()                    { *m = LedgerAdminResponse{} }
+func (m *LedgerAdminResponse) String() string            { return proto.CompactTextString(m) }
+func (*LedgerAdminResponse) ProtoMessage()               {}
+func (*LedgerAdminResponse) Descriptor() ([]byte, []int) { return fileDescriptor13, []int{1} }
+
+type LedgerHealth struct {
+	ChannelId         string `protobuf:"bytes,1,opt,name=channel_id,json=channelId" json:"channel_id,omitempty"`
+	Height            uint64 `protobuf:"varint,2,opt,name=height" json:"height,omitempty"`
+	Status            string `protobuf:"bytes,3,opt,name=status" json:"status,omitempty"`
+	RebuildBlockIndex bool   `protobuf:"varint,4,opt,name=rebuild_block_index,json=rebuildBlockIndex" json:"rebuild_block_index,omitempty"`
+	RollbackHeight    uint64 `protobuf:"varint,5,opt,name=rollback_height,json=rollbackHeight" json:"rollback_height,omitempty"`
+	RebuildDatabases  bool   `protobuf:"varint,6,opt,name=rebuild_databases,json=rebuildDatabases" json:"rebuild_databases,omitempty"`
+}
+
+func (m *LedgerHealth) Reset()                    { *m = LedgerHealth{} }
+func (m *LedgerHealth) String() string            { return proto.CompactTextString(m) }
+func (*LedgerHealth) ProtoMessage()               {}
+func (*LedgerHealth) Descriptor() ([]byte, []int) { return fileDescriptor13, []int{2} }
+
+type LedgerHealthResponse struct {
+	MaintenanceMode bool            `protobuf:"varint,1,opt,name=maintenance_mode,json=maintenanceMode" json:"maintenance_mode,omitempty"`
+	CommitsQuiesced bool            `protobuf:"varint,2,opt,name=commits_quiesced,json=commitsQuiesced" json:"commits_quiesced,omitempty"`
+	Ledgers         []*LedgerHealth `protobuf:"bytes,3,rep,name=ledgers" json:"ledgers,omitempty"`
+}
+
+func (m *LedgerHealthResponse) Reset()                    { *m = LedgerHealthResponse{} }
+func (m *LedgerHealthResponse) String() string            { return proto.CompactTextString(m) }
+func (*LedgerHealthResponse) ProtoMessage()               {}
+func (*LedgerHealthResponse) Descriptor() ([]byte, []int) { return fileDescriptor13, []int{3} }
+
+func (m *LedgerHealthResponse) GetLedgers() []*LedgerHealth {
+	if m != nil {
+		return m.Ledgers
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*LedgerAdminRequest)(nil), "protos.LedgerAdminRequest")
+	proto.RegisterType((*LedgerAdminResponse)(nil), "protos.LedgerAdminResponse")
+	proto.RegisterType((*LedgerHealth)(nil), "protos.LedgerHealth")
+	proto.RegisterType((*LedgerHealthResponse)(nil), "protos.LedgerHealthResponse")
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion3
+
+// Client API for LedgerAdmin service
+
+type LedgerAdminClient interface {
+	Prune(ctx context.Context, in *common.Envelope, opts ...grpc.CallOption) (*LedgerAdminResponse, error)
+	Reindex(ctx context.Context, in *common.Envelope, opts ...grpc.CallOption) (*LedgerAdminResponse, error)
+	Snapshot(ctx context.Context, in *common.Envelope, opts ...grpc.CallOption) (*LedgerAdminResponse, error)
+	Rollback(ctx context.Context, in *common.Envelope, opts ...grpc.CallOption) (*LedgerAdminResponse, error)
+	Compact(ctx context.Context, in *common.Envelope, opts ...grpc.CallOption) (*LedgerAdminResponse, error)
+	GetHealth(ctx context.Context, in *common.Envelope, opts ...grpc.CallOption) (*LedgerHealthResponse, error)
+}
+
+type ledgerAdminClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewLedgerAdminClient(cc *grpc.ClientConn) LedgerAdminClient {
+	return &ledgerAdminClient{cc}
+}
+
+func (c *ledgerAdminClient) Prune(ctx context.Context, in *common.Envelope, opts ...grpc.CallOption) (*LedgerAdminResponse, error) {
+	out := new(LedgerAdminResponse)
+	err := grpc.Invoke(ctx, "/protos.LedgerAdmin/Prune", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ledgerAdminClient) Reindex(ctx context.Context, in *common.Envelope, opts ...grpc.CallOption) (*LedgerAdminResponse, error) {
+	out := new(LedgerAdminResponse)
+	err := grpc.Invoke(ctx, "/protos.LedgerAdmin/Reindex", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ledgerAdminClient) Snapshot(ctx context.Context, in *common.Envelope, opts ...grpc.CallOption) (*LedgerAdminResponse, error) {
+	out := new(LedgerAdminResponse)
+	err := grpc.Invoke(ctx, "/protos.LedgerAdmin/Snapshot", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ledgerAdminClient) Rollback(ctx context.Context, in *common.Envelope, opts ...grpc.CallOption) (*LedgerAdminResponse, error) {
+	out := new(LedgerAdminResponse)
+	err := grpc.Invoke(ctx, "/protos.LedgerAdmin/Rollback", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ledgerAdminClient) Compact(ctx context.Context, in *common.Envelope, opts ...grpc.CallOption) (*LedgerAdminResponse, error) {
+	out := new(LedgerAdminResponse)
+	err := grpc.Invoke(ctx, "/protos.LedgerAdmin/Compact", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ledgerAdminClient) GetHealth(ctx context.Context, in *common.Envelope, opts ...grpc.CallOption) (*LedgerHealthResponse, error) {
+	out := new(LedgerHealthResponse)
+	err := grpc.Invoke(ctx, "/protos.LedgerAdmin/GetHealth", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for LedgerAdmin service
+
+type LedgerAdminServer interface {
+	Prune(context.Context, *common.Envelope) (*LedgerAdminResponse, error)
+	Reindex(context.Context, *common.Envelope) (*LedgerAdminResponse, error)
+	Snapshot(context.Context, *common.Envelope) (*LedgerAdminResponse, error)
+	Rollback(context.Context, *common.Envelope) (*LedgerAdminResponse, error)
+	Compact(context.Context, *common.Envelope) (*LedgerAdminResponse, error)
+	GetHealth(context.Context, *common.Envelope) (*LedgerHealthResponse, error)
+}
+
+func RegisterLedgerAdminServer(s *grpc.Server, srv LedgerAdminServer) {
+	s.RegisterService(&_LedgerAdmin_serviceDesc, srv)
+}
+
+func _LedgerAdmin_Prune_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(common.Envelope)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LedgerAdminServer).Prune(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protos.LedgerAdmin/Prune",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LedgerAdminServer).Prune(ctx, req.(*common.Envelope))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LedgerAdmin_Reindex_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(common.Envelope)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LedgerAdminServer).Reindex(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protos.LedgerAdmin/Reindex",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LedgerAdminServer).Reindex(ctx, req.(*common.Envelope))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LedgerAdmin_Snapshot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(common.Envelope)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LedgerAdminServer).Snapshot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protos.LedgerAdmin/Snapshot",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LedgerAdminServer).Snapshot(ctx, req.(*common.Envelope))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LedgerAdmin_Rollback_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(common.Envelope)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LedgerAdminServer).Rollback(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protos.LedgerAdmin/Rollback",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LedgerAdminServer).Rollback(ctx, req.(*common.Envelope))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LedgerAdmin_Compact_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(common.Envelope)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LedgerAdminServer).Compact(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protos.LedgerAdmin/Compact",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LedgerAdminServer).Compact(ctx, req.(*common.Envelope))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LedgerAdmin_GetHealth_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(common.Envelope)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LedgerAdminServer).GetHealth(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protos.LedgerAdmin/GetHealth",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LedgerAdminServer).GetHealth(ctx, req.(*common.Envelope))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _LedgerAdmin_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "protos.LedgerAdmin",
+	HandlerType: (*LedgerAdminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Prune",
+			Handler:    _LedgerAdmin_Prune_Handler,
+		},
+		{
+			MethodName: "Reindex",
+			Handler:    _LedgerAdmin_Reindex_Handler,
+		},
+		{
+			MethodName: "Snapshot",
+			Handler:    _LedgerAdmin_Snapshot_Handler,
+		},
+		{
+			MethodName: "Rollback",
+			Handler:    _LedgerAdmin_Rollback_Handler,
+		},
+		{
+			MethodName: "Compact",
+			Handler:    _LedgerAdmin_Compact_Handler,
+		},
+		{
+			MethodName: "GetHealth",
+			Handler:    _LedgerAdmin_GetHealth_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: fileDescriptor13,
+}
+
+func init() { proto.RegisterFile("peer/ledgeradmin.proto", fileDescriptor13) }
+
+var fileDescriptor13 = []byte{
+	// 541 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x94, 0xdd, 0x6e, 0xd3, 0x30,
+	0x14, 0xc7, 0xc9, 0xd6, 0x75, 0xcd, 0xd9, 0x44, 0x87, 0x3b, 0x55, 0xd5, 0x28, 0xa2, 0xf4, 0x66,
+	0x9d, 0x26, 0x35, 0xd2, 0xb8, 0x41, 0x20, 0x2e, 0x18, 0x20, 0x36, 0x89, 0x21, 0x08, 0x77, 0xdc,
+	0x44, 0x4e, 0x7c, 0x68, 0xac, 0x25, 0x76, 0x66, 0x3b, 0x88, 0xbd, 0x0b, 0x0f, 0xc0, 0x43, 0xf0,
+	0x36, 0xbc, 0x04, 0x97, 0x28, 0xb6, 0xfb, 0x25, 0x90, 0x10, 0xbd, 0x8a, 0xfc, 0x3b, 0xfe, 0xfb,
+	0xfc, 0xcf, 0x47, 0x0b, 0xfd, 0x0a, 0x51, 0x45, 0x05, 0xb2, 0x19, 0x2a, 0xca, 0x4a, 0x2e, 0xa6,
+	0x95, 0x92, 0x46, 0x92, 0xb6, 0xfd, 0xe8, 0xa3, 0x5e, 0x26, 0xcb, 0x52, 0x8a, 0xc8, 0x7d, 0x5c,
+	0x70, 0xfc, 0x3d, 0x00, 0xf2, 0xd6, 0x4a, 0x5e, 0x34, 0x92, 0x18, 0x6f, 0x6a, 0xd4, 0x86, 0x3c,
+	0x00, 0xc8, 0x72, 0x2a, 0x04, 0x16, 0x09, 0x67, 0x83, 0x60, 0x14, 0x4c, 0xc2, 0x38, 0xf4, 0xe4,
+	0x92, 0x91, 0x3e, 0xb4, 0x73, 0xe4, 0xb3, 0xdc, 0x0c, 0xb6, 0x46, 0xc1, 0xa4, 0x15, 0xfb, 0x13,
+	0x79, 0x08, 0x7b, 0x69, 0x21, 0xb3, 0xeb, 0x84, 0x0b, 0x86, 0x5f, 0x07, 0xdb, 0xa3, 0x60, 0xd2,
+	0x89, 0xc1, 0xa2, 0xcb, 0x86, 0x90, 0x21, 0x84, 0x8c, 0x1a, 0x9a, 0x52, 0x8d, 0x7a, 0xd0, 0xb2,
+	0xe1, 0x25, 0x68, 0xa2, 0xb2, 0x42, 0x45, 0x0d, 0x97, 0x62, 0xb0, 0xe3, 0x92, 0x2e, 0xc0, 0xf8,
+	0x47, 0x00, 0xbd, 0x35, 0xab, 0xba, 0x92, 0x42, 0xe3, 0xbf, 0xbc, 0x0e, 0x21, 0xd4, 0x59, 0x8e,
+	0xac, 0x2e, 0x90, 0x59, 0xbb, 0x9d, 0x78, 0x09, 0xac, 0x21, 0xae, 0x30, 0x33, 0x52, 0xdd, 0x5a,
+	0xbf, 0x61, 0xbc, 0x04, 0xe4, 0x11, 0xec, 0xbb, 0x7a, 0x44, 0x5d, 0xa6, 0xa8, 0xac, 0xe3, 0x56,
+	0xec, 0x6a, 0x7c, 0x67, 0x11, 0x39, 0x86, 0x2e, 0xab, 0x9d, 0xc3, 0xa4, 0xe4, 0x45, 0xc1, 0xb5,
+	0x75, 0xde, 0x8a, 0xef, 0xce, 0xf1, 0x95, 0xa5, 0xe3, 0x9f, 0x01, 0xec, 0x3b, 0xfb, 0x17, 0x48,
+	0x0b, 0x93, 0x6f, 0xda, 0xe3, 0x3e, 0xb4, 0xb5, 0xa1, 0xa6, 0xd6, 0xde, 0xae, 0x3f, 0x91, 0x29,
+	0xf4, 0x14, 0xa6, 0x35, 0x2f, 0x58, 0xb2, 0x3a, 0x03, 0xd7, 0xe4, 0x7b, 0x3e, 0x74, 0xbe, 0x1c,
+	0xc5, 0x31, 0x74, 0x95, 0x2c, 0x8a, 0x94, 0x66, 0xd7, 0x89, 0x4f, 0xe4, 0x8d, 0xcf, 0xf1, 0x85,
+	0x4b, 0x78, 0x0a, 0x73, 0x75, 0xb2, 0x9c, 0x5d, 0xdb, 0x3e, 0x7b, 0xe0, 0x03, 0xaf, 0xe6, 0x7c,
+	0xfc, 0x2d, 0x80, 0xc3, 0xd5, 0x2a, 0x17, 0x53, 0x3a, 0x81, 0x83, 0x92, 0x72, 0x61, 0x50, 0x50,
+	0x91, 0x61, 0x52, 0x4a, 0x86, 0xb6, 0xe6, 0x4e, 0xdc, 0x5d, 0xe1, 0x57, 0x92, 0xd9, 0xab, 0xcd,
+	0x8e, 0x72, 0xa3, 0x93, 0x9b, 0x9a, 0xa3, 0xce, 0x16, 0x83, 0xeb, 0x7a, 0xfe, 0xc1, 0x63, 0x32,
+	0x85, 0x5d, 0xb7, 0xf0, 0x4d, 0x37, 0xb6, 0x27, 0x7b, 0x67, 0x87, 0x6e, 0xaf, 0xf5, 0x74, 0xcd,
+	0xc4, 0xfc, 0xd2, 0xd9, 0xaf, 0x2d, 0xd8, 0x5b, 0xd9, 0x21, 0xf2, 0x04, 0x76, 0xde, 0xab, 0x5a,
+	0x20, 0x39, 0x98, 0xfa, 0x9f, 0xc5, 0x6b, 0xf1, 0x05, 0x0b, 0x59, 0xe1, 0xd1, 0xfd, 0xf5, 0x97,
+	0xd6, 0x76, 0x6e, 0x7c, 0x87, 0x3c, 0x85, 0xdd, 0x18, 0x6d, 0x8b, 0xff, 0x5f, 0xfb, 0x0c, 0x3a,
+	0x1f, 0x05, 0xad, 0x74, 0x2e, 0xcd, 0x46, 0xe2, 0xd8, 0x0f, 0x68, 0x23, 0xd7, 0x2f, 0x65, 0x59,
+	0xd1, 0x6c, 0x83, 0xc4, 0xcf, 0x21, 0x7c, 0x83, 0xc6, 0x2f, 0xef, 0x9f, 0xea, 0xe1, 0x5f, 0x3b,
+	0xbf, 0x90, 0x9f, 0x9f, 0x7e, 0x3a, 0x99, 0x71, 0x93, 0xd7, 0x69, 0xa3, 0x8c, 0xf2, 0xdb, 0x0a,
+	0x95, 0x9b, 0x4a, 0xf4, 0x99, 0xa6, 0x8a, 0x67, 0x91, 0x93, 0x47, 0x15, 0xa2, 0x4a, 0xdd, 0x7f,
+	0xd6, 0xe3, 0xdf, 0x03, 0x00, 0x04, 0xa6, 0x51, 0x94, 0xd4, 0x04, 0x00, 0x00,
+}
@@ -85,33 +85,79 @@ func (m *ChannelInfo) String() string            { return proto.CompactTextStrin
 func (*ChannelInfo) ProtoMessage()               {}
 func (*ChannelInfo) Descriptor() ([]byte, []int) { return fileDescriptor9, []int{3} }
 
+// ChaincodeEventsQueryResponse returns the events of a chaincode that pertain
+// to a query in qscc.go, such as GetEventsByChaincode
+type ChaincodeEventsQueryResponse struct {
+	Events []*ChaincodeEventInfo `protobuf:"bytes,1,rep,name=events" json:"events,omitempty"`
+}
+
+func (m *ChaincodeEventsQueryResponse) Reset()                    { *m = ChaincodeEventsQueryResponse{} }
+func (m *ChaincodeEventsQueryResponse) String() string            { return proto.CompactTextString(m) }
+func (*ChaincodeEventsQueryResponse) ProtoMessage()               {}
+func (*ChaincodeEventsQueryResponse) Descriptor() ([]byte, []int) { return fileDescriptor9, []int{4} }
+
+func (m *ChaincodeEventsQueryResponse) GetEvents() []*ChaincodeEventInfo {
+	if m != nil {
+		return m.Events
+	}
+	return nil
+}
+
+// ChaincodeEventInfo contains the information indexed for a chaincode event
+// at the commit of the transaction that set the event
+type ChaincodeEventInfo struct {
+	ChaincodeId string `protobuf:"bytes,1,opt,name=chaincode_id,json=chaincodeId" json:"chaincode_id,omitempty"`
+	TxId        string `protobuf:"bytes,2,opt,name=tx_id,json=txId" json:"tx_id,omitempty"`
+	EventName   string `protobuf:"bytes,3,opt,name=event_name,json=eventName" json:"event_name,omitempty"`
+	// the SHA256 hash of the payload of the event
+	PayloadHash []byte `protobuf:"bytes,4,opt,name=payload_hash,json=payloadHash,proto3" json:"payload_hash,omitempty"`
+	BlockNumber uint64 `protobuf:"varint,5,opt,name=block_number,json=blockNumber" json:"block_number,omitempty"`
+	// the position of the transaction in the block
+	TxNumber uint64 `protobuf:"varint,6,opt,name=tx_number,json=txNumber" json:"tx_number,omitempty"`
+}
+
+func (m *ChaincodeEventInfo) Reset()                    { *m = ChaincodeEventInfo{} }
+func (m *ChaincodeEventInfo) String() string            { return proto.CompactTextString(m) }
+func (*ChaincodeEventInfo) ProtoMessage()               {}
+func (*ChaincodeEventInfo) Descriptor() ([]byte, []int) { return fileDescriptor9, []int{5} }
+
 func init() {
 	proto.RegisterType((*ChaincodeQueryResponse)(nil), "protos.ChaincodeQueryResponse")
 	proto.RegisterType((*ChaincodeInfo)(nil), "protos.ChaincodeInfo")
 	proto.RegisterType((*ChannelQueryResponse)(nil), "protos.ChannelQueryResponse")
 	proto.RegisterType((*ChannelInfo)(nil), "protos.ChannelInfo")
+	proto.RegisterType((*ChaincodeEventsQueryResponse)(nil), "protos.ChaincodeEventsQueryResponse")
+	proto.RegisterType((*ChaincodeEventInfo)(nil), "protos.ChaincodeEventInfo")
 }
 
 func init() { proto.RegisterFile("peer/query.proto", fileDescriptor9) }
 
 var fileDescriptor9 = []byte{
-	// 273 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x54, 0x91, 0x5f, 0x4b, 0xc3, 0x30,
-	0x14, 0xc5, 0xa9, 0xfb, 0xa3, 0xbb, 0x43, 0x90, 0x38, 0x25, 0x2f, 0xc2, 0xe8, 0xd3, 0x44, 0x69,
-	0x40, 0xf1, 0x0b, 0xb8, 0x07, 0xd9, 0x93, 0xd8, 0x47, 0x5f, 0xa4, 0x4d, 0xef, 0xd6, 0xc0, 0x96,
-	0xc4, 0xa4, 0x1d, 0xec, 0x53, 0xf8, 0x95, 0xe5, 0x26, 0xeb, 0xe8, 0x9e, 0x7a, 0xee, 0xef, 0x9c,
-	0x4b, 0x39, 0xb9, 0x70, 0x63, 0x11, 0x9d, 0xf8, 0x6d, 0xd1, 0x1d, 0x32, 0xeb, 0x4c, 0x63, 0xd8,
-	0x38, 0x7c, 0x7c, 0xfa, 0x09, 0xf7, 0xcb, 0xba, 0x50, 0x5a, 0x9a, 0x0a, 0xbf, 0xc8, 0xcf, 0xd1,
-	0x5b, 0xa3, 0x3d, 0xb2, 0x37, 0x00, 0xd9, 0x39, 0x9e, 0x27, 0xf3, 0xc1, 0x62, 0xfa, 0x72, 0x17,
-	0xb7, 0x7d, 0x76, 0xda, 0x59, 0xe9, 0xb5, 0xc9, 0x7b, 0xc1, 0xf4, 0x2f, 0x81, 0xeb, 0x33, 0x97,
-	0x31, 0x18, 0xea, 0x62, 0x87, 0x3c, 0x99, 0x27, 0x8b, 0x49, 0x1e, 0x34, 0xe3, 0x70, 0xb9, 0x47,
-	0xe7, 0x95, 0xd1, 0xfc, 0x22, 0xe0, 0x6e, 0xa4, 0xb4, 0x2d, 0x9a, 0x9a, 0x0f, 0x62, 0x9a, 0x34,
-	0x9b, 0xc1, 0x48, 0x69, 0xdb, 0x36, 0x7c, 0x18, 0x60, 0x1c, 0x28, 0x89, 0x5e, 0x4a, 0x3e, 0x8a,
-	0x49, 0xd2, 0xc4, 0xf6, 0xc4, 0xc6, 0x91, 0x91, 0x4e, 0x3f, 0x60, 0xb6, 0xac, 0x0b, 0xad, 0x71,
-	0x7b, 0x5e, 0x50, 0xc0, 0x95, 0x8c, 0xbc, 0xab, 0x77, 0xdb, 0xab, 0x47, 0x3c, 0x94, 0x3b, 0x85,
-	0xd2, 0x67, 0x98, 0xf6, 0x0c, 0xf6, 0x10, 0x1e, 0x88, 0xc6, 0x1f, 0x55, 0x1d, 0xdb, 0x4d, 0x8e,
-	0x64, 0x55, 0xbd, 0x3f, 0x7d, 0x3f, 0x6e, 0x54, 0x53, 0xb7, 0x65, 0x26, 0xcd, 0x4e, 0xd4, 0x07,
-	0x8b, 0x6e, 0x8b, 0xd5, 0x06, 0x9d, 0x58, 0x17, 0xa5, 0x53, 0x52, 0xc4, 0x7f, 0x09, 0x3a, 0x4d,
-	0x19, 0xcf, 0xf1, 0xfa, 0x1f, 0x00, 0x00, 0xff, 0xff, 0x0c, 0x71, 0x90, 0xfd, 0xa9, 0x01, 0x00,
+	// 401 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x64, 0x92, 0xdf, 0x6e, 0xd3, 0x30,
+	0x14, 0xc6, 0x15, 0xd6, 0x96, 0xf5, 0xa4, 0x48, 0xc8, 0x1b, 0x28, 0xe2, 0x8f, 0xb4, 0xe5, 0x6a,
+	0x08, 0xd4, 0x48, 0x43, 0xbc, 0x00, 0x13, 0x82, 0xde, 0x0c, 0x91, 0x4b, 0x6e, 0x22, 0xc7, 0x39,
+	0x9b, 0x23, 0x52, 0x3b, 0xd8, 0x4e, 0x95, 0x3e, 0x05, 0x6f, 0xc6, 0x33, 0x21, 0x1f, 0x27, 0xa1,
+	0x55, 0xaf, 0x72, 0xfc, 0xfb, 0x3e, 0xc7, 0x3e, 0xdf, 0x31, 0x3c, 0x6f, 0x11, 0x4d, 0xf6, 0xbb,
+	0x43, 0xb3, 0x5f, 0xb7, 0x46, 0x3b, 0xcd, 0x16, 0xf4, 0xb1, 0xe9, 0x77, 0x78, 0x79, 0x27, 0x79,
+	0xad, 0x84, 0xae, 0xf0, 0x87, 0xd7, 0x73, 0xb4, 0xad, 0x56, 0x16, 0xd9, 0x27, 0x00, 0x31, 0x2a,
+	0x36, 0x89, 0xae, 0xce, 0x6e, 0xe2, 0xdb, 0x17, 0x61, 0xb7, 0x5d, 0x4f, 0x7b, 0x36, 0xea, 0x41,
+	0xe7, 0x07, 0xc6, 0xf4, 0x4f, 0x04, 0xcf, 0x8e, 0x54, 0xc6, 0x60, 0xa6, 0xf8, 0x16, 0x93, 0xe8,
+	0x2a, 0xba, 0x59, 0xe6, 0x54, 0xb3, 0x04, 0x9e, 0xee, 0xd0, 0xd8, 0x5a, 0xab, 0xe4, 0x09, 0xe1,
+	0x71, 0xe9, 0xdd, 0x2d, 0x77, 0x32, 0x39, 0x0b, 0x6e, 0x5f, 0xb3, 0x4b, 0x98, 0xd7, 0xaa, 0xed,
+	0x5c, 0x32, 0x23, 0x18, 0x16, 0xde, 0x89, 0x56, 0x88, 0x64, 0x1e, 0x9c, 0xbe, 0xf6, 0x6c, 0xe7,
+	0xd9, 0x22, 0x30, 0x5f, 0xa7, 0x5f, 0xe1, 0xf2, 0x4e, 0x72, 0xa5, 0xb0, 0x39, 0x6e, 0x30, 0x83,
+	0x73, 0x11, 0xf8, 0xd8, 0xde, 0xc5, 0x41, 0x7b, 0x9e, 0x53, 0x73, 0x93, 0x29, 0xfd, 0x00, 0xf1,
+	0x81, 0xc0, 0xde, 0x52, 0x40, 0x7e, 0x59, 0xd4, 0xd5, 0xd0, 0xdd, 0x72, 0x20, 0x9b, 0x2a, 0xcd,
+	0xe1, 0xcd, 0x94, 0xc3, 0x97, 0x1d, 0x2a, 0x67, 0x8f, 0x8f, 0xbf, 0x85, 0x05, 0x12, 0x1e, 0x0e,
+	0x7f, 0x75, 0x92, 0x2d, 0xed, 0xa2, 0x3b, 0x0c, 0xce, 0xf4, 0x6f, 0x04, 0xec, 0x54, 0x66, 0xd7,
+	0xb0, 0x9a, 0x26, 0xf0, 0xff, 0x2e, 0xf1, 0xc4, 0x36, 0x15, 0xbb, 0x80, 0xb9, 0xeb, 0xbd, 0x16,
+	0xe2, 0x9e, 0xb9, 0x7e, 0x53, 0xf9, 0x0e, 0xe8, 0xc7, 0x05, 0xcd, 0x27, 0x24, 0xbe, 0x24, 0x72,
+	0xef, 0x87, 0x74, 0x0d, 0xab, 0x96, 0xef, 0x1b, 0xcd, 0xab, 0x42, 0x72, 0x2b, 0x29, 0xfd, 0x55,
+	0x1e, 0x0f, 0xec, 0x1b, 0xb7, 0xd2, 0x5b, 0xca, 0x46, 0x8b, 0x5f, 0x85, 0xea, 0xb6, 0x25, 0x1a,
+	0x9a, 0xc5, 0x2c, 0x8f, 0x89, 0xdd, 0x13, 0x62, 0xaf, 0x61, 0xe9, 0xfa, 0x51, 0x5f, 0x90, 0x7e,
+	0xee, 0xfa, 0x20, 0x7e, 0x7e, 0xff, 0xf3, 0xdd, 0x63, 0xed, 0x64, 0x57, 0xae, 0x85, 0xde, 0x66,
+	0x72, 0xdf, 0xa2, 0x69, 0xb0, 0x7a, 0x44, 0x93, 0x3d, 0xf0, 0xd2, 0xd4, 0x22, 0x0b, 0x99, 0x64,
+	0xfe, 0xfd, 0x96, 0xe1, 0xcd, 0x7e, 0xfc, 0x37, 0x00, 0x40, 0xcd, 0x03, 0x88, 0xce, 0x02, 0x00,
 	0x00,
 }
@@ -0,0 +1,223 @@
+// Code generated by protoc-gen-go.
+// source: peer/snapshot.proto
+// DO NOT EDIT!
+
+package peer
+
+import proto "github.com/golang/protobuf/proto"
+import fmt "fmt"
+import math "math"
+import common "github.com/hyperledger/fabric/protos/common"
+
+import (
+	context "golang.org/x/net/context"
+	grpc "google.golang.org/grpc"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+type SnapshotRequest struct {
+	MinHeight uint64 `protobuf:"varint,1,opt,name=min_height,json=minHeight" json:"min_height,omitempty"`
+}
+
+func (m *SnapshotRequest) Reset()                    { *m = SnapshotRequest{} }
+func (m *SnapshotRequest) String() string            { return proto.CompactTextString(m) }
+func (*SnapshotRequest) ProtoMessage()               {}
+func (*SnapshotRequest) Descriptor() ([]byte, []int) { return fileDescriptor11, []int{0} }
+
+type SnapshotChunk struct {
+	FileName string `protobuf:"bytes,1,opt,name=file_name,json=fileName" json:"file_name,omitempty"`
+	Data     []byte `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+}
+
+func (m *SnapshotChunk) Reset()                    { *m = SnapshotChunk{} }
+func (m *SnapshotChunk) String() string            { return proto.CompactTextString(m) }
+func (*SnapshotChunk) ProtoMessage()               {}
+func (*SnapshotChunk) Descriptor() ([]byte, []int) { return fileDescriptor11, []int{1} }
+
+type CommitHashRequest struct {
+	BlockNumber uint64 `protobuf:"varint,1,opt,name=block_number,json=blockNumber" json:"block_number,omitempty"`
+}
+
+func (m *CommitHashRequest) Reset()                    { *m = CommitHashRequest{} }
+func (m *CommitHashRequest) String() string            { return proto.CompactTextString(m) }
+func (*CommitHashRequest) ProtoMessage()               {}
+func (*CommitHashRequest) Descriptor() ([]byte, []int) { return fileDescriptor11, []int{2} }
+
+type CommitHashResponse struct {
+	CommitHash []byte `protobuf:"bytes,1,opt,name=commit_hash,json=commitHash,proto3" json:"commit_hash,omitempty"`
+}
+
+func (m *CommitHashResponse) Reset()                    { *m = CommitHashResponse{} }
+func (m *CommitHashResponse) String() string            { return proto.CompactTextString(m) }
+func (*CommitHashResponse) ProtoMessage()               {}
+func (*CommitHashResponse) Descriptor() ([]byte, []int) { return fileDescriptor11, []int{3} }
+
+func init() {
+	proto.RegisterType((*SnapshotRequest)(nil), "protos.SnapshotRequest")
+	proto.RegisterType((*SnapshotChunk)(nil), "protos.SnapshotChunk")
+	proto.RegisterType((*CommitHashRequest)(nil), "protos.CommitHashRequest")
+	proto.RegisterType((*CommitHashResponse)(nil), "protos.CommitHashResponse")
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion3
+
+// Client API for Snapshot service
+
+type SnapshotClient interface {
+	Fetch(ctx context.Context, in *common.Envelope, opts ...grpc.CallOption) (Snapshot_FetchClient, error)
+	GetCommitHash(ctx context.Context, in *common.Envelope, opts ...grpc.CallOption) (*CommitHashResponse, error)
+}
+
+type snapshotClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewSnapshotClient(cc *grpc.ClientConn) SnapshotClient {
+	return &snapshotClient{cc}
+}
+
+func (c *snapshotClient) Fetch(ctx context.Context, in *common.Envelope, opts ...grpc.CallOption) (Snapshot_FetchClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Snapshot_serviceDesc.Streams[0], c.cc, "/protos.Snapshot/Fetch", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &snapshotFetchClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Snapshot_FetchClient interface {
+	Recv() (*SnapshotChunk, error)
+	grpc.ClientStream
+}
+
+type snapshotFetchClient struct {
+	grpc.ClientStream
+}
+
+func (x *snapshotFetchClient) Recv() (*SnapshotChunk, error) {
+	m := new(SnapshotChunk)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *snapshotClient) GetCommitHash(ctx context.Context, in *common.Envelope, opts ...grpc.CallOption) (*CommitHashResponse, error) {
+	out := new(CommitHashResponse)
+	err := grpc.Invoke(ctx, "/protos.Snapshot/GetCommitHash", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for Snapshot service
+
+type SnapshotServer interface {
+	Fetch(*common.Envelope, Snapshot_FetchServer) error
+	GetCommitHash(context.Context, *common.Envelope) (*CommitHashResponse, error)
+}
+
+func RegisterSnapshotServer(s *grpc.Server, srv SnapshotServer) {
+	s.RegisterService(&_Snapshot_serviceDesc, srv)
+}
+
+func _Snapshot_Fetch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(common.Envelope)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SnapshotServer).Fetch(m, &snapshotFetchServer{stream})
+}
+
+type Snapshot_FetchServer interface {
+	Send(*SnapshotChunk) error
+	grpc.ServerStream
+}
+
+type snapshotFetchServer struct {
+	grpc.ServerStream
+}
+
+func (x *snapshotFetchServer) Send(m *SnapshotChunk) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Snapshot_GetCommitHash_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(common.Envelope)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SnapshotServer).GetCommitHash(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protos.Snapshot/GetCommitHash",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SnapshotServer).GetCommitHash(ctx, req.(*common.Envelope))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Snapshot_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "protos.Snapshot",
+	HandlerType: (*SnapshotServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetCommitHash",
+			Handler:    _Snapshot_GetCommitHash_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Fetch",
+			Handler:       _Snapshot_Fetch_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: fileDescriptor11,
+}
+
+func init() { proto.RegisterFile("peer/snapshot.proto", fileDescriptor11) }
+
+var fileDescriptor11 = []byte{
+	// 308 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x91, 0xcb, 0x4b, 0xf3, 0x40,
+	0x14, 0xc5, 0x9b, 0x8f, 0x7e, 0xd2, 0xde, 0xb6, 0xa8, 0x53, 0x84, 0x52, 0x11, 0x6b, 0x56, 0x15,
+	0xa1, 0x29, 0xbe, 0xb6, 0x8a, 0x45, 0xed, 0xaa, 0x8b, 0xb8, 0x73, 0x13, 0x26, 0xe9, 0x6d, 0x67,
+	0x68, 0xe6, 0xe1, 0xcc, 0x44, 0x70, 0xe9, 0x7f, 0x2e, 0x9d, 0x24, 0x3e, 0xd0, 0xd5, 0x1d, 0xce,
+	0x9c, 0x1f, 0xe7, 0x3e, 0xa0, 0xaf, 0x11, 0x4d, 0x64, 0x25, 0xd5, 0x96, 0x29, 0x37, 0xd1, 0x46,
+	0x39, 0x45, 0x76, 0x7c, 0xb1, 0xc3, 0x7e, 0xa6, 0x84, 0x50, 0x32, 0x2a, 0x4b, 0xf9, 0x19, 0x4e,
+	0x61, 0xf7, 0xa9, 0xb2, 0xc7, 0xf8, 0x52, 0xa0, 0x75, 0xe4, 0x08, 0x40, 0x70, 0x99, 0x30, 0xe4,
+	0x6b, 0xe6, 0x06, 0xc1, 0x28, 0x18, 0x37, 0xe3, 0xb6, 0xe0, 0x72, 0xee, 0x85, 0xf0, 0x16, 0x7a,
+	0x35, 0x31, 0x63, 0x85, 0xdc, 0x90, 0x43, 0x68, 0xaf, 0x78, 0x8e, 0x89, 0xa4, 0x02, 0xbd, 0xbd,
+	0x1d, 0xb7, 0xb6, 0xc2, 0x82, 0x0a, 0x24, 0x04, 0x9a, 0x4b, 0xea, 0xe8, 0xe0, 0xdf, 0x28, 0x18,
+	0x77, 0x63, 0xff, 0x0e, 0xaf, 0x61, 0x7f, 0xa6, 0x84, 0xe0, 0x6e, 0x4e, 0x2d, 0xab, 0x53, 0x4f,
+	0xa0, 0x9b, 0xe6, 0x2a, 0xdb, 0x24, 0xb2, 0x10, 0x29, 0x9a, 0x2a, 0xb7, 0xe3, 0xb5, 0x85, 0x97,
+	0xc2, 0x2b, 0x20, 0xdf, 0x39, 0xab, 0x95, 0xb4, 0x48, 0x8e, 0xa1, 0x93, 0x79, 0x35, 0x61, 0xd4,
+	0x32, 0xcf, 0x75, 0x63, 0xc8, 0x3e, 0x8d, 0xe7, 0xef, 0x01, 0xb4, 0xea, 0x8e, 0xc9, 0x25, 0xfc,
+	0x7f, 0x40, 0x97, 0x31, 0xb2, 0x37, 0xa9, 0xf6, 0x70, 0x2f, 0x5f, 0x31, 0x57, 0x1a, 0x87, 0x07,
+	0xe5, 0x4a, 0xec, 0xe4, 0xc7, 0x78, 0x61, 0x63, 0x1a, 0x90, 0x1b, 0xe8, 0x3d, 0xa2, 0xfb, 0x0a,
+	0xff, 0x83, 0x1e, 0xd6, 0xf4, 0xef, 0x16, 0xc3, 0xc6, 0xdd, 0xd9, 0xf3, 0xe9, 0x9a, 0x3b, 0x56,
+	0xa4, 0x5b, 0x2e, 0x62, 0x6f, 0x1a, 0x4d, 0x8e, 0xcb, 0x35, 0x9a, 0x68, 0x45, 0x53, 0xc3, 0xb3,
+	0xa8, 0x84, 0xa3, 0xed, 0xfd, 0xd2, 0xf2, 0x60, 0x17, 0x1f, 0x03, 0x00, 0x27, 0x96, 0x17, 0xdc,
+	0xce, 0x01, 0x00, 0x00,
+}
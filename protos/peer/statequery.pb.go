@@ -0,0 +1,479 @@
+// Code generated by protoc-gen-go.
+// source: peer/statequery.proto
+// DO NOT EDIT!
+
+package peer
+
+import proto "github.com/golang/protobuf/proto"
+import fmt "fmt"
+import math "math"
+import common "github.com/hyperledger/fabric/protos/common"
+
+import (
+	context "golang.org/x/net/context"
+	grpc "google.golang.org/grpc"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+type StateKeyRequest struct {
+	Namespace string `protobuf:"bytes,1,opt,name=namespace" json:"namespace,omitempty"`
+	Key       string `protobuf:"bytes,2,opt,name=key" json:"key,omitempty"`
+}
+
+func (m *StateKeyRequest) Reset()                    { *m = StateKeyRequest{} }
+func (m *StateKeyRequest) String() string            { return proto.CompactTextString(m) }
+func (*StateKeyRequest) ProtoMessage()               {}
+func (*StateKeyRequest) Descriptor() ([]byte, []int) { return fileDescriptor12, []int{0} }
+
+type StateRangeRequest struct {
+	Namespace string `protobuf:"bytes,1,opt,name=namespace" json:"namespace,omitempty"`
+	StartKey  string `protobuf:"bytes,2,opt,name=start_key,json=startKey" json:"start_key,omitempty"`
+	EndKey    string `protobuf:"bytes,3,opt,name=end_key,json=endKey" json:"end_key,omitempty"`
+}
+
+func (m *StateRangeRequest) Reset()                    { *m = StateRangeRequest{} }
+func (m *StateRangeRequest) String() string            { return proto.CompactTextString(m) }
+func (*StateRangeRequest) ProtoMessage()               {}
+func (*StateRangeRequest) Descriptor() ([]byte, []int) { return fileDescriptor12, []int{1} }
+
+type StateRichQueryRequest struct {
+	Namespace string `protobuf:"bytes,1,opt,name=namespace" json:"namespace,omitempty"`
+	Query     string `protobuf:"bytes,2,opt,name=query" json:"query,omitempty"`
+}
+
+func (m *StateRichQueryRequest) Reset()                    { *m = StateRichQueryRequest{} }
+func (m *StateRichQueryRequest) String() string            { return proto.CompactTextString(m) }
+func (*StateRichQueryRequest) ProtoMessage()               {}
+func (*StateRichQueryRequest) Descriptor() ([]byte, []int) { return fileDescriptor12, []int{2} }
+
+// KeyModificationResult is a value set to a key by a transaction
+type KeyModificationResult struct {
+	TxId  string `protobuf:"bytes,1,opt,name=tx_id,json=txId" json:"tx_id,omitempty"`
+	Value []byte `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+func (m *KeyModificationResult) Reset()                    { *m = KeyModificationResult{} }
+func (m *KeyModificationResult) String() string            { return proto.CompactTextString(m) }
+func (*KeyModificationResult) ProtoMessage()               {}
+func (*KeyModificationResult) Descriptor() ([]byte, []int) { return fileDescriptor12, []int{3} }
+
+// StateAsOfRequest requests the value a key had at a block height, that is after
+// the blocks below the height were committed
+type StateAsOfRequest struct {
+	Namespace   string `protobuf:"bytes,1,opt,name=namespace" json:"namespace,omitempty"`
+	Key         string `protobuf:"bytes,2,opt,name=key" json:"key,omitempty"`
+	BlockHeight uint64 `protobuf:"varint,3,opt,name=block_height,json=blockHeight" json:"block_height,omitempty"`
+}
+
+func (m *StateAsOfRequest) Reset()                    { *m = StateAsOfRequest{} }
+func (m *StateAsOfRequest) String() string            { return proto.CompactTextString(m) }
+func (*StateAsOfRequest) ProtoMessage()               {}
+func (*StateAsOfRequest) Descriptor() ([]byte, []int) { return fileDescriptor12, []int{4} }
+
+// StateUpdatesRequest requests the net changes to the keys of a namespace made by
+// the blocks from the from height (inclusive) to the to height (exclusive)
+type StateUpdatesRequest struct {
+	Namespace  string `protobuf:"bytes,1,opt,name=namespace" json:"namespace,omitempty"`
+	FromHeight uint64 `protobuf:"varint,2,opt,name=from_height,json=fromHeight" json:"from_height,omitempty"`
+	ToHeight   uint64 `protobuf:"varint,3,opt,name=to_height,json=toHeight" json:"to_height,omitempty"`
+}
+
+func (m *StateUpdatesRequest) Reset()                    { *m = StateUpdatesRequest{} }
+func (m *StateUpdatesRequest) String() string            { return proto.CompactTextString(m) }
+func (*StateUpdatesRequest) ProtoMessage()               {}
+func (*StateUpdatesRequest) Descriptor() ([]byte, []int) { return fileDescriptor12, []int{5} }
+
+func init() {
+	proto.RegisterType((*StateKeyRequest)(nil), "protos.StateKeyRequest")
+	proto.RegisterType((*StateRangeRequest)(nil), "protos.StateRangeRequest")
+	proto.RegisterType((*StateRichQueryRequest)(nil), "protos.StateRichQueryRequest")
+	proto.RegisterType((*KeyModificationResult)(nil), "protos.KeyModificationResult")
+	proto.RegisterType((*StateAsOfRequest)(nil), "protos.StateAsOfRequest")
+	proto.RegisterType((*StateUpdatesRequest)(nil), "protos.StateUpdatesRequest")
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion3
+
+// Client API for StateQuery service
+
+type StateQueryClient interface {
+	GetState(ctx context.Context, in *common.Envelope, opts ...grpc.CallOption) (*QueryStateKeyValue, error)
+	GetStateByRange(ctx context.Context, in *common.Envelope, opts ...grpc.CallOption) (StateQuery_GetStateByRangeClient, error)
+	GetQueryResult(ctx context.Context, in *common.Envelope, opts ...grpc.CallOption) (StateQuery_GetQueryResultClient, error)
+	GetHistoryForKey(ctx context.Context, in *common.Envelope, opts ...grpc.CallOption) (StateQuery_GetHistoryForKeyClient, error)
+	GetStateAsOf(ctx context.Context, in *common.Envelope, opts ...grpc.CallOption) (*QueryStateKeyValue, error)
+	GetStateUpdatesBetween(ctx context.Context, in *common.Envelope, opts ...grpc.CallOption) (StateQuery_GetStateUpdatesBetweenClient, error)
+}
+
+type stateQueryClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewStateQueryClient(cc *grpc.ClientConn) StateQueryClient {
+	return &stateQueryClient{cc}
+}
+
+func (c *stateQueryClient) GetState(ctx context.Context, in *common.Envelope, opts ...grpc.CallOption) (*QueryStateKeyValue, error) {
+	out := new(QueryStateKeyValue)
+	err := grpc.Invoke(ctx, "/protos.StateQuery/GetState", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stateQueryClient) GetStateByRange(ctx context.Context, in *common.Envelope, opts ...grpc.CallOption) (StateQuery_GetStateByRangeClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_StateQuery_serviceDesc.Streams[0], c.cc, "/protos.StateQuery/GetStateByRange", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &stateQueryGetStateByRangeClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type StateQuery_GetStateByRangeClient interface {
+	Recv() (*QueryStateKeyValue, error)
+	grpc.ClientStream
+}
+
+type stateQueryGetStateByRangeClient struct {
+	grpc.ClientStream
+}
+
+func (x *stateQueryGetStateByRangeClient) Recv() (*QueryStateKeyValue, error) {
+	m := new(QueryStateKeyValue)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *stateQueryClient) GetQueryResult(ctx context.Context, in *common.Envelope, opts ...grpc.CallOption) (StateQuery_GetQueryResultClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_StateQuery_serviceDesc.Streams[1], c.cc, "/protos.StateQuery/GetQueryResult", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &stateQueryGetQueryResultClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type StateQuery_GetQueryResultClient interface {
+	Recv() (*QueryStateKeyValue, error)
+	grpc.ClientStream
+}
+
+type stateQueryGetQueryResultClient struct {
+	grpc.ClientStream
+}
+
+func (x *stateQueryGetQueryResultClient) Recv() (*QueryStateKeyValue, error) {
+	m := new(QueryStateKeyValue)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *stateQueryClient) GetHistoryForKey(ctx context.Context, in *common.Envelope, opts ...grpc.CallOption) (StateQuery_GetHistoryForKeyClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_StateQuery_serviceDesc.Streams[2], c.cc, "/protos.StateQuery/GetHistoryForKey", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &stateQueryGetHistoryForKeyClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type StateQuery_GetHistoryForKeyClient interface {
+	Recv() (*KeyModificationResult, error)
+	grpc.ClientStream
+}
+
+type stateQueryGetHistoryForKeyClient struct {
+	grpc.ClientStream
+}
+
+func (x *stateQueryGetHistoryForKeyClient) Recv() (*KeyModificationResult, error) {
+	m := new(KeyModificationResult)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *stateQueryClient) GetStateAsOf(ctx context.Context, in *common.Envelope, opts ...grpc.CallOption) (*QueryStateKeyValue, error) {
+	out := new(QueryStateKeyValue)
+	err := grpc.Invoke(ctx, "/protos.StateQuery/GetStateAsOf", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stateQueryClient) GetStateUpdatesBetween(ctx context.Context, in *common.Envelope, opts ...grpc.CallOption) (StateQuery_GetStateUpdatesBetweenClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_StateQuery_serviceDesc.Streams[3], c.cc, "/protos.StateQuery/GetStateUpdatesBetween", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &stateQueryGetStateUpdatesBetweenClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type StateQuery_GetStateUpdatesBetweenClient interface {
+	Recv() (*QueryStateKeyValue, error)
+	grpc.ClientStream
+}
+
+type stateQueryGetStateUpdatesBetweenClient struct {
+	grpc.ClientStream
+}
+
+func (x *stateQueryGetStateUpdatesBetweenClient) Recv() (*QueryStateKeyValue, error) {
+	m := new(QueryStateKeyValue)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Server API for StateQuery service
+
+type StateQueryServer interface {
+	GetState(context.Context, *common.Envelope) (*QueryStateKeyValue, error)
+	GetStateByRange(*common.Envelope, StateQuery_GetStateByRangeServer) error
+	GetQueryResult(*common.Envelope, StateQuery_GetQueryResultServer) error
+	GetHistoryForKey(*common.Envelope, StateQuery_GetHistoryForKeyServer) error
+	GetStateAsOf(context.Context, *common.Envelope) (*QueryStateKeyValue, error)
+	GetStateUpdatesBetween(*common.Envelope, StateQuery_GetStateUpdatesBetweenServer) error
+}
+
+func RegisterStateQueryServer(s *grpc.Server, srv StateQueryServer) {
+	s.RegisterService(&_StateQuery_serviceDesc, srv)
+}
+
+func _StateQuery_GetState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(common.Envelope)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StateQueryServer).GetState(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protos.StateQuery/GetState",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StateQueryServer).GetState(ctx, req.(*common.Envelope))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StateQuery_GetStateByRange_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(common.Envelope)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(StateQueryServer).GetStateByRange(m, &stateQueryGetStateByRangeServer{stream})
+}
+
+type StateQuery_GetStateByRangeServer interface {
+	Send(*QueryStateKeyValue) error
+	grpc.ServerStream
+}
+
+type stateQueryGetStateByRangeServer struct {
+	grpc.ServerStream
+}
+
+func (x *stateQueryGetStateByRangeServer) Send(m *QueryStateKeyValue) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _StateQuery_GetQueryResult_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(common.Envelope)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(StateQueryServer).GetQueryResult(m, &stateQueryGetQueryResultServer{stream})
+}
+
+type StateQuery_GetQueryResultServer interface {
+	Send(*QueryStateKeyValue) error
+	grpc.ServerStream
+}
+
+type stateQueryGetQueryResultServer struct {
+	grpc.ServerStream
+}
+
+func (x *stateQueryGetQueryResultServer) Send(m *QueryStateKeyValue) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _StateQuery_GetHistoryForKey_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(common.Envelope)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(StateQueryServer).GetHistoryForKey(m, &stateQueryGetHistoryForKeyServer{stream})
+}
+
+type StateQuery_GetHistoryForKeyServer interface {
+	Send(*KeyModificationResult) error
+	grpc.ServerStream
+}
+
+type stateQueryGetHistoryForKeyServer struct {
+	grpc.ServerStream
+}
+
+func (x *stateQueryGetHistoryForKeyServer) Send(m *KeyModificationResult) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _StateQuery_GetStateAsOf_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(common.Envelope)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StateQueryServer).GetStateAsOf(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protos.StateQuery/GetStateAsOf",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StateQueryServer).GetStateAsOf(ctx, req.(*common.Envelope))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StateQuery_GetStateUpdatesBetween_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(common.Envelope)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(StateQueryServer).GetStateUpdatesBetween(m, &stateQueryGetStateUpdatesBetweenServer{stream})
+}
+
+type StateQuery_GetStateUpdatesBetweenServer interface {
+	Send(*QueryStateKeyValue) error
+	grpc.ServerStream
+}
+
+type stateQueryGetStateUpdatesBetweenServer struct {
+	grpc.ServerStream
+}
+
+func (x *stateQueryGetStateUpdatesBetweenServer) Send(m *QueryStateKeyValue) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+var _StateQuery_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "protos.StateQuery",
+	HandlerType: (*StateQueryServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetState",
+			Handler:    _StateQuery_GetState_Handler,
+		},
+		{
+			MethodName: "GetStateAsOf",
+			Handler:    _StateQuery_GetStateAsOf_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "GetStateByRange",
+			Handler:       _StateQuery_GetStateByRange_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "GetQueryResult",
+			Handler:       _StateQuery_GetQueryResult_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "GetHistoryForKey",
+			Handler:       _StateQuery_GetHistoryForKey_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "GetStateUpdatesBetween",
+			Handler:       _StateQuery_GetStateUpdatesBetween_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: fileDescriptor12,
+}
+
+func init() { proto.RegisterFile("peer/statequery.proto", fileDescriptor12) }
+
+var fileDescriptor12 = []byte{
+	// 469 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x94, 0xcf, 0x6e, 0xd3, 0x40,
+	0x10, 0xc6, 0x69, 0xd3, 0x96, 0x64, 0x1a, 0xd1, 0xb0, 0x21, 0x10, 0x05, 0x10, 0xe0, 0x13, 0x08,
+	0x29, 0x46, 0x70, 0xe3, 0x80, 0xd4, 0x20, 0x48, 0x21, 0x42, 0x08, 0x23, 0x38, 0x70, 0x89, 0x36,
+	0xf6, 0xc4, 0x5e, 0xd5, 0xf6, 0xba, 0xbb, 0x93, 0x52, 0xbf, 0x10, 0xcf, 0x89, 0x76, 0xd6, 0x56,
+	0x85, 0xe0, 0x90, 0x70, 0x5a, 0xef, 0x37, 0x9e, 0xdf, 0xe7, 0xf9, 0x23, 0xc3, 0xa8, 0x42, 0x34,
+	0xa1, 0x25, 0x49, 0x78, 0xb1, 0x41, 0x53, 0x4f, 0x2b, 0xa3, 0x49, 0x8b, 0x23, 0x3e, 0xec, 0x64,
+	0x18, 0xeb, 0xa2, 0xd0, 0x65, 0xe8, 0x0f, 0x1f, 0x9c, 0x8c, 0x39, 0x27, 0xce, 0xa4, 0x2a, 0x63,
+	0x9d, 0xa0, 0xcd, 0x54, 0xe1, 0x23, 0xc1, 0x29, 0x9c, 0x7c, 0x75, 0xa8, 0x05, 0xd6, 0x91, 0xe3,
+	0x59, 0x12, 0x0f, 0xa0, 0x57, 0xca, 0x02, 0x6d, 0x25, 0x63, 0x1c, 0xef, 0x3d, 0xde, 0x7b, 0xda,
+	0x8b, 0xae, 0x05, 0x31, 0x80, 0xce, 0x39, 0xd6, 0xe3, 0x7d, 0xd6, 0xdd, 0x63, 0x90, 0xc2, 0x6d,
+	0x46, 0x44, 0xb2, 0x4c, 0x71, 0x3b, 0xc8, 0x7d, 0xe8, 0x59, 0x92, 0x86, 0x96, 0xd7, 0xa8, 0x2e,
+	0x0b, 0x0b, 0xac, 0xc5, 0x3d, 0xb8, 0x89, 0x65, 0xc2, 0xa1, 0x0e, 0x87, 0x8e, 0xb0, 0x4c, 0x16,
+	0x58, 0x07, 0x0b, 0x18, 0x79, 0x23, 0x15, 0x67, 0x5f, 0x5c, 0xe9, 0xdb, 0x99, 0xdd, 0x81, 0x43,
+	0x6e, 0x54, 0x63, 0xe4, 0x2f, 0xc1, 0x0c, 0x46, 0x0b, 0xac, 0x3f, 0xe9, 0x44, 0xad, 0x55, 0x2c,
+	0x49, 0xe9, 0x32, 0x42, 0xbb, 0xc9, 0x49, 0x0c, 0xe1, 0x90, 0xae, 0x96, 0x2a, 0x69, 0x40, 0x07,
+	0x74, 0xf5, 0x21, 0x71, 0x8c, 0x4b, 0x99, 0x6f, 0x90, 0x19, 0xfd, 0xc8, 0x5f, 0x02, 0x84, 0x01,
+	0x7f, 0xd0, 0xa9, 0xfd, 0xbc, 0xfe, 0xcf, 0xee, 0x89, 0x27, 0xd0, 0x5f, 0xe5, 0x3a, 0x3e, 0x5f,
+	0x66, 0xa8, 0xd2, 0x8c, 0xb8, 0xe4, 0x83, 0xe8, 0x98, 0xb5, 0x33, 0x96, 0x82, 0x0b, 0x18, 0xb2,
+	0xcd, 0xb7, 0x2a, 0x91, 0x84, 0x76, 0x3b, 0xa7, 0x47, 0x70, 0xbc, 0x36, 0xba, 0x68, 0xb1, 0xfb,
+	0x8c, 0x05, 0x27, 0x79, 0xaa, 0x9b, 0x01, 0xe9, 0x3f, 0x5d, 0xbb, 0xa4, 0x7d, 0xf0, 0xe5, 0xaf,
+	0x0e, 0x00, 0x7b, 0x72, 0x9f, 0xc5, 0x6b, 0xe8, 0xce, 0x91, 0x58, 0x10, 0x83, 0x69, 0xb3, 0x5a,
+	0xef, 0xca, 0x4b, 0xcc, 0x75, 0x85, 0x93, 0x89, 0xdf, 0x25, 0x3b, 0xe5, 0x97, 0xdb, 0x75, 0xfa,
+	0xce, 0x2d, 0xba, 0x21, 0xde, 0xc2, 0x49, 0x9b, 0x3b, 0xab, 0x79, 0x47, 0x76, 0x45, 0xbc, 0xd8,
+	0x13, 0x33, 0xb8, 0x35, 0x47, 0x6a, 0x86, 0xce, 0x63, 0xda, 0x9d, 0x31, 0x87, 0xc1, 0x1c, 0xe9,
+	0x4c, 0x59, 0xd2, 0xa6, 0x7e, 0xaf, 0x8d, 0xdb, 0xb5, 0xbf, 0x29, 0x0f, 0x5b, 0xca, 0x3f, 0xb7,
+	0x83, 0x41, 0x6f, 0xa0, 0xdf, 0x56, 0xe4, 0x26, 0xbf, 0x73, 0x47, 0x3e, 0xc2, 0xdd, 0x36, 0xbf,
+	0x19, 0xe9, 0x0c, 0xe9, 0x27, 0x62, 0xb9, 0x7b, 0x51, 0xb3, 0xe7, 0x3f, 0x9e, 0xa5, 0x8a, 0xb2,
+	0xcd, 0xca, 0x65, 0x86, 0x59, 0x5d, 0xa1, 0xc9, 0x31, 0x49, 0xd1, 0x84, 0x6b, 0xb9, 0x32, 0x2a,
+	0x0e, 0x7d, 0x7a, 0x58, 0x21, 0x9a, 0x95, 0xff, 0x47, 0xbc, 0xfa, 0x3d, 0x00, 0xb4, 0xf7, 0x5c,
+	0x81, 0x43, 0x04, 0x00, 0x00,
+}
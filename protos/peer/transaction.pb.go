@@ -113,6 +113,10 @@ type ProcessedTransaction struct {
 	TransactionEnvelope *common.Envelope `protobuf:"bytes,1,opt,name=transactionEnvelope" json:"transactionEnvelope,omitempty"`
 	// An indication of whether the transaction was validated or invalidated by committing peer
 	ValidationCode int32 `protobuf:"varint,2,opt,name=validationCode" json:"validationCode,omitempty"`
+	// The number of the block that contains the transaction
+	BlockNumber uint64 `protobuf:"varint,3,opt,name=blockNumber" json:"blockNumber,omitempty"`
+	// The position of the transaction in the block, starting from 0
+	TxIndex uint64 `protobuf:"varint,4,opt,name=txIndex" json:"txIndex,omitempty"`
 }
 
 func (m *ProcessedTransaction) Reset()                    { *m = ProcessedTransaction{} }
@@ -239,51 +243,52 @@ func init() {
 func init() { proto.RegisterFile("peer/transaction.proto", fileDescriptor10) }
 
 var fileDescriptor10 = []byte{
-	// 724 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x74, 0x54, 0x4d, 0x6f, 0xe3, 0x36,
-	0x14, 0xac, 0x93, 0x26, 0x69, 0x9e, 0xd3, 0x84, 0x61, 0xb2, 0x5e, 0xc7, 0x08, 0xba, 0x0b, 0x1f,
-	0x8a, 0x6d, 0x17, 0x88, 0x81, 0xec, 0xa1, 0x40, 0xd1, 0x0b, 0x2d, 0x31, 0xb1, 0x50, 0x99, 0x14,
-	0x28, 0x3a, 0x4d, 0x7a, 0x28, 0x21, 0x5b, 0x5c, 0x47, 0x80, 0x2d, 0x0a, 0x92, 0xb2, 0x68, 0xae,
-	0xfd, 0x01, 0xed, 0x7f, 0xea, 0x1f, 0x6b, 0xa1, 0x2f, 0x7f, 0x64, 0xdb, 0x8b, 0x69, 0xce, 0x8c,
-	0xde, 0xcc, 0x7b, 0x94, 0x08, 0x9d, 0x44, 0xeb, 0x74, 0x90, 0xa7, 0x41, 0x9c, 0x05, 0xb3, 0x3c,
-	0x32, 0xf1, 0x55, 0x92, 0x9a, 0xdc, 0xe0, 0xfd, 0x72, 0xc9, 0x7a, 0x6f, 0xe6, 0xc6, 0xcc, 0x17,
-	0x7a, 0x50, 0x6e, 0xa7, 0x4f, 0x1f, 0x07, 0x79, 0xb4, 0xd4, 0x59, 0x1e, 0x2c, 0x93, 0x4a, 0xd8,
-	0xbb, 0x2c, 0x0b, 0x24, 0xa9, 0x49, 0x4c, 0x16, 0x2c, 0x54, 0xaa, 0xb3, 0xc4, 0xc4, 0x99, 0xae,
-	0xd9, 0xb3, 0x99, 0x59, 0x2e, 0x4d, 0x3c, 0xa8, 0x96, 0x0a, 0xec, 0xff, 0x06, 0xa7, 0x7e, 0x34,
-	0x8f, 0x75, 0x28, 0xd7, 0xb6, 0xf8, 0x3d, 0x9c, 0x6e, 0xa4, 0x50, 0xd3, 0xe7, 0x5c, 0x67, 0xdd,
-	0xd6, 0xdb, 0xd6, 0xbb, 0x23, 0x81, 0x36, 0x88, 0x61, 0x81, 0xe3, 0x4b, 0x38, 0xcc, 0xa2, 0x79,
-	0x1c, 0xe4, 0x4f, 0xa9, 0xee, 0xee, 0x94, 0xa2, 0x35, 0xd0, 0xff, 0xa3, 0x05, 0xe7, 0x5e, 0x6a,
-	0x66, 0x3a, 0xcb, 0xb6, 0x3d, 0x86, 0x70, 0xb6, 0x51, 0x8a, 0xc6, 0x9f, 0xf4, 0xc2, 0x24, 0xba,
-	0x74, 0x69, 0x5f, 0xa3, 0xab, 0x3a, 0x64, 0x83, 0x8b, 0xff, 0x12, 0xe3, 0x6f, 0xe1, 0xf8, 0x53,
-	0xb0, 0x88, 0xc2, 0xa0, 0x40, 0x2d, 0x13, 0x56, 0xfe, 0x7b, 0xe2, 0x05, 0xda, 0x1f, 0x42, 0x7b,
-	0xd3, 0xfa, 0x03, 0x1c, 0x54, 0xff, 0x8a, 0xa6, 0x76, 0xdf, 0xb5, 0xaf, 0x2f, 0xaa, 0x61, 0x64,
-	0x57, 0x1b, 0x2a, 0x52, 0xfe, 0x8a, 0x46, 0xd9, 0xa7, 0x70, 0xfa, 0x19, 0x8b, 0x3b, 0xb0, 0xff,
-	0xa8, 0x83, 0x50, 0xa7, 0xf5, 0x74, 0xea, 0x1d, 0xee, 0xc2, 0x41, 0x12, 0x3c, 0x2f, 0x4c, 0x10,
-	0xd6, 0x13, 0x69, 0xb6, 0xfd, 0xbf, 0x5a, 0xd0, 0xb1, 0x1e, 0x83, 0x28, 0x9e, 0x99, 0x50, 0x57,
-	0x55, 0xbc, 0x8a, 0xc2, 0x3f, 0x41, 0x6f, 0xd6, 0x30, 0x6a, 0x75, 0x88, 0x4d, 0x9d, 0xca, 0xa0,
-	0xbb, 0x52, 0x78, 0xb5, 0xa0, 0x79, 0xfa, 0x07, 0xd8, 0xaf, 0xa2, 0x95, 0x8e, 0xed, 0xeb, 0x37,
-	0x4d, 0x4f, 0x2b, 0x37, 0x1a, 0x87, 0x26, 0xcd, 0x74, 0x58, 0x77, 0x56, 0xcb, 0xfb, 0x7f, 0xb6,
-	0xe0, 0xf5, 0xff, 0x68, 0xf0, 0x8f, 0x70, 0xf1, 0xd9, 0xdb, 0xf4, 0x22, 0xd1, 0xeb, 0x46, 0x20,
-	0x6a, 0x7e, 0x1d, 0xe8, 0x48, 0x57, 0xd5, 0x96, 0x3a, 0xce, 0xb3, 0xee, 0x4e, 0x39, 0xea, 0xb3,
-	0x26, 0x16, 0x5d, 0x73, 0x62, 0x4b, 0xf8, 0xfd, 0xdf, 0xbb, 0x80, 0xe4, 0xef, 0x77, 0x5b, 0x47,
-	0x88, 0x0f, 0x61, 0xef, 0x8e, 0xb8, 0x8e, 0x8d, 0xbe, 0xc0, 0x08, 0x8e, 0x98, 0xe3, 0x2a, 0xca,
-	0xee, 0xa8, 0xcb, 0x3d, 0x8a, 0x5a, 0xf8, 0x04, 0xda, 0x43, 0x62, 0x2b, 0x8f, 0x3c, 0xb8, 0x9c,
-	0xd8, 0x68, 0x07, 0xbf, 0x82, 0xd3, 0x02, 0xb0, 0xf8, 0x78, 0xcc, 0x99, 0x1a, 0x51, 0x62, 0x53,
-	0x81, 0x76, 0xf1, 0x05, 0xbc, 0x2a, 0x61, 0x41, 0x89, 0xe4, 0x42, 0xf9, 0xce, 0x2d, 0x23, 0x72,
-	0x22, 0x28, 0xfa, 0x12, 0xbf, 0x85, 0x4b, 0x87, 0x95, 0x0e, 0x8a, 0x32, 0x9b, 0x0b, 0x9f, 0x0a,
-	0x25, 0x05, 0x61, 0x3e, 0xb1, 0xa4, 0xc3, 0x19, 0xda, 0xc3, 0xdf, 0x40, 0xaf, 0x51, 0x58, 0x9c,
-	0xdd, 0x38, 0xb7, 0x5b, 0xfc, 0x3e, 0xee, 0x41, 0x67, 0xc2, 0xfc, 0x89, 0xe7, 0x71, 0x21, 0xa9,
-	0xad, 0xe4, 0xfd, 0x2a, 0xcf, 0x41, 0x93, 0xc7, 0x13, 0xdc, 0xe3, 0x3e, 0x71, 0x95, 0xbc, 0x77,
-	0x6c, 0xf4, 0x15, 0xc6, 0x70, 0x6c, 0x4f, 0x3c, 0xd7, 0xb1, 0x88, 0xa4, 0x15, 0x76, 0x58, 0xd8,
-	0xd4, 0x01, 0xc6, 0x94, 0x49, 0xe5, 0x71, 0xd7, 0xb1, 0x1e, 0xd4, 0x0d, 0x71, 0xdc, 0x22, 0x28,
-	0xe0, 0x0e, 0xe0, 0xf1, 0x9d, 0x65, 0x29, 0x41, 0x49, 0x15, 0xc4, 0x75, 0x2c, 0x89, 0xda, 0x45,
-	0x6f, 0xde, 0x88, 0x30, 0xc9, 0xc7, 0x2f, 0xa8, 0x23, 0x7c, 0x06, 0x27, 0x13, 0xf6, 0x33, 0xe3,
-	0xbf, 0xb0, 0x22, 0x95, 0x7c, 0xf0, 0x28, 0xfa, 0xba, 0x88, 0x2b, 0x89, 0xb8, 0xa5, 0x52, 0x59,
-	0x23, 0xe2, 0x30, 0xc5, 0xb8, 0x54, 0x37, 0x7c, 0xc2, 0x6c, 0x74, 0x8c, 0xcf, 0x01, 0x8d, 0x89,
-	0xf0, 0x47, 0x65, 0x52, 0x45, 0x85, 0xe0, 0x02, 0x9d, 0x34, 0x73, 0x97, 0xf7, 0x75, 0xcb, 0x08,
-	0x5f, 0xc0, 0x79, 0x33, 0x12, 0x2e, 0x47, 0x54, 0x14, 0xce, 0x3e, 0x67, 0xe8, 0x9f, 0xd6, 0xf0,
-	0xfd, 0xaf, 0xdf, 0xcd, 0xa3, 0xfc, 0xf1, 0x69, 0x5a, 0x7c, 0xc9, 0x83, 0xc7, 0xe7, 0x44, 0xa7,
-	0x0b, 0x1d, 0xce, 0x75, 0x3a, 0xf8, 0x18, 0x4c, 0xd3, 0x68, 0x56, 0x5d, 0x62, 0xd9, 0xa0, 0xb8,
-	0xb1, 0xa6, 0xd5, 0x05, 0xf7, 0xe1, 0xdf, 0x00, 0x00, 0x00, 0xff, 0xff, 0xa1, 0x6e, 0x52, 0x79,
-	0x01, 0x05, 0x00, 0x00,
+	// 752 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x74, 0x54, 0x5d, 0x4f, 0xe3, 0x46,
+	0x14, 0x6d, 0xf8, 0x2c, 0x37, 0x14, 0x86, 0x81, 0xcd, 0x86, 0x08, 0x75, 0x51, 0x1e, 0x2a, 0xda,
+	0x95, 0x88, 0xc4, 0x3e, 0x54, 0xaa, 0xfa, 0x32, 0xb1, 0x07, 0x62, 0xd5, 0x99, 0xb1, 0xc6, 0x13,
+	0x0a, 0x7d, 0xe8, 0xc8, 0x89, 0x67, 0x83, 0xd5, 0xc4, 0x63, 0xd9, 0x66, 0x05, 0x7f, 0xa2, 0xfd,
+	0x47, 0x7d, 0xe8, 0x1f, 0x6b, 0xe5, 0x2f, 0x08, 0x6c, 0xf7, 0x25, 0xce, 0x3d, 0xe7, 0xe4, 0x9e,
+	0x73, 0x6f, 0xac, 0x0b, 0x9d, 0x44, 0xeb, 0x74, 0x90, 0xa7, 0x41, 0x9c, 0x05, 0xb3, 0x3c, 0x32,
+	0xf1, 0x79, 0x92, 0x9a, 0xdc, 0xe0, 0xad, 0xf2, 0x91, 0xf5, 0xde, 0xcd, 0x8d, 0x99, 0x2f, 0xf4,
+	0xa0, 0x2c, 0xa7, 0xf7, 0x1f, 0x07, 0x79, 0xb4, 0xd4, 0x59, 0x1e, 0x2c, 0x93, 0x4a, 0xd8, 0x3b,
+	0x29, 0x1b, 0x24, 0xa9, 0x49, 0x4c, 0x16, 0x2c, 0x54, 0xaa, 0xb3, 0xc4, 0xc4, 0x99, 0xae, 0xd9,
+	0xc3, 0x99, 0x59, 0x2e, 0x4d, 0x3c, 0xa8, 0x1e, 0x15, 0xd8, 0xff, 0x1d, 0x0e, 0xfc, 0x68, 0x1e,
+	0xeb, 0x50, 0x3e, 0xdb, 0xe2, 0xf7, 0x70, 0xb0, 0x92, 0x42, 0x4d, 0x1f, 0x73, 0x9d, 0x75, 0x5b,
+	0xa7, 0xad, 0xb3, 0x5d, 0x81, 0x56, 0x88, 0x61, 0x81, 0xe3, 0x13, 0xd8, 0xc9, 0xa2, 0x79, 0x1c,
+	0xe4, 0xf7, 0xa9, 0xee, 0xae, 0x95, 0xa2, 0x67, 0xa0, 0xff, 0x77, 0x0b, 0x8e, 0xbc, 0xd4, 0xcc,
+	0x74, 0x96, 0xbd, 0xf4, 0x18, 0xc2, 0xe1, 0x4a, 0x2b, 0x1a, 0x7f, 0xd2, 0x0b, 0x93, 0xe8, 0xd2,
+	0xa5, 0x7d, 0x81, 0xce, 0xeb, 0x90, 0x0d, 0x2e, 0xfe, 0x4f, 0x8c, 0xbf, 0x83, 0xbd, 0x4f, 0xc1,
+	0x22, 0x0a, 0x83, 0x02, 0xb5, 0x4c, 0x58, 0xf9, 0x6f, 0x8a, 0x57, 0x28, 0x3e, 0x85, 0xf6, 0x74,
+	0x61, 0x66, 0x7f, 0xb0, 0xfb, 0xe5, 0x54, 0xa7, 0xdd, 0xf5, 0xd3, 0xd6, 0xd9, 0x86, 0x58, 0x85,
+	0x70, 0x17, 0xb6, 0xf3, 0x07, 0x27, 0x0e, 0xf5, 0x43, 0x77, 0xa3, 0x64, 0x9b, 0xb2, 0x3f, 0x84,
+	0xf6, 0x6a, 0xec, 0x0f, 0xb0, 0x5d, 0x7d, 0x2b, 0x16, 0xb2, 0x7e, 0xd6, 0xbe, 0x38, 0xae, 0x16,
+	0x99, 0x9d, 0xaf, 0xa8, 0x48, 0xf9, 0x29, 0x1a, 0x65, 0x9f, 0xc2, 0xc1, 0x67, 0x2c, 0xee, 0xc0,
+	0xd6, 0x9d, 0x0e, 0x42, 0x9d, 0xd6, 0x9b, 0xad, 0xab, 0x22, 0x4a, 0x12, 0x3c, 0x2e, 0x4c, 0x10,
+	0xd6, 0xdb, 0x6c, 0xca, 0xfe, 0x5f, 0x2d, 0xe8, 0x58, 0x77, 0x41, 0x14, 0xcf, 0x4c, 0xa8, 0xab,
+	0x2e, 0x5e, 0x45, 0xe1, 0x9f, 0xa1, 0x37, 0x6b, 0x18, 0xf5, 0xf4, 0x02, 0x34, 0x7d, 0x2a, 0x83,
+	0xee, 0x93, 0xc2, 0xab, 0x05, 0xcd, 0xaf, 0x7f, 0x84, 0xad, 0x2a, 0x5a, 0xe9, 0xd8, 0xbe, 0x78,
+	0xd7, 0xcc, 0xf4, 0xe4, 0x46, 0xe3, 0xd0, 0xa4, 0x99, 0x0e, 0xeb, 0xc9, 0x6a, 0x79, 0xff, 0xcf,
+	0x16, 0xbc, 0xfd, 0x82, 0x06, 0xff, 0x04, 0xc7, 0x9f, 0xbd, 0x89, 0xaf, 0x12, 0xbd, 0x6d, 0x04,
+	0xa2, 0xe6, 0x9f, 0x03, 0xed, 0xea, 0xaa, 0xdb, 0x52, 0xc7, 0x79, 0xd6, 0x5d, 0x2b, 0x57, 0x7d,
+	0xd8, 0xc4, 0xa2, 0xcf, 0x9c, 0x78, 0x21, 0xfc, 0xe1, 0x9f, 0x75, 0x40, 0xf2, 0xe1, 0xfa, 0xe5,
+	0xdf, 0xbf, 0x03, 0x9b, 0xd7, 0xc4, 0x75, 0x6c, 0xf4, 0x15, 0x46, 0xb0, 0xcb, 0x1c, 0x57, 0x51,
+	0x76, 0x4d, 0x5d, 0xee, 0x51, 0xd4, 0xc2, 0xfb, 0xd0, 0x1e, 0x12, 0x5b, 0x79, 0xe4, 0xd6, 0xe5,
+	0xc4, 0x46, 0x6b, 0xf8, 0x0d, 0x1c, 0x14, 0x80, 0xc5, 0xc7, 0x63, 0xce, 0xd4, 0x88, 0x12, 0x9b,
+	0x0a, 0xb4, 0x8e, 0x8f, 0xe1, 0x4d, 0x09, 0x0b, 0x4a, 0x24, 0x17, 0xca, 0x77, 0xae, 0x18, 0x91,
+	0x13, 0x41, 0xd1, 0x06, 0x3e, 0x85, 0x13, 0x87, 0x95, 0x0e, 0x8a, 0x32, 0x9b, 0x0b, 0x9f, 0x0a,
+	0x25, 0x05, 0x61, 0x3e, 0xb1, 0xa4, 0xc3, 0x19, 0xda, 0xc4, 0xdf, 0x42, 0xaf, 0x51, 0x58, 0x9c,
+	0x5d, 0x3a, 0x57, 0x2f, 0xf8, 0x2d, 0xdc, 0x83, 0xce, 0x84, 0xf9, 0x13, 0xcf, 0xe3, 0x42, 0x52,
+	0x5b, 0xc9, 0x9b, 0xa7, 0x3c, 0xdb, 0x4d, 0x1e, 0x4f, 0x70, 0x8f, 0xfb, 0xc4, 0x55, 0xf2, 0xc6,
+	0xb1, 0xd1, 0xd7, 0x18, 0xc3, 0x9e, 0x3d, 0xf1, 0x5c, 0xc7, 0x22, 0x92, 0x56, 0xd8, 0x4e, 0x61,
+	0x53, 0x07, 0x18, 0x53, 0x26, 0x95, 0xc7, 0x5d, 0xc7, 0xba, 0x55, 0x97, 0xc4, 0x71, 0x8b, 0xa0,
+	0x80, 0x3b, 0x80, 0xc7, 0xd7, 0x96, 0xa5, 0x04, 0x25, 0x55, 0x10, 0xd7, 0xb1, 0x24, 0x6a, 0x17,
+	0xb3, 0x79, 0x23, 0xc2, 0x24, 0x1f, 0xbf, 0xa2, 0x76, 0xf1, 0x21, 0xec, 0x4f, 0xd8, 0x2f, 0x8c,
+	0xff, 0xca, 0x8a, 0x54, 0xf2, 0xd6, 0xa3, 0xe8, 0x9b, 0x22, 0xae, 0x24, 0xe2, 0x8a, 0x4a, 0x65,
+	0x8d, 0x88, 0xc3, 0x14, 0xe3, 0x52, 0x5d, 0xf2, 0x09, 0xb3, 0xd1, 0x1e, 0x3e, 0x02, 0x34, 0x26,
+	0xc2, 0x1f, 0x95, 0x49, 0x15, 0x15, 0x82, 0x0b, 0xb4, 0xdf, 0xec, 0x5d, 0xde, 0xd4, 0x23, 0x23,
+	0x7c, 0x0c, 0x47, 0xcd, 0x4a, 0xb8, 0x1c, 0x51, 0x51, 0x38, 0xfb, 0x9c, 0xa1, 0x7f, 0x5b, 0xc3,
+	0xf7, 0xbf, 0x7d, 0x3f, 0x8f, 0xf2, 0xbb, 0xfb, 0x69, 0x71, 0x05, 0x06, 0x77, 0x8f, 0x89, 0x4e,
+	0x17, 0x3a, 0x9c, 0xeb, 0x74, 0xf0, 0x31, 0x98, 0xa6, 0xd1, 0xac, 0x3a, 0x80, 0xd9, 0xa0, 0xb8,
+	0x76, 0xd3, 0xea, 0x38, 0x7e, 0xf8, 0x6f, 0x00, 0x42, 0xb2, 0xcf, 0x61, 0x3d, 0x05, 0x00, 0x00,
 }
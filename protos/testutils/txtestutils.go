@@ -96,7 +96,7 @@ func ConstructSingedTxEnv(chainID string, ccName string, pResponse *pb.Response,
 		return nil, "", err
 	}
 
-	presp, err := putils.CreateProposalResponse(prop.Header, prop.Payload, pResponse, simulationResults, nil, nil, signer)
+	presp, err := putils.CreateProposalResponse(prop.Header, prop.Payload, pResponse, simulationResults, events, nil, signer)
 	if err != nil {
 		return nil, "", err
 	}
@@ -17,10 +17,12 @@ limitations under the License.
 package utils
 
 import (
+	"bytes"
 	"fmt"
 
 	"github.com/golang/protobuf/proto"
 	cb "github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric/protos/peer"
 )
 
 // GetChainIDFromBlock returns chain ID in the block
@@ -113,3 +115,50 @@ func InitBlockMetadata(block *cb.Block) {
 		}
 	}
 }
+
+// VerifyTransactionProof verifies the given proof of the existence of a transaction without access to the ledger.
+// It checks that the block data hashes to the data hash of the first header, that the headers are chained via
+// the previous hashes and, if anchorHash is not nil, that the last header hashes to anchorHash. The validation
+// code of the transaction recorded in the proof is returned. The orderer signatures in LastBlockSignatures are
+// not verified here as that requires the MSPs of the channel
+func VerifyTransactionProof(proof *cb.TransactionProof, anchorHash []byte) (pb.TxValidationCode, error) {
+	if len(proof.Headers) == 0 || proof.Data == nil {
+		return pb.TxValidationCode_INVALID_OTHER_REASON, fmt.Errorf("Proof does not contain the block headers and data")
+	}
+	if !bytes.Equal(proof.Data.Hash(), proof.Headers[0].DataHash) {
+		return pb.TxValidationCode_INVALID_OTHER_REASON, fmt.Errorf("Block data does not match the data hash of block [%d]", proof.Headers[0].Number)
+	}
+	for i := 1; i < len(proof.Headers); i++ {
+		prev, curr := proof.Headers[i-1], proof.Headers[i]
+		if curr.Number != prev.Number+1 || !bytes.Equal(curr.PreviousHash, prev.Hash()) {
+			return pb.TxValidationCode_INVALID_OTHER_REASON, fmt.Errorf("Block [%d] is not chained to block [%d]", curr.Number, prev.Number)
+		}
+	}
+	lastHeader := proof.Headers[len(proof.Headers)-1]
+	if anchorHash != nil && !bytes.Equal(lastHeader.Hash(), anchorHash) {
+		return pb.TxValidationCode_INVALID_OTHER_REASON, fmt.Errorf("Block [%d] does not match the anchor hash", lastHeader.Number)
+	}
+
+	if int(proof.TxIndex) >= len(proof.Data.Data) || int(proof.TxIndex) >= len(proof.ValidationFlags) {
+		return pb.TxValidationCode_INVALID_OTHER_REASON, fmt.Errorf("Transaction index [%d] is out of range", proof.TxIndex)
+	}
+	env, err := GetEnvelopeFromBlock(proof.Data.Data[proof.TxIndex])
+	if err != nil {
+		return pb.TxValidationCode_INVALID_OTHER_REASON, err
+	}
+	payload, err := GetPayload(env)
+	if err != nil {
+		return pb.TxValidationCode_INVALID_OTHER_REASON, err
+	}
+	if payload.Header == nil {
+		return pb.TxValidationCode_INVALID_OTHER_REASON, fmt.Errorf("Transaction at index [%d] does not have a header", proof.TxIndex)
+	}
+	chdr, err := UnmarshalChannelHeader(payload.Header.ChannelHeader)
+	if err != nil {
+		return pb.TxValidationCode_INVALID_OTHER_REASON, err
+	}
+	if chdr.TxId != proof.TxId {
+		return pb.TxValidationCode_INVALID_OTHER_REASON, fmt.Errorf("Transaction at index [%d] has id [%s], expected [%s]", proof.TxIndex, chdr.TxId, proof.TxId)
+	}
+	return pb.TxValidationCode(proof.ValidationFlags[proof.TxIndex]), nil
+}